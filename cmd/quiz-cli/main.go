@@ -2,14 +2,26 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"quiz-app/internal/cli"
 )
 
 func main() {
-	if err := cli.Run(context.Background(), os.Stdin, os.Stdout); err != nil {
+	submitTo := flag.String("submit-to", "", "quiz service base URL to register the finished run with (optional)")
+	username := flag.String("username", "", "username for submitted attempts (required with --submit-to)")
+	timeout := flag.Duration("timeout", 5*time.Second, "HTTP timeout when submitting results")
+	flag.Parse()
+
+	options := cli.Options{
+		SubmitTo:      *submitTo,
+		Username:      *username,
+		SubmitTimeout: *timeout,
+	}
+	if err := cli.RunWithOptions(context.Background(), os.Stdin, os.Stdout, options); err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
 	}
@@ -3,18 +3,43 @@ package main
 import (
 	"context"
 	"errors"
+	"expvar"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+	// Embedded zone data lets -streak-timezone work in minimal images without
+	// /usr/share/zoneinfo.
+	_ "time/tzdata"
 
+	"quiz-app/internal/bundles"
 	"quiz-app/internal/httpapi"
+	"quiz-app/internal/mail"
 	"quiz-app/internal/opentdb"
+	"quiz-app/internal/providers"
 	"quiz-app/internal/quiz"
+	boltstore "quiz-app/internal/quiz/bolt"
+	postgresstore "quiz-app/internal/quiz/postgres"
 	sqlitestore "quiz-app/internal/quiz/sqlite"
+	"quiz-app/internal/webhook"
 )
 
+// store is the full persistence surface the service binary needs from a backend.
+type store interface {
+	quiz.QuizRepository
+	quiz.AttemptRepository
+	Close() error
+}
+
 func main() {
 	defaultAddr := os.Getenv("ADDR")
 	if defaultAddr == "" {
@@ -26,48 +51,572 @@ func main() {
 		defaultDBPath = "quiz.db"
 	}
 
+	defaultStore := os.Getenv("QUIZ_STORE")
+	if defaultStore == "" {
+		defaultStore = "sqlite"
+	}
+
 	addr := flag.String("addr", defaultAddr, "HTTP listen address")
-	dbPath := flag.String("db", defaultDBPath, "SQLite database path")
+	storeKind := flag.String("store", defaultStore, "storage backend: sqlite, bolt (pure Go, needs no cgo), or postgres (shared by several replicas)")
+	dbPath := flag.String("db", defaultDBPath, "database file path for the selected store, or the connection string for postgres")
 	debug := flag.Bool("debug", false, "enable debug request/response and outbound call logging")
+	adminToken := flag.String("admin-token", os.Getenv("QUIZ_ADMIN_TOKEN"), "bearer token for host endpoints (empty disables them)")
+	dailyRepeatDays := flag.Int("daily-repeat-days", 7, "days a question used by a daily quiz is kept out of new daily quizzes (0 disables)")
+	submitRate := flag.Float64("submit-rate", 0, "max answers per second each user may submit to one quiz (0 disables)")
+	submitBurst := flag.Int("submit-burst", 0, "largest answer batch accepted at once under -submit-rate (0 means one second's worth, and at least a whole quiz)")
+	bankMaxQuestions := flag.Int("bank-max-questions", 10000, "questions kept in memory for quiz-less answer checks (0 means unbounded)")
+	bankTTL := flag.Duration("bank-ttl", 0, "drop in-memory questions unused for this long (0 disables)")
+	cacheMaxQuizzes := flag.Int("cache-max-quizzes", 1000, "quizzes whose metadata and questions are cached in memory (0 means unbounded)")
+	cacheMaxLeaderboards := flag.Int("cache-max-leaderboards", 1000, "leaderboards cached in memory, and category leaderboards separately (0 means unbounded)")
+	cacheMaxAttemptScores := flag.Int("cache-max-attempt-scores", 100000, "per-player quiz scores cached in memory (0 means unbounded)")
+	cacheTTL := flag.Duration("cache-ttl", 10*time.Minute, "reload cached quizzes, leaderboards, and scores from the store once they are this old, bounding how long changes made outside this process stay unseen (0 disables)")
+	streamBuffer := flag.Int("stream-buffer", 256, "recent leaderboard events kept per streamed quiz for Last-Event-ID resume")
+	leaderboardNotifyInterval := flag.Duration("leaderboard-notify-interval", 0, "send leaderboard stream updates and leaderboard webhooks at most once per quiz per interval, with the latest standings (0 sends one per submission)")
+	contentHashKey := flag.String("content-hash-key", os.Getenv("QUIZ_CONTENT_HASH_KEY"), "secret keying question content hashes (empty uses a random key per process)")
+	strictContentHash := flag.Bool("strict-content-hash", false, "re-serve answers submitted without the question's content_hash instead of scoring them")
+	serverScoring := flag.Bool("server-scoring", false, "never serve correct answers to players (include_correct is ignored) and score only answers submitted with a quiz and username")
+	serveNonces := flag.Bool("serve-nonces", false, "serve a single-use nonce with each question fetched without the answer key, and reject answers without it")
+	retryGrace := flag.Duration("retry-grace", 0, "return the original result instead of already_answered when a player resends the same answer within this long of it (0 disables)")
+	providerOrder := flag.String("providers", os.Getenv("QUIZ_PROVIDERS"), "comma-separated question providers in priority order: opentdb, triviaapi, or file (-questions-file); later ones are tried when one fails or returns too few questions (empty uses file with -questions-file, otherwise opentdb)")
+	questionsFile := flag.String("questions-file", os.Getenv("QUIZ_QUESTIONS_FILE"), "JSON or CSV file of your own questions for the file provider, which new quizzes draw from instead of OpenTriviaDB unless -providers says otherwise (loaded -bundles still take precedence)")
+	bundleNames := flag.String("bundles", os.Getenv("QUIZ_BUNDLES"), "comma-separated embedded question bundles new quizzes draw from instead of OpenTriviaDB (general, tech, movies)")
+	reveal := flag.String("reveal", string(quiz.RevealNever), "answer reveal policy for scored results: never or after_answer")
+	speedBonus := flag.Float64("speed-bonus", 0, "extra points for an instant correct answer, decaying to 0 over -speed-bonus-window (0 disables)")
+	speedBonusWindow := flag.Duration("speed-bonus-window", 20*time.Second, "time after a question is served during which correct answers earn a speed bonus")
+	speedBonusCurve := flag.String("speed-bonus-curve", string(quiz.BonusLinear), "speed bonus decay curve: linear or quadratic")
+	maxFetches := flag.Int("max-concurrent-fetches", 4, "question provider calls allowed in flight at once (0 means unlimited)")
+	fetchQueueTimeout := flag.Duration("fetch-queue-timeout", 2*time.Second, "how long quiz creation waits for a free provider slot before answering 503")
+	opentdbAttempts := flag.Int("opentdb-attempts", 3, "OpenTriviaDB tries per fetch, the first included; transport errors, 5xx, and rate limiting are retried")
+	opentdbBackoff := flag.Duration("opentdb-backoff", 50*time.Millisecond, "first wait between OpenTriviaDB retries, doubled each retry up to -opentdb-backoff-max, with jitter")
+	opentdbBackoffMax := flag.Duration("opentdb-backoff-max", 200*time.Millisecond, "longest wait between OpenTriviaDB retries, except after rate limiting, which waits at least -opentdb-rate-limit-wait")
+	opentdbRateLimitWait := flag.Duration("opentdb-rate-limit-wait", 5*time.Second, "least wait before retrying a rate-limited OpenTriviaDB fetch; no retry is made when the request's deadline is sooner")
+	opentdbBreakerFailures := flag.Int("opentdb-breaker-failures", 5, "failed OpenTriviaDB fetches in a row after which fetches fail fast for -opentdb-breaker-cooldown (0 disables)")
+	opentdbBreakerCooldown := flag.Duration("opentdb-breaker-cooldown", 30*time.Second, "how long OpenTriviaDB fetches fail fast once the breaker opens, before one fetch is tried again")
+	providerFallback := flag.String("provider-fallback", "", "comma-separated question sources tried in order after -providers when every provider fails or returns too few questions: pool (questions already stored) or bundles (every embedded bundle) (empty disables)")
+	restrictedSources := flag.String("export-restricted-sources", os.Getenv("QUIZ_EXPORT_RESTRICTED_SOURCES"), "comma-separated question sources whose license forbids copying them; quiz bundle exports withhold their questions (file also covers file:NAME)")
+	retireMinAttempts := flag.Int("retire-min-attempts", 0, "attempts a question needs before extreme results flag it for retirement review (0 disables)")
+	retireExtremeRate := flag.Float64("retire-extreme-rate", 0.02, "flag questions answered correctly at most this share of the time, or at least 1 minus it")
+	queryTimeout := flag.Duration("query-timeout", 5*time.Second, "longest a single sqlite statement may run before failing with 503 (0 disables)")
+	maintenanceWindow := flag.String("sqlite-maintenance-window", "", "daily UTC window, as HH:MM-HH:MM, in which sqlite vacuum and ANALYZE run (empty disables)")
+	maintenanceInterval := flag.Duration("sqlite-maintenance-interval", 24*time.Hour, "least time between sqlite maintenance runs inside -sqlite-maintenance-window")
+	retentionDays := flag.Int("attempt-retention-days", 0, "archive the attempts of locked quizzes once their last answer is this many days old, keeping per-user totals (0 keeps attempts forever)")
+	retentionInterval := flag.Duration("attempt-retention-interval", time.Hour, "time between attempt retention runs under -attempt-retention-days")
+	slowQuery := flag.Duration("slow-query", 500*time.Millisecond, "log sqlite statements that take at least this long, with string arguments redacted (0 disables)")
+	poolLowWater := flag.Int("pool-low-water", 0, "report the bundle pool as low in GET /admin/pool/stats once fewer questions than this are undrawn (0 disables)")
+	smtpAddr := flag.String("smtp-addr", os.Getenv("QUIZ_SMTP_ADDR"), "SMTP relay host:port for player identity emails (empty disables identity verification)")
+	smtpFrom := flag.String("smtp-from", os.Getenv("QUIZ_SMTP_FROM"), "From address for player identity emails")
+	smtpUsername := flag.String("smtp-username", os.Getenv("QUIZ_SMTP_USERNAME"), "SMTP username (empty skips authentication; the password comes from QUIZ_SMTP_PASSWORD)")
+	identityLinkBase := flag.String("identity-link-base", os.Getenv("QUIZ_IDENTITY_LINK_BASE"), "public base URL of this service for links in identity and results emails (empty sends only the code)")
+	accountTokenKey := flag.String("account-token-key", os.Getenv("QUIZ_ACCOUNT_TOKEN_KEY"), "secret signing the account tokens POST /users/login issues (empty uses a random key per process, so tokens end with it)")
+	accountTokenTTL := flag.Duration("account-token-ttl", 24*time.Hour, "how long an account token from POST /users/login lasts")
+	requireLogin := flag.Bool("require-login", false, "store answers only when sent with an account token, for every username rather than only registered ones (needs the sqlite or bolt store)")
+	resultsEmail := flag.Bool("results-email", false, "email participants with a verified identity their final score and rank when a quiz's results are published (requires -smtp-addr)")
+	routeRateLimits := flag.String("route-rate-limits", "", "per-client request limits by route group, as group=rate[:burst],... (groups: questions, quizzes, responses, leaderboard, admin, users); users defaults to 1:10")
+	compressMinBytes := flag.Int("compress-min-bytes", httpapi.DefaultCompressMinBytes, "gzip or deflate responses at least this large for clients that accept it (0 disables)")
+	streakTimezone := flag.String("streak-timezone", "UTC", "IANA time zone whose midnight separates days for participation streaks, such as America/New_York")
+	offlineSyncWindow := flag.Duration("offline-sync-window", 24*time.Hour, "how long after it was chosen a signed offline answer may still be synced")
+	resultsDir := flag.String("results-dir", "", "also write each quiz's final results to DIR/{quiz_id}/results.json when they are published (empty disables)")
+	chaosSpec := flag.String("chaos", "", "development only: inject faults into requests and question fetches, as latency=DURATION,errors=RATE,partial=RATE, e.g. latency=500ms,errors=0.1 (empty disables)")
+	drainTimeout := flag.Duration("drain-timeout", 10*time.Second, "how long shutdown waits for in-flight requests and leaderboard streams, then queued webhooks, results emails, and background jobs, to finish before the store closes")
 	flag.Parse()
 
-	store, err := sqlitestore.NewSQLiteStore(*dbPath)
+	revealPolicy, err := quiz.ParseRevealPolicy(*reveal)
+	if err != nil {
+		log.Fatalf("invalid -reveal: %v", err)
+	}
+	bonusCurve, err := quiz.ParseBonusCurve(*speedBonusCurve)
+	if err != nil {
+		log.Fatalf("invalid -speed-bonus-curve: %v", err)
+	}
+	if *speedBonus < 0 || *speedBonusWindow < 0 {
+		log.Fatalf("invalid -speed-bonus: points and window must not be negative")
+	}
+
+	if *retireMinAttempts < 0 || *retireExtremeRate < 0 || *retireExtremeRate >= 0.5 {
+		log.Fatalf("invalid -retire-min-attempts or -retire-extreme-rate: attempts must not be negative and the rate must be in [0, 0.5)")
+	}
+
+	if *opentdbAttempts < 1 || *opentdbBackoff <= 0 || *opentdbBackoffMax <= 0 || *opentdbRateLimitWait <= 0 {
+		log.Fatalf("invalid -opentdb-attempts or backoff: attempts must be at least 1 and waits must be positive")
+	}
+	if *opentdbBreakerFailures < 0 || *opentdbBreakerCooldown <= 0 {
+		log.Fatalf("invalid -opentdb-breaker-failures or -opentdb-breaker-cooldown: failures must not be negative and the cooldown must be positive")
+	}
+
+	rateLimits, err := httpapi.ParseRateLimits(*routeRateLimits)
+	if err != nil {
+		log.Fatalf("invalid -route-rate-limits: %v", err)
+	}
+	for group, limit := range httpapi.DefaultRateLimits {
+		if _, ok := rateLimits[group]; !ok {
+			rateLimits[group] = limit
+		}
+	}
+
+	chaos, err := httpapi.ParseChaos(*chaosSpec)
+	if err != nil {
+		log.Fatalf("invalid -chaos: %v", err)
+	}
+
+	streakLocation, err := time.LoadLocation(*streakTimezone)
+	if err != nil {
+		log.Fatalf("invalid -streak-timezone: %v", err)
+	}
+
+	var maintenance sqlitestore.MaintenanceSchedule
+	if *maintenanceWindow != "" {
+		if *storeKind != "sqlite" {
+			log.Fatalf("invalid -sqlite-maintenance-window: requires -store=sqlite")
+		}
+		window, err := sqlitestore.ParseMaintenanceWindow(*maintenanceWindow, time.UTC)
+		if err != nil {
+			log.Fatalf("invalid -sqlite-maintenance-window: %v", err)
+		}
+		maintenance = sqlitestore.MaintenanceSchedule{Window: window, Interval: *maintenanceInterval}
+	}
+
+	if *retentionDays < 0 || *retentionInterval <= 0 {
+		log.Fatalf("invalid -attempt-retention-days or -attempt-retention-interval: days must not be negative and the interval must be positive")
+	}
+
+	if *smtpAddr != "" && *smtpFrom == "" {
+		log.Fatalf("invalid -smtp-from: required with -smtp-addr")
+	}
+	if *resultsEmail && *smtpAddr == "" {
+		log.Fatalf("invalid -results-email: requires -smtp-addr")
+	}
+
+	if *accountTokenTTL <= 0 {
+		log.Fatalf("invalid -account-token-ttl: must be positive")
+	}
+
+	store, err := openStore(*storeKind, *dbPath, sqlitestore.StoreOptions{QueryTimeout: *queryTimeout, SlowQueryThreshold: *slowQuery})
 	if err != nil {
-		log.Fatalf("failed to initialize sqlite store: %v", err)
+		log.Fatalf("failed to initialize %s store: %v", *storeKind, err)
+	}
+	// A flag whose feature the store cannot keep would otherwise start a
+	// server that does nothing with it, or, for -require-login, one where
+	// nobody could register and so no answer would ever be stored.
+	for _, need := range []struct {
+		flag, capability string
+		enabled, ok      bool
+	}{
+		{"-require-login", "accounts", *requireLogin, implements[quiz.AccountStore](store)},
+		{"-speed-bonus", "question serve times", *speedBonus > 0, implements[quiz.QuestionServeTracker](store)},
+		{"-retire-min-attempts", "retirement reviews", *retireMinAttempts > 0, implements[quiz.QuestionRetirementStore](store)},
+		{"-attempt-retention-days", "archived attempts", *retentionDays > 0, implements[quiz.AttemptArchiver](store)},
+		{"-smtp-addr", "verified identities", *smtpAddr != "", implements[quiz.IdentityStore](store)},
+		{"-results-email", "published results", *resultsEmail, implements[quiz.ResultsStore](store)},
+	} {
+		if need.enabled && !need.ok {
+			log.Fatalf("invalid %s: the %s store does not keep %s", need.flag, *storeKind, need.capability)
+		}
 	}
-	defer store.Close()
 
-	fetcher := opentdb.FetchQuestions
+	providerConfig := providers.Config{
+		QuestionsFile: *questionsFile,
+		OpenTDB: opentdb.ClientOptions{
+			Retry:   opentdb.RetryPolicy{MaxAttempts: *opentdbAttempts, BaseDelay: *opentdbBackoff, MaxDelay: *opentdbBackoffMax, RateLimitDelay: *opentdbRateLimitWait},
+			Breaker: opentdb.BreakerPolicy{Failures: *opentdbBreakerFailures, Cooldown: *opentdbBreakerCooldown},
+		},
+	}
+	if *debug {
+		providerConfig.OpenTDB.Logf = log.Printf
+	}
+	if *providerOrder == "" {
+		*providerOrder = providers.DefaultOrder(providerConfig)
+	}
+	chain, err := providers.Build(*providerOrder, providerConfig)
+	if err != nil {
+		log.Fatalf("invalid question providers: %v", err)
+	}
+	providerNames := make([]string, len(chain))
+	for idx, provider := range chain {
+		providerNames[idx] = provider.Name
+	}
+	log.Printf("question providers in priority order: %s", strings.Join(providerNames, ", "))
+	fetcher, providerName := quiz.QuestionsFetcher(chain[0].Fetch), chain[0].Name
+	if chaos.Enabled() {
+		log.Printf("chaos mode: latency up to %s, errors=%g, partial=%g; do not run this in production", chaos.Latency, chaos.ErrorRate, chaos.PartialRate)
+		fetcher = chaosFetcher(fetcher, chaos)
+	}
 	if *debug {
-		fetcher = loggedFetcher(fetcher)
+		fetcher = loggedFetcher(fetcher, providerName)
+	}
+	pool := bundles.NewPoolWithOptions(fetcher, bundles.PoolOptions{LowWater: *poolLowWater})
+	for _, name := range strings.Split(*bundleNames, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		info, err := pool.Load(name)
+		if err != nil {
+			log.Fatalf("invalid -bundles: %v", err)
+		}
+		log.Printf("loaded question bundle %s (%d questions)", info.Name, info.QuestionCount)
 	}
+	var restricted []string
+	for _, name := range strings.Split(*restrictedSources, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			restricted = append(restricted, name)
+		}
+	}
+	fallbacks, err := fallbackProviders(*providerFallback, store)
+	if err != nil {
+		log.Fatalf("invalid -provider-fallback: %v", err)
+	}
+	// The lower-priority providers come before -provider-fallback's sources,
+	// which reuse questions rather than fetch new ones.
+	failover := make([]quiz.FallbackProvider, 0, len(chain)-1+len(fallbacks))
+	for _, provider := range chain[1:] {
+		fetch := quiz.QuestionsFetcher(provider.Fetch)
+		if *debug {
+			fetch = loggedFetcher(fetch, provider.Name)
+		}
+		failover = append(failover, quiz.FallbackProvider{Name: provider.Name, Fetch: fetch})
+	}
+	fallbacks = append(failover, fallbacks...)
 
-	service := quiz.NewService(store, store, fetcher)
+	webhooks := webhook.NewSender(nil)
+	var resultsExporter quiz.ResultsExporter
+	if *resultsDir != "" {
+		resultsExporter = newResultsExporter(*resultsDir)
+	}
+	var (
+		identityMailer quiz.IdentityMailer
+		resultsMailer  quiz.ResultsMailer
+		// background tracks work shutdown waits for before the store closes.
+		background sync.WaitGroup
+	)
+	if *smtpAddr != "" {
+		sender := mail.NewSender(*smtpAddr, *smtpFrom, *smtpUsername, os.Getenv("QUIZ_SMTP_PASSWORD"))
+		identityMailer = newIdentityMailer(sender, *identityLinkBase)
+		if *resultsEmail {
+			resultsMailer = newResultsMailer(sender, *identityLinkBase, &background)
+		}
+	}
+	service := quiz.New(quiz.Config{
+		Quizzes:  store,
+		Attempts: store,
+		Fetcher:  pool.FetchQuestions,
+		ServiceOptions: quiz.ServiceOptions{
+			RevealPolicy:              revealPolicy,
+			SpeedBonus:                quiz.SpeedBonus{MaxPoints: *speedBonus, Window: *speedBonusWindow, Curve: bonusCurve},
+			DailyRepeatDays:           *dailyRepeatDays,
+			SubmitRateLimit:           *submitRate,
+			SubmitBurst:               *submitBurst,
+			StreamBufferSize:          *streamBuffer,
+			LeaderboardNotifyInterval: *leaderboardNotifyInterval,
+			ContentHashKey:            []byte(*contentHashKey),
+			ProviderName:              func() string { return pool.Provider(providerName) },
+			RequireContentHash:        *strictContentHash,
+			ServeNonces:               *serveNonces,
+			RetryGraceWindow:          *retryGrace,
+			CompletionNotifier: func(url string, event quiz.CompletionEvent) {
+				webhooks.Send(url, event)
+			},
+
+			MaxConcurrentFetches: *maxFetches,
+			FetchQueueTimeout:    *fetchQueueTimeout,
+			FallbackProviders:    fallbacks,
+
+			Retirement: quiz.RetirementPolicy{MinAttempts: *retireMinAttempts, ExtremeRate: *retireExtremeRate},
+
+			IdentityMailer:    identityMailer,
+			AccountTokenKey:   []byte(*accountTokenKey),
+			AccountTokenTTL:   *accountTokenTTL,
+			RequireLogin:      *requireLogin,
+			StreakLocation:    streakLocation,
+			OfflineSyncWindow: *offlineSyncWindow,
+			ResultsExporter:   resultsExporter,
+			ResultsMailer:     resultsMailer,
+			RestrictedSources: restricted,
+
+			Cache: quiz.CacheOptions{
+				MaxQuizzes:       *cacheMaxQuizzes,
+				MaxLeaderboards:  *cacheMaxLeaderboards,
+				MaxAttemptScores: *cacheMaxAttemptScores,
+				TTL:              *cacheTTL,
+			},
+		},
+	})
+	expvar.Publish("quiz_cache", expvar.Func(func() any { return service.CacheStats() }))
+
+	bankOptions := quiz.BankOptions{MaxQuestions: *bankMaxQuestions, TTL: *bankTTL}
+	// Falling back to the store keeps quiz-less answer checks working after a
+	// restart or eviction, and makes copying every served question redundant.
+	lookup, readThrough := store.(quiz.QuestionLookup)
+	if readThrough {
+		bankOptions.Store = lookup
+	}
+
+	router := httpapi.NewRouterWithOptions(service, quiz.NewBankWithOptions(bankOptions), httpapi.RouterOptions{
+		Debug:              *debug,
+		AdminToken:         *adminToken,
+		SkipBankPopulation: readThrough,
+		Bundles:            pool,
+		RateLimits:         rateLimits,
+		CompressMinBytes:   *compressMinBytes,
+		Chaos:              chaos,
+		ServerScoring:      *serverScoring,
+	})
 
 	server := &http.Server{
 		Addr:              *addr,
-		Handler:           httpapi.NewRouterWithOptions(service, quiz.NewBank(), httpapi.RouterOptions{Debug: *debug}),
+		Handler:           router,
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	log.Printf("quiz-service listening on %s with db=%s debug=%t", *addr, *dbPath, *debug)
-	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("server failed: %v", err)
+	// Shutdown does not wait for leaderboard and submission streams to go
+	// idle on their own; closing them sends each viewer a closing event and
+	// ends its response.
+	server.RegisterOnShutdown(service.CloseLeaderboardStreams)
+	server.RegisterOnShutdown(service.CloseSubmissionStreams)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if sqliteStore, ok := store.(*sqlitestore.SQLiteStore); ok && *maintenanceWindow != "" {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			sqliteStore.RunMaintenance(ctx, maintenance)
+		}()
+	}
+	if *retentionDays > 0 {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			service.RunAttemptRetention(ctx, quiz.AttemptRetention{MaxAge: time.Duration(*retentionDays) * 24 * time.Hour, Interval: *retentionInterval})
+		}()
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	log.Printf("quiz-service listening on %s with store=%s db=%s debug=%t", *addr, *storeKind, redactDSN(*dbPath), *debug)
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("server failed: %v", err)
+		}
+	case <-ctx.Done():
+		stop()
+		log.Printf("shutting down; draining for up to %s", *drainTimeout)
+		drainCtx, cancel := context.WithTimeout(context.Background(), *drainTimeout)
+		defer cancel()
+		if err := server.Shutdown(drainCtx); err != nil {
+			log.Printf("drain incomplete, closing remaining connections: %v", err)
+			_ = server.Close()
+		}
+		// Requests are done; send what they left queued, and let background
+		// jobs stop, before the store closes under them.
+		service.FlushNotifications(drainCtx)
+		if err := webhooks.Wait(drainCtx); err != nil {
+			log.Printf("shutdown: abandoning webhook deliveries still in flight: %v", err)
+		}
+		if err := waitGroupContext(drainCtx, &background); err != nil {
+			log.Printf("shutdown: abandoning background work still running: %v", err)
+		}
+	}
+
+	if err := store.Close(); err != nil {
+		log.Printf("closing %s store: %v", *storeKind, err)
+	}
+	log.Printf("quiz-service stopped")
+}
+
+// waitGroupContext waits for wg until ctx is done.
+func waitGroupContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// implements reports whether store has the optional capability T.
+func implements[T any](store store) bool {
+	_, ok := store.(T)
+	return ok
+}
+
+// openStore opens the selected backend. queries applies to sqlite only; bolt
+// transactions run in memory under a file lock and cannot be interrupted, and
+// postgres takes its statement_timeout from the connection string.
+func openStore(kind, path string, queries sqlitestore.StoreOptions) (store, error) {
+	switch kind {
+	case "sqlite":
+		log.Printf("sqlite driver=%s", sqlitestore.Driver())
+		return sqlitestore.NewSQLiteStoreWithOptions(path, queries)
+	case "bolt":
+		return boltstore.NewBoltStore(path)
+	case "postgres":
+		return postgresstore.NewPostgresStore(path)
+	default:
+		return nil, fmt.Errorf("unknown store %q (want sqlite, bolt, or postgres)", kind)
+	}
+}
+
+// redactDSN hides the password in a postgres connection string, in URL or
+// key=value form, so it can be logged. File paths pass through unchanged.
+func redactDSN(dsn string) string {
+	if parsed, err := url.Parse(dsn); err == nil && parsed.Scheme != "" {
+		return parsed.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}xxxxx")
+}
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// fallbackProviders builds the -provider-fallback chain. "pool" reuses
+// questions from earlier quizzes in store; "bundles" draws from every embedded
+// bundle, whether or not -bundles loaded it.
+func fallbackProviders(names string, store quiz.QuizRepository) ([]quiz.FallbackProvider, error) {
+	var providers []quiz.FallbackProvider
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case "pool":
+			fetch := quiz.StoredQuestionsFetcher(store)
+			if fetch == nil {
+				return nil, errors.New("the store cannot sample stored questions")
+			}
+			providers = append(providers, quiz.FallbackProvider{Name: name, Fetch: fetch})
+		case "bundles":
+			embedded := bundles.NewPool(nil)
+			for _, info := range bundles.List() {
+				if _, err := embedded.Load(info.Name); err != nil {
+					return nil, err
+				}
+			}
+			providers = append(providers, quiz.FallbackProvider{Name: name, Fetch: embedded.FetchQuestions})
+		default:
+			return nil, fmt.Errorf("unknown source %q (want pool or bundles)", name)
+		}
+	}
+	return providers, nil
+}
+
+// newIdentityMailer emails verification codes. With linkBase set, the email
+// also carries a magic link to GET /users/{username}/identity/verify.
+func newIdentityMailer(sender *mail.Sender, linkBase string) quiz.IdentityMailer {
+	linkBase = strings.TrimRight(linkBase, "/")
+	return func(ctx context.Context, email string, message quiz.IdentityMessage) error {
+		var body strings.Builder
+		fmt.Fprintf(&body, "Your verification code for %s is %s.\n", message.Username, message.Code)
+		if linkBase != "" {
+			link := linkBase + "/users/" + url.PathEscape(message.Username) + "/identity/verify?token=" + url.QueryEscape(message.LinkToken)
+			fmt.Fprintf(&body, "\nOr open this link to verify:\n%s\n", link)
+		}
+		fmt.Fprintf(&body, "\nThe code expires at %s. If you did not ask for it, ignore this email.\n", message.ExpiresAt.Format(time.RFC1123))
+		return sender.Send(ctx, email, "Your quiz verification code", body.String())
+	}
+}
+
+// newResultsMailer emails a participant's final result in the background, so
+// publishing results does not wait on the relay; pending tracks each email
+// for shutdown. Failures are logged.
+func newResultsMailer(sender *mail.Sender, linkBase string, pending *sync.WaitGroup) quiz.ResultsMailer {
+	linkBase = strings.TrimRight(linkBase, "/")
+	return func(email string, event quiz.CompletionEvent) {
+		result := event.Result
+		var body strings.Builder
+		fmt.Fprintf(&body, "Results for quiz %s are in.\n\n", event.QuizID)
+		fmt.Fprintf(&body, "%s, you finished #%d of %d with %g points from %d answers.\n", event.Username, result.Rank, event.Participants, result.TotalScore, result.AnsweredCount)
+		fmt.Fprintf(&body, "\nReview every question and the final standings:\n%s%s\n", linkBase, result.ResultsPath)
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := sender.Send(ctx, email, "Your quiz results", body.String()); err != nil {
+				log.Printf("results email failed quiz_id=%s username=%s: %v", event.QuizID, event.Username, err)
+			}
+		}()
+	}
+}
+
+// newResultsExporter writes published results under dir, laid out like the
+// API paths below /quizzes/, so the directory can be served as static files.
+// Files are replaced through a rename, so a reader never sees half a
+// document. Failures are logged; the store keeps the results regardless.
+func newResultsExporter(dir string) quiz.ResultsExporter {
+	return func(results quiz.PublishedResults) {
+		if results.QuizID != filepath.Base(results.QuizID) || strings.HasPrefix(results.QuizID, ".") {
+			log.Printf("results export skipped quiz_id=%q: not usable as a directory name", results.QuizID)
+			return
+		}
+		quizDir := filepath.Join(dir, results.QuizID)
+		if err := os.MkdirAll(quizDir, 0o755); err != nil {
+			log.Printf("results export failed quiz_id=%s: %v", results.QuizID, err)
+			return
+		}
+		temp, err := os.CreateTemp(quizDir, ".results-*.json")
+		if err != nil {
+			log.Printf("results export failed quiz_id=%s: %v", results.QuizID, err)
+			return
+		}
+		_, err = temp.Write(results.Document)
+		if closeErr := temp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(temp.Name(), 0o644)
+		}
+		if err == nil {
+			err = os.Rename(temp.Name(), filepath.Join(quizDir, "results.json"))
+		}
+		if err != nil {
+			_ = os.Remove(temp.Name())
+			log.Printf("results export failed quiz_id=%s: %v", results.QuizID, err)
+		}
+	}
+}
+
+// chaosFetcher delays, fails, or truncates question fetches per chaos, as a
+// flaky provider would. Truncated fetches return half the questions.
+func chaosFetcher(fetcher quiz.QuestionsFetcher, chaos httpapi.Chaos) quiz.QuestionsFetcher {
+	return func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		if delay := chaos.Delay(); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			}
+		}
+		if chaos.Roll(chaos.ErrorRate) {
+			return nil, errors.New("chaos: injected provider failure")
+		}
+		questions, err := fetcher(ctx, amount, filter)
+		if err == nil && chaos.Roll(chaos.PartialRate) {
+			questions = questions[:len(questions)/2]
+		}
+		return questions, err
 	}
 }
 
-func loggedFetcher(fetcher quiz.QuestionsFetcher) quiz.QuestionsFetcher {
-	return func(ctx context.Context, amount int) ([]opentdb.RawQuestion, error) {
+func loggedFetcher(fetcher quiz.QuestionsFetcher, provider string) quiz.QuestionsFetcher {
+	return func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
 		start := time.Now()
-		log.Printf("outbound request provider=opentdb amount=%d", amount)
+		log.Printf("outbound request provider=%s amount=%d", provider, amount)
 
-		questions, err := fetcher(ctx, amount)
+		questions, err := fetcher(ctx, amount, filter)
 		if err != nil {
-			log.Printf("outbound error provider=opentdb amount=%d duration=%s err=%v", amount, time.Since(start).Round(time.Millisecond), err)
+			log.Printf("outbound error provider=%s amount=%d duration=%s err=%v", provider, amount, time.Since(start).Round(time.Millisecond), err)
 			return nil, err
 		}
 
-		log.Printf("outbound success provider=opentdb amount=%d received=%d duration=%s", amount, len(questions), time.Since(start).Round(time.Millisecond))
+		log.Printf("outbound success provider=%s amount=%d received=%d duration=%s", provider, amount, len(questions), time.Since(start).Round(time.Millisecond))
 		return questions, nil
 	}
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"quiz-app/internal/smoketest"
+	"quiz-app/internal/userclient"
+)
+
+func main() {
+	server := flag.String("server", "http://127.0.0.1:8080", "quiz service base URL to test")
+	timeout := flag.Duration("timeout", 10*time.Second, "HTTP timeout per request")
+	runID := flag.String("run-id", "", "suffix for the run's usernames and questions (default: derived from the current time)")
+	flag.Parse()
+	if flag.NArg() > 0 {
+		*server = flag.Arg(0)
+	}
+
+	client := userclient.NewHTTPClient(*server, &http.Client{Timeout: *timeout})
+	if err := smoketest.Run(context.Background(), client, os.Stdout, smoketest.Options{RunID: *runID}); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
@@ -2,31 +2,74 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"time"
 
 	"quiz-app/internal/userclient"
 )
 
 func main() {
-	username := flag.String("username", "", "username for quiz attempts (required)")
-	server := flag.String("server", "http://127.0.0.1:8080", "quiz service base URL")
-	timeout := flag.Duration("timeout", 5*time.Second, "HTTP timeout")
+	username := flag.String("username", "", "username for quiz attempts (required to play, unless the profile sets one)")
+	server := flag.String("server", "", "quiz service base URL (empty uses the profile's, or http://127.0.0.1:8080)")
+	timeout := flag.Duration("timeout", 0, "HTTP timeout (0 uses the profile's, or 5s)")
+	configPath := flag.String("config", userclient.DefaultProfilesPath(), "JSON file of named server profiles (a missing file defines none)")
+	profile := flag.String("profile", os.Getenv("QUIZ_PROFILE"), "server profile from --config to use; flags given explicitly override its settings (empty uses the file's default)")
+	jsonOutput := flag.Bool("json", false, "print command results as JSON for scripts (no banner or prompt)")
+	noColor := flag.Bool("no-color", false, "disable colored output")
+	submissionLog := flag.String("submission-log", userclient.DefaultSubmissionLogPath(), "file every sent answer and its server result is appended to (empty disables)")
+	offlineQueue := flag.String("offline-queue", userclient.DefaultOfflineQueuePath(), "file answers wait in while the server is unreachable, until 'sync' (empty disables)")
+	signingKey := flag.String("signing-key", userclient.DefaultSigningKeyPath(), "file holding the key queued answers are signed with, created on first use")
+	playerToken := flag.String("player-token", os.Getenv("QUIZ_PLAYER_TOKEN"), "player token from verifying --username's email (needed only for verified usernames)")
+	accountToken := flag.String("account-token", os.Getenv("QUIZ_ACCOUNT_TOKEN"), "account token from POST /users/login for --username (needed only for registered usernames, or on servers run with -require-login)")
 	flag.Parse()
 
-	if *username == "" {
-		fmt.Fprintln(os.Stderr, "error: --username is required")
-		os.Exit(1)
-	}
-
-	err := userclient.Run(context.Background(), os.Stdin, os.Stdout, userclient.Config{
+	cfg := userclient.Config{
 		Username:    *username,
 		ServerURL:   *server,
 		HTTPTimeout: *timeout,
-	})
+		JSON:        *jsonOutput,
+		NoColor:     *noColor,
+
+		SubmissionLog: *submissionLog,
+		PlayerToken:   *playerToken,
+		AccountToken:  *accountToken,
+		OfflineQueue:  *offlineQueue,
+		SigningKey:    *signingKey,
+		Profile:       *profile,
+	}
+	profiles, err := userclient.LoadProfiles(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+	cfg.Profiles = profiles
+	resolved, err := cfg.ResolveProfile()
 	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+
+	// With a command, run it once and exit: quiz-user-service leaderboard <quiz_id> --limit 5
+	if flag.NArg() > 0 {
+		err := userclient.Exec(context.Background(), os.Stdin, os.Stdout, cfg, flag.CommandLine, flag.Args())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			if errors.Is(err, userclient.ErrUsage) {
+				os.Exit(2)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
+	if resolved.Username == "" {
+		fmt.Fprintln(os.Stderr, "error: --username is required (or a profile that sets one)")
+		os.Exit(1)
+	}
+
+	if err := userclient.Run(context.Background(), os.Stdin, os.Stdout, cfg); err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
 	}
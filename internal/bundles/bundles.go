@@ -0,0 +1,209 @@
+// Package bundles ships curated question sets inside the binary so a fresh
+// install can create quizzes without reaching OpenTriviaDB.
+package bundles
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"quiz-app/internal/opentdb"
+)
+
+// Bundles are stored in the OpenTriviaDB result shape so they go through the
+// same BuildQuestions path as fetched questions.
+//
+//go:embed data/*.json
+var data embed.FS
+
+// defaultAmount matches OpenTriviaDB's default when amount is not positive.
+const defaultAmount = 10
+
+// ErrUnknownBundle reports a bundle name that is not embedded.
+var ErrUnknownBundle = errors.New("unknown question bundle")
+
+// Info describes one embedded bundle.
+type Info struct {
+	Name          string `json:"name"`
+	Title         string `json:"title"`
+	QuestionCount int    `json:"question_count"`
+}
+
+type bundleFile struct {
+	Title     string                `json:"title"`
+	Questions []opentdb.RawQuestion `json:"questions"`
+}
+
+// List returns the embedded bundles sorted by name.
+func List() []Info {
+	entries, err := data.ReadDir("data")
+	if err != nil {
+		return nil
+	}
+	infos := make([]Info, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		bundle, err := read(name)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, Info{Name: name, Title: bundle.Title, QuestionCount: len(bundle.Questions)})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Questions returns the questions of the named bundle.
+func Questions(name string) ([]opentdb.RawQuestion, error) {
+	bundle, err := read(name)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Questions, nil
+}
+
+func read(name string) (bundleFile, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || strings.ContainsAny(name, `/\.`) {
+		return bundleFile{}, fmt.Errorf("%w: %q", ErrUnknownBundle, name)
+	}
+	raw, err := data.ReadFile(path.Join("data", name+".json"))
+	if err != nil {
+		return bundleFile{}, fmt.Errorf("%w: %q", ErrUnknownBundle, name)
+	}
+	var bundle bundleFile
+	if err := json.Unmarshal(raw, &bundle); err != nil {
+		return bundleFile{}, fmt.Errorf("bundle %s: %w", name, err)
+	}
+	return bundle, nil
+}
+
+// Pool serves quiz questions from the loaded bundles. Until a bundle is loaded
+// it defers to the fallback fetcher, usually OpenTriviaDB.
+type Pool struct {
+	fallback func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error)
+	options  PoolOptions
+	now      func() time.Time
+
+	mu       sync.Mutex
+	loaded   map[string][]opentdb.RawQuestion
+	loadedAt map[string]time.Time
+	// draws counts how often each question was served, keyed by question
+	// text, since the process started.
+	draws map[string]int
+}
+
+// PoolOptions sets the thresholds reported by Stats.
+type PoolOptions struct {
+	// LowWater marks the pool low once fewer questions than this have never
+	// been drawn. 0 never marks it low.
+	LowWater int
+}
+
+// NewPool returns an empty pool. fallback may be nil, in which case fetching
+// from an empty pool fails.
+func NewPool(fallback func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error)) *Pool {
+	return NewPoolWithOptions(fallback, PoolOptions{})
+}
+
+func NewPoolWithOptions(fallback func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error), options PoolOptions) *Pool {
+	return &Pool{
+		fallback: fallback,
+		options:  options,
+		now:      time.Now,
+		loaded:   make(map[string][]opentdb.RawQuestion),
+		loadedAt: make(map[string]time.Time),
+		draws:    make(map[string]int),
+	}
+}
+
+// Load adds the named bundle to the pool. Loading a bundle twice is a no-op.
+func (p *Pool) Load(name string) (Info, error) {
+	bundle, err := read(name)
+	if err != nil {
+		return Info{}, err
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loaded[name] = bundle.Questions
+	if _, ok := p.loadedAt[name]; !ok {
+		p.loadedAt[name] = p.now().UTC()
+	}
+	return Info{Name: name, Title: bundle.Title, QuestionCount: len(bundle.Questions)}, nil
+}
+
+// Loaded reports whether the named bundle is in the pool.
+func (p *Pool) Loaded(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.loaded[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// Provider names where FetchQuestions draws from right now: "bundles:" and
+// the loaded bundle names, or fallbackName when none are loaded.
+func (p *Pool) Provider(fallbackName string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.loaded) == 0 {
+		return fallbackName
+	}
+	names := make([]string, 0, len(p.loaded))
+	for name := range p.loaded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "bundles:" + strings.Join(names, ",")
+}
+
+// FetchQuestions draws amount random questions matching filter from the
+// loaded bundles, or every match when the pool holds fewer. It has the
+// quiz.QuestionsFetcher signature.
+func (p *Pool) FetchQuestions(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+	if amount <= 0 {
+		amount = defaultAmount
+	}
+
+	p.mu.Lock()
+	var questions []opentdb.RawQuestion
+	loaded := len(p.loaded) > 0
+	for _, bundle := range p.loaded {
+		for _, question := range bundle {
+			if filter.Matches(question) {
+				questions = append(questions, question)
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	if !loaded {
+		if p.fallback == nil {
+			return nil, errors.New("no question bundles are loaded")
+		}
+		return p.fallback(ctx, amount, filter)
+	}
+
+	rand.Shuffle(len(questions), func(i, j int) {
+		questions[i], questions[j] = questions[j], questions[i]
+	})
+	if amount < len(questions) {
+		questions = questions[:amount]
+	}
+
+	p.mu.Lock()
+	for _, question := range questions {
+		p.draws[question.Question]++
+	}
+	p.mu.Unlock()
+	return questions, nil
+}
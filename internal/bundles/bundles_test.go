@@ -0,0 +1,110 @@
+package bundles
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"quiz-app/internal/opentdb"
+)
+
+func TestEmbeddedBundlesAreWellFormed(t *testing.T) {
+	infos := List()
+	if len(infos) != 3 || infos[0].Name != "general" || infos[1].Name != "movies" || infos[2].Name != "tech" {
+		t.Fatalf("List() = (%+v), want general, movies, tech", infos)
+	}
+	for _, info := range infos {
+		questions, err := Questions(info.Name)
+		if err != nil {
+			t.Fatalf("Questions(%q) failed: %v", info.Name, err)
+		}
+		if info.Title == "" || len(questions) == 0 || len(questions) != info.QuestionCount {
+			t.Fatalf("bundle %q = (%+v with %d questions), want a titled, non-empty bundle", info.Name, info, len(questions))
+		}
+		for _, question := range questions {
+			if question.Question == "" || question.CorrectAnswer == "" || len(question.IncorrectAnswers) == 0 || question.Difficulty == "" {
+				t.Fatalf("bundle %q has an incomplete question: %+v", info.Name, question)
+			}
+		}
+	}
+	if _, err := Questions("../general"); !errors.Is(err, ErrUnknownBundle) {
+		t.Fatalf("Questions(../general) error = (%v), want ErrUnknownBundle", err)
+	}
+}
+
+func TestPoolServesLoadedBundlesBeforeFallback(t *testing.T) {
+	fallbackCalls := 0
+	pool := NewPool(func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		fallbackCalls++
+		return make([]opentdb.RawQuestion, amount), nil
+	})
+
+	if got := pool.Provider("opentdb"); got != "opentdb" {
+		t.Fatalf("Provider on empty pool = %q, want the fallback name", got)
+	}
+	if _, err := pool.FetchQuestions(context.Background(), 3, opentdb.Filter{}); err != nil || fallbackCalls != 1 {
+		t.Fatalf("FetchQuestions on empty pool = (%v, %d fallback calls), want the fallback", err, fallbackCalls)
+	}
+
+	info, err := pool.Load(" Tech ")
+	if err != nil || info.Name != "tech" || !pool.Loaded("tech") {
+		t.Fatalf("Load(tech) = (%+v, %v), want tech loaded", info, err)
+	}
+	if got := pool.Provider("opentdb"); got != "bundles:tech" {
+		t.Fatalf("Provider = %q, want bundles:tech", got)
+	}
+	questions, err := pool.FetchQuestions(context.Background(), 4, opentdb.Filter{})
+	if err != nil || len(questions) != 4 || fallbackCalls != 1 {
+		t.Fatalf("FetchQuestions = (%d questions, %v, %d fallback calls), want 4 from the bundle", len(questions), err, fallbackCalls)
+	}
+	all, _ := pool.FetchQuestions(context.Background(), 1000, opentdb.Filter{})
+	if len(all) != info.QuestionCount {
+		t.Fatalf("FetchQuestions(1000) returned %d questions, want all %d", len(all), info.QuestionCount)
+	}
+}
+
+func TestPoolStatsTrackDrawsAndLowWater(t *testing.T) {
+	pool := NewPoolWithOptions(nil, PoolOptions{LowWater: 5})
+	loadedAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	pool.now = func() time.Time { return loadedAt }
+
+	if stats := pool.Stats(); !stats.Fallback || stats.Low || stats.Questions != 0 {
+		t.Fatalf("Stats on empty pool = (%+v), want fallback and not low", stats)
+	}
+
+	info, err := pool.Load("tech")
+	if err != nil {
+		t.Fatalf("Load(tech) failed: %v", err)
+	}
+	pool.now = func() time.Time { return loadedAt.Add(time.Hour) }
+	// Loading again keeps the first load time.
+	if _, err := pool.Load("tech"); err != nil {
+		t.Fatalf("second Load(tech) failed: %v", err)
+	}
+	drawn, err := pool.FetchQuestions(context.Background(), info.QuestionCount-3, opentdb.Filter{})
+	if err != nil {
+		t.Fatalf("FetchQuestions failed: %v", err)
+	}
+	if _, err := pool.FetchQuestions(context.Background(), info.QuestionCount, opentdb.Filter{}); err != nil {
+		t.Fatalf("FetchQuestions(all) failed: %v", err)
+	}
+
+	stats := pool.Stats()
+	if stats.Fallback || stats.Questions != info.QuestionCount || stats.Unused != 0 || !stats.Low || stats.LowWater != 5 {
+		t.Fatalf("Stats = (%+v), want every tech question drawn and the pool low", stats)
+	}
+	if len(stats.Bundles) != 1 || !stats.Bundles[0].LoadedAt.Equal(loadedAt) || stats.Bundles[0].AgeSeconds != 3600 {
+		t.Fatalf("Bundles = (%+v), want tech loaded an hour ago", stats.Bundles)
+	}
+	if len(stats.Usage) != 2 || stats.Usage[0] != (UsageStats{Draws: 1, Questions: 3}) || stats.Usage[1] != (UsageStats{Draws: 2, Questions: len(drawn)}) {
+		t.Fatalf("Usage = (%+v), want 3 questions drawn once and %d twice", stats.Usage, len(drawn))
+	}
+	total := 0
+	for _, group := range stats.Difficulties {
+		total += group.Questions
+	}
+	if total != info.QuestionCount || len(stats.Categories) == 0 {
+		t.Fatalf("Difficulties = (%+v), Categories = (%+v), want every question grouped", stats.Difficulties, stats.Categories)
+	}
+}
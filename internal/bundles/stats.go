@@ -0,0 +1,119 @@
+package bundles
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats summarizes what the pool can still serve.
+type Stats struct {
+	// Questions counts every loaded question; Unused counts those never
+	// drawn since the process started.
+	Questions int `json:"questions"`
+	Unused    int `json:"unused"`
+	// Fallback is true while no bundle is loaded, so new quizzes come from the
+	// fallback fetcher instead of the pool.
+	Fallback bool `json:"fallback"`
+	// LowWater is the configured threshold; Low reports that Unused fell
+	// below it.
+	LowWater int  `json:"low_water"`
+	Low      bool `json:"low"`
+
+	Bundles      []BundleStats `json:"bundles"`
+	Categories   []GroupStats  `json:"categories"`
+	Difficulties []GroupStats  `json:"difficulties"`
+	// Usage lists how many questions were drawn each number of times, fewest
+	// draws first.
+	Usage []UsageStats `json:"usage"`
+}
+
+// BundleStats describes one loaded bundle. Age is measured from when it was
+// first loaded into this process.
+type BundleStats struct {
+	Name       string    `json:"name"`
+	Questions  int       `json:"questions"`
+	Unused     int       `json:"unused"`
+	LoadedAt   time.Time `json:"loaded_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+}
+
+// GroupStats counts loaded questions sharing a category or difficulty.
+type GroupStats struct {
+	Name      string `json:"name"`
+	Questions int    `json:"questions"`
+	Unused    int    `json:"unused"`
+}
+
+// UsageStats counts questions drawn exactly Draws times.
+type UsageStats struct {
+	Draws     int `json:"draws"`
+	Questions int `json:"questions"`
+}
+
+// Stats returns the pool's current contents, grouped by bundle, category,
+// difficulty, and draw count, sorted by name.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now().UTC()
+	stats := Stats{
+		Fallback:     len(p.loaded) == 0,
+		LowWater:     p.options.LowWater,
+		Bundles:      []BundleStats{},
+		Categories:   []GroupStats{},
+		Difficulties: []GroupStats{},
+		Usage:        []UsageStats{},
+	}
+	categories := make(map[string]*GroupStats)
+	difficulties := make(map[string]*GroupStats)
+	usage := make(map[int]int)
+	count := func(groups map[string]*GroupStats, name string, unused bool) {
+		group, ok := groups[name]
+		if !ok {
+			group = &GroupStats{Name: name}
+			groups[name] = group
+		}
+		group.Questions++
+		if unused {
+			group.Unused++
+		}
+	}
+
+	for name, questions := range p.loaded {
+		loadedAt := p.loadedAt[name]
+		bundle := BundleStats{Name: name, Questions: len(questions), LoadedAt: loadedAt, AgeSeconds: int64(now.Sub(loadedAt) / time.Second)}
+		for _, question := range questions {
+			draws := p.draws[question.Question]
+			unused := draws == 0
+			if unused {
+				bundle.Unused++
+			}
+			count(categories, question.Category, unused)
+			count(difficulties, question.Difficulty, unused)
+			usage[draws]++
+		}
+		stats.Questions += bundle.Questions
+		stats.Unused += bundle.Unused
+		stats.Bundles = append(stats.Bundles, bundle)
+	}
+	stats.Low = !stats.Fallback && stats.LowWater > 0 && stats.Unused < stats.LowWater
+
+	sort.Slice(stats.Bundles, func(i, j int) bool { return stats.Bundles[i].Name < stats.Bundles[j].Name })
+	stats.Categories = sortedGroups(categories)
+	stats.Difficulties = sortedGroups(difficulties)
+	for draws, questions := range usage {
+		stats.Usage = append(stats.Usage, UsageStats{Draws: draws, Questions: questions})
+	}
+	sort.Slice(stats.Usage, func(i, j int) bool { return stats.Usage[i].Draws < stats.Usage[j].Draws })
+	return stats
+}
+
+func sortedGroups(groups map[string]*GroupStats) []GroupStats {
+	sorted := make([]GroupStats, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
@@ -3,12 +3,16 @@ package cli
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"quiz-app/internal/opentdb"
 	"quiz-app/internal/quiz"
+	"quiz-app/internal/userclient"
 )
 
 const (
@@ -16,6 +20,17 @@ const (
 	questionCount = 10
 )
 
+// Options controls optional behavior of a CLI session. The zero value runs a
+// purely local quiz.
+type Options struct {
+	// SubmitTo is a quiz-service base URL. When set, the finished run is
+	// registered there as a new quiz with Username's attempts.
+	SubmitTo string
+	Username string
+	// SubmitTimeout bounds each submission request. Zero means no timeout.
+	SubmitTimeout time.Duration
+}
+
 // Run executes a complete single-player quiz session in the terminal.
 //
 // Why this function is structured as an orchestration flow:
@@ -34,9 +49,20 @@ const (
 // 4. Score only successfully answered questions; skipped questions reveal the answer.
 // 5. Print final score against total fetched questions.
 func Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	return RunWithOptions(ctx, in, out, Options{})
+}
+
+// RunWithOptions is Run with optional result submission. Submission happens only
+// after the local session ends, so a server outage never interrupts play.
+func RunWithOptions(ctx context.Context, in io.Reader, out io.Writer, options Options) error {
+	submit := strings.TrimSpace(options.SubmitTo) != ""
+	if submit && strings.TrimSpace(options.Username) == "" {
+		return errors.New("--username is required with --submit-to")
+	}
+
 	// The CLI intentionally fetches fresh questions for each run instead of caching.
 	// This keeps the command stateless and avoids persistence concerns in this mode.
-	rawQuestions, err := opentdb.FetchQuestions(ctx, questionCount)
+	rawQuestions, err := opentdb.FetchQuestions(ctx, questionCount, opentdb.Filter{})
 	if err != nil {
 		return err
 	}
@@ -46,6 +72,7 @@ func Run(ctx context.Context, in io.Reader, out io.Writer) error {
 	questions := quiz.BuildQuestions(rawQuestions)
 	reader := bufio.NewReader(in)
 	score := 0
+	answers := make(map[int]string, len(questions))
 
 	for idx, question := range questions {
 		printQuestion(out, idx+1, question)
@@ -59,6 +86,7 @@ func Run(ctx context.Context, in io.Reader, out io.Writer) error {
 			fmt.Fprintf(out, "Skipping. Correct answer was %s\n\n", correctText)
 			continue
 		}
+		answers[idx] = question.Options[chosenIndex].Letter
 
 		if chosenIndex == question.CorrectIndex {
 			fmt.Fprintln(out, "Correct!")
@@ -71,6 +99,47 @@ func Run(ctx context.Context, in io.Reader, out io.Writer) error {
 	}
 
 	fmt.Fprintf(out, "\nFinal score: %d/%d\n", score, len(questions))
+
+	if !submit {
+		return nil
+	}
+	client := userclient.NewHTTPClient(options.SubmitTo, &http.Client{Timeout: options.SubmitTimeout})
+	return submitResults(ctx, out, client, options.Username, questions, answers)
+}
+
+// submitResults registers the played questions as a quiz on the server and
+// persists the answered ones for username. Skipped questions are left
+// unanswered, matching how they were excluded from the local score.
+func submitResults(ctx context.Context, out io.Writer, client *userclient.HTTPClient, username string, questions []quiz.Question, answers map[int]string) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	metadata, created, err := client.CreateQuizFromQuestions(ctx, questions)
+	if err != nil {
+		return fmt.Errorf("submit results: create quiz: %w", err)
+	}
+
+	responses := make([]quiz.SubmittedResponse, 0, len(answers))
+	for idx, question := range created {
+		answer, ok := answers[idx]
+		if !ok {
+			continue
+		}
+		responses = append(responses, quiz.SubmittedResponse{
+			QuestionID:  question.QuestionID,
+			Answer:      answer,
+			ContentHash: question.ContentHash,
+		})
+	}
+
+	if len(responses) > 0 {
+		if _, err := client.SubmitResponses(ctx, metadata.QuizID, username, responses); err != nil {
+			return fmt.Errorf("submit results: quiz %s: %w", metadata.QuizID, err)
+		}
+	}
+
+	fmt.Fprintf(out, "Results submitted as quiz %s for %s.\n", metadata.QuizID, username)
 	return nil
 }
 
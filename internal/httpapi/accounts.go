@@ -0,0 +1,77 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"quiz-app/internal/quiz"
+)
+
+// HandleRegister creates an account. Registering a verified username needs
+// its player token.
+func (a *API) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	var request accountRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	account, err := a.service.Register(r.Context(), request.Username, request.Password, r.Header.Get(playerTokenHeader))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, accountResponse{Username: account.Username, CreatedAt: account.CreatedAt})
+}
+
+// HandleLogin exchanges a username and password for an account token.
+func (a *API) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	var request accountRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	token, err := a.service.Login(r.Context(), request.Username, request.Password)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, loginResponse{
+		Username:  token.Username,
+		Token:     token.Token,
+		TokenType: "Bearer",
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+// playerCredentials reads the account token from the bearer token and the
+// player token from its header. The admin token is not an account token, so
+// an admin client's requests act as whichever username they name.
+func (a *API) playerCredentials(r *http.Request) quiz.PlayerCredentials {
+	credentials := quiz.PlayerCredentials{PlayerToken: r.Header.Get(playerTokenHeader)}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	if ok && (a.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.adminToken)) != 1) {
+		credentials.AccountToken = token
+	}
+	return credentials
+}
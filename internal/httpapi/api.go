@@ -1,10 +1,26 @@
 package httpapi
 
-import "quiz-app/internal/quiz"
+import (
+	"quiz-app/internal/bundles"
+	"quiz-app/internal/quiz"
+)
 
 type API struct {
 	bank    *quiz.Bank
 	service *quiz.Service
+	// populateBank copies questions served by quiz endpoints into bank.
+	populateBank bool
+
+	// adminToken guards host-only endpoints. Empty disables them.
+	adminToken string
+	// bundles receives question bundles loaded by hosts. Nil disables loading.
+	bundles *bundles.Pool
+	// serverScoring withholds correct answers from players and refuses to
+	// score answers that are not persisted; see RouterOptions.ServerScoring.
+	serverScoring bool
+	// openAPI returns the OpenAPI document. It is a field because the route
+	// registry that the document describes also holds its handler.
+	openAPI func() ([]byte, error)
 }
 
 func NewAPI(service *quiz.Service, bank *quiz.Bank) *API {
@@ -12,7 +28,9 @@ func NewAPI(service *quiz.Service, bank *quiz.Bank) *API {
 		bank = quiz.NewBank()
 	}
 	return &API{
-		bank:    bank,
-		service: service,
+		bank:         bank,
+		service:      service,
+		populateBank: true,
+		openAPI:      openAPIJSON,
 	}
 }
@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"quiz-app/internal/bundles"
+)
+
+// HandleBundles lists the question bundles embedded in the binary and whether
+// new quizzes draw from them.
+func (a *API) HandleBundles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if !a.requireAdmin(w, r) {
+		return
+	}
+	if a.bundles == nil {
+		writeJSON(w, http.StatusNotImplemented, errorResponse{Error: "question bundles are not enabled"})
+		return
+	}
+
+	response := bundlesResponse{Bundles: []bundleResponse{}}
+	for _, info := range bundles.List() {
+		response.Bundles = append(response.Bundles, bundleResponse{Info: info, Loaded: a.bundles.Loaded(info.Name)})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleLoadBundle adds an embedded bundle to the pool new quizzes draw from.
+// Once any bundle is loaded, quizzes no longer fetch from OpenTriviaDB.
+func (a *API) HandleLoadBundle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if !a.requireAdmin(w, r) {
+		return
+	}
+	if a.bundles == nil {
+		writeJSON(w, http.StatusNotImplemented, errorResponse{Error: "question bundles are not enabled"})
+		return
+	}
+
+	info, err := a.bundles.Load(r.PathValue("name"))
+	if err != nil {
+		if errors.Is(err, bundles.ErrUnknownBundle) {
+			writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, bundleResponse{Info: info, Loaded: true})
+}
+
+// HandlePoolStats reports what the bundle pool can still serve, so operators
+// can check before an offline event that new quizzes will not run short.
+func (a *API) HandlePoolStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if !a.requireAdmin(w, r) {
+		return
+	}
+	if a.bundles == nil {
+		writeJSON(w, http.StatusNotImplemented, errorResponse{Error: "question bundles are not enabled"})
+		return
+	}
+	writeJSON(w, http.StatusOK, a.bundles.Stats())
+}
@@ -0,0 +1,200 @@
+package httpapi
+
+import (
+	"fmt"
+	"math/rand"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chaos injects failures into requests so clients' retry, circuit-breaker,
+// and offline handling can be exercised locally. It is meant for
+// development only. Each request draws independently; rates are shares of
+// requests between 0 and 1.
+type Chaos struct {
+	// Latency is the most delay added before a request is handled. Each
+	// request waits a random time up to it.
+	Latency time.Duration
+	// ErrorRate is the share of requests answered with a 500, 502, or 503
+	// instead of being handled.
+	ErrorRate float64
+	// PartialRate is the share of handled requests whose response is cut off
+	// halfway through its body, as if the connection dropped. Event streams
+	// and responses the handler flushes are never cut.
+	PartialRate float64
+	// Float64 draws random numbers in [0, 1). Nil uses math/rand.
+	Float64 func() float64
+}
+
+// Enabled reports whether c injects anything.
+func (c Chaos) Enabled() bool {
+	return c.Latency > 0 || c.ErrorRate > 0 || c.PartialRate > 0
+}
+
+// ParseChaos reads "latency=DURATION,errors=RATE,partial=RATE" as taken by
+// the -chaos flag, for example "latency=300ms,errors=0.1". Every key is
+// optional.
+func ParseChaos(value string) (Chaos, error) {
+	var chaos Chaos
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, setting, ok := strings.Cut(item, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		setting = strings.TrimSpace(setting)
+		switch name {
+		case "latency":
+			latency, err := time.ParseDuration(setting)
+			if !ok || err != nil || latency < 0 {
+				return Chaos{}, fmt.Errorf("chaos %q: latency must be a non-negative duration", item)
+			}
+			chaos.Latency = latency
+		case "errors", "partial":
+			rate, err := strconv.ParseFloat(setting, 64)
+			if !ok || err != nil || rate < 0 || rate > 1 {
+				return Chaos{}, fmt.Errorf("chaos %q: rate must be between 0 and 1", item)
+			}
+			if name == "errors" {
+				chaos.ErrorRate = rate
+			} else {
+				chaos.PartialRate = rate
+			}
+		default:
+			return Chaos{}, fmt.Errorf("chaos %q: want latency=DURATION, errors=RATE, or partial=RATE", item)
+		}
+	}
+	return chaos, nil
+}
+
+// Roll reports whether an event with probability rate happens this time.
+func (c Chaos) Roll(rate float64) bool {
+	return rate > 0 && c.random() < rate
+}
+
+// Delay returns a random delay up to Latency.
+func (c Chaos) Delay() time.Duration {
+	if c.Latency <= 0 {
+		return 0
+	}
+	return time.Duration(c.random() * float64(c.Latency))
+}
+
+func (c Chaos) random() float64 {
+	if c.Float64 != nil {
+		return c.Float64()
+	}
+	return rand.Float64()
+}
+
+var chaosStatuses = []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable}
+
+// injectChaos applies chaos to every request before it reaches next. Injected
+// responses carry an X-Chaos header naming what was done, so they are easy to
+// tell from real failures.
+func injectChaos(chaos Chaos, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delay := chaos.Delay(); delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		if chaos.Roll(chaos.ErrorRate) {
+			status := chaosStatuses[int(chaos.random()*float64(len(chaosStatuses)))%len(chaosStatuses)]
+			w.Header().Set("X-Chaos", "error")
+			writeJSON(w, status, errorResponse{Error: "injected failure (chaos mode)"})
+			return
+		}
+		// A WebSocket handshake hijacks the connection, so there is no
+		// response to cut.
+		if isWebSocketUpgrade(r) || !chaos.Roll(chaos.PartialRate) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &partialWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(writer, r)
+		writer.cut()
+	})
+}
+
+// partialWriter holds a response back so it can be sent cut in half. A
+// handler that flushes or streams events gets its response passed through
+// untouched.
+type partialWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	buffered    []byte
+	passThrough bool
+}
+
+func (p *partialWriter) WriteHeader(statusCode int) {
+	if p.passThrough {
+		p.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	p.statusCode = statusCode
+}
+
+func (p *partialWriter) Write(payload []byte) (int, error) {
+	if !p.passThrough && p.isEventStream() {
+		p.Flush()
+	}
+	if p.passThrough {
+		return p.ResponseWriter.Write(payload)
+	}
+	p.buffered = append(p.buffered, payload...)
+	return len(payload), nil
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (p *partialWriter) Unwrap() http.ResponseWriter {
+	return p.ResponseWriter
+}
+
+func (p *partialWriter) Flush() {
+	if !p.passThrough {
+		p.passThrough = true
+		p.ResponseWriter.WriteHeader(p.statusCode)
+		if _, err := p.ResponseWriter.Write(p.buffered); err != nil {
+			return
+		}
+		p.buffered = nil
+	}
+	if flusher, ok := p.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (p *partialWriter) isEventStream() bool {
+	mediaType, _, _ := mime.ParseMediaType(p.Header().Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+// cut sends the full Content-Length but only the first half of the body, then
+// drops the connection. Bodies too short to halve go out whole.
+func (p *partialWriter) cut() {
+	if p.passThrough {
+		return
+	}
+	if len(p.buffered) < 2 {
+		p.Flush()
+		return
+	}
+	p.Header().Set("X-Chaos", "partial")
+	p.Header().Set("Content-Length", strconv.Itoa(len(p.buffered)))
+	p.ResponseWriter.WriteHeader(p.statusCode)
+	_, _ = p.ResponseWriter.Write(p.buffered[:len(p.buffered)/2])
+	if flusher, ok := p.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,205 @@
+package httpapi
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressMinBytes is the -compress-min-bytes default: below about a
+// kilobyte, compression saves less than its headers and CPU cost.
+const DefaultCompressMinBytes = 1024
+
+var (
+	gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	zlibWriters = sync.Pool{New: func() any { return zlib.NewWriter(io.Discard) }}
+)
+
+// compressResponses gzip- or deflate-encodes responses of at least minBytes
+// for clients that accept it. The first minBytes are held back to decide;
+// shorter responses go out as they are. Event streams are never compressed,
+// and a handler that flushes before the threshold gets an uncompressed
+// response, so streaming output is never held back. WebSocket handshakes are
+// passed through untouched.
+func compressResponses(minBytes int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead || isWebSocketUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writer := &compressWriter{ResponseWriter: w, encoding: encoding, minBytes: minBytes, statusCode: http.StatusOK}
+		// Not deferred: after a panic, recoverPanics answers on w with whatever
+		// is still unsent discarded.
+		next.ServeHTTP(writer, r)
+		writer.close()
+	})
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, by
+// quality and then preferring gzip. It returns "" when neither is accepted.
+func negotiateEncoding(header string) string {
+	quality := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		quality[name] = q
+	}
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		q, ok := quality[encoding]
+		if !ok {
+			q = quality["*"]
+		}
+		if q > bestQuality {
+			best, bestQuality = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response until it knows whether to
+// compress: once minBytes are written it compresses, and if the handler
+// finishes or flushes first it sends the buffer as is.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding   string
+	minBytes   int
+	statusCode int
+
+	buffered []byte
+	decided  bool
+	encoder  interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+func (c *compressWriter) WriteHeader(statusCode int) {
+	if c.decided {
+		return
+	}
+	c.statusCode = statusCode
+	// Bodiless responses have nothing to compress.
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		c.decide(false)
+	}
+}
+
+func (c *compressWriter) Write(payload []byte) (int, error) {
+	if !c.decided {
+		c.buffered = append(c.buffered, payload...)
+		if len(c.buffered) < c.minBytes && !c.isEventStream() {
+			return len(payload), nil
+		}
+		c.decide(c.compressible())
+		return len(payload), c.flushBuffer()
+	}
+	if c.encoder != nil {
+		return c.encoder.Write(payload)
+	}
+	return c.ResponseWriter.Write(payload)
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Flush sends what the handler wrote so far. Before the threshold that means
+// giving up on compression for this response.
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		c.decide(false)
+		if c.flushBuffer() != nil {
+			return
+		}
+	}
+	if c.encoder != nil && c.encoder.Flush() != nil {
+		return
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *compressWriter) isEventStream() bool {
+	mediaType, _, _ := mime.ParseMediaType(c.Header().Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+// compressible rules out responses another layer already encoded and event
+// streams, whose events must reach the client one by one.
+func (c *compressWriter) compressible() bool {
+	return c.Header().Get("Content-Encoding") == "" && !c.isEventStream()
+}
+
+// decide commits the response headers, compressed or not.
+func (c *compressWriter) decide(compress bool) {
+	c.decided = true
+	if compress {
+		header := c.Header()
+		header.Set("Content-Encoding", c.encoding)
+		header.Del("Content-Length")
+		if c.encoding == "gzip" {
+			encoder := gzipWriters.Get().(*gzip.Writer)
+			encoder.Reset(c.ResponseWriter)
+			c.encoder = encoder
+		} else {
+			encoder := zlibWriters.Get().(*zlib.Writer)
+			encoder.Reset(c.ResponseWriter)
+			c.encoder = encoder
+		}
+	}
+	c.ResponseWriter.WriteHeader(c.statusCode)
+}
+
+func (c *compressWriter) flushBuffer() error {
+	buffered := c.buffered
+	c.buffered = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	var err error
+	if c.encoder != nil {
+		_, err = c.encoder.Write(buffered)
+	} else {
+		_, err = c.ResponseWriter.Write(buffered)
+	}
+	return err
+}
+
+// close finishes the response: a response still under the threshold goes out
+// uncompressed, and an encoder writes its trailer and returns to its pool.
+func (c *compressWriter) close() {
+	if !c.decided {
+		c.decide(false)
+		_ = c.flushBuffer()
+		return
+	}
+	if c.encoder == nil {
+		return
+	}
+	_ = c.encoder.Close()
+	switch encoder := c.encoder.(type) {
+	case *gzip.Writer:
+		gzipWriters.Put(encoder)
+	case *zlib.Writer:
+		zlibWriters.Put(encoder)
+	}
+	c.encoder = nil
+}
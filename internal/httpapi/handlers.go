@@ -1,11 +1,16 @@
 package httpapi
 
 import (
-	"encoding/json"
 	"errors"
+	"expvar"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"quiz-app/internal/quiz"
 )
@@ -14,8 +19,23 @@ const (
 	defaultQuestionCount    = 10
 	defaultLeaderboardLimit = 10
 	defaultListLimit        = 10
-	maxQuestionCount        = 50
+	maxQuestionCount        = quiz.MaxQuizQuestions
 	maxLeaderboardLimit     = 50
+	defaultSearchLimit      = 10
+	maxSearchLimit          = 50
+	defaultAdminListLimit   = 50
+	maxAdminListLimit       = 200
+	defaultHistoryLimit     = 50
+	maxHistoryLimit         = 200
+	// maxResponsesPerRequest bounds one POST /responses batch; a quiz has at
+	// most maxQuestionCount questions, so real clients stay far below it.
+	maxResponsesPerRequest = 200
+	// maxRequestBodyBytes bounds decoded request bodies.
+	maxRequestBodyBytes = 1 << 20
+	// maxSecondsPerQuestion bounds quiz countdowns to one hour per question.
+	maxSecondsPerQuestion = 3600
+	// playerTokenHeader carries the token from a verified identity.
+	playerTokenHeader = "X-Player-Token"
 )
 
 func (a *API) HandleQuestions(w http.ResponseWriter, r *http.Request) {
@@ -32,21 +52,73 @@ func (a *API) HandleQuestions(w http.ResponseWriter, r *http.Request) {
 	username := strings.TrimSpace(r.URL.Query().Get("username"))
 	createIfMissing := parseBoolParam(r, "create_if_missing")
 	includeCorrectIndex := parseBoolParam(r, "include_correct")
+	// Server-scored players get no answer key, however they ask.
+	answerKeyWithheld := includeCorrectIndex && a.serverScoring
+	if answerKeyWithheld {
+		includeCorrectIndex = false
+	}
 	questionCount, err := parseQuestionCountParam(r, "question_count", defaultQuestionCount, maxQuestionCount)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
 		return
 	}
+	language, err := parseLanguageParam(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	from, err := parseOffsetParam(r, "from")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	if from > 0 && quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "from needs a quiz_id"})
+		return
+	}
+	requested, _ := parseIntParam(r, "question_count", defaultQuestionCount)
+
+	var options quiz.QuizOptions
+	query := r.URL.Query()
+	if query.Has("category") || query.Has("difficulty") || query.Has("type") {
+		if quizID != "" && !createIfMissing {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "category, difficulty, and type only apply to a quiz this request creates"})
+			return
+		}
+		if err := parseQuestionFilter(&options, query.Get("category"), query.Get("difficulty"), query.Get("type")); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if username != "" && (quizID == "" || createIfMissing) {
+		// A quiz this request may create follows its creator's preferences
+		// wherever the query leaves them unset.
+		preferences, err := a.service.CreatorPreferences(r.Context(), username)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		if !r.URL.Query().Has("question_count") && preferences.QuestionCount > 0 {
+			requested = preferences.QuestionCount
+			questionCount = min(requested, maxQuestionCount)
+		}
+		if options.Difficulty == "" {
+			options.Difficulty = preferences.Difficulty
+		}
+	}
 
 	var (
 		metadata  quiz.QuizMetadata
 		questions []quiz.Question
+		created   bool
+		warnings  []apiWarning
 	)
 
-	if quizID == "" {
-		metadata, err = a.service.CreateQuiz(r.Context(), questionCount)
+	switch {
+	case quizID == "":
+		metadata, err = a.service.CreateQuizWithOptions(r.Context(), questionCount, options)
 		if err != nil {
-			writeJSON(w, http.StatusBadGateway, errorResponse{Error: "failed to fetch questions"})
+			writeFetchError(w, err, "failed to fetch questions")
 			return
 		}
 		_, questions, err = a.service.GetQuizQuestions(r.Context(), metadata.QuizID, false, 0)
@@ -54,15 +126,54 @@ func (a *API) HandleQuestions(w http.ResponseWriter, r *http.Request) {
 			writeServiceError(w, err)
 			return
 		}
-	} else {
-		metadata, questions, err = a.service.GetQuizQuestions(r.Context(), quizID, createIfMissing, questionCount)
+		created = true
+	case createIfMissing:
+		metadata, questions, created, err = a.service.GetOrCreateQuizQuestionsWithOptions(r.Context(), quizID, questionCount, options)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	default:
+		metadata, questions, err = a.service.GetQuizQuestions(r.Context(), quizID, false, questionCount)
 		if err != nil {
 			writeServiceError(w, err)
 			return
 		}
 	}
+	if metadata.Adaptive {
+		writeServiceError(w, quiz.ErrAdaptiveQuiz)
+		return
+	}
+
+	response := questionsResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: len(questions),
+		State:         a.service.QuizState(metadata),
+		Locked:        metadata.Locked,
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Practice:      metadata.Practice,
+		Scoring:       a.scoringPolicy(),
+
+		SecondsPerQuestion: int(metadata.QuestionTimeLimit / time.Second),
+		Origin:             toQuizOriginResponse(metadata.Origin),
+	}
+	if created {
+		// Creation details mirror POST /quizzes so callers can tell a fresh quiz
+		// (possibly short of what they asked for) from an existing one.
+		warnings = append(questionCountWarnings(requested, questionCount, len(questions)), providerFallbackWarnings(metadata.Origin)...)
+		response.Created = true
+		response.CreatedAt = optionalTime(metadata.CreatedAt)
+		response.RequestedQuestionCount = questionCount
+		response.ActualQuestionCount = len(questions)
+	}
 
-	a.bank.AddBuiltQuestions(questions)
+	a.rememberQuestions(questions)
+	// A client that already holds the first from questions gets only the rest;
+	// question_count stays the quiz's total so it can tell what it is missing.
+	if from > 0 {
+		response.From = from
+		questions = questions[min(from, len(questions)):]
+	}
 
 	var attemptScores map[string]float64
 	if quizID != "" && username != "" {
@@ -73,10 +184,69 @@ func (a *API) HandleQuestions(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	writeJSON(w, http.StatusOK, questionsResponse{
-		QuizID:        metadata.QuizID,
-		QuestionCount: len(questions),
-		Questions:     toQuestionResponses(questions, attemptScores, includeCorrectIndex),
+	var nonces map[string]string
+	if username != "" {
+		served := make([]string, 0, len(questions))
+		for _, question := range questions {
+			if !question.Voided {
+				served = append(served, question.QuestionID)
+			}
+		}
+		nonces = a.service.RecordServed(r.Context(), metadata.QuizID, username, served, includeCorrectIndex)
+	}
+
+	response.Questions = toQuestionResponses(questions, attemptScores, includeCorrectIndex)
+	for idx := range response.Questions {
+		item := &response.Questions[idx]
+		item.ContentHash = a.service.ContentHash(questions[idx])
+		item.Nonce = nonces[item.QuestionID]
+		item.Languages = questions[idx].Languages()
+		if public, ok := questions[idx].Translated(language); ok {
+			item.Question, item.Options, item.Language = public.Question, public.Options, language
+		}
+	}
+	if answerKeyWithheld {
+		warnings = append(warnings, apiWarning{
+			Code:    warningAnswerKeyWithheld,
+			Message: "this server scores answers itself and does not serve correct answers",
+			Field:   "include_correct",
+		})
+	}
+	response.Warnings = warnings
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (a *API) HandleSearchQuestions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "q is required"})
+		return
+	}
+	limit, err := parseQuestionCountParam(r, "limit", defaultSearchLimit, maxSearchLimit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	questions, err := a.service.SearchQuestions(r.Context(), text, limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	// Search results never carry answer metadata; callers must fetch a quiz to play.
+	writeJSON(w, http.StatusOK, questionSearchResponse{
+		Query:   text,
+		Results: quiz.ToPublicQuestions(questions),
 	})
 }
 
@@ -89,8 +259,8 @@ func (a *API) HandleResponses(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	var request responsesRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
 		return
 	}
 
@@ -98,15 +268,40 @@ func (a *API) HandleResponses(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "responses is required"})
 		return
 	}
+	if len(request.Responses) > maxResponsesPerRequest {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("at most %d responses are allowed per request", maxResponsesPerRequest)})
+		return
+	}
 
 	quizID := strings.TrimSpace(request.QuizID)
 	username := strings.TrimSpace(request.Username)
+	if quizID != "" && a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
 	var (
 		results  []quiz.ResponseResult
 		err      error
-		warnings []string
+		warnings []apiWarning
 	)
 
+	if credentials := a.playerCredentials(r); quizID != "" && (username != "" || credentials.AccountToken != "") {
+		// With an account token the answers are the token's username's,
+		// whatever the body says. Registered and verified usernames only
+		// accept answers with their tokens; others accept anyone, as before.
+		username, err = a.service.AuthenticateSubmitter(r.Context(), username, credentials)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if a.serverScoring && (quizID == "" || username == "") {
+		// Scoring answers without storing them would let a player probe for
+		// the correct ones before submitting.
+		writeServerScoringRequired(w)
+		return
+	}
+
 	if quizID != "" && username != "" {
 		results, err = a.service.SubmitResponses(r.Context(), quizID, username, request.Responses)
 		if err != nil {
@@ -121,12 +316,24 @@ func (a *API) HandleResponses(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else {
-		results = a.bank.EvaluateResponses(request.Responses)
+		results, err = a.bank.EvaluateResponsesContext(r.Context(), request.Responses)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
 	}
 
 	if quizID == "" || username == "" {
 		// Explicitly signal that answers were processed but not persisted for leaderboard usage.
-		warnings = append(warnings, "responses are not linked to leaderboard unless both quiz_id and username are provided")
+		field := "quiz_id"
+		if quizID != "" {
+			field = "username"
+		}
+		warnings = append(warnings, apiWarning{
+			Code:    warningNotPersisted,
+			Message: "responses are not linked to leaderboard unless both quiz_id and username are provided",
+			Field:   field,
+		})
 	}
 
 	writeJSON(w, http.StatusOK, responsesResponse{
@@ -148,77 +355,276 @@ func (a *API) HandleCreateQuiz(w http.ResponseWriter, r *http.Request) {
 	request := createQuizRequest{}
 	if r.ContentLength > 0 {
 		defer r.Body.Close()
-		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
-			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+		if err := decodeJSONBody(w, r, &request); err != nil && !errors.Is(err, io.EOF) {
+			writeBodyError(w, err)
+			return
+		}
+	}
+
+	if request.SecondsPerQuestion < 0 || request.SecondsPerQuestion > maxSecondsPerQuestion {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("seconds_per_question must be between 0 and %d", maxSecondsPerQuestion)})
+		return
+	}
+	options := quiz.QuizOptions{QuestionTimeLimit: time.Duration(request.SecondsPerQuestion) * time.Second, Draft: request.Draft}
+	if request.ClosesAt != nil {
+		options.ClosesAt = *request.ClosesAt
+	}
+
+	if len(request.Questions) > 0 && request.DifficultyMix != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "difficulty_mix is not allowed with questions"})
+		return
+	}
+	if request.Category != 0 || strings.TrimSpace(request.Difficulty) != "" || strings.TrimSpace(request.Type) != "" {
+		if len(request.Questions) > 0 || request.Adaptive {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "category, difficulty, and type are not allowed with questions or adaptive"})
+			return
+		}
+		if request.DifficultyMix != nil && strings.TrimSpace(request.Difficulty) != "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "difficulty is not allowed with difficulty_mix"})
 			return
 		}
+		category := ""
+		if request.Category != 0 {
+			category = strconv.Itoa(request.Category)
+		}
+		if err := parseQuestionFilter(&options, category, request.Difficulty, request.Type); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+	if len(request.Questions) > 0 {
+		a.createQuizFromQuestions(w, r, request, options)
+		return
+	}
+	if strings.TrimSpace(request.Author) != "" || request.Practice {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "author and practice are only allowed with questions"})
+		return
+	}
+	if request.DifficultyMix != nil {
+		a.createMixedQuiz(w, r, request, options)
+		return
 	}
 
+	if strings.TrimSpace(request.Username) != "" {
+		preferences, err := a.service.CreatorPreferences(r.Context(), request.Username)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		if request.QuestionCount <= 0 {
+			request.QuestionCount = preferences.QuestionCount
+		}
+		if !request.Adaptive && options.Difficulty == "" {
+			// Adaptive quizzes draw a pool across every difficulty.
+			options.Difficulty = preferences.Difficulty
+		}
+	}
 	questionCount := normalizeQuestionCount(request.QuestionCount, defaultQuestionCount, maxQuestionCount)
 
-	metadata, err := a.service.CreateQuiz(r.Context(), questionCount)
+	var (
+		metadata quiz.QuizMetadata
+		err      error
+	)
+	if request.Adaptive && request.SecondsPerQuestion > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "seconds_per_question is not supported for adaptive quizzes"})
+		return
+	}
+	if request.Adaptive && (request.Draft || request.ClosesAt != nil) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "draft and closes_at are not supported when creating adaptive quizzes; set them with PUT /quizzes/{quiz_id}/state"})
+		return
+	}
+	if request.Adaptive {
+		metadata, err = a.service.CreateAdaptiveQuiz(r.Context(), questionCount)
+		if errors.Is(err, quiz.ErrUnsupported) {
+			writeServiceError(w, err)
+			return
+		}
+	} else {
+		metadata, err = a.service.CreateQuizWithOptions(r.Context(), questionCount, options)
+	}
 	if err != nil {
-		writeJSON(w, http.StatusBadGateway, errorResponse{Error: "failed to create quiz"})
+		writeFetchError(w, err, "failed to create quiz")
 		return
 	}
 
 	_, questions, err := a.service.GetQuizQuestions(r.Context(), metadata.QuizID, false, 0)
 	if err == nil {
-		a.bank.AddBuiltQuestions(questions)
+		a.rememberQuestions(questions)
 	}
 
 	writeJSON(w, http.StatusCreated, createQuizResponse{
 		QuizID:        metadata.QuizID,
 		QuestionCount: metadata.QuestionCount,
 		CreatedAt:     metadata.CreatedAt,
+		State:         a.service.QuizState(metadata),
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Origin:        toQuizOriginResponse(metadata.Origin),
+		Adaptive:      metadata.Adaptive,
+		Warnings:      append(questionCountWarnings(request.QuestionCount, questionCount, metadata.QuestionCount), providerFallbackWarnings(metadata.Origin)...),
+
+		SecondsPerQuestion: int(metadata.QuestionTimeLimit / time.Second),
 	})
 }
 
-func (a *API) HandleLeaderboard(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeMethodNotAllowed(w, http.MethodGet)
+// createMixedQuiz handles POST /quizzes bodies with a difficulty_mix. Levels
+// the provider could not fill are reported as warnings, not errors.
+func (a *API) createMixedQuiz(w http.ResponseWriter, r *http.Request, request createQuizRequest, options quiz.QuizOptions) {
+	if request.Adaptive {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "difficulty_mix is not supported for adaptive quizzes"})
 		return
 	}
-	if a.service == nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+	mix, err := quiz.ParseDifficultyMix(request.DifficultyMix)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if total := mix.Total(); total > maxQuestionCount {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("difficulty_mix asks for %d questions; the maximum is %d", total, maxQuestionCount)})
+		return
+	} else if request.QuestionCount > 0 && request.QuestionCount != total {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("question_count %d does not match the difficulty_mix total %d", request.QuestionCount, total)})
 		return
 	}
 
-	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
-	if quizID == "" {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+	metadata, buckets, err := a.service.CreateMixedQuiz(r.Context(), mix, options)
+	if err != nil {
+		writeFetchError(w, err, "failed to create quiz")
 		return
 	}
+	if _, questions, err := a.service.GetQuizQuestions(r.Context(), metadata.QuizID, false, 0); err == nil {
+		a.rememberQuestions(questions)
+	}
+
+	response := createQuizResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: metadata.QuestionCount,
+		CreatedAt:     metadata.CreatedAt,
+		State:         a.service.QuizState(metadata),
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Origin:        toQuizOriginResponse(metadata.Origin),
+		Warnings:      append(difficultyShortfallWarnings(buckets), providerFallbackWarnings(metadata.Origin)...),
+
+		SecondsPerQuestion: int(metadata.QuestionTimeLimit / time.Second),
+	}
+	for _, bucket := range buckets {
+		response.DifficultyMix = append(response.DifficultyMix, difficultyBucketResponse{
+			Difficulty: bucket.Difficulty,
+			Requested:  bucket.Requested,
+			Delivered:  bucket.Delivered,
+		})
+	}
+	writeJSON(w, http.StatusCreated, response)
+}
 
-	limit, err := parseLeaderboardLimit(r, defaultLeaderboardLimit, maxLeaderboardLimit)
+// createQuizFromQuestions handles POST /quizzes bodies that carry their own
+// questions, so clients that already played a quiz offline can register it.
+// When author is set, the questions are credited to them.
+func (a *API) createQuizFromQuestions(w http.ResponseWriter, r *http.Request, request createQuizRequest, options quiz.QuizOptions) {
+	if request.Adaptive {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "adaptive quizzes draw from a fetched pool; questions are not allowed"})
+		return
+	}
+	questions, err := buildCustomQuestions(request.Questions)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
 		return
 	}
 
-	entries, err := a.service.GetLeaderboard(r.Context(), quizID, limit)
+	metadata, err := a.service.CreateCustomQuiz(r.Context(), questions, quiz.CustomQuizOptions{
+		Author:      strings.TrimSpace(request.Author),
+		Practice:    request.Practice,
+		QuizOptions: options,
+	})
 	if err != nil {
 		writeServiceError(w, err)
 		return
 	}
+	a.rememberQuestions(questions)
 
-	items := make([]leaderboardEntryResponse, 0, len(entries))
-	for _, entry := range entries {
-		items = append(items, leaderboardEntryResponse{
-			Username:         entry.Username,
-			TotalScore:       entry.TotalScore,
-			AnsweredCount:    entry.AnsweredCount,
-			LastSubmissionAt: entry.LastSubmissionAt,
-		})
+	questionIDs := make([]string, 0, len(questions))
+	contentHashes := make([]string, 0, len(questions))
+	for _, question := range questions {
+		questionIDs = append(questionIDs, question.QuestionID)
+		contentHashes = append(contentHashes, a.service.ContentHash(question))
 	}
+	writeJSON(w, http.StatusCreated, createQuizResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: metadata.QuestionCount,
+		CreatedAt:     metadata.CreatedAt,
+		State:         a.service.QuizState(metadata),
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Origin:        toQuizOriginResponse(metadata.Origin),
+		Practice:      metadata.Practice,
+		QuestionIDs:   questionIDs,
+		ContentHashes: contentHashes,
 
-	writeJSON(w, http.StatusOK, leaderboardResponse{
-		QuizID:      quizID,
-		Leaderboard: items,
+		SecondsPerQuestion: int(metadata.QuestionTimeLimit / time.Second),
 	})
 }
 
-func (a *API) HandleActiveQuizzes(w http.ResponseWriter, r *http.Request) {
+// buildCustomQuestions validates caller-supplied questions, naming the
+// offending index in errors.
+func buildCustomQuestions(items []createQuizQuestion) ([]quiz.Question, error) {
+	if len(items) > maxQuestionCount {
+		return nil, fmt.Errorf("at most %d questions are allowed", maxQuestionCount)
+	}
+
+	questions := make([]quiz.Question, 0, len(items))
+	for idx, item := range items {
+		question, err := newCustomQuestion(item)
+		if err == nil {
+			question, err = question.WithFeedback(item.Feedback)
+		}
+		if err == nil && strings.TrimSpace(item.Difficulty) != "" {
+			question.Difficulty, err = quiz.ParseDifficulty(item.Difficulty)
+		}
+		if err == nil {
+			question, err = question.WithTranslations(item.Translations)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("questions[%d]: %v", idx, err)
+		}
+		questions = append(questions, question)
+	}
+	return questions, nil
+}
+
+// newCustomQuestion builds one caller-supplied question of its type.
+func newCustomQuestion(item createQuizQuestion) (quiz.Question, error) {
+	kind, err := quiz.ParseQuestionKind(item.Type)
+	if err != nil {
+		return quiz.Question{}, err
+	}
+	if kind != quiz.TypeMultiSelect && len(item.CorrectIndexes) > 0 {
+		return quiz.Question{}, fmt.Errorf("%w: correct_indexes is only for multi_select questions", quiz.ErrInvalidQuestion)
+	}
+	switch kind {
+	case quiz.TypeMultiSelect:
+		return quiz.NewMultiSelectQuestion(item.Question, item.Options, item.CorrectIndexes)
+	case quiz.TypeTrueFalse:
+		options := item.Options
+		if len(options) == 0 {
+			options = []string{"True", "False"}
+		}
+		// Either order is accepted, as OpenTriviaDB questions are shuffled.
+		if len(options) != 2 || !slices.ContainsFunc(options, isWord("True")) || !slices.ContainsFunc(options, isWord("False")) {
+			return quiz.Question{}, fmt.Errorf("%w: true_false options are True and False", quiz.ErrInvalidQuestion)
+		}
+		question, err := quiz.NewQuestion(item.Question, options, item.CorrectIndex)
+		question.Type = quiz.TypeTrueFalse
+		return question, err
+	default:
+		return quiz.NewQuestion(item.Question, item.Options, item.CorrectIndex)
+	}
+}
+
+func isWord(word string) func(string) bool {
+	return func(text string) bool { return strings.EqualFold(strings.TrimSpace(text), word) }
+}
+
+// HandleNextQuestion serves a player the next question of an adaptive quiz,
+// picked by difficulty from how they answered so far.
+func (a *API) HandleNextQuestion(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeMethodNotAllowed(w, http.MethodGet)
 		return
@@ -228,28 +634,1069 @@ func (a *API) HandleActiveQuizzes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	limit, err := parseIntParam(r, "limit", defaultListLimit)
+	language, err := parseLanguageParam(r)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
 		return
 	}
+	step, err := a.service.NextAdaptiveQuestion(r.Context(), r.PathValue("quiz_id"), r.URL.Query().Get("username"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
 
-	active, err := a.service.ListActiveQuizzes(r.Context(), limit)
+	response := nextQuestionResponse{
+		QuizID:           step.Quiz.QuizID,
+		Done:             step.Done,
+		TargetDifficulty: step.Target,
+		AnsweredCount:    step.AnsweredCount,
+		CorrectCount:     step.CorrectCount,
+	}
+	if step.AnsweredCount > 0 {
+		response.Accuracy = float64(step.CorrectCount) / float64(step.AnsweredCount)
+	}
+	if !step.Done {
+		a.rememberQuestions([]quiz.Question{step.Question})
+		response.Question = &adaptiveQuestionResponse{
+			QuestionID:  step.Question.QuestionID,
+			Question:    step.Question.Question,
+			Options:     step.Question.Options,
+			Difficulty:  step.Question.Difficulty,
+			ContentHash: a.service.ContentHash(step.Question),
+			Nonce:       step.Nonce,
+			Languages:   step.Question.Languages(),
+		}
+		if public, ok := step.Question.Translated(language); ok {
+			response.Question.Question, response.Question.Options, response.Question.Language = public.Question, public.Options, language
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleDailyQuiz returns today's daily quiz (UTC), creating it on first use.
+func (a *API) HandleDailyQuiz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	metadata, err := a.service.GetDailyQuiz(r.Context())
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to list active quizzes"})
+		writeFetchError(w, err, "failed to load daily quiz")
 		return
 	}
 
-	response := activeQuizzesResponse{
-		Quizzes: make([]activeQuizResponse, 0, len(active)),
+	writeJSON(w, http.StatusOK, createQuizResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: metadata.QuestionCount,
+		CreatedAt:     metadata.CreatedAt,
+		State:         a.service.QuizState(metadata),
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Origin:        toQuizOriginResponse(metadata.Origin),
+	})
+}
+
+func (a *API) HandleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
 	}
-	for _, item := range active {
-		response.Quizzes = append(response.Quizzes, activeQuizResponse{
-			QuizID:        item.QuizID,
-			QuestionCount: item.QuestionCount,
-			CreatedAt:     item.CreatedAt,
-		})
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
 	}
 
-	writeJSON(w, http.StatusOK, response)
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	settings, err := a.service.GetLeaderboardSettings(r.Context(), quizID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	limit, err := parseLeaderboardLimit(r, effectiveLeaderboardLimit(settings), maxLeaderboardLimit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	// category ranks players on that category's questions alone.
+	category := strings.TrimSpace(r.URL.Query().Get("category"))
+	var board quiz.PublicLeaderboard
+	if category != "" {
+		board, err = a.service.GetCategoryLeaderboard(r.Context(), quizID, category, limit)
+	} else {
+		board, err = a.service.GetPublicLeaderboard(r.Context(), quizID, limit)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	items := make([]leaderboardEntryResponse, 0, len(board.Entries))
+	for _, entry := range board.Entries {
+		items = append(items, leaderboardEntryResponse{
+			Username:         entry.Username,
+			TotalScore:       entry.TotalScore,
+			AnsweredCount:    entry.AnsweredCount,
+			LastSubmissionAt: entry.LastSubmissionAt,
+			Anonymous:        entry.Anonymous,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, leaderboardResponse{
+		QuizID:      quizID,
+		Category:    category,
+		Leaderboard: items,
+		FrozenAt:    optionalTime(board.FrozenAt),
+		RevealAt:    optionalTime(board.RevealAt),
+	})
+}
+
+// HandleLeaderboardSettings shows a quiz's leaderboard settings to anyone and
+// lets the host replace them: the default number of entries, and a freeze that
+// hides changes for the last freeze_seconds before locks_at (or the quiz's
+// closes_at).
+func (a *API) HandleLeaderboardSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPut)
+		return
+	}
+	if r.Method == http.MethodPut {
+		if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+			return
+		}
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	var (
+		settings quiz.LeaderboardSettings
+		err      error
+	)
+	if r.Method == http.MethodPut {
+		var request leaderboardSettingsRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if request.DefaultLimit < 0 || request.DefaultLimit > maxLeaderboardLimit {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("default_limit must be between 0 and %d", maxLeaderboardLimit)})
+			return
+		}
+		if request.FreezeSeconds < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "freeze_seconds must not be negative"})
+			return
+		}
+		settings = quiz.LeaderboardSettings{
+			DefaultLimit: request.DefaultLimit,
+			FreezeWindow: time.Duration(request.FreezeSeconds) * time.Second,
+			Tiebreak:     parseTiebreak(request.Tiebreak),
+		}
+		if request.LocksAt != nil {
+			settings.LocksAt = *request.LocksAt
+		}
+		settings, err = a.service.SetLeaderboardSettings(r.Context(), quizID, settings)
+	} else {
+		settings, err = a.service.GetLeaderboardSettings(r.Context(), quizID)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, leaderboardSettingsResponse{
+		QuizID:        quizID,
+		DefaultLimit:  effectiveLeaderboardLimit(settings),
+		FreezeSeconds: int(settings.FreezeWindow / time.Second),
+		LocksAt:       optionalTime(settings.LocksAt),
+		Tiebreak:      string(effectiveTiebreak(settings)),
+		UpdatedAt:     optionalTime(settings.UpdatedAt),
+	})
+}
+
+// parseTiebreak reads a tiebreak from a request; the service rejects names
+// it does not know.
+func parseTiebreak(raw string) quiz.Tiebreak {
+	return quiz.Tiebreak(strings.ToLower(strings.TrimSpace(raw)))
+}
+
+// effectiveTiebreak names the tiebreak in force, spelling out the default.
+func effectiveTiebreak(settings quiz.LeaderboardSettings) quiz.Tiebreak {
+	if settings.Tiebreak == "" {
+		return quiz.TiebreakLastSubmission
+	}
+	return settings.Tiebreak
+}
+
+// effectiveLeaderboardLimit is the host's default leaderboard size, or the
+// server's when the host set none.
+func effectiveLeaderboardLimit(settings quiz.LeaderboardSettings) int {
+	if settings.DefaultLimit > 0 {
+		return min(settings.DefaultLimit, maxLeaderboardLimit)
+	}
+	return defaultLeaderboardLimit
+}
+
+// HandleVoidQuestion lets the host withdraw a question mid-event. Existing
+// attempts on it stop counting and later submissions get voided_question.
+func (a *API) HandleVoidQuestion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	questionID := strings.TrimSpace(r.PathValue("question_id"))
+	if quizID == "" || questionID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id and question_id are required"})
+		return
+	}
+
+	if err := a.service.VoidQuestion(r.Context(), quizID, questionID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, voidQuestionResponse{
+		QuizID:     quizID,
+		QuestionID: questionID,
+		Voided:     true,
+	})
+}
+
+// HandleAnswerKey gives the host every question of a quiz with its answer,
+// difficulty, and feedback, in serving order, to prepare or moderate an event.
+func (a *API) HandleAnswerKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	metadata, questions, err := a.service.GetQuizQuestions(r.Context(), quizID, false, 0)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := answerKeyResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: len(questions),
+		Practice:      metadata.Practice,
+		Adaptive:      metadata.Adaptive,
+		Questions:     make([]answerKeyQuestion, 0, len(questions)),
+	}
+	for idx, question := range questions {
+		item := answerKeyQuestion{
+			Position:       idx + 1,
+			QuestionID:     question.QuestionID,
+			Question:       question.Question,
+			Options:        question.Options,
+			Type:           question.Type,
+			CorrectIndex:   question.CorrectIndex,
+			CorrectIndexes: question.CorrectIndexes,
+			Difficulty:     question.Difficulty,
+			Feedback:       question.Feedback,
+			Voided:         question.Voided,
+		}
+		texts := make([]string, 0, 1)
+		for _, correct := range question.CorrectOptions() {
+			if correct >= 0 && correct < len(question.Options) {
+				texts = append(texts, question.Options[correct].Text)
+			}
+		}
+		if len(texts) > 0 {
+			item.CorrectLetter = question.CorrectLetters()
+			item.CorrectText = strings.Join(texts, "; ")
+		}
+		response.Questions = append(response.Questions, item)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleServeLog shows the host who fetched a quiz's questions and when,
+// including who received the answer key, next to how much each user answered.
+func (a *API) HandleServeLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	serves, err := a.service.ServeLog(r.Context(), quizID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := serveLogResponse{QuizID: quizID, Serves: make([]serveLogResponseEntry, 0, len(serves))}
+	for _, serve := range serves {
+		response.Serves = append(response.Serves, serveLogResponseEntry{
+			Username:          serve.Username,
+			FirstServedAt:     optionalTime(serve.FirstServedAt),
+			LastServedAt:      optionalTime(serve.LastServedAt),
+			FetchCount:        serve.FetchCount,
+			AnswerKeyServedAt: optionalTime(serve.AnswerKeyServedAt),
+			NoncesIssued:      serve.NoncesIssued,
+			AnsweredCount:     serve.AnsweredCount,
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleCompletionWebhook registers a webhook that fires when enough of the
+// listed participants finish the quiz, when a specific user finishes, or with
+// each participant's final result once the quiz locks.
+func (a *API) HandleCompletionWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	var request completionWebhookRequest
+	defer r.Body.Close()
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if parsed, err := url.Parse(strings.TrimSpace(request.URL)); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "url must be an absolute http(s) URL"})
+		return
+	}
+
+	watch, err := a.service.WatchCompletion(r.Context(), quizID, quiz.CompletionWatch{
+		URL:                request.URL,
+		Participants:       request.Participants,
+		ThresholdPercent:   request.ThresholdPercent,
+		Username:           request.Username,
+		LeaderboardChanges: request.LeaderboardChanges,
+		FinalResults:       request.FinalResults,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, completionWebhookResponse{
+		QuizID:             quizID,
+		URL:                watch.URL,
+		Participants:       watch.Participants,
+		ThresholdPercent:   watch.ThresholdPercent,
+		Username:           watch.Username,
+		LeaderboardChanges: watch.LeaderboardChanges,
+		FinalResults:       watch.FinalResults,
+	})
+}
+
+func (a *API) HandleActiveQuizzes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	limit, err := parseIntParam(r, "limit", defaultListLimit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	active, err := a.service.ListActiveQuizzes(r.Context(), limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to list active quizzes"})
+		return
+	}
+
+	response := activeQuizzesResponse{
+		Quizzes: make([]activeQuizResponse, 0, len(active)),
+	}
+	scoring := a.scoringPolicy()
+	for _, item := range active {
+		response.Quizzes = append(response.Quizzes, activeQuizResponse{
+			QuizID:        item.QuizID,
+			QuestionCount: item.QuestionCount,
+			CreatedAt:     item.CreatedAt,
+			State:         a.service.QuizState(item),
+			Locked:        item.Locked,
+			ClosesAt:      optionalTime(item.ClosesAt),
+			Scoring:       scoring,
+			Origin:        toQuizOriginResponse(item.Origin),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleDebugVars serves the process's expvars, such as memory stats and
+// recovered panics, to hosts.
+func (a *API) HandleDebugVars(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if !a.requireAdmin(w, r) {
+		return
+	}
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+// HandleStatsOverview serves service-wide activity for a public status page.
+// The figures come from in-memory counters, so polling it never hits the store.
+func (a *API) HandleStatsOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	overview := a.service.Overview()
+	response := statsOverviewResponse{
+		GeneratedAt:          overview.GeneratedAt,
+		QuizzesToday:         overview.QuizzesToday,
+		SubmissionsPerMinute: overview.SubmissionsPerMinute,
+		ActiveUsersLastHour:  overview.ActiveUsersLastHour,
+		TopCategories:        make([]categoryCountResponse, 0, len(overview.TopCategories)),
+	}
+	for _, item := range overview.TopCategories {
+		response.TopCategories = append(response.TopCategories, categoryCountResponse{
+			Category:      item.Category,
+			QuestionCount: item.QuestionCount,
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleAdminQuizzes lists every quiz with usage statistics for capacity
+// management. Sorting and paging happen in the service so every store agrees.
+func (a *API) HandleAdminQuizzes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if !a.requireAdmin(w, r) {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	limit, err := parseQuestionCountParam(r, "limit", defaultAdminListLimit, maxAdminListLimit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	offset, err := parseOffsetParam(r, "offset")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	sortKey := strings.TrimSpace(r.URL.Query().Get("sort"))
+	if sortKey == "" {
+		sortKey = string(quiz.SortByCreatedAt)
+	}
+	order := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("order")))
+	if order == "" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "order must be asc or desc"})
+		return
+	}
+
+	stats, total, err := a.service.ListQuizStats(r.Context(), quiz.QuizStatsQuery{
+		Sort:       quiz.QuizStatsSort(sortKey),
+		Descending: order == "desc",
+		Offset:     offset,
+		Limit:      limit,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := adminQuizzesResponse{
+		Quizzes: make([]adminQuizResponse, 0, len(stats)),
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		Sort:    sortKey,
+		Order:   order,
+	}
+	for _, item := range stats {
+		response.Quizzes = append(response.Quizzes, adminQuizResponse{
+			QuizID:                 item.QuizID,
+			QuestionCount:          item.QuestionCount,
+			RequestedQuestionCount: item.RequestedQuestionCount,
+			CreatedAt:              item.CreatedAt,
+			State:                  a.service.QuizState(item.QuizMetadata),
+			Locked:                 item.Locked,
+			ClosesAt:               optionalTime(item.ClosesAt),
+			AttemptCount:           item.AttemptCount,
+			ParticipantCount:       item.ParticipantCount,
+			StorageBytes:           item.StorageBytes,
+			LastActivityAt:         item.LastActivityAt,
+			Origin:                 toQuizOriginResponse(item.Origin),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleBookmarks lists (GET) or adds to (POST) a user's question bookmarks.
+// Adding needs the same tokens as submitting answers for the username.
+func (a *API) HandleBookmarks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	username := strings.TrimSpace(r.PathValue("username"))
+	if r.Method == http.MethodPost {
+		defer r.Body.Close()
+
+		var request bookmarkRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if strings.TrimSpace(request.QuestionID) == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "question_id is required"})
+			return
+		}
+		authenticated, err := a.service.AuthenticateSubmitter(r.Context(), username, a.playerCredentials(r))
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		username = authenticated
+		if err := a.service.AddBookmark(r.Context(), username, request.QuestionID); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+
+	bookmarks, err := a.service.ListBookmarks(r.Context(), username)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := bookmarksResponse{
+		Username:  username,
+		Bookmarks: make([]bookmarkResponse, 0, len(bookmarks)),
+	}
+	for _, bookmark := range bookmarks {
+		response.Bookmarks = append(response.Bookmarks, bookmarkResponse{
+			QuestionID: bookmark.Question.QuestionID,
+			Question:   bookmark.Question.Question,
+			Options:    bookmark.Question.Options,
+			CreatedAt:  bookmark.CreatedAt,
+		})
+	}
+
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, response)
+}
+
+// HandleProfile reads or updates a user's settings. PUT replaces them, and
+// needs the same tokens as submitting answers for the username.
+func (a *API) HandleProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPut)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	username := strings.TrimSpace(r.PathValue("username"))
+	var (
+		profile quiz.UserProfile
+		err     error
+	)
+	if r.Method == http.MethodPut {
+		defer r.Body.Close()
+
+		var request profileRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if request.Anonymous == nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "anonymous is required"})
+			return
+		}
+		if username, err = a.service.AuthenticateSubmitter(r.Context(), username, a.playerCredentials(r)); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		profile, err = a.service.SetAnonymous(r.Context(), username, *request.Anonymous)
+	} else {
+		profile, err = a.service.GetProfile(r.Context(), username)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profileResponse{
+		Username:  profile.Username,
+		Anonymous: profile.Anonymous,
+		UpdatedAt: optionalTime(profile.UpdatedAt),
+	})
+}
+
+// HandlePreferences reads or replaces the defaults applied to quizzes a user
+// creates without an explicit question count or difficulty. PUT needs the same
+// tokens as submitting answers for the username.
+func (a *API) HandlePreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPut)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	username := strings.TrimSpace(r.PathValue("username"))
+	var (
+		preferences quiz.UserPreferences
+		err         error
+	)
+	if r.Method == http.MethodPut {
+		defer r.Body.Close()
+
+		var request preferencesRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if request.QuestionCount > maxQuestionCount {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("question_count must be at most %d", maxQuestionCount)})
+			return
+		}
+		if username, err = a.service.AuthenticateSubmitter(r.Context(), username, a.playerCredentials(r)); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		preferences, err = a.service.SetPreferences(r.Context(), username, quiz.UserPreferences{
+			QuestionCount: request.QuestionCount,
+			Difficulty:    quiz.Difficulty(request.Difficulty),
+		})
+	} else {
+		preferences, err = a.service.GetPreferences(r.Context(), username)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, preferencesResponse{
+		Username:      preferences.Username,
+		QuestionCount: preferences.QuestionCount,
+		Difficulty:    string(preferences.Difficulty),
+		UpdatedAt:     optionalTime(preferences.UpdatedAt),
+	})
+}
+
+// HandleUserStats reports a user's daily participation streak and answer
+// totals across quizzes.
+func (a *API) HandleUserStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	username := strings.TrimSpace(r.PathValue("username"))
+	streak, err := a.service.GetStreak(r.Context(), username)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	response := userStatsResponse{
+		Username:      streak.Username,
+		CurrentStreak: streak.Current,
+		LongestStreak: streak.Longest,
+		LastActiveDay: streak.LastDay,
+		Timezone:      a.service.StreakLocation().String(),
+	}
+
+	// Summaries include quizzes whose attempts were archived.
+	summaries, err := a.service.AttemptSummaries(r.Context(), username)
+	switch {
+	case errors.Is(err, quiz.ErrUnsupported):
+	case err != nil:
+		writeServiceError(w, err)
+		return
+	default:
+		totals := &userTotalsResponse{QuizzesPlayed: len(summaries)}
+		for _, summary := range summaries {
+			totals.AnsweredCount += summary.AnsweredCount
+			totals.CorrectCount += summary.CorrectCount
+			totals.TotalScore += summary.TotalScore
+			totals.ArchivedCount += summary.ArchivedCount
+		}
+		response.Totals = totals
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleUserAttempts lists every quiz a user answered in, most recently
+// played first, with their score and answer times in each.
+func (a *API) HandleUserAttempts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+	limit, err := parseQuestionCountParam(r, "limit", defaultHistoryLimit, maxHistoryLimit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	history, err := a.service.UserAttempts(r.Context(), r.PathValue("username"), limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := userAttemptsResponse{
+		Username: history.Username,
+		Attempts: make([]userAttemptResponse, 0, len(history.Attempts)),
+	}
+	for _, attempt := range history.Attempts {
+		response.Attempts = append(response.Attempts, userAttemptResponse{
+			QuizID:          attempt.QuizID,
+			QuestionCount:   attempt.QuestionCount,
+			Practice:        attempt.Practice,
+			TotalScore:      attempt.TotalScore,
+			AnsweredCount:   attempt.AnsweredCount,
+			CorrectCount:    attempt.CorrectCount,
+			ArchivedCount:   attempt.ArchivedCount,
+			FirstAnsweredAt: attempt.FirstSubmittedAt,
+			LastAnsweredAt:  attempt.LastSubmittedAt,
+			QuizCreatedAt:   optionalTime(attempt.QuizCreatedAt),
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleDeleteBookmark removes a bookmark. Like adding one, it needs the same
+// tokens as submitting answers for the username.
+func (a *API) HandleDeleteBookmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeMethodNotAllowed(w, http.MethodDelete)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	username, err := a.service.AuthenticateSubmitter(r.Context(), r.PathValue("username"), a.playerCredentials(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if err := a.service.RemoveBookmark(r.Context(), username, r.PathValue("question_id")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlePracticeQuiz creates a personal quiz from a user's bookmarks, for a
+// caller holding the username's tokens.
+func (a *API) HandlePracticeQuiz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	request := practiceQuizRequest{}
+	if r.ContentLength > 0 {
+		defer r.Body.Close()
+		if err := decodeJSONBody(w, r, &request); err != nil && !errors.Is(err, io.EOF) {
+			writeBodyError(w, err)
+			return
+		}
+	}
+	// Zero means every bookmark, still bounded like any other quiz.
+	questionCount := normalizeQuestionCount(request.QuestionCount, maxQuestionCount, maxQuestionCount)
+	username, err := a.service.AuthenticateSubmitter(r.Context(), r.PathValue("username"), a.playerCredentials(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	metadata, questions, err := a.service.CreatePracticeQuiz(r.Context(), username, questionCount)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	questionIDs := make([]string, 0, len(questions))
+	contentHashes := make([]string, 0, len(questions))
+	for _, question := range questions {
+		questionIDs = append(questionIDs, question.QuestionID)
+		contentHashes = append(contentHashes, a.service.ContentHash(question))
+	}
+	writeJSON(w, http.StatusCreated, createQuizResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: metadata.QuestionCount,
+		CreatedAt:     metadata.CreatedAt,
+		State:         a.service.QuizState(metadata),
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Origin:        toQuizOriginResponse(metadata.Origin),
+		Practice:      metadata.Practice,
+		QuestionIDs:   questionIDs,
+		ContentHashes: contentHashes,
+	})
+}
+
+// HandleAuthorPerformance reports how an author's questions did across quizzes.
+func (a *API) HandleAuthorPerformance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	author := strings.TrimSpace(r.PathValue("author"))
+	performance, err := a.service.QuestionPerformanceByAuthor(r.Context(), author)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := authorPerformanceResponse{
+		Author:    author,
+		Questions: make([]questionPerformanceResponse, 0, len(performance)),
+	}
+	for _, item := range performance {
+		response.Questions = append(response.Questions, questionPerformanceResponse{
+			QuestionID:      item.Question.QuestionID,
+			Question:        item.Question.Question,
+			QuizCount:       item.QuizCount,
+			AttemptCount:    item.AttemptCount,
+			CorrectCount:    item.CorrectCount,
+			CorrectnessRate: item.CorrectnessRate(),
+			ReportCount:     item.ReportCount,
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleReportQuestion lets a player flag a broken or unclear question.
+func (a *API) HandleReportQuestion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+	defer r.Body.Close()
+
+	var request questionReportRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	questionID := r.PathValue("question_id")
+	if err := a.service.ReportQuestion(r.Context(), questionID, request.Username, request.Reason); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, questionReportResponse{QuestionID: questionID, Status: "reported"})
+}
+
+// rememberQuestions copies served questions into the bank for quiz-less
+// evaluation, unless the router was configured to leave the bank alone.
+func (a *API) rememberQuestions(questions []quiz.Question) {
+	if a.populateBank {
+		a.bank.AddBuiltQuestions(questions)
+	}
+}
+
+// HandleBankQuestions adds caller-supplied questions to the ad-hoc evaluation
+// bank without creating a quiz. Questions already banked or stored keep their
+// answer key.
+func (a *API) HandleBankQuestions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	defer r.Body.Close()
+
+	var request bankQuestionsRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if len(request.Questions) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "questions is required"})
+		return
+	}
+	questions, err := buildCustomQuestions(request.Questions)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	if err := a.bank.AddCustomQuestions(r.Context(), questions); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	questionIDs := make([]string, 0, len(questions))
+	for _, question := range questions {
+		questionIDs = append(questionIDs, question.QuestionID)
+	}
+
+	writeJSON(w, http.StatusCreated, bankQuestionsResponse{QuestionIDs: questionIDs})
+}
+
+// HandleBankEvaluate checks answers against the bank. Nothing is persisted.
+func (a *API) HandleBankEvaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	defer r.Body.Close()
+
+	var request bankEvaluateRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if request.Responses == nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "responses is required"})
+		return
+	}
+
+	if a.serverScoring {
+		writeServerScoringRequired(w)
+		return
+	}
+
+	results, err := a.bank.EvaluateResponsesContext(r.Context(), request.Responses)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, responsesResponse{Results: results})
+}
+
+func (a *API) HandleBankStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	stats := a.bank.Stats()
+	writeJSON(w, http.StatusOK, bankStatsResponse{
+		Size:         stats.Size,
+		MaxQuestions: stats.MaxQuestions,
+		TTLSeconds:   int64(stats.TTL.Seconds()),
+		Hits:         stats.Hits,
+		Misses:       stats.Misses,
+		StoreHits:    stats.StoreHits,
+		StoreErrors:  stats.StoreErrors,
+		Evictions:    stats.Evictions,
+		Expirations:  stats.Expirations,
+	})
 }
@@ -1,13 +1,26 @@
 package httpapi
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"quiz-app/internal/bundles"
+	"quiz-app/internal/opentdb"
 	"quiz-app/internal/quiz"
 )
 
@@ -222,4 +235,2747 @@ func TestHandleResponsesWithoutQuizOrUsernameAddsWarning(t *testing.T) {
 	if len(payload.Warnings) != 1 {
 		t.Fatalf("expected warning for non-leaderboard submission, got %+v", payload.Warnings)
 	}
+	if payload.Warnings[0].Code != warningNotPersisted || payload.Warnings[0].Field != "quiz_id" {
+		t.Fatalf("warning = %+v, want not_persisted on quiz_id", payload.Warnings[0])
+	}
+}
+
+func TestHandleSearchQuestionsRequiresQuery(t *testing.T) {
+	api := NewAPI(quiz.NewService(nil, nil, nil), nil)
+	req := httptest.NewRequest(http.MethodGet, "/questions/search?q=%20", nil)
+	rec := httptest.NewRecorder()
+
+	api.HandleSearchQuestions(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(rec.Body.String(), "q is required") {
+		t.Fatalf("unexpected response body: %s", rec.Body.String())
+	}
+}
+
+func TestHandleSearchQuestionsUnsupportedStore(t *testing.T) {
+	api := NewAPI(quiz.NewService(nil, nil, nil), nil)
+	req := httptest.NewRequest(http.MethodGet, "/questions/search?q=paris", nil)
+	rec := httptest.NewRecorder()
+
+	api.HandleSearchQuestions(rec, req)
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotImplemented)
+	}
+}
+
+func TestHandleVoidQuestionRequiresAdminToken(t *testing.T) {
+	api := NewAPI(quiz.NewService(nil, nil, nil), nil)
+	req := httptest.NewRequest(http.MethodPost, "/quizzes/quiz-1/questions/q1/void", nil)
+	rec := httptest.NewRecorder()
+
+	api.HandleVoidQuestion(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status without configured token = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	api.adminToken = "secret"
+	req = httptest.NewRequest(http.MethodPost, "/quizzes/quiz-1/questions/q1/void", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+
+	api.HandleVoidQuestion(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status with wrong token = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestToQuestionResponsesMarksVoidedQuestions(t *testing.T) {
+	questions := []quiz.Question{
+		{PublicQuestion: quiz.PublicQuestion{QuestionID: "q1"}, Voided: true},
+	}
+
+	got := toQuestionResponses(questions, map[string]float64{"q1": 1}, false)
+	if !got[0].Voided || got[0].AttemptStatus != "voided" {
+		t.Fatalf("voided response = %+v, want voided status", got[0])
+	}
+}
+
+func TestHandleCreateQuizRejectsInvalidCustomQuestion(t *testing.T) {
+	api := NewAPI(quiz.NewService(nil, nil, nil), nil)
+	body := bytes.NewBufferString(`{"questions":[{"question":"2 + 2?","options":["4"],"correct_index":0}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/quizzes", body)
+	rec := httptest.NewRecorder()
+
+	api.HandleCreateQuiz(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if !strings.Contains(rec.Body.String(), "questions[0]") {
+		t.Fatalf("unexpected response body: %s", rec.Body.String())
+	}
+}
+
+func TestNewCustomQuestionTypes(t *testing.T) {
+	multi, err := newCustomQuestion(createQuizQuestion{Question: "Primes?", Options: []string{"2", "4", "5"}, Type: "multi_select", CorrectIndexes: []int{2, 0}})
+	if err != nil || multi.Type != quiz.TypeMultiSelect || multi.CorrectLetters() != "A,C" {
+		t.Fatalf("multi_select = %+v, %v, want answers A,C", multi, err)
+	}
+	trueFalse, err := newCustomQuestion(createQuizQuestion{Question: "Is 7 even?", Type: "true_false", CorrectIndex: 1})
+	if err != nil || trueFalse.Type != quiz.TypeTrueFalse || len(trueFalse.Options) != 2 || trueFalse.Options[1].Text != "False" || trueFalse.CorrectIndex != 1 {
+		t.Fatalf("true_false = %+v, %v, want True/False answered False", trueFalse, err)
+	}
+
+	for _, item := range []createQuizQuestion{
+		{Question: "Q?", Options: []string{"a", "b"}, CorrectIndexes: []int{0}},
+		{Question: "Q?", Options: []string{"Yes", "No"}, Type: "true_false"},
+		{Question: "Q?", Options: []string{"a", "b"}, Type: "essay"},
+	} {
+		if _, err := newCustomQuestion(item); err == nil {
+			t.Fatalf("newCustomQuestion(%+v) succeeded, want an error", item)
+		}
+	}
+}
+
+func TestQuestionCountWarnings(t *testing.T) {
+	if got := questionCountWarnings(10, 10, 10); len(got) != 0 {
+		t.Fatalf("exact count warnings = %+v, want none", got)
+	}
+
+	got := questionCountWarnings(80, 50, 42)
+	if len(got) != 2 || got[0].Code != warningQuestionCountCapped || got[1].Code != warningProviderShortfall {
+		t.Fatalf("capped+shortfall warnings = %+v", got)
+	}
+	if got[1].Field != "question_count" {
+		t.Fatalf("shortfall field = %q, want question_count", got[1].Field)
+	}
+}
+
+func TestToScoringPolicyResponseAndOptionalTime(t *testing.T) {
+	api := NewAPI(quiz.NewServiceWithOptions(nil, nil, nil, quiz.ServiceOptions{RevealPolicy: quiz.RevealAfterAnswer}), nil)
+	scoring := toScoringPolicyResponse(api.service.ScoringPolicy())
+
+	if scoring.CorrectPoints != 1 || scoring.IncorrectPoints != 0 || scoring.AttemptsPerQuestion != 1 || scoring.RevealPolicy != "after_answer" {
+		t.Fatalf("scoring = (%+v), want 1/0 points, one attempt, after_answer", scoring)
+	}
+	if optionalTime(time.Time{}) != nil {
+		t.Fatalf("optionalTime(zero) = non-nil, want nil")
+	}
+}
+
+func TestWriteServiceErrorRateLimitedSetsRetryAfter(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	writeServiceError(rec, &quiz.RateLimitError{RetryAfter: 1500 * time.Millisecond})
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = (%q), want \"2\"", got)
+	}
+}
+
+func TestWriteServiceErrorBatchTooLargeIsNotRetryable(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	writeServiceError(rec, fmt.Errorf("%w: 60 answers", quiz.ErrBatchTooLarge))
+
+	if rec.Code != http.StatusRequestEntityTooLarge || rec.Header().Get("Retry-After") != "" {
+		t.Fatalf("status = %d with Retry-After %q, want 413 without one", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestHandleAdminQuizzesValidatesRequest(t *testing.T) {
+	api := NewAPI(quiz.NewService(nil, nil, nil), nil)
+	api.adminToken = "secret"
+
+	tests := []struct {
+		name   string
+		target string
+		token  string
+		want   int
+	}{
+		{name: "missing token", target: "/admin/quizzes", want: http.StatusUnauthorized},
+		{name: "bad order", target: "/admin/quizzes?order=sideways", token: "secret", want: http.StatusBadRequest},
+		{name: "negative offset", target: "/admin/quizzes?offset=-1", token: "secret", want: http.StatusBadRequest},
+		{name: "store without stats", target: "/admin/quizzes", token: "secret", want: http.StatusNotImplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+
+			api.HandleAdminQuizzes(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterRoutesBookmarkPracticeBeforeQuestionID(t *testing.T) {
+	router := NewRouter(quiz.NewService(nil, nil, nil), nil)
+	req := httptest.NewRequest(http.MethodGet, "/users/alice/bookmarks/practice", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Fatalf("status = %d allow = %q, want 405 allowing POST", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestHandleCreateQuizRejectsAuthorWithoutQuestions(t *testing.T) {
+	api := NewAPI(quiz.NewService(nil, nil, nil), nil)
+	body := strings.NewReader(`{"question_count":5,"author":"carol"}`)
+	req := httptest.NewRequest(http.MethodPost, "/quizzes", body)
+	rec := httptest.NewRecorder()
+
+	api.HandleCreateQuiz(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestBankEndpointsAddAndEvaluateQuestions(t *testing.T) {
+	router := NewRouterWithOptions(quiz.NewService(nil, nil, nil), nil, RouterOptions{SkipBankPopulation: true})
+
+	add := httptest.NewRequest(http.MethodPost, "/bank/questions", strings.NewReader(
+		`{"questions":[{"question":"Capital of France?","options":["Lyon","Paris"],"correct_index":1}]}`,
+	))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, add)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("add status = %d, want 201 (body %s)", rec.Code, rec.Body.String())
+	}
+	var added bankQuestionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil || len(added.QuestionIDs) != 1 {
+		t.Fatalf("add response = (%s, %v), want one question id", rec.Body.String(), err)
+	}
+
+	body, _ := json.Marshal(bankEvaluateRequest{Responses: []quiz.SubmittedResponse{{QuestionID: added.QuestionIDs[0], Answer: "B"}}})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bank/evaluate", bytes.NewReader(body)))
+	var evaluated responsesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &evaluated); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("evaluate = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if len(evaluated.Results) != 1 || evaluated.Results[0].Status != quiz.StatusCorrect || len(evaluated.Warnings) != 0 {
+		t.Fatalf("evaluate results = (%+v), want one correct result without warnings", evaluated)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/bank/stats", nil))
+	var stats bankStatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil || stats.Size != 1 || stats.Hits != 1 {
+		t.Fatalf("stats = (%s, %v), want size 1 and 1 hit", rec.Body.String(), err)
+	}
+
+	// The same prompt and options with another correct answer must not
+	// replace the banked answer key.
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bank/questions", strings.NewReader(
+		`{"questions":[{"question":"Capital of France?","options":["Lyon","Paris"],"correct_index":0}]}`,
+	)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("re-adding with another answer status = %d, want 409 (body %s)", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bank/evaluate", bytes.NewReader(body)))
+	if err := json.Unmarshal(rec.Body.Bytes(), &evaluated); err != nil || len(evaluated.Results) != 1 || evaluated.Results[0].Status != quiz.StatusCorrect {
+		t.Fatalf("evaluate after a rejected re-add = (%d, %s), want B still correct", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleCreateQuizValidatesAdaptiveDifficultyTimerAndMix(t *testing.T) {
+	api := NewAPI(quiz.NewService(nil, nil, nil), nil)
+	bodies := []string{
+		`{"adaptive":true,"questions":[{"question":"Q?","options":["A","B"],"correct_index":0}]}`,
+		`{"questions":[{"question":"Q?","options":["A","B"],"correct_index":0,"difficulty":"brutal"}]}`,
+		`{"seconds_per_question":-1,"questions":[{"question":"Q?","options":["A","B"],"correct_index":0}]}`,
+		`{"seconds_per_question":3601,"questions":[{"question":"Q?","options":["A","B"],"correct_index":0}]}`,
+		`{"adaptive":true,"seconds_per_question":20}`,
+		`{"difficulty_mix":{"easy":1},"questions":[{"question":"Q?","options":["A","B"],"correct_index":0}]}`,
+		`{"difficulty_mix":{"easy":1},"adaptive":true}`,
+		`{"difficulty_mix":{"easy":4,"hard":2},"question_count":5}`,
+		`{"difficulty_mix":{"easy":40,"hard":20}}`,
+		`{"difficulty_mix":{"brutal":2}}`,
+		`{"difficulty_mix":{}}`,
+	}
+	for _, body := range bodies {
+		req := httptest.NewRequest(http.MethodPost, "/quizzes", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		api.HandleCreateQuiz(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("HandleCreateQuiz(%s) status = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestWriteLeaderboardEventFormatsServerSentEvent(t *testing.T) {
+	var out bytes.Buffer
+	writeLeaderboardEvent(&out, quiz.LeaderboardEvent{
+		ID:           "abc-3",
+		Type:         quiz.LeaderboardEventDelta,
+		QuizID:       "quiz-1",
+		Entries:      []quiz.RankedLeaderboardEntry{{Rank: 1, LeaderboardEntry: quiz.LeaderboardEntry{Username: "alice", TotalScore: 2}}},
+		Participants: 1,
+	})
+
+	text := out.String()
+	if !strings.HasPrefix(text, "id: abc-3\nevent: delta\ndata: {") || !strings.HasSuffix(text, "}\n\n") {
+		t.Fatalf("event = %q, want id, event, and one data line", text)
+	}
+	if !strings.Contains(text, `"rank":1,"username":"alice"`) {
+		t.Fatalf("event data = %q, want the ranked entry flattened", text)
+	}
+}
+
+func TestStatusRecorderPassesFlushThrough(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var writer http.ResponseWriter = &statusRecorder{ResponseWriter: rec}
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		t.Fatalf("statusRecorder does not implement http.Flusher")
+	}
+	flusher.Flush()
+	if !rec.Flushed {
+		t.Fatalf("Flush was not passed to the wrapped writer")
+	}
+}
+
+func TestBundleEndpointsLoadEmbeddedBundles(t *testing.T) {
+	pool := bundles.NewPool(nil)
+	router := NewRouterWithOptions(quiz.NewService(nil, nil, nil), nil, RouterOptions{AdminToken: "secret", Bundles: pool})
+	do := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/admin/bundles/cooking"); rec.Code != http.StatusNotFound {
+		t.Fatalf("load unknown bundle status = %d, want 404", rec.Code)
+	}
+	rec := do(http.MethodPost, "/admin/bundles/movies")
+	var loaded bundleResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &loaded); err != nil || rec.Code != http.StatusOK || !loaded.Loaded || loaded.QuestionCount == 0 {
+		t.Fatalf("load movies = (%d, %s), want 200 with the bundle loaded", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/admin/bundles")
+	var listed bundlesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || len(listed.Bundles) != 3 {
+		t.Fatalf("list bundles = (%d, %s), want 3 bundles", rec.Code, rec.Body.String())
+	}
+	for _, bundle := range listed.Bundles {
+		if bundle.Loaded != (bundle.Name == "movies") {
+			t.Fatalf("bundle %q loaded = %t, want only movies loaded", bundle.Name, bundle.Loaded)
+		}
+	}
+
+	rec = do(http.MethodGet, "/admin/pool/stats")
+	var stats bundles.Stats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil || rec.Code != http.StatusOK || stats.Fallback || stats.Questions != loaded.QuestionCount || len(stats.Bundles) != 1 {
+		t.Fatalf("pool stats = (%d, %s), want the movies bundle", rec.Code, rec.Body.String())
+	}
+}
+
+// singleQuizRepo serves one stored quiz for handler tests.
+type singleQuizRepo struct {
+	metadata  quiz.QuizMetadata
+	questions []quiz.Question
+}
+
+func (r *singleQuizRepo) CreateQuiz(context.Context, quiz.QuizMetadata, []quiz.Question) error {
+	return nil
+}
+
+func (r *singleQuizRepo) GetQuizMetadata(_ context.Context, quizID string) (quiz.QuizMetadata, error) {
+	if quizID != r.metadata.QuizID {
+		return quiz.QuizMetadata{}, quiz.ErrQuizNotFound
+	}
+	return r.metadata, nil
+}
+
+func (r *singleQuizRepo) GetQuizQuestions(_ context.Context, quizID string) ([]quiz.Question, error) {
+	if quizID != r.metadata.QuizID {
+		return nil, quiz.ErrQuizNotFound
+	}
+	return r.questions, nil
+}
+
+func (r *singleQuizRepo) QuizExists(_ context.Context, quizID string) (bool, error) {
+	return quizID == r.metadata.QuizID, nil
+}
+
+func (r *singleQuizRepo) ListActiveQuizzes(context.Context, int) ([]quiz.QuizMetadata, error) {
+	return []quiz.QuizMetadata{r.metadata}, nil
+}
+
+func TestHandleAnswerKeyReturnsAnswersToHosts(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	question.Difficulty = quiz.DifficultyEasy
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	router := NewRouterWithOptions(quiz.NewService(repo, nil, nil), nil, RouterOptions{AdminToken: "secret"})
+
+	req := httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/answer-key", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status without token = %d, want 401", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var response answerKeyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("answer key = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if len(response.Questions) != 1 {
+		t.Fatalf("questions = (%+v), want 1", response.Questions)
+	}
+	got := response.Questions[0]
+	if got.Position != 1 || got.CorrectIndex != 1 || got.CorrectLetter != "B" || got.CorrectText != "Jupiter" || got.Difficulty != quiz.DifficultyEasy {
+		t.Fatalf("questions[0] = (%+v), want Jupiter (B) as the easy answer", got)
+	}
+}
+
+// loggingAttemptRepo keeps a serving log in memory; bob answered without
+// fetching.
+type loggingAttemptRepo struct {
+	acceptingAttemptRepo
+	log map[string]quiz.ServeLogEntry
+}
+
+func (r *loggingAttemptRepo) LogServe(_ context.Context, _, usernameNormalized string, servedAt time.Time, withAnswerKey bool, noncesIssued int) error {
+	entry, ok := r.log[usernameNormalized]
+	if !ok {
+		entry = quiz.ServeLogEntry{Username: usernameNormalized, FirstServedAt: servedAt}
+	}
+	entry.LastServedAt = servedAt
+	entry.FetchCount++
+	entry.NoncesIssued += noncesIssued
+	if withAnswerKey && entry.AnswerKeyServedAt.IsZero() {
+		entry.AnswerKeyServedAt = servedAt
+	}
+	r.log[usernameNormalized] = entry
+	return nil
+}
+
+func (r *loggingAttemptRepo) ListServeLog(context.Context, string) ([]quiz.ServeLogEntry, error) {
+	entries := make([]quiz.ServeLogEntry, 0, len(r.log))
+	for _, entry := range r.log {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (r *loggingAttemptRepo) GetLeaderboard(context.Context, string) ([]quiz.LeaderboardEntry, error) {
+	return []quiz.LeaderboardEntry{{Username: "bob", AnsweredCount: 2}}, nil
+}
+
+func TestHandleServeLogShowsFetchesAndAnswerKeyAccess(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	attempts := &loggingAttemptRepo{log: map[string]quiz.ServeLogEntry{}}
+	router := NewRouterWithOptions(quiz.NewService(repo, attempts, nil), nil, RouterOptions{AdminToken: "secret"})
+
+	for _, target := range []string{"/questions?quiz_id=qz_1&username=Alice", "/questions?quiz_id=qz_1&username=alice&include_correct=true", "/questions?quiz_id=qz_1"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200", target, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/serves", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status without token = %d, want 401", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var response serveLogResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("serve log = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if len(response.Serves) != 2 {
+		t.Fatalf("serves = (%+v), want alice then bob", response.Serves)
+	}
+	alice, bob := response.Serves[0], response.Serves[1]
+	if alice.Username != "alice" || alice.FetchCount != 2 || alice.AnswerKeyServedAt == nil || alice.AnsweredCount != 0 {
+		t.Fatalf("serves[0] = (%+v), want alice fetching twice with the answer key", alice)
+	}
+	if bob.Username != "bob" || bob.FetchCount != 0 || bob.FirstServedAt != nil || bob.AnsweredCount != 2 {
+		t.Fatalf("serves[1] = (%+v), want bob answering without a logged fetch", bob)
+	}
+}
+
+func TestHandleQuestionsServesNoncesForServerScoredPlay(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	attempts := &loggingAttemptRepo{log: map[string]quiz.ServeLogEntry{}}
+	service := quiz.NewServiceWithOptions(repo, attempts, nil, quiz.ServiceOptions{ServeNonces: true})
+	router := NewRouterWithOptions(service, nil, RouterOptions{AdminToken: "secret"})
+
+	fetch := func(target string) questionResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var response questionsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK || len(response.Questions) != 1 {
+			t.Fatalf("GET %s = (%d, %s), want one question", target, rec.Code, rec.Body.String())
+		}
+		return response.Questions[0]
+	}
+	if served := fetch("/questions?quiz_id=qz_1"); served.Nonce != "" {
+		t.Fatalf("anonymous fetch nonce = %q, want none", served.Nonce)
+	}
+	if served := fetch("/questions?quiz_id=qz_1&username=bob&include_correct=true"); served.Nonce != "" {
+		t.Fatalf("answer key fetch nonce = %q, want none", served.Nonce)
+	}
+	served := fetch("/questions?quiz_id=qz_1&username=alice")
+	if served.Nonce == "" {
+		t.Fatal("server-scored fetch has no nonce")
+	}
+
+	submit := func(username, nonce string) string {
+		t.Helper()
+		body := fmt.Sprintf(`{"quiz_id":"qz_1","username":%q,"responses":[{"question_id":%q,"answer":"B","nonce":%q}]}`, username, served.QuestionID, nonce)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/responses", strings.NewReader(body)))
+		var response responsesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK || len(response.Results) != 1 {
+			t.Fatalf("POST /responses for %s = (%d, %s), want one result", username, rec.Code, rec.Body.String())
+		}
+		return response.Results[0].Status
+	}
+	if status := submit("carol", served.Nonce); status != quiz.StatusInvalidNonce {
+		t.Fatalf("carol replaying alice's payload status = %q, want invalid_nonce", status)
+	}
+	if status := submit("alice", served.Nonce); status != quiz.StatusIncorrect {
+		t.Fatalf("alice status = %q, want her answer scored", status)
+	}
+	// A retry with the spent nonce is left to the store's duplicate handling.
+	if status := submit("alice", served.Nonce); status == quiz.StatusInvalidNonce {
+		t.Fatalf("alice retrying status = %q, want the retry passed to the store", status)
+	}
+
+	entries, err := service.ServeLog(context.Background(), "qz_1")
+	if err != nil {
+		t.Fatalf("ServeLog failed: %v", err)
+	}
+	for _, entry := range entries {
+		if want := map[string]int{"alice": 1}[entry.Username]; entry.NoncesIssued != want {
+			t.Fatalf("serve log entry %+v, want %d nonces issued", entry, want)
+		}
+	}
+}
+
+func TestServerScoringWithholdsAnswerKey(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	attempts := &loggingAttemptRepo{log: map[string]quiz.ServeLogEntry{}}
+	router := NewRouterWithOptions(quiz.NewService(repo, attempts, nil), nil, RouterOptions{AdminToken: "secret", ServerScoring: true})
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer secret")
+		router.ServeHTTP(rec, request)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/questions?quiz_id=qz_1&username=alice&include_correct=true", "")
+	var served questionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil || rec.Code != http.StatusOK || len(served.Questions) != 1 {
+		t.Fatalf("GET /questions = (%d, %s), want one question", rec.Code, rec.Body.String())
+	}
+	if served.Questions[0].CorrectIndex != nil || !served.Scoring.ServerScored {
+		t.Fatalf("served %s, want no correct_index and scoring.server_scored", rec.Body.String())
+	}
+	if len(served.Warnings) != 1 || served.Warnings[0].Code != warningAnswerKeyWithheld {
+		t.Fatalf("warnings = %+v, want answer_key_withheld", served.Warnings)
+	}
+
+	for _, probe := range []struct{ target, body string }{
+		{"/responses", `{"quiz_id":"qz_1","responses":[{"question_id":"` + question.QuestionID + `","answer":"B"}]}`},
+		{"/responses", `{"responses":[{"question_id":"` + question.QuestionID + `","answer":"B"}]}`},
+		{"/bank/evaluate", `{"responses":[{"question_id":"` + question.QuestionID + `","answer":"B"}]}`},
+	} {
+		if rec := serve(http.MethodPost, probe.target, probe.body); rec.Code != http.StatusForbidden {
+			t.Fatalf("POST %s %s = (%d, %s), want 403", probe.target, probe.body, rec.Code, rec.Body.String())
+		}
+	}
+	rec = serve(http.MethodPost, "/responses", `{"quiz_id":"qz_1","username":"alice","responses":[{"question_id":"`+question.QuestionID+`","answer":"B"}]}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `correct"`) {
+		t.Fatalf("POST /responses with a username = (%d, %s), want the answer scored", rec.Code, rec.Body.String())
+	}
+
+	// Hosts still get the answer key.
+	if rec := serve(http.MethodGet, "/quizzes/qz_1/answer-key", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"correct_letter"`) {
+		t.Fatalf("GET answer-key = (%d, %s), want it served to the host", rec.Code, rec.Body.String())
+	}
+}
+
+// streakAttemptRepo keeps streaks in memory on top of acceptingAttemptRepo.
+type streakAttemptRepo struct {
+	acceptingAttemptRepo
+	streaks map[string]quiz.Streak
+}
+
+func (r *streakAttemptRepo) GetStreak(_ context.Context, usernameNormalized string) (quiz.Streak, error) {
+	streak := r.streaks[usernameNormalized]
+	streak.Username = usernameNormalized
+	return streak, nil
+}
+
+func (r *streakAttemptRepo) RecordStreakDay(_ context.Context, usernameNormalized, day string, recordedAt time.Time) (quiz.Streak, error) {
+	streak := r.streaks[usernameNormalized].Advance(day)
+	streak.UpdatedAt = recordedAt
+	r.streaks[usernameNormalized] = streak
+	return streak, nil
+}
+
+func TestHandleUserStatsReportsStreak(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	attempts := &streakAttemptRepo{streaks: map[string]quiz.Streak{"alice": {Current: 4, Longest: 4, LastDay: "2024-02-29"}}}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := quiz.NewServiceWithOptions(repo, attempts, nil, quiz.ServiceOptions{Now: func() time.Time { return now }})
+	router := NewRouter(service, nil)
+
+	body := `{"quiz_id":"qz_1","username":"Alice","responses":[{"question_id":"` + question.QuestionID + `","answer":"A"}]}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/responses", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /responses = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/Alice/stats", nil))
+	var response userStatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET /users/Alice/stats = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if response.Username != "alice" || response.CurrentStreak != 5 || response.LongestStreak != 5 || response.LastActiveDay != "2024-03-01" || response.Timezone != "UTC" {
+		t.Fatalf("stats = %+v, want alice on a 5-day streak through 2024-03-01 UTC", response)
+	}
+	if response.Totals != nil {
+		t.Fatalf("totals = %+v, want none from a store without attempt summaries", response.Totals)
+	}
+
+	rec = httptest.NewRecorder()
+	NewRouter(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/alice/stats", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("stats without a streak store = %d, want 501", rec.Code)
+	}
+}
+
+// summaryAttemptRepo adds fixed attempt summaries to streakAttemptRepo.
+type summaryAttemptRepo struct {
+	streakAttemptRepo
+	summaries []quiz.AttemptSummary
+}
+
+func (r *summaryAttemptRepo) IdleQuizzes(context.Context, time.Time) ([]string, error) {
+	return nil, nil
+}
+
+func (r *summaryAttemptRepo) ArchiveAttempts(context.Context, string, time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *summaryAttemptRepo) AttemptSummaries(context.Context, string) ([]quiz.AttemptSummary, error) {
+	return r.summaries, nil
+}
+
+func TestHandleUserStatsCountsArchivedAttempts(t *testing.T) {
+	attempts := &summaryAttemptRepo{
+		streakAttemptRepo: streakAttemptRepo{streaks: map[string]quiz.Streak{}},
+		summaries: []quiz.AttemptSummary{
+			{QuizID: "qz_2", Username: "alice", TotalScore: 1.5, AnsweredCount: 2, CorrectCount: 1},
+			{QuizID: "qz_1", Username: "alice", TotalScore: 3, AnsweredCount: 4, CorrectCount: 3, ArchivedCount: 4},
+		},
+	}
+	router := NewRouter(quiz.NewService(&singleQuizRepo{}, attempts, nil), nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/alice/stats", nil))
+	var response userStatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK || response.Totals == nil {
+		t.Fatalf("GET /users/alice/stats = (%d, %s), want 200 with totals", rec.Code, rec.Body.String())
+	}
+	want := userTotalsResponse{QuizzesPlayed: 2, AnsweredCount: 6, CorrectCount: 4, TotalScore: 4.5, ArchivedCount: 4}
+	if *response.Totals != want {
+		t.Fatalf("totals = %+v, want %+v", *response.Totals, want)
+	}
+}
+
+// quizSetRepo serves several stored quizzes for handler tests.
+type quizSetRepo struct {
+	singleQuizRepo
+	quizzes map[string]singleQuizRepo
+}
+
+func (r *quizSetRepo) GetQuizMetadata(ctx context.Context, quizID string) (quiz.QuizMetadata, error) {
+	stored := r.quizzes[quizID]
+	return stored.GetQuizMetadata(ctx, quizID)
+}
+
+func (r *quizSetRepo) GetQuizQuestions(ctx context.Context, quizID string) ([]quiz.Question, error) {
+	stored := r.quizzes[quizID]
+	return stored.GetQuizQuestions(ctx, quizID)
+}
+
+func (r *quizSetRepo) QuizExists(_ context.Context, quizID string) (bool, error) {
+	_, ok := r.quizzes[quizID]
+	return ok, nil
+}
+
+// summaryListerRepo lists fixed attempt summaries whatever the window.
+type summaryListerRepo struct {
+	acceptingAttemptRepo
+	summaries []quiz.AttemptSummary
+}
+
+func (r *summaryListerRepo) ListAttemptSummaries(context.Context, time.Time, time.Time) ([]quiz.AttemptSummary, error) {
+	return r.summaries, nil
+}
+
+func TestHandleGlobalLeaderboardNormalizesAcrossQuizzes(t *testing.T) {
+	long := make([]quiz.Question, 10)
+	hard := []quiz.Question{{Difficulty: quiz.DifficultyHard}, {Difficulty: quiz.DifficultyHard}}
+	repo := &quizSetRepo{quizzes: map[string]singleQuizRepo{
+		"qz_long":     {metadata: quiz.QuizMetadata{QuizID: "qz_long", QuestionCount: 10}, questions: long},
+		"qz_hard":     {metadata: quiz.QuizMetadata{QuizID: "qz_hard", QuestionCount: 2}, questions: hard},
+		"qz_practice": {metadata: quiz.QuizMetadata{QuizID: "qz_practice", QuestionCount: 10, Practice: true}, questions: long},
+	}}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	attempts := &summaryListerRepo{summaries: []quiz.AttemptSummary{
+		{QuizID: "qz_long", Username: "alice", TotalScore: 10, AnsweredCount: 10, CorrectCount: 10, LastSubmittedAt: at},
+		{QuizID: "qz_hard", Username: "alice", TotalScore: 1, AnsweredCount: 2, CorrectCount: 1, LastSubmittedAt: at},
+		{QuizID: "qz_hard", Username: "bob", TotalScore: 2, AnsweredCount: 2, CorrectCount: 2, LastSubmittedAt: at},
+		{QuizID: "qz_long", Username: "bob", TotalScore: 6, AnsweredCount: 10, CorrectCount: 6, LastSubmittedAt: at},
+		{QuizID: "qz_practice", Username: "bob", TotalScore: 10, AnsweredCount: 10, CorrectCount: 10, LastSubmittedAt: at},
+		{QuizID: "qz_gone", Username: "carol", TotalScore: 50, AnsweredCount: 50, CorrectCount: 50, LastSubmittedAt: at},
+	}}
+	router := NewRouter(quiz.NewService(repo, attempts, nil), nil)
+
+	get := func(target string) (int, globalLeaderboardResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var response globalLeaderboardResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("GET %s body = %s, want JSON: %v", target, rec.Body.String(), err)
+			}
+		}
+		return rec.Code, response
+	}
+
+	// bob's perfect hard quiz (125) and 60% of the long one beat alice's
+	// perfect long quiz (100) and half the hard one (62.5), though alice has
+	// more points. Practice quizzes and deleted quizzes do not count.
+	code, board := get("/leaderboard/global")
+	if code != http.StatusOK || board.OrderBy != "normalized" || board.QuizCount != 2 || board.Participants != 2 || len(board.Leaderboard) != 2 {
+		t.Fatalf("global leaderboard = (%d, %+v), want alice and bob over two quizzes", code, board)
+	}
+	first, second := board.Leaderboard[0], board.Leaderboard[1]
+	if first.Username != "bob" || first.Rank != 1 || first.NormalizedScore != 185 || first.RawScore != 8 || first.QuizzesPlayed != 2 {
+		t.Fatalf("first = %+v, want bob with 185 normalized from 8 points", first)
+	}
+	if second.Username != "alice" || second.NormalizedScore != 162.5 || second.RawScore != 11 || second.CorrectCount != 11 {
+		t.Fatalf("second = %+v, want alice with 162.5 normalized from 11 points", second)
+	}
+
+	code, board = get("/leaderboard/global?order=raw&limit=1&since=2026-01-01&until=2026-04-01T00:00:00Z")
+	if code != http.StatusOK || len(board.Leaderboard) != 1 || board.Leaderboard[0].Username != "alice" || board.Participants != 2 ||
+		board.Since == nil || !board.Since.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || board.Until == nil {
+		t.Fatalf("raw season leaderboard = (%d, %+v), want alice alone within the season", code, board)
+	}
+
+	for _, target := range []string{
+		"/leaderboard/global?order=best",
+		"/leaderboard/global?since=yesterday",
+		"/leaderboard/global?since=2026-04-01&until=2026-01-01",
+	} {
+		if code, _ := get(target); code != http.StatusBadRequest {
+			t.Fatalf("GET %s = %d, want 400", target, code)
+		}
+	}
+}
+
+// userAttemptRepo returns fixed summaries as every user's attempt history.
+type userAttemptRepo struct {
+	acceptingAttemptRepo
+	summaries []quiz.AttemptSummary
+}
+
+func (r *userAttemptRepo) GetUserAttempts(context.Context, string) ([]quiz.AttemptSummary, error) {
+	return r.summaries, nil
+}
+
+func TestHandleUserAttemptsListsQuizzesMostRecentFirst(t *testing.T) {
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	repo := &quizSetRepo{quizzes: map[string]singleQuizRepo{
+		"qz_new": {metadata: quiz.QuizMetadata{QuizID: "qz_new", QuestionCount: 5, CreatedAt: created}},
+		"qz_old": {metadata: quiz.QuizMetadata{QuizID: "qz_old", QuestionCount: 10, CreatedAt: created.Add(-48 * time.Hour)}},
+	}}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	attempts := &userAttemptRepo{summaries: []quiz.AttemptSummary{
+		{QuizID: "qz_new", Username: "alice", TotalScore: 2.5, AnsweredCount: 3, CorrectCount: 2, FirstSubmittedAt: at, LastSubmittedAt: at.Add(time.Minute)},
+		{QuizID: "qz_gone", Username: "alice", TotalScore: 1, AnsweredCount: 1, CorrectCount: 1, FirstSubmittedAt: at, LastSubmittedAt: at},
+		{QuizID: "qz_old", Username: "alice", TotalScore: 4, AnsweredCount: 10, CorrectCount: 4, ArchivedCount: 10, FirstSubmittedAt: at.Add(-time.Hour), LastSubmittedAt: at.Add(-time.Hour)},
+	}}
+	router := NewRouter(quiz.NewService(repo, attempts, nil), nil)
+
+	get := func(target string) (int, userAttemptsResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var response userAttemptsResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("GET %s body = %s, want JSON: %v", target, rec.Body.String(), err)
+			}
+		}
+		return rec.Code, response
+	}
+
+	// Deleted quizzes are left out; the rest keep the store's order.
+	code, history := get("/users/Alice/attempts")
+	if code != http.StatusOK || history.Username != "alice" || len(history.Attempts) != 2 {
+		t.Fatalf("history = (%d, %+v), want alice's two stored quizzes", code, history)
+	}
+	latest := history.Attempts[0]
+	if latest.QuizID != "qz_new" || latest.QuestionCount != 5 || latest.TotalScore != 2.5 || latest.AnsweredCount != 3 || latest.CorrectCount != 2 ||
+		!latest.FirstAnsweredAt.Equal(at) || !latest.LastAnsweredAt.Equal(at.Add(time.Minute)) || latest.QuizCreatedAt == nil || !latest.QuizCreatedAt.Equal(created) {
+		t.Fatalf("latest = %+v, want qz_new with its totals and timestamps", latest)
+	}
+	if older := history.Attempts[1]; older.QuizID != "qz_old" || older.ArchivedCount != 10 {
+		t.Fatalf("older = %+v, want qz_old with archived answers", older)
+	}
+
+	if code, history := get("/users/alice/attempts?limit=1"); code != http.StatusOK || len(history.Attempts) != 1 || history.Attempts[0].QuizID != "qz_new" {
+		t.Fatalf("limited history = (%d, %+v), want qz_new alone", code, history)
+	}
+	if code, _ := get("/users/alice/attempts?limit=0"); code != http.StatusBadRequest {
+		t.Fatalf("GET with limit=0 = %d, want 400", code)
+	}
+
+	// Stores without the capability answer 501.
+	unsupported := NewRouter(quiz.NewService(repo, &acceptingAttemptRepo{}, nil), nil)
+	rec := httptest.NewRecorder()
+	unsupported.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/alice/attempts", nil))
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("GET without a history store = %d, want 501", rec.Code)
+	}
+}
+
+// signingQuizRepo keeps signing keys in memory on top of singleQuizRepo.
+type signingQuizRepo struct {
+	singleQuizRepo
+	keys map[string]quiz.SigningKey
+}
+
+func (r *signingQuizRepo) SaveSigningKey(_ context.Context, key quiz.SigningKey) error {
+	if _, ok := r.keys[key.Username+"/"+key.KeyID]; !ok {
+		r.keys[key.Username+"/"+key.KeyID] = key
+	}
+	return nil
+}
+
+func (r *signingQuizRepo) GetSigningKey(_ context.Context, usernameNormalized, keyID string) (quiz.SigningKey, error) {
+	return r.keys[usernameNormalized+"/"+keyID], nil
+}
+
+func TestHandleSigningKeyRegistersOnce(t *testing.T) {
+	repo := &signingQuizRepo{keys: make(map[string]quiz.SigningKey)}
+	router := NewRouter(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil)
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	body := `{"public_key":"` + base64.StdEncoding.EncodeToString(publicKey) + `"}`
+
+	for _, want := range []int{http.StatusCreated, http.StatusOK} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users/Alice/signing-keys", strings.NewReader(body)))
+		var response signingKeyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != want {
+			t.Fatalf("POST /users/Alice/signing-keys = (%d, %s), want %d", rec.Code, rec.Body.String(), want)
+		}
+		if response.Username != "alice" || response.KeyID != quiz.SigningKeyID(publicKey) {
+			t.Fatalf("signing key = %+v, want alice's key %s", response, quiz.SigningKeyID(publicKey))
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users/alice/signing-keys", strings.NewReader(`{"public_key":"c2hvcnQ="}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("short key = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	NewRouter(quiz.NewService(&singleQuizRepo{}, acceptingAttemptRepo{}, nil), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users/alice/signing-keys", strings.NewReader(body)))
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("signing key without a key store = %d, want 501", rec.Code)
+	}
+}
+
+// acceptingAttemptRepo records nothing and marks every response incorrect, so
+// fuzzed submissions reach the whole submit path.
+type acceptingAttemptRepo struct{}
+
+func (acceptingAttemptRepo) SubmitResponses(_ context.Context, _, _ string, responses []quiz.SubmittedResponse) ([]quiz.ResponseResult, error) {
+	results := make([]quiz.ResponseResult, 0, len(responses))
+	for _, response := range responses {
+		results = append(results, quiz.ResponseResult{QuestionID: response.QuestionID, Status: quiz.StatusIncorrect})
+	}
+	return results, nil
+}
+
+func (acceptingAttemptRepo) GetLeaderboard(context.Context, string) ([]quiz.LeaderboardEntry, error) {
+	return nil, nil
+}
+
+func (acceptingAttemptRepo) GetAttemptScores(context.Context, string, string) (map[string]float64, error) {
+	return map[string]float64{}, nil
+}
+
+func newFuzzAPI(t testing.TB) *API {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	bank := quiz.NewBank()
+	bank.AddBuiltQuestions(repo.questions)
+	return NewAPI(quiz.NewService(repo, acceptingAttemptRepo{}, nil), bank)
+}
+
+// Handlers must answer every body with a client error or success, never a
+// panic or a 5xx.
+func FuzzHandleResponses(f *testing.F) {
+	for _, seed := range []string{
+		`{"responses":[{"question_id":"q1","answer":"A"}]}`,
+		`{"quiz_id":"qz_1","username":"alice","responses":[{"question_id":"q1","answer":"b","content_hash":"00"}]}`,
+		`{"quiz_id":"qz_1","responses":[]}`,
+		`{"quiz_id":"missing","username":" ","responses":[{}]}`,
+		`{"responses":null}`,
+		"{\"username\":\"\xff\",\"responses\":[{\"answer\":\"\xc5\xbf\"}]}",
+		`[]`,
+	} {
+		f.Add([]byte(seed))
+	}
+	api := newFuzzAPI(f)
+	f.Fuzz(func(t *testing.T, body []byte) {
+		req := httptest.NewRequest(http.MethodPost, "/responses", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		api.HandleResponses(rec, req)
+		if rec.Code >= http.StatusInternalServerError {
+			t.Fatalf("status = %d (%s), want below 500", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func FuzzHandleCreateQuiz(f *testing.F) {
+	for _, seed := range []string{
+		`{"questions":[{"question":"2 + 2?","options":["3","4"],"correct_index":1}]}`,
+		`{"questions":[{"question":"?","options":["a","b"],"correct_index":-1,"feedback":["x","y","z"]}]}`,
+		`{"questions":[{"question":"?","options":["a","b"],"correct_index":0,"difficulty":"extreme"}],"practice":true}`,
+		`{"questions":[{"question":"?","options":["a"," "],"correct_index":0}],"adaptive":true}`,
+		`{"author":"bob"}`,
+		"{\"questions\":[{\"question\":\"\xff\",\"options\":[\"\xfe\",\"\x00\"]}]}",
+	} {
+		f.Add([]byte(seed))
+	}
+	api := newFuzzAPI(f)
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var request createQuizRequest
+		if json.Unmarshal(body, &request) != nil || len(request.Questions) == 0 {
+			// Without questions the handler fetches from the provider, which
+			// the fuzz API does not have.
+			return
+		}
+		req := httptest.NewRequest(http.MethodPost, "/quizzes", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		api.HandleCreateQuiz(rec, req)
+		if rec.Code >= http.StatusInternalServerError {
+			t.Fatalf("status = %d (%s), want below 500", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestHandleResponsesRejectsOversizedRequests(t *testing.T) {
+	api := newFuzzAPI(t)
+
+	responses := make([]quiz.SubmittedResponse, maxResponsesPerRequest+1)
+	body, err := json.Marshal(responsesRequest{Responses: responses})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	api.HandleResponses(rec, httptest.NewRequest(http.MethodPost, "/responses", bytes.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status for %d responses = %d, want 400", len(responses), rec.Code)
+	}
+
+	body = []byte(`{"responses":[{"question_id":"` + strings.Repeat("x", maxRequestBodyBytes) + `"}]}`)
+	rec = httptest.NewRecorder()
+	api.HandleResponses(rec, httptest.NewRequest(http.MethodPost, "/responses", bytes.NewReader(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status for %d-byte body = %d, want 413", len(body), rec.Code)
+	}
+}
+
+func TestPublicHandlersCapRequestBodies(t *testing.T) {
+	api := newFuzzAPI(t)
+	body := `{"question_id":"` + strings.Repeat("x", maxRequestBodyBytes) + `"}`
+
+	for name, handle := range map[string]http.HandlerFunc{
+		"report":   api.HandleReportQuestion,
+		"bank":     api.HandleBankQuestions,
+		"evaluate": api.HandleBankEvaluate,
+		"practice": api.HandlePracticeQuiz,
+	} {
+		rec := httptest.NewRecorder()
+		handle(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("%s: status for %d-byte body = %d, want 413", name, len(body), rec.Code)
+		}
+	}
+}
+
+func TestHandleQuestionsServesRequestedLanguage(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err == nil {
+		question, err = question.WithTranslations(map[string]quiz.Translation{"es": {Question: "¿Planeta más grande?", Options: []string{"Marte", "Júpiter"}}})
+	}
+	if err != nil {
+		t.Fatalf("build question: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	router := NewRouter(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/questions?quiz_id=qz_1&lang=ES", nil))
+	var response questionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET /questions?lang=ES = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	got := response.Questions[0]
+	if got.Language != "es" || got.Question != "¿Planeta más grande?" || got.Options[1] != (quiz.Option{Letter: "B", Text: "Júpiter"}) || got.QuestionID != question.QuestionID {
+		t.Fatalf("questions[0] = (%+v), want the Spanish text with canonical ID and letters", got)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/questions?quiz_id=qz_1&lang=fr", nil))
+	response = questionsResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || response.Questions[0].Language != "" || response.Questions[0].Question != "Largest planet?" {
+		t.Fatalf("GET /questions?lang=fr = (%s), want the canonical text", rec.Body.String())
+	}
+	if langs := response.Questions[0].Languages; len(langs) != 1 || langs[0] != "es" {
+		t.Fatalf("languages = (%v), want [es]", langs)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/questions?quiz_id=qz_1&lang=not_a_tag", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid lang status = %d, want 400", rec.Code)
+	}
+
+	body := bytes.NewBufferString(`{"questions":[{"question":"?","options":["a","b"],"correct_index":0,"translations":{"es":{"question":"?","options":["a"]}}}]}`)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/quizzes", body))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "es translation has 1 options") {
+		t.Fatalf("short translation = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleQuestionsFromPositionReturnsOnlyLaterQuestions(t *testing.T) {
+	var questions []quiz.Question
+	for _, prompt := range []string{"One?", "Two?", "Three?"} {
+		question, err := quiz.NewQuestion(prompt, []string{"yes", "no"}, 0)
+		if err != nil {
+			t.Fatalf("build question: %v", err)
+		}
+		questions = append(questions, question)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 3}, questions: questions}
+	router := NewRouter(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil)
+	get := func(query string) (*httptest.ResponseRecorder, questionsResponse) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/questions?"+query, nil))
+		var response questionsResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	rec, response := get("quiz_id=qz_1&from=2")
+	if rec.Code != http.StatusOK || response.From != 2 || response.QuestionCount != 3 || len(response.Questions) != 1 || response.Questions[0].QuestionID != questions[2].QuestionID {
+		t.Fatalf("from=2 = (%d, %s), want only the third question of three", rec.Code, rec.Body.String())
+	}
+	if response.Questions[0].ContentHash == "" {
+		t.Fatalf("from=2 question = %+v, want its content hash", response.Questions[0])
+	}
+	if rec, response := get("quiz_id=qz_1&from=5"); rec.Code != http.StatusOK || len(response.Questions) != 0 || response.QuestionCount != 3 {
+		t.Fatalf("from past the end = (%d, %s), want no questions", rec.Code, rec.Body.String())
+	}
+	for _, query := range []string{"quiz_id=qz_1&from=-1", "quiz_id=qz_1&from=x", "from=1"} {
+		if rec, _ := get(query); rec.Code != http.StatusBadRequest {
+			t.Fatalf("GET /questions?%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestHandleStatsOverviewIsPublic(t *testing.T) {
+	router := NewRouterWithOptions(quiz.NewService(nil, nil, nil), nil, RouterOptions{AdminToken: "secret"})
+	req := httptest.NewRequest(http.MethodGet, "/stats/overview", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /stats/overview status = %d, want 200 without a token", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"quizzes_today":0`) || !strings.Contains(body, `"top_categories":[]`) {
+		t.Fatalf("overview body = %s, want zero counters and an empty category list", body)
+	}
+}
+
+func TestWriteFetchErrorSeparatesBusyProviderFromFailures(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeFetchError(rec, fmt.Errorf("create: %w", quiz.ErrProviderBusy), "failed to create quiz")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("busy provider = (%d, Retry-After %q), want 503 with Retry-After 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	rec = httptest.NewRecorder()
+	writeFetchError(rec, errors.New("upstream timeout"), "failed to create quiz")
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "failed to create quiz") {
+		t.Fatalf("upstream failure = (%d, %s), want 502", rec.Code, rec.Body.String())
+	}
+}
+
+// settingsQuizRepo keeps leaderboard settings for its one quiz.
+type settingsQuizRepo struct {
+	singleQuizRepo
+	settings quiz.LeaderboardSettings
+}
+
+func (r *settingsQuizRepo) GetLeaderboardSettings(context.Context, string) (quiz.LeaderboardSettings, error) {
+	return r.settings, nil
+}
+
+func (r *settingsQuizRepo) SaveLeaderboardSettings(_ context.Context, _ string, settings quiz.LeaderboardSettings) error {
+	r.settings = settings
+	return nil
+}
+
+type twoPlayerAttemptRepo struct {
+	acceptingAttemptRepo
+}
+
+func (twoPlayerAttemptRepo) GetLeaderboard(context.Context, string) ([]quiz.LeaderboardEntry, error) {
+	return []quiz.LeaderboardEntry{{Username: "alice", TotalScore: 2}, {Username: "bob", TotalScore: 1}}, nil
+}
+
+func TestHandleLeaderboardSettingsSetsDefaultLimit(t *testing.T) {
+	repo := &settingsQuizRepo{singleQuizRepo: singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1"}}}
+	router := NewRouterWithOptions(quiz.NewService(repo, twoPlayerAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	put := func(body string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/quizzes/qz_1/leaderboard/settings", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := put(`{"default_limit":1}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("PUT without token = %d, want 401", rec.Code)
+	}
+	for _, body := range []string{`{"default_limit":51}`, `{"freeze_seconds":-1}`, `{"freeze_seconds":300}`, `{"tiebreak":"slowest"}`} {
+		if rec := put(body, "secret"); rec.Code != http.StatusBadRequest {
+			t.Fatalf("PUT %s = (%d, %s), want 400", body, rec.Code, rec.Body.String())
+		}
+	}
+	rec := put(`{"default_limit":1}`, "secret")
+	var settings leaderboardSettingsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &settings); err != nil || rec.Code != http.StatusOK || settings.DefaultLimit != 1 || settings.UpdatedAt == nil {
+		t.Fatalf("PUT settings = (%d, %s), want default limit 1", rec.Code, rec.Body.String())
+	}
+	if settings.Tiebreak != "last_submission" {
+		t.Fatalf("default tiebreak = %q, want last_submission spelled out", settings.Tiebreak)
+	}
+	rec = put(`{"default_limit":1,"tiebreak":" Fewest_Answers "}`, "secret")
+	if err := json.Unmarshal(rec.Body.Bytes(), &settings); err != nil || rec.Code != http.StatusOK || settings.Tiebreak != "fewest_answers" || repo.settings.Tiebreak != quiz.TiebreakFewestAnswers {
+		t.Fatalf("PUT tiebreak = (%d, %s), want fewest_answers saved", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/leaderboard", nil))
+	var board leaderboardResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &board); err != nil || len(board.Leaderboard) != 1 || board.Leaderboard[0].Username != "alice" || board.FrozenAt != nil {
+		t.Fatalf("leaderboard = (%d, %s), want only the leader, not frozen", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/leaderboard?limit=5", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &board); err != nil || len(board.Leaderboard) != 2 {
+		t.Fatalf("leaderboard with limit = (%d, %s), want both players", rec.Code, rec.Body.String())
+	}
+}
+
+// resultsQuizRepo keeps published results for its one quiz.
+type resultsQuizRepo struct {
+	singleQuizRepo
+	results quiz.PublishedResults
+}
+
+func (r *resultsQuizRepo) SaveQuizResults(_ context.Context, results quiz.PublishedResults) error {
+	r.results = results
+	return nil
+}
+
+func (r *resultsQuizRepo) GetQuizResults(context.Context, string) (quiz.PublishedResults, error) {
+	return r.results, nil
+}
+
+func TestHandleQuizResultsServesImmutableDocument(t *testing.T) {
+	repo := &resultsQuizRepo{singleQuizRepo: singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1"}}}
+	router := NewRouter(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil)
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/results.json", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("", ""); rec.Code != http.StatusConflict {
+		t.Fatalf("results before the lock = (%d, %s), want 409", rec.Code, rec.Body.String())
+	}
+
+	document := `{"quiz_id":"qz_1","standings":[]}`
+	repo.results = quiz.PublishedResults{QuizID: "qz_1", Document: []byte(document), PublishedAt: time.Date(2026, 3, 1, 21, 0, 0, 0, time.UTC)}
+	rec := get("", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != document {
+		t.Fatalf("results = (%d, %s), want the published document", rec.Code, rec.Body.String())
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" || !strings.Contains(rec.Header().Get("Cache-Control"), "immutable") || rec.Header().Get("Last-Modified") != "Sun, 01 Mar 2026 21:00:00 GMT" {
+		t.Fatalf("headers = %v, want a strong ETag, immutable caching, and Last-Modified", rec.Header())
+	}
+	if rec := get("If-None-Match", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("conditional GET = (%d, %s), want 304", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleImportQuizCreatesQuizAndReportsSkippedLines(t *testing.T) {
+	router := NewRouter(quiz.NewService(&singleQuizRepo{}, nil, nil), quiz.NewBank())
+	gift := "What is the capital of France? {~Lyon =Paris}\n\nDiscuss. {}\n\nThe sun is a star. {T}\n"
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/quizzes/import?dry_run=true", strings.NewReader(gift)))
+	var response importQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK || response.Quiz != nil {
+		t.Fatalf("dry run = (%d, %s), want 200 without a quiz", rec.Code, rec.Body.String())
+	}
+	if response.Format != "gift" || response.QuestionCount != 2 || len(response.Errors) != 1 || response.Errors[0].Line != 3 {
+		t.Fatalf("dry run = %+v, want two gift questions and the essay on line 3", response)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/quizzes/import?format=gift&practice=true", strings.NewReader(gift)))
+	response = importQuizResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusCreated || response.Quiz == nil {
+		t.Fatalf("import = (%d, %s), want 201 with a quiz", rec.Code, rec.Body.String())
+	}
+	if created := response.Quiz; created.QuestionCount != 2 || !created.Practice || len(created.QuestionIDs) != 2 || len(created.ContentHashes) != 2 {
+		t.Fatalf("quiz = %+v, want a two-question practice quiz", created)
+	}
+
+	for target, body := range map[string]string{
+		"/quizzes/import?format=qti":   gift,
+		"/quizzes/import":              "no format here",
+		"/quizzes/import?format=aiken": "Question?\nA. One\n",
+	} {
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("POST %s with %q = (%d, %s), want 400", target, body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// recordingQuizRepo keeps the last quiz created through it.
+type recordingQuizRepo struct {
+	singleQuizRepo
+}
+
+func (r *recordingQuizRepo) CreateQuiz(_ context.Context, metadata quiz.QuizMetadata, questions []quiz.Question) error {
+	r.metadata, r.questions = metadata, questions
+	return nil
+}
+
+func TestHandleRematchReusesQuizSettings(t *testing.T) {
+	kept, _ := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	voided, _ := quiz.NewQuestion("Withdrawn?", []string{"a", "b"}, 0)
+	voided.Voided = true
+	repo := &recordingQuizRepo{singleQuizRepo{
+		metadata: quiz.QuizMetadata{
+			QuizID:            "qz_1",
+			QuestionCount:     2,
+			QuestionTimeLimit: 20 * time.Second,
+			Origin:            quiz.QuizOrigin{Provider: quiz.ProviderCustom},
+		},
+		questions: []quiz.Question{kept, voided},
+	}}
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return []opentdb.RawQuestion{{Question: "Fresh?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}}}, nil
+	}
+	router := NewRouter(quiz.NewService(repo, nil, fetcher), nil)
+	do := func(target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do("/quizzes/qz_1/rematch", "")
+	var copied createQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &copied); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("rematch = (%d, %s), want 201", rec.Code, rec.Body.String())
+	}
+	if copied.QuizID == "qz_1" || copied.QuestionCount != 1 || copied.SecondsPerQuestion != 20 || copied.Origin == nil || copied.Origin.Provider != quiz.ProviderCustom {
+		t.Fatalf("rematch = %+v, want the live question copied with the timer and origin", copied)
+	}
+
+	if rec := do("/quizzes/"+copied.QuizID+"/rematch", `{"questions":"fresh"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("fresh rematch of a custom quiz = (%d, %s), want 409", rec.Code, rec.Body.String())
+	}
+	if rec := do("/quizzes/"+copied.QuizID+"/rematch", `{"questions":"sometimes"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("rematch with unknown mode = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	if rec := do("/quizzes/missing/rematch", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("rematch of unknown quiz = (%d, %s), want 404", rec.Code, rec.Body.String())
+	}
+
+	fetched := &recordingQuizRepo{singleQuizRepo{
+		metadata:  quiz.QuizMetadata{QuizID: "qz_2", QuestionCount: 1, RequestedQuestionCount: 1, Origin: quiz.QuizOrigin{Provider: "opentdb"}},
+		questions: []quiz.Question{kept},
+	}}
+	router = NewRouter(quiz.NewService(fetched, nil, fetcher), nil)
+	rec = do("/quizzes/qz_2/rematch", "")
+	var fresh createQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &fresh); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("fresh rematch = (%d, %s), want 201", rec.Code, rec.Body.String())
+	}
+	if fresh.QuestionCount != 1 || len(fresh.QuestionIDs) != 1 || fetched.questions[0].Question != "Fresh?" || fresh.Origin == nil || fresh.Origin.Provider != "opentdb" {
+		t.Fatalf("fresh rematch = %+v, want one fetched question from opentdb", fresh)
+	}
+}
+
+func TestHandleCreateQuizWarnsOnProviderFallback(t *testing.T) {
+	failing := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return nil, errors.New("upstream down")
+	}
+	bundled := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return []opentdb.RawQuestion{{Question: "Bundled?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}}}, nil
+	}
+	service := quiz.NewServiceWithOptions(&singleQuizRepo{}, nil, failing, quiz.ServiceOptions{
+		FallbackProviders: []quiz.FallbackProvider{{Name: "bundles", Fetch: bundled}},
+	})
+	router := NewRouterWithOptions(service, nil, RouterOptions{SkipBankPopulation: true})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/quizzes", strings.NewReader(`{"question_count":1}`)))
+	var created createQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("POST /quizzes = (%d, %s), want 201", rec.Code, rec.Body.String())
+	}
+	if created.Origin == nil || created.Origin.Provider != "bundles" || created.Origin.FallbackFrom != "opentdb" {
+		t.Fatalf("origin = %+v, want bundles standing in for opentdb", created.Origin)
+	}
+	if len(created.Warnings) != 1 || created.Warnings[0].Code != warningProviderFallback {
+		t.Fatalf("warnings = %+v, want one %s", created.Warnings, warningProviderFallback)
+	}
+
+	router = NewRouterWithOptions(quiz.NewServiceWithOptions(&singleQuizRepo{}, nil, failing, quiz.ServiceOptions{
+		FallbackProviders: []quiz.FallbackProvider{{Name: "pool", Fetch: failing}},
+	}), nil, RouterOptions{SkipBankPopulation: true})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/questions?question_count=1", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("GET /questions with every provider down = (%d, %s), want 502", rec.Code, rec.Body.String())
+	}
+}
+
+func TestQuizBundleRoundTripsBetweenDeployments(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err == nil {
+		question, err = question.WithFeedback([]string{"Mars is smaller than Earth.", ""})
+	}
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	question.Difficulty = quiz.DifficultyEasy
+	question.Source = "triviaapi"
+	voided, _ := quiz.NewQuestion("Withdrawn?", []string{"a", "b"}, 0)
+	voided.Voided = true
+	source := &singleQuizRepo{
+		metadata:  quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 2, Practice: true, QuestionTimeLimit: 20 * time.Second},
+		questions: []quiz.Question{question, voided},
+	}
+	exporter := NewRouterWithOptions(quiz.NewService(source, nil, nil), nil, RouterOptions{AdminToken: "secret"})
+
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/bundle", nil))
+	var public quizBundle
+	if err := json.Unmarshal(rec.Body.Bytes(), &public); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("public export = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if public.IncludesAnswers || len(public.Questions) != 1 || public.Questions[0].CorrectIndex != nil || public.Questions[0].Feedback != nil {
+		t.Fatalf("public bundle = (%+v), want the one live question without answers", public)
+	}
+
+	rec = httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/bundle?answers=true", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("answers without token = %d, want 401", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/bundle?answers=true", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	exporter.ServeHTTP(rec, req)
+	var full quizBundle
+	if err := json.Unmarshal(rec.Body.Bytes(), &full); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("full export = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+
+	target := &recordingQuizRepo{}
+	importer := NewRouter(quiz.NewService(target, nil, nil), nil)
+	post := func(bundle quizBundle) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(bundle)
+		rec := httptest.NewRecorder()
+		importer.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/quizzes/import-bundle", bytes.NewReader(body)))
+		return rec
+	}
+	if rec := post(public); rec.Code != http.StatusBadRequest {
+		t.Fatalf("import without answers = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+
+	full.Settings.Leaderboard = &leaderboardSettingsRequest{DefaultLimit: 5}
+	rec = post(full)
+	var created createQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("import = (%d, %s), want 201", rec.Code, rec.Body.String())
+	}
+	if created.QuizID == "qz_1" || !created.Practice || created.SecondsPerQuestion != 20 || len(created.QuestionIDs) != 1 {
+		t.Fatalf("created = (%+v), want a new practice quiz with one timed question", created)
+	}
+	if len(created.Warnings) != 1 || created.Warnings[0].Code != warningSettingsNotApplied {
+		t.Fatalf("warnings = (%+v), want settings_not_applied from a store without leaderboard settings", created.Warnings)
+	}
+	got := target.questions
+	if len(got) != 1 || got[0].QuestionID != question.QuestionID || got[0].CorrectIndex != 1 || got[0].Difficulty != quiz.DifficultyEasy || got[0].FeedbackFor(0) != "Mars is smaller than Earth." || got[0].Source != "triviaapi" {
+		t.Fatalf("stored questions = (%+v), want the exported question unchanged", got)
+	}
+}
+
+// lookupQuizRepo is a recordingQuizRepo that also stores the questions in
+// stored, looked up by ID.
+type lookupQuizRepo struct {
+	recordingQuizRepo
+	stored []quiz.Question
+}
+
+func (r *lookupQuizRepo) LookupQuestions(_ context.Context, questionIDs []string) ([]quiz.Question, error) {
+	var found []quiz.Question
+	for _, question := range r.stored {
+		if slices.Contains(questionIDs, question.QuestionID) {
+			found = append(found, question)
+		}
+	}
+	return found, nil
+}
+
+func TestQuizBundleWithholdsRestrictedSourceQuestions(t *testing.T) {
+	open, _ := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	open.Source = "opentdb"
+	licensed, _ := quiz.NewQuestion("Smallest planet?", []string{"Mercury", "Mars"}, 0)
+	licensed.Source = "file:licensed.csv"
+	source := &singleQuizRepo{
+		metadata:  quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 2},
+		questions: []quiz.Question{licensed, open},
+	}
+	service := quiz.NewServiceWithOptions(source, nil, nil, quiz.ServiceOptions{RestrictedSources: []string{"file"}})
+	exporter := NewRouterWithOptions(service, nil, RouterOptions{AdminToken: "secret"})
+
+	req := httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/bundle?answers=true", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, req)
+	var bundle quizBundle
+	if err := json.Unmarshal(rec.Body.Bytes(), &bundle); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("export = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	withheld := bundle.Questions[0]
+	if bundle.Version != quizBundleVersion || len(bundle.Questions) != 2 || !withheld.Withheld || withheld.QuestionID != licensed.QuestionID || withheld.Question != "" || withheld.Options != nil || withheld.CorrectIndex != nil {
+		t.Fatalf("bundle = (%+v), want the licensed question withheld by ID in a version %d bundle", bundle, quizBundleVersion)
+	}
+	if bundle.Questions[1].Withheld || bundle.Questions[1].Question != open.Question {
+		t.Fatalf("open question = (%+v), want it copied", bundle.Questions[1])
+	}
+
+	post := func(repo quiz.QuizRepository) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(bundle)
+		rec := httptest.NewRecorder()
+		NewRouter(quiz.NewService(repo, nil, nil), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/quizzes/import-bundle", bytes.NewReader(body)))
+		return rec
+	}
+	if rec := post(&recordingQuizRepo{}); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), licensed.QuestionID) {
+		t.Fatalf("import without the question stored = (%d, %s), want 400 naming it", rec.Code, rec.Body.String())
+	}
+	target := &lookupQuizRepo{stored: []quiz.Question{licensed}}
+	if rec := post(target); rec.Code != http.StatusCreated {
+		t.Fatalf("import = (%d, %s), want 201", rec.Code, rec.Body.String())
+	}
+	got := target.questions
+	if len(got) != 2 || got[0].QuestionID != licensed.QuestionID || got[0].Source != licensed.Source || got[1].QuestionID != open.QuestionID || got[1].Source != "opentdb" {
+		t.Fatalf("stored questions = (%+v), want the stored licensed question then the copied one", got)
+	}
+}
+
+func TestHandleLeaderboardStreamEndsWithClosingEventOnShutdown(t *testing.T) {
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}}
+	service := quiz.NewService(repo, &acceptingAttemptRepo{}, nil)
+	server := httptest.NewServer(NewRouter(service, nil))
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL + "/quizzes/qz_1/leaderboard/stream")
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("stream = (%v, %v), want 200", response, err)
+	}
+	defer response.Body.Close()
+	reader := bufio.NewReader(response.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading snapshot: %v", err)
+		}
+		if strings.HasPrefix(line, "data:") {
+			break
+		}
+	}
+
+	service.CloseLeaderboardStreams()
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading to the end of the stream: %v", err)
+	}
+	if text := string(rest); !strings.Contains(text, "event: closing\n") || strings.Contains(text, "id:") {
+		t.Fatalf("stream tail = %q, want a closing event without an id line", text)
+	}
+
+	rec := httptest.NewRecorder()
+	NewRouter(service, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/leaderboard/stream", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("stream after shutdown = %d, want 503 with Retry-After", rec.Code)
+	}
+}
+
+func TestHandleQuizEventsStreamsSubmissionsToHosts(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	service := quiz.NewService(repo, acceptingAttemptRepo{}, nil)
+	server := httptest.NewServer(NewRouterWithOptions(service, nil, RouterOptions{AdminToken: "secret"}))
+	defer server.Close()
+
+	if response, err := server.Client().Get(server.URL + "/quizzes/qz_1/events"); err != nil || response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("events without a token = (%v, %v), want 401", response, err)
+	}
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/quizzes/qz_1/events", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	response, err := server.Client().Do(request)
+	if err != nil || response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("events = (%v, %v), want a 200 event stream", response, err)
+	}
+	defer response.Body.Close()
+	reader := bufio.NewReader(response.Body)
+	nextEvent := func() quiz.SubmissionEvent {
+		t.Helper()
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("reading event: %v", err)
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				var event quiz.SubmissionEvent
+				if err := json.Unmarshal([]byte(data), &event); err != nil {
+					t.Fatalf("decoding %q: %v", data, err)
+				}
+				return event
+			}
+		}
+	}
+
+	if snapshot := nextEvent(); snapshot.Type != quiz.SubmissionEventSnapshot || snapshot.QuestionCount != 1 || snapshot.Participants != 0 {
+		t.Fatalf("first event = %+v, want an empty snapshot", snapshot)
+	}
+	if _, err := service.SubmitResponses(context.Background(), "qz_1", "Alice", []quiz.SubmittedResponse{{QuestionID: question.QuestionID, Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	event := nextEvent()
+	if event.Type != quiz.SubmissionEventSubmission || event.Submission == nil || event.Submission.Username != "alice" ||
+		!event.Submission.Finished || event.Submission.ScoreDelta != 0 || event.FinishedCount != 1 {
+		t.Fatalf("submission event = %+v (%+v), want alice finished with no points", event, event.Submission)
+	}
+
+	service.CloseSubmissionStreams()
+	if closing := nextEvent(); closing.Type != quiz.SubmissionEventClosing {
+		t.Fatalf("last event = %+v, want closing", closing)
+	}
+}
+
+type retirementQuizRepo struct {
+	singleQuizRepo
+	review *quiz.RetirementReview
+}
+
+func (r *retirementQuizRepo) QuestionStats(context.Context, int) ([]quiz.QuestionPerformance, error) {
+	return nil, nil
+}
+
+func (r *retirementQuizRepo) FlagQuestion(context.Context, quiz.RetirementReview) error {
+	return nil
+}
+
+func (r *retirementQuizRepo) ListRetirementReviews(_ context.Context, status quiz.RetirementStatus) ([]quiz.RetirementReview, error) {
+	if r.review == nil || (status != "" && r.review.Status != status) {
+		return nil, nil
+	}
+	return []quiz.RetirementReview{*r.review}, nil
+}
+
+func (r *retirementQuizRepo) DecideRetirement(_ context.Context, questionID string, status quiz.RetirementStatus, decidedAt time.Time) (quiz.RetirementReview, error) {
+	if r.review == nil || r.review.Question.QuestionID != questionID {
+		return quiz.RetirementReview{}, quiz.ErrNotFlagged
+	}
+	r.review.Status, r.review.DecidedAt = status, decidedAt
+	return *r.review, nil
+}
+
+func TestHandleRetirementQueueAndDecision(t *testing.T) {
+	repo := &retirementQuizRepo{review: &quiz.RetirementReview{
+		Question:     quiz.Question{PublicQuestion: quiz.PublicQuestion{QuestionID: "q1", Question: "Broken?"}},
+		Status:       quiz.RetirementPending,
+		AttemptCount: 40,
+		FlaggedAt:    time.Unix(1700000000, 0).UTC(),
+	}}
+	router := NewRouterWithOptions(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(method, target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/admin/retirements", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET without token = %d, want 401", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/admin/retirements?status=maybe", "", "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("GET unknown status = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	rec := do(http.MethodGet, "/admin/retirements", "", "secret")
+	var queue retirementQueueResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &queue); err != nil || rec.Code != http.StatusOK || queue.Status != "pending" || len(queue.Questions) != 1 || queue.Questions[0].CorrectnessRate != 0 {
+		t.Fatalf("GET queue = (%d, %s), want q1 pending", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodPost, "/admin/retirements/q1", `{"decision":"maybe"}`, "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST unknown decision = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/admin/retirements/q2", `{"decision":"retire"}`, "secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("POST unflagged question = (%d, %s), want 404", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodPost, "/admin/retirements/q1", `{"decision":"retire"}`, "secret")
+	var review retirementReviewResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &review); err != nil || rec.Code != http.StatusOK || review.Status != "retired" || review.DecidedAt == nil {
+		t.Fatalf("POST retire = (%d, %s), want retired with decided_at", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/admin/retirements?status=all", "", "secret")
+	if err := json.Unmarshal(rec.Body.Bytes(), &queue); err != nil || queue.Status != "all" || len(queue.Questions) != 1 {
+		t.Fatalf("GET all = (%d, %s), want q1", rec.Code, rec.Body.String())
+	}
+}
+
+type taggedQuizRepo struct {
+	singleQuizRepo
+	tags map[string][]string
+}
+
+func (r *taggedQuizRepo) SetQuestionTags(_ context.Context, questionID string, tags []string) error {
+	if _, ok := r.tags[questionID]; !ok {
+		return quiz.ErrUnknownQuestion
+	}
+	r.tags[questionID] = tags
+	return nil
+}
+
+func (r *taggedQuizRepo) QuestionTags(_ context.Context, questionID string) ([]string, error) {
+	tags, ok := r.tags[questionID]
+	if !ok {
+		return nil, quiz.ErrUnknownQuestion
+	}
+	return tags, nil
+}
+
+func (r *taggedQuizRepo) SampleBankQuestions(_ context.Context, filter quiz.BankFilter, limit int) ([]quiz.Question, error) {
+	sampled := make([]quiz.Question, 0, limit)
+	for _, question := range r.questions {
+		if len(sampled) < limit && strings.Join(r.tags[question.QuestionID], ",") == strings.Join(filter.Tags, ",") {
+			sampled = append(sampled, question)
+		}
+	}
+	return sampled, nil
+}
+
+func TestHandleQuestionTagsAndBankQuizzes(t *testing.T) {
+	repo := &taggedQuizRepo{tags: make(map[string][]string)}
+	for _, prompt := range []string{"Capital of France?", "Capital of Peru?", "Capital of Chad?"} {
+		question, err := quiz.NewQuestion(prompt, []string{"Yes", "No"}, 0)
+		if err != nil {
+			t.Fatalf("NewQuestion failed: %v", err)
+		}
+		repo.questions = append(repo.questions, question)
+		repo.tags[question.QuestionID] = nil
+	}
+	router := NewRouterWithOptions(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(method, target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := "/questions/" + repo.questions[0].QuestionID + "/tags"
+	if rec := do(http.MethodPut, first, `{"tags":["geo"]}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("PUT without token = %d, want 401", rec.Code)
+	}
+	if rec := do(http.MethodPut, first, `{"tags":["two words"]}`, "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT invalid tag = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/questions/missing/tags", "", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET unknown question = (%d, %s), want 404", rec.Code, rec.Body.String())
+	}
+	for _, question := range repo.questions[:2] {
+		if rec := do(http.MethodPut, "/questions/"+question.QuestionID+"/tags", `{"tags":[" Geo ","capitals","geo"]}`, "secret"); rec.Code != http.StatusOK {
+			t.Fatalf("PUT tags = (%d, %s), want 200", rec.Code, rec.Body.String())
+		}
+	}
+	rec := do(http.MethodGet, first, "", "")
+	var tags questionTagsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil || rec.Code != http.StatusOK || strings.Join(tags.Tags, ",") != "capitals,geo" {
+		t.Fatalf("GET tags = (%d, %s), want capitals,geo", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodPost, "/quizzes/from-bank", `{"tags":["geo","capitals"],"question_count":1,"quiz_count":2,"seconds_per_question":20}`, "")
+	var created bankQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || rec.Code != http.StatusCreated || len(created.Quizzes) != 2 {
+		t.Fatalf("POST /quizzes/from-bank = (%d, %s), want two quizzes", rec.Code, rec.Body.String())
+	}
+	for _, item := range created.Quizzes {
+		if item.QuestionCount != 1 || len(item.QuestionIDs) != 1 || item.SecondsPerQuestion != 20 || item.Origin == nil || item.Origin.Provider != quiz.ProviderBank {
+			t.Fatalf("bank quiz = (%+v), want one question from the bank at 20s", item)
+		}
+	}
+	if created.Quizzes[0].QuestionIDs[0] == created.Quizzes[1].QuestionIDs[0] {
+		t.Fatalf("bank quizzes share question %s, want distinct questions", created.Quizzes[0].QuestionIDs[0])
+	}
+
+	if rec := do(http.MethodPost, "/quizzes/from-bank", `{"tags":["geo","capitals"],"question_count":3}`, ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("POST too many questions = (%d, %s), want 422", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/quizzes/from-bank", `{"question_count":1,"quiz_count":21}`, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST too many quizzes = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/quizzes/from-bank", `{"difficulty":"brutal"}`, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST unknown difficulty = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+}
+
+type removableQuizRepo struct {
+	singleQuizRepo
+	deleted bool
+}
+
+func (r *removableQuizRepo) DeleteQuiz(_ context.Context, quizID string) (int, error) {
+	if r.deleted || quizID != r.metadata.QuizID {
+		return 0, quiz.ErrQuizNotFound
+	}
+	r.deleted = true
+	return 3, nil
+}
+
+func (r *removableQuizRepo) ArchiveQuiz(_ context.Context, quizID string, archivedAt time.Time) (int, error) {
+	if quizID != r.metadata.QuizID {
+		return 0, quiz.ErrQuizNotFound
+	}
+	r.metadata.Locked = true
+	r.metadata.ArchivedAt = archivedAt
+	return 2, nil
+}
+
+func TestHandleRemoveQuiz(t *testing.T) {
+	repo := &removableQuizRepo{singleQuizRepo: singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}}}
+	router := NewRouterWithOptions(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("/quizzes/qz_1", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("DELETE without token = %d, want 401", rec.Code)
+	}
+	if rec := do("/quizzes/qz_1?mode=shred", "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("DELETE unknown mode = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	if rec := do("/quizzes/qz_2", "secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("DELETE unknown quiz = (%d, %s), want 404", rec.Code, rec.Body.String())
+	}
+
+	rec := do("/quizzes/qz_1", "secret")
+	var removed removeQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &removed); err != nil || rec.Code != http.StatusOK || removed.Mode != "archive" || removed.Attempts != 2 || removed.ArchivedAt == nil {
+		t.Fatalf("DELETE archive = (%d, %s), want archived with 2 attempts", rec.Code, rec.Body.String())
+	}
+	firstArchivedAt := *removed.ArchivedAt
+	rec = do("/quizzes/qz_1?mode=archive", "secret")
+	if err := json.Unmarshal(rec.Body.Bytes(), &removed); err != nil || rec.Code != http.StatusOK || removed.Attempts != 0 || !removed.ArchivedAt.Equal(firstArchivedAt) {
+		t.Fatalf("DELETE archive again = (%d, %s), want the first archive time", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/state", nil))
+	if !strings.Contains(rec.Body.String(), `"state":"archived"`) {
+		t.Fatalf("GET state after archive = (%d, %s), want archived", rec.Code, rec.Body.String())
+	}
+
+	rec = do("/quizzes/qz_1?mode=delete", "secret")
+	removed = removeQuizResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &removed); err != nil || rec.Code != http.StatusOK || removed.Mode != "delete" || removed.Attempts != 3 || removed.ArchivedAt != nil {
+		t.Fatalf("DELETE delete = (%d, %s), want deleted with 3 attempts", rec.Code, rec.Body.String())
+	}
+	if rec := do("/quizzes/qz_1?mode=delete", "secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("DELETE deleted quiz = (%d, %s), want 404", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleRemoveQuizUnsupported(t *testing.T) {
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}}
+	router := NewRouterWithOptions(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	req := httptest.NewRequest(http.MethodDelete, "/quizzes/qz_1?mode=delete", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotImplemented {
+		t.Fatalf("DELETE on a store without removal = (%d, %s), want 501", rec.Code, rec.Body.String())
+	}
+}
+
+type rosterQuizRepo struct {
+	singleQuizRepo
+	roster quiz.Roster
+}
+
+func (r *rosterQuizRepo) GetRoster(context.Context, string) (quiz.Roster, error) {
+	return r.roster, nil
+}
+
+func (r *rosterQuizRepo) SaveRoster(_ context.Context, _ string, roster quiz.Roster) error {
+	r.roster = roster
+	return nil
+}
+
+func TestHandleRosterRestrictsAndExportsNames(t *testing.T) {
+	repo := &rosterQuizRepo{singleQuizRepo: singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}}}
+	router := NewRouterWithOptions(quiz.NewService(repo, twoPlayerAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(method, target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"restricted":true,"generate_join_codes":true,"students":[{"username":"Alice","name":"Alice Smith"},{"username":"carol","name":"Carol White"}]}`
+	if rec := do(http.MethodPut, "/quizzes/qz_1/roster", body, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("PUT without token = %d, want 401", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/quizzes/qz_1/roster", `{"restricted":true}`, "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT empty restricted roster = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	rec := do(http.MethodPut, "/quizzes/qz_1/roster", body, "secret")
+	var roster rosterResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &roster); err != nil || rec.Code != http.StatusOK || !roster.Restricted || len(roster.Students) != 3 {
+		t.Fatalf("PUT roster = (%d, %s), want alice, bob, and carol", rec.Code, rec.Body.String())
+	}
+	if got := roster.Students[0]; got.Username != "alice" || got.Name != "Alice Smith" || got.Rank != 1 || got.JoinCode == "" {
+		t.Fatalf("students[0] = (%+v), want Alice Smith ranked 1 with a join code", got)
+	}
+	if got := roster.Students[1]; got.Username != "bob" || got.OnRoster || got.Rank != 2 {
+		t.Fatalf("students[1] = (%+v), want unrostered bob ranked 2", got)
+	}
+
+	rec = do(http.MethodPost, "/quizzes/qz_1/join", `{"join_code":"`+roster.Students[0].JoinCode+`"}`, "")
+	var joined joinQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil || rec.Code != http.StatusOK || joined.Username != "alice" {
+		t.Fatalf("POST join = (%d, %s), want alice", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/quizzes/qz_1/join", `{"join_code":"WRONG1"}`, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("POST join with a wrong code = (%d, %s), want 404", rec.Code, rec.Body.String())
+	}
+
+	submit := `{"quiz_id":"qz_1","username":"bob","responses":[{"question_id":"q1","answer":"A"}]}`
+	if rec := do(http.MethodPost, "/responses", submit, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST /responses off the roster = (%d, %s), want 403", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/quizzes/qz_1/roster?format=csv", "", "secret")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("GET csv = (%d, %s), want CSV", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "1,alice,Alice Smith,") || !strings.HasPrefix(lines[3], ",carol,Carol White,") {
+		t.Fatalf("csv = (%q), want a header and alice, bob, carol", lines)
+	}
+}
+
+type identityQuizRepo struct {
+	singleQuizRepo
+	identities map[string]quiz.Identity
+}
+
+func (r *identityQuizRepo) GetIdentity(_ context.Context, usernameNormalized string) (quiz.Identity, error) {
+	return r.identities[usernameNormalized], nil
+}
+
+func (r *identityQuizRepo) SaveIdentity(_ context.Context, identity quiz.Identity) error {
+	r.identities[identity.Username] = identity
+	return nil
+}
+
+func TestHandleIdentityVerifiesAndGuardsSubmissions(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &identityQuizRepo{
+		singleQuizRepo: singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}},
+		identities:     make(map[string]quiz.Identity),
+	}
+	var message quiz.IdentityMessage
+	service := quiz.NewServiceWithOptions(repo, acceptingAttemptRepo{}, nil, quiz.ServiceOptions{
+		IdentityMailer: func(_ context.Context, _ string, sent quiz.IdentityMessage) error {
+			message = sent
+			return nil
+		},
+	})
+	router := NewRouter(service, nil)
+	do := func(method, target, body, playerToken string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if playerToken != "" {
+			req.Header.Set("X-Player-Token", playerToken)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/users/alice/identity", `{"email":"nope"}`, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST identity with a bad email = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/users/Alice/identity", `{"email":"alice@example.com"}`, ""); rec.Code != http.StatusAccepted || message.Code == "" {
+		t.Fatalf("POST identity = (%d, %s), want 202 and a mailed code", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/users/alice/identity/verify", `{}`, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST verify without a code = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+
+	rec := do(http.MethodGet, "/users/alice/identity/verify?token="+message.LinkToken, "", "")
+	var verified identityResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &verified); err != nil || rec.Code != http.StatusOK || !verified.Verified || verified.PlayerToken == "" {
+		t.Fatalf("GET verify link = (%d, %s), want a player token", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/users/ALICE/identity", "", "")
+	var status identityResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil || rec.Code != http.StatusOK || status.Username != "alice" || !status.Verified || status.PlayerToken != "" {
+		t.Fatalf("GET identity = (%d, %s), want verified alice without a token", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "example.com") {
+		t.Fatalf("GET identity leaked the email address: %s", rec.Body.String())
+	}
+
+	submit := `{"quiz_id":"qz_1","username":"alice","responses":[{"question_id":"` + question.QuestionID + `","answer":"B"}]}`
+	if rec := do(http.MethodPost, "/responses", submit, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("POST /responses without a player token = (%d, %s), want 401", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/responses", submit, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("POST /responses with a wrong player token = (%d, %s), want 401", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/responses", submit, verified.PlayerToken); rec.Code != http.StatusOK {
+		t.Fatalf("POST /responses with the player token = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	// Unverified usernames still submit without a token.
+	guest := strings.Replace(submit, `"alice"`, `"bob"`, 1)
+	if rec := do(http.MethodPost, "/responses", guest, ""); rec.Code != http.StatusOK {
+		t.Fatalf("POST /responses for an unverified username = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+}
+
+type accountQuizRepo struct {
+	singleQuizRepo
+	accounts map[string]quiz.Account
+}
+
+func (r *accountQuizRepo) CreateAccount(_ context.Context, account quiz.Account) error {
+	if _, ok := r.accounts[account.Username]; ok {
+		return quiz.ErrAccountExists
+	}
+	r.accounts[account.Username] = account
+	return nil
+}
+
+func (r *accountQuizRepo) GetAccount(_ context.Context, usernameNormalized string) (quiz.Account, error) {
+	return r.accounts[usernameNormalized], nil
+}
+
+// submitterAttemptRepo records whom answers were stored for.
+type submitterAttemptRepo struct {
+	acceptingAttemptRepo
+	usernames []string
+}
+
+func (r *submitterAttemptRepo) SubmitResponses(ctx context.Context, quizID, usernameNormalized string, responses []quiz.SubmittedResponse) ([]quiz.ResponseResult, error) {
+	r.usernames = append(r.usernames, usernameNormalized)
+	return r.acceptingAttemptRepo.SubmitResponses(ctx, quizID, usernameNormalized, responses)
+}
+
+func TestHandleAccountsRegisterLoginAndGuardSubmissions(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &accountQuizRepo{
+		singleQuizRepo: singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}},
+		accounts:       make(map[string]quiz.Account),
+	}
+	attempts := &submitterAttemptRepo{}
+	router := NewRouterWithOptions(quiz.NewService(repo, attempts, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("/users/register", `{"username":"alice","password":"short"}`, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST register with a short password = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	rec := do("/users/register", `{"username":"Alice","password":"correct horse"}`, "")
+	var account accountResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &account); err != nil || rec.Code != http.StatusCreated || account.Username != "alice" {
+		t.Fatalf("POST register = (%d, %s), want 201 for alice", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "pbkdf2") {
+		t.Fatalf("POST register leaked the password hash: %s", rec.Body.String())
+	}
+	if rec := do("/users/register", `{"username":"alice","password":"another password"}`, ""); rec.Code != http.StatusConflict {
+		t.Fatalf("POST register again = (%d, %s), want 409", rec.Code, rec.Body.String())
+	}
+	if rec := do("/users/login", `{"username":"alice","password":"wrong password"}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("POST login with a wrong password = (%d, %s), want 401", rec.Code, rec.Body.String())
+	}
+	rec = do("/users/login", `{"username":"alice","password":"correct horse"}`, "")
+	var login loginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &login); err != nil || rec.Code != http.StatusOK || login.Token == "" || login.TokenType != "Bearer" {
+		t.Fatalf("POST login = (%d, %s), want a bearer token", rec.Code, rec.Body.String())
+	}
+
+	// Changing a registered user's settings or bookmarks needs their token,
+	// like answering as them.
+	for _, write := range []struct{ method, target, body string }{
+		{http.MethodPut, "/users/alice/profile", `{"anonymous":false}`},
+		{http.MethodPut, "/users/alice/preferences", `{"question_count":5}`},
+		{http.MethodPost, "/users/alice/bookmarks", `{"question_id":"` + question.QuestionID + `"}`},
+		{http.MethodDelete, "/users/alice/bookmarks/" + question.QuestionID, ""},
+		{http.MethodPost, "/users/alice/bookmarks/practice", `{}`},
+	} {
+		req := httptest.NewRequest(write.method, write.target, strings.NewReader(write.body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s %s without a token = (%d, %s), want 401", write.method, write.target, rec.Code, rec.Body.String())
+		}
+		req = httptest.NewRequest(write.method, strings.Replace(write.target, "alice", "bob", 1), strings.NewReader(write.body))
+		req.Header.Set("Authorization", "Bearer "+login.Token)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s %s for bob with alice's token = (%d, %s), want 403", write.method, write.target, rec.Code, rec.Body.String())
+		}
+	}
+
+	answers := `"responses":[{"question_id":"` + question.QuestionID + `","answer":"B"}]`
+	if rec := do("/responses", `{"quiz_id":"qz_1","username":"alice",`+answers+`}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("POST /responses as alice without a token = (%d, %s), want 401", rec.Code, rec.Body.String())
+	}
+	if rec := do("/responses", `{"quiz_id":"qz_1","username":"bob",`+answers+`}`, login.Token); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST /responses as bob with alice's token = (%d, %s), want 403", rec.Code, rec.Body.String())
+	}
+	if rec := do("/responses", `{"quiz_id":"qz_1",`+answers+`}`, "not-a-token"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("POST /responses with a bad token = (%d, %s), want 401", rec.Code, rec.Body.String())
+	}
+	rec = do("/responses", `{"quiz_id":"qz_1",`+answers+`}`, login.Token)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), warningNotPersisted) {
+		t.Fatalf("POST /responses with the token and no username = (%d, %s), want persisted", rec.Code, rec.Body.String())
+	}
+	// The admin token is not an account token; unregistered usernames
+	// still submit without one.
+	if rec := do("/responses", `{"quiz_id":"qz_1","username":"bob",`+answers+`}`, "secret"); rec.Code != http.StatusOK {
+		t.Fatalf("POST /responses as unregistered bob = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if want := []string{"alice", "bob"}; strings.Join(attempts.usernames, ",") != strings.Join(want, ",") {
+		t.Fatalf("stored answers for %v, want %v", attempts.usernames, want)
+	}
+}
+
+type auditAttemptRepo struct {
+	acceptingAttemptRepo
+	entries []quiz.AuditEntry
+}
+
+func (r *auditAttemptRepo) RecordAuditEntry(_ context.Context, entry quiz.AuditEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *auditAttemptRepo) ListAuditEntries(context.Context, string) ([]quiz.AuditEntry, error) {
+	return r.entries, nil
+}
+
+func TestHandleSubmitOnBehalfRecordsAuditEntry(t *testing.T) {
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}}
+	router := NewRouterWithOptions(quiz.NewService(repo, &auditAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(method, target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"username":"Alice","admin":"Dana","responses":[{"question_id":"q1","answer":"A"}]}`
+	if rec := do(http.MethodPost, "/quizzes/qz_1/responses/on-behalf", body, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("POST without token = %d, want 401", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/quizzes/qz_1/responses/on-behalf", `{"username":"alice","responses":[{"question_id":"q1","answer":"A"}]}`, "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST without admin = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	rec := do(http.MethodPost, "/quizzes/qz_1/responses/on-behalf", body, "secret")
+	var submitted responsesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &submitted); err != nil || rec.Code != http.StatusOK || len(submitted.Results) != 1 {
+		t.Fatalf("POST on behalf = (%d, %s), want one result", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodGet, "/quizzes/qz_1/audit", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET audit without token = %d, want 401", rec.Code)
+	}
+	rec = do(http.MethodGet, "/quizzes/qz_1/audit", "", "secret")
+	var audit auditLogResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &audit); err != nil || rec.Code != http.StatusOK || len(audit.Entries) != 1 {
+		t.Fatalf("GET audit = (%d, %s), want one entry", rec.Code, rec.Body.String())
+	}
+	if entry := audit.Entries[0]; entry.Admin != "Dana" || entry.Username != "alice" || entry.Action != quiz.AuditSubmitOnBehalf || entry.Detail != "q1=A" {
+		t.Fatalf("audit entry = %+v, want Dana entering alice's answer", entry)
+	}
+}
+
+type historyAttemptRepo struct {
+	twoPlayerAttemptRepo
+	byUser map[string][]quiz.Attempt
+}
+
+func (r historyAttemptRepo) ListAttempts(_ context.Context, _, usernameNormalized string) ([]quiz.Attempt, error) {
+	return r.byUser[usernameNormalized], nil
+}
+
+func TestHandleLeaderboardByCategory(t *testing.T) {
+	science, err := quiz.NewQuestion("Boiling point of water?", []string{"100C", "50C"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	science.Category = "Science"
+	history, err := quiz.NewQuestion("First moon landing?", []string{"1969", "1972"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	history.Category = "History"
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 2}, questions: []quiz.Question{science, history}}
+	attempts := historyAttemptRepo{byUser: map[string][]quiz.Attempt{
+		"alice": {{QuestionID: history.QuestionID, Score: 1}},
+		"bob":   {{QuestionID: science.QuestionID, Score: 1}},
+	}}
+	router := NewRouter(quiz.NewService(repo, attempts, nil), nil)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/quizzes/qz_1/leaderboard?category=science")
+	var board leaderboardResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &board); err != nil || rec.Code != http.StatusOK || board.Category != "science" {
+		t.Fatalf("GET category leaderboard = (%d, %s), want the science board", rec.Code, rec.Body.String())
+	}
+	if len(board.Leaderboard) != 1 || board.Leaderboard[0].Username != "bob" {
+		t.Fatalf("science leaderboard = %+v, want only bob", board.Leaderboard)
+	}
+	if rec := get("/quizzes/qz_1/leaderboard?category=Art"); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET unknown category = (%d, %s), want 404", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleAttemptReview(t *testing.T) {
+	first, err := quiz.NewQuestion("Boiling point of water?", []string{"100C", "50C"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	second, err := quiz.NewQuestion("First moon landing?", []string{"1969", "1972"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 2}, questions: []quiz.Question{first, second}}
+	attempts := historyAttemptRepo{byUser: map[string][]quiz.Attempt{
+		"alice": {{QuestionID: first.QuestionID, AnswerLetter: "B"}, {QuestionID: second.QuestionID, AnswerLetter: "A", Score: 1}},
+		"bob":   {{QuestionID: first.QuestionID, AnswerLetter: "A", Score: 1}},
+	}}
+	review := func(options RouterOptions, target string) (attemptReviewResponse, *httptest.ResponseRecorder) {
+		router := NewRouterWithOptions(quiz.NewService(repo, attempts, nil), nil, options)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var response attemptReviewResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode review: %v", err)
+			}
+		}
+		return response, rec
+	}
+
+	got, rec := review(RouterOptions{}, "/quizzes/qz_1/attempts/Alice")
+	if rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("GET review = (%d, %q), want 200 and no-store", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+	if !got.Completed || !got.Revealed || got.TotalScore != 1 || got.QuestionCount != 2 || len(got.Questions) != 2 {
+		t.Fatalf("finished review = %+v, want alice's two answers with the key", got)
+	}
+	if q := got.Questions[0]; q.ChosenLetter != "B" || q.Status != quiz.StatusIncorrect || q.CorrectLetter != "A" || q.CorrectText != "100C" || q.AnsweredAt != nil {
+		t.Fatalf("missed question = %+v, want B wrong and 100C revealed", q)
+	}
+
+	got, _ = review(RouterOptions{}, "/quizzes/qz_1/attempts/bob")
+	if got.Completed || got.Revealed || got.Questions[1].Status != quiz.StatusUnanswered || got.Questions[0].CorrectLetter != "" {
+		t.Fatalf("unfinished review = %+v, want no key", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"answers_revealed":true`) {
+		t.Fatalf("review body %s, want answers_revealed", rec.Body.String())
+	}
+
+	got, _ = review(RouterOptions{ServerScoring: true}, "/quizzes/qz_1/attempts/alice")
+	if !got.Completed || got.Revealed || got.Questions[0].CorrectLetter != "" {
+		t.Fatalf("server-scored review = %+v, want the key withheld until the lock", got)
+	}
+	repo.metadata.Locked = true
+	got, _ = review(RouterOptions{ServerScoring: true}, "/quizzes/qz_1/attempts/bob")
+	if !got.Locked || !got.Revealed || got.Questions[1].CorrectText != "1969" {
+		t.Fatalf("locked server-scored review = %+v, want the key", got)
+	}
+
+	if _, rec := review(RouterOptions{}, "/quizzes/missing/attempts/alice"); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET review of a missing quiz = %d, want 404", rec.Code)
+	}
+}
+
+type preferencesAttemptRepo struct {
+	acceptingAttemptRepo
+	saved map[string]quiz.UserPreferences
+}
+
+func (r *preferencesAttemptRepo) GetPreferences(_ context.Context, usernameNormalized string) (quiz.UserPreferences, error) {
+	if preferences, ok := r.saved[usernameNormalized]; ok {
+		return preferences, nil
+	}
+	return quiz.UserPreferences{Username: usernameNormalized}, nil
+}
+
+func (r *preferencesAttemptRepo) SavePreferences(_ context.Context, preferences quiz.UserPreferences) error {
+	r.saved[preferences.Username] = preferences
+	return nil
+}
+
+func TestHandleCreateQuizAppliesCreatorPreferences(t *testing.T) {
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		raw := []opentdb.RawQuestion{{Question: "Hard?", Difficulty: "hard", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}}}
+		for idx := 1; idx < amount; idx++ {
+			raw = append(raw, opentdb.RawQuestion{Question: fmt.Sprintf("Easy %d?", idx), Difficulty: "easy", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}})
+		}
+		return raw, nil
+	}
+	attempts := &preferencesAttemptRepo{saved: make(map[string]quiz.UserPreferences)}
+	router := NewRouterWithOptions(quiz.NewService(&singleQuizRepo{}, attempts, fetcher), nil, RouterOptions{SkipBankPopulation: true})
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	for _, body := range []string{`{"question_count":99}`, `{"difficulty":"brutal"}`, `{"question_count":-1}`} {
+		if rec := serve(http.MethodPut, "/users/alice/preferences", body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("PUT %s status = %d, want 400 (body %s)", body, rec.Code, rec.Body.String())
+		}
+	}
+	rec := serve(http.MethodPut, "/users/Alice/preferences", `{"question_count":3,"difficulty":"Easy"}`)
+	var saved preferencesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &saved); err != nil || rec.Code != http.StatusOK || saved.Username != "alice" || saved.QuestionCount != 3 || saved.Difficulty != "easy" {
+		t.Fatalf("PUT preferences = (%d, %s), want 3 easy questions for alice", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodGet, "/users/alice/preferences", ""); !strings.Contains(rec.Body.String(), `"difficulty":"easy"`) {
+		t.Fatalf("GET preferences = (%d, %s), want the saved difficulty", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		wantCount int
+	}{
+		{"POST with preferences", http.MethodPost, "/quizzes", `{"username":"alice"}`, 3},
+		{"POST with an explicit count", http.MethodPost, "/quizzes", `{"username":"alice","question_count":2}`, 2},
+		{"POST without a username", http.MethodPost, "/quizzes", `{}`, defaultQuestionCount},
+		{"GET create with preferences", http.MethodGet, "/questions?username=alice", "", 3},
+		{"GET create_if_missing with an explicit count", http.MethodGet, "/questions?quiz_id=alices-quiz&create_if_missing=true&username=alice&question_count=4", "", 4},
+	}
+	for _, tt := range tests {
+		rec := serve(tt.method, tt.target, tt.body)
+		var created struct {
+			QuizID        string `json:"quiz_id"`
+			QuestionCount int    `json:"question_count"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.QuestionCount != tt.wantCount {
+			t.Fatalf("%s = (%d, %s), want %d questions", tt.name, rec.Code, rec.Body.String(), tt.wantCount)
+		}
+	}
+}
+
+func TestHandleCreateQuizFiltersFetchedQuestions(t *testing.T) {
+	var filters []opentdb.Filter
+	fetcher := func(_ context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		filters = append(filters, filter)
+		if filter.Category == 32 {
+			return nil, opentdb.ErrNotEnoughQuestions
+		}
+		raw := make([]opentdb.RawQuestion, 0, amount)
+		for idx := 0; idx < amount; idx++ {
+			raw = append(raw, opentdb.RawQuestion{Type: "boolean", Difficulty: "hard", Question: fmt.Sprintf("Fact %d?", idx), CorrectAnswer: "True", IncorrectAnswers: []string{"False"}})
+		}
+		return raw, nil
+	}
+	router := NewRouterWithOptions(quiz.NewService(&singleQuizRepo{}, acceptingAttemptRepo{}, fetcher), nil, RouterOptions{SkipBankPopulation: true})
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/quizzes", `{"question_count":2,"category":18,"difficulty":"hard","type":"boolean"}`)
+	var created createQuizResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || rec.Code != http.StatusCreated || created.Origin == nil || created.Origin.Category != 18 || created.Origin.Type != "boolean" {
+		t.Fatalf("POST filtered = (%d, %s), want a quiz recording category 18 and boolean", rec.Code, rec.Body.String())
+	}
+	if want := (opentdb.Filter{Category: 18, Difficulty: "hard", Type: "boolean"}); filters[len(filters)-1] != want {
+		t.Fatalf("provider filter = %+v, want %+v", filters[len(filters)-1], want)
+	}
+
+	rec = serve(http.MethodGet, "/questions?question_count=2&category=science:+computers&type=boolean", "")
+	if rec.Code != http.StatusOK || filters[len(filters)-1] != (opentdb.Filter{Category: 18, Type: "boolean"}) {
+		t.Fatalf("GET filtered = (%d, %s) with filter %+v, want category 18 of boolean", rec.Code, rec.Body.String(), filters[len(filters)-1])
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"unknown category", http.MethodPost, "/quizzes", `{"category":99}`, http.StatusBadRequest},
+		{"unknown type", http.MethodPost, "/quizzes", `{"type":"essay"}`, http.StatusBadRequest},
+		{"unknown difficulty", http.MethodGet, "/questions?difficulty=brutal", "", http.StatusBadRequest},
+		{"difficulty with a mix", http.MethodPost, "/quizzes", `{"difficulty":"easy","difficulty_mix":{"hard":2}}`, http.StatusBadRequest},
+		{"filter with adaptive", http.MethodPost, "/quizzes", `{"adaptive":true,"category":9}`, http.StatusBadRequest},
+		{"filter for an existing quiz", http.MethodGet, "/questions?quiz_id=qz_1&category=9", "", http.StatusBadRequest},
+		{"too few questions upstream", http.MethodPost, "/quizzes", `{"category":32}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		if rec := serve(tt.method, tt.target, tt.body); rec.Code != tt.want {
+			t.Fatalf("%s = (%d, %s), want %d", tt.name, rec.Code, rec.Body.String(), tt.want)
+		}
+	}
+}
+
+type hostQuizRepo struct {
+	singleQuizRepo
+	hosts []quiz.QuizHost
+}
+
+func (r *hostQuizRepo) GetHosts(_ context.Context, quizID string) ([]quiz.QuizHost, error) {
+	if quizID != r.metadata.QuizID {
+		return nil, nil
+	}
+	return append([]quiz.QuizHost(nil), r.hosts...), nil
+}
+
+func (r *hostQuizRepo) SaveHosts(_ context.Context, _ string, hosts []quiz.QuizHost) error {
+	r.hosts = hosts
+	return nil
+}
+
+func TestHandleHostsLetsCoHostsRunTheirQuiz(t *testing.T) {
+	question, err := quiz.NewQuestion("2 + 2?", []string{"4", "5"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &hostQuizRepo{singleQuizRepo: singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}}
+	attempts := &auditAttemptRepo{}
+	router := NewRouterWithOptions(quiz.NewService(repo, attempts, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(method, target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	addHost := func(body, token string) hostsResponse {
+		t.Helper()
+		rec := do(http.MethodPost, "/quizzes/qz_1/hosts", body, token)
+		var response hostsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusCreated || response.Added == nil || response.Added.HostToken == "" {
+			t.Fatalf("POST hosts %s = (%d, %s), want 201 with a host token", body, rec.Code, rec.Body.String())
+		}
+		return response
+	}
+
+	if rec := do(http.MethodPost, "/quizzes/qz_1/hosts", `{"username":"alice"}`, "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST hosts with the admin token and no admin name = (%d, %s), want 400", rec.Code, rec.Body.String())
+	}
+	owner := addHost(`{"username":"alice","admin":"Dana"}`, "secret")
+	cohost := addHost(`{"username":"bob"}`, owner.Added.HostToken)
+	if owner.Added.Role != "owner" || cohost.Added.Role != "co_host" || len(cohost.Hosts) != 2 {
+		t.Fatalf("hosts after adding bob = %+v, want alice as owner and bob as co-host", cohost)
+	}
+
+	bob := cohost.Added.HostToken
+	if rec := do(http.MethodGet, "/quizzes/qz_1/answer-key", "", bob); rec.Code != http.StatusOK {
+		t.Fatalf("GET answer-key as co-host = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/quizzes/qz_2/answer-key", "", bob); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET another quiz's answer-key as co-host = (%d, %s), want 401", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/admin/quizzes", "", bob); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /admin/quizzes as co-host = (%d, %s), want 401", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/quizzes/qz_1/hosts", "", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET hosts with a wrong token = %d, want 401", rec.Code)
+	}
+
+	// Proxy answers entered by a host are recorded under their username.
+	body := `{"username":"carol","responses":[{"question_id":"` + question.QuestionID + `","answer":"A"}]}`
+	if rec := do(http.MethodPost, "/quizzes/qz_1/responses/on-behalf", body, bob); rec.Code != http.StatusOK {
+		t.Fatalf("POST on-behalf as co-host = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+
+	// A co-host can neither take over nor replace the owner's token.
+	if rec := do(http.MethodPost, "/quizzes/qz_1/hosts", `{"username":"bob","role":"owner"}`, bob); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST hosts taking ownership as co-host = (%d, %s), want 403", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/quizzes/qz_1/hosts", `{"username":"alice"}`, bob); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST hosts replacing the owner's token as co-host = (%d, %s), want 403", rec.Code, rec.Body.String())
+	}
+
+	// Alice hands ownership to bob, who keeps his token.
+	rec := do(http.MethodPost, "/quizzes/qz_1/hosts", `{"username":"bob","role":"owner"}`, owner.Added.HostToken)
+	var transfer hostsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &transfer); err != nil || rec.Code != http.StatusCreated || transfer.Added == nil || transfer.Added.HostToken != "" {
+		t.Fatalf("POST hosts handing over to bob = (%d, %s), want 201 without a host token", rec.Code, rec.Body.String())
+	}
+	if transfer.Hosts[0].Username != "bob" || transfer.Hosts[0].Role != "owner" || transfer.Hosts[1].Role != "co_host" {
+		t.Fatalf("hosts after transfer = %+v, want bob first as owner", transfer.Hosts)
+	}
+
+	rec = do(http.MethodGet, "/quizzes/qz_1/audit", "", bob)
+	var audit auditLogResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &audit); err != nil || rec.Code != http.StatusOK || len(audit.Entries) != 4 {
+		t.Fatalf("GET audit = (%d, %s), want four entries", rec.Code, rec.Body.String())
+	}
+	if entry := audit.Entries[2]; entry.Admin != "bob" || entry.Action != quiz.AuditSubmitOnBehalf {
+		t.Fatalf("proxy audit entry = %+v, want it entered by bob", entry)
+	}
+	if entry := audit.Entries[3]; entry.Admin != "alice" || entry.Action != quiz.AuditOwnershipTransferred || entry.Detail != "from=alice" {
+		t.Fatalf("transfer audit entry = %+v, want alice handing over to bob", entry)
+	}
+}
+
+func TestHandleAnswerPositionsReportsLetters(t *testing.T) {
+	first, err := quiz.NewQuestion("2 + 2?", []string{"5", "4", "3"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	second, err := quiz.NewQuestion("3 + 3?", []string{"5", "6"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 2}, questions: []quiz.Question{first, second}}
+	router := NewRouterWithOptions(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(method, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/quizzes/qz_1/answer-positions", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET without a token = (%d, %s), want 401", rec.Code, rec.Body.String())
+	}
+	rec := do(http.MethodGet, "secret")
+	var response answerPositionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if response.Questions != 2 || len(response.Letters) != 3 || response.Letters[1].Correct != 2 || response.Letters[2].Expected != 0.33 || response.Moved != nil {
+		t.Fatalf("response = %+v, want B correct twice across three letters and nothing moved", response)
+	}
+	// The test repo cannot swap questions, so a rebalance is not available.
+	if rec := do(http.MethodPost, "secret"); rec.Code != http.StatusNotImplemented {
+		t.Fatalf("POST = (%d, %s), want 501", rec.Code, rec.Body.String())
+	}
+}
+
+// lifecycleQuizRepo lets hosts change the one stored quiz's state.
+type lifecycleQuizRepo struct {
+	singleQuizRepo
+}
+
+func (r *lifecycleQuizRepo) SetQuizLifecycle(_ context.Context, quizID string, lifecycle quiz.QuizLifecycle) error {
+	if quizID != r.metadata.QuizID {
+		return quiz.ErrQuizNotFound
+	}
+	r.metadata.Draft, r.metadata.Locked, r.metadata.ClosesAt = lifecycle.Draft, lifecycle.Locked, lifecycle.ClosesAt
+	return nil
+}
+
+func TestHandleQuizStateLocksQuizAgainstAnswers(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &lifecycleQuizRepo{singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1, Draft: true}, questions: []quiz.Question{question}}}
+	router := NewRouterWithOptions(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(method, path, token, body string) (*httptest.ResponseRecorder, quizStateResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var response quizStateResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+	submit := `{"quiz_id":"qz_1","username":"alice","responses":[{"question_id":"` + question.QuestionID + `","answer":"B"}]}`
+
+	if rec, response := do(http.MethodGet, "/quizzes/qz_1/state", "", ""); rec.Code != http.StatusOK || response.State != quiz.QuizStateDraft {
+		t.Fatalf("GET state = (%d, %s), want draft", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(http.MethodPost, "/responses", "", submit); rec.Code != http.StatusConflict {
+		t.Fatalf("answer to a draft = (%d, %s), want 409", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(http.MethodPut, "/quizzes/qz_1/state", "", `{"state":"active"}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("PUT state without token = %d, want 401", rec.Code)
+	}
+	if rec, _ := do(http.MethodPut, "/quizzes/qz_1/state", "secret", `{"state":"paused"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT unknown state = %d, want 400", rec.Code)
+	}
+	if rec, _ := do(http.MethodPut, "/quizzes/qz_1/state", "secret", `{"closes_at":"2000-01-01T00:00:00Z"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("PUT past closes_at = %d, want 400", rec.Code)
+	}
+	if rec, response := do(http.MethodPut, "/quizzes/qz_1/state", "secret", `{"state":"active"}`); rec.Code != http.StatusOK || response.State != quiz.QuizStateActive {
+		t.Fatalf("activate = (%d, %s), want active", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(http.MethodPost, "/responses", "", submit); rec.Code != http.StatusOK {
+		t.Fatalf("answer to an active quiz = (%d, %s), want 200", rec.Code, rec.Body.String())
+	}
+	if rec, response := do(http.MethodPut, "/quizzes/qz_1/state", "secret", `{"state":"locked"}`); rec.Code != http.StatusOK || response.State != quiz.QuizStateLocked {
+		t.Fatalf("lock = (%d, %s), want locked", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(http.MethodPost, "/responses", "", submit); rec.Code != http.StatusConflict {
+		t.Fatalf("answer to a locked quiz = (%d, %s), want 409", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(http.MethodPut, "/quizzes/qz_1/state", "secret", `{"state":"active"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("reopen a locked quiz = (%d, %s), want 409", rec.Code, rec.Body.String())
+	}
+}
+
+// writeMaskedFrame sends a client frame, which RFC 6455 requires be masked.
+func writeMaskedFrame(t *testing.T, conn net.Conn, opcode byte, payload []byte) {
+	t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := append([]byte{0x80 | opcode, 0x80 | byte(len(payload))}, mask[:]...)
+	for idx, b := range payload {
+		frame = append(frame, b^mask[idx%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+}
+
+// readServerFrame reads one unmasked frame with a short payload.
+func readServerFrame(t *testing.T, reader *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		t.Fatalf("read frame header: %v", err)
+	}
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var extended [2]byte
+		if _, err := io.ReadFull(reader, extended[:]); err != nil {
+			t.Fatalf("read frame length: %v", err)
+		}
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatalf("read frame payload: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+func TestHandleLeaderboardWebSocketSendsSnapshotAndClosesOnShutdown(t *testing.T) {
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}}
+	service := quiz.NewService(repo, &acceptingAttemptRepo{}, nil)
+	server := httptest.NewServer(NewRouterWithOptions(service, nil, RouterOptions{CompressMinBytes: 1, Debug: true}))
+	defer server.Close()
+
+	response, err := server.Client().Get(server.URL + "/quizzes/qz_1/leaderboard/ws")
+	if err != nil || response.StatusCode != http.StatusUpgradeRequired {
+		t.Fatalf("plain GET = (%v, %v), want 426", response, err)
+	}
+	response.Body.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprint(conn, "GET /quizzes/qz_1/leaderboard/ws HTTP/1.1\r\nHost: quiz\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Accept-Encoding: gzip\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	handshake, err := http.ReadResponse(reader, nil)
+	if err != nil || handshake.StatusCode != http.StatusSwitchingProtocols || handshake.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake = (%v, %v), want 101 with the RFC 6455 sample accept key", handshake, err)
+	}
+
+	opcode, payload := readServerFrame(t, reader)
+	var snapshot websocketLeaderboardEvent
+	if err := json.Unmarshal(payload, &snapshot); err != nil || opcode != websocketOpText || snapshot.Type != quiz.LeaderboardEventSnapshot || snapshot.ID == "" {
+		t.Fatalf("first message = (%d, %s), want a snapshot text frame with an id", opcode, payload)
+	}
+
+	writeMaskedFrame(t, conn, websocketOpPing, []byte("hi"))
+	if opcode, payload := readServerFrame(t, reader); opcode != websocketOpPong || string(payload) != "hi" {
+		t.Fatalf("ping answer = (%d, %q), want pong hi", opcode, payload)
+	}
+
+	service.CloseLeaderboardStreams()
+	opcode, payload = readServerFrame(t, reader)
+	var closing websocketLeaderboardEvent
+	if err := json.Unmarshal(payload, &closing); err != nil || opcode != websocketOpText || closing.Type != quiz.LeaderboardEventClosing || closing.ID != "" {
+		t.Fatalf("after shutdown = (%d, %s), want a closing event without an id", opcode, payload)
+	}
+	if opcode, payload := readServerFrame(t, reader); opcode != websocketOpClose || binary.BigEndian.Uint16(payload) != websocketCloseGoingAway {
+		t.Fatalf("last frame = (%d, %v), want a going-away close", opcode, payload)
+	}
+	writeMaskedFrame(t, conn, websocketOpClose, binary.BigEndian.AppendUint16(nil, websocketCloseGoingAway))
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Fatalf("after the close handshake read = %v, want EOF", err)
+	}
 }
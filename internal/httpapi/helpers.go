@@ -1,11 +1,15 @@
 package httpapi
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"quiz-app/internal/quiz"
 )
@@ -14,8 +18,105 @@ func writeServiceError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, quiz.ErrQuizNotFound):
 		writeJSON(w, http.StatusNotFound, errorResponse{Error: "quiz not found"})
+	case errors.Is(err, quiz.ErrQuestionNotFound):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "question not found in quiz"})
+	case errors.Is(err, quiz.ErrUnknownCategory):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrUnknownQuestion):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "question not found"})
+	case errors.Is(err, quiz.ErrNoBookmarks):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: "no bookmarked questions to practice"})
+	case errors.Is(err, quiz.ErrNotAdaptive), errors.Is(err, quiz.ErrAdaptiveQuiz), errors.Is(err, quiz.ErrOutOfSequence):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrNotRefetchable):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidQuestion):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidCompletionWatch):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrStreamsClosed):
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrQueryTimeout):
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "store is busy; try again shortly"})
+	case errors.Is(err, quiz.ErrProviderBusy):
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "question provider is busy; try again shortly"})
+	case errors.Is(err, quiz.ErrInvalidDifficultyMix), errors.Is(err, quiz.ErrInvalidQuestionFilter):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrNotEnoughQuestions):
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "the question provider does not have that many questions for this category, difficulty, and type"})
+	case errors.Is(err, quiz.ErrBankTooSmall):
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidTag):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidPreferences):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidQuizStatsQuery):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidLeaderboardSettings):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrResultsNotFinal):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrNotFlagged):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidRetirementStatus):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidActingAdmin):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrQuizClosed):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidQuizState):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrQuizLocked), errors.Is(err, quiz.ErrQuestionAnswered), errors.Is(err, quiz.ErrQuestionConflict):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidHost):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrHostForbidden):
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidRoster):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrNotOnRoster):
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrUnknownJoinCode):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrIdentityDisabled):
+		writeJSON(w, http.StatusNotImplemented, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidEmail), errors.Is(err, quiz.ErrInvalidIdentityCode):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrIdentityTaken):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidSigningKey):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrPlayerTokenRequired):
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidPassword):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrAccountExists):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidCredentials), errors.Is(err, quiz.ErrInvalidAccountToken), errors.Is(err, quiz.ErrLoginRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="quiz-player"`)
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrAccountMismatch):
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrInvalidReport):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrNotificationsDisabled):
+		writeJSON(w, http.StatusNotImplemented, errorResponse{Error: "notifications are not configured"})
 	case errors.Is(err, quiz.ErrInvalidUsername):
 		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "username is required to link responses to leaderboard"})
+	case errors.Is(err, quiz.ErrUnsupported):
+		writeJSON(w, http.StatusNotImplemented, errorResponse{Error: "operation not supported by configured store"})
+	case errors.Is(err, quiz.ErrBatchTooLarge):
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorResponse{Error: err.Error()})
+	case errors.Is(err, quiz.ErrRateLimited):
+		var limited *quiz.RateLimitError
+		if errors.As(err, &limited) {
+			seconds := int(math.Ceil(limited.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+		}
+		writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: err.Error()})
 	default:
 		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "request failed"})
 	}
@@ -28,22 +129,89 @@ func toQuestionResponses(questions []quiz.Question, attemptScores map[string]flo
 			QuestionID:    question.QuestionID,
 			Question:      question.Question,
 			Options:       question.Options,
+			Type:          question.Type,
 			AttemptStatus: "not_attempted",
 		}
 		if includeCorrectIndex {
 			correctIndex := question.CorrectIndex
 			item.CorrectIndex = &correctIndex
+			item.CorrectIndexes = question.CorrectIndexes
 		}
 		if score, ok := attemptScores[question.QuestionID]; ok {
 			scoreCopy := score
 			item.AttemptScore = &scoreCopy
 			item.AttemptStatus = "already_attempted"
 		}
+		if question.Voided {
+			item.Voided = true
+			item.AttemptStatus = "voided"
+		}
 		response = append(response, item)
 	}
 	return response
 }
 
+// scoringPolicy is the service's scoring policy as served by this API.
+func (a *API) scoringPolicy() scoringPolicyResponse {
+	response := toScoringPolicyResponse(a.service.ScoringPolicy())
+	response.ServerScored = a.serverScoring
+	return response
+}
+
+func toScoringPolicyResponse(policy quiz.ScoringPolicy) scoringPolicyResponse {
+	response := scoringPolicyResponse{
+		CorrectPoints:       policy.CorrectPoints,
+		IncorrectPoints:     policy.IncorrectPoints,
+		AttemptsPerQuestion: policy.AttemptsPerQuestion,
+		RevealPolicy:        string(policy.RevealPolicy),
+	}
+	if bonus := policy.SpeedBonus; bonus.Enabled() {
+		response.SpeedBonus = &speedBonusResponse{
+			MaxPoints:     bonus.MaxPoints,
+			WindowSeconds: bonus.Window.Seconds(),
+			Curve:         string(bonus.Curve),
+		}
+	}
+	return response
+}
+
+// toQuizOriginResponse returns nil for quizzes without a recorded origin.
+func toQuizOriginResponse(origin quiz.QuizOrigin) *quizOriginResponse {
+	if origin.Provider == "" {
+		return nil
+	}
+	return &quizOriginResponse{
+		Provider:      origin.Provider,
+		FallbackFrom:  origin.FallbackFrom,
+		DifficultyMix: origin.DifficultyMix,
+		Seed:          origin.Seed,
+		Category:      origin.Category,
+		Type:          origin.QuestionType,
+	}
+}
+
+// parseQuestionFilter reads the category, difficulty, and type a fetched quiz
+// is narrowed to into options. category is an OpenTriviaDB ID or name.
+func parseQuestionFilter(options *quiz.QuizOptions, category, difficulty, questionType string) error {
+	var err error
+	if options.Category, err = quiz.ParseQuestionCategory(category); err != nil {
+		return err
+	}
+	if options.Difficulty, err = quiz.ParseDifficulty(difficulty); err != nil {
+		return err
+	}
+	options.QuestionType, err = quiz.ParseQuestionType(questionType)
+	return err
+}
+
+// optionalTime maps the zero time to nil so omitempty drops it from JSON.
+func optionalTime(value time.Time) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	return &value
+}
+
 func parseBoolParam(r *http.Request, key string) bool {
 	value := strings.ToLower(strings.TrimSpace(r.URL.Query().Get(key)))
 	return value == "1" || value == "true" || value == "yes"
@@ -62,6 +230,50 @@ func parseIntParam(r *http.Request, key string, defaultValue int) (int, error) {
 	return parsed, nil
 }
 
+// parseOffsetParam reads a zero-based position such as a page offset; unlike
+// parseIntParam it accepts 0.
+func parseOffsetParam(r *http.Request, key string) (int, error) {
+	value := strings.TrimSpace(r.URL.Query().Get(key))
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, errors.New(key + " must be a non-negative integer")
+	}
+	return parsed, nil
+}
+
+// parseTimeParam reads an optional instant given in RFC 3339 or as a date,
+// which means midnight UTC. Missing is the zero time.
+func parseTimeParam(r *http.Request, key string) (time.Time, error) {
+	value := strings.TrimSpace(r.URL.Query().Get(key))
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.UTC(), nil
+	}
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Time{}, errors.New(key + " must be a date (2006-01-02) or an RFC 3339 time")
+}
+
+// parseLanguageParam reads the optional ?lang= translation tag.
+func parseLanguageParam(r *http.Request) (string, error) {
+	value := strings.TrimSpace(r.URL.Query().Get("lang"))
+	if value == "" {
+		return "", nil
+	}
+	language, err := quiz.ParseLanguage(value)
+	if err != nil {
+		return "", errors.New("lang must be a language tag such as es or pt-br")
+	}
+	return language, nil
+}
+
 func parseQuestionCountParam(r *http.Request, key string, defaultValue, maxValue int) (int, error) {
 	parsed, err := parseIntParam(r, key, defaultValue)
 	if err != nil {
@@ -73,6 +285,72 @@ func parseQuestionCountParam(r *http.Request, key string, defaultValue, maxValue
 	return parsed, nil
 }
 
+// questionCountWarnings explains why a created quiz has fewer questions than the
+// caller asked for: the request was capped, the provider ran short, or both.
+func questionCountWarnings(requested, capped, actual int) []apiWarning {
+	var warnings []apiWarning
+	if requested > capped {
+		warnings = append(warnings, apiWarning{
+			Code:    warningQuestionCountCapped,
+			Message: fmt.Sprintf("question_count %d exceeds the maximum; capped to %d", requested, capped),
+			Field:   "question_count",
+		})
+	}
+	if actual < capped {
+		warnings = append(warnings, apiWarning{
+			Code:    warningProviderShortfall,
+			Message: fmt.Sprintf("question provider returned %d of %d requested questions", actual, capped),
+			Field:   "question_count",
+		})
+	}
+	return warnings
+}
+
+// providerFallbackWarnings reports a quiz created from a fallback provider
+// because the configured one failed.
+func providerFallbackWarnings(origin quiz.QuizOrigin) []apiWarning {
+	if origin.FallbackFrom == "" {
+		return nil
+	}
+	return []apiWarning{{
+		Code:    warningProviderFallback,
+		Message: fmt.Sprintf("question provider %s failed or returned too few questions; questions came from %s", origin.FallbackFrom, origin.Provider),
+	}}
+}
+
+// writeFetchError reports a failed quiz creation: 503 when the provider was
+// too busy to start the fetch, 502 with message otherwise.
+func writeFetchError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, quiz.ErrProviderBusy) || errors.Is(err, quiz.ErrNotEnoughQuestions) || errors.Is(err, quiz.ErrInvalidQuestionFilter) {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusBadGateway, errorResponse{Error: message})
+}
+
+// writeServerScoringRequired refuses to score answers that would not be
+// stored, in server-scoring mode.
+func writeServerScoringRequired(w http.ResponseWriter) {
+	writeJSON(w, http.StatusForbidden, errorResponse{Error: "this server only scores answers submitted with quiz_id and username"})
+}
+
+// difficultyShortfallWarnings names each level of a difficulty mix the
+// provider could not fill.
+func difficultyShortfallWarnings(buckets []quiz.DifficultyBucket) []apiWarning {
+	var warnings []apiWarning
+	for _, bucket := range buckets {
+		if bucket.Shortfall() <= 0 {
+			continue
+		}
+		warnings = append(warnings, apiWarning{
+			Code:    warningDifficultyShortfall,
+			Message: fmt.Sprintf("question provider returned %d of %d requested %s questions", bucket.Delivered, bucket.Requested, bucket.Difficulty),
+			Field:   "difficulty_mix." + string(bucket.Difficulty),
+		})
+	}
+	return warnings
+}
+
 func normalizeQuestionCount(value, defaultValue, maxValue int) int {
 	if value <= 0 {
 		value = defaultValue
@@ -103,6 +381,68 @@ func parseLeaderboardLimit(r *http.Request, defaultValue, maxValue int) (int, er
 	return parsed, nil
 }
 
+// requireAdmin checks the request's bearer token against the configured admin
+// token and writes the error response when the caller is not allowed.
+func (a *API) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if a.adminToken == "" {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "admin endpoints are disabled"})
+		return false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(a.adminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="quiz-admin"`)
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "valid admin token required"})
+		return false
+	}
+	return true
+}
+
+// requireHost is requireAdmin for one quiz: it also accepts the host token of
+// any host listed on quizID. The returned host is the zero value when the
+// caller used the admin token.
+func (a *API) requireHost(w http.ResponseWriter, r *http.Request, quizID string) (quiz.QuizHost, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	if a.adminToken != "" && ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.adminToken)) == 1 {
+		return quiz.QuizHost{}, true
+	}
+	if ok && token != "" && a.service != nil {
+		host, err := a.service.AuthenticateHost(r.Context(), strings.TrimSpace(quizID), token)
+		if err == nil {
+			return host, true
+		}
+		if !errors.Is(err, quiz.ErrInvalidHostToken) {
+			writeServiceError(w, err)
+			return quiz.QuizHost{}, false
+		}
+	}
+	if a.adminToken == "" && !ok {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "admin endpoints are disabled"})
+		return quiz.QuizHost{}, false
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="quiz-admin"`)
+	writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "valid admin or host token required"})
+	return quiz.QuizHost{}, false
+}
+
+// decodeJSONBody decodes the request body into dst, reading at most
+// maxRequestBodyBytes.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	return json.NewDecoder(r.Body).Decode(dst)
+}
+
+// writeBodyError reports a decodeJSONBody failure.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorResponse{Error: fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit)})
+		return
+	}
+	writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+}
+
 func writeMethodNotAllowed(w http.ResponseWriter, allowedMethod string) {
 	w.Header().Set("Allow", allowedMethod)
 	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
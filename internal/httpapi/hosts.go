@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	"quiz-app/internal/quiz"
+)
+
+// HandleHosts lists a quiz's hosts or adds one. POST adds a co-host, or with
+// role=owner hands ownership over, which only the owner and the admin may do;
+// re-adding a host replaces their token, which only the admin and that host
+// may do. The response carries the new host's token, which is shown only once.
+// Changes are recorded in the quiz's audit log under the calling host's
+// username, or under admin when the admin token is used.
+func (a *API) HandleHosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPost)
+		return
+	}
+	caller, ok := a.requireHost(w, r, r.PathValue("quiz_id"))
+	if !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	response := hostsResponse{QuizID: quizID}
+	if r.Method == http.MethodPost {
+		var request hostRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		role, err := quiz.ParseHostRole(strings.TrimSpace(request.Role))
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		actor := caller.Username
+		if actor == "" {
+			actor = strings.TrimSpace(request.Admin)
+		}
+		added, token, err := a.service.AddHost(r.Context(), quizID, caller, actor, request.Username, role)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		response.Added = &addedHostResponse{hostResponseEntry: newHostResponseEntry(added), HostToken: token}
+	}
+
+	hosts, err := a.service.Hosts(r.Context(), quizID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	response.Hosts = make([]hostResponseEntry, 0, len(hosts))
+	for _, host := range hosts {
+		response.Hosts = append(response.Hosts, newHostResponseEntry(host))
+	}
+	status := http.StatusOK
+	if response.Added != nil {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, response)
+}
+
+func newHostResponseEntry(host quiz.QuizHost) hostResponseEntry {
+	return hostResponseEntry{Username: host.Username, Role: string(host.Role), AddedBy: host.AddedBy, AddedAt: host.AddedAt}
+}
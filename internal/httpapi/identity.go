@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HandleIdentity starts an email verification for a username (POST) or reports
+// whether the username is verified (GET). The email address is never returned:
+// usernames are public, and this endpoint needs no token.
+func (a *API) HandleIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	username := strings.TrimSpace(r.PathValue("username"))
+	if r.Method == http.MethodPost {
+		var request identityRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		expiresAt, err := a.service.StartIdentityVerification(r.Context(), username, request.Email)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, identityChallengeResponse{ExpiresAt: expiresAt})
+		return
+	}
+
+	identity, err := a.service.GetIdentity(r.Context(), username)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, identityResponse{
+		Username:   identity.Username,
+		Verified:   identity.Verified(),
+		VerifiedAt: optionalTime(identity.VerifiedAt),
+	})
+}
+
+// HandleVerifyIdentity completes a verification. POST takes the emailed code
+// or link token in the body; GET takes the link token from the query string,
+// so the magic link in the email works when opened directly. Either way the
+// response carries the player token, which is shown only this once.
+func (a *API) HandleVerifyIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	var request verifyIdentityRequest
+	if r.Method == http.MethodPost {
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+	} else {
+		request.Token = r.URL.Query().Get("token")
+	}
+	if strings.TrimSpace(request.Code) == "" && strings.TrimSpace(request.Token) == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "code or token is required"})
+		return
+	}
+
+	identity, playerToken, err := a.service.VerifyIdentity(r.Context(), r.PathValue("username"), request.Code, request.Token)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, identityResponse{
+		Username:    identity.Username,
+		Verified:    true,
+		VerifiedAt:  optionalTime(identity.VerifiedAt),
+		PlayerToken: playerToken,
+	})
+}
+
+// HandleSigningKey registers a public key the user's client signs offline
+// answers with. Registered and verified usernames need their tokens, as for
+// submissions; registering the same key again returns the first registration.
+func (a *API) HandleSigningKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	var request signingKeyRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	username, err := a.service.AuthenticateSubmitter(r.Context(), r.PathValue("username"), a.playerCredentials(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	key, created, err := a.service.RegisterSigningKey(r.Context(), username, request.PublicKey)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, signingKeyResponse{
+		Username:     key.Username,
+		KeyID:        key.KeyID,
+		RegisteredAt: key.RegisteredAt,
+	})
+}
@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"quiz-app/internal/questionimport"
+	"quiz-app/internal/quiz"
+)
+
+// HandleImportQuiz creates a quiz from a question bank file in Aiken, GIFT, or
+// Moodle XML. The body is the file itself; ?format= names its format, which is
+// detected when omitted. Questions that cannot be imported are listed with
+// their line numbers, and the rest become the quiz. With ?dry_run=true nothing
+// is created.
+func (a *API) HandleImportQuiz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	var format questionimport.Format
+	if value := strings.TrimSpace(r.URL.Query().Get("format")); value != "" {
+		parsed, err := questionimport.ParseFormat(value)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		format = parsed
+	}
+
+	defer r.Body.Close()
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	result, err := questionimport.Parse(data, format)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	response := importQuizResponse{
+		Format:        string(result.Format),
+		QuestionCount: len(result.Questions),
+	}
+	for _, lineErr := range result.Errors {
+		response.Errors = append(response.Errors, importErrorResponse{Line: lineErr.Line, Error: lineErr.Message})
+	}
+	switch {
+	case len(result.Questions) == 0:
+		response.Error = "no questions could be imported"
+		writeJSON(w, http.StatusBadRequest, response)
+		return
+	case len(result.Questions) > maxQuestionCount:
+		response.Error = fmt.Sprintf("the file has %d importable questions; at most %d are allowed per quiz", len(result.Questions), maxQuestionCount)
+		writeJSON(w, http.StatusBadRequest, response)
+		return
+	case parseBoolParam(r, "dry_run"):
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	metadata, err := a.service.CreateCustomQuiz(r.Context(), result.Questions, quiz.CustomQuizOptions{
+		Author:   strings.TrimSpace(r.URL.Query().Get("author")),
+		Practice: parseBoolParam(r, "practice"),
+	})
+	if err != nil {
+		if errors.Is(err, quiz.ErrInvalidQuestion) {
+			response.Error = err.Error()
+			writeJSON(w, http.StatusBadRequest, response)
+			return
+		}
+		writeServiceError(w, err)
+		return
+	}
+	a.rememberQuestions(result.Questions)
+
+	response.Quiz = &createQuizResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: metadata.QuestionCount,
+		CreatedAt:     metadata.CreatedAt,
+		State:         a.service.QuizState(metadata),
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Origin:        toQuizOriginResponse(metadata.Origin),
+		Practice:      metadata.Practice,
+	}
+	for _, question := range result.Questions {
+		response.Quiz.QuestionIDs = append(response.Quiz.QuestionIDs, question.QuestionID)
+		response.Quiz.ContentHashes = append(response.Quiz.ContentHashes, a.service.ContentHash(question))
+	}
+	writeJSON(w, http.StatusCreated, response)
+}
@@ -0,0 +1,123 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// HandleQuizState reports a quiz's lifecycle state and deadline. PUT lets a
+// host activate a draft, move it back to draft, lock it, or change when it
+// closes; a locked quiz cannot change.
+func (a *API) HandleQuizState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPut)
+		return
+	}
+	if r.Method == http.MethodPut {
+		if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+			return
+		}
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	var (
+		metadata quiz.QuizMetadata
+		err      error
+	)
+	if r.Method == http.MethodPut {
+		var request quizStateRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if request.ClearClosesAt && request.ClosesAt != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "closes_at is not allowed with clear_closes_at"})
+			return
+		}
+		change := quiz.QuizStateChange{ClosesAt: request.ClosesAt}
+		if request.ClearClosesAt {
+			change.ClosesAt = &time.Time{}
+		}
+		if strings.TrimSpace(request.State) != "" {
+			if change.State, err = quiz.ParseQuizState(request.State); err != nil {
+				writeServiceError(w, err)
+				return
+			}
+		}
+		metadata, err = a.service.SetQuizState(r.Context(), quizID, change)
+	} else {
+		metadata, err = a.service.EnsureQuiz(r.Context(), quizID, false, 0)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, quizStateResponse{
+		QuizID:   metadata.QuizID,
+		State:    a.service.QuizState(metadata),
+		ClosesAt: optionalTime(metadata.ClosesAt),
+	})
+}
+
+// HandleRemoveQuiz takes a quiz out of service. By default it archives the
+// quiz, keeping its leaderboard and results; ?mode=delete removes the quiz
+// and everything stored for it instead.
+func (a *API) HandleRemoveQuiz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeMethodNotAllowed(w, http.MethodDelete)
+		return
+	}
+	if !a.requireAdmin(w, r) {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	var (
+		removal quiz.QuizRemoval
+		err     error
+		mode    = strings.ToLower(strings.TrimSpace(r.URL.Query().Get("mode")))
+	)
+	switch mode {
+	case "", "archive":
+		mode = "archive"
+		removal, err = a.service.ArchiveQuiz(r.Context(), quizID)
+	case "delete":
+		removal, err = a.service.DeleteQuiz(r.Context(), quizID)
+	default:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "mode must be archive or delete"})
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, removeQuizResponse{
+		QuizID:     removal.QuizID,
+		Mode:       mode,
+		Attempts:   removal.Attempts,
+		ArchivedAt: optionalTime(removal.ArchivedAt),
+	})
+}
@@ -0,0 +1,516 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"quiz-app/internal/bundles"
+	"quiz-app/internal/quiz"
+)
+
+//go:generate go test -run TestOpenAPIDocumentIsCurrent -update .
+
+// The OpenAPI document is generated from the route registry and the payload
+// table below, with schemas built by reflection from the handlers' own
+// request and response types, so it cannot describe a field the handlers do
+// not send. docs/openapi.json is a committed copy; a test fails when it no
+// longer matches, and running go generate refreshes it.
+
+// openAPIPath serves the document.
+const openAPIPath = "/openapi.json"
+
+// operationPayload describes the bodies of one route and method.
+type operationPayload struct {
+	// query names the query parameters the handler reads.
+	query []string
+	// request is a value of the JSON request body type, nil for no body.
+	request any
+	// optionalBody marks a request body that may be omitted.
+	optionalBody bool
+	// requestContent is the content type of a request body that is not
+	// JSON; it is sent as a string.
+	requestContent string
+	// status is the success status; statuses lists any others sharing
+	// the response body.
+	status   int
+	statuses []int
+	// response is a value of the JSON response body type, nil for no body.
+	response any
+	// responseContent is the content type of a response body that is not
+	// JSON; it is described as a string.
+	responseContent string
+}
+
+// operationPayloads holds one entry per route and method in routeTable,
+// keyed "METHOD pattern". Errors are errorResponse unless noted in the
+// route's docs, so they are not listed.
+var operationPayloads = map[string]operationPayload{
+	"GET /questions":                              {query: []string{"quiz_id", "create_if_missing", "question_count", "category", "difficulty", "type", "username", "include_correct", "from", "lang"}, status: http.StatusOK, response: questionsResponse{}},
+	"GET /questions/search":                       {query: []string{"q", "limit"}, status: http.StatusOK, response: questionSearchResponse{}},
+	"GET /questions/{question_id}/tags":           {status: http.StatusOK, response: questionTagsResponse{}},
+	"PUT /questions/{question_id}/tags":           {request: questionTagsRequest{}, status: http.StatusOK, response: questionTagsResponse{}},
+	"POST /questions/{question_id}/reports":       {request: questionReportRequest{}, status: http.StatusCreated, response: questionReportResponse{}},
+	"POST /bank/questions":                        {request: bankQuestionsRequest{}, status: http.StatusCreated, response: bankQuestionsResponse{}},
+	"GET /bank/stats":                             {status: http.StatusOK, response: bankStatsResponse{}},
+	"GET /authors/{author}/questions/performance": {status: http.StatusOK, response: authorPerformanceResponse{}},
+
+	"POST /quizzes":                                        {request: createQuizRequest{}, optionalBody: true, status: http.StatusCreated, response: createQuizResponse{}},
+	"GET /quizzes/active":                                  {query: []string{"limit"}, status: http.StatusOK, response: activeQuizzesResponse{}},
+	"POST /quizzes/from-bank":                              {request: bankQuizRequest{}, optionalBody: true, status: http.StatusCreated, response: bankQuizResponse{}},
+	"POST /quizzes/import":                                 {query: []string{"format", "author", "practice", "dry_run"}, requestContent: "text/plain", status: http.StatusCreated, statuses: []int{http.StatusOK}, response: importQuizResponse{}},
+	"POST /quizzes/import-bundle":                          {request: quizBundle{}, status: http.StatusCreated, response: createQuizResponse{}},
+	"GET /quizzes/daily":                                   {status: http.StatusOK, response: createQuizResponse{}},
+	"DELETE /quizzes/{quiz_id}":                            {query: []string{"mode"}, status: http.StatusOK, response: removeQuizResponse{}},
+	"GET /quizzes/{quiz_id}/state":                         {status: http.StatusOK, response: quizStateResponse{}},
+	"PUT /quizzes/{quiz_id}/state":                         {request: quizStateRequest{}, status: http.StatusOK, response: quizStateResponse{}},
+	"GET /quizzes/{quiz_id}/roster":                        {query: []string{"format"}, status: http.StatusOK, response: rosterResponse{}},
+	"PUT /quizzes/{quiz_id}/roster":                        {request: rosterRequest{}, status: http.StatusOK, response: rosterResponse{}},
+	"GET /quizzes/{quiz_id}/events":                        {status: http.StatusOK, responseContent: "text/event-stream"},
+	"POST /quizzes/{quiz_id}/join":                         {request: joinQuizRequest{}, status: http.StatusOK, response: joinQuizResponse{}},
+	"POST /quizzes/{quiz_id}/rematch":                      {request: rematchRequest{}, optionalBody: true, status: http.StatusCreated, response: createQuizResponse{}},
+	"GET /quizzes/{quiz_id}/next":                          {query: []string{"username", "lang"}, status: http.StatusOK, response: nextQuestionResponse{}},
+	"GET /quizzes/{quiz_id}/attempts/{username}":           {status: http.StatusOK, response: attemptReviewResponse{}},
+	"GET /quizzes/{quiz_id}/answer-key":                    {status: http.StatusOK, response: answerKeyResponse{}},
+	"GET /quizzes/{quiz_id}/answer-positions":              {status: http.StatusOK, response: answerPositionsResponse{}},
+	"POST /quizzes/{quiz_id}/answer-positions":             {status: http.StatusOK, response: answerPositionsResponse{}},
+	"GET /quizzes/{quiz_id}/bundle":                        {query: []string{"answers"}, status: http.StatusOK, response: quizBundle{}},
+	"GET /quizzes/{quiz_id}/audit":                         {status: http.StatusOK, response: auditLogResponse{}},
+	"GET /quizzes/{quiz_id}/hosts":                         {status: http.StatusOK, response: hostsResponse{}},
+	"POST /quizzes/{quiz_id}/hosts":                        {request: hostRequest{}, status: http.StatusCreated, response: hostsResponse{}},
+	"GET /quizzes/{quiz_id}/serves":                        {status: http.StatusOK, response: serveLogResponse{}},
+	"POST /quizzes/{quiz_id}/questions/{question_id}/void": {status: http.StatusOK, response: voidQuestionResponse{}},
+	"POST /quizzes/{quiz_id}/webhooks":                     {request: completionWebhookRequest{}, status: http.StatusCreated, response: completionWebhookResponse{}},
+	"GET /stats/overview":                                  {status: http.StatusOK, response: statsOverviewResponse{}},
+
+	"POST /responses": {request: responsesRequest{}, status: http.StatusOK, response: responsesResponse{}},
+	"POST /quizzes/{quiz_id}/responses/on-behalf": {request: onBehalfRequest{}, status: http.StatusOK, response: responsesResponse{}},
+	"POST /bank/evaluate":                         {request: bankEvaluateRequest{}, status: http.StatusOK, response: responsesResponse{}},
+
+	"GET /quizzes/{quiz_id}/leaderboard":          {query: []string{"limit", "category"}, status: http.StatusOK, response: leaderboardResponse{}},
+	"GET /quizzes/{quiz_id}/leaderboard/stream":   {query: []string{"last_event_id"}, status: http.StatusOK, responseContent: "text/event-stream"},
+	"GET /quizzes/{quiz_id}/leaderboard/ws":       {query: []string{"last_event_id"}, status: http.StatusSwitchingProtocols},
+	"GET /quizzes/{quiz_id}/leaderboard/settings": {status: http.StatusOK, response: leaderboardSettingsResponse{}},
+	"PUT /quizzes/{quiz_id}/leaderboard/settings": {request: leaderboardSettingsRequest{}, status: http.StatusOK, response: leaderboardSettingsResponse{}},
+	"GET /leaderboard/global":                     {query: []string{"limit", "order", "since", "until"}, status: http.StatusOK, response: globalLeaderboardResponse{}},
+	"GET /quizzes/{quiz_id}/results.json":         {status: http.StatusOK, response: quiz.QuizResults{}},
+
+	"GET /admin/quizzes":                    {query: []string{"limit", "offset", "sort", "order"}, status: http.StatusOK, response: adminQuizzesResponse{}},
+	"GET /admin/bundles":                    {status: http.StatusOK, response: bundlesResponse{}},
+	"POST /admin/bundles/{name}":            {status: http.StatusOK, response: bundleResponse{}},
+	"GET /admin/pool/stats":                 {status: http.StatusOK, response: bundles.Stats{}},
+	"GET /admin/retirements":                {query: []string{"status"}, status: http.StatusOK, response: retirementQueueResponse{}},
+	"POST /admin/retirements/{question_id}": {request: retirementDecisionRequest{}, status: http.StatusOK, response: retirementReviewResponse{}},
+	"GET /debug/vars":                       {status: http.StatusOK, response: map[string]any{}},
+	"GET /openapi.json":                     {status: http.StatusOK, response: map[string]any{}},
+
+	"POST /users/register":                             {request: accountRequest{}, status: http.StatusCreated, response: accountResponse{}},
+	"POST /users/login":                                {request: accountRequest{}, status: http.StatusOK, response: loginResponse{}},
+	"GET /users/{username}/bookmarks":                  {status: http.StatusOK, response: bookmarksResponse{}},
+	"POST /users/{username}/bookmarks":                 {request: bookmarkRequest{}, status: http.StatusCreated, response: bookmarksResponse{}},
+	"DELETE /users/{username}/bookmarks/{question_id}": {status: http.StatusNoContent},
+	"POST /users/{username}/bookmarks/practice":        {request: practiceQuizRequest{}, optionalBody: true, status: http.StatusCreated, response: createQuizResponse{}},
+	"GET /users/{username}/profile":                    {status: http.StatusOK, response: profileResponse{}},
+	"PUT /users/{username}/profile":                    {request: profileRequest{}, status: http.StatusOK, response: profileResponse{}},
+	"GET /users/{username}/preferences":                {status: http.StatusOK, response: preferencesResponse{}},
+	"PUT /users/{username}/preferences":                {request: preferencesRequest{}, status: http.StatusOK, response: preferencesResponse{}},
+	"GET /users/{username}/stats":                      {status: http.StatusOK, response: userStatsResponse{}},
+	"GET /users/{username}/attempts":                   {query: []string{"limit"}, status: http.StatusOK, response: userAttemptsResponse{}},
+	"GET /users/{username}/identity":                   {status: http.StatusOK, response: identityResponse{}},
+	"POST /users/{username}/identity":                  {request: identityRequest{}, status: http.StatusAccepted, response: identityChallengeResponse{}},
+	"GET /users/{username}/identity/verify":            {query: []string{"token"}, status: http.StatusOK, response: identityResponse{}},
+	"POST /users/{username}/identity/verify":           {request: verifyIdentityRequest{}, status: http.StatusOK, response: identityResponse{}},
+	"POST /users/{username}/signing-keys":              {request: signingKeyRequest{}, status: http.StatusCreated, statuses: []int{http.StatusOK}, response: signingKeyResponse{}},
+}
+
+// HandleOpenAPI serves the OpenAPI 3.1 description of every route.
+func (a *API) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	document, err := a.openAPI()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "OpenAPI document unavailable"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(document)
+}
+
+// openAPIJSON is the indented document, built once.
+var openAPIJSON = sync.OnceValues(func() ([]byte, error) {
+	document, err := OpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(encoded, '\n'), nil
+})
+
+// OpenAPI builds the OpenAPI 3.1 document for the route registry. It fails
+// when a route has no payload entry, or an entry no route.
+func OpenAPI() (map[string]any, error) {
+	builder := &schemaBuilder{components: make(map[string]any), types: make(map[string]reflect.Type), requestTypes: make(map[reflect.Type]bool)}
+	for _, payload := range operationPayloads {
+		if payload.request != nil {
+			builder.markRequest(reflect.TypeOf(payload.request))
+		}
+	}
+	paths := make(map[string]any)
+	used := make(map[string]bool)
+	operationIDs := make(map[string]string)
+
+	for _, route := range routeTable {
+		item := make(map[string]any)
+		for _, method := range route.Methods {
+			key := method + " " + route.Pattern
+			payload, ok := operationPayloads[key]
+			if !ok {
+				return nil, fmt.Errorf("openapi: no payload entry for %s", key)
+			}
+			used[key] = true
+			operation, err := builder.operation(route, method, payload)
+			if err != nil {
+				return nil, fmt.Errorf("openapi: %s: %w", key, err)
+			}
+			id := operation["operationId"].(string)
+			if other, ok := operationIDs[id]; ok {
+				return nil, fmt.Errorf("openapi: operation ID %s is used by %s and %s", id, other, key)
+			}
+			operationIDs[id] = key
+			item[strings.ToLower(method)] = operation
+		}
+		paths[route.Pattern] = item
+	}
+	for key := range operationPayloads {
+		if !used[key] {
+			return nil, fmt.Errorf("openapi: payload entry %s matches no route", key)
+		}
+	}
+
+	errorSchema, err := builder.schema(reflect.TypeOf(errorResponse{}))
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]any, 0, len(RouteGroups))
+	for _, group := range RouteGroups {
+		tags = append(tags, map[string]any{"name": string(group)})
+	}
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "quiz-service",
+			"version":     "1",
+			"description": "Quiz creation, question serving, answer scoring, and leaderboards. Behaviour is described in docs/api.md.",
+		},
+		"tags":  tags,
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": builder.components,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "The request failed; error says why.",
+					"content":     map[string]any{"application/json": map[string]any{"schema": errorSchema}},
+				},
+			},
+			"securitySchemes": map[string]any{
+				"bearerToken": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "The admin token, or for host routes a host token of one of the quiz's hosts.",
+				},
+			},
+		},
+	}, nil
+}
+
+var pathParameter = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+func (b *schemaBuilder) operation(route Route, method string, payload operationPayload) (map[string]any, error) {
+	operation := map[string]any{
+		"operationId": operationID(route, method),
+		"summary":     route.Summary,
+		"tags":        []any{string(route.Group)},
+	}
+
+	var parameters []any
+	for _, match := range pathParameter.FindAllStringSubmatch(route.Pattern, -1) {
+		parameters = append(parameters, map[string]any{"name": match[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+	}
+	for _, name := range payload.query {
+		parameters = append(parameters, map[string]any{"name": name, "in": "query", "schema": map[string]any{"type": "string"}})
+	}
+	if parameters != nil {
+		operation["parameters"] = parameters
+	}
+
+	switch {
+	case payload.request != nil:
+		schema, err := b.schema(reflect.TypeOf(payload.request))
+		if err != nil {
+			return nil, err
+		}
+		operation["requestBody"] = map[string]any{
+			"required": !payload.optionalBody,
+			"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+		}
+	case payload.requestContent != "":
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{payload.requestContent: map[string]any{"schema": map[string]any{"type": "string"}}},
+		}
+	}
+
+	var success map[string]any
+	switch {
+	case payload.response != nil:
+		schema, err := b.schema(reflect.TypeOf(payload.response))
+		if err != nil {
+			return nil, err
+		}
+		success = map[string]any{"application/json": map[string]any{"schema": schema}}
+	case payload.responseContent != "":
+		success = map[string]any{payload.responseContent: map[string]any{"schema": map[string]any{"type": "string"}}}
+	}
+	responses := map[string]any{"default": map[string]any{"$ref": "#/components/responses/Error"}}
+	for _, status := range append([]int{payload.status}, payload.statuses...) {
+		response := map[string]any{"description": http.StatusText(status)}
+		if success != nil {
+			response["content"] = success
+		}
+		responses[fmt.Sprint(status)] = response
+	}
+	operation["responses"] = responses
+
+	if needsToken(route.Scope, method) {
+		operation["security"] = []any{map[string]any{"bearerToken": []any{}}}
+	}
+	return operation, nil
+}
+
+// needsToken reports whether scope asks for the admin or a host token on
+// method.
+func needsToken(scope Scope, method string) bool {
+	switch scope {
+	case ScopeAdmin, ScopeHost:
+		return true
+	case ScopeAdminWrites, ScopeHostWrites:
+		return method != http.MethodGet
+	}
+	return false
+}
+
+// operationID names an operation after its handler, such as createQuiz for
+// HandleCreateQuiz; routes with several methods add the method, as in
+// quizStatePut.
+func operationID(route Route, method string) string {
+	name := runtime.FuncForPC(reflect.ValueOf(route.handle).Pointer()).Name()
+	name = strings.TrimPrefix(name[strings.LastIndex(name, ".")+1:], "Handle")
+	if len(route.Methods) > 1 {
+		name += method[:1] + strings.ToLower(method[1:])
+	}
+	return lowerFirst(name)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	openAPINullSchema = map[string]any{"type": "null"}
+)
+
+// schemaBuilder turns payload types into JSON schemas the way encoding/json
+// would encode them. Each named struct becomes one component, referenced
+// wherever it is used.
+type schemaBuilder struct {
+	components map[string]any
+	types      map[string]reflect.Type
+	// requestTypes holds the structs that request bodies use. Handlers
+	// accept them with fields missing or unknown ones added, so their
+	// schemas require nothing and allow anything extra.
+	requestTypes map[reflect.Type]bool
+}
+
+// markRequest adds the structs t is made of to requestTypes.
+func (b *schemaBuilder) markRequest(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		b.markRequest(t.Elem())
+	case reflect.Struct:
+		if b.requestTypes[t] || t == timeType {
+			return
+		}
+		b.requestTypes[t] = true
+		for idx := 0; idx < t.NumField(); idx++ {
+			b.markRequest(t.Field(idx).Type)
+		}
+	}
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) (map[string]any, error) {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		values, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return b.reference(t)
+	}
+	return nil, fmt.Errorf("openapi: cannot describe %s", t)
+}
+
+// reference returns a $ref to t's component, adding the component the first
+// time t is seen.
+func (b *schemaBuilder) reference(t reflect.Type) (map[string]any, error) {
+	name := componentName(t)
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if seen, ok := b.types[name]; ok {
+		if seen != t {
+			return nil, fmt.Errorf("openapi: %s and %s are both named %s", seen, t, name)
+		}
+		return ref, nil
+	}
+	b.types[name] = t
+	object, err := b.object(t)
+	if err != nil {
+		return nil, err
+	}
+	b.components[name] = object
+	return ref, nil
+}
+
+// object describes a struct's JSON fields. In responses, fields without
+// omitempty are always encoded, so they are required, and no others appear;
+// a pointer among them may be null.
+func (b *schemaBuilder) object(t reflect.Type) (map[string]any, error) {
+	properties := make(map[string]any)
+	var required []string
+	if err := b.addFields(t, properties, &required); err != nil {
+		return nil, err
+	}
+	object := map[string]any{"type": "object", "properties": properties}
+	if b.requestTypes[t] {
+		return object, nil
+	}
+	object["additionalProperties"] = false
+	if len(required) > 0 {
+		slices.Sort(required)
+		object["required"] = required
+	}
+	return object, nil
+}
+
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]any, required *[]string) error {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			// Embedded structs are flattened, as encoding/json does.
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				if err := b.addFields(fieldType, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := b.schema(fieldType)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t, field.Name, err)
+		}
+		omitEmpty := slices.Contains(strings.Split(options, ","), "omitempty")
+		if fieldType.Kind() == reflect.Pointer && !omitEmpty {
+			schema = map[string]any{"anyOf": []any{schema, openAPINullSchema}}
+		}
+		properties[name] = schema
+		if !omitEmpty {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// componentName is t's name with its first letter raised, such as
+// CreateQuizResponse. reference rejects two types with one name.
+func componentName(t reflect.Type) string {
+	return upperFirst(t.Name())
+}
+
+func upperFirst(value string) string {
+	if value == "" {
+		return value
+	}
+	runes := []rune(value)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func lowerFirst(value string) string {
+	if value == "" {
+		return value
+	}
+	runes := []rune(value)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
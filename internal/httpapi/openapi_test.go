@@ -0,0 +1,225 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"quiz-app/internal/quiz"
+)
+
+var updateOpenAPI = flag.Bool("update", false, "rewrite docs/openapi.json from the route registry")
+
+var openAPIGoldenPath = filepath.Join("..", "..", "docs", "openapi.json")
+
+// The committed document is what API consumers read; it must change whenever
+// a route or payload type does.
+func TestOpenAPIDocumentIsCurrent(t *testing.T) {
+	document, err := openAPIJSON()
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+	if *updateOpenAPI {
+		if err := os.WriteFile(openAPIGoldenPath, document, 0o644); err != nil {
+			t.Fatalf("write %s: %v", openAPIGoldenPath, err)
+		}
+		return
+	}
+	committed, err := os.ReadFile(openAPIGoldenPath)
+	if err != nil {
+		t.Fatalf("read %s: %v", openAPIGoldenPath, err)
+	}
+	if !bytes.Equal(committed, document) {
+		t.Fatalf("%s is out of date with the route registry or payload types; run go generate ./internal/httpapi", openAPIGoldenPath)
+	}
+}
+
+func TestOpenAPIRejectsRoutesWithoutPayloads(t *testing.T) {
+	key := "GET " + openAPIPath
+	saved := operationPayloads[key]
+	delete(operationPayloads, key)
+	_, err := OpenAPI()
+	operationPayloads[key] = saved
+	if err == nil || !strings.Contains(err.Error(), "no payload entry for "+key) {
+		t.Fatalf("OpenAPI without an entry for %s = %v, want it named", key, err)
+	}
+
+	operationPayloads["GET /retired"] = operationPayload{status: http.StatusOK}
+	_, err = OpenAPI()
+	delete(operationPayloads, "GET /retired")
+	if err == nil || !strings.Contains(err.Error(), "matches no route") {
+		t.Fatalf("OpenAPI with a stale entry = %v, want it rejected", err)
+	}
+}
+
+// Live responses must validate against the schemas the document gives them,
+// so a handler writing a body other than its listed type is caught.
+func TestOpenAPIDescribesLiveResponses(t *testing.T) {
+	question, err := quiz.NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}, questions: []quiz.Question{question}}
+	router := NewRouter(quiz.NewService(repo, acceptingAttemptRepo{}, nil), nil)
+	document, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+	// Round-trip the document so it reads like a client would read it.
+	encoded, _ := json.Marshal(document)
+	var spec map[string]any
+	if err := json.Unmarshal(encoded, &spec); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+
+	for _, tc := range []struct {
+		method, pattern, path, body string
+		status                      int
+	}{
+		{http.MethodGet, "/questions", "/questions?quiz_id=qz_1&include_correct=true", "", http.StatusOK},
+		{http.MethodPost, "/responses", "/responses", `{"quiz_id":"qz_1","username":"alice","responses":[{"question_id":"` + question.QuestionID + `","answer":"B"}]}`, http.StatusOK},
+		{http.MethodGet, "/quizzes/{quiz_id}/leaderboard", "/quizzes/qz_1/leaderboard", "", http.StatusOK},
+		{http.MethodGet, "/bank/stats", "/bank/stats", "", http.StatusOK},
+		{http.MethodGet, "/quizzes/{quiz_id}/leaderboard", "/quizzes/missing/leaderboard", "", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Fatalf("%s %s status = %d, want %d: %s", tc.method, tc.path, rec.Code, tc.status, rec.Body.String())
+		}
+		responses := lookupPath(t, spec, "paths", tc.pattern, strings.ToLower(tc.method), "responses").(map[string]any)
+		response, ok := responses[fmt.Sprint(rec.Code)]
+		if !ok {
+			response = responses["default"]
+		}
+		response = resolveRef(t, spec, response.(map[string]any))
+		schema := lookupPath(t, response, "content", "application/json", "schema")
+		var body any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s body is not JSON: %v", tc.method, tc.path, err)
+		}
+		if err := validateSchema(t, spec, schema.(map[string]any), body, "body"); err != nil {
+			t.Fatalf("%s %s does not match its schema: %v\n%s", tc.method, tc.path, err, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, openAPIPath, nil))
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), mustOpenAPIJSON(t)) {
+		t.Fatalf("GET %s = %d, want the document", openAPIPath, rec.Code)
+	}
+}
+
+func mustOpenAPIJSON(t *testing.T) []byte {
+	t.Helper()
+	document, err := openAPIJSON()
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+	return document
+}
+
+func lookupPath(t *testing.T, value any, keys ...string) any {
+	t.Helper()
+	for _, key := range keys {
+		object, ok := value.(map[string]any)
+		if !ok || object[key] == nil {
+			t.Fatalf("document has no %q under %v", key, keys)
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func resolveRef(t *testing.T, spec map[string]any, value map[string]any) map[string]any {
+	t.Helper()
+	ref, ok := value["$ref"].(string)
+	if !ok {
+		return value
+	}
+	return lookupPath(t, spec, strings.Split(strings.TrimPrefix(ref, "#/"), "/")...).(map[string]any)
+}
+
+// validateSchema checks value against the subset of JSON Schema the
+// generator emits.
+func validateSchema(t *testing.T, spec, schema map[string]any, value any, at string) error {
+	schema = resolveRef(t, spec, schema)
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, option := range anyOf {
+			if validateSchema(t, spec, option.(map[string]any), value, at) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s matches none of its schemas", at)
+	}
+
+	switch schema["type"] {
+	case nil:
+		return nil
+	case "null":
+		if value != nil {
+			return fmt.Errorf("%s = %v, want null", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s = %v, want a boolean", at, value)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s = %v, want a string", at, value)
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok || (schema["type"] == "integer" && number != float64(int64(number))) {
+			return fmt.Errorf("%s = %v, want %s", at, value, schema["type"])
+		}
+	case "array":
+		// encoding/json writes nil slices as null.
+		items, ok := value.([]any)
+		if !ok && value != nil {
+			return fmt.Errorf("%s = %v, want an array", at, value)
+		}
+		for idx, item := range items {
+			if err := validateSchema(t, spec, schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", at, idx)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			if value == nil {
+				return nil
+			}
+			return fmt.Errorf("%s = %v, want an object", at, value)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if _, ok := object[name.(string)]; !ok {
+					return fmt.Errorf("%s is missing required %s", at, name)
+				}
+			}
+		}
+		for name, field := range object {
+			fieldSchema, ok := properties[name].(map[string]any)
+			if !ok {
+				additional, ok := schema["additionalProperties"].(map[string]any)
+				if !ok {
+					return fmt.Errorf("%s has %s, which its schema does not list", at, name)
+				}
+				fieldSchema = additional
+			}
+			if err := validateSchema(t, spec, fieldSchema, field, at+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
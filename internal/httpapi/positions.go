@@ -0,0 +1,72 @@
+package httpapi
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"quiz-app/internal/quiz"
+)
+
+// HandleAnswerPositions reports how often each letter is a quiz's correct
+// answer. POST also moves correct answers of unanswered questions off
+// over-used letters, which gives the moved questions new IDs, so hosts should
+// run it before players fetch the quiz. It is refused once the quiz locks.
+func (a *API) HandleAnswerPositions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPost)
+		return
+	}
+	if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	var (
+		report quiz.AnswerPositionReport
+		err    error
+	)
+	if r.Method == http.MethodPost {
+		report, err = a.service.RebalanceAnswerPositions(r.Context(), quizID)
+	} else {
+		report, err = a.service.AnswerPositions(r.Context(), quizID)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := answerPositionsResponse{
+		QuizID:    report.QuizID,
+		Questions: report.Questions,
+		Letters:   make([]answerLetterResponse, 0, len(report.Letters)),
+		Outliers:  make([]string, 0),
+	}
+	for _, count := range report.Letters {
+		response.Letters = append(response.Letters, answerLetterResponse{Letter: count.Letter, Correct: count.Correct, Expected: count.Expected, Outlier: count.Outlier})
+		if count.Outlier {
+			response.Outliers = append(response.Outliers, count.Letter)
+		}
+	}
+	if r.Method == http.MethodPost {
+		response.Moved = make([]movedQuestionResponse, 0, len(report.Moved))
+		for from, to := range report.Moved {
+			response.Moved = append(response.Moved, movedQuestionResponse{From: from, To: to})
+		}
+		slices.SortFunc(response.Moved, func(a, b movedQuestionResponse) int { return strings.Compare(a.From, b.From) })
+		response.Kept = report.Kept
+		if response.Kept == nil {
+			response.Kept = make([]string, 0)
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HandleSubmitOnBehalf records answers an admin enters for a player, such as
+// from a paper answer sheet. The player token is not needed: the admin token
+// or a host token is, and the admin's name is kept with each answer and in the
+// audit log. A host who leaves out the name is recorded under their username.
+func (a *API) HandleSubmitOnBehalf(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	host, ok := a.requireHost(w, r, r.PathValue("quiz_id"))
+	if !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+	var request onBehalfRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if request.Responses == nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "responses is required"})
+		return
+	}
+	if len(request.Responses) > maxResponsesPerRequest {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("at most %d responses are allowed per request", maxResponsesPerRequest)})
+		return
+	}
+
+	if strings.TrimSpace(request.Admin) == "" {
+		request.Admin = host.Username
+	}
+
+	results, err := a.service.SubmitOnBehalf(r.Context(), quizID, request.Username, request.Admin, request.Responses)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, responsesResponse{Results: results})
+}
+
+// HandleAuditLog lists the admin actions taken for players in a quiz, oldest
+// first.
+func (a *API) HandleAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	entries, err := a.service.AuditEntries(r.Context(), quizID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	response := auditLogResponse{QuizID: quizID, Entries: make([]auditLogResponseEntry, 0, len(entries))}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, auditLogResponseEntry{
+			At:       entry.At,
+			Admin:    entry.Actor,
+			Action:   entry.Action,
+			Username: entry.Username,
+			Detail:   entry.Detail,
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
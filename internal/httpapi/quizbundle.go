@@ -0,0 +1,267 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+const (
+	// quizBundleFormat and quizBundleVersion identify exported quiz bundles.
+	// Bump the version when a change would make older servers misread one.
+	// Version 2 added multi-select questions and version 3 withheld ones;
+	// bundles without either are still written as version 1, which older
+	// servers read.
+	quizBundleFormat  = "quiz-app/bundle"
+	quizBundleVersion = 3
+)
+
+// HandleQuizBundle exports a quiz as a portable bundle: its questions in
+// serving order, its settings, and its leaderboard settings. Anyone may export
+// the questions alone; ?answers=true adds correct answers and feedback and
+// needs the admin token or a host token for the quiz. Voided questions are
+// left out, and questions from a restricted source are withheld: the bundle
+// names them by ID instead of copying them.
+func (a *API) HandleQuizBundle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	withAnswers := parseBoolParam(r, "answers")
+	if withAnswers {
+		if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+			return
+		}
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	metadata, questions, err := a.service.GetQuizQuestions(r.Context(), quizID, false, 0)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	// An adaptive pool is served one question at a time; listing it publicly
+	// would let players read ahead.
+	if metadata.Adaptive && !withAnswers {
+		writeServiceError(w, quiz.ErrAdaptiveQuiz)
+		return
+	}
+	settings, err := a.service.GetLeaderboardSettings(r.Context(), quizID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	bundle := quizBundle{
+		Format:          quizBundleFormat,
+		Version:         1,
+		ExportedAt:      time.Now().UTC().Truncate(time.Second),
+		SourceQuizID:    metadata.QuizID,
+		IncludesAnswers: withAnswers,
+		Settings: quizBundleSettings{
+			Practice:           metadata.Practice,
+			Adaptive:           metadata.Adaptive,
+			SecondsPerQuestion: int(metadata.QuestionTimeLimit / time.Second),
+		},
+		Questions: make([]quizBundleQuestion, 0, len(questions)),
+	}
+	if settings.DefaultLimit > 0 || settings.FreezeWindow > 0 || !settings.LocksAt.IsZero() || settings.Tiebreak != "" {
+		bundle.Settings.Leaderboard = &leaderboardSettingsRequest{
+			DefaultLimit:  settings.DefaultLimit,
+			FreezeSeconds: int(settings.FreezeWindow / time.Second),
+			LocksAt:       optionalTime(settings.LocksAt),
+			Tiebreak:      string(settings.Tiebreak),
+		}
+	}
+	for _, question := range questions {
+		if question.Voided {
+			continue
+		}
+		if !a.service.Redistributable(question) {
+			bundle.Version = quizBundleVersion
+			bundle.Questions = append(bundle.Questions, quizBundleQuestion{Withheld: true, QuestionID: question.QuestionID, Source: question.Source})
+			continue
+		}
+		item := quizBundleQuestion{
+			Question:     question.Question,
+			Options:      make([]string, 0, len(question.Options)),
+			Type:         string(question.Type),
+			Difficulty:   string(question.Difficulty),
+			Category:     question.Category,
+			Translations: question.Translations,
+			Source:       question.Source,
+		}
+		for _, option := range question.Options {
+			item.Options = append(item.Options, option.Text)
+		}
+		if question.Type == quiz.TypeMultiSelect {
+			bundle.Version = max(bundle.Version, 2)
+		}
+		if withAnswers {
+			correctIndex := question.CorrectIndex
+			item.CorrectIndex = &correctIndex
+			item.CorrectIndexes = question.CorrectIndexes
+			item.Feedback = question.Feedback
+		}
+		bundle.Questions = append(bundle.Questions, item)
+	}
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// HandleImportQuizBundle recreates a quiz from a bundle exported with answers,
+// under a new quiz ID. Withheld questions are taken from this server's store
+// and fail the import when it does not hold them. Leaderboard settings that
+// this server cannot apply are reported as warnings; the quiz is still
+// created.
+func (a *API) HandleImportQuizBundle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	var bundle quizBundle
+	defer r.Body.Close()
+	if err := decodeJSONBody(w, r, &bundle); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	switch {
+	case bundle.Format != quizBundleFormat:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("format must be %q", quizBundleFormat)})
+		return
+	case bundle.Version < 1 || bundle.Version > quizBundleVersion:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("bundle version %d is not supported; this server reads up to %d", bundle.Version, quizBundleVersion)})
+		return
+	case len(bundle.Questions) == 0:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "the bundle has no questions"})
+		return
+	case len(bundle.Questions) > maxQuestionCount:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("at most %d questions are allowed", maxQuestionCount)})
+		return
+	case bundle.Settings.SecondsPerQuestion < 0 || bundle.Settings.SecondsPerQuestion > maxSecondsPerQuestion:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("seconds_per_question must be between 0 and %d", maxSecondsPerQuestion)})
+		return
+	}
+
+	items := make([]createQuizQuestion, 0, len(bundle.Questions))
+	var withheld []string
+	for idx, item := range bundle.Questions {
+		if item.Withheld {
+			withheld = append(withheld, strings.TrimSpace(item.QuestionID))
+			continue
+		}
+		if item.CorrectIndex == nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("questions[%d]: correct_index is missing; export the bundle with answers=true", idx)})
+			return
+		}
+		items = append(items, createQuizQuestion{
+			Question:       item.Question,
+			Options:        item.Options,
+			CorrectIndex:   *item.CorrectIndex,
+			Type:           item.Type,
+			CorrectIndexes: item.CorrectIndexes,
+			Feedback:       item.Feedback,
+			Difficulty:     item.Difficulty,
+			Translations:   item.Translations,
+		})
+	}
+	built, err := buildCustomQuestions(items)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	stored := make(map[string]quiz.Question, len(withheld))
+	if len(withheld) > 0 {
+		found, err := a.service.LookupQuestions(r.Context(), withheld)
+		if err != nil && !errors.Is(err, quiz.ErrUnsupported) {
+			writeServiceError(w, err)
+			return
+		}
+		for _, question := range found {
+			stored[question.QuestionID] = question
+		}
+	}
+
+	questions := make([]quiz.Question, 0, len(bundle.Questions))
+	for idx, item := range bundle.Questions {
+		if item.Withheld {
+			question, ok := stored[strings.TrimSpace(item.QuestionID)]
+			if !ok {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("questions[%d]: %s was withheld by the exporting server (source %q) and is not stored here", idx, item.QuestionID, item.Source)})
+				return
+			}
+			questions = append(questions, question)
+			continue
+		}
+		question := built[0]
+		built = built[1:]
+		question.Category = strings.TrimSpace(item.Category)
+		question.Source = strings.TrimSpace(item.Source)
+		questions = append(questions, question)
+	}
+
+	metadata, err := a.service.CreateCustomQuiz(r.Context(), questions, quiz.CustomQuizOptions{
+		Practice: bundle.Settings.Practice,
+		Adaptive: bundle.Settings.Adaptive,
+		QuizOptions: quiz.QuizOptions{
+			QuestionTimeLimit: time.Duration(bundle.Settings.SecondsPerQuestion) * time.Second,
+		},
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	a.rememberQuestions(questions)
+
+	response := createQuizResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: metadata.QuestionCount,
+		CreatedAt:     metadata.CreatedAt,
+		State:         a.service.QuizState(metadata),
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Origin:        toQuizOriginResponse(metadata.Origin),
+		Practice:      metadata.Practice,
+		Adaptive:      metadata.Adaptive,
+
+		SecondsPerQuestion: int(metadata.QuestionTimeLimit / time.Second),
+	}
+	for _, question := range questions {
+		response.QuestionIDs = append(response.QuestionIDs, question.QuestionID)
+		response.ContentHashes = append(response.ContentHashes, a.service.ContentHash(question))
+	}
+	if leaderboard := bundle.Settings.Leaderboard; leaderboard != nil {
+		settings := quiz.LeaderboardSettings{
+			DefaultLimit: min(max(leaderboard.DefaultLimit, 0), maxLeaderboardLimit),
+			FreezeWindow: time.Duration(max(leaderboard.FreezeSeconds, 0)) * time.Second,
+			Tiebreak:     parseTiebreak(leaderboard.Tiebreak),
+		}
+		if leaderboard.LocksAt != nil {
+			settings.LocksAt = *leaderboard.LocksAt
+		}
+		if _, err := a.service.SetLeaderboardSettings(r.Context(), metadata.QuizID, settings); err != nil {
+			response.Warnings = append(response.Warnings, apiWarning{
+				Code:    warningSettingsNotApplied,
+				Message: fmt.Sprintf("leaderboard settings were not applied: %v", err),
+				Field:   "settings.leaderboard",
+			})
+		}
+	}
+	writeJSON(w, http.StatusCreated, response)
+}
@@ -0,0 +1,102 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+type globalEntryResponse struct {
+	Rank             int       `json:"rank"`
+	Username         string    `json:"username"`
+	Anonymous        bool      `json:"anonymous,omitempty"`
+	QuizzesPlayed    int       `json:"quizzes_played"`
+	AnsweredCount    int       `json:"answered_count"`
+	CorrectCount     int       `json:"correct_count"`
+	RawScore         float64   `json:"raw_score"`
+	NormalizedScore  float64   `json:"normalized_score"`
+	LastSubmissionAt time.Time `json:"last_submission_at"`
+}
+
+type globalLeaderboardResponse struct {
+	Since        *time.Time            `json:"since,omitempty"`
+	Until        *time.Time            `json:"until,omitempty"`
+	OrderBy      string                `json:"order"`
+	QuizCount    int                   `json:"quiz_count"`
+	Participants int                   `json:"participants"`
+	Leaderboard  []globalEntryResponse `json:"leaderboard"`
+}
+
+// HandleGlobalLeaderboard ranks players across quizzes, optionally only those
+// created in a season given by ?since= and ?until=. Every entry carries both
+// the raw points and the normalized total; ?order= picks which one ranks.
+func (a *API) HandleGlobalLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	limit, err := parseLeaderboardLimit(r, defaultLeaderboardLimit, maxLeaderboardLimit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	orderBy, err := quiz.ParseGlobalRanking(r.URL.Query().Get("order"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "since must be before until"})
+		return
+	}
+
+	board, err := a.service.GetGlobalLeaderboard(r.Context(), quiz.GlobalLeaderboardQuery{
+		Since:   since,
+		Until:   until,
+		OrderBy: orderBy,
+		Limit:   limit,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	items := make([]globalEntryResponse, 0, len(board.Entries))
+	for _, entry := range board.Entries {
+		items = append(items, globalEntryResponse{
+			Rank:             entry.Rank,
+			Username:         entry.Username,
+			Anonymous:        entry.Anonymous,
+			QuizzesPlayed:    entry.QuizzesPlayed,
+			AnsweredCount:    entry.AnsweredCount,
+			CorrectCount:     entry.CorrectCount,
+			RawScore:         entry.RawScore,
+			NormalizedScore:  entry.NormalizedScore,
+			LastSubmissionAt: entry.LastSubmissionAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, globalLeaderboardResponse{
+		Since:        optionalTime(board.Since),
+		Until:        optionalTime(board.Until),
+		OrderBy:      string(board.OrderBy),
+		QuizCount:    board.QuizCount,
+		Participants: board.Participants,
+		Leaderboard:  items,
+	})
+}
@@ -0,0 +1,161 @@
+package httpapi
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxIdleClientBuckets bounds limiter memory; full (idle) buckets are dropped
+// once the map grows past it.
+const maxIdleClientBuckets = 4096
+
+// RateLimit caps requests per client address to one route group.
+type RateLimit struct {
+	// PerSecond is the sustained request rate; 0 disables the limit.
+	PerSecond float64
+	// Burst is how many requests may arrive at once; 0 means one second's
+	// worth.
+	Burst int
+}
+
+// DefaultRateLimits apply to the groups -route-rate-limits leaves out. Every
+// login runs a deliberately slow password hash, so the users group is limited
+// even when nothing else is.
+var DefaultRateLimits = map[RouteGroup]RateLimit{
+	GroupUsers: {PerSecond: 1, Burst: 10},
+}
+
+// ParseRateLimits reads "group=rate[:burst],..." as taken by the
+// -route-rate-limits flag, for example "responses=5:20,quizzes=1".
+func ParseRateLimits(value string) (map[RouteGroup]RateLimit, error) {
+	limits := make(map[RouteGroup]RateLimit)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(item, "=")
+		group := RouteGroup(strings.ToLower(strings.TrimSpace(name)))
+		if !ok || !slices.Contains(RouteGroups, group) {
+			return nil, fmt.Errorf("rate limit %q: want group=rate[:burst] with a group from %v", item, RouteGroups)
+		}
+		rateText, burstText, hasBurst := strings.Cut(spec, ":")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateText), 64)
+		if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("rate limit %q: rate must be a positive number of requests per second", item)
+		}
+		limit := RateLimit{PerSecond: rate}
+		if hasBurst {
+			limit.Burst, err = strconv.Atoi(strings.TrimSpace(burstText))
+			if err != nil || limit.Burst <= 0 {
+				return nil, fmt.Errorf("rate limit %q: burst must be a positive integer", item)
+			}
+		}
+		limits[group] = limit
+	}
+	return limits, nil
+}
+
+// clientLimiter is a token bucket per client address for one route group.
+// Clients are told apart by the connection's remote address, so clients
+// behind one proxy share a bucket.
+type clientLimiter struct {
+	group RouteGroup
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*clientBucket
+}
+
+type clientBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newClientLimiter returns nil when limit is disabled.
+func newClientLimiter(group RouteGroup, limit RateLimit) *clientLimiter {
+	if limit.PerSecond <= 0 {
+		return nil
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(limit.PerSecond))
+	}
+	return &clientLimiter{
+		group:   group,
+		rate:    limit.PerSecond,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*clientBucket),
+	}
+}
+
+// middleware answers 429 with Retry-After once a client runs out of tokens.
+func (l *clientLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wait := l.take(clientAddress(r)); wait > 0 {
+			seconds := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+			writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: fmt.Sprintf("rate limit exceeded for %s requests", l.group)})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// take spends one token from key's bucket, or returns how long until one is
+// available.
+func (l *clientLimiter) take(key string) time.Duration {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		l.pruneLocked(now)
+		bucket = &clientBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	l.refillLocked(bucket, now)
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return 0
+}
+
+func (l *clientLimiter) refillLocked(bucket *clientBucket, now time.Time) {
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed.Seconds()*l.rate)
+		bucket.updated = now
+	}
+}
+
+func (l *clientLimiter) pruneLocked(now time.Time) {
+	if len(l.buckets) < maxIdleClientBuckets {
+		return
+	}
+	for key, bucket := range l.buckets {
+		l.refillLocked(bucket, now)
+		if bucket.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,106 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"expvar"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 64
+)
+
+// panicsRecovered counts handler panics turned into 500s. It is published
+// with the other expvars on GET /debug/vars.
+var panicsRecovered = expvar.NewInt("httpapi_panics_recovered")
+
+// recoverPanics keeps one bad handler path from taking the server down. Every
+// request gets a request ID, taken from X-Request-ID when the caller sent a
+// usable one, echoed in the response header. A panic is logged with its stack
+// and that ID and answered with a 500 naming the ID, unless the handler had
+// already started its response; then the connection is just closed.
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := incomingRequestID(r.Header.Get(requestIDHeader))
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		tracked := &headerTracker{ResponseWriter: w}
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// net/http uses ErrAbortHandler to drop a response on purpose.
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			panicsRecovered.Add(1)
+			log.Printf("panic request_id=%s method=%s path=%s: %v\n%s", requestID, r.Method, r.URL.Path, recovered, debug.Stack())
+			if tracked.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error", RequestID: requestID})
+		}()
+
+		next.ServeHTTP(tracked, r)
+	})
+}
+
+// incomingRequestID accepts a caller's request ID if it is short printable
+// ASCII, so it can be logged and echoed safely.
+func incomingRequestID(value string) string {
+	if value == "" || len(value) > maxRequestIDLength {
+		return ""
+	}
+	for idx := 0; idx < len(value); idx++ {
+		if value[idx] <= ' ' || value[idx] > '~' {
+			return ""
+		}
+	}
+	return value
+}
+
+func newRequestID() string {
+	var raw [8]byte
+	_, _ = rand.Read(raw[:])
+	return hex.EncodeToString(raw[:])
+}
+
+// headerTracker notes whether a response has started, which decides whether a
+// panic can still be answered with a 500.
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (h *headerTracker) WriteHeader(statusCode int) {
+	h.wroteHeader = true
+	h.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (h *headerTracker) Write(payload []byte) (int, error) {
+	h.wroteHeader = true
+	return h.ResponseWriter.Write(payload)
+}
+
+// Unwrap lets http.ResponseController reach the connection. A hijacked
+// connection counts as a started response.
+func (h *headerTracker) Unwrap() http.ResponseWriter {
+	h.wroteHeader = true
+	return h.ResponseWriter
+}
+
+// Flush passes through so streaming endpoints keep working.
+func (h *headerTracker) Flush() {
+	if flusher, ok := h.ResponseWriter.(http.Flusher); ok {
+		h.wroteHeader = true
+		flusher.Flush()
+	}
+}
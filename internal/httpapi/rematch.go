@@ -0,0 +1,72 @@
+package httpapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// HandleRematch creates a new quiz with the settings of an existing one. The
+// optional body picks the questions: "fresh" fetches new ones with the
+// original parameters, "same" copies the original questions. Without a body,
+// fetched quizzes get fresh questions and the rest are copied.
+func (a *API) HandleRematch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	request := rematchRequest{}
+	if r.ContentLength > 0 {
+		defer r.Body.Close()
+		if err := decodeJSONBody(w, r, &request); err != nil && !errors.Is(err, io.EOF) {
+			writeBodyError(w, err)
+			return
+		}
+	}
+	mode, err := quiz.ParseRematchMode(request.Questions)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	metadata, questions, err := a.service.Rematch(r.Context(), r.PathValue("quiz_id"), mode)
+	switch {
+	case err == nil:
+	case errors.Is(err, quiz.ErrQuizNotFound), errors.Is(err, quiz.ErrNotRefetchable), errors.Is(err, quiz.ErrInvalidQuestion), errors.Is(err, quiz.ErrQueryTimeout):
+		writeServiceError(w, err)
+		return
+	default:
+		writeFetchError(w, err, "failed to create quiz")
+		return
+	}
+	a.rememberQuestions(questions)
+
+	questionIDs := make([]string, 0, len(questions))
+	contentHashes := make([]string, 0, len(questions))
+	for _, question := range questions {
+		questionIDs = append(questionIDs, question.QuestionID)
+		contentHashes = append(contentHashes, a.service.ContentHash(question))
+	}
+	writeJSON(w, http.StatusCreated, createQuizResponse{
+		QuizID:        metadata.QuizID,
+		QuestionCount: metadata.QuestionCount,
+		CreatedAt:     metadata.CreatedAt,
+		State:         a.service.QuizState(metadata),
+		ClosesAt:      optionalTime(metadata.ClosesAt),
+		Origin:        toQuizOriginResponse(metadata.Origin),
+		Practice:      metadata.Practice,
+		Adaptive:      metadata.Adaptive,
+		QuestionIDs:   questionIDs,
+		ContentHashes: contentHashes,
+
+		SecondsPerQuestion: int(metadata.QuestionTimeLimit / time.Second),
+	})
+}
@@ -0,0 +1,46 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// resultsCacheControl lets browsers and CDNs keep a results document for a
+// year without revalidating: once published it never changes.
+const resultsCacheControl = "public, max-age=31536000, immutable"
+
+// HandleQuizResults serves a locked quiz's final results document. The first
+// request after the lock publishes it; every response afterwards carries the
+// same bytes, with a strong ETag for conditional requests.
+func (a *API) HandleQuizResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	published, err := a.service.QuizResults(r.Context(), quizID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	sum := sha256.Sum256(published.Document)
+	header := w.Header()
+	header.Set("Content-Type", "application/json")
+	header.Set("Cache-Control", resultsCacheControl)
+	header.Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "results.json", published.PublishedAt, bytes.NewReader(published.Document))
+}
@@ -0,0 +1,109 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	"quiz-app/internal/quiz"
+)
+
+// HandleRetirementQueue lists questions flagged for retirement because their
+// results show almost no spread. Listing also flags any new candidates, so the
+// queue is current whenever a host looks at it.
+func (a *API) HandleRetirementQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if !a.requireAdmin(w, r) {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = string(quiz.RetirementPending)
+	} else if strings.EqualFold(strings.TrimSpace(status), "all") {
+		status = ""
+	}
+	parsed, err := quiz.ParseRetirementStatus(status)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	reviews, err := a.service.RetirementQueue(r.Context(), parsed)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	response := retirementQueueResponse{
+		Status:    string(parsed),
+		Questions: make([]retirementReviewResponse, 0, len(reviews)),
+	}
+	if parsed == "" {
+		response.Status = "all"
+	}
+	for _, review := range reviews {
+		response.Questions = append(response.Questions, toRetirementReviewResponse(review))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleRetirementDecision confirms ("retire") or dismisses ("keep") a flagged
+// question. Retired questions are left out of quizzes created afterwards;
+// existing quizzes keep them.
+func (a *API) HandleRetirementDecision(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if !a.requireAdmin(w, r) {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	var request retirementDecisionRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	var status quiz.RetirementStatus
+	switch strings.ToLower(strings.TrimSpace(request.Decision)) {
+	case "retire":
+		status = quiz.RetirementRetired
+	case "keep":
+		status = quiz.RetirementKept
+	default:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "decision must be retire or keep"})
+		return
+	}
+
+	review, err := a.service.DecideRetirement(r.Context(), r.PathValue("question_id"), status)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toRetirementReviewResponse(review))
+}
+
+func toRetirementReviewResponse(review quiz.RetirementReview) retirementReviewResponse {
+	return retirementReviewResponse{
+		QuestionID:      review.Question.QuestionID,
+		Question:        review.Question.Question,
+		Options:         review.Question.Options,
+		CorrectIndex:    review.Question.CorrectIndex,
+		Status:          string(review.Status),
+		AttemptCount:    review.AttemptCount,
+		CorrectCount:    review.CorrectCount,
+		CorrectnessRate: review.CorrectnessRate(),
+		FlaggedAt:       review.FlaggedAt,
+		DecidedAt:       optionalTime(review.DecidedAt),
+	}
+}
@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HandleAttemptReview serves one player's answers in a quiz next to each
+// question, with the correct answers once the player has finished or the quiz
+// has locked. With server scoring the answers wait for the lock, since any
+// player could finish the quiz under a throwaway username to read them. A
+// claimed username needs the same tokens as submitting answers for it.
+func (a *API) HandleAttemptReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+	username, err := a.service.AuthenticateSubmitter(r.Context(), r.PathValue("username"), a.playerCredentials(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	review, err := a.service.ReviewAttempt(r.Context(), quizID, username)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if a.serverScoring && !review.Locked {
+		review = review.WithoutAnswers()
+	}
+
+	response := attemptReviewResponse{
+		QuizID:        review.QuizID,
+		Username:      review.Username,
+		Completed:     review.Completed,
+		Locked:        review.Locked,
+		Revealed:      review.Revealed,
+		TotalScore:    review.TotalScore,
+		AnsweredCount: review.AnsweredCount,
+		QuestionCount: len(review.Questions),
+		Questions:     make([]reviewedQuestionResponse, 0, len(review.Questions)),
+	}
+	for _, question := range review.Questions {
+		response.Questions = append(response.Questions, reviewedQuestionResponse{
+			QuestionID:    question.QuestionID,
+			Question:      question.Question,
+			Options:       question.Options,
+			Type:          question.Type,
+			Voided:        question.Voided,
+			ChosenLetter:  question.ChosenLetter,
+			Status:        question.Status,
+			Score:         question.Score,
+			AnsweredAt:    optionalTime(question.AnsweredAt),
+			CorrectLetter: question.CorrectLetter,
+			CorrectText:   question.CorrectText,
+		})
+	}
+	// A review changes with every answer, and a revealed one is an answer key.
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,151 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// HandleRoster reads or replaces a quiz's roster. Reading lists every
+// rostered student next to their live standing, so the host can match
+// usernames to real students; format=csv returns the same rows as a
+// spreadsheet. Both methods require the admin token because the roster holds
+// real names and join codes.
+func (a *API) HandleRoster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPut)
+		return
+	}
+	if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format != "" && format != "json" && format != "csv" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "format must be json or csv"})
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var request rosterRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		roster := quiz.Roster{Restricted: request.Restricted, Students: make([]quiz.RosterStudent, 0, len(request.Students))}
+		for _, student := range request.Students {
+			roster.Students = append(roster.Students, quiz.RosterStudent{Username: student.Username, Name: student.Name})
+		}
+		if _, err := a.service.SetRoster(r.Context(), quizID, roster, request.GenerateJoinCodes); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+	}
+
+	roster, standings, err := a.service.RosterStandings(r.Context(), quizID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if format == "csv" {
+		writeRosterCSV(w, quizID, standings)
+		return
+	}
+
+	response := rosterResponse{
+		QuizID:     quizID,
+		Restricted: roster.Restricted,
+		UpdatedAt:  optionalTime(roster.UpdatedAt),
+		Students:   make([]rosterStandingResponse, 0, len(standings)),
+	}
+	for _, standing := range standings {
+		response.Students = append(response.Students, rosterStandingResponse{
+			Username:         standing.Username,
+			Name:             standing.Name,
+			JoinCode:         standing.JoinCode,
+			OnRoster:         standing.OnRoster,
+			Rank:             standing.Rank,
+			TotalScore:       standing.Entry.TotalScore,
+			AnsweredCount:    standing.Entry.AnsweredCount,
+			LastSubmissionAt: optionalTime(standing.Entry.LastSubmissionAt),
+			ProxyAnswers:     standing.ProxyAnswers,
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// writeRosterCSV writes standings as CSV with a header row. Students who have
+// not answered have an empty rank; proxy_answers counts answers an admin
+// entered for them.
+func writeRosterCSV(w http.ResponseWriter, quizID string, standings []quiz.RosterStanding) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": quizID + "-roster.csv"}))
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"rank", "username", "name", "join_code", "on_roster", "total_score", "answered_count", "last_submission_at", "proxy_answers"})
+	for _, standing := range standings {
+		rank, lastSubmission := "", ""
+		if standing.Rank > 0 {
+			rank = strconv.Itoa(standing.Rank)
+			lastSubmission = standing.Entry.LastSubmissionAt.UTC().Format(time.RFC3339)
+		}
+		_ = out.Write([]string{
+			rank,
+			standing.Username,
+			standing.Name,
+			standing.JoinCode,
+			strconv.FormatBool(standing.OnRoster),
+			strconv.FormatFloat(standing.Entry.TotalScore, 'f', -1, 64),
+			strconv.Itoa(standing.Entry.AnsweredCount),
+			lastSubmission,
+			strconv.Itoa(standing.ProxyAnswers),
+		})
+	}
+	out.Flush()
+}
+
+// HandleJoinQuiz resolves a student's join code to their rostered username.
+// Players then use that username as usual.
+func (a *API) HandleJoinQuiz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+	var request joinQuizRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	student, err := a.service.JoinQuiz(r.Context(), quizID, request.JoinCode)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, joinQuizResponse{QuizID: quizID, Username: student.Username, Name: student.Name})
+}
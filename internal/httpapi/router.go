@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"quiz-app/internal/bundles"
 	"quiz-app/internal/quiz"
 )
 
@@ -15,25 +16,83 @@ func NewRouter(service *quiz.Service, bank *quiz.Bank) http.Handler {
 
 type RouterOptions struct {
 	Debug bool
+	// AdminToken is the bearer token required by host endpoints such as voiding
+	// a question. When empty, those endpoints respond 403.
+	AdminToken string
+	// SkipBankPopulation stops quiz endpoints from copying the questions they
+	// serve into the bank. Use it when the bank reads through to the store, or
+	// when only questions added via POST /bank/questions should be evaluated
+	// without a quiz.
+	SkipBankPopulation bool
+	// Bundles is the pool new quizzes draw from when hosts load an embedded
+	// question bundle via /admin/bundles. Nil makes those endpoints respond 501.
+	Bundles *bundles.Pool
+	// RateLimits caps requests per client address for each route group.
+	// Groups without an entry are not limited.
+	RateLimits map[RouteGroup]RateLimit
+	// CompressMinBytes turns on gzip and deflate for responses at least this
+	// large, for clients whose Accept-Encoding allows it. Zero disables
+	// compression.
+	CompressMinBytes int
+	// Chaos injects latency, errors, and cut-off responses into every request,
+	// for testing clients in development. The zero value injects nothing.
+	Chaos Chaos
+	// ServerScoring makes the server the only judge of answers, so a
+	// leaderboard cannot be won by reading the answer key: GET /questions
+	// ignores include_correct, and answers are scored only when submitted
+	// with a quiz and a username, which stores them. Host endpoints such as
+	// the answer key are unaffected.
+	ServerScoring bool
 }
 
 func NewRouterWithOptions(service *quiz.Service, bank *quiz.Bank, options RouterOptions) http.Handler {
 	api := NewAPI(service, bank)
+	api.adminToken = options.AdminToken
+	api.populateBank = !options.SkipBankPopulation
+	api.bundles = options.Bundles
+	api.serverScoring = options.ServerScoring
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/questions", api.HandleQuestions)
-	mux.HandleFunc("/responses", api.HandleResponses)
-	mux.HandleFunc("/quizzes", api.HandleCreateQuiz)
-	mux.HandleFunc("/quizzes/active", api.HandleActiveQuizzes)
-	mux.HandleFunc("/quizzes/{quiz_id}/leaderboard", api.HandleLeaderboard)
-
-	if !options.Debug {
-		return mux
+	stacks := make(map[RouteGroup]func(http.Handler) http.Handler, len(RouteGroups))
+	for _, group := range RouteGroups {
+		stacks[group] = groupMiddleware(group, options)
 	}
-	return debugRequestLoggingMiddleware(mux)
+	for _, route := range routeTable {
+		mux.Handle(route.Pattern, stacks[route.Group](api.routeHandler(route)))
+	}
+	if options.Debug {
+		// Unmatched paths still get their 404 logged.
+		mux.Handle("/", debugRequestLoggingMiddleware("", http.NotFoundHandler()))
+	}
+
+	var handler http.Handler = mux
+	if options.Chaos.Enabled() {
+		handler = injectChaos(options.Chaos, handler)
+	}
+	return recoverPanics(handler)
 }
 
-func debugRequestLoggingMiddleware(next http.Handler) http.Handler {
+// groupMiddleware builds one route group's stack, outermost first:
+// compression, so debug logging sees the uncompressed body, then debug
+// logging, so throttled requests are logged too, then the group's rate limit.
+// Each group has its own limiter, shared by all of its routes.
+func groupMiddleware(group RouteGroup, options RouterOptions) func(http.Handler) http.Handler {
+	limiter := newClientLimiter(group, options.RateLimits[group])
+	return func(next http.Handler) http.Handler {
+		if limiter != nil {
+			next = limiter.middleware(next)
+		}
+		if options.Debug {
+			next = debugRequestLoggingMiddleware(group, next)
+		}
+		if options.CompressMinBytes > 0 {
+			next = compressResponses(options.CompressMinBytes, next)
+		}
+		return next
+	}
+}
+
+func debugRequestLoggingMiddleware(group RouteGroup, next http.Handler) http.Handler {
 	const maxLoggedResponseBytes = 128
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -46,7 +105,8 @@ func debugRequestLoggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(recorder, r)
 
 		log.Printf(
-			"request method=%s path=%s query=%q status=%d bytes=%d duration=%s remote=%s user_agent=%q response_body=%q truncated=%t",
+			"request group=%s method=%s path=%s query=%q status=%d bytes=%d duration=%s remote=%s user_agent=%q response_body=%q truncated=%t",
+			group,
 			r.Method,
 			r.URL.Path,
 			r.URL.RawQuery,
@@ -75,6 +135,19 @@ func (s *statusRecorder) WriteHeader(statusCode int) {
 	s.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap lets http.ResponseController reach the connection, so WebSocket
+// upgrades still work with -debug.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Flush passes through so streaming endpoints still work with -debug.
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func (s *statusRecorder) Write(payload []byte) (int, error) {
 	written, err := s.ResponseWriter.Write(payload)
 	s.bytesWritten += written
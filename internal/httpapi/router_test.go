@@ -1,9 +1,18 @@
 package httpapi
 
 import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"quiz-app/internal/quiz"
 )
 
 func TestStatusRecorderWriteTracksAndTruncates(t *testing.T) {
@@ -32,3 +41,309 @@ func TestStatusRecorderWriteTracksAndTruncates(t *testing.T) {
 		t.Fatalf("expected truncated flag to be true")
 	}
 }
+
+func TestRecoverPanicsAnswers500WithRequestID(t *testing.T) {
+	handler := recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil store")
+	}))
+	before := panicsRecovered.Value()
+
+	req := httptest.NewRequest(http.MethodGet, "/quizzes/active", nil)
+	req.Header.Set(requestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if rec.Header().Get(requestIDHeader) != "req-123" || !strings.Contains(rec.Body.String(), `"request_id":"req-123"`) {
+		t.Fatalf("response = (%v, %s), want the caller's request ID echoed", rec.Header(), rec.Body.String())
+	}
+	if panicsRecovered.Value() != before+1 {
+		t.Fatalf("panicsRecovered = %d, want %d", panicsRecovered.Value(), before+1)
+	}
+
+	// Unusable IDs are replaced with a generated one.
+	req = httptest.NewRequest(http.MethodGet, "/quizzes/active", nil)
+	req.Header.Set(requestIDHeader, "bad id\n")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if id := rec.Header().Get(requestIDHeader); len(id) != 16 {
+		t.Fatalf("generated request ID = %q, want 16 hex characters", id)
+	}
+}
+
+func TestDebugVarsRequiresAdminToken(t *testing.T) {
+	router := NewRouterWithOptions(quiz.NewService(nil, nil, nil), nil, RouterOptions{AdminToken: "secret"})
+	req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /debug/vars without token status = %d, want 401", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"httpapi_panics_recovered"`) {
+		t.Fatalf("GET /debug/vars = (%d, %.80s), want the panic counter", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRouteRegistryAnswersAllowAndEnforcesScopes(t *testing.T) {
+	patterns := make(map[string]bool)
+	for _, route := range Routes() {
+		if patterns[route.Pattern] || route.handle == nil || len(route.Methods) == 0 || route.Summary == "" || !slices.Contains(RouteGroups, route.Group) {
+			t.Fatalf("route %+v is duplicated or incomplete", route)
+		}
+		patterns[route.Pattern] = true
+	}
+
+	router := NewRouterWithOptions(quiz.NewService(nil, nil, nil), nil, RouterOptions{AdminToken: "secret"})
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	rec := do(http.MethodOptions, "/quizzes/qz_1/leaderboard/settings")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Allow") != "GET, PUT" {
+		t.Fatalf("OPTIONS = (%d, Allow %q), want 204 with GET, PUT", rec.Code, rec.Header().Get("Allow"))
+	}
+	rec = do(http.MethodDelete, "/users/alice/identity")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, POST" {
+		t.Fatalf("DELETE = (%d, Allow %q), want 405 with GET, POST", rec.Code, rec.Header().Get("Allow"))
+	}
+	if rec := do(http.MethodPut, "/quizzes/qz_1/leaderboard/settings"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("PUT settings without token = %d, want 401", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/admin/pool/stats"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /admin/pool/stats without token = %d, want 401", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/nowhere"); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET /nowhere = %d, want 404", rec.Code)
+	}
+}
+
+func TestRouteGroupRateLimitsPerClient(t *testing.T) {
+	router := NewRouterWithOptions(nil, nil, RouterOptions{
+		RateLimits: map[RouteGroup]RateLimit{GroupResponses: {PerSecond: 0.5, Burst: 1}},
+	})
+	do := func(method, target, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(`{"responses":[]}`))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/bank/evaluate", "10.0.0.1:1000"); rec.Code == http.StatusTooManyRequests {
+		t.Fatalf("first request = 429, want it through")
+	}
+	rec := do(http.MethodPost, "/bank/evaluate", "10.0.0.1:2000")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2" {
+		t.Fatalf("second request = (%d, Retry-After %q), want 429 with Retry-After 2", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := do(http.MethodPost, "/bank/evaluate", "10.0.0.2:1000"); rec.Code == http.StatusTooManyRequests {
+		t.Fatalf("other client = 429, want its own bucket")
+	}
+	if rec := do(http.MethodGet, "/bank/stats", "10.0.0.1:1000"); rec.Code == http.StatusTooManyRequests {
+		t.Fatalf("other group = 429, want it unlimited")
+	}
+}
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits(" responses=5:20, Quizzes=0.5 ,")
+	if err != nil {
+		t.Fatalf("ParseRateLimits failed: %v", err)
+	}
+	if len(limits) != 2 || limits[GroupResponses] != (RateLimit{PerSecond: 5, Burst: 20}) || limits[GroupQuizzes] != (RateLimit{PerSecond: 0.5}) {
+		t.Fatalf("limits = %+v, want responses 5:20 and quizzes 0.5", limits)
+	}
+	for _, value := range []string{"responses", "nope=1", "responses=0", "responses=abc", "responses=1:0", "responses=1:x"} {
+		if _, err := ParseRateLimits(value); err == nil {
+			t.Fatalf("ParseRateLimits(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                      "",
+		"gzip, deflate, br":     "gzip",
+		"deflate":               "deflate",
+		"gzip;q=0.5, deflate":   "deflate",
+		"gzip;q=0, deflate;q=0": "",
+		"identity":              "",
+		"*":                     "gzip",
+		"gzip;q=0, *;q=0.3":     "deflate",
+		"GZIP ; q=1":            "gzip",
+		"gzip;q=bogus, deflate": "deflate",
+	} {
+		if got := negotiateEncoding(header); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCompressResponsesEncodesLargeBodies(t *testing.T) {
+	large := strings.Repeat(`{"question":"Which planet is the largest in the solar system?"},`, 100)
+	handler := compressResponses(1024, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("encoded") != "" {
+			w.Header().Set("Content-Encoding", "br")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if r.URL.Query().Get("small") != "" {
+			_, _ = io.WriteString(w, `{"ok":true}`)
+			return
+		}
+		// Written in pieces, so the threshold is crossed mid-response.
+		for idx := 0; idx < len(large); idx += 300 {
+			_, _ = io.WriteString(w, large[idx:min(idx+300, len(large))])
+		}
+	}))
+	do := func(target, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for encoding, open := range map[string]func(io.Reader) (io.Reader, error){
+		"gzip":    func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+	} {
+		rec := do("/", encoding)
+		if rec.Code != http.StatusCreated || rec.Header().Get("Content-Encoding") != encoding || rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("%s response = (%d, %v), want 201 encoded with Vary", encoding, rec.Code, rec.Header())
+		}
+		if rec.Body.Len() >= len(large) {
+			t.Fatalf("%s body is %d bytes, want smaller than %d", encoding, rec.Body.Len(), len(large))
+		}
+		reader, err := open(rec.Body)
+		if err != nil {
+			t.Fatalf("opening %s body: %v", encoding, err)
+		}
+		if body, err := io.ReadAll(reader); err != nil || string(body) != large {
+			t.Fatalf("decoded %s body = (%d bytes, %v), want the original", encoding, len(body), err)
+		}
+	}
+
+	for _, tc := range []struct{ target, acceptEncoding string }{
+		{"/", ""},
+		{"/", "br"},
+		{"/?small=1", "gzip"},
+		{"/?encoded=1", "gzip"},
+	} {
+		rec := do(tc.target, tc.acceptEncoding)
+		if rec.Code != http.StatusCreated || rec.Header().Get("Content-Encoding") == "gzip" {
+			t.Fatalf("%s with %q = (%d, %v), want 201 not gzipped", tc.target, tc.acceptEncoding, rec.Code, rec.Header())
+		}
+		if tc.target != "/?encoded=1" && rec.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("%s with %q = %v, want no Content-Encoding", tc.target, tc.acceptEncoding, rec.Header())
+		}
+	}
+}
+
+func TestCompressResponsesLeavesStreamingAlone(t *testing.T) {
+	large := strings.Repeat("x", 4096)
+	handler := compressResponses(1024, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("early") != "" {
+			// Flushing before the threshold sends everything uncompressed.
+			_, _ = io.WriteString(w, "start\n")
+			w.(http.Flusher).Flush()
+			_, _ = io.WriteString(w, large)
+			return
+		}
+		// Past the threshold, a flush pushes out a decodable gzip block.
+		_, _ = io.WriteString(w, large)
+		w.(http.Flusher).Flush()
+		_, _ = io.WriteString(w, "end")
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/?early=1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "" || !rec.Flushed || rec.Body.String() != "start\n"+large {
+		t.Fatalf("early flush = (%v, flushed %t, %d bytes), want an uncompressed, flushed body", rec.Header(), rec.Flushed, rec.Body.Len())
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(rec, req)
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil || rec.Header().Get("Content-Encoding") != "gzip" || !rec.Flushed {
+		t.Fatalf("late flush = (%v, flushed %t, %v), want a flushed gzip body", rec.Header(), rec.Flushed, err)
+	}
+	if body, err := io.ReadAll(reader); err != nil || string(body) != large+"end" {
+		t.Fatalf("decoded body = (%d bytes, %v), want the whole body", len(body), err)
+	}
+
+	// Leaderboard streams are never compressed, even once the replay passes
+	// the threshold.
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}}
+	service := quiz.NewService(repo, &acceptingAttemptRepo{}, nil)
+	server := httptest.NewServer(NewRouterWithOptions(service, nil, RouterOptions{CompressMinBytes: 1}))
+	defer server.Close()
+	streamReq, _ := http.NewRequest(http.MethodGet, server.URL+"/quizzes/qz_1/leaderboard/stream", nil)
+	streamReq.Header.Set("Accept-Encoding", "gzip")
+	response, err := server.Client().Do(streamReq)
+	if err != nil || response.StatusCode != http.StatusOK || response.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("stream = (%v, %v), want 200 without Content-Encoding", response, err)
+	}
+	defer response.Body.Close()
+	line, err := bufio.NewReader(response.Body).ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("first stream line = (%q, %v), want the retry hint in plain text", line, err)
+	}
+	service.CloseLeaderboardStreams()
+}
+
+func TestParseChaos(t *testing.T) {
+	chaos, err := ParseChaos(" latency=250ms, Errors=0.1 ,partial=1,")
+	if err != nil {
+		t.Fatalf("ParseChaos failed: %v", err)
+	}
+	if chaos.Latency != 250*time.Millisecond || chaos.ErrorRate != 0.1 || chaos.PartialRate != 1 || !chaos.Enabled() {
+		t.Fatalf("chaos = %+v, want 250ms, 0.1 errors, and every response cut", chaos)
+	}
+	if chaos, err := ParseChaos(""); err != nil || chaos.Enabled() {
+		t.Fatalf("ParseChaos(\"\") = (%+v, %v), want nothing injected", chaos, err)
+	}
+	for _, value := range []string{"latency", "latency=-1s", "latency=soon", "errors=1.5", "partial=-0.1", "errors=x", "drop=0.5"} {
+		if _, err := ParseChaos(value); err == nil {
+			t.Fatalf("ParseChaos(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestChaosInjectsErrorsAndCutsResponses(t *testing.T) {
+	repo := &singleQuizRepo{metadata: quiz.QuizMetadata{QuizID: "qz_1", QuestionCount: 1}}
+	service := quiz.NewService(repo, &acceptingAttemptRepo{}, nil)
+
+	// Draws of 0 fail every request; the second draw picks the status.
+	failing := NewRouterWithOptions(service, nil, RouterOptions{Chaos: Chaos{ErrorRate: 0.5, Float64: func() float64 { return 0 }}})
+	rec := httptest.NewRecorder()
+	failing.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quizzes/qz_1/leaderboard", nil))
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("X-Chaos") != "error" {
+		t.Fatalf("injected error = (%d, %v), want 500 marked X-Chaos: error", rec.Code, rec.Header())
+	}
+
+	// Draws of 0.9 pass the 0.5 error rate and hit the 0.95 partial rate.
+	cutting := NewRouterWithOptions(service, nil, RouterOptions{Chaos: Chaos{ErrorRate: 0.5, PartialRate: 0.95, Float64: func() float64 { return 0.9 }}})
+	server := httptest.NewServer(cutting)
+	defer server.Close()
+	response, err := server.Client().Get(server.URL + "/quizzes/qz_1/leaderboard")
+	if err != nil {
+		t.Fatalf("GET leaderboard failed: %v", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || response.Header.Get("X-Chaos") != "partial" || err == nil || int64(len(body)) >= response.ContentLength {
+		t.Fatalf("cut response = (%d, %v, %d of %d bytes, %v), want half a body and a read error", response.StatusCode, response.Header, len(body), response.ContentLength, err)
+	}
+}
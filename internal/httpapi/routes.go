@@ -0,0 +1,193 @@
+package httpapi
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// RouteGroup names a sub-router. Every route belongs to one group, and each
+// group gets its own middleware stack, so a rate limit on answer submissions
+// does not throttle leaderboard polling.
+type RouteGroup string
+
+const (
+	// GroupQuestions serves questions outside a quiz: fetching, search,
+	// reports, tags, the ad-hoc bank, and author stats.
+	GroupQuestions RouteGroup = "questions"
+	// GroupQuizzes creates, imports, and serves quizzes.
+	GroupQuizzes RouteGroup = "quizzes"
+	// GroupResponses scores answers, with or without a quiz.
+	GroupResponses RouteGroup = "responses"
+	// GroupLeaderboard serves standings, their stream, their settings, final
+	// results, and rankings across quizzes.
+	GroupLeaderboard RouteGroup = "leaderboard"
+	// GroupAdmin holds host-only tools that are not about one quiz, and the
+	// API description.
+	GroupAdmin RouteGroup = "admin"
+	// GroupUsers holds accounts and per-user settings, identities, and
+	// bookmarks.
+	GroupUsers RouteGroup = "users"
+)
+
+// RouteGroups lists every group, in registry order.
+var RouteGroups = []RouteGroup{GroupQuestions, GroupQuizzes, GroupResponses, GroupLeaderboard, GroupAdmin, GroupUsers}
+
+// Scope says who may call a route.
+type Scope string
+
+const (
+	// ScopePublic routes need no token. Some still check the admin token for
+	// options that expose answers, such as ?answers=true on a quiz bundle.
+	ScopePublic Scope = "public"
+	// ScopeAdmin routes need the admin token for every method.
+	ScopeAdmin Scope = "admin"
+	// ScopeAdminWrites routes are public to read and need the admin token to
+	// change.
+	ScopeAdminWrites Scope = "admin_writes"
+	// ScopeHost routes act on the quiz in their path and need the admin token
+	// or the host token of one of that quiz's hosts for every method.
+	ScopeHost Scope = "host"
+	// ScopeHostWrites routes are public to read and need a ScopeHost token to
+	// change.
+	ScopeHostWrites Scope = "host_writes"
+)
+
+// Route is one entry in the route registry. The registry is the single list
+// of endpoints: the router is built from it, 405 and OPTIONS responses take
+// their Allow header from it, and API descriptions should be generated from
+// it rather than from the docs.
+type Route struct {
+	Group   RouteGroup
+	Pattern string
+	Methods []string
+	Scope   Scope
+	Summary string
+
+	handle func(*API, http.ResponseWriter, *http.Request)
+}
+
+// Allow is the route's Allow header value.
+func (r Route) Allow() string {
+	return strings.Join(r.Methods, ", ")
+}
+
+var (
+	onlyGet    = []string{http.MethodGet}
+	onlyPost   = []string{http.MethodPost}
+	getOrPost  = []string{http.MethodGet, http.MethodPost}
+	getOrPut   = []string{http.MethodGet, http.MethodPut}
+	onlyDelete = []string{http.MethodDelete}
+	routeTable = []Route{
+		{GroupQuestions, "/questions", onlyGet, ScopePublic, "fetch quiz questions (can create if quiz_id absent or create-if-missing)", (*API).HandleQuestions},
+		{GroupQuestions, "/questions/search", onlyGet, ScopePublic, "search stored questions by prompt text", (*API).HandleSearchQuestions},
+		{GroupQuestions, "/questions/{question_id}/tags", getOrPut, ScopeAdminWrites, "tags a stored question is sampled by for quizzes from the bank; admins replace them", (*API).HandleQuestionTags},
+		{GroupQuestions, "/questions/{question_id}/reports", onlyPost, ScopePublic, "report a broken or unclear question", (*API).HandleReportQuestion},
+		{GroupQuestions, "/bank/questions", onlyPost, ScopePublic, "add questions for ad-hoc answer checks", (*API).HandleBankQuestions},
+		{GroupQuestions, "/bank/stats", onlyGet, ScopePublic, "question bank size and hit/miss counters", (*API).HandleBankStats},
+		{GroupQuestions, "/authors/{author}/questions/performance", onlyGet, ScopePublic, "attempts, correctness, and reports for an author's questions", (*API).HandleAuthorPerformance},
+
+		{GroupQuizzes, "/quizzes", onlyPost, ScopePublic, "create a quiz", (*API).HandleCreateQuiz},
+		{GroupQuizzes, "/quizzes/active", onlyGet, ScopePublic, "list recently created quizzes with their state", (*API).HandleActiveQuizzes},
+		{GroupQuizzes, "/quizzes/from-bank", onlyPost, ScopePublic, "create one or more quizzes from stored questions matching tags and difficulty, without the provider", (*API).HandleCreateBankQuizzes},
+		{GroupQuizzes, "/quizzes/import", onlyPost, ScopePublic, "create a quiz from an Aiken, GIFT, or Moodle XML file", (*API).HandleImportQuiz},
+		{GroupQuizzes, "/quizzes/import-bundle", onlyPost, ScopePublic, "recreate a quiz from a bundle exported by another deployment", (*API).HandleImportQuizBundle},
+		{GroupQuizzes, "/quizzes/daily", onlyGet, ScopePublic, "today's daily quiz (created on first request)", (*API).HandleDailyQuiz},
+		{GroupQuizzes, "/quizzes/{quiz_id}", onlyDelete, ScopeAdmin, "archive a quiz, keeping its results, or delete it with ?mode=delete", (*API).HandleRemoveQuiz},
+		{GroupQuizzes, "/quizzes/{quiz_id}/state", getOrPut, ScopeHostWrites, "lifecycle state (draft, active, locked, expired, or archived) and deadline; hosts activate, lock, or reschedule", (*API).HandleQuizState},
+		{GroupQuizzes, "/quizzes/{quiz_id}/roster", getOrPut, ScopeHost, "classroom roster with live standings", (*API).HandleRoster},
+		{GroupQuizzes, "/quizzes/{quiz_id}/events", onlyGet, ScopeHost, "live submissions with score deltas and running totals for host dashboards (server-sent events)", (*API).HandleQuizEvents},
+		{GroupQuizzes, "/quizzes/{quiz_id}/join", onlyPost, ScopePublic, "look up a student's username by roster join code", (*API).HandleJoinQuiz},
+		{GroupQuizzes, "/quizzes/{quiz_id}/rematch", onlyPost, ScopePublic, "new quiz with the same settings, fresh or same questions", (*API).HandleRematch},
+		{GroupQuizzes, "/quizzes/{quiz_id}/next", onlyGet, ScopePublic, "next question of an adaptive quiz for one player", (*API).HandleNextQuestion},
+		{GroupQuizzes, "/quizzes/{quiz_id}/attempts/{username}", onlyGet, ScopePublic, "one player's answers next to each question, with the correct answers once they finish or the quiz locks", (*API).HandleAttemptReview},
+		{GroupQuizzes, "/quizzes/{quiz_id}/answer-key", onlyGet, ScopeHost, "questions with answers for preparing an event", (*API).HandleAnswerKey},
+		{GroupQuizzes, "/quizzes/{quiz_id}/answer-positions", getOrPost, ScopeHost, "how often each letter is the correct answer; POST moves answers off over-used letters before lock", (*API).HandleAnswerPositions},
+		{GroupQuizzes, "/quizzes/{quiz_id}/bundle", onlyGet, ScopePublic, "export a quiz and its settings as a portable JSON bundle", (*API).HandleQuizBundle},
+		{GroupQuizzes, "/quizzes/{quiz_id}/audit", onlyGet, ScopeHost, "admin and host actions, such as answers entered for players and host changes", (*API).HandleAuditLog},
+		{GroupQuizzes, "/quizzes/{quiz_id}/hosts", getOrPost, ScopeHost, "list the quiz's hosts, add a co-host, or transfer ownership (audited)", (*API).HandleHosts},
+		{GroupQuizzes, "/quizzes/{quiz_id}/serves", onlyGet, ScopeHost, "who fetched the questions, when, and whether with the answer key", (*API).HandleServeLog},
+		{GroupQuizzes, "/quizzes/{quiz_id}/questions/{question_id}/void", onlyPost, ScopeHost, "void a question mid-event", (*API).HandleVoidQuestion},
+		{GroupQuizzes, "/quizzes/{quiz_id}/webhooks", onlyPost, ScopeHost, "notify a URL when participants complete the quiz", (*API).HandleCompletionWebhook},
+		{GroupQuizzes, "/stats/overview", onlyGet, ScopePublic, "quizzes today, submissions per minute, active users, and top categories", (*API).HandleStatsOverview},
+
+		{GroupResponses, "/responses", onlyPost, ScopePublic, "submit/evaluate responses", (*API).HandleResponses},
+		{GroupResponses, "/quizzes/{quiz_id}/responses/on-behalf", onlyPost, ScopeHost, "enter a player's answers for them, e.g. from a paper sheet (audited)", (*API).HandleSubmitOnBehalf},
+		{GroupResponses, "/bank/evaluate", onlyPost, ScopePublic, "check answers without a quiz (not persisted)", (*API).HandleBankEvaluate},
+
+		{GroupLeaderboard, "/quizzes/{quiz_id}/leaderboard", onlyGet, ScopePublic, "fetch leaderboard", (*API).HandleLeaderboard},
+		{GroupLeaderboard, "/quizzes/{quiz_id}/leaderboard/stream", onlyGet, ScopePublic, "live leaderboard deltas (server-sent events)", (*API).HandleLeaderboardStream},
+		{GroupLeaderboard, "/quizzes/{quiz_id}/leaderboard/ws", onlyGet, ScopePublic, "live leaderboard deltas over a WebSocket", (*API).HandleLeaderboardWebSocket},
+		{GroupLeaderboard, "/quizzes/{quiz_id}/leaderboard/settings", getOrPut, ScopeHostWrites, "per-quiz default leaderboard size and end-of-quiz freeze", (*API).HandleLeaderboardSettings},
+		{GroupLeaderboard, "/leaderboard/global", onlyGet, ScopePublic, "rankings across quizzes, optionally for a season, with raw and normalized totals", (*API).HandleGlobalLeaderboard},
+		{GroupLeaderboard, "/quizzes/{quiz_id}/results.json", onlyGet, ScopePublic, "immutable final standings and per-question stats once the quiz locks", (*API).HandleQuizResults},
+
+		{GroupAdmin, "/admin/quizzes", onlyGet, ScopeAdmin, "every quiz with attempt, participant, and storage stats", (*API).HandleAdminQuizzes},
+		{GroupAdmin, "/admin/bundles", onlyGet, ScopeAdmin, "list embedded question bundles", (*API).HandleBundles},
+		{GroupAdmin, "/admin/bundles/{name}", onlyPost, ScopeAdmin, "draw new quizzes from an embedded bundle instead of OpenTriviaDB", (*API).HandleLoadBundle},
+		{GroupAdmin, "/admin/pool/stats", onlyGet, ScopeAdmin, "bundle pool size by category, difficulty, draws, and age", (*API).HandlePoolStats},
+		{GroupAdmin, "/admin/retirements", onlyGet, ScopeAdmin, "questions flagged for near-0% or near-100% correctness", (*API).HandleRetirementQueue},
+		{GroupAdmin, "/admin/retirements/{question_id}", onlyPost, ScopeAdmin, "retire a flagged question from new quizzes, or keep it", (*API).HandleRetirementDecision},
+		{GroupAdmin, "/debug/vars", onlyGet, ScopeAdmin, "process expvars, including recovered handler panics", (*API).HandleDebugVars},
+		{GroupAdmin, openAPIPath, onlyGet, ScopePublic, "OpenAPI 3.1 description of every route and its request and response bodies", (*API).HandleOpenAPI},
+
+		{GroupUsers, "/users/register", onlyPost, ScopePublic, "create an account with a password", (*API).HandleRegister},
+		{GroupUsers, "/users/login", onlyPost, ScopePublic, "exchange a password for an account token (JWT) that answers are submitted with", (*API).HandleLogin},
+		{GroupUsers, "/users/{username}/bookmarks", getOrPost, ScopePublic, "list or add question bookmarks", (*API).HandleBookmarks},
+		{GroupUsers, "/users/{username}/bookmarks/{question_id}", onlyDelete, ScopePublic, "remove a bookmark", (*API).HandleDeleteBookmark},
+		{GroupUsers, "/users/{username}/bookmarks/practice", onlyPost, ScopePublic, "create a practice quiz from bookmarks", (*API).HandlePracticeQuiz},
+		{GroupUsers, "/users/{username}/profile", getOrPut, ScopePublic, "user settings, such as hiding from public leaderboards", (*API).HandleProfile},
+		{GroupUsers, "/users/{username}/preferences", getOrPut, ScopePublic, "default question count and difficulty for quizzes the user creates", (*API).HandlePreferences},
+		{GroupUsers, "/users/{username}/stats", onlyGet, ScopePublic, "daily participation streak and answer totals across quizzes", (*API).HandleUserStats},
+		{GroupUsers, "/users/{username}/attempts", onlyGet, ScopePublic, "every quiz the user answered in, with score, answered count, and timestamps", (*API).HandleUserAttempts},
+		{GroupUsers, "/users/{username}/identity", getOrPost, ScopePublic, "verification status, or email a code and magic link to verify the username", (*API).HandleIdentity},
+		{GroupUsers, "/users/{username}/identity/verify", getOrPost, ScopePublic, "complete verification and receive the player token", (*API).HandleVerifyIdentity},
+		{GroupUsers, "/users/{username}/signing-keys", onlyPost, ScopePublic, "register a key for signing answers queued offline", (*API).HandleSigningKey},
+	}
+)
+
+// Routes returns a copy of the route registry, grouped as the router mounts
+// it.
+func Routes() []Route {
+	return slices.Clone(routeTable)
+}
+
+// routeHandler enforces the registry entry before calling the handler: OPTIONS
+// is answered with the Allow header, other unlisted methods get 405, and the
+// scope's token check runs next. Handlers repeat their own method and token
+// checks, since they are exported and can be mounted without this router.
+func (a *API) routeHandler(route Route) http.Handler {
+	allow := route.Allow()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", allow)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !slices.Contains(route.Methods, r.Method) {
+			writeMethodNotAllowed(w, allow)
+			return
+		}
+		switch route.Scope {
+		case ScopeAdmin:
+			if !a.requireAdmin(w, r) {
+				return
+			}
+		case ScopeAdminWrites:
+			if r.Method != http.MethodGet && !a.requireAdmin(w, r) {
+				return
+			}
+		case ScopeHost:
+			if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+				return
+			}
+		case ScopeHostWrites:
+			if r.Method != http.MethodGet {
+				if _, ok := a.requireHost(w, r, r.PathValue("quiz_id")); !ok {
+					return
+				}
+			}
+		}
+		route.handle(a, w, r)
+	})
+}
@@ -0,0 +1,175 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+const (
+	// streamRetryMillis is the reconnect delay suggested to EventSource clients.
+	streamRetryMillis = 2000
+	// streamKeepAlive keeps idle connections from being closed by proxies.
+	streamKeepAlive = 15 * time.Second
+)
+
+// HandleLeaderboardStream streams leaderboard changes as server-sent events.
+// Browsers' EventSource reconnects on its own and sends Last-Event-ID, so a
+// viewer that drops only receives the deltas it missed. Clients that cannot set
+// the header may pass last_event_id instead. On shutdown each viewer gets a
+// closing event before the response ends.
+func (a *API) HandleLeaderboardStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming unsupported"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	stream, err := a.service.SubscribeLeaderboard(r.Context(), quizID, lastEventID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis)
+	for _, event := range stream.Replay {
+		writeLeaderboardEvent(w, event)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-stream.Events:
+			if !ok {
+				// Dropped for falling behind; the client resumes from its last ID.
+				return
+			}
+			writeLeaderboardEvent(w, event)
+			flusher.Flush()
+			if event.Type == quiz.LeaderboardEventClosing {
+				return
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeLeaderboardEvent(w io.Writer, event quiz.LeaderboardEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	// An empty id line would reset the client's last event ID, so events
+	// without one, like closing, leave it out.
+	if event.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", event.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+}
+
+// HandleQuizEvents streams a quiz's submissions to its hosts as server-sent
+// events: a snapshot of every player's progress, then one event per
+// submission with the player's username, the points it earned, and the
+// running totals. Events carry no IDs; a host that reconnects gets a fresh
+// snapshot, which holds everything a dashboard shows.
+func (a *API) HandleQuizEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming unsupported"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	stream, err := a.service.SubscribeSubmissions(r.Context(), quizID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis)
+	writeSubmissionEvent(w, stream.Snapshot)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-stream.Events:
+			if !ok {
+				// Dropped for falling behind; the client reconnects for a
+				// fresh snapshot.
+				return
+			}
+			writeSubmissionEvent(w, event)
+			flusher.Flush()
+			if event.Type == quiz.SubmissionEventClosing {
+				return
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSubmissionEvent(w io.Writer, event quiz.SubmissionEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+}
@@ -0,0 +1,127 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// HandleQuestionTags reads (GET) or replaces (PUT) the tags of a stored
+// question. Tags are what POST /quizzes/from-bank samples by.
+func (a *API) HandleQuestionTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeMethodNotAllowed(w, http.MethodGet+", "+http.MethodPut)
+		return
+	}
+	if r.Method == http.MethodPut && !a.requireAdmin(w, r) {
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	questionID := strings.TrimSpace(r.PathValue("question_id"))
+	var (
+		tags []string
+		err  error
+	)
+	if r.Method == http.MethodGet {
+		tags, err = a.service.QuestionTags(r.Context(), questionID)
+	} else {
+		var request questionTagsRequest
+		if err := decodeJSONBody(w, r, &request); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		tags, err = a.service.SetQuestionTags(r.Context(), questionID, request.Tags)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, questionTagsResponse{QuestionID: questionID, Tags: tags})
+}
+
+// HandleCreateBankQuizzes creates one or more quizzes from stored questions
+// that match the requested tags and difficulty. OpenTriviaDB is never called,
+// so it works offline and for questions imported by hand.
+func (a *API) HandleCreateBankQuizzes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+
+	request := bankQuizRequest{}
+	if r.ContentLength > 0 {
+		defer r.Body.Close()
+		if err := decodeJSONBody(w, r, &request); err != nil && !errors.Is(err, io.EOF) {
+			writeBodyError(w, err)
+			return
+		}
+	}
+	if request.SecondsPerQuestion < 0 || request.SecondsPerQuestion > maxSecondsPerQuestion {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("seconds_per_question must be between 0 and %d", maxSecondsPerQuestion)})
+		return
+	}
+	if request.QuizCount < 0 || request.QuizCount > quiz.MaxBankQuizzes {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("quiz_count must be between 1 and %d", quiz.MaxBankQuizzes)})
+		return
+	}
+	difficulty, err := quiz.ParseDifficulty(request.Difficulty)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	questionCount := normalizeQuestionCount(request.QuestionCount, defaultQuestionCount, maxQuestionCount)
+
+	options := quiz.BankQuizOptions{
+		BankFilter:    quiz.BankFilter{Tags: request.Tags, Difficulty: difficulty},
+		QuestionCount: questionCount,
+		QuizCount:     request.QuizCount,
+		QuizOptions:   quiz.QuizOptions{QuestionTimeLimit: time.Duration(request.SecondsPerQuestion) * time.Second, Draft: request.Draft},
+	}
+	if request.ClosesAt != nil {
+		options.ClosesAt = *request.ClosesAt
+	}
+	created, err := a.service.CreateBankQuizzes(r.Context(), options)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := bankQuizResponse{Quizzes: make([]createQuizResponse, 0, len(created))}
+	for _, item := range created {
+		a.rememberQuestions(item.Questions)
+		questionIDs := make([]string, 0, len(item.Questions))
+		contentHashes := make([]string, 0, len(item.Questions))
+		for _, question := range item.Questions {
+			questionIDs = append(questionIDs, question.QuestionID)
+			contentHashes = append(contentHashes, a.service.ContentHash(question))
+		}
+		metadata := item.Metadata
+		response.Quizzes = append(response.Quizzes, createQuizResponse{
+			QuizID:        metadata.QuizID,
+			QuestionCount: metadata.QuestionCount,
+			CreatedAt:     metadata.CreatedAt,
+			State:         a.service.QuizState(metadata),
+			ClosesAt:      optionalTime(metadata.ClosesAt),
+			Origin:        toQuizOriginResponse(metadata.Origin),
+			QuestionIDs:   questionIDs,
+			ContentHashes: contentHashes,
+			Warnings:      questionCountWarnings(request.QuestionCount, questionCount, metadata.QuestionCount),
+
+			SecondsPerQuestion: int(metadata.QuestionTimeLimit / time.Second),
+		})
+	}
+	writeJSON(w, http.StatusCreated, response)
+}
@@ -3,24 +3,108 @@ package httpapi
 import (
 	"time"
 
+	"quiz-app/internal/bundles"
 	"quiz-app/internal/quiz"
 )
 
+// Warning codes are stable identifiers clients can branch on; messages are for
+// humans and may change.
+const (
+	warningNotPersisted        = "not_persisted"
+	warningQuestionCountCapped = "question_count_capped"
+	warningProviderShortfall   = "provider_shortfall"
+	warningDifficultyShortfall = "difficulty_shortfall"
+	warningSettingsNotApplied  = "settings_not_applied"
+	warningProviderFallback    = "provider_fallback"
+	warningAnswerKeyWithheld   = "answer_key_withheld"
+)
+
+// apiWarning reports a soft failure: the request succeeded, but not exactly as
+// asked. Field names the request parameter involved, when there is one.
+type apiWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
 type questionsResponse struct {
-	QuizID        string             `json:"quiz_id"`
-	QuestionCount int                `json:"question_count"`
-	Questions     []questionResponse `json:"questions"`
+	QuizID        string                `json:"quiz_id"`
+	QuestionCount int                   `json:"question_count"`
+	State         quiz.QuizState        `json:"state"`
+	Locked        bool                  `json:"locked"`
+	ClosesAt      *time.Time            `json:"closes_at,omitempty"`
+	Practice      bool                  `json:"practice,omitempty"`
+	Adaptive      bool                  `json:"adaptive,omitempty"`
+	Scoring       scoringPolicyResponse `json:"scoring"`
+	// From is the position of the first question in Questions when the
+	// request asked for questions from a position on.
+	From      int                `json:"from,omitempty"`
+	Questions []questionResponse `json:"questions"`
+	Warnings  []apiWarning       `json:"warnings,omitempty"`
+
+	// SecondsPerQuestion is the per-question countdown clients should show;
+	// omitted for untimed quizzes.
+	SecondsPerQuestion int                 `json:"seconds_per_question,omitempty"`
+	Origin             *quizOriginResponse `json:"origin,omitempty"`
+
+	// Creation details, set only when this request created the quiz.
+	Created                bool       `json:"created,omitempty"`
+	CreatedAt              *time.Time `json:"created_at,omitempty"`
+	RequestedQuestionCount int        `json:"requested_question_count,omitempty"`
+	ActualQuestionCount    int        `json:"actual_question_count,omitempty"`
+}
+
+// scoringPolicyResponse lets clients describe the scoring rules and decide
+// whether to expect correct answers in results.
+type scoringPolicyResponse struct {
+	CorrectPoints       float64 `json:"correct_points"`
+	IncorrectPoints     float64 `json:"incorrect_points"`
+	AttemptsPerQuestion int     `json:"attempts_per_question"`
+	RevealPolicy        string  `json:"reveal_policy"`
+	// SpeedBonus is set when fast correct answers earn extra points.
+	SpeedBonus *speedBonusResponse `json:"speed_bonus,omitempty"`
+	// ServerScored is set when the server never serves correct answers, so
+	// clients take every verdict from the results of POST /responses.
+	ServerScored bool `json:"server_scored,omitempty"`
+}
+
+type speedBonusResponse struct {
+	MaxPoints     float64 `json:"max_points"`
+	WindowSeconds float64 `json:"window_seconds"`
+	Curve         string  `json:"curve"`
 }
 
 type questionResponse struct {
-	QuestionID    string        `json:"question_id"`
-	Question      string        `json:"question"`
-	Options       []quiz.Option `json:"options"`
-	CorrectIndex  *int          `json:"correct_index,omitempty"`
-	AttemptStatus string        `json:"attempt_status"`
-	AttemptScore  *float64      `json:"attempt_score,omitempty"`
+	QuestionID string        `json:"question_id"`
+	Question   string        `json:"question"`
+	Options    []quiz.Option `json:"options"`
+	// Type is omitted for single-answer questions.
+	Type         quiz.QuestionKind `json:"type,omitempty"`
+	CorrectIndex *int              `json:"correct_index,omitempty"`
+	// CorrectIndexes lists every answer of a multi-select question, when
+	// CorrectIndex is shown.
+	CorrectIndexes []int    `json:"correct_indexes,omitempty"`
+	AttemptStatus  string   `json:"attempt_status"`
+	AttemptScore   *float64 `json:"attempt_score,omitempty"`
+	Voided         bool     `json:"voided,omitempty"`
+	// ContentHash is echoed back with answers; see quiz.Service.ContentHash.
+	ContentHash string `json:"content_hash"`
+	// Nonce is echoed back with the answer when the service issues serve
+	// nonces; see quiz.ServiceOptions.ServeNonces.
+	Nonce string `json:"nonce,omitempty"`
+	// Language is set when the text was served in the requested ?lang=.
+	Language string `json:"language,omitempty"`
+	// Languages lists the translations available for the question.
+	Languages []string `json:"languages,omitempty"`
+}
+
+type questionSearchResponse struct {
+	Query   string                `json:"query"`
+	Results []quiz.PublicQuestion `json:"results"`
 }
 
+// responsesRequest's Username may be left out when the request carries an
+// account token, whose username is used instead.
 type responsesRequest struct {
 	QuizID    string                   `json:"quiz_id,omitempty"`
 	Username  string                   `json:"username,omitempty"`
@@ -29,17 +113,389 @@ type responsesRequest struct {
 
 type responsesResponse struct {
 	Results  []quiz.ResponseResult `json:"results"`
-	Warnings []string              `json:"warnings,omitempty"`
+	Warnings []apiWarning          `json:"warnings,omitempty"`
+}
+
+// onBehalfRequest is an admin's proxy submission. Admin names whoever is
+// entering the answers, since admin tokens are shared.
+type onBehalfRequest struct {
+	Username  string                   `json:"username"`
+	Admin     string                   `json:"admin"`
+	Responses []quiz.SubmittedResponse `json:"responses"`
+}
+
+type auditLogResponse struct {
+	QuizID  string                  `json:"quiz_id"`
+	Entries []auditLogResponseEntry `json:"entries"`
+}
+
+type auditLogResponseEntry struct {
+	At       time.Time `json:"at"`
+	Admin    string    `json:"admin"`
+	Action   string    `json:"action"`
+	Username string    `json:"username"`
+	Detail   string    `json:"detail"`
+}
+
+type hostRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	// Admin names who made the change when the admin token is used; a host's
+	// own username is recorded otherwise.
+	Admin string `json:"admin"`
+}
+
+type hostsResponse struct {
+	QuizID string              `json:"quiz_id"`
+	Hosts  []hostResponseEntry `json:"hosts"`
+	Added  *addedHostResponse  `json:"added,omitempty"`
+}
+
+type hostResponseEntry struct {
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	AddedBy  string    `json:"added_by"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+type addedHostResponse struct {
+	hostResponseEntry
+	HostToken string `json:"host_token,omitempty"`
+}
+
+type answerPositionsResponse struct {
+	QuizID    string                 `json:"quiz_id"`
+	Questions int                    `json:"questions"`
+	Letters   []answerLetterResponse `json:"letters"`
+	Outliers  []string               `json:"outliers"`
+	// Moved and Kept are set by POST only.
+	Moved []movedQuestionResponse `json:"moved,omitempty"`
+	Kept  []string                `json:"kept,omitempty"`
+}
+
+type answerLetterResponse struct {
+	Letter   string  `json:"letter"`
+	Correct  int     `json:"correct"`
+	Expected float64 `json:"expected"`
+	Outlier  bool    `json:"outlier"`
+}
+
+type movedQuestionResponse struct {
+	From string `json:"from_question_id"`
+	To   string `json:"to_question_id"`
+}
+
+type bankQuestionsRequest struct {
+	Questions []createQuizQuestion `json:"questions"`
+}
+
+type bankQuestionsResponse struct {
+	QuestionIDs []string `json:"question_ids"`
+}
+
+type bankEvaluateRequest struct {
+	Responses []quiz.SubmittedResponse `json:"responses"`
+}
+
+type bankStatsResponse struct {
+	Size         int   `json:"size"`
+	MaxQuestions int   `json:"max_questions"`
+	TTLSeconds   int64 `json:"ttl_seconds"`
+	Hits         int64 `json:"hits"`
+	Misses       int64 `json:"misses"`
+	StoreHits    int64 `json:"store_hits"`
+	StoreErrors  int64 `json:"store_errors"`
+	Evictions    int64 `json:"evictions"`
+	Expirations  int64 `json:"expirations"`
 }
 
 type createQuizRequest struct {
 	QuestionCount int `json:"question_count"`
+	// Username applies that user's saved preferences to the question count
+	// and difficulty when the request leaves them unset.
+	Username  string               `json:"username,omitempty"`
+	Questions []createQuizQuestion `json:"questions,omitempty"`
+	// Author credits supplied questions to a user for performance reporting.
+	Author string `json:"author,omitempty"`
+	// Practice makes wrong answers come back with per-option feedback.
+	Practice bool `json:"practice,omitempty"`
+	// Adaptive treats the fetched questions as a pool served one at a time by
+	// difficulty. It cannot be combined with supplied questions.
+	Adaptive bool `json:"adaptive,omitempty"`
+	// SecondsPerQuestion sets a per-question countdown for clients.
+	SecondsPerQuestion int `json:"seconds_per_question,omitempty"`
+	// DifficultyMix asks for a number of fetched questions per difficulty
+	// instead of question_count random ones.
+	DifficultyMix map[string]int `json:"difficulty_mix,omitempty"`
+	// Category, Difficulty, and Type narrow fetched questions to one
+	// OpenTriviaDB category ID, one level, and multiple or boolean questions.
+	// Difficulty cannot be combined with difficulty_mix.
+	Category   int    `json:"category,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Type       string `json:"type,omitempty"`
+	// Draft creates the quiz as a draft that takes no answers until a host
+	// activates it. ClosesAt is when the quiz stops taking answers.
+	Draft    bool       `json:"draft,omitempty"`
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+}
+
+// quizStateRequest changes a quiz's state. Omitted fields keep their value;
+// clear_closes_at removes the deadline.
+type quizStateRequest struct {
+	State         string     `json:"state,omitempty"`
+	ClosesAt      *time.Time `json:"closes_at,omitempty"`
+	ClearClosesAt bool       `json:"clear_closes_at,omitempty"`
+}
+
+type quizStateResponse struct {
+	QuizID   string         `json:"quiz_id"`
+	State    quiz.QuizState `json:"state"`
+	ClosesAt *time.Time     `json:"closes_at,omitempty"`
+}
+
+// removeQuizResponse reports an archived or deleted quiz. Attempts counts
+// the individual answers removed; an archived quiz keeps their totals.
+type removeQuizResponse struct {
+	QuizID     string     `json:"quiz_id"`
+	Mode       string     `json:"mode"`
+	Attempts   int        `json:"attempts"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// importQuizResponse reports a question bank import. Quiz is set once the quiz
+// was created; Errors lists the questions left out, by line.
+type importQuizResponse struct {
+	Error         string                `json:"error,omitempty"`
+	Format        string                `json:"format"`
+	QuestionCount int                   `json:"question_count"`
+	Errors        []importErrorResponse `json:"errors,omitempty"`
+	Quiz          *createQuizResponse   `json:"quiz,omitempty"`
+}
+
+type importErrorResponse struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// createQuizQuestion is a caller-supplied question. Options keep their order and
+// are lettered A, B, C... by position.
+// quizBundle is a quiz definition in a form another deployment can recreate
+// with POST /quizzes/import-bundle. Without answers it is only good for
+// sharing what a quiz asks.
+type quizBundle struct {
+	Format          string               `json:"format"`
+	Version         int                  `json:"version"`
+	ExportedAt      time.Time            `json:"exported_at"`
+	SourceQuizID    string               `json:"source_quiz_id,omitempty"`
+	IncludesAnswers bool                 `json:"includes_answers"`
+	Settings        quizBundleSettings   `json:"settings"`
+	Questions       []quizBundleQuestion `json:"questions"`
+}
+
+type quizBundleSettings struct {
+	Practice           bool `json:"practice,omitempty"`
+	Adaptive           bool `json:"adaptive,omitempty"`
+	SecondsPerQuestion int  `json:"seconds_per_question,omitempty"`
+	// Leaderboard is omitted when the quiz uses the server's defaults.
+	Leaderboard *leaderboardSettingsRequest `json:"leaderboard,omitempty"`
+}
+
+// quizBundleQuestion is createQuizQuestion with the answer optional, so a
+// bundle exported without answers has none.
+type quizBundleQuestion struct {
+	Question     string   `json:"question"`
+	Options      []string `json:"options"`
+	CorrectIndex *int     `json:"correct_index,omitempty"`
+	// Type and CorrectIndexes are as in createQuizQuestion.
+	Type           string                      `json:"type,omitempty"`
+	CorrectIndexes []int                       `json:"correct_indexes,omitempty"`
+	Feedback       []string                    `json:"feedback,omitempty"`
+	Difficulty     string                      `json:"difficulty,omitempty"`
+	Category       string                      `json:"category,omitempty"`
+	Translations   map[string]quiz.Translation `json:"translations,omitempty"`
+	// Source is where the question came from, such as opentdb or custom. An
+	// import keeps it.
+	Source string `json:"source,omitempty"`
+	// Withheld marks a question whose source does not allow copying it: only
+	// QuestionID and Source are set, and an import looks the question up in
+	// its own store.
+	Withheld   bool   `json:"withheld,omitempty"`
+	QuestionID string `json:"question_id,omitempty"`
+}
+
+type createQuizQuestion struct {
+	Question     string   `json:"question"`
+	Options      []string `json:"options"`
+	CorrectIndex int      `json:"correct_index"`
+	// Type is single (the default), multi_select, or true_false. A
+	// multi_select question lists its answers in CorrectIndexes; a
+	// true_false one has the options True and False, which may be omitted.
+	Type           string `json:"type,omitempty"`
+	CorrectIndexes []int  `json:"correct_indexes,omitempty"`
+	// Feedback optionally explains each option, by position. It is only shown
+	// after a wrong answer in practice quizzes or with -reveal after_answer.
+	Feedback []string `json:"feedback,omitempty"`
+	// Difficulty is easy, medium, or hard; empty leaves the question untagged.
+	Difficulty string `json:"difficulty,omitempty"`
+	// Translations maps a language tag to the prompt and option texts in that
+	// language, options in the same order as Options.
+	Translations map[string]quiz.Translation `json:"translations,omitempty"`
 }
 
 type createQuizResponse struct {
-	QuizID        string    `json:"quiz_id"`
-	QuestionCount int       `json:"question_count"`
-	CreatedAt     time.Time `json:"created_at"`
+	QuizID        string         `json:"quiz_id"`
+	QuestionCount int            `json:"question_count"`
+	CreatedAt     time.Time      `json:"created_at"`
+	State         quiz.QuizState `json:"state"`
+	ClosesAt      *time.Time     `json:"closes_at,omitempty"`
+	Practice      bool           `json:"practice,omitempty"`
+	Adaptive      bool           `json:"adaptive,omitempty"`
+	QuestionIDs   []string       `json:"question_ids,omitempty"`
+	// ContentHashes lines up with QuestionIDs.
+	ContentHashes []string     `json:"content_hashes,omitempty"`
+	Warnings      []apiWarning `json:"warnings,omitempty"`
+
+	SecondsPerQuestion int `json:"seconds_per_question,omitempty"`
+	// DifficultyMix reports each requested level of a difficulty mix.
+	DifficultyMix []difficultyBucketResponse `json:"difficulty_mix,omitempty"`
+	// Origin records how the quiz was created; omitted for quizzes stored
+	// before origins were recorded.
+	Origin *quizOriginResponse `json:"origin,omitempty"`
+}
+
+type quizOriginResponse struct {
+	Provider      string                  `json:"provider"`
+	FallbackFrom  string                  `json:"fallback_from,omitempty"`
+	DifficultyMix map[quiz.Difficulty]int `json:"difficulty_mix,omitempty"`
+	Seed          int64                   `json:"seed,omitempty"`
+	Category      int                     `json:"category,omitempty"`
+	Type          string                  `json:"type,omitempty"`
+}
+
+type rematchRequest struct {
+	// Questions is "fresh" or "same"; empty picks by how the quiz was created.
+	Questions string `json:"questions"`
+}
+
+type difficultyBucketResponse struct {
+	Difficulty quiz.Difficulty `json:"difficulty"`
+	Requested  int             `json:"requested"`
+	Delivered  int             `json:"delivered"`
+}
+
+type adaptiveQuestionResponse struct {
+	QuestionID  string          `json:"question_id"`
+	Question    string          `json:"question"`
+	Options     []quiz.Option   `json:"options"`
+	Difficulty  quiz.Difficulty `json:"difficulty,omitempty"`
+	ContentHash string          `json:"content_hash"`
+	Nonce       string          `json:"nonce,omitempty"`
+	Language    string          `json:"language,omitempty"`
+	Languages   []string        `json:"languages,omitempty"`
+}
+
+type nextQuestionResponse struct {
+	QuizID string `json:"quiz_id"`
+	Done   bool   `json:"done"`
+	// Question is omitted once the pool is used up.
+	Question         *adaptiveQuestionResponse `json:"question,omitempty"`
+	TargetDifficulty quiz.Difficulty           `json:"target_difficulty,omitempty"`
+	AnsweredCount    int                       `json:"answered_count"`
+	CorrectCount     int                       `json:"correct_count"`
+	Accuracy         float64                   `json:"accuracy"`
+}
+
+// attemptReviewResponse is one player's answers in a quiz for a post-quiz
+// review. correct_letter and correct_text are omitted until revealed.
+type attemptReviewResponse struct {
+	QuizID        string                     `json:"quiz_id"`
+	Username      string                     `json:"username"`
+	Completed     bool                       `json:"completed"`
+	Locked        bool                       `json:"locked"`
+	Revealed      bool                       `json:"answers_revealed"`
+	TotalScore    float64                    `json:"total_score"`
+	AnsweredCount int                        `json:"answered_count"`
+	QuestionCount int                        `json:"question_count"`
+	Questions     []reviewedQuestionResponse `json:"questions"`
+}
+
+type reviewedQuestionResponse struct {
+	QuestionID    string            `json:"question_id"`
+	Question      string            `json:"question"`
+	Options       []quiz.Option     `json:"options"`
+	Type          quiz.QuestionKind `json:"type,omitempty"`
+	Voided        bool              `json:"voided,omitempty"`
+	ChosenLetter  string            `json:"chosen_letter,omitempty"`
+	Status        string            `json:"status"`
+	Score         float64           `json:"score"`
+	AnsweredAt    *time.Time        `json:"answered_at,omitempty"`
+	CorrectLetter string            `json:"correct_letter,omitempty"`
+	CorrectText   string            `json:"correct_text,omitempty"`
+}
+
+type answerKeyResponse struct {
+	QuizID        string              `json:"quiz_id"`
+	QuestionCount int                 `json:"question_count"`
+	Practice      bool                `json:"practice,omitempty"`
+	Adaptive      bool                `json:"adaptive,omitempty"`
+	Questions     []answerKeyQuestion `json:"questions"`
+}
+
+type serveLogResponse struct {
+	QuizID string                  `json:"quiz_id"`
+	Serves []serveLogResponseEntry `json:"serves"`
+}
+
+type serveLogResponseEntry struct {
+	Username          string     `json:"username"`
+	FirstServedAt     *time.Time `json:"first_served_at,omitempty"`
+	LastServedAt      *time.Time `json:"last_served_at,omitempty"`
+	FetchCount        int        `json:"fetch_count"`
+	AnswerKeyServedAt *time.Time `json:"answer_key_served_at,omitempty"`
+	NoncesIssued      int        `json:"nonces_issued,omitempty"`
+	AnsweredCount     int        `json:"answered_count"`
+}
+
+type answerKeyQuestion struct {
+	Position     int               `json:"position"`
+	QuestionID   string            `json:"question_id"`
+	Question     string            `json:"question"`
+	Options      []quiz.Option     `json:"options"`
+	Type         quiz.QuestionKind `json:"type,omitempty"`
+	CorrectIndex int               `json:"correct_index"`
+	// CorrectIndexes, and the letters and texts in CorrectLetter and
+	// CorrectText, list every answer of a multi-select question.
+	CorrectIndexes []int           `json:"correct_indexes,omitempty"`
+	CorrectLetter  string          `json:"correct_letter"`
+	CorrectText    string          `json:"correct_text"`
+	Difficulty     quiz.Difficulty `json:"difficulty,omitempty"`
+	Feedback       []string        `json:"feedback,omitempty"`
+	Voided         bool            `json:"voided,omitempty"`
+}
+
+type voidQuestionResponse struct {
+	QuizID     string `json:"quiz_id"`
+	QuestionID string `json:"question_id"`
+	Voided     bool   `json:"voided"`
+}
+
+type completionWebhookRequest struct {
+	URL                string   `json:"url"`
+	Participants       []string `json:"participants,omitempty"`
+	ThresholdPercent   int      `json:"threshold_percent,omitempty"`
+	Username           string   `json:"username,omitempty"`
+	LeaderboardChanges bool     `json:"leaderboard_changes,omitempty"`
+	FinalResults       bool     `json:"final_results,omitempty"`
+}
+
+type completionWebhookResponse struct {
+	QuizID             string   `json:"quiz_id"`
+	URL                string   `json:"url"`
+	Participants       []string `json:"participants,omitempty"`
+	ThresholdPercent   int      `json:"threshold_percent,omitempty"`
+	Username           string   `json:"username,omitempty"`
+	LeaderboardChanges bool     `json:"leaderboard_changes,omitempty"`
+	FinalResults       bool     `json:"final_results,omitempty"`
 }
 
 type leaderboardEntryResponse struct {
@@ -47,23 +503,353 @@ type leaderboardEntryResponse struct {
 	TotalScore       float64   `json:"total_score"`
 	AnsweredCount    int       `json:"answered_count"`
 	LastSubmissionAt time.Time `json:"last_submission_at"`
+	// Anonymous marks a participant shown under a pseudonym.
+	Anonymous bool `json:"anonymous,omitempty"`
 }
 
 type leaderboardResponse struct {
 	QuizID      string                     `json:"quiz_id"`
+	Category    string                     `json:"category,omitempty"`
 	Leaderboard []leaderboardEntryResponse `json:"leaderboard"`
+
+	// FrozenAt and RevealAt are set during a leaderboard freeze: entries are
+	// the standings as of FrozenAt until RevealAt.
+	FrozenAt *time.Time `json:"frozen_at,omitempty"`
+	RevealAt *time.Time `json:"reveal_at,omitempty"`
+}
+
+// leaderboardSettingsRequest replaces every setting; omitted fields reset.
+type leaderboardSettingsRequest struct {
+	DefaultLimit  int        `json:"default_limit"`
+	FreezeSeconds int        `json:"freeze_seconds"`
+	LocksAt       *time.Time `json:"locks_at,omitempty"`
+	Tiebreak      string     `json:"tiebreak,omitempty"`
+}
+
+type leaderboardSettingsResponse struct {
+	QuizID        string     `json:"quiz_id"`
+	DefaultLimit  int        `json:"default_limit"`
+	FreezeSeconds int        `json:"freeze_seconds"`
+	LocksAt       *time.Time `json:"locks_at,omitempty"`
+	Tiebreak      string     `json:"tiebreak"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// rosterRequest replaces the whole roster; omitted students are removed.
+type rosterRequest struct {
+	Restricted        bool                   `json:"restricted"`
+	GenerateJoinCodes bool                   `json:"generate_join_codes"`
+	Students          []rosterStudentRequest `json:"students"`
+}
+
+type rosterStudentRequest struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+type rosterResponse struct {
+	QuizID     string                   `json:"quiz_id"`
+	Restricted bool                     `json:"restricted"`
+	UpdatedAt  *time.Time               `json:"updated_at,omitempty"`
+	Students   []rosterStandingResponse `json:"students"`
+}
+
+type rosterStandingResponse struct {
+	Username         string     `json:"username"`
+	Name             string     `json:"name,omitempty"`
+	JoinCode         string     `json:"join_code,omitempty"`
+	OnRoster         bool       `json:"on_roster"`
+	Rank             int        `json:"rank,omitempty"`
+	TotalScore       float64    `json:"total_score"`
+	AnsweredCount    int        `json:"answered_count"`
+	LastSubmissionAt *time.Time `json:"last_submission_at,omitempty"`
+	ProxyAnswers     int        `json:"proxy_answers,omitempty"`
+}
+
+type joinQuizRequest struct {
+	JoinCode string `json:"join_code"`
+}
+
+type joinQuizResponse struct {
+	QuizID   string `json:"quiz_id"`
+	Username string `json:"username"`
+	Name     string `json:"name,omitempty"`
 }
 
 type activeQuizResponse struct {
-	QuizID        string    `json:"quiz_id"`
-	QuestionCount int       `json:"question_count"`
-	CreatedAt     time.Time `json:"created_at"`
+	QuizID        string                `json:"quiz_id"`
+	QuestionCount int                   `json:"question_count"`
+	CreatedAt     time.Time             `json:"created_at"`
+	State         quiz.QuizState        `json:"state"`
+	Locked        bool                  `json:"locked"`
+	ClosesAt      *time.Time            `json:"closes_at,omitempty"`
+	Scoring       scoringPolicyResponse `json:"scoring"`
+	Origin        *quizOriginResponse   `json:"origin,omitempty"`
 }
 
 type activeQuizzesResponse struct {
 	Quizzes []activeQuizResponse `json:"quizzes"`
 }
 
+type statsOverviewResponse struct {
+	GeneratedAt          time.Time               `json:"generated_at"`
+	QuizzesToday         int                     `json:"quizzes_today"`
+	SubmissionsPerMinute float64                 `json:"submissions_per_minute"`
+	ActiveUsersLastHour  int                     `json:"active_users_last_hour"`
+	TopCategories        []categoryCountResponse `json:"top_categories"`
+}
+
+type categoryCountResponse struct {
+	Category      string `json:"category"`
+	QuestionCount int    `json:"question_count"`
+}
+
+type bookmarkRequest struct {
+	QuestionID string `json:"question_id"`
+}
+
+type bookmarkResponse struct {
+	QuestionID string        `json:"question_id"`
+	Question   string        `json:"question"`
+	Options    []quiz.Option `json:"options"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type bookmarksResponse struct {
+	Username  string             `json:"username"`
+	Bookmarks []bookmarkResponse `json:"bookmarks"`
+}
+
+type practiceQuizRequest struct {
+	QuestionCount int `json:"question_count"`
+}
+
+// questionTagsRequest replaces every tag of a question; an empty list clears
+// them.
+type questionTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type questionTagsResponse struct {
+	QuestionID string   `json:"question_id"`
+	Tags       []string `json:"tags"`
+}
+
+// bankQuizRequest samples stored questions that carry every tag and, when
+// set, the difficulty. QuizCount quizzes of QuestionCount questions each are
+// created, none sharing a question.
+type bankQuizRequest struct {
+	Tags          []string `json:"tags,omitempty"`
+	Difficulty    string   `json:"difficulty,omitempty"`
+	QuestionCount int      `json:"question_count"`
+	QuizCount     int      `json:"quiz_count,omitempty"`
+	// SecondsPerQuestion, Draft, and ClosesAt apply to every quiz, as on
+	// POST /quizzes.
+	SecondsPerQuestion int        `json:"seconds_per_question,omitempty"`
+	Draft              bool       `json:"draft,omitempty"`
+	ClosesAt           *time.Time `json:"closes_at,omitempty"`
+}
+
+type bankQuizResponse struct {
+	Quizzes []createQuizResponse `json:"quizzes"`
+}
+
+type questionPerformanceResponse struct {
+	QuestionID      string  `json:"question_id"`
+	Question        string  `json:"question"`
+	QuizCount       int     `json:"quiz_count"`
+	AttemptCount    int     `json:"attempt_count"`
+	CorrectCount    int     `json:"correct_count"`
+	CorrectnessRate float64 `json:"correctness_rate"`
+	ReportCount     int     `json:"report_count"`
+}
+
+type authorPerformanceResponse struct {
+	Author    string                        `json:"author"`
+	Questions []questionPerformanceResponse `json:"questions"`
+}
+
+type questionReportRequest struct {
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+}
+
+type questionReportResponse struct {
+	QuestionID string `json:"question_id"`
+	Status     string `json:"status"`
+}
+
+type retirementReviewResponse struct {
+	QuestionID      string        `json:"question_id"`
+	Question        string        `json:"question"`
+	Options         []quiz.Option `json:"options"`
+	CorrectIndex    int           `json:"correct_index"`
+	Status          string        `json:"status"`
+	AttemptCount    int           `json:"attempt_count"`
+	CorrectCount    int           `json:"correct_count"`
+	CorrectnessRate float64       `json:"correctness_rate"`
+	FlaggedAt       time.Time     `json:"flagged_at"`
+	DecidedAt       *time.Time    `json:"decided_at,omitempty"`
+}
+
+type retirementQueueResponse struct {
+	Status    string                     `json:"status"`
+	Questions []retirementReviewResponse `json:"questions"`
+}
+
+type retirementDecisionRequest struct {
+	Decision string `json:"decision"`
+}
+
+type adminQuizResponse struct {
+	QuizID                 string              `json:"quiz_id"`
+	QuestionCount          int                 `json:"question_count"`
+	RequestedQuestionCount int                 `json:"requested_question_count,omitempty"`
+	CreatedAt              time.Time           `json:"created_at"`
+	State                  quiz.QuizState      `json:"state"`
+	Locked                 bool                `json:"locked"`
+	ClosesAt               *time.Time          `json:"closes_at,omitempty"`
+	AttemptCount           int                 `json:"attempt_count"`
+	ParticipantCount       int                 `json:"participant_count"`
+	StorageBytes           int64               `json:"storage_bytes"`
+	LastActivityAt         time.Time           `json:"last_activity_at"`
+	Origin                 *quizOriginResponse `json:"origin,omitempty"`
+}
+
+type adminQuizzesResponse struct {
+	Quizzes []adminQuizResponse `json:"quizzes"`
+	Total   int                 `json:"total"`
+	Limit   int                 `json:"limit"`
+	Offset  int                 `json:"offset"`
+	Sort    string              `json:"sort"`
+	Order   string              `json:"order"`
+}
+
 type errorResponse struct {
 	Error string `json:"error"`
+	// RequestID identifies a failed request in the server log.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type bundleResponse struct {
+	bundles.Info
+	Loaded bool `json:"loaded"`
+}
+
+type bundlesResponse struct {
+	Bundles []bundleResponse `json:"bundles"`
+}
+
+type profileRequest struct {
+	Anonymous *bool `json:"anonymous"`
+}
+
+type profileResponse struct {
+	Username  string     `json:"username"`
+	Anonymous bool       `json:"anonymous"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// preferencesRequest replaces a user's quiz defaults; omitted fields clear them.
+type preferencesRequest struct {
+	QuestionCount int    `json:"question_count"`
+	Difficulty    string `json:"difficulty"`
+}
+
+type preferencesResponse struct {
+	Username      string     `json:"username"`
+	QuestionCount int        `json:"question_count,omitempty"`
+	Difficulty    string     `json:"difficulty,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// userStatsResponse days are dates in Timezone, the deployment's streak time
+// zone.
+type userStatsResponse struct {
+	Username      string `json:"username"`
+	CurrentStreak int    `json:"current_streak"`
+	LongestStreak int    `json:"longest_streak"`
+	LastActiveDay string `json:"last_active_day,omitempty"`
+	Timezone      string `json:"timezone"`
+	// Totals is omitted when the store keeps no per-quiz summaries.
+	Totals *userTotalsResponse `json:"totals,omitempty"`
+}
+
+type userTotalsResponse struct {
+	QuizzesPlayed int     `json:"quizzes_played"`
+	AnsweredCount int     `json:"answered_count"`
+	CorrectCount  int     `json:"correct_count"`
+	TotalScore    float64 `json:"total_score"`
+	ArchivedCount int     `json:"archived_count"`
+}
+
+type userAttemptsResponse struct {
+	Username string                `json:"username"`
+	Attempts []userAttemptResponse `json:"attempts"`
+}
+
+type userAttemptResponse struct {
+	QuizID          string     `json:"quiz_id"`
+	QuestionCount   int        `json:"question_count"`
+	Practice        bool       `json:"practice,omitempty"`
+	TotalScore      float64    `json:"total_score"`
+	AnsweredCount   int        `json:"answered_count"`
+	CorrectCount    int        `json:"correct_count"`
+	ArchivedCount   int        `json:"archived_count"`
+	FirstAnsweredAt time.Time  `json:"first_answered_at"`
+	LastAnsweredAt  time.Time  `json:"last_answered_at"`
+	QuizCreatedAt   *time.Time `json:"quiz_created_at,omitempty"`
+}
+
+// accountRequest registers an account or logs in to one.
+type accountRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type accountResponse struct {
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// loginResponse carries the account token to send as the bearer token.
+type loginResponse struct {
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type identityRequest struct {
+	Email string `json:"email"`
+}
+
+// signingKeyRequest carries a base64-encoded Ed25519 public key.
+type signingKeyRequest struct {
+	PublicKey string `json:"public_key"`
+}
+
+type signingKeyResponse struct {
+	Username     string    `json:"username"`
+	KeyID        string    `json:"key_id"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+type identityChallengeResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// verifyIdentityRequest carries either the emailed code or the magic link's
+// token.
+type verifyIdentityRequest struct {
+	Code  string `json:"code"`
+	Token string `json:"token"`
+}
+
+type identityResponse struct {
+	Username   string     `json:"username"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	// PlayerToken is set only in the response that completes a verification.
+	PlayerToken string `json:"player_token,omitempty"`
 }
@@ -0,0 +1,340 @@
+package httpapi
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// The WebSocket endpoint serves the same leaderboard events as the
+// server-sent event stream, for displays that already speak WebSocket. Only
+// what a viewer needs from RFC 6455 is implemented: unfragmented text frames
+// out, pings, pongs and close frames in. Viewers have nothing to send, so
+// their data frames are read and dropped.
+
+const (
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	websocketOpText  = 0x1
+	websocketOpClose = 0x8
+	websocketOpPing  = 0x9
+	websocketOpPong  = 0xA
+
+	websocketCloseNormal        = 1000
+	websocketCloseGoingAway     = 1001
+	websocketCloseProtocolError = 1002
+	websocketCloseTooBig        = 1009
+	websocketCloseTryAgainLater = 1013
+
+	// websocketMaxFrameBytes bounds a frame from a viewer; a ping or close
+	// never needs more.
+	websocketMaxFrameBytes = 4096
+	// websocketWriteTimeout drops a viewer that stops reading, as a full
+	// subscriber buffer does on the event stream.
+	websocketWriteTimeout = 10 * time.Second
+	// websocketCloseWait is how long a server-initiated close waits for the
+	// viewer's close frame before dropping the connection.
+	websocketCloseWait = time.Second
+)
+
+var (
+	errWebSocketProtocol = errors.New("websocket protocol error")
+	errWebSocketTooBig   = errors.New("websocket frame too large")
+)
+
+// websocketLeaderboardEvent is a leaderboard event with its ID, which the
+// event stream sends on its own id line.
+type websocketLeaderboardEvent struct {
+	ID string `json:"id,omitempty"`
+	quiz.LeaderboardEvent
+}
+
+// HandleLeaderboardWebSocket pushes leaderboard changes over a WebSocket,
+// one JSON text message per event. Messages carry the event's id; a viewer
+// that reconnects with it as last_event_id receives only the changes it
+// missed. On shutdown the closing event is followed by a going-away close
+// frame, and a viewer that falls behind is closed with try-again-later.
+func (a *API) HandleLeaderboardWebSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	if a.service == nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "quiz service unavailable"})
+		return
+	}
+	if !isWebSocketUpgrade(r) {
+		w.Header().Set("Upgrade", "websocket")
+		writeJSON(w, http.StatusUpgradeRequired, errorResponse{Error: "websocket upgrade required; plain HTTP clients can use /leaderboard/stream"})
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeJSON(w, http.StatusUpgradeRequired, errorResponse{Error: "unsupported websocket version"})
+		return
+	}
+	key := strings.TrimSpace(r.Header.Get("Sec-WebSocket-Key"))
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid Sec-WebSocket-Key"})
+		return
+	}
+
+	quizID := strings.TrimSpace(r.PathValue("quiz_id"))
+	if quizID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "quiz_id is required"})
+		return
+	}
+
+	stream, err := a.service.SubscribeLeaderboard(r.Context(), quizID, r.URL.Query().Get("last_event_id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	defer stream.Close()
+
+	conn, buffered, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "websocket unsupported"})
+		return
+	}
+	defer conn.Close()
+	// The server's read and write timeouts were meant for the handshake.
+	_ = conn.SetDeadline(time.Time{})
+
+	ws := &websocketConn{conn: conn, writer: buffered.Writer}
+	handshake := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + websocketAccept(key) + "\r\n\r\n"
+	if ws.write([]byte(handshake)) != nil {
+		return
+	}
+	for _, event := range stream.Replay {
+		if ws.writeEvent(event) != nil {
+			return
+		}
+	}
+
+	// After a hijack the request context no longer ends with the connection,
+	// so the read loop reports when the viewer goes away.
+	gone := make(chan struct{})
+	go ws.readLoop(buffered.Reader, gone)
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-gone:
+			return
+		case event, ok := <-stream.Events:
+			if !ok {
+				// Dropped for falling behind; the viewer resumes from its last ID.
+				ws.close(websocketCloseTryAgainLater, "fell behind; reconnect with last_event_id", gone)
+				return
+			}
+			if ws.writeEvent(event) != nil {
+				return
+			}
+			if event.Type == quiz.LeaderboardEventClosing {
+				ws.close(websocketCloseGoingAway, "server closing", gone)
+				return
+			}
+		case <-keepAlive.C:
+			if ws.writeFrame(websocketOpPing, nil) != nil {
+				return
+			}
+		}
+	}
+}
+
+// isWebSocketUpgrade reports whether r asks to switch to the WebSocket
+// protocol.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Connection", "upgrade") && headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// websocketConn serializes writes from the event loop and the read loop,
+// which answers pings and close frames.
+type websocketConn struct {
+	conn      net.Conn
+	mu        sync.Mutex
+	writer    *bufio.Writer
+	closeSent bool
+}
+
+func (c *websocketConn) write(payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.writeLocked(payload)
+}
+
+func (c *websocketConn) writeLocked(payload []byte) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	if _, err := c.writer.Write(payload); err != nil {
+		return err
+	}
+	return c.writer.Flush()
+}
+
+func (c *websocketConn) writeEvent(event quiz.LeaderboardEvent) error {
+	payload, err := json.Marshal(websocketLeaderboardEvent{ID: event.ID, LeaderboardEvent: event})
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(websocketOpText, payload)
+}
+
+// writeFrame sends one unmasked, unfragmented frame. Nothing is sent after a
+// close frame.
+func (c *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	if opcode == websocketOpClose {
+		c.closeSent = true
+	}
+
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|opcode)
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	return c.writeLocked(append(frame, payload...))
+}
+
+func (c *websocketConn) writeClose(code uint16, reason string) error {
+	// Control frames carry at most 125 bytes, two of them the code.
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	return c.writeFrame(websocketOpClose, append(binary.BigEndian.AppendUint16(nil, code), reason...))
+}
+
+// close starts the closing handshake and waits briefly for the viewer to
+// answer before the connection is dropped.
+func (c *websocketConn) close(code uint16, reason string, gone <-chan struct{}) {
+	if c.writeClose(code, reason) != nil {
+		return
+	}
+	select {
+	case <-gone:
+	case <-time.After(websocketCloseWait):
+	}
+}
+
+// readLoop answers the viewer's pings and close frame and closes gone when
+// the viewer closes or the connection fails.
+func (c *websocketConn) readLoop(reader *bufio.Reader, gone chan<- struct{}) {
+	defer close(gone)
+	for {
+		opcode, payload, err := readWebSocketFrame(reader)
+		switch {
+		case errors.Is(err, errWebSocketTooBig):
+			_ = c.writeClose(websocketCloseTooBig, "viewers may only send control frames")
+			return
+		case errors.Is(err, errWebSocketProtocol):
+			_ = c.writeClose(websocketCloseProtocolError, err.Error())
+			return
+		case err != nil:
+			return
+		}
+		switch opcode {
+		case websocketOpPing:
+			if c.writeFrame(websocketOpPong, payload) != nil {
+				return
+			}
+		case websocketOpClose:
+			// Echo the viewer's code; a close frame may also arrive in
+			// answer to ours, which writeFrame then leaves unanswered.
+			code := uint16(websocketCloseNormal)
+			if len(payload) >= 2 {
+				code = binary.BigEndian.Uint16(payload)
+			}
+			_ = c.writeClose(code, "")
+			return
+		}
+	}
+}
+
+// readWebSocketFrame reads one frame from a viewer and unmasks its payload.
+func readWebSocketFrame(reader io.Reader) (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	final, opcode := header[0]&0x80 != 0, header[0]&0x0F
+	masked, length := header[1]&0x80 != 0, uint64(header[1]&0x7F)
+	switch {
+	case header[0]&0x70 != 0:
+		return 0, nil, fmt.Errorf("%w: reserved bits set", errWebSocketProtocol)
+	case !masked:
+		return 0, nil, fmt.Errorf("%w: client frames must be masked", errWebSocketProtocol)
+	case opcode >= websocketOpClose && (!final || length > 125):
+		return 0, nil, fmt.Errorf("%w: invalid control frame", errWebSocketProtocol)
+	}
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > websocketMaxFrameBytes {
+		return 0, nil, errWebSocketTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(reader, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for idx := range payload {
+		payload[idx] ^= mask[idx%4]
+	}
+	return opcode, payload, nil
+}
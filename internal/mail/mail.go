@@ -0,0 +1,108 @@
+// Package mail sends plain-text email through an SMTP relay.
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Sender delivers one message per call over a fresh connection. Verification
+// mail is rare enough that pooling connections is not worth the complexity.
+type Sender struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+// NewSender returns a Sender for the relay at addr (host:port). Username may be
+// empty for relays that accept mail without authentication.
+func NewSender(addr, from, username, password string) *Sender {
+	return &Sender{addr: addr, from: from, username: username, password: password}
+}
+
+// Send delivers a plain-text message to one recipient. The connection is
+// upgraded with STARTTLS whenever the relay offers it, and the whole exchange is
+// bounded by ctx's deadline, or a default timeout when ctx has none.
+func (s *Sender) Send(ctx context.Context, to, subject, body string) error {
+	for _, header := range []string{s.from, to, subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return errors.New("mail header contains a line break")
+		}
+	}
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return fmt.Errorf("smtp address %q: %w", s.addr, err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message(s.from, to, subject, body)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func message(from, to, subject, body string) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + from + "\r\n")
+	builder.WriteString("To: " + to + "\r\n")
+	builder.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(body)
+	return []byte(builder.String())
+}
@@ -0,0 +1,87 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// fakeRelay accepts one message without TLS or authentication and returns
+// the envelope recipient and the message data.
+func fakeRelay(t *testing.T) (string, <-chan [2]string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan [2]string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		_ = text.PrintfLine("220 localhost ready")
+		var rcpt string
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch command {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250 localhost")
+			case "MAIL":
+				_ = text.PrintfLine("250 OK")
+			case "RCPT":
+				rcpt = line
+				_ = text.PrintfLine("250 OK")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				received <- [2]string{rcpt, string(data)}
+				_ = text.PrintfLine("250 OK")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				return
+			default:
+				_ = text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestSendDeliversMessage(t *testing.T) {
+	addr, received := fakeRelay(t)
+
+	sender := NewSender(addr, "quiz@example.com", "", "")
+	if err := sender.Send(context.Background(), "alice@example.com", "Your code", "Code: 123456\n"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	got := <-received
+	if !strings.Contains(got[0], "<alice@example.com>") {
+		t.Fatalf("RCPT = %q, want alice@example.com", got[0])
+	}
+	for _, want := range []string{"From: quiz@example.com", "To: alice@example.com", "Subject: Your code", "Code: 123456"} {
+		if !strings.Contains(got[1], want) {
+			t.Fatalf("message missing %q:\n%s", want, got[1])
+		}
+	}
+}
+
+func TestSendRejectsHeaderInjection(t *testing.T) {
+	sender := NewSender("127.0.0.1:1", "quiz@example.com", "", "")
+	if err := sender.Send(context.Background(), "alice@example.com\r\nBcc: eve@example.com", "hi", "body"); err == nil {
+		t.Fatalf("expected error for recipient with a line break")
+	}
+}
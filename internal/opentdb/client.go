@@ -3,6 +3,7 @@ package opentdb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,8 +11,9 @@ import (
 )
 
 const (
-	apiURL           = "https://opentdb.com/api.php"
-	defaultAmount    = 10
+	apiURL        = "https://opentdb.com/api.php"
+	defaultAmount = 10
+	// The default RetryPolicy.
 	maxFetchAttempts = 3
 	retryBaseDelay   = 50 * time.Millisecond
 	retryMaxDelay    = 200 * time.Millisecond
@@ -27,6 +29,25 @@ type RawQuestion struct {
 	IncorrectAnswers []string `json:"incorrect_answers"`
 }
 
+// ErrNotEnoughQuestions reports a filtered fetch asking for more questions
+// than OpenTriviaDB has for the filter (response_code 1).
+var ErrNotEnoughQuestions = errors.New("opentdb has not enough questions for the filter")
+
+// OpenTriviaDB response codes other than success.
+const (
+	// responseCodeNoResults answers a query it cannot fill.
+	responseCodeNoResults = 1
+	// responseCodeTokenNotFound answers a session token it does not know,
+	// such as one unused for six hours.
+	responseCodeTokenNotFound = 3
+	// responseCodeTokenEmpty answers a session token that has already seen
+	// every question for the query.
+	responseCodeTokenEmpty = 4
+	// responseCodeRateLimited answers a request that came too soon after the
+	// last one from the same IP.
+	responseCodeRateLimited = 5
+)
+
 type apiResponse struct {
 	ResponseCode int           `json:"response_code"`
 	Results      []RawQuestion `json:"results"`
@@ -34,6 +55,27 @@ type apiResponse struct {
 
 type Client struct {
 	httpClient *http.Client
+	options    ClientOptions
+	session    session
+	breaker    breaker
+	now        func() time.Time
+}
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// SessionToken makes the client hold an OpenTriviaDB session token and
+	// send it with every fetch, so OpenTriviaDB does not repeat a question
+	// until the token has seen every question for the query; the token is
+	// then reset. Without it, quizzes created close together can share
+	// questions.
+	SessionToken bool
+	// Retry says how failed fetches are retried.
+	Retry RetryPolicy
+	// Breaker makes the client fail fast while OpenTriviaDB keeps failing.
+	Breaker BreakerPolicy
+	// Logf, when set, receives each retry with its attempt number, wait
+	// and cause, and each time the circuit opens.
+	Logf func(format string, args ...any)
 }
 
 var defaultHTTPClient = &http.Client{
@@ -43,76 +85,83 @@ var defaultHTTPClient = &http.Client{
 var defaultClient = NewClient(nil)
 
 func NewClient(httpClient *http.Client) *Client {
+	return NewClientWithOptions(httpClient, ClientOptions{})
+}
+
+func NewClientWithOptions(httpClient *http.Client, options ClientOptions) *Client {
 	if httpClient == nil {
 		httpClient = defaultHTTPClient
 	}
-	return &Client{httpClient: httpClient}
+	return &Client{httpClient: httpClient, options: options, now: time.Now}
 }
 
-func FetchQuestions(ctx context.Context, amount int) ([]RawQuestion, error) {
-	return defaultClient.FetchQuestions(ctx, amount)
+func FetchQuestions(ctx context.Context, amount int, filter Filter) ([]RawQuestion, error) {
+	return defaultClient.FetchQuestions(ctx, amount, filter)
 }
 
-func (c *Client) FetchQuestions(ctx context.Context, amount int) ([]RawQuestion, error) {
+// FetchQuestions fetches amount random questions matching filter. With a
+// session token, a token OpenTriviaDB no longer knows is replaced and an
+// exhausted one is reset, and the fetch is then tried once more.
+func (c *Client) FetchQuestions(ctx context.Context, amount int, filter Filter) ([]RawQuestion, error) {
 	if amount <= 0 {
 		amount = defaultAmount
 	}
 
-	reqURL := apiURL + "?amount=" + strconv.Itoa(amount)
-	delay := retryBaseDelay
-	var lastErr error
-
-	for attempt := 1; attempt <= maxFetchAttempts; attempt++ {
-		questions, retryable, err := c.fetchQuestionsOnce(ctx, reqURL)
-		if err == nil {
-			return questions, nil
-		}
-		lastErr = err
-
-		if !retryable || attempt == maxFetchAttempts {
-			break
-		}
-
-		if err := sleepWithContext(ctx, delay); err != nil {
-			return nil, err
-		}
-
-		delay *= 2
-		if delay > retryMaxDelay {
-			delay = retryMaxDelay
-		}
+	query := filter.values()
+	query.Set("amount", strconv.Itoa(amount))
+	if !c.options.SessionToken {
+		return c.fetchWithRetries(ctx, query)
 	}
 
-	return nil, lastErr
+	token := c.sessionToken(ctx)
+	questions, err := c.fetchWithRetries(ctx, withToken(query, token))
+	if token != "" && (errors.Is(err, errTokenNotFound) || errors.Is(err, errTokenEmpty)) {
+		token = c.renewSessionToken(ctx, token, errors.Is(err, errTokenEmpty))
+		questions, err = c.fetchWithRetries(ctx, withToken(query, token))
+	}
+	return questions, err
 }
 
-func (c *Client) fetchQuestionsOnce(ctx context.Context, reqURL string) ([]RawQuestion, bool, error) {
+func (c *Client) fetchQuestionsOnce(ctx context.Context, reqURL string) ([]RawQuestion, retryHint, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, false, err
+		return nil, retryHint{}, err
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, true, err
+		return nil, retryHint{retryable: true}, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, retryHint{retryable: true, rateLimited: true, wait: retryAfter(resp.Header)}, fmt.Errorf("%w: status %d", ErrRateLimited, resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, shouldRetryStatus(resp.StatusCode), fmt.Errorf("opentdb returned status %d", resp.StatusCode)
+		return nil, retryHint{retryable: shouldRetryStatus(resp.StatusCode)}, fmt.Errorf("opentdb returned status %d", resp.StatusCode)
 	}
 
 	var payload apiResponse
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
 		// Decode errors are treated as non-retryable to avoid amplifying malformed payloads.
-		return nil, false, err
+		return nil, retryHint{}, err
 	}
 
+	switch payload.ResponseCode {
+	case responseCodeNoResults:
+		return nil, retryHint{}, ErrNotEnoughQuestions
+	case responseCodeTokenNotFound:
+		return nil, retryHint{}, errTokenNotFound
+	case responseCodeTokenEmpty:
+		return nil, retryHint{}, errTokenEmpty
+	case responseCodeRateLimited:
+		return nil, retryHint{retryable: true, rateLimited: true}, fmt.Errorf("%w: response_code=%d", ErrRateLimited, payload.ResponseCode)
+	}
 	if payload.ResponseCode != 0 {
-		return nil, false, fmt.Errorf("opentdb response_code=%d", payload.ResponseCode)
+		return nil, retryHint{}, fmt.Errorf("opentdb response_code=%d", payload.ResponseCode)
 	}
 
-	return payload.Results, false, nil
+	return payload.Results, retryHint{}, nil
 }
 
 func shouldRetryStatus(statusCode int) bool {
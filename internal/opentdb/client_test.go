@@ -5,9 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -33,7 +37,7 @@ func TestFetchQuestionsUsesDefaultAmountWhenNonPositive(t *testing.T) {
 		return &resp, nil
 	}))
 
-	questions, err := client.FetchQuestions(context.Background(), 0)
+	questions, err := client.FetchQuestions(context.Background(), 0, Filter{})
 	if err != nil {
 		t.Fatalf("FetchQuestions returned error: %v", err)
 	}
@@ -57,7 +61,7 @@ func TestFetchQuestionsPropagatesNonOKStatus(t *testing.T) {
 		return &resp, nil
 	}))
 
-	if _, err := client.FetchQuestions(context.Background(), 5); err == nil {
+	if _, err := client.FetchQuestions(context.Background(), 5, Filter{}); err == nil {
 		t.Fatalf("expected error for non-200 status")
 	}
 	if callCount != maxFetchAttempts {
@@ -75,7 +79,7 @@ func TestFetchQuestionsJSONDecodeError(t *testing.T) {
 		return &resp, nil
 	}))
 
-	if _, err := client.FetchQuestions(context.Background(), 3); err == nil {
+	if _, err := client.FetchQuestions(context.Background(), 3, Filter{}); err == nil {
 		t.Fatalf("expected JSON decode error")
 	}
 }
@@ -103,7 +107,7 @@ func TestFetchQuestionsNonZeroResponseCode(t *testing.T) {
 		return &resp, nil
 	}))
 
-	if _, err := client.FetchQuestions(context.Background(), 3); err == nil {
+	if _, err := client.FetchQuestions(context.Background(), 3, Filter{}); err == nil {
 		t.Fatalf("expected error for non-zero response_code")
 	}
 	if callCount != 1 {
@@ -141,7 +145,7 @@ func TestFetchQuestionsRetriesThenSucceeds(t *testing.T) {
 		}, nil
 	}))
 
-	questions, err := client.FetchQuestions(context.Background(), 3)
+	questions, err := client.FetchQuestions(context.Background(), 3, Filter{})
 	if err != nil {
 		t.Fatalf("unexpected error after retries: %v", err)
 	}
@@ -167,10 +171,223 @@ func TestFetchQuestionsRetriesTransportError(t *testing.T) {
 		}, nil
 	}))
 
-	if _, err := client.FetchQuestions(context.Background(), 3); err != nil {
+	if _, err := client.FetchQuestions(context.Background(), 3, Filter{}); err != nil {
 		t.Fatalf("unexpected error after transport retries: %v", err)
 	}
 	if callCount != 3 {
 		t.Fatalf("retry attempts = %d, want 3", callCount)
 	}
 }
+
+func TestFetchQuestionsSendsFilter(t *testing.T) {
+	var seen map[string]string
+	client := newTestClient(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		query := r.URL.Query()
+		seen = map[string]string{"amount": query.Get("amount"), "category": query.Get("category"), "difficulty": query.Get("difficulty"), "type": query.Get("type")}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"response_code":1,"results":[]}`))),
+			Header:     make(http.Header),
+		}, nil
+	}))
+
+	_, err := client.FetchQuestions(context.Background(), 4, Filter{Category: 17, Difficulty: "hard", Type: TypeBoolean})
+	if !errors.Is(err, ErrNotEnoughQuestions) {
+		t.Fatalf("error = %v, want ErrNotEnoughQuestions", err)
+	}
+	want := map[string]string{"amount": "4", "category": "17", "difficulty": "hard", "type": "boolean"}
+	for key, value := range want {
+		if seen[key] != value {
+			t.Fatalf("query %s = %q, want %q", key, seen[key], value)
+		}
+	}
+}
+
+func TestFilterMatchesAndLookupCategory(t *testing.T) {
+	category, ok := LookupCategory("science & NATURE")
+	if !ok || category.ID != 17 {
+		t.Fatalf("LookupCategory by name = %+v, %v", category, ok)
+	}
+	if category, ok := LookupCategory("18"); !ok || category.Name != "Science: Computers" {
+		t.Fatalf("LookupCategory by id = %+v, %v", category, ok)
+	}
+	if _, ok := LookupCategory("8"); ok {
+		t.Fatal("LookupCategory accepted an unknown id")
+	}
+
+	question := RawQuestion{Category: "Entertainment: Musicals &amp; Theatres", Difficulty: "easy", Type: TypeMultiple}
+	if !(Filter{Category: 13, Difficulty: "easy"}).Matches(question) {
+		t.Fatal("filter rejected a matching question")
+	}
+	if (Filter{Type: TypeBoolean}).Matches(question) || (Filter{Category: 9}).Matches(question) {
+		t.Fatal("filter accepted a question of another type or category")
+	}
+}
+
+func TestFetchQuestionsKeepsSessionToken(t *testing.T) {
+	var (
+		commands []string
+		tokens   []string
+		// fetchCodes answers the question fetches in turn; later ones succeed.
+		fetchCodes = []int{0, 4, 0, 3, 0}
+		issued     = 0
+	)
+	client := NewClientWithOptions(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		query := r.URL.Query()
+		var body string
+		if r.URL.Path == "/api_token.php" {
+			commands = append(commands, query.Get("command")+" "+query.Get("token"))
+			if query.Get("command") == "request" {
+				issued++
+			}
+			body = fmt.Sprintf(`{"response_code":0,"token":"tok%d"}`, issued)
+		} else {
+			tokens = append(tokens, query.Get("token"))
+			code := 0
+			if len(fetchCodes) > 0 {
+				code, fetchCodes = fetchCodes[0], fetchCodes[1:]
+			}
+			body = fmt.Sprintf(`{"response_code":%d,"results":[{"question":"ok"}]}`, code)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})}, ClientOptions{SessionToken: true})
+
+	for idx := 0; idx < 3; idx++ {
+		if questions, err := client.FetchQuestions(context.Background(), 1, Filter{}); err != nil || len(questions) != 1 {
+			t.Fatalf("fetch %d = (%+v, %v), want one question", idx, questions, err)
+		}
+	}
+	// The token is requested once and reused, reset when it runs out of
+	// questions, and replaced when OpenTriviaDB forgets it.
+	if want := []string{"request ", "reset tok1", "request "}; !reflect.DeepEqual(commands, want) {
+		t.Fatalf("token commands = %q, want %q", commands, want)
+	}
+	if want := []string{"tok1", "tok1", "tok1", "tok1", "tok2"}; !reflect.DeepEqual(tokens, want) {
+		t.Fatalf("fetch tokens = %q, want %q", tokens, want)
+	}
+}
+
+func TestFetchQuestionsWithoutSessionTokenWhenTokenRequestFails(t *testing.T) {
+	var tokenRequests, fetches int
+	client := NewClientWithOptions(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/api_token.php" {
+			tokenRequests++
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+		}
+		fetches++
+		if token := r.URL.Query().Get("token"); token != "" {
+			t.Fatalf("fetch sent token %q, want none", token)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"response_code":0,"results":[{"question":"ok"}]}`)), Header: make(http.Header)}, nil
+	})}, ClientOptions{SessionToken: true})
+
+	for idx := 0; idx < 2; idx++ {
+		if _, err := client.FetchQuestions(context.Background(), 1, Filter{}); err != nil {
+			t.Fatalf("fetch %d failed: %v", idx, err)
+		}
+	}
+	// A failed request is not repeated on every fetch.
+	if tokenRequests != 1 || fetches != 2 {
+		t.Fatalf("token requests = %d, fetches = %d, want 1 and 2", tokenRequests, fetches)
+	}
+}
+
+func TestFetchQuestionsWaitsOutRateLimit(t *testing.T) {
+	var (
+		codes = []int{5, 5, 0}
+		logs  []string
+	)
+	client := NewClientWithOptions(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		code := codes[0]
+		codes = codes[1:]
+		body := fmt.Sprintf(`{"response_code":%d,"results":[{"question":"ok"}]}`, code)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})}, ClientOptions{
+		Retry: RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, RateLimitDelay: 20 * time.Millisecond},
+		Logf:  func(format string, args ...any) { logs = append(logs, fmt.Sprintf(format, args...)) },
+	})
+
+	started := time.Now()
+	questions, err := client.FetchQuestions(context.Background(), 1, Filter{})
+	if err != nil || len(questions) != 1 {
+		t.Fatalf("FetchQuestions = (%+v, %v), want one question after the rate limit", questions, err)
+	}
+	if elapsed := time.Since(started); elapsed < 40*time.Millisecond {
+		t.Fatalf("elapsed = %s, want each rate-limited retry to wait out the limit", elapsed)
+	}
+	if len(logs) != 2 || !strings.Contains(logs[0], "attempt=2/4") || !strings.Contains(logs[1], "attempt=3/4") || !strings.Contains(logs[1], "rate limit") {
+		t.Fatalf("logs = %q, want one line per retry with its attempt and cause", logs)
+	}
+}
+
+func TestFetchQuestionsDoesNotRetryPastDeadline(t *testing.T) {
+	calls := 0
+	client := NewClientWithOptions(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		header := http.Header{"Retry-After": {"10"}}
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader("")), Header: header}, nil
+	})}, ClientOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	started := time.Now()
+	if _, err := client.FetchQuestions(ctx, 1, Filter{}); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("error = %v, want ErrRateLimited", err)
+	}
+	if calls != 1 || time.Since(started) > 500*time.Millisecond {
+		t.Fatalf("calls = %d after %s, want one call and no wait the deadline cannot cover", calls, time.Since(started))
+	}
+}
+
+func TestFetchQuestionsCircuitBreaker(t *testing.T) {
+	var (
+		calls int
+		down  = true
+		now   = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	)
+	client := NewClientWithOptions(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"response_code":0,"results":[{"question":"ok"}]}`)), Header: make(http.Header)}, nil
+	})}, ClientOptions{
+		Retry:   RetryPolicy{MaxAttempts: 1},
+		Breaker: BreakerPolicy{Failures: 2, Cooldown: time.Minute},
+	})
+	client.now = func() time.Time { return now }
+	fetch := func() error {
+		_, err := client.FetchQuestions(context.Background(), 1, Filter{})
+		return err
+	}
+
+	for idx := 0; idx < 2; idx++ {
+		if err := fetch(); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("fetch %d error = %v, want the transport error", idx, err)
+		}
+	}
+	if err := fetch(); !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Fatalf("open circuit: error = %v after %d calls, want ErrCircuitOpen without a call", err, calls)
+	}
+
+	// After the cooldown one failing probe opens the circuit again.
+	now = now.Add(time.Minute)
+	if err := fetch(); err == nil || errors.Is(err, ErrCircuitOpen) || calls != 3 {
+		t.Fatalf("probe: error = %v after %d calls, want the transport error from one call", err, calls)
+	}
+	if err := fetch(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("after a failed probe: error = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it.
+	now = now.Add(time.Minute)
+	down = false
+	for idx := 0; idx < 2; idx++ {
+		if err := fetch(); err != nil {
+			t.Fatalf("closed circuit fetch %d failed: %v", idx, err)
+		}
+	}
+	if calls != 5 {
+		t.Fatalf("calls = %d, want 5", calls)
+	}
+}
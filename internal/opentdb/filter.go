@@ -0,0 +1,124 @@
+package opentdb
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Question types OpenTriviaDB serves.
+const (
+	TypeMultiple = "multiple"
+	TypeBoolean  = "boolean"
+)
+
+// Filter narrows a fetch to one category, difficulty, and question type.
+// Zero fields match everything.
+type Filter struct {
+	// Category is an OpenTriviaDB category ID; see Categories.
+	Category int
+	// Difficulty is easy, medium, or hard.
+	Difficulty string
+	// Type is TypeMultiple or TypeBoolean.
+	Type string
+}
+
+// IsZero reports whether f matches every question.
+func (f Filter) IsZero() bool {
+	return f == Filter{}
+}
+
+// Matches reports whether question passes f. Providers other than
+// OpenTriviaDB cannot filter at the source, so callers use it to filter what
+// they return.
+func (f Filter) Matches(question RawQuestion) bool {
+	if f.Category != 0 {
+		category, ok := CategoryByID(f.Category)
+		if !ok || !strings.EqualFold(html.UnescapeString(question.Category), category.Name) {
+			return false
+		}
+	}
+	if f.Difficulty != "" && !strings.EqualFold(question.Difficulty, f.Difficulty) {
+		return false
+	}
+	if f.Type != "" && !strings.EqualFold(question.Type, f.Type) {
+		return false
+	}
+	return true
+}
+
+func (f Filter) values() url.Values {
+	values := url.Values{}
+	if f.Category != 0 {
+		values.Set("category", strconv.Itoa(f.Category))
+	}
+	if f.Difficulty != "" {
+		values.Set("difficulty", f.Difficulty)
+	}
+	if f.Type != "" {
+		values.Set("type", f.Type)
+	}
+	return values
+}
+
+// Category is one of OpenTriviaDB's question categories. Name is spelled as
+// in fetched questions.
+type Category struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Categories lists OpenTriviaDB's categories by ID. The list changes rarely
+// enough to keep here instead of fetching it.
+var Categories = []Category{
+	{9, "General Knowledge"},
+	{10, "Entertainment: Books"},
+	{11, "Entertainment: Film"},
+	{12, "Entertainment: Music"},
+	{13, "Entertainment: Musicals & Theatres"},
+	{14, "Entertainment: Television"},
+	{15, "Entertainment: Video Games"},
+	{16, "Entertainment: Board Games"},
+	{17, "Science & Nature"},
+	{18, "Science: Computers"},
+	{19, "Science: Mathematics"},
+	{20, "Mythology"},
+	{21, "Sports"},
+	{22, "Geography"},
+	{23, "History"},
+	{24, "Politics"},
+	{25, "Art"},
+	{26, "Celebrities"},
+	{27, "Animals"},
+	{28, "Vehicles"},
+	{29, "Entertainment: Comics"},
+	{30, "Science: Gadgets"},
+	{31, "Entertainment: Japanese Anime & Manga"},
+	{32, "Entertainment: Cartoon & Animations"},
+}
+
+// CategoryByID returns the category with id.
+func CategoryByID(id int) (Category, bool) {
+	for _, category := range Categories {
+		if category.ID == id {
+			return category, true
+		}
+	}
+	return Category{}, false
+}
+
+// LookupCategory finds a category by ID or by name, ignoring case, so "17"
+// and "science & nature" both name Science & Nature.
+func LookupCategory(value string) (Category, bool) {
+	value = strings.TrimSpace(value)
+	if id, err := strconv.Atoi(value); err == nil {
+		return CategoryByID(id)
+	}
+	for _, category := range Categories {
+		if strings.EqualFold(category.Name, value) {
+			return category, true
+		}
+	}
+	return Category{}, false
+}
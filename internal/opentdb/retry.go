@@ -0,0 +1,226 @@
+package opentdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCooldown = 30 * time.Second
+	// rateLimitDelay is OpenTriviaDB's limit: one request per IP every five
+	// seconds.
+	rateLimitDelay = 5 * time.Second
+	// maxRetryAfter bounds how long a Retry-After header can make a fetch
+	// wait.
+	maxRetryAfter = 30 * time.Second
+)
+
+var (
+	// ErrRateLimited reports a fetch OpenTriviaDB refused for coming too
+	// soon after the last one (response_code 5, or status 429).
+	ErrRateLimited = errors.New("opentdb rate limit exceeded")
+	// ErrCircuitOpen reports a fetch refused without calling OpenTriviaDB,
+	// because recent fetches kept failing; see BreakerPolicy.
+	ErrCircuitOpen = errors.New("opentdb circuit open after repeated failures")
+)
+
+// RetryPolicy says how a Client retries a failed fetch. Transport errors,
+// 5xx and 429 statuses, and rate limiting are retried; other answers are not.
+// Delays start at BaseDelay and double up to MaxDelay, each with random
+// jitter of up to half; a rate-limited fetch waits at least RateLimitDelay,
+// or as long as a 429's Retry-After asks. A retry that would wait past the
+// context's deadline is not made. Zero fields take the defaults: three
+// attempts, 50ms doubling to 200ms, and five seconds after rate limiting.
+type RetryPolicy struct {
+	// MaxAttempts counts the first try; 1 disables retries.
+	MaxAttempts    int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	RateLimitDelay time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = maxFetchAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = retryBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = retryMaxDelay
+	}
+	p.MaxDelay = max(p.MaxDelay, p.BaseDelay)
+	if p.RateLimitDelay <= 0 {
+		p.RateLimitDelay = rateLimitDelay
+	}
+	return p
+}
+
+// BreakerPolicy opens a Client's circuit once Failures fetches in a row have
+// failed after their retries, so fetches fail fast with ErrCircuitOpen for
+// Cooldown instead of waiting on OpenTriviaDB. After the cooldown one fetch
+// is let through: its success closes the circuit and its failure opens it
+// again. Answers such as ErrNotEnoughQuestions are not failures. Zero
+// Failures disables the breaker; zero Cooldown means 30 seconds.
+type BreakerPolicy struct {
+	Failures int
+	Cooldown time.Duration
+}
+
+// retryHint is what one failed request says about retrying it.
+type retryHint struct {
+	retryable   bool
+	rateLimited bool
+	// wait is the least time to wait first, from a Retry-After header.
+	wait time.Duration
+}
+
+// fetchWithRetries sends query to OpenTriviaDB under the client's retry
+// policy and circuit breaker.
+func (c *Client) fetchWithRetries(ctx context.Context, query url.Values) ([]RawQuestion, error) {
+	if err := c.breaker.allow(c.options.Breaker, c.now()); err != nil {
+		return nil, err
+	}
+	policy := c.options.Retry.withDefaults()
+	reqURL := apiURL + "?" + query.Encode()
+	delay := policy.BaseDelay
+	var lastErr error
+
+	for attempt := 1; ; attempt++ {
+		questions, hint, err := c.fetchQuestionsOnce(ctx, reqURL)
+		if err == nil {
+			c.recordOutcome(nil)
+			return questions, nil
+		}
+		lastErr = err
+		if !hint.retryable || attempt == policy.MaxAttempts {
+			break
+		}
+
+		wait := max(jitter(delay), hint.wait)
+		if hint.rateLimited {
+			wait = max(wait, policy.RateLimitDelay)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			c.logf("opentdb retry skipped attempt=%d/%d wait=%s err=%v: the deadline is sooner", attempt+1, policy.MaxAttempts, wait.Round(time.Millisecond), err)
+			break
+		}
+		c.logf("opentdb retry attempt=%d/%d wait=%s err=%v", attempt+1, policy.MaxAttempts, wait.Round(time.Millisecond), err)
+		if err := sleepWithContext(ctx, wait); err != nil {
+			c.breaker.release()
+			return nil, err
+		}
+		delay = min(delay*2, policy.MaxDelay)
+	}
+
+	c.recordOutcome(lastErr)
+	return nil, lastErr
+}
+
+// recordOutcome tells the breaker how a fetch ended. OpenTriviaDB answering
+// that it has too few questions, or rejecting a session token, shows it is
+// up; a cancelled fetch shows nothing.
+func (c *Client) recordOutcome(err error) {
+	switch {
+	case err == nil, errors.Is(err, ErrNotEnoughQuestions), errors.Is(err, errTokenNotFound):
+		c.breaker.record(c.options.Breaker, false, c.now())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		c.breaker.release()
+	default:
+		if c.breaker.record(c.options.Breaker, true, c.now()) {
+			c.logf("opentdb circuit open for %s after %d failed fetches: %v", c.options.Breaker.cooldown(), c.options.Breaker.Failures, err)
+		}
+	}
+}
+
+func (c *Client) logf(format string, args ...any) {
+	if c.options.Logf != nil {
+		c.options.Logf(format, args...)
+	}
+}
+
+func (p BreakerPolicy) cooldown() time.Duration {
+	if p.Cooldown <= 0 {
+		return defaultCooldown
+	}
+	return p.Cooldown
+}
+
+// breaker is a Client's circuit state under its BreakerPolicy.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	// probing is set while the one fetch after a cooldown is in flight.
+	probing bool
+}
+
+func (b *breaker) allow(policy BreakerPolicy, now time.Time) error {
+	if policy.Failures <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Before(b.openUntil) {
+		return fmt.Errorf("%w; retrying in %s", ErrCircuitOpen, b.openUntil.Sub(now).Round(time.Second))
+	}
+	if b.failures >= policy.Failures {
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record counts a finished fetch and reports whether it opened the circuit.
+func (b *breaker) record(policy BreakerPolicy, failed bool, now time.Time) bool {
+	if policy.Failures <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return false
+	}
+	b.failures++
+	if b.failures < policy.Failures {
+		return false
+	}
+	b.openUntil = now.Add(policy.cooldown())
+	return true
+}
+
+// release ends a fetch that neither succeeded nor failed, letting another
+// fetch probe the circuit.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// jitter returns a random delay between half of delay and delay, so clients
+// that failed together do not retry together.
+func jitter(delay time.Duration) time.Duration {
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// retryAfter reads a Retry-After header in seconds, bounded by
+// maxRetryAfter. Dates and missing headers read as zero.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return min(time.Duration(seconds)*time.Second, maxRetryAfter)
+}
@@ -0,0 +1,128 @@
+package opentdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	tokenURL = "https://opentdb.com/api_token.php"
+	// tokenRetryInterval is how long a client fetches without a session
+	// token after failing to get one, before asking again.
+	tokenRetryInterval = time.Minute
+)
+
+var (
+	errTokenNotFound = errors.New("opentdb session token not found")
+	// errTokenEmpty is ErrNotEnoughQuestions too: when a reset token is
+	// still empty, no questions are left for the query.
+	errTokenEmpty = fmt.Errorf("%w: session token has seen every question for the query", ErrNotEnoughQuestions)
+)
+
+// session is a Client's OpenTriviaDB session token. The mutex is held while
+// a token is requested, so concurrent fetches share one token.
+type session struct {
+	mu       sync.Mutex
+	token    string
+	failedAt time.Time
+}
+
+type tokenResponse struct {
+	ResponseCode int    `json:"response_code"`
+	Token        string `json:"token"`
+}
+
+// sessionToken returns the client's token, requesting one if it has none.
+// Token trouble never fails a fetch: without a token it returns "" and the
+// fetch goes ahead, only without protection from repeats.
+func (c *Client) sessionToken(ctx context.Context) string {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	if c.session.token == "" && time.Since(c.session.failedAt) >= tokenRetryInterval {
+		token, err := c.tokenCommand(ctx, "request", "")
+		if err != nil {
+			c.session.failedAt = time.Now()
+		}
+		c.session.token = token
+	}
+	return c.session.token
+}
+
+// renewSessionToken replaces stale after OpenTriviaDB rejected it: an
+// exhausted token is reset so it can see every question again, and a token
+// OpenTriviaDB does not know, or one that fails to reset, is replaced. A
+// concurrent fetch may have renewed it already, in which case its token is
+// returned.
+func (c *Client) renewSessionToken(ctx context.Context, stale string, reset bool) string {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	if c.session.token != stale {
+		return c.session.token
+	}
+
+	var (
+		token string
+		err   error
+	)
+	if reset {
+		token, err = c.tokenCommand(ctx, "reset", stale)
+	}
+	if !reset || err != nil {
+		token, err = c.tokenCommand(ctx, "request", "")
+	}
+	if err != nil {
+		c.session.failedAt = time.Now()
+	}
+	c.session.token = token
+	return token
+}
+
+// tokenCommand runs one api_token.php command and returns the token it
+// answers with.
+func (c *Client) tokenCommand(ctx context.Context, command, token string) (string, error) {
+	query := url.Values{"command": {command}}
+	if token != "" {
+		query.Set("token", token)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("opentdb token %s returned status %d", command, resp.StatusCode)
+	}
+
+	var payload tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", err
+	}
+	if payload.ResponseCode != 0 || payload.Token == "" {
+		return "", fmt.Errorf("opentdb token %s response_code=%d", command, payload.ResponseCode)
+	}
+	return payload.Token, nil
+}
+
+// withToken returns query with token added, or query itself when token is
+// empty.
+func withToken(query url.Values, token string) url.Values {
+	if token == "" {
+		return query
+	}
+	withToken := make(url.Values, len(query)+1)
+	for key, values := range query {
+		withToken[key] = values
+	}
+	withToken.Set("token", token)
+	return withToken
+}
@@ -0,0 +1,120 @@
+// Package providers is the registry of question providers the service can
+// build quizzes from. Build turns a priority list such as
+// "opentdb,triviaapi" into providers in that order; the quiz service tries
+// the first and fails over down the list when one errors or returns too few
+// questions.
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"quiz-app/internal/opentdb"
+	"quiz-app/internal/questionsource"
+	"quiz-app/internal/triviaapi"
+)
+
+// Provider is one configured question source. Fetch has the
+// quiz.QuestionsFetcher signature.
+type Provider struct {
+	// Name is recorded as the provider of quizzes it supplies.
+	Name  string
+	Fetch func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error)
+}
+
+// Config is what providers need to start.
+type Config struct {
+	// QuestionsFile is the JSON or CSV file the file provider reads; see
+	// questionsource.
+	QuestionsFile string
+	// HTTPClient makes the network providers' requests. Nil uses each
+	// client's default.
+	HTTPClient *http.Client
+	// OpenTDB tunes the OpenTriviaDB client's retries and circuit breaker.
+	// Its session token is always on.
+	OpenTDB opentdb.ClientOptions
+}
+
+// ErrUnknownProvider reports a name Build does not know.
+var ErrUnknownProvider = errors.New("unknown question provider")
+
+var registry = map[string]func(Config) (Provider, error){
+	// A session token keeps quizzes created close together from sharing
+	// questions.
+	"opentdb": func(config Config) (Provider, error) {
+		options := config.OpenTDB
+		options.SessionToken = true
+		client := opentdb.NewClientWithOptions(config.HTTPClient, options)
+		return Provider{Name: "opentdb", Fetch: client.FetchQuestions}, nil
+	},
+	"triviaapi": func(config Config) (Provider, error) {
+		return Provider{Name: "triviaapi", Fetch: triviaapi.NewClient(config.HTTPClient).FetchQuestions}, nil
+	},
+	// The file provider is named after its file, "file:biology.csv", so
+	// quiz origins say which file a quiz came from.
+	"file": func(config Config) (Provider, error) {
+		if config.QuestionsFile == "" {
+			return Provider{}, errors.New("the file provider needs a questions file")
+		}
+		source, err := questionsource.Load(config.QuestionsFile)
+		if err != nil {
+			return Provider{}, err
+		}
+		return Provider{Name: source.Name(), Fetch: source.FetchQuestions}, nil
+	},
+}
+
+// Names lists the registered providers in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultOrder is the priority list used when none is configured: the
+// questions file when config names one, otherwise OpenTriviaDB alone.
+func DefaultOrder(config Config) string {
+	if config.QuestionsFile != "" {
+		return "file"
+	}
+	return "opentdb"
+}
+
+// Build starts the comma-separated providers in order, highest priority
+// first. Names are case-insensitive and may appear once each.
+func Build(order string, config Config) ([]Provider, error) {
+	var (
+		built []Provider
+		seen  = make(map[string]bool)
+	)
+	for _, name := range strings.Split(order, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		start, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("%w %q (want %s)", ErrUnknownProvider, name, strings.Join(Names(), ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("provider %q is listed twice", name)
+		}
+		seen[name] = true
+		provider, err := start(config)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		built = append(built, provider)
+	}
+	if len(built) == 0 {
+		return nil, errors.New("no question providers configured")
+	}
+	return built, nil
+}
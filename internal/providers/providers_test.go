@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildKeepsPriorityOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "biology.csv")
+	if err := os.WriteFile(path, []byte("question,correct_answer,incorrect_answer\nCells have a nucleus?,True,False\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	config := Config{QuestionsFile: path}
+
+	built, err := Build(" TriviaAPI, file ,opentdb", config)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	var names []string
+	for _, provider := range built {
+		if provider.Fetch == nil {
+			t.Fatalf("provider %s has no fetcher", provider.Name)
+		}
+		names = append(names, provider.Name)
+	}
+	if want := []string{"triviaapi", "file:biology.csv", "opentdb"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("providers = %v, want %v", names, want)
+	}
+
+	if DefaultOrder(config) != "file" || DefaultOrder(Config{}) != "opentdb" {
+		t.Fatalf("default orders = (%q, %q), want file with a questions file, otherwise opentdb", DefaultOrder(config), DefaultOrder(Config{}))
+	}
+}
+
+func TestBuildRejectsBadLists(t *testing.T) {
+	if _, err := Build("opentdb,jservice", Config{}); !errors.Is(err, ErrUnknownProvider) || !strings.Contains(err.Error(), "file, opentdb, triviaapi") {
+		t.Fatalf("Build(unknown) error = %v, want ErrUnknownProvider listing the known ones", err)
+	}
+	for order, want := range map[string]string{
+		"opentdb,OpenTDB": "listed twice",
+		"file":            "needs a questions file",
+		" , ":             "no question providers",
+	} {
+		if _, err := Build(order, Config{}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Build(%q) error = %v, want %q", order, err, want)
+		}
+	}
+	if _, err := Build("file", Config{QuestionsFile: filepath.Join(t.TempDir(), "missing.json")}); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Build(missing file) error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,85 @@
+package questionimport
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Aiken is one question per block:
+//
+//	What is the capital of France?
+//	A. Lyon
+//	B) Paris
+//	ANSWER: B
+//
+// Options are lettered in order from A. The ANSWER line ends the question.
+
+var (
+	aikenOption = regexp.MustCompile(`^([A-Z])[.)]\s+(.*)$`)
+	aikenAnswer = regexp.MustCompile(`^ANSWER\s*:\s*([A-Za-z])$`)
+)
+
+func parseAiken(data []byte) Result {
+	result := Result{Format: FormatAiken}
+
+	var (
+		start   int
+		prompt  []string
+		options []string
+		broken  string
+	)
+	reset := func() {
+		start, prompt, options, broken = 0, nil, nil, ""
+	}
+
+	for idx, raw := range splitLines(data) {
+		line := strings.TrimSpace(raw)
+		lineNumber := idx + 1
+		if line == "" {
+			// Blank lines may separate questions but never end one; an
+			// unfinished question is reported when the next one starts.
+			continue
+		}
+		if start == 0 {
+			start = lineNumber
+		}
+
+		if match := aikenAnswer.FindStringSubmatch(line); match != nil {
+			answer := int(strings.ToUpper(match[1])[0] - 'A')
+			switch {
+			case broken != "":
+				result.fail(start, "%s", broken)
+			case len(prompt) == 0:
+				result.fail(start, "question text is missing")
+			case answer >= len(options):
+				result.fail(start, "ANSWER %s does not match any option", strings.ToUpper(match[1]))
+			default:
+				result.add(start, strings.Join(prompt, " "), options, answer, nil)
+			}
+			reset()
+			continue
+		}
+
+		if match := aikenOption.FindStringSubmatch(line); match != nil && len(prompt) > 0 {
+			if want := string(rune('A' + len(options))); match[1] != want && broken == "" {
+				broken = "option " + match[1] + " is out of order; expected " + want
+			}
+			options = append(options, strings.TrimSpace(match[2]))
+			continue
+		}
+
+		if len(options) > 0 {
+			// Text after the options without an ANSWER line: the previous
+			// question never ended.
+			result.fail(start, "ANSWER line is missing")
+			reset()
+			start = lineNumber
+		}
+		prompt = append(prompt, line)
+	}
+
+	if start != 0 {
+		result.fail(start, "ANSWER line is missing")
+	}
+	return result
+}
@@ -0,0 +1,304 @@
+package questionimport
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GIFT questions are separated by blank lines, with the answers in braces:
+//
+//	// comment
+//	::Capital:: What is the capital of France? {
+//	  ~Lyon #Second largest city.
+//	  =Paris
+//	}
+//	The sky is green. {F}
+//
+// "=" marks the right answer and "~" a wrong one, "#" starts feedback, and a
+// backslash escapes any of ~=#{}:. Text after the braces turns the question
+// into a fill-in-the-blank prompt.
+
+var (
+	giftWeight = regexp.MustCompile(`^%(-?[0-9.]+)%`)
+	giftMarkup = regexp.MustCompile(`^\[(html|moodle|plain|markdown)\]`)
+)
+
+type giftBlock struct {
+	line int
+	text string
+}
+
+func parseGIFT(data []byte) Result {
+	result := Result{Format: FormatGIFT}
+	for _, block := range giftBlocks(data) {
+		parseGIFTQuestion(&result, block)
+	}
+	return result
+}
+
+// giftBlocks splits the file into question texts, dropping comments and
+// category lines. A blank line inside braces does not end a question.
+func giftBlocks(data []byte) []giftBlock {
+	var (
+		blocks  []giftBlock
+		current []string
+		start   int
+		depth   int
+	)
+	flush := func() {
+		if len(current) > 0 {
+			blocks = append(blocks, giftBlock{line: start, text: strings.Join(current, "\n")})
+		}
+		current, start, depth = nil, 0, 0
+	}
+
+	for idx, raw := range splitLines(data) {
+		line := strings.TrimSpace(raw)
+		switch {
+		case strings.HasPrefix(line, "//"):
+			continue
+		case strings.HasPrefix(line, "$CATEGORY:") && depth == 0:
+			flush()
+			continue
+		case line == "" && depth == 0:
+			flush()
+			continue
+		}
+		if start == 0 {
+			start = idx + 1
+		}
+		current = append(current, line)
+		depth += braceDepth(line)
+	}
+	flush()
+	return blocks
+}
+
+// braceDepth is the net count of unescaped braces opened on line.
+func braceDepth(line string) int {
+	depth := 0
+	escaped := false
+	for _, char := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case char == '{':
+			depth++
+		case char == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+func parseGIFTQuestion(result *Result, block giftBlock) {
+	text := block.text
+	if strings.HasPrefix(text, "::") {
+		if end := indexUnescaped(text[2:], "::"); end >= 0 {
+			text = strings.TrimSpace(text[2+end+2:])
+		}
+	}
+
+	open := indexUnescaped(text, "{")
+	if open < 0 {
+		result.fail(block.line, "answers in {braces} are missing; description-only items are not supported")
+		return
+	}
+	closeAt := indexUnescaped(text[open+1:], "}")
+	if closeAt < 0 {
+		result.fail(block.line, "answer block is not closed with }")
+		return
+	}
+	before := strings.TrimSpace(text[:open])
+	answers := strings.TrimSpace(text[open+1 : open+1+closeAt])
+	after := strings.TrimSpace(text[open+1+closeAt+1:])
+
+	before = giftMarkup.ReplaceAllString(before, "")
+	prompt := giftUnescape(before)
+	if after != "" {
+		// Missing-word format: the answers fill a blank in the sentence.
+		prompt = strings.TrimSpace(prompt + " _____ " + giftUnescape(after))
+	}
+
+	switch {
+	case answers == "":
+		result.fail(block.line, "essay questions are not supported")
+		return
+	case strings.HasPrefix(answers, "#"):
+		result.fail(block.line, "numerical questions are not supported")
+		return
+	}
+	if truth, feedback, ok := giftTrueFalse(answers); ok {
+		options := []string{"True", "False"}
+		correct := 1
+		if truth {
+			correct = 0
+		}
+		// GIFT gives feedback for a wrong answer first, then for a right one.
+		var optionFeedback []string
+		if len(feedback) > 0 {
+			optionFeedback = make([]string, 2)
+			optionFeedback[1-correct] = feedback[0]
+			if len(feedback) > 1 {
+				optionFeedback[correct] = feedback[1]
+			}
+		}
+		result.add(block.line, prompt, options, correct, optionFeedback)
+		return
+	}
+
+	var (
+		options  []string
+		feedback []string
+		correct  = -1
+		hasWrong bool
+	)
+	for _, part := range splitUnescaped(answers, "=~") {
+		body := strings.TrimSpace(part.text)
+		if part.marker == 0 {
+			if body != "" {
+				result.fail(block.line, "answer %q must start with = or ~", giftUnescape(body))
+				return
+			}
+			continue
+		}
+		if indexUnescaped(body, "->") >= 0 {
+			result.fail(block.line, "matching questions are not supported")
+			return
+		}
+
+		isCorrect := part.marker == '='
+		if match := giftWeight.FindStringSubmatch(body); match != nil {
+			weight, err := strconv.ParseFloat(match[1], 64)
+			if err != nil {
+				result.fail(block.line, "answer weight %q is not a number", match[0])
+				return
+			}
+			if weight > 0 && weight < 100 {
+				result.fail(block.line, "partial-credit answers are not supported")
+				return
+			}
+			isCorrect = weight >= 100
+			body = body[len(match[0]):]
+		}
+
+		answer, comment := body, ""
+		if hash := indexUnescaped(body, "#"); hash >= 0 {
+			answer, comment = body[:hash], body[hash+1:]
+		}
+		if isCorrect {
+			if correct >= 0 {
+				result.fail(block.line, "only one answer may be correct")
+				return
+			}
+			correct = len(options)
+		} else {
+			hasWrong = true
+		}
+		options = append(options, giftUnescape(answer))
+		feedback = append(feedback, giftUnescape(comment))
+	}
+
+	switch {
+	case correct < 0:
+		result.fail(block.line, "no answer is marked correct with =")
+		return
+	case !hasWrong:
+		result.fail(block.line, "short-answer questions are not supported; add wrong answers with ~")
+		return
+	}
+	if !anyNonEmpty(feedback) {
+		feedback = nil
+	}
+	result.add(block.line, prompt, options, correct, feedback)
+}
+
+// giftTrueFalse reads {T}, {FALSE}, {T#wrong#right} and the like.
+func giftTrueFalse(answers string) (truth bool, feedback []string, ok bool) {
+	parts := splitHashes(answers)
+	switch strings.ToUpper(strings.TrimSpace(parts[0])) {
+	case "T", "TRUE":
+		truth = true
+	case "F", "FALSE":
+		truth = false
+	default:
+		return false, nil, false
+	}
+	for _, part := range parts[1:] {
+		feedback = append(feedback, giftUnescape(part))
+	}
+	return truth, feedback, true
+}
+
+func splitHashes(text string) []string {
+	var parts []string
+	for {
+		idx := indexUnescaped(text, "#")
+		if idx < 0 {
+			return append(parts, text)
+		}
+		parts = append(parts, text[:idx])
+		text = text[idx+1:]
+	}
+}
+
+type giftPart struct {
+	marker rune
+	text   string
+}
+
+// splitUnescaped cuts text before each unescaped marker character. The first
+// part has marker 0 and holds any text before the first marker.
+func splitUnescaped(text, markers string) []giftPart {
+	parts := []giftPart{{}}
+	var current strings.Builder
+	escaped := false
+	for _, char := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case strings.ContainsRune(markers, char):
+			parts[len(parts)-1].text = current.String()
+			current.Reset()
+			parts = append(parts, giftPart{marker: char})
+			continue
+		}
+		current.WriteRune(char)
+	}
+	parts[len(parts)-1].text = current.String()
+	return parts
+}
+
+// indexUnescaped is strings.Index that skips backslash-escaped matches.
+func indexUnescaped(text, substr string) int {
+	for idx := 0; idx < len(text); idx++ {
+		if text[idx] == '\\' {
+			idx++
+			continue
+		}
+		if strings.HasPrefix(text[idx:], substr) {
+			return idx
+		}
+	}
+	return -1
+}
+
+var giftEscapes = strings.NewReplacer(`\~`, "~", `\=`, "=", `\#`, "#", `\{`, "{", `\}`, "}", `\:`, ":", `\n`, "\n", `\\`, `\`)
+
+func giftUnescape(text string) string {
+	return strings.TrimSpace(giftEscapes.Replace(strings.TrimSpace(text)))
+}
+
+func anyNonEmpty(values []string) bool {
+	for _, value := range values {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package questionimport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Moodle XML is Moodle's own export format: a <quiz> of <question> elements
+// whose type attribute names the question type. Category entries are skipped.
+
+type moodleText struct {
+	Format string `xml:"format,attr"`
+	Text   string `xml:"text"`
+}
+
+type moodleAnswer struct {
+	Fraction string     `xml:"fraction,attr"`
+	Format   string     `xml:"format,attr"`
+	Text     string     `xml:"text"`
+	Feedback moodleText `xml:"feedback"`
+}
+
+type moodleQuestion struct {
+	Type         string         `xml:"type,attr"`
+	QuestionText moodleText     `xml:"questiontext"`
+	Single       string         `xml:"single"`
+	Answers      []moodleAnswer `xml:"answer"`
+}
+
+var (
+	htmlBlockTag = regexp.MustCompile(`(?i)</?(p|br|div|li|tr|td|h[1-6])\b[^>]*>`)
+	htmlTag      = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+func parseMoodleXML(data []byte) (Result, error) {
+	result := Result{Format: FormatMoodleXML}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	sawQuiz := false
+	for {
+		line, _ := decoder.InputPos()
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("moodle xml: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "quiz":
+			sawQuiz = true
+			continue
+		case "question":
+		default:
+			continue
+		}
+
+		var question moodleQuestion
+		if err := decoder.DecodeElement(&question, &start); err != nil {
+			return Result{}, fmt.Errorf("moodle xml: line %d: %w", line, err)
+		}
+		addMoodleQuestion(&result, line, question)
+	}
+	if !sawQuiz {
+		return Result{}, errors.New("moodle xml: <quiz> element is missing")
+	}
+	return result, nil
+}
+
+func addMoodleQuestion(result *Result, line int, question moodleQuestion) {
+	switch question.Type {
+	case "category":
+		return
+	case "multichoice", "truefalse":
+	default:
+		result.fail(line, "%s questions are not supported", question.Type)
+		return
+	}
+	if question.Type == "multichoice" && strings.EqualFold(strings.TrimSpace(question.Single), "false") {
+		result.fail(line, "multiple-answer questions are not supported")
+		return
+	}
+
+	var (
+		options  []string
+		feedback []string
+		correct  = -1
+	)
+	for _, answer := range question.Answers {
+		fraction, err := strconv.ParseFloat(strings.TrimSpace(answer.Fraction), 64)
+		if err != nil {
+			result.fail(line, "answer fraction %q is not a number", answer.Fraction)
+			return
+		}
+		if fraction > 0 && fraction < 100 {
+			result.fail(line, "partial-credit answers are not supported")
+			return
+		}
+		if fraction >= 100 {
+			if correct >= 0 {
+				result.fail(line, "only one answer may be correct")
+				return
+			}
+			correct = len(options)
+		}
+		text := moodleTextContent(moodleText{Format: answer.Format, Text: answer.Text})
+		if question.Type == "truefalse" {
+			text = titleCase(text)
+		}
+		options = append(options, text)
+		feedback = append(feedback, moodleTextContent(answer.Feedback))
+	}
+	if correct < 0 {
+		result.fail(line, "no answer has fraction 100")
+		return
+	}
+	if !anyNonEmpty(feedback) {
+		feedback = nil
+	}
+	result.add(line, moodleTextContent(question.QuestionText), options, correct, feedback)
+}
+
+// moodleTextContent returns text as plain text. HTML markup is dropped, since
+// quizzes show plain text only.
+func moodleTextContent(text moodleText) string {
+	content := text.Text
+	if text.Format == "" || text.Format == "html" || text.Format == "moodle_auto_format" {
+		content = htmlBlockTag.ReplaceAllString(content, " ")
+		content = html.UnescapeString(htmlTag.ReplaceAllString(content, ""))
+	}
+	return strings.Join(strings.Fields(content), " ")
+}
+
+func titleCase(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
@@ -0,0 +1,131 @@
+// Package questionimport reads question banks exported by learning platforms
+// (Aiken, GIFT, and Moodle XML) into quiz questions. Only single-answer
+// multiple choice and true/false questions map onto quizzes; other question
+// types are reported as errors on the line where they start, so a teacher can
+// see what was left out and fix the source file.
+package questionimport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// Format names a supported question bank format.
+type Format string
+
+const (
+	FormatAiken     Format = "aiken"
+	FormatGIFT      Format = "gift"
+	FormatMoodleXML Format = "moodle_xml"
+)
+
+var (
+	// ErrUnknownFormat reports a format name this package does not read.
+	ErrUnknownFormat = errors.New("unknown import format")
+	// ErrUndetectedFormat reports input that looks like none of the formats.
+	ErrUndetectedFormat = errors.New("could not detect import format")
+)
+
+// ParseFormat accepts a format name case-insensitively. "xml" and "moodle"
+// are accepted for Moodle XML.
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "aiken":
+		return FormatAiken, nil
+	case "gift":
+		return FormatGIFT, nil
+	case "moodle_xml", "moodle", "xml":
+		return FormatMoodleXML, nil
+	default:
+		return "", fmt.Errorf("%w: %q (want aiken, gift, or moodle_xml)", ErrUnknownFormat, value)
+	}
+}
+
+// LineError is a question that could not be imported. Line is 1-based and
+// points at the start of the question.
+type LineError struct {
+	Line    int
+	Message string
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Result is everything read from one file: the questions that could be
+// imported, in file order, and an error for each one that could not.
+type Result struct {
+	Format    Format
+	Questions []quizkit.Question
+	Errors    []LineError
+}
+
+func (r *Result) add(line int, prompt string, options []string, correctIndex int, feedback []string) {
+	question, err := quizkit.NewQuestion(prompt, options, correctIndex)
+	if err == nil && len(feedback) > 0 {
+		question, err = question.WithFeedback(feedback)
+	}
+	if err != nil {
+		r.fail(line, strings.TrimPrefix(err.Error(), quizkit.ErrInvalidQuestion.Error()+": "))
+		return
+	}
+	r.Questions = append(r.Questions, question)
+}
+
+func (r *Result) fail(line int, format string, args ...any) {
+	r.Errors = append(r.Errors, LineError{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// Parse reads data in the given format, detecting it when format is empty.
+// Problems with single questions are collected in Result.Errors; the returned
+// error is only set when the file as a whole cannot be read.
+func Parse(data []byte, format Format) (Result, error) {
+	if format == "" {
+		detected, err := Detect(data)
+		if err != nil {
+			return Result{}, err
+		}
+		format = detected
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	switch format {
+	case FormatAiken:
+		return parseAiken(data), nil
+	case FormatGIFT:
+		return parseGIFT(data), nil
+	case FormatMoodleXML:
+		return parseMoodleXML(data)
+	default:
+		return Result{}, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+var aikenAnswerLine = regexp.MustCompile(`(?m)^\s*ANSWER\s*:\s*[A-Za-z]\s*$`)
+
+// Detect guesses the format of data: XML is Moodle, an "ANSWER: X" line is
+// Aiken, and braces are GIFT.
+func Detect(data []byte) (Format, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<?xml")), bytes.HasPrefix(trimmed, []byte("<quiz")):
+		return FormatMoodleXML, nil
+	case aikenAnswerLine.Match(trimmed):
+		return FormatAiken, nil
+	case bytes.ContainsRune(trimmed, '{') && bytes.ContainsRune(trimmed, '}'):
+		return FormatGIFT, nil
+	default:
+		return "", ErrUndetectedFormat
+	}
+}
+
+// splitLines splits data into lines without their line endings.
+func splitLines(data []byte) []string {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,181 @@
+package questionimport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	cases := map[string]Format{
+		"<?xml version=\"1.0\"?>\n<quiz></quiz>":             FormatMoodleXML,
+		"  <quiz>\n</quiz>":                                  FormatMoodleXML,
+		"Capital of France?\nA. Lyon\nB. Paris\nANSWER: B\n": FormatAiken,
+		"Capital of France? {=Paris ~Lyon}":                  FormatGIFT,
+	}
+	for input, want := range cases {
+		if got, err := Detect([]byte(input)); err != nil || got != want {
+			t.Fatalf("Detect(%q) = (%q, %v), want %q", input, got, err, want)
+		}
+	}
+	if _, err := Detect([]byte("just some text")); !errors.Is(err, ErrUndetectedFormat) {
+		t.Fatalf("Detect(plain text) error = %v, want ErrUndetectedFormat", err)
+	}
+	if _, err := ParseFormat("qti"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("ParseFormat(qti) error = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestParseAikenReportsBrokenQuestionsByLine(t *testing.T) {
+	input := `What is the capital of France?
+A. Lyon
+B) Paris
+ANSWER: B
+
+Which planet is largest?
+A. Mars
+C. Jupiter
+ANSWER: B
+
+How many legs does a spider have?
+A. Six
+B. Eight
+
+Water boils at?
+A. 90C
+B. 100C
+ANSWER: D
+`
+	result, err := Parse([]byte(input), "")
+	if err != nil || result.Format != FormatAiken {
+		t.Fatalf("Parse = (%q, %v), want aiken", result.Format, err)
+	}
+	if len(result.Questions) != 1 {
+		t.Fatalf("questions = %+v, want only the first", result.Questions)
+	}
+	question := result.Questions[0]
+	if question.Question != "What is the capital of France?" || question.CorrectIndex != 1 || question.Options[1].Text != "Paris" {
+		t.Fatalf("question = %+v, want Paris as B", question)
+	}
+
+	want := []LineError{
+		{Line: 6, Message: "option C is out of order; expected B"},
+		{Line: 11, Message: "ANSWER line is missing"},
+		{Line: 15, Message: "ANSWER D does not match any option"},
+	}
+	if !reflect.DeepEqual(result.Errors, want) {
+		t.Fatalf("errors = %+v, want %+v", result.Errors, want)
+	}
+}
+
+func TestParseGIFT(t *testing.T) {
+	input := `// Geography
+$CATEGORY: geography
+
+::Capital:: What is the capital of France? {
+  ~Lyon #Second largest city.
+  =Paris
+}
+
+The sun is a star. {T#Look again.#Right.}
+
+[html]Grant is buried in {~Lincoln's =Grant's ~no one's} tomb.
+
+What does 2 \+ 2 equal\? {=4 =four}
+
+Pick a number {#3:1}
+
+Match them {=a -> 1 =b -> 2}
+
+Two right {=a =b ~c}
+
+A 50\% chance {~%50%half =whole ~none}
+`
+	result, err := Parse([]byte(input), FormatGIFT)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(result.Questions) != 3 {
+		t.Fatalf("questions = %+v, want three", result.Questions)
+	}
+
+	capital := result.Questions[0]
+	if capital.Question != "What is the capital of France?" || capital.CorrectIndex != 1 || capital.FeedbackFor(0) != "Second largest city." {
+		t.Fatalf("capital = %+v, want Paris with feedback on Lyon", capital)
+	}
+	sun := result.Questions[1]
+	if sun.Options[0].Text != "True" || sun.CorrectIndex != 0 || sun.FeedbackFor(1) != "Look again." || sun.FeedbackFor(0) != "Right." {
+		t.Fatalf("sun = %+v, want true with wrong-then-right feedback", sun)
+	}
+	tomb := result.Questions[2]
+	if tomb.Question != "Grant is buried in _____ tomb." || tomb.CorrectIndex != 1 || tomb.Options[0].Text != "Lincoln's" {
+		t.Fatalf("tomb = %+v, want a fill-in-the-blank prompt", tomb)
+	}
+
+	wantLines := []int{13, 15, 17, 19, 21}
+	if len(result.Errors) != len(wantLines) {
+		t.Fatalf("errors = %+v, want %d", result.Errors, len(wantLines))
+	}
+	for idx, line := range wantLines {
+		if result.Errors[idx].Line != line {
+			t.Fatalf("errors[%d] = %+v, want line %d", idx, result.Errors[idx], line)
+		}
+	}
+}
+
+func TestParseMoodleXML(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<quiz>
+  <question type="category">
+    <category><text>$course$/Geography</text></category>
+  </question>
+  <question type="multichoice">
+    <name><text>Capital</text></name>
+    <questiontext format="html"><text><![CDATA[<p>What is the capital of <b>France</b>?</p>]]></text></questiontext>
+    <single>true</single>
+    <answer fraction="0"><text>Lyon</text><feedback><text>Second largest city.</text></feedback></answer>
+    <answer fraction="100"><text>Paris</text></answer>
+  </question>
+  <question type="truefalse">
+    <questiontext format="html"><text>The sun is a star.</text></questiontext>
+    <answer fraction="100"><text>true</text></answer>
+    <answer fraction="0"><text>false</text></answer>
+  </question>
+  <question type="essay">
+    <questiontext format="html"><text>Discuss.</text></questiontext>
+  </question>
+  <question type="multichoice">
+    <questiontext format="html"><text>Pick two</text></questiontext>
+    <single>false</single>
+    <answer fraction="50"><text>a</text></answer>
+    <answer fraction="50"><text>b</text></answer>
+  </question>
+</quiz>
+`
+	result, err := Parse([]byte(input), "")
+	if err != nil || result.Format != FormatMoodleXML {
+		t.Fatalf("Parse = (%q, %v), want moodle_xml", result.Format, err)
+	}
+	if len(result.Questions) != 2 {
+		t.Fatalf("questions = %+v, want two", result.Questions)
+	}
+	capital := result.Questions[0]
+	if capital.Question != "What is the capital of France?" || capital.CorrectIndex != 1 || capital.FeedbackFor(0) != "Second largest city." {
+		t.Fatalf("capital = %+v, want plain text with Paris correct", capital)
+	}
+	if sun := result.Questions[1]; sun.Options[0].Text != "True" || sun.CorrectIndex != 0 {
+		t.Fatalf("sun = %+v, want True correct", sun)
+	}
+
+	want := []LineError{
+		{Line: 18, Message: "essay questions are not supported"},
+		{Line: 21, Message: "multiple-answer questions are not supported"},
+	}
+	if !reflect.DeepEqual(result.Errors, want) {
+		t.Fatalf("errors = %+v, want %+v", result.Errors, want)
+	}
+
+	if _, err := Parse([]byte("<quiz><question>"), FormatMoodleXML); err == nil {
+		t.Fatal("Parse(truncated xml) succeeded, want an error")
+	}
+}
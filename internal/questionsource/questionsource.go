@@ -0,0 +1,331 @@
+// Package questionsource serves quiz questions from a teacher's own JSON or
+// CSV file instead of OpenTriviaDB. The file is read once, when the service
+// starts, and every question in it is checked then, so a mistake stops the
+// server with its line number rather than failing a quiz later.
+package questionsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"quiz-app/internal/opentdb"
+	"quiz-app/pkg/quizkit"
+)
+
+// Format names a supported questions file format.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+// defaultAmount matches OpenTriviaDB's default when amount is not positive.
+const defaultAmount = 10
+
+var (
+	// ErrUnknownFormat reports a file whose extension is neither .json nor
+	// .csv.
+	ErrUnknownFormat = errors.New("unknown questions file format")
+	// ErrNoQuestions reports a file without a single question.
+	ErrNoQuestions = errors.New("questions file has no questions")
+)
+
+// FormatFor picks the format from path's extension.
+func FormatFor(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("%w: %q (want a .json or .csv file)", ErrUnknownFormat, filepath.Base(path))
+	}
+}
+
+// Source holds the questions read from one file.
+type Source struct {
+	name      string
+	questions []opentdb.RawQuestion
+}
+
+// Load reads the questions file at path. Every invalid question is reported,
+// each with its line (CSV) or position (JSON).
+func Load(path string) (*Source, error) {
+	format, err := FormatFor(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := Parse(raw, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return &Source{name: "file:" + filepath.Base(path), questions: questions}, nil
+}
+
+// Name is the provider name recorded in quiz origins: "file:" and the file's
+// base name.
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Len is the number of questions in the file.
+func (s *Source) Len() int {
+	return len(s.questions)
+}
+
+// FetchQuestions draws amount random questions matching filter, or every
+// match when the file holds fewer. It has the quiz.QuestionsFetcher
+// signature.
+func (s *Source) FetchQuestions(_ context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+	if amount <= 0 {
+		amount = defaultAmount
+	}
+	questions := make([]opentdb.RawQuestion, 0, len(s.questions))
+	for _, question := range s.questions {
+		if filter.Matches(question) {
+			questions = append(questions, question)
+		}
+	}
+	rand.Shuffle(len(questions), func(i, j int) {
+		questions[i], questions[j] = questions[j], questions[i]
+	})
+	if amount < len(questions) {
+		questions = questions[:amount]
+	}
+	return questions, nil
+}
+
+// Parse reads questions in format. The README describes both layouts.
+func Parse(raw []byte, format Format) ([]opentdb.RawQuestion, error) {
+	// Spreadsheet exports often start with a byte order mark.
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	var (
+		questions []opentdb.RawQuestion
+		err       error
+	)
+	switch format {
+	case FormatJSON:
+		questions, err = parseJSON(raw)
+	case FormatCSV:
+		questions, err = parseCSV(raw)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+	return questions, nil
+}
+
+// jsonQuestion takes either options with correct_index, as POST /quizzes
+// does, or OpenTriviaDB's correct_answer and incorrect_answers, so bundle
+// files work as they are.
+type jsonQuestion struct {
+	Question         string   `json:"question"`
+	Options          []string `json:"options"`
+	CorrectIndex     *int     `json:"correct_index"`
+	CorrectAnswer    string   `json:"correct_answer"`
+	IncorrectAnswers []string `json:"incorrect_answers"`
+	Difficulty       string   `json:"difficulty"`
+	Category         string   `json:"category"`
+}
+
+func parseJSON(raw []byte) ([]opentdb.RawQuestion, error) {
+	var entries []jsonQuestion
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	} else {
+		var file struct {
+			Questions []jsonQuestion `json:"questions"`
+		}
+		if err := json.Unmarshal(trimmed, &file); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		entries = file.Questions
+	}
+
+	var (
+		questions []opentdb.RawQuestion
+		errs      []error
+	)
+	for idx, entry := range entries {
+		correct, incorrect := entry.CorrectAnswer, entry.IncorrectAnswers
+		if entry.CorrectIndex != nil || len(entry.Options) > 0 {
+			if entry.CorrectAnswer != "" || len(entry.IncorrectAnswers) > 0 {
+				errs = append(errs, fmt.Errorf("question %d: use options and correct_index, or correct_answer and incorrect_answers, not both", idx+1))
+				continue
+			}
+			if entry.CorrectIndex == nil || *entry.CorrectIndex < 0 || *entry.CorrectIndex >= len(entry.Options) {
+				errs = append(errs, fmt.Errorf("question %d: correct_index must point at one of the options", idx+1))
+				continue
+			}
+			correct = entry.Options[*entry.CorrectIndex]
+			incorrect = make([]string, 0, len(entry.Options)-1)
+			incorrect = append(incorrect, entry.Options[:*entry.CorrectIndex]...)
+			incorrect = append(incorrect, entry.Options[*entry.CorrectIndex+1:]...)
+		}
+		question, err := rawQuestion(entry.Question, correct, incorrect, entry.Difficulty, entry.Category)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("question %d: %w", idx+1, err))
+			continue
+		}
+		questions = append(questions, question)
+	}
+	return questions, errors.Join(errs...)
+}
+
+// parseCSV reads a header row naming the columns, then one question per row.
+// question and correct_answer are required; every column whose name starts
+// with incorrect_answer holds one wrong option, and blank ones are skipped.
+// difficulty and category are optional.
+func parseCSV(raw []byte) ([]opentdb.RawQuestion, error) {
+	reader := csv.NewReader(bytes.NewReader(raw))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNoQuestions
+		}
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	var incorrectColumns []int
+	for idx, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "question" || name == "correct_answer" || name == "difficulty" || name == "category":
+			if _, ok := columns[name]; ok {
+				return nil, fmt.Errorf("line 1: column %q appears twice", name)
+			}
+			columns[name] = idx
+		case strings.HasPrefix(name, "incorrect_answer"):
+			incorrectColumns = append(incorrectColumns, idx)
+		default:
+			return nil, fmt.Errorf("line 1: unknown column %q (want question, correct_answer, incorrect_answer..., difficulty, category)", name)
+		}
+	}
+	for _, required := range []string{"question", "correct_answer"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("line 1: missing column %q", required)
+		}
+	}
+	if len(incorrectColumns) == 0 {
+		return nil, errors.New("line 1: missing incorrect_answer columns")
+	}
+	field := func(record []string, name string) string {
+		if idx, ok := columns[name]; ok {
+			return record[idx]
+		}
+		return ""
+	}
+
+	var (
+		questions []opentdb.RawQuestion
+		errs      []error
+	)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Rows with the wrong number of fields and broken quoting come
+			// back as csv.ParseError, which carries its own line number.
+			errs = append(errs, err)
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				continue
+			}
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		incorrect := make([]string, 0, len(incorrectColumns))
+		for _, idx := range incorrectColumns {
+			if text := strings.TrimSpace(record[idx]); text != "" {
+				incorrect = append(incorrect, text)
+			}
+		}
+		question, err := rawQuestion(field(record, "question"), field(record, "correct_answer"), incorrect, field(record, "difficulty"), field(record, "category"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		questions = append(questions, question)
+	}
+	return questions, errors.Join(errs...)
+}
+
+// rawQuestion checks a question and converts it to the provider shape. Text
+// is escaped because fetched questions are unescaped as OpenTriviaDB's HTML
+// entities.
+func rawQuestion(prompt, correct string, incorrect []string, difficulty, category string) (opentdb.RawQuestion, error) {
+	prompt, correct = strings.TrimSpace(prompt), strings.TrimSpace(correct)
+	switch {
+	case prompt == "":
+		return opentdb.RawQuestion{}, errors.New("question text is required")
+	case correct == "":
+		return opentdb.RawQuestion{}, errors.New("correct answer is required")
+	case len(incorrect) == 0:
+		return opentdb.RawQuestion{}, errors.New("at least one incorrect answer is required")
+	case len(incorrect)+1 > quizkit.MaxOptions:
+		return opentdb.RawQuestion{}, fmt.Errorf("at most %d options are allowed", quizkit.MaxOptions)
+	}
+	seen := map[string]bool{strings.ToLower(correct): true}
+	for idx, text := range incorrect {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return opentdb.RawQuestion{}, fmt.Errorf("incorrect answer %d is empty", idx+1)
+		}
+		if seen[strings.ToLower(text)] {
+			return opentdb.RawQuestion{}, fmt.Errorf("answer %q appears twice", text)
+		}
+		seen[strings.ToLower(text)] = true
+	}
+	level, err := quizkit.ParseDifficulty(difficulty)
+	if err != nil {
+		return opentdb.RawQuestion{}, errors.New(strings.TrimPrefix(err.Error(), quizkit.ErrInvalidQuestion.Error()+": "))
+	}
+
+	questionType := opentdb.TypeMultiple
+	if len(incorrect) == 1 && isTrueFalse(correct, incorrect[0]) {
+		questionType = opentdb.TypeBoolean
+	}
+	escaped := make([]string, len(incorrect))
+	for idx, text := range incorrect {
+		escaped[idx] = html.EscapeString(strings.TrimSpace(text))
+	}
+	return opentdb.RawQuestion{
+		Type:             questionType,
+		Difficulty:       string(level),
+		Category:         html.EscapeString(strings.TrimSpace(category)),
+		Question:         html.EscapeString(prompt),
+		CorrectAnswer:    html.EscapeString(correct),
+		IncorrectAnswers: escaped,
+	}, nil
+}
+
+func isTrueFalse(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	return (a == "true" && b == "false") || (a == "false" && b == "true")
+}
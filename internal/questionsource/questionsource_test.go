@@ -0,0 +1,122 @@
+package questionsource
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"quiz-app/internal/opentdb"
+	"quiz-app/internal/quiz"
+)
+
+func TestParseJSONAcceptsBothQuestionShapes(t *testing.T) {
+	input := `{"questions": [
+		{"question": "Fish & chips come from?", "options": ["France", "England", "Peru"], "correct_index": 1, "difficulty": "Easy", "category": "Food"},
+		{"question": "The sun is a star.", "correct_answer": "True", "incorrect_answers": ["False"]}
+	]}`
+	questions, err := Parse([]byte(input), FormatJSON)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []opentdb.RawQuestion{
+		{Type: opentdb.TypeMultiple, Difficulty: "easy", Category: "Food", Question: "Fish &amp; chips come from?", CorrectAnswer: "England", IncorrectAnswers: []string{"France", "Peru"}},
+		{Type: opentdb.TypeBoolean, Question: "The sun is a star.", CorrectAnswer: "True", IncorrectAnswers: []string{"False"}},
+	}
+	if !reflect.DeepEqual(questions, want) {
+		t.Fatalf("questions = %+v, want %+v", questions, want)
+	}
+
+	// Built questions read as the teacher wrote them.
+	built := quiz.BuildQuestions(questions[:1])
+	if built[0].Question != "Fish & chips come from?" || built[0].Options[built[0].CorrectIndex].Text != "England" {
+		t.Fatalf("built = %+v, want the unescaped text with England correct", built[0])
+	}
+
+	bare, err := Parse([]byte(`[{"question": "2 + 2?", "options": ["4", "5"], "correct_index": 0}]`), FormatJSON)
+	if err != nil || len(bare) != 1 {
+		t.Fatalf("bare array = (%+v, %v), want one question", bare, err)
+	}
+}
+
+func TestParseCSVReportsBrokenRowsByLine(t *testing.T) {
+	input := "\xef\xbb\xbfquestion,correct_answer,incorrect_answer_1,incorrect_answer_2,difficulty\n" +
+		"Capital of France?,Paris,Lyon,,medium\n" +
+		"\"Largest planet, by mass?\",Jupiter,Mars,Venus,\n" +
+		"No wrong answers?,Yes,,,\n" +
+		"Duplicate?,Yes,yes,,\n" +
+		"Too hard?,Yes,No,,extreme\n"
+	questions, err := Parse([]byte(input), FormatCSV)
+	if err == nil {
+		t.Fatalf("Parse = %+v, want errors for lines 4-6", questions)
+	}
+	for _, want := range []string{"line 4: at least one incorrect answer", `line 5: answer "yes" appears twice`, `line 6: unknown difficulty "extreme"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error = %q, want it to mention %q", err, want)
+		}
+	}
+	if questions != nil {
+		t.Fatalf("questions = %+v, want none from a file with errors", questions)
+	}
+
+	valid := strings.Join(strings.SplitAfter(input, "\n")[:3], "")
+	questions, err = Parse([]byte(valid), FormatCSV)
+	if err != nil || len(questions) != 2 || questions[0].Difficulty != "medium" || !reflect.DeepEqual(questions[0].IncorrectAnswers, []string{"Lyon"}) || questions[1].Question != "Largest planet, by mass?" {
+		t.Fatalf("valid rows = (%+v, %v), want both questions", questions, err)
+	}
+
+	for input, want := range map[string]string{
+		"question,answer\n":               `unknown column "answer"`,
+		"question,incorrect_answer\n":     `missing column "correct_answer"`,
+		"question,correct_answer\nA?,B\n": "missing incorrect_answer columns",
+		"":                                ErrNoQuestions.Error(),
+		"question,correct_answer,incorrect_answer": ErrNoQuestions.Error(),
+	} {
+		if _, err := Parse([]byte(input), FormatCSV); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Parse(%q) error = %v, want %q", input, err, want)
+		}
+	}
+}
+
+func TestLoadAndFetchQuestions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "biology.csv")
+	content := "question,correct_answer,incorrect_answer,incorrect_answer,difficulty\n" +
+		"Cells have a nucleus?,True,False,,easy\n" +
+		"Powerhouse of the cell?,Mitochondria,Ribosome,Golgi,hard\n" +
+		"Unit of heredity?,Gene,Cell,Organ,hard\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	source, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if source.Name() != "file:biology.csv" || source.Len() != 3 {
+		t.Fatalf("source = (%s, %d), want file:biology.csv with 3 questions", source.Name(), source.Len())
+	}
+
+	var fetcher quiz.QuestionsFetcher = source.FetchQuestions
+	hard, err := fetcher(context.Background(), 10, opentdb.Filter{Difficulty: "hard"})
+	if err != nil || len(hard) != 2 {
+		t.Fatalf("hard questions = (%+v, %v), want both hard ones", hard, err)
+	}
+	one, err := fetcher(context.Background(), 1, opentdb.Filter{})
+	if err != nil || len(one) != 1 {
+		t.Fatalf("one question = (%+v, %v), want one", one, err)
+	}
+	boolean, err := fetcher(context.Background(), 0, opentdb.Filter{Type: opentdb.TypeBoolean})
+	if err != nil || len(boolean) != 1 || boolean[0].CorrectAnswer != "True" {
+		t.Fatalf("true/false questions = (%+v, %v), want the nucleus question", boolean, err)
+	}
+
+	if _, err := Load(filepath.Join(dir, "questions.txt")); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("Load(.txt) error = %v, want ErrUnknownFormat", err)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Load(missing) error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,277 @@
+package quiz
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"quiz-app/internal/opentdb"
+	"quiz-app/pkg/quizkit"
+)
+
+// BankOptions bounds the in-memory question bank. The zero value keeps every
+// question forever, matching NewBank.
+type BankOptions struct {
+	// MaxQuestions caps the bank; the least recently used question is evicted
+	// first. Zero means unbounded.
+	MaxQuestions int
+	// TTL drops questions that were neither added nor looked up for this long.
+	// Zero disables expiry.
+	TTL time.Duration
+	// Store answers lookups the bank misses, so quiz-less evaluation keeps
+	// working after a restart or eviction. Found questions are cached again.
+	Store QuestionLookup
+}
+
+// BankStats is a point-in-time view of bank usage.
+type BankStats struct {
+	Size         int
+	Hits         int64
+	Misses       int64
+	StoreHits    int64
+	StoreErrors  int64
+	Evictions    int64
+	Expirations  int64
+	MaxQuestions int
+	TTL          time.Duration
+}
+
+// Bank holds built questions for evaluating answers that do not name a quiz.
+// It is safe for concurrent use.
+type Bank struct {
+	maxQuestions int
+	ttl          time.Duration
+	store        QuestionLookup
+	now          func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recency orders entries most recently used first.
+	recency *list.List
+	stats   BankStats
+}
+
+type bankEntry struct {
+	question Question
+	usedAt   time.Time
+}
+
+func NewBank() *Bank {
+	return NewBankWithOptions(BankOptions{})
+}
+
+func NewBankWithOptions(options BankOptions) *Bank {
+	return &Bank{
+		maxQuestions: max(options.MaxQuestions, 0),
+		ttl:          max(options.TTL, 0),
+		store:        options.Store,
+		now:          time.Now,
+		entries:      make(map[string]*list.Element),
+		recency:      list.New(),
+	}
+}
+
+func (b *Bank) AddQuestions(raw []opentdb.RawQuestion) []Question {
+	questions := BuildQuestions(raw)
+	b.AddBuiltQuestions(questions)
+	return questions
+}
+
+// AddBuiltQuestions adds questions the server built or served, replacing any
+// entry with the same ID. Use AddCustomQuestions for caller-supplied ones.
+func (b *Bank) AddBuiltQuestions(questions []Question) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	for _, question := range questions {
+		if question.QuestionID == "" {
+			question.QuestionID = MakeQuestionID(question)
+		}
+		b.putLocked(question, now)
+	}
+}
+
+// AddCustomQuestions adds caller-supplied questions without replacing any the
+// bank or its store already holds, since a question ID covers only the prompt
+// and options. A question whose ID is taken by one with another answer key
+// fails the call with ErrQuestionConflict, and nothing is added.
+func (b *Bank) AddCustomQuestions(ctx context.Context, questions []Question) error {
+	questionIDs := make([]string, 0, len(questions))
+	for idx := range questions {
+		if questions[idx].QuestionID == "" {
+			questions[idx].QuestionID = MakeQuestionID(questions[idx])
+		}
+		questionIDs = append(questionIDs, questions[idx].QuestionID)
+	}
+
+	known := make(map[string]Question, len(questions))
+	if b.store != nil {
+		stored, err := b.store.LookupQuestions(ctx, questionIDs)
+		if err != nil {
+			return err
+		}
+		for _, question := range stored {
+			known[question.QuestionID] = question
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, questionID := range questionIDs {
+		if element, ok := b.entries[questionID]; ok {
+			known[questionID] = element.Value.(*bankEntry).question
+		}
+	}
+	for _, question := range questions {
+		if existing, ok := known[question.QuestionID]; ok && !sameAnswerKey(existing, question) {
+			return fmt.Errorf("%w: %s", ErrQuestionConflict, question.QuestionID)
+		}
+	}
+
+	now := b.now()
+	for _, question := range questions {
+		if element, ok := b.entries[question.QuestionID]; ok {
+			element.Value.(*bankEntry).usedAt = now
+			b.recency.MoveToFront(element)
+			continue
+		}
+		if existing, ok := known[question.QuestionID]; ok {
+			question = existing
+		}
+		b.putLocked(question, now)
+	}
+	return nil
+}
+
+func sameAnswerKey(a, b Question) bool {
+	return a.CorrectIndex == b.CorrectIndex && slices.Equal(a.CorrectIndexes, b.CorrectIndexes)
+}
+
+// EvaluateResponses is EvaluateResponsesContext without a deadline. Store
+// errors are treated as unknown questions.
+func (b *Bank) EvaluateResponses(responses []SubmittedResponse) []ResponseResult {
+	results, _ := b.EvaluateResponsesContext(context.Background(), responses)
+	return results
+}
+
+// EvaluateResponsesContext scores responses against banked questions, asking
+// the configured store for any the bank does not hold. Multi-select answers
+// earn partial credit, reported in Credit. Results are always
+// returned; a store error leaves those questions as StatusInvalidQuestion and
+// is reported alongside.
+func (b *Bank) EvaluateResponsesContext(ctx context.Context, responses []SubmittedResponse) ([]ResponseResult, error) {
+	questionIDs := make([]string, 0, len(responses))
+	for _, response := range responses {
+		questionIDs = append(questionIDs, response.QuestionID)
+	}
+	questions, err := b.lookup(ctx, questionIDs)
+
+	results := make([]ResponseResult, 0, len(responses))
+	for _, response := range responses {
+		result := ResponseResult{QuestionID: response.QuestionID, Status: StatusInvalidQuestion}
+		if question, ok := questions[response.QuestionID]; ok {
+			result = quizkit.GradeResponse(question, response)
+		}
+		results = append(results, result)
+	}
+	return results, err
+}
+
+// Stats reports current size and lifetime counters.
+func (b *Bank) Stats() BankStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireLocked(b.now())
+	stats := b.stats
+	stats.Size = len(b.entries)
+	stats.MaxQuestions = b.maxQuestions
+	stats.TTL = b.ttl
+	return stats
+}
+
+func (b *Bank) lookup(ctx context.Context, questionIDs []string) (map[string]Question, error) {
+	found := make(map[string]Question, len(questionIDs))
+	missing := make([]string, 0)
+
+	b.mu.Lock()
+	now := b.now()
+	b.expireLocked(now)
+	for _, questionID := range questionIDs {
+		if _, ok := found[questionID]; ok {
+			continue
+		}
+		element, ok := b.entries[questionID]
+		if !ok {
+			b.stats.Misses++
+			missing = append(missing, questionID)
+			continue
+		}
+		b.stats.Hits++
+		entry := element.Value.(*bankEntry)
+		entry.usedAt = now
+		b.recency.MoveToFront(element)
+		found[questionID] = entry.question
+	}
+	b.mu.Unlock()
+
+	if len(missing) == 0 || b.store == nil {
+		return found, nil
+	}
+
+	// The store is queried without holding the lock so slow lookups do not
+	// block other requests.
+	loaded, err := b.store.LookupQuestions(ctx, missing)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.stats.StoreErrors++
+		return found, err
+	}
+	now = b.now()
+	for _, question := range loaded {
+		b.stats.StoreHits++
+		b.putLocked(question, now)
+		found[question.QuestionID] = question
+	}
+	return found, nil
+}
+
+func (b *Bank) putLocked(question Question, now time.Time) {
+	if element, ok := b.entries[question.QuestionID]; ok {
+		element.Value = &bankEntry{question: question, usedAt: now}
+		b.recency.MoveToFront(element)
+		return
+	}
+
+	b.entries[question.QuestionID] = b.recency.PushFront(&bankEntry{question: question, usedAt: now})
+	for b.maxQuestions > 0 && len(b.entries) > b.maxQuestions {
+		b.removeLocked(b.recency.Back())
+		b.stats.Evictions++
+	}
+}
+
+// expireLocked drops entries idle longer than the TTL. Recency order means the
+// scan can stop at the first live entry from the back.
+func (b *Bank) expireLocked(now time.Time) {
+	if b.ttl <= 0 {
+		return
+	}
+	for element := b.recency.Back(); element != nil; element = b.recency.Back() {
+		if now.Sub(element.Value.(*bankEntry).usedAt) < b.ttl {
+			return
+		}
+		b.removeLocked(element)
+		b.stats.Expirations++
+	}
+}
+
+func (b *Bank) removeLocked(element *list.Element) {
+	b.recency.Remove(element)
+	delete(b.entries, element.Value.(*bankEntry).question.QuestionID)
+}
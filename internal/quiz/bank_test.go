@@ -0,0 +1,148 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeQuestionLookup struct {
+	questions map[string]Question
+	calls     int
+	err       error
+}
+
+func (f *fakeQuestionLookup) LookupQuestions(_ context.Context, questionIDs []string) ([]Question, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	found := make([]Question, 0, len(questionIDs))
+	for _, questionID := range questionIDs {
+		if question, ok := f.questions[questionID]; ok {
+			found = append(found, question)
+		}
+	}
+	return found, nil
+}
+
+func bankQuestion(questionID string) Question {
+	return Question{
+		PublicQuestion: PublicQuestion{
+			QuestionID: questionID,
+			Question:   "Prompt " + questionID,
+			Options:    []Option{{Letter: "A", Text: "Yes"}, {Letter: "B", Text: "No"}},
+		},
+		CorrectIndex: 0,
+	}
+}
+
+func TestBankEvictsLeastRecentlyUsed(t *testing.T) {
+	bank := NewBankWithOptions(BankOptions{MaxQuestions: 2})
+	bank.AddBuiltQuestions([]Question{bankQuestion("q1"), bankQuestion("q2")})
+
+	// Touch q1 so q2 becomes the eviction candidate.
+	bank.EvaluateResponses([]SubmittedResponse{{QuestionID: "q1", Answer: "A"}})
+	bank.AddBuiltQuestions([]Question{bankQuestion("q3")})
+
+	results := bank.EvaluateResponses([]SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "q2", Answer: "A"},
+		{QuestionID: "q3", Answer: "A"},
+	})
+	if results[0].Status != StatusCorrect || results[1].Status != StatusInvalidQuestion || results[2].Status != StatusCorrect {
+		t.Fatalf("results = (%+v), want q2 evicted", results)
+	}
+
+	stats := bank.Stats()
+	if stats.Size != 2 || stats.Evictions != 1 || stats.Hits != 3 || stats.Misses != 1 {
+		t.Fatalf("stats = (%+v), want size 2, 1 eviction, 3 hits, 1 miss", stats)
+	}
+}
+
+func TestBankExpiresIdleQuestions(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	bank := NewBankWithOptions(BankOptions{TTL: time.Minute})
+	bank.now = func() time.Time { return now }
+
+	bank.AddBuiltQuestions([]Question{bankQuestion("q1"), bankQuestion("q2")})
+	now = now.Add(40 * time.Second)
+	bank.EvaluateResponses([]SubmittedResponse{{QuestionID: "q1", Answer: "A"}})
+	now = now.Add(40 * time.Second)
+
+	results := bank.EvaluateResponses([]SubmittedResponse{
+		{QuestionID: "q1", Answer: "B"},
+		{QuestionID: "q2", Answer: "A"},
+	})
+	if results[0].Status != StatusIncorrect || results[1].Status != StatusInvalidQuestion {
+		t.Fatalf("results = (%+v), want q1 kept alive and q2 expired", results)
+	}
+	if stats := bank.Stats(); stats.Size != 1 || stats.Expirations != 1 {
+		t.Fatalf("stats = (%+v), want size 1 and 1 expiration", stats)
+	}
+}
+
+func TestBankFallsBackToStore(t *testing.T) {
+	store := &fakeQuestionLookup{questions: map[string]Question{"q1": bankQuestion("q1")}}
+	bank := NewBankWithOptions(BankOptions{Store: store})
+
+	results, err := bank.EvaluateResponsesContext(context.Background(), []SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "missing", Answer: "A"},
+	})
+	if err != nil {
+		t.Fatalf("EvaluateResponsesContext failed: %v", err)
+	}
+	if results[0].Status != StatusCorrect || results[1].Status != StatusInvalidQuestion {
+		t.Fatalf("results = (%+v), want q1 from store and missing invalid", results)
+	}
+
+	// The loaded question is cached; only the unknown ID goes back to the store.
+	bank.EvaluateResponses([]SubmittedResponse{{QuestionID: "q1", Answer: "A"}, {QuestionID: "missing", Answer: "A"}})
+	if store.calls != 2 {
+		t.Fatalf("store calls = %d, want 2", store.calls)
+	}
+	if stats := bank.Stats(); stats.StoreHits != 1 || stats.Size != 1 {
+		t.Fatalf("stats = (%+v), want 1 store hit and size 1", stats)
+	}
+
+	store.err = errors.New("disk on fire")
+	if _, err := bank.EvaluateResponsesContext(context.Background(), []SubmittedResponse{{QuestionID: "other", Answer: "A"}}); err == nil {
+		t.Fatalf("EvaluateResponsesContext error = nil, want store error")
+	}
+	if stats := bank.Stats(); stats.StoreErrors != 1 {
+		t.Fatalf("stats = (%+v), want 1 store error", stats)
+	}
+}
+
+func TestBankCustomQuestionsKeepExistingAnswerKeys(t *testing.T) {
+	ctx := context.Background()
+	stored := bankQuestion("q2")
+	store := &fakeQuestionLookup{questions: map[string]Question{"q2": stored}}
+	bank := NewBankWithOptions(BankOptions{Store: store})
+	bank.AddBuiltQuestions([]Question{bankQuestion("q1")})
+
+	rekeyed := bankQuestion("q1")
+	rekeyed.CorrectIndex = 1
+	if err := bank.AddCustomQuestions(ctx, []Question{rekeyed, bankQuestion("q3")}); !errors.Is(err, ErrQuestionConflict) {
+		t.Fatalf("AddCustomQuestions over a banked question error = %v, want ErrQuestionConflict", err)
+	}
+	rekeyed = bankQuestion("q2")
+	rekeyed.CorrectIndex = 1
+	if err := bank.AddCustomQuestions(ctx, []Question{rekeyed}); !errors.Is(err, ErrQuestionConflict) {
+		t.Fatalf("AddCustomQuestions over a stored question error = %v, want ErrQuestionConflict", err)
+	}
+	if err := bank.AddCustomQuestions(ctx, []Question{bankQuestion("q1"), bankQuestion("q2")}); err != nil {
+		t.Fatalf("AddCustomQuestions with the same answer keys failed: %v", err)
+	}
+
+	results := bank.EvaluateResponses([]SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "q2", Answer: "A"},
+		{QuestionID: "q3", Answer: "A"},
+	})
+	if results[0].Status != StatusCorrect || results[1].Status != StatusCorrect || results[2].Status != StatusInvalidQuestion {
+		t.Fatalf("results = (%+v), want q1 and q2 unchanged and q3 never added", results)
+	}
+}
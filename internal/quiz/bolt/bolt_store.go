@@ -0,0 +1,98 @@
+package bolt
+
+import (
+	"strings"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+)
+
+// Bucket layout:
+//   - quizzes:   quiz_id -> quizRecord (JSON)
+//   - questions: question_id -> questionRecord (JSON), shared across quizzes
+//   - attempts:  one nested bucket per quiz_id, keyed by attemptKey(username, question_id)
+//   - usage:     question_id -> most recent usage (unix seconds, decimal)
+//   - bookmarks: one nested bucket per username, question_id -> created at (unix nanos, decimal)
+//   - authors:   question_id -> authorRecord (JSON)
+//   - reports:   one nested bucket per question_id, username -> reportRecord (JSON)
+//   - profiles:  username -> profileRecord (JSON)
+//   - serves:    one nested bucket per quiz_id, attemptKey(username, question_id) -> first served at (unix nanos, decimal)
+//   - servelog:  one nested bucket per quiz_id, username -> serveLogRecord (JSON)
+//   - leaderboards: quiz_id -> leaderboardSettingsRecord (JSON)
+//   - retirements: question_id -> retirementRecord (JSON)
+//   - rosters:   quiz_id -> rosterRecord (JSON)
+//   - identities: username -> identityRecord (JSON)
+//   - streaks:   username -> streakRecord (JSON)
+//   - signingkeys: one nested bucket per username, key_id -> signingKeyRecord (JSON)
+//   - results:   quiz_id -> resultsRecord (JSON)
+//   - audit:     one nested bucket per quiz_id, sequence (big-endian uint64) -> auditRecord (JSON)
+//   - preferences: username -> preferencesRecord (JSON)
+//   - hosts:     quiz_id -> []hostRecord (JSON)
+//   - summaries: one nested bucket per quiz_id, username -> summaryRecord (JSON) of archived attempts
+//   - users:     username -> accountRecord (JSON)
+//   - tags:      question_id -> sorted tags ([]string, JSON)
+var (
+	quizzesBucket   = []byte("quizzes")
+	questionsBucket = []byte("questions")
+	attemptsBucket  = []byte("attempts")
+	usageBucket     = []byte("usage")
+	bookmarksBucket = []byte("bookmarks")
+	authorsBucket   = []byte("authors")
+	reportsBucket   = []byte("reports")
+	profilesBucket  = []byte("profiles")
+	servesBucket    = []byte("serves")
+	serveLogBucket  = []byte("servelog")
+
+	leaderboardsBucket = []byte("leaderboards")
+	retirementsBucket  = []byte("retirements")
+	rostersBucket      = []byte("rosters")
+	identitiesBucket   = []byte("identities")
+	streaksBucket      = []byte("streaks")
+	signingKeysBucket  = []byte("signingkeys")
+	resultsBucket      = []byte("results")
+	auditBucket        = []byte("audit")
+	preferencesBucket  = []byte("preferences")
+	hostsBucket        = []byte("hosts")
+	summariesBucket    = []byte("summaries")
+	usersBucket        = []byte("users")
+	tagsBucket         = []byte("tags")
+)
+
+// BoltStore is a pure-Go embedded implementation of the quiz repositories.
+// It exists so the service can be built with CGO_ENABLED=0 (for example when
+// cross-compiling to ARM) while keeping the same behavior as the SQLite store.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	if strings.TrimSpace(path) == "" {
+		path = "quiz.bolt"
+	}
+
+	// Timeout mirrors SQLite busy_timeout: fail instead of blocking forever when
+	// another process holds the file lock.
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{quizzesBucket, questionsBucket, attemptsBucket, usageBucket, bookmarksBucket, authorsBucket, reportsBucket, profilesBucket, servesBucket, serveLogBucket, leaderboardsBucket, retirementsBucket, rostersBucket, identitiesBucket, streaksBucket, signingKeysBucket, resultsBucket, auditBucket, preferencesBucket, hostsBucket, summariesBucket, usersBucket, tagsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
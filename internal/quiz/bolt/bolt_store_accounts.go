@@ -0,0 +1,54 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type accountRecord struct {
+	PasswordHash  string `json:"password_hash"`
+	CreatedAtUnix int64  `json:"created_at_unix"`
+}
+
+func (s *BoltStore) CreateAccount(_ context.Context, account quiz.Account) error {
+	record := accountRecord{
+		PasswordHash:  account.PasswordHash,
+		CreatedAtUnix: account.CreatedAt.UnixNano(),
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		if users.Get([]byte(account.Username)) != nil {
+			return quiz.ErrAccountExists
+		}
+		return putJSON(users, account.Username, record)
+	})
+}
+
+func (s *BoltStore) GetAccount(_ context.Context, usernameNormalized string) (quiz.Account, error) {
+	var account quiz.Account
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(usersBucket).Get([]byte(usernameNormalized))
+		if raw == nil {
+			return nil
+		}
+		var record accountRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		account = quiz.Account{
+			Username:     usernameNormalized,
+			PasswordHash: record.PasswordHash,
+			CreatedAt:    time.Unix(0, record.CreatedAtUnix).UTC(),
+		}
+		return nil
+	})
+	if err != nil {
+		return quiz.Account{}, err
+	}
+	return account, nil
+}
@@ -0,0 +1,73 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+// ListQuizStats scans every quiz and its attempts bucket. Storage is the sum of
+// stored key and value sizes, which ignores B+tree page overhead.
+func (s *BoltStore) ListQuizStats(_ context.Context) ([]quiz.QuizStats, error) {
+	stats := make([]quiz.QuizStats, 0)
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		questionBucket := tx.Bucket(questionsBucket)
+		attempts := tx.Bucket(attemptsBucket)
+
+		return tx.Bucket(quizzesBucket).ForEach(func(key, value []byte) error {
+			var record quizRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+
+			item := quiz.QuizStats{
+				QuizMetadata: record.metadata(),
+				StorageBytes: int64(len(key) + len(value)),
+			}
+			item.LastActivityAt = item.CreatedAt
+			for _, questionID := range record.QuestionIDs {
+				item.StorageBytes += int64(len(questionID) + len(questionBucket.Get([]byte(questionID))))
+			}
+
+			quizAttempts := attempts.Bucket(key)
+			if quizAttempts == nil {
+				stats = append(stats, item)
+				return nil
+			}
+
+			participants := make(map[string]struct{})
+			err := quizAttempts.ForEach(func(attemptKey, attemptValue []byte) error {
+				var attempt attemptRecord
+				if err := json.Unmarshal(attemptValue, &attempt); err != nil {
+					return err
+				}
+
+				username, _, _ := bytes.Cut(attemptKey, []byte(attemptSeparator))
+				participants[string(username)] = struct{}{}
+				item.AttemptCount++
+				item.StorageBytes += int64(len(attemptKey) + len(attemptValue))
+				if submittedAt := time.Unix(0, attempt.SubmittedAtUnix).UTC(); submittedAt.After(item.LastActivityAt) {
+					item.LastActivityAt = submittedAt
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			item.ParticipantCount = len(participants)
+			stats = append(stats, item)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,330 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+	"quiz-app/pkg/quizkit"
+)
+
+// attemptSeparator cannot appear in normalized usernames typed by humans, so a
+// username prefix scan never bleeds into a different user.
+const attemptSeparator = "\x00"
+
+type attemptRecord struct {
+	AnswerLetter    string  `json:"answer_letter"`
+	Score           float64 `json:"score"`
+	SubmittedAtUnix int64   `json:"submitted_at_unix"`
+	SubmittedBy     string  `json:"submitted_by,omitempty"`
+}
+
+func attemptKey(usernameNormalized, questionID string) []byte {
+	return []byte(usernameNormalized + attemptSeparator + questionID)
+}
+
+// SubmitResponses mirrors the SQLite invariants inside a single bbolt write
+// transaction: unknown or voided questions and bad letters are rejected per item, and an
+// existing (quiz, question, user) attempt is never overwritten. Multi-select
+// answers score their partial credit.
+func (s *BoltStore) SubmitResponses(_ context.Context, quizID, usernameNormalized string, responses []quiz.SubmittedResponse) ([]quiz.ResponseResult, error) {
+	results := make([]quiz.ResponseResult, 0, len(responses))
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok || len(record.QuestionIDs) == 0 {
+			return quiz.ErrQuizNotFound
+		}
+
+		questionBucket := tx.Bucket(questionsBucket)
+		questionLookup := make(map[string]questionRecord, len(record.QuestionIDs))
+		for _, questionID := range record.QuestionIDs {
+			stored, ok, err := loadQuestion(questionBucket, questionID)
+			if err != nil {
+				return err
+			}
+			if ok {
+				questionLookup[questionID] = stored
+			}
+		}
+
+		quizAttempts, err := tx.Bucket(attemptsBucket).CreateBucketIfNotExists([]byte(quizID))
+		if err != nil {
+			return err
+		}
+
+		for _, response := range responses {
+			stored, ok := questionLookup[response.QuestionID]
+			if !ok {
+				results = append(results, quiz.ResponseResult{
+					QuestionID: response.QuestionID,
+					Status:     quiz.StatusInvalidQuestion,
+				})
+				continue
+			}
+			if record.voided(response.QuestionID) {
+				results = append(results, quiz.ResponseResult{
+					QuestionID: response.QuestionID,
+					Status:     quiz.StatusVoidedQuestion,
+				})
+				continue
+			}
+
+			question := stored.question()
+			status, credit := quizkit.GradeAnswer(question, response.Answer)
+			if status == quiz.StatusInvalidLetter {
+				results = append(results, quiz.ResponseResult{
+					QuestionID: response.QuestionID,
+					Status:     quiz.StatusInvalidLetter,
+				})
+				continue
+			}
+			chosen, _ := question.ParseAnswer(response.Answer)
+			letter := quizkit.FormatLetters(chosen)
+
+			key := attemptKey(usernameNormalized, response.QuestionID)
+			if existing := quizAttempts.Get(key); existing != nil {
+				var previous attemptRecord
+				if err := json.Unmarshal(existing, &previous); err != nil {
+					return err
+				}
+				score := previous.Score
+				results = append(results, quiz.ResponseResult{
+					QuestionID:   response.QuestionID,
+					Status:       quiz.StatusAlreadyAnswered,
+					AttemptScore: &score,
+				})
+				continue
+			}
+
+			score := 0.0
+			bonus := 0.0
+			switch status {
+			case quiz.StatusCorrect:
+				bonus = response.Bonus
+				score = 1.0 + bonus
+				credit = 0
+			case quiz.StatusPartiallyCorrect:
+				score = credit
+			}
+
+			encoded, err := json.Marshal(attemptRecord{
+				AnswerLetter:    letter,
+				Score:           score,
+				SubmittedAtUnix: time.Now().UTC().UnixNano(),
+				SubmittedBy:     response.SubmittedBy,
+			})
+			if err != nil {
+				return err
+			}
+			if err := quizAttempts.Put(key, encoded); err != nil {
+				return err
+			}
+
+			results = append(results, quiz.ResponseResult{
+				QuestionID: response.QuestionID,
+				Status:     status,
+				Credit:     credit,
+				Bonus:      bonus,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *BoltStore) GetLeaderboard(_ context.Context, quizID string) ([]quiz.LeaderboardEntry, error) {
+	byUser := make(map[string]*quiz.LeaderboardEntry)
+	var tiebreak quiz.Tiebreak
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+		if raw := tx.Bucket(leaderboardsBucket).Get([]byte(quizID)); raw != nil {
+			var settings leaderboardSettingsRecord
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			tiebreak = quiz.Tiebreak(settings.Tiebreak)
+		}
+
+		add := func(username string, score float64, answered int, submittedAtUnix int64) {
+			entry, exists := byUser[username]
+			if !exists {
+				entry = &quiz.LeaderboardEntry{Username: username}
+				byUser[username] = entry
+			}
+			entry.TotalScore += score
+			entry.AnsweredCount += answered
+			submittedAt := time.Unix(0, submittedAtUnix).UTC()
+			if submittedAt.After(entry.LastSubmissionAt) {
+				entry.LastSubmissionAt = submittedAt
+			}
+		}
+
+		if quizAttempts := tx.Bucket(attemptsBucket).Bucket([]byte(quizID)); quizAttempts != nil {
+			err := quizAttempts.ForEach(func(key, value []byte) error {
+				username, questionID, ok := bytes.Cut(key, []byte(attemptSeparator))
+				if !ok || record.voided(string(questionID)) {
+					return nil
+				}
+
+				var attempt attemptRecord
+				if err := json.Unmarshal(value, &attempt); err != nil {
+					return err
+				}
+				add(string(username), attempt.Score, 1, attempt.SubmittedAtUnix)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Totals of attempts archived by the retention policy.
+		if summaries := tx.Bucket(summariesBucket).Bucket([]byte(quizID)); summaries != nil {
+			return summaries.ForEach(func(key, value []byte) error {
+				var summary summaryRecord
+				if err := json.Unmarshal(value, &summary); err != nil {
+					return err
+				}
+				add(string(key), summary.TotalScore, summary.AnsweredCount, summary.LastSubmittedAtUnix)
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := make([]quiz.LeaderboardEntry, 0, len(byUser))
+	for _, entry := range byUser {
+		leaderboard = append(leaderboard, *entry)
+	}
+
+	// Keep ordering aligned with the SQLite ORDER BY and the in-memory cache.
+	quizkit.SortLeaderboardBy(tiebreak, leaderboard)
+	return leaderboard, nil
+}
+
+func (s *BoltStore) GetAttemptScores(_ context.Context, quizID, usernameNormalized string) (map[string]float64, error) {
+	scores := make(map[string]float64)
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil || !ok {
+			return err
+		}
+		quizAttempts := tx.Bucket(attemptsBucket).Bucket([]byte(quizID))
+		if quizAttempts == nil {
+			return nil
+		}
+
+		prefix := []byte(usernameNormalized + attemptSeparator)
+		cursor := quizAttempts.Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			var attempt attemptRecord
+			if err := json.Unmarshal(value, &attempt); err != nil {
+				return err
+			}
+			questionID := string(key[len(prefix):])
+			if record.voided(questionID) {
+				continue
+			}
+			scores[questionID] = attempt.Score
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+func (s *BoltStore) ListAttempts(_ context.Context, quizID, usernameNormalized string) ([]quiz.Attempt, error) {
+	attempts := make([]quiz.Attempt, 0)
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil || !ok {
+			return err
+		}
+		quizAttempts := tx.Bucket(attemptsBucket).Bucket([]byte(quizID))
+		if quizAttempts == nil {
+			return nil
+		}
+
+		prefix := []byte(usernameNormalized + attemptSeparator)
+		cursor := quizAttempts.Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			questionID := string(key[len(prefix):])
+			if record.voided(questionID) {
+				continue
+			}
+			var stored attemptRecord
+			if err := json.Unmarshal(value, &stored); err != nil {
+				return err
+			}
+			attempts = append(attempts, quiz.Attempt{
+				QuestionID:   questionID,
+				AnswerLetter: stored.AnswerLetter,
+				Score:        stored.Score,
+				SubmittedAt:  time.Unix(0, stored.SubmittedAtUnix).UTC(),
+				SubmittedBy:  stored.SubmittedBy,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys sort by question ID; submission order comes from the timestamps.
+	sort.SliceStable(attempts, func(i, j int) bool {
+		return attempts[i].SubmittedAt.Before(attempts[j].SubmittedAt)
+	})
+	return attempts, nil
+}
+
+// VoidQuestion records the void on the quiz record; attempts are kept and
+// filtered out when scores are read, matching the SQLite store.
+func (s *BoltStore) VoidQuestion(_ context.Context, quizID, questionID string, voidedAt time.Time) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+		if !record.hasQuestion(questionID) {
+			return quiz.ErrQuestionNotFound
+		}
+		if record.voided(questionID) {
+			return nil
+		}
+
+		if record.VoidedQuestions == nil {
+			record.VoidedQuestions = make(map[string]int64)
+		}
+		record.VoidedQuestions[questionID] = voidedAt.UTC().Unix()
+		return putJSON(tx.Bucket(quizzesBucket), quizID, record)
+	})
+}
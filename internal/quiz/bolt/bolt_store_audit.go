@@ -0,0 +1,77 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type auditRecord struct {
+	AtUnix   int64  `json:"at_unix"`
+	Actor    string `json:"actor"`
+	Action   string `json:"action"`
+	Username string `json:"username"`
+	Detail   string `json:"detail"`
+}
+
+// RecordAuditEntry keys entries by the quiz bucket's sequence, so a cursor
+// walks them in the order they were recorded.
+func (s *BoltStore) RecordAuditEntry(_ context.Context, entry quiz.AuditEntry) error {
+	raw, err := json.Marshal(auditRecord{
+		AtUnix:   entry.At.UnixNano(),
+		Actor:    entry.Actor,
+		Action:   entry.Action,
+		Username: entry.Username,
+		Detail:   entry.Detail,
+	})
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		quizLog, err := tx.Bucket(auditBucket).CreateBucketIfNotExists([]byte(entry.QuizID))
+		if err != nil {
+			return err
+		}
+		sequence, err := quizLog.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, sequence)
+		return quizLog.Put(key, raw)
+	})
+}
+
+func (s *BoltStore) ListAuditEntries(_ context.Context, quizID string) ([]quiz.AuditEntry, error) {
+	entries := make([]quiz.AuditEntry, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		quizLog := tx.Bucket(auditBucket).Bucket([]byte(quizID))
+		if quizLog == nil {
+			return nil
+		}
+		return quizLog.ForEach(func(_, value []byte) error {
+			var record auditRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			entries = append(entries, quiz.AuditEntry{
+				QuizID:   quizID,
+				At:       time.Unix(0, record.AtUnix).UTC(),
+				Actor:    record.Actor,
+				Action:   record.Action,
+				Username: record.Username,
+				Detail:   record.Detail,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
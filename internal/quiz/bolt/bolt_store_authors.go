@@ -0,0 +1,143 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type authorRecord struct {
+	Author        string `json:"author"`
+	CreatedAtUnix int64  `json:"created_at_unix"`
+}
+
+type reportRecord struct {
+	Reason        string `json:"reason"`
+	CreatedAtUnix int64  `json:"created_at_unix"`
+}
+
+func (s *BoltStore) RecordQuestionAuthor(_ context.Context, authorNormalized string, questionIDs []string, createdAt time.Time) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		authors := tx.Bucket(authorsBucket)
+		for _, questionID := range questionIDs {
+			if authors.Get([]byte(questionID)) != nil {
+				continue
+			}
+			record := authorRecord{Author: authorNormalized, CreatedAtUnix: createdAt.UnixNano()}
+			if err := putJSON(authors, questionID, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// QuestionPerformanceByAuthor scans every quiz to count usage and attempts; like
+// ListActiveQuizzes it assumes the embedded store holds few quizzes.
+func (s *BoltStore) QuestionPerformanceByAuthor(_ context.Context, authorNormalized string) ([]quiz.QuestionPerformance, error) {
+	byQuestion := make(map[string]*quiz.QuestionPerformance)
+	createdAt := make(map[string]int64)
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		questionBucket := tx.Bucket(questionsBucket)
+		err := tx.Bucket(authorsBucket).ForEach(func(key, value []byte) error {
+			var record authorRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			if record.Author != authorNormalized {
+				return nil
+			}
+			stored, ok, err := loadQuestion(questionBucket, string(key))
+			if err != nil || !ok {
+				return err
+			}
+			item := &quiz.QuestionPerformance{Question: stored.question()}
+			if reports := tx.Bucket(reportsBucket).Bucket(key); reports != nil {
+				item.ReportCount = reports.Stats().KeyN
+			}
+			byQuestion[string(key)] = item
+			createdAt[string(key)] = record.CreatedAtUnix
+			return nil
+		})
+		if err != nil || len(byQuestion) == 0 {
+			return err
+		}
+
+		attempts := tx.Bucket(attemptsBucket)
+		return tx.Bucket(quizzesBucket).ForEach(func(_, value []byte) error {
+			var record quizRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			for _, questionID := range record.QuestionIDs {
+				if item, ok := byQuestion[questionID]; ok {
+					item.QuizCount++
+				}
+			}
+
+			quizAttempts := attempts.Bucket([]byte(record.QuizID))
+			if quizAttempts == nil {
+				return nil
+			}
+			return quizAttempts.ForEach(func(key, value []byte) error {
+				_, questionID, ok := bytes.Cut(key, []byte(attemptSeparator))
+				if !ok || record.voided(string(questionID)) {
+					return nil
+				}
+				item, ok := byQuestion[string(questionID)]
+				if !ok {
+					return nil
+				}
+				var attempt attemptRecord
+				if err := json.Unmarshal(value, &attempt); err != nil {
+					return err
+				}
+				item.AttemptCount++
+				if attempt.Score >= 1 {
+					item.CorrectCount++
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	performance := make([]quiz.QuestionPerformance, 0, len(byQuestion))
+	for _, item := range byQuestion {
+		performance = append(performance, *item)
+	}
+	sort.Slice(performance, func(i, j int) bool {
+		left, right := performance[i].Question.QuestionID, performance[j].Question.QuestionID
+		if createdAt[left] != createdAt[right] {
+			return createdAt[left] > createdAt[right]
+		}
+		return left < right
+	})
+	return performance, nil
+}
+
+func (s *BoltStore) ReportQuestion(_ context.Context, questionID, usernameNormalized, reason string, reportedAt time.Time) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(questionsBucket).Get([]byte(questionID)) == nil {
+			return quiz.ErrUnknownQuestion
+		}
+
+		questionReports, err := tx.Bucket(reportsBucket).CreateBucketIfNotExists([]byte(questionID))
+		if err != nil {
+			return err
+		}
+		if questionReports.Get([]byte(usernameNormalized)) != nil {
+			return nil
+		}
+		return putJSON(questionReports, usernameNormalized, reportRecord{Reason: reason, CreatedAtUnix: reportedAt.UnixNano()})
+	})
+}
@@ -0,0 +1,77 @@
+package bolt
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *BoltStore) AddBookmark(_ context.Context, usernameNormalized, questionID string, createdAt time.Time) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(questionsBucket).Get([]byte(questionID)) == nil {
+			return quiz.ErrUnknownQuestion
+		}
+
+		userBookmarks, err := tx.Bucket(bookmarksBucket).CreateBucketIfNotExists([]byte(usernameNormalized))
+		if err != nil {
+			return err
+		}
+		if userBookmarks.Get([]byte(questionID)) != nil {
+			return nil
+		}
+		return userBookmarks.Put([]byte(questionID), []byte(strconv.FormatInt(createdAt.UnixNano(), 10)))
+	})
+}
+
+func (s *BoltStore) RemoveBookmark(_ context.Context, usernameNormalized, questionID string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		userBookmarks := tx.Bucket(bookmarksBucket).Bucket([]byte(usernameNormalized))
+		if userBookmarks == nil {
+			return nil
+		}
+		return userBookmarks.Delete([]byte(questionID))
+	})
+}
+
+func (s *BoltStore) ListBookmarks(_ context.Context, usernameNormalized string) ([]quiz.Bookmark, error) {
+	bookmarks := make([]quiz.Bookmark, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		userBookmarks := tx.Bucket(bookmarksBucket).Bucket([]byte(usernameNormalized))
+		if userBookmarks == nil {
+			return nil
+		}
+
+		questionBucket := tx.Bucket(questionsBucket)
+		return userBookmarks.ForEach(func(key, value []byte) error {
+			createdAt, err := strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return err
+			}
+			stored, ok, err := loadQuestion(questionBucket, string(key))
+			if err != nil || !ok {
+				return err
+			}
+			bookmarks = append(bookmarks, quiz.Bookmark{
+				Question:  stored.question(),
+				CreatedAt: time.Unix(0, createdAt).UTC(),
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(bookmarks, func(i, j int) bool {
+		if !bookmarks[i].CreatedAt.Equal(bookmarks[j].CreatedAt) {
+			return bookmarks[i].CreatedAt.After(bookmarks[j].CreatedAt)
+		}
+		return bookmarks[i].Question.QuestionID < bookmarks[j].Question.QuestionID
+	})
+	return bookmarks, nil
+}
@@ -0,0 +1,63 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type hostRecord struct {
+	Username    string `json:"username"`
+	Role        string `json:"role"`
+	TokenHash   string `json:"token_hash"`
+	AddedBy     string `json:"added_by"`
+	AddedAtUnix int64  `json:"added_at_unix"`
+}
+
+func (s *BoltStore) GetHosts(_ context.Context, quizID string) ([]quiz.QuizHost, error) {
+	hosts := make([]quiz.QuizHost, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(hostsBucket).Get([]byte(quizID))
+		if raw == nil {
+			return nil
+		}
+		var records []hostRecord
+		if err := json.Unmarshal(raw, &records); err != nil {
+			return err
+		}
+		for _, record := range records {
+			hosts = append(hosts, quiz.QuizHost{
+				Username:  record.Username,
+				Role:      quiz.HostRole(record.Role),
+				TokenHash: record.TokenHash,
+				AddedBy:   record.AddedBy,
+				AddedAt:   time.Unix(0, record.AddedAtUnix).UTC(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+func (s *BoltStore) SaveHosts(_ context.Context, quizID string, hosts []quiz.QuizHost) error {
+	records := make([]hostRecord, 0, len(hosts))
+	for _, host := range hosts {
+		records = append(records, hostRecord{
+			Username:    host.Username,
+			Role:        string(host.Role),
+			TokenHash:   host.TokenHash,
+			AddedBy:     host.AddedBy,
+			AddedAtUnix: host.AddedAt.UnixNano(),
+		})
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return putJSON(tx.Bucket(hostsBucket), quizID, records)
+	})
+}
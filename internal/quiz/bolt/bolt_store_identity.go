@@ -0,0 +1,53 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type identityRecord struct {
+	Email          string `json:"email"`
+	TokenHash      string `json:"token_hash"`
+	VerifiedAtUnix int64  `json:"verified_at_unix"`
+}
+
+func (s *BoltStore) GetIdentity(_ context.Context, usernameNormalized string) (quiz.Identity, error) {
+	var identity quiz.Identity
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(identitiesBucket).Get([]byte(usernameNormalized))
+		if raw == nil {
+			return nil
+		}
+		var record identityRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		identity = quiz.Identity{
+			Username:   usernameNormalized,
+			Email:      record.Email,
+			TokenHash:  record.TokenHash,
+			VerifiedAt: time.Unix(0, record.VerifiedAtUnix).UTC(),
+		}
+		return nil
+	})
+	if err != nil {
+		return quiz.Identity{}, err
+	}
+	return identity, nil
+}
+
+func (s *BoltStore) SaveIdentity(_ context.Context, identity quiz.Identity) error {
+	record := identityRecord{
+		Email:          identity.Email,
+		TokenHash:      identity.TokenHash,
+		VerifiedAtUnix: identity.VerifiedAt.UnixNano(),
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return putJSON(tx.Bucket(identitiesBucket), identity.Username, record)
+	})
+}
@@ -0,0 +1,64 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type leaderboardSettingsRecord struct {
+	DefaultLimit  int    `json:"default_limit,omitempty"`
+	FreezeSeconds int64  `json:"freeze_seconds,omitempty"`
+	LocksAtUnix   int64  `json:"locks_at_unix,omitempty"`
+	Tiebreak      string `json:"tiebreak,omitempty"`
+	UpdatedAtUnix int64  `json:"updated_at_unix"`
+}
+
+func (s *BoltStore) GetLeaderboardSettings(_ context.Context, quizID string) (quiz.LeaderboardSettings, error) {
+	var settings quiz.LeaderboardSettings
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(leaderboardsBucket).Get([]byte(quizID))
+		if raw == nil {
+			return nil
+		}
+		var record leaderboardSettingsRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		settings.DefaultLimit = record.DefaultLimit
+		settings.FreezeWindow = time.Duration(record.FreezeSeconds) * time.Second
+		if record.LocksAtUnix != 0 {
+			settings.LocksAt = time.Unix(0, record.LocksAtUnix).UTC()
+		}
+		settings.Tiebreak = quiz.Tiebreak(record.Tiebreak)
+		settings.UpdatedAt = time.Unix(0, record.UpdatedAtUnix).UTC()
+		return nil
+	})
+	if err != nil {
+		return quiz.LeaderboardSettings{}, err
+	}
+	return settings, nil
+}
+
+func (s *BoltStore) SaveLeaderboardSettings(_ context.Context, quizID string, settings quiz.LeaderboardSettings) error {
+	record := leaderboardSettingsRecord{
+		DefaultLimit:  settings.DefaultLimit,
+		FreezeSeconds: int64(settings.FreezeWindow / time.Second),
+		Tiebreak:      string(settings.Tiebreak),
+		UpdatedAtUnix: settings.UpdatedAt.UnixNano(),
+	}
+	if !settings.LocksAt.IsZero() {
+		record.LocksAtUnix = settings.LocksAt.UnixNano()
+	}
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(leaderboardsBucket).Put([]byte(quizID), raw)
+	})
+}
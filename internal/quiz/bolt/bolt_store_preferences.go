@@ -0,0 +1,53 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type preferencesRecord struct {
+	QuestionCount int    `json:"question_count,omitempty"`
+	Difficulty    string `json:"difficulty,omitempty"`
+	UpdatedAtUnix int64  `json:"updated_at_unix"`
+}
+
+func (s *BoltStore) GetPreferences(_ context.Context, usernameNormalized string) (quiz.UserPreferences, error) {
+	preferences := quiz.UserPreferences{Username: usernameNormalized}
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(preferencesBucket).Get([]byte(usernameNormalized))
+		if raw == nil {
+			return nil
+		}
+		var record preferencesRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		preferences.QuestionCount = record.QuestionCount
+		preferences.Difficulty = quiz.Difficulty(record.Difficulty)
+		preferences.UpdatedAt = time.Unix(0, record.UpdatedAtUnix).UTC()
+		return nil
+	})
+	if err != nil {
+		return quiz.UserPreferences{}, err
+	}
+	return preferences, nil
+}
+
+func (s *BoltStore) SavePreferences(_ context.Context, preferences quiz.UserPreferences) error {
+	raw, err := json.Marshal(preferencesRecord{
+		QuestionCount: preferences.QuestionCount,
+		Difficulty:    string(preferences.Difficulty),
+		UpdatedAtUnix: preferences.UpdatedAt.UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(preferencesBucket).Put([]byte(preferences.Username), raw)
+	})
+}
@@ -0,0 +1,75 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type profileRecord struct {
+	Anonymous     bool  `json:"anonymous"`
+	UpdatedAtUnix int64 `json:"updated_at_unix"`
+}
+
+func (s *BoltStore) GetProfile(_ context.Context, usernameNormalized string) (quiz.UserProfile, error) {
+	profile := quiz.UserProfile{Username: usernameNormalized}
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(profilesBucket).Get([]byte(usernameNormalized))
+		if raw == nil {
+			return nil
+		}
+		var record profileRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		profile.Anonymous = record.Anonymous
+		profile.UpdatedAt = time.Unix(0, record.UpdatedAtUnix).UTC()
+		return nil
+	})
+	if err != nil {
+		return quiz.UserProfile{}, err
+	}
+	return profile, nil
+}
+
+func (s *BoltStore) SaveProfile(_ context.Context, profile quiz.UserProfile) error {
+	raw, err := json.Marshal(profileRecord{
+		Anonymous:     profile.Anonymous,
+		UpdatedAtUnix: profile.UpdatedAt.UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(profilesBucket).Put([]byte(profile.Username), raw)
+	})
+}
+
+func (s *BoltStore) AnonymousUsers(_ context.Context, usernamesNormalized []string) (map[string]bool, error) {
+	anonymous := make(map[string]bool)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		profiles := tx.Bucket(profilesBucket)
+		for _, username := range usernamesNormalized {
+			raw := profiles.Get([]byte(username))
+			if raw == nil {
+				continue
+			}
+			var record profileRecord
+			if err := json.Unmarshal(raw, &record); err != nil {
+				return err
+			}
+			if record.Anonymous {
+				anonymous[username] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return anonymous, nil
+}
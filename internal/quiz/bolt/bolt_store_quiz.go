@@ -0,0 +1,462 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type quizRecord struct {
+	QuizID        string   `json:"quiz_id"`
+	CreatedAtUnix int64    `json:"created_at_unix"`
+	QuestionCount int      `json:"question_count"`
+	Requested     int      `json:"requested_question_count,omitempty"`
+	Locked        bool     `json:"locked"`
+	Draft         bool     `json:"draft,omitempty"`
+	ClosesAtUnix  int64    `json:"closes_at_unix,omitempty"`
+	Practice      bool     `json:"practice,omitempty"`
+	Adaptive      bool     `json:"adaptive,omitempty"`
+	QuestionIDs   []string `json:"question_ids"`
+	// VoidedQuestions maps voided question IDs to their void time (unix seconds).
+	VoidedQuestions map[string]int64 `json:"voided_questions,omitempty"`
+	// SecondsPerQuestion is QuizMetadata.QuestionTimeLimit in whole seconds.
+	SecondsPerQuestion int64 `json:"seconds_per_question,omitempty"`
+	// Provider through QuestionType are QuizMetadata.Origin.
+	Provider      string             `json:"provider,omitempty"`
+	FallbackFrom  string             `json:"fallback_from,omitempty"`
+	DifficultyMix quiz.DifficultyMix `json:"difficulty_mix,omitempty"`
+	Seed          int64              `json:"seed,omitempty"`
+	Category      int                `json:"category,omitempty"`
+	QuestionType  string             `json:"question_type,omitempty"`
+	// ArchivedAtUnix is QuizMetadata.ArchivedAt in unix nanoseconds.
+	ArchivedAtUnix int64 `json:"archived_at_unix,omitempty"`
+}
+
+type questionRecord struct {
+	QuestionID    string        `json:"question_id"`
+	Prompt        string        `json:"prompt"`
+	Options       []quiz.Option `json:"options"`
+	CorrectIndex  int           `json:"correct_index"`
+	Source        string        `json:"source"`
+	CreatedAtUnix int64         `json:"created_at_unix"`
+	Feedback      []string      `json:"feedback,omitempty"`
+	Difficulty    string        `json:"difficulty,omitempty"`
+	Category      string        `json:"category,omitempty"`
+	// Translations is keyed by language tag.
+	Translations map[string]quiz.Translation `json:"translations,omitempty"`
+	// Type is empty for single-answer questions; CorrectIndexes is set only
+	// for multi-select ones.
+	Type           string `json:"type,omitempty"`
+	CorrectIndexes []int  `json:"correct_indexes,omitempty"`
+}
+
+// CreateQuiz follows the SQLite overwrite semantics: an existing quiz with the
+// same ID has its question list replaced and all of its attempts removed.
+func (s *BoltStore) CreateQuiz(_ context.Context, metadata quiz.QuizMetadata, questions []quiz.Question) error {
+	if metadata.QuizID == "" {
+		return errors.New("quiz id is required")
+	}
+
+	if metadata.QuestionCount <= 0 {
+		metadata.QuestionCount = len(questions)
+	}
+
+	if metadata.CreatedAt.IsZero() {
+		metadata.CreatedAt = time.Now().UTC()
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{attemptsBucket, summariesBucket} {
+			parent := tx.Bucket(name)
+			if parent.Bucket([]byte(metadata.QuizID)) != nil {
+				if err := parent.DeleteBucket([]byte(metadata.QuizID)); err != nil {
+					return err
+				}
+			}
+		}
+
+		record := quizRecord{
+			QuizID:        metadata.QuizID,
+			CreatedAtUnix: metadata.CreatedAt.UnixNano(),
+			QuestionCount: metadata.QuestionCount,
+			Requested:     metadata.RequestedQuestionCount,
+			Locked:        metadata.Locked,
+			Draft:         metadata.Draft,
+			Practice:      metadata.Practice,
+			Adaptive:      metadata.Adaptive,
+			QuestionIDs:   make([]string, 0, len(questions)),
+			Provider:      metadata.Origin.Provider,
+			FallbackFrom:  metadata.Origin.FallbackFrom,
+			DifficultyMix: metadata.Origin.DifficultyMix,
+			Seed:          metadata.Origin.Seed,
+			Category:      metadata.Origin.Category,
+			QuestionType:  metadata.Origin.QuestionType,
+		}
+		record.SecondsPerQuestion = int64(metadata.QuestionTimeLimit / time.Second)
+		if !metadata.ClosesAt.IsZero() {
+			record.ClosesAtUnix = metadata.ClosesAt.UnixNano()
+		}
+
+		questionBucket := tx.Bucket(questionsBucket)
+		for _, question := range questions {
+			if question.QuestionID == "" {
+				question.QuestionID = quiz.MakeQuestionID(question)
+			}
+
+			if err := putQuestion(questionBucket, question, metadata.CreatedAt); err != nil {
+				return err
+			}
+			record.QuestionIDs = append(record.QuestionIDs, question.QuestionID)
+		}
+
+		return putJSON(tx.Bucket(quizzesBucket), metadata.QuizID, record)
+	})
+}
+
+// ReplaceQuizQuestion puts the new question in the old one's position, like
+// the SQLite store. Attempt keys end in their question ID, so a suffix scan of
+// the quiz's attempts finds answers to the old question.
+func (s *BoltStore) ReplaceQuizQuestion(_ context.Context, quizID, oldQuestionID string, question quiz.Question) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+		position := slices.Index(record.QuestionIDs, oldQuestionID)
+		if position < 0 {
+			return quiz.ErrQuestionNotFound
+		}
+		if quizAttempts := tx.Bucket(attemptsBucket).Bucket([]byte(quizID)); quizAttempts != nil {
+			suffix := []byte(attemptSeparator + oldQuestionID)
+			cursor := quizAttempts.Cursor()
+			for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+				if bytes.HasSuffix(key, suffix) {
+					return quiz.ErrQuestionAnswered
+				}
+			}
+		}
+
+		if err := putQuestion(tx.Bucket(questionsBucket), question, time.Now().UTC()); err != nil {
+			return err
+		}
+		record.QuestionIDs[position] = question.QuestionID
+		return putJSON(tx.Bucket(quizzesBucket), quizID, record)
+	})
+}
+
+// putQuestion stores question, or checks it against the stored record with the
+// same ID, like the SQLite upsert: a copy with other text, answer key,
+// feedback, or translations fails with quiz.ErrQuestionConflict, and
+// difficulty, category, or type are only filled in where the record has none.
+func putQuestion(bucket *bbolt.Bucket, question quiz.Question, createdAt time.Time) error {
+	stored := questionRecord{
+		QuestionID:    question.QuestionID,
+		Prompt:        question.Question,
+		Options:       question.Options,
+		CorrectIndex:  question.CorrectIndex,
+		Source:        question.Source,
+		CreatedAtUnix: createdAt.UnixNano(),
+		Feedback:      question.Feedback,
+		Difficulty:    string(question.Difficulty),
+		Category:      question.Category,
+		Translations:  question.Translations,
+		Type:          string(question.Type),
+	}
+	if question.Type == quiz.TypeMultiSelect {
+		stored.CorrectIndexes = question.CorrectIndexes
+	}
+	existing, ok, err := loadQuestion(bucket, question.QuestionID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return putJSON(bucket, question.QuestionID, stored)
+	}
+	if existing.Prompt != stored.Prompt || !slices.Equal(existing.Options, stored.Options) ||
+		existing.CorrectIndex != stored.CorrectIndex || !slices.Equal(existing.CorrectIndexes, stored.CorrectIndexes) ||
+		(len(stored.Feedback) > 0 && !slices.Equal(existing.Feedback, stored.Feedback)) ||
+		(len(stored.Translations) > 0 && !reflect.DeepEqual(existing.Translations, stored.Translations)) {
+		return fmt.Errorf("%w: %s", quiz.ErrQuestionConflict, question.QuestionID)
+	}
+	if existing.Difficulty == "" {
+		existing.Difficulty = stored.Difficulty
+	}
+	if existing.Category == "" {
+		existing.Category = stored.Category
+	}
+	if existing.Type == "" {
+		existing.Type = stored.Type
+	}
+	return putJSON(bucket, question.QuestionID, existing)
+}
+
+func (s *BoltStore) GetQuizMetadata(_ context.Context, quizID string) (quiz.QuizMetadata, error) {
+	var metadata quiz.QuizMetadata
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+		metadata = record.metadata()
+		return nil
+	})
+	return metadata, err
+}
+
+func (s *BoltStore) QuizExists(_ context.Context, quizID string) (bool, error) {
+	var exists bool
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		exists = tx.Bucket(quizzesBucket).Get([]byte(quizID)) != nil
+		return nil
+	})
+	return exists, err
+}
+
+func (s *BoltStore) GetQuizQuestions(_ context.Context, quizID string) ([]quiz.Question, error) {
+	var questions []quiz.Question
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+
+		questionBucket := tx.Bucket(questionsBucket)
+		questions = make([]quiz.Question, 0, len(record.QuestionIDs))
+		for _, questionID := range record.QuestionIDs {
+			stored, ok, err := loadQuestion(questionBucket, questionID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			question := stored.question()
+			question.Voided = record.voided(questionID)
+			questions = append(questions, question)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+// ListActiveQuizzes scans every quiz record; an embedded single-node store is
+// expected to hold few enough quizzes that a full scan stays cheap.
+func (s *BoltStore) ListActiveQuizzes(_ context.Context, limit int) ([]quiz.QuizMetadata, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	active := make([]quiz.QuizMetadata, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(quizzesBucket).ForEach(func(_, value []byte) error {
+			var record quizRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			if record.ArchivedAtUnix == 0 {
+				active = append(active, record.metadata())
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(active, func(i, j int) bool {
+		if !active[i].CreatedAt.Equal(active[j].CreatedAt) {
+			return active[i].CreatedAt.After(active[j].CreatedAt)
+		}
+		return active[i].QuizID < active[j].QuizID
+	})
+	if len(active) > limit {
+		active = active[:limit]
+	}
+	return active, nil
+}
+
+func (s *BoltStore) LookupQuestions(_ context.Context, questionIDs []string) ([]quiz.Question, error) {
+	questions := make([]quiz.Question, 0, len(questionIDs))
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		questionBucket := tx.Bucket(questionsBucket)
+		for _, questionID := range questionIDs {
+			stored, ok, err := loadQuestion(questionBucket, questionID)
+			if err != nil {
+				return err
+			}
+			if ok {
+				questions = append(questions, stored.question())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+// SampleQuestions reads every question key and shuffles them, which is fine
+// for the rare quiz built while the provider is down.
+func (s *BoltStore) SampleQuestions(_ context.Context, limit int) ([]quiz.Question, error) {
+	if limit <= 0 {
+		return []quiz.Question{}, nil
+	}
+	questions := make([]quiz.Question, 0, limit)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		questionBucket := tx.Bucket(questionsBucket)
+		var keys [][]byte
+		if err := questionBucket.ForEach(func(key, _ []byte) error {
+			keys = append(keys, key)
+			return nil
+		}); err != nil {
+			return err
+		}
+		rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		for _, key := range keys[:min(limit, len(keys))] {
+			stored, ok, err := loadQuestion(questionBucket, string(key))
+			if err != nil {
+				return err
+			}
+			if ok {
+				questions = append(questions, stored.question())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (s *BoltStore) SetQuizLifecycle(_ context.Context, quizID string, lifecycle quiz.QuizLifecycle) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+		record.Draft = lifecycle.Draft
+		record.Locked = lifecycle.Locked
+		record.ClosesAtUnix = 0
+		if !lifecycle.ClosesAt.IsZero() {
+			record.ClosesAtUnix = lifecycle.ClosesAt.UnixNano()
+		}
+		return putJSON(tx.Bucket(quizzesBucket), quizID, record)
+	})
+}
+
+func (r quizRecord) metadata() quiz.QuizMetadata {
+	metadata := quiz.QuizMetadata{
+		QuizID:                 r.QuizID,
+		QuestionCount:          r.QuestionCount,
+		RequestedQuestionCount: r.Requested,
+		CreatedAt:              time.Unix(0, r.CreatedAtUnix).UTC(),
+		Locked:                 r.Locked,
+		Draft:                  r.Draft,
+		Practice:               r.Practice,
+		Adaptive:               r.Adaptive,
+		QuestionTimeLimit:      time.Duration(r.SecondsPerQuestion) * time.Second,
+		Origin: quiz.QuizOrigin{
+			Provider: r.Provider, FallbackFrom: r.FallbackFrom, DifficultyMix: r.DifficultyMix, Seed: r.Seed,
+			Category: r.Category, QuestionType: r.QuestionType,
+		},
+	}
+	if r.ClosesAtUnix != 0 {
+		metadata.ClosesAt = time.Unix(0, r.ClosesAtUnix).UTC()
+	}
+	if r.ArchivedAtUnix != 0 {
+		metadata.ArchivedAt = time.Unix(0, r.ArchivedAtUnix).UTC()
+	}
+	return metadata
+}
+
+func (r quizRecord) voided(questionID string) bool {
+	_, ok := r.VoidedQuestions[questionID]
+	return ok
+}
+
+func (r quizRecord) hasQuestion(questionID string) bool {
+	for _, candidate := range r.QuestionIDs {
+		if candidate == questionID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r questionRecord) question() quiz.Question {
+	return quiz.Question{
+		PublicQuestion: quiz.PublicQuestion{
+			QuestionID: r.QuestionID,
+			Question:   r.Prompt,
+			Options:    r.Options,
+			Type:       quiz.QuestionKind(r.Type),
+		},
+		CorrectIndex:   r.CorrectIndex,
+		CorrectIndexes: r.CorrectIndexes,
+		Feedback:       r.Feedback,
+		Difficulty:     quiz.Difficulty(r.Difficulty),
+		Category:       r.Category,
+		Source:         r.Source,
+		Translations:   r.Translations,
+	}
+}
+
+func loadQuiz(tx *bbolt.Tx, quizID string) (quizRecord, bool, error) {
+	var record quizRecord
+	value := tx.Bucket(quizzesBucket).Get([]byte(quizID))
+	if value == nil {
+		return record, false, nil
+	}
+	if err := json.Unmarshal(value, &record); err != nil {
+		return record, false, err
+	}
+	return record, true, nil
+}
+
+func loadQuestion(bucket *bbolt.Bucket, questionID string) (questionRecord, bool, error) {
+	var record questionRecord
+	value := bucket.Get([]byte(questionID))
+	if value == nil {
+		return record, false, nil
+	}
+	if err := json.Unmarshal(value, &record); err != nil {
+		return record, false, err
+	}
+	return record, true, nil
+}
+
+func putJSON(bucket *bbolt.Bucket, key string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), encoded)
+}
@@ -0,0 +1,146 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+var (
+	// perQuizBuckets hold one nested bucket per quiz_id.
+	perQuizBuckets = [][]byte{attemptsBucket, summariesBucket, servesBucket, serveLogBucket, auditBucket}
+	// quizKeyedBuckets are keyed by quiz_id.
+	quizKeyedBuckets = [][]byte{leaderboardsBucket, rostersBucket, resultsBucket, hostsBucket, quizzesBucket}
+)
+
+// DeleteQuiz counts the attempts it deletes; summaries of archived ones go
+// uncounted.
+func (s *BoltStore) DeleteQuiz(_ context.Context, quizID string) (int, error) {
+	deleted := 0
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+		orphans, err := orphanedQuestions(tx, record)
+		if err != nil {
+			return err
+		}
+		for _, questionID := range orphans {
+			for _, name := range [][]byte{questionsBucket, usageBucket} {
+				if err := tx.Bucket(name).Delete([]byte(questionID)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if quizAttempts := tx.Bucket(attemptsBucket).Bucket([]byte(quizID)); quizAttempts != nil {
+			deleted = quizAttempts.Stats().KeyN
+		}
+		for _, name := range perQuizBuckets {
+			parent := tx.Bucket(name)
+			if parent.Bucket([]byte(quizID)) == nil {
+				continue
+			}
+			if err := parent.DeleteBucket([]byte(quizID)); err != nil {
+				return err
+			}
+		}
+		for _, name := range quizKeyedBuckets {
+			if err := tx.Bucket(name).Delete([]byte(quizID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// orphanedQuestions returns the questions of record that no other quiz,
+// bookmark, author, report, retirement review, or tag refers to. Bookmarks are
+// kept per user, so each user's bucket is checked.
+func orphanedQuestions(tx *bbolt.Tx, record quizRecord) ([]string, error) {
+	candidates := make(map[string]bool, len(record.QuestionIDs))
+	for _, questionID := range record.QuestionIDs {
+		candidates[questionID] = true
+	}
+
+	err := tx.Bucket(quizzesBucket).ForEach(func(key, value []byte) error {
+		if string(key) == record.QuizID {
+			return nil
+		}
+		var other quizRecord
+		if err := json.Unmarshal(value, &other); err != nil {
+			return err
+		}
+		for _, questionID := range other.QuestionIDs {
+			delete(candidates, questionID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := tx.Bucket(bookmarksBucket)
+	orphans := make([]string, 0, len(candidates))
+	for _, questionID := range record.QuestionIDs {
+		if !candidates[questionID] {
+			continue
+		}
+		key := []byte(questionID)
+		if tx.Bucket(authorsBucket).Get(key) != nil || tx.Bucket(reportsBucket).Bucket(key) != nil || tx.Bucket(retirementsBucket).Get(key) != nil || tx.Bucket(tagsBucket).Get(key) != nil {
+			continue
+		}
+		bookmarked := false
+		err := bookmarks.ForEachBucket(func(username []byte) error {
+			bookmarked = bookmarked || bookmarks.Bucket(username).Get(key) != nil
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !bookmarked {
+			orphans = append(orphans, questionID)
+		}
+	}
+	return orphans, nil
+}
+
+func (s *BoltStore) ArchiveQuiz(_ context.Context, quizID string, archivedAt time.Time) (int, error) {
+	deleted := 0
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+		if deleted, err = archiveAttempts(tx, record, math.MaxInt64); err != nil {
+			return err
+		}
+		record.Locked = true
+		if record.ArchivedAtUnix == 0 {
+			record.ArchivedAtUnix = archivedAt.UnixNano()
+		}
+		return putJSON(tx.Bucket(quizzesBucket), quizID, record)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
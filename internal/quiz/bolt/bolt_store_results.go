@@ -0,0 +1,59 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+// resultsRecord keeps the document as bytes rather than embedded JSON, so it
+// is returned exactly as it was saved.
+type resultsRecord struct {
+	Document        []byte `json:"document"`
+	PublishedAtUnix int64  `json:"published_at_unix"`
+}
+
+func (s *BoltStore) SaveQuizResults(_ context.Context, results quiz.PublishedResults) error {
+	raw, err := json.Marshal(resultsRecord{
+		Document:        results.Document,
+		PublishedAtUnix: results.PublishedAt.UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(resultsBucket)
+		if bucket.Get([]byte(results.QuizID)) != nil {
+			return nil
+		}
+		return bucket.Put([]byte(results.QuizID), raw)
+	})
+}
+
+func (s *BoltStore) GetQuizResults(_ context.Context, quizID string) (quiz.PublishedResults, error) {
+	var results quiz.PublishedResults
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(resultsBucket).Get([]byte(quizID))
+		if raw == nil {
+			return nil
+		}
+		var record resultsRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		results = quiz.PublishedResults{
+			QuizID:      quizID,
+			Document:    record.Document,
+			PublishedAt: time.Unix(0, record.PublishedAtUnix).UTC(),
+		}
+		return nil
+	})
+	if err != nil {
+		return quiz.PublishedResults{}, err
+	}
+	return results, nil
+}
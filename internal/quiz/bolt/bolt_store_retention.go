@@ -0,0 +1,372 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type summaryRecord struct {
+	TotalScore           float64 `json:"total_score"`
+	AnsweredCount        int     `json:"answered_count"`
+	CorrectCount         int     `json:"correct_count"`
+	FirstSubmittedAtUnix int64   `json:"first_submitted_at_unix"`
+	LastSubmittedAtUnix  int64   `json:"last_submitted_at_unix"`
+}
+
+// add folds one attempt into the summary.
+func (r *summaryRecord) add(attempt attemptRecord) {
+	if r.AnsweredCount == 0 || attempt.SubmittedAtUnix < r.FirstSubmittedAtUnix {
+		r.FirstSubmittedAtUnix = attempt.SubmittedAtUnix
+	}
+	r.LastSubmittedAtUnix = max(r.LastSubmittedAtUnix, attempt.SubmittedAtUnix)
+	r.TotalScore += attempt.Score
+	r.AnsweredCount++
+	if attempt.Score >= 1 {
+		r.CorrectCount++
+	}
+}
+
+// merge adds other's totals to the summary.
+func (r *summaryRecord) merge(other summaryRecord) {
+	if other.AnsweredCount == 0 {
+		return
+	}
+	if r.AnsweredCount == 0 || other.FirstSubmittedAtUnix < r.FirstSubmittedAtUnix {
+		r.FirstSubmittedAtUnix = other.FirstSubmittedAtUnix
+	}
+	r.LastSubmittedAtUnix = max(r.LastSubmittedAtUnix, other.LastSubmittedAtUnix)
+	r.TotalScore += other.TotalScore
+	r.AnsweredCount += other.AnsweredCount
+	r.CorrectCount += other.CorrectCount
+}
+
+// IdleQuizzes reads every attempt; it runs from the retention job, not on
+// request paths.
+func (s *BoltStore) IdleQuizzes(_ context.Context, cutoff time.Time) ([]string, error) {
+	quizIDs := make([]string, 0)
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		attempts := tx.Bucket(attemptsBucket)
+		return attempts.ForEach(func(quizID, _ []byte) error {
+			quizAttempts := attempts.Bucket(quizID)
+			if quizAttempts == nil {
+				return nil
+			}
+			var newest int64
+			err := quizAttempts.ForEach(func(_, value []byte) error {
+				var attempt attemptRecord
+				if err := json.Unmarshal(value, &attempt); err != nil {
+					return err
+				}
+				newest = max(newest, attempt.SubmittedAtUnix)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if newest != 0 && newest < cutoff.UnixNano() {
+				quizIDs = append(quizIDs, string(quizID))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return quizIDs, nil
+}
+
+// ArchiveAttempts leaves answers to voided questions out of the summaries,
+// matching the SQLite store, and deletes them with the rest.
+func (s *BoltStore) ArchiveAttempts(_ context.Context, quizID string, cutoff time.Time) (int, error) {
+	deleted := 0
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		record, ok, err := loadQuiz(tx, quizID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrQuizNotFound
+		}
+		if deleted, err = archiveAttempts(tx, record, cutoff.UnixNano()); err != nil {
+			return err
+		}
+		record.Locked = true
+		return putJSON(tx.Bucket(quizzesBucket), quizID, record)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// archiveAttempts folds record's attempts submitted before cutoff (unix
+// nanoseconds) into its summaries and deletes them.
+func archiveAttempts(tx *bbolt.Tx, record quizRecord, cutoff int64) (int, error) {
+	quizAttempts := tx.Bucket(attemptsBucket).Bucket([]byte(record.QuizID))
+	if quizAttempts == nil {
+		return 0, nil
+	}
+
+	archived := make(map[string]*summaryRecord)
+	var expired [][]byte
+	err := quizAttempts.ForEach(func(key, value []byte) error {
+		var attempt attemptRecord
+		if err := json.Unmarshal(value, &attempt); err != nil {
+			return err
+		}
+		if attempt.SubmittedAtUnix >= cutoff {
+			return nil
+		}
+		expired = append(expired, bytes.Clone(key))
+
+		username, questionID, ok := bytes.Cut(key, []byte(attemptSeparator))
+		if !ok || record.voided(string(questionID)) {
+			return nil
+		}
+		summary, exists := archived[string(username)]
+		if !exists {
+			summary = &summaryRecord{}
+			archived[string(username)] = summary
+		}
+		summary.add(attempt)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	summaries, err := tx.Bucket(summariesBucket).CreateBucketIfNotExists([]byte(record.QuizID))
+	if err != nil {
+		return 0, err
+	}
+	for username, summary := range archived {
+		if raw := summaries.Get([]byte(username)); raw != nil {
+			var previous summaryRecord
+			if err := json.Unmarshal(raw, &previous); err != nil {
+				return 0, err
+			}
+			summary.merge(previous)
+		}
+		if err := putJSON(summaries, username, summary); err != nil {
+			return 0, err
+		}
+	}
+	// Keys are deleted after the walk; bbolt cursors skip entries when the
+	// bucket changes underneath them.
+	for _, key := range expired {
+		if err := quizAttempts.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+// GetUserAttempts is AttemptSummaries.
+func (s *BoltStore) GetUserAttempts(ctx context.Context, usernameNormalized string) ([]quiz.AttemptSummary, error) {
+	return s.AttemptSummaries(ctx, usernameNormalized)
+}
+
+// AttemptSummaries seeks to the user's attempts in each quiz's bucket and
+// looks the user up in each archived quiz, so it costs one lookup per quiz.
+func (s *BoltStore) AttemptSummaries(_ context.Context, usernameNormalized string) ([]quiz.AttemptSummary, error) {
+	type quizTotals struct {
+		summaryRecord
+		archived int
+	}
+	byQuiz := make(map[string]*quizTotals)
+	totalsFor := func(quizID string) *quizTotals {
+		totals, ok := byQuiz[quizID]
+		if !ok {
+			totals = &quizTotals{}
+			byQuiz[quizID] = totals
+		}
+		return totals
+	}
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		prefix := []byte(usernameNormalized + attemptSeparator)
+		attempts := tx.Bucket(attemptsBucket)
+		err := attempts.ForEach(func(quizID, _ []byte) error {
+			quizAttempts := attempts.Bucket(quizID)
+			if quizAttempts == nil {
+				return nil
+			}
+			cursor := quizAttempts.Cursor()
+			key, value := cursor.Seek(prefix)
+			if key == nil || !bytes.HasPrefix(key, prefix) {
+				return nil
+			}
+			record, ok, err := loadQuiz(tx, string(quizID))
+			if err != nil || !ok {
+				return err
+			}
+			for ; key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+				if record.voided(string(key[len(prefix):])) {
+					continue
+				}
+				var attempt attemptRecord
+				if err := json.Unmarshal(value, &attempt); err != nil {
+					return err
+				}
+				totalsFor(string(quizID)).add(attempt)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		summaries := tx.Bucket(summariesBucket)
+		return summaries.ForEach(func(quizID, _ []byte) error {
+			quizSummaries := summaries.Bucket(quizID)
+			if quizSummaries == nil {
+				return nil
+			}
+			raw := quizSummaries.Get([]byte(usernameNormalized))
+			if raw == nil {
+				return nil
+			}
+			var archived summaryRecord
+			if err := json.Unmarshal(raw, &archived); err != nil {
+				return err
+			}
+			totals := totalsFor(string(quizID))
+			totals.merge(archived)
+			totals.archived += archived.AnsweredCount
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]quiz.AttemptSummary, 0, len(byQuiz))
+	for quizID, totals := range byQuiz {
+		if totals.AnsweredCount == 0 {
+			continue
+		}
+		result = append(result, quiz.AttemptSummary{
+			QuizID:           quizID,
+			Username:         usernameNormalized,
+			TotalScore:       totals.TotalScore,
+			AnsweredCount:    totals.AnsweredCount,
+			CorrectCount:     totals.CorrectCount,
+			FirstSubmittedAt: time.Unix(0, totals.FirstSubmittedAtUnix).UTC(),
+			LastSubmittedAt:  time.Unix(0, totals.LastSubmittedAtUnix).UTC(),
+			ArchivedCount:    totals.archived,
+		})
+	}
+	// Match the SQLite ORDER BY: most recently played first, then by quiz.
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].LastSubmittedAt.Equal(result[j].LastSubmittedAt) {
+			return result[i].LastSubmittedAt.After(result[j].LastSubmittedAt)
+		}
+		return result[i].QuizID < result[j].QuizID
+	})
+	return result, nil
+}
+
+// ListAttemptSummaries reads every attempt of the quizzes in the window.
+func (s *BoltStore) ListAttemptSummaries(_ context.Context, since, until time.Time) ([]quiz.AttemptSummary, error) {
+	type summaryKey struct{ quizID, username string }
+	type userTotals struct {
+		summaryRecord
+		archived int
+	}
+	byUser := make(map[summaryKey]*userTotals)
+	totalsFor := func(quizID, username string) *userTotals {
+		key := summaryKey{quizID, username}
+		totals, ok := byUser[key]
+		if !ok {
+			totals = &userTotals{}
+			byUser[key] = totals
+		}
+		return totals
+	}
+	inWindow := func(record quizRecord) bool {
+		createdAt := time.Unix(0, record.CreatedAtUnix)
+		return (since.IsZero() || !createdAt.Before(since)) && (until.IsZero() || createdAt.Before(until))
+	}
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		attempts := tx.Bucket(attemptsBucket)
+		err := attempts.ForEach(func(quizID, _ []byte) error {
+			quizAttempts := attempts.Bucket(quizID)
+			if quizAttempts == nil {
+				return nil
+			}
+			record, ok, err := loadQuiz(tx, string(quizID))
+			if err != nil || !ok || !inWindow(record) {
+				return err
+			}
+			return quizAttempts.ForEach(func(key, value []byte) error {
+				username, questionID, found := strings.Cut(string(key), attemptSeparator)
+				if !found || record.voided(questionID) {
+					return nil
+				}
+				var attempt attemptRecord
+				if err := json.Unmarshal(value, &attempt); err != nil {
+					return err
+				}
+				totalsFor(string(quizID), username).add(attempt)
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		summaries := tx.Bucket(summariesBucket)
+		return summaries.ForEach(func(quizID, _ []byte) error {
+			quizSummaries := summaries.Bucket(quizID)
+			if quizSummaries == nil {
+				return nil
+			}
+			record, ok, err := loadQuiz(tx, string(quizID))
+			if err != nil || !ok || !inWindow(record) {
+				return err
+			}
+			return quizSummaries.ForEach(func(username, raw []byte) error {
+				var archived summaryRecord
+				if err := json.Unmarshal(raw, &archived); err != nil {
+					return err
+				}
+				totals := totalsFor(string(quizID), string(username))
+				totals.merge(archived)
+				totals.archived += archived.AnsweredCount
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]quiz.AttemptSummary, 0, len(byUser))
+	for key, totals := range byUser {
+		if totals.AnsweredCount == 0 {
+			continue
+		}
+		result = append(result, quiz.AttemptSummary{
+			QuizID:           key.quizID,
+			Username:         key.username,
+			TotalScore:       totals.TotalScore,
+			AnsweredCount:    totals.AnsweredCount,
+			CorrectCount:     totals.CorrectCount,
+			FirstSubmittedAt: time.Unix(0, totals.FirstSubmittedAtUnix).UTC(),
+			LastSubmittedAt:  time.Unix(0, totals.LastSubmittedAtUnix).UTC(),
+			ArchivedCount:    totals.archived,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,186 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type retirementRecord struct {
+	Status        string `json:"status"`
+	AttemptCount  int    `json:"attempt_count"`
+	CorrectCount  int    `json:"correct_count"`
+	FlaggedAtUnix int64  `json:"flagged_at_unix"`
+	DecidedAtUnix int64  `json:"decided_at_unix,omitempty"`
+}
+
+func (r retirementRecord) review(question quiz.Question) quiz.RetirementReview {
+	review := quiz.RetirementReview{
+		Question:     question,
+		Status:       quiz.RetirementStatus(r.Status),
+		AttemptCount: r.AttemptCount,
+		CorrectCount: r.CorrectCount,
+		FlaggedAt:    time.Unix(0, r.FlaggedAtUnix).UTC(),
+	}
+	if r.DecidedAtUnix != 0 {
+		review.DecidedAt = time.Unix(0, r.DecidedAtUnix).UTC()
+	}
+	return review
+}
+
+// QuestionStats scans every quiz's attempts; like QuestionPerformanceByAuthor
+// it assumes the embedded store holds few quizzes.
+func (s *BoltStore) QuestionStats(_ context.Context, minAttempts int) ([]quiz.QuestionPerformance, error) {
+	byQuestion := make(map[string]*quiz.QuestionPerformance)
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		attempts := tx.Bucket(attemptsBucket)
+		err := tx.Bucket(quizzesBucket).ForEach(func(_, value []byte) error {
+			var record quizRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			quizAttempts := attempts.Bucket([]byte(record.QuizID))
+			if quizAttempts == nil {
+				return nil
+			}
+			return quizAttempts.ForEach(func(key, value []byte) error {
+				_, questionID, ok := bytes.Cut(key, []byte(attemptSeparator))
+				if !ok || record.voided(string(questionID)) {
+					return nil
+				}
+				var attempt attemptRecord
+				if err := json.Unmarshal(value, &attempt); err != nil {
+					return err
+				}
+				item, ok := byQuestion[string(questionID)]
+				if !ok {
+					item = &quiz.QuestionPerformance{}
+					byQuestion[string(questionID)] = item
+				}
+				item.AttemptCount++
+				if attempt.Score >= 1 {
+					item.CorrectCount++
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		questionBucket := tx.Bucket(questionsBucket)
+		for questionID, item := range byQuestion {
+			if item.AttemptCount < minAttempts {
+				delete(byQuestion, questionID)
+				continue
+			}
+			stored, ok, err := loadQuestion(questionBucket, questionID)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				delete(byQuestion, questionID)
+				continue
+			}
+			item.Question = stored.question()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]quiz.QuestionPerformance, 0, len(byQuestion))
+	for _, item := range byQuestion {
+		stats = append(stats, *item)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Question.QuestionID < stats[j].Question.QuestionID
+	})
+	return stats, nil
+}
+
+func (s *BoltStore) FlagQuestion(_ context.Context, review quiz.RetirementReview) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		retirements := tx.Bucket(retirementsBucket)
+		if retirements.Get([]byte(review.Question.QuestionID)) != nil {
+			return nil
+		}
+		return putJSON(retirements, review.Question.QuestionID, retirementRecord{
+			Status:        string(review.Status),
+			AttemptCount:  review.AttemptCount,
+			CorrectCount:  review.CorrectCount,
+			FlaggedAtUnix: review.FlaggedAt.UnixNano(),
+		})
+	})
+}
+
+func (s *BoltStore) ListRetirementReviews(_ context.Context, status quiz.RetirementStatus) ([]quiz.RetirementReview, error) {
+	reviews := make([]quiz.RetirementReview, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		questionBucket := tx.Bucket(questionsBucket)
+		return tx.Bucket(retirementsBucket).ForEach(func(key, value []byte) error {
+			var record retirementRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			if status != "" && record.Status != string(status) {
+				return nil
+			}
+			stored, ok, err := loadQuestion(questionBucket, string(key))
+			if err != nil || !ok {
+				return err
+			}
+			reviews = append(reviews, record.review(stored.question()))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].FlaggedAt.Equal(reviews[j].FlaggedAt) {
+			return reviews[i].FlaggedAt.After(reviews[j].FlaggedAt)
+		}
+		return reviews[i].Question.QuestionID < reviews[j].Question.QuestionID
+	})
+	return reviews, nil
+}
+
+func (s *BoltStore) DecideRetirement(_ context.Context, questionID string, status quiz.RetirementStatus, decidedAt time.Time) (quiz.RetirementReview, error) {
+	var review quiz.RetirementReview
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		retirements := tx.Bucket(retirementsBucket)
+		value := retirements.Get([]byte(questionID))
+		if value == nil {
+			return quiz.ErrNotFlagged
+		}
+		var record retirementRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		stored, ok, err := loadQuestion(tx.Bucket(questionsBucket), questionID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return quiz.ErrNotFlagged
+		}
+		record.Status = string(status)
+		record.DecidedAtUnix = decidedAt.UnixNano()
+		review = record.review(stored.question())
+		return putJSON(retirements, questionID, record)
+	})
+	if err != nil {
+		return quiz.RetirementReview{}, err
+	}
+	return review, nil
+}
@@ -0,0 +1,62 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type rosterRecord struct {
+	Restricted    bool                  `json:"restricted,omitempty"`
+	Students      []rosterStudentRecord `json:"students"`
+	UpdatedAtUnix int64                 `json:"updated_at_unix"`
+}
+
+type rosterStudentRecord struct {
+	Username string `json:"username"`
+	Name     string `json:"name,omitempty"`
+	JoinCode string `json:"join_code,omitempty"`
+}
+
+func (s *BoltStore) GetRoster(_ context.Context, quizID string) (quiz.Roster, error) {
+	var roster quiz.Roster
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(rostersBucket).Get([]byte(quizID))
+		if raw == nil {
+			return nil
+		}
+		var record rosterRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		roster.Restricted = record.Restricted
+		roster.Students = make([]quiz.RosterStudent, 0, len(record.Students))
+		for _, student := range record.Students {
+			roster.Students = append(roster.Students, quiz.RosterStudent(student))
+		}
+		roster.UpdatedAt = time.Unix(0, record.UpdatedAtUnix).UTC()
+		return nil
+	})
+	if err != nil {
+		return quiz.Roster{}, err
+	}
+	return roster, nil
+}
+
+func (s *BoltStore) SaveRoster(_ context.Context, quizID string, roster quiz.Roster) error {
+	record := rosterRecord{
+		Restricted:    roster.Restricted,
+		Students:      make([]rosterStudentRecord, 0, len(roster.Students)),
+		UpdatedAtUnix: roster.UpdatedAt.UnixNano(),
+	}
+	for _, student := range roster.Students {
+		record.Students = append(record.Students, rosterStudentRecord(student))
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return putJSON(tx.Bucket(rostersBucket), quizID, record)
+	})
+}
@@ -0,0 +1,65 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type signingKeyRecord struct {
+	PublicKey        []byte `json:"public_key"`
+	RegisteredAtUnix int64  `json:"registered_at_unix"`
+}
+
+func (s *BoltStore) SaveSigningKey(_ context.Context, key quiz.SigningKey) error {
+	raw, err := json.Marshal(signingKeyRecord{
+		PublicKey:        key.PublicKey,
+		RegisteredAtUnix: key.RegisteredAt.UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		userKeys, err := tx.Bucket(signingKeysBucket).CreateBucketIfNotExists([]byte(key.Username))
+		if err != nil {
+			return err
+		}
+		if userKeys.Get([]byte(key.KeyID)) != nil {
+			return nil
+		}
+		return userKeys.Put([]byte(key.KeyID), raw)
+	})
+}
+
+func (s *BoltStore) GetSigningKey(_ context.Context, usernameNormalized, keyID string) (quiz.SigningKey, error) {
+	var key quiz.SigningKey
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		userKeys := tx.Bucket(signingKeysBucket).Bucket([]byte(usernameNormalized))
+		if userKeys == nil {
+			return nil
+		}
+		raw := userKeys.Get([]byte(keyID))
+		if raw == nil {
+			return nil
+		}
+		var record signingKeyRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		key = quiz.SigningKey{
+			Username:     usernameNormalized,
+			KeyID:        keyID,
+			PublicKey:    record.PublicKey,
+			RegisteredAt: time.Unix(0, record.RegisteredAtUnix).UTC(),
+		}
+		return nil
+	})
+	if err != nil {
+		return quiz.SigningKey{}, err
+	}
+	return key, nil
+}
@@ -0,0 +1,77 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+type streakRecord struct {
+	Current       int    `json:"current"`
+	Longest       int    `json:"longest"`
+	LastDay       string `json:"last_day"`
+	UpdatedAtUnix int64  `json:"updated_at_unix"`
+}
+
+func (s *BoltStore) GetStreak(_ context.Context, usernameNormalized string) (quiz.Streak, error) {
+	var streak quiz.Streak
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		streak, err = readStreak(tx, usernameNormalized)
+		return err
+	})
+	if err != nil {
+		return quiz.Streak{}, err
+	}
+	return streak, nil
+}
+
+func (s *BoltStore) RecordStreakDay(_ context.Context, usernameNormalized, day string, recordedAt time.Time) (quiz.Streak, error) {
+	var streak quiz.Streak
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		current, err := readStreak(tx, usernameNormalized)
+		if err != nil {
+			return err
+		}
+		streak = current.Advance(day)
+		if streak == current {
+			return nil
+		}
+		streak.UpdatedAt = recordedAt.UTC()
+		raw, err := json.Marshal(streakRecord{
+			Current:       streak.Current,
+			Longest:       streak.Longest,
+			LastDay:       streak.LastDay,
+			UpdatedAtUnix: streak.UpdatedAt.UnixNano(),
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(streaksBucket).Put([]byte(usernameNormalized), raw)
+	})
+	if err != nil {
+		return quiz.Streak{}, err
+	}
+	return streak, nil
+}
+
+func readStreak(tx *bbolt.Tx, usernameNormalized string) (quiz.Streak, error) {
+	streak := quiz.Streak{Username: usernameNormalized}
+	raw := tx.Bucket(streaksBucket).Get([]byte(usernameNormalized))
+	if raw == nil {
+		return streak, nil
+	}
+	var record streakRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return quiz.Streak{}, err
+	}
+	streak.Current = record.Current
+	streak.Longest = record.Longest
+	streak.LastDay = record.LastDay
+	streak.UpdatedAt = time.Unix(0, record.UpdatedAtUnix).UTC()
+	return streak, nil
+}
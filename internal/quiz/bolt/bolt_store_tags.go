@@ -0,0 +1,97 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+// SetQuestionTags removes the key when tags is empty, so orphanedQuestions
+// does not keep an untagged question alive.
+func (s *BoltStore) SetQuestionTags(_ context.Context, questionID string, tags []string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(questionsBucket).Get([]byte(questionID)) == nil {
+			return quiz.ErrUnknownQuestion
+		}
+		if len(tags) == 0 {
+			return tx.Bucket(tagsBucket).Delete([]byte(questionID))
+		}
+		return putJSON(tx.Bucket(tagsBucket), questionID, tags)
+	})
+}
+
+func (s *BoltStore) QuestionTags(_ context.Context, questionID string) ([]string, error) {
+	tags := make([]string, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket(questionsBucket).Get([]byte(questionID)) == nil {
+			return quiz.ErrUnknownQuestion
+		}
+		if value := tx.Bucket(tagsBucket).Get([]byte(questionID)); value != nil {
+			return json.Unmarshal(value, &tags)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// SampleBankQuestions reads every candidate and shuffles the matches, like
+// SampleQuestions. With tags in the filter only tagged questions are read.
+func (s *BoltStore) SampleBankQuestions(_ context.Context, filter quiz.BankFilter, limit int) ([]quiz.Question, error) {
+	if limit <= 0 {
+		return []quiz.Question{}, nil
+	}
+	var questions []quiz.Question
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		questionBucket := tx.Bucket(questionsBucket)
+		candidates := questionBucket
+		if len(filter.Tags) > 0 {
+			candidates = tx.Bucket(tagsBucket)
+		}
+		return candidates.ForEach(func(key, value []byte) error {
+			if len(filter.Tags) > 0 {
+				var tags []string
+				if err := json.Unmarshal(value, &tags); err != nil {
+					return err
+				}
+				if !hasEveryTag(tags, filter.Tags) {
+					return nil
+				}
+			}
+			stored, ok, err := loadQuestion(questionBucket, string(key))
+			if err != nil || !ok {
+				return err
+			}
+			if filter.Difficulty != "" && stored.Difficulty != string(filter.Difficulty) {
+				return nil
+			}
+			questions = append(questions, stored.question())
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rand.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+	return append([]quiz.Question{}, questions[:min(limit, len(questions))]...), nil
+}
+
+func hasEveryTag(tags, wanted []string) bool {
+	have := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		have[tag] = true
+	}
+	for _, tag := range wanted {
+		if !have[tag] {
+			return false
+		}
+	}
+	return true
+}
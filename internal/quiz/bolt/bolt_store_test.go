@@ -0,0 +1,1069 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+	"quiz-app/internal/quiz/storetest"
+)
+
+func newTestBoltStore(t *testing.T) *BoltStore {
+	t.Helper()
+
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "test.bolt"))
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	return store
+}
+
+func TestBoltStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Store { return newTestBoltStore(t) })
+}
+
+func sampleQuestions() []quiz.Question {
+	return []quiz.Question{
+		{
+			PublicQuestion: quiz.PublicQuestion{
+				QuestionID: "q1",
+				Question:   "2+2?",
+				Options: []quiz.Option{
+					{Letter: "A", Text: "4"},
+					{Letter: "B", Text: "3"},
+				},
+			},
+			CorrectIndex: 0,
+		},
+		{
+			PublicQuestion: quiz.PublicQuestion{
+				QuestionID: "q2",
+				Question:   "Sky color?",
+				Options: []quiz.Option{
+					{Letter: "A", Text: "Green"},
+					{Letter: "B", Text: "Blue"},
+				},
+			},
+			CorrectIndex: 1,
+		},
+	}
+}
+
+func seedAttempt(t *testing.T, store *BoltStore, quizID, username, questionID string, score float64, submittedAt int64) {
+	t.Helper()
+
+	err := store.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.Bucket(attemptsBucket).CreateBucketIfNotExists([]byte(quizID))
+		if err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(attemptRecord{AnswerLetter: "A", Score: score, SubmittedAtUnix: submittedAt})
+		if err != nil {
+			return err
+		}
+		return bucket.Put(attemptKey(username, questionID), encoded)
+	})
+	if err != nil {
+		t.Fatalf("seed attempt failed: %v", err)
+	}
+}
+
+func TestBoltStoreCreateAndReadQuiz(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	createdAt := time.Unix(1700000000, 123).UTC()
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1", QuestionCount: 2, CreatedAt: createdAt}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	gotMeta, err := store.GetQuizMetadata(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetQuizMetadata failed: %v", err)
+	}
+	if gotMeta.QuizID != "quiz-1" || gotMeta.QuestionCount != 2 || !gotMeta.CreatedAt.Equal(createdAt) {
+		t.Fatalf("unexpected metadata: %+v", gotMeta)
+	}
+
+	gotQuestions, err := store.GetQuizQuestions(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	if len(gotQuestions) != 2 || gotQuestions[0].QuestionID != "q1" || gotQuestions[1].QuestionID != "q2" {
+		t.Fatalf("question order not preserved: %+v", gotQuestions)
+	}
+	if gotQuestions[1].CorrectIndex != 1 || gotQuestions[1].Options[1].Text != "Blue" {
+		t.Fatalf("question fields not round-tripped: %+v", gotQuestions[1])
+	}
+
+	exists, err := store.QuizExists(ctx, "quiz-1")
+	if err != nil || !exists {
+		t.Fatalf("QuizExists = (%t, %v), want (true, nil)", exists, err)
+	}
+
+	if _, err := store.GetQuizMetadata(ctx, "missing"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("expected ErrQuizNotFound for missing metadata, got %v", err)
+	}
+	if _, err := store.GetQuizQuestions(ctx, "missing"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("expected ErrQuizNotFound for missing questions, got %v", err)
+	}
+}
+
+func TestBoltStoreCreateQuizOverwriteClearsAttempts(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz initial failed: %v", err)
+	}
+	if _, err := store.SubmitResponses(ctx, "quiz-1", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+
+	newQuestions := []quiz.Question{
+		{
+			PublicQuestion: quiz.PublicQuestion{
+				QuestionID: "q-new",
+				Question:   "New question",
+				Options:    []quiz.Option{{Letter: "A", Text: "Yes"}, {Letter: "B", Text: "No"}},
+			},
+			CorrectIndex: 0,
+		},
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, newQuestions); err != nil {
+		t.Fatalf("CreateQuiz overwrite failed: %v", err)
+	}
+
+	questions, err := store.GetQuizQuestions(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions after overwrite failed: %v", err)
+	}
+	if len(questions) != 1 || questions[0].QuestionID != "q-new" {
+		t.Fatalf("expected overwritten quiz questions, got %+v", questions)
+	}
+
+	scores, err := store.GetAttemptScores(ctx, "quiz-1", "alice")
+	if err != nil {
+		t.Fatalf("GetAttemptScores failed: %v", err)
+	}
+	if len(scores) != 0 {
+		t.Fatalf("expected attempts reset on overwrite, got %+v", scores)
+	}
+}
+
+func TestBoltStoreSubmitResponsesStatusesAndDuplicate(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	results, err := store.SubmitResponses(ctx, "quiz-1", "alice", []quiz.SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "q2", Answer: "A"},
+		{QuestionID: "q2", Answer: "ZZ"},
+		{QuestionID: "missing", Answer: "A"},
+	})
+	if err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	want := []string{quiz.StatusCorrect, quiz.StatusIncorrect, quiz.StatusInvalidLetter, quiz.StatusInvalidQuestion}
+	for idx, status := range want {
+		if results[idx].Status != status {
+			t.Fatalf("result %d status = %q, want %q", idx, results[idx].Status, status)
+		}
+	}
+
+	duplicate, err := store.SubmitResponses(ctx, "quiz-1", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "B"}})
+	if err != nil {
+		t.Fatalf("SubmitResponses duplicate failed: %v", err)
+	}
+	if len(duplicate) != 1 || duplicate[0].Status != quiz.StatusAlreadyAnswered {
+		t.Fatalf("expected already_answered, got %+v", duplicate)
+	}
+	if duplicate[0].AttemptScore == nil || *duplicate[0].AttemptScore != 1.0 {
+		t.Fatalf("expected attempt_score=1.0 for duplicate, got %+v", duplicate[0].AttemptScore)
+	}
+
+	if _, err := store.SubmitResponses(ctx, "missing", "alice", nil); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("expected ErrQuizNotFound for missing quiz submit, got %v", err)
+	}
+}
+
+func TestBoltStoreGetLeaderboardOrdering(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	seedAttempt(t, store, "quiz-1", "bob", "q1", 1.0, 300)
+	seedAttempt(t, store, "quiz-1", "bob", "q2", 1.0, 400)
+	seedAttempt(t, store, "quiz-1", "alice", "q1", 1.0, 100)
+	seedAttempt(t, store, "quiz-1", "alice", "q2", 1.0, 200)
+	seedAttempt(t, store, "quiz-1", "carol", "q1", 1.0, 500)
+	seedAttempt(t, store, "quiz-1", "dave", "q2", 1.0, 500)
+
+	board, err := store.GetLeaderboard(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+
+	wantOrder := []string{"alice", "bob", "carol", "dave"}
+	if len(board) != len(wantOrder) {
+		t.Fatalf("expected %d leaderboard rows, got %d", len(wantOrder), len(board))
+	}
+	for idx := range wantOrder {
+		if board[idx].Username != wantOrder[idx] {
+			t.Fatalf("unexpected leaderboard order at %d: got %+v", idx, board)
+		}
+	}
+	if board[0].TotalScore != 2 || board[0].AnsweredCount != 2 || board[0].LastSubmissionAt.UnixNano() != 200 {
+		t.Fatalf("unexpected aggregate for alice: %+v", board[0])
+	}
+
+	if _, err := store.GetLeaderboard(ctx, "missing"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("expected ErrQuizNotFound for missing quiz leaderboard, got %v", err)
+	}
+}
+
+func TestBoltStoreGetAttemptScoresIsolatesUsers(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if _, err := store.SubmitResponses(ctx, "quiz-1", "al", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses al failed: %v", err)
+	}
+	if _, err := store.SubmitResponses(ctx, "quiz-1", "alice", []quiz.SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "q2", Answer: "A"},
+	}); err != nil {
+		t.Fatalf("SubmitResponses alice failed: %v", err)
+	}
+
+	scores, err := store.GetAttemptScores(ctx, "quiz-1", "al")
+	if err != nil {
+		t.Fatalf("GetAttemptScores failed: %v", err)
+	}
+	if len(scores) != 1 || scores["q1"] != 1.0 {
+		t.Fatalf("expected only al's attempt, got %+v", scores)
+	}
+
+	scores, err = store.GetAttemptScores(ctx, "quiz-1", "alice")
+	if err != nil {
+		t.Fatalf("GetAttemptScores failed: %v", err)
+	}
+	if len(scores) != 2 || scores["q1"] != 1.0 || scores["q2"] != 0.0 {
+		t.Fatalf("unexpected alice scores: %+v", scores)
+	}
+}
+
+func TestBoltStoreListActiveQuizzes(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	for idx := 0; idx < 12; idx++ {
+		err := store.CreateQuiz(ctx, quiz.QuizMetadata{
+			QuizID:    "quiz-" + string(rune('a'+idx)),
+			CreatedAt: time.Unix(int64(100+idx), 0).UTC(),
+		}, sampleQuestions()[:1])
+		if err != nil {
+			t.Fatalf("CreateQuiz #%d failed: %v", idx, err)
+		}
+	}
+
+	active, err := store.ListActiveQuizzes(ctx, 0)
+	if err != nil {
+		t.Fatalf("ListActiveQuizzes default failed: %v", err)
+	}
+	if len(active) != 10 {
+		t.Fatalf("expected default 10 quizzes, got %d", len(active))
+	}
+	if active[0].QuizID != "quiz-l" {
+		t.Fatalf("expected newest quiz first, got %+v", active[0])
+	}
+	for idx := 1; idx < len(active); idx++ {
+		if active[idx-1].CreatedAt.Before(active[idx].CreatedAt) {
+			t.Fatalf("active quizzes not sorted desc by created_at: %+v", active)
+		}
+	}
+}
+
+func TestBoltStoreVoidQuestionExcludesAttemptsAndRejectsAnswers(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-void", CreatedAt: time.Now().UTC()}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if _, err := store.SubmitResponses(ctx, "quiz-void", "alice", []quiz.SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "q2", Answer: "B"},
+	}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+
+	if err := store.VoidQuestion(ctx, "quiz-void", "q1", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+	if err := store.VoidQuestion(ctx, "quiz-void", "q1", time.Now()); err != nil {
+		t.Fatalf("repeated VoidQuestion = %v, want nil", err)
+	}
+
+	questions, err := store.GetQuizQuestions(ctx, "quiz-void")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	if !questions[0].Voided || questions[1].Voided {
+		t.Fatalf("voided flags = (%t, %t), want (true, false)", questions[0].Voided, questions[1].Voided)
+	}
+
+	leaderboard, err := store.GetLeaderboard(ctx, "quiz-void")
+	if err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+	if len(leaderboard) != 1 || leaderboard[0].TotalScore != 1 || leaderboard[0].AnsweredCount != 1 {
+		t.Fatalf("unexpected leaderboard after void: %+v", leaderboard)
+	}
+
+	scores, err := store.GetAttemptScores(ctx, "quiz-void", "alice")
+	if err != nil {
+		t.Fatalf("GetAttemptScores failed: %v", err)
+	}
+	if _, ok := scores["q1"]; ok || len(scores) != 1 {
+		t.Fatalf("unexpected attempt scores after void: %+v", scores)
+	}
+
+	results, err := store.SubmitResponses(ctx, "quiz-void", "bob", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}})
+	if err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	if results[0].Status != quiz.StatusVoidedQuestion {
+		t.Fatalf("status = %q, want %q", results[0].Status, quiz.StatusVoidedQuestion)
+	}
+
+	if err := store.VoidQuestion(ctx, "quiz-void", "missing", time.Now()); !errors.Is(err, quiz.ErrQuestionNotFound) {
+		t.Fatalf("VoidQuestion missing question = %v, want ErrQuestionNotFound", err)
+	}
+	if err := store.VoidQuestion(ctx, "missing-quiz", "q1", time.Now()); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("VoidQuestion missing quiz = %v, want ErrQuizNotFound", err)
+	}
+}
+
+func TestBoltStoreQuestionPerformanceByAuthor(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(quiz-1) failed: %v", err)
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-2"}, sampleQuestions()[:1]); err != nil {
+		t.Fatalf("CreateQuiz(quiz-2) failed: %v", err)
+	}
+
+	authoredAt := time.Unix(1700000000, 0).UTC()
+	if err := store.RecordQuestionAuthor(ctx, "carol", []string{"q1", "q2"}, authoredAt); err != nil {
+		t.Fatalf("RecordQuestionAuthor(carol) failed: %v", err)
+	}
+	// The first author keeps the question.
+	if err := store.RecordQuestionAuthor(ctx, "dave", []string{"q1"}, authoredAt.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordQuestionAuthor(dave) failed: %v", err)
+	}
+
+	submissions := []struct {
+		quizID   string
+		username string
+		answers  []quiz.SubmittedResponse
+	}{
+		{"quiz-1", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}, {QuestionID: "q2", Answer: "A"}}},
+		{"quiz-2", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}}},
+		{"quiz-2", "bob", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "B"}}},
+	}
+	for _, submission := range submissions {
+		if _, err := store.SubmitResponses(ctx, submission.quizID, submission.username, submission.answers); err != nil {
+			t.Fatalf("SubmitResponses(%s, %s) failed: %v", submission.quizID, submission.username, err)
+		}
+	}
+	if err := store.VoidQuestion(ctx, "quiz-1", "q2", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+
+	if err := store.ReportQuestion(ctx, "q1", "alice", "typo", authoredAt); err != nil {
+		t.Fatalf("ReportQuestion(alice) failed: %v", err)
+	}
+	if err := store.ReportQuestion(ctx, "q1", "alice", "still a typo", authoredAt); err != nil {
+		t.Fatalf("repeated ReportQuestion(alice) failed: %v", err)
+	}
+	if err := store.ReportQuestion(ctx, "q1", "bob", "", authoredAt); err != nil {
+		t.Fatalf("ReportQuestion(bob) failed: %v", err)
+	}
+	if err := store.ReportQuestion(ctx, "missing", "bob", "", authoredAt); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("ReportQuestion(missing) error = (%v), want ErrUnknownQuestion", err)
+	}
+
+	performance, err := store.QuestionPerformanceByAuthor(ctx, "carol")
+	if err != nil {
+		t.Fatalf("QuestionPerformanceByAuthor failed: %v", err)
+	}
+	if len(performance) != 2 || performance[0].Question.QuestionID != "q1" || performance[1].Question.QuestionID != "q2" {
+		t.Fatalf("performance = (%+v), want q1 then q2", performance)
+	}
+	if got := performance[0]; got.QuizCount != 2 || got.AttemptCount != 3 || got.CorrectCount != 2 || got.ReportCount != 2 {
+		t.Fatalf("q1 performance = (%+v), want 2 quizzes, 3 attempts, 2 correct, 2 reports", got)
+	}
+	if got := performance[1]; got.QuizCount != 1 || got.AttemptCount != 0 || got.ReportCount != 0 {
+		t.Fatalf("q2 performance = (%+v), want 1 quiz and voided attempt excluded", got)
+	}
+	if performance[0].Question.Question != "2+2?" {
+		t.Fatalf("question = (%+v), want full question", performance[0].Question)
+	}
+
+	if others, err := store.QuestionPerformanceByAuthor(ctx, "dave"); err != nil || len(others) != 0 {
+		t.Fatalf("dave's performance = (%+v, %v), want none", others, err)
+	}
+}
+
+func TestBoltStoreKeepsFeedbackAndPracticeFlag(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	questions := sampleQuestions()
+	questions[0].Feedback = []string{"", "3 is one short."}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-practice", Practice: true}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	// Storing the same question again without feedback keeps the earlier feedback.
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-plain"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(plain) failed: %v", err)
+	}
+
+	metadata, err := store.GetQuizMetadata(ctx, "quiz-practice")
+	if err != nil || !metadata.Practice {
+		t.Fatalf("practice metadata = (%+v, %v), want Practice", metadata, err)
+	}
+	if plain, _ := store.GetQuizMetadata(ctx, "quiz-plain"); plain.Practice {
+		t.Fatalf("plain metadata = (%+v), want not Practice", plain)
+	}
+
+	stored, err := store.GetQuizQuestions(ctx, "quiz-plain")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	if stored[0].FeedbackFor(1) != "3 is one short." || stored[1].Feedback != nil {
+		t.Fatalf("feedback = (%q, %q), want q1 feedback kept and none for q2", stored[0].Feedback, stored[1].Feedback)
+	}
+}
+
+func TestBoltStoreKeepsTranslations(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	questions := sampleQuestions()
+	questions[1].Translations = map[string]quiz.Translation{"es": {Question: "¿Color del cielo?", Options: []string{"Verde", "Azul"}}}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-bilingual"}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	// A copy without translations keeps the stored ones.
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-plain"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(plain) failed: %v", err)
+	}
+
+	stored, err := store.GetQuizQuestions(ctx, "quiz-plain")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	public, ok := stored[1].Translated("es")
+	if !ok || public.Question != "¿Color del cielo?" || public.Options[1].Text != "Azul" || stored[0].Translations != nil {
+		t.Fatalf("translations = (%+v, %+v), want q2 in Spanish and none for q1", stored[1].Translations, stored[0].Translations)
+	}
+}
+
+func TestBoltStoreListAttemptsAndAdaptiveFlag(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	questions := sampleQuestions()
+	questions[0].Difficulty = quiz.DifficultyHard
+	questions[0].Category = "Science & Nature"
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-adaptive", Adaptive: true}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	// Storing the same question again without a difficulty or category keeps
+	// the earlier ones.
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-plain", QuestionTimeLimit: 20 * time.Second}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(plain) failed: %v", err)
+	}
+
+	metadata, err := store.GetQuizMetadata(ctx, "quiz-adaptive")
+	if err != nil || !metadata.Adaptive || metadata.QuestionTimeLimit != 0 {
+		t.Fatalf("adaptive metadata = (%+v, %v), want Adaptive and untimed", metadata, err)
+	}
+	metadata, err = store.GetQuizMetadata(ctx, "quiz-plain")
+	if err != nil || metadata.Adaptive || metadata.QuestionTimeLimit != 20*time.Second {
+		t.Fatalf("plain metadata = (%+v, %v), want a 20s question time limit", metadata, err)
+	}
+	stored, err := store.GetQuizQuestions(ctx, "quiz-plain")
+	if err != nil || stored[0].Difficulty != quiz.DifficultyHard || stored[1].Difficulty != "" {
+		t.Fatalf("difficulties = (%+v, %v), want hard kept for q1 only", stored, err)
+	}
+	if stored[0].Category != "Science & Nature" || stored[1].Category != "" {
+		t.Fatalf("categories = (%q, %q), want q1's category kept", stored[0].Category, stored[1].Category)
+	}
+
+	// Answer order, not question order, decides the history order.
+	for _, response := range []quiz.SubmittedResponse{{QuestionID: "q2", Answer: "B"}, {QuestionID: "q1", Answer: "B"}} {
+		if _, err := store.SubmitResponses(ctx, "quiz-adaptive", "alice", []quiz.SubmittedResponse{response}); err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", response.QuestionID, err)
+		}
+	}
+	attempts, err := store.ListAttempts(ctx, "quiz-adaptive", "alice")
+	if err != nil || len(attempts) != 2 || attempts[0].QuestionID != "q2" || attempts[0].Score != 1 || attempts[1].QuestionID != "q1" || attempts[1].Score != 0 {
+		t.Fatalf("ListAttempts = (%+v, %v), want q2 correct then q1 wrong", attempts, err)
+	}
+
+	if err := store.VoidQuestion(ctx, "quiz-adaptive", "q2", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+	attempts, err = store.ListAttempts(ctx, "quiz-adaptive", "alice")
+	if err != nil || len(attempts) != 1 || attempts[0].QuestionID != "q1" {
+		t.Fatalf("ListAttempts after void = (%+v, %v), want only q1", attempts, err)
+	}
+	if attempts, err := store.ListAttempts(ctx, "quiz-adaptive", "bob"); err != nil || len(attempts) != 0 {
+		t.Fatalf("ListAttempts(bob) = (%+v, %v), want none", attempts, err)
+	}
+}
+
+func TestBoltStoreQuizOriginRoundTrip(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	origin := quiz.QuizOrigin{
+		Provider:      "bundles:tech",
+		DifficultyMix: quiz.DifficultyMix{quiz.DifficultyMedium: 4},
+		Seed:          7,
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-mixed", Origin: origin}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-legacy"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	got, err := store.GetQuizMetadata(ctx, "quiz-mixed")
+	if err != nil || got.Origin.Provider != origin.Provider || got.Origin.Seed != 7 || got.Origin.DifficultyMix[quiz.DifficultyMedium] != 4 {
+		t.Fatalf("GetQuizMetadata = (%+v, %v), want origin %+v", got, err, origin)
+	}
+	legacy, err := store.GetQuizMetadata(ctx, "quiz-legacy")
+	if err != nil || legacy.Origin.Provider != "" || legacy.Origin.DifficultyMix != nil {
+		t.Fatalf("GetQuizMetadata(legacy) = (%+v, %v), want no origin", legacy, err)
+	}
+}
+
+func TestBoltStoreProfiles(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	profile, err := store.GetProfile(ctx, "alice")
+	if err != nil || profile.Username != "alice" || profile.Anonymous || !profile.UpdatedAt.IsZero() {
+		t.Fatalf("GetProfile(unsaved) = (%+v, %v), want defaults", profile, err)
+	}
+
+	updatedAt := time.Unix(1700000000, 0).UTC()
+	if err := store.SaveProfile(ctx, quiz.UserProfile{Username: "alice", Anonymous: true, UpdatedAt: updatedAt}); err != nil {
+		t.Fatalf("SaveProfile failed: %v", err)
+	}
+	if err := store.SaveProfile(ctx, quiz.UserProfile{Username: "bob", UpdatedAt: updatedAt}); err != nil {
+		t.Fatalf("SaveProfile(bob) failed: %v", err)
+	}
+	profile, err = store.GetProfile(ctx, "alice")
+	if err != nil || !profile.Anonymous || !profile.UpdatedAt.Equal(updatedAt) {
+		t.Fatalf("GetProfile = (%+v, %v), want anonymous saved at %v", profile, err, updatedAt)
+	}
+
+	anonymous, err := store.AnonymousUsers(ctx, []string{"alice", "bob", "carol"})
+	if err != nil || len(anonymous) != 1 || !anonymous["alice"] {
+		t.Fatalf("AnonymousUsers = (%v, %v), want only alice", anonymous, err)
+	}
+}
+
+func TestBoltStorePreferences(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	preferences, err := store.GetPreferences(ctx, "alice")
+	if err != nil || preferences.Username != "alice" || preferences.QuestionCount != 0 || preferences.Difficulty != "" || !preferences.UpdatedAt.IsZero() {
+		t.Fatalf("GetPreferences(unsaved) = (%+v, %v), want empty preferences", preferences, err)
+	}
+
+	updatedAt := time.Unix(1700000000, 0).UTC()
+	saved := quiz.UserPreferences{Username: "alice", QuestionCount: 8, Difficulty: quiz.DifficultyHard, UpdatedAt: updatedAt}
+	if err := store.SavePreferences(ctx, saved); err != nil {
+		t.Fatalf("SavePreferences failed: %v", err)
+	}
+	preferences, err = store.GetPreferences(ctx, "alice")
+	if err != nil || preferences != saved {
+		t.Fatalf("GetPreferences = (%+v, %v), want %+v", preferences, err, saved)
+	}
+
+	// Saving replaces every field, so a cleared difficulty stays cleared.
+	saved.Difficulty = ""
+	if err := store.SavePreferences(ctx, saved); err != nil {
+		t.Fatalf("SavePreferences(cleared) failed: %v", err)
+	}
+	if preferences, err = store.GetPreferences(ctx, "alice"); err != nil || preferences != saved {
+		t.Fatalf("GetPreferences after clearing = (%+v, %v), want %+v", preferences, err, saved)
+	}
+}
+
+func TestBoltStoreStreaks(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	streak, err := store.GetStreak(ctx, "alice")
+	if err != nil || streak.Username != "alice" || streak.Current != 0 || streak.LastDay != "" {
+		t.Fatalf("GetStreak(unplayed) = (%+v, %v), want zero streak", streak, err)
+	}
+
+	recordedAt := time.Unix(1700000000, 0).UTC()
+	for _, day := range []string{"2024-03-01", "2024-03-02", "2024-03-02", "2024-03-03", "2024-03-05"} {
+		if _, err := store.RecordStreakDay(ctx, "alice", day, recordedAt); err != nil {
+			t.Fatalf("RecordStreakDay(%s) failed: %v", day, err)
+		}
+	}
+	streak, err = store.GetStreak(ctx, "alice")
+	if err != nil || streak.Current != 1 || streak.Longest != 3 || streak.LastDay != "2024-03-05" || !streak.UpdatedAt.Equal(recordedAt) {
+		t.Fatalf("GetStreak = (%+v, %v), want current 1, longest 3, last day 2024-03-05", streak, err)
+	}
+}
+
+func TestBoltStoreSigningKeys(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if key, err := store.GetSigningKey(ctx, "alice", "k1"); err != nil || key.PublicKey != nil {
+		t.Fatalf("GetSigningKey(unknown) = (%+v, %v), want the zero key", key, err)
+	}
+	first := quiz.SigningKey{Username: "alice", KeyID: "k1", PublicKey: ed25519.PublicKey(bytes.Repeat([]byte{1}, ed25519.PublicKeySize)), RegisteredAt: time.Unix(1700000000, 0).UTC()}
+	if err := store.SaveSigningKey(ctx, first); err != nil {
+		t.Fatalf("SaveSigningKey failed: %v", err)
+	}
+	// Saving the key again keeps the first registration.
+	again := first
+	again.RegisteredAt = first.RegisteredAt.Add(time.Hour)
+	if err := store.SaveSigningKey(ctx, again); err != nil {
+		t.Fatalf("SaveSigningKey(again) failed: %v", err)
+	}
+	key, err := store.GetSigningKey(ctx, "alice", "k1")
+	if err != nil || key.Username != "alice" || key.KeyID != "k1" || !key.PublicKey.Equal(first.PublicKey) || !key.RegisteredAt.Equal(first.RegisteredAt) {
+		t.Fatalf("GetSigningKey = (%+v, %v), want the first registration", key, err)
+	}
+	if other, err := store.GetSigningKey(ctx, "bob", "k1"); err != nil || other.PublicKey != nil {
+		t.Fatalf("GetSigningKey(bob) = (%+v, %v), want keys kept per user", other, err)
+	}
+}
+
+func TestBoltStoreQuizResults(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if results, err := store.GetQuizResults(ctx, "quiz-1"); err != nil || results.Document != nil {
+		t.Fatalf("GetQuizResults(unpublished) = (%+v, %v), want the zero value", results, err)
+	}
+	first := quiz.PublishedResults{QuizID: "quiz-1", Document: []byte(`{"quiz_id":"quiz-1","note":"<first>"}`), PublishedAt: time.Unix(1700000000, 0).UTC()}
+	if err := store.SaveQuizResults(ctx, first); err != nil {
+		t.Fatalf("SaveQuizResults failed: %v", err)
+	}
+	// Publishing again keeps the first document.
+	if err := store.SaveQuizResults(ctx, quiz.PublishedResults{QuizID: "quiz-1", Document: []byte(`{}`), PublishedAt: first.PublishedAt.Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveQuizResults(again) failed: %v", err)
+	}
+	results, err := store.GetQuizResults(ctx, "quiz-1")
+	if err != nil || results.QuizID != "quiz-1" || !bytes.Equal(results.Document, first.Document) || !results.PublishedAt.Equal(first.PublishedAt) {
+		t.Fatalf("GetQuizResults = (%+v, %v), want the first document byte for byte", results, err)
+	}
+}
+
+func TestBoltStoreAddsSpeedBonusToCorrectAnswers(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	first := time.Unix(100, 0).UTC()
+	if err := store.RecordServes(ctx, "quiz-1", "alice", []string{"q1", "q2"}, first); err != nil {
+		t.Fatalf("RecordServes failed: %v", err)
+	}
+	// Serving again keeps the first time, and other users are tracked separately.
+	if err := store.RecordServes(ctx, "quiz-1", "alice", []string{"q1"}, first.Add(time.Minute)); err != nil {
+		t.Fatalf("RecordServes(again) failed: %v", err)
+	}
+	if err := store.RecordServes(ctx, "quiz-1", "alicia", []string{"q1"}, first.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordServes(alicia) failed: %v", err)
+	}
+	served, err := store.ServeTimes(ctx, "quiz-1", "alice")
+	if err != nil || len(served) != 2 || !served["q1"].Equal(first) || !served["q2"].Equal(first) {
+		t.Fatalf("ServeTimes = (%v, %v), want q1 and q2 at %v", served, err, first)
+	}
+
+	results, err := store.SubmitResponses(ctx, "quiz-1", "alice", []quiz.SubmittedResponse{
+		{QuestionID: "q1", Answer: "A", Bonus: 0.5},
+		{QuestionID: "q2", Answer: "A", Bonus: 0.5},
+	})
+	if err != nil || results[0].Bonus != 0.5 || results[1].Bonus != 0 {
+		t.Fatalf("SubmitResponses = (%+v, %v), want the bonus on the correct answer only", results, err)
+	}
+	leaderboard, err := store.GetLeaderboard(ctx, "quiz-1")
+	if err != nil || len(leaderboard) != 1 || leaderboard[0].TotalScore != 1.5 {
+		t.Fatalf("GetLeaderboard = (%+v, %v), want alice on 1.5", leaderboard, err)
+	}
+}
+
+func TestBoltStoreServeLog(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	first := time.Unix(100, 0).UTC()
+	for idx, withAnswerKey := range []bool{false, true, true} {
+		noncesIssued := 0
+		if !withAnswerKey {
+			noncesIssued = 3
+		}
+		if err := store.LogServe(ctx, "quiz-1", "alice", first.Add(time.Duration(idx)*time.Minute), withAnswerKey, noncesIssued); err != nil {
+			t.Fatalf("LogServe(%d) failed: %v", idx, err)
+		}
+	}
+	if err := store.LogServe(ctx, "quiz-2", "bob", first, false, 0); err != nil {
+		t.Fatalf("LogServe(bob) failed: %v", err)
+	}
+
+	entries, err := store.ListServeLog(ctx, "quiz-1")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ListServeLog = (%+v, %v), want only alice", entries, err)
+	}
+	entry := entries[0]
+	if entry.Username != "alice" || entry.FetchCount != 3 || entry.NoncesIssued != 3 || !entry.FirstServedAt.Equal(first) ||
+		!entry.LastServedAt.Equal(first.Add(2*time.Minute)) || !entry.AnswerKeyServedAt.Equal(first.Add(time.Minute)) {
+		t.Fatalf("entry = %+v, want 3 fetches and 3 nonces with the answer key first served at +1m", entry)
+	}
+}
+
+func TestBoltStoreLeaderboardSettings(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	settings, err := store.GetLeaderboardSettings(ctx, "quiz-1")
+	if err != nil || settings != (quiz.LeaderboardSettings{}) {
+		t.Fatalf("GetLeaderboardSettings(unsaved) = (%+v, %v), want zero settings", settings, err)
+	}
+
+	saved := quiz.LeaderboardSettings{
+		DefaultLimit: 25,
+		FreezeWindow: 5 * time.Minute,
+		LocksAt:      time.Unix(1700000600, 0).UTC(),
+		UpdatedAt:    time.Unix(1700000000, 0).UTC(),
+	}
+	if err := store.SaveLeaderboardSettings(ctx, "quiz-1", saved); err != nil {
+		t.Fatalf("SaveLeaderboardSettings failed: %v", err)
+	}
+	if settings, err = store.GetLeaderboardSettings(ctx, "quiz-1"); err != nil || settings != saved {
+		t.Fatalf("GetLeaderboardSettings = (%+v, %v), want %+v", settings, err, saved)
+	}
+
+	cleared := quiz.LeaderboardSettings{DefaultLimit: 10, UpdatedAt: saved.UpdatedAt.Add(time.Minute)}
+	if err := store.SaveLeaderboardSettings(ctx, "quiz-1", cleared); err != nil {
+		t.Fatalf("SaveLeaderboardSettings(cleared) failed: %v", err)
+	}
+	if settings, err = store.GetLeaderboardSettings(ctx, "quiz-1"); err != nil || settings != cleared {
+		t.Fatalf("GetLeaderboardSettings after clearing = (%+v, %v), want %+v", settings, err, cleared)
+	}
+}
+
+func TestBoltStoreRetirementReviews(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(quiz-1) failed: %v", err)
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-2"}, sampleQuestions()[:1]); err != nil {
+		t.Fatalf("CreateQuiz(quiz-2) failed: %v", err)
+	}
+	submissions := []struct {
+		quizID   string
+		username string
+		answers  []quiz.SubmittedResponse
+	}{
+		{"quiz-1", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}, {QuestionID: "q2", Answer: "A"}}},
+		{"quiz-1", "bob", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}, {QuestionID: "q2", Answer: "B"}}},
+		{"quiz-2", "carol", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}}},
+	}
+	for _, submission := range submissions {
+		if _, err := store.SubmitResponses(ctx, submission.quizID, submission.username, submission.answers); err != nil {
+			t.Fatalf("SubmitResponses(%s, %s) failed: %v", submission.quizID, submission.username, err)
+		}
+	}
+	if err := store.VoidQuestion(ctx, "quiz-2", "q1", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+
+	stats, err := store.QuestionStats(ctx, 2)
+	if err != nil {
+		t.Fatalf("QuestionStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Question.QuestionID != "q1" || stats[1].Question.QuestionID != "q2" {
+		t.Fatalf("stats = (%+v), want q1 then q2", stats)
+	}
+	if got := stats[0]; got.AttemptCount != 2 || got.CorrectCount != 2 || got.Question.Question != "2+2?" {
+		t.Fatalf("q1 stats = (%+v), want 2 attempts, 2 correct, voided attempt excluded", got)
+	}
+	if got := stats[1]; got.AttemptCount != 2 || got.CorrectCount != 1 {
+		t.Fatalf("q2 stats = (%+v), want 2 attempts, 1 correct", got)
+	}
+	if stats, err := store.QuestionStats(ctx, 3); err != nil || len(stats) != 0 {
+		t.Fatalf("QuestionStats(3) = (%+v, %v), want none", stats, err)
+	}
+
+	flaggedAt := time.Unix(1700000000, 0).UTC()
+	if err := store.FlagQuestion(ctx, quiz.RetirementReview{
+		Question:     stats[0].Question,
+		Status:       quiz.RetirementPending,
+		AttemptCount: 2,
+		CorrectCount: 2,
+		FlaggedAt:    flaggedAt,
+	}); err != nil {
+		t.Fatalf("FlagQuestion failed: %v", err)
+	}
+	if _, err := store.DecideRetirement(ctx, "q1", quiz.RetirementRetired, flaggedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("DecideRetirement failed: %v", err)
+	}
+	// Flagging again leaves the decision alone.
+	if err := store.FlagQuestion(ctx, quiz.RetirementReview{Question: stats[0].Question, Status: quiz.RetirementPending, FlaggedAt: flaggedAt.Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("repeated FlagQuestion failed: %v", err)
+	}
+
+	reviews, err := store.ListRetirementReviews(ctx, quiz.RetirementRetired)
+	if err != nil {
+		t.Fatalf("ListRetirementReviews failed: %v", err)
+	}
+	if len(reviews) != 1 {
+		t.Fatalf("retired reviews = (%+v), want q1", reviews)
+	}
+	if got := reviews[0]; got.Question.QuestionID != "q1" || got.AttemptCount != 2 || !got.FlaggedAt.Equal(flaggedAt) || !got.DecidedAt.Equal(flaggedAt.Add(time.Hour)) {
+		t.Fatalf("review = (%+v), want q1 flagged at %v and decided an hour later", got, flaggedAt)
+	}
+	if pending, err := store.ListRetirementReviews(ctx, quiz.RetirementPending); err != nil || len(pending) != 0 {
+		t.Fatalf("pending reviews = (%+v, %v), want none", pending, err)
+	}
+	if _, err := store.DecideRetirement(ctx, "q2", quiz.RetirementKept, flaggedAt); !errors.Is(err, quiz.ErrNotFlagged) {
+		t.Fatalf("DecideRetirement(q2) error = (%v), want ErrNotFlagged", err)
+	}
+}
+
+func TestBoltStoreRosterRoundTrip(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if roster, err := store.GetRoster(ctx, "quiz-1"); err != nil || roster.Restricted || len(roster.Students) != 0 || !roster.UpdatedAt.IsZero() {
+		t.Fatalf("GetRoster(empty) = (%+v, %v), want zero roster", roster, err)
+	}
+
+	updatedAt := time.Unix(1700000000, 0).UTC()
+	roster := quiz.Roster{
+		Restricted: true,
+		Students: []quiz.RosterStudent{
+			{Username: "zed", Name: "Zed Adams", JoinCode: "K7PQ2X"},
+			{Username: "alice", Name: "Alice Smith"},
+		},
+		UpdatedAt: updatedAt,
+	}
+	if err := store.SaveRoster(ctx, "quiz-1", roster); err != nil {
+		t.Fatalf("SaveRoster failed: %v", err)
+	}
+	got, err := store.GetRoster(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetRoster failed: %v", err)
+	}
+	if !got.Restricted || !got.UpdatedAt.Equal(updatedAt) || len(got.Students) != 2 || got.Students[0] != roster.Students[0] || got.Students[1] != roster.Students[1] {
+		t.Fatalf("GetRoster = (%+v), want (%+v) in roster order", got, roster)
+	}
+
+	// Saving replaces the whole roster.
+	if err := store.SaveRoster(ctx, "quiz-1", quiz.Roster{Students: []quiz.RosterStudent{{Username: "alice"}}, UpdatedAt: updatedAt}); err != nil {
+		t.Fatalf("second SaveRoster failed: %v", err)
+	}
+	if got, err := store.GetRoster(ctx, "quiz-1"); err != nil || got.Restricted || len(got.Students) != 1 || got.Students[0].Username != "alice" {
+		t.Fatalf("GetRoster after replace = (%+v, %v), want only alice, unrestricted", got, err)
+	}
+}
+
+func TestBoltStoreHostsRoundTrip(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if hosts, err := store.GetHosts(ctx, "quiz-1"); err != nil || len(hosts) != 0 {
+		t.Fatalf("GetHosts(empty) = (%+v, %v), want none", hosts, err)
+	}
+
+	addedAt := time.Unix(1700000000, 0).UTC()
+	hosts := []quiz.QuizHost{
+		{Username: "carol", Role: quiz.HostOwner, TokenHash: "hash-c", AddedBy: "bob", AddedAt: addedAt},
+		{Username: "alice", Role: quiz.HostCoHost, TokenHash: "hash-a", AddedBy: "Dana", AddedAt: addedAt.Add(-time.Hour)},
+	}
+	if err := store.SaveHosts(ctx, "quiz-1", hosts); err != nil {
+		t.Fatalf("SaveHosts failed: %v", err)
+	}
+	got, err := store.GetHosts(ctx, "quiz-1")
+	if err != nil || len(got) != 2 || got[0] != hosts[0] || got[1] != hosts[1] {
+		t.Fatalf("GetHosts = (%+v, %v), want %+v in order", got, err, hosts)
+	}
+	if other, err := store.GetHosts(ctx, "quiz-2"); err != nil || len(other) != 0 {
+		t.Fatalf("GetHosts(other quiz) = (%+v, %v), want none", other, err)
+	}
+
+	// Saving replaces the whole list.
+	if err := store.SaveHosts(ctx, "quiz-1", hosts[1:]); err != nil {
+		t.Fatalf("second SaveHosts failed: %v", err)
+	}
+	if got, err := store.GetHosts(ctx, "quiz-1"); err != nil || len(got) != 1 || got[0].Username != "alice" {
+		t.Fatalf("GetHosts after replace = (%+v, %v), want only alice", got, err)
+	}
+}
+
+func TestBoltStoreIdentityRoundTrip(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if identity, err := store.GetIdentity(ctx, "alice"); err != nil || identity.Verified() {
+		t.Fatalf("GetIdentity(missing) = (%+v, %v), want unverified", identity, err)
+	}
+
+	verifiedAt := time.Unix(1700000000, 0).UTC()
+	identity := quiz.Identity{Username: "alice", Email: "alice@example.com", TokenHash: "hash-1", VerifiedAt: verifiedAt}
+	if err := store.SaveIdentity(ctx, identity); err != nil {
+		t.Fatalf("SaveIdentity failed: %v", err)
+	}
+	// Saving again replaces the token.
+	identity.TokenHash = "hash-2"
+	if err := store.SaveIdentity(ctx, identity); err != nil {
+		t.Fatalf("second SaveIdentity failed: %v", err)
+	}
+	got, err := store.GetIdentity(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetIdentity failed: %v", err)
+	}
+	if got.Username != "alice" || got.Email != "alice@example.com" || got.TokenHash != "hash-2" || !got.VerifiedAt.Equal(verifiedAt) {
+		t.Fatalf("GetIdentity = (%+v), want (%+v)", got, identity)
+	}
+}
+
+func TestBoltStoreAccountRoundTrip(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	if account, err := store.GetAccount(ctx, "alice"); err != nil || account.Registered() {
+		t.Fatalf("GetAccount(missing) = (%+v, %v), want unregistered", account, err)
+	}
+
+	createdAt := time.Unix(1700000000, 0).UTC()
+	account := quiz.Account{Username: "alice", PasswordHash: "hash-1", CreatedAt: createdAt}
+	if err := store.CreateAccount(ctx, account); err != nil {
+		t.Fatalf("CreateAccount failed: %v", err)
+	}
+	// A taken username keeps its first account.
+	if err := store.CreateAccount(ctx, quiz.Account{Username: "alice", PasswordHash: "hash-2", CreatedAt: createdAt}); !errors.Is(err, quiz.ErrAccountExists) {
+		t.Fatalf("second CreateAccount = %v, want ErrAccountExists", err)
+	}
+	got, err := store.GetAccount(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetAccount failed: %v", err)
+	}
+	if got.Username != "alice" || got.PasswordHash != "hash-1" || !got.CreatedAt.Equal(createdAt) {
+		t.Fatalf("GetAccount = (%+v), want (%+v)", got, account)
+	}
+}
+
+func TestBoltStoreQuestionTagsRoundTrip(t *testing.T) {
+	store := newTestBoltStore(t)
+	ctx := context.Background()
+
+	questions := sampleQuestions()
+	questions[0].Difficulty, questions[1].Difficulty = quiz.DifficultyEasy, quiz.DifficultyHard
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "q1", []string{"geo", "week-1"}); err != nil {
+		t.Fatalf("SetQuestionTags(q1) failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "q2", []string{"geo"}); err != nil {
+		t.Fatalf("SetQuestionTags(q2) failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "missing", []string{"geo"}); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("SetQuestionTags(missing) = %v, want ErrUnknownQuestion", err)
+	}
+	if tags, err := store.QuestionTags(ctx, "q1"); err != nil || strings.Join(tags, ",") != "geo,week-1" {
+		t.Fatalf("QuestionTags(q1) = (%v, %v), want geo,week-1", tags, err)
+	}
+	if _, err := store.QuestionTags(ctx, "missing"); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("QuestionTags(missing) = %v, want ErrUnknownQuestion", err)
+	}
+
+	for _, tc := range []struct {
+		filter quiz.BankFilter
+		want   string
+	}{
+		{quiz.BankFilter{Tags: []string{"geo"}}, "q1,q2"},
+		{quiz.BankFilter{Tags: []string{"geo", "week-1"}}, "q1"},
+		{quiz.BankFilter{Tags: []string{"geo"}, Difficulty: quiz.DifficultyHard}, "q2"},
+		{quiz.BankFilter{Tags: []string{"history"}}, ""},
+		{quiz.BankFilter{}, "q1,q2"},
+	} {
+		sampled, err := store.SampleBankQuestions(ctx, tc.filter, 10)
+		if err != nil {
+			t.Fatalf("SampleBankQuestions(%+v) failed: %v", tc.filter, err)
+		}
+		ids := make([]string, 0, len(sampled))
+		for _, question := range sampled {
+			ids = append(ids, question.QuestionID)
+		}
+		slices.Sort(ids)
+		if got := strings.Join(ids, ","); got != tc.want {
+			t.Fatalf("SampleBankQuestions(%+v) = %s, want %s", tc.filter, got, tc.want)
+		}
+	}
+	if sampled, err := store.SampleBankQuestions(ctx, quiz.BankFilter{Tags: []string{"geo"}}, 1); err != nil || len(sampled) != 1 || len(sampled[0].Options) != 2 {
+		t.Fatalf("SampleBankQuestions(limit 1) = (%+v, %v), want one full question", sampled, err)
+	}
+
+	// Clearing q1's tags lets it go with the quiz; tagged q2 stays.
+	if err := store.SetQuestionTags(ctx, "q1", nil); err != nil {
+		t.Fatalf("SetQuestionTags(q1, nil) failed: %v", err)
+	}
+	if _, err := store.DeleteQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("DeleteQuiz failed: %v", err)
+	}
+	if _, err := store.QuestionTags(ctx, "q1"); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("QuestionTags(q1) after delete = %v, want ErrUnknownQuestion", err)
+	}
+	if tags, err := store.QuestionTags(ctx, "q2"); err != nil || strings.Join(tags, ",") != "geo" {
+		t.Fatalf("QuestionTags(q2) after delete = (%v, %v), want geo", tags, err)
+	}
+}
@@ -0,0 +1,176 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"quiz-app/internal/quiz"
+)
+
+// RecordQuestionUsage keeps only the latest usage per question, which is all
+// the repeat window needs.
+func (s *BoltStore) RecordQuestionUsage(_ context.Context, _ string, questionIDs []string, usedAt time.Time) error {
+	value := []byte(strconv.FormatInt(usedAt.UTC().Unix(), 10))
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		usage := tx.Bucket(usageBucket)
+		for _, questionID := range questionIDs {
+			if previous, ok := parseUsage(usage.Get([]byte(questionID))); ok && previous >= usedAt.UTC().Unix() {
+				continue
+			}
+			if err := usage.Put([]byte(questionID), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) RecentlyUsedQuestions(_ context.Context, since time.Time) ([]quiz.Question, error) {
+	used := make([]quiz.Question, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		questionBucket := tx.Bucket(questionsBucket)
+		return tx.Bucket(usageBucket).ForEach(func(key, value []byte) error {
+			if usedAt, ok := parseUsage(value); !ok || usedAt < since.UTC().Unix() {
+				return nil
+			}
+			stored, ok, err := loadQuestion(questionBucket, string(key))
+			if err != nil || !ok {
+				return err
+			}
+			used = append(used, stored.question())
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return used, nil
+}
+
+func parseUsage(value []byte) (int64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	parsed, err := strconv.ParseInt(string(value), 10, 64)
+	return parsed, err == nil
+}
+
+// RecordServes stores the first time usernameNormalized was served each of
+// questionIDs in quizID, reusing the attempts bucket's key layout.
+func (s *BoltStore) RecordServes(_ context.Context, quizID, usernameNormalized string, questionIDs []string, servedAt time.Time) error {
+	value := []byte(strconv.FormatInt(servedAt.UTC().UnixNano(), 10))
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		quizServes, err := tx.Bucket(servesBucket).CreateBucketIfNotExists([]byte(quizID))
+		if err != nil {
+			return err
+		}
+		for _, questionID := range questionIDs {
+			key := attemptKey(usernameNormalized, questionID)
+			if quizServes.Get(key) != nil {
+				continue
+			}
+			if err := quizServes.Put(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) ServeTimes(_ context.Context, quizID, usernameNormalized string) (map[string]time.Time, error) {
+	served := make(map[string]time.Time)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		quizServes := tx.Bucket(servesBucket).Bucket([]byte(quizID))
+		if quizServes == nil {
+			return nil
+		}
+		prefix := []byte(usernameNormalized + attemptSeparator)
+		cursor := quizServes.Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			servedAtNs, ok := parseUsage(value)
+			if !ok {
+				continue
+			}
+			served[string(key[len(prefix):])] = time.Unix(0, servedAtNs).UTC()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return served, nil
+}
+
+type serveLogRecord struct {
+	FirstServedAtUnix     int64 `json:"first_served_at_unix"`
+	LastServedAtUnix      int64 `json:"last_served_at_unix"`
+	FetchCount            int   `json:"fetch_count"`
+	AnswerKeyServedAtUnix int64 `json:"answer_key_served_at_unix,omitempty"`
+	NoncesIssued          int   `json:"nonces_issued,omitempty"`
+}
+
+// LogServe folds one fetch into the user's serving log record for quizID.
+// Timestamps are unix nanoseconds.
+func (s *BoltStore) LogServe(_ context.Context, quizID, usernameNormalized string, servedAt time.Time, withAnswerKey bool, noncesIssued int) error {
+	servedAtNs := servedAt.UTC().UnixNano()
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		quizLog, err := tx.Bucket(serveLogBucket).CreateBucketIfNotExists([]byte(quizID))
+		if err != nil {
+			return err
+		}
+		record := serveLogRecord{FirstServedAtUnix: servedAtNs}
+		if raw := quizLog.Get([]byte(usernameNormalized)); raw != nil {
+			if err := json.Unmarshal(raw, &record); err != nil {
+				return err
+			}
+		}
+		record.LastServedAtUnix = max(record.LastServedAtUnix, servedAtNs)
+		record.FetchCount++
+		record.NoncesIssued += noncesIssued
+		if withAnswerKey && record.AnswerKeyServedAtUnix == 0 {
+			record.AnswerKeyServedAtUnix = servedAtNs
+		}
+		raw, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return quizLog.Put([]byte(usernameNormalized), raw)
+	})
+}
+
+func (s *BoltStore) ListServeLog(_ context.Context, quizID string) ([]quiz.ServeLogEntry, error) {
+	entries := make([]quiz.ServeLogEntry, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		quizLog := tx.Bucket(serveLogBucket).Bucket([]byte(quizID))
+		if quizLog == nil {
+			return nil
+		}
+		return quizLog.ForEach(func(key, value []byte) error {
+			var record serveLogRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			entry := quiz.ServeLogEntry{
+				Username:      string(key),
+				FirstServedAt: time.Unix(0, record.FirstServedAtUnix).UTC(),
+				LastServedAt:  time.Unix(0, record.LastServedAtUnix).UTC(),
+				FetchCount:    record.FetchCount,
+				NoncesIssued:  record.NoncesIssued,
+			}
+			if record.AnswerKeyServedAtUnix != 0 {
+				entry.AnswerKeyServedAt = time.Unix(0, record.AnswerKeyServedAtUnix).UTC()
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,164 @@
+package quiz
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// CacheOptions bounds the service's in-memory caches of quizzes, leaderboards,
+// and attempt scores. The zero value keeps every entry until the quiz is
+// invalidated or its scoring changes, matching NewService.
+type CacheOptions struct {
+	// MaxQuizzes caps the quizzes whose metadata and questions are cached.
+	// MaxLeaderboards caps cached leaderboards, counting each category
+	// leaderboard separately. MaxAttemptScores caps cached per-player scores.
+	// The least recently used entry is evicted first; zero means unbounded.
+	MaxQuizzes       int
+	MaxLeaderboards  int
+	MaxAttemptScores int
+	// TTL reloads entries from the store once they are this old, however
+	// often they are read. Changes made through the service invalidate its
+	// entries at once; TTL is the fallback for changes made by other
+	// processes sharing the store. Zero disables expiry.
+	TTL time.Duration
+}
+
+// CacheStats is a point-in-time view of one cache.
+type CacheStats struct {
+	Size        int   `json:"size"`
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Evictions   int64 `json:"evictions"`
+	Expirations int64 `json:"expirations"`
+}
+
+// lruCache is a string-keyed cache bounded by entry count and age. It is safe
+// for concurrent use. It takes a plain mutex rather than an RWMutex because
+// every hit moves the entry to the front of the recency list, so reads write
+// too.
+//
+// Values are handed to callbacks under the lock (see with and each) so that
+// mutable values such as leaderboards can be patched in place; callbacks must
+// not call back into the same cache.
+type lruCache[V any] struct {
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recency orders entries most recently used first.
+	recency *list.List
+	stats   CacheStats
+}
+
+type lruEntry[V any] struct {
+	key      string
+	value    V
+	storedAt time.Time
+}
+
+func newLRUCache[V any](maxEntries int, ttl time.Duration, now func() time.Time) *lruCache[V] {
+	return &lruCache[V]{
+		maxEntries: max(maxEntries, 0),
+		ttl:        max(ttl, 0),
+		now:        now,
+		entries:    make(map[string]*list.Element),
+		recency:    list.New(),
+	}
+}
+
+func (c *lruCache[V]) get(key string) (V, bool) {
+	var value V
+	ok := c.with(key, func(cached V) { value = cached })
+	return value, ok
+}
+
+// with calls fn with key's value under the lock and reports whether the key
+// was cached.
+func (c *lruCache[V]) with(key string, fn func(V)) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if ok && c.expiredLocked(element) {
+		c.removeLocked(element)
+		c.stats.Expirations++
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return false
+	}
+	c.stats.Hits++
+	c.recency.MoveToFront(element)
+	fn(element.Value.(*lruEntry[V]).value)
+	return true
+}
+
+// put stores value under key, evicting the least recently used entries past
+// the cap. Replacing a value restarts its TTL.
+func (c *lruCache[V]) put(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &lruEntry[V]{key: key, value: value, storedAt: c.now()}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.recency.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.recency.PushFront(entry)
+	for c.maxEntries > 0 && len(c.entries) > c.maxEntries {
+		c.removeLocked(c.recency.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *lruCache[V]) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.removeLocked(element)
+	}
+}
+
+// each calls fn with every live entry under the lock, without counting as a
+// use, and removes the entries fn returns false for.
+func (c *lruCache[V]) each(fn func(key string, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for element := c.recency.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*lruEntry[V])
+		switch {
+		case c.expiredLocked(element):
+			c.removeLocked(element)
+			c.stats.Expirations++
+		case !fn(entry.key, entry.value):
+			c.removeLocked(element)
+		}
+		element = next
+	}
+}
+
+func (c *lruCache[V]) snapshot() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = len(c.entries)
+	return stats
+}
+
+func (c *lruCache[V]) expiredLocked(element *list.Element) bool {
+	return c.ttl > 0 && c.now().Sub(element.Value.(*lruEntry[V]).storedAt) >= c.ttl
+}
+
+func (c *lruCache[V]) removeLocked(element *list.Element) {
+	c.recency.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry[V]).key)
+}
@@ -0,0 +1,108 @@
+package quiz
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRUCacheEvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cache := newLRUCache[int](2, time.Minute, func() time.Time { return now })
+
+	cache.put("a", 1)
+	cache.put("b", 2)
+	if _, ok := cache.get("a"); !ok {
+		t.Fatalf("a missing before the cache is full")
+	}
+	// b is now the least recently used, so c pushes it out.
+	cache.put("c", 3)
+	if _, ok := cache.get("b"); ok {
+		t.Fatalf("b still cached, want it evicted")
+	}
+	if value, ok := cache.get("a"); !ok || value != 1 {
+		t.Fatalf("get(a) = (%d, %t), want 1", value, ok)
+	}
+
+	// Reads do not extend the TTL; replacing a value does.
+	now = now.Add(50 * time.Second)
+	cache.put("c", 30)
+	now = now.Add(20 * time.Second)
+	if _, ok := cache.get("a"); ok {
+		t.Fatalf("a still cached after its TTL")
+	}
+	if value, ok := cache.get("c"); !ok || value != 30 {
+		t.Fatalf("get(c) = (%d, %t), want the replaced value within its TTL", value, ok)
+	}
+
+	stats := cache.snapshot()
+	if stats.Size != 1 || stats.Evictions != 1 || stats.Expirations != 1 || stats.Hits != 3 || stats.Misses != 2 {
+		t.Fatalf("stats = %+v, want 1 entry, 1 eviction, 1 expiration, 3 hits, 2 misses", stats)
+	}
+}
+
+func TestServiceLeaderboardCacheIsBounded(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	repo.metadataByQuiz["quiz-2"] = QuizMetadata{QuizID: "quiz-2"}
+	attempts := &fakeAttemptRepo{leaderboard: []LeaderboardEntry{{Username: "alice", TotalScore: 1, AnsweredCount: 1}}}
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{Cache: CacheOptions{MaxLeaderboards: 1}})
+
+	ctx := context.Background()
+	for _, quizID := range []string{"quiz-1", "quiz-1", "quiz-2", "quiz-1"} {
+		if _, err := service.GetLeaderboard(ctx, quizID, 0); err != nil {
+			t.Fatalf("GetLeaderboard(%s) failed: %v", quizID, err)
+		}
+	}
+	// quiz-1 is served from the cache once, then reloaded after quiz-2
+	// took its place.
+	if attempts.leaderboardCalls != 3 {
+		t.Fatalf("store leaderboard reads = %d, want 3", attempts.leaderboardCalls)
+	}
+	if stats := service.CacheStats()["leaderboards"]; stats.Size != 1 || stats.Evictions != 2 {
+		t.Fatalf("leaderboard cache stats = %+v, want 1 entry after 2 evictions", stats)
+	}
+}
+
+// TestServiceCachesAreSafeForConcurrentUse is meant for go test -race: it
+// patches and reads the same cached leaderboard and scores from many
+// goroutines, as concurrent submissions and leaderboard requests do.
+func TestServiceCachesAreSafeForConcurrentUse(t *testing.T) {
+	service := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, nil)
+	service.setCachedLeaderboard("quiz-1", nil, "")
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		username := fmt.Sprintf("user-%d", worker)
+		service.setCachedAttemptScores("quiz-1", username, nil)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := 0; idx < 100; idx++ {
+				results := []ResponseResult{{QuestionID: fmt.Sprintf("q%d", idx), Status: StatusCorrect}}
+				service.updateCachedLeaderboardAfterSubmission("quiz-1", username, results)
+				service.updateCachedAttemptScoresAfterSubmission("quiz-1", username, results)
+				entries, _ := service.getCachedLeaderboard("quiz-1")
+				for _, entry := range entries {
+					_ = entry.TotalScore
+				}
+				scores, _ := service.getCachedAttemptScores("quiz-1", username)
+				for range scores {
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, ok := service.getCachedLeaderboard("quiz-1")
+	if !ok || len(entries) != 8 {
+		t.Fatalf("cached leaderboard = %+v, want 8 players", entries)
+	}
+	for _, entry := range entries {
+		if entry.TotalScore != 100 || entry.AnsweredCount != 100 {
+			t.Fatalf("entry = %+v, want 100 correct answers", entry)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// CreateAccount relies on the users primary key, so two replicas registering
+// the same username at once store one account and report ErrAccountExists to
+// the other.
+func (s *PostgresStore) CreateAccount(ctx context.Context, account quiz.Account) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO users (username_norm, password_hash, created_at_unix)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (username_norm) DO NOTHING`,
+		account.Username,
+		account.PasswordHash,
+		account.CreatedAt.UnixNano(),
+	)
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return quiz.ErrAccountExists
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetAccount(ctx context.Context, usernameNormalized string) (quiz.Account, error) {
+	account := quiz.Account{Username: usernameNormalized}
+	var createdAtUnix int64
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT password_hash, created_at_unix FROM users WHERE username_norm = $1`,
+		usernameNormalized,
+	).Scan(&account.PasswordHash, &createdAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.Account{}, nil
+	}
+	if err != nil {
+		return quiz.Account{}, err
+	}
+	account.CreatedAt = time.Unix(0, createdAtUnix).UTC()
+	return account, nil
+}
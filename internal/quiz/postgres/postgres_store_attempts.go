@@ -0,0 +1,322 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"quiz-app/internal/quiz"
+	"quiz-app/pkg/quizkit"
+)
+
+// SubmitResponses runs as a single transaction, with the same invariants as
+// the SQLite store: (quiz_id, question_id, username_norm) is unique, and an
+// existing attempt is never overwritten. Replicas submitting the same answer
+// at once are resolved by the primary key: INSERT ... ON CONFLICT DO NOTHING
+// waits for the other insert, and the loser reads back the stored score.
+func (s *PostgresStore) SubmitResponses(ctx context.Context, quizID, usernameNormalized string, responses []quiz.SubmittedResponse) ([]quiz.ResponseResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT `+questionColumns+`, qq.voided_at_unix IS NOT NULL
+		 FROM quiz_questions qq
+		 JOIN questions q ON q.question_id = qq.question_id
+		 WHERE qq.quiz_id = $1`,
+		quizID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	questionLookup := make(map[string]quiz.Question)
+	for rows.Next() {
+		var question quiz.Question
+		if err := scanQuestion(rows, &question, &question.Voided); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		questionLookup[question.QuestionID] = question
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	_ = rows.Close()
+
+	if len(questionLookup) == 0 {
+		return nil, quiz.ErrQuizNotFound
+	}
+
+	results := make([]quiz.ResponseResult, 0, len(responses))
+	for _, response := range responses {
+		question, ok := questionLookup[response.QuestionID]
+		if !ok {
+			results = append(results, quiz.ResponseResult{QuestionID: response.QuestionID, Status: quiz.StatusInvalidQuestion})
+			continue
+		}
+		status, credit := quizkit.GradeAnswer(question, response.Answer)
+		if status == quiz.StatusVoidedQuestion || status == quiz.StatusInvalidLetter {
+			results = append(results, quiz.ResponseResult{QuestionID: response.QuestionID, Status: status})
+			continue
+		}
+		chosen, _ := question.ParseAnswer(response.Answer)
+		letter := quizkit.FormatLetters(chosen)
+
+		score := 0.0
+		bonus := 0.0
+		switch status {
+		case quiz.StatusCorrect:
+			bonus = response.Bonus
+			score = 1.0 + bonus
+			credit = 0
+		case quiz.StatusPartiallyCorrect:
+			score = credit
+		}
+		var attemptScore *float64
+
+		insertResult, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO attempts (quiz_id, question_id, username_norm, answer_letter, score, submitted_at_unix, submitted_by)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 ON CONFLICT DO NOTHING`,
+			quizID,
+			response.QuestionID,
+			usernameNormalized,
+			letter,
+			score,
+			time.Now().UTC().UnixNano(),
+			response.SubmittedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		inserted, err := insertResult.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if inserted == 0 {
+			// Duplicate answer for (quiz, question, user): keep the original row
+			// and return its score for consistent client reconciliation.
+			status = quiz.StatusAlreadyAnswered
+			bonus = 0
+			credit = 0
+
+			var existingScore float64
+			if err := tx.QueryRowContext(
+				ctx,
+				`SELECT score FROM attempts WHERE quiz_id = $1 AND question_id = $2 AND username_norm = $3`,
+				quizID,
+				response.QuestionID,
+				usernameNormalized,
+			).Scan(&existingScore); err != nil {
+				return nil, err
+			}
+			attemptScore = &existingScore
+		}
+
+		results = append(results, quiz.ResponseResult{
+			QuestionID:   response.QuestionID,
+			Status:       status,
+			AttemptScore: attemptScore,
+			Credit:       credit,
+			Bonus:        bonus,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetLeaderboard returns every entry, ordered like quizkit.RanksBeforeBy:
+// highest total first, then the earliest finish, then the username. Totals of
+// an archived quiz come from attempt_summaries.
+func (s *PostgresStore) GetLeaderboard(ctx context.Context, quizID string) ([]quiz.LeaderboardEntry, error) {
+	exists, err := s.QuizExists(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, quiz.ErrQuizNotFound
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT username_norm, SUM(score) AS total_score, SUM(answered) AS answered_count, MAX(submitted_at_unix) AS last_submission
+		 FROM (
+			SELECT a.username_norm, a.score, 1 AS answered, a.submitted_at_unix
+			FROM attempts a
+			JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+			WHERE a.quiz_id = $1 AND qq.voided_at_unix IS NULL
+			UNION ALL
+			SELECT username_norm, total_score, answered_count, last_submitted_at_unix
+			FROM attempt_summaries
+			WHERE quiz_id = $1
+		 ) totals
+		 GROUP BY username_norm
+		 ORDER BY total_score DESC, last_submission ASC, username_norm ASC`,
+		quizID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaderboard := make([]quiz.LeaderboardEntry, 0)
+	for rows.Next() {
+		var (
+			entry            quiz.LeaderboardEntry
+			lastSubmissionNs int64
+		)
+		if err := rows.Scan(&entry.Username, &entry.TotalScore, &entry.AnsweredCount, &lastSubmissionNs); err != nil {
+			return nil, err
+		}
+		entry.LastSubmissionAt = time.Unix(0, lastSubmissionNs).UTC()
+		leaderboard = append(leaderboard, entry)
+	}
+	return leaderboard, rows.Err()
+}
+
+func (s *PostgresStore) GetAttemptScores(ctx context.Context, quizID, usernameNormalized string) (map[string]float64, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT a.question_id, a.score
+		 FROM attempts a
+		 JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+		 WHERE a.quiz_id = $1 AND a.username_norm = $2 AND qq.voided_at_unix IS NULL`,
+		quizID,
+		usernameNormalized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := make(map[string]float64)
+	for rows.Next() {
+		var (
+			questionID string
+			score      float64
+		)
+		if err := rows.Scan(&questionID, &score); err != nil {
+			return nil, err
+		}
+		scores[questionID] = score
+	}
+	return scores, rows.Err()
+}
+
+// ListAttempts orders by seq after the timestamp so answers stored in the same
+// nanosecond keep their insert order.
+func (s *PostgresStore) ListAttempts(ctx context.Context, quizID, usernameNormalized string) ([]quiz.Attempt, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT a.question_id, a.answer_letter, a.score, a.submitted_at_unix, a.submitted_by
+		 FROM attempts a
+		 JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+		 WHERE a.quiz_id = $1 AND a.username_norm = $2 AND qq.voided_at_unix IS NULL
+		 ORDER BY a.submitted_at_unix ASC, a.seq ASC`,
+		quizID,
+		usernameNormalized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := make([]quiz.Attempt, 0)
+	for rows.Next() {
+		var (
+			attempt         quiz.Attempt
+			submittedAtUnix int64
+		)
+		if err := rows.Scan(&attempt.QuestionID, &attempt.AnswerLetter, &attempt.Score, &submittedAtUnix, &attempt.SubmittedBy); err != nil {
+			return nil, err
+		}
+		attempt.SubmittedAt = time.Unix(0, submittedAtUnix).UTC()
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+// GetUserAttempts totals the user's attempts per quiz through
+// idx_attempts_user, adding the summaries of archived quizzes through
+// idx_attempt_summaries_user.
+func (s *PostgresStore) GetUserAttempts(ctx context.Context, usernameNormalized string) ([]quiz.AttemptSummary, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT quiz_id, SUM(score), SUM(answered), SUM(correct), MIN(first_at), MAX(last_at) AS last_submission, SUM(archived)
+		 FROM (
+			SELECT a.quiz_id, a.score, 1 AS answered, CASE WHEN a.score >= 1 THEN 1 ELSE 0 END AS correct, a.submitted_at_unix AS first_at, a.submitted_at_unix AS last_at, 0 AS archived
+			FROM attempts a
+			JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+			WHERE a.username_norm = $1 AND qq.voided_at_unix IS NULL
+			UNION ALL
+			SELECT quiz_id, total_score, answered_count, correct_count, first_submitted_at_unix, last_submitted_at_unix, answered_count
+			FROM attempt_summaries
+			WHERE username_norm = $1
+		 ) totals
+		 GROUP BY quiz_id
+		 ORDER BY last_submission DESC, quiz_id ASC`,
+		usernameNormalized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]quiz.AttemptSummary, 0)
+	for rows.Next() {
+		var (
+			summary         quiz.AttemptSummary
+			firstAt, lastAt int64
+		)
+		if err := rows.Scan(&summary.QuizID, &summary.TotalScore, &summary.AnsweredCount, &summary.CorrectCount, &firstAt, &lastAt, &summary.ArchivedCount); err != nil {
+			return nil, err
+		}
+		summary.Username = usernameNormalized
+		summary.FirstSubmittedAt = time.Unix(0, firstAt).UTC()
+		summary.LastSubmittedAt = time.Unix(0, lastAt).UTC()
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+// VoidQuestion marks questionID as voided within quizID. Attempts stay in the
+// table so the void can be audited, but every scoring query filters them out.
+func (s *PostgresStore) VoidQuestion(ctx context.Context, quizID, questionID string, voidedAt time.Time) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE quiz_questions
+		 SET voided_at_unix = COALESCE(voided_at_unix, $1)
+		 WHERE quiz_id = $2 AND question_id = $3`,
+		voidedAt.UTC().UnixNano(),
+		quizID,
+		questionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		exists, err := s.QuizExists(ctx, quizID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return quiz.ErrQuizNotFound
+		}
+		return quiz.ErrQuestionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,437 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *PostgresStore) CreateQuiz(ctx context.Context, metadata quiz.QuizMetadata, questions []quiz.Question) error {
+	if metadata.QuizID == "" {
+		return errors.New("quiz id is required")
+	}
+
+	if metadata.QuestionCount <= 0 {
+		metadata.QuestionCount = len(questions)
+	}
+
+	if metadata.CreatedAt.IsZero() {
+		metadata.CreatedAt = time.Now().UTC()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quiz_questions WHERE quiz_id = $1`, metadata.QuizID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM attempts WHERE quiz_id = $1`, metadata.QuizID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM attempt_summaries WHERE quiz_id = $1`, metadata.QuizID); err != nil {
+		return err
+	}
+
+	mixJSON, err := encodeDifficultyMix(metadata.Origin.DifficultyMix)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO quizzes (quiz_id, created_at_unix, question_count, requested_question_count, locked, draft, closes_at_unix, practice, adaptive, seconds_per_question, provider, difficulty_mix_json, seed, fallback_from, category, question_type)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		 ON CONFLICT (quiz_id) DO UPDATE SET
+			created_at_unix = excluded.created_at_unix,
+			question_count = excluded.question_count,
+			requested_question_count = excluded.requested_question_count,
+			locked = excluded.locked,
+			draft = excluded.draft,
+			closes_at_unix = excluded.closes_at_unix,
+			practice = excluded.practice,
+			adaptive = excluded.adaptive,
+			seconds_per_question = excluded.seconds_per_question,
+			provider = excluded.provider,
+			difficulty_mix_json = excluded.difficulty_mix_json,
+			seed = excluded.seed,
+			fallback_from = excluded.fallback_from,
+			category = excluded.category,
+			question_type = excluded.question_type,
+			archived_at_unix = NULL`,
+		metadata.QuizID,
+		metadata.CreatedAt.UnixNano(),
+		metadata.QuestionCount,
+		metadata.RequestedQuestionCount,
+		metadata.Locked,
+		metadata.Draft,
+		nullableUnixNano(metadata.ClosesAt),
+		metadata.Practice,
+		metadata.Adaptive,
+		int64(metadata.QuestionTimeLimit/time.Second),
+		metadata.Origin.Provider,
+		mixJSON,
+		metadata.Origin.Seed,
+		metadata.Origin.FallbackFrom,
+		metadata.Origin.Category,
+		metadata.Origin.QuestionType,
+	)
+	if err != nil {
+		return err
+	}
+
+	for idx := range questions {
+		question := questions[idx]
+		if question.QuestionID == "" {
+			question.QuestionID = quiz.MakeQuestionID(question)
+		}
+
+		if err := upsertQuestion(ctx, tx, question, metadata.CreatedAt); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO quiz_questions (quiz_id, question_id, position) VALUES ($1, $2, $3)`,
+			metadata.QuizID,
+			question.QuestionID,
+			idx,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// upsertQuestion stores question, or checks it against the stored row with the
+// same ID, like the SQLite store: a copy with other text, answer key, feedback,
+// or translations fails with quiz.ErrQuestionConflict, and difficulty,
+// category, and type are only filled in where the stored row has none.
+func upsertQuestion(ctx context.Context, tx *sql.Tx, question quiz.Question, createdAt time.Time) error {
+	optionsJSON, err := json.Marshal(question.Options)
+	if err != nil {
+		return err
+	}
+	feedbackJSON, err := encodeJSON(question.Feedback, len(question.Feedback))
+	if err != nil {
+		return err
+	}
+	translationsJSON, err := encodeJSON(question.Translations, len(question.Translations))
+	if err != nil {
+		return err
+	}
+	// Only multi-select questions have more than correct_index to store.
+	var correctIndexes []int
+	if question.Type == quiz.TypeMultiSelect {
+		correctIndexes = question.CorrectIndexes
+	}
+	correctIndexesJSON, err := encodeJSON(correctIndexes, len(correctIndexes))
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO questions (question_id, prompt, options_json, correct_index, option_count, source, created_at_unix, feedback_json, difficulty, translations_json, category, question_type, correct_indexes_json)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 ON CONFLICT (question_id) DO UPDATE SET
+			difficulty = CASE WHEN questions.difficulty <> '' THEN questions.difficulty ELSE excluded.difficulty END,
+			category = CASE WHEN questions.category <> '' THEN questions.category ELSE excluded.category END,
+			question_type = CASE WHEN questions.question_type <> '' THEN questions.question_type ELSE excluded.question_type END
+		 WHERE questions.prompt = excluded.prompt
+			AND questions.options_json = excluded.options_json
+			AND questions.correct_index = excluded.correct_index
+			AND questions.correct_indexes_json IS NOT DISTINCT FROM excluded.correct_indexes_json
+			AND (excluded.feedback_json IS NULL OR excluded.feedback_json = questions.feedback_json)
+			AND (excluded.translations_json IS NULL OR excluded.translations_json = questions.translations_json)`,
+		question.QuestionID,
+		question.Question,
+		string(optionsJSON),
+		question.CorrectIndex,
+		len(question.Options),
+		question.Source,
+		createdAt.UnixNano(),
+		feedbackJSON,
+		string(question.Difficulty),
+		translationsJSON,
+		question.Category,
+		string(question.Type),
+		correctIndexesJSON,
+	)
+	if err != nil {
+		return err
+	}
+	stored, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if stored == 0 {
+		return fmt.Errorf("%w: %s", quiz.ErrQuestionConflict, question.QuestionID)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetQuizMetadata(ctx context.Context, quizID string) (quiz.QuizMetadata, error) {
+	metadata, err := scanQuizMetadata(s.db.QueryRowContext(
+		ctx,
+		`SELECT `+quizMetadataColumns+` FROM quizzes WHERE quiz_id = $1`,
+		quizID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return quiz.QuizMetadata{}, quiz.ErrQuizNotFound
+		}
+		return quiz.QuizMetadata{}, err
+	}
+	return metadata, nil
+}
+
+const quizMetadataColumns = `quiz_id, question_count, requested_question_count, created_at_unix, locked, draft, closes_at_unix, practice, adaptive, seconds_per_question, provider, difficulty_mix_json, seed, fallback_from, category, question_type, archived_at_unix`
+
+// scanQuizMetadata reads one row selected with quizMetadataColumns.
+func scanQuizMetadata(row interface{ Scan(...any) error }) (quiz.QuizMetadata, error) {
+	var (
+		metadata           quiz.QuizMetadata
+		createdAtUnix      int64
+		closesAtUnix       sql.NullInt64
+		secondsPerQuestion int64
+		archivedAtUnix     sql.NullInt64
+		mixJSON            sql.NullString
+	)
+	if err := row.Scan(
+		&metadata.QuizID, &metadata.QuestionCount, &metadata.RequestedQuestionCount, &createdAtUnix, &metadata.Locked, &metadata.Draft, &closesAtUnix, &metadata.Practice, &metadata.Adaptive, &secondsPerQuestion,
+		&metadata.Origin.Provider, &mixJSON, &metadata.Origin.Seed, &metadata.Origin.FallbackFrom, &metadata.Origin.Category, &metadata.Origin.QuestionType, &archivedAtUnix,
+	); err != nil {
+		return quiz.QuizMetadata{}, err
+	}
+	if mixJSON.Valid {
+		if err := json.Unmarshal([]byte(mixJSON.String), &metadata.Origin.DifficultyMix); err != nil {
+			return quiz.QuizMetadata{}, err
+		}
+	}
+
+	metadata.CreatedAt = time.Unix(0, createdAtUnix).UTC()
+	metadata.QuestionTimeLimit = time.Duration(secondsPerQuestion) * time.Second
+	if closesAtUnix.Valid {
+		metadata.ClosesAt = time.Unix(0, closesAtUnix.Int64).UTC()
+	}
+	if archivedAtUnix.Valid {
+		metadata.ArchivedAt = time.Unix(0, archivedAtUnix.Int64).UTC()
+	}
+	return metadata, nil
+}
+
+func (s *PostgresStore) QuizExists(ctx context.Context, quizID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM quizzes WHERE quiz_id = $1)`, quizID).Scan(&exists)
+	return exists, err
+}
+
+func (s *PostgresStore) GetQuizQuestions(ctx context.Context, quizID string) ([]quiz.Question, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+questionColumns+`, qq.voided_at_unix IS NOT NULL
+		 FROM quiz_questions qq
+		 JOIN questions q ON q.question_id = qq.question_id
+		 WHERE qq.quiz_id = $1
+		 ORDER BY qq.position ASC`,
+		quizID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := make([]quiz.Question, 0)
+	for rows.Next() {
+		var question quiz.Question
+		if err := scanQuestion(rows, &question, &question.Voided); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(questions) == 0 {
+		exists, err := s.QuizExists(ctx, quizID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, quiz.ErrQuizNotFound
+		}
+	}
+	return questions, nil
+}
+
+func (s *PostgresStore) ListActiveQuizzes(ctx context.Context, limit int) ([]quiz.QuizMetadata, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+quizMetadataColumns+`
+		 FROM quizzes
+		 WHERE archived_at_unix IS NULL
+		 ORDER BY created_at_unix DESC
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	active := make([]quiz.QuizMetadata, 0)
+	for rows.Next() {
+		item, err := scanQuizMetadata(rows)
+		if err != nil {
+			return nil, err
+		}
+		active = append(active, item)
+	}
+	return active, rows.Err()
+}
+
+func (s *PostgresStore) SetQuizLifecycle(ctx context.Context, quizID string, lifecycle quiz.QuizLifecycle) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE quizzes SET draft = $1, locked = $2, closes_at_unix = $3 WHERE quiz_id = $4`,
+		lifecycle.Draft,
+		lifecycle.Locked,
+		nullableUnixNano(lifecycle.ClosesAt),
+		quizID,
+	)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return quiz.ErrQuizNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) LookupQuestions(ctx context.Context, questionIDs []string) ([]quiz.Question, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+questionColumns+` FROM questions q WHERE q.question_id = ANY($1)`,
+		pq.Array(questionIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := make([]quiz.Question, 0, len(questionIDs))
+	for rows.Next() {
+		var question quiz.Question
+		if err := scanQuestion(rows, &question); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, rows.Err()
+}
+
+// SampleQuestions orders the questions table by random(), which scans it; it
+// runs only when the provider has failed, not on every quiz.
+func (s *PostgresStore) SampleQuestions(ctx context.Context, limit int) ([]quiz.Question, error) {
+	if limit <= 0 {
+		return []quiz.Question{}, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT `+questionColumns+` FROM questions q ORDER BY random() LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := make([]quiz.Question, 0, limit)
+	for rows.Next() {
+		var question quiz.Question
+		if err := scanQuestion(rows, &question); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, rows.Err()
+}
+
+const questionColumns = `q.question_id, q.prompt, q.options_json, q.correct_index, q.feedback_json, q.difficulty, q.translations_json, q.category, q.question_type, q.correct_indexes_json, q.source`
+
+// scanQuestion reads one row selected with questionColumns into question,
+// followed by any extra columns.
+func scanQuestion(row interface{ Scan(...any) error }, question *quiz.Question, extra ...any) error {
+	var (
+		optionsJSON      string
+		feedbackJSON     sql.NullString
+		difficulty       string
+		translationsJSON sql.NullString
+		kind             string
+		correctJSON      sql.NullString
+	)
+	dest := append([]any{&question.QuestionID, &question.Question, &optionsJSON, &question.CorrectIndex, &feedbackJSON, &difficulty, &translationsJSON, &question.Category, &kind, &correctJSON, &question.Source}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	question.Difficulty = quiz.Difficulty(difficulty)
+	question.Type = quiz.QuestionKind(kind)
+	if correctJSON.Valid {
+		if err := json.Unmarshal([]byte(correctJSON.String), &question.CorrectIndexes); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal([]byte(optionsJSON), &question.Options); err != nil {
+		return err
+	}
+	if feedbackJSON.Valid {
+		if err := json.Unmarshal([]byte(feedbackJSON.String), &question.Feedback); err != nil {
+			return err
+		}
+	}
+	if translationsJSON.Valid {
+		if err := json.Unmarshal([]byte(translationsJSON.String), &question.Translations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeDifficultyMix stores quizzes without a mix as NULL.
+func encodeDifficultyMix(mix quiz.DifficultyMix) (any, error) {
+	return encodeJSON(mix, len(mix))
+}
+
+// encodeJSON stores empty values as NULL so upserts can keep earlier ones.
+func encodeJSON(value any, length int) (any, error) {
+	if length == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func nullableUnixNano(value time.Time) any {
+	if value.IsZero() {
+		return nil
+	}
+	return value.UnixNano()
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// quizTables lists every table keyed by quiz_id, for DeleteQuiz. quizzes
+// and quiz_questions go last; DeleteQuiz reads quiz_questions first.
+var quizTables = []string{
+	"attempts",
+	"attempt_summaries",
+	"quiz_questions",
+	"quizzes",
+}
+
+// DeleteQuiz counts the attempts it deletes; summaries of archived ones go
+// uncounted. The quiz row is locked first, so a replica archiving or
+// re-creating the quiz at the same time waits for the delete.
+func (s *PostgresStore) DeleteQuiz(ctx context.Context, quizID string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := lockQuiz(ctx, tx, quizID); err != nil {
+		return 0, err
+	}
+	// Questions are shared by content, so only those nothing else refers to
+	// go with the quiz.
+	if _, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM questions
+		 WHERE question_id IN (SELECT question_id FROM quiz_questions WHERE quiz_id = $1)
+			AND question_id NOT IN (SELECT question_id FROM quiz_questions WHERE quiz_id <> $1)
+			AND question_id NOT IN (SELECT question_id FROM question_tags)`,
+		quizID,
+	); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, table := range quizTables {
+		result, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE quiz_id = $1`, quizID)
+		if err != nil {
+			return 0, err
+		}
+		if table == "attempts" {
+			count, err := result.RowsAffected()
+			if err != nil {
+				return 0, err
+			}
+			deleted = int(count)
+		}
+	}
+	return deleted, tx.Commit()
+}
+
+// ArchiveQuiz folds the quiz's attempts into attempt_summaries, as the SQLite
+// store's retention does, and keeps the first archive time.
+func (s *PostgresStore) ArchiveQuiz(ctx context.Context, quizID string, archivedAt time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := lockQuiz(ctx, tx, quizID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO attempt_summaries (quiz_id, username_norm, total_score, answered_count, correct_count, first_submitted_at_unix, last_submitted_at_unix)
+		 SELECT a.quiz_id, a.username_norm, SUM(a.score), COUNT(*), SUM(CASE WHEN a.score >= 1 THEN 1 ELSE 0 END), MIN(a.submitted_at_unix), MAX(a.submitted_at_unix)
+		 FROM attempts a
+		 JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+		 WHERE a.quiz_id = $1 AND qq.voided_at_unix IS NULL
+		 GROUP BY a.quiz_id, a.username_norm
+		 ON CONFLICT (quiz_id, username_norm) DO UPDATE SET
+			total_score = attempt_summaries.total_score + excluded.total_score,
+			answered_count = attempt_summaries.answered_count + excluded.answered_count,
+			correct_count = attempt_summaries.correct_count + excluded.correct_count,
+			first_submitted_at_unix = LEAST(attempt_summaries.first_submitted_at_unix, excluded.first_submitted_at_unix),
+			last_submitted_at_unix = GREATEST(attempt_summaries.last_submitted_at_unix, excluded.last_submitted_at_unix)`,
+		quizID,
+	); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM attempts WHERE quiz_id = $1`, quizID)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE quizzes SET locked = TRUE, archived_at_unix = COALESCE(archived_at_unix, $1) WHERE quiz_id = $2`,
+		archivedAt.UnixNano(),
+		quizID,
+	); err != nil {
+		return 0, err
+	}
+	return int(deleted), tx.Commit()
+}
+
+// lockQuiz takes the quiz row for the rest of tx, or returns ErrQuizNotFound.
+func lockQuiz(ctx context.Context, tx *sql.Tx, quizID string) error {
+	var found int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM quizzes WHERE quiz_id = $1 FOR UPDATE`, quizID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.ErrQuizNotFound
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+)
+
+// schemaLockID keys the advisory lock that serializes schema setup, so
+// replicas starting together do not race on CREATE TABLE IF NOT EXISTS,
+// which PostgreSQL does not make atomic.
+const schemaLockID = 0x71756978 // "quix"
+
+func (s *PostgresStore) initSchema(ctx context.Context) error {
+	// Like the SQLite schema, no FK constraints: quiz overwrite/reset flows are
+	// controlled by application transactions. Timestamps are Unix nanoseconds
+	// so both SQL stores read them the same way.
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS quizzes (
+			quiz_id TEXT PRIMARY KEY,
+			created_at_unix BIGINT NOT NULL,
+			question_count INTEGER NOT NULL,
+			requested_question_count INTEGER NOT NULL DEFAULT 0,
+			locked BOOLEAN NOT NULL DEFAULT FALSE,
+			draft BOOLEAN NOT NULL DEFAULT FALSE,
+			closes_at_unix BIGINT,
+			practice BOOLEAN NOT NULL DEFAULT FALSE,
+			adaptive BOOLEAN NOT NULL DEFAULT FALSE,
+			seconds_per_question INTEGER NOT NULL DEFAULT 0,
+			provider TEXT NOT NULL DEFAULT '',
+			difficulty_mix_json TEXT,
+			seed BIGINT NOT NULL DEFAULT 0,
+			fallback_from TEXT NOT NULL DEFAULT '',
+			category INTEGER NOT NULL DEFAULT 0,
+			question_type TEXT NOT NULL DEFAULT '',
+			archived_at_unix BIGINT
+		)`,
+		`CREATE TABLE IF NOT EXISTS questions (
+			question_id TEXT PRIMARY KEY,
+			prompt TEXT NOT NULL,
+			options_json TEXT NOT NULL,
+			correct_index INTEGER NOT NULL,
+			option_count INTEGER NOT NULL,
+			source TEXT NOT NULL,
+			created_at_unix BIGINT NOT NULL,
+			feedback_json TEXT,
+			difficulty TEXT NOT NULL DEFAULT '',
+			translations_json TEXT,
+			category TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS quiz_questions (
+			quiz_id TEXT NOT NULL,
+			question_id TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			voided_at_unix BIGINT,
+			PRIMARY KEY (quiz_id, position),
+			UNIQUE (quiz_id, question_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS attempts (
+			quiz_id TEXT NOT NULL,
+			question_id TEXT NOT NULL,
+			username_norm TEXT NOT NULL,
+			answer_letter TEXT NOT NULL,
+			score DOUBLE PRECISION NOT NULL,
+			submitted_at_unix BIGINT NOT NULL,
+			submitted_by TEXT NOT NULL DEFAULT '',
+			-- Insert order, for answers stored in the same nanosecond.
+			seq BIGINT GENERATED ALWAYS AS IDENTITY,
+			PRIMARY KEY (quiz_id, question_id, username_norm)
+		)`,
+		`CREATE TABLE IF NOT EXISTS attempt_summaries (
+			quiz_id TEXT NOT NULL,
+			username_norm TEXT NOT NULL,
+			total_score DOUBLE PRECISION NOT NULL,
+			answered_count INTEGER NOT NULL,
+			correct_count INTEGER NOT NULL,
+			first_submitted_at_unix BIGINT NOT NULL,
+			last_submitted_at_unix BIGINT NOT NULL,
+			PRIMARY KEY (quiz_id, username_norm)
+		)`,
+		`CREATE TABLE IF NOT EXISTS question_tags (
+			question_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (question_id, tag)
+		)`,
+		`CREATE TABLE IF NOT EXISTS users (
+			username_norm TEXT PRIMARY KEY,
+			password_hash TEXT NOT NULL,
+			created_at_unix BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_quizzes_created_at ON quizzes(created_at_unix DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_attempts_question ON attempts(question_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_attempts_quiz_user ON attempts(quiz_id, username_norm)`,
+		`CREATE INDEX IF NOT EXISTS idx_attempts_user ON attempts(username_norm)`,
+		`CREATE INDEX IF NOT EXISTS idx_attempt_summaries_user ON attempt_summaries(username_norm)`,
+		`CREATE INDEX IF NOT EXISTS idx_question_tags_tag ON question_tags(tag)`,
+		// Columns added after the initial schema, for databases created before.
+		`ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS draft BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE questions ADD COLUMN IF NOT EXISTS question_type TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE questions ADD COLUMN IF NOT EXISTS correct_indexes_json TEXT`,
+		`ALTER TABLE quizzes ADD COLUMN IF NOT EXISTS archived_at_unix BIGINT`,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, schemaLockID); err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *PostgresStore) SetQuestionTags(ctx context.Context, questionID string, tags []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkQuestionExists(ctx, tx, questionID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM question_tags WHERE question_id = $1`, questionID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO question_tags (question_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			questionID,
+			tag,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) QuestionTags(ctx context.Context, questionID string) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkQuestionExists(ctx, tx, questionID); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT tag FROM question_tags WHERE question_id = $1 ORDER BY tag ASC`, questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]string, 0)
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// SampleBankQuestions orders the matching questions by random(), like
+// SampleQuestions; a question matches when question_tags holds every tag.
+func (s *PostgresStore) SampleBankQuestions(ctx context.Context, filter quiz.BankFilter, limit int) ([]quiz.Question, error) {
+	if limit <= 0 {
+		return []quiz.Question{}, nil
+	}
+	tags := filter.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+questionColumns+`
+		 FROM questions q
+		 WHERE ($1 = '' OR q.difficulty = $1)
+			AND (cardinality($2::text[]) = 0 OR q.question_id IN (
+				SELECT question_id FROM question_tags
+				WHERE tag = ANY($2)
+				GROUP BY question_id
+				HAVING COUNT(*) = cardinality($2::text[])
+			))
+		 ORDER BY random()
+		 LIMIT $3`,
+		string(filter.Difficulty),
+		pq.Array(tags),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := make([]quiz.Question, 0, limit)
+	for rows.Next() {
+		var question quiz.Question
+		if err := scanQuestion(rows, &question); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, rows.Err()
+}
+
+func checkQuestionExists(ctx context.Context, tx *sql.Tx, questionID string) error {
+	var found int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM questions WHERE question_id = $1`, questionID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.ErrUnknownQuestion
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"quiz-app/internal/quiz"
+	"quiz-app/internal/quiz/storetest"
+)
+
+// newTestPostgresStore opens a store in a fresh schema of the database named
+// by QUIZ_TEST_POSTGRES_DSN, and drops the schema when t ends. Without the
+// variable the test is skipped.
+func newTestPostgresStore(t *testing.T) *PostgresStore {
+	t.Helper()
+
+	dsn := os.Getenv("QUIZ_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("QUIZ_TEST_POSTGRES_DSN not set")
+	}
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open %s: %v", dsn, err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+
+	schema := fmt.Sprintf("quiztest_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { _, _ = admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	store, err := NewPostgresStore(withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("NewPostgresStore failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func withSearchPath(dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&search_path=" + schema
+	}
+	return dsn + "?search_path=" + schema
+}
+
+func TestPostgresStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Store { return newTestPostgresStore(t) })
+}
+
+func TestPostgresStoreVoidsQuestions(t *testing.T) {
+	store := newTestPostgresStore(t)
+	ctx := context.Background()
+
+	questions := []quiz.Question{
+		{PublicQuestion: quiz.PublicQuestion{QuestionID: "q1", Question: "2+2?", Options: []quiz.Option{{Letter: "A", Text: "4"}, {Letter: "B", Text: "3"}}}, CorrectIndex: 0},
+		{PublicQuestion: quiz.PublicQuestion{QuestionID: "q2", Question: "Sky?", Options: []quiz.Option{{Letter: "A", Text: "Green"}, {Letter: "B", Text: "Blue"}}}, CorrectIndex: 1},
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if _, err := store.SubmitResponses(ctx, "quiz-1", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}, {QuestionID: "q2", Answer: "B"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+
+	if err := store.VoidQuestion(ctx, "quiz-1", "q1", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+	if err := store.VoidQuestion(ctx, "quiz-1", "missing", time.Now()); !errors.Is(err, quiz.ErrQuestionNotFound) {
+		t.Fatalf("VoidQuestion(missing) error = %v, want ErrQuestionNotFound", err)
+	}
+
+	entries, err := store.GetLeaderboard(ctx, "quiz-1")
+	if err != nil || len(entries) != 1 || entries[0].TotalScore != 1 || entries[0].AnsweredCount != 1 {
+		t.Fatalf("GetLeaderboard = (%+v, %v), want alice with only q2 counted", entries, err)
+	}
+	attempts, err := store.ListAttempts(ctx, "quiz-1", "alice")
+	if err != nil || len(attempts) != 1 || attempts[0].QuestionID != "q2" {
+		t.Fatalf("ListAttempts = (%+v, %v), want only q2", attempts, err)
+	}
+	results, err := store.SubmitResponses(ctx, "quiz-1", "bob", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}})
+	if err != nil || results[0].Status != quiz.StatusVoidedQuestion {
+		t.Fatalf("answer to a voided question = (%+v, %v), want voided_question", results, err)
+	}
+
+	found, err := store.LookupQuestions(ctx, []string{"q2", "missing"})
+	if err != nil || len(found) != 1 || found[0].QuestionID != "q2" || found[0].CorrectIndex != 1 {
+		t.Fatalf("LookupQuestions = (%+v, %v), want q2 only", found, err)
+	}
+}
+
+func TestPostgresStoreAccountRoundTrip(t *testing.T) {
+	store := newTestPostgresStore(t)
+	ctx := context.Background()
+
+	if account, err := store.GetAccount(ctx, "alice"); err != nil || account.Registered() {
+		t.Fatalf("GetAccount(missing) = (%+v, %v), want unregistered", account, err)
+	}
+
+	createdAt := time.Unix(1700000000, 0).UTC()
+	account := quiz.Account{Username: "alice", PasswordHash: "hash-1", CreatedAt: createdAt}
+	if err := store.CreateAccount(ctx, account); err != nil {
+		t.Fatalf("CreateAccount failed: %v", err)
+	}
+	// A taken username keeps its first account.
+	if err := store.CreateAccount(ctx, quiz.Account{Username: "alice", PasswordHash: "hash-2", CreatedAt: createdAt}); !errors.Is(err, quiz.ErrAccountExists) {
+		t.Fatalf("second CreateAccount = %v, want ErrAccountExists", err)
+	}
+	got, err := store.GetAccount(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetAccount failed: %v", err)
+	}
+	if got.Username != "alice" || got.PasswordHash != "hash-1" || !got.CreatedAt.Equal(createdAt) {
+		t.Fatalf("GetAccount = (%+v), want (%+v)", got, account)
+	}
+}
+
+func TestPostgresStoreQuestionTagsRoundTrip(t *testing.T) {
+	store := newTestPostgresStore(t)
+	ctx := context.Background()
+
+	questions := []quiz.Question{
+		{PublicQuestion: quiz.PublicQuestion{QuestionID: "q1", Question: "2+2?", Options: []quiz.Option{{Letter: "A", Text: "4"}, {Letter: "B", Text: "3"}}}, Difficulty: quiz.DifficultyEasy},
+		{PublicQuestion: quiz.PublicQuestion{QuestionID: "q2", Question: "Sky?", Options: []quiz.Option{{Letter: "A", Text: "Green"}, {Letter: "B", Text: "Blue"}}}, CorrectIndex: 1, Difficulty: quiz.DifficultyHard},
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "q1", []string{"geo", "week-1"}); err != nil {
+		t.Fatalf("SetQuestionTags(q1) failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "q2", []string{"geo"}); err != nil {
+		t.Fatalf("SetQuestionTags(q2) failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "missing", []string{"geo"}); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("SetQuestionTags(missing) = %v, want ErrUnknownQuestion", err)
+	}
+	if tags, err := store.QuestionTags(ctx, "q1"); err != nil || strings.Join(tags, ",") != "geo,week-1" {
+		t.Fatalf("QuestionTags(q1) = (%v, %v), want geo,week-1", tags, err)
+	}
+
+	for _, tc := range []struct {
+		filter quiz.BankFilter
+		want   string
+	}{
+		{quiz.BankFilter{Tags: []string{"geo"}}, "q1,q2"},
+		{quiz.BankFilter{Tags: []string{"geo", "week-1"}}, "q1"},
+		{quiz.BankFilter{Tags: []string{"geo"}, Difficulty: quiz.DifficultyHard}, "q2"},
+		{quiz.BankFilter{Tags: []string{"history"}}, ""},
+		{quiz.BankFilter{}, "q1,q2"},
+	} {
+		sampled, err := store.SampleBankQuestions(ctx, tc.filter, 10)
+		if err != nil {
+			t.Fatalf("SampleBankQuestions(%+v) failed: %v", tc.filter, err)
+		}
+		ids := make([]string, 0, len(sampled))
+		for _, question := range sampled {
+			ids = append(ids, question.QuestionID)
+		}
+		slices.Sort(ids)
+		if got := strings.Join(ids, ","); got != tc.want {
+			t.Fatalf("SampleBankQuestions(%+v) = %s, want %s", tc.filter, got, tc.want)
+		}
+	}
+
+	// Clearing q1's tags lets it go with the quiz; tagged q2 stays.
+	if err := store.SetQuestionTags(ctx, "q1", nil); err != nil {
+		t.Fatalf("SetQuestionTags(q1, nil) failed: %v", err)
+	}
+	if _, err := store.DeleteQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("DeleteQuiz failed: %v", err)
+	}
+	if _, err := store.QuestionTags(ctx, "q1"); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("QuestionTags(q1) after delete = %v, want ErrUnknownQuestion", err)
+	}
+	if tags, err := store.QuestionTags(ctx, "q2"); err != nil || strings.Join(tags, ",") != "geo" {
+		t.Fatalf("QuestionTags(q2) after delete = (%v, %v), want geo", tags, err)
+	}
+}
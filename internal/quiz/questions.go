@@ -1,192 +1,150 @@
 package quiz
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
 	"html"
 	"math/rand"
-	"strings"
-	"sync"
 	"time"
 
 	"quiz-app/internal/opentdb"
+	"quiz-app/pkg/quizkit"
 )
 
+// The question model and evaluation rules live in pkg/quizkit so other programs
+// can embed them; these aliases keep the service's existing names.
 const (
-	StatusCorrect         = "correct"
-	StatusIncorrect       = "incorrect"
-	StatusInvalidQuestion = "invalid_question"
-	StatusInvalidLetter   = "invalid_letter"
-	StatusAlreadyAnswered = "already_answered"
+	StatusCorrect          = quizkit.StatusCorrect
+	StatusPartiallyCorrect = quizkit.StatusPartiallyCorrect
+	StatusIncorrect        = quizkit.StatusIncorrect
+	StatusInvalidQuestion  = quizkit.StatusInvalidQuestion
+	StatusInvalidLetter    = quizkit.StatusInvalidLetter
+	StatusAlreadyAnswered  = quizkit.StatusAlreadyAnswered
+	StatusVoidedQuestion   = quizkit.StatusVoidedQuestion
+	StatusStaleQuestion    = quizkit.StatusStaleQuestion
+
+	StatusDuplicateInRequest = quizkit.StatusDuplicateInRequest
+	StatusInvalidSignature   = quizkit.StatusInvalidSignature
+	StatusOutsideWindow      = quizkit.StatusOutsideWindow
+	StatusInvalidNonce       = quizkit.StatusInvalidNonce
+
+	DifficultyEasy   = quizkit.DifficultyEasy
+	DifficultyMedium = quizkit.DifficultyMedium
+	DifficultyHard   = quizkit.DifficultyHard
+
+	TiebreakLastSubmission = quizkit.TiebreakLastSubmission
+	TiebreakFewestAnswers  = quizkit.TiebreakFewestAnswers
+
+	TypeSingle      = quizkit.TypeSingle
+	TypeMultiSelect = quizkit.TypeMultiSelect
+	TypeTrueFalse   = quizkit.TypeTrueFalse
 )
 
-type Option struct {
-	Letter string `json:"letter"`
-	Text   string `json:"text"`
-}
-
-type Question struct {
-	PublicQuestion
-	CorrectIndex int
-}
-
-type PublicQuestion struct {
-	QuestionID string   `json:"question_id"`
-	Question   string   `json:"question"`
-	Options    []Option `json:"options"`
-}
+type (
+	Difficulty        = quizkit.Difficulty
+	QuestionKind      = quizkit.QuestionType
+	Option            = quizkit.Option
+	Question          = quizkit.Question
+	PublicQuestion    = quizkit.PublicQuestion
+	SubmittedResponse = quizkit.SubmittedResponse
+	ResponseResult    = quizkit.ResponseResult
+	Translation       = quizkit.Translation
+	Tiebreak          = quizkit.Tiebreak
+)
 
-type SubmittedResponse struct {
-	QuestionID string `json:"question_id"`
-	Answer     string `json:"answer"`
+func init() {
+	rand.Seed(time.Now().UnixNano())
 }
 
-type ResponseResult struct {
-	QuestionID   string   `json:"question_id"`
-	Status       string   `json:"status"`
-	AttemptScore *float64 `json:"attempt_score,omitempty"`
+// BuildQuestions converts provider questions, shuffling their options with
+// the global math/rand source.
+func BuildQuestions(raw []opentdb.RawQuestion) []Question {
+	return NewQuestionBuilder(nil).Build(raw)
 }
 
-type Bank struct {
-	questions sync.Map
+// QuestionBuilder converts provider questions with its own source of
+// randomness for option order. A builder with its own source is not safe for
+// concurrent use; give each quiz its own.
+type QuestionBuilder struct {
+	shuffle func(n int, swap func(i, j int))
 }
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// NewQuestionBuilder shuffles options with random. A nil random uses the
+// global math/rand source, like BuildQuestions.
+func NewQuestionBuilder(random *rand.Rand) *QuestionBuilder {
+	if random == nil {
+		return &QuestionBuilder{shuffle: rand.Shuffle}
+	}
+	return &QuestionBuilder{shuffle: random.Shuffle}
 }
 
-func NewBank() *Bank {
-	return &Bank{}
+// NewSeededQuestionBuilder is NewQuestionBuilder with a source seeded by seed,
+// so the same seed and provider questions give the same option order.
+func NewSeededQuestionBuilder(seed int64) *QuestionBuilder {
+	return NewQuestionBuilder(rand.New(rand.NewSource(seed)))
 }
 
-func BuildQuestions(raw []opentdb.RawQuestion) []Question {
+// Build converts raw in order. Each question consumes the builder's source,
+// so the options of one question depend on the questions built before it.
+func (b *QuestionBuilder) Build(raw []opentdb.RawQuestion) []Question {
 	questions := make([]Question, 0, len(raw))
 	for _, item := range raw {
-		question := buildQuestion(item)
+		question := b.buildQuestion(item)
 		question.QuestionID = MakeQuestionID(question)
 		questions = append(questions, question)
 	}
 	return questions
 }
 
-func (b *Bank) AddQuestions(raw []opentdb.RawQuestion) []Question {
-	questions := make([]Question, 0, len(raw))
+// ErrInvalidQuestion reports a caller-supplied question that cannot be stored.
+var ErrInvalidQuestion = quizkit.ErrInvalidQuestion
 
-	for _, item := range raw {
-		question := buildQuestion(item)
-		question.QuestionID = MakeQuestionID(question)
-		b.questions.Store(question.QuestionID, question)
-		questions = append(questions, question)
-	}
-
-	return questions
+// NewQuestion builds a question from caller-supplied text, keeping the option
+// order as given so letters line up with what the caller displayed.
+func NewQuestion(prompt string, options []string, correctIndex int) (Question, error) {
+	return quizkit.NewQuestion(prompt, options, correctIndex)
 }
 
-func (b *Bank) AddBuiltQuestions(questions []Question) {
-	for _, question := range questions {
-		if question.QuestionID == "" {
-			question.QuestionID = MakeQuestionID(question)
-		}
-		b.questions.Store(question.QuestionID, question)
-	}
+// NewMultiSelectQuestion builds a question with one or more correct options,
+// scored with partial credit.
+func NewMultiSelectQuestion(prompt string, options []string, correctIndexes []int) (Question, error) {
+	return quizkit.NewMultiSelectQuestion(prompt, options, correctIndexes)
 }
 
-func (b *Bank) EvaluateResponses(responses []SubmittedResponse) []ResponseResult {
-	results := make([]ResponseResult, 0, len(responses))
-
-	for _, response := range responses {
-		storedQuestion, ok := b.questions.Load(response.QuestionID)
-		if !ok {
-			results = append(results, ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     StatusInvalidQuestion,
-			})
-			continue
-		}
-
-		question, ok := storedQuestion.(Question)
-		if !ok {
-			results = append(results, ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     StatusInvalidQuestion,
-			})
-			continue
-		}
-
-		if len(question.Options) < 1 {
-			results = append(results, ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     StatusInvalidLetter,
-			})
-			continue
-		}
-
-		letter := NormalizeLetter(response.Answer)
-		if letter == "" {
-			results = append(results, ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     StatusInvalidLetter,
-			})
-			continue
-		}
+// NewTrueFalseQuestion builds a question with the options True and False.
+func NewTrueFalseQuestion(prompt string, answer bool) (Question, error) {
+	return quizkit.NewTrueFalseQuestion(prompt, answer)
+}
 
-		answerIndex := int(letter[0] - 'A')
-		if answerIndex < 0 || answerIndex >= len(question.Options) {
-			results = append(results, ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     StatusInvalidLetter,
-			})
-			continue
-		}
+// ParseQuestionKind accepts single, multi_select, or true_false; empty means
+// single. ParseQuestionType instead reads OpenTriviaDB's type filter.
+func ParseQuestionKind(value string) (QuestionKind, error) {
+	return quizkit.ParseQuestionType(value)
+}
 
-		status := StatusIncorrect
-		if answerIndex == question.CorrectIndex {
-			status = StatusCorrect
-		}
-		results = append(results, ResponseResult{
-			QuestionID: response.QuestionID,
-			Status:     status,
-		})
-	}
+// ParseLanguage normalizes a language tag such as "es" or "pt-BR".
+func ParseLanguage(value string) (string, error) {
+	return quizkit.ParseLanguage(value)
+}
 
-	return results
+// ParseDifficulty accepts easy, medium, or hard in any case.
+func ParseDifficulty(value string) (Difficulty, error) {
+	return quizkit.ParseDifficulty(value)
 }
 
 func ToPublicQuestions(questions []Question) []PublicQuestion {
-	public := make([]PublicQuestion, 0, len(questions))
-	for _, question := range questions {
-		public = append(public, question.PublicQuestion)
-	}
-	return public
+	return quizkit.ToPublicQuestions(questions)
 }
 
 // MakeQuestionID generates a deterministic question ID from prompt + option text.
-// Option ordering is intentionally part of the key for this project.
 func MakeQuestionID(question Question) string {
-	const hashChars = 12
-
-	var keyBuilder strings.Builder
-	keyBuilder.WriteString(question.Question)
-	for _, option := range question.Options {
-		keyBuilder.WriteString("|")
-		keyBuilder.WriteString(option.Text)
-	}
-
-	hash := sha1.Sum([]byte(keyBuilder.String()))
-	encoded := hex.EncodeToString(hash[:])
-	return "q_" + encoded[:hashChars]
+	return quizkit.MakeQuestionID(question)
 }
 
 // NormalizeLetter trims and uppercases an answer and returns only single-letter values.
 func NormalizeLetter(answer string) string {
-	letter := strings.ToUpper(strings.TrimSpace(answer))
-	if len(letter) != 1 {
-		return ""
-	}
-	return letter
+	return quizkit.NormalizeLetter(answer)
 }
 
-func buildQuestion(raw opentdb.RawQuestion) Question {
+func (b *QuestionBuilder) buildQuestion(raw opentdb.RawQuestion) Question {
 	type choice struct {
 		text      string
 		isCorrect bool
@@ -205,7 +163,7 @@ func buildQuestion(raw opentdb.RawQuestion) Question {
 		isCorrect: true,
 	})
 
-	rand.Shuffle(len(choices), func(i, j int) {
+	b.shuffle(len(choices), func(i, j int) {
 		choices[i], choices[j] = choices[j], choices[i]
 	})
 
@@ -213,9 +171,8 @@ func buildQuestion(raw opentdb.RawQuestion) Question {
 	correctIndex := -1
 
 	for idx, candidate := range choices {
-		letter := string(rune('A' + idx))
 		options[idx] = Option{
-			Letter: letter,
+			Letter: quizkit.OptionLetter(idx),
 			Text:   candidate.text,
 		}
 		if candidate.isCorrect {
@@ -223,11 +180,23 @@ func buildQuestion(raw opentdb.RawQuestion) Question {
 		}
 	}
 
+	// Unknown upstream difficulties are left untagged rather than failing the fetch.
+	difficulty, _ := quizkit.ParseDifficulty(raw.Difficulty)
+	// Boolean questions keep their shuffled order; answering with the word
+	// matches the option text wherever it landed.
+	kind := TypeSingle
+	if raw.Type == opentdb.TypeBoolean {
+		kind = TypeTrueFalse
+	}
+
 	return Question{
 		PublicQuestion: PublicQuestion{
 			Question: html.UnescapeString(raw.Question),
 			Options:  options,
+			Type:     kind,
 		},
 		CorrectIndex: correctIndex,
+		Difficulty:   difficulty,
+		Category:     html.UnescapeString(raw.Category),
 	}
 }
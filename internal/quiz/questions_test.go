@@ -1,6 +1,7 @@
 package quiz
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -44,6 +45,33 @@ func TestBuildQuestionsUnescapesAndAssignsID(t *testing.T) {
 	}
 }
 
+func TestSeededQuestionBuilderGivesExactOptionLayout(t *testing.T) {
+	raw := []opentdb.RawQuestion{
+		{Question: "Largest planet?", CorrectAnswer: "Jupiter", IncorrectAnswers: []string{"Mars", "Venus", "Saturn"}},
+		{Question: "Smallest planet?", CorrectAnswer: "Mercury", IncorrectAnswers: []string{"Mars", "Pluto"}},
+	}
+
+	questions := NewSeededQuestionBuilder(42).Build(raw)
+	want := [][]string{
+		{"Saturn", "Jupiter", "Mars", "Venus"},
+		{"Pluto", "Mercury", "Mars"},
+	}
+	for idx, question := range questions {
+		texts := make([]string, 0, len(question.Options))
+		for _, option := range question.Options {
+			texts = append(texts, option.Text)
+		}
+		if strings.Join(texts, "|") != strings.Join(want[idx], "|") || question.CorrectIndex != 1 {
+			t.Fatalf("question %d options = %v (correct %d), want %v (correct 1)", idx, texts, question.CorrectIndex, want[idx])
+		}
+	}
+
+	again := NewSeededQuestionBuilder(42).Build(raw)
+	if again[0].QuestionID != questions[0].QuestionID || again[1].QuestionID != questions[1].QuestionID {
+		t.Fatalf("same seed built IDs %s, %s, want %s, %s", again[0].QuestionID, again[1].QuestionID, questions[0].QuestionID, questions[1].QuestionID)
+	}
+}
+
 func TestMakeQuestionIDDiffersWhenOptionOrderDiffers(t *testing.T) {
 	q1 := Question{
 		PublicQuestion: PublicQuestion{
@@ -134,3 +162,51 @@ func TestNormalizeLetter(t *testing.T) {
 		})
 	}
 }
+
+func TestNewQuestionKeepsOptionOrderAndValidates(t *testing.T) {
+	question, err := NewQuestion("Capital of France?", []string{"Lyon", "Paris"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	if question.Options[0].Letter != "A" || question.Options[1].Text != "Paris" || question.CorrectIndex != 1 {
+		t.Fatalf("unexpected question: %+v", question)
+	}
+	if question.QuestionID != MakeQuestionID(question) {
+		t.Fatalf("question id = %q, want deterministic id", question.QuestionID)
+	}
+
+	invalid := []struct {
+		prompt       string
+		options      []string
+		correctIndex int
+	}{
+		{"", []string{"A", "B"}, 0},
+		{"Q", []string{"only"}, 0},
+		{"Q", []string{"A", "B"}, 2},
+		{"Q", []string{"A", " "}, 0},
+	}
+	for _, tc := range invalid {
+		if _, err := NewQuestion(tc.prompt, tc.options, tc.correctIndex); !errors.Is(err, ErrInvalidQuestion) {
+			t.Fatalf("NewQuestion(%q, %v, %d) = %v, want ErrInvalidQuestion", tc.prompt, tc.options, tc.correctIndex, err)
+		}
+	}
+}
+
+func FuzzNormalizeUsername(f *testing.F) {
+	for _, seed := range []string{"Alice", "  bob  ", "", " \t", "ÉMILE", "\xff\xfe"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, username string) {
+		normalized, err := normalizeUsername(username)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidUsername) || strings.TrimSpace(username) != "" {
+				t.Fatalf("normalizeUsername(%q) error = %v, want ErrInvalidUsername only for blank names", username, err)
+			}
+			return
+		}
+		again, err := normalizeUsername(normalized)
+		if err != nil || again != normalized {
+			t.Fatalf("normalizeUsername(%q) = (%q, %v), want it unchanged", normalized, again, err)
+		}
+	})
+}
@@ -2,27 +2,60 @@ package quiz
 
 import (
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"time"
+
+	"quiz-app/pkg/quizkit"
 )
 
 var (
-	ErrQuizNotFound    = errors.New("quiz not found")
-	ErrInvalidUsername = errors.New("invalid username")
+	ErrQuizNotFound     = errors.New("quiz not found")
+	ErrInvalidUsername  = errors.New("invalid username")
+	ErrUnsupported      = errors.New("operation not supported by store")
+	ErrQuestionNotFound = errors.New("question not found in quiz")
+	ErrUnknownQuestion  = errors.New("question not found")
+	// ErrQuestionConflict reports a question whose ID is already stored with
+	// other text, answer key, feedback, or translations. Every quiz that uses
+	// a question shares its stored row, so stores never rewrite one.
+	ErrQuestionConflict = errors.New("question already stored with different content")
+	// ErrQueryTimeout reports a store statement cut off by the store's own
+	// timeout rather than by the caller giving up.
+	ErrQueryTimeout = errors.New("store query timed out")
 )
 
 type QuizMetadata struct {
 	QuizID        string
 	QuestionCount int
-	CreatedAt     time.Time
+	// RequestedQuestionCount is how many questions the creator asked for; it can
+	// exceed QuestionCount when the provider ran short. Zero means unknown.
+	RequestedQuestionCount int
+	CreatedAt              time.Time
+	// Locked quizzes are read-only for players.
+	Locked bool
+	// Draft quizzes are still being prepared and take no answers until a host
+	// activates them.
+	Draft bool
+	// ClosesAt is when the quiz stops taking answers. Zero means no deadline.
+	ClosesAt time.Time
+	// Practice quizzes are for learning: incorrect answers come back with the
+	// author's feedback for the chosen option.
+	Practice bool
+	// Adaptive quizzes serve each player one question at a time from the pool,
+	// picked by difficulty from their previous answers.
+	Adaptive bool
+	// QuestionTimeLimit is how long clients give a player per question. It is
+	// a pacing hint for clients; the server does not enforce it. Zero means
+	// untimed. Stores keep whole seconds.
+	QuestionTimeLimit time.Duration
+	// Origin records how the quiz was created; see Service.Rematch.
+	Origin QuizOrigin
+	// ArchivedAt is when an admin archived the quiz; see Service.ArchiveQuiz.
+	// Zero means it was not archived.
+	ArchivedAt time.Time
 }
 
-type LeaderboardEntry struct {
-	Username         string    `json:"username"`
-	TotalScore       float64   `json:"total_score"`
-	AnsweredCount    int       `json:"answered_count"`
-	LastSubmissionAt time.Time `json:"last_submission_at"`
-}
+type LeaderboardEntry = quizkit.LeaderboardEntry
 
 type QuizRepository interface {
 	CreateQuiz(ctx context.Context, metadata QuizMetadata, questions []Question) error
@@ -37,3 +70,422 @@ type AttemptRepository interface {
 	GetLeaderboard(ctx context.Context, quizID string) ([]LeaderboardEntry, error)
 	GetAttemptScores(ctx context.Context, quizID, usernameNormalized string) (map[string]float64, error)
 }
+
+// Optional store capabilities.
+//
+// QuizRepository and AttemptRepository stay intentionally small so every storage
+// backend can implement them. Features that need extra queries are expressed as
+// narrow interfaces that a backend may implement in addition; the service checks
+// for them at call time and returns ErrUnsupported when the configured store
+// does not provide the capability.
+
+// QuestionSearcher finds stored questions by prompt text across all quizzes.
+// Questions in an adaptive quiz are left out, since its questions are served
+// one at a time.
+type QuestionSearcher interface {
+	SearchQuestions(ctx context.Context, text string, limit int) ([]Question, error)
+}
+
+// QuestionLookup loads stored questions by ID regardless of which quiz used
+// them. IDs that were never stored are left out of the result.
+type QuestionLookup interface {
+	LookupQuestions(ctx context.Context, questionIDs []string) ([]Question, error)
+}
+
+// QuestionSampler draws up to limit stored questions at random, from every
+// quiz ever created, so they can stand in for the provider; see
+// StoredQuestionsFetcher.
+type QuestionSampler interface {
+	SampleQuestions(ctx context.Context, limit int) ([]Question, error)
+}
+
+// BankFilter narrows SampleBankQuestions. A question matches when it carries
+// every tag and, if Difficulty is set, has that difficulty.
+type BankFilter struct {
+	Tags       []string
+	Difficulty Difficulty
+}
+
+// QuestionTagStore keeps admin-assigned tags on stored questions so quizzes
+// can be assembled from them; see Service.CreateBankQuizzes. SetQuestionTags
+// replaces every tag of the question, and both it and QuestionTags return
+// ErrUnknownQuestion for a question that was never stored. Tags come back
+// sorted. SampleBankQuestions draws up to limit matching questions at random.
+type QuestionTagStore interface {
+	SetQuestionTags(ctx context.Context, questionID string, tags []string) error
+	QuestionTags(ctx context.Context, questionID string) ([]string, error)
+	SampleBankQuestions(ctx context.Context, filter BankFilter, limit int) ([]Question, error)
+}
+
+// QuestionServeTracker remembers when each user was first served each question
+// of a quiz, so answer latency can be measured on the server. RecordServes
+// keeps the earliest time when a question is served again. ServeTimes returns
+// the recorded times keyed by question ID.
+type QuestionServeTracker interface {
+	RecordServes(ctx context.Context, quizID, usernameNormalized string, questionIDs []string, servedAt time.Time) error
+	ServeTimes(ctx context.Context, quizID, usernameNormalized string) (map[string]time.Time, error)
+}
+
+// ServeLogEntry summarizes one user's question fetches for a quiz.
+type ServeLogEntry struct {
+	Username      string
+	FirstServedAt time.Time
+	LastServedAt  time.Time
+	FetchCount    int
+	// AnswerKeyServedAt is the first fetch that included correct answers
+	// (include_correct). Zero means the user never received the answer key.
+	AnswerKeyServedAt time.Time
+	// NoncesIssued counts the serve nonces issued to the user; see
+	// ServiceOptions.ServeNonces.
+	NoncesIssued int
+}
+
+// ServeLogger keeps a per-quiz log of which users fetched questions and when.
+// LogServe folds each fetch, and the nonces issued with it, into the user's
+// entry; ListServeLog returns the quiz's entries in any order.
+type ServeLogger interface {
+	LogServe(ctx context.Context, quizID, usernameNormalized string, servedAt time.Time, withAnswerKey bool, noncesIssued int) error
+	ListServeLog(ctx context.Context, quizID string) ([]ServeLogEntry, error)
+}
+
+// Attempt is one stored answer.
+type Attempt struct {
+	QuestionID   string
+	AnswerLetter string
+	Score        float64
+	SubmittedAt  time.Time
+	// SubmittedBy is the admin who entered the answer for the player; it is
+	// empty for answers the player sent.
+	SubmittedBy string
+}
+
+// AttemptHistory lists a user's answers in one quiz, oldest first, with
+// answers to voided questions left out. Answers stored in the same instant
+// keep the order they were submitted in.
+type AttemptHistory interface {
+	ListAttempts(ctx context.Context, quizID, usernameNormalized string) ([]Attempt, error)
+}
+
+// QuestionVoider withdraws a question from one quiz. Voiding is idempotent:
+// voiding an already voided question succeeds and keeps the original timestamp.
+// Stores that implement it must also exclude voided questions from
+// GetLeaderboard and GetAttemptScores, and reject new answers to them with
+// StatusVoidedQuestion.
+type QuestionVoider interface {
+	VoidQuestion(ctx context.Context, quizID, questionID string, voidedAt time.Time) error
+}
+
+// QuizLifecycleStore changes the fields of a stored quiz that make up its
+// state: Draft, Locked, and ClosesAt. It returns ErrQuizNotFound for a quiz
+// that was never stored.
+type QuizLifecycleStore interface {
+	SetQuizLifecycle(ctx context.Context, quizID string, lifecycle QuizLifecycle) error
+}
+
+// QuizRemover takes quizzes out of service for good. DeleteQuiz removes a
+// quiz with everything stored for it: its question list, attempts, summaries,
+// serves, settings, roster, hosts, results, and audit log. Its questions are
+// removed too unless another quiz, a bookmark, an author, a report, or a
+// retirement review still refers to them. ArchiveQuiz locks a quiz, folds all
+// of its attempts into per-user summaries as AttemptArchiver does, and sets
+// ArchivedAt, in one transaction; ListActiveQuizzes leaves archived quizzes
+// out. Archiving an archived quiz keeps the first ArchivedAt. Both return the
+// number of attempts they deleted, and ErrQuizNotFound for a quiz that was
+// never stored.
+type QuizRemover interface {
+	DeleteQuiz(ctx context.Context, quizID string) (int, error)
+	ArchiveQuiz(ctx context.Context, quizID string, archivedAt time.Time) (int, error)
+}
+
+// QuestionReshuffler swaps one question of a quiz for a copy with its options
+// in another order. A question's ID follows its option order, so the copy has
+// a new ID; ReplaceQuizQuestion stores it in the old question's place. It
+// returns ErrQuestionAnswered when the quiz holds answers to the old question,
+// whose letters would no longer match.
+type QuestionReshuffler interface {
+	ReplaceQuizQuestion(ctx context.Context, quizID, oldQuestionID string, question Question) error
+}
+
+// QuestionUsageTracker remembers when questions were served in scheduled quizzes
+// so new ones can avoid recent repeats. RecentlyUsedQuestions returns full
+// questions because option shuffling gives a refetched question a new ID; callers
+// match on prompt text as well.
+type QuestionUsageTracker interface {
+	RecordQuestionUsage(ctx context.Context, quizID string, questionIDs []string, usedAt time.Time) error
+	RecentlyUsedQuestions(ctx context.Context, since time.Time) ([]Question, error)
+}
+
+// QuizStats is a quiz's metadata plus usage figures for capacity management.
+type QuizStats struct {
+	QuizMetadata
+	AttemptCount     int
+	ParticipantCount int
+	// StorageBytes estimates the bytes held by the quiz's questions and attempts.
+	// Shared questions are counted once per quiz that uses them.
+	StorageBytes int64
+	// LastActivityAt is the latest attempt, or CreatedAt when nobody answered.
+	LastActivityAt time.Time
+}
+
+// QuizStatsLister lists every stored quiz with usage statistics. Callers sort
+// and paginate, so implementations may return quizzes in any order.
+type QuizStatsLister interface {
+	ListQuizStats(ctx context.Context) ([]QuizStats, error)
+}
+
+// Bookmark is a question a user saved to revisit.
+type Bookmark struct {
+	Question  Question
+	CreatedAt time.Time
+}
+
+// BookmarkStore keeps per-user question bookmarks. Usernames are normalized by
+// the caller. AddBookmark returns ErrUnknownQuestion for questions that were
+// never stored and keeps the original timestamp when re-adding; RemoveBookmark
+// succeeds when nothing is bookmarked. ListBookmarks returns newest first.
+type BookmarkStore interface {
+	AddBookmark(ctx context.Context, usernameNormalized, questionID string, createdAt time.Time) error
+	RemoveBookmark(ctx context.Context, usernameNormalized, questionID string) error
+	ListBookmarks(ctx context.Context, usernameNormalized string) ([]Bookmark, error)
+}
+
+// QuestionPerformance summarizes how one question did across every quiz that
+// used it. Attempts on quizzes where the question was voided are not counted.
+type QuestionPerformance struct {
+	Question     Question
+	QuizCount    int
+	AttemptCount int
+	CorrectCount int
+	ReportCount  int
+}
+
+// CorrectnessRate is the share of attempts answered correctly, or 0 when the
+// question has no attempts yet.
+func (p QuestionPerformance) CorrectnessRate() float64 {
+	if p.AttemptCount == 0 {
+		return 0
+	}
+	return float64(p.CorrectCount) / float64(p.AttemptCount)
+}
+
+// QuestionAuthorTracker remembers who wrote custom questions so authors can see
+// how their questions perform. Question IDs derive from content, so the first
+// author to submit a question keeps it; later claims are ignored.
+// QuestionPerformanceByAuthor returns the author's newest questions first.
+type QuestionAuthorTracker interface {
+	RecordQuestionAuthor(ctx context.Context, authorNormalized string, questionIDs []string, createdAt time.Time) error
+	QuestionPerformanceByAuthor(ctx context.Context, authorNormalized string) ([]QuestionPerformance, error)
+}
+
+// QuestionReporter stores player reports about broken or unclear questions.
+// Each user reports a question at most once; repeats keep the first report.
+// Unknown questions return ErrUnknownQuestion.
+type QuestionReporter interface {
+	ReportQuestion(ctx context.Context, questionID, usernameNormalized, reason string, reportedAt time.Time) error
+}
+
+// UserProfile holds per-user settings. UpdatedAt is zero for users who never
+// saved one.
+type UserProfile struct {
+	Username string
+	// Anonymous masks the username on public leaderboards; scores still count
+	// toward rankings and aggregates.
+	Anonymous bool
+	UpdatedAt time.Time
+}
+
+// ProfileStore keeps user profiles. Usernames are normalized by the caller.
+// GetProfile returns a default profile for users who never saved one.
+// AnonymousUsers returns the subset of usernames that participate anonymously.
+type ProfileStore interface {
+	GetProfile(ctx context.Context, usernameNormalized string) (UserProfile, error)
+	SaveProfile(ctx context.Context, profile UserProfile) error
+	AnonymousUsers(ctx context.Context, usernamesNormalized []string) (map[string]bool, error)
+}
+
+// UserPreferences are a user's defaults for quizzes they create. Zero fields
+// leave the server defaults in place. UpdatedAt is zero for users who never
+// saved any.
+type UserPreferences struct {
+	Username      string
+	QuestionCount int
+	Difficulty    Difficulty
+	UpdatedAt     time.Time
+}
+
+// PreferenceStore keeps user preferences. Usernames are normalized by the
+// caller. GetPreferences returns empty preferences for users who never saved
+// any.
+type PreferenceStore interface {
+	GetPreferences(ctx context.Context, usernameNormalized string) (UserPreferences, error)
+	SavePreferences(ctx context.Context, preferences UserPreferences) error
+}
+
+// Streak counts consecutive days on which a user answered at least one quiz
+// question, across all quizzes. Days are dates ("2006-01-02") in the
+// deployment's streak time zone; LastDay is empty for users who never played.
+type Streak struct {
+	Username  string
+	Current   int
+	Longest   int
+	LastDay   string
+	UpdatedAt time.Time
+}
+
+// StreakStore keeps daily participation streaks. Usernames are normalized by
+// the caller. GetStreak returns the zero streak for users who never played.
+// RecordStreakDay applies Streak.Advance atomically and returns the result.
+type StreakStore interface {
+	GetStreak(ctx context.Context, usernameNormalized string) (Streak, error)
+	RecordStreakDay(ctx context.Context, usernameNormalized, day string, recordedAt time.Time) (Streak, error)
+}
+
+// SigningKey is a public key a client registered to sign the answers it
+// queues while offline. KeyID is derived from PublicKey; see SigningKeyID.
+type SigningKey struct {
+	Username     string
+	KeyID        string
+	PublicKey    ed25519.PublicKey
+	RegisteredAt time.Time
+}
+
+// SigningKeyStore keeps clients' answer-signing keys. Usernames are
+// normalized by the caller. SaveSigningKey keeps the first registration of a
+// key for a username; GetSigningKey returns the zero SigningKey for keys
+// never registered.
+type SigningKeyStore interface {
+	SaveSigningKey(ctx context.Context, key SigningKey) error
+	GetSigningKey(ctx context.Context, usernameNormalized, keyID string) (SigningKey, error)
+}
+
+// PublishedResults is a quiz's final results document as published. Document
+// is the JSON served to readers, kept byte for byte so it never changes.
+type PublishedResults struct {
+	QuizID      string
+	Document    []byte
+	PublishedAt time.Time
+}
+
+// ResultsStore keeps published results documents. SaveQuizResults keeps the
+// first document saved for a quiz; GetQuizResults returns the zero value for
+// quizzes with none.
+type ResultsStore interface {
+	SaveQuizResults(ctx context.Context, results PublishedResults) error
+	GetQuizResults(ctx context.Context, quizID string) (PublishedResults, error)
+}
+
+// AttemptSummary is one user's totals in one quiz. CorrectCount counts
+// answers that scored. Archived answers are counted as they stood when they
+// were archived, so a question voided later still counts toward them.
+type AttemptSummary struct {
+	QuizID           string
+	Username         string
+	TotalScore       float64
+	AnsweredCount    int
+	CorrectCount     int
+	FirstSubmittedAt time.Time
+	LastSubmittedAt  time.Time
+	// ArchivedCount is how many of AnsweredCount were archived; only totals
+	// remain of those.
+	ArchivedCount int
+}
+
+// AttemptArchiver bounds the attempts a store keeps. IdleQuizzes lists quizzes
+// with attempts, all submitted before cutoff. ArchiveAttempts folds quizID's
+// attempts submitted before cutoff into per-user summaries, deletes them, and
+// marks the quiz locked, in one transaction, and returns how many attempts it
+// deleted; archiving a quiz again adds to its summaries. Stores that
+// implement it count archived summaries in GetLeaderboard. AttemptSummaries
+// returns one summary per quiz the user answered in, archived and kept
+// attempts combined, most recently played first.
+type AttemptArchiver interface {
+	IdleQuizzes(ctx context.Context, cutoff time.Time) ([]string, error)
+	ArchiveAttempts(ctx context.Context, quizID string, cutoff time.Time) (int, error)
+	AttemptSummaries(ctx context.Context, usernameNormalized string) ([]AttemptSummary, error)
+}
+
+// AttemptSummaryLister lists every user's AttemptSummary in each quiz created
+// in [since, until), for rankings across quizzes; a zero bound is open.
+// Summaries combine archived and kept attempts as AttemptSummaries does, and
+// come in any order.
+type AttemptSummaryLister interface {
+	ListAttemptSummaries(ctx context.Context, since, until time.Time) ([]AttemptSummary, error)
+}
+
+// UserAttemptLister returns one AttemptSummary per quiz the user answered in,
+// most recently played first, for the user's attempt history. Stores that
+// also implement AttemptArchiver combine archived and kept attempts, as
+// AttemptSummaries does; voided questions are not counted.
+type UserAttemptLister interface {
+	GetUserAttempts(ctx context.Context, usernameNormalized string) ([]AttemptSummary, error)
+}
+
+// AuditEntry is one admin action recorded in a quiz's audit log. Actor is
+// the admin as they named themselves, and Username the player acted for.
+type AuditEntry struct {
+	QuizID   string
+	At       time.Time
+	Actor    string
+	Action   string
+	Username string
+	Detail   string
+}
+
+// AuditLog keeps a per-quiz log of admin actions taken for players.
+// ListAuditEntries returns a quiz's entries oldest first. Stores that
+// implement it must also keep each response's SubmittedBy with the attempt
+// SubmitResponses stores and return it from ListAttempts.
+type AuditLog interface {
+	RecordAuditEntry(ctx context.Context, entry AuditEntry) error
+	ListAuditEntries(ctx context.Context, quizID string) ([]AuditEntry, error)
+}
+
+// LeaderboardSettingsStore keeps hosts' per-quiz leaderboard settings.
+// GetLeaderboardSettings returns the zero value for quizzes without any.
+type LeaderboardSettingsStore interface {
+	GetLeaderboardSettings(ctx context.Context, quizID string) (LeaderboardSettings, error)
+	SaveLeaderboardSettings(ctx context.Context, quizID string, settings LeaderboardSettings) error
+}
+
+// RosterStore keeps hosts' per-quiz rosters. GetRoster returns the zero value
+// for quizzes without one; SaveRoster replaces the whole roster.
+type RosterStore interface {
+	GetRoster(ctx context.Context, quizID string) (Roster, error)
+	SaveRoster(ctx context.Context, quizID string, roster Roster) error
+}
+
+// HostStore keeps the hosts of each quiz. GetHosts returns an empty list for
+// quizzes without any; SaveHosts replaces the whole list.
+type HostStore interface {
+	GetHosts(ctx context.Context, quizID string) ([]QuizHost, error)
+	SaveHosts(ctx context.Context, quizID string, hosts []QuizHost) error
+}
+
+// IdentityStore keeps verified player identities. GetIdentity returns the zero
+// value for usernames nobody verified; SaveIdentity replaces any earlier one.
+type IdentityStore interface {
+	GetIdentity(ctx context.Context, usernameNormalized string) (Identity, error)
+	SaveIdentity(ctx context.Context, identity Identity) error
+}
+
+// AccountStore keeps user accounts. GetAccount returns the zero value for
+// usernames nobody registered; CreateAccount returns ErrAccountExists for a
+// username that is taken.
+type AccountStore interface {
+	CreateAccount(ctx context.Context, account Account) error
+	GetAccount(ctx context.Context, usernameNormalized string) (Account, error)
+}
+
+// QuestionRetirementStore keeps the review queue for questions flagged by a
+// RetirementPolicy. QuestionStats returns the performance of every stored
+// question with at least minAttempts attempts. FlagQuestion adds a pending
+// review and leaves a question that already has one, pending or decided,
+// untouched. ListRetirementReviews returns the newest flags first; an empty
+// status lists them all. DecideRetirement records a decision and returns the
+// updated review, or ErrNotFlagged.
+type QuestionRetirementStore interface {
+	QuestionStats(ctx context.Context, minAttempts int) ([]QuestionPerformance, error)
+	FlagQuestion(ctx context.Context, review RetirementReview) error
+	ListRetirementReviews(ctx context.Context, status RetirementStatus) ([]RetirementReview, error)
+	DecideRetirement(ctx context.Context, questionID string, status RetirementStatus, decidedAt time.Time) (RetirementReview, error)
+}
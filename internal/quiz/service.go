@@ -3,145 +3,539 @@ package quiz
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"math/rand"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"quiz-app/internal/opentdb"
+	"quiz-app/pkg/quizkit"
 )
 
-type QuestionsFetcher func(ctx context.Context, amount int) ([]opentdb.RawQuestion, error)
+// QuestionsFetcher fetches amount provider questions matching filter.
+// Providers that cannot filter at the source filter what they return, which
+// may leave fewer than amount.
+type QuestionsFetcher func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error)
 
-// Service intentionally keeps cache state lock-free for this demo to keep code paths small.
-// Under high concurrent request volume, cache reads/writes can race and return stale snapshots.
-// We accept that tradeoff here because expected QPS is low and DB remains source of truth.
+// RevealPolicy controls whether scored results echo the canonical answer back to
+// the caller, so clients that never received correct_index can still explain a miss.
+type RevealPolicy = quizkit.RevealPolicy
+
+const (
+	RevealNever       = quizkit.RevealNever
+	RevealAfterAnswer = quizkit.RevealAfterAnswer
+)
+
+// ParseRevealPolicy maps a flag/config value to a RevealPolicy. Empty means never.
+func ParseRevealPolicy(value string) (RevealPolicy, error) {
+	return quizkit.ParseRevealPolicy(value)
+}
+
+// ScoringPolicy summarizes how submitted answers are scored, so clients can
+// explain the rules without hard-coding them.
+type ScoringPolicy = quizkit.ScoringPolicy
+
+// ServiceOptions carries optional Service behavior. The zero value matches NewService.
+type ServiceOptions struct {
+	RevealPolicy RevealPolicy
+	// DailyRepeatDays excludes questions used by daily quizzes within this many
+	// days when assembling a new daily quiz. Zero disables the window.
+	DailyRepeatDays int
+	// CompletionNotifier delivers completion watch events. Nil disables watches.
+	CompletionNotifier CompletionNotifier
+	// SubmitRateLimit caps answers per second each user may submit to one quiz.
+	// Zero disables throttling. SubmitBurst is the largest batch accepted at
+	// once; zero means one second's worth of answers, and at least
+	// MaxQuizQuestions.
+	SubmitRateLimit float64
+	SubmitBurst     int
+	// SelectionPolicy picks questions in adaptive quizzes. Nil uses a
+	// quizkit.Staircase starting at medium.
+	SelectionPolicy quizkit.SelectionPolicy
+	// StreamBufferSize is how many recent leaderboard events each streamed quiz
+	// keeps for viewers resuming with Last-Event-ID. Zero uses 256.
+	StreamBufferSize int
+	// LeaderboardNotifyInterval coalesces leaderboard notifications: viewers
+	// and leaderboard webhooks of a quiz get at most one per interval, carrying
+	// the latest standings. Zero sends a stream delta and a webhook for every
+	// submission.
+	LeaderboardNotifyInterval time.Duration
+	// ContentHashKey keys the content_hash served with each question. Empty
+	// uses a random key per process; set it so hashes survive restarts.
+	ContentHashKey []byte
+	// RequireContentHash rejects answers submitted without a content_hash as
+	// stale instead of scoring them.
+	RequireContentHash bool
+	// ServeNonces issues a nonce with every question served to a named player
+	// without the answer key, and rejects that player's answers unless each
+	// carries its question's nonce, once. It keeps one player's submission
+	// payload from being replayed by another.
+	ServeNonces bool
+	// RetryGraceWindow answers a player's repeat of a stored answer, same
+	// letter, with the original correct or incorrect result instead of
+	// already_answered when it arrives within this long of the original, so
+	// retries after a lost response read as success. Zero disables it; it needs
+	// a store that implements AttemptHistory.
+	RetryGraceWindow time.Duration
+	// Now is the service clock. Nil uses time.Now.
+	Now func() time.Time
+	// NewQuizID names quizzes created without a caller-chosen ID. Nil uses
+	// random "qz_" IDs.
+	NewQuizID func() string
+	// NewSeed seeds the option shuffle of each fetched quiz; the seed is
+	// recorded in QuizOrigin. Nil draws random nonzero seeds.
+	NewSeed func() int64
+	// SpeedBonus adds decaying points to fast correct answers. The zero value
+	// disables it; it needs a store that implements QuestionServeTracker.
+	SpeedBonus SpeedBonus
+	// MaxConcurrentFetches caps calls to the question fetcher in flight at
+	// once. Zero means no cap. Calls beyond it wait up to FetchQueueTimeout
+	// (zero means two seconds) and then fail with ErrProviderBusy.
+	MaxConcurrentFetches int
+	FetchQueueTimeout    time.Duration
+	// FallbackProviders are tried in order when the fetcher fails or returns
+	// too few questions, so quizzes can still be created while the provider
+	// is down. The one used is recorded in QuizOrigin. They are not subject to
+	// MaxConcurrentFetches.
+	FallbackProviders []FallbackProvider
+
+	// Retirement flags questions for review by their results; see
+	// RetirementPolicy. It needs a store that implements
+	// QuestionRetirementStore.
+	Retirement RetirementPolicy
+
+	// IdentityMailer sends email verification codes for optional player
+	// identities. Nil disables verification.
+	IdentityMailer IdentityMailer
+	// AccountTokenKey signs the tokens Login issues. Empty uses a random key
+	// per process; set it so tokens survive restarts and work on every
+	// replica. AccountTokenTTL is how long a token lasts; zero means 24 hours.
+	// Accounts need a store that implements AccountStore.
+	AccountTokenKey []byte
+	AccountTokenTTL time.Duration
+	// RequireLogin rejects answers submitted without an account token, for
+	// every username rather than only registered ones.
+	RequireLogin bool
+	// ProviderName names the provider behind the fetcher when a quiz is
+	// fetched, for QuizOrigin. It is called per quiz because the provider can
+	// change at runtime. Nil records "opentdb".
+	ProviderName func() string
+	// StreakLocation is the time zone whose midnight separates days for
+	// participation streaks. Nil uses UTC. Streaks need a store that implements
+	// StreakStore.
+	StreakLocation *time.Location
+	// OfflineSyncWindow is how long after a signed answer was chosen it may
+	// still be synced; see SignAnswer. Zero uses 24 hours. Signed answers need
+	// a store that implements SigningKeyStore.
+	OfflineSyncWindow time.Duration
+	// ResultsExporter receives each quiz's final results when they are
+	// published, for example to keep a static copy. Nil keeps them in the store
+	// only. Publishing needs a store that implements ResultsStore.
+	ResultsExporter ResultsExporter
+	// ResultsMailer emails participants with a verified identity their final
+	// result when a quiz's results are published. Nil sends no mail.
+	ResultsMailer ResultsMailer
+	// RestrictedSources names question sources whose license forbids copying
+	// their questions elsewhere; see Redistributable. A name also covers the
+	// sources it prefixes with a colon, so "file" covers every questions file.
+	RestrictedSources []string
+	// Cache bounds the in-memory caches. The zero value never evicts.
+	Cache CacheOptions
+}
+
+// Config wires a Service: the stores it needs plus optional subsystems in the
+// embedded ServiceOptions. Every optional field defaults from its zero value,
+// so new subsystems can be added here without breaking existing callers.
+type Config struct {
+	Quizzes  QuizRepository
+	Attempts AttemptRepository
+	// Fetcher supplies questions for quizzes the service creates. Nil leaves
+	// only caller-supplied questions.
+	Fetcher QuestionsFetcher
+	ServiceOptions
+}
+
+// New builds a Service from cfg.
+func New(cfg Config) *Service {
+	return NewServiceWithOptions(cfg.Quizzes, cfg.Attempts, cfg.Fetcher, cfg.ServiceOptions)
+}
+
+// Service is safe for concurrent use. Its caches are bounded by
+// ServiceOptions.Cache; the store remains the source of truth, so evicted or
+// expired entries are reloaded on the next read.
 type Service struct {
 	quizzes  QuizRepository
 	attempts AttemptRepository
 	fetcher  QuestionsFetcher
-
-	quizMetaCache    map[string]QuizMetadata
-	quizQuestions    map[string][]Question
-	leaderboardCache map[string]*leaderboardCache
-	attemptScores    map[string]map[string]float64
+	// fallbacks follow fetcher in the provider chain; see fetchQuestions.
+	fallbacks []FallbackProvider
+
+	revealPolicy    RevealPolicy
+	speedBonus      SpeedBonus
+	dailyRepeatDays int
+	now             func() time.Time
+	newQuizID       func() string
+	notifier        CompletionNotifier
+	submitLimiter   *submitLimiter
+	selectionPolicy quizkit.SelectionPolicy
+	streams         *leaderboardStreams
+	submissions     *submissionStreams
+	invalidation    quizInvalidation
+	notifyInterval  time.Duration
+	counters        *serviceCounters
+	retirement      RetirementPolicy
+	retired         *retiredPrompts
+	identityMailer  IdentityMailer
+	identities      *identityChallenges
+	accountKey      []byte
+	accountTTL      time.Duration
+	requireLogin    bool
+	providerName    func() string
+	newSeed         func() int64
+	streakLocation  *time.Location
+	syncWindow      time.Duration
+	exportResults   ResultsExporter
+	resultsMailer   ResultsMailer
+	// restrictedSources is ServiceOptions.RestrictedSources.
+	restrictedSources []string
+
+	contentHashKey     []byte
+	requireContentHash bool
+	nonces             *serveNonces
+	retryGrace         time.Duration
+	pseudonymKey       []byte
+
+	hostChanges hostLocks
+
+	watchesMu         sync.Mutex
+	completionWatches map[string][]*completionWatchState
+	// resultsTimers publish results when a quiz with final-results watches
+	// locks; see publishWhenLocked.
+	resultsTimers map[string]*time.Timer
+
+	quizMetaCache    *lruCache[QuizMetadata]
+	quizQuestions    *lruCache[[]Question]
+	leaderboardCache *lruCache[*leaderboardCache]
+	// attemptScores is keyed by attemptScoresCacheKey.
+	attemptScores *lruCache[map[string]float64]
+	// categoryLeaderboards is keyed by categoryLeaderboardKey.
+	categoryLeaderboards *lruCache[*leaderboardCache]
 }
 
 type leaderboardCache struct {
 	ordered     []LeaderboardEntry
 	indexByUser map[string]int
+	// tiebreak is the quiz's setting when the cache was built; changing the
+	// setting drops the cache.
+	tiebreak Tiebreak
 }
 
+// NewService builds a Service with default options. See New for wiring
+// optional subsystems.
 func NewService(quizzes QuizRepository, attempts AttemptRepository, fetcher QuestionsFetcher) *Service {
-	return &Service{
-		quizzes:          quizzes,
-		attempts:         attempts,
-		fetcher:          fetcher,
-		quizMetaCache:    make(map[string]QuizMetadata),
-		quizQuestions:    make(map[string][]Question),
-		leaderboardCache: make(map[string]*leaderboardCache),
-		attemptScores:    make(map[string]map[string]float64),
+	return NewServiceWithOptions(quizzes, attempts, fetcher, ServiceOptions{})
+}
+
+// NewServiceWithOptions is New with the stores passed positionally.
+func NewServiceWithOptions(quizzes QuizRepository, attempts AttemptRepository, fetcher QuestionsFetcher, options ServiceOptions) *Service {
+	revealPolicy := options.RevealPolicy
+	if revealPolicy == "" {
+		revealPolicy = RevealNever
+	}
+	selectionPolicy := options.SelectionPolicy
+	if selectionPolicy == nil {
+		selectionPolicy = quizkit.Staircase{Start: DifficultyMedium}
+	}
+	providerName := options.ProviderName
+	if providerName == nil {
+		providerName = func() string { return defaultProviderName }
+	}
+	now := options.Now
+	if now == nil {
+		now = time.Now
+	}
+	newQuizID := options.NewQuizID
+	if newQuizID == nil {
+		newQuizID = generateQuizID
+	}
+	newSeed := options.NewSeed
+	if newSeed == nil {
+		newSeed = generateSeed
+	}
+	streakLocation := options.StreakLocation
+	if streakLocation == nil {
+		streakLocation = time.UTC
 	}
+
+	service := &Service{
+		quizzes:            quizzes,
+		attempts:           attempts,
+		fetcher:            limitFetches(fetcher, options.MaxConcurrentFetches, options.FetchQueueTimeout),
+		revealPolicy:       revealPolicy,
+		speedBonus:         options.SpeedBonus,
+		dailyRepeatDays:    options.DailyRepeatDays,
+		now:                now,
+		newQuizID:          newQuizID,
+		newSeed:            newSeed,
+		notifier:           options.CompletionNotifier,
+		submitLimiter:      newSubmitLimiter(options.SubmitRateLimit, options.SubmitBurst),
+		selectionPolicy:    selectionPolicy,
+		streams:            newLeaderboardStreams(options.StreamBufferSize),
+		submissions:        newSubmissionStreams(),
+		notifyInterval:     options.LeaderboardNotifyInterval,
+		counters:           newServiceCounters(),
+		retirement:         options.Retirement,
+		retired:            &retiredPrompts{},
+		identityMailer:     options.IdentityMailer,
+		identities:         &identityChallenges{pending: make(map[string]identityChallenge)},
+		accountKey:         newHMACKey(options.AccountTokenKey),
+		accountTTL:         options.AccountTokenTTL,
+		requireLogin:       options.RequireLogin,
+		providerName:       providerName,
+		streakLocation:     streakLocation,
+		syncWindow:         options.OfflineSyncWindow,
+		exportResults:      options.ResultsExporter,
+		resultsMailer:      options.ResultsMailer,
+		restrictedSources:  slices.Clone(options.RestrictedSources),
+		contentHashKey:     newHMACKey(options.ContentHashKey),
+		requireContentHash: options.RequireContentHash,
+		retryGrace:         options.RetryGraceWindow,
+		pseudonymKey:       newHMACKey(nil),
+		quizMetaCache:      newLRUCache[QuizMetadata](options.Cache.MaxQuizzes, options.Cache.TTL, now),
+		quizQuestions:      newLRUCache[[]Question](options.Cache.MaxQuizzes, options.Cache.TTL, now),
+		leaderboardCache:   newLRUCache[*leaderboardCache](options.Cache.MaxLeaderboards, options.Cache.TTL, now),
+		attemptScores:      newLRUCache[map[string]float64](options.Cache.MaxAttemptScores, options.Cache.TTL, now),
+
+		categoryLeaderboards: newLRUCache[*leaderboardCache](options.Cache.MaxLeaderboards, options.Cache.TTL, now),
+
+		completionWatches: make(map[string][]*completionWatchState),
+		resultsTimers:     make(map[string]*time.Timer),
+	}
+	if options.ServeNonces {
+		service.nonces = newServeNonces(service.offlineSyncWindow())
+	}
+	service.registerInvalidationHooks()
+	service.fetcher = service.skipRetired(service.fetcher)
+	for _, provider := range options.FallbackProviders {
+		if provider.Fetch != nil {
+			service.fallbacks = append(service.fallbacks, FallbackProvider{Name: provider.Name, Fetch: service.skipRetired(provider.Fetch)})
+		}
+	}
+	return service
 }
 
 func (s *Service) CreateQuiz(ctx context.Context, questionCount int) (QuizMetadata, error) {
-	quizID := generateQuizID()
-	return s.createQuizWithID(ctx, quizID, questionCount)
+	return s.CreateQuizWithOptions(ctx, questionCount, QuizOptions{})
+}
+
+// QuizOptions carries optional settings for new quizzes.
+type QuizOptions struct {
+	// QuestionTimeLimit paces play; see QuizMetadata.QuestionTimeLimit.
+	QuestionTimeLimit time.Duration
+	// Difficulty draws fetched questions from one level only, as a one-level
+	// DifficultyMix would. Empty takes whatever the provider returns.
+	Difficulty Difficulty
+	// Category draws fetched questions from one OpenTriviaDB category, by ID;
+	// see ParseQuestionCategory. Zero takes any category.
+	Category int
+	// QuestionType draws fetched questions of one type, multiple or boolean.
+	// Empty takes either.
+	QuestionType string
+	// Draft creates the quiz as a draft that takes no answers until a host
+	// activates it; see SetQuizState.
+	Draft bool
+	// ClosesAt is when the quiz stops taking answers; see
+	// QuizMetadata.ClosesAt. It must be in the future.
+	ClosesAt time.Time
+}
+
+// CreateQuizWithOptions is CreateQuiz with options.
+func (s *Service) CreateQuizWithOptions(ctx context.Context, questionCount int, options QuizOptions) (QuizMetadata, error) {
+	quizID := s.newQuizID()
+	metadata, _, err := s.createQuizWithID(ctx, quizID, questionCount, options)
+	return metadata, err
+}
+
+// CreateQuizFromQuestions stores caller-supplied questions as a new quiz
+// instead of fetching them upstream.
+func (s *Service) CreateQuizFromQuestions(ctx context.Context, questions []Question) (QuizMetadata, error) {
+	return s.CreateCustomQuiz(ctx, questions, CustomQuizOptions{})
+}
+
+// CustomQuizOptions carries optional settings for quizzes built from
+// caller-supplied questions.
+type CustomQuizOptions struct {
+	// Author credits the questions to a user; see CreateAuthoredQuiz.
+	Author string
+	// Practice marks the quiz as a learning quiz; see QuizMetadata.Practice.
+	Practice bool
+	// Adaptive serves the questions one at a time by difficulty, as a pool
+	// fetched by CreateAdaptiveQuiz would be.
+	Adaptive bool
+	QuizOptions
+}
+
+// CreateCustomQuiz is CreateQuizFromQuestions with options.
+func (s *Service) CreateCustomQuiz(ctx context.Context, questions []Question, options CustomQuizOptions) (QuizMetadata, error) {
+	return s.createCustomQuiz(ctx, questions, options, QuizOrigin{Provider: ProviderCustom})
+}
+
+// createCustomQuiz stores questions the service did not fetch just now, under
+// the given origin.
+func (s *Service) createCustomQuiz(ctx context.Context, questions []Question, options CustomQuizOptions, origin QuizOrigin) (QuizMetadata, error) {
+	if len(questions) == 0 {
+		return QuizMetadata{}, fmt.Errorf("%w: at least one question is required", ErrInvalidQuestion)
+	}
+
+	seen := make(map[string]struct{}, len(questions))
+	for _, question := range questions {
+		if _, ok := seen[question.QuestionID]; ok {
+			return QuizMetadata{}, fmt.Errorf("%w: duplicate question %q", ErrInvalidQuestion, question.Question)
+		}
+		seen[question.QuestionID] = struct{}{}
+	}
+
+	if options.Adaptive {
+		if _, ok := s.attempts.(AttemptHistory); !ok {
+			return QuizMetadata{}, ErrUnsupported
+		}
+	}
+	lifecycle, err := s.lifecycleFor(options.QuizOptions)
+	if err != nil {
+		return QuizMetadata{}, err
+	}
+
+	var (
+		tracker          QuestionAuthorTracker
+		authorNormalized string
+	)
+	if options.Author != "" {
+		var err error
+		if tracker, authorNormalized, err = s.authorTracker(options.Author); err != nil {
+			return QuizMetadata{}, err
+		}
+	}
+
+	metadata := QuizMetadata{
+		QuizID:                 s.newQuizID(),
+		QuestionCount:          len(questions),
+		RequestedQuestionCount: len(questions),
+		CreatedAt:              s.now().UTC(),
+		Draft:                  lifecycle.Draft,
+		ClosesAt:               lifecycle.ClosesAt,
+		Practice:               options.Practice,
+		Adaptive:               options.Adaptive,
+		QuestionTimeLimit:      options.QuestionTimeLimit,
+		Origin:                 origin,
+	}
+	if err := s.storeQuiz(ctx, metadata, questions); err != nil {
+		return QuizMetadata{}, err
+	}
+
+	if tracker != nil {
+		if err := recordQuestionAuthor(ctx, tracker, authorNormalized, questions, metadata.CreatedAt); err != nil {
+			return QuizMetadata{}, err
+		}
+	}
+	return metadata, nil
 }
 
 func (s *Service) EnsureQuiz(ctx context.Context, quizID string, createIfMissing bool, questionCount int) (QuizMetadata, error) {
+	metadata, _, err := s.ensureQuiz(ctx, quizID, createIfMissing, questionCount, QuizOptions{})
+	return metadata, err
+}
+
+// ensureQuiz is EnsureQuiz that also reports whether this call created the quiz.
+func (s *Service) ensureQuiz(ctx context.Context, quizID string, createIfMissing bool, questionCount int, options QuizOptions) (QuizMetadata, bool, error) {
 	quizID = strings.TrimSpace(quizID)
 	if quizID == "" {
-		return QuizMetadata{}, ErrQuizNotFound
+		return QuizMetadata{}, false, ErrQuizNotFound
 	}
 
 	if metadata, ok := s.getCachedQuizMetadata(quizID); ok {
-		return metadata, nil
+		return metadata, false, nil
 	}
 
+	generation := s.cacheGeneration()
 	metadata, err := s.quizzes.GetQuizMetadata(ctx, quizID)
 	if err == nil {
-		s.setCachedQuizMetadata(metadata)
-		return metadata, nil
+		if s.unchangedSince(generation) {
+			s.setCachedQuizMetadata(metadata)
+		}
+		return metadata, false, nil
 	}
 	if !errors.Is(err, ErrQuizNotFound) {
-		return QuizMetadata{}, err
+		return QuizMetadata{}, false, err
 	}
 	if !createIfMissing {
-		return QuizMetadata{}, ErrQuizNotFound
+		return QuizMetadata{}, false, ErrQuizNotFound
 	}
 
-	return s.createQuizWithID(ctx, quizID, questionCount)
+	return s.createQuizWithID(ctx, quizID, questionCount, options)
 }
 
 func (s *Service) GetQuizQuestions(ctx context.Context, quizID string, createIfMissing bool, questionCount int) (QuizMetadata, []Question, error) {
+	metadata, questions, _, err := s.getQuizQuestions(ctx, quizID, createIfMissing, questionCount, QuizOptions{})
+	return metadata, questions, err
+}
+
+// GetOrCreateQuizQuestions loads a quiz, creating it with questionCount questions
+// when missing. created reports whether this call created it, so callers can
+// compare metadata.RequestedQuestionCount with what the provider delivered.
+func (s *Service) GetOrCreateQuizQuestions(ctx context.Context, quizID string, questionCount int) (QuizMetadata, []Question, bool, error) {
+	return s.getQuizQuestions(ctx, quizID, true, questionCount, QuizOptions{})
+}
+
+// GetOrCreateQuizQuestionsWithOptions is GetOrCreateQuizQuestions with options
+// for the quiz if this call creates it.
+func (s *Service) GetOrCreateQuizQuestionsWithOptions(ctx context.Context, quizID string, questionCount int, options QuizOptions) (QuizMetadata, []Question, bool, error) {
+	return s.getQuizQuestions(ctx, quizID, true, questionCount, options)
+}
+
+func (s *Service) getQuizQuestions(ctx context.Context, quizID string, createIfMissing bool, questionCount int, options QuizOptions) (QuizMetadata, []Question, bool, error) {
 	if metadata, questions, ok := s.getCachedQuiz(quizID); ok {
-		return metadata, questions, nil
+		return metadata, questions, false, nil
 	}
 
-	metadata, err := s.EnsureQuiz(ctx, quizID, createIfMissing, questionCount)
+	metadata, created, err := s.ensureQuiz(ctx, quizID, createIfMissing, questionCount, options)
 	if err != nil {
-		return QuizMetadata{}, nil, err
+		return QuizMetadata{}, nil, false, err
+	}
+	if created {
+		if _, questions, ok := s.getCachedQuiz(metadata.QuizID); ok {
+			return metadata, questions, true, nil
+		}
 	}
 
+	generation := s.cacheGeneration()
 	questions, err := s.quizzes.GetQuizQuestions(ctx, metadata.QuizID)
 	if err != nil {
-		return QuizMetadata{}, nil, err
+		return QuizMetadata{}, nil, false, err
 	}
-	s.setCachedQuiz(metadata, questions)
-	return metadata, questions, nil
+	if s.unchangedSince(generation) {
+		s.setCachedQuiz(metadata, questions)
+	}
+	return metadata, questions, created, nil
 }
 
 func (s *Service) EvaluateResponsesForQuiz(ctx context.Context, quizID string, responses []SubmittedResponse) ([]ResponseResult, error) {
-	_, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	metadata, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	lookup := make(map[string]Question, len(questions))
-	for _, question := range questions {
-		lookup[question.QuestionID] = question
-	}
-
-	results := make([]ResponseResult, 0, len(responses))
-	for _, response := range responses {
-		question, ok := lookup[response.QuestionID]
-		if !ok {
-			results = append(results, ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     StatusInvalidQuestion,
-			})
-			continue
-		}
-
-		letter := NormalizeLetter(response.Answer)
-		if letter == "" {
-			results = append(results, ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     StatusInvalidLetter,
-			})
-			continue
-		}
-
-		answerIndex := int(letter[0] - 'A')
-		if answerIndex < 0 || answerIndex >= len(question.Options) {
-			results = append(results, ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     StatusInvalidLetter,
-			})
-			continue
-		}
-
-		status := StatusIncorrect
-		if answerIndex == question.CorrectIndex {
-			status = StatusCorrect
-		}
-		results = append(results, ResponseResult{
-			QuestionID: response.QuestionID,
-			Status:     status,
-		})
+	stale, err := s.staleResponses(ctx, metadata.QuizID, responses)
+	if err != nil {
+		return nil, err
 	}
-
-	return results, nil
+	skipped := setAside(duplicateResponses(responses), stale)
+	fresh := withoutSetAside(responses, skipped)
+	results := quizkit.Evaluate(questions, fresh)
+	s.explainResults(metadata, results, fresh, questions)
+	return mergeSetAside(results, skipped, len(responses)), nil
 }
 
 func (s *Service) SubmitResponses(ctx context.Context, quizID, username string, responses []SubmittedResponse) ([]ResponseResult, error) {
@@ -150,19 +544,93 @@ func (s *Service) SubmitResponses(ctx context.Context, quizID, username string,
 		return nil, err
 	}
 
+	if err := s.checkAcceptsAnswers(metadata, responses); err != nil {
+		return nil, err
+	}
+
 	usernameNormalized, err := normalizeUsername(username)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkRoster(ctx, metadata.QuizID, usernameNormalized); err != nil {
+		return nil, err
+	}
+	if err := s.submitLimiter.allow(attemptScoresCacheKey(metadata.QuizID, usernameNormalized), len(responses), s.now()); err != nil {
+		return nil, err
+	}
+	stale, err := s.staleResponses(ctx, metadata.QuizID, responses)
+	if err != nil {
+		return nil, err
+	}
+	unverified, err := s.unverifiedAnswers(ctx, metadata, usernameNormalized, responses)
+	if err != nil {
+		return nil, err
+	}
+	skipped := setAside(duplicateResponses(responses), stale, unverified)
+	if s.nonces != nil {
+		skipped = setAside(skipped, s.nonces.spend(metadata.QuizID, usernameNormalized, responses, skipped, s.now()))
+	}
+	fresh := withoutSetAside(responses, skipped)
+	if len(fresh) == 0 && len(skipped) > 0 {
+		return mergeSetAside(nil, skipped, len(responses)), nil
+	}
+	if metadata.Adaptive {
+		if err := s.checkAdaptiveSequence(ctx, metadata, usernameNormalized, fresh); err != nil {
+			return nil, err
+		}
+	}
+
+	fresh, err = s.withSpeedBonus(ctx, metadata, usernameNormalized, fresh)
+	if err != nil {
+		return nil, err
+	}
 
-	results, err := s.attempts.SubmitResponses(ctx, metadata.QuizID, usernameNormalized, responses)
+	results, err := s.attempts.SubmitResponses(ctx, metadata.QuizID, usernameNormalized, fresh)
 	if err != nil {
 		return nil, err
 	}
 
-	s.updateCachedLeaderboardAfterSubmission(metadata.QuizID, usernameNormalized, results)
-	s.updateCachedAttemptScoresAfterSubmission(metadata.QuizID, usernameNormalized, results)
-	return results, nil
+	s.afterSubmission(ctx, metadata.QuizID, usernameNormalized, results)
+	s.graceRetries(ctx, metadata.QuizID, usernameNormalized, fresh, results)
+
+	if s.explainsResults(metadata) {
+		// Explaining is best-effort: scoring already persisted, so a lookup failure
+		// here must not turn a successful submission into an error.
+		if _, questions, err := s.GetQuizQuestions(ctx, metadata.QuizID, false, 0); err == nil {
+			s.explainResults(metadata, results, fresh, questions)
+		}
+	}
+	return mergeSetAside(results, skipped, len(responses)), nil
+}
+
+// afterSubmission brings counters, caches, live viewers, completion watches,
+// and streaks up to date with answers the store just scored.
+func (s *Service) afterSubmission(ctx context.Context, quizID, usernameNormalized string, results []ResponseResult) {
+	s.counters.recordSubmission(s.now(), usernameNormalized)
+	s.updateCachedLeaderboardAfterSubmission(quizID, usernameNormalized, results)
+	s.updateCachedAttemptScoresAfterSubmission(quizID, usernameNormalized, results)
+	s.leaderboardChanged(ctx, quizID, usernameNormalized, results)
+	s.publishSubmission(ctx, quizID, usernameNormalized, results)
+	s.checkCompletionWatches(ctx, quizID)
+	s.recordStreakDay(ctx, usernameNormalized, results)
+}
+
+// explainsResults reports whether incorrect answers to the quiz get any
+// explanation: the correct answer under RevealAfterAnswer, or distractor
+// feedback in practice quizzes and under RevealAfterAnswer (review mode).
+func (s *Service) explainsResults(metadata QuizMetadata) bool {
+	return s.revealPolicy == RevealAfterAnswer || metadata.Practice
+}
+
+// explainResults fills correct_letter/correct_text and feedback on incorrect
+// results as allowed by explainsResults. Correct results need no explanation.
+func (s *Service) explainResults(metadata QuizMetadata, results []ResponseResult, responses []SubmittedResponse, questions []Question) {
+	if s.revealPolicy == RevealAfterAnswer {
+		quizkit.RevealCorrectAnswers(results, questions)
+	}
+	if s.explainsResults(metadata) {
+		quizkit.ExplainIncorrectAnswers(results, responses, questions)
+	}
 }
 
 func (s *Service) GetLeaderboard(ctx context.Context, quizID string, limit int) ([]LeaderboardEntry, error) {
@@ -175,12 +643,19 @@ func (s *Service) GetLeaderboard(ctx context.Context, quizID string, limit int)
 		return applyLeaderboardLimit(entries, limit), nil
 	}
 
+	generation := s.cacheGeneration()
 	entries, err := s.attempts.GetLeaderboard(ctx, metadata.QuizID)
 	if err != nil {
 		return nil, err
 	}
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return nil, err
+	}
 
-	s.setCachedLeaderboard(metadata.QuizID, entries)
+	if s.unchangedSince(generation) {
+		s.setCachedLeaderboard(metadata.QuizID, entries, settings.Tiebreak)
+	}
 	return applyLeaderboardLimit(entries, limit), nil
 }
 
@@ -199,60 +674,146 @@ func (s *Service) GetAttemptScores(ctx context.Context, quizID, username string)
 		return scores, nil
 	}
 
+	generation := s.cacheGeneration()
 	scores, err := s.attempts.GetAttemptScores(ctx, metadata.QuizID, usernameNormalized)
 	if err != nil {
 		return nil, err
 	}
-	s.setCachedAttemptScores(metadata.QuizID, usernameNormalized, scores)
+	if s.unchangedSince(generation) {
+		s.setCachedAttemptScores(metadata.QuizID, usernameNormalized, scores)
+	}
 	return scores, nil
 }
 
+// VoidQuestion withdraws questionID from quizID mid-event. The store excludes
+// existing attempts on it from scores and rejects new answers; cached questions,
+// attempt scores and the leaderboard for the quiz are dropped so the next reads
+// are recomputed without it.
+func (s *Service) VoidQuestion(ctx context.Context, quizID, questionID string) error {
+	voider, ok := s.attempts.(QuestionVoider)
+	if !ok {
+		return ErrUnsupported
+	}
+
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return err
+	}
+
+	questionID = strings.TrimSpace(questionID)
+	if questionID == "" {
+		return ErrQuestionNotFound
+	}
+
+	if err := voider.VoidQuestion(ctx, metadata.QuizID, questionID, s.now().UTC()); err != nil {
+		return err
+	}
+	s.invalidateQuizScoring(metadata.QuizID)
+	s.publishLeaderboardSnapshot(ctx, metadata.QuizID)
+	// Voiding can complete a quiz for players who skipped only that question.
+	s.checkCompletionWatches(ctx, metadata.QuizID)
+	return nil
+}
+
+// ScoringPolicy reports the scoring rules applied by the configured stores.
+func (s *Service) ScoringPolicy() ScoringPolicy {
+	policy := quizkit.DefaultScoringPolicy()
+	policy.RevealPolicy = s.revealPolicy
+	if s.speedBonusEnabled() {
+		policy.SpeedBonus = s.speedBonus
+	}
+	return policy
+}
+
 func (s *Service) ListActiveQuizzes(ctx context.Context, limit int) ([]QuizMetadata, error) {
 	return s.quizzes.ListActiveQuizzes(ctx, limit)
 }
 
-func (s *Service) createQuizWithID(ctx context.Context, quizID string, questionCount int) (QuizMetadata, error) {
+// SearchQuestions returns stored questions whose prompt contains text.
+func (s *Service) SearchQuestions(ctx context.Context, text string, limit int) ([]Question, error) {
+	searcher, ok := s.quizzes.(QuestionSearcher)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return []Question{}, nil
+	}
+	return searcher.SearchQuestions(ctx, text, limit)
+}
+
+func (s *Service) createQuizWithID(ctx context.Context, quizID string, questionCount int, options QuizOptions) (QuizMetadata, bool, error) {
 	if s.fetcher == nil {
-		return QuizMetadata{}, errors.New("question fetcher is not configured")
+		return QuizMetadata{}, false, errors.New("question fetcher is not configured")
 	}
 
 	if metadata, ok := s.getCachedQuizMetadata(quizID); ok {
-		return metadata, nil
+		return metadata, false, nil
 	}
 
+	generation := s.cacheGeneration()
 	existing, err := s.quizzes.GetQuizMetadata(ctx, quizID)
 	if err == nil {
-		s.setCachedQuizMetadata(existing)
-		return existing, nil
+		if s.unchangedSince(generation) {
+			s.setCachedQuizMetadata(existing)
+		}
+		return existing, false, nil
 	}
 	if !errors.Is(err, ErrQuizNotFound) {
-		return QuizMetadata{}, err
+		return QuizMetadata{}, false, err
 	}
 
-	rawQuestions, err := s.fetcher(ctx, questionCount)
+	if err := validateQuestionFilter(options); err != nil {
+		return QuizMetadata{}, false, err
+	}
+	lifecycle, err := s.lifecycleFor(options)
 	if err != nil {
-		return QuizMetadata{}, err
+		return QuizMetadata{}, false, err
+	}
+	origin, builder := s.fetchedOrigin()
+	origin.Category = options.Category
+	origin.QuestionType = options.QuestionType
+	var questions []Question
+	if options.Difficulty != "" {
+		origin.DifficultyMix = DifficultyMix{options.Difficulty: questionCount}
+		questions, _, err = s.fetchMix(ctx, &origin, builder)
+		if err == nil && len(questions) == 0 {
+			err = fmt.Errorf("the provider returned no %s questions", options.Difficulty)
+		}
+	} else {
+		var rawQuestions []opentdb.RawQuestion
+		rawQuestions, err = s.fetchQuestions(ctx, &origin, questionCount, false)
+		questions = builder.Build(rawQuestions)
+	}
+	if err != nil {
+		return QuizMetadata{}, false, err
 	}
 
-	questions := BuildQuestions(rawQuestions)
-	now := time.Now().UTC()
+	now := s.now().UTC()
 	metadata := QuizMetadata{
-		QuizID:        quizID,
-		QuestionCount: len(questions),
-		CreatedAt:     now,
+		QuizID:                 quizID,
+		QuestionCount:          len(questions),
+		RequestedQuestionCount: questionCount,
+		CreatedAt:              now,
+		Draft:                  lifecycle.Draft,
+		ClosesAt:               lifecycle.ClosesAt,
+		QuestionTimeLimit:      options.QuestionTimeLimit,
+		Origin:                 origin,
 	}
 
-	if err := s.quizzes.CreateQuiz(ctx, metadata, questions); err != nil {
+	if err := s.storeQuiz(ctx, metadata, questions); err != nil {
+		generation := s.cacheGeneration()
 		existing, lookupErr := s.quizzes.GetQuizMetadata(ctx, quizID)
 		if lookupErr == nil {
-			s.setCachedQuizMetadata(existing)
-			return existing, nil
+			if s.unchangedSince(generation) {
+				s.setCachedQuizMetadata(existing)
+			}
+			return existing, false, nil
 		}
-		return QuizMetadata{}, err
+		return QuizMetadata{}, false, err
 	}
-
-	s.setCachedQuiz(metadata, questions)
-	return metadata, nil
+	return metadata, true, nil
 }
 
 func normalizeUsername(username string) (string, error) {
@@ -263,6 +824,11 @@ func normalizeUsername(username string) (string, error) {
 	return normalized, nil
 }
 
+// generateSeed never returns 0, which QuizOrigin uses for "no recorded seed".
+func generateSeed() int64 {
+	return rand.Int63n(math.MaxInt64-1) + 1
+}
+
 func generateQuizID() string {
 	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
 	const length = 10
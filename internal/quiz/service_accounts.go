@@ -0,0 +1,353 @@
+package quiz
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Accounts are the other way to own a username. A player registers it with a
+// password and logs in for a signed token (a JWT); requests carrying that
+// token act as its username, whatever username they name. A registered
+// username only takes answers sent with its token, and with RequireLogin no
+// username takes answers without one. Otherwise unregistered usernames work
+// as before. Accounts and verified identities stack: a username that has both
+// needs the account token and the player token.
+
+const (
+	minPasswordLength = 8
+	// maxPasswordLength bounds the work a login asks of the server.
+	maxPasswordLength      = 256
+	passwordIterations     = 600_000
+	passwordSaltBytes      = 16
+	passwordHashScheme     = "pbkdf2-sha256"
+	defaultAccountTokenTTL = 24 * time.Hour
+	accountTokenIssuer     = "quiz-app"
+)
+
+var (
+	// ErrInvalidPassword reports a password too short or too long to register.
+	ErrInvalidPassword = fmt.Errorf("password must be %d to %d characters", minPasswordLength, maxPasswordLength)
+	// ErrAccountExists reports a registration for a username already taken.
+	ErrAccountExists = errors.New("username is already registered")
+	// ErrInvalidCredentials reports a login with an unknown username or a
+	// wrong password; which one is not said.
+	ErrInvalidCredentials = errors.New("wrong username or password")
+	// ErrInvalidAccountToken reports an account token that is malformed,
+	// signed with another key, or expired.
+	ErrInvalidAccountToken = errors.New("invalid or expired account token")
+	// ErrLoginRequired reports answers for a registered username, or for any
+	// username under RequireLogin, sent without an account token.
+	ErrLoginRequired = errors.New("log in to submit answers as this username")
+	// ErrAccountMismatch reports a request naming a username other than the
+	// one its account token is for.
+	ErrAccountMismatch = errors.New("account token is for a different username")
+)
+
+// Account is a registered username.
+type Account struct {
+	// Username is normalized.
+	Username string
+	// PasswordHash is a salted PBKDF2-HMAC-SHA256 hash in the form
+	// "pbkdf2-sha256$iterations$salt$key", so the cost can be raised later
+	// without invalidating earlier hashes.
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+// Registered reports whether the account exists.
+func (a Account) Registered() bool {
+	return a.PasswordHash != ""
+}
+
+// AccountToken is a signed token from Login.
+type AccountToken struct {
+	Username  string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// PlayerCredentials are the tokens a request presents to act as a username:
+// an account token from Login, and a verified identity's player token.
+type PlayerCredentials struct {
+	AccountToken string
+	PlayerToken  string
+}
+
+// Register creates an account for username. A verified username can only be
+// registered with its player token, so registering cannot lock its owner out.
+func (s *Service) Register(ctx context.Context, username, password, playerToken string) (Account, error) {
+	store, ok := s.quizzes.(AccountStore)
+	if !ok {
+		return Account{}, ErrUnsupported
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return Account{}, err
+	}
+	if utf8.RuneCountInString(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return Account{}, ErrInvalidPassword
+	}
+	if err := s.AuthenticatePlayer(ctx, usernameNormalized, playerToken); err != nil {
+		return Account{}, err
+	}
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return Account{}, err
+	}
+	account := Account{
+		Username:     usernameNormalized,
+		PasswordHash: passwordHash,
+		CreatedAt:    s.now().UTC(),
+	}
+	if err := store.CreateAccount(ctx, account); err != nil {
+		return Account{}, err
+	}
+	return account, nil
+}
+
+// Login checks username's password and issues an account token.
+func (s *Service) Login(ctx context.Context, username, password string) (AccountToken, error) {
+	store, ok := s.quizzes.(AccountStore)
+	if !ok {
+		return AccountToken{}, ErrUnsupported
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return AccountToken{}, err
+	}
+	if len(password) > maxPasswordLength {
+		return AccountToken{}, ErrInvalidCredentials
+	}
+	account, err := store.GetAccount(ctx, usernameNormalized)
+	if err != nil {
+		return AccountToken{}, err
+	}
+	if !account.Registered() {
+		// Hash anyway, so an unknown username takes as long to refuse as a
+		// wrong password and logins cannot be timed to list accounts.
+		passwordMatches(password, unknownAccountHash())
+		return AccountToken{}, ErrInvalidCredentials
+	}
+	if !passwordMatches(password, account.PasswordHash) {
+		return AccountToken{}, ErrInvalidCredentials
+	}
+	return s.issueAccountToken(usernameNormalized)
+}
+
+// unknownAccountHash is the hash Login checks passwords for unknown usernames
+// against. No password matches it in practice; only the work counts.
+var unknownAccountHash = sync.OnceValue(func() string {
+	hash, _ := hashPassword("")
+	return hash
+})
+
+// AuthenticateSubmitter returns the username a request acts as. With an
+// account token that is the token's username, and username, when given, must
+// match it. Without one, username is taken as given unless it is registered
+// or RequireLogin is set. Either way a verified username also needs its
+// player token.
+func (s *Service) AuthenticateSubmitter(ctx context.Context, username string, credentials PlayerCredentials) (string, error) {
+	username = strings.TrimSpace(username)
+	if token := strings.TrimSpace(credentials.AccountToken); token != "" {
+		tokenUsername, err := s.AuthenticateAccount(token)
+		if err != nil {
+			return "", err
+		}
+		if username != "" {
+			usernameNormalized, err := normalizeUsername(username)
+			if err != nil {
+				return "", err
+			}
+			if usernameNormalized != tokenUsername {
+				return "", ErrAccountMismatch
+			}
+		}
+		username = tokenUsername
+	} else {
+		if s.requireLogin {
+			return "", ErrLoginRequired
+		}
+		registered, err := s.registered(ctx, username)
+		if err != nil {
+			return "", err
+		}
+		if registered {
+			return "", ErrLoginRequired
+		}
+	}
+
+	if err := s.AuthenticatePlayer(ctx, username, credentials.PlayerToken); err != nil {
+		return "", err
+	}
+	return username, nil
+}
+
+// registered reports whether username has an account. Stores without
+// accounts have none.
+func (s *Service) registered(ctx context.Context, username string) (bool, error) {
+	store, ok := s.quizzes.(AccountStore)
+	if !ok {
+		return false, nil
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return false, err
+	}
+	account, err := store.GetAccount(ctx, usernameNormalized)
+	if err != nil {
+		return false, err
+	}
+	return account.Registered(), nil
+}
+
+// accountTokenHeader is the encoded JWT header of every account token.
+var accountTokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+type accountTokenClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func (s *Service) issueAccountToken(usernameNormalized string) (AccountToken, error) {
+	ttl := s.accountTTL
+	if ttl <= 0 {
+		ttl = defaultAccountTokenTTL
+	}
+	now := s.now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(ttl)
+	claims, err := json.Marshal(accountTokenClaims{
+		Issuer:    accountTokenIssuer,
+		Subject:   usernameNormalized,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return AccountToken{}, err
+	}
+
+	signed := accountTokenHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return AccountToken{
+		Username:  usernameNormalized,
+		Token:     signed + "." + base64.RawURLEncoding.EncodeToString(s.signAccountToken(signed)),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// AuthenticateAccount checks an account token's signature and expiry and
+// returns its normalized username.
+func (s *Service) AuthenticateAccount(token string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidAccountToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, s.signAccountToken(parts[0]+"."+parts[1])) {
+		return "", ErrInvalidAccountToken
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+	}
+	if !decodeTokenPart(parts[0], &header) || header.Algorithm != "HS256" {
+		return "", ErrInvalidAccountToken
+	}
+	var claims accountTokenClaims
+	if !decodeTokenPart(parts[1], &claims) || claims.Issuer != accountTokenIssuer || claims.Subject == "" {
+		return "", ErrInvalidAccountToken
+	}
+	if !s.now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return "", ErrInvalidAccountToken
+	}
+	return claims.Subject, nil
+}
+
+func (s *Service) signAccountToken(signed string) []byte {
+	mac := hmac.New(sha256.New, s.accountKey)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+func decodeTokenPart(part string, dst any) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(part)
+	return err == nil && json.Unmarshal(raw, dst) == nil
+}
+
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2SHA256([]byte(password), salt, passwordIterations, sha256.Size)
+	return strings.Join([]string{
+		passwordHashScheme,
+		strconv.Itoa(passwordIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+func passwordMatches(password, passwordHash string) bool {
+	parts := strings.Split(passwordHash, "$")
+	if len(parts) != 4 || parts[0] != passwordHashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare(pbkdf2SHA256([]byte(password), salt, iterations, len(key)), key) == 1
+}
+
+// pbkdf2SHA256 is PBKDF2 (RFC 8018) with HMAC-SHA-256 as the pseudorandom
+// function.
+func pbkdf2SHA256(password, salt []byte, iterations, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	blockCount := (keyLength + prf.Size() - 1) / prf.Size()
+	key := make([]byte, 0, blockCount*prf.Size())
+	var (
+		index [4]byte
+		u     []byte
+	)
+	for block := 1; block <= blockCount; block++ {
+		binary.BigEndian.PutUint32(index[:], uint32(block))
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(index[:])
+		u = prf.Sum(u[:0])
+		t := append([]byte(nil), u...)
+		for range iterations - 1 {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLength]
+}
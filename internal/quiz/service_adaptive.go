@@ -0,0 +1,168 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"quiz-app/pkg/quizkit"
+)
+
+var (
+	// ErrNotAdaptive reports an adaptive-only operation on a regular quiz.
+	ErrNotAdaptive = errors.New("quiz is not adaptive")
+	// ErrAdaptiveQuiz reports a request for the whole question list of an
+	// adaptive quiz, whose questions are served one at a time.
+	ErrAdaptiveQuiz = errors.New("adaptive quiz questions are served one at a time")
+	// ErrOutOfSequence reports an adaptive answer to a question that was not
+	// served next.
+	ErrOutOfSequence = errors.New("answer is not for the question served next")
+)
+
+// difficultyTargeter is implemented by policies that aim for a difficulty, such
+// as quizkit.Staircase.
+type difficultyTargeter interface {
+	Target(pool []Question, history []quizkit.AnsweredQuestion) Difficulty
+}
+
+// AdaptiveStep is where one player stands in an adaptive quiz.
+type AdaptiveStep struct {
+	Quiz QuizMetadata
+	// Question is the next question to answer; unset when Done.
+	Question Question
+	Done     bool
+	// Target is the difficulty the policy aimed for. Question may differ when
+	// the pool has no unanswered question at that level.
+	Target        Difficulty
+	AnsweredCount int
+	CorrectCount  int
+	// Nonce is served with Question when the service issues serve nonces.
+	Nonce string
+}
+
+// CreateAdaptiveQuiz fetches a pool of questionCount questions and stores it as
+// an adaptive quiz.
+func (s *Service) CreateAdaptiveQuiz(ctx context.Context, questionCount int) (QuizMetadata, error) {
+	if _, ok := s.attempts.(AttemptHistory); !ok {
+		return QuizMetadata{}, ErrUnsupported
+	}
+	if s.fetcher == nil {
+		return QuizMetadata{}, errors.New("question fetcher is not configured")
+	}
+
+	origin, builder := s.fetchedOrigin()
+	rawQuestions, err := s.fetchQuestions(ctx, &origin, questionCount, false)
+	if err != nil {
+		return QuizMetadata{}, err
+	}
+	questions := builder.Build(rawQuestions)
+	if len(questions) == 0 {
+		return QuizMetadata{}, fmt.Errorf("%w: the provider returned no questions", ErrInvalidQuestion)
+	}
+
+	metadata := QuizMetadata{
+		QuizID:                 s.newQuizID(),
+		QuestionCount:          len(questions),
+		RequestedQuestionCount: questionCount,
+		CreatedAt:              s.now().UTC(),
+		Adaptive:               true,
+		Origin:                 origin,
+	}
+	if err := s.storeQuiz(ctx, metadata, questions); err != nil {
+		return QuizMetadata{}, err
+	}
+	return metadata, nil
+}
+
+// NextAdaptiveQuestion returns the question username should answer next in an
+// adaptive quiz. Calling it again before answering returns the same question,
+// and the speed bonus stays timed from the first call.
+func (s *Service) NextAdaptiveQuestion(ctx context.Context, quizID, username string) (AdaptiveStep, error) {
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return AdaptiveStep{}, err
+	}
+	metadata, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return AdaptiveStep{}, err
+	}
+	if !metadata.Adaptive {
+		return AdaptiveStep{}, ErrNotAdaptive
+	}
+
+	history, err := s.adaptiveHistory(ctx, metadata.QuizID, usernameNormalized)
+	if err != nil {
+		return AdaptiveStep{}, err
+	}
+
+	step := AdaptiveStep{Quiz: metadata, AnsweredCount: len(history)}
+	for _, answer := range history {
+		if answer.Correct {
+			step.CorrectCount++
+		}
+	}
+	if targeter, ok := s.selectionPolicy.(difficultyTargeter); ok {
+		step.Target = targeter.Target(questions, history)
+	}
+	next, ok := s.selectionPolicy.Next(questions, history)
+	step.Question, step.Done = next, !ok
+	if ok {
+		step.Nonce = s.RecordServed(ctx, metadata.QuizID, usernameNormalized, []string{next.QuestionID}, false)[next.QuestionID]
+		s.startSpeedBonusClock(ctx, metadata.QuizID, usernameNormalized, next.QuestionID)
+	}
+	return step, nil
+}
+
+// checkAdaptiveSequence lets an adaptive answer through only when it is for the
+// question served next. Answers to questions the user already answered pass so
+// retries still get already_answered.
+func (s *Service) checkAdaptiveSequence(ctx context.Context, metadata QuizMetadata, usernameNormalized string, responses []SubmittedResponse) error {
+	_, questions, err := s.GetQuizQuestions(ctx, metadata.QuizID, false, 0)
+	if err != nil {
+		return err
+	}
+	history, err := s.adaptiveHistory(ctx, metadata.QuizID, usernameNormalized)
+	if err != nil {
+		return err
+	}
+
+	answered := make(map[string]struct{}, len(history))
+	for _, answer := range history {
+		answered[answer.QuestionID] = struct{}{}
+	}
+	next, ok := s.selectionPolicy.Next(questions, history)
+
+	for _, response := range responses {
+		questionID := strings.TrimSpace(response.QuestionID)
+		if _, done := answered[questionID]; done {
+			continue
+		}
+		if !ok || questionID != next.QuestionID {
+			return ErrOutOfSequence
+		}
+		// Only one new answer per step: the one after it has not been picked yet.
+		ok = false
+	}
+	return nil
+}
+
+func (s *Service) adaptiveHistory(ctx context.Context, quizID, usernameNormalized string) ([]quizkit.AnsweredQuestion, error) {
+	lister, ok := s.attempts.(AttemptHistory)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	attempts, err := lister.ListAttempts(ctx, quizID, usernameNormalized)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]quizkit.AnsweredQuestion, 0, len(attempts))
+	for _, attempt := range attempts {
+		history = append(history, quizkit.AnsweredQuestion{
+			QuestionID: attempt.QuestionID,
+			Correct:    attempt.Score >= 1,
+		})
+	}
+	return history, nil
+}
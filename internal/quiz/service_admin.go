@@ -0,0 +1,89 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidQuizStatsQuery reports an unknown sort key or a negative page bound.
+var ErrInvalidQuizStatsQuery = errors.New("invalid quiz stats query")
+
+// QuizStatsSort names the field ListQuizStats orders by.
+type QuizStatsSort string
+
+const (
+	SortByCreatedAt     QuizStatsSort = "created_at"
+	SortByLastActivity  QuizStatsSort = "last_activity"
+	SortByAttempts      QuizStatsSort = "attempts"
+	SortByParticipants  QuizStatsSort = "participants"
+	SortByStorage       QuizStatsSort = "storage"
+	SortByQuestionCount QuizStatsSort = "question_count"
+)
+
+// QuizStatsQuery selects one page of ListQuizStats. An empty Sort means
+// SortByCreatedAt; a zero Limit returns every quiz after Offset.
+type QuizStatsQuery struct {
+	Sort       QuizStatsSort
+	Descending bool
+	Offset     int
+	Limit      int
+}
+
+// ListQuizStats returns one sorted page of per-quiz statistics and the total
+// number of quizzes. Ties are broken by quiz ID so pages are stable.
+func (s *Service) ListQuizStats(ctx context.Context, query QuizStatsQuery) ([]QuizStats, int, error) {
+	lister, ok := s.quizzes.(QuizStatsLister)
+	if !ok {
+		return nil, 0, ErrUnsupported
+	}
+	if query.Sort == "" {
+		query.Sort = SortByCreatedAt
+	}
+	less, ok := quizStatsLess[query.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: unknown sort %q", ErrInvalidQuizStatsQuery, query.Sort)
+	}
+	if query.Offset < 0 || query.Limit < 0 {
+		return nil, 0, fmt.Errorf("%w: offset and limit must not be negative", ErrInvalidQuizStatsQuery)
+	}
+
+	stats, err := lister.ListQuizStats(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if query.Descending {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return stats[i].QuizID < stats[j].QuizID
+	})
+
+	total := len(stats)
+	if query.Offset >= total {
+		return []QuizStats{}, total, nil
+	}
+	stats = stats[query.Offset:]
+	if query.Limit > 0 && len(stats) > query.Limit {
+		stats = stats[:query.Limit]
+	}
+	return stats, total, nil
+}
+
+var quizStatsLess = map[QuizStatsSort]func(a, b QuizStats) bool{
+	SortByCreatedAt:     func(a, b QuizStats) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	SortByLastActivity:  func(a, b QuizStats) bool { return a.LastActivityAt.Before(b.LastActivityAt) },
+	SortByAttempts:      func(a, b QuizStats) bool { return a.AttemptCount < b.AttemptCount },
+	SortByParticipants:  func(a, b QuizStats) bool { return a.ParticipantCount < b.ParticipantCount },
+	SortByStorage:       func(a, b QuizStats) bool { return a.StorageBytes < b.StorageBytes },
+	SortByQuestionCount: func(a, b QuizStats) bool { return a.QuestionCount < b.QuestionCount },
+}
@@ -0,0 +1,83 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidReport reports a question report that cannot be stored.
+var ErrInvalidReport = errors.New("invalid report")
+
+// maxReportReasonLength bounds free-text report reasons.
+const maxReportReasonLength = 500
+
+// CreateAuthoredQuiz is CreateQuizFromQuestions that also records author as the
+// writer of the questions, so they show up in QuestionPerformanceByAuthor.
+func (s *Service) CreateAuthoredQuiz(ctx context.Context, author string, questions []Question) (QuizMetadata, error) {
+	if strings.TrimSpace(author) == "" {
+		return QuizMetadata{}, ErrInvalidUsername
+	}
+	return s.CreateCustomQuiz(ctx, questions, CustomQuizOptions{Author: author})
+}
+
+// authorTracker checks that the store tracks authors before a quiz is created,
+// so an unsupported store does not leave an unattributed quiz behind.
+func (s *Service) authorTracker(author string) (QuestionAuthorTracker, string, error) {
+	tracker, ok := s.quizzes.(QuestionAuthorTracker)
+	if !ok {
+		return nil, "", ErrUnsupported
+	}
+	authorNormalized, err := normalizeUsername(author)
+	if err != nil {
+		return nil, "", err
+	}
+	return tracker, authorNormalized, nil
+}
+
+func recordQuestionAuthor(ctx context.Context, tracker QuestionAuthorTracker, authorNormalized string, questions []Question, createdAt time.Time) error {
+	questionIDs := make([]string, 0, len(questions))
+	for _, question := range questions {
+		questionIDs = append(questionIDs, question.QuestionID)
+	}
+	return tracker.RecordQuestionAuthor(ctx, authorNormalized, questionIDs, createdAt)
+}
+
+// QuestionPerformanceByAuthor reports attempt, correctness, and report counts
+// for every question author wrote, across all quizzes.
+func (s *Service) QuestionPerformanceByAuthor(ctx context.Context, author string) ([]QuestionPerformance, error) {
+	tracker, ok := s.quizzes.(QuestionAuthorTracker)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	authorNormalized, err := normalizeUsername(author)
+	if err != nil {
+		return nil, err
+	}
+	return tracker.QuestionPerformanceByAuthor(ctx, authorNormalized)
+}
+
+// ReportQuestion records username's complaint about a question. Reasons are
+// optional free text, trimmed and capped at maxReportReasonLength characters.
+func (s *Service) ReportQuestion(ctx context.Context, questionID, username, reason string) error {
+	reporter, ok := s.quizzes.(QuestionReporter)
+	if !ok {
+		return ErrUnsupported
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return err
+	}
+	questionID = strings.TrimSpace(questionID)
+	if questionID == "" {
+		return ErrUnknownQuestion
+	}
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxReportReasonLength {
+		return fmt.Errorf("%w: reason exceeds %d characters", ErrInvalidReport, maxReportReasonLength)
+	}
+	return reporter.ReportQuestion(ctx, questionID, usernameNormalized, reason, s.now().UTC())
+}
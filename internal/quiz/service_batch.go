@@ -0,0 +1,86 @@
+package quiz
+
+// A submitted batch may hold responses the service answers itself instead of
+// evaluating: repeats of a question already in the batch, and answers to stale
+// copies. These helpers set such responses aside by position and put their
+// results back afterwards so callers always see results in request order.
+
+// duplicateResponses marks every response after the first for the same
+// question ID as duplicate_in_request. Only the first answer in a batch is
+// evaluated, whatever the later ones say.
+func duplicateResponses(responses []SubmittedResponse) map[int]ResponseResult {
+	var duplicates map[int]ResponseResult
+	seen := make(map[string]bool, len(responses))
+	for idx, response := range responses {
+		if !seen[response.QuestionID] {
+			seen[response.QuestionID] = true
+			continue
+		}
+		if duplicates == nil {
+			duplicates = make(map[int]ResponseResult)
+		}
+		duplicates[idx] = ResponseResult{QuestionID: response.QuestionID, Status: StatusDuplicateInRequest}
+	}
+	return duplicates
+}
+
+// setAside combines the results of the checks above. A duplicate is reported
+// as such even when another check also failed: re-serving the question once is
+// enough. Among the other checks, earlier ones win.
+func setAside(duplicates map[int]ResponseResult, checks ...map[int]ResponseResult) map[int]ResponseResult {
+	combined := duplicates
+	for _, check := range checks {
+		if len(check) == 0 {
+			continue
+		}
+		if len(combined) == 0 {
+			combined = check
+			continue
+		}
+		merged := make(map[int]ResponseResult, len(combined)+len(check))
+		for position, result := range check {
+			merged[position] = result
+		}
+		// Results already combined came from duplicates or earlier checks.
+		for position, result := range combined {
+			merged[position] = result
+		}
+		combined = merged
+	}
+	return combined
+}
+
+// withoutSetAside returns the responses that still need evaluating, in order.
+func withoutSetAside(responses []SubmittedResponse, skipped map[int]ResponseResult) []SubmittedResponse {
+	if len(skipped) == 0 {
+		return responses
+	}
+	fresh := make([]SubmittedResponse, 0, len(responses)-len(skipped))
+	for idx, response := range responses {
+		if _, ok := skipped[idx]; !ok {
+			fresh = append(fresh, response)
+		}
+	}
+	return fresh
+}
+
+// mergeSetAside puts set-aside results back at their response positions so
+// results stay in request order.
+func mergeSetAside(results []ResponseResult, skipped map[int]ResponseResult, total int) []ResponseResult {
+	if len(skipped) == 0 {
+		return results
+	}
+	merged := make([]ResponseResult, 0, total)
+	next := 0
+	for idx := 0; idx < total; idx++ {
+		if result, ok := skipped[idx]; ok {
+			merged = append(merged, result)
+			continue
+		}
+		if next < len(results) {
+			merged = append(merged, results[next])
+			next++
+		}
+	}
+	return merged
+}
@@ -0,0 +1,67 @@
+package quiz
+
+import (
+	"context"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// SpeedBonus awards decaying extra points for fast correct answers, measured
+// from when the server first served the question to the player. Only adaptive
+// quizzes earn it: their questions are served one at a time by
+// NextAdaptiveQuestion, while any other quiz can be read in full, without a
+// username, long before its timed serve.
+type SpeedBonus = quizkit.SpeedBonus
+
+// BonusCurve shapes how a speed bonus decays over its window.
+type BonusCurve = quizkit.BonusCurve
+
+const (
+	BonusLinear    = quizkit.BonusLinear
+	BonusQuadratic = quizkit.BonusQuadratic
+)
+
+// ParseBonusCurve maps a flag/config value to a BonusCurve. Empty means linear.
+func ParseBonusCurve(value string) (BonusCurve, error) {
+	return quizkit.ParseBonusCurve(value)
+}
+
+// speedBonusEnabled reports whether answers can earn a speed bonus: one is
+// configured and the store remembers when questions were served.
+func (s *Service) speedBonusEnabled() bool {
+	_, tracked := s.attempts.(QuestionServeTracker)
+	return tracked && s.speedBonus.Enabled()
+}
+
+// startSpeedBonusClock notes when an adaptive question was first served to
+// the user. Failures are ignored like the rest of RecordServed.
+func (s *Service) startSpeedBonusClock(ctx context.Context, quizID, usernameNormalized, questionID string) {
+	if s.speedBonusEnabled() {
+		_ = s.attempts.(QuestionServeTracker).RecordServes(ctx, quizID, usernameNormalized, []string{questionID}, s.now().UTC())
+	}
+}
+
+// withSpeedBonus returns a copy of responses with Bonus set from how long each
+// question had been served to the user when the server received the answer.
+// Answers on quizzes that are not adaptive, and to questions with no serve
+// record, earn no bonus. A signed AnsweredAt is not used: the client picks it,
+// and the signature check tolerates it reaching back before the serve time.
+func (s *Service) withSpeedBonus(ctx context.Context, metadata QuizMetadata, usernameNormalized string, responses []SubmittedResponse) ([]SubmittedResponse, error) {
+	if !s.speedBonusEnabled() || !metadata.Adaptive || len(responses) == 0 {
+		return responses, nil
+	}
+	served, err := s.attempts.(QuestionServeTracker).ServeTimes(ctx, metadata.QuizID, usernameNormalized)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	bonused := make([]SubmittedResponse, len(responses))
+	for idx, response := range responses {
+		if servedAt, ok := served[response.QuestionID]; ok {
+			response.Bonus = s.speedBonus.Points(now.Sub(servedAt))
+		}
+		bonused[idx] = response
+	}
+	return bonused, nil
+}
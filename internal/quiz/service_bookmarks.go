@@ -0,0 +1,94 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+)
+
+// ErrNoBookmarks reports a practice quiz request from a user with no bookmarks.
+var ErrNoBookmarks = errors.New("no bookmarked questions")
+
+func (s *Service) bookmarkStore() (BookmarkStore, error) {
+	store, ok := s.quizzes.(BookmarkStore)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return store, nil
+}
+
+// AddBookmark saves questionID for username. Bookmarking twice is a no-op.
+func (s *Service) AddBookmark(ctx context.Context, username, questionID string) error {
+	store, err := s.bookmarkStore()
+	if err != nil {
+		return err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return err
+	}
+	questionID = strings.TrimSpace(questionID)
+	if questionID == "" {
+		return ErrUnknownQuestion
+	}
+	return store.AddBookmark(ctx, usernameNormalized, questionID, s.now().UTC())
+}
+
+// RemoveBookmark deletes questionID from username's bookmarks, if present.
+func (s *Service) RemoveBookmark(ctx context.Context, username, questionID string) error {
+	store, err := s.bookmarkStore()
+	if err != nil {
+		return err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return err
+	}
+	return store.RemoveBookmark(ctx, usernameNormalized, strings.TrimSpace(questionID))
+}
+
+// ListBookmarks returns username's bookmarks, newest first.
+func (s *Service) ListBookmarks(ctx context.Context, username string) ([]Bookmark, error) {
+	store, err := s.bookmarkStore()
+	if err != nil {
+		return nil, err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListBookmarks(ctx, usernameNormalized)
+}
+
+// CreatePracticeQuiz assembles a new quiz from up to questionCount of
+// username's bookmarked questions, picked at random. A non-positive
+// questionCount uses every bookmark. Question IDs are kept, so answers in the
+// practice quiz line up with the original questions. The quiz is marked
+// Practice, so wrong answers come back with the author's feedback.
+func (s *Service) CreatePracticeQuiz(ctx context.Context, username string, questionCount int) (QuizMetadata, []Question, error) {
+	bookmarks, err := s.ListBookmarks(ctx, username)
+	if err != nil {
+		return QuizMetadata{}, nil, err
+	}
+	if len(bookmarks) == 0 {
+		return QuizMetadata{}, nil, ErrNoBookmarks
+	}
+
+	questions := make([]Question, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		questions = append(questions, bookmark.Question)
+	}
+	rand.Shuffle(len(questions), func(i, j int) {
+		questions[i], questions[j] = questions[j], questions[i]
+	})
+	if questionCount > 0 && questionCount < len(questions) {
+		questions = questions[:questionCount]
+	}
+
+	metadata, err := s.createCustomQuiz(ctx, questions, CustomQuizOptions{Practice: true}, QuizOrigin{Provider: ProviderBookmarks})
+	if err != nil {
+		return QuizMetadata{}, nil, err
+	}
+	return metadata, questions, nil
+}
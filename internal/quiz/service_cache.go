@@ -1,21 +1,33 @@
 package quiz
 
-import "time"
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"quiz-app/pkg/quizkit"
+)
 
 // Cache-specific helpers are isolated here so service.go can focus on orchestration.
+//
+// Every cache is an lruCache, so handlers can share them safely. Leaderboards
+// and attempt scores are patched in place after each submission, so readers
+// get copies rather than the cached values.
 
 func (s *Service) getCachedQuizMetadata(quizID string) (QuizMetadata, bool) {
-	metadata, ok := s.quizMetaCache[quizID]
-	return metadata, ok
+	return s.quizMetaCache.get(quizID)
 }
 
 func (s *Service) setCachedQuizMetadata(metadata QuizMetadata) {
-	s.quizMetaCache[metadata.QuizID] = metadata
+	s.quizMetaCache.put(metadata.QuizID, metadata)
 }
 
+// getCachedQuiz returns the cached questions as stored; callers must not
+// modify them.
 func (s *Service) getCachedQuiz(quizID string) (QuizMetadata, []Question, bool) {
-	metadata, metaOK := s.quizMetaCache[quizID]
-	questions, questionsOK := s.quizQuestions[quizID]
+	metadata, metaOK := s.quizMetaCache.get(quizID)
+	questions, questionsOK := s.quizQuestions.get(quizID)
 	if !metaOK || !questionsOK {
 		return QuizMetadata{}, nil, false
 	}
@@ -23,91 +35,161 @@ func (s *Service) getCachedQuiz(quizID string) (QuizMetadata, []Question, bool)
 }
 
 func (s *Service) setCachedQuiz(metadata QuizMetadata, questions []Question) {
-	s.quizMetaCache[metadata.QuizID] = metadata
-	s.quizQuestions[metadata.QuizID] = questions
+	s.quizMetaCache.put(metadata.QuizID, metadata)
+	s.quizQuestions.put(metadata.QuizID, questions)
 }
 
 func (s *Service) getCachedLeaderboard(quizID string) ([]LeaderboardEntry, bool) {
-	cache, ok := s.leaderboardCache[quizID]
-	if !ok || cache == nil {
-		return nil, false
-	}
-	// Return direct cached memory for simplicity; caller treats result as read-only.
-	return cache.ordered, true
+	var entries []LeaderboardEntry
+	ok := s.leaderboardCache.with(quizID, func(cache *leaderboardCache) {
+		entries = slices.Clone(cache.ordered)
+	})
+	return entries, ok
 }
 
 func (s *Service) getCachedAttemptScores(quizID, usernameNormalized string) (map[string]float64, bool) {
-	scores, ok := s.attemptScores[attemptScoresCacheKey(quizID, usernameNormalized)]
-	// Map is shared cache state; callers should only read from the returned map.
+	var scores map[string]float64
+	ok := s.attemptScores.with(attemptScoresCacheKey(quizID, usernameNormalized), func(cached map[string]float64) {
+		scores = maps.Clone(cached)
+	})
 	return scores, ok
 }
 
+// setCachedAttemptScores caches a copy of scores, which the caller keeps.
 func (s *Service) setCachedAttemptScores(quizID, usernameNormalized string, scores map[string]float64) {
-	if scores == nil {
-		scores = make(map[string]float64)
+	cached := maps.Clone(scores)
+	if cached == nil {
+		cached = make(map[string]float64)
 	}
-	s.attemptScores[attemptScoresCacheKey(quizID, usernameNormalized)] = scores
+	s.attemptScores.put(attemptScoresCacheKey(quizID, usernameNormalized), cached)
+}
+
+// setCachedLeaderboard caches a copy of entries, which the caller keeps.
+func (s *Service) setCachedLeaderboard(quizID string, entries []LeaderboardEntry, tiebreak Tiebreak) {
+	s.leaderboardCache.put(quizID, newLeaderboardCache(slices.Clone(entries), tiebreak))
 }
 
-func (s *Service) setCachedLeaderboard(quizID string, entries []LeaderboardEntry) {
+// CacheStats reports each cache's size and lifetime counters, by cache name.
+func (s *Service) CacheStats() map[string]CacheStats {
+	return map[string]CacheStats{
+		"quiz_metadata":         s.quizMetaCache.snapshot(),
+		"quiz_questions":        s.quizQuestions.snapshot(),
+		"leaderboards":          s.leaderboardCache.snapshot(),
+		"category_leaderboards": s.categoryLeaderboards.snapshot(),
+		"attempt_scores":        s.attemptScores.snapshot(),
+	}
+}
+
+func newLeaderboardCache(entries []LeaderboardEntry, tiebreak Tiebreak) *leaderboardCache {
 	indexByUser := make(map[string]int, len(entries))
 	for idx := range entries {
 		indexByUser[entries[idx].Username] = idx
 	}
-
-	s.leaderboardCache[quizID] = &leaderboardCache{
+	return &leaderboardCache{
 		ordered:     entries,
 		indexByUser: indexByUser,
+		tiebreak:    tiebreak,
 	}
 }
 
 func (s *Service) updateCachedAttemptScoresAfterSubmission(quizID, usernameNormalized string, results []ResponseResult) {
 	// Keep writes cheap: only patch attempt-score cache if this user+quiz cache was
 	// already materialized by a previous read. Otherwise, it is rebuilt from DB on demand.
-	scores, ok := s.getCachedAttemptScores(quizID, usernameNormalized)
+	s.attemptScores.with(attemptScoresCacheKey(quizID, usernameNormalized), func(scores map[string]float64) {
+		for _, result := range results {
+			switch result.Status {
+			case StatusCorrect:
+				scores[result.QuestionID] = 1.0 + result.Bonus
+			case StatusPartiallyCorrect:
+				scores[result.QuestionID] = result.Credit
+			case StatusIncorrect:
+				scores[result.QuestionID] = 0.0
+			case StatusAlreadyAnswered:
+				if result.AttemptScore != nil {
+					scores[result.QuestionID] = *result.AttemptScore
+				}
+			}
+		}
+	})
+}
+
+func (s *Service) updateCachedLeaderboardAfterSubmission(quizID, username string, results []ResponseResult) {
+	now := s.now().UTC()
+	s.leaderboardCache.with(quizID, func(cache *leaderboardCache) {
+		patchLeaderboard(cache, username, results, now)
+	})
+	s.updateCachedCategoryLeaderboardsAfterSubmission(quizID, username, results, now)
+}
+
+// updateCachedCategoryLeaderboardsAfterSubmission patches each of the quiz's
+// cached category leaderboards with the results for questions in that
+// category. Without the quiz's questions cached there is no way to tell which
+// results belong where, so the category caches are dropped instead.
+func (s *Service) updateCachedCategoryLeaderboardsAfterSubmission(quizID, username string, results []ResponseResult, now time.Time) {
+	questions, ok := s.quizQuestions.get(quizID)
 	if !ok {
+		s.invalidateCategoryLeaderboards(quizID)
 		return
 	}
 
-	for _, result := range results {
-		switch result.Status {
-		case StatusCorrect:
-			scores[result.QuestionID] = 1.0
-		case StatusIncorrect:
-			scores[result.QuestionID] = 0.0
-		case StatusAlreadyAnswered:
-			if result.AttemptScore != nil {
-				scores[result.QuestionID] = *result.AttemptScore
+	categoryByQuestion := make(map[string]string, len(questions))
+	for _, question := range questions {
+		categoryByQuestion[question.QuestionID] = strings.ToLower(question.Category)
+	}
+	prefix := categoryLeaderboardKey(quizID, "")
+	s.categoryLeaderboards.each(func(key string, cache *leaderboardCache) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return true
+		}
+		category := strings.TrimPrefix(key, prefix)
+		inCategory := make([]ResponseResult, 0, len(results))
+		for _, result := range results {
+			if categoryByQuestion[result.QuestionID] == category {
+				inCategory = append(inCategory, result)
 			}
 		}
-	}
+		patchLeaderboard(cache, username, inCategory, now)
+		return true
+	})
 }
 
-func (s *Service) updateCachedLeaderboardAfterSubmission(quizID, username string, results []ResponseResult) {
-	cache, ok := s.leaderboardCache[quizID]
-	if !ok || cache == nil {
-		return
-	}
+// invalidateCategoryLeaderboards drops every cached category leaderboard of
+// quizID.
+func (s *Service) invalidateCategoryLeaderboards(quizID string) {
+	prefix := categoryLeaderboardKey(quizID, "")
+	s.categoryLeaderboards.each(func(key string, _ *leaderboardCache) bool {
+		return !strings.HasPrefix(key, prefix)
+	})
+}
 
-	// Maintain ordering incrementally so we do not rerun DB SUM/GROUP BY on every submit.
-	// Current scoring model is binary (correct=1, incorrect=0), but this can be swapped
-	// to use result.AttemptScore when richer per-question scoring is introduced.
-	newAnswers := 0
-	scoreDelta := 0.0
+// patchLeaderboard folds one submission's results, made at now, into a cached
+// leaderboard. The caller holds the cache's lock.
+// scoreResults counts the answers results stored and the points they earned.
+// Correct answers are worth 1 plus any speed bonus, partially correct ones
+// their credit, and incorrect ones 0.
+func scoreResults(results []ResponseResult) (answered int, score float64) {
 	for _, result := range results {
 		switch result.Status {
 		case StatusCorrect:
-			newAnswers++
-			scoreDelta += 1.0
+			answered++
+			score += 1.0 + result.Bonus
+		case StatusPartiallyCorrect:
+			answered++
+			score += result.Credit
 		case StatusIncorrect:
-			newAnswers++
+			answered++
 		}
 	}
+	return answered, score
+}
+
+func patchLeaderboard(cache *leaderboardCache, username string, results []ResponseResult, now time.Time) {
+	// Maintain ordering incrementally so we do not rerun DB SUM/GROUP BY on every submit.
+	newAnswers, scoreDelta := scoreResults(results)
 	if newAnswers == 0 {
 		return
 	}
 
-	now := time.Now().UTC()
 	idx, exists := cache.indexByUser[username]
 	if !exists {
 		cache.ordered = append(cache.ordered, LeaderboardEntry{
@@ -118,54 +200,67 @@ func (s *Service) updateCachedLeaderboardAfterSubmission(quizID, username string
 		})
 		idx = len(cache.ordered) - 1
 		cache.indexByUser[username] = idx
-		s.bubbleLeaderboard(cache, idx)
+		bubbleLeaderboard(cache, idx)
 		return
 	}
 
 	cache.ordered[idx].TotalScore += scoreDelta
 	cache.ordered[idx].AnsweredCount += newAnswers
 	cache.ordered[idx].LastSubmissionAt = now
-	s.bubbleLeaderboard(cache, idx)
+	bubbleLeaderboard(cache, idx)
+}
+
+// dropCachedQuiz drops everything cached for quizID: its metadata and every
+// view invalidateQuizScoring drops.
+func (s *Service) dropCachedQuiz(quizID string) {
+	s.quizMetaCache.delete(quizID)
+	s.invalidateQuizScoring(quizID)
+}
+
+// invalidateQuizScoring drops every cached view derived from a quiz's questions
+// and attempts. It is used when scoring changes retroactively, where patching
+// the caches in place would be error-prone; the next read rebuilds from the store.
+func (s *Service) invalidateQuizScoring(quizID string) {
+	s.quizQuestions.delete(quizID)
+	s.leaderboardCache.delete(quizID)
+	s.invalidateCategoryLeaderboards(quizID)
+
+	prefix := attemptScoresCacheKey(quizID, "")
+	s.attemptScores.each(func(key string, _ map[string]float64) bool {
+		return !strings.HasPrefix(key, prefix)
+	})
 }
 
 func attemptScoresCacheKey(quizID, usernameNormalized string) string {
 	return quizID + "::" + usernameNormalized
 }
 
-func (s *Service) bubbleLeaderboard(cache *leaderboardCache, idx int) {
+// categoryLeaderboardKey keys categoryLeaderboards by quiz and lowercased
+// category, so differently cased requests share one cache entry.
+func categoryLeaderboardKey(quizID, category string) string {
+	return quizID + "::" + strings.ToLower(category)
+}
+
+func bubbleLeaderboard(cache *leaderboardCache, idx int) {
 	// Only one user row changes per submission, so local bubbling is enough to
 	// restore ordering in O(distance moved) instead of re-sorting the full slice.
-	for idx > 0 && leaderboardBefore(cache.ordered[idx], cache.ordered[idx-1]) {
-		s.swapLeaderboardEntries(cache, idx, idx-1)
+	for idx > 0 && quizkit.RanksBeforeBy(cache.tiebreak, cache.ordered[idx], cache.ordered[idx-1]) {
+		swapLeaderboardEntries(cache, idx, idx-1)
 		idx--
 	}
 
-	for idx+1 < len(cache.ordered) && leaderboardBefore(cache.ordered[idx+1], cache.ordered[idx]) {
-		s.swapLeaderboardEntries(cache, idx, idx+1)
+	for idx+1 < len(cache.ordered) && quizkit.RanksBeforeBy(cache.tiebreak, cache.ordered[idx+1], cache.ordered[idx]) {
+		swapLeaderboardEntries(cache, idx, idx+1)
 		idx++
 	}
 }
 
-func (s *Service) swapLeaderboardEntries(cache *leaderboardCache, i, j int) {
+func swapLeaderboardEntries(cache *leaderboardCache, i, j int) {
 	cache.ordered[i], cache.ordered[j] = cache.ordered[j], cache.ordered[i]
 	cache.indexByUser[cache.ordered[i].Username] = i
 	cache.indexByUser[cache.ordered[j].Username] = j
 }
 
-func leaderboardBefore(a, b LeaderboardEntry) bool {
-	// Ranking policy:
-	// 1) higher score first
-	// 2) earlier final submission wins ties
-	// 3) username lexical order for deterministic output
-	if a.TotalScore != b.TotalScore {
-		return a.TotalScore > b.TotalScore
-	}
-	if !a.LastSubmissionAt.Equal(b.LastSubmissionAt) {
-		return a.LastSubmissionAt.Before(b.LastSubmissionAt)
-	}
-	return a.Username < b.Username
-}
-
 func applyLeaderboardLimit(entries []LeaderboardEntry, limit int) []LeaderboardEntry {
 	if limit <= 0 || limit >= len(entries) {
 		return entries
@@ -0,0 +1,162 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Daily quizzes are created lazily: the first request on a given UTC day builds
+// that day's quiz and every later request reuses it.
+
+const (
+	dailyQuizPrefix        = "daily-"
+	dailyQuestionCount     = 10
+	dailyMaxFetchRounds    = 3
+	dailyFetchBatchMaximum = 50
+)
+
+// DailyQuizID returns the quiz ID used for the daily quiz of day (in UTC).
+func DailyQuizID(day time.Time) string {
+	return dailyQuizPrefix + day.UTC().Format("2006-01-02")
+}
+
+// GetDailyQuiz returns today's daily quiz, creating it on first use. When the
+// store tracks question usage and a repeat window is configured, questions used
+// by daily quizzes within the window are skipped.
+func (s *Service) GetDailyQuiz(ctx context.Context) (QuizMetadata, error) {
+	now := s.now().UTC()
+	quizID := DailyQuizID(now)
+
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err == nil {
+		return metadata, nil
+	}
+	if !errors.Is(err, ErrQuizNotFound) {
+		return QuizMetadata{}, err
+	}
+	if s.fetcher == nil {
+		return QuizMetadata{}, errors.New("question fetcher is not configured")
+	}
+
+	tracker, tracksUsage := s.quizzes.(QuestionUsageTracker)
+	excluded := newRepeatFilter(nil)
+	if tracksUsage && s.dailyRepeatDays > 0 {
+		since := now.AddDate(0, 0, -s.dailyRepeatDays)
+		recent, err := tracker.RecentlyUsedQuestions(ctx, since)
+		if err != nil {
+			return QuizMetadata{}, err
+		}
+		excluded = newRepeatFilter(recent)
+	}
+
+	origin, builder := s.fetchedOrigin()
+	questions, err := s.fetchUnusedQuestions(ctx, &origin, dailyQuestionCount, excluded, builder)
+	if err != nil {
+		return QuizMetadata{}, err
+	}
+
+	metadata = QuizMetadata{
+		QuizID:                 quizID,
+		QuestionCount:          len(questions),
+		RequestedQuestionCount: dailyQuestionCount,
+		CreatedAt:              now,
+		Origin:                 origin,
+	}
+	if err := s.storeQuiz(ctx, metadata, questions); err != nil {
+		return QuizMetadata{}, err
+	}
+
+	if tracksUsage {
+		questionIDs := make([]string, 0, len(questions))
+		for _, question := range questions {
+			questionIDs = append(questionIDs, question.QuestionID)
+		}
+		if err := tracker.RecordQuestionUsage(ctx, quizID, questionIDs, now); err != nil {
+			return QuizMetadata{}, err
+		}
+	}
+	return metadata, nil
+}
+
+// fetchUnusedQuestions over-fetches from the provider and drops excluded and
+// duplicate questions. If the provider keeps returning recent questions, the
+// quiz is built from whatever fresh questions were found rather than failing.
+func (s *Service) fetchUnusedQuestions(ctx context.Context, origin *QuizOrigin, count int, excluded repeatFilter, builder *QuestionBuilder) ([]Question, error) {
+	batch := count
+	if !excluded.empty() {
+		batch = min(count*2, dailyFetchBatchMaximum)
+	}
+
+	selected := make([]Question, 0, count)
+	seen := newRepeatFilter(nil)
+	for round := 0; round < dailyMaxFetchRounds && len(selected) < count; round++ {
+		raw, err := s.fetchQuestions(ctx, origin, batch, round > 0)
+		if err != nil {
+			if len(selected) > 0 {
+				break
+			}
+			return nil, err
+		}
+
+		for _, question := range builder.Build(raw) {
+			if excluded.matches(question) || seen.matches(question) {
+				continue
+			}
+			seen.add(question)
+			selected = append(selected, question)
+			if len(selected) == count {
+				break
+			}
+		}
+
+		if excluded.empty() {
+			break
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.New("no unused questions available for daily quiz")
+	}
+	return selected, nil
+}
+
+// repeatFilter matches questions by ID or by normalized prompt, since the same
+// upstream question gets a different ID whenever its options shuffle differently.
+type repeatFilter struct {
+	ids     map[string]struct{}
+	prompts map[string]struct{}
+}
+
+func newRepeatFilter(questions []Question) repeatFilter {
+	filter := repeatFilter{
+		ids:     make(map[string]struct{}, len(questions)),
+		prompts: make(map[string]struct{}, len(questions)),
+	}
+	for _, question := range questions {
+		filter.add(question)
+	}
+	return filter
+}
+
+func (f repeatFilter) add(question Question) {
+	f.ids[question.QuestionID] = struct{}{}
+	f.prompts[normalizePrompt(question.Question)] = struct{}{}
+}
+
+func (f repeatFilter) matches(question Question) bool {
+	if _, ok := f.ids[question.QuestionID]; ok {
+		return true
+	}
+	_, ok := f.prompts[normalizePrompt(question.Question)]
+	return ok
+}
+
+func (f repeatFilter) empty() bool {
+	return len(f.ids) == 0
+}
+
+func normalizePrompt(prompt string) string {
+	return strings.ToLower(strings.Join(strings.Fields(prompt), " "))
+}
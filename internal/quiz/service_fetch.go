@@ -0,0 +1,173 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"time"
+
+	"quiz-app/internal/opentdb"
+)
+
+// defaultFetchQueueTimeout is how long a quiz creation waits for a free
+// provider slot when ServiceOptions.FetchQueueTimeout is zero.
+const defaultFetchQueueTimeout = 2 * time.Second
+
+// ErrProviderBusy reports a fetch that could not start because every provider
+// slot stayed taken for the whole queueing timeout.
+var ErrProviderBusy = errors.New("question provider busy")
+
+// limitFetches bounds how many calls to fetcher run at once. A call waits up
+// to queueTimeout for a slot and then fails with ErrProviderBusy, so a burst
+// of quiz creations queues briefly and sheds the rest instead of opening
+// unbounded upstream requests. A non-positive limit leaves fetcher as is.
+func limitFetches(fetcher QuestionsFetcher, limit int, queueTimeout time.Duration) QuestionsFetcher {
+	if fetcher == nil || limit <= 0 {
+		return fetcher
+	}
+	if queueTimeout <= 0 {
+		queueTimeout = defaultFetchQueueTimeout
+	}
+	slots := make(chan struct{}, limit)
+	return func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		wait := time.NewTimer(queueTimeout)
+		defer wait.Stop()
+		select {
+		case slots <- struct{}{}:
+		case <-wait.C:
+			return nil, ErrProviderBusy
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-slots }()
+		return fetcher(ctx, amount, filter)
+	}
+}
+
+// FallbackProvider is a named question source in the provider chain after the
+// service's fetcher.
+type FallbackProvider struct {
+	// Name is recorded as QuizOrigin.Provider for quizzes it supplies.
+	Name  string
+	Fetch QuestionsFetcher
+}
+
+// errNoQuestions stands in for the error of a provider that answered with no
+// questions, which sends the fetch down the provider chain like a failure.
+var errNoQuestions = errors.New("returned no questions")
+
+// StoredQuestionsFetcher draws from questions already in store, from any
+// quiz, for use as a FallbackProvider. The questions are converted back to
+// provider form so they are reshuffled like fresh ones, and filtered after
+// sampling. Multi-select questions have no provider form and are skipped. It
+// returns nil when store does not implement QuestionSampler.
+func StoredQuestionsFetcher(store QuizRepository) QuestionsFetcher {
+	sampler, ok := store.(QuestionSampler)
+	if !ok {
+		return nil
+	}
+	return func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		questions, err := sampler.SampleQuestions(ctx, amount)
+		if err != nil {
+			return nil, err
+		}
+		raw := make([]opentdb.RawQuestion, 0, len(questions))
+		for _, question := range questions {
+			if question.Type == TypeMultiSelect {
+				continue
+			}
+			if converted := toRawQuestion(question); filter.Matches(converted) {
+				raw = append(raw, converted)
+			}
+		}
+		return raw, nil
+	}
+}
+
+// toRawQuestion is the inverse of QuestionBuilder.buildQuestion. Text is
+// escaped because the builder unescapes provider HTML entities.
+func toRawQuestion(question Question) opentdb.RawQuestion {
+	raw := opentdb.RawQuestion{
+		Type:       opentdb.TypeMultiple,
+		Difficulty: string(question.Difficulty),
+		Category:   html.EscapeString(question.Category),
+		Question:   html.EscapeString(question.Question),
+	}
+	if question.Type == TypeTrueFalse {
+		raw.Type = opentdb.TypeBoolean
+	}
+	for idx, option := range question.Options {
+		if idx == question.CorrectIndex {
+			raw.CorrectAnswer = html.EscapeString(option.Text)
+		} else {
+			raw.IncorrectAnswers = append(raw.IncorrectAnswers, html.EscapeString(option.Text))
+		}
+	}
+	return raw
+}
+
+// fetchQuestions fetches amount questions for a quiz being built with origin.
+// Unpinned, it tries the fetcher and then each fallback provider in order
+// until one returns at least amount questions, and records the one used in
+// origin. When none has enough, the largest answer wins, the earliest
+// provider on a tie. Pinned, for the extra rounds of an over-fetching quiz,
+// it asks only the provider already recorded, so every question of a quiz
+// comes from origin.Provider.
+func (s *Service) fetchQuestions(ctx context.Context, origin *QuizOrigin, amount int, pinned bool) ([]opentdb.RawQuestion, error) {
+	if pinned && origin.FallbackFrom != "" {
+		for _, provider := range s.fallbacks {
+			if provider.Name == origin.Provider {
+				return provider.Fetch(ctx, amount, origin.filter())
+			}
+		}
+	}
+
+	want := max(amount, 1)
+	raw, err := s.fetcher(ctx, amount, origin.filter())
+	if pinned || len(s.fallbacks) == 0 || (err == nil && len(raw) >= want) {
+		return raw, err
+	}
+	var (
+		best         []opentdb.RawQuestion
+		bestProvider string
+		errs         []error
+	)
+	if err == nil && len(raw) > 0 {
+		best = raw
+	} else {
+		if err == nil {
+			err = errNoQuestions
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", origin.Provider, err))
+	}
+	for _, provider := range s.fallbacks {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		raw, err := provider.Fetch(ctx, amount, origin.filter())
+		if err == nil && len(raw) >= want {
+			origin.FallbackFrom = origin.Provider
+			origin.Provider = provider.Name
+			return raw, nil
+		}
+		if err == nil && len(raw) > len(best) {
+			best, bestProvider = raw, provider.Name
+			continue
+		}
+		if err == nil && len(raw) == 0 {
+			err = errNoQuestions
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name, err))
+		}
+	}
+	if len(best) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	if bestProvider != "" {
+		origin.FallbackFrom = origin.Provider
+		origin.Provider = bestProvider
+	}
+	return best, nil
+}
@@ -0,0 +1,66 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"quiz-app/internal/opentdb"
+)
+
+// Fetched quizzes can be narrowed to one provider category and one question
+// type, alongside the difficulty options. The provider applies the filter
+// where it can; the origin records it so rematches ask for the same.
+
+// ErrInvalidQuestionFilter reports an unknown category or question type.
+var ErrInvalidQuestionFilter = errors.New("invalid question filter")
+
+// ErrNotEnoughQuestions reports a filtered fetch the provider cannot fill.
+var ErrNotEnoughQuestions = opentdb.ErrNotEnoughQuestions
+
+// ParseQuestionCategory reads a category by OpenTriviaDB ID or name and
+// returns its ID. Empty means any category and returns 0.
+func ParseQuestionCategory(value string) (int, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+	category, ok := opentdb.LookupCategory(value)
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown category %q", ErrInvalidQuestionFilter, value)
+	}
+	return category.ID, nil
+}
+
+// ParseQuestionType reads a question type: multiple or boolean. Empty means
+// either.
+func ParseQuestionType(value string) (string, error) {
+	switch questionType := strings.ToLower(strings.TrimSpace(value)); questionType {
+	case "", opentdb.TypeMultiple, opentdb.TypeBoolean:
+		return questionType, nil
+	default:
+		return "", fmt.Errorf("%w: unknown type %q (want multiple or boolean)", ErrInvalidQuestionFilter, value)
+	}
+}
+
+func validateQuestionFilter(options QuizOptions) error {
+	if options.Category != 0 {
+		if _, ok := opentdb.CategoryByID(options.Category); !ok {
+			return fmt.Errorf("%w: unknown category %d", ErrInvalidQuestionFilter, options.Category)
+		}
+	}
+	_, err := ParseQuestionType(options.QuestionType)
+	return err
+}
+
+// filter is what the provider is asked for while building a quiz with o. A
+// difficulty mix of one level asks for that level only; mixes of several
+// levels are sorted after fetching.
+func (o QuizOrigin) filter() opentdb.Filter {
+	filter := opentdb.Filter{Category: o.Category, Type: o.QuestionType}
+	if len(o.DifficultyMix) == 1 {
+		for difficulty := range o.DifficultyMix {
+			filter.Difficulty = string(difficulty)
+		}
+	}
+	return filter
+}
@@ -0,0 +1,74 @@
+package quiz
+
+import (
+	"context"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// A client on a flaky network may not see the response to its submission and
+// send the same answers again. The store keeps the first answer and reports
+// the repeat as already_answered, which the client then shows as a warning
+// about its own retry. Within the retry grace window, a repeat of the same
+// letter gets the original outcome back instead. The stored answer is not
+// touched either way. A repeat of a multi-select answer may list its letters
+// in any order.
+
+// graceRetries rewrites already_answered results in place to the outcome of
+// the stored answer when the response repeats its letter and arrives within
+// the retry grace window of it. The answers are already scored, so it is
+// best-effort: without attempt history or when the history cannot be read,
+// the results are left as they are.
+func (s *Service) graceRetries(ctx context.Context, quizID, usernameNormalized string, responses []SubmittedResponse, results []ResponseResult) {
+	if s.retryGrace <= 0 {
+		return
+	}
+	history, ok := s.attempts.(AttemptHistory)
+	if !ok {
+		return
+	}
+
+	var retried bool
+	for _, result := range results {
+		if result.Status == StatusAlreadyAnswered {
+			retried = true
+			break
+		}
+	}
+	if !retried {
+		return
+	}
+	attempts, err := history.ListAttempts(ctx, quizID, usernameNormalized)
+	if err != nil {
+		return
+	}
+	stored := make(map[string]Attempt, len(attempts))
+	for _, attempt := range attempts {
+		stored[attempt.QuestionID] = attempt
+	}
+
+	now := s.now()
+	correctPoints := quizkit.DefaultScoringPolicy().CorrectPoints
+	for idx := range results {
+		if idx >= len(responses) || results[idx].Status != StatusAlreadyAnswered {
+			continue
+		}
+		attempt, ok := stored[results[idx].QuestionID]
+		if !ok || attempt.SubmittedBy != "" || attempt.AnswerLetter != quizkit.NormalizeLetters(responses[idx].Answer) {
+			continue
+		}
+		if now.Sub(attempt.SubmittedAt) > s.retryGrace {
+			continue
+		}
+		original := ResponseResult{QuestionID: results[idx].QuestionID, Status: StatusIncorrect}
+		switch {
+		case attempt.Score >= correctPoints:
+			original.Status = StatusCorrect
+			original.Bonus = attempt.Score - correctPoints
+		case attempt.Score > 0:
+			original.Status = StatusPartiallyCorrect
+			original.Credit = attempt.Score / correctPoints
+		}
+		results[idx] = original
+	}
+}
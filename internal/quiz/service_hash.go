@@ -0,0 +1,86 @@
+package quiz
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// Content hashes let a submission say which copy of a question it answered.
+// Question IDs come from the prompt and options only, so correcting the answer
+// key keeps the ID; a response whose hash no longer matches is re-served rather
+// than scored against content the player never saw.
+
+// newHMACKey returns key, or a random key when none is configured. Random keys
+// change on restart; for content hashes that only costs clients one re-serve.
+func newHMACKey(key []byte) []byte {
+	if len(key) > 0 {
+		return append([]byte(nil), key...)
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		panic("quiz: generate hmac key: " + err.Error())
+	}
+	return random
+}
+
+// ContentHash returns the content_hash served with question.
+func (s *Service) ContentHash(question Question) string {
+	return quizkit.ContentHash(question, s.contentHashKey)
+}
+
+// staleResponses compares each response's content hash with the stored
+// question and returns, by response position, the results for those that must
+// be re-served. Responses without a hash are accepted unless strict mode
+// requires one. Unknown and voided questions are left for normal evaluation.
+func (s *Service) staleResponses(ctx context.Context, quizID string, responses []SubmittedResponse) (map[int]ResponseResult, error) {
+	checked := s.requireContentHash
+	for _, response := range responses {
+		if response.ContentHash != "" {
+			checked = true
+			break
+		}
+	}
+	if !checked {
+		return nil, nil
+	}
+
+	// Read the store, not the cache: the cached copy may predate a correction.
+	questions, err := s.quizzes.GetQuizQuestions(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	lookup := make(map[string]Question, len(questions))
+	for _, question := range questions {
+		lookup[question.QuestionID] = question
+	}
+
+	stale := make(map[int]ResponseResult)
+	for idx, response := range responses {
+		question, ok := lookup[response.QuestionID]
+		if !ok || question.Voided {
+			continue
+		}
+		if response.ContentHash == "" && !s.requireContentHash {
+			continue
+		}
+		current := s.ContentHash(question)
+		if hmac.Equal([]byte(response.ContentHash), []byte(current)) {
+			continue
+		}
+		public := question.PublicQuestion
+		stale[idx] = ResponseResult{
+			QuestionID:  response.QuestionID,
+			Status:      StatusStaleQuestion,
+			Question:    &public,
+			ContentHash: current,
+		}
+	}
+	if len(stale) > 0 {
+		// The next GET /questions should serve the corrected copy.
+		s.quizQuestions.delete(quizID)
+	}
+	return stale, nil
+}
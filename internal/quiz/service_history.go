@@ -0,0 +1,70 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// UserAttempt is a user's totals in one quiz, with the quiz's question count,
+// creation time, and whether it is a practice quiz.
+type UserAttempt struct {
+	AttemptSummary
+	QuestionCount int
+	QuizCreatedAt time.Time
+	Practice      bool
+}
+
+// UserHistory is every quiz one user answered in.
+type UserHistory struct {
+	Username string
+	Attempts []UserAttempt
+}
+
+// UserAttempts returns every quiz username answered in, most recently played
+// first, with their score, answered count, and first and last answer times.
+// A positive limit keeps only the most recent quizzes. Quizzes deleted since
+// the user played are left out. During a quiz's leaderboard freeze its totals
+// stand as of the freeze, and a quiz first played since then is left out.
+func (s *Service) UserAttempts(ctx context.Context, username string, limit int) (UserHistory, error) {
+	lister, ok := s.attempts.(UserAttemptLister)
+	if !ok {
+		return UserHistory{}, ErrUnsupported
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return UserHistory{}, err
+	}
+
+	summaries, err := lister.GetUserAttempts(ctx, usernameNormalized)
+	if err != nil {
+		return UserHistory{}, err
+	}
+	history := UserHistory{Username: usernameNormalized, Attempts: make([]UserAttempt, 0, len(summaries))}
+	for _, summary := range summaries {
+		if limit > 0 && len(history.Attempts) == limit {
+			break
+		}
+		metadata, _, err := s.ensureQuiz(ctx, summary.QuizID, false, 0, QuizOptions{})
+		if errors.Is(err, ErrQuizNotFound) {
+			continue
+		}
+		if err != nil {
+			return UserHistory{}, err
+		}
+		summary, ok, err := s.publicSummary(ctx, metadata, summary)
+		if err != nil {
+			return UserHistory{}, err
+		}
+		if !ok {
+			continue
+		}
+		history.Attempts = append(history.Attempts, UserAttempt{
+			AttemptSummary: summary,
+			QuestionCount:  metadata.QuestionCount,
+			QuizCreatedAt:  metadata.CreatedAt,
+			Practice:       metadata.Practice,
+		})
+	}
+	return history, nil
+}
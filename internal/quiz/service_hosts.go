@@ -0,0 +1,263 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A quiz can have hosts besides whoever holds the admin token: one owner and
+// any number of co-hosts, each with their own host token. Every host-only
+// operation on the quiz accepts any of them, so an event keeps running when
+// the organizer drops out. Hosts and the admin can add co-hosts; only the
+// owner and the admin can hand ownership to someone else, and only the admin
+// or a host themself can replace that host's token. Changes to one quiz's
+// hosts are made one at a time, and each is written to the quiz's audit log
+// once it is saved. Only a hash of each host token is stored.
+
+// HostRole is a host's standing on one quiz.
+type HostRole string
+
+const (
+	HostOwner  HostRole = "owner"
+	HostCoHost HostRole = "co_host"
+)
+
+// Audit actions for host changes.
+const (
+	AuditHostAdded            = "host_added"
+	AuditOwnershipTransferred = "ownership_transferred"
+)
+
+// MaxQuizHosts bounds one quiz's host list.
+const MaxQuizHosts = 20
+
+var (
+	// ErrInvalidHost reports a host change that cannot be made, such as an
+	// unknown role or a full host list.
+	ErrInvalidHost = errors.New("invalid host")
+	// ErrInvalidHostToken reports a token that belongs to none of a quiz's
+	// hosts.
+	ErrInvalidHostToken = errors.New("invalid host token")
+	// ErrHostForbidden reports a host change the calling host may not make:
+	// replacing another host's token, or transferring ownership as a co-host.
+	ErrHostForbidden = errors.New("host change not allowed")
+)
+
+// QuizHost is one host of a quiz.
+type QuizHost struct {
+	// Username is normalized like every other username.
+	Username  string
+	Role      HostRole
+	TokenHash string
+	AddedBy   string
+	AddedAt   time.Time
+}
+
+// ParseHostRole reads a role name; empty means a co-host.
+func ParseHostRole(value string) (HostRole, error) {
+	switch HostRole(value) {
+	case "", HostCoHost:
+		return HostCoHost, nil
+	case HostOwner:
+		return HostOwner, nil
+	default:
+		return "", fmt.Errorf("%w: unknown role %q (want owner or co_host)", ErrInvalidHost, value)
+	}
+}
+
+// hostLocks serializes host changes per quiz, so two concurrent AddHost calls
+// cannot both read the same list and drop each other's host.
+type hostLocks struct {
+	mu    sync.Mutex
+	locks map[string]*hostLock
+}
+
+type hostLock struct {
+	mu sync.Mutex
+	// users counts the callers holding or waiting for mu; the lock is dropped
+	// from the map when it reaches zero.
+	users int
+}
+
+func (l *hostLocks) lock(quizID string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*hostLock)
+	}
+	entry, ok := l.locks[quizID]
+	if !ok {
+		entry = &hostLock{}
+		l.locks[quizID] = entry
+	}
+	entry.users++
+	l.mu.Unlock()
+
+	entry.mu.Lock()
+	return func() {
+		entry.mu.Unlock()
+		l.mu.Lock()
+		if entry.users--; entry.users == 0 {
+			delete(l.locks, quizID)
+		}
+		l.mu.Unlock()
+	}
+}
+
+func (s *Service) hostStore() (HostStore, AuditLog, error) {
+	hosts, ok := s.quizzes.(HostStore)
+	if !ok {
+		return nil, nil, ErrUnsupported
+	}
+	audit, ok := s.attempts.(AuditLog)
+	if !ok {
+		return nil, nil, ErrUnsupported
+	}
+	return hosts, audit, nil
+}
+
+// Hosts returns quizID's hosts, owner first.
+func (s *Service) Hosts(ctx context.Context, quizID string) ([]QuizHost, error) {
+	store, _, err := s.hostStore()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetHosts(ctx, metadata.QuizID)
+}
+
+// AddHost makes username a host of quizID and returns the new host token,
+// which is not stored and cannot be shown again. Adding someone already
+// listed replaces their token, for a host who lost theirs. With HostOwner,
+// ownership moves to username and the previous owner stays on as a co-host;
+// the first host of a quiz becomes its owner whatever the role. caller is the
+// host making the change, or the zero value for the admin. A co-host cannot
+// transfer ownership and no host can replace another host's token; both
+// return ErrHostForbidden. When the owner hands over to an existing co-host,
+// that host keeps their token and the returned token is empty. actor names
+// who made the change in the audit log.
+func (s *Service) AddHost(ctx context.Context, quizID string, caller QuizHost, actor, username string, role HostRole) (QuizHost, string, error) {
+	store, audit, err := s.hostStore()
+	if err != nil {
+		return QuizHost{}, "", err
+	}
+	actor, err = normalizeActingAdmin(actor)
+	if err != nil {
+		return QuizHost{}, "", err
+	}
+	if _, err := ParseHostRole(string(role)); err != nil {
+		return QuizHost{}, "", err
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return QuizHost{}, "", err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return QuizHost{}, "", err
+	}
+
+	unlock := s.hostChanges.lock(metadata.QuizID)
+	defer unlock()
+	hosts, err := store.GetHosts(ctx, metadata.QuizID)
+	if err != nil {
+		return QuizHost{}, "", err
+	}
+	existing, previousOwner := -1, ""
+	for idx, host := range hosts {
+		if host.Username == usernameNormalized {
+			existing = idx
+		}
+		if host.Role == HostOwner {
+			previousOwner = host.Username
+		}
+	}
+	admin := caller.Username == ""
+	if role == HostOwner && previousOwner != "" && previousOwner != usernameNormalized && !admin && caller.Role != HostOwner {
+		return QuizHost{}, "", fmt.Errorf("%w: only the owner or the admin can transfer ownership", ErrHostForbidden)
+	}
+	// The owner handing over to a co-host leaves the co-host's token alone.
+	replaceToken := existing < 0 || admin || caller.Username == usernameNormalized
+	transfer := role == HostOwner && existing >= 0 && hosts[existing].Role != HostOwner
+	if !replaceToken && !transfer {
+		return QuizHost{}, "", fmt.Errorf("%w: only the admin or %s can replace their host token", ErrHostForbidden, usernameNormalized)
+	}
+	if existing < 0 && len(hosts) >= MaxQuizHosts {
+		return QuizHost{}, "", fmt.Errorf("%w: at most %d hosts per quiz", ErrInvalidHost, MaxQuizHosts)
+	}
+	if previousOwner == "" {
+		role = HostOwner
+	}
+
+	token := ""
+	now := s.now().UTC()
+	added := QuizHost{Username: usernameNormalized, Role: HostCoHost, AddedBy: actor, AddedAt: now}
+	if existing >= 0 {
+		added.Role = hosts[existing].Role
+		if !replaceToken {
+			added = hosts[existing]
+		}
+		hosts = append(hosts[:existing], hosts[existing+1:]...)
+	}
+	if replaceToken {
+		if token, err = randomToken(); err != nil {
+			return QuizHost{}, "", err
+		}
+		added.TokenHash = hashSecret(token)
+	}
+
+	entry := AuditEntry{QuizID: metadata.QuizID, At: now, Actor: actor, Action: AuditHostAdded, Username: usernameNormalized, Detail: "role=" + string(HostCoHost)}
+	if role == HostOwner && added.Role != HostOwner {
+		added.Role = HostOwner
+		for idx := range hosts {
+			if hosts[idx].Role == HostOwner {
+				hosts[idx].Role = HostCoHost
+			}
+		}
+		entry.Action, entry.Detail = AuditOwnershipTransferred, "from="+previousOwner
+		if previousOwner == "" {
+			entry.Action, entry.Detail = AuditHostAdded, "role="+string(HostOwner)
+		}
+	} else if existing >= 0 {
+		entry.Detail = "role=" + string(added.Role) + ", token replaced"
+	}
+
+	// The owner leads the list; co-hosts keep the order they were added in.
+	if added.Role == HostOwner {
+		hosts = append([]QuizHost{added}, hosts...)
+	} else {
+		hosts = append(hosts, added)
+	}
+
+	if err := store.SaveHosts(ctx, metadata.QuizID, hosts); err != nil {
+		return QuizHost{}, "", err
+	}
+	if err := audit.RecordAuditEntry(ctx, entry); err != nil {
+		return QuizHost{}, "", err
+	}
+	return added, token, nil
+}
+
+// AuthenticateHost returns the host of quizID whose token is token, or
+// ErrInvalidHostToken when there is none.
+func (s *Service) AuthenticateHost(ctx context.Context, quizID, token string) (QuizHost, error) {
+	store, ok := s.quizzes.(HostStore)
+	if !ok || token == "" {
+		return QuizHost{}, ErrInvalidHostToken
+	}
+	hosts, err := store.GetHosts(ctx, quizID)
+	if err != nil {
+		return QuizHost{}, err
+	}
+	for _, host := range hosts {
+		if secretMatches(token, host.TokenHash) {
+			return host, nil
+		}
+	}
+	return QuizHost{}, ErrInvalidHostToken
+}
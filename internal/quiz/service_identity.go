@@ -0,0 +1,267 @@
+package quiz
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verified identities are optional. A player proves they own an email address
+// once, by code or magic link, and gets a player token. From then on, only
+// requests carrying that token may submit answers as that username; usernames
+// nobody verified work as before.
+
+const (
+	identityCodeTTL      = 15 * time.Minute
+	identityCodeAttempts = 5
+	identityCodeDigits   = 6
+)
+
+var (
+	// ErrIdentityDisabled reports identity calls on a service without a mailer.
+	ErrIdentityDisabled = errors.New("identity verification is not configured")
+	// ErrInvalidEmail reports an address that cannot receive a code.
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrIdentityTaken reports a verification for a username already verified
+	// with a different email address.
+	ErrIdentityTaken = errors.New("username is verified with a different email address")
+	// ErrInvalidIdentityCode reports a wrong, expired, or used code or link.
+	ErrInvalidIdentityCode = errors.New("invalid or expired verification code")
+	// ErrPlayerTokenRequired reports a submission for a verified username
+	// without its player token.
+	ErrPlayerTokenRequired = errors.New("username is verified; a valid player token is required")
+)
+
+// Identity links a username to a verified email address.
+type Identity struct {
+	// Username is normalized.
+	Username string
+	Email    string
+	// TokenHash is the hex SHA-256 of the player token. The token itself is
+	// returned once, when the identity is verified.
+	TokenHash  string
+	VerifiedAt time.Time
+}
+
+// Verified reports whether the identity exists.
+func (i Identity) Verified() bool {
+	return i.TokenHash != ""
+}
+
+// IdentityMessage is what an IdentityMailer sends. Code and LinkToken prove
+// the same challenge; the mailer decides how to present them.
+type IdentityMessage struct {
+	Username  string
+	Code      string
+	LinkToken string
+	ExpiresAt time.Time
+}
+
+// IdentityMailer delivers a verification message to email.
+type IdentityMailer func(ctx context.Context, email string, message IdentityMessage) error
+
+// identityChallenge is a pending verification. Challenges live in memory only:
+// they last minutes, and a restart just means asking for a new code.
+type identityChallenge struct {
+	email     string
+	codeHash  string
+	linkHash  string
+	expiresAt time.Time
+	attempts  int
+}
+
+type identityChallenges struct {
+	mu      sync.Mutex
+	pending map[string]identityChallenge
+}
+
+// StartIdentityVerification sends a code and magic link for username to email
+// and returns when they expire. Asking again replaces the previous code. A
+// verified username can only be verified again with the same address, for
+// example to get a token on a new device.
+func (s *Service) StartIdentityVerification(ctx context.Context, username, email string) (time.Time, error) {
+	store, ok := s.quizzes.(IdentityStore)
+	if !ok {
+		return time.Time{}, ErrUnsupported
+	}
+	if s.identityMailer == nil {
+		return time.Time{}, ErrIdentityDisabled
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return time.Time{}, err
+	}
+	email, err = normalizeEmail(email)
+	if err != nil {
+		return time.Time{}, err
+	}
+	existing, err := store.GetIdentity(ctx, usernameNormalized)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if existing.Verified() && existing.Email != email {
+		return time.Time{}, ErrIdentityTaken
+	}
+
+	code, err := randomDigits(identityCodeDigits)
+	if err != nil {
+		return time.Time{}, err
+	}
+	linkToken, err := randomToken()
+	if err != nil {
+		return time.Time{}, err
+	}
+	expiresAt := s.now().UTC().Add(identityCodeTTL)
+	s.identities.mu.Lock()
+	s.identities.pending[usernameNormalized] = identityChallenge{
+		email:     email,
+		codeHash:  hashSecret(code),
+		linkHash:  hashSecret(linkToken),
+		expiresAt: expiresAt,
+	}
+	s.identities.mu.Unlock()
+
+	message := IdentityMessage{Username: usernameNormalized, Code: code, LinkToken: linkToken, ExpiresAt: expiresAt}
+	if err := s.identityMailer(ctx, email, message); err != nil {
+		s.identities.mu.Lock()
+		delete(s.identities.pending, usernameNormalized)
+		s.identities.mu.Unlock()
+		return time.Time{}, fmt.Errorf("send verification email: %w", err)
+	}
+	return expiresAt, nil
+}
+
+// VerifyIdentity completes a verification with the emailed code or the magic
+// link's token and returns the identity and a new player token. A new token
+// replaces any earlier one for the username. A challenge allows a few wrong
+// codes before it has to be requested again.
+func (s *Service) VerifyIdentity(ctx context.Context, username, code, linkToken string) (Identity, string, error) {
+	store, ok := s.quizzes.(IdentityStore)
+	if !ok {
+		return Identity{}, "", ErrUnsupported
+	}
+	if s.identityMailer == nil {
+		return Identity{}, "", ErrIdentityDisabled
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return Identity{}, "", err
+	}
+	code, linkToken = strings.TrimSpace(code), strings.TrimSpace(linkToken)
+
+	s.identities.mu.Lock()
+	challenge, ok := s.identities.pending[usernameNormalized]
+	if !ok || !s.now().Before(challenge.expiresAt) {
+		delete(s.identities.pending, usernameNormalized)
+		s.identities.mu.Unlock()
+		return Identity{}, "", ErrInvalidIdentityCode
+	}
+	matched := (code != "" && secretMatches(code, challenge.codeHash)) || (linkToken != "" && secretMatches(linkToken, challenge.linkHash))
+	if !matched {
+		challenge.attempts++
+		if challenge.attempts >= identityCodeAttempts {
+			delete(s.identities.pending, usernameNormalized)
+		} else {
+			s.identities.pending[usernameNormalized] = challenge
+		}
+		s.identities.mu.Unlock()
+		return Identity{}, "", ErrInvalidIdentityCode
+	}
+	delete(s.identities.pending, usernameNormalized)
+	s.identities.mu.Unlock()
+
+	playerToken, err := randomToken()
+	if err != nil {
+		return Identity{}, "", err
+	}
+	identity := Identity{
+		Username:   usernameNormalized,
+		Email:      challenge.email,
+		TokenHash:  hashSecret(playerToken),
+		VerifiedAt: s.now().UTC(),
+	}
+	if err := store.SaveIdentity(ctx, identity); err != nil {
+		return Identity{}, "", err
+	}
+	return identity, playerToken, nil
+}
+
+// GetIdentity returns username's identity, or an unverified Identity holding
+// only the normalized username when nobody verified it. Stores without
+// identities report every username unverified.
+func (s *Service) GetIdentity(ctx context.Context, username string) (Identity, error) {
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return Identity{}, err
+	}
+	store, ok := s.quizzes.(IdentityStore)
+	if !ok {
+		return Identity{Username: usernameNormalized}, nil
+	}
+	identity, err := store.GetIdentity(ctx, usernameNormalized)
+	if err != nil {
+		return Identity{}, err
+	}
+	identity.Username = usernameNormalized
+	return identity, nil
+}
+
+// AuthenticatePlayer checks playerToken for username. Unverified usernames
+// need no token.
+func (s *Service) AuthenticatePlayer(ctx context.Context, username, playerToken string) error {
+	identity, err := s.GetIdentity(ctx, username)
+	if err != nil || !identity.Verified() {
+		return err
+	}
+	if !secretMatches(strings.TrimSpace(playerToken), identity.TokenHash) {
+		return ErrPlayerTokenRequired
+	}
+	return nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", fmt.Errorf("%w: %q", ErrInvalidEmail, email)
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func secretMatches(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(hash)) == 1
+}
+
+func randomDigits(count int) (string, error) {
+	var builder strings.Builder
+	for range count {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		builder.WriteByte(byte('0' + digit.Int64()))
+	}
+	return builder.String(), nil
+}
+
+func randomToken() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
@@ -0,0 +1,148 @@
+package quiz
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Quiz invalidation keeps what the service holds in memory for a quiz in step
+// with the store. Storing a quiz under an ID, whether new or over an older
+// quiz whose attempts the store wipes, archiving it, and deleting it each run
+// every hook registered for the quiz: the caches and serve nonces drop their
+// entries, removed quizzes lose their watches, and live viewers are told. Changes made by other
+// processes sharing the store run no hooks; CacheOptions.TTL bounds how long
+// those stay stale.
+
+// QuizInvalidation says why a quiz's in-memory state was invalidated.
+type QuizInvalidation string
+
+const (
+	// QuizStored means a quiz was stored under the ID. The store replaces any
+	// earlier quiz with that ID and drops its attempts.
+	QuizStored   QuizInvalidation = "stored"
+	QuizArchived QuizInvalidation = "archived"
+	QuizDeleted  QuizInvalidation = "deleted"
+)
+
+// QuizInvalidationHook is called after quizID changed in the store for
+// reason. Hooks run synchronously, in registration order, on the goroutine
+// that made the change; they must not block.
+type QuizInvalidationHook func(quizID string, reason QuizInvalidation)
+
+type quizInvalidation struct {
+	mu    sync.Mutex
+	hooks []QuizInvalidationHook
+	// generation counts invalidations. A cache fill that read the store
+	// while it moved may hold data from before the change, so it is dropped.
+	generation atomic.Uint64
+}
+
+// OnQuizInvalidated registers hook for every later quiz invalidation, for
+// layers that keep their own per-quiz state.
+func (s *Service) OnQuizInvalidated(hook QuizInvalidationHook) {
+	s.invalidation.mu.Lock()
+	defer s.invalidation.mu.Unlock()
+	s.invalidation.hooks = append(s.invalidation.hooks, hook)
+}
+
+// invalidateQuiz runs every hook for quizID. The generation moves first, so a
+// fill racing the hooks cannot cache what they dropped.
+func (s *Service) invalidateQuiz(quizID string, reason QuizInvalidation) {
+	s.invalidation.generation.Add(1)
+	s.invalidation.mu.Lock()
+	hooks := slices.Clone(s.invalidation.hooks)
+	s.invalidation.mu.Unlock()
+	for _, hook := range hooks {
+		hook(quizID, reason)
+	}
+}
+
+// cacheGeneration is read before loading a cache entry from the store; pass
+// it to unchangedSince before storing the entry.
+func (s *Service) cacheGeneration() uint64 {
+	return s.invalidation.generation.Load()
+}
+
+func (s *Service) unchangedSince(generation uint64) bool {
+	return s.invalidation.generation.Load() == generation
+}
+
+// registerInvalidationHooks wires the service's own per-quiz state to
+// invalidateQuiz. NewServiceWithOptions calls it before any other hook.
+func (s *Service) registerInvalidationHooks() {
+	s.OnQuizInvalidated(func(quizID string, _ QuizInvalidation) {
+		s.dropCachedQuiz(quizID)
+	})
+	s.OnQuizInvalidated(func(quizID string, reason QuizInvalidation) {
+		if reason != QuizStored {
+			s.forgetQuizWatches(quizID)
+		}
+	})
+	s.OnQuizInvalidated(s.quizStreamsChanged)
+	if s.nonces != nil {
+		s.OnQuizInvalidated(func(quizID string, _ QuizInvalidation) {
+			s.nonces.forgetQuiz(quizID)
+		})
+	}
+}
+
+// storeQuiz saves a quiz, replacing any quiz stored under its ID with that
+// quiz's attempts, and caches it in place of whatever the service held for
+// the ID. Questions without a Source take the quiz's provider when it fetched
+// or was given them.
+func (s *Service) storeQuiz(ctx context.Context, metadata QuizMetadata, questions []Question) error {
+	if metadata.Origin.Fetched() || metadata.Origin.Provider == ProviderCustom {
+		questions = slices.Clone(questions)
+		for idx := range questions {
+			if questions[idx].Source == "" {
+				questions[idx].Source = metadata.Origin.Provider
+			}
+		}
+	}
+	if err := s.quizzes.CreateQuiz(ctx, metadata, questions); err != nil {
+		return err
+	}
+	s.invalidateQuiz(metadata.QuizID, QuizStored)
+	s.setCachedQuiz(metadata, questions)
+	s.counters.recordQuizCreated(metadata.CreatedAt, questions)
+	return nil
+}
+
+// forgetQuizWatches drops the completion watches of a removed quiz and stops
+// the timer that publishes its results.
+func (s *Service) forgetQuizWatches(quizID string) {
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+	delete(s.completionWatches, quizID)
+	if timer := s.resultsTimers[quizID]; timer != nil {
+		timer.Stop()
+		delete(s.resultsTimers, quizID)
+	}
+}
+
+// quizStreamsChanged ends the live streams of a deleted quiz. Viewers of a
+// quiz stored over an older one get a fresh snapshot, since the standings
+// they hold are gone. An archived quiz keeps its leaderboard, so its viewers
+// are left alone.
+func (s *Service) quizStreamsChanged(quizID string, reason QuizInvalidation) {
+	switch reason {
+	case QuizStored:
+		s.publishLeaderboardSnapshot(context.Background(), quizID)
+		s.publishSubmissionSnapshot(context.Background(), quizID)
+	case QuizDeleted:
+		s.streams.closeQuiz(LeaderboardEvent{
+			Type:       LeaderboardEventClosing,
+			QuizID:     quizID,
+			Message:    "quiz deleted",
+			OccurredAt: s.now().UTC(),
+		})
+		s.submissions.closeQuiz(SubmissionEvent{
+			Type:       SubmissionEventClosing,
+			QuizID:     quizID,
+			Message:    "quiz deleted",
+			OccurredAt: s.now().UTC(),
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// ErrUnknownCategory reports a category leaderboard for a category none of
+// the quiz's questions carry.
+var ErrUnknownCategory = errors.New("no question in this quiz has that category")
+
+// ErrInvalidLeaderboardSettings reports leaderboard settings that cannot be
+// applied, such as a freeze with no lock time to end it.
+var ErrInvalidLeaderboardSettings = errors.New("invalid leaderboard settings")
+
+// LeaderboardSettings are a host's display rules for one quiz's leaderboard.
+// The zero value shows live standings at the server's default size.
+type LeaderboardSettings struct {
+	// DefaultLimit is how many entries a leaderboard shows when the request
+	// names no limit. Zero keeps the server default.
+	DefaultLimit int
+	// FreezeWindow hides changes during the last part of the quiz: from
+	// FreezeWindow before the lock, public leaderboards show the standings as
+	// of that moment until the quiz locks. Zero never freezes. Stores keep
+	// whole seconds.
+	FreezeWindow time.Duration
+	// LocksAt is when the freeze ends and the final standings are revealed.
+	// Zero falls back to the quiz's ClosesAt.
+	LocksAt time.Time
+	// Tiebreak orders players with equal scores. Empty ranks whoever reached
+	// the score first ahead.
+	Tiebreak  Tiebreak
+	UpdatedAt time.Time
+}
+
+// lockTime is when the quiz's leaderboard is revealed, or zero when neither the
+// settings nor the quiz name one.
+func (s LeaderboardSettings) lockTime(metadata QuizMetadata) time.Time {
+	if !s.LocksAt.IsZero() {
+		return s.LocksAt
+	}
+	return metadata.ClosesAt
+}
+
+// freeze returns the window during which changes are hidden and whether now
+// falls inside it. A locked quiz is never frozen: locking is the reveal.
+func (s LeaderboardSettings) freeze(metadata QuizMetadata, now time.Time) (frozenAt, revealAt time.Time, frozen bool) {
+	revealAt = s.lockTime(metadata)
+	if s.FreezeWindow <= 0 || revealAt.IsZero() || metadata.Locked {
+		return time.Time{}, time.Time{}, false
+	}
+	frozenAt = revealAt.Add(-s.FreezeWindow)
+	if now.Before(frozenAt) || !now.Before(revealAt) {
+		return time.Time{}, time.Time{}, false
+	}
+	return frozenAt, revealAt, true
+}
+
+// PublicLeaderboard is a leaderboard as players see it. While the quiz is
+// frozen, Entries are the standings as of FrozenAt and RevealAt says when live
+// standings return; both are zero otherwise.
+type PublicLeaderboard struct {
+	Entries  []RankedLeaderboardEntry
+	FrozenAt time.Time
+	RevealAt time.Time
+}
+
+// Frozen reports whether changes are currently hidden.
+func (b PublicLeaderboard) Frozen() bool {
+	return !b.FrozenAt.IsZero()
+}
+
+// GetLeaderboardSettings returns quizID's leaderboard settings. Quizzes without
+// settings, and stores that cannot keep them, get the zero value.
+func (s *Service) GetLeaderboardSettings(ctx context.Context, quizID string) (LeaderboardSettings, error) {
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return LeaderboardSettings{}, err
+	}
+	return s.leaderboardSettings(ctx, metadata.QuizID)
+}
+
+func (s *Service) leaderboardSettings(ctx context.Context, quizID string) (LeaderboardSettings, error) {
+	store, ok := s.quizzes.(LeaderboardSettingsStore)
+	if !ok {
+		return LeaderboardSettings{}, nil
+	}
+	return store.GetLeaderboardSettings(ctx, quizID)
+}
+
+// SetLeaderboardSettings replaces quizID's leaderboard settings. A freeze needs
+// a lock time, from the settings or the quiz's deadline, and a store with
+// attempt history to rebuild the standings as of the freeze.
+func (s *Service) SetLeaderboardSettings(ctx context.Context, quizID string, settings LeaderboardSettings) (LeaderboardSettings, error) {
+	store, ok := s.quizzes.(LeaderboardSettingsStore)
+	if !ok {
+		return LeaderboardSettings{}, ErrUnsupported
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return LeaderboardSettings{}, err
+	}
+
+	if settings.DefaultLimit < 0 {
+		return LeaderboardSettings{}, fmt.Errorf("%w: default limit must not be negative", ErrInvalidLeaderboardSettings)
+	}
+	if settings.FreezeWindow < 0 {
+		return LeaderboardSettings{}, fmt.Errorf("%w: freeze window must not be negative", ErrInvalidLeaderboardSettings)
+	}
+	if !settings.Tiebreak.Valid() {
+		return LeaderboardSettings{}, fmt.Errorf("%w: tiebreak must be %s or %s", ErrInvalidLeaderboardSettings, TiebreakLastSubmission, TiebreakFewestAnswers)
+	}
+	settings.FreezeWindow = settings.FreezeWindow.Truncate(time.Second)
+	if !settings.LocksAt.IsZero() {
+		settings.LocksAt = settings.LocksAt.UTC()
+	}
+	if settings.FreezeWindow > 0 {
+		if settings.lockTime(metadata).IsZero() {
+			return LeaderboardSettings{}, fmt.Errorf("%w: a freeze needs a lock time", ErrInvalidLeaderboardSettings)
+		}
+		if _, ok := s.attempts.(AttemptHistory); !ok {
+			return LeaderboardSettings{}, ErrUnsupported
+		}
+	}
+	settings.UpdatedAt = s.now().UTC()
+
+	if err := store.SaveLeaderboardSettings(ctx, metadata.QuizID, settings); err != nil {
+		return LeaderboardSettings{}, err
+	}
+	// The cached order follows the old tiebreak; the next read rebuilds it.
+	s.leaderboardCache.delete(metadata.QuizID)
+	s.invalidateCategoryLeaderboards(metadata.QuizID)
+	// Viewers may be looking at a freeze that just ended or began.
+	s.publishLeaderboardSnapshot(ctx, metadata.QuizID)
+	// A new lock time reschedules publishing for final-results watches.
+	if len(s.finalResultsURLs(metadata.QuizID)) > 0 {
+		s.publishWhenLocked(metadata.QuizID)
+	}
+	return settings, nil
+}
+
+// frozenUntil returns when quizID's current freeze ends, if it is frozen.
+func (s *Service) frozenUntil(ctx context.Context, quizID string) (time.Time, bool) {
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return time.Time{}, false
+	}
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return time.Time{}, false
+	}
+	_, revealAt, frozen := settings.freeze(metadata, s.now())
+	return revealAt, frozen
+}
+
+// leaderboardAsOf rebuilds the standings from attempts submitted before asOf,
+// ordered by tiebreak. It reads each participant's history, which is fine for
+// the few minutes a leaderboard is frozen but too slow to replace the stored
+// aggregate.
+func (s *Service) leaderboardAsOf(ctx context.Context, quizID string, asOf time.Time, tiebreak Tiebreak) ([]LeaderboardEntry, error) {
+	return s.standingsFromHistory(ctx, quizID, asOf, nil, tiebreak)
+}
+
+// standingsFromHistory is leaderboardAsOf counting only the questions in
+// include; a nil include counts every question and a zero asOf every answer.
+func (s *Service) standingsFromHistory(ctx context.Context, quizID string, asOf time.Time, include map[string]bool, tiebreak Tiebreak) ([]LeaderboardEntry, error) {
+	history, ok := s.attempts.(AttemptHistory)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	live, err := s.GetLeaderboard(ctx, quizID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(live))
+	for _, participant := range live {
+		attempts, err := history.ListAttempts(ctx, quizID, participant.Username)
+		if err != nil {
+			return nil, err
+		}
+		entry := LeaderboardEntry{Username: participant.Username}
+		for _, attempt := range attempts {
+			if !asOf.IsZero() && !attempt.SubmittedAt.Before(asOf) {
+				continue
+			}
+			if include != nil && !include[attempt.QuestionID] {
+				continue
+			}
+			entry.TotalScore += attempt.Score
+			entry.AnsweredCount++
+			if attempt.SubmittedAt.After(entry.LastSubmissionAt) {
+				entry.LastSubmissionAt = attempt.SubmittedAt
+			}
+		}
+		if entry.AnsweredCount > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	quizkit.SortLeaderboardBy(tiebreak, entries)
+	return entries, nil
+}
+
+// publicSummary returns summary as the quiz's leaderboard shows it: during a
+// freeze, only the answers submitted before the freeze count, so per-user
+// totals cannot be used to rebuild the hidden standings. ok is false when the
+// user had no answers before the freeze. Archived quizzes are locked, so they
+// are never frozen.
+func (s *Service) publicSummary(ctx context.Context, metadata QuizMetadata, summary AttemptSummary) (AttemptSummary, bool, error) {
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return AttemptSummary{}, false, err
+	}
+	frozenAt, _, frozen := settings.freeze(metadata, s.now())
+	if !frozen {
+		return summary, true, nil
+	}
+	history, ok := s.attempts.(AttemptHistory)
+	if !ok {
+		return AttemptSummary{}, false, ErrUnsupported
+	}
+	attempts, err := history.ListAttempts(ctx, summary.QuizID, summary.Username)
+	if err != nil {
+		return AttemptSummary{}, false, err
+	}
+
+	asOf := AttemptSummary{QuizID: summary.QuizID, Username: summary.Username}
+	for _, attempt := range attempts {
+		if !attempt.SubmittedAt.Before(frozenAt) {
+			continue
+		}
+		asOf.TotalScore += attempt.Score
+		asOf.AnsweredCount++
+		if attempt.Score >= 1 {
+			asOf.CorrectCount++
+		}
+		if asOf.FirstSubmittedAt.IsZero() || attempt.SubmittedAt.Before(asOf.FirstSubmittedAt) {
+			asOf.FirstSubmittedAt = attempt.SubmittedAt
+		}
+		if attempt.SubmittedAt.After(asOf.LastSubmittedAt) {
+			asOf.LastSubmittedAt = attempt.SubmittedAt
+		}
+	}
+	return asOf, asOf.AnsweredCount > 0, nil
+}
+
+// GetCategoryLeaderboard is GetPublicLeaderboard counting only the answers to
+// quizID's questions in category, matched without regard to case, so a mixed
+// quiz can name a winner per topic. Voided questions do not count. Standings
+// are rebuilt from attempt history on the first read and then cached per quiz
+// and category, patched as answers arrive like the main leaderboard.
+func (s *Service) GetCategoryLeaderboard(ctx context.Context, quizID, category string, limit int) (PublicLeaderboard, error) {
+	metadata, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return PublicLeaderboard{}, err
+	}
+	include := make(map[string]bool)
+	for _, question := range questions {
+		if !question.Voided && question.Category != "" && strings.EqualFold(question.Category, strings.TrimSpace(category)) {
+			include[question.QuestionID] = true
+		}
+	}
+	if len(include) == 0 {
+		return PublicLeaderboard{}, ErrUnknownCategory
+	}
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return PublicLeaderboard{}, err
+	}
+
+	var (
+		board   PublicLeaderboard
+		entries []LeaderboardEntry
+	)
+	key := categoryLeaderboardKey(metadata.QuizID, strings.TrimSpace(category))
+	if frozenAt, revealAt, frozen := settings.freeze(metadata, s.now()); frozen {
+		board.FrozenAt, board.RevealAt = frozenAt, revealAt
+		entries, err = s.standingsFromHistory(ctx, metadata.QuizID, frozenAt, include, settings.Tiebreak)
+	} else if !s.categoryLeaderboards.with(key, func(cache *leaderboardCache) { entries = slices.Clone(cache.ordered) }) {
+		generation := s.cacheGeneration()
+		entries, err = s.standingsFromHistory(ctx, metadata.QuizID, time.Time{}, include, settings.Tiebreak)
+		if err == nil && s.unchangedSince(generation) {
+			s.categoryLeaderboards.put(key, newLeaderboardCache(slices.Clone(entries), settings.Tiebreak))
+		}
+	}
+	if err != nil {
+		return PublicLeaderboard{}, err
+	}
+
+	board.Entries, err = s.rankPublicly(ctx, metadata.QuizID, applyLeaderboardLimit(entries, limit))
+	if err != nil {
+		return PublicLeaderboard{}, err
+	}
+	return board, nil
+}
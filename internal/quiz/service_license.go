@@ -0,0 +1,38 @@
+package quiz
+
+import (
+	"context"
+	"strings"
+)
+
+// Some providers license their questions for play on this server but not for
+// copying elsewhere. ServiceOptions.RestrictedSources names them by
+// Question.Source, and quiz bundles carry a reference to each of their
+// questions in place of its text. A deployment that already stores the
+// question can resolve the reference on import.
+
+// Redistributable reports whether question may be copied out of the server,
+// for example into a quiz bundle: false when its Source is one of
+// ServiceOptions.RestrictedSources.
+func (s *Service) Redistributable(question Question) bool {
+	for _, restricted := range s.restrictedSources {
+		if question.Source == restricted || strings.HasPrefix(question.Source, restricted+":") {
+			return false
+		}
+	}
+	return true
+}
+
+// LookupQuestions returns the stored questions with the given IDs, skipping
+// unknown ones. It returns ErrUnsupported when the store cannot look
+// questions up by ID.
+func (s *Service) LookupQuestions(ctx context.Context, questionIDs []string) ([]Question, error) {
+	lookup, ok := s.quizzes.(QuestionLookup)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	if len(questionIDs) == 0 {
+		return []Question{}, nil
+	}
+	return lookup.LookupQuestions(ctx, questionIDs)
+}
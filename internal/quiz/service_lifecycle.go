@@ -0,0 +1,187 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A quiz is created active, or as a draft when QuizOptions.Draft is set. A
+// host activates a draft once it is ready and locks the quiz when the event is
+// over; an active quiz whose ClosesAt has passed is expired. Only active
+// quizzes take answers, with two exceptions for answers that were chosen in
+// time but arrive late: answers signed offline before the close, and answers
+// an admin enters from paper sheets. Locking is final, because locked results
+// are published and may be archived. An admin may archive any quiz, which
+// locks it; see Service.ArchiveQuiz.
+
+// QuizState is where a quiz is in its lifecycle. Only draft, active, locked,
+// and archived are stored; expired follows from ClosesAt.
+type QuizState string
+
+const (
+	QuizStateDraft    QuizState = "draft"
+	QuizStateActive   QuizState = "active"
+	QuizStateLocked   QuizState = "locked"
+	QuizStateExpired  QuizState = "expired"
+	QuizStateArchived QuizState = "archived"
+)
+
+var (
+	// ErrQuizClosed reports answers to a quiz that is not taking them: a
+	// draft, or a locked or expired quiz.
+	ErrQuizClosed = errors.New("quiz is not accepting answers")
+	// ErrInvalidQuizState reports an unknown state or an unusable deadline.
+	ErrInvalidQuizState = errors.New("invalid quiz state")
+)
+
+// QuizLifecycle is the stored part of a quiz's state; see QuizMetadata.
+type QuizLifecycle struct {
+	Draft    bool
+	Locked   bool
+	ClosesAt time.Time
+}
+
+// State is the quiz's state at now. A quiz locked while still a draft reads
+// as locked.
+func (m QuizMetadata) State(now time.Time) QuizState {
+	switch {
+	case !m.ArchivedAt.IsZero():
+		return QuizStateArchived
+	case m.Locked:
+		return QuizStateLocked
+	case m.Draft:
+		return QuizStateDraft
+	case !m.ClosesAt.IsZero() && !now.Before(m.ClosesAt):
+		return QuizStateExpired
+	default:
+		return QuizStateActive
+	}
+}
+
+// QuizState is metadata's state by the service's clock.
+func (s *Service) QuizState(metadata QuizMetadata) QuizState {
+	return metadata.State(s.now())
+}
+
+// ParseQuizState reads a state a host can move a quiz to: draft, active, or
+// locked. Expired is not one of them; set ClosesAt instead. Nor is archived,
+// which is for admins; see Service.ArchiveQuiz.
+func ParseQuizState(value string) (QuizState, error) {
+	switch state := QuizState(strings.ToLower(strings.TrimSpace(value))); state {
+	case QuizStateDraft, QuizStateActive, QuizStateLocked:
+		return state, nil
+	default:
+		return "", fmt.Errorf("%w: state must be %s, %s, or %s", ErrInvalidQuizState, QuizStateDraft, QuizStateActive, QuizStateLocked)
+	}
+}
+
+// QuizStateChange is a host's change to a quiz's state.
+type QuizStateChange struct {
+	// State is draft, active, or locked. Empty keeps the current state.
+	State QuizState
+	// ClosesAt replaces the deadline when set; a zero time removes it.
+	ClosesAt *time.Time
+}
+
+// SetQuizState applies change to quizID and returns the updated metadata. A
+// locked quiz cannot change and returns ErrQuizLocked. Moving an active quiz
+// back to draft keeps the answers it already has. It needs a store that
+// implements QuizLifecycleStore.
+func (s *Service) SetQuizState(ctx context.Context, quizID string, change QuizStateChange) (QuizMetadata, error) {
+	store, ok := s.quizzes.(QuizLifecycleStore)
+	if !ok {
+		return QuizMetadata{}, ErrUnsupported
+	}
+	// Read past the cache: another process sharing the store may have locked
+	// the quiz.
+	metadata, err := s.quizzes.GetQuizMetadata(ctx, strings.TrimSpace(quizID))
+	if err != nil {
+		return QuizMetadata{}, err
+	}
+	if metadata.Locked {
+		return QuizMetadata{}, ErrQuizLocked
+	}
+
+	lifecycle := QuizLifecycle{Draft: metadata.Draft, Locked: metadata.Locked, ClosesAt: metadata.ClosesAt}
+	switch change.State {
+	case "":
+	case QuizStateDraft:
+		lifecycle.Draft = true
+	case QuizStateActive:
+		lifecycle.Draft = false
+	case QuizStateLocked:
+		lifecycle.Locked = true
+	default:
+		_, err := ParseQuizState(string(change.State))
+		return QuizMetadata{}, err
+	}
+	if change.ClosesAt != nil {
+		if err := s.checkClosesAt(*change.ClosesAt); err != nil {
+			return QuizMetadata{}, err
+		}
+		lifecycle.ClosesAt = change.ClosesAt.UTC()
+	}
+
+	if err := store.SetQuizLifecycle(ctx, metadata.QuizID, lifecycle); err != nil {
+		return QuizMetadata{}, err
+	}
+	metadata.Draft, metadata.Locked, metadata.ClosesAt = lifecycle.Draft, lifecycle.Locked, lifecycle.ClosesAt
+	s.setCachedQuizMetadata(metadata)
+	// Locking, or a new deadline standing in for the lock time, reschedules
+	// publishing for final-results watches.
+	if len(s.finalResultsURLs(metadata.QuizID)) > 0 {
+		s.publishWhenLocked(metadata.QuizID)
+	}
+	return metadata, nil
+}
+
+// checkClosesAt rejects deadlines that have already passed; zero means no
+// deadline. Ending a quiz now is what locking is for.
+func (s *Service) checkClosesAt(closesAt time.Time) error {
+	if !closesAt.IsZero() && !closesAt.After(s.now()) {
+		return fmt.Errorf("%w: closes_at must be in the future", ErrInvalidQuizState)
+	}
+	return nil
+}
+
+// checkAcceptsAnswers returns ErrQuizClosed unless the quiz takes responses
+// from players now. An expired quiz still takes a batch that is signed
+// throughout; unverifiedAnswers then sets aside answers chosen after the
+// close.
+func (s *Service) checkAcceptsAnswers(metadata QuizMetadata, responses []SubmittedResponse) error {
+	state := metadata.State(s.now())
+	if state == QuizStateActive {
+		return nil
+	}
+	if state == QuizStateExpired && len(responses) > 0 {
+		allSigned := true
+		for _, response := range responses {
+			allSigned = allSigned && signed(response)
+		}
+		if allSigned {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: quiz is %s", ErrQuizClosed, state)
+}
+
+// lifecycleFor is the stored state a new quiz starts in. Drafts need a store
+// that can activate them later.
+func (s *Service) lifecycleFor(options QuizOptions) (QuizLifecycle, error) {
+	if options.Draft {
+		if _, ok := s.quizzes.(QuizLifecycleStore); !ok {
+			return QuizLifecycle{}, ErrUnsupported
+		}
+	}
+	if err := s.checkClosesAt(options.ClosesAt); err != nil {
+		return QuizLifecycle{}, err
+	}
+	closesAt := options.ClosesAt
+	if !closesAt.IsZero() {
+		closesAt = closesAt.UTC()
+	}
+	return QuizLifecycle{Draft: options.Draft, ClosesAt: closesAt}, nil
+}
@@ -0,0 +1,156 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// mixMaxFetchRounds bounds how often the provider is asked for more questions
+// while filling a difficulty mix; scarce levels end up short instead of looping.
+const mixMaxFetchRounds = 5
+
+// ErrInvalidDifficultyMix reports a difficulty mix that names unknown levels
+// or asks for no questions.
+var ErrInvalidDifficultyMix = errors.New("invalid difficulty mix")
+
+// DifficultyMix asks for a number of questions at each difficulty.
+type DifficultyMix map[Difficulty]int
+
+// ParseDifficultyMix validates question counts keyed by difficulty name, such
+// as {"easy": 4, "hard": 2}. Names are case-insensitive and zero counts are
+// dropped.
+func ParseDifficultyMix(counts map[string]int) (DifficultyMix, error) {
+	mix := make(DifficultyMix, len(counts))
+	for name, count := range counts {
+		difficulty, err := ParseDifficulty(name)
+		if err != nil || difficulty == "" {
+			return nil, fmt.Errorf("%w: unknown difficulty %q (want easy, medium, or hard)", ErrInvalidDifficultyMix, name)
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("%w: %s count must not be negative", ErrInvalidDifficultyMix, difficulty)
+		}
+		if _, dup := mix[difficulty]; dup {
+			return nil, fmt.Errorf("%w: %s is listed twice", ErrInvalidDifficultyMix, difficulty)
+		}
+		if count > 0 {
+			mix[difficulty] = count
+		}
+	}
+	if mix.Total() == 0 {
+		return nil, fmt.Errorf("%w: at least one question is required", ErrInvalidDifficultyMix)
+	}
+	return mix, nil
+}
+
+// Total is the number of questions the mix asks for.
+func (m DifficultyMix) Total() int {
+	total := 0
+	for _, count := range m {
+		total += count
+	}
+	return total
+}
+
+// DifficultyBucket reports how well one level of a mix was filled.
+type DifficultyBucket struct {
+	Difficulty Difficulty
+	Requested  int
+	Delivered  int
+}
+
+// Shortfall is how many requested questions the provider could not supply.
+func (b DifficultyBucket) Shortfall() int {
+	return b.Requested - b.Delivered
+}
+
+// CreateMixedQuiz creates a quiz with the number of questions per difficulty
+// that mix asks for. It keeps fetching from the provider until every level is
+// filled or mixMaxFetchRounds is reached, then stores what it found; buckets
+// lists each requested level, easiest first, with what was delivered.
+func (s *Service) CreateMixedQuiz(ctx context.Context, mix DifficultyMix, options QuizOptions) (QuizMetadata, []DifficultyBucket, error) {
+	if s.fetcher == nil {
+		return QuizMetadata{}, nil, errors.New("question fetcher is not configured")
+	}
+	total := mix.Total()
+	if total == 0 {
+		return QuizMetadata{}, nil, fmt.Errorf("%w: at least one question is required", ErrInvalidDifficultyMix)
+	}
+	if err := validateQuestionFilter(options); err != nil {
+		return QuizMetadata{}, nil, err
+	}
+	lifecycle, err := s.lifecycleFor(options)
+	if err != nil {
+		return QuizMetadata{}, nil, err
+	}
+
+	origin, builder := s.fetchedOrigin()
+	origin.DifficultyMix = mix
+	origin.Category = options.Category
+	origin.QuestionType = options.QuestionType
+	questions, delivered, err := s.fetchMix(ctx, &origin, builder)
+	if err != nil {
+		return QuizMetadata{}, nil, err
+	}
+	if len(questions) == 0 {
+		return QuizMetadata{}, nil, errors.New("the provider returned no questions matching the difficulty mix")
+	}
+
+	metadata := QuizMetadata{
+		QuizID:                 s.newQuizID(),
+		QuestionCount:          len(questions),
+		RequestedQuestionCount: total,
+		CreatedAt:              s.now().UTC(),
+		Draft:                  lifecycle.Draft,
+		ClosesAt:               lifecycle.ClosesAt,
+		QuestionTimeLimit:      options.QuestionTimeLimit,
+		Origin:                 origin,
+	}
+	if err := s.storeQuiz(ctx, metadata, questions); err != nil {
+		return QuizMetadata{}, nil, err
+	}
+
+	buckets := make([]DifficultyBucket, 0, len(mix))
+	for _, difficulty := range []Difficulty{DifficultyEasy, DifficultyMedium, DifficultyHard} {
+		if requested, ok := mix[difficulty]; ok {
+			buckets = append(buckets, DifficultyBucket{Difficulty: difficulty, Requested: requested, Delivered: delivered[difficulty]})
+		}
+	}
+	return metadata, buckets, nil
+}
+
+// fetchMix over-fetches from the provider and keeps questions whose difficulty
+// still has room in origin.DifficultyMix, in the order they arrived. Untagged
+// questions and repeats are skipped. A provider error ends the search early
+// once some questions were found. Fetches from one category ask for no more
+// than the total, since a narrow category may not hold twice as many.
+func (s *Service) fetchMix(ctx context.Context, origin *QuizOrigin, builder *QuestionBuilder) ([]Question, map[Difficulty]int, error) {
+	mix := origin.DifficultyMix
+	total := mix.Total()
+	batch := min(total*2, dailyFetchBatchMaximum)
+	if origin.Category != 0 {
+		batch = min(total, dailyFetchBatchMaximum)
+	}
+	selected := make([]Question, 0, total)
+	delivered := make(map[Difficulty]int, len(mix))
+	seen := newRepeatFilter(nil)
+
+	for round := 0; round < mixMaxFetchRounds && len(selected) < total; round++ {
+		raw, err := s.fetchQuestions(ctx, origin, batch, round > 0)
+		if err != nil {
+			if len(selected) > 0 {
+				break
+			}
+			return nil, nil, err
+		}
+		for _, question := range builder.Build(raw) {
+			if delivered[question.Difficulty] >= mix[question.Difficulty] || seen.matches(question) {
+				continue
+			}
+			seen.add(question)
+			selected = append(selected, question)
+			delivered[question.Difficulty]++
+		}
+	}
+	return selected, delivered, nil
+}
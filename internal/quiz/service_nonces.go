@@ -0,0 +1,201 @@
+package quiz
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// A submission payload names the quiz, the player, and the answers, so one
+// player can hand theirs to another to replay verbatim. With serve nonces on,
+// every question served to a player in server-scored mode comes with a random
+// nonce that only that player's answer to that question can carry, once.
+// Players served the answer key (include_correct) are scoring themselves and
+// are left out; the serving log already flags them.
+//
+// Nonces live in memory: after a restart, answers carrying old nonces are
+// rejected and the player fetches the questions again. Fetching questions
+// needs no login, so the set is bounded: a player's nonces expire once they
+// have been idle for the offline sync window, the oldest player is dropped
+// when maxServeNoncePlayers is reached, and a quiz's nonces go when it is
+// stored over, archived, or deleted. A player who loses theirs this way gets
+// invalid_nonce and fetches again.
+
+// serveNonceBytes is the entropy of each nonce.
+const serveNonceBytes = 16
+
+// maxServeNoncePlayers bounds how many players across all quizzes hold nonces.
+const maxServeNoncePlayers = 100_000
+
+type serveNonces struct {
+	// ttl is how long a player's nonces outlive their last fetch or answer.
+	ttl time.Duration
+
+	mu sync.Mutex
+	// players is keyed by quiz ID, then normalized username.
+	players map[string]map[string]*playerNonces
+	// count is the number of players across all quizzes.
+	count int
+}
+
+type playerNonces struct {
+	// answerKey is set once the player was served correct answers.
+	answerKey bool
+	// outstanding maps question IDs to the nonce not yet spent.
+	outstanding map[string]string
+	// spent maps question IDs to the nonce last spent on them. A retry of an
+	// answer carries it again; the store reports the repeat either way.
+	spent map[string]string
+	// touched is when the player last fetched or answered.
+	touched time.Time
+}
+
+func newServeNonces(ttl time.Duration) *serveNonces {
+	return &serveNonces{ttl: ttl, players: make(map[string]map[string]*playerNonces)}
+}
+
+// issue returns the nonce for each of questionIDs, keeping any the player has
+// not spent yet so fetching again does not invalidate an answer in flight.
+// issued counts the new ones. Serving the answer key issues none and exempts
+// the player from then on.
+func (n *serveNonces) issue(quizID, usernameNormalized string, questionIDs []string, withAnswerKey bool, now time.Time) (nonces map[string]string, issued int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	player := n.playerLocked(quizID, usernameNormalized, now)
+	if player == nil {
+		n.pruneLocked(now)
+		player = &playerNonces{outstanding: make(map[string]string), spent: make(map[string]string)}
+		if n.players[quizID] == nil {
+			n.players[quizID] = make(map[string]*playerNonces)
+		}
+		n.players[quizID][usernameNormalized] = player
+		n.count++
+	}
+	player.touched = now
+	if withAnswerKey {
+		player.answerKey = true
+		player.outstanding = make(map[string]string)
+	}
+	if player.answerKey {
+		return nil, 0
+	}
+
+	nonces = make(map[string]string, len(questionIDs))
+	for _, questionID := range questionIDs {
+		nonce, ok := player.outstanding[questionID]
+		if !ok {
+			nonce = newServeNonce()
+			player.outstanding[questionID] = nonce
+			issued++
+		}
+		nonces[questionID] = nonce
+	}
+	return nonces, issued
+}
+
+// spend checks each response's nonce against the one issued to the player for
+// its question and returns, by response position, invalid_nonce results for
+// those that do not match. Matching nonces are spent. A nonce the player
+// already spent on the question still matches, so a retried submission
+// reaches the store, which keeps the first answer. Responses already set aside
+// by earlier checks are skipped and keep their nonces.
+func (n *serveNonces) spend(quizID, usernameNormalized string, responses []SubmittedResponse, skipped map[int]ResponseResult, now time.Time) map[int]ResponseResult {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	player := n.playerLocked(quizID, usernameNormalized, now)
+	if player != nil {
+		if player.answerKey {
+			return nil
+		}
+		player.touched = now
+	}
+
+	rejected := make(map[int]ResponseResult)
+	for idx, response := range responses {
+		if _, ok := skipped[idx]; ok {
+			continue
+		}
+		if player != nil && response.Nonce != "" {
+			if nonce, ok := player.outstanding[response.QuestionID]; ok && hmac.Equal([]byte(nonce), []byte(response.Nonce)) {
+				delete(player.outstanding, response.QuestionID)
+				player.spent[response.QuestionID] = nonce
+				continue
+			}
+			if nonce, ok := player.spent[response.QuestionID]; ok && hmac.Equal([]byte(nonce), []byte(response.Nonce)) {
+				continue
+			}
+		}
+		rejected[idx] = ResponseResult{QuestionID: response.QuestionID, Status: StatusInvalidNonce}
+	}
+	return rejected
+}
+
+// forgetQuiz drops every player's nonces for quizID.
+func (n *serveNonces) forgetQuiz(quizID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.count -= len(n.players[quizID])
+	delete(n.players, quizID)
+}
+
+// playerLocked returns the player's nonces, or nil when there are none or
+// they expired. Callers hold n.mu.
+func (n *serveNonces) playerLocked(quizID, usernameNormalized string, now time.Time) *playerNonces {
+	player := n.players[quizID][usernameNormalized]
+	if player != nil && n.expired(player, now) {
+		n.removeLocked(quizID, usernameNormalized)
+		return nil
+	}
+	return player
+}
+
+func (n *serveNonces) expired(player *playerNonces, now time.Time) bool {
+	return n.ttl > 0 && now.Sub(player.touched) > n.ttl
+}
+
+// pruneLocked makes room for one more player: expired players go first, and
+// if none had expired the one idle longest goes. Callers hold n.mu.
+func (n *serveNonces) pruneLocked(now time.Time) {
+	if n.count < maxServeNoncePlayers {
+		return
+	}
+	oldestQuiz, oldestUser := "", ""
+	var oldest time.Time
+	for quizID, players := range n.players {
+		for username, player := range players {
+			switch {
+			case n.expired(player, now):
+				n.removeLocked(quizID, username)
+			case oldestUser == "" || player.touched.Before(oldest):
+				oldestQuiz, oldestUser, oldest = quizID, username, player.touched
+			}
+		}
+	}
+	if n.count >= maxServeNoncePlayers && oldestUser != "" {
+		n.removeLocked(oldestQuiz, oldestUser)
+	}
+}
+
+// removeLocked forgets one player. Callers hold n.mu.
+func (n *serveNonces) removeLocked(quizID, usernameNormalized string) {
+	if _, ok := n.players[quizID][usernameNormalized]; !ok {
+		return
+	}
+	delete(n.players[quizID], usernameNormalized)
+	n.count--
+	if len(n.players[quizID]) == 0 {
+		delete(n.players, quizID)
+	}
+}
+
+func newServeNonce() string {
+	random := make([]byte, serveNonceBytes)
+	if _, err := rand.Read(random); err != nil {
+		panic("quiz: generate serve nonce: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(random)
+}
@@ -0,0 +1,271 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Completion watches let a host ask to be told when a quiz is done enough to
+// lock and reveal results, or whenever its leaderboard changes. Watches live in
+// memory only; they are lost on restart and must be registered again.
+
+var (
+	ErrNotificationsDisabled  = errors.New("notifications are not configured")
+	ErrInvalidCompletionWatch = errors.New("invalid completion watch")
+)
+
+const (
+	EventCompletionThreshold = "quiz.completion_threshold"
+	EventUserFinished        = "quiz.user_finished"
+	EventLeaderboardChanged  = "quiz.leaderboard_changed"
+
+	// leaderboardWebhookEntries is how many top entries a leaderboard-changed
+	// event carries.
+	leaderboardWebhookEntries = 10
+)
+
+// CompletionEvent is the payload delivered to a completion watch URL.
+type CompletionEvent struct {
+	Event            string    `json:"event"`
+	QuizID           string    `json:"quiz_id"`
+	Username         string    `json:"username,omitempty"`
+	Completed        int       `json:"completed"`
+	Participants     int       `json:"participants"`
+	ThresholdPercent int       `json:"threshold_percent,omitempty"`
+	OccurredAt       time.Time `json:"occurred_at"`
+
+	// Leaderboard holds the top public standings on leaderboard-changed
+	// events, where Participants is the full leaderboard length.
+	Leaderboard []RankedLeaderboardEntry `json:"leaderboard,omitempty"`
+	// Result is Username's final standing on final-results events, where
+	// Participants counts the final standings.
+	Result *FinalResult `json:"result,omitempty"`
+}
+
+// CompletionNotifier delivers event to url. It is called inline after a
+// submission, so implementations must hand network work off and return quickly.
+type CompletionNotifier func(url string, event CompletionEvent)
+
+// CompletionWatch fires EventCompletionThreshold once when ThresholdPercent of
+// Participants have answered every non-voided question, and EventUserFinished
+// once when Username has. With LeaderboardChanges it also fires
+// EventLeaderboardChanged after submissions move the leaderboard, at most once
+// per LeaderboardNotifyInterval. With FinalResults it fires EventFinalResults
+// once per participant when the quiz's results are published, which happens as
+// soon as the quiz locks. At least one trigger must be set.
+type CompletionWatch struct {
+	URL                string
+	Participants       []string
+	ThresholdPercent   int
+	Username           string
+	LeaderboardChanges bool
+	FinalResults       bool
+}
+
+type completionWatchState struct {
+	watch          CompletionWatch
+	thresholdFired bool
+	userFired      bool
+}
+
+func (w *completionWatchState) pending() bool {
+	thresholdPending := w.watch.ThresholdPercent > 0 && !w.thresholdFired
+	userPending := w.watch.Username != "" && !w.userFired
+	return thresholdPending || userPending
+}
+
+// WatchCompletion registers watch on quizID and returns it with usernames
+// normalized. A watch whose condition already holds fires immediately.
+func (s *Service) WatchCompletion(ctx context.Context, quizID string, watch CompletionWatch) (CompletionWatch, error) {
+	if s.notifier == nil {
+		return CompletionWatch{}, ErrNotificationsDisabled
+	}
+
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return CompletionWatch{}, err
+	}
+
+	watch, err = normalizeCompletionWatch(watch)
+	if err != nil {
+		return CompletionWatch{}, err
+	}
+
+	s.watchesMu.Lock()
+	s.completionWatches[metadata.QuizID] = append(s.completionWatches[metadata.QuizID], &completionWatchState{watch: watch})
+	s.watchesMu.Unlock()
+
+	s.checkCompletionWatches(ctx, metadata.QuizID)
+	if watch.FinalResults {
+		s.publishWhenLocked(metadata.QuizID)
+	}
+	return watch, nil
+}
+
+func normalizeCompletionWatch(watch CompletionWatch) (CompletionWatch, error) {
+	watch.URL = strings.TrimSpace(watch.URL)
+	if watch.URL == "" {
+		return CompletionWatch{}, fmt.Errorf("%w: url is required", ErrInvalidCompletionWatch)
+	}
+	if watch.ThresholdPercent < 0 || watch.ThresholdPercent > 100 {
+		return CompletionWatch{}, fmt.Errorf("%w: threshold_percent must be between 0 and 100", ErrInvalidCompletionWatch)
+	}
+
+	participants := make([]string, 0, len(watch.Participants))
+	seen := make(map[string]struct{}, len(watch.Participants))
+	for _, participant := range watch.Participants {
+		normalized, err := normalizeUsername(participant)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[normalized]; ok {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		participants = append(participants, normalized)
+	}
+	watch.Participants = participants
+
+	if watch.ThresholdPercent > 0 && len(participants) == 0 {
+		return CompletionWatch{}, fmt.Errorf("%w: participants are required with threshold_percent", ErrInvalidCompletionWatch)
+	}
+	if strings.TrimSpace(watch.Username) != "" {
+		watch.Username, _ = normalizeUsername(watch.Username)
+	}
+	if watch.ThresholdPercent == 0 && watch.Username == "" && !watch.LeaderboardChanges && !watch.FinalResults {
+		return CompletionWatch{}, fmt.Errorf("%w: set threshold_percent, username, leaderboard_changes, or final_results", ErrInvalidCompletionWatch)
+	}
+	return watch, nil
+}
+
+// checkCompletionWatches evaluates pending watches for quizID. It is best-effort:
+// a lookup failure skips this round, and the next submission re-checks.
+func (s *Service) checkCompletionWatches(ctx context.Context, quizID string) {
+	if s.notifier == nil || !s.hasPendingCompletionWatches(quizID) {
+		return
+	}
+
+	_, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return
+	}
+	activeQuestions := 0
+	for _, question := range questions {
+		if !question.Voided {
+			activeQuestions++
+		}
+	}
+	if activeQuestions == 0 {
+		return
+	}
+
+	entries, err := s.GetLeaderboard(ctx, quizID, 0)
+	if err != nil {
+		return
+	}
+	finished := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		if entry.AnsweredCount >= activeQuestions {
+			finished[entry.Username] = struct{}{}
+		}
+	}
+
+	now := s.now().UTC()
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+
+	for _, state := range s.completionWatches[quizID] {
+		watch := state.watch
+		if watch.ThresholdPercent > 0 && !state.thresholdFired {
+			completed := 0
+			for _, participant := range watch.Participants {
+				if _, ok := finished[participant]; ok {
+					completed++
+				}
+			}
+			if completed*100 >= watch.ThresholdPercent*len(watch.Participants) {
+				state.thresholdFired = true
+				s.notifier(watch.URL, CompletionEvent{
+					Event:            EventCompletionThreshold,
+					QuizID:           quizID,
+					Completed:        completed,
+					Participants:     len(watch.Participants),
+					ThresholdPercent: watch.ThresholdPercent,
+					OccurredAt:       now,
+				})
+			}
+		}
+
+		if watch.Username != "" && !state.userFired {
+			if _, ok := finished[watch.Username]; ok {
+				state.userFired = true
+				s.notifier(watch.URL, CompletionEvent{
+					Event:        EventUserFinished,
+					QuizID:       quizID,
+					Username:     watch.Username,
+					Completed:    1,
+					Participants: 1,
+					OccurredAt:   now,
+				})
+			}
+		}
+	}
+}
+
+func (s *Service) hasPendingCompletionWatches(quizID string) bool {
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+
+	for _, state := range s.completionWatches[quizID] {
+		if state.pending() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) hasLeaderboardWatches(quizID string) bool {
+	if s.notifier == nil {
+		return false
+	}
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+
+	for _, state := range s.completionWatches[quizID] {
+		if state.watch.LeaderboardChanges {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyLeaderboardWatches sends the current public standings of quizID to
+// every watch that asked for leaderboard changes. Like completion checks it is
+// best-effort: a lookup failure skips this change.
+func (s *Service) notifyLeaderboardWatches(ctx context.Context, quizID string) {
+	if !s.hasLeaderboardWatches(quizID) {
+		return
+	}
+	board, err := s.GetPublicLeaderboard(ctx, quizID, 0)
+	if err != nil {
+		return
+	}
+	event := CompletionEvent{
+		Event:        EventLeaderboardChanged,
+		QuizID:       quizID,
+		Participants: len(board.Entries),
+		Leaderboard:  board.Entries[:min(len(board.Entries), leaderboardWebhookEntries)],
+		OccurredAt:   s.now().UTC(),
+	}
+
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+	for _, state := range s.completionWatches[quizID] {
+		if state.watch.LeaderboardChanges {
+			s.notifier(state.watch.URL, event)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Providers recorded for quizzes whose questions were not fetched.
+const (
+	// ProviderCustom marks caller-supplied questions, including imports.
+	ProviderCustom = "custom"
+	// ProviderBookmarks marks practice quizzes built from a user's bookmarks.
+	ProviderBookmarks = "bookmarks"
+	// ProviderBank marks quizzes sampled from tagged stored questions.
+	ProviderBank = "bank"
+)
+
+// defaultProviderName is recorded for fetched quizzes when
+// ServiceOptions.ProviderName is nil, since the default fetcher is
+// OpenTriviaDB.
+const defaultProviderName = "opentdb"
+
+// ErrNotRefetchable reports a fresh rematch of a quiz whose questions were not
+// fetched from a provider, so there is nothing to fetch again.
+var ErrNotRefetchable = errors.New("quiz questions were not fetched from a provider")
+
+// QuizOrigin records how a quiz was created, so it can be created again. The
+// requested question count lives in QuizMetadata.RequestedQuestionCount.
+type QuizOrigin struct {
+	// Provider is where the questions came from: the configured provider's
+	// name for fetched quizzes, or ProviderCustom, ProviderBookmarks, or
+	// ProviderBank. Empty for quizzes stored before origins were recorded.
+	Provider string
+	// FallbackFrom names the provider that failed when Provider is one of
+	// ServiceOptions.FallbackProviders standing in for it; empty otherwise.
+	FallbackFrom string
+	// DifficultyMix is what a mixed quiz asked for; nil otherwise.
+	DifficultyMix DifficultyMix
+	// Seed shuffled the options of fetched questions: building the same
+	// provider questions, in the same order, with NewSeededQuestionBuilder(Seed)
+	// gives the same option order. Zero means the options were shuffled
+	// without a recorded seed.
+	Seed int64
+	// Category is the OpenTriviaDB category ID fetched questions were drawn
+	// from; zero for any category.
+	Category int
+	// QuestionType is the question type fetched questions were drawn from,
+	// multiple or boolean; empty for either.
+	QuestionType string
+}
+
+// Fetched reports whether the questions came from the provider.
+func (o QuizOrigin) Fetched() bool {
+	switch o.Provider {
+	case "", ProviderCustom, ProviderBookmarks, ProviderBank:
+		return false
+	}
+	return true
+}
+
+// RematchMode picks the questions of a rematch.
+type RematchMode string
+
+const (
+	// RematchFresh fetches new questions with the original parameters.
+	RematchFresh RematchMode = "fresh"
+	// RematchSame reuses the original questions, as a template.
+	RematchSame RematchMode = "same"
+)
+
+// ParseRematchMode accepts "fresh", "same", or empty for the default.
+func ParseRematchMode(value string) (RematchMode, error) {
+	mode := RematchMode(strings.ToLower(strings.TrimSpace(value)))
+	switch mode {
+	case "", RematchFresh, RematchSame:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: unknown rematch mode %q (want fresh or same)", ErrInvalidQuestion, value)
+	}
+}
+
+// Rematch creates a new quiz with the same settings as quizID: question count,
+// difficulty mix, time limit, and the practice and adaptive flags. RematchFresh
+// fetches new questions from the provider configured now, which may differ from
+// the one recorded; RematchSame copies the questions that were not voided. An
+// empty mode is fresh for fetched quizzes and same for the rest. The new quiz
+// has no attempts, leaderboard settings, or roster.
+func (s *Service) Rematch(ctx context.Context, quizID string, mode RematchMode) (QuizMetadata, []Question, error) {
+	metadata, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return QuizMetadata{}, nil, err
+	}
+	if mode == "" {
+		mode = RematchSame
+		if metadata.Origin.Fetched() {
+			mode = RematchFresh
+		}
+	}
+	options := QuizOptions{
+		QuestionTimeLimit: metadata.QuestionTimeLimit,
+		Category:          metadata.Origin.Category,
+		QuestionType:      metadata.Origin.QuestionType,
+	}
+
+	if mode == RematchSame {
+		kept := make([]Question, 0, len(questions))
+		for _, question := range questions {
+			if !question.Voided {
+				kept = append(kept, question)
+			}
+		}
+		origin := metadata.Origin
+		if origin.Provider == "" {
+			origin.Provider = ProviderCustom
+		}
+		created, err := s.createCustomQuiz(ctx, kept, CustomQuizOptions{Practice: metadata.Practice, Adaptive: metadata.Adaptive, QuizOptions: options}, origin)
+		if err != nil {
+			return QuizMetadata{}, nil, err
+		}
+		return created, kept, nil
+	}
+
+	if !metadata.Origin.Fetched() {
+		return QuizMetadata{}, nil, ErrNotRefetchable
+	}
+	count := metadata.RequestedQuestionCount
+	if count <= 0 {
+		count = metadata.QuestionCount
+	}
+	var created QuizMetadata
+	switch {
+	case len(metadata.Origin.DifficultyMix) > 0:
+		created, _, err = s.CreateMixedQuiz(ctx, metadata.Origin.DifficultyMix, options)
+	case metadata.Adaptive:
+		created, err = s.CreateAdaptiveQuiz(ctx, count)
+	default:
+		created, err = s.CreateQuizWithOptions(ctx, count, options)
+	}
+	if err != nil {
+		return QuizMetadata{}, nil, err
+	}
+	_, fresh, err := s.GetQuizQuestions(ctx, created.QuizID, false, 0)
+	if err != nil {
+		return QuizMetadata{}, nil, err
+	}
+	return created, fresh, nil
+}
+
+// fetchedOrigin is the origin of a quiz about to be fetched, with a builder
+// seeded by origin.Seed for its questions.
+func (s *Service) fetchedOrigin() (QuizOrigin, *QuestionBuilder) {
+	seed := s.newSeed()
+	return QuizOrigin{Provider: s.providerName(), Seed: seed}, NewSeededQuestionBuilder(seed)
+}
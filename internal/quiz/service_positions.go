@@ -0,0 +1,213 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// Providers place the correct answer at random, but a short quiz can still
+// land on B six times out of ten, and players who notice guess B. The answer
+// position report compares how often each letter is correct with what an even
+// spread would give, and a rebalance moves correct answers off over-used
+// letters. Only questions nobody in the quiz has answered are moved, and only
+// before the quiz locks, since a moved answer changes the question's letters
+// and therefore its ID.
+
+var (
+	// ErrQuizLocked reports a change to a quiz that has already locked.
+	ErrQuizLocked = errors.New("quiz has locked")
+	// ErrQuestionAnswered reports a question swap refused because the quiz
+	// already holds answers to the question.
+	ErrQuestionAnswered = errors.New("question already has answers in this quiz")
+)
+
+// AnswerLetterCount is how often one letter is the correct answer.
+type AnswerLetterCount struct {
+	Letter  string
+	Correct int
+	// Expected is the count an even spread gives: the sum, over questions
+	// with this letter, of one over their option count.
+	Expected float64
+	// Outlier is set when Correct is more than outlierMargin away from
+	// Expected.
+	Outlier bool
+}
+
+// AnswerPositionReport is the spread of correct letters across a quiz's
+// non-voided questions.
+type AnswerPositionReport struct {
+	QuizID    string
+	Questions int
+	Letters   []AnswerLetterCount
+	// Moved maps the old ID of each question a rebalance moved to its new
+	// ID. Empty for a plain report.
+	Moved map[string]string
+	// Kept lists questions on over-used letters that could not move because
+	// they already have answers.
+	Kept []string
+}
+
+// outlierMargin is how far a letter's count may stray from its expected
+// count: half the expected count, and at least one question.
+func outlierMargin(expected float64) float64 {
+	return math.Max(1, expected/2)
+}
+
+// AnswerPositions reports quizID's spread of correct letters.
+func (s *Service) AnswerPositions(ctx context.Context, quizID string) (AnswerPositionReport, error) {
+	metadata, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return AnswerPositionReport{}, err
+	}
+	return answerPositions(metadata.QuizID, questions), nil
+}
+
+// RebalanceAnswerPositions moves correct answers of unanswered questions from
+// over-used letters to under-used ones until no move evens the spread out
+// further. A moved question swaps its correct option with the option at the
+// target letter, so it gets a new question ID; players holding the old copy
+// are served the new one. It needs a store that implements QuestionReshuffler.
+func (s *Service) RebalanceAnswerPositions(ctx context.Context, quizID string) (AnswerPositionReport, error) {
+	reshuffler, ok := s.quizzes.(QuestionReshuffler)
+	if !ok {
+		return AnswerPositionReport{}, ErrUnsupported
+	}
+	metadata, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return AnswerPositionReport{}, err
+	}
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return AnswerPositionReport{}, err
+	}
+	if lockAt := settings.lockTime(metadata); metadata.Locked || (!lockAt.IsZero() && !s.now().Before(lockAt)) {
+		return AnswerPositionReport{}, ErrQuizLocked
+	}
+
+	questions = slices.Clone(questions)
+	moved := make(map[string]string)
+	kept := make(map[int]bool)
+	defer func() {
+		if len(moved) > 0 {
+			s.invalidateQuizScoring(metadata.QuizID)
+		}
+	}()
+
+	for {
+		report := answerPositions(metadata.QuizID, questions)
+		deviation := make(map[string]float64, len(report.Letters))
+		for _, count := range report.Letters {
+			deviation[count.Letter] = float64(count.Correct) - count.Expected
+		}
+
+		// Pick the movable question whose move evens the spread most: off
+		// the most over-used letter, onto the least used letter it offers.
+		// Once outliers are gone this keeps going while a move still helps,
+		// so a rebalance does not leave 3-2-2-1 splits behind.
+		best, bestTarget, bestGain := -1, 0, 1.0
+		for idx, question := range questions {
+			if question.Voided || question.Type == TypeMultiSelect || kept[idx] || deviation[quizkit.OptionLetter(question.CorrectIndex)] <= 0 {
+				continue
+			}
+			from := deviation[quizkit.OptionLetter(question.CorrectIndex)]
+			for target := range question.Options {
+				// Moving lowers from by one and raises the target by one,
+				// which only helps when the target is more than one below.
+				if gain := from - deviation[quizkit.OptionLetter(target)]; gain > bestGain {
+					best, bestTarget, bestGain = idx, target, gain
+				}
+			}
+		}
+		if best < 0 {
+			report.Moved = moved
+			for idx := range kept {
+				report.Kept = append(report.Kept, questions[idx].QuestionID)
+			}
+			slices.Sort(report.Kept)
+			return report, nil
+		}
+
+		original := questions[best]
+		swapped := swapCorrectOption(original, bestTarget)
+		err := reshuffler.ReplaceQuizQuestion(ctx, metadata.QuizID, original.QuestionID, swapped)
+		if errors.Is(err, ErrQuestionAnswered) {
+			kept[best] = true
+			continue
+		}
+		if err != nil {
+			return AnswerPositionReport{}, err
+		}
+		questions[best] = swapped
+		// A question moved twice still reports its ID from before the run.
+		from := original.QuestionID
+		for old, current := range moved {
+			if current == original.QuestionID {
+				from = old
+			}
+		}
+		moved[from] = swapped.QuestionID
+	}
+}
+
+func answerPositions(quizID string, questions []Question) AnswerPositionReport {
+	report := AnswerPositionReport{QuizID: quizID}
+	for _, question := range questions {
+		// A multi-select question has no single position to count.
+		if question.Voided || question.Type == TypeMultiSelect || len(question.Options) == 0 {
+			continue
+		}
+		report.Questions++
+		for len(report.Letters) < len(question.Options) {
+			report.Letters = append(report.Letters, AnswerLetterCount{Letter: quizkit.OptionLetter(len(report.Letters))})
+		}
+		share := 1 / float64(len(question.Options))
+		for idx := range question.Options {
+			report.Letters[idx].Expected += share
+		}
+		if question.CorrectIndex >= 0 && question.CorrectIndex < len(question.Options) {
+			report.Letters[question.CorrectIndex].Correct++
+		}
+	}
+	for idx := range report.Letters {
+		count := &report.Letters[idx]
+		count.Expected = math.Round(count.Expected*100) / 100
+		count.Outlier = math.Abs(float64(count.Correct)-count.Expected) > outlierMargin(count.Expected)
+	}
+	return report
+}
+
+// swapCorrectOption returns question with its correct option and the option
+// at target trading places, along with their feedback and translated text.
+func swapCorrectOption(question Question, target int) Question {
+	from := question.CorrectIndex
+	swap := func(values []string) []string {
+		if from >= len(values) || target >= len(values) {
+			return values
+		}
+		values = slices.Clone(values)
+		values[from], values[target] = values[target], values[from]
+		return values
+	}
+
+	options := slices.Clone(question.Options)
+	options[from].Text, options[target].Text = options[target].Text, options[from].Text
+	question.Options = options
+	question.CorrectIndex = target
+	if question.Feedback != nil {
+		question.Feedback = swap(question.Feedback)
+	}
+	if question.Translations != nil {
+		translations := make(map[string]Translation, len(question.Translations))
+		for language, translation := range question.Translations {
+			translation.Options = swap(translation.Options)
+			translations[language] = translation
+		}
+		question.Translations = translations
+	}
+	question.QuestionID = MakeQuestionID(question)
+	return question
+}
@@ -0,0 +1,76 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidPreferences reports preferences with a negative question count or
+// an unknown difficulty.
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+func (s *Service) preferenceStore() (PreferenceStore, error) {
+	store, ok := s.attempts.(PreferenceStore)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return store, nil
+}
+
+// GetPreferences returns username's quiz defaults, or empty preferences if
+// none were saved.
+func (s *Service) GetPreferences(ctx context.Context, username string) (UserPreferences, error) {
+	store, err := s.preferenceStore()
+	if err != nil {
+		return UserPreferences{}, err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return UserPreferences{}, err
+	}
+	return store.GetPreferences(ctx, usernameNormalized)
+}
+
+// SetPreferences replaces username's quiz defaults. A zero QuestionCount or
+// empty Difficulty clears that default. Callers cap QuestionCount to what
+// quiz creation allows when they apply it.
+func (s *Service) SetPreferences(ctx context.Context, username string, preferences UserPreferences) (UserPreferences, error) {
+	store, err := s.preferenceStore()
+	if err != nil {
+		return UserPreferences{}, err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return UserPreferences{}, err
+	}
+	if preferences.QuestionCount < 0 {
+		return UserPreferences{}, fmt.Errorf("%w: question_count must not be negative", ErrInvalidPreferences)
+	}
+	difficulty, err := ParseDifficulty(string(preferences.Difficulty))
+	if err != nil {
+		return UserPreferences{}, fmt.Errorf("%w: unknown difficulty %q (want easy, medium, or hard)", ErrInvalidPreferences, preferences.Difficulty)
+	}
+
+	saved := UserPreferences{
+		Username:      usernameNormalized,
+		QuestionCount: preferences.QuestionCount,
+		Difficulty:    difficulty,
+		UpdatedAt:     s.now().UTC(),
+	}
+	if err := store.SavePreferences(ctx, saved); err != nil {
+		return UserPreferences{}, err
+	}
+	return saved, nil
+}
+
+// CreatorPreferences returns the defaults to apply to a quiz username is
+// creating. Anonymous requests and stores without preferences get none, so
+// creation never fails for want of them.
+func (s *Service) CreatorPreferences(ctx context.Context, username string) (UserPreferences, error) {
+	preferences, err := s.GetPreferences(ctx, username)
+	if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrInvalidUsername) {
+		return UserPreferences{}, nil
+	}
+	return preferences, err
+}
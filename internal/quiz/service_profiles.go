@@ -0,0 +1,137 @@
+package quiz
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// anonymousPrefix starts the pseudonym shown for anonymous participants.
+const anonymousPrefix = "anonymous-"
+
+func (s *Service) profileStore() (ProfileStore, error) {
+	store, ok := s.attempts.(ProfileStore)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return store, nil
+}
+
+// GetProfile returns username's profile, or the defaults if none was saved.
+func (s *Service) GetProfile(ctx context.Context, username string) (UserProfile, error) {
+	store, err := s.profileStore()
+	if err != nil {
+		return UserProfile{}, err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return UserProfile{}, err
+	}
+	return store.GetProfile(ctx, usernameNormalized)
+}
+
+// SetAnonymous changes whether username appears on public leaderboards. It
+// applies to every quiz, including ones already played.
+func (s *Service) SetAnonymous(ctx context.Context, username string, anonymous bool) (UserProfile, error) {
+	store, err := s.profileStore()
+	if err != nil {
+		return UserProfile{}, err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return UserProfile{}, err
+	}
+
+	profile := UserProfile{Username: usernameNormalized, Anonymous: anonymous, UpdatedAt: s.now().UTC()}
+	if err := store.SaveProfile(ctx, profile); err != nil {
+		return UserProfile{}, err
+	}
+	return profile, nil
+}
+
+// GetPublicLeaderboard is GetLeaderboard for display: entries are ranked and
+// anonymous participants are masked. Ranks are computed before masking, so
+// masked entries keep their place. During the quiz's leaderboard freeze, the
+// entries are the standings as of the freeze.
+func (s *Service) GetPublicLeaderboard(ctx context.Context, quizID string, limit int) (PublicLeaderboard, error) {
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return PublicLeaderboard{}, err
+	}
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return PublicLeaderboard{}, err
+	}
+
+	var board PublicLeaderboard
+	var entries []LeaderboardEntry
+	if frozenAt, revealAt, frozen := settings.freeze(metadata, s.now()); frozen {
+		board.FrozenAt, board.RevealAt = frozenAt, revealAt
+		entries, err = s.leaderboardAsOf(ctx, metadata.QuizID, frozenAt, settings.Tiebreak)
+		entries = applyLeaderboardLimit(entries, limit)
+	} else {
+		entries, err = s.GetLeaderboard(ctx, metadata.QuizID, limit)
+	}
+	if err != nil {
+		return PublicLeaderboard{}, err
+	}
+
+	board.Entries, err = s.rankPublicly(ctx, metadata.QuizID, entries)
+	if err != nil {
+		return PublicLeaderboard{}, err
+	}
+	return board, nil
+}
+
+// rankPublicly numbers entries from 1 and masks anonymous players.
+func (s *Service) rankPublicly(ctx context.Context, quizID string, entries []LeaderboardEntry) ([]RankedLeaderboardEntry, error) {
+	ranked := make([]RankedLeaderboardEntry, 0, len(entries))
+	for idx, entry := range entries {
+		ranked = append(ranked, RankedLeaderboardEntry{Rank: idx + 1, LeaderboardEntry: entry})
+	}
+	return s.maskAnonymous(ctx, quizID, ranked)
+}
+
+// maskAnonymous returns entries with anonymous usernames replaced by
+// pseudonyms. A lookup failure is returned rather than ignored so names are
+// never shown by mistake. Stores without profiles have no anonymous users.
+func (s *Service) maskAnonymous(ctx context.Context, quizID string, entries []RankedLeaderboardEntry) ([]RankedLeaderboardEntry, error) {
+	store, err := s.profileStore()
+	if err != nil || len(entries) == 0 {
+		return entries, nil
+	}
+
+	usernames := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		usernames = append(usernames, entry.Username)
+	}
+	anonymous, err := store.AnonymousUsers(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	masked := make([]RankedLeaderboardEntry, len(entries))
+	copy(masked, entries)
+	for idx := range masked {
+		if anonymous[masked[idx].Username] {
+			masked[idx].Username = s.pseudonym(quizID, masked[idx].Username)
+			masked[idx].Anonymous = true
+		}
+	}
+	return masked, nil
+}
+
+// pseudonym names an anonymous participant consistently within one quiz, so
+// stream clients can still track their entry, but differently across quizzes
+// so results cannot be linked. The key is per process; pseudonyms change on
+// restart, when live streams start over with a snapshot anyway.
+func (s *Service) pseudonym(quizID, usernameNormalized string) string {
+	const hashChars = 8
+
+	mac := hmac.New(sha256.New, s.pseudonymKey)
+	mac.Write([]byte(quizID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(usernameNormalized))
+	return anonymousPrefix + hex.EncodeToString(mac.Sum(nil))[:hashChars]
+}
@@ -0,0 +1,116 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// At hybrid events some players answer on paper, and an admin enters their
+// answers afterwards. Those answers are scored like any other but keep the
+// admin's name on the attempt, and each batch is written to the quiz's audit
+// log before it is stored, so no proxy answer exists without a record of who
+// entered it. Admin tokens are shared, so the name is the one the admin gives.
+
+// AuditSubmitOnBehalf is the audit action for answers an admin entered for a
+// player.
+const AuditSubmitOnBehalf = "submit_on_behalf"
+
+// maxActingAdminLength bounds the admin name kept with each proxy answer.
+const maxActingAdminLength = 64
+
+// ErrInvalidActingAdmin reports a proxy submission or host change without a
+// usable admin name.
+var ErrInvalidActingAdmin = errors.New("admin must name who is making the change (at most 64 characters)")
+
+// SubmitOnBehalf stores responses as username's answers, entered by
+// actingAdmin. Answers are scored as usual but earn no speed bonus, and
+// offline signatures and content hashes are ignored because the admin did not
+// see the questions as served. A restricted roster still applies, and drafts
+// and locked quizzes refuse the answers. It needs a store that implements
+// AuditLog.
+func (s *Service) SubmitOnBehalf(ctx context.Context, quizID, username, actingAdmin string, responses []SubmittedResponse) ([]ResponseResult, error) {
+	audit, ok := s.attempts.(AuditLog)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	actor, err := normalizeActingAdmin(actingAdmin)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	// Paper answers are entered after the event, so only drafts and locked
+	// quizzes refuse them.
+	if state := metadata.State(s.now()); state == QuizStateDraft || state == QuizStateLocked {
+		return nil, fmt.Errorf("%w: quiz is %s", ErrQuizClosed, state)
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRoster(ctx, metadata.QuizID, usernameNormalized); err != nil {
+		return nil, err
+	}
+
+	skipped := duplicateResponses(responses)
+	fresh := withoutSetAside(responses, skipped)
+	if len(fresh) == 0 {
+		return mergeSetAside(nil, skipped, len(responses)), nil
+	}
+	proxied := make([]SubmittedResponse, 0, len(fresh))
+	answers := make([]string, 0, len(fresh))
+	for _, response := range fresh {
+		proxied = append(proxied, SubmittedResponse{QuestionID: response.QuestionID, Answer: response.Answer, SubmittedBy: actor})
+		answers = append(answers, response.QuestionID+"="+strings.TrimSpace(response.Answer))
+	}
+
+	err = audit.RecordAuditEntry(ctx, AuditEntry{
+		QuizID:   metadata.QuizID,
+		At:       s.now().UTC(),
+		Actor:    actor,
+		Action:   AuditSubmitOnBehalf,
+		Username: usernameNormalized,
+		Detail:   strings.Join(answers, ", "),
+	})
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.attempts.SubmitResponses(ctx, metadata.QuizID, usernameNormalized, proxied)
+	if err != nil {
+		return nil, err
+	}
+	s.afterSubmission(ctx, metadata.QuizID, usernameNormalized, results)
+	return mergeSetAside(results, skipped, len(responses)), nil
+}
+
+// AuditEntries returns quizID's audit entries, oldest first. It needs a store that
+// implements AuditLog.
+func (s *Service) AuditEntries(ctx context.Context, quizID string) ([]AuditEntry, error) {
+	audit, ok := s.attempts.(AuditLog)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	return audit.ListAuditEntries(ctx, metadata.QuizID)
+}
+
+// normalizeActingAdmin trims name and rejects empty, overlong, or
+// control-character names, which would make the audit log ambiguous.
+func normalizeActingAdmin(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxActingAdminLength {
+		return "", ErrInvalidActingAdmin
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return "", ErrInvalidActingAdmin
+	}
+	return name, nil
+}
@@ -0,0 +1,221 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// GlobalRanking says which total orders a global leaderboard.
+type GlobalRanking string
+
+const (
+	// RankNormalized orders by the sum of each quiz's normalized score, so
+	// every quiz counts the same whatever its length; see
+	// quizkit.NormalizedScore.
+	RankNormalized GlobalRanking = "normalized"
+	// RankRaw orders by points summed across quizzes.
+	RankRaw GlobalRanking = "raw"
+)
+
+// ParseGlobalRanking maps a query value to a GlobalRanking. Empty means
+// normalized.
+func ParseGlobalRanking(value string) (GlobalRanking, error) {
+	switch GlobalRanking(strings.ToLower(strings.TrimSpace(value))) {
+	case "", RankNormalized:
+		return RankNormalized, nil
+	case RankRaw:
+		return RankRaw, nil
+	default:
+		return "", errors.New("order must be one of: normalized, raw")
+	}
+}
+
+// GlobalLeaderboardQuery selects a global leaderboard. Since and Until bound
+// a season: only quizzes created in [Since, Until) count. Zero bounds are
+// open, so the zero query ranks every quiz ever played.
+type GlobalLeaderboardQuery struct {
+	Since   time.Time
+	Until   time.Time
+	OrderBy GlobalRanking
+	// Limit caps the entries returned; zero or less returns them all.
+	Limit int
+}
+
+// GlobalEntry is one player's standing across quizzes. RawScore sums their
+// points; NormalizedScore sums, per quiz, their share of the quiz's maximum
+// as a percentage scaled by the quiz's difficulty.
+type GlobalEntry struct {
+	Rank             int
+	Username         string
+	Anonymous        bool
+	QuizzesPlayed    int
+	AnsweredCount    int
+	CorrectCount     int
+	RawScore         float64
+	NormalizedScore  float64
+	LastSubmissionAt time.Time
+}
+
+// GlobalLeaderboard ranks players across every quiz in a season.
+type GlobalLeaderboard struct {
+	Since   time.Time
+	Until   time.Time
+	OrderBy GlobalRanking
+	// QuizCount is how many quizzes counted; Participants how many players
+	// are ranked, including any past Limit.
+	QuizCount    int
+	Participants int
+	Entries      []GlobalEntry
+}
+
+// quizNorm is what a quiz's questions make possible, for normalizing its
+// scores.
+type quizNorm struct {
+	maxScore float64
+	weight   float64
+}
+
+// GetGlobalLeaderboard ranks every player by their totals across the quizzes
+// query selects, archived attempts included. Practice quizzes are left out:
+// they are built from each player's own bookmarks. Each quiz's maximum comes
+// from its questions now, so voiding a question raises everyone's share of
+// that quiz. Anonymous players are masked with a pseudonym that differs from
+// theirs on any one quiz. A quiz whose leaderboard is frozen counts as it
+// stood at the freeze.
+func (s *Service) GetGlobalLeaderboard(ctx context.Context, query GlobalLeaderboardQuery) (GlobalLeaderboard, error) {
+	lister, ok := s.attempts.(AttemptSummaryLister)
+	if !ok {
+		return GlobalLeaderboard{}, ErrUnsupported
+	}
+	if query.OrderBy == "" {
+		query.OrderBy = RankNormalized
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && !query.Since.Before(query.Until) {
+		return GlobalLeaderboard{}, errors.New("since must be before until")
+	}
+
+	summaries, err := lister.ListAttemptSummaries(ctx, query.Since, query.Until)
+	if err != nil {
+		return GlobalLeaderboard{}, err
+	}
+
+	policy := s.ScoringPolicy()
+	norms := make(map[string]quizNorm)
+	metadatas := make(map[string]QuizMetadata)
+	byUser := make(map[string]*GlobalEntry)
+	for _, summary := range summaries {
+		norm, seen := norms[summary.QuizID]
+		if !seen {
+			metadata, questions, err := s.GetQuizQuestions(ctx, summary.QuizID, false, 0)
+			if errors.Is(err, ErrQuizNotFound) {
+				continue
+			}
+			if err != nil {
+				return GlobalLeaderboard{}, err
+			}
+			if !metadata.Practice {
+				norm = quizNorm{maxScore: policy.MaxScore(questions), weight: quizkit.QuizWeight(questions)}
+			}
+			norms[summary.QuizID] = norm
+			metadatas[summary.QuizID] = metadata
+		}
+		if norm == (quizNorm{}) {
+			continue
+		}
+		summary, ok, err := s.publicSummary(ctx, metadatas[summary.QuizID], summary)
+		if err != nil {
+			return GlobalLeaderboard{}, err
+		}
+		if !ok {
+			continue
+		}
+
+		entry, ok := byUser[summary.Username]
+		if !ok {
+			entry = &GlobalEntry{Username: summary.Username}
+			byUser[summary.Username] = entry
+		}
+		entry.QuizzesPlayed++
+		entry.AnsweredCount += summary.AnsweredCount
+		entry.CorrectCount += summary.CorrectCount
+		entry.RawScore += summary.TotalScore
+		entry.NormalizedScore += quizkit.NormalizedScore(summary.TotalScore, norm.maxScore, norm.weight)
+		if summary.LastSubmittedAt.After(entry.LastSubmissionAt) {
+			entry.LastSubmissionAt = summary.LastSubmittedAt
+		}
+	}
+
+	board := GlobalLeaderboard{Since: query.Since, Until: query.Until, OrderBy: query.OrderBy, Participants: len(byUser)}
+	for _, norm := range norms {
+		if norm != (quizNorm{}) {
+			board.QuizCount++
+		}
+	}
+	board.Entries = make([]GlobalEntry, 0, len(byUser))
+	for _, entry := range byUser {
+		// Sums of rounded floats drift; keep totals at the thousandths the
+		// per-quiz scores are rounded to.
+		entry.RawScore = math.Round(entry.RawScore*1000) / 1000
+		entry.NormalizedScore = math.Round(entry.NormalizedScore*1000) / 1000
+		board.Entries = append(board.Entries, *entry)
+	}
+	sortGlobalEntries(query.OrderBy, board.Entries)
+	if query.Limit > 0 && len(board.Entries) > query.Limit {
+		board.Entries = board.Entries[:query.Limit]
+	}
+	for idx := range board.Entries {
+		board.Entries[idx].Rank = idx + 1
+	}
+
+	board.Entries, err = s.maskGlobalAnonymous(ctx, board.Entries)
+	if err != nil {
+		return GlobalLeaderboard{}, err
+	}
+	return board, nil
+}
+
+// sortGlobalEntries orders entries by the chosen total, breaking ties as quiz
+// leaderboards do: whoever reached the score first, then by username.
+func sortGlobalEntries(orderBy GlobalRanking, entries []GlobalEntry) {
+	standing := func(entry GlobalEntry) LeaderboardEntry {
+		total := entry.NormalizedScore
+		if orderBy == RankRaw {
+			total = entry.RawScore
+		}
+		return LeaderboardEntry{Username: entry.Username, TotalScore: total, LastSubmissionAt: entry.LastSubmissionAt}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return quizkit.RanksBefore(standing(entries[i]), standing(entries[j]))
+	})
+}
+
+// maskGlobalAnonymous is maskAnonymous for global entries. Pseudonyms are
+// keyed to no quiz, so a global pseudonym cannot be matched to a quiz's.
+func (s *Service) maskGlobalAnonymous(ctx context.Context, entries []GlobalEntry) ([]GlobalEntry, error) {
+	store, err := s.profileStore()
+	if err != nil || len(entries) == 0 {
+		return entries, nil
+	}
+
+	usernames := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		usernames = append(usernames, entry.Username)
+	}
+	anonymous, err := store.AnonymousUsers(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range entries {
+		if anonymous[entries[idx].Username] {
+			entries[idx].Username = s.pseudonym("", entries[idx].Username)
+			entries[idx].Anonymous = true
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,92 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Admins take quizzes out of service in one of two ways. Archiving keeps the
+// outcome: the quiz is locked, its results are published, and its attempts
+// are folded into per-user summaries as attempt retention does, so the
+// leaderboard, results, and user stats stay readable while the quiz leaves
+// the active list and takes no more answers. Deleting keeps nothing: the quiz,
+// its attempts, and every setting kept for it are removed, and the quiz ID
+// reads as never created. Both need a store that implements QuizRemover.
+
+// QuizRemoval describes an archived or deleted quiz.
+type QuizRemoval struct {
+	QuizID string
+	// Attempts counts the attempts deleted; archived ones live on in the
+	// summaries.
+	Attempts int
+	// ArchivedAt is set for archived quizzes.
+	ArchivedAt time.Time
+}
+
+func (s *Service) quizRemover() (QuizRemover, error) {
+	remover, ok := s.quizzes.(QuizRemover)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return remover, nil
+}
+
+// ArchiveQuiz locks quizID, publishes its results, and archives it. An
+// archived quiz is archived again without change. Results are published
+// before the attempts go, because they are rebuilt from individual answers;
+// stores without results or attempt history archive without them.
+func (s *Service) ArchiveQuiz(ctx context.Context, quizID string) (QuizRemoval, error) {
+	remover, err := s.quizRemover()
+	if err != nil {
+		return QuizRemoval{}, err
+	}
+	// Read past the cache, like SetQuizState: another process may have
+	// archived or deleted the quiz.
+	metadata, err := s.quizzes.GetQuizMetadata(ctx, strings.TrimSpace(quizID))
+	if err != nil {
+		return QuizRemoval{}, err
+	}
+	if !metadata.ArchivedAt.IsZero() {
+		return QuizRemoval{QuizID: metadata.QuizID, ArchivedAt: metadata.ArchivedAt}, nil
+	}
+
+	if !metadata.Locked {
+		if _, err := s.SetQuizState(ctx, metadata.QuizID, QuizStateChange{State: QuizStateLocked}); err != nil && !errors.Is(err, ErrUnsupported) {
+			return QuizRemoval{}, err
+		}
+	}
+	if _, err := s.QuizResults(ctx, metadata.QuizID); err != nil && !errors.Is(err, ErrUnsupported) && !errors.Is(err, ErrResultsNotFinal) {
+		return QuizRemoval{}, err
+	}
+
+	archivedAt := s.now().UTC()
+	deleted, err := remover.ArchiveQuiz(ctx, metadata.QuizID, archivedAt)
+	if err != nil {
+		return QuizRemoval{}, err
+	}
+	// Totals survive in the summaries, but views of single answers do not.
+	s.invalidateQuiz(metadata.QuizID, QuizArchived)
+	return QuizRemoval{QuizID: metadata.QuizID, Attempts: deleted, ArchivedAt: archivedAt}, nil
+}
+
+// DeleteQuiz removes quizID and everything stored for it. Viewers of its live
+// leaderboard get a closing event, and its completion webhooks are dropped.
+func (s *Service) DeleteQuiz(ctx context.Context, quizID string) (QuizRemoval, error) {
+	remover, err := s.quizRemover()
+	if err != nil {
+		return QuizRemoval{}, err
+	}
+	quizID = strings.TrimSpace(quizID)
+	if quizID == "" {
+		return QuizRemoval{}, ErrQuizNotFound
+	}
+
+	deleted, err := remover.DeleteQuiz(ctx, quizID)
+	if err != nil {
+		return QuizRemoval{}, err
+	}
+	s.invalidateQuiz(quizID, QuizDeleted)
+	return QuizRemoval{QuizID: quizID, Attempts: deleted}, nil
+}
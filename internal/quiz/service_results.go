@@ -0,0 +1,202 @@
+package quiz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// A quiz's final results are published once, on the first request after it
+// locks, and kept as the exact bytes served. Every later read comes from that
+// document, so a results page stays the same when answers arrive after the
+// lock, players turn anonymous, or attempts are archived.
+
+// ErrResultsNotFinal reports a results request for a quiz that has not locked.
+var ErrResultsNotFinal = errors.New("results are published once the quiz locks")
+
+// ResultsExporter receives a quiz's results document when it is published. It
+// is called inline from the request that published it.
+type ResultsExporter func(results PublishedResults)
+
+// QuizResults is the results document: the final standings as of the lock
+// and how each question was answered.
+type QuizResults struct {
+	QuizID      string            `json:"quiz_id"`
+	LockedAt    time.Time         `json:"locked_at"`
+	PublishedAt time.Time         `json:"published_at"`
+	Standings   []ResultsStanding `json:"standings"`
+	Questions   []QuestionResults `json:"questions"`
+}
+
+// ResultsStanding is one player's final standing. ProxyAnswers counts the
+// answers in it that an admin entered for the player.
+type ResultsStanding struct {
+	RankedLeaderboardEntry
+	ProxyAnswers int `json:"proxy_answers,omitempty"`
+}
+
+// QuestionResults is one question of a results document with its answer and
+// how often each option was chosen before the lock. Voided questions are left
+// out. A multi-select question's CorrectLetter lists its letters as "A,C".
+type QuestionResults struct {
+	QuestionID    string         `json:"question_id"`
+	Question      string         `json:"question"`
+	Options       []Option       `json:"options"`
+	CorrectLetter string         `json:"correct_letter"`
+	Category      string         `json:"category,omitempty"`
+	Difficulty    Difficulty     `json:"difficulty,omitempty"`
+	AttemptCount  int            `json:"attempt_count"`
+	CorrectCount  int            `json:"correct_count"`
+	AnswerCounts  map[string]int `json:"answer_counts"`
+}
+
+// QuizResults returns quizID's published results, publishing them first if
+// the quiz has locked since: its leaderboard lock time or deadline has
+// passed, or it was locked outright. Before that it returns
+// ErrResultsNotFinal. Publishing needs a store that implements ResultsStore
+// and attempt history to rebuild the standings as of the lock.
+func (s *Service) QuizResults(ctx context.Context, quizID string) (PublishedResults, error) {
+	store, ok := s.quizzes.(ResultsStore)
+	if !ok {
+		return PublishedResults{}, ErrUnsupported
+	}
+	metadata, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return PublishedResults{}, err
+	}
+	published, err := store.GetQuizResults(ctx, metadata.QuizID)
+	if err != nil || published.Document != nil {
+		return published, err
+	}
+
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return PublishedResults{}, err
+	}
+	now := s.now().UTC()
+	lockedAt := settings.lockTime(metadata)
+	switch {
+	case !lockedAt.IsZero() && !now.Before(lockedAt):
+	case metadata.Locked:
+		lockedAt = now
+	default:
+		return PublishedResults{}, ErrResultsNotFinal
+	}
+
+	results, standings, err := s.finalResults(ctx, metadata.QuizID, questions, settings.Tiebreak, lockedAt.UTC())
+	if err != nil {
+		return PublishedResults{}, err
+	}
+	results.PublishedAt = now
+	document, err := json.Marshal(results)
+	if err != nil {
+		return PublishedResults{}, err
+	}
+	err = store.SaveQuizResults(ctx, PublishedResults{QuizID: metadata.QuizID, Document: document, PublishedAt: now})
+	if err != nil {
+		return PublishedResults{}, err
+	}
+	// A concurrent request may have published first; its document is the one
+	// that stays.
+	published, err = store.GetQuizResults(ctx, metadata.QuizID)
+	if err != nil {
+		return PublishedResults{}, err
+	}
+	if s.exportResults != nil {
+		s.exportResults(published)
+	}
+	if bytes.Equal(published.Document, document) {
+		s.notifyFinalResults(ctx, metadata.QuizID, standings, results.LockedAt)
+	}
+	return published, nil
+}
+
+// finalResults rebuilds the standings and question statistics from attempts
+// submitted before lockedAt. It also returns the standings before anonymous
+// players are masked, for notifying each participant.
+func (s *Service) finalResults(ctx context.Context, quizID string, questions []Question, tiebreak Tiebreak, lockedAt time.Time) (QuizResults, []RankedLeaderboardEntry, error) {
+	history, ok := s.attempts.(AttemptHistory)
+	if !ok {
+		return QuizResults{}, nil, ErrUnsupported
+	}
+	participants, err := s.GetLeaderboard(ctx, quizID, 0)
+	if err != nil {
+		return QuizResults{}, nil, err
+	}
+
+	results := QuizResults{QuizID: quizID, LockedAt: lockedAt, Questions: make([]QuestionResults, 0, len(questions))}
+	byQuestion := make(map[string]*QuestionResults, len(questions))
+	for _, question := range questions {
+		if question.Voided {
+			continue
+		}
+		item := QuestionResults{
+			QuestionID:   question.QuestionID,
+			Question:     question.Question,
+			Options:      question.Options,
+			Category:     question.Category,
+			Difficulty:   question.Difficulty,
+			AnswerCounts: make(map[string]int),
+		}
+		if question.CorrectIndex >= 0 && question.CorrectIndex < len(question.Options) {
+			item.CorrectLetter = question.CorrectLetters()
+		}
+		results.Questions = append(results.Questions, item)
+	}
+	for idx := range results.Questions {
+		byQuestion[results.Questions[idx].QuestionID] = &results.Questions[idx]
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(participants))
+	proxied := make(map[string]int)
+	for _, participant := range participants {
+		attempts, err := history.ListAttempts(ctx, quizID, participant.Username)
+		if err != nil {
+			return QuizResults{}, nil, err
+		}
+		entry := LeaderboardEntry{Username: participant.Username}
+		for _, attempt := range attempts {
+			if !attempt.SubmittedAt.Before(lockedAt) {
+				continue
+			}
+			entry.TotalScore += attempt.Score
+			entry.AnsweredCount++
+			if attempt.SubmittedAt.After(entry.LastSubmissionAt) {
+				entry.LastSubmissionAt = attempt.SubmittedAt
+			}
+			if attempt.SubmittedBy != "" {
+				proxied[participant.Username]++
+			}
+			if question, ok := byQuestion[attempt.QuestionID]; ok {
+				question.AttemptCount++
+				question.AnswerCounts[attempt.AnswerLetter]++
+				if attempt.AnswerLetter == question.CorrectLetter {
+					question.CorrectCount++
+				}
+			}
+		}
+		if entry.AnsweredCount > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	quizkit.SortLeaderboardBy(tiebreak, entries)
+
+	ranked := make([]RankedLeaderboardEntry, 0, len(entries))
+	for idx, entry := range entries {
+		ranked = append(ranked, RankedLeaderboardEntry{Rank: idx + 1, LeaderboardEntry: entry})
+	}
+	// Masking keeps the order, so proxy counts are matched up by position.
+	masked, err := s.maskAnonymous(ctx, quizID, ranked)
+	if err != nil {
+		return QuizResults{}, nil, err
+	}
+	results.Standings = make([]ResultsStanding, 0, len(masked))
+	for idx, entry := range masked {
+		results.Standings = append(results.Standings, ResultsStanding{RankedLeaderboardEntry: entry, ProxyAnswers: proxied[entries[idx].Username]})
+	}
+	return results, ranked, nil
+}
@@ -0,0 +1,136 @@
+package quiz
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// When a quiz's results are published, each participant can be told their
+// final score and rank with a link to the results document. Two channels are
+// available: webhooks registered on the quiz with FinalResults, which receive
+// one event per participant (and can forward them to a chat bot), and email
+// to participants who verified an identity, when a ResultsMailer is
+// configured. Either way the notification goes out once, from the request
+// that published the results.
+
+// EventFinalResults is sent per participant when a quiz's results are
+// published.
+const EventFinalResults = "quiz.final_results"
+
+// FinalResult is one participant's standing in published results.
+// ResultsPath is the API path of the results document; clients resolve it
+// against the service URL.
+type FinalResult struct {
+	Rank          int       `json:"rank"`
+	TotalScore    float64   `json:"total_score"`
+	AnsweredCount int       `json:"answered_count"`
+	LockedAt      time.Time `json:"locked_at"`
+	ResultsPath   string    `json:"results_path"`
+}
+
+// ResultsMailer emails a participant their final result, carried in
+// event.Result. Like CompletionNotifier it is called inline while results are
+// published, so implementations must hand network work off and return
+// quickly.
+type ResultsMailer func(email string, event CompletionEvent)
+
+// ResultsPath is the API path of quizID's results document.
+func ResultsPath(quizID string) string {
+	return "/quizzes/" + url.PathEscape(quizID) + "/results.json"
+}
+
+// notifyFinalResults sends each participant in standings their final result
+// over the configured channels. standings are unmasked: every channel is
+// private to the host or to the participant.
+func (s *Service) notifyFinalResults(ctx context.Context, quizID string, standings []RankedLeaderboardEntry, lockedAt time.Time) {
+	urls := s.finalResultsURLs(quizID)
+	identities, _ := s.quizzes.(IdentityStore)
+	if s.resultsMailer == nil {
+		identities = nil
+	}
+	if len(urls) == 0 || s.notifier == nil {
+		urls = nil
+	}
+	if len(urls) == 0 && identities == nil {
+		return
+	}
+
+	now := s.now().UTC()
+	for _, entry := range standings {
+		event := CompletionEvent{
+			Event:        EventFinalResults,
+			QuizID:       quizID,
+			Username:     entry.Username,
+			Participants: len(standings),
+			OccurredAt:   now,
+			Result: &FinalResult{
+				Rank:          entry.Rank,
+				TotalScore:    entry.TotalScore,
+				AnsweredCount: entry.AnsweredCount,
+				LockedAt:      lockedAt,
+				ResultsPath:   ResultsPath(quizID),
+			},
+		}
+		for _, target := range urls {
+			s.notifier(target, event)
+		}
+		if identities == nil {
+			continue
+		}
+		// Mail is best-effort: a lookup failure skips that participant.
+		identity, err := identities.GetIdentity(ctx, entry.Username)
+		if err == nil && identity.Verified() {
+			s.resultsMailer(identity.Email, event)
+		}
+	}
+}
+
+func (s *Service) finalResultsURLs(quizID string) []string {
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+
+	var urls []string
+	for _, state := range s.completionWatches[quizID] {
+		if state.watch.FinalResults {
+			urls = append(urls, state.watch.URL)
+		}
+	}
+	return urls
+}
+
+// publishWhenLocked publishes quizID's results, and with them the final
+// results notifications, as soon as the quiz locks instead of on the first
+// results request. While the lock time is ahead it waits on a timer, and
+// checks again when the timer fires, so a lock moved later is still honored;
+// a quiz with no lock time waits for a results request. The timer lives in
+// memory, like the watches that ask for it.
+func (s *Service) publishWhenLocked(quizID string) {
+	ctx := context.Background()
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return
+	}
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return
+	}
+
+	lockedAt := settings.lockTime(metadata)
+	if wait := lockedAt.Sub(s.now()); !metadata.Locked && !lockedAt.IsZero() && wait > 0 {
+		s.watchesMu.Lock()
+		defer s.watchesMu.Unlock()
+		if timer := s.resultsTimers[metadata.QuizID]; timer != nil {
+			timer.Stop()
+		}
+		s.resultsTimers[metadata.QuizID] = time.AfterFunc(wait, func() { s.publishWhenLocked(metadata.QuizID) })
+		return
+	}
+	s.watchesMu.Lock()
+	delete(s.resultsTimers, metadata.QuizID)
+	s.watchesMu.Unlock()
+	if metadata.Locked || !lockedAt.IsZero() {
+		// Publishing is best-effort here; the next results request retries.
+		_, _ = s.QuizResults(ctx, metadata.QuizID)
+	}
+}
@@ -0,0 +1,168 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Busy deployments pile up attempts for quizzes nobody plays any more. The
+// attempt retention policy keeps the attempts table bounded: once a quiz has
+// locked and its newest attempt is older than MaxAge, its results are
+// published, its attempts are folded into per-user summaries, and the
+// attempts are deleted. The results document, the leaderboard, and user stats
+// read the summaries from then on. Views of individual answers, such as the
+// roster, author performance, and retirement statistics, no longer see the
+// archived ones.
+
+// AttemptRetention schedules RunAttemptRetention.
+type AttemptRetention struct {
+	// MaxAge is how long after a locked quiz's last answer its attempts are
+	// archived.
+	MaxAge time.Duration
+	// Interval is the time between runs; zero means hourly.
+	Interval time.Duration
+	// Logf receives a line per run that archived something or failed; nil
+	// uses log.Printf.
+	Logf func(format string, args ...any)
+}
+
+// RetentionReport describes one EnforceAttemptRetention run.
+type RetentionReport struct {
+	// Quizzes and Attempts count the quizzes archived and attempts deleted.
+	Quizzes  int
+	Attempts int
+	// Unlocked counts idle quizzes left alone because they have not locked.
+	Unlocked int
+	// Failed counts quizzes whose results or archive failed; the run moves on
+	// to the next quiz and the next run tries again.
+	Failed int
+}
+
+func (s *Service) attemptArchiver() (AttemptArchiver, error) {
+	archiver, ok := s.attempts.(AttemptArchiver)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return archiver, nil
+}
+
+// EnforceAttemptRetention archives the attempts of every locked quiz whose
+// attempts are all older than maxAge. A quiz's results are published before
+// its attempts go, because they are rebuilt from individual answers. The
+// cached leaderboards stay valid, since archiving keeps every total.
+func (s *Service) EnforceAttemptRetention(ctx context.Context, maxAge time.Duration) (RetentionReport, error) {
+	archiver, err := s.attemptArchiver()
+	if err != nil {
+		return RetentionReport{}, err
+	}
+	if maxAge <= 0 {
+		return RetentionReport{}, errors.New("attempt retention must be positive")
+	}
+
+	cutoff := s.now().UTC().Add(-maxAge)
+	quizIDs, err := archiver.IdleQuizzes(ctx, cutoff)
+	if err != nil {
+		return RetentionReport{}, err
+	}
+
+	var (
+		report RetentionReport
+		errs   []error
+	)
+	for _, quizID := range quizIDs {
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		if _, err := s.QuizResults(ctx, quizID); err != nil {
+			if errors.Is(err, ErrResultsNotFinal) {
+				report.Unlocked++
+				continue
+			}
+			report.Failed++
+			errs = append(errs, fmt.Errorf("publish results of %s: %w", quizID, err))
+			continue
+		}
+		deleted, err := archiver.ArchiveAttempts(ctx, quizID, cutoff)
+		if err != nil {
+			report.Failed++
+			errs = append(errs, fmt.Errorf("archive attempts of %s: %w", quizID, err))
+			continue
+		}
+		report.Quizzes++
+		report.Attempts += deleted
+	}
+	return report, errors.Join(errs...)
+}
+
+// RunAttemptRetention runs EnforceAttemptRetention on retention's schedule,
+// starting right away, until ctx is done.
+func (s *Service) RunAttemptRetention(ctx context.Context, retention AttemptRetention) {
+	logf := retention.Logf
+	if logf == nil {
+		logf = log.Printf
+	}
+	interval := retention.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		report, err := s.EnforceAttemptRetention(ctx, retention.MaxAge)
+		if err != nil && ctx.Err() == nil {
+			logf("attempt retention: %d quizzes failed: %v", report.Failed, err)
+		}
+		if report.Quizzes > 0 {
+			logf("attempt retention archived quizzes=%d attempts=%d", report.Quizzes, report.Attempts)
+		}
+		timer.Reset(interval)
+	}
+}
+
+// AttemptSummaries returns username's totals in every quiz they answered in,
+// most recently played first, whether or not the attempts were archived. Like
+// UserAttempts, a quiz whose leaderboard is frozen counts as of the freeze.
+func (s *Service) AttemptSummaries(ctx context.Context, username string) ([]AttemptSummary, error) {
+	archiver, err := s.attemptArchiver()
+	if err != nil {
+		return nil, err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := archiver.AttemptSummaries(ctx, usernameNormalized)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := summaries[:0]
+	for _, summary := range summaries {
+		metadata, _, err := s.ensureQuiz(ctx, summary.QuizID, false, 0, QuizOptions{})
+		if errors.Is(err, ErrQuizNotFound) {
+			visible = append(visible, summary)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		summary, ok, err := s.publicSummary(ctx, metadata, summary)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			visible = append(visible, summary)
+		}
+	}
+	return visible, nil
+}
@@ -0,0 +1,200 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+	"time"
+
+	"quiz-app/internal/opentdb"
+)
+
+// Questions whose results show almost no spread, such as everyone answering
+// wrong (a broken answer key or an ambiguous prompt) or everyone answering
+// right (a giveaway), are flagged for review. A host confirms or dismisses each
+// flag; confirmed questions are retired and left out of new quizzes.
+
+var (
+	// ErrNotFlagged reports a retirement decision for a question that is not
+	// in the review queue.
+	ErrNotFlagged = errors.New("question is not in the retirement queue")
+	// ErrInvalidRetirementStatus reports an unknown review status.
+	ErrInvalidRetirementStatus = errors.New("invalid retirement status")
+)
+
+// RetirementStatus is where a flagged question stands in review.
+type RetirementStatus string
+
+const (
+	RetirementPending RetirementStatus = "pending"
+	RetirementRetired RetirementStatus = "retired"
+	RetirementKept    RetirementStatus = "kept"
+)
+
+// ParseRetirementStatus accepts pending, retired, or kept. Empty is returned
+// as is, meaning any status.
+func ParseRetirementStatus(value string) (RetirementStatus, error) {
+	switch status := RetirementStatus(strings.ToLower(strings.TrimSpace(value))); status {
+	case "", RetirementPending, RetirementRetired, RetirementKept:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w: %q (want pending, retired, or kept)", ErrInvalidRetirementStatus, value)
+	}
+}
+
+// RetirementPolicy decides which questions are flagged. The zero value flags
+// nothing.
+type RetirementPolicy struct {
+	// MinAttempts is how many attempts a question needs before its results
+	// are judged.
+	MinAttempts int
+	// ExtremeRate flags questions answered correctly at most this share of the
+	// time, or at least 1-ExtremeRate. 0.02 flags 0-2% and 98-100%.
+	ExtremeRate float64
+}
+
+func (p RetirementPolicy) enabled() bool {
+	return p.MinAttempts > 0
+}
+
+func (p RetirementPolicy) flags(performance QuestionPerformance) bool {
+	if !p.enabled() || performance.AttemptCount < p.MinAttempts {
+		return false
+	}
+	rate := performance.CorrectnessRate()
+	return rate <= p.ExtremeRate || rate >= 1-p.ExtremeRate
+}
+
+// RetirementReview is one question in the review queue. The counts are as of
+// when it was flagged.
+type RetirementReview struct {
+	Question     Question
+	Status       RetirementStatus
+	AttemptCount int
+	CorrectCount int
+	FlaggedAt    time.Time
+	// DecidedAt is zero while the review is pending.
+	DecidedAt time.Time
+}
+
+// CorrectnessRate is the share of attempts answered correctly when flagged.
+func (r RetirementReview) CorrectnessRate() float64 {
+	return QuestionPerformance{AttemptCount: r.AttemptCount, CorrectCount: r.CorrectCount}.CorrectnessRate()
+}
+
+// RetirementQueue flags questions that meet the retirement policy and lists
+// the reviews with status, or every review when status is empty, newest flag
+// first. Questions already reviewed are not flagged again, so a question the
+// host kept stays in use.
+func (s *Service) RetirementQueue(ctx context.Context, status RetirementStatus) ([]RetirementReview, error) {
+	store, ok := s.quizzes.(QuestionRetirementStore)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	if s.retirement.enabled() {
+		stats, err := store.QuestionStats(ctx, s.retirement.MinAttempts)
+		if err != nil {
+			return nil, err
+		}
+		now := s.now().UTC()
+		for _, performance := range stats {
+			if !s.retirement.flags(performance) {
+				continue
+			}
+			review := RetirementReview{
+				Question:     performance.Question,
+				Status:       RetirementPending,
+				AttemptCount: performance.AttemptCount,
+				CorrectCount: performance.CorrectCount,
+				FlaggedAt:    now,
+			}
+			if err := store.FlagQuestion(ctx, review); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return store.ListRetirementReviews(ctx, status)
+}
+
+// DecideRetirement confirms (RetirementRetired) or dismisses (RetirementKept)
+// a flagged question. A decision can be changed later, for example to bring
+// back a retired question after fixing it upstream.
+func (s *Service) DecideRetirement(ctx context.Context, questionID string, status RetirementStatus) (RetirementReview, error) {
+	store, ok := s.quizzes.(QuestionRetirementStore)
+	if !ok {
+		return RetirementReview{}, ErrUnsupported
+	}
+	if status != RetirementRetired && status != RetirementKept {
+		return RetirementReview{}, fmt.Errorf("%w: a decision must be retired or kept", ErrInvalidRetirementStatus)
+	}
+	review, err := store.DecideRetirement(ctx, strings.TrimSpace(questionID), status, s.now().UTC())
+	if err != nil {
+		return RetirementReview{}, err
+	}
+	s.retired.reset()
+	return review, nil
+}
+
+// retiredPrompts caches the prompts of retired questions. Fetched questions
+// get a new ID whenever their options shuffle differently, so they are matched
+// by prompt, like daily-quiz repeats.
+type retiredPrompts struct {
+	mu      sync.Mutex
+	loaded  bool
+	prompts map[string]struct{}
+}
+
+func (r *retiredPrompts) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loaded, r.prompts = false, nil
+}
+
+func (r *retiredPrompts) get(ctx context.Context, store QuestionRetirementStore) (map[string]struct{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loaded {
+		return r.prompts, nil
+	}
+	reviews, err := store.ListRetirementReviews(ctx, RetirementRetired)
+	if err != nil {
+		return nil, err
+	}
+	r.prompts = make(map[string]struct{}, len(reviews))
+	for _, review := range reviews {
+		r.prompts[normalizePrompt(review.Question.Question)] = struct{}{}
+	}
+	r.loaded = true
+	return r.prompts, nil
+}
+
+// skipRetired drops retired questions from what fetcher returns, so every
+// quiz the service builds from the provider leaves them out. Quizzes come up
+// short rather than fetching again, the same as any provider shortfall.
+func (s *Service) skipRetired(fetcher QuestionsFetcher) QuestionsFetcher {
+	store, ok := s.quizzes.(QuestionRetirementStore)
+	if fetcher == nil || !ok {
+		return fetcher
+	}
+	return func(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		raw, err := fetcher(ctx, amount, filter)
+		if err != nil {
+			return nil, err
+		}
+		retired, err := s.retired.get(ctx, store)
+		if err != nil || len(retired) == 0 {
+			// The pool is still usable without the filter.
+			return raw, nil
+		}
+		kept := raw[:0:0]
+		for _, question := range raw {
+			if _, ok := retired[normalizePrompt(html.UnescapeString(question.Question))]; !ok {
+				kept = append(kept, question)
+			}
+		}
+		return kept, nil
+	}
+}
@@ -0,0 +1,167 @@
+package quiz
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// StatusUnanswered marks a question of an attempt review the player has not
+// answered. It is never a ResponseResult status.
+const StatusUnanswered = "unanswered"
+
+// AttemptReview is one player's answers in a quiz, question by question, for
+// a post-quiz review. The correct answers are only filled in once the player
+// has answered every question or the quiz has locked, so a review cannot be
+// used to look up answers mid-quiz.
+type AttemptReview struct {
+	QuizID   string
+	Username string
+	// Completed reports whether the player answered every question that was
+	// not voided.
+	Completed bool
+	// Locked reports whether the quiz stopped taking answers: it was locked
+	// or archived, or its leaderboard lock time or deadline has passed.
+	Locked bool
+	// Revealed reports whether Questions carry their correct answers.
+	Revealed      bool
+	TotalScore    float64
+	AnsweredCount int
+	Questions     []ReviewedQuestion
+}
+
+// ReviewedQuestion is one question of an attempt review. ChosenLetter is the
+// stored answer, with a multi-select answer's letters as "A,C"; it and
+// AnsweredAt are empty when Status is unanswered. CorrectLetter and
+// CorrectText are empty until the review is revealed.
+type ReviewedQuestion struct {
+	QuestionID    string
+	Question      string
+	Options       []Option
+	Type          QuestionKind
+	Voided        bool
+	ChosenLetter  string
+	Status        string
+	Score         float64
+	AnsweredAt    time.Time
+	CorrectLetter string
+	CorrectText   string
+}
+
+// ReviewAttempt returns username's answers in quizID next to each question,
+// in quiz order. A voided question is listed with the voided_question status
+// and no score. It needs a store that implements AttemptHistory.
+func (s *Service) ReviewAttempt(ctx context.Context, quizID, username string) (AttemptReview, error) {
+	history, ok := s.attempts.(AttemptHistory)
+	if !ok {
+		return AttemptReview{}, ErrUnsupported
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return AttemptReview{}, err
+	}
+	metadata, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return AttemptReview{}, err
+	}
+	attempts, err := history.ListAttempts(ctx, metadata.QuizID, usernameNormalized)
+	if err != nil {
+		return AttemptReview{}, err
+	}
+	locked, err := s.quizStoppedAnswers(ctx, metadata)
+	if err != nil {
+		return AttemptReview{}, err
+	}
+
+	answered := make(map[string]Attempt, len(attempts))
+	for _, attempt := range attempts {
+		answered[attempt.QuestionID] = attempt
+	}
+	review := AttemptReview{
+		QuizID:    metadata.QuizID,
+		Username:  usernameNormalized,
+		Completed: true,
+		Locked:    locked,
+		Questions: make([]ReviewedQuestion, 0, len(questions)),
+	}
+	for _, question := range questions {
+		item := ReviewedQuestion{
+			QuestionID: question.QuestionID,
+			Question:   question.Question,
+			Options:    question.Options,
+			Type:       question.Type,
+			Voided:     question.Voided,
+			Status:     StatusUnanswered,
+		}
+		attempt, ok := answered[question.QuestionID]
+		switch {
+		case question.Voided:
+			item.Status = StatusVoidedQuestion
+		case ok:
+			item.ChosenLetter = attempt.AnswerLetter
+			item.Status = quizkit.EvaluateAnswer(question, attempt.AnswerLetter)
+			item.Score = attempt.Score
+			item.AnsweredAt = attempt.SubmittedAt
+			review.TotalScore += attempt.Score
+			review.AnsweredCount++
+		default:
+			review.Completed = false
+		}
+		review.Questions = append(review.Questions, item)
+	}
+	// A quiz with no question left to answer has nothing to complete.
+	review.Completed = review.Completed && review.AnsweredCount > 0
+
+	if review.Completed || review.Locked {
+		review.Revealed = true
+		for idx, question := range questions {
+			review.Questions[idx].CorrectLetter, review.Questions[idx].CorrectText = correctAnswer(question)
+		}
+	}
+	return review, nil
+}
+
+// WithoutAnswers returns the review with the correct answers removed.
+func (r AttemptReview) WithoutAnswers() AttemptReview {
+	questions := make([]ReviewedQuestion, len(r.Questions))
+	for idx, question := range r.Questions {
+		question.CorrectLetter, question.CorrectText = "", ""
+		questions[idx] = question
+	}
+	r.Questions = questions
+	r.Revealed = false
+	return r
+}
+
+// quizStoppedAnswers reports whether metadata's quiz is over for good, the
+// same moment QuizResults publishes the results.
+func (s *Service) quizStoppedAnswers(ctx context.Context, metadata QuizMetadata) (bool, error) {
+	if metadata.Locked || !metadata.ArchivedAt.IsZero() {
+		return true, nil
+	}
+	settings, err := s.leaderboardSettings(ctx, metadata.QuizID)
+	if err != nil {
+		return false, err
+	}
+	lockedAt := settings.lockTime(metadata)
+	return !lockedAt.IsZero() && !s.now().Before(lockedAt), nil
+}
+
+// correctAnswer is question's correct letters and their texts, joined like
+// RevealCorrectAnswers joins them. A question whose answer is out of range
+// has none.
+func correctAnswer(question Question) (letter, text string) {
+	texts := make([]string, 0, 1)
+	for _, correct := range question.CorrectOptions() {
+		if correct < 0 || correct >= len(question.Options) {
+			return "", ""
+		}
+		texts = append(texts, question.Options[correct].Text)
+	}
+	if len(texts) == 0 {
+		return "", ""
+	}
+	return question.CorrectLetters(), strings.Join(texts, "; ")
+}
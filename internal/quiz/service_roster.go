@@ -0,0 +1,289 @@
+package quiz
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxRosterSize bounds one quiz's roster; a classroom roster is far smaller.
+const MaxRosterSize = 1000
+
+// joinCodeAlphabet leaves out letters and digits that are easy to misread
+// when a code is handed out on paper.
+const (
+	joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 6
+)
+
+var (
+	// ErrInvalidRoster reports a roster that cannot be saved, such as one
+	// listing a username twice.
+	ErrInvalidRoster = errors.New("invalid roster")
+	// ErrNotOnRoster reports a submission from a username missing from a
+	// restricted roster.
+	ErrNotOnRoster = errors.New("username is not on the quiz roster")
+	// ErrUnknownJoinCode reports a join code no student on the roster has.
+	ErrUnknownJoinCode = errors.New("unknown join code")
+)
+
+// RosterStudent is one pre-registered participant.
+type RosterStudent struct {
+	// Username is normalized like every other username.
+	Username string
+	// Name is the student's real name, shown to the host next to the
+	// username. Other players never see it.
+	Name string
+	// JoinCode lets the student find their username by code. Empty when the
+	// host did not ask for codes.
+	JoinCode string
+}
+
+// Roster lists the participants a host expects for one quiz. The zero value
+// is an empty, unrestricted roster.
+type Roster struct {
+	// Restricted rejects submissions from usernames not on the roster.
+	Restricted bool
+	Students   []RosterStudent
+	UpdatedAt  time.Time
+}
+
+func (r Roster) student(usernameNormalized string) (RosterStudent, bool) {
+	for _, student := range r.Students {
+		if student.Username == usernameNormalized {
+			return student, true
+		}
+	}
+	return RosterStudent{}, false
+}
+
+// RosterStanding is one row of a roster export: a rostered student, or a
+// participant the roster did not list. Rank is zero for students who have not
+// answered yet. ProxyAnswers counts answers an admin entered for the student.
+type RosterStanding struct {
+	RosterStudent
+	OnRoster     bool
+	Rank         int
+	Entry        LeaderboardEntry
+	ProxyAnswers int
+}
+
+// GetRoster returns quizID's roster, or the zero Roster when it has none.
+func (s *Service) GetRoster(ctx context.Context, quizID string) (Roster, error) {
+	store, ok := s.quizzes.(RosterStore)
+	if !ok {
+		return Roster{}, ErrUnsupported
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return Roster{}, err
+	}
+	return store.GetRoster(ctx, metadata.QuizID)
+}
+
+// SetRoster replaces quizID's roster. With generateJoinCodes, students without
+// a code get one; students already on the roster keep theirs, so re-uploading
+// a corrected roster does not invalidate codes already handed out. Without it,
+// every code is dropped.
+func (s *Service) SetRoster(ctx context.Context, quizID string, roster Roster, generateJoinCodes bool) (Roster, error) {
+	store, ok := s.quizzes.(RosterStore)
+	if !ok {
+		return Roster{}, ErrUnsupported
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return Roster{}, err
+	}
+	if len(roster.Students) > MaxRosterSize {
+		return Roster{}, fmt.Errorf("%w: at most %d students", ErrInvalidRoster, MaxRosterSize)
+	}
+	if roster.Restricted && len(roster.Students) == 0 {
+		return Roster{}, fmt.Errorf("%w: a restricted roster needs at least one student", ErrInvalidRoster)
+	}
+
+	previous, err := store.GetRoster(ctx, metadata.QuizID)
+	if err != nil {
+		return Roster{}, err
+	}
+	usedCodes := make(map[string]struct{}, len(roster.Students))
+	students := make([]RosterStudent, 0, len(roster.Students))
+	seen := make(map[string]struct{}, len(roster.Students))
+	for idx, student := range roster.Students {
+		username, err := normalizeUsername(student.Username)
+		if err != nil {
+			return Roster{}, fmt.Errorf("%w: students[%d] has no username", ErrInvalidRoster, idx)
+		}
+		if _, ok := seen[username]; ok {
+			return Roster{}, fmt.Errorf("%w: %q is listed more than once", ErrInvalidRoster, username)
+		}
+		seen[username] = struct{}{}
+
+		entry := RosterStudent{Username: username, Name: strings.TrimSpace(student.Name)}
+		if generateJoinCodes {
+			if existing, ok := previous.student(username); ok && existing.JoinCode != "" {
+				entry.JoinCode = existing.JoinCode
+				usedCodes[entry.JoinCode] = struct{}{}
+			}
+		}
+		students = append(students, entry)
+	}
+	if generateJoinCodes {
+		for idx := range students {
+			if students[idx].JoinCode != "" {
+				continue
+			}
+			code, err := newJoinCode(usedCodes)
+			if err != nil {
+				return Roster{}, err
+			}
+			students[idx].JoinCode = code
+		}
+	}
+
+	saved := Roster{Restricted: roster.Restricted, Students: students, UpdatedAt: s.now().UTC()}
+	if err := store.SaveRoster(ctx, metadata.QuizID, saved); err != nil {
+		return Roster{}, err
+	}
+	return saved, nil
+}
+
+// JoinQuiz returns the rostered student holding code, so a student can start
+// playing without typing their username. Codes are case-insensitive.
+func (s *Service) JoinQuiz(ctx context.Context, quizID, code string) (RosterStudent, error) {
+	roster, err := s.GetRoster(ctx, quizID)
+	if err != nil {
+		return RosterStudent{}, err
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return RosterStudent{}, ErrUnknownJoinCode
+	}
+	for _, student := range roster.Students {
+		if student.JoinCode == code {
+			return student, nil
+		}
+	}
+	return RosterStudent{}, ErrUnknownJoinCode
+}
+
+// RosterStandings pairs every rostered student with their live leaderboard
+// entry, in leaderboard order, followed by rostered students who have not
+// answered in roster order. Participants missing from an unrestricted roster
+// are included with OnRoster false, so the export accounts for every score.
+func (s *Service) RosterStandings(ctx context.Context, quizID string) (Roster, []RosterStanding, error) {
+	roster, err := s.GetRoster(ctx, quizID)
+	if err != nil {
+		return Roster{}, nil, err
+	}
+	entries, err := s.GetLeaderboard(ctx, quizID, 0)
+	if err != nil {
+		return Roster{}, nil, err
+	}
+
+	proxied, err := s.proxyAnswerCounts(ctx, quizID)
+	if err != nil {
+		return Roster{}, nil, err
+	}
+
+	standings := make([]RosterStanding, 0, max(len(entries), len(roster.Students)))
+	answered := make(map[string]struct{}, len(entries))
+	for idx, entry := range entries {
+		student, onRoster := roster.student(entry.Username)
+		if !onRoster {
+			student = RosterStudent{Username: entry.Username}
+		}
+		standings = append(standings, RosterStanding{RosterStudent: student, OnRoster: onRoster, Rank: idx + 1, Entry: entry, ProxyAnswers: proxied[entry.Username]})
+		answered[entry.Username] = struct{}{}
+	}
+	for _, student := range roster.Students {
+		if _, ok := answered[student.Username]; !ok {
+			standings = append(standings, RosterStanding{RosterStudent: student, OnRoster: true})
+		}
+	}
+	return roster, standings, nil
+}
+
+// proxyAnswerCounts counts, per player, the stored answers an admin entered
+// for them. Only players named in the audit log are read. Stores without an
+// audit log have no proxy answers.
+func (s *Service) proxyAnswerCounts(ctx context.Context, quizID string) (map[string]int, error) {
+	audit, ok := s.attempts.(AuditLog)
+	if !ok {
+		return nil, nil
+	}
+	history, ok := s.attempts.(AttemptHistory)
+	if !ok {
+		return nil, nil
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := audit.ListAuditEntries(ctx, metadata.QuizID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		if entry.Action != AuditSubmitOnBehalf {
+			continue
+		}
+		if _, seen := counts[entry.Username]; seen {
+			continue
+		}
+		attempts, err := history.ListAttempts(ctx, metadata.QuizID, entry.Username)
+		if err != nil {
+			return nil, err
+		}
+		counts[entry.Username] = 0
+		for _, attempt := range attempts {
+			if attempt.SubmittedBy != "" {
+				counts[entry.Username]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// checkRoster rejects usernameNormalized when quizID has a restricted roster
+// that does not list it. Stores without rosters never restrict.
+func (s *Service) checkRoster(ctx context.Context, quizID, usernameNormalized string) error {
+	store, ok := s.quizzes.(RosterStore)
+	if !ok {
+		return nil
+	}
+	roster, err := store.GetRoster(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	if !roster.Restricted {
+		return nil
+	}
+	if _, ok := roster.student(usernameNormalized); !ok {
+		return ErrNotOnRoster
+	}
+	return nil
+}
+
+// newJoinCode returns a random code not in used and adds it. Codes are only
+// unique within one quiz; JoinQuiz always names the quiz.
+func newJoinCode(used map[string]struct{}) (string, error) {
+	random := make([]byte, joinCodeLength)
+	for {
+		if _, err := rand.Read(random); err != nil {
+			return "", err
+		}
+		code := make([]byte, joinCodeLength)
+		for idx, value := range random {
+			code[idx] = joinCodeAlphabet[int(value)%len(joinCodeAlphabet)]
+		}
+		if _, ok := used[string(code)]; !ok {
+			used[string(code)] = struct{}{}
+			return string(code), nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package quiz
+
+import (
+	"context"
+	"sort"
+)
+
+// QuizServe is one user's serving log entry plus how many of the quiz's
+// questions they answered, so hosts can tell players from lurkers.
+type QuizServe struct {
+	ServeLogEntry
+	AnsweredCount int
+}
+
+// RecordServed notes that username was served questionIDs of quizID, with the
+// answer key when withAnswerKey is set. It appends to the serving log.
+// With ServiceOptions.ServeNonces it returns the nonce to serve with each
+// question, keyed by question ID; otherwise, and when serving the answer key,
+// it returns nil. Empty usernames are skipped. Failures are ignored: serving
+// must not fail because the log could not be written.
+func (s *Service) RecordServed(ctx context.Context, quizID, username string, questionIDs []string, withAnswerKey bool) map[string]string {
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil || len(questionIDs) == 0 {
+		return nil
+	}
+	var (
+		nonces map[string]string
+		issued int
+	)
+	if s.nonces != nil {
+		nonces, issued = s.nonces.issue(quizID, usernameNormalized, questionIDs, withAnswerKey, s.now())
+	}
+	now := s.now().UTC()
+	s.counters.recordActive(now, usernameNormalized)
+	if logger, ok := s.attempts.(ServeLogger); ok {
+		_ = logger.LogServe(ctx, quizID, usernameNormalized, now, withAnswerKey, issued)
+	}
+	return nonces
+}
+
+// ServeLog lists who fetched quizID's questions, earliest first, followed by
+// users who answered without a logged fetch.
+func (s *Service) ServeLog(ctx context.Context, quizID string) ([]QuizServe, error) {
+	logger, ok := s.attempts.(ServeLogger)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := logger.ListServeLog(ctx, metadata.QuizID)
+	if err != nil {
+		return nil, err
+	}
+	leaderboard, err := s.GetLeaderboard(ctx, metadata.QuizID, 0)
+	if err != nil {
+		return nil, err
+	}
+	answered := make(map[string]int, len(leaderboard))
+	for _, entry := range leaderboard {
+		answered[entry.Username] = entry.AnsweredCount
+	}
+
+	serves := make([]QuizServe, 0, len(entries)+len(leaderboard))
+	for _, entry := range entries {
+		serves = append(serves, QuizServe{ServeLogEntry: entry, AnsweredCount: answered[entry.Username]})
+		delete(answered, entry.Username)
+	}
+	sort.Slice(serves, func(i, j int) bool {
+		if !serves[i].FirstServedAt.Equal(serves[j].FirstServedAt) {
+			return serves[i].FirstServedAt.Before(serves[j].FirstServedAt)
+		}
+		return serves[i].Username < serves[j].Username
+	})
+
+	unlogged := make([]QuizServe, 0, len(answered))
+	for username, count := range answered {
+		unlogged = append(unlogged, QuizServe{ServeLogEntry: ServeLogEntry{Username: username}, AnsweredCount: count})
+	}
+	sort.Slice(unlogged, func(i, j int) bool { return unlogged[i].Username < unlogged[j].Username })
+	return append(serves, unlogged...), nil
+}
@@ -0,0 +1,205 @@
+package quiz
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Offline clients queue answers while the server is unreachable and send them
+// later. To show that a late answer was chosen when the client says, and not
+// made up at sync time, the client signs each queued answer with a key it
+// registered while online. The server checks the signature and then checks the
+// claimed time against what it knows: when the key was registered, when the
+// question was served, when the quiz closed, and how long ago it was.
+
+const (
+	// defaultOfflineSyncWindow is how long a signed answer may wait to be synced
+	// when ServiceOptions.OfflineSyncWindow is zero.
+	defaultOfflineSyncWindow = 24 * time.Hour
+	// signedAnswerClockSkew tolerates client clocks that disagree with the
+	// server's by up to this much.
+	signedAnswerClockSkew = time.Minute
+	// signedAnswerVersion starts every signed message, so the format can change
+	// without old signatures verifying under a new meaning.
+	signedAnswerVersion = "quiz-app signed answer v1"
+)
+
+// ErrInvalidSigningKey reports a key that is not a base64 Ed25519 public key.
+var ErrInvalidSigningKey = errors.New("invalid signing key: want a base64-encoded Ed25519 public key")
+
+// SigningKeyID names a public key: the first 16 hex digits of its SHA-256.
+func SigningKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SignedAnswerMessage is what a client signs for one queued answer. It binds
+// the answer to the quiz, the player, the question's content hash, the time
+// it was chosen, and the signing key.
+func SignedAnswerMessage(quizID, username string, response SubmittedResponse) []byte {
+	answeredAt := ""
+	if response.AnsweredAt != nil {
+		answeredAt = response.AnsweredAt.UTC().Format(time.RFC3339Nano)
+	}
+	return []byte(strings.Join([]string{
+		signedAnswerVersion,
+		quizID,
+		strings.ToLower(strings.TrimSpace(username)),
+		response.QuestionID,
+		response.Answer,
+		response.ContentHash,
+		answeredAt,
+		response.KeyID,
+	}, "\n"))
+}
+
+// SignAnswer returns response stamped with answeredAt and signed by key for
+// quizID and username.
+func SignAnswer(key ed25519.PrivateKey, quizID, username string, response SubmittedResponse, answeredAt time.Time) SubmittedResponse {
+	answeredAt = answeredAt.UTC()
+	response.AnsweredAt = &answeredAt
+	response.KeyID = SigningKeyID(key.Public().(ed25519.PublicKey))
+	response.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, SignedAnswerMessage(quizID, username, response)))
+	return response
+}
+
+func signed(response SubmittedResponse) bool {
+	return response.AnsweredAt != nil || response.KeyID != "" || response.Signature != ""
+}
+
+// RegisterSigningKey records publicKey, base64-encoded, as a key username's
+// client signs offline answers with. Registering a key again returns the
+// first registration, and created is false.
+func (s *Service) RegisterSigningKey(ctx context.Context, username, publicKey string) (key SigningKey, created bool, err error) {
+	store, ok := s.quizzes.(SigningKeyStore)
+	if !ok {
+		return SigningKey{}, false, ErrUnsupported
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return SigningKey{}, false, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return SigningKey{}, false, ErrInvalidSigningKey
+	}
+
+	keyID := SigningKeyID(raw)
+	existing, err := store.GetSigningKey(ctx, usernameNormalized, keyID)
+	if err != nil {
+		return SigningKey{}, false, err
+	}
+	if existing.PublicKey != nil {
+		return existing, false, nil
+	}
+	key = SigningKey{
+		Username:     usernameNormalized,
+		KeyID:        keyID,
+		PublicKey:    raw,
+		RegisteredAt: s.now().UTC(),
+	}
+	if err := store.SaveSigningKey(ctx, key); err != nil {
+		return SigningKey{}, false, err
+	}
+	return key, true, nil
+}
+
+// offlineSyncWindow is how long after AnsweredAt a signed answer is accepted.
+func (s *Service) offlineSyncWindow() time.Duration {
+	if s.syncWindow > 0 {
+		return s.syncWindow
+	}
+	return defaultOfflineSyncWindow
+}
+
+// unverifiedAnswers sets aside signed responses that do not check out:
+// invalid_signature for an unknown key or a bad signature, outside_window for
+// an AnsweredAt the server cannot vouch for. Unsigned responses pass through,
+// and a request without signed responses costs no store reads.
+func (s *Service) unverifiedAnswers(ctx context.Context, metadata QuizMetadata, usernameNormalized string, responses []SubmittedResponse) (map[int]ResponseResult, error) {
+	anySigned := false
+	for _, response := range responses {
+		if signed(response) {
+			anySigned = true
+			break
+		}
+	}
+	if !anySigned {
+		return nil, nil
+	}
+
+	var served map[string]time.Time
+	if tracker, ok := s.attempts.(QuestionServeTracker); ok {
+		var err error
+		if served, err = tracker.ServeTimes(ctx, metadata.QuizID, usernameNormalized); err != nil {
+			return nil, err
+		}
+	}
+	store, _ := s.quizzes.(SigningKeyStore)
+	keys := make(map[string]SigningKey)
+	now := s.now().UTC()
+
+	rejected := make(map[int]ResponseResult)
+	for idx, response := range responses {
+		if !signed(response) {
+			continue
+		}
+		key, ok := keys[response.KeyID]
+		if !ok && store != nil && response.KeyID != "" {
+			var err error
+			if key, err = store.GetSigningKey(ctx, usernameNormalized, response.KeyID); err != nil {
+				return nil, err
+			}
+			keys[response.KeyID] = key
+		}
+
+		status := ""
+		switch {
+		case !validSignature(key, metadata.QuizID, usernameNormalized, response):
+			status = StatusInvalidSignature
+		case !s.withinWindow(response.AnsweredAt.UTC(), now, key, metadata, served[response.QuestionID]):
+			status = StatusOutsideWindow
+		default:
+			continue
+		}
+		rejected[idx] = ResponseResult{QuestionID: response.QuestionID, Status: status}
+	}
+	return rejected, nil
+}
+
+func validSignature(key SigningKey, quizID, usernameNormalized string, response SubmittedResponse) bool {
+	if key.PublicKey == nil || response.AnsweredAt == nil {
+		return false
+	}
+	signature, err := base64.StdEncoding.DecodeString(response.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key.PublicKey, SignedAnswerMessage(quizID, usernameNormalized, response), signature)
+}
+
+// withinWindow reports whether answeredAt is a time the answer could have been
+// chosen: after the key was registered and the question was served (servedAt
+// is zero when unknown), no later than now or the quiz's close, and recent
+// enough to sync.
+func (s *Service) withinWindow(answeredAt, now time.Time, key SigningKey, metadata QuizMetadata, servedAt time.Time) bool {
+	switch {
+	case answeredAt.After(now.Add(signedAnswerClockSkew)):
+		return false
+	case now.Sub(answeredAt) > s.offlineSyncWindow():
+		return false
+	case answeredAt.Before(key.RegisteredAt.Add(-signedAnswerClockSkew)):
+		return false
+	case !servedAt.IsZero() && answeredAt.Before(servedAt.Add(-signedAnswerClockSkew)):
+		return false
+	case !metadata.ClosesAt.IsZero() && answeredAt.After(metadata.ClosesAt):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package quiz
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// statsRateWindow is how many recent minutes SubmissionsPerMinute averages.
+	statsRateWindow = 5
+	// statsActiveWindow is how long a user counts as active after a fetch or
+	// submission.
+	statsActiveWindow = time.Hour
+	// statsTopCategories is how many categories Overview lists.
+	statsTopCategories = 5
+)
+
+// ServiceOverview is a snapshot of recent activity across every quiz, for a
+// public status page. It comes from counters the service keeps in memory, so
+// it covers activity since the process started.
+type ServiceOverview struct {
+	GeneratedAt time.Time
+	// QuizzesToday counts quizzes created since midnight UTC.
+	QuizzesToday int
+	// SubmissionsPerMinute averages accepted answer submissions over the last
+	// few minutes.
+	SubmissionsPerMinute float64
+	// ActiveUsersLastHour counts users who fetched questions or submitted
+	// answers within the last hour.
+	ActiveUsersLastHour int
+	// TopCategories ranks the categories of questions in today's new quizzes,
+	// most questions first.
+	TopCategories []CategoryCount
+}
+
+// CategoryCount is how many questions of one category were used.
+type CategoryCount struct {
+	Category      string
+	QuestionCount int
+}
+
+// serviceCounters keeps the rolling figures behind Overview. Every update is a
+// constant-time bump under one mutex; only the active-user set is pruned, and
+// only on read.
+type serviceCounters struct {
+	mu sync.Mutex
+
+	day          string
+	quizzesToday int
+	categories   map[string]int
+
+	// minutes holds submission counts per minute in a ring indexed by the
+	// minute number modulo its length.
+	minutes [statsRateWindow]minuteCount
+
+	activeUsers map[string]time.Time
+}
+
+type minuteCount struct {
+	minute int64
+	count  int
+}
+
+func newServiceCounters() *serviceCounters {
+	return &serviceCounters{
+		categories:  make(map[string]int),
+		activeUsers: make(map[string]time.Time),
+	}
+}
+
+// rollDay resets the daily counters when at falls on a new UTC day.
+func (c *serviceCounters) rollDay(at time.Time) {
+	day := at.UTC().Format("2006-01-02")
+	if day == c.day {
+		return
+	}
+	c.day = day
+	c.quizzesToday = 0
+	c.categories = make(map[string]int)
+}
+
+func (c *serviceCounters) recordQuizCreated(at time.Time, questions []Question) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rollDay(at)
+	c.quizzesToday++
+	for _, question := range questions {
+		if question.Category != "" {
+			c.categories[question.Category]++
+		}
+	}
+}
+
+func (c *serviceCounters) recordSubmission(at time.Time, usernameNormalized string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	minute := at.Unix() / 60
+	slot := &c.minutes[minute%statsRateWindow]
+	if slot.minute != minute {
+		*slot = minuteCount{minute: minute}
+	}
+	slot.count++
+	c.activeUsers[usernameNormalized] = at
+}
+
+func (c *serviceCounters) recordActive(at time.Time, usernameNormalized string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if at.After(c.activeUsers[usernameNormalized]) {
+		c.activeUsers[usernameNormalized] = at
+	}
+}
+
+func (c *serviceCounters) overview(now time.Time) ServiceOverview {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rollDay(now)
+
+	overview := ServiceOverview{GeneratedAt: now.UTC(), QuizzesToday: c.quizzesToday}
+
+	current := now.Unix() / 60
+	submissions := 0
+	for _, slot := range c.minutes {
+		if slot.minute > current-statsRateWindow && slot.minute <= current {
+			submissions += slot.count
+		}
+	}
+	overview.SubmissionsPerMinute = float64(submissions) / statsRateWindow
+
+	cutoff := now.Add(-statsActiveWindow)
+	for user, seen := range c.activeUsers {
+		if seen.Before(cutoff) {
+			delete(c.activeUsers, user)
+		}
+	}
+	overview.ActiveUsersLastHour = len(c.activeUsers)
+
+	overview.TopCategories = make([]CategoryCount, 0, len(c.categories))
+	for category, count := range c.categories {
+		overview.TopCategories = append(overview.TopCategories, CategoryCount{Category: category, QuestionCount: count})
+	}
+	sort.Slice(overview.TopCategories, func(i, j int) bool {
+		a, b := overview.TopCategories[i], overview.TopCategories[j]
+		if a.QuestionCount != b.QuestionCount {
+			return a.QuestionCount > b.QuestionCount
+		}
+		return a.Category < b.Category
+	})
+	if len(overview.TopCategories) > statsTopCategories {
+		overview.TopCategories = overview.TopCategories[:statsTopCategories]
+	}
+	return overview
+}
+
+// Overview reports recent activity across the whole service. It never touches
+// the stores, so it is cheap enough for a public page to poll.
+func (s *Service) Overview() ServiceOverview {
+	return s.counters.overview(s.now())
+}
@@ -0,0 +1,96 @@
+package quiz
+
+import (
+	"context"
+	"time"
+)
+
+// streakDayLayout formats streak days.
+const streakDayLayout = "2006-01-02"
+
+// Advance records play on day. Playing again on LastDay changes nothing, the
+// day after LastDay extends the streak, and any later day starts a new one.
+// Days before LastDay, seen when the streak time zone changes, are ignored.
+func (s Streak) Advance(day string) Streak {
+	switch {
+	case s.LastDay == "" || day > nextStreakDay(s.LastDay):
+		s.Current = 1
+	case day == nextStreakDay(s.LastDay):
+		s.Current++
+	default:
+		return s
+	}
+	s.LastDay = day
+	s.Longest = max(s.Longest, s.Current)
+	return s
+}
+
+// asOf zeroes Current when the streak was broken before today: a streak stays
+// alive until the end of the day after LastDay.
+func (s Streak) asOf(today string) Streak {
+	if s.LastDay != "" && today > nextStreakDay(s.LastDay) {
+		s.Current = 0
+	}
+	return s
+}
+
+func nextStreakDay(day string) string {
+	parsed, err := time.Parse(streakDayLayout, day)
+	if err != nil {
+		return day
+	}
+	return parsed.AddDate(0, 0, 1).Format(streakDayLayout)
+}
+
+func (s *Service) streakStore() (StreakStore, error) {
+	store, ok := s.attempts.(StreakStore)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return store, nil
+}
+
+// StreakLocation is the time zone whose midnight ends streak days.
+func (s *Service) StreakLocation() *time.Location {
+	return s.streakLocation
+}
+
+func (s *Service) streakDay(at time.Time) string {
+	return at.In(s.streakLocation).Format(streakDayLayout)
+}
+
+// GetStreak returns username's participation streak as of now. Current is
+// zero once a full streak day has passed without play.
+func (s *Service) GetStreak(ctx context.Context, username string) (Streak, error) {
+	store, err := s.streakStore()
+	if err != nil {
+		return Streak{}, err
+	}
+	usernameNormalized, err := normalizeUsername(username)
+	if err != nil {
+		return Streak{}, err
+	}
+	streak, err := store.GetStreak(ctx, usernameNormalized)
+	if err != nil {
+		return Streak{}, err
+	}
+	streak.Username = usernameNormalized
+	return streak.asOf(s.streakDay(s.now())), nil
+}
+
+// recordStreakDay counts today toward the user's streak when the submission
+// scored at least one answer. It is best-effort, like the other side effects
+// of a submission: the answers are already persisted.
+func (s *Service) recordStreakDay(ctx context.Context, usernameNormalized string, results []ResponseResult) {
+	store, err := s.streakStore()
+	if err != nil {
+		return
+	}
+	for _, result := range results {
+		if result.Status == StatusCorrect || result.Status == StatusPartiallyCorrect || result.Status == StatusIncorrect {
+			now := s.now()
+			_, _ = store.RecordStreakDay(ctx, usernameNormalized, s.streakDay(now), now.UTC())
+			return
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Leaderboard streams push leaderboard changes to live viewers. Each streamed
+// quiz keeps a bounded ring of recent events so a viewer that reconnects with
+// the last event ID it saw gets only the changes it missed. Streams live in
+// memory: IDs from before a restart, or older than the ring, are answered with
+// a fresh snapshot instead.
+
+const (
+	LeaderboardEventSnapshot = "snapshot"
+	LeaderboardEventDelta    = "delta"
+	// LeaderboardEventClosing is the last event before the server shuts down,
+	// or before the quiz is deleted. It has no ID and is not kept in the ring,
+	// so a viewer reconnects with the ID of the last change it saw.
+	LeaderboardEventClosing = "closing"
+
+	defaultStreamBufferSize = 256
+	// streamRetention is how long a quiz keeps its ring after the last viewer
+	// leaves, so brief disconnects still resume.
+	streamRetention = 10 * time.Minute
+	// subscriberBuffer is how far a viewer may fall behind before it is
+	// dropped; it can reconnect and resume from the ring.
+	subscriberBuffer = 32
+)
+
+// RankedLeaderboardEntry is a leaderboard entry with its 1-based rank.
+// Anonymous is set when the username was masked for display.
+type RankedLeaderboardEntry struct {
+	Rank int `json:"rank"`
+	LeaderboardEntry
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+// LeaderboardEvent is one change to a quiz leaderboard. A snapshot carries the
+// whole leaderboard. A delta carries the one entry that changed: clients move
+// that user to Rank and shift the others, whose relative order is unchanged.
+type LeaderboardEvent struct {
+	// ID orders events within one process; pass it back to resume.
+	ID      string                   `json:"-"`
+	Type    string                   `json:"type"`
+	QuizID  string                   `json:"quiz_id"`
+	Entries []RankedLeaderboardEntry `json:"entries"`
+	// Participants is the leaderboard length after the change.
+	Participants int       `json:"participants"`
+	OccurredAt   time.Time `json:"occurred_at"`
+
+	// FrozenAt and RevealAt are set on snapshots taken during a leaderboard
+	// freeze; no deltas are sent until RevealAt, when a live snapshot follows.
+	FrozenAt *time.Time `json:"frozen_at,omitempty"`
+	RevealAt *time.Time `json:"reveal_at,omitempty"`
+
+	// Message explains a closing event.
+	Message string `json:"message,omitempty"`
+}
+
+// ErrStreamsClosed reports a subscription attempted after
+// CloseLeaderboardStreams or CloseSubmissionStreams, while the server is
+// shutting down.
+var ErrStreamsClosed = errors.New("leaderboard streams are closed; the server is shutting down")
+
+// LeaderboardStream is one viewer's subscription. Send Replay first, then
+// Events until it is closed. Events is closed when the viewer falls too far
+// behind, Close is called, the quiz is deleted, or the server shuts down; in
+// the last two cases a closing event comes first.
+type LeaderboardStream struct {
+	Replay []LeaderboardEvent
+	Events <-chan LeaderboardEvent
+	close  func()
+}
+
+// Close stops delivery. It is safe to call more than once.
+func (s LeaderboardStream) Close() {
+	if s.close != nil {
+		s.close()
+	}
+}
+
+type leaderboardStreams struct {
+	mu      sync.Mutex
+	size    int
+	streams map[string]*quizStream
+	// reveals holds one timer per frozen quiz that publishes the live
+	// standings once its freeze ends.
+	reveals map[string]*time.Timer
+	// flushes holds one timer per quiz with leaderboard changes waiting out
+	// the notify interval.
+	flushes map[string]*time.Timer
+	// unflushed holds the quizzes whose flush closeAll cancelled, for
+	// FlushNotifications to send.
+	unflushed map[string]struct{}
+	// closed is set by closeAll; no new viewers are accepted after it.
+	closed bool
+}
+
+type quizStream struct {
+	// epoch distinguishes this process's event IDs from a previous one's.
+	epoch       string
+	seq         uint64
+	ring        []LeaderboardEvent
+	subscribers map[chan LeaderboardEvent]struct{}
+	idleSince   time.Time
+}
+
+func newLeaderboardStreams(size int) *leaderboardStreams {
+	if size <= 0 {
+		size = defaultStreamBufferSize
+	}
+	return &leaderboardStreams{
+		size:      size,
+		streams:   make(map[string]*quizStream),
+		reveals:   make(map[string]*time.Timer),
+		flushes:   make(map[string]*time.Timer),
+		unflushed: make(map[string]struct{}),
+	}
+}
+
+func (q *quizStream) eventID(seq uint64) string {
+	return q.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// replayAfter returns the ring events after lastEventID, or false when the ID
+// is from another epoch or older than the ring and a snapshot is needed.
+func (q *quizStream) replayAfter(lastEventID string) ([]LeaderboardEvent, bool) {
+	epoch, rawSeq, ok := strings.Cut(strings.TrimSpace(lastEventID), "-")
+	if !ok || epoch != q.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil || seq > q.seq {
+		return nil, false
+	}
+	missed := int(q.seq - seq)
+	if missed > len(q.ring) {
+		return nil, false
+	}
+	return append([]LeaderboardEvent(nil), q.ring[len(q.ring)-missed:]...), true
+}
+
+// SubscribeLeaderboard streams leaderboard changes for quizID. With the ID of
+// the last event a viewer received, only later events are replayed; otherwise
+// Replay starts with a snapshot.
+func (s *Service) SubscribeLeaderboard(ctx context.Context, quizID, lastEventID string) (LeaderboardStream, error) {
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return LeaderboardStream{}, err
+	}
+
+	hub := s.streams
+	events := make(chan LeaderboardEvent, subscriberBuffer)
+
+	hub.mu.Lock()
+	if hub.closed {
+		hub.mu.Unlock()
+		return LeaderboardStream{}, ErrStreamsClosed
+	}
+	hub.sweepIdle(s.now())
+	stream, ok := hub.streams[metadata.QuizID]
+	if !ok {
+		stream = &quizStream{
+			epoch:       strconv.FormatInt(s.now().UnixNano(), 36),
+			subscribers: make(map[chan LeaderboardEvent]struct{}),
+		}
+		hub.streams[metadata.QuizID] = stream
+	}
+	replay, resumed := stream.replayAfter(lastEventID)
+	snapshotID := stream.eventID(stream.seq)
+	// Registering before the snapshot is read means nothing published in
+	// between is lost; deltas carry absolute values, so a repeat is harmless.
+	stream.subscribers[events] = struct{}{}
+	hub.mu.Unlock()
+
+	subscription := LeaderboardStream{
+		Replay: replay,
+		Events: events,
+		close:  func() { hub.unsubscribe(metadata.QuizID, events, s.now()) },
+	}
+	if resumed {
+		return subscription, nil
+	}
+
+	snapshot, err := s.leaderboardSnapshot(ctx, metadata.QuizID)
+	if err != nil {
+		subscription.Close()
+		return LeaderboardStream{}, err
+	}
+	snapshot.ID = snapshotID
+	subscription.Replay = []LeaderboardEvent{snapshot}
+	return subscription, nil
+}
+
+func (s *Service) leaderboardSnapshot(ctx context.Context, quizID string) (LeaderboardEvent, error) {
+	board, err := s.GetPublicLeaderboard(ctx, quizID, 0)
+	if err != nil {
+		return LeaderboardEvent{}, err
+	}
+	event := LeaderboardEvent{
+		Type:         LeaderboardEventSnapshot,
+		QuizID:       quizID,
+		Entries:      board.Entries,
+		Participants: len(board.Entries),
+		OccurredAt:   s.now().UTC(),
+	}
+	if board.Frozen() {
+		event.FrozenAt, event.RevealAt = &board.FrozenAt, &board.RevealAt
+	}
+	return event, nil
+}
+
+// leaderboardChanged tells live viewers and leaderboard webhooks that
+// usernameNormalized's answers moved the standings. With a notify interval
+// set, a burst of submissions to one quiz is coalesced into a single snapshot
+// and webhook carrying the standings at the end of the interval.
+func (s *Service) leaderboardChanged(ctx context.Context, quizID, usernameNormalized string, results []ResponseResult) {
+	stored := false
+	for _, result := range results {
+		if result.Status == StatusCorrect || result.Status == StatusPartiallyCorrect || result.Status == StatusIncorrect {
+			stored = true
+			break
+		}
+	}
+	if !stored || (!s.streams.active(quizID) && !s.hasLeaderboardWatches(quizID)) {
+		return
+	}
+	if revealAt, frozen := s.frozenUntil(ctx, quizID); frozen {
+		// The change stays hidden until the freeze ends. Nothing changed if
+		// nobody submitted, so the reveal is only scheduled from here.
+		s.streams.scheduleOnce(s.streams.reveals, quizID, revealAt.Sub(s.now()), func() {
+			s.publishLeaderboardChange(context.Background(), quizID)
+		})
+		return
+	}
+	// Later changes inside the window are picked up by the pending flush,
+	// which reads the standings when it runs. Once the streams are closed
+	// for shutdown, changes are sent at once instead.
+	if s.notifyInterval > 0 && s.streams.scheduleOnce(s.streams.flushes, quizID, s.notifyInterval, func() {
+		s.publishLeaderboardChange(context.Background(), quizID)
+	}) {
+		return
+	}
+	s.publishLeaderboardDelta(ctx, quizID, usernameNormalized)
+	s.notifyLeaderboardWatches(ctx, quizID)
+}
+
+// publishLeaderboardChange sends the current standings to viewers and
+// leaderboard webhooks of quizID.
+func (s *Service) publishLeaderboardChange(ctx context.Context, quizID string) {
+	s.publishLeaderboardSnapshot(ctx, quizID)
+	s.notifyLeaderboardWatches(ctx, quizID)
+}
+
+// publishLeaderboardDelta sends usernameNormalized's new standing to viewers of
+// quizID. Quizzes nobody streams are skipped without touching the leaderboard.
+func (s *Service) publishLeaderboardDelta(ctx context.Context, quizID, usernameNormalized string) {
+	if !s.streams.active(quizID) {
+		return
+	}
+	entries, err := s.GetLeaderboard(ctx, quizID, 0)
+	if err != nil {
+		return
+	}
+	for idx, entry := range entries {
+		if entry.Username != usernameNormalized {
+			continue
+		}
+		masked, err := s.maskAnonymous(ctx, quizID, []RankedLeaderboardEntry{{Rank: idx + 1, LeaderboardEntry: entry}})
+		if err != nil {
+			return
+		}
+		s.streams.publish(quizID, LeaderboardEvent{
+			Type:         LeaderboardEventDelta,
+			QuizID:       quizID,
+			Entries:      masked,
+			Participants: len(entries),
+			OccurredAt:   s.now().UTC(),
+		})
+		return
+	}
+}
+
+// publishLeaderboardSnapshot sends the whole leaderboard after a change that
+// can move every entry, such as voiding a question.
+func (s *Service) publishLeaderboardSnapshot(ctx context.Context, quizID string) {
+	if !s.streams.active(quizID) {
+		return
+	}
+	if snapshot, err := s.leaderboardSnapshot(ctx, quizID); err == nil {
+		s.streams.publish(quizID, snapshot)
+	}
+}
+
+// CloseLeaderboardStreams ends every leaderboard stream for shutdown: each
+// viewer gets a closing event and its Events channel is closed, pending reveal
+// snapshots are cancelled, coalesced notifications are held for
+// FlushNotifications, and later subscriptions fail with ErrStreamsClosed.
+// It is safe to call more than once.
+func (s *Service) CloseLeaderboardStreams() {
+	s.streams.closeAll(LeaderboardEvent{
+		Type:       LeaderboardEventClosing,
+		Message:    "server closing; reconnect with the last event ID",
+		OccurredAt: s.now().UTC(),
+	})
+}
+
+// FlushNotifications finishes shutdown once requests have drained and
+// CloseLeaderboardStreams has run. Leaderboard webhooks still waiting out the
+// notify interval are sent now with the latest standings, and the timers that
+// publish results when quizzes lock are stopped, so nothing touches the store
+// after it closes; the first results request after a restart publishes them.
+func (s *Service) FlushNotifications(ctx context.Context) {
+	for _, quizID := range s.streams.takeUnflushed() {
+		if ctx.Err() != nil {
+			return
+		}
+		s.notifyLeaderboardWatches(ctx, quizID)
+	}
+
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+	for quizID, timer := range s.resultsTimers {
+		timer.Stop()
+		delete(s.resultsTimers, quizID)
+	}
+}
+
+func (h *leaderboardStreams) closeAll(closing LeaderboardEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for quizID, timer := range h.reveals {
+		timer.Stop()
+		delete(h.reveals, quizID)
+	}
+	for quizID, timer := range h.flushes {
+		if timer.Stop() {
+			h.unflushed[quizID] = struct{}{}
+		}
+		delete(h.flushes, quizID)
+	}
+	for quizID, stream := range h.streams {
+		event := closing
+		event.QuizID = quizID
+		stream.closeSubscribers(event)
+	}
+}
+
+// closeQuiz ends closing.QuizID's stream for good, as closeAll does for every
+// stream, and forgets its ring and pending timers.
+func (h *leaderboardStreams) closeQuiz(closing LeaderboardEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	quizID := closing.QuizID
+	for _, timers := range []map[string]*time.Timer{h.reveals, h.flushes} {
+		if timer := timers[quizID]; timer != nil {
+			timer.Stop()
+			delete(timers, quizID)
+		}
+	}
+	delete(h.unflushed, quizID)
+	if stream, ok := h.streams[quizID]; ok {
+		stream.closeSubscribers(closing)
+		delete(h.streams, quizID)
+	}
+}
+
+// closeSubscribers sends closing to every viewer and closes their channels.
+// Callers hold the leaderboardStreams lock.
+func (q *quizStream) closeSubscribers(closing LeaderboardEvent) {
+	for subscriber := range q.subscribers {
+		// A viewer with a full buffer misses the closing event but still
+		// sees its stream end.
+		select {
+		case subscriber <- closing:
+		default:
+		}
+		delete(q.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+func (h *leaderboardStreams) active(quizID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.streams[quizID]
+	return ok
+}
+
+// scheduleOnce runs run after delay unless timers already holds one pending
+// for quizID, and reports whether run is pending. Nothing is scheduled after
+// closeAll.
+func (h *leaderboardStreams) scheduleOnce(timers map[string]*time.Timer, quizID string, delay time.Duration, run func()) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	if _, ok := timers[quizID]; ok {
+		return true
+	}
+	timers[quizID] = time.AfterFunc(delay, func() {
+		h.mu.Lock()
+		delete(timers, quizID)
+		h.mu.Unlock()
+		run()
+	})
+	return true
+}
+
+// takeUnflushed returns and forgets the quizzes closeAll left unflushed.
+func (h *leaderboardStreams) takeUnflushed() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	quizIDs := make([]string, 0, len(h.unflushed))
+	for quizID := range h.unflushed {
+		quizIDs = append(quizIDs, quizID)
+		delete(h.unflushed, quizID)
+	}
+	sort.Strings(quizIDs)
+	return quizIDs
+}
+
+func (h *leaderboardStreams) publish(quizID string, event LeaderboardEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stream, ok := h.streams[quizID]
+	if !ok || h.closed {
+		return
+	}
+	stream.seq++
+	event.ID = stream.eventID(stream.seq)
+	if len(stream.ring) == h.size {
+		copy(stream.ring, stream.ring[1:])
+		stream.ring = stream.ring[:h.size-1]
+	}
+	stream.ring = append(stream.ring, event)
+
+	for subscriber := range stream.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// Too far behind: drop the viewer rather than block submissions.
+			// It reconnects with its last ID and resumes from the ring.
+			delete(stream.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+func (h *leaderboardStreams) unsubscribe(quizID string, events chan LeaderboardEvent, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stream, ok := h.streams[quizID]
+	if !ok {
+		return
+	}
+	if _, subscribed := stream.subscribers[events]; subscribed {
+		delete(stream.subscribers, events)
+		close(events)
+	}
+	if len(stream.subscribers) == 0 {
+		stream.idleSince = now
+	}
+}
+
+// sweepIdle drops rings of quizzes nobody has watched for streamRetention.
+// Callers hold h.mu.
+func (h *leaderboardStreams) sweepIdle(now time.Time) {
+	for quizID, stream := range h.streams {
+		if len(stream.subscribers) == 0 && now.Sub(stream.idleSince) > streamRetention {
+			delete(h.streams, quizID)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package quiz
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Submission streams feed host dashboards: every stored submission is pushed
+// with the player's unmasked username, the points it earned, and the running
+// totals of the quiz. Unlike leaderboard streams they keep no ring; a host
+// that reconnects starts again from a snapshot, which holds everything the
+// dashboard shows.
+
+const (
+	SubmissionEventSnapshot   = "snapshot"
+	SubmissionEventSubmission = "submission"
+	// SubmissionEventClosing is the last event before the server shuts down,
+	// or before the quiz is deleted.
+	SubmissionEventClosing = "closing"
+)
+
+// PlayerProgress is one player's standing on a host dashboard. Finished is
+// set once the player has answered every question that is not voided.
+type PlayerProgress struct {
+	Username      string  `json:"username"`
+	TotalScore    float64 `json:"total_score"`
+	AnsweredCount int     `json:"answered_count"`
+	Finished      bool    `json:"finished"`
+}
+
+// QuizSubmission is one submission as a host sees it: the answers it stored,
+// the points they earned, and the player's totals afterwards.
+type QuizSubmission struct {
+	PlayerProgress
+	Answered   int     `json:"answered"`
+	ScoreDelta float64 `json:"score_delta"`
+}
+
+// SubmissionEvent is one message on a submission stream. A snapshot carries
+// every player; a submission carries the one that just submitted. Both carry
+// the quiz totals after the change.
+type SubmissionEvent struct {
+	Type       string           `json:"type"`
+	QuizID     string           `json:"quiz_id"`
+	Players    []PlayerProgress `json:"players,omitempty"`
+	Submission *QuizSubmission  `json:"submission,omitempty"`
+	// QuestionCount counts the questions that are not voided.
+	QuestionCount int       `json:"question_count"`
+	Participants  int       `json:"participants"`
+	FinishedCount int       `json:"finished_count"`
+	OccurredAt    time.Time `json:"occurred_at"`
+
+	// Message explains a closing event.
+	Message string `json:"message,omitempty"`
+}
+
+// SubmissionStream is one host's subscription. Send Snapshot first, then
+// Events until it is closed. Events is closed when the host falls too far
+// behind, Close is called, the quiz is deleted, or the server shuts down; in
+// the last two cases a closing event comes first.
+type SubmissionStream struct {
+	Snapshot SubmissionEvent
+	Events   <-chan SubmissionEvent
+	close    func()
+}
+
+// Close stops delivery. It is safe to call more than once.
+func (s SubmissionStream) Close() {
+	if s.close != nil {
+		s.close()
+	}
+}
+
+type submissionStreams struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan SubmissionEvent]struct{}
+	// closed is set by closeAll; no new hosts are accepted after it.
+	closed bool
+}
+
+func newSubmissionStreams() *submissionStreams {
+	return &submissionStreams{subscribers: make(map[string]map[chan SubmissionEvent]struct{})}
+}
+
+// SubscribeSubmissions streams the submissions to quizID, starting with a
+// snapshot of every player's progress.
+func (s *Service) SubscribeSubmissions(ctx context.Context, quizID string) (SubmissionStream, error) {
+	metadata, err := s.EnsureQuiz(ctx, quizID, false, 0)
+	if err != nil {
+		return SubmissionStream{}, err
+	}
+
+	hub := s.submissions
+	events := make(chan SubmissionEvent, subscriberBuffer)
+	hub.mu.Lock()
+	if hub.closed {
+		hub.mu.Unlock()
+		return SubmissionStream{}, ErrStreamsClosed
+	}
+	subscribers, ok := hub.subscribers[metadata.QuizID]
+	if !ok {
+		subscribers = make(map[chan SubmissionEvent]struct{})
+		hub.subscribers[metadata.QuizID] = subscribers
+	}
+	// Registering before the snapshot is read means nothing published in
+	// between is lost. A submission may then show in both, which is harmless
+	// as player totals are absolute.
+	subscribers[events] = struct{}{}
+	hub.mu.Unlock()
+
+	subscription := SubmissionStream{
+		Events: events,
+		close:  func() { hub.unsubscribe(metadata.QuizID, events) },
+	}
+	if subscription.Snapshot, err = s.submissionSnapshot(ctx, metadata.QuizID); err != nil {
+		subscription.Close()
+		return SubmissionStream{}, err
+	}
+	return subscription, nil
+}
+
+func (s *Service) submissionSnapshot(ctx context.Context, quizID string) (SubmissionEvent, error) {
+	players, questionCount, err := s.playerProgress(ctx, quizID)
+	if err != nil {
+		return SubmissionEvent{}, err
+	}
+	snapshot := SubmissionEvent{
+		Type:          SubmissionEventSnapshot,
+		QuizID:        quizID,
+		Players:       players,
+		QuestionCount: questionCount,
+		OccurredAt:    s.now().UTC(),
+	}
+	snapshot.tally(players)
+	return snapshot, nil
+}
+
+// playerProgress returns every player's progress in leaderboard order, and
+// the number of questions that are not voided.
+func (s *Service) playerProgress(ctx context.Context, quizID string) ([]PlayerProgress, int, error) {
+	_, questions, err := s.GetQuizQuestions(ctx, quizID, false, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	questionCount := 0
+	for _, question := range questions {
+		if !question.Voided {
+			questionCount++
+		}
+	}
+	entries, err := s.GetLeaderboard(ctx, quizID, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	players := make([]PlayerProgress, 0, len(entries))
+	for _, entry := range entries {
+		players = append(players, PlayerProgress{
+			Username:      entry.Username,
+			TotalScore:    entry.TotalScore,
+			AnsweredCount: entry.AnsweredCount,
+			Finished:      questionCount > 0 && entry.AnsweredCount >= questionCount,
+		})
+	}
+	return players, questionCount, nil
+}
+
+func (e *SubmissionEvent) tally(players []PlayerProgress) {
+	e.Participants = len(players)
+	e.FinishedCount = 0
+	for _, player := range players {
+		if player.Finished {
+			e.FinishedCount++
+		}
+	}
+}
+
+// publishSubmission tells hosts watching quizID that usernameNormalized's
+// answers were stored. Quizzes nobody watches are skipped without touching
+// the leaderboard.
+func (s *Service) publishSubmission(ctx context.Context, quizID, usernameNormalized string, results []ResponseResult) {
+	answered, scoreDelta := scoreResults(results)
+	if answered == 0 || !s.submissions.active(quizID) {
+		return
+	}
+	players, questionCount, err := s.playerProgress(ctx, quizID)
+	if err != nil {
+		return
+	}
+	for _, player := range players {
+		if player.Username != usernameNormalized {
+			continue
+		}
+		event := SubmissionEvent{
+			Type:          SubmissionEventSubmission,
+			QuizID:        quizID,
+			Submission:    &QuizSubmission{PlayerProgress: player, Answered: answered, ScoreDelta: scoreDelta},
+			QuestionCount: questionCount,
+			OccurredAt:    s.now().UTC(),
+		}
+		event.tally(players)
+		s.submissions.publish(quizID, event)
+		return
+	}
+}
+
+// publishSubmissionSnapshot sends every player's progress to hosts of quizID
+// after a change that replaces it, such as storing a new quiz under the ID.
+func (s *Service) publishSubmissionSnapshot(ctx context.Context, quizID string) {
+	if !s.submissions.active(quizID) {
+		return
+	}
+	if snapshot, err := s.submissionSnapshot(ctx, quizID); err == nil {
+		s.submissions.publish(quizID, snapshot)
+	}
+}
+
+// CloseSubmissionStreams ends every submission stream for shutdown: each host
+// gets a closing event and its Events channel is closed, and later
+// subscriptions fail with ErrStreamsClosed. It is safe to call more than once.
+func (s *Service) CloseSubmissionStreams() {
+	s.submissions.closeAll(SubmissionEvent{
+		Type:       SubmissionEventClosing,
+		Message:    "server closing; reconnect for a fresh snapshot",
+		OccurredAt: s.now().UTC(),
+	})
+}
+
+func (h *submissionStreams) active(quizID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[quizID]) > 0
+}
+
+func (h *submissionStreams) publish(quizID string, event SubmissionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	for subscriber := range h.subscribers[quizID] {
+		select {
+		case subscriber <- event:
+		default:
+			// Too far behind: drop the host rather than block submissions.
+			// It reconnects and starts from a fresh snapshot.
+			h.remove(quizID, subscriber)
+		}
+	}
+}
+
+func (h *submissionStreams) closeAll(closing SubmissionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for quizID := range h.subscribers {
+		event := closing
+		event.QuizID = quizID
+		h.closeSubscribers(event)
+	}
+}
+
+// closeQuiz ends closing.QuizID's streams, as closeAll does for every quiz.
+func (h *submissionStreams) closeQuiz(closing SubmissionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeSubscribers(closing)
+}
+
+// closeSubscribers sends closing to every host of closing.QuizID and closes
+// their channels. Callers hold h.mu.
+func (h *submissionStreams) closeSubscribers(closing SubmissionEvent) {
+	for subscriber := range h.subscribers[closing.QuizID] {
+		// A host with a full buffer misses the closing event but still sees
+		// its stream end.
+		select {
+		case subscriber <- closing:
+		default:
+		}
+		h.remove(closing.QuizID, subscriber)
+	}
+}
+
+func (h *submissionStreams) unsubscribe(quizID string, events chan SubmissionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, subscribed := h.subscribers[quizID][events]; subscribed {
+		h.remove(quizID, events)
+	}
+}
+
+// remove closes events and forgets the quiz once nobody watches it. Callers
+// hold h.mu.
+func (h *submissionStreams) remove(quizID string, events chan SubmissionEvent) {
+	delete(h.subscribers[quizID], events)
+	close(events)
+	if len(h.subscribers[quizID]) == 0 {
+		delete(h.subscribers, quizID)
+	}
+}
@@ -0,0 +1,184 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Admins tag stored questions (for example "geography" or "week-3") so hosts
+// can assemble quizzes from what is already in the store, without going back
+// to the provider.
+
+var (
+	// ErrInvalidTag reports a tag that is empty, too long, or uses characters
+	// other than letters, digits, '-' and '_', and a list of too many tags.
+	ErrInvalidTag = errors.New("invalid question tag")
+	// ErrBankTooSmall reports a bank quiz request that fewer stored questions
+	// match than it needs. Nothing is created.
+	ErrBankTooSmall = errors.New("not enough stored questions match")
+)
+
+const (
+	// MaxQuestionTags bounds the tags on one question, and in one filter.
+	MaxQuestionTags = 10
+	// maxTagLength bounds one tag, in bytes; tags are ASCII.
+	maxTagLength = 32
+	// MaxBankQuizzes bounds the quizzes one CreateBankQuizzes call creates.
+	MaxBankQuizzes = 20
+)
+
+// NormalizeTags lowercases and trims tags, drops duplicates, and sorts them.
+// A tag is 1 to 32 letters, digits, '-' or '_'.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: %q must be 1 to %d characters", ErrInvalidTag, tag, maxTagLength)
+		}
+		for _, r := range tag {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+				return nil, fmt.Errorf("%w: %q may only use letters, digits, '-' and '_'", ErrInvalidTag, tag)
+			}
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > MaxQuestionTags {
+		return nil, fmt.Errorf("%w: at most %d tags", ErrInvalidTag, MaxQuestionTags)
+	}
+	return normalized, nil
+}
+
+func (s *Service) tagStore() (QuestionTagStore, error) {
+	store, ok := s.quizzes.(QuestionTagStore)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return store, nil
+}
+
+// SetQuestionTags replaces the tags of a stored question and returns them
+// normalized. An empty list clears them.
+func (s *Service) SetQuestionTags(ctx context.Context, questionID string, tags []string) ([]string, error) {
+	store, err := s.tagStore()
+	if err != nil {
+		return nil, err
+	}
+	questionID = strings.TrimSpace(questionID)
+	if questionID == "" {
+		return nil, ErrUnknownQuestion
+	}
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.SetQuestionTags(ctx, questionID, normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// QuestionTags returns the tags of a stored question, sorted.
+func (s *Service) QuestionTags(ctx context.Context, questionID string) ([]string, error) {
+	store, err := s.tagStore()
+	if err != nil {
+		return nil, err
+	}
+	questionID = strings.TrimSpace(questionID)
+	if questionID == "" {
+		return nil, ErrUnknownQuestion
+	}
+	return store.QuestionTags(ctx, questionID)
+}
+
+// BankQuizOptions asks CreateBankQuizzes for QuizCount quizzes of
+// QuestionCount questions each, drawn from stored questions that match the
+// filter. A QuizCount of zero creates one quiz.
+type BankQuizOptions struct {
+	BankFilter
+	QuestionCount int
+	QuizCount     int
+	// QuizOptions applies to every quiz created. Its fetch filters
+	// (Difficulty, Category, QuestionType) are ignored; filter with
+	// BankFilter instead.
+	QuizOptions
+}
+
+// BankQuiz is one quiz created by CreateBankQuizzes.
+type BankQuiz struct {
+	Metadata  QuizMetadata
+	Questions []Question
+}
+
+// CreateBankQuizzes samples stored questions matching options and creates
+// quizzes from them, as a host would for several rooms of one event. No
+// question appears in two of the quizzes, and retired questions are left out.
+// When too few questions match, it returns ErrBankTooSmall and creates
+// nothing. The quizzes record ProviderBank as their origin, so a fresh
+// rematch is not possible; a same-questions rematch is.
+func (s *Service) CreateBankQuizzes(ctx context.Context, options BankQuizOptions) ([]BankQuiz, error) {
+	store, err := s.tagStore()
+	if err != nil {
+		return nil, err
+	}
+	if options.QuestionCount <= 0 {
+		return nil, fmt.Errorf("%w: question count must be positive", ErrInvalidQuestion)
+	}
+	if options.QuizCount == 0 {
+		options.QuizCount = 1
+	}
+	if options.QuizCount < 0 || options.QuizCount > MaxBankQuizzes {
+		return nil, fmt.Errorf("%w: quiz count must be between 1 and %d", ErrInvalidQuestion, MaxBankQuizzes)
+	}
+	filter := options.BankFilter
+	if filter.Tags, err = NormalizeTags(filter.Tags); err != nil {
+		return nil, err
+	}
+	if _, err := s.lifecycleFor(options.QuizOptions); err != nil {
+		return nil, err
+	}
+
+	var retired map[string]struct{}
+	if retirements, ok := s.quizzes.(QuestionRetirementStore); ok {
+		if retired, err = s.retired.get(ctx, retirements); err != nil {
+			return nil, err
+		}
+	}
+	needed := options.QuestionCount * options.QuizCount
+	// Retired questions are dropped after sampling, so draw enough extra to
+	// make up for every one of them.
+	sampled, err := store.SampleBankQuestions(ctx, filter, needed+len(retired))
+	if err != nil {
+		return nil, err
+	}
+	questions := sampled[:0:0]
+	for _, question := range sampled {
+		if _, ok := retired[normalizePrompt(question.Question)]; !ok {
+			questions = append(questions, question)
+		}
+	}
+	if len(questions) < needed {
+		return nil, fmt.Errorf("%w: %d of %d", ErrBankTooSmall, len(questions), needed)
+	}
+
+	customOptions := CustomQuizOptions{QuizOptions: QuizOptions{
+		QuestionTimeLimit: options.QuestionTimeLimit,
+		Draft:             options.Draft,
+		ClosesAt:          options.ClosesAt,
+	}}
+	created := make([]BankQuiz, 0, options.QuizCount)
+	for start := 0; start < needed; start += options.QuestionCount {
+		quizQuestions := questions[start : start+options.QuestionCount]
+		metadata, err := s.createCustomQuiz(ctx, quizQuestions, customOptions, QuizOrigin{Provider: ProviderBank})
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, BankQuiz{Metadata: metadata, Questions: quizQuestions})
+	}
+	return created, nil
+}
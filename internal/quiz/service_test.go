@@ -1,9 +1,22 @@
 package quiz
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"quiz-app/internal/opentdb"
 )
 
 type fakeQuizRepo struct {
@@ -70,8 +83,9 @@ type fakeAttemptRepo struct {
 	submitErr     error
 	submitCalls   int
 
-	lastSubmitQuizID   string
-	lastSubmitUsername string
+	lastSubmitQuizID    string
+	lastSubmitUsername  string
+	lastSubmitResponses []SubmittedResponse
 
 	leaderboard      []LeaderboardEntry
 	leaderboardErr   error
@@ -85,10 +99,11 @@ type fakeAttemptRepo struct {
 	lastAttemptUsername string
 }
 
-func (f *fakeAttemptRepo) SubmitResponses(_ context.Context, quizID, usernameNormalized string, _ []SubmittedResponse) ([]ResponseResult, error) {
+func (f *fakeAttemptRepo) SubmitResponses(_ context.Context, quizID, usernameNormalized string, responses []SubmittedResponse) ([]ResponseResult, error) {
 	f.submitCalls++
 	f.lastSubmitQuizID = quizID
 	f.lastSubmitUsername = usernameNormalized
+	f.lastSubmitResponses = responses
 	if f.submitErr != nil {
 		return nil, f.submitErr
 	}
@@ -213,7 +228,7 @@ func TestServiceSubmitResponsesUpdatesCachedLeaderboardAndAttemptScores(t *testi
 			AnsweredCount:    2,
 			LastSubmissionAt: time.Unix(100, 0).UTC(),
 		},
-	})
+	}, "")
 	service.setCachedAttemptScores("quiz-1", "alice", map[string]float64{"old": 1.0})
 
 	_, err := service.SubmitResponses(context.Background(), "quiz-1", " Alice ", []SubmittedResponse{
@@ -284,8 +299,8 @@ func TestServiceSubmitResponsesDoesNotCreateAttemptScoreCacheWhenMissing(t *test
 		t.Fatalf("SubmitResponses failed: %v", err)
 	}
 
-	if len(service.attemptScores) != 0 {
-		t.Fatalf("expected no attempt-score cache creation on write path, got %d entries", len(service.attemptScores))
+	if size := service.attemptScores.snapshot().Size; size != 0 {
+		t.Fatalf("expected no attempt-score cache creation on write path, got %d entries", size)
 	}
 }
 
@@ -332,3 +347,3499 @@ func TestServiceGetLeaderboardCachesAndAppliesLimit(t *testing.T) {
 		t.Fatalf("expected all entries when limit <= 0, got %d", len(allEntries))
 	}
 }
+
+func TestServiceSubmitResponsesRevealsCorrectAnswerWhenPolicyAllows(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{
+			PublicQuestion: PublicQuestion{
+				QuestionID: "q1",
+				Question:   "Capital of France?",
+				Options:    []Option{{Letter: "A", Text: "Lyon"}, {Letter: "B", Text: "Paris"}},
+			},
+			CorrectIndex: 1,
+		},
+		{
+			PublicQuestion: PublicQuestion{
+				QuestionID: "q2",
+				Question:   "2+2?",
+				Options:    []Option{{Letter: "A", Text: "4"}, {Letter: "B", Text: "5"}},
+			},
+			CorrectIndex: 0,
+		},
+	}
+
+	attempts := &fakeAttemptRepo{
+		submitResults: []ResponseResult{
+			{QuestionID: "q1", Status: StatusIncorrect},
+			{QuestionID: "q2", Status: StatusCorrect},
+		},
+	}
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{RevealPolicy: RevealAfterAnswer})
+
+	results, err := service.SubmitResponses(context.Background(), "quiz-1", "alice", []SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "q2", Answer: "A"},
+	})
+	if err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	if results[0].CorrectLetter != "B" || results[0].CorrectText != "Paris" {
+		t.Fatalf("expected reveal on incorrect result, got %+v", results[0])
+	}
+	if results[1].CorrectLetter != "" || results[1].CorrectText != "" {
+		t.Fatalf("expected no reveal on correct result, got %+v", results[1])
+	}
+}
+
+func TestServiceEvaluateResponsesForQuizHidesAnswerByDefault(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{
+			PublicQuestion: PublicQuestion{
+				QuestionID: "q1",
+				Options:    []Option{{Letter: "A", Text: "Lyon"}, {Letter: "B", Text: "Paris"}},
+			},
+			CorrectIndex: 1,
+		},
+	}
+	service := NewService(repo, &fakeAttemptRepo{}, nil)
+
+	results, err := service.EvaluateResponsesForQuiz(context.Background(), "quiz-1", []SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+	})
+	if err != nil {
+		t.Fatalf("EvaluateResponsesForQuiz failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Status != StatusIncorrect {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].CorrectLetter != "" || results[0].CorrectText != "" {
+		t.Fatalf("expected answer to stay hidden under default policy, got %+v", results[0])
+	}
+}
+
+func TestParseRevealPolicy(t *testing.T) {
+	if got, err := ParseRevealPolicy(""); err != nil || got != RevealNever {
+		t.Fatalf("default ParseRevealPolicy = (%q, %v), want (never, nil)", got, err)
+	}
+	if got, err := ParseRevealPolicy(" After_Answer "); err != nil || got != RevealAfterAnswer {
+		t.Fatalf("ParseRevealPolicy = (%q, %v), want (after_answer, nil)", got, err)
+	}
+	if _, err := ParseRevealPolicy("always"); err == nil {
+		t.Fatalf("expected error for unknown reveal policy")
+	}
+}
+
+type fakeServingAttemptRepo struct {
+	*fakeAttemptRepo
+	served    map[string]time.Time
+	submitted []SubmittedResponse
+	history   []Attempt
+}
+
+func (f *fakeServingAttemptRepo) ListAttempts(context.Context, string, string) ([]Attempt, error) {
+	return f.history, nil
+}
+
+func (f *fakeServingAttemptRepo) RecordServes(_ context.Context, _, _ string, questionIDs []string, servedAt time.Time) error {
+	for _, questionID := range questionIDs {
+		if _, ok := f.served[questionID]; !ok {
+			f.served[questionID] = servedAt
+		}
+	}
+	return nil
+}
+
+func (f *fakeServingAttemptRepo) ServeTimes(context.Context, string, string) (map[string]time.Time, error) {
+	return f.served, nil
+}
+
+func (f *fakeServingAttemptRepo) SubmitResponses(ctx context.Context, quizID, usernameNormalized string, responses []SubmittedResponse) ([]ResponseResult, error) {
+	f.submitted = responses
+	return f.fakeAttemptRepo.SubmitResponses(ctx, quizID, usernameNormalized, responses)
+}
+
+func TestServiceSubmitResponsesAddsSpeedBonusFromServeTime(t *testing.T) {
+	repo := newFakeQuizRepo()
+	pool := []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "e1"}, Difficulty: DifficultyEasy},
+		{PublicQuestion: PublicQuestion{QuestionID: "m1"}, Difficulty: DifficultyMedium},
+		{PublicQuestion: PublicQuestion{QuestionID: "h1"}, Difficulty: DifficultyHard},
+	}
+	repo.metadataByQuiz["adaptive"] = QuizMetadata{QuizID: "adaptive", QuestionCount: len(pool), Adaptive: true}
+	repo.questionsByQuiz["adaptive"] = pool
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	attempts := &fakeServingAttemptRepo{fakeAttemptRepo: &fakeAttemptRepo{}, served: map[string]time.Time{}}
+	now := time.Unix(1000, 0)
+	service := New(Config{Quizzes: repo, Attempts: attempts, ServiceOptions: ServiceOptions{
+		Now:        func() time.Time { return now },
+		SpeedBonus: SpeedBonus{MaxPoints: 1, Window: 10 * time.Second, Curve: BonusLinear},
+	}})
+	ctx := context.Background()
+
+	// Each adaptive question is timed from its own first serve; asking for it
+	// again does not restart the clock.
+	if _, err := service.NextAdaptiveQuestion(ctx, "adaptive", "Alice"); err != nil {
+		t.Fatalf("NextAdaptiveQuestion failed: %v", err)
+	}
+	now = now.Add(4 * time.Second)
+	if _, err := service.NextAdaptiveQuestion(ctx, "adaptive", "alice"); err != nil {
+		t.Fatalf("NextAdaptiveQuestion(again) failed: %v", err)
+	}
+	now = now.Add(2 * time.Second)
+	if _, err := service.SubmitResponses(ctx, "adaptive", "alice", []SubmittedResponse{{QuestionID: "m1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses(m1) failed: %v", err)
+	}
+	if bonus := attempts.submitted[0].Bonus; bonus != 0.4 {
+		t.Fatalf("m1 bonus = %v, want 0.4 from its first serve", bonus)
+	}
+
+	attempts.history = []Attempt{{QuestionID: "m1", Score: 1}}
+	if step, err := service.NextAdaptiveQuestion(ctx, "adaptive", "alice"); err != nil || step.Question.QuestionID != "h1" {
+		t.Fatalf("second step = (%+v, %v), want h1", step, err)
+	}
+	now = now.Add(2 * time.Second)
+	if _, err := service.SubmitResponses(ctx, "adaptive", "alice", []SubmittedResponse{{QuestionID: "h1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses(h1) failed: %v", err)
+	}
+	if bonus := attempts.submitted[0].Bonus; bonus != 0.8 {
+		t.Fatalf("h1 bonus = %v, want 0.8 from its own serve", bonus)
+	}
+
+	// A quiz served whole can be read before any timed serve, so it earns none.
+	service.RecordServed(ctx, "quiz-1", "alice", []string{"q1"}, false)
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "alice", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses(q1) failed: %v", err)
+	}
+	if bonus := attempts.submitted[0].Bonus; bonus != 0 {
+		t.Fatalf("q1 bonus = %v, want 0 outside an adaptive quiz", bonus)
+	}
+	if policy := service.ScoringPolicy(); policy.SpeedBonus.MaxPoints != 1 {
+		t.Fatalf("ScoringPolicy().SpeedBonus = %+v, want the configured bonus", policy.SpeedBonus)
+	}
+
+	// Without serve tracking the bonus is neither applied nor advertised.
+	plain := New(Config{Quizzes: repo, Attempts: &fakeAttemptRepo{}, ServiceOptions: ServiceOptions{
+		SpeedBonus: SpeedBonus{MaxPoints: 1, Window: 10 * time.Second},
+	}})
+	if policy := plain.ScoringPolicy(); policy.SpeedBonus.Enabled() {
+		t.Fatalf("ScoringPolicy().SpeedBonus = %+v, want disabled without serve tracking", policy.SpeedBonus)
+	}
+}
+
+func TestServiceSubmitResponsesSpendsServeNonces(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	attempts := &fakeAttemptRepo{}
+	service := New(Config{Quizzes: repo, Attempts: attempts, ServiceOptions: ServiceOptions{ServeNonces: true}})
+	ctx := context.Background()
+
+	alice := service.RecordServed(ctx, "quiz-1", "Alice", []string{"q1", "q2"}, false)
+	if len(alice) != 2 || alice["q1"] == "" || alice["q1"] == alice["q2"] {
+		t.Fatalf("RecordServed(alice) = %v, want a distinct nonce per question", alice)
+	}
+	if again := service.RecordServed(ctx, "quiz-1", "alice", []string{"q1"}, false); again["q1"] != alice["q1"] {
+		t.Fatalf("RecordServed(alice) again = %v, want the unspent nonce %q kept", again, alice["q1"])
+	}
+	bob := service.RecordServed(ctx, "quiz-1", "bob", []string{"q1"}, false)
+
+	submit := func(username string, responses ...SubmittedResponse) []ResponseResult {
+		t.Helper()
+		attempts.lastSubmitResponses = nil
+		attempts.submitResults = make([]ResponseResult, 0, len(responses))
+		for _, response := range responses {
+			attempts.submitResults = append(attempts.submitResults, ResponseResult{QuestionID: response.QuestionID, Status: StatusCorrect})
+		}
+		results, err := service.SubmitResponses(ctx, "quiz-1", username, responses)
+		if err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", username, err)
+		}
+		return results
+	}
+
+	// Bob replays alice's payload verbatim; his own nonce is still good.
+	results := submit("bob", SubmittedResponse{QuestionID: "q1", Answer: "A", Nonce: alice["q1"]})
+	if results[0].Status != StatusInvalidNonce || attempts.lastSubmitResponses != nil {
+		t.Fatalf("bob replaying alice = %+v, want invalid_nonce without reaching the store", results)
+	}
+	results = submit("alice",
+		SubmittedResponse{QuestionID: "q1", Answer: "A", Nonce: alice["q1"]},
+		SubmittedResponse{QuestionID: "q2", Answer: "A"},
+	)
+	if results[0].Status != StatusCorrect || results[1].Status != StatusInvalidNonce || len(attempts.lastSubmitResponses) != 1 {
+		t.Fatalf("alice = %+v, want q1 scored and q2 rejected without a nonce", results)
+	}
+	// A retry carries the spent nonce again and goes on to the store, which
+	// keeps the first answer; nobody else can present it.
+	if results = submit("alice", SubmittedResponse{QuestionID: "q1", Answer: "A", Nonce: alice["q1"]}); len(attempts.lastSubmitResponses) != 1 {
+		t.Fatalf("alice retrying = %+v, want the retry passed to the store", results)
+	}
+	if results = submit("bob", SubmittedResponse{QuestionID: "q1", Answer: "A", Nonce: alice["q1"]}); results[0].Status != StatusInvalidNonce {
+		t.Fatalf("bob replaying alice's spent nonce = %+v, want invalid_nonce", results)
+	}
+	if results = submit("bob", SubmittedResponse{QuestionID: "q1", Answer: "A", Nonce: bob["q1"]}); results[0].Status != StatusCorrect {
+		t.Fatalf("bob = %+v, want his own nonce accepted", results)
+	}
+
+	// Players served the answer key score themselves and are exempt.
+	if nonces := service.RecordServed(ctx, "quiz-1", "carol", []string{"q1"}, true); nonces != nil {
+		t.Fatalf("RecordServed(carol, answer key) = %v, want no nonces", nonces)
+	}
+	if results = submit("carol", SubmittedResponse{QuestionID: "q1", Answer: "A"}); results[0].Status != StatusCorrect {
+		t.Fatalf("carol = %+v, want the answer scored without a nonce", results)
+	}
+}
+
+func TestServiceServeNoncesExpireAndFollowTheirQuiz(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	attempts := &fakeAttemptRepo{submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}}}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := New(Config{Quizzes: repo, Attempts: attempts, ServiceOptions: ServiceOptions{
+		Now:               func() time.Time { return now },
+		ServeNonces:       true,
+		OfflineSyncWindow: time.Hour,
+	}})
+	ctx := context.Background()
+	submit := func(nonce string) string {
+		t.Helper()
+		results, err := service.SubmitResponses(ctx, "quiz-1", "alice", []SubmittedResponse{{QuestionID: "q1", Answer: "A", Nonce: nonce}})
+		if err != nil {
+			t.Fatalf("SubmitResponses failed: %v", err)
+		}
+		return results[0].Status
+	}
+
+	// Nonces left idle past the sync window expire.
+	first := service.RecordServed(ctx, "quiz-1", "alice", []string{"q1"}, false)
+	now = now.Add(time.Hour + time.Second)
+	if status := submit(first["q1"]); status != StatusInvalidNonce {
+		t.Fatalf("answer with an expired nonce = %s, want invalid_nonce", status)
+	}
+	second := service.RecordServed(ctx, "quiz-1", "alice", []string{"q1"}, false)
+	if second["q1"] == first["q1"] {
+		t.Fatalf("RecordServed after expiry = %v, want a fresh nonce", second)
+	}
+
+	// Archiving the quiz drops its nonces.
+	service.invalidateQuiz("quiz-1", QuizArchived)
+	if status := submit(second["q1"]); status != StatusInvalidNonce {
+		t.Fatalf("answer after the quiz was archived = %s, want invalid_nonce", status)
+	}
+	if service.nonces.count != 0 {
+		t.Fatalf("nonce players = %d, want none left", service.nonces.count)
+	}
+}
+
+func TestServeNoncesDropTheOldestPlayerWhenFull(t *testing.T) {
+	nonces := newServeNonces(0)
+	start := time.Unix(0, 0)
+	for idx := 0; idx <= maxServeNoncePlayers; idx++ {
+		nonces.issue("quiz-1", fmt.Sprintf("player-%d", idx), []string{"q1"}, false, start.Add(time.Duration(idx)))
+	}
+	if nonces.count != maxServeNoncePlayers {
+		t.Fatalf("players = %d, want the cap of %d", nonces.count, maxServeNoncePlayers)
+	}
+	if _, ok := nonces.players["quiz-1"]["player-0"]; ok {
+		t.Fatalf("player-0 kept, want the player idle longest dropped")
+	}
+}
+
+func TestServiceSubmitResponsesGracesQuickRetries(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	attempts := &fakeAttemptHistoryRepo{
+		fakeAttemptRepo: &fakeAttemptRepo{submitResults: []ResponseResult{
+			{QuestionID: "q1", Status: StatusAlreadyAnswered},
+			{QuestionID: "q2", Status: StatusAlreadyAnswered},
+			{QuestionID: "q3", Status: StatusAlreadyAnswered},
+			{QuestionID: "q4", Status: StatusAlreadyAnswered},
+		}},
+		history: []Attempt{
+			{QuestionID: "q1", AnswerLetter: "A", Score: 1.25, SubmittedAt: now.Add(-2 * time.Second)},
+			{QuestionID: "q2", AnswerLetter: "B", Score: 0, SubmittedAt: now.Add(-2 * time.Second)},
+			{QuestionID: "q3", AnswerLetter: "C", Score: 1, SubmittedAt: now.Add(-2 * time.Second)},
+			{QuestionID: "q4", AnswerLetter: "D", Score: 1, SubmittedAt: now.Add(-time.Minute)},
+		},
+	}
+	service := New(Config{Quizzes: repo, Attempts: attempts, ServiceOptions: ServiceOptions{
+		RetryGraceWindow: 5 * time.Second,
+		Now:              func() time.Time { return now },
+	}})
+
+	results, err := service.SubmitResponses(context.Background(), "quiz-1", "alice", []SubmittedResponse{
+		{QuestionID: "q1", Answer: "a"},
+		{QuestionID: "q2", Answer: "B"},
+		{QuestionID: "q3", Answer: "D"},
+		{QuestionID: "q4", Answer: "D"},
+	})
+	if err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	want := []ResponseResult{
+		{QuestionID: "q1", Status: StatusCorrect, Bonus: 0.25},
+		{QuestionID: "q2", Status: StatusIncorrect},
+		// A different letter is a second answer, not a retry.
+		{QuestionID: "q3", Status: StatusAlreadyAnswered},
+		// So is the same letter after the window.
+		{QuestionID: "q4", Status: StatusAlreadyAnswered},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("results = %+v, want %+v", results, want)
+	}
+}
+
+type fakeVoidingAttemptRepo struct {
+	*fakeAttemptRepo
+	voided []string
+}
+
+func (f *fakeVoidingAttemptRepo) VoidQuestion(_ context.Context, quizID, questionID string, _ time.Time) error {
+	f.voided = append(f.voided, quizID+"/"+questionID)
+	return nil
+}
+
+func TestServiceVoidQuestionInvalidatesQuizCaches(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "q1", Options: []Option{{Letter: "A", Text: "One"}}}},
+	}
+	attempts := &fakeVoidingAttemptRepo{fakeAttemptRepo: &fakeAttemptRepo{
+		leaderboard:   []LeaderboardEntry{{Username: "alice", TotalScore: 1}},
+		attemptScores: map[string]float64{"q1": 1},
+	}}
+	service := NewService(repo, attempts, nil)
+	ctx := context.Background()
+
+	if _, _, err := service.GetQuizQuestions(ctx, "quiz-1", false, 0); err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	if _, err := service.GetLeaderboard(ctx, "quiz-1", 10); err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+	if _, err := service.GetAttemptScores(ctx, "quiz-1", "alice"); err != nil {
+		t.Fatalf("GetAttemptScores failed: %v", err)
+	}
+
+	if err := service.VoidQuestion(ctx, "quiz-1", "q1"); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+	if len(attempts.voided) != 1 || attempts.voided[0] != "quiz-1/q1" {
+		t.Fatalf("voided calls = %v, want [quiz-1/q1]", attempts.voided)
+	}
+
+	if _, _, err := service.GetQuizQuestions(ctx, "quiz-1", false, 0); err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	if _, err := service.GetLeaderboard(ctx, "quiz-1", 10); err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+	if _, err := service.GetAttemptScores(ctx, "quiz-1", "alice"); err != nil {
+		t.Fatalf("GetAttemptScores failed: %v", err)
+	}
+	if repo.getQuestionsCalls != 2 || attempts.leaderboardCalls != 2 || attempts.attemptScoresCalls != 2 {
+		t.Fatalf("expected reads after void to hit the store, got questions=%d leaderboard=%d scores=%d",
+			repo.getQuestionsCalls, attempts.leaderboardCalls, attempts.attemptScoresCalls)
+	}
+}
+
+func TestServiceVoidQuestionUnsupportedStore(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	service := NewService(repo, &fakeAttemptRepo{}, nil)
+
+	if err := service.VoidQuestion(context.Background(), "quiz-1", "q1"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("VoidQuestion = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestServiceCreateQuizFromQuestionsRejectsDuplicates(t *testing.T) {
+	repo := newFakeQuizRepo()
+	service := NewService(repo, &fakeAttemptRepo{}, nil)
+
+	question, err := NewQuestion("2 + 2?", []string{"4", "5"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+
+	if _, err := service.CreateQuizFromQuestions(context.Background(), []Question{question, question}); !errors.Is(err, ErrInvalidQuestion) {
+		t.Fatalf("duplicate CreateQuizFromQuestions = %v, want ErrInvalidQuestion", err)
+	}
+
+	metadata, err := service.CreateQuizFromQuestions(context.Background(), []Question{question})
+	if err != nil {
+		t.Fatalf("CreateQuizFromQuestions failed: %v", err)
+	}
+	if metadata.QuestionCount != 1 || repo.createCalls != 1 {
+		t.Fatalf("unexpected create: metadata=%+v createCalls=%d", metadata, repo.createCalls)
+	}
+}
+
+type fakeUsageQuizRepo struct {
+	*fakeQuizRepo
+	recent   []Question
+	recorded map[string][]string
+}
+
+func (f *fakeUsageQuizRepo) RecordQuestionUsage(_ context.Context, quizID string, questionIDs []string, _ time.Time) error {
+	f.recorded[quizID] = questionIDs
+	return nil
+}
+
+func (f *fakeUsageQuizRepo) RecentlyUsedQuestions(_ context.Context, _ time.Time) ([]Question, error) {
+	return f.recent, nil
+}
+
+func TestServiceGetDailyQuizSkipsRecentlyUsedQuestions(t *testing.T) {
+	repo := &fakeUsageQuizRepo{
+		fakeQuizRepo: newFakeQuizRepo(),
+		// Same prompt as an upstream question but a different ID, as happens when
+		// options shuffle differently between fetches.
+		recent:   []Question{{PublicQuestion: PublicQuestion{QuestionID: "q_old", Question: "Used  yesterday?"}}},
+		recorded: make(map[string][]string),
+	}
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return []opentdb.RawQuestion{
+			{Question: "used yesterday?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Fresh?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		}, nil
+	}
+	service := NewServiceWithOptions(repo, &fakeAttemptRepo{}, fetcher, ServiceOptions{DailyRepeatDays: 7})
+	service.now = func() time.Time { return time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC) }
+
+	metadata, err := service.GetDailyQuiz(context.Background())
+	if err != nil {
+		t.Fatalf("GetDailyQuiz failed: %v", err)
+	}
+	if metadata.QuizID != "daily-2026-03-02" || metadata.QuestionCount != 1 {
+		t.Fatalf("daily metadata = %+v, want daily-2026-03-02 with 1 question", metadata)
+	}
+
+	questions := repo.questionsByQuiz["daily-2026-03-02"]
+	if len(questions) != 1 || questions[0].Question != "Fresh?" {
+		t.Fatalf("daily questions = %+v, want only the fresh question", questions)
+	}
+	if len(repo.recorded["daily-2026-03-02"]) != 1 {
+		t.Fatalf("recorded usage = %+v, want one question", repo.recorded)
+	}
+
+	if _, err := service.GetDailyQuiz(context.Background()); err != nil {
+		t.Fatalf("second GetDailyQuiz failed: %v", err)
+	}
+	if repo.createCalls != 1 {
+		t.Fatalf("createCalls = %d, want daily quiz reused", repo.createCalls)
+	}
+}
+
+func TestServiceCompletionWatchFiresOnceAtThreshold(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 2}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "q1"}},
+		{PublicQuestion: PublicQuestion{QuestionID: "q2"}},
+	}
+	attempts := &fakeAttemptRepo{
+		submitResults: []ResponseResult{{QuestionID: "q2", Status: StatusCorrect}},
+		leaderboard: []LeaderboardEntry{
+			{Username: "alice", AnsweredCount: 2},
+			{Username: "bob", AnsweredCount: 1},
+		},
+	}
+
+	var events []CompletionEvent
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{
+		CompletionNotifier: func(_ string, event CompletionEvent) {
+			events = append(events, event)
+		},
+	})
+	ctx := context.Background()
+
+	watch, err := service.WatchCompletion(ctx, "quiz-1", CompletionWatch{
+		URL:              "http://hooks.test/done",
+		Participants:     []string{"Alice", "bob", "carol", "dave"},
+		ThresholdPercent: 50,
+		Username:         "Bob",
+	})
+	if err != nil {
+		t.Fatalf("WatchCompletion failed: %v", err)
+	}
+	if watch.Username != "bob" || len(watch.Participants) != 4 {
+		t.Fatalf("normalized watch = %+v", watch)
+	}
+	if len(events) != 0 {
+		t.Fatalf("events before threshold = %+v, want none", events)
+	}
+
+	// Bob finishes: 2 of 4 participants done meets 50%, and the user watch fires.
+	attempts.leaderboard = []LeaderboardEntry{
+		{Username: "alice", AnsweredCount: 2},
+		{Username: "bob", AnsweredCount: 2},
+	}
+	service.leaderboardCache.delete("quiz-1")
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "bob", []SubmittedResponse{{QuestionID: "q2", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Event != EventCompletionThreshold || events[0].Completed != 2 || events[1].Event != EventUserFinished {
+		t.Fatalf("events after bob finished = %+v", events)
+	}
+
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "bob", []SubmittedResponse{{QuestionID: "q2", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected watches to fire once, got %d events", len(events))
+	}
+}
+
+func TestServiceWatchCompletionValidates(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+
+	disabled := NewService(repo, &fakeAttemptRepo{}, nil)
+	if _, err := disabled.WatchCompletion(context.Background(), "quiz-1", CompletionWatch{URL: "http://x", Username: "a"}); !errors.Is(err, ErrNotificationsDisabled) {
+		t.Fatalf("WatchCompletion without notifier = %v, want ErrNotificationsDisabled", err)
+	}
+
+	service := NewServiceWithOptions(repo, &fakeAttemptRepo{}, nil, ServiceOptions{
+		CompletionNotifier: func(string, CompletionEvent) {},
+	})
+	if _, err := service.WatchCompletion(context.Background(), "quiz-1", CompletionWatch{URL: "http://x", ThresholdPercent: 80}); !errors.Is(err, ErrInvalidCompletionWatch) {
+		t.Fatalf("threshold without participants = %v, want ErrInvalidCompletionWatch", err)
+	}
+}
+
+func TestServiceSubmitResponsesThrottlesPerUser(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	attempts := &fakeAttemptRepo{}
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{SubmitRateLimit: 3, SubmitBurst: 3})
+	now := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+	answer := []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.SubmitResponses(ctx, "quiz-1", "alice", answer); err != nil {
+			t.Fatalf("SubmitResponses #%d failed: %v", i+1, err)
+		}
+	}
+
+	_, err := service.SubmitResponses(ctx, "quiz-1", "Alice", answer)
+	var limited *RateLimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &limited) {
+		t.Fatalf("fourth SubmitResponses error = (%v), want ErrRateLimited", err)
+	}
+	if limited.RetryAfter <= 0 || limited.RetryAfter > time.Second/3 {
+		t.Fatalf("RetryAfter = (%v), want within one token refill", limited.RetryAfter)
+	}
+	if attempts.submitCalls != 3 {
+		t.Fatalf("submitCalls = (%d), want 3", attempts.submitCalls)
+	}
+
+	// Other users have their own bucket.
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "bob", answer); err != nil {
+		t.Fatalf("SubmitResponses for bob failed: %v", err)
+	}
+
+	now = now.Add(time.Second / 2)
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "alice", answer); err != nil {
+		t.Fatalf("SubmitResponses after refill failed: %v", err)
+	}
+
+	// A batch over the burst never fits, so it is not worth retrying.
+	batch := make([]SubmittedResponse, 4)
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "carol", batch); !errors.Is(err, ErrBatchTooLarge) || errors.Is(err, ErrRateLimited) {
+		t.Fatalf("oversized batch error = (%v), want ErrBatchTooLarge", err)
+	}
+
+	// The default burst takes a whole quiz in one batch.
+	if limiter := newSubmitLimiter(3, 0); limiter.allow("quiz-1::dave", MaxQuizQuestions, now) != nil {
+		t.Fatalf("default burst rejected a batch of %d answers", MaxQuizQuestions)
+	}
+}
+
+func TestServiceGetOrCreateQuizQuestionsRecordsShortfall(t *testing.T) {
+	repo := newFakeQuizRepo()
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return []opentdb.RawQuestion{
+			{Question: "One?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Two?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		}, nil
+	}
+	service := NewService(repo, &fakeAttemptRepo{}, fetcher)
+	ctx := context.Background()
+
+	metadata, questions, created, err := service.GetOrCreateQuizQuestions(ctx, "shared", 5)
+	if err != nil {
+		t.Fatalf("GetOrCreateQuizQuestions failed: %v", err)
+	}
+	if !created || len(questions) != 2 || metadata.QuestionCount != 2 || metadata.RequestedQuestionCount != 5 {
+		t.Fatalf("GetOrCreateQuizQuestions = (%+v, %d questions, created=%t), want 2 of 5 created", metadata, len(questions), created)
+	}
+	if stored := repo.metadataByQuiz["shared"]; stored.RequestedQuestionCount != 5 {
+		t.Fatalf("stored RequestedQuestionCount = (%d), want 5", stored.RequestedQuestionCount)
+	}
+
+	_, _, created, err = service.GetOrCreateQuizQuestions(ctx, "shared", 5)
+	if err != nil || created {
+		t.Fatalf("second GetOrCreateQuizQuestions = (created=%t, %v), want existing quiz", created, err)
+	}
+}
+
+func TestServiceStoringQuizOverAnEvictedOneDropsItsCachedStandings(t *testing.T) {
+	repo := newFakeQuizRepo()
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return []opentdb.RawQuestion{{Question: "One?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}}}, nil
+	}
+	attempts := &fakeAttemptRepo{attemptScores: map[string]float64{}}
+	service := NewService(repo, attempts, fetcher)
+	var invalidated []string
+	service.OnQuizInvalidated(func(quizID string, reason QuizInvalidation) {
+		invalidated = append(invalidated, quizID+" "+string(reason))
+	})
+	ctx := context.Background()
+
+	// Standings outlive the metadata of an earlier quiz under the same ID,
+	// for example after the metadata was evicted and the quiz deleted by
+	// another process.
+	service.setCachedLeaderboard("shared", []LeaderboardEntry{{Username: "alice", TotalScore: 3, AnsweredCount: 3}}, "")
+	service.setCachedAttemptScores("shared", "alice", map[string]float64{"old": 1})
+
+	if _, _, created, err := service.GetOrCreateQuizQuestions(ctx, "shared", 1); err != nil || !created {
+		t.Fatalf("GetOrCreateQuizQuestions = (created=%t, %v), want a new quiz", created, err)
+	}
+	if strings.Join(invalidated, ",") != "shared stored" {
+		t.Fatalf("invalidations = %v, want the stored quiz", invalidated)
+	}
+	if entries, err := service.GetLeaderboard(ctx, "shared", 0); err != nil || len(entries) != 0 || attempts.leaderboardCalls != 1 {
+		t.Fatalf("GetLeaderboard = (%+v, %v) after %d store reads, want the empty standings from the store", entries, err, attempts.leaderboardCalls)
+	}
+	if scores, err := service.GetAttemptScores(ctx, "shared", "alice"); err != nil || len(scores) != 0 {
+		t.Fatalf("GetAttemptScores = (%v, %v), want none from the store", scores, err)
+	}
+}
+
+type fakeStatsQuizRepo struct {
+	*fakeQuizRepo
+	stats []QuizStats
+}
+
+func (f *fakeStatsQuizRepo) ListQuizStats(_ context.Context) ([]QuizStats, error) {
+	return append([]QuizStats(nil), f.stats...), nil
+}
+
+func TestServiceListQuizStatsSortsAndPaginates(t *testing.T) {
+	repo := &fakeStatsQuizRepo{
+		fakeQuizRepo: newFakeQuizRepo(),
+		stats: []QuizStats{
+			{QuizMetadata: QuizMetadata{QuizID: "a", CreatedAt: time.Unix(1, 0)}, AttemptCount: 5},
+			{QuizMetadata: QuizMetadata{QuizID: "b", CreatedAt: time.Unix(3, 0)}, AttemptCount: 9},
+			{QuizMetadata: QuizMetadata{QuizID: "c", CreatedAt: time.Unix(2, 0)}, AttemptCount: 5},
+		},
+	}
+	service := NewService(repo, &fakeAttemptRepo{}, nil)
+	ctx := context.Background()
+
+	page, total, err := service.ListQuizStats(ctx, QuizStatsQuery{Sort: SortByAttempts, Descending: true, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListQuizStats failed: %v", err)
+	}
+	if total != 3 || len(page) != 2 || page[0].QuizID != "b" || page[1].QuizID != "a" {
+		t.Fatalf("first page = (%+v, total %d), want b then a of 3", page, total)
+	}
+
+	page, _, err = service.ListQuizStats(ctx, QuizStatsQuery{Sort: SortByAttempts, Descending: true, Offset: 2, Limit: 2})
+	if err != nil || len(page) != 1 || page[0].QuizID != "c" {
+		t.Fatalf("second page = (%+v, %v), want c", page, err)
+	}
+
+	page, _, err = service.ListQuizStats(ctx, QuizStatsQuery{})
+	if err != nil || page[0].QuizID != "a" || page[2].QuizID != "b" {
+		t.Fatalf("default order = (%+v, %v), want oldest first", page, err)
+	}
+
+	if _, _, err := service.ListQuizStats(ctx, QuizStatsQuery{Sort: "bogus"}); !errors.Is(err, ErrInvalidQuizStatsQuery) {
+		t.Fatalf("unknown sort error = (%v), want ErrInvalidQuizStatsQuery", err)
+	}
+	if _, _, err := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, nil).ListQuizStats(ctx, QuizStatsQuery{}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("store without stats error = (%v), want ErrUnsupported", err)
+	}
+}
+
+type fakeBookmarkQuizRepo struct {
+	*fakeQuizRepo
+	bookmarks map[string][]Bookmark
+}
+
+func (f *fakeBookmarkQuizRepo) AddBookmark(_ context.Context, usernameNormalized, questionID string, createdAt time.Time) error {
+	f.bookmarks[usernameNormalized] = append(f.bookmarks[usernameNormalized], Bookmark{
+		Question:  Question{PublicQuestion: PublicQuestion{QuestionID: questionID}},
+		CreatedAt: createdAt,
+	})
+	return nil
+}
+
+func (f *fakeBookmarkQuizRepo) RemoveBookmark(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (f *fakeBookmarkQuizRepo) ListBookmarks(_ context.Context, usernameNormalized string) ([]Bookmark, error) {
+	return f.bookmarks[usernameNormalized], nil
+}
+
+func TestServiceCreatePracticeQuizUsesBookmarks(t *testing.T) {
+	repo := &fakeBookmarkQuizRepo{fakeQuizRepo: newFakeQuizRepo(), bookmarks: make(map[string][]Bookmark)}
+	service := NewService(repo, &fakeAttemptRepo{}, nil)
+	ctx := context.Background()
+
+	if _, _, err := service.CreatePracticeQuiz(ctx, "alice", 5); !errors.Is(err, ErrNoBookmarks) {
+		t.Fatalf("CreatePracticeQuiz without bookmarks error = (%v), want ErrNoBookmarks", err)
+	}
+
+	for _, questionID := range []string{"q1", "q2", "q3"} {
+		if err := service.AddBookmark(ctx, " Alice ", questionID); err != nil {
+			t.Fatalf("AddBookmark(%s) failed: %v", questionID, err)
+		}
+	}
+
+	metadata, questions, err := service.CreatePracticeQuiz(ctx, "alice", 2)
+	if err != nil {
+		t.Fatalf("CreatePracticeQuiz failed: %v", err)
+	}
+	if len(questions) != 2 || metadata.QuestionCount != 2 {
+		t.Fatalf("practice quiz = (%+v, %d questions), want 2", metadata, len(questions))
+	}
+	if stored := repo.questionsByQuiz[metadata.QuizID]; len(stored) != 2 {
+		t.Fatalf("stored questions = (%d), want 2", len(stored))
+	}
+
+	if _, questions, err = service.CreatePracticeQuiz(ctx, "alice", 0); err != nil || len(questions) != 3 {
+		t.Fatalf("CreatePracticeQuiz(all) = (%d questions, %v), want 3", len(questions), err)
+	}
+}
+
+type fakeAuthorQuizRepo struct {
+	*fakeQuizRepo
+	authors map[string][]string
+	reports map[string]string
+}
+
+func (f *fakeAuthorQuizRepo) RecordQuestionAuthor(_ context.Context, authorNormalized string, questionIDs []string, _ time.Time) error {
+	f.authors[authorNormalized] = append(f.authors[authorNormalized], questionIDs...)
+	return nil
+}
+
+func (f *fakeAuthorQuizRepo) QuestionPerformanceByAuthor(_ context.Context, authorNormalized string) ([]QuestionPerformance, error) {
+	performance := make([]QuestionPerformance, 0, len(f.authors[authorNormalized]))
+	for _, questionID := range f.authors[authorNormalized] {
+		performance = append(performance, QuestionPerformance{Question: Question{PublicQuestion: PublicQuestion{QuestionID: questionID}}})
+	}
+	return performance, nil
+}
+
+func (f *fakeAuthorQuizRepo) ReportQuestion(_ context.Context, questionID, usernameNormalized, reason string, _ time.Time) error {
+	f.reports[questionID+"/"+usernameNormalized] = reason
+	return nil
+}
+
+func TestServiceCreateAuthoredQuizRecordsAuthor(t *testing.T) {
+	repo := &fakeAuthorQuizRepo{fakeQuizRepo: newFakeQuizRepo(), authors: make(map[string][]string), reports: make(map[string]string)}
+	service := NewService(repo, &fakeAttemptRepo{}, nil)
+	ctx := context.Background()
+
+	question, err := NewQuestion("2+2?", []string{"4", "5"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	if _, err := service.CreateAuthoredQuiz(ctx, " Carol ", []Question{question}); err != nil {
+		t.Fatalf("CreateAuthoredQuiz failed: %v", err)
+	}
+
+	performance, err := service.QuestionPerformanceByAuthor(ctx, "CAROL")
+	if err != nil {
+		t.Fatalf("QuestionPerformanceByAuthor failed: %v", err)
+	}
+	if len(performance) != 1 || performance[0].Question.QuestionID != question.QuestionID {
+		t.Fatalf("performance = (%+v), want the authored question", performance)
+	}
+
+	if err := service.ReportQuestion(ctx, question.QuestionID, "Bob", "  two answers look right  "); err != nil {
+		t.Fatalf("ReportQuestion failed: %v", err)
+	}
+	if got := repo.reports[question.QuestionID+"/bob"]; got != "two answers look right" {
+		t.Fatalf("stored reason = (%q), want trimmed reason", got)
+	}
+	if err := service.ReportQuestion(ctx, question.QuestionID, "bob", strings.Repeat("x", maxReportReasonLength+1)); !errors.Is(err, ErrInvalidReport) {
+		t.Fatalf("ReportQuestion(long reason) error = (%v), want ErrInvalidReport", err)
+	}
+
+	plain := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, nil)
+	if _, err := plain.CreateAuthoredQuiz(ctx, "carol", []Question{question}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("CreateAuthoredQuiz without tracker error = (%v), want ErrUnsupported", err)
+	}
+}
+
+func TestServiceSubmitResponsesExplainsDistractorsInPracticeQuizzes(t *testing.T) {
+	repo := newFakeQuizRepo()
+	question, err := NewQuestion("Capital of France?", []string{"Berlin", "Paris"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	if question, err = question.WithFeedback([]string{"Berlin is Germany's capital."}); err != nil {
+		t.Fatalf("WithFeedback failed: %v", err)
+	}
+	responses := []SubmittedResponse{{QuestionID: question.QuestionID, Answer: "A"}}
+	attempts := &fakeAttemptRepo{submitResults: []ResponseResult{{QuestionID: question.QuestionID, Status: StatusIncorrect}}}
+	service := NewService(repo, attempts, nil)
+	ctx := context.Background()
+
+	regular, err := service.CreateQuizFromQuestions(ctx, []Question{question})
+	if err != nil {
+		t.Fatalf("CreateQuizFromQuestions failed: %v", err)
+	}
+	results, err := service.SubmitResponses(ctx, regular.QuizID, "alice", responses)
+	if err != nil || results[0].Feedback != "" {
+		t.Fatalf("regular quiz results = (%+v, %v), want no feedback", results, err)
+	}
+
+	practice, err := service.CreateCustomQuiz(ctx, []Question{question}, CustomQuizOptions{Practice: true})
+	if err != nil {
+		t.Fatalf("CreateCustomQuiz failed: %v", err)
+	}
+	attempts.submitResults = []ResponseResult{{QuestionID: question.QuestionID, Status: StatusIncorrect}}
+	results, err = service.SubmitResponses(ctx, practice.QuizID, "alice", responses)
+	if err != nil || results[0].Feedback != "Berlin is Germany's capital." || results[0].CorrectLetter != "" {
+		t.Fatalf("practice quiz results = (%+v, %v), want feedback without the correct answer", results, err)
+	}
+}
+
+func TestNewUsesConfiguredClockAndQuizIDs(t *testing.T) {
+	repo := newFakeQuizRepo()
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := New(Config{
+		Quizzes:  repo,
+		Attempts: &fakeAttemptRepo{},
+		ServiceOptions: ServiceOptions{
+			Now:       func() time.Time { return fixed },
+			NewQuizID: func() string { return "qz_fixed" },
+		},
+	})
+	question := Question{PublicQuestion: PublicQuestion{QuestionID: "q1", Question: "?", Options: []Option{{Letter: "A", Text: "a"}, {Letter: "B", Text: "b"}}}}
+
+	metadata, err := service.CreateQuizFromQuestions(context.Background(), []Question{question})
+	if err != nil {
+		t.Fatalf("CreateQuizFromQuestions failed: %v", err)
+	}
+	if metadata.QuizID != "qz_fixed" || !metadata.CreatedAt.Equal(fixed) {
+		t.Fatalf("metadata = (%s, %v), want (qz_fixed, %v)", metadata.QuizID, metadata.CreatedAt, fixed)
+	}
+}
+
+type fakeAttemptHistoryRepo struct {
+	*fakeAttemptRepo
+	history []Attempt
+}
+
+func (f *fakeAttemptHistoryRepo) ListAttempts(context.Context, string, string) ([]Attempt, error) {
+	return f.history, nil
+}
+
+func TestServiceAdaptiveQuizServesQuestionsInSequence(t *testing.T) {
+	repo := newFakeQuizRepo()
+	pool := []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "e1"}, Difficulty: DifficultyEasy},
+		{PublicQuestion: PublicQuestion{QuestionID: "m1"}, Difficulty: DifficultyMedium},
+		{PublicQuestion: PublicQuestion{QuestionID: "h1"}, Difficulty: DifficultyHard},
+	}
+	repo.metadataByQuiz["adaptive"] = QuizMetadata{QuizID: "adaptive", QuestionCount: len(pool), Adaptive: true}
+	repo.questionsByQuiz["adaptive"] = pool
+	repo.metadataByQuiz["regular"] = QuizMetadata{QuizID: "regular", QuestionCount: len(pool)}
+	repo.questionsByQuiz["regular"] = pool
+	attempts := &fakeAttemptHistoryRepo{fakeAttemptRepo: &fakeAttemptRepo{}}
+	service := NewService(repo, attempts, nil)
+	ctx := context.Background()
+
+	step, err := service.NextAdaptiveQuestion(ctx, "adaptive", "Alice")
+	if err != nil || step.Question.QuestionID != "m1" || step.Target != DifficultyMedium || step.Done {
+		t.Fatalf("first step = (%+v, %v), want m1 at medium", step, err)
+	}
+	if _, err := service.SubmitResponses(ctx, "adaptive", "alice", []SubmittedResponse{{QuestionID: "e1", Answer: "A"}}); !errors.Is(err, ErrOutOfSequence) {
+		t.Fatalf("SubmitResponses(e1) error = (%v), want ErrOutOfSequence", err)
+	}
+	if _, err := service.SubmitResponses(ctx, "adaptive", "alice", []SubmittedResponse{{QuestionID: "m1", Answer: "A"}, {QuestionID: "h1", Answer: "A"}}); !errors.Is(err, ErrOutOfSequence) {
+		t.Fatalf("SubmitResponses(m1, h1) error = (%v), want ErrOutOfSequence", err)
+	}
+	if _, err := service.SubmitResponses(ctx, "adaptive", "alice", []SubmittedResponse{{QuestionID: "m1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses(m1) failed: %v", err)
+	}
+
+	attempts.history = []Attempt{{QuestionID: "m1", Score: 1}}
+	step, err = service.NextAdaptiveQuestion(ctx, "adaptive", "alice")
+	if err != nil || step.Question.QuestionID != "h1" || step.AnsweredCount != 1 || step.CorrectCount != 1 {
+		t.Fatalf("second step = (%+v, %v), want h1 after one correct answer", step, err)
+	}
+
+	attempts.history = []Attempt{{QuestionID: "m1", Score: 1}, {QuestionID: "h1", Score: 0}, {QuestionID: "e1", Score: 1}}
+	step, err = service.NextAdaptiveQuestion(ctx, "adaptive", "alice")
+	if err != nil || !step.Done {
+		t.Fatalf("final step = (%+v, %v), want done", step, err)
+	}
+
+	if _, err := service.NextAdaptiveQuestion(ctx, "regular", "alice"); !errors.Is(err, ErrNotAdaptive) {
+		t.Fatalf("NextAdaptiveQuestion(regular) error = (%v), want ErrNotAdaptive", err)
+	}
+}
+
+func TestServiceLeaderboardStreamResumesFromLastEventID(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 2}
+	repo.questionsByQuiz["quiz-1"] = []Question{{PublicQuestion: PublicQuestion{QuestionID: "q1"}}}
+	attempts := &fakeAttemptRepo{
+		leaderboard:   []LeaderboardEntry{{Username: "bob", TotalScore: 1, AnsweredCount: 1}},
+		submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}},
+	}
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{StreamBufferSize: 2})
+	ctx := context.Background()
+
+	stream, err := service.SubscribeLeaderboard(ctx, "quiz-1", "")
+	if err != nil {
+		t.Fatalf("SubscribeLeaderboard failed: %v", err)
+	}
+	if len(stream.Replay) != 1 || stream.Replay[0].Type != LeaderboardEventSnapshot || stream.Replay[0].Participants != 1 {
+		t.Fatalf("initial replay = (%+v), want one snapshot with bob", stream.Replay)
+	}
+	snapshotID := stream.Replay[0].ID
+
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "Alice", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	delta := <-stream.Events
+	if delta.Type != LeaderboardEventDelta || len(delta.Entries) != 1 || delta.Entries[0].Username != "alice" || delta.Participants != 2 {
+		t.Fatalf("delta = (%+v), want alice's new standing", delta)
+	}
+	stream.Close()
+	stream.Close()
+
+	resumed, err := service.SubscribeLeaderboard(ctx, "quiz-1", snapshotID)
+	if err != nil || len(resumed.Replay) != 1 || resumed.Replay[0].ID != delta.ID {
+		t.Fatalf("resume from snapshot = (%+v, %v), want only the missed delta", resumed.Replay, err)
+	}
+	resumed.Close()
+
+	caughtUp, _ := service.SubscribeLeaderboard(ctx, "quiz-1", delta.ID)
+	if len(caughtUp.Replay) != 0 {
+		t.Fatalf("resume from latest = (%+v), want nothing to replay", caughtUp.Replay)
+	}
+	caughtUp.Close()
+
+	// Two more events push the first delta out of the two-event ring.
+	for _, username := range []string{"carol", "dave"} {
+		if _, err := service.SubmitResponses(ctx, "quiz-1", username, []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", username, err)
+		}
+	}
+	for _, lastEventID := range []string{snapshotID, "stale-epoch-1", "garbage"} {
+		stale, err := service.SubscribeLeaderboard(ctx, "quiz-1", lastEventID)
+		if err != nil || len(stale.Replay) != 1 || stale.Replay[0].Type != LeaderboardEventSnapshot || stale.Replay[0].Participants != 4 {
+			t.Fatalf("resume from %q = (%+v, %v), want a fresh snapshot", lastEventID, stale.Replay, err)
+		}
+		stale.Close()
+	}
+}
+
+func TestServiceCoalescesLeaderboardNotificationsPerInterval(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1}
+	repo.questionsByQuiz["quiz-1"] = []Question{{PublicQuestion: PublicQuestion{QuestionID: "q1"}}}
+	attempts := &fakeAttemptRepo{
+		leaderboard:   []LeaderboardEntry{{Username: "bob", TotalScore: 1, AnsweredCount: 1}},
+		submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}},
+	}
+	webhooks := make(chan CompletionEvent, 8)
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{
+		LeaderboardNotifyInterval: 20 * time.Millisecond,
+		CompletionNotifier: func(_ string, event CompletionEvent) {
+			webhooks <- event
+		},
+	})
+	ctx := context.Background()
+
+	if _, err := service.WatchCompletion(ctx, "quiz-1", CompletionWatch{URL: "http://hooks.test/board", LeaderboardChanges: true}); err != nil {
+		t.Fatalf("WatchCompletion failed: %v", err)
+	}
+	stream, err := service.SubscribeLeaderboard(ctx, "quiz-1", "")
+	if err != nil {
+		t.Fatalf("SubscribeLeaderboard failed: %v", err)
+	}
+	defer stream.Close()
+
+	for _, username := range []string{"alice", "carol", "dave"} {
+		if _, err := service.SubmitResponses(ctx, "quiz-1", username, []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", username, err)
+		}
+	}
+
+	// The burst arrives as one snapshot and one webhook with all four players.
+	select {
+	case event := <-stream.Events:
+		if event.Type != LeaderboardEventSnapshot || event.Participants != 4 {
+			t.Fatalf("coalesced stream event = (%+v), want a snapshot of 4 players", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no stream event after the notify interval")
+	}
+	select {
+	case event := <-webhooks:
+		if event.Event != EventLeaderboardChanged || event.Participants != 4 || len(event.Leaderboard) != 4 || event.Leaderboard[0].Rank != 1 {
+			t.Fatalf("coalesced webhook = (%+v), want the standings of 4 players", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no webhook after the notify interval")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if len(stream.Events) != 0 || len(webhooks) != 0 {
+		t.Fatalf("extra notifications after the burst: %d stream events, %d webhooks", len(stream.Events), len(webhooks))
+	}
+}
+
+func TestServiceFlushNotificationsSendsPendingLeaderboardWebhooks(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1}
+	repo.questionsByQuiz["quiz-1"] = []Question{{PublicQuestion: PublicQuestion{QuestionID: "q1"}}}
+	attempts := &fakeAttemptRepo{
+		leaderboard:   []LeaderboardEntry{{Username: "bob", TotalScore: 1, AnsweredCount: 1}},
+		submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}},
+	}
+	webhooks := make(chan CompletionEvent, 8)
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{
+		LeaderboardNotifyInterval: time.Hour,
+		CompletionNotifier: func(_ string, event CompletionEvent) {
+			webhooks <- event
+		},
+	})
+	ctx := context.Background()
+	if _, err := service.WatchCompletion(ctx, "quiz-1", CompletionWatch{URL: "http://hooks.test/board", LeaderboardChanges: true}); err != nil {
+		t.Fatalf("WatchCompletion failed: %v", err)
+	}
+	submit := func(username string) {
+		if _, err := service.SubmitResponses(ctx, "quiz-1", username, []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", username, err)
+		}
+	}
+
+	submit("alice")
+	service.CloseLeaderboardStreams()
+	if len(webhooks) != 0 {
+		t.Fatalf("webhooks = %d before the interval, want none", len(webhooks))
+	}
+	// While draining, changes are not held for an interval that will not end.
+	submit("carol")
+	if len(webhooks) != 1 {
+		t.Fatalf("webhooks = %d after a submission during shutdown, want 1", len(webhooks))
+	}
+	<-webhooks
+
+	service.FlushNotifications(ctx)
+	service.FlushNotifications(ctx)
+	if len(webhooks) != 1 {
+		t.Fatalf("webhooks = %d after flushing, want the pending one once", len(webhooks))
+	}
+	if event := <-webhooks; event.Event != EventLeaderboardChanged || len(event.Leaderboard) == 0 {
+		t.Fatalf("flushed webhook = %+v, want the standings", event)
+	}
+}
+
+func TestServiceCloseLeaderboardStreamsSendsClosingEvent(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1}
+	repo.questionsByQuiz["quiz-1"] = []Question{{PublicQuestion: PublicQuestion{QuestionID: "q1"}}}
+	attempts := &fakeAttemptRepo{submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}}}
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{})
+	ctx := context.Background()
+
+	stream, err := service.SubscribeLeaderboard(ctx, "quiz-1", "")
+	if err != nil {
+		t.Fatalf("SubscribeLeaderboard failed: %v", err)
+	}
+	service.CloseLeaderboardStreams()
+	service.CloseLeaderboardStreams()
+
+	closing, ok := <-stream.Events
+	if !ok || closing.Type != LeaderboardEventClosing || closing.QuizID != "quiz-1" || closing.ID != "" {
+		t.Fatalf("last event = (%+v, %t), want a closing event without an ID", closing, ok)
+	}
+	if _, ok := <-stream.Events; ok {
+		t.Fatal("Events still open after the closing event")
+	}
+	stream.Close()
+
+	// Submissions still work; they just have nobody to tell.
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "alice", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses after close failed: %v", err)
+	}
+	if _, err := service.SubscribeLeaderboard(ctx, "quiz-1", ""); !errors.Is(err, ErrStreamsClosed) {
+		t.Fatalf("SubscribeLeaderboard after close error = (%v), want ErrStreamsClosed", err)
+	}
+}
+
+func TestServiceSubscribeSubmissionsStreamsScoreDeltasAndTotals(t *testing.T) {
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 2}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "q1"}},
+		{PublicQuestion: PublicQuestion{QuestionID: "q2"}},
+	}
+	attempts := &fakeAttemptRepo{
+		submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect, Bonus: 0.5}},
+		leaderboard:   []LeaderboardEntry{{Username: "bob", TotalScore: 2, AnsweredCount: 2}},
+	}
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{})
+	ctx := context.Background()
+
+	stream, err := service.SubscribeSubmissions(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("SubscribeSubmissions failed: %v", err)
+	}
+	snapshot := stream.Snapshot
+	if snapshot.Type != SubmissionEventSnapshot || len(snapshot.Players) != 1 || !snapshot.Players[0].Finished ||
+		snapshot.QuestionCount != 2 || snapshot.Participants != 1 || snapshot.FinishedCount != 1 {
+		t.Fatalf("snapshot = %+v, want bob finished out of one participant", snapshot)
+	}
+
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "Alice", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	event := <-stream.Events
+	want := QuizSubmission{
+		PlayerProgress: PlayerProgress{Username: "alice", TotalScore: 1.5, AnsweredCount: 1},
+		Answered:       1,
+		ScoreDelta:     1.5,
+	}
+	if event.Type != SubmissionEventSubmission || event.Submission == nil || *event.Submission != want ||
+		event.Participants != 2 || event.FinishedCount != 1 || event.Players != nil {
+		t.Fatalf("submission event = %+v (%+v), want alice's first answer with two participants", event, event.Submission)
+	}
+
+	service.CloseSubmissionStreams()
+	service.CloseSubmissionStreams()
+	if closing, ok := <-stream.Events; !ok || closing.Type != SubmissionEventClosing || closing.QuizID != "quiz-1" {
+		t.Fatalf("last event = (%+v, %t), want a closing event", closing, ok)
+	}
+	if _, ok := <-stream.Events; ok {
+		t.Fatal("Events still open after the closing event")
+	}
+	stream.Close()
+	if _, err := service.SubscribeSubmissions(ctx, "quiz-1"); !errors.Is(err, ErrStreamsClosed) {
+		t.Fatalf("SubscribeSubmissions after close error = (%v), want ErrStreamsClosed", err)
+	}
+}
+
+func TestServiceSubmitResponsesReservesQuestionsChangedSinceServing(t *testing.T) {
+	ctx := context.Background()
+	question := func(correctIndex int) Question {
+		return Question{
+			PublicQuestion: PublicQuestion{
+				QuestionID: "q1",
+				Question:   "Capital of Australia?",
+				Options:    []Option{{Letter: "A", Text: "Sydney"}, {Letter: "B", Text: "Canberra"}},
+			},
+			CorrectIndex: correctIndex,
+		}
+	}
+	other := Question{
+		PublicQuestion: PublicQuestion{
+			QuestionID: "q2",
+			Question:   "2+2?",
+			Options:    []Option{{Letter: "A", Text: "4"}, {Letter: "B", Text: "5"}},
+		},
+	}
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	repo.questionsByQuiz["quiz-1"] = []Question{question(0), other}
+	attempts := &fakeAttemptRepo{submitResults: []ResponseResult{{QuestionID: "q2", Status: StatusCorrect}}}
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{ContentHashKey: []byte("test-key")})
+
+	_, served, err := service.GetQuizQuestions(ctx, "quiz-1", false, 0)
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	servedHash := service.ContentHash(served[0])
+	if servedHash == service.ContentHash(question(1)) {
+		t.Fatalf("ContentHash ignores the answer key")
+	}
+
+	// The answer key is corrected after the question was served.
+	repo.questionsByQuiz["quiz-1"] = []Question{question(1), other}
+	results, err := service.SubmitResponses(ctx, "quiz-1", "alice", []SubmittedResponse{
+		{QuestionID: "q1", Answer: "B", ContentHash: servedHash},
+		{QuestionID: "q2", Answer: "A", ContentHash: service.ContentHash(other)},
+	})
+	if err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Status != StatusStaleQuestion || results[1].Status != StatusCorrect {
+		t.Fatalf("SubmitResponses = (%+v), want [stale_question correct]", results)
+	}
+	if results[0].Question == nil || results[0].ContentHash != service.ContentHash(question(1)) {
+		t.Fatalf("stale result = (%+v), want the corrected question re-served", results[0])
+	}
+	if _, questions, _ := service.GetQuizQuestions(ctx, "quiz-1", false, 0); questions[0].CorrectIndex != 1 {
+		t.Fatalf("GetQuizQuestions after stale answer = (%+v), want the corrected copy", questions[0])
+	}
+
+	strict := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{RequireContentHash: true})
+	calls := attempts.submitCalls
+	results, err = strict.SubmitResponses(ctx, "quiz-1", "alice", []SubmittedResponse{{QuestionID: "q1", Answer: "B"}})
+	if err != nil {
+		t.Fatalf("strict SubmitResponses failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Status != StatusStaleQuestion || attempts.submitCalls != calls {
+		t.Fatalf("strict SubmitResponses = (%+v, %d store calls), want stale_question and nothing stored", results, attempts.submitCalls-calls)
+	}
+}
+
+type fakeProfileAttemptRepo struct {
+	*fakeAttemptRepo
+	profiles map[string]UserProfile
+}
+
+func (f *fakeProfileAttemptRepo) GetProfile(_ context.Context, usernameNormalized string) (UserProfile, error) {
+	if profile, ok := f.profiles[usernameNormalized]; ok {
+		return profile, nil
+	}
+	return UserProfile{Username: usernameNormalized}, nil
+}
+
+func (f *fakeProfileAttemptRepo) SaveProfile(_ context.Context, profile UserProfile) error {
+	f.profiles[profile.Username] = profile
+	return nil
+}
+
+func (f *fakeProfileAttemptRepo) AnonymousUsers(_ context.Context, usernames []string) (map[string]bool, error) {
+	anonymous := make(map[string]bool)
+	for _, username := range usernames {
+		if f.profiles[username].Anonymous {
+			anonymous[username] = true
+		}
+	}
+	return anonymous, nil
+}
+
+func TestServicePublicLeaderboardMasksAnonymousUsers(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	repo.metadataByQuiz["quiz-2"] = QuizMetadata{QuizID: "quiz-2"}
+	attempts := &fakeProfileAttemptRepo{
+		fakeAttemptRepo: &fakeAttemptRepo{leaderboard: []LeaderboardEntry{
+			{Username: "alice", TotalScore: 3},
+			{Username: "bob", TotalScore: 2},
+		}},
+		profiles: make(map[string]UserProfile),
+	}
+	service := NewService(repo, attempts, nil)
+
+	profile, err := service.SetAnonymous(ctx, " Alice ", true)
+	if err != nil || profile.Username != "alice" || !profile.Anonymous {
+		t.Fatalf("SetAnonymous = (%+v, %v), want alice anonymous", profile, err)
+	}
+
+	board, err := service.GetPublicLeaderboard(ctx, "quiz-1", 0)
+	if err != nil {
+		t.Fatalf("GetPublicLeaderboard failed: %v", err)
+	}
+	entries := board.Entries
+	if len(entries) != 2 || !entries[0].Anonymous || !strings.HasPrefix(entries[0].Username, anonymousPrefix) || entries[0].Rank != 1 || entries[0].TotalScore != 3 {
+		t.Fatalf("entries[0] = (%+v), want alice masked at rank 1 with her score", entries[0])
+	}
+	if entries[1].Username != "bob" || entries[1].Anonymous {
+		t.Fatalf("entries[1] = (%+v), want bob shown", entries[1])
+	}
+	if raw, _ := service.GetLeaderboard(ctx, "quiz-1", 0); raw[0].Username != "alice" {
+		t.Fatalf("GetLeaderboard = (%+v), want the unmasked cache left intact", raw)
+	}
+
+	again, _ := service.GetPublicLeaderboard(ctx, "quiz-1", 0)
+	other, _ := service.GetPublicLeaderboard(ctx, "quiz-2", 0)
+	if again.Entries[0].Username != entries[0].Username || other.Entries[0].Username == entries[0].Username {
+		t.Fatalf("pseudonyms = (%q, %q, %q), want stable within a quiz and different across quizzes", entries[0].Username, again.Entries[0].Username, other.Entries[0].Username)
+	}
+}
+
+type fakeStreakAttemptRepo struct {
+	*fakeAttemptRepo
+	streaks map[string]Streak
+}
+
+func (f *fakeStreakAttemptRepo) GetStreak(_ context.Context, usernameNormalized string) (Streak, error) {
+	streak := f.streaks[usernameNormalized]
+	streak.Username = usernameNormalized
+	return streak, nil
+}
+
+func (f *fakeStreakAttemptRepo) RecordStreakDay(_ context.Context, usernameNormalized, day string, recordedAt time.Time) (Streak, error) {
+	streak := f.streaks[usernameNormalized].Advance(day)
+	streak.Username = usernameNormalized
+	streak.UpdatedAt = recordedAt
+	f.streaks[usernameNormalized] = streak
+	return streak, nil
+}
+
+func TestServiceStreaksFollowConfiguredDayBoundary(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	repo.metadataByQuiz["quiz-2"] = QuizMetadata{QuizID: "quiz-2"}
+	attempts := &fakeStreakAttemptRepo{
+		fakeAttemptRepo: &fakeAttemptRepo{submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}}},
+		streaks:         make(map[string]Streak),
+	}
+	// 03:00 UTC on March 1 is still February 29 five hours west of UTC.
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	service := New(Config{Quizzes: repo, Attempts: attempts, ServiceOptions: ServiceOptions{
+		Now:            func() time.Time { return now },
+		StreakLocation: time.FixedZone("UTC-5", -5*60*60),
+	}})
+	submit := func(quizID string) {
+		t.Helper()
+		if _, err := service.SubmitResponses(ctx, quizID, "Alice", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", quizID, err)
+		}
+	}
+
+	submit("quiz-1")
+	now = time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	submit("quiz-2")
+	streak, err := service.GetStreak(ctx, "alice")
+	if err != nil || streak.Current != 2 || streak.Longest != 2 || streak.LastDay != "2024-03-01" {
+		t.Fatalf("GetStreak = (%+v, %v), want a 2-day streak through 2024-03-01", streak, err)
+	}
+
+	// Unscored answers do not count as playing.
+	attempts.submitResults = []ResponseResult{{QuestionID: "q1", Status: StatusAlreadyAnswered}}
+	now = time.Date(2024, 3, 2, 20, 0, 0, 0, time.UTC)
+	submit("quiz-1")
+	if streak, _ := service.GetStreak(ctx, "alice"); streak.LastDay != "2024-03-01" || streak.Current != 2 {
+		t.Fatalf("GetStreak after unscored answers = %+v, want unchanged and still alive", streak)
+	}
+
+	now = time.Date(2024, 3, 3, 6, 0, 0, 0, time.UTC)
+	streak, err = service.GetStreak(ctx, "alice")
+	if err != nil || streak.Current != 0 || streak.Longest != 2 {
+		t.Fatalf("GetStreak after a missed day = (%+v, %v), want current 0 and longest 2", streak, err)
+	}
+}
+
+type fakeSigningQuizRepo struct {
+	*fakeQuizRepo
+	keys map[string]SigningKey
+}
+
+func (f *fakeSigningQuizRepo) SaveSigningKey(_ context.Context, key SigningKey) error {
+	if _, ok := f.keys[key.Username+"/"+key.KeyID]; !ok {
+		f.keys[key.Username+"/"+key.KeyID] = key
+	}
+	return nil
+}
+
+func (f *fakeSigningQuizRepo) GetSigningKey(_ context.Context, usernameNormalized, keyID string) (SigningKey, error) {
+	return f.keys[usernameNormalized+"/"+keyID], nil
+}
+
+func TestServiceVerifiesSignedOfflineAnswers(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeSigningQuizRepo{fakeQuizRepo: newFakeQuizRepo(), keys: make(map[string]SigningKey)}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	attempts := &fakeAttemptRepo{submitResults: []ResponseResult{
+		{QuestionID: "q1", Status: StatusCorrect},
+		{QuestionID: "q5", Status: StatusIncorrect},
+	}}
+	registeredAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := registeredAt
+	service := New(Config{Quizzes: repo, Attempts: attempts, ServiceOptions: ServiceOptions{
+		Now:               func() time.Time { return now },
+		OfflineSyncWindow: 6 * time.Hour,
+	}})
+
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	publicKey := base64.StdEncoding.EncodeToString(private.Public().(ed25519.PublicKey))
+	key, created, err := service.RegisterSigningKey(ctx, "Alice", publicKey)
+	if err != nil || !created || key.Username != "alice" || key.KeyID != SigningKeyID(private.Public().(ed25519.PublicKey)) {
+		t.Fatalf("RegisterSigningKey = (%+v, %v, %v), want a new key for alice", key, created, err)
+	}
+	now = registeredAt.Add(time.Hour)
+	if again, created, err := service.RegisterSigningKey(ctx, "alice", publicKey); err != nil || created || !again.RegisteredAt.Equal(registeredAt) {
+		t.Fatalf("RegisterSigningKey(again) = (%+v, %v, %v), want the first registration", again, created, err)
+	}
+	if _, _, err := service.RegisterSigningKey(ctx, "alice", "not-a-key"); !errors.Is(err, ErrInvalidSigningKey) {
+		t.Fatalf("RegisterSigningKey(bad key) error = %v, want ErrInvalidSigningKey", err)
+	}
+
+	sign := func(questionID string, answeredAt time.Time) SubmittedResponse {
+		return SignAnswer(private, "quiz-1", "alice", SubmittedResponse{QuestionID: questionID, Answer: "A"}, answeredAt)
+	}
+	tampered := sign("q2", registeredAt.Add(time.Minute))
+	tampered.Answer = "B"
+	now = registeredAt.Add(5 * time.Hour)
+	results, err := service.SubmitResponses(ctx, "quiz-1", "Alice", []SubmittedResponse{
+		sign("q1", registeredAt.Add(time.Minute)),
+		tampered,
+		sign("q3", registeredAt.Add(-time.Hour)),
+		sign("q4", now.Add(time.Hour)),
+		{QuestionID: "q5", Answer: "C"},
+	})
+	if err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	want := []string{StatusCorrect, StatusInvalidSignature, StatusOutsideWindow, StatusOutsideWindow, StatusIncorrect}
+	if len(results) != len(want) {
+		t.Fatalf("SubmitResponses = %+v, want %d results", results, len(want))
+	}
+	for idx, status := range want {
+		if results[idx].Status != status {
+			t.Fatalf("result %d = %+v, want %s", idx, results[idx], status)
+		}
+	}
+	if stored := attempts.lastSubmitResponses; len(stored) != 2 || stored[0].QuestionID != "q1" || stored[1].QuestionID != "q5" {
+		t.Fatalf("stored responses = %+v, want only the verified and the unsigned answer", stored)
+	}
+
+	// Past the sync window, even a well-signed answer is refused.
+	now = registeredAt.Add(7 * time.Hour)
+	late, err := service.SubmitResponses(ctx, "quiz-1", "alice", []SubmittedResponse{sign("q6", registeredAt.Add(time.Minute))})
+	if err != nil || len(late) != 1 || late[0].Status != StatusOutsideWindow {
+		t.Fatalf("SubmitResponses(late) = (%+v, %v), want outside_window", late, err)
+	}
+}
+
+func TestServiceTimesSignedAnswerBonusToReceipt(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeSigningQuizRepo{fakeQuizRepo: newFakeQuizRepo(), keys: make(map[string]SigningKey)}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1, Adaptive: true}
+	repo.questionsByQuiz["quiz-1"] = []Question{{PublicQuestion: PublicQuestion{QuestionID: "q1"}}}
+	attempts := &fakeServingAttemptRepo{fakeAttemptRepo: &fakeAttemptRepo{}, served: map[string]time.Time{}}
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	service := New(Config{Quizzes: repo, Attempts: attempts, ServiceOptions: ServiceOptions{
+		Now:        func() time.Time { return now },
+		SpeedBonus: SpeedBonus{MaxPoints: 1, Window: 10 * time.Second, Curve: BonusLinear},
+	}})
+
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	if _, _, err := service.RegisterSigningKey(ctx, "alice", base64.StdEncoding.EncodeToString(private.Public().(ed25519.PublicKey))); err != nil {
+		t.Fatalf("RegisterSigningKey failed: %v", err)
+	}
+	servedAt := now
+	if _, err := service.NextAdaptiveQuestion(ctx, "quiz-1", "alice"); err != nil {
+		t.Fatalf("NextAdaptiveQuestion failed: %v", err)
+	}
+
+	// Signed as answered before the serve, inside the tolerated clock skew,
+	// but received after the bonus window closed.
+	now = servedAt.Add(time.Minute)
+	backdated := SignAnswer(private, "quiz-1", "alice", SubmittedResponse{QuestionID: "q1", Answer: "A"}, servedAt.Add(-30*time.Second))
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "alice", []SubmittedResponse{backdated}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	if len(attempts.submitted) != 1 || attempts.submitted[0].Bonus != 0 {
+		t.Fatalf("stored responses = %+v, want the backdated answer accepted without a bonus", attempts.submitted)
+	}
+}
+
+type fakeLifecycleQuizRepo struct {
+	*fakeQuizRepo
+}
+
+func (f *fakeLifecycleQuizRepo) SetQuizLifecycle(_ context.Context, quizID string, lifecycle QuizLifecycle) error {
+	metadata, ok := f.metadataByQuiz[quizID]
+	if !ok {
+		return ErrQuizNotFound
+	}
+	metadata.Draft, metadata.Locked, metadata.ClosesAt = lifecycle.Draft, lifecycle.Locked, lifecycle.ClosesAt
+	f.metadataByQuiz[quizID] = metadata
+	return nil
+}
+
+func TestServiceQuizLifecycleGatesAnswers(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeLifecycleQuizRepo{fakeQuizRepo: newFakeQuizRepo()}
+	attempts := &fakeAttemptRepo{submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}}}
+	now := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+	service := New(Config{Quizzes: repo, Attempts: attempts, ServiceOptions: ServiceOptions{Now: func() time.Time { return now }}})
+	questions := []Question{{PublicQuestion: PublicQuestion{QuestionID: "q1", Question: "2+2?", Options: []Option{{Letter: "A", Text: "4"}, {Letter: "B", Text: "3"}}}}}
+	answer := []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}
+
+	if _, err := service.CreateCustomQuiz(ctx, questions, CustomQuizOptions{QuizOptions: QuizOptions{ClosesAt: now}}); !errors.Is(err, ErrInvalidQuizState) {
+		t.Fatalf("CreateCustomQuiz closing now error = %v, want ErrInvalidQuizState", err)
+	}
+	closesAt := now.Add(time.Hour)
+	metadata, err := service.CreateCustomQuiz(ctx, questions, CustomQuizOptions{QuizOptions: QuizOptions{Draft: true, ClosesAt: closesAt}})
+	if err != nil || metadata.State(now) != QuizStateDraft || !metadata.ClosesAt.Equal(closesAt) {
+		t.Fatalf("CreateCustomQuiz = (%+v, %v), want a draft closing in an hour", metadata, err)
+	}
+	quizID := metadata.QuizID
+	if _, err := service.SubmitResponses(ctx, quizID, "alice", answer); !errors.Is(err, ErrQuizClosed) {
+		t.Fatalf("SubmitResponses(draft) error = %v, want ErrQuizClosed", err)
+	}
+
+	if metadata, err = service.SetQuizState(ctx, quizID, QuizStateChange{State: QuizStateActive}); err != nil || metadata.State(now) != QuizStateActive {
+		t.Fatalf("SetQuizState(active) = (%+v, %v), want active", metadata, err)
+	}
+	if _, err := service.SubmitResponses(ctx, quizID, "alice", answer); err != nil || attempts.submitCalls != 1 {
+		t.Fatalf("SubmitResponses(active) error = %v after %d store calls, want it stored", err, attempts.submitCalls)
+	}
+
+	// Past the deadline only a fully signed batch gets through to signature
+	// checks; this one has no registered key.
+	now = closesAt
+	if _, err := service.SubmitResponses(ctx, quizID, "alice", answer); !errors.Is(err, ErrQuizClosed) {
+		t.Fatalf("SubmitResponses(expired) error = %v, want ErrQuizClosed", err)
+	}
+	answeredAt := closesAt.Add(-time.Minute)
+	results, err := service.SubmitResponses(ctx, quizID, "alice", []SubmittedResponse{{QuestionID: "q1", Answer: "A", AnsweredAt: &answeredAt, KeyID: "key", Signature: "sig"}})
+	if err != nil || len(results) != 1 || results[0].Status != StatusInvalidSignature {
+		t.Fatalf("SubmitResponses(expired, signed) = (%+v, %v), want a signature check", results, err)
+	}
+	if _, err := service.SetQuizState(ctx, quizID, QuizStateChange{ClosesAt: &closesAt}); !errors.Is(err, ErrInvalidQuizState) {
+		t.Fatalf("SetQuizState(past deadline) error = %v, want ErrInvalidQuizState", err)
+	}
+	reopened := now.Add(time.Hour)
+	if metadata, err = service.SetQuizState(ctx, quizID, QuizStateChange{ClosesAt: &reopened}); err != nil || metadata.State(now) != QuizStateActive {
+		t.Fatalf("SetQuizState(new deadline) = (%+v, %v), want active again", metadata, err)
+	}
+
+	if metadata, err = service.SetQuizState(ctx, quizID, QuizStateChange{State: QuizStateLocked}); err != nil || metadata.State(now) != QuizStateLocked {
+		t.Fatalf("SetQuizState(locked) = (%+v, %v), want locked", metadata, err)
+	}
+	if _, err := service.SubmitResponses(ctx, quizID, "alice", answer); !errors.Is(err, ErrQuizClosed) {
+		t.Fatalf("SubmitResponses(locked) error = %v, want ErrQuizClosed", err)
+	}
+	if _, err := service.SetQuizState(ctx, quizID, QuizStateChange{State: QuizStateActive}); !errors.Is(err, ErrQuizLocked) {
+		t.Fatalf("SetQuizState after locking error = %v, want ErrQuizLocked", err)
+	}
+	if _, err := service.SetQuizState(ctx, quizID, QuizStateChange{State: QuizStateExpired}); !errors.Is(err, ErrQuizLocked) {
+		t.Fatalf("SetQuizState(expired) on a locked quiz error = %v, want ErrQuizLocked", err)
+	}
+
+	plain := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, nil)
+	if _, err := plain.CreateCustomQuiz(ctx, questions, CustomQuizOptions{QuizOptions: QuizOptions{Draft: true}}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("CreateCustomQuiz(draft) without a lifecycle store error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestParseQuizState(t *testing.T) {
+	if state, err := ParseQuizState(" Locked "); err != nil || state != QuizStateLocked {
+		t.Fatalf("ParseQuizState(Locked) = (%q, %v), want locked", state, err)
+	}
+	for _, value := range []string{"", "expired", "closed"} {
+		if _, err := ParseQuizState(value); !errors.Is(err, ErrInvalidQuizState) {
+			t.Fatalf("ParseQuizState(%q) error = %v, want ErrInvalidQuizState", value, err)
+		}
+	}
+}
+
+func TestParseDifficultyMix(t *testing.T) {
+	mix, err := ParseDifficultyMix(map[string]int{"Easy": 4, "medium": 0, "hard": 2})
+	if err != nil {
+		t.Fatalf("ParseDifficultyMix failed: %v", err)
+	}
+	if len(mix) != 2 || mix[DifficultyEasy] != 4 || mix[DifficultyHard] != 2 || mix.Total() != 6 {
+		t.Fatalf("mix = %v, want easy 4 and hard 2", mix)
+	}
+
+	for _, counts := range []map[string]int{
+		{"easy": 1, "brutal": 1},
+		{"easy": -1, "hard": 2},
+		{"easy": 1, "EASY": 1},
+		{"easy": 0},
+		{},
+	} {
+		if _, err := ParseDifficultyMix(counts); !errors.Is(err, ErrInvalidDifficultyMix) {
+			t.Fatalf("ParseDifficultyMix(%v) error = %v, want ErrInvalidDifficultyMix", counts, err)
+		}
+	}
+}
+
+func TestServiceCreateMixedQuizFillsBucketsAndReportsShortfall(t *testing.T) {
+	repo := newFakeQuizRepo()
+	fetchCalls := 0
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		fetchCalls++
+		return []opentdb.RawQuestion{
+			{Question: "Easy one?", Difficulty: "easy", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Easy two?", Difficulty: "easy", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Easy three?", Difficulty: "easy", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Untagged?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Hard one?", Difficulty: "hard", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		}, nil
+	}
+	service := NewService(repo, &fakeAttemptRepo{}, fetcher)
+
+	mix := DifficultyMix{DifficultyEasy: 2, DifficultyHard: 2}
+	metadata, buckets, err := service.CreateMixedQuiz(context.Background(), mix, QuizOptions{})
+	if err != nil {
+		t.Fatalf("CreateMixedQuiz failed: %v", err)
+	}
+	if metadata.QuestionCount != 3 || metadata.RequestedQuestionCount != 4 {
+		t.Fatalf("metadata = %+v, want 3 of 4 requested questions", metadata)
+	}
+	want := []DifficultyBucket{
+		{Difficulty: DifficultyEasy, Requested: 2, Delivered: 2},
+		{Difficulty: DifficultyHard, Requested: 2, Delivered: 1},
+	}
+	if len(buckets) != len(want) || buckets[0] != want[0] || buckets[1] != want[1] {
+		t.Fatalf("buckets = %+v, want %+v", buckets, want)
+	}
+	if buckets[1].Shortfall() != 1 {
+		t.Fatalf("hard shortfall = %d, want 1", buckets[1].Shortfall())
+	}
+	// The repeated hard question never fills the bucket, so every round runs.
+	if fetchCalls != mixMaxFetchRounds {
+		t.Fatalf("fetchCalls = %d, want %d", fetchCalls, mixMaxFetchRounds)
+	}
+	for _, question := range repo.questionsByQuiz[metadata.QuizID] {
+		if question.Difficulty == "" {
+			t.Fatalf("stored questions include untagged %+v", question)
+		}
+	}
+}
+
+type fakePreferenceRepo struct {
+	*fakeAttemptRepo
+	saved map[string]UserPreferences
+}
+
+func (f *fakePreferenceRepo) GetPreferences(_ context.Context, usernameNormalized string) (UserPreferences, error) {
+	if preferences, ok := f.saved[usernameNormalized]; ok {
+		return preferences, nil
+	}
+	return UserPreferences{Username: usernameNormalized}, nil
+}
+
+func (f *fakePreferenceRepo) SavePreferences(_ context.Context, preferences UserPreferences) error {
+	f.saved[preferences.Username] = preferences
+	return nil
+}
+
+func TestServicePreferencesPickDifficultyOfCreatedQuiz(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeQuizRepo()
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return []opentdb.RawQuestion{
+			{Question: "Easy one?", Difficulty: "easy", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Hard one?", Difficulty: "hard", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Hard two?", Difficulty: "hard", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		}, nil
+	}
+	attempts := &fakePreferenceRepo{fakeAttemptRepo: &fakeAttemptRepo{}, saved: make(map[string]UserPreferences)}
+	service := NewService(repo, attempts, fetcher)
+
+	for _, invalid := range []UserPreferences{{QuestionCount: -1}, {Difficulty: "brutal"}} {
+		if _, err := service.SetPreferences(ctx, "alice", invalid); !errors.Is(err, ErrInvalidPreferences) {
+			t.Fatalf("SetPreferences(%+v) error = (%v), want ErrInvalidPreferences", invalid, err)
+		}
+	}
+	if _, err := service.SetPreferences(ctx, " Alice ", UserPreferences{QuestionCount: 2, Difficulty: "HARD"}); err != nil {
+		t.Fatalf("SetPreferences failed: %v", err)
+	}
+	preferences, err := service.CreatorPreferences(ctx, "alice")
+	if err != nil || preferences.QuestionCount != 2 || preferences.Difficulty != DifficultyHard || preferences.UpdatedAt.IsZero() {
+		t.Fatalf("CreatorPreferences = (%+v, %v), want 2 hard questions", preferences, err)
+	}
+	if preferences, err := service.CreatorPreferences(ctx, ""); err != nil || preferences.QuestionCount != 0 {
+		t.Fatalf("CreatorPreferences(anonymous) = (%+v, %v), want none", preferences, err)
+	}
+
+	metadata, questions, created, err := service.GetOrCreateQuizQuestionsWithOptions(ctx, "alices-quiz", preferences.QuestionCount, QuizOptions{Difficulty: preferences.Difficulty})
+	if err != nil || !created {
+		t.Fatalf("GetOrCreateQuizQuestionsWithOptions = (%t, %v), want a new quiz", created, err)
+	}
+	if len(questions) != 2 || metadata.Origin.DifficultyMix[DifficultyHard] != 2 {
+		t.Fatalf("created %d questions with origin %+v, want 2 hard ones", len(questions), metadata.Origin)
+	}
+	for _, question := range questions {
+		if question.Difficulty != DifficultyHard {
+			t.Fatalf("created quiz includes %s question %q", question.Difficulty, question.Question)
+		}
+	}
+
+	// Stores without preferences create quizzes as before.
+	plain := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, fetcher)
+	if preferences, err := plain.CreatorPreferences(ctx, "alice"); err != nil || preferences.Difficulty != "" {
+		t.Fatalf("CreatorPreferences without a store = (%+v, %v), want none", preferences, err)
+	}
+	if _, err := plain.GetPreferences(ctx, "alice"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("GetPreferences without a store error = (%v), want ErrUnsupported", err)
+	}
+}
+
+func TestServiceRematchReusesRecordedOrigin(t *testing.T) {
+	repo := newFakeQuizRepo()
+	fetchCalls := 0
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		fetchCalls++
+		return []opentdb.RawQuestion{
+			{Question: fmt.Sprintf("Easy %d?", fetchCalls), Difficulty: "easy", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: fmt.Sprintf("Hard %d?", fetchCalls), Difficulty: "hard", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		}, nil
+	}
+	service := New(Config{Quizzes: repo, Attempts: &fakeAttemptRepo{}, Fetcher: fetcher, ServiceOptions: ServiceOptions{
+		ProviderName: func() string { return "bundles:tech" },
+		NewSeed:      func() int64 { return 42 },
+	}})
+	ctx := context.Background()
+
+	mix := DifficultyMix{DifficultyEasy: 1, DifficultyHard: 1}
+	original, _, err := service.CreateMixedQuiz(ctx, mix, QuizOptions{QuestionTimeLimit: 20 * time.Second})
+	if err != nil {
+		t.Fatalf("CreateMixedQuiz failed: %v", err)
+	}
+	if original.Origin.Provider != "bundles:tech" || original.Origin.DifficultyMix[DifficultyHard] != 1 {
+		t.Fatalf("Origin = %+v, want the provider and mix recorded", original.Origin)
+	}
+	if original.Origin.Seed != 42 {
+		t.Fatalf("Origin.Seed = %d, want the seed the options were shuffled with", original.Origin.Seed)
+	}
+	firstBatch := []opentdb.RawQuestion{
+		{Question: "Easy 1?", Difficulty: "easy", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		{Question: "Hard 1?", Difficulty: "hard", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+	}
+	for idx, question := range NewSeededQuestionBuilder(42).Build(firstBatch) {
+		if stored := repo.questionsByQuiz[original.QuizID][idx]; stored.QuestionID != question.QuestionID {
+			t.Fatalf("stored question %d = %s, want %s rebuilt from the recorded seed", idx, stored.QuestionID, question.QuestionID)
+		}
+	}
+
+	fresh, questions, err := service.Rematch(ctx, original.QuizID, "")
+	if err != nil {
+		t.Fatalf("Rematch(fresh) failed: %v", err)
+	}
+	if fresh.QuizID == original.QuizID || fresh.QuestionTimeLimit != 20*time.Second || fresh.Origin.DifficultyMix[DifficultyEasy] != 1 {
+		t.Fatalf("fresh rematch = %+v, want a new quiz with the original settings", fresh)
+	}
+	if len(questions) != 2 || questions[0].Question == repo.questionsByQuiz[original.QuizID][0].Question {
+		t.Fatalf("fresh rematch questions = %+v, want newly fetched ones", questions)
+	}
+
+	same, copied, err := service.Rematch(ctx, original.QuizID, RematchSame)
+	if err != nil {
+		t.Fatalf("Rematch(same) failed: %v", err)
+	}
+	if len(copied) != 2 || copied[0].Question != repo.questionsByQuiz[original.QuizID][0].Question || same.Origin.Provider != "bundles:tech" {
+		t.Fatalf("same rematch = (%+v, %+v), want the original questions and origin", same, copied)
+	}
+
+	custom, err := service.CreateCustomQuiz(ctx, copied, CustomQuizOptions{})
+	if err != nil {
+		t.Fatalf("CreateCustomQuiz failed: %v", err)
+	}
+	if custom.Origin.Provider != ProviderCustom {
+		t.Fatalf("custom Origin = %+v, want %q", custom.Origin, ProviderCustom)
+	}
+	// Each question keeps the provider it was fetched from; new ones are custom.
+	written, err := NewQuestion("Written here?", []string{"Yes", "No"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	mixed, err := service.CreateCustomQuiz(ctx, []Question{copied[0], written}, CustomQuizOptions{})
+	if err != nil {
+		t.Fatalf("CreateCustomQuiz(mixed) failed: %v", err)
+	}
+	if stored := repo.questionsByQuiz[mixed.QuizID]; stored[0].Source != "bundles:tech" || stored[1].Source != ProviderCustom {
+		t.Fatalf("stored sources = (%q, %q), want bundles:tech and custom", stored[0].Source, stored[1].Source)
+	}
+	if _, _, err := service.Rematch(ctx, custom.QuizID, RematchFresh); !errors.Is(err, ErrNotRefetchable) {
+		t.Fatalf("Rematch(fresh) of a custom quiz error = %v, want ErrNotRefetchable", err)
+	}
+	if _, err := ParseRematchMode("sometimes"); !errors.Is(err, ErrInvalidQuestion) {
+		t.Fatalf("ParseRematchMode error = %v, want ErrInvalidQuestion", err)
+	}
+}
+
+func TestServiceCreatesFilteredQuizzes(t *testing.T) {
+	ctx := context.Background()
+	var filters []opentdb.Filter
+	fetcher := func(_ context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		filters = append(filters, filter)
+		raw := make([]opentdb.RawQuestion, 0, amount)
+		for idx := 0; idx < amount; idx++ {
+			raw = append(raw, opentdb.RawQuestion{
+				Type: "boolean", Category: "Science &amp; Nature", Difficulty: "medium",
+				Question: fmt.Sprintf("Fact %d-%d?", len(filters), idx), CorrectAnswer: "True", IncorrectAnswers: []string{"False"},
+			})
+		}
+		return raw, nil
+	}
+	service := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, fetcher)
+
+	if _, err := service.CreateQuizWithOptions(ctx, 2, QuizOptions{Category: 8}); !errors.Is(err, ErrInvalidQuestionFilter) {
+		t.Fatalf("CreateQuizWithOptions(category 8) error = %v, want ErrInvalidQuestionFilter", err)
+	}
+	if _, err := service.CreateQuizWithOptions(ctx, 2, QuizOptions{QuestionType: "essay"}); !errors.Is(err, ErrInvalidQuestionFilter) {
+		t.Fatalf("CreateQuizWithOptions(type essay) error = %v, want ErrInvalidQuestionFilter", err)
+	}
+	if len(filters) != 0 {
+		t.Fatalf("invalid filters reached the provider: %+v", filters)
+	}
+
+	metadata, err := service.CreateQuizWithOptions(ctx, 3, QuizOptions{Category: 17, Difficulty: DifficultyMedium, QuestionType: "boolean"})
+	if err != nil || metadata.QuestionCount != 3 {
+		t.Fatalf("CreateQuizWithOptions = (%+v, %v), want 3 questions", metadata, err)
+	}
+	want := opentdb.Filter{Category: 17, Difficulty: "medium", Type: "boolean"}
+	if filters[0] != want {
+		t.Fatalf("provider filter = %+v, want %+v", filters[0], want)
+	}
+	if metadata.Origin.Category != 17 || metadata.Origin.QuestionType != "boolean" {
+		t.Fatalf("origin = %+v, want category 17 of boolean questions", metadata.Origin)
+	}
+
+	// A rematch asks the provider for the same category and type.
+	if _, _, err := service.Rematch(ctx, metadata.QuizID, RematchFresh); err != nil {
+		t.Fatalf("Rematch failed: %v", err)
+	}
+	if got := filters[len(filters)-1]; got != want {
+		t.Fatalf("rematch filter = %+v, want %+v", got, want)
+	}
+
+	// A mix of several levels asks for the category and sorts the levels itself.
+	if _, _, err := service.CreateMixedQuiz(ctx, DifficultyMix{DifficultyEasy: 1, DifficultyMedium: 1}, QuizOptions{Category: 17}); err != nil {
+		t.Fatalf("CreateMixedQuiz failed: %v", err)
+	}
+	if got := filters[len(filters)-1]; got != (opentdb.Filter{Category: 17}) {
+		t.Fatalf("mix filter = %+v, want only the category", got)
+	}
+}
+
+func TestServiceOverviewCountsRecentActivity(t *testing.T) {
+	repo := newFakeQuizRepo()
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return []opentdb.RawQuestion{
+			{Question: "One?", Category: "Science &amp; Nature", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Two?", Category: "History", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Three?", Category: "Science &amp; Nature", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		}, nil
+	}
+	attempts := &fakeAttemptRepo{submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}}}
+	service := NewService(repo, attempts, fetcher)
+	now := time.Date(2026, 3, 2, 23, 50, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	metadata, err := service.CreateQuiz(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	service.RecordServed(context.Background(), metadata.QuizID, "Carol", []string{"q1"}, false)
+	for _, user := range []string{"alice", "bob", "alice"} {
+		if _, err := service.SubmitResponses(context.Background(), metadata.QuizID, user, []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", user, err)
+		}
+	}
+
+	overview := service.Overview()
+	if overview.QuizzesToday != 1 || overview.ActiveUsersLastHour != 3 || overview.SubmissionsPerMinute != 0.6 {
+		t.Fatalf("overview = %+v, want 1 quiz, 3 active users, 0.6 submissions/min", overview)
+	}
+	want := []CategoryCount{{Category: "Science & Nature", QuestionCount: 2}, {Category: "History", QuestionCount: 1}}
+	if len(overview.TopCategories) != 2 || overview.TopCategories[0] != want[0] || overview.TopCategories[1] != want[1] {
+		t.Fatalf("TopCategories = %+v, want %+v", overview.TopCategories, want)
+	}
+
+	// Past midnight and an hour later, every window has rolled over.
+	now = now.Add(time.Hour + time.Minute)
+	overview = service.Overview()
+	if overview.QuizzesToday != 0 || overview.ActiveUsersLastHour != 0 || overview.SubmissionsPerMinute != 0 || len(overview.TopCategories) != 0 {
+		t.Fatalf("overview after an hour = %+v, want everything reset", overview)
+	}
+}
+
+func TestServiceRejectsRepeatedQuestionsWithinOneRequest(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 2}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "q1", Options: []Option{{Letter: "A"}, {Letter: "B"}}}, CorrectIndex: 0},
+		{PublicQuestion: PublicQuestion{QuestionID: "q2", Options: []Option{{Letter: "A"}, {Letter: "B"}}}, CorrectIndex: 0},
+	}
+	attempts := &fakeAttemptRepo{submitResults: []ResponseResult{
+		{QuestionID: "q1", Status: StatusIncorrect},
+		{QuestionID: "q2", Status: StatusCorrect},
+	}}
+	service := NewService(repo, attempts, nil)
+	batch := []SubmittedResponse{
+		{QuestionID: "q1", Answer: "B"},
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "q2", Answer: "A"},
+		{QuestionID: "q1", Answer: "A"},
+	}
+
+	results, err := service.SubmitResponses(ctx, "quiz-1", "alice", batch)
+	if err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	if len(attempts.lastSubmitResponses) != 2 || attempts.lastSubmitResponses[0] != batch[0] || attempts.lastSubmitResponses[1] != batch[2] {
+		t.Fatalf("stored responses = %+v, want only the first answer to each question", attempts.lastSubmitResponses)
+	}
+	statuses := make([]string, 0, len(results))
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	if strings.Join(statuses, " ") != "incorrect duplicate_in_request correct duplicate_in_request" || results[3].QuestionID != "q1" {
+		t.Fatalf("SubmitResponses = %+v, want repeats marked duplicate_in_request in request order", results)
+	}
+
+	results, err = service.EvaluateResponsesForQuiz(ctx, "quiz-1", batch[:2])
+	if err != nil {
+		t.Fatalf("EvaluateResponsesForQuiz failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Status != StatusIncorrect || results[1].Status != StatusDuplicateInRequest {
+		t.Fatalf("EvaluateResponsesForQuiz = %+v, want [incorrect duplicate_in_request]", results)
+	}
+}
+
+func TestServiceShedsQuizCreationWhenProviderSlotsStayBusy(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		started <- struct{}{}
+		<-release
+		return []opentdb.RawQuestion{{Question: "Q?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}}}, nil
+	}
+	service := NewServiceWithOptions(newFakeQuizRepo(), &fakeAttemptRepo{}, fetcher, ServiceOptions{
+		MaxConcurrentFetches: 1,
+		FetchQueueTimeout:    10 * time.Millisecond,
+	})
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := service.CreateQuiz(context.Background(), 1)
+		first <- err
+	}()
+	<-started
+
+	if _, err := service.CreateQuiz(context.Background(), 1); !errors.Is(err, ErrProviderBusy) {
+		t.Fatalf("CreateQuiz while the only slot is taken error = %v, want ErrProviderBusy", err)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("first CreateQuiz failed: %v", err)
+	}
+	go func() { <-started }()
+	if _, err := service.CreateQuiz(context.Background(), 1); err != nil {
+		t.Fatalf("CreateQuiz after the slot freed up failed: %v", err)
+	}
+}
+
+func TestServiceFallsBackThroughProviderChain(t *testing.T) {
+	down := errors.New("opentdb unavailable")
+	var poolCalls, bundleCalls int
+	pool := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		poolCalls++
+		return nil, nil
+	}
+	embedded := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		bundleCalls++
+		return []opentdb.RawQuestion{
+			{Question: "Easy?", Difficulty: "easy", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Hard?", Difficulty: "hard", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		}, nil
+	}
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return nil, down
+	}
+	service := NewServiceWithOptions(newFakeQuizRepo(), &fakeAttemptRepo{}, fetcher, ServiceOptions{
+		FallbackProviders: []FallbackProvider{{Name: "pool", Fetch: pool}, {Name: "bundles", Fetch: embedded}},
+	})
+	ctx := context.Background()
+
+	// The empty pool is skipped like a failure.
+	metadata, err := service.CreateQuiz(ctx, 2)
+	if err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if metadata.QuestionCount != 2 || metadata.Origin.Provider != "bundles" || metadata.Origin.FallbackFrom != "opentdb" {
+		t.Fatalf("CreateQuiz = %+v, want the bundles questions with the fallback recorded", metadata)
+	}
+	if poolCalls != 1 || bundleCalls != 1 {
+		t.Fatalf("fallback calls pool=%d bundles=%d, want one each", poolCalls, bundleCalls)
+	}
+
+	// Extra rounds of a mixed quiz stay with the provider that answered first.
+	mixed, buckets, err := service.CreateMixedQuiz(ctx, DifficultyMix{DifficultyEasy: 2}, QuizOptions{})
+	if err != nil {
+		t.Fatalf("CreateMixedQuiz failed: %v", err)
+	}
+	if mixed.Origin.Provider != "bundles" || buckets[0].Delivered != 1 || poolCalls != 2 || bundleCalls != 1+mixMaxFetchRounds {
+		t.Fatalf("CreateMixedQuiz = (%+v, %+v) with pool=%d bundles=%d calls, want every round from bundles", mixed.Origin, buckets, poolCalls, bundleCalls)
+	}
+
+	none := NewServiceWithOptions(newFakeQuizRepo(), &fakeAttemptRepo{}, fetcher, ServiceOptions{
+		FallbackProviders: []FallbackProvider{{Name: "pool", Fetch: pool}},
+	})
+	if _, err := none.CreateQuiz(ctx, 2); !errors.Is(err, down) || !strings.Contains(err.Error(), "pool: returned no questions") {
+		t.Fatalf("CreateQuiz with every provider failing error = %v, want each provider's failure", err)
+	}
+}
+
+func TestServiceFailsOverWhenProviderReturnsTooFewQuestions(t *testing.T) {
+	questionsFrom := func(prefix string, count int) QuestionsFetcher {
+		return func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+			raw := make([]opentdb.RawQuestion, 0, count)
+			for idx := range min(count, amount) {
+				raw = append(raw, opentdb.RawQuestion{Question: fmt.Sprintf("%s %d?", prefix, idx), CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}})
+			}
+			return raw, nil
+		}
+	}
+	ctx := context.Background()
+
+	// The first provider with enough questions supplies the quiz.
+	service := NewServiceWithOptions(newFakeQuizRepo(), &fakeAttemptRepo{}, questionsFrom("opentdb", 1), ServiceOptions{
+		FallbackProviders: []FallbackProvider{{Name: "triviaapi", Fetch: questionsFrom("trivia", 2)}, {Name: "file:mine.csv", Fetch: questionsFrom("file", 5)}},
+	})
+	metadata, err := service.CreateQuiz(ctx, 3)
+	if err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if metadata.QuestionCount != 3 || metadata.Origin.Provider != "file:mine.csv" || metadata.Origin.FallbackFrom != "opentdb" {
+		t.Fatalf("CreateQuiz = %+v, want three questions from the file", metadata)
+	}
+
+	// Without one, the largest answer wins.
+	service = NewServiceWithOptions(newFakeQuizRepo(), &fakeAttemptRepo{}, questionsFrom("opentdb", 1), ServiceOptions{
+		FallbackProviders: []FallbackProvider{{Name: "triviaapi", Fetch: questionsFrom("trivia", 2)}, {Name: "pool", Fetch: questionsFrom("pool", 0)}},
+	})
+	metadata, err = service.CreateQuiz(ctx, 3)
+	if err != nil {
+		t.Fatalf("CreateQuiz with every provider short failed: %v", err)
+	}
+	if metadata.QuestionCount != 2 || metadata.RequestedQuestionCount != 3 || metadata.Origin.Provider != "triviaapi" {
+		t.Fatalf("CreateQuiz = %+v, want triviaapi's two questions", metadata)
+	}
+
+	// A short answer from the fetcher is kept when no fallback does better.
+	service = NewServiceWithOptions(newFakeQuizRepo(), &fakeAttemptRepo{}, questionsFrom("opentdb", 2), ServiceOptions{
+		FallbackProviders: []FallbackProvider{{Name: "triviaapi", Fetch: questionsFrom("trivia", 2)}},
+	})
+	if metadata, err := service.CreateQuiz(ctx, 3); err != nil || metadata.Origin.Provider != "opentdb" || metadata.Origin.FallbackFrom != "" {
+		t.Fatalf("CreateQuiz = (%+v, %v), want opentdb's questions on a tie", metadata, err)
+	}
+}
+
+func TestStoredQuestionsFetcherReshufflesStoredQuestions(t *testing.T) {
+	stored := Question{
+		PublicQuestion: PublicQuestion{Question: "Tom & Jerry?", Options: []Option{{Letter: "A", Text: "Cat"}, {Letter: "B", Text: "<Mouse>"}}},
+		CorrectIndex:   1,
+		Difficulty:     DifficultyEasy,
+		Category:       "Cartoons",
+	}
+	fetch := StoredQuestionsFetcher(fakeSamplerRepo{fakeQuizRepo: newFakeQuizRepo(), sample: []Question{stored}})
+	raw, err := fetch(context.Background(), 5, opentdb.Filter{})
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	rebuilt := BuildQuestions(raw)
+	if len(rebuilt) != 1 || rebuilt[0].Question != stored.Question || rebuilt[0].Category != "Cartoons" || rebuilt[0].Difficulty != DifficultyEasy {
+		t.Fatalf("rebuilt = %+v, want the stored question back", rebuilt)
+	}
+	if correct := rebuilt[0].Options[rebuilt[0].CorrectIndex].Text; correct != "<Mouse>" {
+		t.Fatalf("rebuilt correct option = %q, want %q", correct, "<Mouse>")
+	}
+	if StoredQuestionsFetcher(newFakeQuizRepo()) != nil {
+		t.Fatal("StoredQuestionsFetcher of a store without QuestionSampler is not nil")
+	}
+}
+
+type fakeSamplerRepo struct {
+	*fakeQuizRepo
+	sample []Question
+}
+
+func (f fakeSamplerRepo) SampleQuestions(_ context.Context, limit int) ([]Question, error) {
+	return f.sample[:min(limit, len(f.sample))], nil
+}
+
+type fakeLeaderboardQuizRepo struct {
+	*fakeQuizRepo
+	settings map[string]LeaderboardSettings
+}
+
+func (f *fakeLeaderboardQuizRepo) GetLeaderboardSettings(_ context.Context, quizID string) (LeaderboardSettings, error) {
+	return f.settings[quizID], nil
+}
+
+func (f *fakeLeaderboardQuizRepo) SaveLeaderboardSettings(_ context.Context, quizID string, settings LeaderboardSettings) error {
+	f.settings[quizID] = settings
+	return nil
+}
+
+type fakeUserHistoryRepo struct {
+	*fakeAttemptRepo
+	byUser map[string][]Attempt
+}
+
+func (f *fakeUserHistoryRepo) ListAttempts(_ context.Context, _, usernameNormalized string) ([]Attempt, error) {
+	return f.byUser[usernameNormalized], nil
+}
+
+// GetUserAttempts sums the user's answers as one live summary for quiz-1.
+func (f *fakeUserHistoryRepo) GetUserAttempts(_ context.Context, usernameNormalized string) ([]AttemptSummary, error) {
+	attempts := f.byUser[usernameNormalized]
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+	summary := AttemptSummary{QuizID: "quiz-1", Username: usernameNormalized, AnsweredCount: len(attempts)}
+	for _, attempt := range attempts {
+		summary.TotalScore += attempt.Score
+	}
+	return []AttemptSummary{summary}, nil
+}
+
+func TestServiceFreezesPublicLeaderboardUntilLock(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	repo := &fakeLeaderboardQuizRepo{fakeQuizRepo: newFakeQuizRepo(), settings: make(map[string]LeaderboardSettings)}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", CreatedAt: base}
+	attempts := &fakeUserHistoryRepo{
+		fakeAttemptRepo: &fakeAttemptRepo{leaderboard: []LeaderboardEntry{
+			{Username: "bob", TotalScore: 3, AnsweredCount: 3, LastSubmissionAt: base.Add(345 * time.Second)},
+			{Username: "alice", TotalScore: 2, AnsweredCount: 2, LastSubmissionAt: base.Add(2 * time.Minute)},
+			{Username: "carol", TotalScore: 1, AnsweredCount: 1, LastSubmissionAt: base.Add(310 * time.Second)},
+		}},
+		byUser: map[string][]Attempt{
+			"alice": {{Score: 1, SubmittedAt: base.Add(time.Minute)}, {Score: 1, SubmittedAt: base.Add(2 * time.Minute)}},
+			"bob":   {{Score: 1, SubmittedAt: base.Add(3 * time.Minute)}, {Score: 1, SubmittedAt: base.Add(330 * time.Second)}, {Score: 1, SubmittedAt: base.Add(345 * time.Second)}},
+			"carol": {{Score: 1, SubmittedAt: base.Add(310 * time.Second)}},
+		},
+	}
+	service := NewService(repo, attempts, nil)
+	service.now = func() time.Time { return base.Add(6 * time.Minute) }
+
+	if _, err := service.SetLeaderboardSettings(ctx, "quiz-1", LeaderboardSettings{FreezeWindow: 5 * time.Minute}); !errors.Is(err, ErrInvalidLeaderboardSettings) {
+		t.Fatalf("freeze without a lock time error = %v, want ErrInvalidLeaderboardSettings", err)
+	}
+	saved, err := service.SetLeaderboardSettings(ctx, "quiz-1", LeaderboardSettings{DefaultLimit: 2, FreezeWindow: 5*time.Minute + 300*time.Millisecond, LocksAt: base.Add(10 * time.Minute)})
+	if err != nil || saved.FreezeWindow != 5*time.Minute || !saved.UpdatedAt.Equal(base.Add(6*time.Minute)) {
+		t.Fatalf("SetLeaderboardSettings = (%+v, %v), want a whole-second freeze stamped now", saved, err)
+	}
+	if got, _ := service.GetLeaderboardSettings(ctx, "quiz-1"); got.DefaultLimit != 2 {
+		t.Fatalf("GetLeaderboardSettings = %+v, want default limit 2", got)
+	}
+
+	board, err := service.GetPublicLeaderboard(ctx, "quiz-1", 0)
+	if err != nil {
+		t.Fatalf("frozen GetPublicLeaderboard failed: %v", err)
+	}
+	if !board.Frozen() || !board.FrozenAt.Equal(base.Add(5*time.Minute)) || !board.RevealAt.Equal(base.Add(10*time.Minute)) {
+		t.Fatalf("frozen board window = (%v, %v), want the last five minutes before the lock", board.FrozenAt, board.RevealAt)
+	}
+	if len(board.Entries) != 2 || board.Entries[0].Username != "alice" || board.Entries[1].Username != "bob" || board.Entries[1].TotalScore != 1 || board.Entries[1].AnsweredCount != 1 {
+		t.Fatalf("frozen entries = %+v, want alice then bob with only his pre-freeze answer", board.Entries)
+	}
+	// Per-user totals stand at the freeze too, or they would give the hidden
+	// standings away.
+	if history, err := service.UserAttempts(ctx, "bob", 0); err != nil || len(history.Attempts) != 1 || history.Attempts[0].TotalScore != 1 || history.Attempts[0].AnsweredCount != 1 {
+		t.Fatalf("frozen UserAttempts(bob) = (%+v, %v), want only his pre-freeze answer", history, err)
+	}
+	if history, err := service.UserAttempts(ctx, "carol", 0); err != nil || len(history.Attempts) != 0 {
+		t.Fatalf("frozen UserAttempts(carol) = (%+v, %v), want none before the freeze", history, err)
+	}
+
+	service.now = func() time.Time { return base.Add(10 * time.Minute) }
+	board, err = service.GetPublicLeaderboard(ctx, "quiz-1", 0)
+	if err != nil || board.Frozen() || len(board.Entries) != 3 || board.Entries[0].Username != "bob" {
+		t.Fatalf("revealed board = (%+v, %v), want live standings at the lock", board, err)
+	}
+	if history, err := service.UserAttempts(ctx, "bob", 0); err != nil || len(history.Attempts) != 1 || history.Attempts[0].TotalScore != 3 {
+		t.Fatalf("revealed UserAttempts(bob) = (%+v, %v), want his live total", history, err)
+	}
+}
+
+func TestServiceCachedLeaderboardFollowsTiebreak(t *testing.T) {
+	ctx := context.Background()
+	early := time.Unix(100, 0).UTC()
+	repo := &fakeLeaderboardQuizRepo{fakeQuizRepo: newFakeQuizRepo(), settings: make(map[string]LeaderboardSettings)}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1"}
+	attempts := &fakeAttemptRepo{
+		leaderboard: []LeaderboardEntry{
+			{Username: "bob", TotalScore: 2, AnsweredCount: 3, LastSubmissionAt: early},
+			{Username: "alice", TotalScore: 1, AnsweredCount: 1, LastSubmissionAt: early},
+		},
+		submitResults: []ResponseResult{{QuestionID: "q1", Status: StatusCorrect}},
+	}
+	service := NewService(repo, attempts, nil)
+
+	if _, err := service.SetLeaderboardSettings(ctx, "quiz-1", LeaderboardSettings{Tiebreak: "slowest"}); !errors.Is(err, ErrInvalidLeaderboardSettings) {
+		t.Fatalf("unknown tiebreak error = %v, want ErrInvalidLeaderboardSettings", err)
+	}
+	if _, err := service.GetLeaderboard(ctx, "quiz-1", 0); err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+	if _, err := service.SetLeaderboardSettings(ctx, "quiz-1", LeaderboardSettings{Tiebreak: TiebreakFewestAnswers}); err != nil {
+		t.Fatalf("SetLeaderboardSettings failed: %v", err)
+	}
+	if _, ok := service.getCachedLeaderboard("quiz-1"); ok {
+		t.Fatalf("leaderboard stayed cached after its tiebreak changed")
+	}
+	if _, err := service.GetLeaderboard(ctx, "quiz-1", 0); err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+
+	// Alice ties bob on score with two answers to his three, and moves ahead
+	// even though she finished later.
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "alice", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	leaderboard, ok := service.getCachedLeaderboard("quiz-1")
+	if !ok || len(leaderboard) != 2 || leaderboard[0].Username != "alice" || leaderboard[0].AnsweredCount != 2 {
+		t.Fatalf("cached leaderboard = %+v, want alice first on fewer answers", leaderboard)
+	}
+}
+
+func TestServiceCategoryLeaderboardCountsOnlyThatCategory(t *testing.T) {
+	ctx := context.Background()
+	at := time.Unix(100, 0).UTC()
+	repo := &fakeLeaderboardQuizRepo{fakeQuizRepo: newFakeQuizRepo(), settings: make(map[string]LeaderboardSettings)}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 4}
+	options := []Option{{Letter: "A", Text: "yes"}, {Letter: "B", Text: "no"}}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "q1", Options: options}, Category: "Science"},
+		{PublicQuestion: PublicQuestion{QuestionID: "q2", Options: options}, Category: "History"},
+		{PublicQuestion: PublicQuestion{QuestionID: "q3", Options: options}, Category: "Science"},
+		{PublicQuestion: PublicQuestion{QuestionID: "q4", Options: options}, Category: "Art", Voided: true},
+	}
+	attempts := &fakeUserHistoryRepo{
+		fakeAttemptRepo: &fakeAttemptRepo{
+			leaderboard: []LeaderboardEntry{
+				{Username: "alice", TotalScore: 2, AnsweredCount: 2, LastSubmissionAt: at},
+				{Username: "bob", TotalScore: 1, AnsweredCount: 2, LastSubmissionAt: at},
+			},
+			submitResults: []ResponseResult{{QuestionID: "q3", Status: StatusCorrect}, {QuestionID: "q2", Status: StatusCorrect}},
+		},
+		byUser: map[string][]Attempt{
+			"alice": {{QuestionID: "q1", Score: 1, SubmittedAt: at}, {QuestionID: "q2", Score: 1, SubmittedAt: at}},
+			"bob":   {{QuestionID: "q2", Score: 1, SubmittedAt: at}, {QuestionID: "q3", Score: 0, SubmittedAt: at}},
+		},
+	}
+	service := NewService(repo, attempts, nil)
+
+	board, err := service.GetCategoryLeaderboard(ctx, "quiz-1", " science ", 0)
+	if err != nil {
+		t.Fatalf("GetCategoryLeaderboard failed: %v", err)
+	}
+	if len(board.Entries) != 2 || board.Entries[0].Username != "alice" || board.Entries[0].TotalScore != 1 || board.Entries[1].AnsweredCount != 1 {
+		t.Fatalf("science board = %+v, want alice on q1 ahead of bob's one wrong answer", board.Entries)
+	}
+	if _, err := service.GetCategoryLeaderboard(ctx, "quiz-1", "Art", 0); !errors.Is(err, ErrUnknownCategory) {
+		t.Fatalf("category with only voided questions error = %v, want ErrUnknownCategory", err)
+	}
+
+	// Bob's new q3 point is patched into the cached Science board; his q2
+	// point belongs to History and is left out.
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "bob", []SubmittedResponse{{QuestionID: "q3", Answer: "A"}, {QuestionID: "q2", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	board, err = service.GetCategoryLeaderboard(ctx, "quiz-1", "SCIENCE", 0)
+	if err != nil {
+		t.Fatalf("GetCategoryLeaderboard failed: %v", err)
+	}
+	if bob := board.Entries[1]; len(board.Entries) != 2 || bob.Username != "bob" || bob.TotalScore != 1 || bob.AnsweredCount != 2 {
+		t.Fatalf("science board after submit = %+v, want bob with one point from two answers, behind alice who scored first", board.Entries)
+	}
+}
+
+type fakeResultsQuizRepo struct {
+	*fakeLeaderboardQuizRepo
+	results map[string]PublishedResults
+}
+
+func (f *fakeResultsQuizRepo) SaveQuizResults(_ context.Context, results PublishedResults) error {
+	if _, ok := f.results[results.QuizID]; !ok {
+		f.results[results.QuizID] = results
+	}
+	return nil
+}
+
+func (f *fakeResultsQuizRepo) GetQuizResults(_ context.Context, quizID string) (PublishedResults, error) {
+	return f.results[quizID], nil
+}
+
+func TestServicePublishesResultsOnceLocked(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	repo := &fakeResultsQuizRepo{
+		fakeLeaderboardQuizRepo: &fakeLeaderboardQuizRepo{fakeQuizRepo: newFakeQuizRepo(), settings: make(map[string]LeaderboardSettings)},
+		results:                 make(map[string]PublishedResults),
+	}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 3, ClosesAt: base.Add(10 * time.Minute)}
+	options := []Option{{Letter: "A", Text: "yes"}, {Letter: "B", Text: "no"}}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "q1", Question: "One?", Options: options}, CorrectIndex: 0},
+		{PublicQuestion: PublicQuestion{QuestionID: "q2", Question: "Two?", Options: options}, CorrectIndex: 1},
+		{PublicQuestion: PublicQuestion{QuestionID: "q3", Question: "Three?", Options: options}, Voided: true},
+	}
+	attempts := &fakeUserHistoryRepo{
+		fakeAttemptRepo: &fakeAttemptRepo{leaderboard: []LeaderboardEntry{
+			{Username: "alice", TotalScore: 2, AnsweredCount: 2},
+			{Username: "bob", TotalScore: 1, AnsweredCount: 2},
+		}},
+		byUser: map[string][]Attempt{
+			// Alice's second answer came after the lock and does not count.
+			"alice": {{QuestionID: "q1", AnswerLetter: "A", Score: 1, SubmittedAt: base.Add(time.Minute)}, {QuestionID: "q2", AnswerLetter: "B", Score: 1, SubmittedAt: base.Add(11 * time.Minute)}},
+			"bob":   {{QuestionID: "q1", AnswerLetter: "B", SubmittedAt: base.Add(2 * time.Minute)}, {QuestionID: "q2", AnswerLetter: "B", Score: 1, SubmittedAt: base.Add(3 * time.Minute), SubmittedBy: "Dana"}},
+		},
+	}
+	var exported []PublishedResults
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{
+		ResultsExporter: func(results PublishedResults) { exported = append(exported, results) },
+	})
+
+	service.now = func() time.Time { return base.Add(5 * time.Minute) }
+	if _, err := service.QuizResults(ctx, "quiz-1"); !errors.Is(err, ErrResultsNotFinal) {
+		t.Fatalf("QuizResults before the lock error = %v, want ErrResultsNotFinal", err)
+	}
+
+	service.now = func() time.Time { return base.Add(12 * time.Minute) }
+	published, err := service.QuizResults(ctx, "quiz-1")
+	if err != nil || !published.PublishedAt.Equal(base.Add(12*time.Minute)) || len(exported) != 1 {
+		t.Fatalf("QuizResults = (%+v, %v) with %d exports, want one publication now", published, err, len(exported))
+	}
+	var results QuizResults
+	if err := json.Unmarshal(published.Document, &results); err != nil {
+		t.Fatalf("document does not parse: %v", err)
+	}
+	if !results.LockedAt.Equal(base.Add(10*time.Minute)) || len(results.Standings) != 2 {
+		t.Fatalf("results = %+v, want two players locked at the deadline", results)
+	}
+	if first := results.Standings[0]; first.Username != "alice" || first.Rank != 1 || first.TotalScore != 1 || first.AnsweredCount != 1 {
+		t.Fatalf("winner = %+v, want alice on her one answer before the lock", first)
+	}
+	if first, second := results.Standings[0], results.Standings[1]; first.ProxyAnswers != 0 || second.ProxyAnswers != 1 {
+		t.Fatalf("proxy answers = %d and %d, want only bob's answer entered by an admin", first.ProxyAnswers, second.ProxyAnswers)
+	}
+	if len(results.Questions) != 2 {
+		t.Fatalf("questions = %+v, want the voided one left out", results.Questions)
+	}
+	q1, q2 := results.Questions[0], results.Questions[1]
+	if q1.CorrectLetter != "A" || q1.AttemptCount != 2 || q1.CorrectCount != 1 || q1.AnswerCounts["A"] != 1 || q1.AnswerCounts["B"] != 1 {
+		t.Fatalf("q1 = %+v, want one A and one B", q1)
+	}
+	if q2.AttemptCount != 1 || q2.CorrectCount != 1 {
+		t.Fatalf("q2 = %+v, want only bob's answer", q2)
+	}
+
+	// Later reads serve the published bytes even after the attempts are gone.
+	attempts.byUser = nil
+	service.now = func() time.Time { return base.Add(time.Hour) }
+	again, err := service.QuizResults(ctx, "quiz-1")
+	if err != nil || !bytes.Equal(again.Document, published.Document) || len(exported) != 1 {
+		t.Fatalf("second QuizResults = (%s, %v), want the same document without another export", again.Document, err)
+	}
+}
+
+type fakeIdentityResultsQuizRepo struct {
+	*fakeResultsQuizRepo
+	identities map[string]Identity
+}
+
+func (f *fakeIdentityResultsQuizRepo) GetIdentity(_ context.Context, usernameNormalized string) (Identity, error) {
+	return f.identities[usernameNormalized], nil
+}
+
+func (f *fakeIdentityResultsQuizRepo) SaveIdentity(_ context.Context, identity Identity) error {
+	f.identities[identity.Username] = identity
+	return nil
+}
+
+func TestServiceNotifiesFinalResultsOnce(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	repo := &fakeIdentityResultsQuizRepo{
+		fakeResultsQuizRepo: &fakeResultsQuizRepo{
+			fakeLeaderboardQuizRepo: &fakeLeaderboardQuizRepo{fakeQuizRepo: newFakeQuizRepo(), settings: make(map[string]LeaderboardSettings)},
+			results:                 make(map[string]PublishedResults),
+		},
+		identities: map[string]Identity{"bob": {Username: "bob", Email: "bob@example.com", TokenHash: "hash"}},
+	}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1, ClosesAt: base.Add(10 * time.Minute)}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "q1", Options: []Option{{Letter: "A", Text: "yes"}, {Letter: "B", Text: "no"}}}},
+	}
+	attempts := &fakeUserHistoryRepo{
+		fakeAttemptRepo: &fakeAttemptRepo{leaderboard: []LeaderboardEntry{{Username: "alice", TotalScore: 1, AnsweredCount: 1}, {Username: "bob", AnsweredCount: 1}}},
+		byUser: map[string][]Attempt{
+			"alice": {{QuestionID: "q1", AnswerLetter: "A", Score: 1, SubmittedAt: base.Add(time.Minute)}},
+			"bob":   {{QuestionID: "q1", AnswerLetter: "B", SubmittedAt: base.Add(2 * time.Minute)}},
+		},
+	}
+	var (
+		hooked []CompletionEvent
+		mailed []string
+	)
+	service := NewServiceWithOptions(repo, attempts, nil, ServiceOptions{
+		CompletionNotifier: func(_ string, event CompletionEvent) { hooked = append(hooked, event) },
+		ResultsMailer: func(email string, event CompletionEvent) {
+			mailed = append(mailed, email+" #"+fmt.Sprint(event.Result.Rank))
+		},
+	})
+
+	// Before the lock, registering schedules publication for the deadline.
+	service.now = func() time.Time { return base.Add(5 * time.Minute) }
+	if _, err := service.WatchCompletion(ctx, "quiz-1", CompletionWatch{URL: "https://host.example/hook", FinalResults: true}); err != nil {
+		t.Fatalf("WatchCompletion failed: %v", err)
+	}
+	timer := service.resultsTimers["quiz-1"]
+	if timer == nil || len(hooked) != 0 {
+		t.Fatalf("timer = %v with %d events, want publication scheduled and nothing sent", timer, len(hooked))
+	}
+	timer.Stop()
+
+	service.now = func() time.Time { return base.Add(12 * time.Minute) }
+	if _, err := service.QuizResults(ctx, "quiz-1"); err != nil {
+		t.Fatalf("QuizResults failed: %v", err)
+	}
+	if len(hooked) != 2 || hooked[0].Event != EventFinalResults || hooked[0].Username != "alice" || hooked[1].Participants != 2 {
+		t.Fatalf("webhook events = %+v, want one final result each for alice and bob", hooked)
+	}
+	if result := hooked[1].Result; result == nil || result.Rank != 2 || result.ResultsPath != "/quizzes/quiz-1/results.json" || !result.LockedAt.Equal(base.Add(10*time.Minute)) {
+		t.Fatalf("bob's result = %+v, want rank 2 with the results link", result)
+	}
+	if len(mailed) != 1 || mailed[0] != "bob@example.com #2" {
+		t.Fatalf("mailed = %v, want only bob, who verified an identity", mailed)
+	}
+
+	if _, err := service.QuizResults(ctx, "quiz-1"); err != nil || len(hooked) != 2 || len(mailed) != 1 {
+		t.Fatalf("second QuizResults = %v with %d events and %d mails, want nothing sent again", err, len(hooked), len(mailed))
+	}
+}
+
+type fakeRetirementQuizRepo struct {
+	*fakeQuizRepo
+	stats   []QuestionPerformance
+	reviews map[string]RetirementReview
+}
+
+func (f *fakeRetirementQuizRepo) QuestionStats(_ context.Context, minAttempts int) ([]QuestionPerformance, error) {
+	stats := make([]QuestionPerformance, 0, len(f.stats))
+	for _, item := range f.stats {
+		if item.AttemptCount >= minAttempts {
+			stats = append(stats, item)
+		}
+	}
+	return stats, nil
+}
+
+func (f *fakeRetirementQuizRepo) FlagQuestion(_ context.Context, review RetirementReview) error {
+	if _, ok := f.reviews[review.Question.QuestionID]; !ok {
+		f.reviews[review.Question.QuestionID] = review
+	}
+	return nil
+}
+
+func (f *fakeRetirementQuizRepo) ListRetirementReviews(_ context.Context, status RetirementStatus) ([]RetirementReview, error) {
+	reviews := make([]RetirementReview, 0, len(f.reviews))
+	for _, review := range f.reviews {
+		if status == "" || review.Status == status {
+			reviews = append(reviews, review)
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].Question.QuestionID < reviews[j].Question.QuestionID })
+	return reviews, nil
+}
+
+func (f *fakeRetirementQuizRepo) DecideRetirement(_ context.Context, questionID string, status RetirementStatus, decidedAt time.Time) (RetirementReview, error) {
+	review, ok := f.reviews[questionID]
+	if !ok {
+		return RetirementReview{}, ErrNotFlagged
+	}
+	review.Status, review.DecidedAt = status, decidedAt
+	f.reviews[questionID] = review
+	return review, nil
+}
+
+func TestServiceRetirementQueueFlagsExtremeQuestionsAndSkipsRetired(t *testing.T) {
+	prompt := func(id, text string) Question {
+		return Question{PublicQuestion: PublicQuestion{QuestionID: id, Question: text}}
+	}
+	repo := &fakeRetirementQuizRepo{
+		fakeQuizRepo: newFakeQuizRepo(),
+		stats: []QuestionPerformance{
+			{Question: prompt("broken", "Broken?"), AttemptCount: 50, CorrectCount: 0},
+			{Question: prompt("giveaway", "Giveaway?"), AttemptCount: 50, CorrectCount: 50},
+			{Question: prompt("fair", "Fair?"), AttemptCount: 50, CorrectCount: 30},
+			{Question: prompt("new", "New?"), AttemptCount: 3, CorrectCount: 0},
+		},
+		reviews: make(map[string]RetirementReview),
+	}
+	fetcher := func(_ context.Context, amount int, _ opentdb.Filter) ([]opentdb.RawQuestion, error) {
+		return []opentdb.RawQuestion{
+			{Question: "Broken?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Giveaway?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+			{Question: "Fair?", CorrectAnswer: "Yes", IncorrectAnswers: []string{"No"}},
+		}, nil
+	}
+	service := NewServiceWithOptions(repo, &fakeAttemptRepo{}, fetcher, ServiceOptions{
+		Retirement: RetirementPolicy{MinAttempts: 10, ExtremeRate: 0.02},
+	})
+	ctx := context.Background()
+
+	queue, err := service.RetirementQueue(ctx, RetirementPending)
+	if err != nil {
+		t.Fatalf("RetirementQueue failed: %v", err)
+	}
+	if len(queue) != 2 || queue[0].Question.QuestionID != "broken" || queue[1].Question.QuestionID != "giveaway" {
+		t.Fatalf("queue = (%+v), want broken and giveaway", queue)
+	}
+
+	if _, err := service.DecideRetirement(ctx, "broken", RetirementPending); !errors.Is(err, ErrInvalidRetirementStatus) {
+		t.Fatalf("DecideRetirement(pending) error = (%v), want ErrInvalidRetirementStatus", err)
+	}
+	if _, err := service.DecideRetirement(ctx, "fair", RetirementRetired); !errors.Is(err, ErrNotFlagged) {
+		t.Fatalf("DecideRetirement(fair) error = (%v), want ErrNotFlagged", err)
+	}
+
+	// Only confirmed questions leave the pool.
+	metadata, err := service.CreateQuiz(ctx, 3)
+	if err != nil || metadata.QuestionCount != 3 {
+		t.Fatalf("quiz before retirement = (%+v, %v), want 3 questions", metadata, err)
+	}
+	if _, err := service.DecideRetirement(ctx, "broken", RetirementRetired); err != nil {
+		t.Fatalf("DecideRetirement(broken) failed: %v", err)
+	}
+	if _, err := service.DecideRetirement(ctx, "giveaway", RetirementKept); err != nil {
+		t.Fatalf("DecideRetirement(giveaway) failed: %v", err)
+	}
+	metadata, err = service.CreateQuiz(ctx, 3)
+	if err != nil {
+		t.Fatalf("quiz after retirement failed: %v", err)
+	}
+	questions := repo.questionsByQuiz[metadata.QuizID]
+	if len(questions) != 2 || questions[0].Question == "Broken?" || questions[1].Question == "Broken?" {
+		t.Fatalf("quiz after retirement = (%+v), want the retired question left out", questions)
+	}
+
+	// A kept question is not flagged again.
+	if queue, err := service.RetirementQueue(ctx, RetirementPending); err != nil || len(queue) != 0 {
+		t.Fatalf("pending queue = (%+v, %v), want empty", queue, err)
+	}
+}
+
+type fakeRosterQuizRepo struct {
+	*fakeQuizRepo
+	rosters map[string]Roster
+}
+
+func (f *fakeRosterQuizRepo) GetRoster(_ context.Context, quizID string) (Roster, error) {
+	return f.rosters[quizID], nil
+}
+
+func (f *fakeRosterQuizRepo) SaveRoster(_ context.Context, quizID string, roster Roster) error {
+	f.rosters[quizID] = roster
+	return nil
+}
+
+func TestServiceRosterRestrictsSubmissionsAndKeepsJoinCodes(t *testing.T) {
+	repo := &fakeRosterQuizRepo{fakeQuizRepo: newFakeQuizRepo(), rosters: make(map[string]Roster)}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1}
+	attempts := &fakeAttemptRepo{leaderboard: []LeaderboardEntry{
+		{Username: "guest", TotalScore: 2, AnsweredCount: 2},
+		{Username: "alice", TotalScore: 1, AnsweredCount: 1},
+	}}
+	service := NewService(repo, attempts, nil)
+	ctx := context.Background()
+
+	if _, err := service.SetRoster(ctx, "quiz-1", Roster{Students: []RosterStudent{{Username: "Alice"}, {Username: "alice "}}}, false); !errors.Is(err, ErrInvalidRoster) {
+		t.Fatalf("SetRoster(duplicate) error = (%v), want ErrInvalidRoster", err)
+	}
+
+	roster, err := service.SetRoster(ctx, "quiz-1", Roster{Students: []RosterStudent{
+		{Username: " Alice ", Name: " Alice Smith "},
+		{Username: "bob", Name: "Bob Jones"},
+	}}, true)
+	if err != nil {
+		t.Fatalf("SetRoster failed: %v", err)
+	}
+	if roster.Students[0].Username != "alice" || roster.Students[0].Name != "Alice Smith" || len(roster.Students[0].JoinCode) != joinCodeLength {
+		t.Fatalf("roster = (%+v), want normalized alice with a join code", roster.Students)
+	}
+	aliceCode := roster.Students[0].JoinCode
+
+	// Unrestricted rosters accept anyone.
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "guest", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses(guest, unrestricted) failed: %v", err)
+	}
+
+	roster, err = service.SetRoster(ctx, "quiz-1", Roster{Restricted: true, Students: []RosterStudent{
+		{Username: "alice", Name: "Alice Smith"},
+		{Username: "carol", Name: "Carol White"},
+	}}, true)
+	if err != nil {
+		t.Fatalf("SetRoster(restricted) failed: %v", err)
+	}
+	if roster.Students[0].JoinCode != aliceCode || roster.Students[1].JoinCode == "" {
+		t.Fatalf("roster = (%+v), want alice to keep %s and carol to get a code", roster.Students, aliceCode)
+	}
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "guest", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); !errors.Is(err, ErrNotOnRoster) {
+		t.Fatalf("SubmitResponses(guest, restricted) error = (%v), want ErrNotOnRoster", err)
+	}
+	if _, err := service.SubmitResponses(ctx, "quiz-1", "ALICE", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err != nil {
+		t.Fatalf("SubmitResponses(alice, restricted) failed: %v", err)
+	}
+
+	student, err := service.JoinQuiz(ctx, "quiz-1", strings.ToLower(aliceCode))
+	if err != nil || student.Username != "alice" {
+		t.Fatalf("JoinQuiz = (%+v, %v), want alice", student, err)
+	}
+	if _, err := service.JoinQuiz(ctx, "quiz-1", "NOPE00"); !errors.Is(err, ErrUnknownJoinCode) {
+		t.Fatalf("JoinQuiz(unknown) error = (%v), want ErrUnknownJoinCode", err)
+	}
+
+	_, standings, err := service.RosterStandings(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("RosterStandings failed: %v", err)
+	}
+	if len(standings) != 3 {
+		t.Fatalf("standings = (%+v), want guest, alice, carol", standings)
+	}
+	if got := standings[0]; got.Username != "guest" || got.OnRoster || got.Rank != 1 {
+		t.Fatalf("standings[0] = (%+v), want unrostered guest ranked 1", got)
+	}
+	if got := standings[1]; got.Name != "Alice Smith" || !got.OnRoster || got.Rank != 2 || got.Entry.TotalScore != 1 {
+		t.Fatalf("standings[1] = (%+v), want Alice Smith ranked 2", got)
+	}
+	if got := standings[2]; got.Username != "carol" || got.Rank != 0 {
+		t.Fatalf("standings[2] = (%+v), want carol without a rank", got)
+	}
+}
+
+type fakeIdentityQuizRepo struct {
+	*fakeQuizRepo
+	identities map[string]Identity
+}
+
+func (f *fakeIdentityQuizRepo) GetIdentity(_ context.Context, usernameNormalized string) (Identity, error) {
+	return f.identities[usernameNormalized], nil
+}
+
+func (f *fakeIdentityQuizRepo) SaveIdentity(_ context.Context, identity Identity) error {
+	f.identities[identity.Username] = identity
+	return nil
+}
+
+func TestServiceIdentityVerificationAndPlayerToken(t *testing.T) {
+	repo := &fakeIdentityQuizRepo{fakeQuizRepo: newFakeQuizRepo(), identities: make(map[string]Identity)}
+	var sent []IdentityMessage
+	service := NewServiceWithOptions(repo, &fakeAttemptRepo{}, nil, ServiceOptions{
+		IdentityMailer: func(_ context.Context, email string, message IdentityMessage) error {
+			if email != "alice@example.com" {
+				t.Errorf("mailed %q, want alice@example.com", email)
+			}
+			sent = append(sent, message)
+			return nil
+		},
+	})
+	ctx := context.Background()
+
+	if _, err := service.StartIdentityVerification(ctx, "alice", "not an email"); !errors.Is(err, ErrInvalidEmail) {
+		t.Fatalf("StartIdentityVerification(bad email) error = (%v), want ErrInvalidEmail", err)
+	}
+	// Unverified usernames need no token.
+	if err := service.AuthenticatePlayer(ctx, "alice", ""); err != nil {
+		t.Fatalf("AuthenticatePlayer(unverified) failed: %v", err)
+	}
+
+	if _, err := service.StartIdentityVerification(ctx, " Alice ", "Alice@Example.com"); err != nil {
+		t.Fatalf("StartIdentityVerification failed: %v", err)
+	}
+	if len(sent) != 1 || sent[0].Username != "alice" || len(sent[0].Code) != identityCodeDigits || sent[0].LinkToken == "" {
+		t.Fatalf("sent = (%+v), want one message for alice with a code and link token", sent)
+	}
+	wrong := "000000"
+	if sent[0].Code == wrong {
+		wrong = "111111"
+	}
+	if _, _, err := service.VerifyIdentity(ctx, "alice", wrong, ""); !errors.Is(err, ErrInvalidIdentityCode) {
+		t.Fatalf("VerifyIdentity(wrong code) error = (%v), want ErrInvalidIdentityCode", err)
+	}
+	identity, playerToken, err := service.VerifyIdentity(ctx, "ALICE", sent[0].Code, "")
+	if err != nil {
+		t.Fatalf("VerifyIdentity failed: %v", err)
+	}
+	if identity.Email != "alice@example.com" || !identity.Verified() || playerToken == "" {
+		t.Fatalf("VerifyIdentity = (%+v, %q), want verified alice@example.com with a token", identity, playerToken)
+	}
+	// A code works once.
+	if _, _, err := service.VerifyIdentity(ctx, "alice", sent[0].Code, ""); !errors.Is(err, ErrInvalidIdentityCode) {
+		t.Fatalf("VerifyIdentity(reused code) error = (%v), want ErrInvalidIdentityCode", err)
+	}
+
+	if err := service.AuthenticatePlayer(ctx, "alice", ""); !errors.Is(err, ErrPlayerTokenRequired) {
+		t.Fatalf("AuthenticatePlayer(no token) error = (%v), want ErrPlayerTokenRequired", err)
+	}
+	if err := service.AuthenticatePlayer(ctx, "Alice", playerToken); err != nil {
+		t.Fatalf("AuthenticatePlayer(token) failed: %v", err)
+	}
+
+	if _, err := service.StartIdentityVerification(ctx, "alice", "mallory@example.com"); !errors.Is(err, ErrIdentityTaken) {
+		t.Fatalf("StartIdentityVerification(other email) error = (%v), want ErrIdentityTaken", err)
+	}
+
+	// Verifying again, say on a new device, replaces the token.
+	if _, err := service.StartIdentityVerification(ctx, "alice", "alice@example.com"); err != nil {
+		t.Fatalf("StartIdentityVerification(again) failed: %v", err)
+	}
+	if sent[1].Code == wrong {
+		wrong = "222222"
+	}
+	for range identityCodeAttempts {
+		_, _, _ = service.VerifyIdentity(ctx, "alice", wrong, "")
+	}
+	if _, _, err := service.VerifyIdentity(ctx, "alice", "", sent[1].LinkToken); !errors.Is(err, ErrInvalidIdentityCode) {
+		t.Fatalf("VerifyIdentity after %d wrong codes error = (%v), want ErrInvalidIdentityCode", identityCodeAttempts, err)
+	}
+	if _, err := service.StartIdentityVerification(ctx, "alice", "alice@example.com"); err != nil {
+		t.Fatalf("StartIdentityVerification(third) failed: %v", err)
+	}
+	_, newToken, err := service.VerifyIdentity(ctx, "alice", "", sent[2].LinkToken)
+	if err != nil {
+		t.Fatalf("VerifyIdentity(link) failed: %v", err)
+	}
+	if err := service.AuthenticatePlayer(ctx, "alice", playerToken); !errors.Is(err, ErrPlayerTokenRequired) {
+		t.Fatalf("AuthenticatePlayer(old token) error = (%v), want ErrPlayerTokenRequired", err)
+	}
+	if err := service.AuthenticatePlayer(ctx, "alice", newToken); err != nil {
+		t.Fatalf("AuthenticatePlayer(new token) failed: %v", err)
+	}
+}
+
+type fakeAuditAttemptRepo struct {
+	*fakeAttemptRepo
+	entries []AuditEntry
+}
+
+func (f *fakeAuditAttemptRepo) RecordAuditEntry(_ context.Context, entry AuditEntry) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func (f *fakeAuditAttemptRepo) ListAuditEntries(_ context.Context, quizID string) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	for _, entry := range f.entries {
+		if entry.QuizID == quizID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func TestServiceSubmitOnBehalfFlagsAndAuditsAnswers(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 2}
+	answeredAt := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	attempts := &fakeAuditAttemptRepo{fakeAttemptRepo: &fakeAttemptRepo{submitResults: []ResponseResult{
+		{QuestionID: "q1", Status: StatusCorrect},
+		{QuestionID: "q2", Status: StatusIncorrect},
+	}}}
+	service := NewService(repo, attempts, nil)
+
+	if _, err := service.SubmitOnBehalf(ctx, "quiz-1", "Alice", "  ", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); !errors.Is(err, ErrInvalidActingAdmin) {
+		t.Fatalf("SubmitOnBehalf without an admin error = %v, want ErrInvalidActingAdmin", err)
+	}
+	if attempts.submitCalls != 0 || len(attempts.entries) != 0 {
+		t.Fatalf("rejected submission stored %d batches and %d audit entries, want none", attempts.submitCalls, len(attempts.entries))
+	}
+
+	results, err := service.SubmitOnBehalf(ctx, "quiz-1", "Alice", " Dana ", []SubmittedResponse{
+		{QuestionID: "q1", Answer: "a", AnsweredAt: &answeredAt, KeyID: "key", Signature: "sig", ContentHash: "stale"},
+		{QuestionID: "q2", Answer: "B"},
+		{QuestionID: "q1", Answer: "B"},
+	})
+	if err != nil {
+		t.Fatalf("SubmitOnBehalf failed: %v", err)
+	}
+	if len(results) != 3 || results[0].Status != StatusCorrect || results[2].Status != StatusDuplicateInRequest {
+		t.Fatalf("results = %+v, want scored answers and the repeated question set aside", results)
+	}
+	stored := attempts.lastSubmitResponses
+	if attempts.lastSubmitUsername != "alice" || len(stored) != 2 {
+		t.Fatalf("stored %d responses for %q, want alice's two distinct answers", len(stored), attempts.lastSubmitUsername)
+	}
+	for _, response := range stored {
+		if response.SubmittedBy != "Dana" || response.AnsweredAt != nil || response.Signature != "" || response.ContentHash != "" || response.Bonus != 0 {
+			t.Fatalf("stored response = %+v, want it flagged as entered by Dana with client fields dropped", response)
+		}
+	}
+
+	entries, err := service.AuditEntries(ctx, "quiz-1")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("AuditEntries = (%+v, %v), want one entry", entries, err)
+	}
+	if entry := entries[0]; entry.Actor != "Dana" || entry.Action != AuditSubmitOnBehalf || entry.Username != "alice" || entry.Detail != "q1=a, q2=B" {
+		t.Fatalf("audit entry = %+v, want Dana entering alice's two answers", entry)
+	}
+
+	plain := NewService(repo, &fakeAttemptRepo{}, nil)
+	if _, err := plain.SubmitOnBehalf(ctx, "quiz-1", "alice", "Dana", []SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("SubmitOnBehalf without an audit log error = %v, want ErrUnsupported", err)
+	}
+}
+
+type fakeHostQuizRepo struct {
+	*fakeQuizRepo
+	hosts   map[string][]QuizHost
+	saveErr error
+}
+
+func (f *fakeHostQuizRepo) GetHosts(_ context.Context, quizID string) ([]QuizHost, error) {
+	return append([]QuizHost(nil), f.hosts[quizID]...), nil
+}
+
+func (f *fakeHostQuizRepo) SaveHosts(_ context.Context, quizID string, hosts []QuizHost) error {
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.hosts[quizID] = hosts
+	return nil
+}
+
+func TestServiceHostsTransferOwnershipAndAudit(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeHostQuizRepo{fakeQuizRepo: newFakeQuizRepo(), hosts: make(map[string][]QuizHost)}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1}
+	repo.metadataByQuiz["quiz-2"] = QuizMetadata{QuizID: "quiz-2", QuestionCount: 1}
+	attempts := &fakeAuditAttemptRepo{fakeAttemptRepo: &fakeAttemptRepo{}}
+	service := NewService(repo, attempts, nil)
+
+	// The first host owns the quiz even when added as a co-host.
+	first, firstToken, err := service.AddHost(ctx, "quiz-1", QuizHost{}, "Dana", " Alice ", HostCoHost)
+	if err != nil {
+		t.Fatalf("AddHost(alice) failed: %v", err)
+	}
+	if first.Username != "alice" || first.Role != HostOwner || firstToken == "" || first.TokenHash == firstToken {
+		t.Fatalf("first host = %+v, want alice as owner with a hashed token", first)
+	}
+	_, bobToken, err := service.AddHost(ctx, "quiz-1", first, "alice", "bob", "")
+	if err != nil {
+		t.Fatalf("AddHost(bob) failed: %v", err)
+	}
+	if _, _, err := service.AddHost(ctx, "quiz-1", first, "alice", "carol", "boss"); !errors.Is(err, ErrInvalidHost) {
+		t.Fatalf("AddHost with an unknown role error = %v, want ErrInvalidHost", err)
+	}
+
+	// A co-host can neither take over nor replace another host's token.
+	bob := QuizHost{Username: "bob", Role: HostCoHost}
+	if _, _, err := service.AddHost(ctx, "quiz-1", bob, "bob", "carol", HostOwner); !errors.Is(err, ErrHostForbidden) {
+		t.Fatalf("co-host transferring ownership error = %v, want ErrHostForbidden", err)
+	}
+	if _, _, err := service.AddHost(ctx, "quiz-1", bob, "bob", "alice", ""); !errors.Is(err, ErrHostForbidden) {
+		t.Fatalf("co-host replacing the owner's token error = %v, want ErrHostForbidden", err)
+	}
+
+	// Alice drops out; the admin hands ownership to carol, and alice stays a
+	// co-host.
+	if _, _, err := service.AddHost(ctx, "quiz-1", QuizHost{}, "bob", "carol", HostOwner); err != nil {
+		t.Fatalf("AddHost(carol, owner) failed: %v", err)
+	}
+	hosts, err := service.Hosts(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("Hosts failed: %v", err)
+	}
+	got := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		got = append(got, host.Username+"="+string(host.Role))
+	}
+	if want := "carol=owner, alice=co_host, bob=co_host"; strings.Join(got, ", ") != want {
+		t.Fatalf("hosts = %s, want %s", strings.Join(got, ", "), want)
+	}
+
+	if host, err := service.AuthenticateHost(ctx, "quiz-1", bobToken); err != nil || host.Username != "bob" {
+		t.Fatalf("AuthenticateHost(bob's token) = (%+v, %v), want bob", host, err)
+	}
+	if _, err := service.AuthenticateHost(ctx, "quiz-2", bobToken); !errors.Is(err, ErrInvalidHostToken) {
+		t.Fatalf("AuthenticateHost on another quiz error = %v, want ErrInvalidHostToken", err)
+	}
+
+	// Re-adding a host replaces their token and keeps their role.
+	if _, newToken, err := service.AddHost(ctx, "quiz-1", QuizHost{Username: "alice", Role: HostCoHost}, "alice", "alice", ""); err != nil || newToken == firstToken {
+		t.Fatalf("re-adding alice = (%q, %v), want a fresh token", newToken, err)
+	}
+	if _, err := service.AuthenticateHost(ctx, "quiz-1", firstToken); !errors.Is(err, ErrInvalidHostToken) {
+		t.Fatalf("AuthenticateHost(replaced token) error = %v, want ErrInvalidHostToken", err)
+	}
+
+	var actions []string
+	for _, entry := range attempts.entries {
+		actions = append(actions, entry.Actor+":"+entry.Action+":"+entry.Username+":"+entry.Detail)
+	}
+	want := []string{
+		"Dana:host_added:alice:role=owner",
+		"alice:host_added:bob:role=co_host",
+		"bob:ownership_transferred:carol:from=alice",
+		"alice:host_added:alice:role=co_host, token replaced",
+	}
+	if strings.Join(actions, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("audit entries =\n%s\nwant\n%s", strings.Join(actions, "\n"), strings.Join(want, "\n"))
+	}
+
+	plain := NewService(repo, &fakeAttemptRepo{}, nil)
+	if _, _, err := plain.AddHost(ctx, "quiz-1", QuizHost{}, "Dana", "erin", ""); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("AddHost without an audit log error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestServiceAddHostSerializesChangesAndAuditsOnlySavedOnes(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeHostQuizRepo{fakeQuizRepo: newFakeQuizRepo(), hosts: make(map[string][]QuizHost)}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 1}
+	attempts := &fakeAuditAttemptRepo{fakeAttemptRepo: &fakeAttemptRepo{}}
+	service := NewService(repo, attempts, nil)
+
+	// Concurrent adds must not read the same list and drop each other.
+	var wg sync.WaitGroup
+	for idx := 0; idx < 8; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			if _, _, err := service.AddHost(ctx, "quiz-1", QuizHost{}, "dana", fmt.Sprintf("host%d", idx), ""); err != nil {
+				t.Errorf("AddHost(host%d) failed: %v", idx, err)
+			}
+		}(idx)
+	}
+	wg.Wait()
+	if hosts := repo.hosts["quiz-1"]; len(hosts) != 8 {
+		t.Fatalf("hosts after concurrent adds = %d, want 8", len(hosts))
+	}
+	if len(attempts.entries) != 8 {
+		t.Fatalf("audit entries = %d, want 8", len(attempts.entries))
+	}
+
+	repo.saveErr = errors.New("disk full")
+	if _, _, err := service.AddHost(ctx, "quiz-1", QuizHost{}, "dana", "erin", ""); !errors.Is(err, repo.saveErr) {
+		t.Fatalf("AddHost with a failing save error = %v, want %v", err, repo.saveErr)
+	}
+	if len(attempts.entries) != 8 {
+		t.Fatalf("audit entries after a failed save = %d, want 8", len(attempts.entries))
+	}
+	if len(service.hostChanges.locks) != 0 {
+		t.Fatalf("host locks left behind = %d, want 0", len(service.hostChanges.locks))
+	}
+}
+
+type fakeReshuffleQuizRepo struct {
+	*fakeQuizRepo
+	answered map[string]bool
+}
+
+func (f *fakeReshuffleQuizRepo) ReplaceQuizQuestion(_ context.Context, quizID, oldQuestionID string, question Question) error {
+	if f.answered[oldQuestionID] {
+		return ErrQuestionAnswered
+	}
+	questions := f.questionsByQuiz[quizID]
+	for idx := range questions {
+		if questions[idx].QuestionID == oldQuestionID {
+			questions[idx] = question
+			return nil
+		}
+	}
+	return ErrQuestionNotFound
+}
+
+func TestServiceRebalanceAnswerPositions(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeReshuffleQuizRepo{fakeQuizRepo: newFakeQuizRepo(), answered: make(map[string]bool)}
+	questions := make([]Question, 0, 8)
+	for idx := range 8 {
+		question := Question{
+			PublicQuestion: PublicQuestion{
+				Question: fmt.Sprintf("Question %d?", idx),
+				Options:  []Option{{Letter: "A", Text: "w"}, {Letter: "B", Text: "right"}, {Letter: "C", Text: "y"}, {Letter: "D", Text: "z"}},
+			},
+			CorrectIndex: 1,
+			Feedback:     []string{"no w", "yes", "no y", "no z"},
+		}
+		question.QuestionID = MakeQuestionID(question)
+		questions = append(questions, question)
+	}
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 8}
+	repo.questionsByQuiz["quiz-1"] = questions
+	answeredID := questions[0].QuestionID
+	repo.answered[answeredID] = true
+	service := NewService(repo, &fakeAttemptRepo{}, nil)
+
+	report, err := service.AnswerPositions(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("AnswerPositions failed: %v", err)
+	}
+	if len(report.Letters) != 4 || report.Letters[1].Correct != 8 || report.Letters[1].Expected != 2 || !report.Letters[1].Outlier {
+		t.Fatalf("report letters = %+v, want B correct 8 times against 2 expected", report.Letters)
+	}
+
+	report, err = service.RebalanceAnswerPositions(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("RebalanceAnswerPositions failed: %v", err)
+	}
+	for _, count := range report.Letters {
+		if count.Correct != 2 || count.Outlier {
+			t.Fatalf("letters after rebalance = %+v, want each letter correct twice", report.Letters)
+		}
+	}
+	if len(report.Moved) != 6 || len(report.Kept) != 1 || report.Kept[0] != answeredID {
+		t.Fatalf("moved %d and kept %v, want six moved and the answered question kept", len(report.Moved), report.Kept)
+	}
+	for _, question := range repo.questionsByQuiz["quiz-1"] {
+		correct := question.Options[question.CorrectIndex]
+		if correct.Text != "right" || question.Feedback[question.CorrectIndex] != "yes" || question.QuestionID != MakeQuestionID(question) {
+			t.Fatalf("question after rebalance = %+v, want the right answer and its feedback moved together under a new ID", question)
+		}
+	}
+
+	// Past its close time a quiz counts as locked.
+	repo.metadataByQuiz["quiz-2"] = QuizMetadata{QuizID: "quiz-2", QuestionCount: 8, ClosesAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+	repo.questionsByQuiz["quiz-2"] = questions
+	service.now = func() time.Time { return time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC) }
+	if _, err := service.RebalanceAnswerPositions(ctx, "quiz-2"); !errors.Is(err, ErrQuizLocked) {
+		t.Fatalf("RebalanceAnswerPositions after lock error = %v, want ErrQuizLocked", err)
+	}
+	if _, err := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, nil).RebalanceAnswerPositions(ctx, "quiz-1"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("RebalanceAnswerPositions without store support error = %v, want ErrUnsupported", err)
+	}
+}
+
+type fakeArchiverAttemptRepo struct {
+	*fakeUserHistoryRepo
+	idle       []string
+	idleCutoff time.Time
+	// published reports whether results existed when each quiz was archived.
+	published map[string]bool
+	results   *fakeResultsQuizRepo
+}
+
+func (f *fakeArchiverAttemptRepo) IdleQuizzes(_ context.Context, cutoff time.Time) ([]string, error) {
+	f.idleCutoff = cutoff
+	return f.idle, nil
+}
+
+func (f *fakeArchiverAttemptRepo) ArchiveAttempts(_ context.Context, quizID string, _ time.Time) (int, error) {
+	f.published[quizID] = f.results.results[quizID].Document != nil
+	return len(f.byUser), nil
+}
+
+func (f *fakeArchiverAttemptRepo) AttemptSummaries(context.Context, string) ([]AttemptSummary, error) {
+	return nil, nil
+}
+
+func TestServiceEnforceAttemptRetentionPublishesBeforeArchiving(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeResultsQuizRepo{
+		fakeLeaderboardQuizRepo: &fakeLeaderboardQuizRepo{fakeQuizRepo: newFakeQuizRepo(), settings: make(map[string]LeaderboardSettings)},
+		results:                 make(map[string]PublishedResults),
+	}
+	question := Question{PublicQuestion: PublicQuestion{QuestionID: "q1", Question: "One?", Options: []Option{{Letter: "A", Text: "yes"}, {Letter: "B", Text: "no"}}}}
+	repo.metadataByQuiz["closed"] = QuizMetadata{QuizID: "closed", QuestionCount: 1, ClosesAt: now.AddDate(0, -1, 0)}
+	repo.questionsByQuiz["closed"] = []Question{question}
+	repo.metadataByQuiz["open"] = QuizMetadata{QuizID: "open", QuestionCount: 1}
+	repo.questionsByQuiz["open"] = []Question{question}
+	attempts := &fakeArchiverAttemptRepo{
+		fakeUserHistoryRepo: &fakeUserHistoryRepo{
+			fakeAttemptRepo: &fakeAttemptRepo{leaderboard: []LeaderboardEntry{{Username: "alice", TotalScore: 1, AnsweredCount: 1}}},
+			byUser:          map[string][]Attempt{"alice": {{QuestionID: "q1", AnswerLetter: "A", Score: 1, SubmittedAt: now.AddDate(0, -2, 0)}}},
+		},
+		idle:      []string{"closed", "missing", "open"},
+		published: make(map[string]bool),
+		results:   repo,
+	}
+	service := NewService(repo, attempts, nil)
+	service.now = func() time.Time { return now }
+
+	report, err := service.EnforceAttemptRetention(ctx, 30*24*time.Hour)
+	if err == nil || !errors.Is(err, ErrQuizNotFound) {
+		t.Fatalf("EnforceAttemptRetention error = %v, want the missing quiz reported", err)
+	}
+	if report.Quizzes != 1 || report.Attempts != 1 || report.Unlocked != 1 || report.Failed != 1 {
+		t.Fatalf("report = %+v, want the closed quiz archived, the open one skipped, and the missing one failed", report)
+	}
+	if !attempts.idleCutoff.Equal(now.AddDate(0, 0, -30)) {
+		t.Fatalf("idle cutoff = %v, want 30 days before now", attempts.idleCutoff)
+	}
+	if len(attempts.published) != 1 || !attempts.published["closed"] {
+		t.Fatalf("archived quizzes = %v, want only closed, with its results already published", attempts.published)
+	}
+
+	if _, err := service.EnforceAttemptRetention(ctx, 0); err == nil {
+		t.Fatal("EnforceAttemptRetention with no max age succeeded, want an error")
+	}
+	if _, err := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, nil).EnforceAttemptRetention(ctx, time.Hour); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("EnforceAttemptRetention without store support error = %v, want ErrUnsupported", err)
+	}
+}
+
+type fakeAccountQuizRepo struct {
+	*fakeQuizRepo
+	accounts   map[string]Account
+	identities map[string]Identity
+}
+
+func (f *fakeAccountQuizRepo) CreateAccount(_ context.Context, account Account) error {
+	if _, ok := f.accounts[account.Username]; ok {
+		return ErrAccountExists
+	}
+	f.accounts[account.Username] = account
+	return nil
+}
+
+func (f *fakeAccountQuizRepo) GetAccount(_ context.Context, usernameNormalized string) (Account, error) {
+	return f.accounts[usernameNormalized], nil
+}
+
+func (f *fakeAccountQuizRepo) GetIdentity(_ context.Context, usernameNormalized string) (Identity, error) {
+	return f.identities[usernameNormalized], nil
+}
+
+func (f *fakeAccountQuizRepo) SaveIdentity(_ context.Context, identity Identity) error {
+	f.identities[identity.Username] = identity
+	return nil
+}
+
+func TestServiceAccountsRegisterLoginAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeAccountQuizRepo{fakeQuizRepo: newFakeQuizRepo(), accounts: make(map[string]Account), identities: make(map[string]Identity)}
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	service := New(Config{Quizzes: repo, Attempts: &fakeAttemptRepo{}, ServiceOptions: ServiceOptions{
+		Now:             func() time.Time { return now },
+		AccountTokenKey: []byte("secret"),
+		AccountTokenTTL: time.Hour,
+	}})
+
+	if _, err := service.Register(ctx, "Alice", "short", ""); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("Register(short password) error = %v, want ErrInvalidPassword", err)
+	}
+	account, err := service.Register(ctx, " Alice ", "correct horse", "")
+	if err != nil || account.Username != "alice" || !account.CreatedAt.Equal(now) || strings.Contains(account.PasswordHash, "correct horse") {
+		t.Fatalf("Register = (%+v, %v), want alice with a hashed password", account, err)
+	}
+	if _, err := service.Register(ctx, "ALICE", "another password", ""); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("Register(taken) error = %v, want ErrAccountExists", err)
+	}
+
+	for _, login := range [][2]string{{"alice", "wrong password"}, {"bob", "correct horse"}} {
+		if _, err := service.Login(ctx, login[0], login[1]); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Login(%s, %s) error = %v, want ErrInvalidCredentials", login[0], login[1], err)
+		}
+	}
+	// Unknown usernames are checked against a full-cost hash too.
+	if hash := unknownAccountHash(); !strings.HasPrefix(hash, fmt.Sprintf("%s$%d$", passwordHashScheme, passwordIterations)) {
+		t.Fatalf("unknownAccountHash = %q, want a %d-iteration hash", hash, passwordIterations)
+	}
+	token, err := service.Login(ctx, "Alice", "correct horse")
+	if err != nil || token.Username != "alice" || !token.ExpiresAt.Equal(now.Add(time.Hour)) || strings.Count(token.Token, ".") != 2 {
+		t.Fatalf("Login = (%+v, %v), want a JWT for alice expiring in an hour", token, err)
+	}
+
+	credentials := PlayerCredentials{AccountToken: token.Token}
+	for _, username := range []string{"", "ALICE"} {
+		if got, err := service.AuthenticateSubmitter(ctx, username, credentials); err != nil || got != "alice" {
+			t.Fatalf("AuthenticateSubmitter(%q, token) = (%q, %v), want alice", username, got, err)
+		}
+	}
+	if _, err := service.AuthenticateSubmitter(ctx, "bob", credentials); !errors.Is(err, ErrAccountMismatch) {
+		t.Fatalf("AuthenticateSubmitter(bob, alice's token) error = %v, want ErrAccountMismatch", err)
+	}
+	if _, err := service.AuthenticateSubmitter(ctx, "alice", PlayerCredentials{}); !errors.Is(err, ErrLoginRequired) {
+		t.Fatalf("AuthenticateSubmitter(alice, no token) error = %v, want ErrLoginRequired", err)
+	}
+	if got, err := service.AuthenticateSubmitter(ctx, "bob", PlayerCredentials{}); err != nil || got != "bob" {
+		t.Fatalf("AuthenticateSubmitter(unregistered bob) = (%q, %v), want bob as before", got, err)
+	}
+
+	// Tokens signed with another key, altered, or expired are refused.
+	other := New(Config{Quizzes: repo, Attempts: &fakeAttemptRepo{}, ServiceOptions: ServiceOptions{
+		Now:             func() time.Time { return now },
+		AccountTokenKey: []byte("other secret"),
+	}})
+	forged, err := other.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Login on the other service failed: %v", err)
+	}
+	parts := strings.Split(token.Token, ".")
+	altered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"quiz-app","sub":"bob","iat":0,"exp":9999999999}`)) + "." + parts[2]
+	for _, bad := range []string{forged.Token, altered, "not-a-token"} {
+		if _, err := service.AuthenticateAccount(bad); !errors.Is(err, ErrInvalidAccountToken) {
+			t.Fatalf("AuthenticateAccount(%q) error = %v, want ErrInvalidAccountToken", bad, err)
+		}
+	}
+	now = now.Add(time.Hour)
+	if _, err := service.AuthenticateSubmitter(ctx, "", credentials); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("AuthenticateSubmitter(expired token) error = %v, want ErrInvalidAccountToken", err)
+	}
+}
+
+func TestServiceAccountsStackWithVerifiedIdentities(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeAccountQuizRepo{fakeQuizRepo: newFakeQuizRepo(), accounts: make(map[string]Account), identities: make(map[string]Identity)}
+	repo.identities["alice"] = Identity{Username: "alice", Email: "alice@example.com", TokenHash: hashSecret("player-token")}
+	service := New(Config{Quizzes: repo, Attempts: &fakeAttemptRepo{}, ServiceOptions: ServiceOptions{RequireLogin: true}})
+
+	// Only the identity's owner may register a verified username.
+	if _, err := service.Register(ctx, "alice", "correct horse", ""); !errors.Is(err, ErrPlayerTokenRequired) {
+		t.Fatalf("Register(verified, no player token) error = %v, want ErrPlayerTokenRequired", err)
+	}
+	if _, err := service.Register(ctx, "alice", "correct horse", "player-token"); err != nil {
+		t.Fatalf("Register with player token failed: %v", err)
+	}
+	token, err := service.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if _, err := service.AuthenticateSubmitter(ctx, "", PlayerCredentials{AccountToken: token.Token}); !errors.Is(err, ErrPlayerTokenRequired) {
+		t.Fatalf("AuthenticateSubmitter(account token only) error = %v, want ErrPlayerTokenRequired", err)
+	}
+	if got, err := service.AuthenticateSubmitter(ctx, "", PlayerCredentials{AccountToken: token.Token, PlayerToken: "player-token"}); err != nil || got != "alice" {
+		t.Fatalf("AuthenticateSubmitter(both tokens) = (%q, %v), want alice", got, err)
+	}
+	// RequireLogin covers unregistered usernames too.
+	if _, err := service.AuthenticateSubmitter(ctx, "bob", PlayerCredentials{}); !errors.Is(err, ErrLoginRequired) {
+		t.Fatalf("AuthenticateSubmitter(bob) under RequireLogin error = %v, want ErrLoginRequired", err)
+	}
+}
+
+func TestPBKDF2SHA256MatchesRFC7914Vectors(t *testing.T) {
+	for _, tc := range []struct {
+		password, salt string
+		iterations     int
+		want           string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	} {
+		got := hex.EncodeToString(pbkdf2SHA256([]byte(tc.password), []byte(tc.salt), tc.iterations, 64))
+		if got != tc.want {
+			t.Fatalf("pbkdf2SHA256(%s, %s, %d) = %s, want %s", tc.password, tc.salt, tc.iterations, got, tc.want)
+		}
+	}
+}
+
+func TestServiceReviewAttemptRevealsAnswersOnceFinishedOrLocked(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	options := []Option{{Letter: "A", Text: "a"}, {Letter: "B", Text: "b"}, {Letter: "C", Text: "c"}}
+	repo := newFakeQuizRepo()
+	repo.metadataByQuiz["quiz-1"] = QuizMetadata{QuizID: "quiz-1", QuestionCount: 3, CreatedAt: base}
+	repo.questionsByQuiz["quiz-1"] = []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "q1", Question: "one?", Options: options}, CorrectIndex: 0},
+		{PublicQuestion: PublicQuestion{QuestionID: "q2", Question: "two?", Options: options, Type: TypeMultiSelect}, CorrectIndex: 0, CorrectIndexes: []int{0, 2}},
+		{PublicQuestion: PublicQuestion{QuestionID: "q3", Question: "three?", Options: options}, CorrectIndex: 1, Voided: true},
+	}
+	attempts := &fakeUserHistoryRepo{fakeAttemptRepo: &fakeAttemptRepo{}, byUser: map[string][]Attempt{
+		"alice": {{QuestionID: "q1", AnswerLetter: "B", SubmittedAt: base.Add(time.Minute)}},
+	}}
+	service := NewService(repo, attempts, nil)
+	service.now = func() time.Time { return base.Add(time.Hour) }
+
+	review, err := service.ReviewAttempt(ctx, "quiz-1", " Alice ")
+	if err != nil {
+		t.Fatalf("ReviewAttempt failed: %v", err)
+	}
+	if review.Username != "alice" || review.Completed || review.Locked || review.Revealed || review.AnsweredCount != 1 || len(review.Questions) != 3 {
+		t.Fatalf("unfinished review = %+v, want alice's one answer without the key", review)
+	}
+	statuses := []string{review.Questions[0].Status, review.Questions[1].Status, review.Questions[2].Status}
+	if strings.Join(statuses, " ") != "incorrect unanswered voided_question" || review.Questions[0].ChosenLetter != "B" {
+		t.Fatalf("unfinished statuses = %v, chosen %q", statuses, review.Questions[0].ChosenLetter)
+	}
+	for _, question := range review.Questions {
+		if question.CorrectLetter != "" || question.CorrectText != "" {
+			t.Fatalf("unfinished review reveals %s: %+v", question.QuestionID, question)
+		}
+	}
+
+	attempts.byUser["alice"] = append(attempts.byUser["alice"], Attempt{QuestionID: "q2", AnswerLetter: "A", Score: 0.5, SubmittedAt: base.Add(2 * time.Minute)})
+	review, err = service.ReviewAttempt(ctx, "quiz-1", "alice")
+	if err != nil || !review.Completed || !review.Revealed || review.TotalScore != 0.5 {
+		t.Fatalf("finished review = (%+v, %v), want it complete and revealed", review, err)
+	}
+	if got := review.Questions[1]; got.Status != StatusPartiallyCorrect || got.CorrectLetter != "A,C" || got.CorrectText != "a; c" {
+		t.Fatalf("multi-select review = %+v, want partial credit and both answers", got)
+	}
+	if got := review.Questions[0]; got.CorrectLetter != "A" || got.CorrectText != "a" {
+		t.Fatalf("single review = %+v, want answer A", got)
+	}
+	if hidden := review.WithoutAnswers(); hidden.Revealed || hidden.Questions[0].CorrectLetter != "" || review.Questions[0].CorrectLetter != "A" {
+		t.Fatalf("WithoutAnswers = %+v, want the key removed from a copy", hidden)
+	}
+
+	review, err = service.ReviewAttempt(ctx, "quiz-1", "bob")
+	if err != nil || review.Completed || review.Revealed || review.AnsweredCount != 0 {
+		t.Fatalf("empty review = (%+v, %v), want nothing revealed", review, err)
+	}
+	metadata := repo.metadataByQuiz["quiz-1"]
+	metadata.Locked = true
+	repo.metadataByQuiz["quiz-1"] = metadata
+	service.dropCachedQuiz("quiz-1")
+	review, err = service.ReviewAttempt(ctx, "quiz-1", "bob")
+	if err != nil || !review.Locked || !review.Revealed || review.Questions[0].CorrectLetter != "A" {
+		t.Fatalf("locked review = (%+v, %v), want the key revealed", review, err)
+	}
+
+	if _, err := NewService(repo, &fakeAttemptRepo{}, nil).ReviewAttempt(ctx, "quiz-1", "alice"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("ReviewAttempt without attempt history error = %v, want ErrUnsupported", err)
+	}
+}
+
+type fakeTagQuizRepo struct {
+	*fakeRetirementQuizRepo
+	stored map[string]Question
+	tags   map[string][]string
+}
+
+func (f *fakeTagQuizRepo) SetQuestionTags(_ context.Context, questionID string, tags []string) error {
+	if _, ok := f.stored[questionID]; !ok {
+		return ErrUnknownQuestion
+	}
+	f.tags[questionID] = tags
+	return nil
+}
+
+func (f *fakeTagQuizRepo) QuestionTags(_ context.Context, questionID string) ([]string, error) {
+	if _, ok := f.stored[questionID]; !ok {
+		return nil, ErrUnknownQuestion
+	}
+	return f.tags[questionID], nil
+}
+
+// SampleBankQuestions returns matches in ID order, so tests can tell which
+// questions were drawn.
+func (f *fakeTagQuizRepo) SampleBankQuestions(_ context.Context, filter BankFilter, limit int) ([]Question, error) {
+	ids := make([]string, 0, len(f.stored))
+	for questionID := range f.stored {
+		ids = append(ids, questionID)
+	}
+	sort.Strings(ids)
+	sampled := make([]Question, 0, limit)
+	for _, questionID := range ids {
+		question := f.stored[questionID]
+		if len(sampled) == limit || (filter.Difficulty != "" && question.Difficulty != filter.Difficulty) {
+			continue
+		}
+		have := strings.Join(f.tags[questionID], ",") + ","
+		matches := true
+		for _, tag := range filter.Tags {
+			matches = matches && strings.Contains(have, tag+",")
+		}
+		if matches {
+			sampled = append(sampled, question)
+		}
+	}
+	return sampled, nil
+}
+
+func TestServiceCreateBankQuizzesSamplesTaggedQuestions(t *testing.T) {
+	repo := &fakeTagQuizRepo{
+		fakeRetirementQuizRepo: &fakeRetirementQuizRepo{fakeQuizRepo: newFakeQuizRepo(), reviews: make(map[string]RetirementReview)},
+		stored:                 make(map[string]Question),
+		tags:                   make(map[string][]string),
+	}
+	for idx := 1; idx <= 6; idx++ {
+		question := Question{
+			PublicQuestion: PublicQuestion{
+				QuestionID: fmt.Sprintf("b%d", idx),
+				Question:   fmt.Sprintf("Bank %d?", idx),
+				Options:    []Option{{Letter: "A", Text: "Yes"}, {Letter: "B", Text: "No"}},
+			},
+			Difficulty: DifficultyEasy,
+		}
+		repo.stored[question.QuestionID] = question
+		repo.tags[question.QuestionID] = []string{"geo"}
+	}
+	// b6 has no tags, and b5 is retired.
+	delete(repo.tags, "b6")
+	repo.reviews["b5"] = RetirementReview{Question: repo.stored["b5"], Status: RetirementRetired}
+	service := NewService(repo, &fakeAttemptRepo{}, nil)
+	ctx := context.Background()
+
+	tags, err := service.SetQuestionTags(ctx, " b1 ", []string{"Geo", " geo ", "week-1"})
+	if err != nil || strings.Join(tags, ",") != "geo,week-1" {
+		t.Fatalf("SetQuestionTags = (%v, %v), want geo,week-1", tags, err)
+	}
+	if tags, err := service.QuestionTags(ctx, "b1"); err != nil || strings.Join(tags, ",") != "geo,week-1" {
+		t.Fatalf("QuestionTags = (%v, %v), want geo,week-1", tags, err)
+	}
+	if _, err := service.SetQuestionTags(ctx, "b1", []string{"two words"}); !errors.Is(err, ErrInvalidTag) {
+		t.Fatalf("SetQuestionTags(two words) error = %v, want ErrInvalidTag", err)
+	}
+	if _, err := service.SetQuestionTags(ctx, "missing", []string{"geo"}); !errors.Is(err, ErrUnknownQuestion) {
+		t.Fatalf("SetQuestionTags(missing) error = %v, want ErrUnknownQuestion", err)
+	}
+
+	created, err := service.CreateBankQuizzes(ctx, BankQuizOptions{
+		BankFilter:    BankFilter{Tags: []string{"GEO"}, Difficulty: DifficultyEasy},
+		QuestionCount: 2,
+		QuizCount:     2,
+	})
+	if err != nil {
+		t.Fatalf("CreateBankQuizzes failed: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("CreateBankQuizzes created %d quizzes, want 2", len(created))
+	}
+	seen := make(map[string]bool)
+	for _, item := range created {
+		if item.Metadata.QuestionCount != 2 || item.Metadata.Origin.Provider != ProviderBank || item.Metadata.Origin.Fetched() {
+			t.Fatalf("bank quiz metadata = (%+v), want 2 questions from the bank", item.Metadata)
+		}
+		for _, question := range item.Questions {
+			if seen[question.QuestionID] || question.QuestionID == "b5" || question.QuestionID == "b6" {
+				t.Fatalf("bank quizzes drew %s, want each tagged, unretired question at most once", question.QuestionID)
+			}
+			seen[question.QuestionID] = true
+		}
+		if stored := repo.questionsByQuiz[item.Metadata.QuizID]; len(stored) != 2 {
+			t.Fatalf("stored questions = (%d), want 2", len(stored))
+		}
+	}
+
+	// Four questions match once b5 is left out, so two quizzes of three are
+	// too many.
+	createCalls := repo.createCalls
+	if _, err := service.CreateBankQuizzes(ctx, BankQuizOptions{BankFilter: BankFilter{Tags: []string{"geo"}}, QuestionCount: 3, QuizCount: 2}); !errors.Is(err, ErrBankTooSmall) {
+		t.Fatalf("CreateBankQuizzes(6 of 4) error = %v, want ErrBankTooSmall", err)
+	}
+	if repo.createCalls != createCalls {
+		t.Fatalf("CreateBankQuizzes created %d quizzes after failing, want none", repo.createCalls-createCalls)
+	}
+	if _, err := service.CreateBankQuizzes(ctx, BankQuizOptions{QuestionCount: 1, QuizCount: MaxBankQuizzes + 1}); !errors.Is(err, ErrInvalidQuestion) {
+		t.Fatalf("CreateBankQuizzes(too many quizzes) error = %v, want ErrInvalidQuestion", err)
+	}
+
+	if _, err := NewService(newFakeQuizRepo(), &fakeAttemptRepo{}, nil).CreateBankQuizzes(ctx, BankQuizOptions{QuestionCount: 1}); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("CreateBankQuizzes without a tag store error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestServiceRedistributableChecksRestrictedSources(t *testing.T) {
+	service := NewServiceWithOptions(&fakeQuizRepo{}, nil, nil, ServiceOptions{RestrictedSources: []string{"triviaapi", "file"}})
+	for source, want := range map[string]bool{
+		"opentdb":          true,
+		"custom":           true,
+		"triviaapi":        false,
+		"file:biology.csv": false,
+		"filed":            true,
+	} {
+		if got := service.Redistributable(Question{Source: source}); got != want {
+			t.Fatalf("Redistributable(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
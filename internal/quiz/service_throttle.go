@@ -0,0 +1,121 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRateLimited reports a submission rejected by the per-user rate limit.
+	ErrRateLimited = errors.New("submission rate limit exceeded")
+	// ErrBatchTooLarge reports a batch with more answers than the rate limit
+	// ever lets through at once. Waiting does not help; the batch has to be
+	// split.
+	ErrBatchTooLarge = errors.New("answer batch too large")
+)
+
+// MaxQuizQuestions is the most questions a quiz can have.
+const MaxQuizQuestions = 50
+
+// RateLimitError is returned by SubmitResponses when a user answers faster than
+// the configured rate. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	// RetryAfter is how long the caller must wait before the same batch fits.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s; retry after %s", ErrRateLimited, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// maxIdleSubmitBuckets bounds limiter memory; full (idle) buckets are dropped
+// once the map grows past it since they carry no state worth keeping.
+const maxIdleSubmitBuckets = 1024
+
+// submitLimiter is a token bucket per quiz and user. Each answer in a batch costs
+// one token; tokens refill at rate per second up to burst.
+type submitLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*submitBucket
+}
+
+type submitBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newSubmitLimiter returns nil when rate is not positive, which disables throttling.
+// A non-positive burst defaults to one second's worth of answers, and at least
+// a whole quiz, so a client syncing a finished quiz in one batch is let through.
+func newSubmitLimiter(rate float64, burst int) *submitLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = max(int(math.Ceil(rate)), MaxQuizQuestions)
+	}
+	return &submitLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*submitBucket),
+	}
+}
+
+// allow takes cost tokens from key's bucket, or reports how long until they are
+// available. Batches larger than burst can never fit and fail with
+// ErrBatchTooLarge.
+func (l *submitLimiter) allow(key string, cost int, now time.Time) error {
+	if l == nil || cost <= 0 {
+		return nil
+	}
+	if float64(cost) > l.burst {
+		return fmt.Errorf("%w: %d answers, but at most %d are accepted at once; send them in smaller batches", ErrBatchTooLarge, cost, int(l.burst))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		l.pruneLocked(now)
+		bucket = &submitBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	l.refillLocked(bucket, now)
+
+	if bucket.tokens < float64(cost) {
+		missing := float64(cost) - bucket.tokens
+		return &RateLimitError{RetryAfter: time.Duration(missing / l.rate * float64(time.Second))}
+	}
+	bucket.tokens -= float64(cost)
+	return nil
+}
+
+func (l *submitLimiter) refillLocked(bucket *submitBucket, now time.Time) {
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed.Seconds()*l.rate)
+		bucket.updated = now
+	}
+}
+
+func (l *submitLimiter) pruneLocked(now time.Time) {
+	if len(l.buckets) < maxIdleSubmitBuckets {
+		return
+	}
+	for key, bucket := range l.buckets {
+		l.refillLocked(bucket, now)
+		if bucket.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
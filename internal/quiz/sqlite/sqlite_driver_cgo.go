@@ -0,0 +1,8 @@
+//go:build cgo && !modernc
+
+package sqlite
+
+import _ "github.com/mattn/go-sqlite3"
+
+// driverName selects mattn/go-sqlite3 for cgo-enabled builds (the default).
+const driverName = "sqlite3"
@@ -0,0 +1,9 @@
+//go:build !cgo || modernc
+
+package sqlite
+
+import _ "modernc.org/sqlite"
+
+// driverName selects the pure-Go modernc.org/sqlite driver. It is used
+// automatically for CGO_ENABLED=0 builds and can be forced with -tags modernc.
+const driverName = "sqlite"
@@ -4,20 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"strings"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 type SQLiteStore struct {
-	db *sql.DB
+	db *timedDB
 }
 
 func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	return NewSQLiteStoreWithOptions(path, StoreOptions{})
+}
+
+// NewSQLiteStoreWithOptions opens the store with statement timeouts and
+// slow-query logging; see StoreOptions.
+func NewSQLiteStoreWithOptions(path string, options StoreOptions) (*SQLiteStore, error) {
 	if strings.TrimSpace(path) == "" {
 		path = "quiz.db"
 	}
 
-	db, err := sql.Open("sqlite3", path)
+	db, err := sql.Open(driverName, path)
 	if err != nil {
 		return nil, err
 	}
@@ -28,8 +32,13 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 		_ = db.Close()
 		return nil, err
 	}
+	// Only takes effect on a new file; Maintain converts older ones.
+	if _, err := db.Exec(`PRAGMA auto_vacuum = INCREMENTAL;`); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 
-	store := &SQLiteStore{db: db}
+	store := &SQLiteStore{db: &timedDB{DB: db, options: options}}
 	if err := store.initSchema(context.Background()); err != nil {
 		_ = db.Close()
 		return nil, err
@@ -38,6 +47,12 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 	return store, nil
 }
 
+// Driver reports which database/sql driver this build links in ("sqlite3" for
+// mattn/go-sqlite3, "sqlite" for modernc.org/sqlite).
+func Driver() string {
+	return driverName
+}
+
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) CreateAccount(ctx context.Context, account quiz.Account) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO users (username_norm, password_hash, created_at_unix)
+		 VALUES (?, ?, ?)
+		 ON CONFLICT(username_norm) DO NOTHING`,
+		account.Username,
+		account.PasswordHash,
+		account.CreatedAt.UnixNano(),
+	)
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return quiz.ErrAccountExists
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetAccount(ctx context.Context, usernameNormalized string) (quiz.Account, error) {
+	account := quiz.Account{Username: usernameNormalized}
+	var createdAtUnix int64
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT password_hash, created_at_unix FROM users WHERE username_norm = ?`,
+		usernameNormalized,
+	).Scan(&account.PasswordHash, &createdAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.Account{}, nil
+	}
+	if err != nil {
+		return quiz.Account{}, err
+	}
+	account.CreatedAt = time.Unix(0, createdAtUnix).UTC()
+	return account, nil
+}
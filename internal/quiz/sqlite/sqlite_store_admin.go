@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// attemptRowOverhead approximates the fixed-width columns and row header of one
+// attempts row (score, timestamp, record framing) for storage estimates.
+const attemptRowOverhead = 24
+
+// ListQuizStats aggregates attempts and question payloads per quiz in one query.
+// Storage is estimated from stored text lengths rather than page accounting,
+// which SQLite does not expose per row.
+func (s *SQLiteStore) ListQuizStats(ctx context.Context) ([]quiz.QuizStats, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT z.quiz_id, z.question_count, z.requested_question_count, z.created_at_unix, z.locked, z.draft, z.closes_at_unix,
+			z.provider, z.difficulty_mix_json, z.seed, z.fallback_from, z.category, z.question_type, z.archived_at_unix,
+			COALESCE(a.attempt_count, 0), COALESCE(a.participant_count, 0), a.last_submission,
+			COALESCE(a.bytes, 0) + COALESCE(qb.bytes, 0) + LENGTH(z.quiz_id)
+		 FROM quizzes z
+		 LEFT JOIN (
+			SELECT quiz_id,
+				COUNT(*) AS attempt_count,
+				COUNT(DISTINCT username_norm) AS participant_count,
+				MAX(submitted_at_unix) AS last_submission,
+				SUM(LENGTH(quiz_id) + LENGTH(question_id) + LENGTH(username_norm) + LENGTH(answer_letter) + ?) AS bytes
+			FROM attempts
+			GROUP BY quiz_id
+		 ) a ON a.quiz_id = z.quiz_id
+		 LEFT JOIN (
+			SELECT qq.quiz_id, SUM(LENGTH(q.question_id) + LENGTH(q.prompt) + LENGTH(q.options_json) + COALESCE(LENGTH(q.feedback_json), 0) + COALESCE(LENGTH(q.translations_json), 0)) AS bytes
+			FROM quiz_questions qq
+			JOIN questions q ON q.question_id = qq.question_id
+			GROUP BY qq.quiz_id
+		 ) qb ON qb.quiz_id = z.quiz_id`,
+		attemptRowOverhead,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]quiz.QuizStats, 0)
+	for rows.Next() {
+		var (
+			item           quiz.QuizStats
+			createdAtUnix  int64
+			closesAtUnix   sql.NullInt64
+			lastSubmission sql.NullInt64
+			mixJSON        sql.NullString
+			archivedAtUnix sql.NullInt64
+		)
+		if err := rows.Scan(
+			&item.QuizID, &item.QuestionCount, &item.RequestedQuestionCount, &createdAtUnix, &item.Locked, &item.Draft, &closesAtUnix,
+			&item.Origin.Provider, &mixJSON, &item.Origin.Seed, &item.Origin.FallbackFrom, &item.Origin.Category, &item.Origin.QuestionType, &archivedAtUnix,
+			&item.AttemptCount, &item.ParticipantCount, &lastSubmission, &item.StorageBytes,
+		); err != nil {
+			return nil, err
+		}
+
+		if mixJSON.Valid {
+			if err := json.Unmarshal([]byte(mixJSON.String), &item.Origin.DifficultyMix); err != nil {
+				return nil, err
+			}
+		}
+		item.CreatedAt = time.Unix(0, createdAtUnix).UTC()
+		if closesAtUnix.Valid {
+			item.ClosesAt = time.Unix(0, closesAtUnix.Int64).UTC()
+		}
+		if archivedAtUnix.Valid {
+			item.ArchivedAt = time.Unix(0, archivedAtUnix.Int64).UTC()
+		}
+		item.LastActivityAt = item.CreatedAt
+		if lastSubmission.Valid {
+			item.LastActivityAt = time.Unix(0, lastSubmission.Int64).UTC()
+		}
+		stats = append(stats, item)
+	}
+	return stats, rows.Err()
+}
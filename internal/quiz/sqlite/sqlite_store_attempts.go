@@ -2,24 +2,24 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"quiz-app/internal/quiz"
+	"quiz-app/pkg/quizkit"
 )
 
-type answerKey struct {
-	correctIndex int
-	optionCount  int
-}
-
 // SubmitResponses runs as a single transaction so each request gets consistent
 // duplicate detection and score evaluation.
 //
 // Invariants:
 //   - (quiz_id, question_id, username_norm) is unique in attempts.
 //   - An existing attempt must never be overwritten.
-//   - Unknown questions are ignored, invalid letters are rejected, and valid
-//     first-time submissions are scored and persisted.
+//   - Unknown questions are ignored, voided questions and invalid letters are
+//     rejected, and valid first-time submissions are scored and persisted.
+//     Correct answers score 1 plus the response's speed bonus; partially
+//     correct multi-select answers score their credit.
 //
 // Transaction rationale:
 // We load quiz question metadata and insert attempts in one transaction so
@@ -34,7 +34,7 @@ func (s *SQLiteStore) SubmitResponses(ctx context.Context, quizID, usernameNorma
 
 	rows, err := tx.QueryContext(
 		ctx,
-		`SELECT q.question_id, q.correct_index, q.option_count
+		`SELECT q.question_id, q.options_json, q.correct_index, q.question_type, q.correct_indexes_json, qq.voided_at_unix IS NOT NULL
 		 FROM quiz_questions qq
 		 JOIN questions q ON q.question_id = qq.question_id
 		 WHERE qq.quiz_id = ?`,
@@ -44,21 +44,29 @@ func (s *SQLiteStore) SubmitResponses(ctx context.Context, quizID, usernameNorma
 		return nil, err
 	}
 
-	questionLookup := make(map[string]answerKey)
+	// Only the answer key is loaded: options for their count and, on
+	// true/false questions, their text.
+	questionLookup := make(map[string]quiz.Question)
 	for rows.Next() {
 		var (
-			questionID   string
-			correctIndex int
-			optionCount  int
+			question    quiz.Question
+			optionsJSON string
+			kind        string
+			correctJSON sql.NullString
 		)
-		if err := rows.Scan(&questionID, &correctIndex, &optionCount); err != nil {
+		if err := rows.Scan(&question.QuestionID, &optionsJSON, &question.CorrectIndex, &kind, &correctJSON, &question.Voided); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(optionsJSON), &question.Options); err != nil {
 			_ = rows.Close()
 			return nil, err
 		}
-		questionLookup[questionID] = answerKey{
-			correctIndex: correctIndex,
-			optionCount:  optionCount,
+		if err := decodeAnswerKey(&question, kind, correctJSON); err != nil {
+			_ = rows.Close()
+			return nil, err
 		}
+		questionLookup[question.QuestionID] = question
 	}
 	if err := rows.Err(); err != nil {
 		_ = rows.Close()
@@ -72,7 +80,7 @@ func (s *SQLiteStore) SubmitResponses(ctx context.Context, quizID, usernameNorma
 
 	results := make([]quiz.ResponseResult, 0, len(responses))
 	for _, response := range responses {
-		key, ok := questionLookup[response.QuestionID]
+		question, ok := questionLookup[response.QuestionID]
 		if !ok {
 			results = append(results, quiz.ResponseResult{
 				QuestionID: response.QuestionID,
@@ -81,42 +89,40 @@ func (s *SQLiteStore) SubmitResponses(ctx context.Context, quizID, usernameNorma
 			continue
 		}
 
-		letter := quiz.NormalizeLetter(response.Answer)
-		if letter == "" {
-			results = append(results, quiz.ResponseResult{
-				QuestionID: response.QuestionID,
-				Status:     quiz.StatusInvalidLetter,
-			})
-			continue
-		}
-
-		answerIndex := int(letter[0] - 'A')
-		if answerIndex < 0 || answerIndex >= key.optionCount {
+		status, credit := quizkit.GradeAnswer(question, response.Answer)
+		if status == quiz.StatusVoidedQuestion || status == quiz.StatusInvalidLetter {
 			results = append(results, quiz.ResponseResult{
 				QuestionID: response.QuestionID,
-				Status:     quiz.StatusInvalidLetter,
+				Status:     status,
 			})
 			continue
 		}
+		chosen, _ := question.ParseAnswer(response.Answer)
+		letter := quizkit.FormatLetters(chosen)
 
-		status := quiz.StatusIncorrect
 		score := 0.0
-		if answerIndex == key.correctIndex {
-			status = quiz.StatusCorrect
-			score = 1.0
+		bonus := 0.0
+		switch status {
+		case quiz.StatusCorrect:
+			bonus = response.Bonus
+			score = 1.0 + bonus
+			credit = 0
+		case quiz.StatusPartiallyCorrect:
+			score = credit
 		}
 		var attemptScore *float64
 
 		insertResult, err := tx.ExecContext(
 			ctx,
-			`INSERT OR IGNORE INTO attempts (quiz_id, question_id, username_norm, answer_letter, score, submitted_at_unix)
-			 VALUES (?, ?, ?, ?, ?, ?)`,
+			`INSERT OR IGNORE INTO attempts (quiz_id, question_id, username_norm, answer_letter, score, submitted_at_unix, submitted_by)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
 			quizID,
 			response.QuestionID,
 			usernameNormalized,
 			letter,
 			score,
 			time.Now().UTC().UnixNano(),
+			response.SubmittedBy,
 		)
 		if err != nil {
 			return nil, err
@@ -130,6 +136,8 @@ func (s *SQLiteStore) SubmitResponses(ctx context.Context, quizID, usernameNorma
 			// Duplicate answer for (quiz, question, user): keep original row unchanged
 			// and return previously persisted score for consistent client reconciliation.
 			status = quiz.StatusAlreadyAnswered
+			bonus = 0
+			credit = 0
 
 			var existingScore float64
 			if err := tx.QueryRowContext(
@@ -150,6 +158,8 @@ func (s *SQLiteStore) SubmitResponses(ctx context.Context, quizID, usernameNorma
 			QuestionID:   response.QuestionID,
 			Status:       status,
 			AttemptScore: attemptScore,
+			Credit:       credit,
+			Bonus:        bonus,
 		})
 	}
 
@@ -175,13 +185,30 @@ func (s *SQLiteStore) GetLeaderboard(ctx context.Context, quizID string) ([]quiz
 	// In production, it is recommended to use pagination to limit the number of entries displayed.
 	rows, err := s.db.QueryContext(
 		ctx,
-		`SELECT username_norm, SUM(score) AS total_score, COUNT(*) AS answered_count, MAX(submitted_at_unix) AS last_submission
-		 FROM attempts
-		 WHERE quiz_id = ?
+		`SELECT username_norm, SUM(score) AS total_score, SUM(answered) AS answered_count, MAX(submitted_at_unix) AS last_submission
+		 FROM (
+			SELECT a.username_norm, a.score, 1 AS answered, a.submitted_at_unix
+			FROM attempts a
+			JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+			WHERE a.quiz_id = ? AND qq.voided_at_unix IS NULL
+			UNION ALL
+			-- Totals of attempts archived by the retention policy.
+			SELECT username_norm, total_score, answered_count, last_submitted_at_unix
+			FROM attempt_summaries
+			WHERE quiz_id = ?
+		 )
 		 GROUP BY username_norm
-		 -- Keep ordering deterministic and aligned with in-memory cache comparison.
-		 ORDER BY total_score DESC, last_submission ASC, username_norm ASC`,
+		 -- Keep ordering deterministic and aligned with quizkit.RanksBeforeBy, which
+		 -- orders the in-memory cache; the quiz's tiebreak setting may rank fewer
+		 -- answers ahead before falling back to the earliest finish.
+		 ORDER BY total_score DESC,
+			CASE WHEN (SELECT tiebreak FROM leaderboard_settings WHERE quiz_id = ?) = ? THEN answered_count ELSE 0 END ASC,
+			last_submission ASC,
+			username_norm ASC`,
+		quizID,
+		quizID,
 		quizID,
+		string(quiz.TiebreakFewestAnswers),
 	)
 	if err != nil {
 		return nil, err
@@ -207,9 +234,10 @@ func (s *SQLiteStore) GetLeaderboard(ctx context.Context, quizID string) ([]quiz
 func (s *SQLiteStore) GetAttemptScores(ctx context.Context, quizID, usernameNormalized string) (map[string]float64, error) {
 	rows, err := s.db.QueryContext(
 		ctx,
-		`SELECT question_id, score
-		 FROM attempts
-		 WHERE quiz_id = ? AND username_norm = ?`,
+		`SELECT a.question_id, a.score
+		 FROM attempts a
+		 JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+		 WHERE a.quiz_id = ? AND a.username_norm = ? AND qq.voided_at_unix IS NULL`,
 		quizID,
 		usernameNormalized,
 	)
@@ -232,3 +260,69 @@ func (s *SQLiteStore) GetAttemptScores(ctx context.Context, quizID, usernameNorm
 
 	return scores, rows.Err()
 }
+
+// ListAttempts orders by rowid after the timestamp so answers stored in the
+// same nanosecond keep their insert order.
+func (s *SQLiteStore) ListAttempts(ctx context.Context, quizID, usernameNormalized string) ([]quiz.Attempt, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT a.question_id, a.answer_letter, a.score, a.submitted_at_unix, a.submitted_by
+		 FROM attempts a
+		 JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+		 WHERE a.quiz_id = ? AND a.username_norm = ? AND qq.voided_at_unix IS NULL
+		 ORDER BY a.submitted_at_unix ASC, a.rowid ASC`,
+		quizID,
+		usernameNormalized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := make([]quiz.Attempt, 0)
+	for rows.Next() {
+		var (
+			attempt         quiz.Attempt
+			submittedAtUnix int64
+		)
+		if err := rows.Scan(&attempt.QuestionID, &attempt.AnswerLetter, &attempt.Score, &submittedAtUnix, &attempt.SubmittedBy); err != nil {
+			return nil, err
+		}
+		attempt.SubmittedAt = time.Unix(0, submittedAtUnix).UTC()
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+// VoidQuestion marks questionID as voided within quizID. Attempts stay in the
+// table so the void can be audited, but every scoring query filters them out.
+func (s *SQLiteStore) VoidQuestion(ctx context.Context, quizID, questionID string, voidedAt time.Time) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE quiz_questions
+		 SET voided_at_unix = COALESCE(voided_at_unix, ?)
+		 WHERE quiz_id = ? AND question_id = ?`,
+		voidedAt.UTC().Unix(),
+		quizID,
+		questionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		exists, err := s.QuizExists(ctx, quizID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return quiz.ErrQuizNotFound
+		}
+		return quiz.ErrQuestionNotFound
+	}
+	return nil
+}
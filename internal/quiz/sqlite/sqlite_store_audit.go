@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) RecordAuditEntry(ctx context.Context, entry quiz.AuditEntry) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO audit_log (quiz_id, at_unix, actor, action, username_norm, detail)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.QuizID,
+		entry.At.UnixNano(),
+		entry.Actor,
+		entry.Action,
+		entry.Username,
+		entry.Detail,
+	)
+	return err
+}
+
+// ListAuditEntries orders by rowid, which follows insert order even when two
+// entries share a timestamp.
+func (s *SQLiteStore) ListAuditEntries(ctx context.Context, quizID string) ([]quiz.AuditEntry, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT at_unix, actor, action, username_norm, detail
+		 FROM audit_log
+		 WHERE quiz_id = ?
+		 ORDER BY rowid ASC`,
+		quizID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]quiz.AuditEntry, 0)
+	for rows.Next() {
+		var (
+			entry  = quiz.AuditEntry{QuizID: quizID}
+			atUnix int64
+		)
+		if err := rows.Scan(&atUnix, &entry.Actor, &entry.Action, &entry.Username, &entry.Detail); err != nil {
+			return nil, err
+		}
+		entry.At = time.Unix(0, atUnix).UTC()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) RecordQuestionAuthor(ctx context.Context, authorNormalized string, questionIDs []string, createdAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, questionID := range questionIDs {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR IGNORE INTO question_authors (question_id, author_norm, created_at_unix) VALUES (?, ?, ?)`,
+			questionID,
+			authorNormalized,
+			createdAt.UnixNano(),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// QuestionPerformanceByAuthor counts attempts through quiz_questions so that
+// attempts on a quiz where the question was voided are left out.
+func (s *SQLiteStore) QuestionPerformanceByAuthor(ctx context.Context, authorNormalized string) ([]quiz.QuestionPerformance, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT q.question_id, q.prompt, q.options_json, q.correct_index,
+			(SELECT COUNT(*) FROM quiz_questions qq WHERE qq.question_id = q.question_id),
+			COALESCE(a.attempt_count, 0),
+			COALESCE(a.correct_count, 0),
+			(SELECT COUNT(*) FROM question_reports r WHERE r.question_id = q.question_id)
+		 FROM question_authors qa
+		 JOIN questions q ON q.question_id = qa.question_id
+		 LEFT JOIN (
+			SELECT at.question_id, COUNT(*) AS attempt_count, SUM(CASE WHEN at.score >= 1 THEN 1 ELSE 0 END) AS correct_count
+			FROM attempts at
+			JOIN quiz_questions qq ON qq.quiz_id = at.quiz_id AND qq.question_id = at.question_id
+			WHERE qq.voided_at_unix IS NULL
+			GROUP BY at.question_id
+		 ) a ON a.question_id = q.question_id
+		 WHERE qa.author_norm = ?
+		 ORDER BY qa.created_at_unix DESC, q.question_id ASC`,
+		authorNormalized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	performance := make([]quiz.QuestionPerformance, 0)
+	for rows.Next() {
+		var (
+			item        quiz.QuestionPerformance
+			optionsJSON string
+		)
+		if err := rows.Scan(
+			&item.Question.QuestionID,
+			&item.Question.Question,
+			&optionsJSON,
+			&item.Question.CorrectIndex,
+			&item.QuizCount,
+			&item.AttemptCount,
+			&item.CorrectCount,
+			&item.ReportCount,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(optionsJSON), &item.Question.Options); err != nil {
+			return nil, err
+		}
+		performance = append(performance, item)
+	}
+	return performance, rows.Err()
+}
+
+func (s *SQLiteStore) ReportQuestion(ctx context.Context, questionID, usernameNormalized, reason string, reportedAt time.Time) error {
+	var found int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM questions WHERE question_id = ?`, questionID).Scan(&found); err != nil {
+		return err
+	}
+	if found == 0 {
+		return quiz.ErrUnknownQuestion
+	}
+
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT OR IGNORE INTO question_reports (question_id, username_norm, reason, created_at_unix) VALUES (?, ?, ?, ?)`,
+		questionID,
+		usernameNormalized,
+		reason,
+		reportedAt.UnixNano(),
+	)
+	return err
+}
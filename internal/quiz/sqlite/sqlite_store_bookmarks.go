@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) AddBookmark(ctx context.Context, usernameNormalized, questionID string, createdAt time.Time) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`INSERT OR IGNORE INTO bookmarks (username_norm, question_id, created_at_unix)
+		 SELECT ?, question_id, ? FROM questions WHERE question_id = ?`,
+		usernameNormalized,
+		createdAt.UnixNano(),
+		questionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	// Nothing inserted: either already bookmarked or the question is unknown.
+	var found int
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM questions WHERE question_id = ?`, questionID).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		return quiz.ErrUnknownQuestion
+	}
+	return nil
+}
+
+func (s *SQLiteStore) RemoveBookmark(ctx context.Context, usernameNormalized, questionID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM bookmarks WHERE username_norm = ? AND question_id = ?`,
+		usernameNormalized,
+		questionID,
+	)
+	return err
+}
+
+func (s *SQLiteStore) ListBookmarks(ctx context.Context, usernameNormalized string) ([]quiz.Bookmark, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT q.question_id, q.prompt, q.options_json, q.correct_index, q.feedback_json, q.difficulty, q.translations_json, q.category, q.question_type, q.correct_indexes_json, q.source, b.created_at_unix
+		 FROM bookmarks b
+		 JOIN questions q ON q.question_id = b.question_id
+		 WHERE b.username_norm = ?
+		 ORDER BY b.created_at_unix DESC, b.question_id ASC`,
+		usernameNormalized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookmarks := make([]quiz.Bookmark, 0)
+	for rows.Next() {
+		var (
+			bookmark         quiz.Bookmark
+			optionsJSON      string
+			feedbackJSON     sql.NullString
+			translationsJSON sql.NullString
+			kind             string
+			correctJSON      sql.NullString
+			createdAtUnix    int64
+			err              error
+		)
+		if err := rows.Scan(
+			&bookmark.Question.QuestionID,
+			&bookmark.Question.Question,
+			&optionsJSON,
+			&bookmark.Question.CorrectIndex,
+			&feedbackJSON,
+			&bookmark.Question.Difficulty,
+			&translationsJSON,
+			&bookmark.Question.Category,
+			&kind,
+			&correctJSON,
+			&bookmark.Question.Source,
+			&createdAtUnix,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(optionsJSON), &bookmark.Question.Options); err != nil {
+			return nil, err
+		}
+		// Practice quizzes are built from bookmarks, so keep the answer key,
+		// feedback, and translations.
+		if err := decodeAnswerKey(&bookmark.Question, kind, correctJSON); err != nil {
+			return nil, err
+		}
+		if bookmark.Question.Feedback, err = decodeFeedback(feedbackJSON); err != nil {
+			return nil, err
+		}
+		if bookmark.Question.Translations, err = decodeTranslations(translationsJSON); err != nil {
+			return nil, err
+		}
+		bookmark.CreatedAt = time.Unix(0, createdAtUnix).UTC()
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, rows.Err()
+}
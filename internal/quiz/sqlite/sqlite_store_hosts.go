@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) GetHosts(ctx context.Context, quizID string) ([]quiz.QuizHost, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT username_norm, role, token_hash, added_by, added_at_unix
+		 FROM quiz_hosts
+		 WHERE quiz_id = ?
+		 ORDER BY position ASC`,
+		quizID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hosts := make([]quiz.QuizHost, 0)
+	for rows.Next() {
+		var (
+			host        quiz.QuizHost
+			role        string
+			addedAtUnix int64
+		)
+		if err := rows.Scan(&host.Username, &role, &host.TokenHash, &host.AddedBy, &addedAtUnix); err != nil {
+			return nil, err
+		}
+		host.Role = quiz.HostRole(role)
+		host.AddedAt = time.Unix(0, addedAtUnix).UTC()
+		hosts = append(hosts, host)
+	}
+	return hosts, rows.Err()
+}
+
+func (s *SQLiteStore) SaveHosts(ctx context.Context, quizID string, hosts []quiz.QuizHost) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quiz_hosts WHERE quiz_id = ?`, quizID); err != nil {
+		return err
+	}
+	for position, host := range hosts {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO quiz_hosts (quiz_id, position, username_norm, role, token_hash, added_by, added_at_unix)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			quizID,
+			position,
+			host.Username,
+			string(host.Role),
+			host.TokenHash,
+			host.AddedBy,
+			host.AddedAt.UnixNano(),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
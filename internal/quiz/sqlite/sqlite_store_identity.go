@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) GetIdentity(ctx context.Context, usernameNormalized string) (quiz.Identity, error) {
+	identity := quiz.Identity{Username: usernameNormalized}
+	var verifiedAtUnix int64
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT email, token_hash, verified_at_unix FROM user_identities WHERE username_norm = ?`,
+		usernameNormalized,
+	).Scan(&identity.Email, &identity.TokenHash, &verifiedAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.Identity{}, nil
+	}
+	if err != nil {
+		return quiz.Identity{}, err
+	}
+	identity.VerifiedAt = time.Unix(0, verifiedAtUnix).UTC()
+	return identity, nil
+}
+
+func (s *SQLiteStore) SaveIdentity(ctx context.Context, identity quiz.Identity) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO user_identities (username_norm, email, token_hash, verified_at_unix)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(username_norm) DO UPDATE SET
+			email = excluded.email,
+			token_hash = excluded.token_hash,
+			verified_at_unix = excluded.verified_at_unix`,
+		identity.Username,
+		identity.Email,
+		identity.TokenHash,
+		identity.VerifiedAt.UnixNano(),
+	)
+	return err
+}
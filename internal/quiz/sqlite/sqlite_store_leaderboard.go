@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) GetLeaderboardSettings(ctx context.Context, quizID string) (quiz.LeaderboardSettings, error) {
+	var (
+		settings      quiz.LeaderboardSettings
+		freezeSeconds int64
+		locksAtUnix   sql.NullInt64
+		tiebreak      string
+		updatedAtUnix int64
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT default_limit, freeze_seconds, locks_at_unix, tiebreak, updated_at_unix FROM leaderboard_settings WHERE quiz_id = ?`,
+		quizID,
+	).Scan(&settings.DefaultLimit, &freezeSeconds, &locksAtUnix, &tiebreak, &updatedAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.LeaderboardSettings{}, nil
+	}
+	if err != nil {
+		return quiz.LeaderboardSettings{}, err
+	}
+	settings.FreezeWindow = time.Duration(freezeSeconds) * time.Second
+	if locksAtUnix.Valid {
+		settings.LocksAt = time.Unix(0, locksAtUnix.Int64).UTC()
+	}
+	settings.Tiebreak = quiz.Tiebreak(tiebreak)
+	settings.UpdatedAt = time.Unix(0, updatedAtUnix).UTC()
+	return settings, nil
+}
+
+func (s *SQLiteStore) SaveLeaderboardSettings(ctx context.Context, quizID string, settings quiz.LeaderboardSettings) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO leaderboard_settings (quiz_id, default_limit, freeze_seconds, locks_at_unix, tiebreak, updated_at_unix)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(quiz_id) DO UPDATE SET
+			default_limit = excluded.default_limit,
+			freeze_seconds = excluded.freeze_seconds,
+			locks_at_unix = excluded.locks_at_unix,
+			tiebreak = excluded.tiebreak,
+			updated_at_unix = excluded.updated_at_unix`,
+		quizID,
+		settings.DefaultLimit,
+		int64(settings.FreezeWindow/time.Second),
+		nullableUnixNano(settings.LocksAt),
+		string(settings.Tiebreak),
+		settings.UpdatedAt.UnixNano(),
+	)
+	return err
+}
@@ -0,0 +1,246 @@
+package sqlite
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Maintenance keeps long-lived database files from bloating. Deleted rows
+// leave free pages behind that SQLite reuses but never returns to the file
+// system; incremental auto-vacuum hands them back a batch at a time, so
+// requests can interleave on the single connection. ANALYZE and PRAGMA
+// optimize refresh the table and index statistics the query planner relies on
+// as tables grow.
+
+// vacuumBatchPages is how many free pages one incremental_vacuum statement
+// releases before the connection is handed back to waiting requests.
+const vacuumBatchPages = 1024
+
+// autoVacuumIncremental is PRAGMA auto_vacuum's value for INCREMENTAL.
+const autoVacuumIncremental = 2
+
+// maintenanceVars reports maintenance runs with the other expvars on
+// GET /debug/vars.
+var maintenanceVars = expvar.NewMap("sqlite_maintenance")
+
+// MaintenanceReport describes one Maintain run. Sizes are whole database
+// pages, so they match what the file occupies on disk.
+type MaintenanceReport struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	// Converted is set on the run that switched a database file created
+	// before incremental auto-vacuum with one full VACUUM.
+	Converted   bool
+	BytesBefore int64
+	BytesAfter  int64
+}
+
+// ReclaimedBytes is how much the run shrank the file.
+func (r MaintenanceReport) ReclaimedBytes() int64 {
+	return r.BytesBefore - r.BytesAfter
+}
+
+// Maintain releases free pages and refreshes planner statistics. Statements
+// run without the store's QueryTimeout, since a vacuum may legitimately take
+// longer than any request; ctx still bounds the run.
+func (s *SQLiteStore) Maintain(ctx context.Context) (MaintenanceReport, error) {
+	report := MaintenanceReport{StartedAt: time.Now().UTC()}
+	report, err := s.maintain(ctx, report)
+	report.Duration = time.Since(report.StartedAt)
+
+	if err != nil {
+		maintenanceVars.Add("failures", 1)
+		return report, err
+	}
+	maintenanceVars.Add("runs", 1)
+	maintenanceVars.Add("reclaimed_bytes", report.ReclaimedBytes())
+	setMaintenanceVar("last_reclaimed_bytes", report.ReclaimedBytes())
+	setMaintenanceVar("last_duration_ms", report.Duration.Milliseconds())
+	setMaintenanceVar("last_run_unix", report.StartedAt.Unix())
+	setMaintenanceVar("db_bytes", report.BytesAfter)
+	return report, nil
+}
+
+func (s *SQLiteStore) maintain(ctx context.Context, report MaintenanceReport) (MaintenanceReport, error) {
+	db := s.db.DB
+
+	var err error
+	report.BytesBefore, err = s.databaseBytes(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	var mode int
+	if err := db.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&mode); err != nil {
+		return report, err
+	}
+	if mode != autoVacuumIncremental {
+		// The mode only changes through a full rebuild, which is why this
+		// happens here, in a quiet window, rather than at startup.
+		if _, err := db.ExecContext(ctx, `PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+			return report, err
+		}
+		if _, err := db.ExecContext(ctx, `VACUUM`); err != nil {
+			return report, err
+		}
+		report.Converted = true
+	} else {
+		for {
+			var free int64
+			if err := db.QueryRowContext(ctx, `PRAGMA freelist_count`).Scan(&free); err != nil {
+				return report, err
+			}
+			if free == 0 {
+				break
+			}
+			if _, err := db.ExecContext(ctx, fmt.Sprintf(`PRAGMA incremental_vacuum(%d)`, vacuumBatchPages)); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	for _, statement := range []string{`ANALYZE`, `PRAGMA optimize`} {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return report, err
+		}
+	}
+
+	report.BytesAfter, err = s.databaseBytes(ctx)
+	return report, err
+}
+
+func (s *SQLiteStore) databaseBytes(ctx context.Context) (int64, error) {
+	var pages, pageSize int64
+	if err := s.db.DB.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := s.db.DB.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pages * pageSize, nil
+}
+
+func setMaintenanceVar(key string, value int64) {
+	v := new(expvar.Int)
+	v.Set(value)
+	maintenanceVars.Set(key, v)
+}
+
+// MaintenanceWindow is a daily stretch of time, such as 02:00-05:00, in
+// Location. A window whose end is before its start wraps past midnight.
+type MaintenanceWindow struct {
+	Start, End time.Duration
+	Location   *time.Location
+}
+
+// ParseMaintenanceWindow reads "HH:MM-HH:MM" in location; nil means UTC.
+func ParseMaintenanceWindow(value string, location *time.Location) (MaintenanceWindow, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	rawStart, rawEnd, ok := strings.Cut(strings.TrimSpace(value), "-")
+	if !ok {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q: want HH:MM-HH:MM", value)
+	}
+	start, err := parseClock(rawStart)
+	if err != nil {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q: %w", value, err)
+	}
+	end, err := parseClock(rawEnd)
+	if err != nil {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q: %w", value, err)
+	}
+	if start == end {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window %q: start and end must differ", value)
+	}
+	return MaintenanceWindow{Start: start, End: end, Location: location}, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", value)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+func (w MaintenanceWindow) contains(sinceMidnight time.Duration) bool {
+	if w.Start < w.End {
+		return sinceMidnight >= w.Start && sinceMidnight < w.End
+	}
+	return sinceMidnight >= w.Start || sinceMidnight < w.End
+}
+
+// next returns t if it falls inside the window, otherwise the window's next
+// start.
+func (w MaintenanceWindow) next(t time.Time) time.Time {
+	local := t.In(w.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, w.Location)
+	if w.contains(local.Sub(midnight)) {
+		return t
+	}
+	start := midnight.Add(w.Start)
+	if start.Before(local) {
+		start = midnight.AddDate(0, 0, 1).Add(w.Start)
+	}
+	return start
+}
+
+// MaintenanceSchedule runs Maintain inside Window, at most once per Interval.
+// Zero Interval means once a day.
+type MaintenanceSchedule struct {
+	Window   MaintenanceWindow
+	Interval time.Duration
+	// Logf receives a line per run; nil uses log.Printf.
+	Logf func(format string, args ...any)
+}
+
+// nextRun is the first moment inside the window at least Interval after the
+// last run started.
+func (s MaintenanceSchedule) nextRun(now, last time.Time) time.Time {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	earliest := now
+	if !last.IsZero() && last.Add(interval).After(now) {
+		earliest = last.Add(interval)
+	}
+	return s.Window.next(earliest)
+}
+
+// RunMaintenance runs Maintain on schedule until ctx is done. A failed run is
+// logged and retried at the next scheduled time.
+func (s *SQLiteStore) RunMaintenance(ctx context.Context, schedule MaintenanceSchedule) {
+	logf := schedule.Logf
+	if logf == nil {
+		logf = log.Printf
+	}
+
+	var last time.Time
+	for {
+		now := time.Now()
+		timer := time.NewTimer(schedule.nextRun(now, last).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		report, err := s.Maintain(ctx)
+		last = report.StartedAt
+		if err != nil {
+			if ctx.Err() == nil {
+				logf("sqlite maintenance failed after %s: %v", report.Duration.Round(time.Millisecond), err)
+			}
+			continue
+		}
+		logf("sqlite maintenance done duration=%s reclaimed_bytes=%d db_bytes=%d converted=%t",
+			report.Duration.Round(time.Millisecond), report.ReclaimedBytes(), report.BytesAfter, report.Converted)
+	}
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) GetPreferences(ctx context.Context, usernameNormalized string) (quiz.UserPreferences, error) {
+	preferences := quiz.UserPreferences{Username: usernameNormalized}
+	var (
+		difficulty    string
+		updatedAtUnix int64
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT question_count, difficulty, updated_at_unix FROM user_preferences WHERE username_norm = ?`,
+		usernameNormalized,
+	).Scan(&preferences.QuestionCount, &difficulty, &updatedAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return preferences, nil
+	}
+	if err != nil {
+		return quiz.UserPreferences{}, err
+	}
+	preferences.Difficulty = quiz.Difficulty(difficulty)
+	preferences.UpdatedAt = time.Unix(0, updatedAtUnix).UTC()
+	return preferences, nil
+}
+
+func (s *SQLiteStore) SavePreferences(ctx context.Context, preferences quiz.UserPreferences) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO user_preferences (username_norm, question_count, difficulty, updated_at_unix)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(username_norm) DO UPDATE SET
+			question_count = excluded.question_count,
+			difficulty = excluded.difficulty,
+			updated_at_unix = excluded.updated_at_unix`,
+		preferences.Username,
+		preferences.QuestionCount,
+		string(preferences.Difficulty),
+		preferences.UpdatedAt.UnixNano(),
+	)
+	return err
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) GetProfile(ctx context.Context, usernameNormalized string) (quiz.UserProfile, error) {
+	profile := quiz.UserProfile{Username: usernameNormalized}
+	var (
+		anonymous     bool
+		updatedAtUnix int64
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT anonymous, updated_at_unix FROM user_profiles WHERE username_norm = ?`,
+		usernameNormalized,
+	).Scan(&anonymous, &updatedAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return profile, nil
+	}
+	if err != nil {
+		return quiz.UserProfile{}, err
+	}
+	profile.Anonymous = anonymous
+	profile.UpdatedAt = time.Unix(0, updatedAtUnix).UTC()
+	return profile, nil
+}
+
+func (s *SQLiteStore) SaveProfile(ctx context.Context, profile quiz.UserProfile) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO user_profiles (username_norm, anonymous, updated_at_unix)
+		 VALUES (?, ?, ?)
+		 ON CONFLICT(username_norm) DO UPDATE SET
+			anonymous = excluded.anonymous,
+			updated_at_unix = excluded.updated_at_unix`,
+		profile.Username,
+		profile.Anonymous,
+		profile.UpdatedAt.UnixNano(),
+	)
+	return err
+}
+
+func (s *SQLiteStore) AnonymousUsers(ctx context.Context, usernamesNormalized []string) (map[string]bool, error) {
+	// Whole leaderboards are looked up at once; chunking keeps each query under
+	// SQLite's bound-parameter limit.
+	const chunkSize = 500
+
+	anonymous := make(map[string]bool)
+	for start := 0; start < len(usernamesNormalized); start += chunkSize {
+		chunk := usernamesNormalized[start:min(start+chunkSize, len(usernamesNormalized))]
+		args := make([]any, 0, len(chunk))
+		for _, username := range chunk {
+			args = append(args, username)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(args)), ",")
+		if err := s.collectAnonymousUsers(ctx, anonymous, placeholders, args); err != nil {
+			return nil, err
+		}
+	}
+	return anonymous, nil
+}
+
+func (s *SQLiteStore) collectAnonymousUsers(ctx context.Context, anonymous map[string]bool, placeholders string, args []any) error {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT username_norm FROM user_profiles WHERE anonymous = 1 AND username_norm IN (`+placeholders+`)`,
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return err
+		}
+		anonymous[username] = true
+	}
+	return rows.Err()
+}
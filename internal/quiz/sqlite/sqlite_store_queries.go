@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// StoreOptions bounds and reports how long statements take.
+type StoreOptions struct {
+	// QueryTimeout cuts off each statement after this long, on top of any
+	// deadline the request context already carries. 0 disables it.
+	QueryTimeout time.Duration
+	// SlowQueryThreshold logs statements that take at least this long, with
+	// string arguments redacted. 0 disables the log.
+	SlowQueryThreshold time.Duration
+	// Logf receives slow-query lines; nil uses log.Printf.
+	Logf func(format string, args ...any)
+}
+
+// timedDB applies StoreOptions to every statement run through the store. Rows
+// are timed until they are closed, since SQLite does most of the work of an
+// aggregation while the rows are read rather than when the query starts.
+type timedDB struct {
+	*sql.DB
+	options StoreOptions
+}
+
+// timedTx is a transaction from timedDB.BeginTx. The transaction itself is
+// bound only by the caller's context; each statement in it gets its own timeout.
+type timedTx struct {
+	*sql.Tx
+	options StoreOptions
+}
+
+// timedRows finishes timing its statement when closed. Callers already defer
+// Close, so the statement's context is released as before.
+type timedRows struct {
+	*sql.Rows
+	query *timedQuery
+}
+
+// timedRow finishes timing its statement in Scan, which closes the row.
+type timedRow struct {
+	*sql.Row
+	query *timedQuery
+}
+
+func (db *timedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return timedExec(ctx, db.options, db.DB.ExecContext, query, args)
+}
+
+func (db *timedDB) QueryContext(ctx context.Context, query string, args ...any) (*timedRows, error) {
+	return timedQueryRows(ctx, db.options, db.DB.QueryContext, query, args)
+}
+
+func (db *timedDB) QueryRowContext(ctx context.Context, query string, args ...any) *timedRow {
+	return timedQueryRow(ctx, db.options, db.DB.QueryRowContext, query, args)
+}
+
+func (db *timedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*timedTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &timedTx{Tx: tx, options: db.options}, nil
+}
+
+func (tx *timedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return timedExec(ctx, tx.options, tx.Tx.ExecContext, query, args)
+}
+
+func (tx *timedTx) QueryContext(ctx context.Context, query string, args ...any) (*timedRows, error) {
+	return timedQueryRows(ctx, tx.options, tx.Tx.QueryContext, query, args)
+}
+
+func (tx *timedTx) QueryRowContext(ctx context.Context, query string, args ...any) *timedRow {
+	return timedQueryRow(ctx, tx.options, tx.Tx.QueryRowContext, query, args)
+}
+
+func timedExec(ctx context.Context, options StoreOptions, exec func(context.Context, string, ...any) (sql.Result, error), query string, args []any) (sql.Result, error) {
+	statement := startQuery(ctx, options, query, args)
+	result, err := exec(statement.ctx, query, args...)
+	return result, statement.finish(err)
+}
+
+func timedQueryRows(ctx context.Context, options StoreOptions, run func(context.Context, string, ...any) (*sql.Rows, error), query string, args []any) (*timedRows, error) {
+	statement := startQuery(ctx, options, query, args)
+	rows, err := run(statement.ctx, query, args...)
+	if err != nil {
+		return nil, statement.finish(err)
+	}
+	return &timedRows{Rows: rows, query: statement}, nil
+}
+
+func timedQueryRow(ctx context.Context, options StoreOptions, run func(context.Context, string, ...any) *sql.Row, query string, args []any) *timedRow {
+	statement := startQuery(ctx, options, query, args)
+	return &timedRow{Row: run(statement.ctx, query, args...), query: statement}
+}
+
+func (r *timedRows) Close() error {
+	err := r.Rows.Close()
+	r.query.finish(nil)
+	return err
+}
+
+func (r *timedRows) Err() error {
+	return r.query.timeoutError(r.Rows.Err())
+}
+
+func (r *timedRows) Scan(dest ...any) error {
+	return r.query.timeoutError(r.Rows.Scan(dest...))
+}
+
+func (r *timedRow) Scan(dest ...any) error {
+	return r.query.finish(r.Row.Scan(dest...))
+}
+
+// timedQuery is one statement in flight.
+type timedQuery struct {
+	parent  context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	options StoreOptions
+	query   string
+	args    []any
+	start   time.Time
+	once    sync.Once
+}
+
+func startQuery(ctx context.Context, options StoreOptions, query string, args []any) *timedQuery {
+	statement := &timedQuery{parent: ctx, ctx: ctx, cancel: func() {}, options: options, query: query, args: args, start: time.Now()}
+	if options.QueryTimeout > 0 {
+		statement.ctx, statement.cancel = context.WithTimeout(ctx, options.QueryTimeout)
+	}
+	return statement
+}
+
+// finish releases the statement's context and logs it if it was slow. Only
+// the first call does anything; err is returned with timeouts made
+// recognizable either way.
+func (q *timedQuery) finish(err error) error {
+	err = q.timeoutError(err)
+	q.once.Do(func() {
+		q.cancel()
+		elapsed := time.Since(q.start)
+		if q.options.SlowQueryThreshold <= 0 || elapsed < q.options.SlowQueryThreshold {
+			return
+		}
+		logf := q.options.Logf
+		if logf == nil {
+			logf = log.Printf
+		}
+		logf("slow query duration=%s err=%v query=%q args=%s", elapsed.Round(time.Millisecond), err, compactQuery(q.query), redactArgs(q.args))
+	})
+	return err
+}
+
+// timeoutError marks errors caused by the statement timeout, as opposed to the
+// caller's own context ending, so they can be told apart from other failures.
+func (q *timedQuery) timeoutError(err error) error {
+	if err == nil || errors.Is(err, quiz.ErrQueryTimeout) {
+		return err
+	}
+	if q.ctx.Err() != nil && q.parent.Err() == nil {
+		return fmt.Errorf("%w after %s: %v", quiz.ErrQueryTimeout, q.options.QueryTimeout, err)
+	}
+	return err
+}
+
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// redactArgs keeps numbers, which are mostly limits and timestamps, and hides
+// strings, which hold usernames and answers.
+func redactArgs(args []any) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch value := arg.(type) {
+		case string:
+			parts = append(parts, fmt.Sprintf("<string len=%d>", len(value)))
+		case []byte:
+			parts = append(parts, fmt.Sprintf("<bytes len=%d>", len(value)))
+		case nil:
+			parts = append(parts, "NULL")
+		default:
+			parts = append(parts, fmt.Sprint(value))
+		}
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"quiz-app/internal/quiz"
@@ -35,13 +37,34 @@ func (s *SQLiteStore) CreateQuiz(ctx context.Context, metadata quiz.QuizMetadata
 	if _, err := tx.ExecContext(ctx, `DELETE FROM attempts WHERE quiz_id = ?`, metadata.QuizID); err != nil {
 		return err
 	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM attempt_summaries WHERE quiz_id = ?`, metadata.QuizID); err != nil {
+		return err
+	}
 
+	mixJSON, err := encodeDifficultyMix(metadata.Origin.DifficultyMix)
+	if err != nil {
+		return err
+	}
 	_, err = tx.ExecContext(
 		ctx,
-		`INSERT OR REPLACE INTO quizzes (quiz_id, created_at_unix, question_count, locked) VALUES (?, ?, ?, 0)`,
+		`INSERT OR REPLACE INTO quizzes (quiz_id, created_at_unix, question_count, requested_question_count, locked, draft, closes_at_unix, practice, adaptive, seconds_per_question, provider, difficulty_mix_json, seed, fallback_from, category, question_type)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		metadata.QuizID,
 		metadata.CreatedAt.UnixNano(),
 		metadata.QuestionCount,
+		metadata.RequestedQuestionCount,
+		metadata.Locked,
+		metadata.Draft,
+		nullableUnixNano(metadata.ClosesAt),
+		metadata.Practice,
+		metadata.Adaptive,
+		int64(metadata.QuestionTimeLimit/time.Second),
+		metadata.Origin.Provider,
+		mixJSON,
+		metadata.Origin.Seed,
+		metadata.Origin.FallbackFrom,
+		metadata.Origin.Category,
+		metadata.Origin.QuestionType,
 	)
 	if err != nil {
 		return err
@@ -53,30 +76,7 @@ func (s *SQLiteStore) CreateQuiz(ctx context.Context, metadata quiz.QuizMetadata
 			question.QuestionID = quiz.MakeQuestionID(question)
 		}
 
-		optionsJSON, err := json.Marshal(question.Options)
-		if err != nil {
-			return err
-		}
-
-		_, err = tx.ExecContext(
-			ctx,
-			`INSERT INTO questions (question_id, prompt, options_json, correct_index, option_count, source, created_at_unix)
-			 VALUES (?, ?, ?, ?, ?, ?, ?)
-			 ON CONFLICT(question_id) DO UPDATE SET
-				prompt = excluded.prompt,
-				options_json = excluded.options_json,
-				correct_index = excluded.correct_index,
-				option_count = excluded.option_count,
-				source = excluded.source`,
-			question.QuestionID,
-			question.Question,
-			string(optionsJSON),
-			question.CorrectIndex,
-			len(question.Options),
-			"opentdb",
-			metadata.CreatedAt.UnixNano(),
-		)
-		if err != nil {
+		if err := upsertQuestion(ctx, tx, question, metadata.CreatedAt); err != nil {
 			return err
 		}
 
@@ -94,25 +94,253 @@ func (s *SQLiteStore) CreateQuiz(ctx context.Context, metadata quiz.QuizMetadata
 	return tx.Commit()
 }
 
+func (s *SQLiteStore) ReplaceQuizQuestion(ctx context.Context, quizID, oldQuestionID string, question quiz.Question) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var answered bool
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM attempts WHERE quiz_id = ? AND question_id = ?)`,
+		quizID,
+		oldQuestionID,
+	).Scan(&answered); err != nil {
+		return err
+	}
+	if answered {
+		return quiz.ErrQuestionAnswered
+	}
+
+	if err := upsertQuestion(ctx, tx, question, time.Now().UTC()); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE quiz_questions SET question_id = ? WHERE quiz_id = ? AND question_id = ?`,
+		question.QuestionID,
+		quizID,
+		oldQuestionID,
+	)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return quiz.ErrQuestionNotFound
+	}
+	return tx.Commit()
+}
+
+// upsertQuestion stores question, or checks it against the stored row with the
+// same ID. A stored question is shared by every quiz that uses it, so what
+// players see and are graded against never changes: a copy with other text,
+// answer key, feedback, or translations fails with quiz.ErrQuestionConflict.
+// A copy may leave feedback and translations out. Difficulty, category, and a
+// true/false type are filled in where the stored row has none; the source
+// stays the one the question was first stored with.
+func upsertQuestion(ctx context.Context, tx *timedTx, question quiz.Question, createdAt time.Time) error {
+	optionsJSON, err := json.Marshal(question.Options)
+	if err != nil {
+		return err
+	}
+	feedbackJSON, err := encodeFeedback(question.Feedback)
+	if err != nil {
+		return err
+	}
+	translationsJSON, err := encodeTranslations(question.Translations)
+	if err != nil {
+		return err
+	}
+	correctIndexesJSON, err := encodeCorrectIndexes(question)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO questions (question_id, prompt, options_json, correct_index, option_count, source, created_at_unix, feedback_json, difficulty, translations_json, category, question_type, correct_indexes_json)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(question_id) DO UPDATE SET
+			difficulty = CASE WHEN questions.difficulty <> '' THEN questions.difficulty ELSE excluded.difficulty END,
+			category = CASE WHEN questions.category <> '' THEN questions.category ELSE excluded.category END,
+			question_type = CASE WHEN questions.question_type <> '' THEN questions.question_type ELSE excluded.question_type END
+		 WHERE questions.prompt = excluded.prompt
+			AND questions.options_json = excluded.options_json
+			AND questions.correct_index = excluded.correct_index
+			AND COALESCE(questions.correct_indexes_json, '') = COALESCE(excluded.correct_indexes_json, '')
+			AND (excluded.feedback_json IS NULL OR excluded.feedback_json = questions.feedback_json)
+			AND (excluded.translations_json IS NULL OR excluded.translations_json = questions.translations_json)`,
+		question.QuestionID,
+		question.Question,
+		string(optionsJSON),
+		question.CorrectIndex,
+		len(question.Options),
+		question.Source,
+		createdAt.UnixNano(),
+		feedbackJSON,
+		string(question.Difficulty),
+		translationsJSON,
+		question.Category,
+		string(question.Type),
+		correctIndexesJSON,
+	)
+	if err != nil {
+		return err
+	}
+	stored, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if stored == 0 {
+		return fmt.Errorf("%w: %s", quiz.ErrQuestionConflict, question.QuestionID)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) GetQuizMetadata(ctx context.Context, quizID string) (quiz.QuizMetadata, error) {
-	var metadata quiz.QuizMetadata
-	var createdAtUnix int64
-	err := s.db.QueryRowContext(
+	metadata, err := scanQuizMetadata(s.db.QueryRowContext(
 		ctx,
-		`SELECT quiz_id, question_count, created_at_unix FROM quizzes WHERE quiz_id = ?`,
+		`SELECT `+quizMetadataColumns+` FROM quizzes WHERE quiz_id = ?`,
 		quizID,
-	).Scan(&metadata.QuizID, &metadata.QuestionCount, &createdAtUnix)
+	))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return quiz.QuizMetadata{}, quiz.ErrQuizNotFound
 		}
 		return quiz.QuizMetadata{}, err
 	}
+	return metadata, nil
+}
+
+const quizMetadataColumns = `quiz_id, question_count, requested_question_count, created_at_unix, locked, draft, closes_at_unix, practice, adaptive, seconds_per_question, provider, difficulty_mix_json, seed, fallback_from, category, question_type, archived_at_unix`
+
+// scanQuizMetadata reads one row selected with quizMetadataColumns.
+func scanQuizMetadata(row interface{ Scan(...any) error }) (quiz.QuizMetadata, error) {
+	var (
+		metadata           quiz.QuizMetadata
+		createdAtUnix      int64
+		closesAtUnix       sql.NullInt64
+		secondsPerQuestion int64
+		mixJSON            sql.NullString
+		archivedAtUnix     sql.NullInt64
+	)
+	if err := row.Scan(
+		&metadata.QuizID, &metadata.QuestionCount, &metadata.RequestedQuestionCount, &createdAtUnix, &metadata.Locked, &metadata.Draft, &closesAtUnix, &metadata.Practice, &metadata.Adaptive, &secondsPerQuestion,
+		&metadata.Origin.Provider, &mixJSON, &metadata.Origin.Seed, &metadata.Origin.FallbackFrom, &metadata.Origin.Category, &metadata.Origin.QuestionType, &archivedAtUnix,
+	); err != nil {
+		return quiz.QuizMetadata{}, err
+	}
+	if mixJSON.Valid {
+		if err := json.Unmarshal([]byte(mixJSON.String), &metadata.Origin.DifficultyMix); err != nil {
+			return quiz.QuizMetadata{}, err
+		}
+	}
 
 	metadata.CreatedAt = time.Unix(0, createdAtUnix).UTC()
+	metadata.QuestionTimeLimit = time.Duration(secondsPerQuestion) * time.Second
+	if closesAtUnix.Valid {
+		metadata.ClosesAt = time.Unix(0, closesAtUnix.Int64).UTC()
+	}
+	if archivedAtUnix.Valid {
+		metadata.ArchivedAt = time.Unix(0, archivedAtUnix.Int64).UTC()
+	}
 	return metadata, nil
 }
 
+// encodeDifficultyMix stores quizzes without a mix as NULL.
+func encodeDifficultyMix(mix quiz.DifficultyMix) (any, error) {
+	if len(mix) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(mix)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// encodeFeedback stores no feedback as NULL so upserts can keep earlier feedback.
+func encodeFeedback(feedback []string) (any, error) {
+	if len(feedback) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(feedback)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func decodeFeedback(value sql.NullString) ([]string, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	var feedback []string
+	if err := json.Unmarshal([]byte(value.String), &feedback); err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// encodeCorrectIndexes stores the correct options of a multi-select question,
+// and NULL for other types, whose answer is correct_index alone.
+func encodeCorrectIndexes(question quiz.Question) (any, error) {
+	if question.Type != quiz.TypeMultiSelect {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(question.CorrectIndexes)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// decodeAnswerKey sets the type and correct options read from a questions
+// row on question.
+func decodeAnswerKey(question *quiz.Question, kind string, correctIndexesJSON sql.NullString) error {
+	question.Type = quiz.QuestionKind(kind)
+	if !correctIndexesJSON.Valid || correctIndexesJSON.String == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(correctIndexesJSON.String), &question.CorrectIndexes)
+}
+
+// encodeTranslations stores no translations as NULL, like encodeFeedback.
+func encodeTranslations(translations map[string]quiz.Translation) (any, error) {
+	if len(translations) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(translations)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func decodeTranslations(value sql.NullString) (map[string]quiz.Translation, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	var translations map[string]quiz.Translation
+	if err := json.Unmarshal([]byte(value.String), &translations); err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+func nullableUnixNano(value time.Time) any {
+	if value.IsZero() {
+		return nil
+	}
+	return value.UnixNano()
+}
+
 func (s *SQLiteStore) QuizExists(ctx context.Context, quizID string) (bool, error) {
 	var found int
 	err := s.db.QueryRowContext(
@@ -132,7 +360,7 @@ func (s *SQLiteStore) QuizExists(ctx context.Context, quizID string) (bool, erro
 func (s *SQLiteStore) GetQuizQuestions(ctx context.Context, quizID string) ([]quiz.Question, error) {
 	rows, err := s.db.QueryContext(
 		ctx,
-		`SELECT q.question_id, q.prompt, q.options_json, q.correct_index
+		`SELECT q.question_id, q.prompt, q.options_json, q.correct_index, qq.voided_at_unix IS NOT NULL, q.feedback_json, q.difficulty, q.translations_json, q.category, q.question_type, q.correct_indexes_json, q.source
 		 FROM quiz_questions qq
 		 JOIN questions q ON q.question_id = qq.question_id
 		 WHERE qq.quiz_id = ?
@@ -147,12 +375,20 @@ func (s *SQLiteStore) GetQuizQuestions(ctx context.Context, quizID string) ([]qu
 	questions := make([]quiz.Question, 0)
 	for rows.Next() {
 		var (
-			questionID   string
-			prompt       string
-			optionsJSON  string
-			correctIndex int
+			questionID       string
+			prompt           string
+			optionsJSON      string
+			correctIndex     int
+			voided           bool
+			feedbackJSON     sql.NullString
+			difficulty       string
+			translationsJSON sql.NullString
+			category         string
+			kind             string
+			correctJSON      sql.NullString
+			source           string
 		)
-		if err := rows.Scan(&questionID, &prompt, &optionsJSON, &correctIndex); err != nil {
+		if err := rows.Scan(&questionID, &prompt, &optionsJSON, &correctIndex, &voided, &feedbackJSON, &difficulty, &translationsJSON, &category, &kind, &correctJSON, &source); err != nil {
 			return nil, err
 		}
 
@@ -160,15 +396,33 @@ func (s *SQLiteStore) GetQuizQuestions(ctx context.Context, quizID string) ([]qu
 		if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
 			return nil, err
 		}
+		feedback, err := decodeFeedback(feedbackJSON)
+		if err != nil {
+			return nil, err
+		}
+		translations, err := decodeTranslations(translationsJSON)
+		if err != nil {
+			return nil, err
+		}
 
-		questions = append(questions, quiz.Question{
+		question := quiz.Question{
 			PublicQuestion: quiz.PublicQuestion{
 				QuestionID: questionID,
 				Question:   prompt,
 				Options:    options,
 			},
 			CorrectIndex: correctIndex,
-		})
+			Voided:       voided,
+			Feedback:     feedback,
+			Difficulty:   quiz.Difficulty(difficulty),
+			Category:     category,
+			Source:       source,
+			Translations: translations,
+		}
+		if err := decodeAnswerKey(&question, kind, correctJSON); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -195,8 +449,9 @@ func (s *SQLiteStore) ListActiveQuizzes(ctx context.Context, limit int) ([]quiz.
 
 	rows, err := s.db.QueryContext(
 		ctx,
-		`SELECT quiz_id, question_count, created_at_unix
+		`SELECT `+quizMetadataColumns+`
 		 FROM quizzes
+		 WHERE archived_at_unix IS NULL
 		 ORDER BY created_at_unix DESC
 		 LIMIT ?`,
 		limit,
@@ -208,16 +463,191 @@ func (s *SQLiteStore) ListActiveQuizzes(ctx context.Context, limit int) ([]quiz.
 
 	active := make([]quiz.QuizMetadata, 0)
 	for rows.Next() {
-		var (
-			item          quiz.QuizMetadata
-			createdAtUnix int64
-		)
-		if err := rows.Scan(&item.QuizID, &item.QuestionCount, &createdAtUnix); err != nil {
+		item, err := scanQuizMetadata(rows)
+		if err != nil {
 			return nil, err
 		}
-		item.CreatedAt = time.Unix(0, createdAtUnix).UTC()
 		active = append(active, item)
 	}
 
 	return active, rows.Err()
 }
+
+func (s *SQLiteStore) SetQuizLifecycle(ctx context.Context, quizID string, lifecycle quiz.QuizLifecycle) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE quizzes SET draft = ?, locked = ?, closes_at_unix = ? WHERE quiz_id = ?`,
+		lifecycle.Draft,
+		lifecycle.Locked,
+		nullableUnixNano(lifecycle.ClosesAt),
+		quizID,
+	)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return quiz.ErrQuizNotFound
+	}
+	return nil
+}
+
+// SearchQuestions matches prompts case-insensitively (ASCII) with LIKE, escaping
+// wildcard characters so user text is always treated literally. Questions in an
+// adaptive quiz are left out.
+func (s *SQLiteStore) SearchQuestions(ctx context.Context, text string, limit int) ([]quiz.Question, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT question_id, prompt, options_json, correct_index, question_type, correct_indexes_json
+		 FROM questions
+		 WHERE prompt LIKE ? ESCAPE '\'
+		   AND NOT EXISTS (
+		     SELECT 1 FROM quiz_questions qq JOIN quizzes z ON z.quiz_id = qq.quiz_id
+		     WHERE qq.question_id = questions.question_id AND z.adaptive = 1
+		   )
+		 ORDER BY created_at_unix DESC, question_id ASC
+		 LIMIT ?`,
+		"%"+escapeLike(text)+"%",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := make([]quiz.Question, 0)
+	for rows.Next() {
+		var (
+			questionID   string
+			prompt       string
+			optionsJSON  string
+			correctIndex int
+			kind         string
+			correctJSON  sql.NullString
+		)
+		if err := rows.Scan(&questionID, &prompt, &optionsJSON, &correctIndex, &kind, &correctJSON); err != nil {
+			return nil, err
+		}
+
+		var options []quiz.Option
+		if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+			return nil, err
+		}
+
+		question := quiz.Question{
+			PublicQuestion: quiz.PublicQuestion{
+				QuestionID: questionID,
+				Question:   prompt,
+				Options:    options,
+			},
+			CorrectIndex: correctIndex,
+		}
+		if err := decodeAnswerKey(&question, kind, correctJSON); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+
+	return questions, rows.Err()
+}
+
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// lookupBatchSize keeps IN lists under SQLite's default host parameter limit.
+const lookupBatchSize = 500
+
+func (s *SQLiteStore) LookupQuestions(ctx context.Context, questionIDs []string) ([]quiz.Question, error) {
+	questions := make([]quiz.Question, 0, len(questionIDs))
+	for start := 0; start < len(questionIDs); start += lookupBatchSize {
+		batch, err := s.lookupQuestionBatch(ctx, questionIDs[start:min(start+lookupBatchSize, len(questionIDs))])
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, batch...)
+	}
+	return questions, nil
+}
+
+// SampleQuestions picks IDs with ORDER BY RANDOM(), which scans the questions
+// table; it runs only when the provider has failed, not on every quiz.
+func (s *SQLiteStore) SampleQuestions(ctx context.Context, limit int) ([]quiz.Question, error) {
+	if limit <= 0 {
+		return []quiz.Question{}, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT question_id FROM questions ORDER BY RANDOM() LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questionIDs := make([]string, 0, limit)
+	for rows.Next() {
+		var questionID string
+		if err := rows.Scan(&questionID); err != nil {
+			return nil, err
+		}
+		questionIDs = append(questionIDs, questionID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return s.LookupQuestions(ctx, questionIDs)
+}
+
+func (s *SQLiteStore) lookupQuestionBatch(ctx context.Context, questionIDs []string) ([]quiz.Question, error) {
+	args := make([]any, 0, len(questionIDs))
+	for _, questionID := range questionIDs {
+		args = append(args, questionID)
+	}
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT question_id, prompt, options_json, correct_index, feedback_json, difficulty, translations_json, category, question_type, correct_indexes_json, source
+		 FROM questions
+		 WHERE question_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(questionIDs)), ",")+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := make([]quiz.Question, 0, len(questionIDs))
+	for rows.Next() {
+		var (
+			question         quiz.Question
+			optionsJSON      string
+			feedbackJSON     sql.NullString
+			translationsJSON sql.NullString
+			kind             string
+			correctJSON      sql.NullString
+			err              error
+		)
+		if err := rows.Scan(&question.QuestionID, &question.Question, &optionsJSON, &question.CorrectIndex, &feedbackJSON, &question.Difficulty, &translationsJSON, &question.Category, &kind, &correctJSON, &question.Source); err != nil {
+			return nil, err
+		}
+		if err := decodeAnswerKey(&question, kind, correctJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(optionsJSON), &question.Options); err != nil {
+			return nil, err
+		}
+		if question.Feedback, err = decodeFeedback(feedbackJSON); err != nil {
+			return nil, err
+		}
+		if question.Translations, err = decodeTranslations(translationsJSON); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, rows.Err()
+}
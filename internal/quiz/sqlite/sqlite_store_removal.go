@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// quizTables lists every table keyed by quiz_id, for DeleteQuiz. quizzes
+// and quiz_questions go last; DeleteQuiz reads quiz_questions first.
+var quizTables = []string{
+	"attempts",
+	"attempt_summaries",
+	"question_usage",
+	"question_serves",
+	"quiz_serve_log",
+	"leaderboard_settings",
+	"quiz_rosters",
+	"roster_students",
+	"quiz_hosts",
+	"quiz_results",
+	"audit_log",
+	"quiz_questions",
+	"quizzes",
+}
+
+// DeleteQuiz counts the attempts it deletes; summaries of archived ones go
+// uncounted.
+func (s *SQLiteStore) DeleteQuiz(ctx context.Context, quizID string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkQuizExists(ctx, tx, quizID); err != nil {
+		return 0, err
+	}
+	// Questions are shared by content, so only those nothing else refers to
+	// go with the quiz.
+	if _, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM questions
+		 WHERE question_id IN (SELECT question_id FROM quiz_questions WHERE quiz_id = ?)
+			AND question_id NOT IN (SELECT question_id FROM quiz_questions WHERE quiz_id <> ?)
+			AND question_id NOT IN (SELECT question_id FROM bookmarks)
+			AND question_id NOT IN (SELECT question_id FROM question_authors)
+			AND question_id NOT IN (SELECT question_id FROM question_reports)
+			AND question_id NOT IN (SELECT question_id FROM question_retirements)
+			AND question_id NOT IN (SELECT question_id FROM question_tags)`,
+		quizID,
+		quizID,
+	); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, table := range quizTables {
+		result, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE quiz_id = ?`, quizID)
+		if err != nil {
+			return 0, err
+		}
+		if table == "attempts" {
+			count, err := result.RowsAffected()
+			if err != nil {
+				return 0, err
+			}
+			deleted = int(count)
+		}
+	}
+	return deleted, tx.Commit()
+}
+
+func (s *SQLiteStore) ArchiveQuiz(ctx context.Context, quizID string, archivedAt time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkQuizExists(ctx, tx, quizID); err != nil {
+		return 0, err
+	}
+	deleted, err := archiveAttempts(ctx, tx, quizID, math.MaxInt64)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE quizzes SET locked = 1, archived_at_unix = COALESCE(archived_at_unix, ?) WHERE quiz_id = ?`,
+		archivedAt.UnixNano(),
+		quizID,
+	); err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+func checkQuizExists(ctx context.Context, tx *timedTx, quizID string) error {
+	var found int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM quizzes WHERE quiz_id = ?`, quizID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.ErrQuizNotFound
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) SaveQuizResults(ctx context.Context, results quiz.PublishedResults) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO quiz_results (quiz_id, document, published_at_unix)
+		 VALUES (?, ?, ?)
+		 ON CONFLICT(quiz_id) DO NOTHING`,
+		results.QuizID,
+		results.Document,
+		results.PublishedAt.UnixNano(),
+	)
+	return err
+}
+
+func (s *SQLiteStore) GetQuizResults(ctx context.Context, quizID string) (quiz.PublishedResults, error) {
+	var (
+		document        []byte
+		publishedAtUnix int64
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT document, published_at_unix FROM quiz_results WHERE quiz_id = ?`,
+		quizID,
+	).Scan(&document, &publishedAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.PublishedResults{}, nil
+	}
+	if err != nil {
+		return quiz.PublishedResults{}, err
+	}
+	return quiz.PublishedResults{
+		QuizID:      quizID,
+		Document:    document,
+		PublishedAt: time.Unix(0, publishedAtUnix).UTC(),
+	}, nil
+}
@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) IdleQuizzes(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT quiz_id FROM attempts
+		 GROUP BY quiz_id
+		 HAVING MAX(submitted_at_unix) < ?
+		 ORDER BY quiz_id`,
+		cutoff.UnixNano(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quizIDs := make([]string, 0)
+	for rows.Next() {
+		var quizID string
+		if err := rows.Scan(&quizID); err != nil {
+			return nil, err
+		}
+		quizIDs = append(quizIDs, quizID)
+	}
+	return quizIDs, rows.Err()
+}
+
+// ArchiveAttempts leaves answers to voided questions out of the summaries,
+// as GetLeaderboard does, and deletes them with the rest.
+func (s *SQLiteStore) ArchiveAttempts(ctx context.Context, quizID string, cutoff time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	deleted, err := archiveAttempts(ctx, tx, quizID, cutoff.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE quizzes SET locked = 1 WHERE quiz_id = ?`, quizID); err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+// archiveAttempts folds quizID's attempts submitted before cutoff (unix
+// nanoseconds) into its summaries and deletes them.
+func archiveAttempts(ctx context.Context, tx *timedTx, quizID string, cutoff int64) (int, error) {
+	// The WHERE clause is required: without it SQLite would read ON CONFLICT
+	// as a join constraint.
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO attempt_summaries (quiz_id, username_norm, total_score, answered_count, correct_count, first_submitted_at_unix, last_submitted_at_unix)
+		 SELECT a.quiz_id, a.username_norm, SUM(a.score), COUNT(*), SUM(a.score >= 1), MIN(a.submitted_at_unix), MAX(a.submitted_at_unix)
+		 FROM attempts a
+		 JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+		 WHERE a.quiz_id = ? AND a.submitted_at_unix < ? AND qq.voided_at_unix IS NULL
+		 GROUP BY a.username_norm
+		 ON CONFLICT(quiz_id, username_norm) DO UPDATE SET
+			total_score = total_score + excluded.total_score,
+			answered_count = answered_count + excluded.answered_count,
+			correct_count = correct_count + excluded.correct_count,
+			first_submitted_at_unix = MIN(first_submitted_at_unix, excluded.first_submitted_at_unix),
+			last_submitted_at_unix = MAX(last_submitted_at_unix, excluded.last_submitted_at_unix)`,
+		quizID,
+		cutoff,
+	); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM attempts WHERE quiz_id = ? AND submitted_at_unix < ?`,
+		quizID,
+		cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// GetUserAttempts is AttemptSummaries; idx_attempts_user and
+// idx_attempt_summaries_user keep both halves of the query to the user's
+// rows.
+func (s *SQLiteStore) GetUserAttempts(ctx context.Context, usernameNormalized string) ([]quiz.AttemptSummary, error) {
+	return s.AttemptSummaries(ctx, usernameNormalized)
+}
+
+func (s *SQLiteStore) AttemptSummaries(ctx context.Context, usernameNormalized string) ([]quiz.AttemptSummary, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT quiz_id, SUM(score), SUM(answered), SUM(correct), MIN(first_at), MAX(last_at), SUM(archived)
+		 FROM (
+			SELECT a.quiz_id, a.score, 1 AS answered, a.score >= 1 AS correct, a.submitted_at_unix AS first_at, a.submitted_at_unix AS last_at, 0 AS archived
+			FROM attempts a
+			JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+			WHERE a.username_norm = ? AND qq.voided_at_unix IS NULL
+			UNION ALL
+			SELECT quiz_id, total_score, answered_count, correct_count, first_submitted_at_unix, last_submitted_at_unix, answered_count
+			FROM attempt_summaries
+			WHERE username_norm = ?
+		 )
+		 GROUP BY quiz_id
+		 ORDER BY MAX(last_at) DESC, quiz_id ASC`,
+		usernameNormalized,
+		usernameNormalized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]quiz.AttemptSummary, 0)
+	for rows.Next() {
+		var (
+			summary         quiz.AttemptSummary
+			firstAt, lastAt int64
+		)
+		if err := rows.Scan(&summary.QuizID, &summary.TotalScore, &summary.AnsweredCount, &summary.CorrectCount, &firstAt, &lastAt, &summary.ArchivedCount); err != nil {
+			return nil, err
+		}
+		summary.Username = usernameNormalized
+		summary.FirstSubmittedAt = time.Unix(0, firstAt).UTC()
+		summary.LastSubmittedAt = time.Unix(0, lastAt).UTC()
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+// ListAttemptSummaries runs the AttemptSummaries query for every user at
+// once, restricted to quizzes created in the window.
+func (s *SQLiteStore) ListAttemptSummaries(ctx context.Context, since, until time.Time) ([]quiz.AttemptSummary, error) {
+	sinceNano, untilNano := int64(math.MinInt64), int64(math.MaxInt64)
+	if !since.IsZero() {
+		sinceNano = since.UnixNano()
+	}
+	if !until.IsZero() {
+		untilNano = until.UnixNano()
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT t.quiz_id, t.username_norm, SUM(t.score), SUM(t.answered), SUM(t.correct), MIN(t.first_at), MAX(t.last_at), SUM(t.archived)
+		 FROM (
+			SELECT a.quiz_id, a.username_norm, a.score, 1 AS answered, a.score >= 1 AS correct, a.submitted_at_unix AS first_at, a.submitted_at_unix AS last_at, 0 AS archived
+			FROM attempts a
+			JOIN quiz_questions qq ON qq.quiz_id = a.quiz_id AND qq.question_id = a.question_id
+			WHERE qq.voided_at_unix IS NULL
+			UNION ALL
+			SELECT quiz_id, username_norm, total_score, answered_count, correct_count, first_submitted_at_unix, last_submitted_at_unix, answered_count
+			FROM attempt_summaries
+		 ) t
+		 JOIN quizzes q ON q.quiz_id = t.quiz_id
+		 WHERE q.created_at_unix >= ? AND q.created_at_unix < ?
+		 GROUP BY t.quiz_id, t.username_norm`,
+		sinceNano,
+		untilNano,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]quiz.AttemptSummary, 0)
+	for rows.Next() {
+		var (
+			summary         quiz.AttemptSummary
+			firstAt, lastAt int64
+		)
+		if err := rows.Scan(&summary.QuizID, &summary.Username, &summary.TotalScore, &summary.AnsweredCount, &summary.CorrectCount, &firstAt, &lastAt, &summary.ArchivedCount); err != nil {
+			return nil, err
+		}
+		summary.FirstSubmittedAt = time.Unix(0, firstAt).UTC()
+		summary.LastSubmittedAt = time.Unix(0, lastAt).UTC()
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
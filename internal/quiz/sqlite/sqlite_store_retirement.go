@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// QuestionStats counts attempts through quiz_questions so that attempts on a
+// quiz where the question was voided are left out.
+func (s *SQLiteStore) QuestionStats(ctx context.Context, minAttempts int) ([]quiz.QuestionPerformance, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT q.question_id, q.prompt, q.options_json, q.correct_index,
+			a.attempt_count, a.correct_count
+		 FROM (
+			SELECT at.question_id, COUNT(*) AS attempt_count, SUM(CASE WHEN at.score >= 1 THEN 1 ELSE 0 END) AS correct_count
+			FROM attempts at
+			JOIN quiz_questions qq ON qq.quiz_id = at.quiz_id AND qq.question_id = at.question_id
+			WHERE qq.voided_at_unix IS NULL
+			GROUP BY at.question_id
+			HAVING COUNT(*) >= ?
+		 ) a
+		 JOIN questions q ON q.question_id = a.question_id
+		 ORDER BY q.question_id ASC`,
+		minAttempts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]quiz.QuestionPerformance, 0)
+	for rows.Next() {
+		var (
+			item        quiz.QuestionPerformance
+			optionsJSON string
+		)
+		if err := rows.Scan(
+			&item.Question.QuestionID,
+			&item.Question.Question,
+			&optionsJSON,
+			&item.Question.CorrectIndex,
+			&item.AttemptCount,
+			&item.CorrectCount,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(optionsJSON), &item.Question.Options); err != nil {
+			return nil, err
+		}
+		stats = append(stats, item)
+	}
+	return stats, rows.Err()
+}
+
+func (s *SQLiteStore) FlagQuestion(ctx context.Context, review quiz.RetirementReview) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT OR IGNORE INTO question_retirements (question_id, status, attempt_count, correct_count, flagged_at_unix)
+		 VALUES (?, ?, ?, ?, ?)`,
+		review.Question.QuestionID,
+		string(review.Status),
+		review.AttemptCount,
+		review.CorrectCount,
+		review.FlaggedAt.UnixNano(),
+	)
+	return err
+}
+
+const retirementColumns = `q.question_id, q.prompt, q.options_json, q.correct_index,
+	r.status, r.attempt_count, r.correct_count, r.flagged_at_unix, r.decided_at_unix
+	FROM question_retirements r
+	JOIN questions q ON q.question_id = r.question_id`
+
+func (s *SQLiteStore) ListRetirementReviews(ctx context.Context, status quiz.RetirementStatus) ([]quiz.RetirementReview, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+retirementColumns+`
+		 WHERE ? = '' OR r.status = ?
+		 ORDER BY r.flagged_at_unix DESC, q.question_id ASC`,
+		string(status),
+		string(status),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := make([]quiz.RetirementReview, 0)
+	for rows.Next() {
+		review, err := scanRetirementReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (s *SQLiteStore) DecideRetirement(ctx context.Context, questionID string, status quiz.RetirementStatus, decidedAt time.Time) (quiz.RetirementReview, error) {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE question_retirements SET status = ?, decided_at_unix = ? WHERE question_id = ?`,
+		string(status),
+		decidedAt.UnixNano(),
+		questionID,
+	)
+	if err != nil {
+		return quiz.RetirementReview{}, err
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return quiz.RetirementReview{}, err
+	} else if updated == 0 {
+		return quiz.RetirementReview{}, quiz.ErrNotFlagged
+	}
+
+	review, err := scanRetirementReview(s.db.QueryRowContext(ctx, `SELECT `+retirementColumns+` WHERE r.question_id = ?`, questionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.RetirementReview{}, quiz.ErrNotFlagged
+	}
+	return review, err
+}
+
+func scanRetirementReview(row interface{ Scan(...any) error }) (quiz.RetirementReview, error) {
+	var (
+		review        quiz.RetirementReview
+		optionsJSON   string
+		status        string
+		flaggedAtUnix int64
+		decidedAtUnix sql.NullInt64
+	)
+	if err := row.Scan(
+		&review.Question.QuestionID,
+		&review.Question.Question,
+		&optionsJSON,
+		&review.Question.CorrectIndex,
+		&status,
+		&review.AttemptCount,
+		&review.CorrectCount,
+		&flaggedAtUnix,
+		&decidedAtUnix,
+	); err != nil {
+		return quiz.RetirementReview{}, err
+	}
+	if err := json.Unmarshal([]byte(optionsJSON), &review.Question.Options); err != nil {
+		return quiz.RetirementReview{}, err
+	}
+	review.Status = quiz.RetirementStatus(status)
+	review.FlaggedAt = time.Unix(0, flaggedAtUnix).UTC()
+	if decidedAtUnix.Valid {
+		review.DecidedAt = time.Unix(0, decidedAtUnix.Int64).UTC()
+	}
+	return review, nil
+}
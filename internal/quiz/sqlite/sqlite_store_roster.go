@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) GetRoster(ctx context.Context, quizID string) (quiz.Roster, error) {
+	var (
+		roster        quiz.Roster
+		updatedAtUnix int64
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT restricted, updated_at_unix FROM quiz_rosters WHERE quiz_id = ?`,
+		quizID,
+	).Scan(&roster.Restricted, &updatedAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.Roster{}, nil
+	}
+	if err != nil {
+		return quiz.Roster{}, err
+	}
+	roster.UpdatedAt = time.Unix(0, updatedAtUnix).UTC()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT username_norm, name, join_code FROM roster_students WHERE quiz_id = ? ORDER BY position ASC`,
+		quizID,
+	)
+	if err != nil {
+		return quiz.Roster{}, err
+	}
+	defer rows.Close()
+
+	roster.Students = make([]quiz.RosterStudent, 0)
+	for rows.Next() {
+		var student quiz.RosterStudent
+		if err := rows.Scan(&student.Username, &student.Name, &student.JoinCode); err != nil {
+			return quiz.Roster{}, err
+		}
+		roster.Students = append(roster.Students, student)
+	}
+	return roster, rows.Err()
+}
+
+func (s *SQLiteStore) SaveRoster(ctx context.Context, quizID string, roster quiz.Roster) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO quiz_rosters (quiz_id, restricted, updated_at_unix)
+		 VALUES (?, ?, ?)
+		 ON CONFLICT(quiz_id) DO UPDATE SET
+			restricted = excluded.restricted,
+			updated_at_unix = excluded.updated_at_unix`,
+		quizID,
+		roster.Restricted,
+		roster.UpdatedAt.UnixNano(),
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM roster_students WHERE quiz_id = ?`, quizID); err != nil {
+		return err
+	}
+	for position, student := range roster.Students {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO roster_students (quiz_id, position, username_norm, name, join_code) VALUES (?, ?, ?, ?, ?)`,
+			quizID,
+			position,
+			student.Username,
+			student.Name,
+			student.JoinCode,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
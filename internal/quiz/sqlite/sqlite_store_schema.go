@@ -38,11 +38,165 @@ func (s *SQLiteStore) initSchema(ctx context.Context) error {
 			-- REAL keeps scoring model expandable (partial/negative marks) without migration.
 			score REAL NOT NULL,
 			submitted_at_unix INTEGER NOT NULL,
+			-- Admin who entered the answer for the player; empty when the player sent it.
+			submitted_by TEXT NOT NULL DEFAULT '',
 			PRIMARY KEY (quiz_id, question_id, username_norm)
 		);`,
+		`CREATE TABLE IF NOT EXISTS question_usage (
+			question_id TEXT NOT NULL,
+			quiz_id TEXT NOT NULL,
+			used_at_unix INTEGER NOT NULL,
+			PRIMARY KEY (question_id, quiz_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS bookmarks (
+			username_norm TEXT NOT NULL,
+			question_id TEXT NOT NULL,
+			created_at_unix INTEGER NOT NULL,
+			PRIMARY KEY (username_norm, question_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS question_authors (
+			question_id TEXT PRIMARY KEY,
+			author_norm TEXT NOT NULL,
+			created_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS question_reports (
+			question_id TEXT NOT NULL,
+			username_norm TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			created_at_unix INTEGER NOT NULL,
+			PRIMARY KEY (question_id, username_norm)
+		);`,
+		`CREATE TABLE IF NOT EXISTS user_profiles (
+			username_norm TEXT PRIMARY KEY,
+			anonymous INTEGER NOT NULL DEFAULT 0,
+			updated_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS user_preferences (
+			username_norm TEXT PRIMARY KEY,
+			question_count INTEGER NOT NULL DEFAULT 0,
+			difficulty TEXT NOT NULL DEFAULT '',
+			updated_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS question_serves (
+			quiz_id TEXT NOT NULL,
+			username_norm TEXT NOT NULL,
+			question_id TEXT NOT NULL,
+			served_at_unix_nano INTEGER NOT NULL,
+			PRIMARY KEY (quiz_id, username_norm, question_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS quiz_serve_log (
+			quiz_id TEXT NOT NULL,
+			username_norm TEXT NOT NULL,
+			first_served_at_unix_nano INTEGER NOT NULL,
+			last_served_at_unix_nano INTEGER NOT NULL,
+			fetch_count INTEGER NOT NULL,
+			answer_key_served_at_unix_nano INTEGER,
+			PRIMARY KEY (quiz_id, username_norm)
+		);`,
+		`CREATE TABLE IF NOT EXISTS leaderboard_settings (
+			quiz_id TEXT PRIMARY KEY,
+			default_limit INTEGER NOT NULL DEFAULT 0,
+			freeze_seconds INTEGER NOT NULL DEFAULT 0,
+			locks_at_unix INTEGER,
+			tiebreak TEXT NOT NULL DEFAULT '',
+			updated_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS question_retirements (
+			question_id TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			attempt_count INTEGER NOT NULL,
+			correct_count INTEGER NOT NULL,
+			flagged_at_unix INTEGER NOT NULL,
+			decided_at_unix INTEGER
+		);`,
+		`CREATE TABLE IF NOT EXISTS question_tags (
+			question_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (question_id, tag)
+		);`,
+		`CREATE TABLE IF NOT EXISTS quiz_rosters (
+			quiz_id TEXT PRIMARY KEY,
+			restricted INTEGER NOT NULL DEFAULT 0,
+			updated_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS roster_students (
+			quiz_id TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			username_norm TEXT NOT NULL,
+			name TEXT NOT NULL,
+			join_code TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (quiz_id, position),
+			UNIQUE (quiz_id, username_norm)
+		);`,
+		`CREATE TABLE IF NOT EXISTS quiz_hosts (
+			quiz_id TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			username_norm TEXT NOT NULL,
+			role TEXT NOT NULL,
+			token_hash TEXT NOT NULL,
+			added_by TEXT NOT NULL,
+			added_at_unix INTEGER NOT NULL,
+			PRIMARY KEY (quiz_id, position),
+			UNIQUE (quiz_id, username_norm)
+		);`,
+		`CREATE TABLE IF NOT EXISTS user_identities (
+			username_norm TEXT PRIMARY KEY,
+			email TEXT NOT NULL,
+			token_hash TEXT NOT NULL,
+			verified_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS users (
+			username_norm TEXT PRIMARY KEY,
+			password_hash TEXT NOT NULL,
+			created_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS user_streaks (
+			username_norm TEXT PRIMARY KEY,
+			current_days INTEGER NOT NULL,
+			longest_days INTEGER NOT NULL,
+			last_day TEXT NOT NULL,
+			updated_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS signing_keys (
+			username_norm TEXT NOT NULL,
+			key_id TEXT NOT NULL,
+			public_key BLOB NOT NULL,
+			registered_at_unix INTEGER NOT NULL,
+			PRIMARY KEY (username_norm, key_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS quiz_results (
+			quiz_id TEXT PRIMARY KEY,
+			document BLOB NOT NULL,
+			published_at_unix INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			quiz_id TEXT NOT NULL,
+			at_unix INTEGER NOT NULL,
+			actor TEXT NOT NULL,
+			action TEXT NOT NULL,
+			username_norm TEXT NOT NULL,
+			detail TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS attempt_summaries (
+			quiz_id TEXT NOT NULL,
+			username_norm TEXT NOT NULL,
+			total_score REAL NOT NULL,
+			answered_count INTEGER NOT NULL,
+			correct_count INTEGER NOT NULL,
+			first_submitted_at_unix INTEGER NOT NULL,
+			last_submitted_at_unix INTEGER NOT NULL,
+			PRIMARY KEY (quiz_id, username_norm)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_question_authors_author ON question_authors(author_norm);`,
+		`CREATE INDEX IF NOT EXISTS idx_attempts_question ON attempts(question_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_question_usage_used_at ON question_usage(used_at_unix);`,
 		`CREATE INDEX IF NOT EXISTS idx_quizzes_created_at ON quizzes(created_at_unix DESC);`,
 		`CREATE INDEX IF NOT EXISTS idx_attempts_quiz_user ON attempts(quiz_id, username_norm);`,
 		`CREATE INDEX IF NOT EXISTS idx_attempts_quiz_submitted_at ON attempts(quiz_id, submitted_at_unix);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_quiz ON audit_log(quiz_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_attempt_summaries_user ON attempt_summaries(username_norm);`,
+		`CREATE INDEX IF NOT EXISTS idx_attempts_user ON attempts(username_norm);`,
+		`CREATE INDEX IF NOT EXISTS idx_question_tags_tag ON question_tags(tag);`,
 	}
 
 	for _, stmt := range statements {
@@ -50,5 +204,74 @@ func (s *SQLiteStore) initSchema(ctx context.Context) error {
 			return err
 		}
 	}
+
+	// Columns added after the initial schema. CREATE TABLE IF NOT EXISTS never
+	// alters an existing table, so older database files are upgraded here.
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"quiz_questions", "voided_at_unix", "INTEGER"},
+		{"quizzes", "closes_at_unix", "INTEGER"},
+		{"quizzes", "requested_question_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"quizzes", "practice", "INTEGER NOT NULL DEFAULT 0"},
+		{"questions", "feedback_json", "TEXT"},
+		{"quizzes", "adaptive", "INTEGER NOT NULL DEFAULT 0"},
+		{"questions", "difficulty", "TEXT NOT NULL DEFAULT ''"},
+		{"questions", "translations_json", "TEXT"},
+		{"quizzes", "seconds_per_question", "INTEGER NOT NULL DEFAULT 0"},
+		{"questions", "category", "TEXT NOT NULL DEFAULT ''"},
+		{"quizzes", "provider", "TEXT NOT NULL DEFAULT ''"},
+		{"quizzes", "difficulty_mix_json", "TEXT"},
+		{"quizzes", "seed", "INTEGER NOT NULL DEFAULT 0"},
+		{"leaderboard_settings", "tiebreak", "TEXT NOT NULL DEFAULT ''"},
+		{"attempts", "submitted_by", "TEXT NOT NULL DEFAULT ''"},
+		{"quizzes", "fallback_from", "TEXT NOT NULL DEFAULT ''"},
+		{"quiz_serve_log", "nonces_issued", "INTEGER NOT NULL DEFAULT 0"},
+		{"quizzes", "category", "INTEGER NOT NULL DEFAULT 0"},
+		{"quizzes", "question_type", "TEXT NOT NULL DEFAULT ''"},
+		{"quizzes", "draft", "INTEGER NOT NULL DEFAULT 0"},
+		{"questions", "question_type", "TEXT NOT NULL DEFAULT ''"},
+		{"questions", "correct_indexes_json", "TEXT"},
+		{"quizzes", "archived_at_unix", "INTEGER"},
+	}
+	for _, item := range columns {
+		if err := s.addColumnIfMissing(ctx, item.table, item.column, item.definition); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func (s *SQLiteStore) addColumnIfMissing(ctx context.Context, table, column, definition string) error {
+	rows, err := s.db.QueryContext(ctx, `PRAGMA table_info(`+table+`)`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal any
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_ = rows.Close()
+
+	_, err = s.db.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN `+column+` `+definition)
+	return err
+}
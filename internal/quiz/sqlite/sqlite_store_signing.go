@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) SaveSigningKey(ctx context.Context, key quiz.SigningKey) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO signing_keys (username_norm, key_id, public_key, registered_at_unix)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT(username_norm, key_id) DO NOTHING`,
+		key.Username,
+		key.KeyID,
+		[]byte(key.PublicKey),
+		key.RegisteredAt.UnixNano(),
+	)
+	return err
+}
+
+func (s *SQLiteStore) GetSigningKey(ctx context.Context, usernameNormalized, keyID string) (quiz.SigningKey, error) {
+	var (
+		publicKey        []byte
+		registeredAtUnix int64
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT public_key, registered_at_unix FROM signing_keys WHERE username_norm = ? AND key_id = ?`,
+		usernameNormalized,
+		keyID,
+	).Scan(&publicKey, &registeredAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.SigningKey{}, nil
+	}
+	if err != nil {
+		return quiz.SigningKey{}, err
+	}
+	return quiz.SigningKey{
+		Username:     usernameNormalized,
+		KeyID:        keyID,
+		PublicKey:    publicKey,
+		RegisteredAt: time.Unix(0, registeredAtUnix).UTC(),
+	}, nil
+}
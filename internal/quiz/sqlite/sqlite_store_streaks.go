@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) GetStreak(ctx context.Context, usernameNormalized string) (quiz.Streak, error) {
+	return scanStreak(s.db.QueryRowContext(ctx, selectStreakQuery, usernameNormalized), usernameNormalized)
+}
+
+func (s *SQLiteStore) RecordStreakDay(ctx context.Context, usernameNormalized, day string, recordedAt time.Time) (quiz.Streak, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return quiz.Streak{}, err
+	}
+	defer tx.Rollback()
+
+	current, err := scanStreak(tx.QueryRowContext(ctx, selectStreakQuery, usernameNormalized), usernameNormalized)
+	if err != nil {
+		return quiz.Streak{}, err
+	}
+	next := current.Advance(day)
+	if next == current {
+		return current, nil
+	}
+	next.UpdatedAt = recordedAt.UTC()
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO user_streaks (username_norm, current_days, longest_days, last_day, updated_at_unix)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT(username_norm) DO UPDATE SET
+			current_days = excluded.current_days,
+			longest_days = excluded.longest_days,
+			last_day = excluded.last_day,
+			updated_at_unix = excluded.updated_at_unix`,
+		usernameNormalized,
+		next.Current,
+		next.Longest,
+		next.LastDay,
+		next.UpdatedAt.UnixNano(),
+	); err != nil {
+		return quiz.Streak{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return quiz.Streak{}, err
+	}
+	return next, nil
+}
+
+const selectStreakQuery = `SELECT current_days, longest_days, last_day, updated_at_unix FROM user_streaks WHERE username_norm = ?`
+
+func scanStreak(row *timedRow, usernameNormalized string) (quiz.Streak, error) {
+	streak := quiz.Streak{Username: usernameNormalized}
+	var updatedAtUnix int64
+	err := row.Scan(&streak.Current, &streak.Longest, &streak.LastDay, &updatedAtUnix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return streak, nil
+	}
+	if err != nil {
+		return quiz.Streak{}, err
+	}
+	streak.UpdatedAt = time.Unix(0, updatedAtUnix).UTC()
+	return streak, nil
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"quiz-app/internal/quiz"
+)
+
+func (s *SQLiteStore) SetQuestionTags(ctx context.Context, questionID string, tags []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkQuestionExists(ctx, tx, questionID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM question_tags WHERE question_id = ?`, questionID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR IGNORE INTO question_tags (question_id, tag) VALUES (?, ?)`,
+			questionID,
+			tag,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) QuestionTags(ctx context.Context, questionID string) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkQuestionExists(ctx, tx, questionID); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT tag FROM question_tags WHERE question_id = ? ORDER BY tag ASC`, questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]string, 0)
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// SampleBankQuestions picks IDs with ORDER BY RANDOM() among the questions
+// that carry every tag, counted through question_tags.
+func (s *SQLiteStore) SampleBankQuestions(ctx context.Context, filter quiz.BankFilter, limit int) ([]quiz.Question, error) {
+	if limit <= 0 {
+		return []quiz.Question{}, nil
+	}
+	query := `SELECT question_id FROM questions WHERE (? = '' OR difficulty = ?)`
+	args := []any{string(filter.Difficulty), string(filter.Difficulty)}
+	if len(filter.Tags) > 0 {
+		query += ` AND question_id IN (
+			SELECT question_id FROM question_tags
+			WHERE tag IN (` + strings.TrimSuffix(strings.Repeat("?,", len(filter.Tags)), ",") + `)
+			GROUP BY question_id
+			HAVING COUNT(*) = ?
+		)`
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(filter.Tags))
+	}
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY RANDOM() LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questionIDs := make([]string, 0, limit)
+	for rows.Next() {
+		var questionID string
+		if err := rows.Scan(&questionID); err != nil {
+			return nil, err
+		}
+		questionIDs = append(questionIDs, questionID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return s.LookupQuestions(ctx, questionIDs)
+}
+
+func checkQuestionExists(ctx context.Context, tx *timedTx, questionID string) error {
+	var found int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM questions WHERE question_id = ?`, questionID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quiz.ErrUnknownQuestion
+	}
+	return err
+}
@@ -1,14 +1,21 @@
 package sqlite
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"database/sql"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"quiz-app/internal/quiz"
+	"quiz-app/internal/quiz/storetest"
 )
 
 func newTestSQLiteStore(t *testing.T) *SQLiteStore {
@@ -29,6 +36,10 @@ func newTestSQLiteStore(t *testing.T) *SQLiteStore {
 	return store
 }
 
+func TestSQLiteStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Store { return newTestSQLiteStore(t) })
+}
+
 func sampleQuestions() []quiz.Question {
 	return []quiz.Question{
 		{
@@ -360,3 +371,1135 @@ func TestSQLiteStoreListActiveQuizzes(t *testing.T) {
 		t.Fatalf("expected 3 quizzes, got %d", len(top3))
 	}
 }
+
+func TestSQLiteStoreSearchQuestionsMatchesLiterally(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	questions := append(sampleQuestions(), quiz.Question{
+		PublicQuestion: quiz.PublicQuestion{
+			QuestionID: "q3",
+			Question:   "What is 100% of 2?",
+			Options:    []quiz.Option{{Letter: "A", Text: "2"}},
+		},
+		CorrectIndex: 0,
+	})
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	got, err := store.SearchQuestions(ctx, "sky", 10)
+	if err != nil {
+		t.Fatalf("SearchQuestions failed: %v", err)
+	}
+	if len(got) != 1 || got[0].QuestionID != "q2" {
+		t.Fatalf("expected case-insensitive match on q2, got %+v", got)
+	}
+
+	got, err = store.SearchQuestions(ctx, "100%", 10)
+	if err != nil {
+		t.Fatalf("SearchQuestions with wildcard failed: %v", err)
+	}
+	if len(got) != 1 || got[0].QuestionID != "q3" {
+		t.Fatalf("expected literal %% match on q3 only, got %+v", got)
+	}
+
+	got, err = store.SearchQuestions(ctx, "_", 10)
+	if err != nil {
+		t.Fatalf("SearchQuestions with underscore failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected underscore to be matched literally, got %+v", got)
+	}
+
+	// An adaptive pool is served one question at a time, so search skips it.
+	pooled := quiz.Question{
+		PublicQuestion: quiz.PublicQuestion{
+			QuestionID: "q4",
+			Question:   "Which sky is adaptive?",
+			Options:    []quiz.Option{{Letter: "A", Text: "This one"}},
+		},
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-2", Adaptive: true}, []quiz.Question{pooled}); err != nil {
+		t.Fatalf("CreateQuiz(adaptive) failed: %v", err)
+	}
+	got, err = store.SearchQuestions(ctx, "sky", 10)
+	if err != nil {
+		t.Fatalf("SearchQuestions after an adaptive quiz failed: %v", err)
+	}
+	if len(got) != 1 || got[0].QuestionID != "q2" {
+		t.Fatalf("expected the adaptive question left out, got %+v", got)
+	}
+}
+
+func TestSQLiteStoreVoidQuestionExcludesAttemptsAndRejectsAnswers(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-void", CreatedAt: time.Now().UTC()}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if _, err := store.SubmitResponses(ctx, "quiz-void", "alice", []quiz.SubmittedResponse{
+		{QuestionID: "q1", Answer: "A"},
+		{QuestionID: "q2", Answer: "B"},
+	}); err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+
+	if err := store.VoidQuestion(ctx, "quiz-void", "q1", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+	if err := store.VoidQuestion(ctx, "quiz-void", "q1", time.Now()); err != nil {
+		t.Fatalf("repeated VoidQuestion = %v, want nil", err)
+	}
+
+	questions, err := store.GetQuizQuestions(ctx, "quiz-void")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	if !questions[0].Voided || questions[1].Voided {
+		t.Fatalf("voided flags = (%t, %t), want (true, false)", questions[0].Voided, questions[1].Voided)
+	}
+
+	leaderboard, err := store.GetLeaderboard(ctx, "quiz-void")
+	if err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+	if len(leaderboard) != 1 || leaderboard[0].TotalScore != 1 || leaderboard[0].AnsweredCount != 1 {
+		t.Fatalf("unexpected leaderboard after void: %+v", leaderboard)
+	}
+
+	scores, err := store.GetAttemptScores(ctx, "quiz-void", "alice")
+	if err != nil {
+		t.Fatalf("GetAttemptScores failed: %v", err)
+	}
+	if _, ok := scores["q1"]; ok || len(scores) != 1 {
+		t.Fatalf("unexpected attempt scores after void: %+v", scores)
+	}
+
+	results, err := store.SubmitResponses(ctx, "quiz-void", "bob", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}})
+	if err != nil {
+		t.Fatalf("SubmitResponses failed: %v", err)
+	}
+	if results[0].Status != quiz.StatusVoidedQuestion {
+		t.Fatalf("status = %q, want %q", results[0].Status, quiz.StatusVoidedQuestion)
+	}
+
+	if err := store.VoidQuestion(ctx, "quiz-void", "missing", time.Now()); !errors.Is(err, quiz.ErrQuestionNotFound) {
+		t.Fatalf("VoidQuestion missing question = %v, want ErrQuestionNotFound", err)
+	}
+	if err := store.VoidQuestion(ctx, "missing-quiz", "q1", time.Now()); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("VoidQuestion missing quiz = %v, want ErrQuizNotFound", err)
+	}
+}
+
+func TestSQLiteStoreRecentlyUsedQuestionsHonorsWindow(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "daily-1", CreatedAt: time.Now().UTC()}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	base := time.Unix(1700000000, 0).UTC()
+	if err := store.RecordQuestionUsage(ctx, "daily-old", []string{"q1"}, base.AddDate(0, 0, -10)); err != nil {
+		t.Fatalf("RecordQuestionUsage failed: %v", err)
+	}
+	if err := store.RecordQuestionUsage(ctx, "daily-1", []string{"q2"}, base); err != nil {
+		t.Fatalf("RecordQuestionUsage failed: %v", err)
+	}
+
+	recent, err := store.RecentlyUsedQuestions(ctx, base.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("RecentlyUsedQuestions failed: %v", err)
+	}
+	if len(recent) != 1 || recent[0].QuestionID != "q2" || recent[0].Question != "Sky color?" {
+		t.Fatalf("recent questions = %+v, want only q2", recent)
+	}
+}
+
+func TestSQLiteStoreQuizMetadataRoundTripsOptionalFields(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	closesAt := time.Unix(1700003600, 0).UTC()
+	meta := quiz.QuizMetadata{
+		QuizID:                 "quiz-closing",
+		QuestionCount:          2,
+		RequestedQuestionCount: 5,
+		CreatedAt:              time.Unix(1700000000, 0).UTC(),
+		Locked:                 true,
+		ClosesAt:               closesAt,
+		Origin: quiz.QuizOrigin{
+			Provider:      "opentdb",
+			DifficultyMix: quiz.DifficultyMix{quiz.DifficultyEasy: 3, quiz.DifficultyHard: 2},
+			Seed:          42,
+		},
+	}
+	if err := store.CreateQuiz(ctx, meta, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-open", CreatedAt: time.Unix(1699000000, 0).UTC()}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	got, err := store.GetQuizMetadata(ctx, "quiz-closing")
+	if err != nil {
+		t.Fatalf("GetQuizMetadata failed: %v", err)
+	}
+	if !got.Locked || !got.ClosesAt.Equal(closesAt) || got.RequestedQuestionCount != 5 {
+		t.Fatalf("metadata = (%+v), want locked, 5 requested, closes_at %v", got, closesAt)
+	}
+	if got.Origin.Provider != "opentdb" || got.Origin.Seed != 42 || len(got.Origin.DifficultyMix) != 2 || got.Origin.DifficultyMix[quiz.DifficultyEasy] != 3 {
+		t.Fatalf("Origin = %+v, want the recorded provider, mix, and seed", got.Origin)
+	}
+
+	active, err := store.ListActiveQuizzes(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListActiveQuizzes failed: %v", err)
+	}
+	if len(active) != 2 || !active[0].Locked || active[1].Locked || !active[1].ClosesAt.IsZero() || active[1].Origin.Provider != "" || active[1].Origin.DifficultyMix != nil {
+		t.Fatalf("active = (%+v), want locked quiz first and open quiz without deadline", active)
+	}
+}
+
+func TestSQLiteStoreListQuizStats(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	createdAt := time.Unix(1700000000, 0).UTC()
+	for _, quizID := range []string{"busy", "idle"} {
+		if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: quizID, CreatedAt: createdAt}, sampleQuestions()); err != nil {
+			t.Fatalf("CreateQuiz(%s) failed: %v", quizID, err)
+		}
+	}
+	for _, username := range []string{"alice", "bob"} {
+		if _, err := store.SubmitResponses(ctx, "busy", username, []quiz.SubmittedResponse{
+			{QuestionID: "q1", Answer: "A"},
+			{QuestionID: "q2", Answer: "A"},
+		}); err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", username, err)
+		}
+	}
+
+	stats, err := store.ListQuizStats(ctx)
+	if err != nil {
+		t.Fatalf("ListQuizStats failed: %v", err)
+	}
+	byID := make(map[string]quiz.QuizStats, len(stats))
+	for _, item := range stats {
+		byID[item.QuizID] = item
+	}
+
+	busy, idle := byID["busy"], byID["idle"]
+	if len(stats) != 2 || busy.AttemptCount != 4 || busy.ParticipantCount != 2 || busy.QuestionCount != 2 {
+		t.Fatalf("busy stats = (%+v), want 4 attempts by 2 participants", busy)
+	}
+	if !busy.LastActivityAt.After(createdAt) {
+		t.Fatalf("busy LastActivityAt = (%v), want after creation", busy.LastActivityAt)
+	}
+	if idle.AttemptCount != 0 || !idle.LastActivityAt.Equal(createdAt) {
+		t.Fatalf("idle stats = (%+v), want no attempts and activity at creation", idle)
+	}
+	if idle.StorageBytes <= 0 || busy.StorageBytes <= idle.StorageBytes {
+		t.Fatalf("storage = (busy %d, idle %d), want attempts to add bytes", busy.StorageBytes, idle.StorageBytes)
+	}
+}
+
+func TestSQLiteStoreBookmarks(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	first := time.Unix(1700000000, 0).UTC()
+	if err := store.AddBookmark(ctx, "alice", "q1", first); err != nil {
+		t.Fatalf("AddBookmark(q1) failed: %v", err)
+	}
+	if err := store.AddBookmark(ctx, "alice", "q2", first.Add(time.Minute)); err != nil {
+		t.Fatalf("AddBookmark(q2) failed: %v", err)
+	}
+	// Re-adding keeps the original timestamp.
+	if err := store.AddBookmark(ctx, "alice", "q1", first.Add(time.Hour)); err != nil {
+		t.Fatalf("AddBookmark(q1) again failed: %v", err)
+	}
+	if err := store.AddBookmark(ctx, "alice", "missing", first); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("AddBookmark(missing) error = (%v), want ErrUnknownQuestion", err)
+	}
+
+	bookmarks, err := store.ListBookmarks(ctx, "alice")
+	if err != nil {
+		t.Fatalf("ListBookmarks failed: %v", err)
+	}
+	if len(bookmarks) != 2 || bookmarks[0].Question.QuestionID != "q2" || !bookmarks[1].CreatedAt.Equal(first) {
+		t.Fatalf("bookmarks = (%+v), want q2 then q1 at original time", bookmarks)
+	}
+	if bookmarks[1].Question.Question != "2+2?" || len(bookmarks[1].Question.Options) != 2 {
+		t.Fatalf("bookmarked question = (%+v), want full question", bookmarks[1].Question)
+	}
+
+	if err := store.RemoveBookmark(ctx, "alice", "q2"); err != nil {
+		t.Fatalf("RemoveBookmark failed: %v", err)
+	}
+	if bookmarks, _ := store.ListBookmarks(ctx, "alice"); len(bookmarks) != 1 {
+		t.Fatalf("bookmarks after remove = (%+v), want 1", bookmarks)
+	}
+	if bookmarks, _ := store.ListBookmarks(ctx, "bob"); len(bookmarks) != 0 {
+		t.Fatalf("bob's bookmarks = (%+v), want none", bookmarks)
+	}
+}
+
+func TestSQLiteStoreQuestionPerformanceByAuthor(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(quiz-1) failed: %v", err)
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-2"}, sampleQuestions()[:1]); err != nil {
+		t.Fatalf("CreateQuiz(quiz-2) failed: %v", err)
+	}
+
+	authoredAt := time.Unix(1700000000, 0).UTC()
+	if err := store.RecordQuestionAuthor(ctx, "carol", []string{"q1", "q2"}, authoredAt); err != nil {
+		t.Fatalf("RecordQuestionAuthor(carol) failed: %v", err)
+	}
+	// The first author keeps the question.
+	if err := store.RecordQuestionAuthor(ctx, "dave", []string{"q1"}, authoredAt.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordQuestionAuthor(dave) failed: %v", err)
+	}
+
+	submissions := []struct {
+		quizID   string
+		username string
+		answers  []quiz.SubmittedResponse
+	}{
+		{"quiz-1", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}, {QuestionID: "q2", Answer: "A"}}},
+		{"quiz-2", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}}},
+		{"quiz-2", "bob", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "B"}}},
+	}
+	for _, submission := range submissions {
+		if _, err := store.SubmitResponses(ctx, submission.quizID, submission.username, submission.answers); err != nil {
+			t.Fatalf("SubmitResponses(%s, %s) failed: %v", submission.quizID, submission.username, err)
+		}
+	}
+	if err := store.VoidQuestion(ctx, "quiz-1", "q2", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+
+	if err := store.ReportQuestion(ctx, "q1", "alice", "typo", authoredAt); err != nil {
+		t.Fatalf("ReportQuestion(alice) failed: %v", err)
+	}
+	if err := store.ReportQuestion(ctx, "q1", "alice", "still a typo", authoredAt); err != nil {
+		t.Fatalf("repeated ReportQuestion(alice) failed: %v", err)
+	}
+	if err := store.ReportQuestion(ctx, "q1", "bob", "", authoredAt); err != nil {
+		t.Fatalf("ReportQuestion(bob) failed: %v", err)
+	}
+	if err := store.ReportQuestion(ctx, "missing", "bob", "", authoredAt); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("ReportQuestion(missing) error = (%v), want ErrUnknownQuestion", err)
+	}
+
+	performance, err := store.QuestionPerformanceByAuthor(ctx, "carol")
+	if err != nil {
+		t.Fatalf("QuestionPerformanceByAuthor failed: %v", err)
+	}
+	if len(performance) != 2 || performance[0].Question.QuestionID != "q1" || performance[1].Question.QuestionID != "q2" {
+		t.Fatalf("performance = (%+v), want q1 then q2", performance)
+	}
+	if got := performance[0]; got.QuizCount != 2 || got.AttemptCount != 3 || got.CorrectCount != 2 || got.ReportCount != 2 {
+		t.Fatalf("q1 performance = (%+v), want 2 quizzes, 3 attempts, 2 correct, 2 reports", got)
+	}
+	if got := performance[1]; got.QuizCount != 1 || got.AttemptCount != 0 || got.ReportCount != 0 {
+		t.Fatalf("q2 performance = (%+v), want 1 quiz and voided attempt excluded", got)
+	}
+	if performance[0].Question.Question != "2+2?" {
+		t.Fatalf("question = (%+v), want full question", performance[0].Question)
+	}
+
+	if others, err := store.QuestionPerformanceByAuthor(ctx, "dave"); err != nil || len(others) != 0 {
+		t.Fatalf("dave's performance = (%+v, %v), want none", others, err)
+	}
+}
+
+func TestSQLiteStoreLookupQuestions(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	questions, err := store.LookupQuestions(ctx, []string{"q2", "missing"})
+	if err != nil {
+		t.Fatalf("LookupQuestions failed: %v", err)
+	}
+	if len(questions) != 1 || questions[0].QuestionID != "q2" || questions[0].CorrectIndex != 1 || len(questions[0].Options) != 2 {
+		t.Fatalf("questions = (%+v), want q2 only", questions)
+	}
+}
+
+func TestSQLiteStoreKeepsFeedbackAndPracticeFlag(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	questions := sampleQuestions()
+	questions[0].Feedback = []string{"", "3 is one short."}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-practice", Practice: true}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	// Storing the same question again without feedback keeps the earlier feedback.
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-plain"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(plain) failed: %v", err)
+	}
+
+	metadata, err := store.GetQuizMetadata(ctx, "quiz-practice")
+	if err != nil || !metadata.Practice {
+		t.Fatalf("practice metadata = (%+v, %v), want Practice", metadata, err)
+	}
+	if plain, _ := store.GetQuizMetadata(ctx, "quiz-plain"); plain.Practice {
+		t.Fatalf("plain metadata = (%+v), want not Practice", plain)
+	}
+
+	stored, err := store.GetQuizQuestions(ctx, "quiz-plain")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	if stored[0].FeedbackFor(1) != "3 is one short." || stored[1].Feedback != nil {
+		t.Fatalf("feedback = (%q, %q), want q1 feedback kept and none for q2", stored[0].Feedback, stored[1].Feedback)
+	}
+}
+
+func TestSQLiteStoreKeepsTranslations(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	questions := sampleQuestions()
+	questions[1].Translations = map[string]quiz.Translation{"es": {Question: "¿Color del cielo?", Options: []string{"Verde", "Azul"}}}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-bilingual"}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	// A copy without translations keeps the stored ones.
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-plain"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(plain) failed: %v", err)
+	}
+
+	stored, err := store.GetQuizQuestions(ctx, "quiz-plain")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	public, ok := stored[1].Translated("es")
+	if !ok || public.Question != "¿Color del cielo?" || public.Options[1].Text != "Azul" || stored[0].Translations != nil {
+		t.Fatalf("translations = (%+v, %+v), want q2 in Spanish and none for q1", stored[1].Translations, stored[0].Translations)
+	}
+}
+
+func TestSQLiteStoreListAttemptsAndAdaptiveFlag(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	questions := sampleQuestions()
+	questions[0].Difficulty = quiz.DifficultyHard
+	questions[0].Category = "Science & Nature"
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-adaptive", Adaptive: true}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	// Storing the same question again without a difficulty or category keeps
+	// the earlier ones.
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-plain", QuestionTimeLimit: 20 * time.Second}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(plain) failed: %v", err)
+	}
+
+	metadata, err := store.GetQuizMetadata(ctx, "quiz-adaptive")
+	if err != nil || !metadata.Adaptive || metadata.QuestionTimeLimit != 0 {
+		t.Fatalf("adaptive metadata = (%+v, %v), want Adaptive and untimed", metadata, err)
+	}
+	metadata, err = store.GetQuizMetadata(ctx, "quiz-plain")
+	if err != nil || metadata.Adaptive || metadata.QuestionTimeLimit != 20*time.Second {
+		t.Fatalf("plain metadata = (%+v, %v), want a 20s question time limit", metadata, err)
+	}
+	stored, err := store.GetQuizQuestions(ctx, "quiz-plain")
+	if err != nil || stored[0].Difficulty != quiz.DifficultyHard || stored[1].Difficulty != "" {
+		t.Fatalf("difficulties = (%+v, %v), want hard kept for q1 only", stored, err)
+	}
+	if stored[0].Category != "Science & Nature" || stored[1].Category != "" {
+		t.Fatalf("categories = (%q, %q), want q1's category kept", stored[0].Category, stored[1].Category)
+	}
+
+	// Answer order, not question order, decides the history order.
+	for _, response := range []quiz.SubmittedResponse{{QuestionID: "q2", Answer: "B"}, {QuestionID: "q1", Answer: "B"}} {
+		if _, err := store.SubmitResponses(ctx, "quiz-adaptive", "alice", []quiz.SubmittedResponse{response}); err != nil {
+			t.Fatalf("SubmitResponses(%s) failed: %v", response.QuestionID, err)
+		}
+	}
+	attempts, err := store.ListAttempts(ctx, "quiz-adaptive", "alice")
+	if err != nil || len(attempts) != 2 || attempts[0].QuestionID != "q2" || attempts[0].Score != 1 || attempts[1].QuestionID != "q1" || attempts[1].Score != 0 {
+		t.Fatalf("ListAttempts = (%+v, %v), want q2 correct then q1 wrong", attempts, err)
+	}
+
+	if err := store.VoidQuestion(ctx, "quiz-adaptive", "q2", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+	attempts, err = store.ListAttempts(ctx, "quiz-adaptive", "alice")
+	if err != nil || len(attempts) != 1 || attempts[0].QuestionID != "q1" {
+		t.Fatalf("ListAttempts after void = (%+v, %v), want only q1", attempts, err)
+	}
+	if attempts, err := store.ListAttempts(ctx, "quiz-adaptive", "bob"); err != nil || len(attempts) != 0 {
+		t.Fatalf("ListAttempts(bob) = (%+v, %v), want none", attempts, err)
+	}
+}
+
+func TestSQLiteStoreProfiles(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	profile, err := store.GetProfile(ctx, "alice")
+	if err != nil || profile.Username != "alice" || profile.Anonymous || !profile.UpdatedAt.IsZero() {
+		t.Fatalf("GetProfile(unsaved) = (%+v, %v), want defaults", profile, err)
+	}
+
+	updatedAt := time.Unix(1700000000, 0).UTC()
+	if err := store.SaveProfile(ctx, quiz.UserProfile{Username: "alice", Anonymous: true, UpdatedAt: updatedAt}); err != nil {
+		t.Fatalf("SaveProfile failed: %v", err)
+	}
+	if err := store.SaveProfile(ctx, quiz.UserProfile{Username: "bob", UpdatedAt: updatedAt}); err != nil {
+		t.Fatalf("SaveProfile(bob) failed: %v", err)
+	}
+	profile, err = store.GetProfile(ctx, "alice")
+	if err != nil || !profile.Anonymous || !profile.UpdatedAt.Equal(updatedAt) {
+		t.Fatalf("GetProfile = (%+v, %v), want anonymous saved at %v", profile, err, updatedAt)
+	}
+
+	anonymous, err := store.AnonymousUsers(ctx, []string{"alice", "bob", "carol"})
+	if err != nil || len(anonymous) != 1 || !anonymous["alice"] {
+		t.Fatalf("AnonymousUsers = (%v, %v), want only alice", anonymous, err)
+	}
+}
+
+func TestSQLiteStorePreferences(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	preferences, err := store.GetPreferences(ctx, "alice")
+	if err != nil || preferences.Username != "alice" || preferences.QuestionCount != 0 || preferences.Difficulty != "" || !preferences.UpdatedAt.IsZero() {
+		t.Fatalf("GetPreferences(unsaved) = (%+v, %v), want empty preferences", preferences, err)
+	}
+
+	updatedAt := time.Unix(1700000000, 0).UTC()
+	saved := quiz.UserPreferences{Username: "alice", QuestionCount: 8, Difficulty: quiz.DifficultyHard, UpdatedAt: updatedAt}
+	if err := store.SavePreferences(ctx, saved); err != nil {
+		t.Fatalf("SavePreferences failed: %v", err)
+	}
+	preferences, err = store.GetPreferences(ctx, "alice")
+	if err != nil || preferences != saved {
+		t.Fatalf("GetPreferences = (%+v, %v), want %+v", preferences, err, saved)
+	}
+
+	// Saving replaces every field, so a cleared difficulty stays cleared.
+	saved.Difficulty = ""
+	if err := store.SavePreferences(ctx, saved); err != nil {
+		t.Fatalf("SavePreferences(cleared) failed: %v", err)
+	}
+	if preferences, err = store.GetPreferences(ctx, "alice"); err != nil || preferences != saved {
+		t.Fatalf("GetPreferences after clearing = (%+v, %v), want %+v", preferences, err, saved)
+	}
+}
+
+func TestSQLiteStoreStreaks(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	streak, err := store.GetStreak(ctx, "alice")
+	if err != nil || streak.Username != "alice" || streak.Current != 0 || streak.LastDay != "" {
+		t.Fatalf("GetStreak(unplayed) = (%+v, %v), want zero streak", streak, err)
+	}
+
+	recordedAt := time.Unix(1700000000, 0).UTC()
+	for _, day := range []string{"2024-03-01", "2024-03-02", "2024-03-02", "2024-03-03", "2024-03-05"} {
+		if _, err := store.RecordStreakDay(ctx, "alice", day, recordedAt); err != nil {
+			t.Fatalf("RecordStreakDay(%s) failed: %v", day, err)
+		}
+	}
+	streak, err = store.GetStreak(ctx, "alice")
+	if err != nil || streak.Current != 1 || streak.Longest != 3 || streak.LastDay != "2024-03-05" || !streak.UpdatedAt.Equal(recordedAt) {
+		t.Fatalf("GetStreak = (%+v, %v), want current 1, longest 3, last day 2024-03-05", streak, err)
+	}
+}
+
+func TestSQLiteStoreSigningKeys(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if key, err := store.GetSigningKey(ctx, "alice", "k1"); err != nil || key.PublicKey != nil {
+		t.Fatalf("GetSigningKey(unknown) = (%+v, %v), want the zero key", key, err)
+	}
+	first := quiz.SigningKey{Username: "alice", KeyID: "k1", PublicKey: ed25519.PublicKey(bytes.Repeat([]byte{1}, ed25519.PublicKeySize)), RegisteredAt: time.Unix(1700000000, 0).UTC()}
+	if err := store.SaveSigningKey(ctx, first); err != nil {
+		t.Fatalf("SaveSigningKey failed: %v", err)
+	}
+	// Saving the key again keeps the first registration.
+	again := first
+	again.RegisteredAt = first.RegisteredAt.Add(time.Hour)
+	if err := store.SaveSigningKey(ctx, again); err != nil {
+		t.Fatalf("SaveSigningKey(again) failed: %v", err)
+	}
+	key, err := store.GetSigningKey(ctx, "alice", "k1")
+	if err != nil || key.Username != "alice" || key.KeyID != "k1" || !key.PublicKey.Equal(first.PublicKey) || !key.RegisteredAt.Equal(first.RegisteredAt) {
+		t.Fatalf("GetSigningKey = (%+v, %v), want the first registration", key, err)
+	}
+	if other, err := store.GetSigningKey(ctx, "bob", "k1"); err != nil || other.PublicKey != nil {
+		t.Fatalf("GetSigningKey(bob) = (%+v, %v), want keys kept per user", other, err)
+	}
+}
+
+func TestSQLiteStoreQuizResults(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if results, err := store.GetQuizResults(ctx, "quiz-1"); err != nil || results.Document != nil {
+		t.Fatalf("GetQuizResults(unpublished) = (%+v, %v), want the zero value", results, err)
+	}
+	first := quiz.PublishedResults{QuizID: "quiz-1", Document: []byte(`{"quiz_id":"quiz-1","note":"<first>"}`), PublishedAt: time.Unix(1700000000, 0).UTC()}
+	if err := store.SaveQuizResults(ctx, first); err != nil {
+		t.Fatalf("SaveQuizResults failed: %v", err)
+	}
+	// Publishing again keeps the first document.
+	if err := store.SaveQuizResults(ctx, quiz.PublishedResults{QuizID: "quiz-1", Document: []byte(`{}`), PublishedAt: first.PublishedAt.Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveQuizResults(again) failed: %v", err)
+	}
+	results, err := store.GetQuizResults(ctx, "quiz-1")
+	if err != nil || results.QuizID != "quiz-1" || !bytes.Equal(results.Document, first.Document) || !results.PublishedAt.Equal(first.PublishedAt) {
+		t.Fatalf("GetQuizResults = (%+v, %v), want the first document byte for byte", results, err)
+	}
+}
+
+func TestSQLiteStoreAddsSpeedBonusToCorrectAnswers(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+
+	first := time.Unix(100, 0).UTC()
+	if err := store.RecordServes(ctx, "quiz-1", "alice", []string{"q1", "q2"}, first); err != nil {
+		t.Fatalf("RecordServes failed: %v", err)
+	}
+	// Serving again keeps the first time, and other users are tracked separately.
+	if err := store.RecordServes(ctx, "quiz-1", "alice", []string{"q1"}, first.Add(time.Minute)); err != nil {
+		t.Fatalf("RecordServes(again) failed: %v", err)
+	}
+	if err := store.RecordServes(ctx, "quiz-1", "alicia", []string{"q1"}, first.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordServes(alicia) failed: %v", err)
+	}
+	served, err := store.ServeTimes(ctx, "quiz-1", "alice")
+	if err != nil || len(served) != 2 || !served["q1"].Equal(first) || !served["q2"].Equal(first) {
+		t.Fatalf("ServeTimes = (%v, %v), want q1 and q2 at %v", served, err, first)
+	}
+
+	results, err := store.SubmitResponses(ctx, "quiz-1", "alice", []quiz.SubmittedResponse{
+		{QuestionID: "q1", Answer: "A", Bonus: 0.5},
+		{QuestionID: "q2", Answer: "A", Bonus: 0.5},
+	})
+	if err != nil || results[0].Bonus != 0.5 || results[1].Bonus != 0 {
+		t.Fatalf("SubmitResponses = (%+v, %v), want the bonus on the correct answer only", results, err)
+	}
+	leaderboard, err := store.GetLeaderboard(ctx, "quiz-1")
+	if err != nil || len(leaderboard) != 1 || leaderboard[0].TotalScore != 1.5 {
+		t.Fatalf("GetLeaderboard = (%+v, %v), want alice on 1.5", leaderboard, err)
+	}
+}
+
+func TestSQLiteStoreServeLog(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	first := time.Unix(100, 0).UTC()
+	for idx, withAnswerKey := range []bool{false, true, true} {
+		noncesIssued := 0
+		if !withAnswerKey {
+			noncesIssued = 3
+		}
+		if err := store.LogServe(ctx, "quiz-1", "alice", first.Add(time.Duration(idx)*time.Minute), withAnswerKey, noncesIssued); err != nil {
+			t.Fatalf("LogServe(%d) failed: %v", idx, err)
+		}
+	}
+	if err := store.LogServe(ctx, "quiz-2", "bob", first, false, 0); err != nil {
+		t.Fatalf("LogServe(bob) failed: %v", err)
+	}
+
+	entries, err := store.ListServeLog(ctx, "quiz-1")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ListServeLog = (%+v, %v), want only alice", entries, err)
+	}
+	entry := entries[0]
+	if entry.Username != "alice" || entry.FetchCount != 3 || entry.NoncesIssued != 3 || !entry.FirstServedAt.Equal(first) ||
+		!entry.LastServedAt.Equal(first.Add(2*time.Minute)) || !entry.AnswerKeyServedAt.Equal(first.Add(time.Minute)) {
+		t.Fatalf("entry = %+v, want 3 fetches and 3 nonces with the answer key first served at +1m", entry)
+	}
+}
+
+func TestSQLiteStoreLeaderboardSettings(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	settings, err := store.GetLeaderboardSettings(ctx, "quiz-1")
+	if err != nil || settings != (quiz.LeaderboardSettings{}) {
+		t.Fatalf("GetLeaderboardSettings(unsaved) = (%+v, %v), want zero settings", settings, err)
+	}
+
+	saved := quiz.LeaderboardSettings{
+		DefaultLimit: 25,
+		FreezeWindow: 5 * time.Minute,
+		LocksAt:      time.Unix(1700000600, 0).UTC(),
+		UpdatedAt:    time.Unix(1700000000, 0).UTC(),
+	}
+	if err := store.SaveLeaderboardSettings(ctx, "quiz-1", saved); err != nil {
+		t.Fatalf("SaveLeaderboardSettings failed: %v", err)
+	}
+	if settings, err = store.GetLeaderboardSettings(ctx, "quiz-1"); err != nil || settings != saved {
+		t.Fatalf("GetLeaderboardSettings = (%+v, %v), want %+v", settings, err, saved)
+	}
+
+	cleared := quiz.LeaderboardSettings{DefaultLimit: 10, UpdatedAt: saved.UpdatedAt.Add(time.Minute)}
+	if err := store.SaveLeaderboardSettings(ctx, "quiz-1", cleared); err != nil {
+		t.Fatalf("SaveLeaderboardSettings(cleared) failed: %v", err)
+	}
+	if settings, err = store.GetLeaderboardSettings(ctx, "quiz-1"); err != nil || settings != cleared {
+		t.Fatalf("GetLeaderboardSettings after clearing = (%+v, %v), want %+v", settings, err, cleared)
+	}
+}
+
+func TestSQLiteStoreRetirementReviews(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, sampleQuestions()); err != nil {
+		t.Fatalf("CreateQuiz(quiz-1) failed: %v", err)
+	}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-2"}, sampleQuestions()[:1]); err != nil {
+		t.Fatalf("CreateQuiz(quiz-2) failed: %v", err)
+	}
+	submissions := []struct {
+		quizID   string
+		username string
+		answers  []quiz.SubmittedResponse
+	}{
+		{"quiz-1", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}, {QuestionID: "q2", Answer: "A"}}},
+		{"quiz-1", "bob", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}, {QuestionID: "q2", Answer: "B"}}},
+		{"quiz-2", "carol", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}}},
+	}
+	for _, submission := range submissions {
+		if _, err := store.SubmitResponses(ctx, submission.quizID, submission.username, submission.answers); err != nil {
+			t.Fatalf("SubmitResponses(%s, %s) failed: %v", submission.quizID, submission.username, err)
+		}
+	}
+	if err := store.VoidQuestion(ctx, "quiz-2", "q1", time.Now()); err != nil {
+		t.Fatalf("VoidQuestion failed: %v", err)
+	}
+
+	stats, err := store.QuestionStats(ctx, 2)
+	if err != nil {
+		t.Fatalf("QuestionStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Question.QuestionID != "q1" || stats[1].Question.QuestionID != "q2" {
+		t.Fatalf("stats = (%+v), want q1 then q2", stats)
+	}
+	if got := stats[0]; got.AttemptCount != 2 || got.CorrectCount != 2 || got.Question.Question != "2+2?" {
+		t.Fatalf("q1 stats = (%+v), want 2 attempts, 2 correct, voided attempt excluded", got)
+	}
+	if got := stats[1]; got.AttemptCount != 2 || got.CorrectCount != 1 {
+		t.Fatalf("q2 stats = (%+v), want 2 attempts, 1 correct", got)
+	}
+	if stats, err := store.QuestionStats(ctx, 3); err != nil || len(stats) != 0 {
+		t.Fatalf("QuestionStats(3) = (%+v, %v), want none", stats, err)
+	}
+
+	flaggedAt := time.Unix(1700000000, 0).UTC()
+	if err := store.FlagQuestion(ctx, quiz.RetirementReview{
+		Question:     stats[0].Question,
+		Status:       quiz.RetirementPending,
+		AttemptCount: 2,
+		CorrectCount: 2,
+		FlaggedAt:    flaggedAt,
+	}); err != nil {
+		t.Fatalf("FlagQuestion failed: %v", err)
+	}
+	if _, err := store.DecideRetirement(ctx, "q1", quiz.RetirementRetired, flaggedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("DecideRetirement failed: %v", err)
+	}
+	// Flagging again leaves the decision alone.
+	if err := store.FlagQuestion(ctx, quiz.RetirementReview{Question: stats[0].Question, Status: quiz.RetirementPending, FlaggedAt: flaggedAt.Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("repeated FlagQuestion failed: %v", err)
+	}
+
+	reviews, err := store.ListRetirementReviews(ctx, quiz.RetirementRetired)
+	if err != nil {
+		t.Fatalf("ListRetirementReviews failed: %v", err)
+	}
+	if len(reviews) != 1 {
+		t.Fatalf("retired reviews = (%+v), want q1", reviews)
+	}
+	if got := reviews[0]; got.Question.QuestionID != "q1" || got.AttemptCount != 2 || !got.FlaggedAt.Equal(flaggedAt) || !got.DecidedAt.Equal(flaggedAt.Add(time.Hour)) {
+		t.Fatalf("review = (%+v), want q1 flagged at %v and decided an hour later", got, flaggedAt)
+	}
+	if pending, err := store.ListRetirementReviews(ctx, quiz.RetirementPending); err != nil || len(pending) != 0 {
+		t.Fatalf("pending reviews = (%+v, %v), want none", pending, err)
+	}
+	if _, err := store.DecideRetirement(ctx, "q2", quiz.RetirementKept, flaggedAt); !errors.Is(err, quiz.ErrNotFlagged) {
+		t.Fatalf("DecideRetirement(q2) error = (%v), want ErrNotFlagged", err)
+	}
+}
+
+func TestSQLiteStoreQueryTimeoutAndSlowLog(t *testing.T) {
+	var logged []string
+	store, err := NewSQLiteStoreWithOptions(filepath.Join(t.TempDir(), "timed.db"), StoreOptions{
+		QueryTimeout:       50 * time.Millisecond,
+		SlowQueryThreshold: time.Nanosecond,
+		Logf: func(format string, args ...any) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSQLiteStoreWithOptions failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+
+	logged = nil
+	if _, err := store.SubmitResponses(ctx, "quiz-1", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); err == nil {
+		t.Fatalf("SubmitResponses to a missing quiz succeeded, want an error")
+	}
+	if len(logged) == 0 {
+		t.Fatalf("slow log is empty, want the statements logged")
+	}
+	for _, line := range logged {
+		if strings.Contains(line, "alice") || strings.Contains(line, "quiz-1") {
+			t.Fatalf("slow log line = (%s), want string arguments redacted", line)
+		}
+	}
+
+	// Counting forever only ends when the statement is interrupted.
+	var count int
+	err = store.db.QueryRowContext(ctx, `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT COUNT(*) FROM c`).Scan(&count)
+	if !errors.Is(err, quiz.ErrQueryTimeout) {
+		t.Fatalf("endless query error = (%v), want ErrQueryTimeout", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := store.ListActiveQuizzes(canceled, 10); err == nil || errors.Is(err, quiz.ErrQueryTimeout) {
+		t.Fatalf("canceled caller error = (%v), want the caller's error, not ErrQueryTimeout", err)
+	}
+}
+
+func TestSQLiteStoreRosterRoundTrip(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if roster, err := store.GetRoster(ctx, "quiz-1"); err != nil || roster.Restricted || len(roster.Students) != 0 || !roster.UpdatedAt.IsZero() {
+		t.Fatalf("GetRoster(empty) = (%+v, %v), want zero roster", roster, err)
+	}
+
+	updatedAt := time.Unix(1700000000, 0).UTC()
+	roster := quiz.Roster{
+		Restricted: true,
+		Students: []quiz.RosterStudent{
+			{Username: "zed", Name: "Zed Adams", JoinCode: "K7PQ2X"},
+			{Username: "alice", Name: "Alice Smith"},
+		},
+		UpdatedAt: updatedAt,
+	}
+	if err := store.SaveRoster(ctx, "quiz-1", roster); err != nil {
+		t.Fatalf("SaveRoster failed: %v", err)
+	}
+	got, err := store.GetRoster(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetRoster failed: %v", err)
+	}
+	if !got.Restricted || !got.UpdatedAt.Equal(updatedAt) || len(got.Students) != 2 || got.Students[0] != roster.Students[0] || got.Students[1] != roster.Students[1] {
+		t.Fatalf("GetRoster = (%+v), want (%+v) in roster order", got, roster)
+	}
+
+	// Saving replaces the whole roster.
+	if err := store.SaveRoster(ctx, "quiz-1", quiz.Roster{Students: []quiz.RosterStudent{{Username: "alice"}}, UpdatedAt: updatedAt}); err != nil {
+		t.Fatalf("second SaveRoster failed: %v", err)
+	}
+	if got, err := store.GetRoster(ctx, "quiz-1"); err != nil || got.Restricted || len(got.Students) != 1 || got.Students[0].Username != "alice" {
+		t.Fatalf("GetRoster after replace = (%+v, %v), want only alice, unrestricted", got, err)
+	}
+}
+
+func TestSQLiteStoreHostsRoundTrip(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if hosts, err := store.GetHosts(ctx, "quiz-1"); err != nil || len(hosts) != 0 {
+		t.Fatalf("GetHosts(empty) = (%+v, %v), want none", hosts, err)
+	}
+
+	addedAt := time.Unix(1700000000, 0).UTC()
+	hosts := []quiz.QuizHost{
+		{Username: "carol", Role: quiz.HostOwner, TokenHash: "hash-c", AddedBy: "bob", AddedAt: addedAt},
+		{Username: "alice", Role: quiz.HostCoHost, TokenHash: "hash-a", AddedBy: "Dana", AddedAt: addedAt.Add(-time.Hour)},
+	}
+	if err := store.SaveHosts(ctx, "quiz-1", hosts); err != nil {
+		t.Fatalf("SaveHosts failed: %v", err)
+	}
+	got, err := store.GetHosts(ctx, "quiz-1")
+	if err != nil || len(got) != 2 || got[0] != hosts[0] || got[1] != hosts[1] {
+		t.Fatalf("GetHosts = (%+v, %v), want %+v in order", got, err, hosts)
+	}
+	if other, err := store.GetHosts(ctx, "quiz-2"); err != nil || len(other) != 0 {
+		t.Fatalf("GetHosts(other quiz) = (%+v, %v), want none", other, err)
+	}
+
+	// Saving replaces the whole list.
+	if err := store.SaveHosts(ctx, "quiz-1", hosts[1:]); err != nil {
+		t.Fatalf("second SaveHosts failed: %v", err)
+	}
+	if got, err := store.GetHosts(ctx, "quiz-1"); err != nil || len(got) != 1 || got[0].Username != "alice" {
+		t.Fatalf("GetHosts after replace = (%+v, %v), want only alice", got, err)
+	}
+}
+
+func TestSQLiteStoreIdentityRoundTrip(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if identity, err := store.GetIdentity(ctx, "alice"); err != nil || identity.Verified() {
+		t.Fatalf("GetIdentity(missing) = (%+v, %v), want unverified", identity, err)
+	}
+
+	verifiedAt := time.Unix(1700000000, 0).UTC()
+	identity := quiz.Identity{Username: "alice", Email: "alice@example.com", TokenHash: "hash-1", VerifiedAt: verifiedAt}
+	if err := store.SaveIdentity(ctx, identity); err != nil {
+		t.Fatalf("SaveIdentity failed: %v", err)
+	}
+	// Saving again replaces the token.
+	identity.TokenHash = "hash-2"
+	if err := store.SaveIdentity(ctx, identity); err != nil {
+		t.Fatalf("second SaveIdentity failed: %v", err)
+	}
+	got, err := store.GetIdentity(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetIdentity failed: %v", err)
+	}
+	if got.Username != "alice" || got.Email != "alice@example.com" || got.TokenHash != "hash-2" || !got.VerifiedAt.Equal(verifiedAt) {
+		t.Fatalf("GetIdentity = (%+v), want (%+v)", got, identity)
+	}
+}
+
+func TestSQLiteStoreAccountRoundTrip(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	if account, err := store.GetAccount(ctx, "alice"); err != nil || account.Registered() {
+		t.Fatalf("GetAccount(missing) = (%+v, %v), want unregistered", account, err)
+	}
+
+	createdAt := time.Unix(1700000000, 0).UTC()
+	account := quiz.Account{Username: "alice", PasswordHash: "hash-1", CreatedAt: createdAt}
+	if err := store.CreateAccount(ctx, account); err != nil {
+		t.Fatalf("CreateAccount failed: %v", err)
+	}
+	// A taken username keeps its first account.
+	if err := store.CreateAccount(ctx, quiz.Account{Username: "alice", PasswordHash: "hash-2", CreatedAt: createdAt}); !errors.Is(err, quiz.ErrAccountExists) {
+		t.Fatalf("second CreateAccount = %v, want ErrAccountExists", err)
+	}
+	got, err := store.GetAccount(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetAccount failed: %v", err)
+	}
+	if got.Username != "alice" || got.PasswordHash != "hash-1" || !got.CreatedAt.Equal(createdAt) {
+		t.Fatalf("GetAccount = (%+v), want (%+v)", got, account)
+	}
+}
+
+func TestSQLiteStoreQuestionTagsRoundTrip(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	questions := sampleQuestions()
+	questions[0].Difficulty, questions[1].Difficulty = quiz.DifficultyEasy, quiz.DifficultyHard
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-1"}, questions); err != nil {
+		t.Fatalf("CreateQuiz failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "q1", []string{"geo", "week-1"}); err != nil {
+		t.Fatalf("SetQuestionTags(q1) failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "q2", []string{"geo"}); err != nil {
+		t.Fatalf("SetQuestionTags(q2) failed: %v", err)
+	}
+	if err := store.SetQuestionTags(ctx, "missing", []string{"geo"}); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("SetQuestionTags(missing) = %v, want ErrUnknownQuestion", err)
+	}
+	if tags, err := store.QuestionTags(ctx, "q1"); err != nil || strings.Join(tags, ",") != "geo,week-1" {
+		t.Fatalf("QuestionTags(q1) = (%v, %v), want geo,week-1", tags, err)
+	}
+	if _, err := store.QuestionTags(ctx, "missing"); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("QuestionTags(missing) = %v, want ErrUnknownQuestion", err)
+	}
+
+	for _, tc := range []struct {
+		filter quiz.BankFilter
+		want   string
+	}{
+		{quiz.BankFilter{Tags: []string{"geo"}}, "q1,q2"},
+		{quiz.BankFilter{Tags: []string{"geo", "week-1"}}, "q1"},
+		{quiz.BankFilter{Tags: []string{"geo"}, Difficulty: quiz.DifficultyHard}, "q2"},
+		{quiz.BankFilter{Tags: []string{"history"}}, ""},
+		{quiz.BankFilter{}, "q1,q2"},
+	} {
+		sampled, err := store.SampleBankQuestions(ctx, tc.filter, 10)
+		if err != nil {
+			t.Fatalf("SampleBankQuestions(%+v) failed: %v", tc.filter, err)
+		}
+		ids := make([]string, 0, len(sampled))
+		for _, question := range sampled {
+			ids = append(ids, question.QuestionID)
+		}
+		slices.Sort(ids)
+		if got := strings.Join(ids, ","); got != tc.want {
+			t.Fatalf("SampleBankQuestions(%+v) = %s, want %s", tc.filter, got, tc.want)
+		}
+	}
+	if sampled, err := store.SampleBankQuestions(ctx, quiz.BankFilter{Tags: []string{"geo"}}, 1); err != nil || len(sampled) != 1 || len(sampled[0].Options) != 2 {
+		t.Fatalf("SampleBankQuestions(limit 1) = (%+v, %v), want one full question", sampled, err)
+	}
+
+	// Clearing q1's tags lets it go with the quiz; tagged q2 stays.
+	if err := store.SetQuestionTags(ctx, "q1", nil); err != nil {
+		t.Fatalf("SetQuestionTags(q1, nil) failed: %v", err)
+	}
+	if _, err := store.DeleteQuiz(ctx, "quiz-1"); err != nil {
+		t.Fatalf("DeleteQuiz failed: %v", err)
+	}
+	if _, err := store.QuestionTags(ctx, "q1"); !errors.Is(err, quiz.ErrUnknownQuestion) {
+		t.Fatalf("QuestionTags(q1) after delete = %v, want ErrUnknownQuestion", err)
+	}
+	if tags, err := store.QuestionTags(ctx, "q2"); err != nil || strings.Join(tags, ",") != "geo" {
+		t.Fatalf("QuestionTags(q2) after delete = (%v, %v), want geo", tags, err)
+	}
+}
+
+func TestSQLiteStoreMaintainReclaimsFreedPages(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	ctx := context.Background()
+	db := store.db.DB
+
+	if _, err := db.Exec(`CREATE TABLE scratch (body BLOB)`); err != nil {
+		t.Fatalf("create scratch table: %v", err)
+	}
+	for idx := 0; idx < 200; idx++ {
+		if _, err := db.Exec(`INSERT INTO scratch (body) VALUES (zeroblob(8192))`); err != nil {
+			t.Fatalf("insert scratch row: %v", err)
+		}
+	}
+	if _, err := db.Exec(`DROP TABLE scratch`); err != nil {
+		t.Fatalf("drop scratch table: %v", err)
+	}
+
+	report, err := store.Maintain(ctx)
+	if err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	if report.Converted {
+		t.Fatalf("new database was converted; want incremental auto-vacuum from the start")
+	}
+	if report.ReclaimedBytes() < 200*8192 {
+		t.Fatalf("reclaimed %d bytes, want at least %d", report.ReclaimedBytes(), 200*8192)
+	}
+	var free int
+	if err := db.QueryRow(`PRAGMA freelist_count`).Scan(&free); err != nil {
+		t.Fatalf("freelist_count: %v", err)
+	}
+	if free != 0 {
+		t.Fatalf("freelist_count = %d after Maintain, want 0", free)
+	}
+
+	again, err := store.Maintain(ctx)
+	if err != nil {
+		t.Fatalf("second Maintain failed: %v", err)
+	}
+	if again.ReclaimedBytes() != 0 {
+		t.Fatalf("second run reclaimed %d bytes, want 0", again.ReclaimedBytes())
+	}
+}
+
+func TestSQLiteStoreMaintainConvertsOlderDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	raw, err := sql.Open(driverName, path)
+	if err != nil {
+		t.Fatalf("open raw database: %v", err)
+	}
+	if _, err := raw.Exec(`CREATE TABLE legacy (id INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	_ = raw.Close()
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+
+	report, err := store.Maintain(context.Background())
+	if err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	if !report.Converted {
+		t.Fatalf("first run on an older database did not convert it")
+	}
+	var mode int
+	if err := store.db.DB.QueryRow(`PRAGMA auto_vacuum`).Scan(&mode); err != nil {
+		t.Fatalf("auto_vacuum: %v", err)
+	}
+	if mode != autoVacuumIncremental {
+		t.Fatalf("auto_vacuum = %d after conversion, want %d", mode, autoVacuumIncremental)
+	}
+}
+
+func TestMaintenanceScheduleNextRun(t *testing.T) {
+	night, err := ParseMaintenanceWindow("02:00-05:00", nil)
+	if err != nil {
+		t.Fatalf("ParseMaintenanceWindow failed: %v", err)
+	}
+	overnight, err := ParseMaintenanceWindow("23:00-01:30", nil)
+	if err != nil {
+		t.Fatalf("ParseMaintenanceWindow failed: %v", err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		schedule MaintenanceSchedule
+		now      time.Time
+		last     time.Time
+		want     time.Time
+	}{
+		{"before window", MaintenanceSchedule{Window: night}, at(10, 1, 0), time.Time{}, at(10, 2, 0)},
+		{"inside window", MaintenanceSchedule{Window: night}, at(10, 3, 0), time.Time{}, at(10, 3, 0)},
+		{"after window", MaintenanceSchedule{Window: night}, at(10, 6, 0), time.Time{}, at(11, 2, 0)},
+		{"ran earlier tonight", MaintenanceSchedule{Window: night}, at(10, 2, 30), at(10, 2, 0), at(11, 2, 0)},
+		{"short interval stays in window", MaintenanceSchedule{Window: night, Interval: time.Hour}, at(10, 2, 30), at(10, 2, 0), at(10, 3, 0)},
+		{"wrapping window after midnight", MaintenanceSchedule{Window: overnight}, at(10, 1, 0), time.Time{}, at(10, 1, 0)},
+		{"wrapping window in the afternoon", MaintenanceSchedule{Window: overnight}, at(10, 14, 0), time.Time{}, at(10, 23, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.nextRun(tt.now, tt.last); !got.Equal(tt.want) {
+				t.Fatalf("nextRun = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "02:00", "2am-5am", "03:00-03:00", "25:00-01:00"} {
+		if _, err := ParseMaintenanceWindow(bad, nil); err == nil {
+			t.Fatalf("ParseMaintenanceWindow(%q) succeeded, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// RecordQuestionUsage notes that questionIDs were served by quizID at usedAt.
+// Re-recording the same (question, quiz) pair keeps the first timestamp.
+func (s *SQLiteStore) RecordQuestionUsage(ctx context.Context, quizID string, questionIDs []string, usedAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, questionID := range questionIDs {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR IGNORE INTO question_usage (question_id, quiz_id, used_at_unix) VALUES (?, ?, ?)`,
+			questionID,
+			quizID,
+			usedAt.UTC().Unix(),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) RecentlyUsedQuestions(ctx context.Context, since time.Time) ([]quiz.Question, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT q.question_id, q.prompt, q.options_json, q.correct_index
+		 FROM questions q
+		 WHERE q.question_id IN (SELECT question_id FROM question_usage WHERE used_at_unix >= ?)`,
+		since.UTC().Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := make([]quiz.Question, 0)
+	for rows.Next() {
+		var (
+			question    quiz.Question
+			optionsJSON string
+		)
+		if err := rows.Scan(&question.QuestionID, &question.Question, &optionsJSON, &question.CorrectIndex); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(optionsJSON), &question.Options); err != nil {
+			return nil, err
+		}
+		questions = append(questions, question)
+	}
+	return questions, rows.Err()
+}
+
+// RecordServes notes that usernameNormalized was served questionIDs of quizID
+// at servedAt. Serving a question again keeps the first timestamp.
+func (s *SQLiteStore) RecordServes(ctx context.Context, quizID, usernameNormalized string, questionIDs []string, servedAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, questionID := range questionIDs {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR IGNORE INTO question_serves (quiz_id, username_norm, question_id, served_at_unix_nano) VALUES (?, ?, ?, ?)`,
+			quizID,
+			usernameNormalized,
+			questionID,
+			servedAt.UTC().UnixNano(),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) ServeTimes(ctx context.Context, quizID, usernameNormalized string) (map[string]time.Time, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT question_id, served_at_unix_nano FROM question_serves WHERE quiz_id = ? AND username_norm = ?`,
+		quizID,
+		usernameNormalized,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	served := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			questionID string
+			servedAtNs int64
+		)
+		if err := rows.Scan(&questionID, &servedAtNs); err != nil {
+			return nil, err
+		}
+		served[questionID] = time.Unix(0, servedAtNs).UTC()
+	}
+	return served, rows.Err()
+}
+
+// LogServe upserts the user's serving log entry for quizID. The answer key
+// timestamp keeps the first fetch that included it.
+func (s *SQLiteStore) LogServe(ctx context.Context, quizID, usernameNormalized string, servedAt time.Time, withAnswerKey bool, noncesIssued int) error {
+	var answerKeyAt any
+	if withAnswerKey {
+		answerKeyAt = servedAt.UTC().UnixNano()
+	}
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO quiz_serve_log (quiz_id, username_norm, first_served_at_unix_nano, last_served_at_unix_nano, fetch_count, answer_key_served_at_unix_nano, nonces_issued)
+		 VALUES (?, ?, ?, ?, 1, ?, ?)
+		 ON CONFLICT(quiz_id, username_norm) DO UPDATE SET
+			last_served_at_unix_nano = MAX(last_served_at_unix_nano, excluded.last_served_at_unix_nano),
+			fetch_count = fetch_count + 1,
+			answer_key_served_at_unix_nano = COALESCE(answer_key_served_at_unix_nano, excluded.answer_key_served_at_unix_nano),
+			nonces_issued = nonces_issued + excluded.nonces_issued`,
+		quizID,
+		usernameNormalized,
+		servedAt.UTC().UnixNano(),
+		servedAt.UTC().UnixNano(),
+		answerKeyAt,
+		noncesIssued,
+	)
+	return err
+}
+
+func (s *SQLiteStore) ListServeLog(ctx context.Context, quizID string) ([]quiz.ServeLogEntry, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT username_norm, first_served_at_unix_nano, last_served_at_unix_nano, fetch_count, answer_key_served_at_unix_nano, nonces_issued
+		 FROM quiz_serve_log
+		 WHERE quiz_id = ?`,
+		quizID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]quiz.ServeLogEntry, 0)
+	for rows.Next() {
+		var (
+			entry         quiz.ServeLogEntry
+			firstNs       int64
+			lastNs        int64
+			answerKeyAtNs sql.NullInt64
+		)
+		if err := rows.Scan(&entry.Username, &firstNs, &lastNs, &entry.FetchCount, &answerKeyAtNs, &entry.NoncesIssued); err != nil {
+			return nil, err
+		}
+		entry.FirstServedAt = time.Unix(0, firstNs).UTC()
+		entry.LastServedAt = time.Unix(0, lastNs).UTC()
+		if answerKeyAtNs.Valid {
+			entry.AnswerKeyServedAt = time.Unix(0, answerKeyAtNs.Int64).UTC()
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
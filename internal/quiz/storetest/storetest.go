@@ -0,0 +1,931 @@
+// Package storetest is a conformance suite for quiz storage backends. Every
+// QuizRepository and AttemptRepository implementation runs it from its own
+// tests, so backends agree on the behavior the service relies on: how a
+// re-created quiz replaces the old one, how repeated answers are reported,
+// how the leaderboard is ordered, and which errors mean "not found".
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) storetest.Store { return newTestStore(t) })
+//	}
+package storetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// Store is what the suite exercises: the two repositories every backend
+// implements. Optional capabilities are tested by each backend, except where
+// they change what these repositories return; those checks skip stores
+// without the capability.
+type Store interface {
+	quiz.QuizRepository
+	quiz.AttemptRepository
+}
+
+// Run runs every conformance check as a subtest. newStore must return a new,
+// empty store each time it is called and clean it up when t ends.
+func Run(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Helper()
+	for _, check := range []struct {
+		name string
+		run  func(*testing.T, Store)
+	}{
+		{"CreateAndRead", testCreateAndRead},
+		{"CreateOverwrites", testCreateOverwrites},
+		{"ListActiveQuizzes", testListActiveQuizzes},
+		{"NotFound", testNotFound},
+		{"AnswerStatuses", testAnswerStatuses},
+		{"QuestionTypes", testQuestionTypes},
+		{"SharedQuestions", testSharedQuestions},
+		{"DuplicateAttempts", testDuplicateAttempts},
+		{"LeaderboardOrdering", testLeaderboardOrdering},
+		{"LeaderboardTiebreak", testLeaderboardTiebreak},
+		{"ProxySubmissions", testProxySubmissions},
+		{"ReplaceQuizQuestion", testReplaceQuizQuestion},
+		{"QuizLifecycle", testQuizLifecycle},
+		{"ArchiveAttempts", testArchiveAttempts},
+		{"QuizRemoval", testQuizRemoval},
+		{"ListAttemptSummaries", testListAttemptSummaries},
+		{"UserAttempts", testUserAttempts},
+		{"SampleQuestions", testSampleQuestions},
+	} {
+		t.Run(check.name, func(t *testing.T) {
+			check.run(t, newStore(t))
+		})
+	}
+}
+
+func questions(prefix string) []quiz.Question {
+	return []quiz.Question{
+		{
+			PublicQuestion: quiz.PublicQuestion{
+				QuestionID: prefix + "1",
+				Question:   "2+2?",
+				Options:    []quiz.Option{{Letter: "A", Text: "4"}, {Letter: "B", Text: "3"}},
+			},
+			CorrectIndex: 0,
+		},
+		{
+			PublicQuestion: quiz.PublicQuestion{
+				QuestionID: prefix + "2",
+				Question:   "Sky color?",
+				Options:    []quiz.Option{{Letter: "A", Text: "Green"}, {Letter: "B", Text: "Blue"}, {Letter: "C", Text: "Red"}},
+			},
+			CorrectIndex: 1,
+		},
+	}
+}
+
+func createQuiz(t *testing.T, store Store, metadata quiz.QuizMetadata, questions []quiz.Question) {
+	t.Helper()
+	if err := store.CreateQuiz(context.Background(), metadata, questions); err != nil {
+		t.Fatalf("CreateQuiz(%s) failed: %v", metadata.QuizID, err)
+	}
+}
+
+func submit(t *testing.T, store Store, quizID, username string, responses ...quiz.SubmittedResponse) []quiz.ResponseResult {
+	t.Helper()
+	results, err := store.SubmitResponses(context.Background(), quizID, username, responses)
+	if err != nil {
+		t.Fatalf("SubmitResponses(%s, %s) failed: %v", quizID, username, err)
+	}
+	if len(results) != len(responses) {
+		t.Fatalf("SubmitResponses(%s, %s) = %+v, want one result per response", quizID, username, results)
+	}
+	return results
+}
+
+func testCreateAndRead(t *testing.T, store Store) {
+	ctx := context.Background()
+	createdAt := time.Unix(1700000000, 0).UTC()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1", CreatedAt: createdAt, Practice: true}, questions("q"))
+
+	if exists, err := store.QuizExists(ctx, "quiz-1"); err != nil || !exists {
+		t.Fatalf("QuizExists = (%t, %v), want true", exists, err)
+	}
+	metadata, err := store.GetQuizMetadata(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetQuizMetadata failed: %v", err)
+	}
+	// QuestionCount defaults to the number of questions stored.
+	if metadata.QuizID != "quiz-1" || metadata.QuestionCount != 2 || !metadata.CreatedAt.Equal(createdAt) || !metadata.Practice {
+		t.Fatalf("GetQuizMetadata = %+v, want quiz-1 with 2 questions, created %s, practice", metadata, createdAt)
+	}
+
+	stored, err := store.GetQuizQuestions(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	want := questions("q")
+	if len(stored) != len(want) {
+		t.Fatalf("GetQuizQuestions = %+v, want %d questions", stored, len(want))
+	}
+	for idx := range want {
+		got := stored[idx]
+		if got.QuestionID != want[idx].QuestionID || got.Question != want[idx].Question || got.CorrectIndex != want[idx].CorrectIndex || len(got.Options) != len(want[idx].Options) {
+			t.Fatalf("question %d = %+v, want %+v in creation order", idx, got, want[idx])
+		}
+		for option := range want[idx].Options {
+			if got.Options[option] != want[idx].Options[option] {
+				t.Fatalf("question %d options = %+v, want %+v", idx, got.Options, want[idx].Options)
+			}
+		}
+	}
+
+	// Questions without an ID get the one derived from their content.
+	unnamed := questions("")
+	for idx := range unnamed {
+		unnamed[idx].QuestionID = ""
+	}
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-2"}, unnamed)
+	stored, err = store.GetQuizQuestions(ctx, "quiz-2")
+	if err != nil || len(stored) != 2 || stored[0].QuestionID != quiz.MakeQuestionID(unnamed[0]) {
+		t.Fatalf("GetQuizQuestions(quiz-2) = (%+v, %v), want derived question IDs", stored, err)
+	}
+
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{}, questions("q")); err == nil {
+		t.Fatalf("CreateQuiz without a quiz ID succeeded, want an error")
+	}
+}
+
+func testCreateOverwrites(t *testing.T, store Store) {
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+	submit(t, store, "quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+
+	// Creating a quiz again under the same ID replaces its metadata and
+	// questions and forgets its attempts.
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1", Locked: true}, questions("r")[:1])
+	metadata, err := store.GetQuizMetadata(ctx, "quiz-1")
+	if err != nil || metadata.QuestionCount != 1 || !metadata.Locked {
+		t.Fatalf("GetQuizMetadata after overwrite = (%+v, %v), want 1 question and locked", metadata, err)
+	}
+	stored, err := store.GetQuizQuestions(ctx, "quiz-1")
+	if err != nil || len(stored) != 1 || stored[0].QuestionID != "r1" {
+		t.Fatalf("GetQuizQuestions after overwrite = (%+v, %v), want only r1", stored, err)
+	}
+	if entries, err := store.GetLeaderboard(ctx, "quiz-1"); err != nil || len(entries) != 0 {
+		t.Fatalf("GetLeaderboard after overwrite = (%+v, %v), want no entries", entries, err)
+	}
+	if scores, err := store.GetAttemptScores(ctx, "quiz-1", "alice"); err != nil || len(scores) != 0 {
+		t.Fatalf("GetAttemptScores after overwrite = (%+v, %v), want none", scores, err)
+	}
+	results := submit(t, store, "quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"}, quiz.SubmittedResponse{QuestionID: "r1", Answer: "A"})
+	if results[0].Status != quiz.StatusInvalidQuestion || results[1].Status != quiz.StatusCorrect {
+		t.Fatalf("answers after overwrite = %+v, want q1 gone and r1 answerable again", results)
+	}
+}
+
+func testListActiveQuizzes(t *testing.T, store Store) {
+	ctx := context.Background()
+	if active, err := store.ListActiveQuizzes(ctx, 10); err != nil || len(active) != 0 {
+		t.Fatalf("ListActiveQuizzes on an empty store = (%+v, %v), want none", active, err)
+	}
+	base := time.Unix(1700000000, 0).UTC()
+	for idx, quizID := range []string{"old", "newest", "middle"} {
+		offsets := []time.Duration{0, 2 * time.Hour, time.Hour}
+		createQuiz(t, store, quiz.QuizMetadata{QuizID: quizID, CreatedAt: base.Add(offsets[idx])}, questions(quizID))
+	}
+
+	active, err := store.ListActiveQuizzes(ctx, 2)
+	if err != nil || len(active) != 2 || active[0].QuizID != "newest" || active[1].QuizID != "middle" {
+		t.Fatalf("ListActiveQuizzes(2) = (%+v, %v), want newest then middle", active, err)
+	}
+	if active, err := store.ListActiveQuizzes(ctx, 10); err != nil || len(active) != 3 || active[2].QuizID != "old" {
+		t.Fatalf("ListActiveQuizzes(10) = (%+v, %v), want all three, oldest last", active, err)
+	}
+}
+
+func testNotFound(t *testing.T, store Store) {
+	ctx := context.Background()
+	if exists, err := store.QuizExists(ctx, "missing"); err != nil || exists {
+		t.Fatalf("QuizExists(missing) = (%t, %v), want false", exists, err)
+	}
+	if _, err := store.GetQuizMetadata(ctx, "missing"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("GetQuizMetadata(missing) error = %v, want ErrQuizNotFound", err)
+	}
+	if _, err := store.GetQuizQuestions(ctx, "missing"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("GetQuizQuestions(missing) error = %v, want ErrQuizNotFound", err)
+	}
+	if _, err := store.GetLeaderboard(ctx, "missing"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("GetLeaderboard(missing) error = %v, want ErrQuizNotFound", err)
+	}
+	if _, err := store.SubmitResponses(ctx, "missing", "alice", []quiz.SubmittedResponse{{QuestionID: "q1", Answer: "A"}}); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("SubmitResponses(missing) error = %v, want ErrQuizNotFound", err)
+	}
+	if scores, err := store.GetAttemptScores(ctx, "missing", "alice"); err != nil || len(scores) != 0 {
+		t.Fatalf("GetAttemptScores(missing) = (%+v, %v), want no scores and no error", scores, err)
+	}
+}
+
+func testAnswerStatuses(t *testing.T, store Store) {
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-2"}, questions("z"))
+
+	results := submit(t, store, "quiz-1", "alice",
+		quiz.SubmittedResponse{QuestionID: "q1", Answer: "a"},
+		quiz.SubmittedResponse{QuestionID: "q2", Answer: "C"},
+		quiz.SubmittedResponse{QuestionID: "z1", Answer: "A"},
+		quiz.SubmittedResponse{QuestionID: "missing", Answer: "A"},
+	)
+	want := []string{quiz.StatusCorrect, quiz.StatusIncorrect, quiz.StatusInvalidQuestion, quiz.StatusInvalidQuestion}
+	for idx, status := range want {
+		if results[idx].Status != status || results[idx].AttemptScore != nil {
+			t.Fatalf("result %d = %+v, want %s without an attempt score", idx, results[idx], status)
+		}
+	}
+
+	// Letters outside the question's options are rejected without using up
+	// the question.
+	results = submit(t, store, "quiz-2", "alice",
+		quiz.SubmittedResponse{QuestionID: "z1", Answer: "C"},
+		quiz.SubmittedResponse{QuestionID: "z2", Answer: "?"},
+	)
+	if results[0].Status != quiz.StatusInvalidLetter || results[1].Status != quiz.StatusInvalidLetter {
+		t.Fatalf("invalid letters = %+v, want invalid_letter twice", results)
+	}
+	results = submit(t, store, "quiz-2", "alice", quiz.SubmittedResponse{QuestionID: "z1", Answer: "A"})
+	if results[0].Status != quiz.StatusCorrect {
+		t.Fatalf("answer after an invalid letter = %+v, want correct", results)
+	}
+
+	scores, err := store.GetAttemptScores(ctx, "quiz-1", "alice")
+	if err != nil || len(scores) != 2 || scores["q1"] != 1 || scores["q2"] != 0 {
+		t.Fatalf("GetAttemptScores = (%+v, %v), want q1 scored 1 and q2 scored 0", scores, err)
+	}
+	if scores, err := store.GetAttemptScores(ctx, "quiz-1", "bob"); err != nil || len(scores) != 0 {
+		t.Fatalf("GetAttemptScores(bob) = (%+v, %v), want none", scores, err)
+	}
+
+	// A speed bonus is added to correct answers only.
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-3"}, questions("b"))
+	results = submit(t, store, "quiz-3", "alice",
+		quiz.SubmittedResponse{QuestionID: "b1", Answer: "A", Bonus: 0.5},
+		quiz.SubmittedResponse{QuestionID: "b2", Answer: "A", Bonus: 0.5},
+	)
+	if results[0].Bonus != 0.5 || results[1].Bonus != 0 {
+		t.Fatalf("bonuses = %+v, want 0.5 on the correct answer only", results)
+	}
+	if scores, _ := store.GetAttemptScores(ctx, "quiz-3", "alice"); scores["b1"] != 1.5 || scores["b2"] != 0 {
+		t.Fatalf("scores with bonus = %+v, want b1 1.5 and b2 0", scores)
+	}
+}
+
+// A question is stored once and shared by every quiz that uses it, so a later
+// quiz cannot change its answer key or the feedback and translations players
+// of the earlier quiz see.
+func testSharedQuestions(t *testing.T, store Store) {
+	ctx := context.Background()
+	original := questions("q")[:1]
+	original[0].Feedback = []string{"Yes.", "No."}
+	original[0].Source = "opentdb"
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-a"}, original)
+
+	rekeyed := questions("q")[:1]
+	rekeyed[0].CorrectIndex = 1
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-b"}, rekeyed); !errors.Is(err, quiz.ErrQuestionConflict) {
+		t.Fatalf("CreateQuiz with another answer key = %v, want ErrQuestionConflict", err)
+	}
+	if exists, err := store.QuizExists(ctx, "quiz-b"); err != nil || exists {
+		t.Fatalf("QuizExists(quiz-b) = (%t, %v), want false", exists, err)
+	}
+
+	relabeled := questions("q")[:1]
+	relabeled[0].Feedback = []string{"Wrong.", "Right."}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-b"}, relabeled); !errors.Is(err, quiz.ErrQuestionConflict) {
+		t.Fatalf("CreateQuiz with other feedback = %v, want ErrQuestionConflict", err)
+	}
+	translated := questions("q")[:1]
+	translated[0].Translations = map[string]quiz.Translation{"es": {Question: "¿2+2?", Options: []string{"4", "3"}}}
+	if err := store.CreateQuiz(ctx, quiz.QuizMetadata{QuizID: "quiz-b"}, translated); !errors.Is(err, quiz.ErrQuestionConflict) {
+		t.Fatalf("CreateQuiz with new translations = %v, want ErrQuestionConflict", err)
+	}
+
+	// A plain copy reuses the stored question as it is, source included.
+	copied := questions("q")[:1]
+	copied[0].Source = "custom"
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-c"}, copied)
+	results := submit(t, store, "quiz-a", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "B"})
+	if results[0].Status != quiz.StatusIncorrect {
+		t.Fatalf("answer B to quiz-a = %+v, want incorrect", results)
+	}
+	stored, err := store.GetQuizQuestions(ctx, "quiz-c")
+	if err != nil || len(stored) != 1 || stored[0].CorrectIndex != 0 || stored[0].FeedbackFor(0) != "Yes." || len(stored[0].Translations) != 0 || stored[0].Source != "opentdb" {
+		t.Fatalf("GetQuizQuestions(quiz-c) = (%+v, %v), want the original answer key, feedback, and source", stored, err)
+	}
+	if lookup, ok := store.(quiz.QuestionLookup); ok {
+		found, err := lookup.LookupQuestions(ctx, []string{"q1"})
+		if err != nil || len(found) != 1 || found[0].Source != "opentdb" {
+			t.Fatalf("LookupQuestions(q1) = (%+v, %v), want source opentdb", found, err)
+		}
+	}
+}
+
+// Multi-select and true/false questions keep their type and answer key
+// through the store, and multi-select answers score partial credit.
+func testQuestionTypes(t *testing.T, store Store) {
+	ctx := context.Background()
+	multi, err := quiz.NewMultiSelectQuestion("Which are primes?", []string{"2", "4", "5", "9"}, []int{0, 2})
+	if err != nil {
+		t.Fatalf("NewMultiSelectQuestion failed: %v", err)
+	}
+	trueFalse, err := quiz.NewTrueFalseQuestion("Zero is even.", true)
+	if err != nil {
+		t.Fatalf("NewTrueFalseQuestion failed: %v", err)
+	}
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, []quiz.Question{multi, trueFalse})
+
+	stored, err := store.GetQuizQuestions(ctx, "quiz-1")
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("GetQuizQuestions = (%+v, %v), want both questions", stored, err)
+	}
+	if stored[0].Type != quiz.TypeMultiSelect || !reflect.DeepEqual(stored[0].CorrectIndexes, []int{0, 2}) || stored[1].Type != quiz.TypeTrueFalse {
+		t.Fatalf("stored questions = %+v, want the types and answer keys kept", stored)
+	}
+
+	results := submit(t, store, "quiz-1", "alice",
+		quiz.SubmittedResponse{QuestionID: multi.QuestionID, Answer: "A,B,C"},
+		quiz.SubmittedResponse{QuestionID: trueFalse.QuestionID, Answer: "true"},
+	)
+	if results[0].Status != quiz.StatusPartiallyCorrect || results[0].Credit != 0.5 || results[1].Status != quiz.StatusCorrect {
+		t.Fatalf("results = %+v, want half credit and correct", results)
+	}
+	results = submit(t, store, "quiz-1", "bob",
+		quiz.SubmittedResponse{QuestionID: multi.QuestionID, Answer: "c, a"},
+		quiz.SubmittedResponse{QuestionID: trueFalse.QuestionID, Answer: "B,A"},
+	)
+	if results[0].Status != quiz.StatusCorrect || results[1].Status != quiz.StatusInvalidLetter {
+		t.Fatalf("results = %+v, want correct and invalid_letter", results)
+	}
+
+	scores, err := store.GetAttemptScores(ctx, "quiz-1", "alice")
+	if err != nil || scores[multi.QuestionID] != 0.5 || scores[trueFalse.QuestionID] != 1 {
+		t.Fatalf("GetAttemptScores = (%+v, %v), want 0.5 and 1", scores, err)
+	}
+	if results := submit(t, store, "quiz-1", "alice", quiz.SubmittedResponse{QuestionID: multi.QuestionID, Answer: "A,C"}); results[0].Status != quiz.StatusAlreadyAnswered || *results[0].AttemptScore != 0.5 {
+		t.Fatalf("repeat = %+v, want already_answered with the stored half point", results)
+	}
+}
+
+func testDuplicateAttempts(t *testing.T, store Store) {
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-2"}, questions("q"))
+	submit(t, store, "quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A", Bonus: 0.25})
+
+	// The first answer stands; a repeat reports the stored score and changes
+	// nothing, even with a different answer.
+	results := submit(t, store, "quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "B"})
+	if results[0].Status != quiz.StatusAlreadyAnswered || results[0].AttemptScore == nil || *results[0].AttemptScore != 1.25 || results[0].Bonus != 0 {
+		t.Fatalf("repeat answer = %+v, want already_answered with the stored score 1.25", results[0])
+	}
+	if scores, _ := store.GetAttemptScores(ctx, "quiz-1", "alice"); len(scores) != 1 || scores["q1"] != 1.25 {
+		t.Fatalf("scores after a repeat = %+v, want q1 unchanged at 1.25", scores)
+	}
+
+	// Uniqueness is per quiz and per user.
+	if results := submit(t, store, "quiz-2", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "B"}); results[0].Status != quiz.StatusIncorrect {
+		t.Fatalf("same question in another quiz = %+v, want incorrect", results)
+	}
+	if results := submit(t, store, "quiz-1", "bob", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"}); results[0].Status != quiz.StatusCorrect {
+		t.Fatalf("same question for another user = %+v, want correct", results)
+	}
+
+	entries, err := store.GetLeaderboard(ctx, "quiz-1")
+	if err != nil || len(entries) != 2 || entries[0].Username != "alice" || entries[0].AnsweredCount != 1 || entries[0].TotalScore != 1.25 {
+		t.Fatalf("GetLeaderboard = (%+v, %v), want alice once with 1.25", entries, err)
+	}
+}
+
+func testLeaderboardOrdering(t *testing.T, store Store) {
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+	if entries, err := store.GetLeaderboard(ctx, "quiz-1"); err != nil || len(entries) != 0 {
+		t.Fatalf("GetLeaderboard before any answer = (%+v, %v), want an empty board", entries, err)
+	}
+
+	// Higher totals rank first; equal totals rank by who finished first.
+	submit(t, store, "quiz-1", "dave", quiz.SubmittedResponse{QuestionID: "q1", Answer: "B"})
+	submit(t, store, "quiz-1", "bob", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+	time.Sleep(2 * time.Millisecond)
+	submit(t, store, "quiz-1", "carol", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+	submit(t, store, "quiz-1", "alice",
+		quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"},
+		quiz.SubmittedResponse{QuestionID: "q2", Answer: "B"},
+	)
+
+	entries, err := store.GetLeaderboard(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+	want := []struct {
+		username string
+		score    float64
+		answered int
+	}{{"alice", 2, 2}, {"bob", 1, 1}, {"carol", 1, 1}, {"dave", 0, 1}}
+	if len(entries) != len(want) {
+		t.Fatalf("GetLeaderboard = %+v, want %d entries", entries, len(want))
+	}
+	for idx, entry := range want {
+		got := entries[idx]
+		if got.Username != entry.username || got.TotalScore != entry.score || got.AnsweredCount != entry.answered || got.LastSubmissionAt.IsZero() {
+			t.Fatalf("entry %d = %+v, want %s with %v from %d answers", idx, got, entry.username, entry.score, entry.answered)
+		}
+	}
+	if !entries[1].LastSubmissionAt.Before(entries[2].LastSubmissionAt) {
+		t.Fatalf("tied entries = %+v and %+v, want the earlier finisher first", entries[1], entries[2])
+	}
+}
+
+func testLeaderboardTiebreak(t *testing.T, store Store) {
+	settingsStore, ok := store.(quiz.LeaderboardSettingsStore)
+	if !ok {
+		t.Skip("store does not keep leaderboard settings")
+	}
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+
+	// Both score 1: alice finishes first but needs two answers to bob's one.
+	submit(t, store, "quiz-1", "alice",
+		quiz.SubmittedResponse{QuestionID: "q1", Answer: "B"},
+		quiz.SubmittedResponse{QuestionID: "q2", Answer: "B"},
+	)
+	time.Sleep(2 * time.Millisecond)
+	submit(t, store, "quiz-1", "bob", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+
+	order := func() []string {
+		t.Helper()
+		entries, err := store.GetLeaderboard(ctx, "quiz-1")
+		if err != nil {
+			t.Fatalf("GetLeaderboard failed: %v", err)
+		}
+		usernames := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			usernames = append(usernames, entry.Username)
+		}
+		return usernames
+	}
+	if got := order(); len(got) != 2 || got[0] != "alice" {
+		t.Fatalf("default order = %v, want the earlier finisher alice first", got)
+	}
+
+	settings := quiz.LeaderboardSettings{Tiebreak: quiz.TiebreakFewestAnswers, UpdatedAt: time.Unix(1700000000, 0).UTC()}
+	if err := settingsStore.SaveLeaderboardSettings(ctx, "quiz-1", settings); err != nil {
+		t.Fatalf("SaveLeaderboardSettings failed: %v", err)
+	}
+	if got, err := settingsStore.GetLeaderboardSettings(ctx, "quiz-1"); err != nil || got.Tiebreak != quiz.TiebreakFewestAnswers {
+		t.Fatalf("GetLeaderboardSettings = (%+v, %v), want the fewest_answers tiebreak", got, err)
+	}
+	if got := order(); len(got) != 2 || got[0] != "bob" {
+		t.Fatalf("fewest_answers order = %v, want bob with one answer first", got)
+	}
+}
+
+func testProxySubmissions(t *testing.T, store Store) {
+	audit, ok := store.(quiz.AuditLog)
+	if !ok {
+		t.Skip("store does not keep an audit log")
+	}
+	history, ok := store.(quiz.AttemptHistory)
+	if !ok {
+		t.Skip("store does not list attempts")
+	}
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+
+	submit(t, store, "quiz-1", "alice",
+		quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"},
+		quiz.SubmittedResponse{QuestionID: "q2", Answer: "B", SubmittedBy: "Dana"},
+	)
+	attempts, err := history.ListAttempts(ctx, "quiz-1", "alice")
+	if err != nil {
+		t.Fatalf("ListAttempts failed: %v", err)
+	}
+	submittedBy := make(map[string]string, len(attempts))
+	for _, attempt := range attempts {
+		submittedBy[attempt.QuestionID] = attempt.SubmittedBy
+	}
+	if len(submittedBy) != 2 || submittedBy["q1"] != "" || submittedBy["q2"] != "Dana" {
+		t.Fatalf("SubmittedBy by question = %v, want q2 entered by Dana and q1 by the player", submittedBy)
+	}
+
+	at := time.Unix(1700000000, 0).UTC()
+	for _, entry := range []quiz.AuditEntry{
+		{QuizID: "quiz-1", At: at, Actor: "Dana", Action: quiz.AuditSubmitOnBehalf, Username: "alice", Detail: "q2=B"},
+		{QuizID: "quiz-1", At: at, Actor: "Eli", Action: quiz.AuditSubmitOnBehalf, Username: "bob", Detail: "q1=C"},
+		{QuizID: "quiz-2", At: at, Actor: "Eli", Action: quiz.AuditSubmitOnBehalf, Username: "bob", Detail: "q1=D"},
+	} {
+		if err := audit.RecordAuditEntry(ctx, entry); err != nil {
+			t.Fatalf("RecordAuditEntry failed: %v", err)
+		}
+	}
+	entries, err := audit.ListAuditEntries(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Actor != "Dana" || entries[1].Actor != "Eli" {
+		t.Fatalf("audit entries = %+v, want Dana's then Eli's, in recording order", entries)
+	}
+	if first := entries[0]; first.QuizID != "quiz-1" || !first.At.Equal(at) || first.Username != "alice" || first.Detail != "q2=B" {
+		t.Fatalf("first entry = %+v, want it read back as recorded", first)
+	}
+	if empty, err := audit.ListAuditEntries(ctx, "quiz-3"); err != nil || len(empty) != 0 {
+		t.Fatalf("ListAuditEntries for a quiz without entries = (%v, %v), want none", empty, err)
+	}
+}
+
+func testReplaceQuizQuestion(t *testing.T, store Store) {
+	reshuffler, ok := store.(quiz.QuestionReshuffler)
+	if !ok {
+		t.Skip("store does not replace quiz questions")
+	}
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1", QuestionCount: 2}, questions("q"))
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-2", QuestionCount: 2}, questions("q"))
+	// An answer in another quiz does not pin the question in this one.
+	submit(t, store, "quiz-2", "bob", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+
+	replacement := questions("q")[0]
+	replacement.QuestionID = "q1-moved"
+	replacement.Options = []quiz.Option{{Letter: "A", Text: "3"}, {Letter: "B", Text: "4"}}
+	replacement.CorrectIndex = 1
+	if err := reshuffler.ReplaceQuizQuestion(ctx, "quiz-1", "q1", replacement); err != nil {
+		t.Fatalf("ReplaceQuizQuestion failed: %v", err)
+	}
+	got, err := store.GetQuizQuestions(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetQuizQuestions failed: %v", err)
+	}
+	if len(got) != 2 || got[0].QuestionID != "q1-moved" || got[0].CorrectIndex != 1 || got[0].Options[1].Text != "4" || got[1].QuestionID != "q2" {
+		t.Fatalf("questions after replace = %+v, want q1-moved in q1's place with B correct", got)
+	}
+	if results := submit(t, store, "quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q1-moved", Answer: "B"}); results[0].Status != quiz.StatusCorrect {
+		t.Fatalf("answer to the replacement = %+v, want correct", results[0])
+	}
+	if other, err := store.GetQuizQuestions(ctx, "quiz-2"); err != nil || other[0].QuestionID != "q1" {
+		t.Fatalf("other quiz questions = (%+v, %v), want q1 untouched", other, err)
+	}
+
+	// Answered and unknown questions stay put.
+	answered := replacement
+	answered.QuestionID = "q1-again"
+	if err := reshuffler.ReplaceQuizQuestion(ctx, "quiz-1", "q1-moved", answered); !errors.Is(err, quiz.ErrQuestionAnswered) {
+		t.Fatalf("ReplaceQuizQuestion(answered) error = %v, want ErrQuestionAnswered", err)
+	}
+	if err := reshuffler.ReplaceQuizQuestion(ctx, "quiz-1", "missing", answered); !errors.Is(err, quiz.ErrQuestionNotFound) {
+		t.Fatalf("ReplaceQuizQuestion(missing) error = %v, want ErrQuestionNotFound", err)
+	}
+	if got, err := store.GetQuizQuestions(ctx, "quiz-1"); err != nil || len(got) != 2 || got[0].QuestionID != "q1-moved" {
+		t.Fatalf("questions after refused replaces = (%+v, %v), want them unchanged", got, err)
+	}
+}
+
+func testQuizLifecycle(t *testing.T, store Store) {
+	lifecycles, ok := store.(quiz.QuizLifecycleStore)
+	if !ok {
+		t.Skip("store does not change quiz state")
+	}
+	ctx := context.Background()
+	closesAt := time.Unix(1800000000, 0).UTC()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1", Draft: true, ClosesAt: closesAt}, questions("q"))
+	metadata, err := store.GetQuizMetadata(ctx, "quiz-1")
+	if err != nil || !metadata.Draft || metadata.Locked || !metadata.ClosesAt.Equal(closesAt) {
+		t.Fatalf("GetQuizMetadata = (%+v, %v), want a draft closing at %s", metadata, err, closesAt)
+	}
+
+	if err := lifecycles.SetQuizLifecycle(ctx, "quiz-1", quiz.QuizLifecycle{Locked: true}); err != nil {
+		t.Fatalf("SetQuizLifecycle failed: %v", err)
+	}
+	metadata, err = store.GetQuizMetadata(ctx, "quiz-1")
+	if err != nil || metadata.Draft || !metadata.Locked || !metadata.ClosesAt.IsZero() {
+		t.Fatalf("GetQuizMetadata after change = (%+v, %v), want locked with no deadline", metadata, err)
+	}
+	active, err := store.ListActiveQuizzes(ctx, 10)
+	if err != nil || len(active) != 1 || !active[0].Locked {
+		t.Fatalf("ListActiveQuizzes = (%+v, %v), want quiz-1 locked", active, err)
+	}
+
+	if err := lifecycles.SetQuizLifecycle(ctx, "missing", quiz.QuizLifecycle{}); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("SetQuizLifecycle(missing) error = %v, want ErrQuizNotFound", err)
+	}
+}
+
+func testArchiveAttempts(t *testing.T, store Store) {
+	archiver, ok := store.(quiz.AttemptArchiver)
+	if !ok {
+		t.Skip("store does not archive attempts")
+	}
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-2"}, questions("q"))
+	submit(t, store, "quiz-1", "alice",
+		quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"},
+		quiz.SubmittedResponse{QuestionID: "q2", Answer: "A"},
+	)
+	submit(t, store, "quiz-1", "bob", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+	time.Sleep(2 * time.Millisecond)
+	submit(t, store, "quiz-2", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "B"})
+
+	if idle, err := archiver.IdleQuizzes(ctx, time.Now().Add(-time.Hour)); err != nil || len(idle) != 0 {
+		t.Fatalf("IdleQuizzes before any attempt = (%v, %v), want none", idle, err)
+	}
+	cutoff := time.Now().Add(time.Hour)
+	if idle, err := archiver.IdleQuizzes(ctx, cutoff); err != nil || len(idle) != 2 || idle[0] != "quiz-1" || idle[1] != "quiz-2" {
+		t.Fatalf("IdleQuizzes after every attempt = (%v, %v), want quiz-1 and quiz-2", idle, err)
+	}
+
+	before, err := store.GetLeaderboard(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+	if deleted, err := archiver.ArchiveAttempts(ctx, "quiz-1", cutoff); err != nil || deleted != 3 {
+		t.Fatalf("ArchiveAttempts = (%d, %v), want 3 attempts deleted", deleted, err)
+	}
+	after, err := store.GetLeaderboard(ctx, "quiz-1")
+	if err != nil {
+		t.Fatalf("GetLeaderboard after archive failed: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("leaderboard after archive = %+v, want %+v", after, before)
+	}
+	for idx := range before {
+		if after[idx].Username != before[idx].Username || after[idx].TotalScore != before[idx].TotalScore ||
+			after[idx].AnsweredCount != before[idx].AnsweredCount || !after[idx].LastSubmissionAt.Equal(before[idx].LastSubmissionAt) {
+			t.Fatalf("leaderboard after archive = %+v, want %+v", after, before)
+		}
+	}
+	if scores, err := store.GetAttemptScores(ctx, "quiz-1", "alice"); err != nil || len(scores) != 0 {
+		t.Fatalf("GetAttemptScores after archive = (%v, %v), want the attempts gone", scores, err)
+	}
+	if metadata, err := store.GetQuizMetadata(ctx, "quiz-1"); err != nil || !metadata.Locked {
+		t.Fatalf("metadata after archive = (%+v, %v), want the quiz locked", metadata, err)
+	}
+	if idle, err := archiver.IdleQuizzes(ctx, cutoff); err != nil || len(idle) != 1 || idle[0] != "quiz-2" {
+		t.Fatalf("IdleQuizzes after archive = (%v, %v), want only quiz-2", idle, err)
+	}
+
+	summaries, err := archiver.AttemptSummaries(ctx, "alice")
+	if err != nil {
+		t.Fatalf("AttemptSummaries failed: %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].QuizID != "quiz-2" || summaries[1].QuizID != "quiz-1" {
+		t.Fatalf("alice's summaries = %+v, want quiz-2, played last, then quiz-1", summaries)
+	}
+	if kept := summaries[0]; kept.AnsweredCount != 1 || kept.CorrectCount != 0 || kept.ArchivedCount != 0 || kept.Username != "alice" {
+		t.Fatalf("quiz-2 summary = %+v, want one kept wrong answer", kept)
+	}
+	if archived := summaries[1]; archived.TotalScore != 1 || archived.AnsweredCount != 2 || archived.CorrectCount != 1 || archived.ArchivedCount != 2 ||
+		archived.FirstSubmittedAt.After(archived.LastSubmittedAt) || archived.LastSubmittedAt.IsZero() {
+		t.Fatalf("quiz-1 summary = %+v, want two archived answers, one right", archived)
+	}
+
+	// A later archive adds to the summaries; kept and archived answers combine.
+	submit(t, store, "quiz-1", "bob", quiz.SubmittedResponse{QuestionID: "q2", Answer: "B"})
+	if summaries, err := archiver.AttemptSummaries(ctx, "bob"); err != nil || len(summaries) != 1 || summaries[0].AnsweredCount != 2 || summaries[0].ArchivedCount != 1 {
+		t.Fatalf("bob's summaries with one kept answer = (%+v, %v), want two answers, one archived", summaries, err)
+	}
+	if deleted, err := archiver.ArchiveAttempts(ctx, "quiz-1", time.Now().Add(time.Hour)); err != nil || deleted != 1 {
+		t.Fatalf("second ArchiveAttempts = (%d, %v), want 1 attempt deleted", deleted, err)
+	}
+	if summaries, err := archiver.AttemptSummaries(ctx, "bob"); err != nil || len(summaries) != 1 || summaries[0].TotalScore != 2 || summaries[0].CorrectCount != 2 || summaries[0].ArchivedCount != 2 {
+		t.Fatalf("bob's summaries after the second archive = (%+v, %v), want two right answers archived", summaries, err)
+	}
+
+	// Re-creating the quiz starts it over, summaries included.
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+	if entries, err := store.GetLeaderboard(ctx, "quiz-1"); err != nil || len(entries) != 0 {
+		t.Fatalf("leaderboard after re-creating = (%+v, %v), want none", entries, err)
+	}
+}
+
+func testQuizRemoval(t *testing.T, store Store) {
+	remover, ok := store.(quiz.QuizRemover)
+	if !ok {
+		t.Skip("store does not remove quizzes")
+	}
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "kept"}, questions("q"))
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "archived"}, questions("q"))
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "deleted"}, append(questions("q")[:1], questions("only")...))
+	for _, quizID := range []string{"archived", "deleted"} {
+		submit(t, store, quizID, "alice",
+			quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"},
+			quiz.SubmittedResponse{QuestionID: "only1", Answer: "A"},
+		)
+	}
+	if _, err := remover.DeleteQuiz(ctx, "missing"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("DeleteQuiz(missing) = %v, want ErrQuizNotFound", err)
+	}
+	if _, err := remover.ArchiveQuiz(ctx, "missing", time.Now()); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("ArchiveQuiz(missing) = %v, want ErrQuizNotFound", err)
+	}
+
+	// Archiving keeps the totals and the first archive time.
+	archivedAt := time.Now().UTC().Truncate(time.Millisecond)
+	if deleted, err := remover.ArchiveQuiz(ctx, "archived", archivedAt); err != nil || deleted != 1 {
+		t.Fatalf("ArchiveQuiz = (%d, %v), want the one valid attempt deleted", deleted, err)
+	}
+	if deleted, err := remover.ArchiveQuiz(ctx, "archived", archivedAt.Add(time.Hour)); err != nil || deleted != 0 {
+		t.Fatalf("second ArchiveQuiz = (%d, %v), want nothing left to delete", deleted, err)
+	}
+	metadata, err := store.GetQuizMetadata(ctx, "archived")
+	if err != nil || !metadata.Locked || !metadata.ArchivedAt.Equal(archivedAt) {
+		t.Fatalf("metadata after archive = (%+v, %v), want locked and archived at %s", metadata, err, archivedAt)
+	}
+	if entries, err := store.GetLeaderboard(ctx, "archived"); err != nil || len(entries) != 1 || entries[0].TotalScore != 1 {
+		t.Fatalf("leaderboard after archive = (%+v, %v), want alice's point kept", entries, err)
+	}
+	if active, err := store.ListActiveQuizzes(ctx, 10); err != nil || len(active) != 2 || containsQuiz(active, "archived") {
+		t.Fatalf("ListActiveQuizzes after archive = (%+v, %v), want the archived quiz left out", active, err)
+	}
+
+	// Deleting removes the quiz and the questions only it used.
+	if deleted, err := remover.DeleteQuiz(ctx, "deleted"); err != nil || deleted != 2 {
+		t.Fatalf("DeleteQuiz = (%d, %v), want both attempts deleted", deleted, err)
+	}
+	if _, err := store.GetQuizMetadata(ctx, "deleted"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("GetQuizMetadata after delete = %v, want ErrQuizNotFound", err)
+	}
+	if exists, err := store.QuizExists(ctx, "deleted"); err != nil || exists {
+		t.Fatalf("QuizExists after delete = (%t, %v), want false", exists, err)
+	}
+	if _, err := store.GetLeaderboard(ctx, "deleted"); !errors.Is(err, quiz.ErrQuizNotFound) {
+		t.Fatalf("GetLeaderboard after delete = %v, want ErrQuizNotFound", err)
+	}
+	if lookup, ok := store.(quiz.QuestionLookup); ok {
+		found, err := lookup.LookupQuestions(ctx, []string{"q1", "only1", "only2"})
+		if err != nil || len(found) != 1 || found[0].QuestionID != "q1" {
+			t.Fatalf("LookupQuestions after delete = (%+v, %v), want only the shared q1", found, err)
+		}
+	}
+	if questions, err := store.GetQuizQuestions(ctx, "kept"); err != nil || len(questions) != 2 {
+		t.Fatalf("other quiz after delete = (%+v, %v), want its questions intact", questions, err)
+	}
+
+	// A deleted quiz ID can be used again.
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "deleted"}, questions("q"))
+	if metadata, err := store.GetQuizMetadata(ctx, "deleted"); err != nil || !metadata.ArchivedAt.IsZero() {
+		t.Fatalf("re-created quiz = (%+v, %v), want it back and not archived", metadata, err)
+	}
+}
+
+func containsQuiz(quizzes []quiz.QuizMetadata, quizID string) bool {
+	for _, metadata := range quizzes {
+		if metadata.QuizID == quizID {
+			return true
+		}
+	}
+	return false
+}
+
+func testListAttemptSummaries(t *testing.T, store Store) {
+	lister, ok := store.(quiz.AttemptSummaryLister)
+	if !ok {
+		t.Skip("store does not list attempt summaries")
+	}
+	archiver, ok := store.(quiz.AttemptArchiver)
+	if !ok {
+		t.Skip("store does not archive attempts")
+	}
+	ctx := context.Background()
+	january := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-jan", CreatedAt: january}, questions("q"))
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-feb", CreatedAt: february}, questions("q"))
+	submit(t, store, "quiz-jan", "alice",
+		quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"},
+		quiz.SubmittedResponse{QuestionID: "q2", Answer: "B"},
+	)
+	submit(t, store, "quiz-jan", "bob", quiz.SubmittedResponse{QuestionID: "q1", Answer: "B"})
+	submit(t, store, "quiz-feb", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+	if _, err := archiver.ArchiveAttempts(ctx, "quiz-jan", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ArchiveAttempts failed: %v", err)
+	}
+	submit(t, store, "quiz-jan", "bob", quiz.SubmittedResponse{QuestionID: "q2", Answer: "B"})
+
+	summaries, err := lister.ListAttemptSummaries(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ListAttemptSummaries failed: %v", err)
+	}
+	byKey := make(map[string]quiz.AttemptSummary)
+	for _, summary := range summaries {
+		byKey[summary.QuizID+"/"+summary.Username] = summary
+	}
+	if len(byKey) != 3 || len(summaries) != 3 {
+		t.Fatalf("every summary = %+v, want alice in both quizzes and bob in January's", summaries)
+	}
+	if alice := byKey["quiz-jan/alice"]; alice.TotalScore != 2 || alice.AnsweredCount != 2 || alice.ArchivedCount != 2 {
+		t.Fatalf("alice in January = %+v, want two archived right answers", alice)
+	}
+	if bob := byKey["quiz-jan/bob"]; bob.TotalScore != 1 || bob.AnsweredCount != 2 || bob.CorrectCount != 1 || bob.ArchivedCount != 1 {
+		t.Fatalf("bob in January = %+v, want one archived wrong answer and one kept right answer", bob)
+	}
+
+	// The window is on quiz creation, closed at since and open at until.
+	summaries, err = lister.ListAttemptSummaries(ctx, february, time.Time{})
+	if err != nil || len(summaries) != 1 || summaries[0].QuizID != "quiz-feb" || summaries[0].Username != "alice" {
+		t.Fatalf("summaries since February = (%+v, %v), want alice in quiz-feb", summaries, err)
+	}
+	summaries, err = lister.ListAttemptSummaries(ctx, january, february)
+	if err != nil || len(summaries) != 2 || summaries[0].QuizID != "quiz-jan" || summaries[1].QuizID != "quiz-jan" {
+		t.Fatalf("summaries in January = (%+v, %v), want both players of quiz-jan", summaries, err)
+	}
+}
+
+func testUserAttempts(t *testing.T, store Store) {
+	lister, ok := store.(quiz.UserAttemptLister)
+	if !ok {
+		t.Skip("store does not list a user's attempts")
+	}
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1"}, questions("q"))
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-2"}, questions("p"))
+	submit(t, store, "quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+	submit(t, store, "quiz-1", "bob", quiz.SubmittedResponse{QuestionID: "q1", Answer: "A"})
+	time.Sleep(2 * time.Millisecond)
+	submit(t, store, "quiz-2", "alice", quiz.SubmittedResponse{QuestionID: "p1", Answer: "A"})
+
+	attempts, err := lister.GetUserAttempts(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetUserAttempts failed: %v", err)
+	}
+	if len(attempts) != 2 || attempts[0].QuizID != "quiz-2" || attempts[1].QuizID != "quiz-1" {
+		t.Fatalf("alice's attempts = %+v, want quiz-2 then quiz-1", attempts)
+	}
+	if !attempts[0].LastSubmittedAt.After(attempts[1].LastSubmittedAt) {
+		t.Fatalf("alice's timestamps = %+v, want quiz-2 played after quiz-1", attempts)
+	}
+
+	// Answering again in quiz-1 makes it the most recent.
+	time.Sleep(2 * time.Millisecond)
+	submit(t, store, "quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q2", Answer: "A"})
+	attempts, err = lister.GetUserAttempts(ctx, "alice")
+	if err != nil || len(attempts) != 2 || attempts[0].QuizID != "quiz-1" {
+		t.Fatalf("attempts after answering quiz-1 again = (%+v, %v), want quiz-1 first", attempts, err)
+	}
+	latest := attempts[0]
+	if latest.Username != "alice" || latest.TotalScore != 1 || latest.AnsweredCount != 2 || latest.CorrectCount != 1 {
+		t.Fatalf("alice in quiz-1 = %+v, want one right and one wrong answer", latest)
+	}
+	if !latest.LastSubmittedAt.After(latest.FirstSubmittedAt) {
+		t.Fatalf("alice in quiz-1 = %+v, want the first answer before the last", latest)
+	}
+
+	if voider, ok := store.(quiz.QuestionVoider); ok {
+		if err := voider.VoidQuestion(ctx, "quiz-2", "p1", time.Now()); err != nil {
+			t.Fatalf("VoidQuestion failed: %v", err)
+		}
+		if attempts, err := lister.GetUserAttempts(ctx, "alice"); err != nil || len(attempts) != 1 || attempts[0].QuizID != "quiz-1" {
+			t.Fatalf("attempts after voiding quiz-2's only answer = (%+v, %v), want quiz-1 alone", attempts, err)
+		}
+	}
+
+	if attempts, err := lister.GetUserAttempts(ctx, "carol"); err != nil || attempts == nil || len(attempts) != 0 {
+		t.Fatalf("carol's attempts = (%#v, %v), want an empty list", attempts, err)
+	}
+}
+
+func testSampleQuestions(t *testing.T, store Store) {
+	sampler, ok := store.(quiz.QuestionSampler)
+	if !ok {
+		t.Skip("store does not sample questions")
+	}
+	ctx := context.Background()
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-1", QuestionCount: 2}, questions("q"))
+	// A quiz built from a fallback provider records which provider it replaced
+	// and the filter it was fetched with.
+	origin := quiz.QuizOrigin{Provider: "pool", FallbackFrom: "opentdb", Seed: 3, Category: 17, QuestionType: "boolean"}
+	createQuiz(t, store, quiz.QuizMetadata{QuizID: "quiz-2", QuestionCount: 1, Origin: origin}, questions("r")[1:])
+	if got, err := store.GetQuizMetadata(ctx, "quiz-2"); err != nil || !reflect.DeepEqual(got.Origin, origin) {
+		t.Fatalf("GetQuizMetadata(quiz-2) = (%+v, %v), want the fallback origin %+v", got.Origin, err, origin)
+	}
+
+	sample, err := sampler.SampleQuestions(ctx, 2)
+	if err != nil {
+		t.Fatalf("SampleQuestions(2) failed: %v", err)
+	}
+	if len(sample) != 2 || sample[0].QuestionID == sample[1].QuestionID {
+		t.Fatalf("SampleQuestions(2) = %+v, want two different questions", sample)
+	}
+	all, err := sampler.SampleQuestions(ctx, 10)
+	if err != nil {
+		t.Fatalf("SampleQuestions(10) failed: %v", err)
+	}
+	byID := make(map[string]quiz.Question, len(all))
+	for _, question := range all {
+		byID[question.QuestionID] = question
+	}
+	if len(all) != 3 || len(byID) != 3 {
+		t.Fatalf("SampleQuestions(10) = %+v, want each of the 3 stored questions once", all)
+	}
+	if sky := byID["r2"]; sky.Question != "Sky color?" || sky.CorrectIndex != 1 || len(sky.Options) != 3 {
+		t.Fatalf("sampled r2 = %+v, want the stored question with its answer", sky)
+	}
+}
@@ -0,0 +1,192 @@
+// Package smoketest checks a running quiz service end to end: it creates a
+// quiz, fetches it back, answers it as two new players, and checks the
+// results and the leaderboard. It is meant to run against staging after a
+// deploy, so it only creates data under names unique to the run.
+package smoketest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"quiz-app/internal/quiz"
+	"quiz-app/internal/userclient"
+)
+
+// Options tunes a run. The zero value is ready to use.
+type Options struct {
+	// RunID makes the run's usernames and question prompts unique. Empty uses
+	// the current time.
+	RunID string
+}
+
+// smokeQuestions are the run's quiz. Their prompts get the run ID appended so
+// runs never share questions.
+var smokeQuestions = []struct {
+	prompt       string
+	options      []string
+	correctIndex int
+}{
+	{"Smoke test: 2 + 2?", []string{"3", "4", "5"}, 1},
+	{"Smoke test: first letter of the alphabet?", []string{"A", "B", "Z"}, 0},
+	{"Smoke test: colour of a clear daytime sky?", []string{"green", "red", "blue"}, 2},
+}
+
+// Run walks the happy path against client's server, printing one line per
+// step to out. It stops at the first step that does not behave as expected
+// and returns an error naming it.
+func Run(ctx context.Context, client *userclient.HTTPClient, out io.Writer, options Options) error {
+	runID := options.RunID
+	if runID == "" {
+		runID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	run := &runner{ctx: ctx, client: client, out: out, runID: runID}
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"create quiz", run.createQuiz},
+		{"fetch questions", run.fetchQuestions},
+		{"submit answers for the first player", run.submitFirstPlayer},
+		{"submit answers for the second player", run.submitSecondPlayer},
+		{"resubmit an answered question", run.resubmit},
+		{"check leaderboard", run.checkLeaderboard},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			fmt.Fprintf(out, "FAIL %s: %v\n", step.name, err)
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+		fmt.Fprintf(out, "ok   %s\n", step.name)
+	}
+	fmt.Fprintf(out, "Smoke test passed on quiz %s.\n", run.quizID)
+	return nil
+}
+
+// runner carries what earlier steps learned to later ones.
+type runner struct {
+	ctx    context.Context
+	client *userclient.HTTPClient
+	out    io.Writer
+	runID  string
+
+	quizID    string
+	questions []quiz.Question
+	created   []userclient.CreatedQuestion
+}
+
+func (r *runner) username(player string) string {
+	return "smoke-" + r.runID + "-" + player
+}
+
+func (r *runner) createQuiz() error {
+	for _, item := range smokeQuestions {
+		question, err := quiz.NewQuestion(item.prompt+" ["+r.runID+"]", item.options, item.correctIndex)
+		if err != nil {
+			return err
+		}
+		r.questions = append(r.questions, question)
+	}
+	metadata, created, err := r.client.CreateQuizFromQuestions(r.ctx, r.questions)
+	if err != nil {
+		return err
+	}
+	if metadata.QuizID == "" || metadata.QuestionCount != len(r.questions) {
+		return fmt.Errorf("created quiz %q with %d questions, want an id and %d questions", metadata.QuizID, metadata.QuestionCount, len(r.questions))
+	}
+	r.quizID, r.created = metadata.QuizID, created
+	fmt.Fprintf(r.out, "     quiz %s\n", r.quizID)
+	return nil
+}
+
+func (r *runner) fetchQuestions() error {
+	fetched, err := r.client.GetQuizQuestions(r.ctx, r.quizID, "", false, 0)
+	if err != nil {
+		return err
+	}
+	if fetched.QuizID != r.quizID || len(fetched.Questions) != len(r.created) {
+		return fmt.Errorf("fetched %d questions of quiz %q, want %d of %q", len(fetched.Questions), fetched.QuizID, len(r.created), r.quizID)
+	}
+	for idx, item := range fetched.Questions {
+		if item.QuestionID != r.created[idx].QuestionID {
+			return fmt.Errorf("question %d is %q, want %q", idx+1, item.QuestionID, r.created[idx].QuestionID)
+		}
+		if item.CorrectIndex != r.questions[idx].CorrectIndex {
+			return fmt.Errorf("question %d has correct_index %d, want %d", idx+1, item.CorrectIndex, r.questions[idx].CorrectIndex)
+		}
+		// Keep the served hash so servers that require one accept the answers.
+		r.created[idx].ContentHash = item.ContentHash
+	}
+	return nil
+}
+
+// answer is the response to question idx: its correct letter, or another one.
+func (r *runner) answer(idx int, correct bool) quiz.SubmittedResponse {
+	question := r.questions[idx]
+	letter := question.Options[question.CorrectIndex].Letter
+	if !correct {
+		letter = question.Options[(question.CorrectIndex+1)%len(question.Options)].Letter
+	}
+	return quiz.SubmittedResponse{QuestionID: r.created[idx].QuestionID, Answer: letter, ContentHash: r.created[idx].ContentHash}
+}
+
+// submitFirstPlayer answers everything correctly, repeating the first answer
+// in the same request to check it is set aside.
+func (r *runner) submitFirstPlayer() error {
+	return r.submit("alice", []quiz.SubmittedResponse{r.answer(0, true), r.answer(1, true), r.answer(2, true), r.answer(0, false)},
+		[]string{quiz.StatusCorrect, quiz.StatusCorrect, quiz.StatusCorrect, quiz.StatusDuplicateInRequest})
+}
+
+func (r *runner) submitSecondPlayer() error {
+	return r.submit("bob", []quiz.SubmittedResponse{r.answer(0, true), r.answer(1, false), r.answer(2, true)},
+		[]string{quiz.StatusCorrect, quiz.StatusIncorrect, quiz.StatusCorrect})
+}
+
+// resubmit sends the second player a different answer to a question they
+// got wrong; the first answer must stand.
+func (r *runner) resubmit() error {
+	return r.submit("bob", []quiz.SubmittedResponse{r.answer(1, true)}, []string{quiz.StatusAlreadyAnswered})
+}
+
+func (r *runner) submit(player string, responses []quiz.SubmittedResponse, want []string) error {
+	results, err := r.client.SubmitResponses(r.ctx, r.quizID, r.username(player), responses)
+	if err != nil {
+		return err
+	}
+	if len(results) != len(want) {
+		return fmt.Errorf("got %d results for %d responses", len(results), len(want))
+	}
+	for idx, result := range results {
+		if result.QuestionID != responses[idx].QuestionID || result.Status != want[idx] {
+			return fmt.Errorf("response %d: got %s for %q, want %s for %q", idx+1, result.Status, result.QuestionID, want[idx], responses[idx].QuestionID)
+		}
+	}
+	return nil
+}
+
+// checkLeaderboard expects the first player ahead on three correct answers
+// and the second on two. Scores are checked as lower bounds, since a server
+// with a speed bonus adds to correct answers.
+func (r *runner) checkLeaderboard() error {
+	entries, err := r.client.GetLeaderboard(r.ctx, r.quizID, 10)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 2 {
+		return fmt.Errorf("leaderboard has %d entries, want 2", len(entries))
+	}
+	first, second := entries[0], entries[1]
+	if first.Username != r.username("alice") || second.Username != r.username("bob") {
+		return fmt.Errorf("leaderboard order is %s, %s; want %s first", first.Username, second.Username, r.username("alice"))
+	}
+	if first.AnsweredCount != 3 || second.AnsweredCount != 3 {
+		return fmt.Errorf("answered counts are %d and %d, want 3 each", first.AnsweredCount, second.AnsweredCount)
+	}
+	if first.TotalScore < 3 || second.TotalScore < 2 || second.TotalScore >= first.TotalScore {
+		return fmt.Errorf("scores are %g and %g, want at least 3 and 2 with the first player ahead", first.TotalScore, second.TotalScore)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package smoketest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"quiz-app/internal/httpapi"
+	"quiz-app/internal/quiz"
+	"quiz-app/internal/quiz/sqlite"
+	"quiz-app/internal/userclient"
+)
+
+func TestRunPassesAgainstService(t *testing.T) {
+	store, err := sqlite.NewSQLiteStore(filepath.Join(t.TempDir(), "smoke.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	server := httptest.NewServer(httpapi.NewRouter(quiz.NewService(store, store, nil), quiz.NewBank()))
+	t.Cleanup(server.Close)
+
+	var out bytes.Buffer
+	client := userclient.NewHTTPClient(server.URL, server.Client())
+	if err := Run(context.Background(), client, &out, Options{RunID: "test"}); err != nil {
+		t.Fatalf("Run failed: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "ok   check leaderboard") {
+		t.Fatalf("output = %q, want every step reported", out.String())
+	}
+}
+
+func TestRunFailsOnBrokenService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"down for maintenance"}`, http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	var out bytes.Buffer
+	err := Run(context.Background(), userclient.NewHTTPClient(server.URL, server.Client()), &out, Options{RunID: "test"})
+	if err == nil || !strings.HasPrefix(err.Error(), "create quiz:") {
+		t.Fatalf("Run error = %v, want the create quiz step to fail", err)
+	}
+	if !strings.Contains(out.String(), "FAIL create quiz") {
+		t.Fatalf("output = %q, want the failed step reported", out.String())
+	}
+}
@@ -0,0 +1,164 @@
+// Package triviaapi fetches questions from The Trivia API
+// (the-trivia-api.com) in OpenTriviaDB's shape, so quizzes can be built from
+// it when OpenTriviaDB is down.
+package triviaapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"quiz-app/internal/opentdb"
+)
+
+const (
+	apiURL        = "https://the-trivia-api.com/v2/questions"
+	defaultAmount = 10
+	// maxAmount is the most questions The Trivia API returns per request.
+	maxAmount = 50
+)
+
+// ErrUnsupportedFilter reports a filter The Trivia API cannot serve: it has
+// no true/false questions, and only some OpenTriviaDB categories have a
+// counterpart.
+var ErrUnsupportedFilter = errors.New("triviaapi cannot serve the filter")
+
+// categories maps OpenTriviaDB category IDs to The Trivia API's categories
+// where the two mean the same thing.
+var categories = map[int]string{
+	9:  "general_knowledge",
+	12: "music",
+	17: "science",
+	21: "sport_and_leisure",
+	22: "geography",
+	23: "history",
+}
+
+// categoryNames spells The Trivia API's categories for fetched questions.
+var categoryNames = map[string]string{
+	"arts_and_literature": "Arts & Literature",
+	"film_and_tv":         "Film & TV",
+	"food_and_drink":      "Food & Drink",
+	"general_knowledge":   "General Knowledge",
+	"geography":           "Geography",
+	"history":             "History",
+	"music":               "Music",
+	"science":             "Science",
+	"society_and_culture": "Society & Culture",
+	"sport_and_leisure":   "Sport & Leisure",
+}
+
+type apiQuestion struct {
+	Category         string   `json:"category"`
+	Type             string   `json:"type"`
+	Difficulty       string   `json:"difficulty"`
+	CorrectAnswer    string   `json:"correctAnswer"`
+	IncorrectAnswers []string `json:"incorrectAnswers"`
+	Question         struct {
+		Text string `json:"text"`
+	} `json:"question"`
+}
+
+type Client struct {
+	httpClient *http.Client
+}
+
+var defaultHTTPClient = &http.Client{
+	Timeout: 5 * time.Second,
+}
+
+var defaultClient = NewClient(nil)
+
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	return &Client{httpClient: httpClient}
+}
+
+func FetchQuestions(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+	return defaultClient.FetchQuestions(ctx, amount, filter)
+}
+
+// FetchQuestions fetches amount random text questions matching filter. A
+// filtered category is reported with its OpenTriviaDB name, so fetched
+// questions pass filter.Matches.
+func (c *Client) FetchQuestions(ctx context.Context, amount int, filter opentdb.Filter) ([]opentdb.RawQuestion, error) {
+	if amount <= 0 {
+		amount = defaultAmount
+	}
+	amount = min(amount, maxAmount)
+	if filter.Type != "" && filter.Type != opentdb.TypeMultiple {
+		return nil, fmt.Errorf("%w: no %s questions", ErrUnsupportedFilter, filter.Type)
+	}
+
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(amount))
+	query.Set("types", "text_choice")
+	if filter.Difficulty != "" {
+		query.Set("difficulties", strings.ToLower(filter.Difficulty))
+	}
+	var categoryName string
+	if filter.Category != 0 {
+		category, ok := categories[filter.Category]
+		if !ok {
+			return nil, fmt.Errorf("%w: no counterpart for category %d", ErrUnsupportedFilter, filter.Category)
+		}
+		query.Set("categories", category)
+		opentdbCategory, _ := opentdb.CategoryByID(filter.Category)
+		categoryName = opentdbCategory.Name
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("triviaapi returned status %d", resp.StatusCode)
+	}
+
+	var payload []apiQuestion
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	questions := make([]opentdb.RawQuestion, 0, len(payload))
+	for _, question := range payload {
+		if question.Question.Text == "" || question.CorrectAnswer == "" || len(question.IncorrectAnswers) == 0 {
+			continue
+		}
+		name := categoryName
+		if name == "" {
+			name = categoryNames[question.Category]
+		}
+		if name == "" {
+			name = question.Category
+		}
+		// Text arrives plain; it is escaped because fetched questions are
+		// unescaped as OpenTriviaDB's HTML entities.
+		incorrect := make([]string, len(question.IncorrectAnswers))
+		for idx, text := range question.IncorrectAnswers {
+			incorrect[idx] = html.EscapeString(text)
+		}
+		questions = append(questions, opentdb.RawQuestion{
+			Type:             opentdb.TypeMultiple,
+			Difficulty:       strings.ToLower(question.Difficulty),
+			Category:         html.EscapeString(name),
+			Question:         html.EscapeString(question.Question.Text),
+			CorrectAnswer:    html.EscapeString(question.CorrectAnswer),
+			IncorrectAnswers: incorrect,
+		})
+	}
+	return questions, nil
+}
@@ -0,0 +1,88 @@
+package triviaapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"quiz-app/internal/opentdb"
+	"quiz-app/internal/quiz"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func respond(status int, body string) roundTripperFunc {
+	return func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+	}
+}
+
+func TestFetchQuestionsConvertsToOpenTriviaDBShape(t *testing.T) {
+	body := `[
+		{"category": "film_and_tv", "type": "text_choice", "difficulty": "Hard", "correctAnswer": "Tom & Jerry", "incorrectAnswers": ["Itchy & Scratchy", "<Pinky>"], "question": {"text": "Which duo is a cat & a mouse?"}},
+		{"category": "science", "type": "text_choice", "difficulty": "easy", "correctAnswer": "", "incorrectAnswers": ["x"], "question": {"text": "Broken?"}}
+	]`
+	var query map[string][]string
+	client := NewClient(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.Query()
+		return respond(http.StatusOK, body)(r)
+	})})
+
+	questions, err := client.FetchQuestions(context.Background(), 80, opentdb.Filter{Difficulty: "hard"})
+	if err != nil {
+		t.Fatalf("FetchQuestions failed: %v", err)
+	}
+	if query["limit"][0] != "50" || query["difficulties"][0] != "hard" || query["types"][0] != "text_choice" {
+		t.Fatalf("query = %v, want limit capped at 50 and the difficulty passed on", query)
+	}
+	if len(questions) != 1 {
+		t.Fatalf("questions = %+v, want the broken one dropped", questions)
+	}
+	got := questions[0]
+	if got.Type != opentdb.TypeMultiple || got.Difficulty != "hard" || got.Category != "Film &amp; TV" || got.Question != "Which duo is a cat &amp; a mouse?" {
+		t.Fatalf("question = %+v, want escaped text in OpenTriviaDB's shape", got)
+	}
+
+	// Built questions read as The Trivia API wrote them.
+	built := quiz.BuildQuestions(questions)
+	if built[0].Options[built[0].CorrectIndex].Text != "Tom & Jerry" || built[0].Category != "Film & TV" {
+		t.Fatalf("built = %+v, want the unescaped text", built[0])
+	}
+}
+
+func TestFetchQuestionsMapsFilters(t *testing.T) {
+	var categories string
+	client := NewClient(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		categories = r.URL.Query().Get("categories")
+		return respond(http.StatusOK, `[{"category": "history", "difficulty": "medium", "correctAnswer": "1066", "incorrectAnswers": ["1067"], "question": {"text": "Hastings?"}}]`)(r)
+	})})
+
+	filter := opentdb.Filter{Category: 23}
+	questions, err := client.FetchQuestions(context.Background(), 1, filter)
+	if err != nil || len(questions) != 1 || categories != "history" {
+		t.Fatalf("FetchQuestions = (%+v, %v) with categories=%q, want one history question", questions, err, categories)
+	}
+	if !filter.Matches(questions[0]) {
+		t.Fatalf("question %+v does not match its own filter", questions[0])
+	}
+
+	for _, filter := range []opentdb.Filter{{Type: opentdb.TypeBoolean}, {Category: 31}} {
+		if _, err := client.FetchQuestions(context.Background(), 1, filter); !errors.Is(err, ErrUnsupportedFilter) {
+			t.Fatalf("FetchQuestions(%+v) error = %v, want ErrUnsupportedFilter", filter, err)
+		}
+	}
+}
+
+func TestFetchQuestionsReportsNonOKStatus(t *testing.T) {
+	client := NewClient(&http.Client{Transport: respond(http.StatusTooManyRequests, "")})
+	if _, err := client.FetchQuestions(context.Background(), 5, opentdb.Filter{}); err == nil {
+		t.Fatal("FetchQuestions succeeded on a 429")
+	}
+}
@@ -0,0 +1,285 @@
+package userclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+
+	"quiz-app/internal/quiz"
+)
+
+// ErrUsage marks errors caused by how a command was invoked, so callers can
+// exit with a usage status instead of a failure status.
+var ErrUsage = errors.New("usage")
+
+// commandSpec describes one command for help text, tab completion, and shell
+// completion scripts. The interactive switch in Run and the one-shot switch in
+// Exec dispatch on name.
+type commandSpec struct {
+	name    string
+	args    string
+	summary string
+	// interactive and oneShot say where the command is available.
+	interactive bool
+	oneShot     bool
+	// limit and json are the flags the command accepts; interactively, limit
+	// is an optional last argument and --json works anywhere.
+	limit bool
+	json  bool
+}
+
+var commands = []commandSpec{
+	{name: "help", summary: "show commands", interactive: true, oneShot: true},
+	{name: "quizzes", summary: "list recently created quizzes", interactive: true, oneShot: true, limit: true, json: true},
+	{name: "leaderboard", args: "<quiz_id>", summary: "show a quiz leaderboard", interactive: true, oneShot: true, limit: true, json: true},
+	{name: "search", args: "<text>", summary: "search stored questions", interactive: true, oneShot: true, limit: true, json: true},
+	{name: "create", summary: "create a quiz step by step, with a preview: questions from the server, a file, or typed in", interactive: true, oneShot: true},
+	{name: "import", args: "<file> [format]", summary: "create a quiz from an Aiken, GIFT, or Moodle XML file", interactive: true, oneShot: true, json: true},
+	{name: "play", args: "<quiz_id>", summary: "play a quiz (needs --username)", interactive: true, oneShot: true},
+	{name: "daily", summary: "play today's daily quiz (needs --username)", interactive: true, oneShot: true},
+	{name: "review", args: "<quiz_id>", summary: "go over your answers in a quiz, with the correct ones once you finish (needs --username)", interactive: true, oneShot: true, json: true},
+	{name: "prefs", args: "[count=N] [difficulty=easy|medium|hard|any]", summary: "show or change your defaults for quizzes you create (needs --username)", interactive: true, oneShot: true, json: true},
+	{name: "history", summary: "quizzes played in this session", interactive: true, json: true},
+	{name: "use", args: "[profile]", summary: "switch to a server profile from the config file, or list them", interactive: true, json: true},
+	{name: "sync", summary: "send answers queued while the server was unreachable", interactive: true, oneShot: true},
+	{name: "log", summary: "recent answers sent and whether the server saved them", interactive: true, oneShot: true, limit: true, json: true},
+	{name: "completion", args: "<bash|zsh>", summary: "print a shell completion script", oneShot: true},
+	{name: "exit", summary: "leave the client", interactive: true},
+}
+
+func interactiveCommandNames() []string {
+	names := make([]string, 0, len(commands))
+	for _, spec := range commands {
+		if spec.interactive {
+			names = append(names, spec.name)
+		}
+	}
+	return names
+}
+
+func lookupCommand(name string) (commandSpec, bool) {
+	for _, spec := range commands {
+		if spec.name == name {
+			return spec, true
+		}
+	}
+	return commandSpec{}, false
+}
+
+// Exec runs one command and returns, for use from scripts:
+//
+//	quiz-user-service leaderboard <quiz_id> --limit 5 --json
+//
+// Flags may appear before or after positional arguments. play and daily still
+// read answers from in.
+func Exec(ctx context.Context, in io.Reader, out io.Writer, cfg Config, globals *flag.FlagSet, args []string) error {
+	cfg, err := cfg.ResolveProfile()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	cfg = cfg.withDefaults()
+	if len(args) == 0 {
+		return fmt.Errorf("%w: a command is required", ErrUsage)
+	}
+
+	name := strings.ToLower(args[0])
+	spec, ok := lookupCommand(name)
+	if !ok || !spec.oneShot {
+		return fmt.Errorf("%w: unknown command %q; run 'quiz-user-service help'", ErrUsage, args[0])
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	limitDefault := cfg.ListLimit
+	if name == "leaderboard" {
+		limitDefault = cfg.LeaderboardLimit
+	}
+	var (
+		limit  = &limitDefault
+		asJSON = new(bool)
+	)
+	if spec.limit {
+		limit = flags.Int("limit", limitDefault, "maximum entries to list")
+	}
+	if spec.json {
+		asJSON = flags.Bool("json", cfg.JSON, "print the result as JSON")
+	}
+	positional, err := parseInterspersed(flags, args[1:])
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUsage, name, err)
+	}
+
+	v := view{asJSON: *asJSON, style: styler{enabled: !*asJSON && colorEnabled(out, cfg.NoColor)}}
+	client := NewHTTPClient(cfg.ServerURL, &http.Client{Timeout: cfg.HTTPTimeout})
+	client.SetPlayerToken(cfg.PlayerToken)
+	client.SetAccountToken(cfg.AccountToken)
+	usage := func() error {
+		return fmt.Errorf("%w: %s", ErrUsage, strings.TrimSpace("quiz-user-service "+name+" "+spec.args))
+	}
+
+	switch name {
+	case "help":
+		printCommandHelp(out)
+		return nil
+	case "completion":
+		if len(positional) != 1 {
+			return usage()
+		}
+		return WriteCompletion(out, positional[0], globals)
+	case "quizzes":
+		if len(positional) != 0 {
+			return usage()
+		}
+		if *limit <= 0 {
+			return fmt.Errorf("%w: --limit must be a positive integer", ErrUsage)
+		}
+		return runList(ctx, out, v, client, *limit, cfg.ServerURL)
+	case "leaderboard":
+		if len(positional) != 1 {
+			return usage()
+		}
+		return runLeaderboard(ctx, out, v, client, positional[0], *limit, cfg.ServerURL)
+	case "search":
+		if len(positional) == 0 {
+			return usage()
+		}
+		if *limit <= 0 {
+			return fmt.Errorf("%w: --limit must be a positive integer", ErrUsage)
+		}
+		return runSearch(ctx, out, v, client, strings.Join(positional, " "), *limit, cfg.ServerURL)
+	case "import":
+		if len(positional) < 1 || len(positional) > 2 {
+			return usage()
+		}
+		format := ""
+		if len(positional) == 2 {
+			format = positional[1]
+		}
+		return runImport(ctx, out, v, client, positional[0], format, cfg.ServerURL)
+	case "create":
+		if len(positional) != 0 {
+			return usage()
+		}
+		return runCreateWizard(ctx, bufio.NewReader(in), out, v.style, client, cfg.Username, cfg.ServerURL)
+	case "log":
+		if len(positional) != 0 {
+			return usage()
+		}
+		if *limit <= 0 {
+			return fmt.Errorf("%w: --limit must be a positive integer", ErrUsage)
+		}
+		return runSubmissionLog(out, v, newSubmissionLog(cfg.SubmissionLog), *limit)
+	case "sync":
+		if len(positional) != 0 {
+			return usage()
+		}
+		queue, err := newOfflineQueue(cfg.OfflineQueue, cfg.SigningKey)
+		if err != nil {
+			return err
+		}
+		return runSync(ctx, out, client, queue, newSubmissionLog(cfg.SubmissionLog), cfg.ServerURL)
+	case "review":
+		if cfg.Username == "" {
+			return fmt.Errorf("%w: --username is required for review", ErrUsage)
+		}
+		if len(positional) != 1 {
+			return usage()
+		}
+		return runReview(ctx, out, v, client, cfg.Username, positional[0], cfg.ServerURL)
+	case "prefs":
+		if cfg.Username == "" {
+			return fmt.Errorf("%w: --username is required for prefs", ErrUsage)
+		}
+		change, err := parsePreferenceArgs(positional)
+		if err != nil {
+			return fmt.Errorf("%w: prefs: %v", ErrUsage, err)
+		}
+		return runPreferences(ctx, out, v, client, cfg.Username, change, cfg.ServerURL)
+	case "play", "daily":
+		if cfg.Username == "" {
+			return fmt.Errorf("%w: --username is required to play", ErrUsage)
+		}
+		quizID := ""
+		if name == "play" {
+			if len(positional) != 1 {
+				return usage()
+			}
+			quizID = positional[0]
+		} else {
+			if len(positional) != 0 {
+				return usage()
+			}
+			var metadata quiz.QuizMetadata
+			err := withRetry(ctx, func() (err error) {
+				metadata, err = client.GetDailyQuiz(ctx)
+				return err
+			})
+			if err != nil {
+				return describeClientError(err, cfg.ServerURL)
+			}
+			quizID = metadata.QuizID
+		}
+		persister := newAnswerPersister(client, newSubmissionLog(cfg.SubmissionLog))
+		queue, err := openOfflineQueue(ctx, cfg, client, cfg.Username)
+		if err != nil {
+			return err
+		}
+		persister.queue = queue
+		_, err = runPlay(ctx, bufio.NewReader(in), out, v.style, client, persister, cfg.Username, quizID, cfg.MaxInvalidAnswers, cfg.ServerURL)
+		return err
+	}
+	return usage()
+}
+
+// parseInterspersed parses flags mixed with positional arguments, which the
+// flag package alone stops at. Everything after "--" is positional.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0, len(args))
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		consumed := len(args) - len(rest)
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// printCommandHelp lists the one-shot commands.
+func printCommandHelp(out io.Writer) {
+	fmt.Fprintln(out, "Usage: quiz-user-service [flags] [command [args] [--limit N] [--json]]")
+	fmt.Fprintln(out, "Without a command, starts the interactive client (needs --username).")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, spec := range commands {
+		if !spec.oneShot {
+			continue
+		}
+		usage := []string{spec.name}
+		if spec.args != "" {
+			usage = append(usage, spec.args)
+		}
+		if spec.limit {
+			usage = append(usage, "[--limit N]")
+		}
+		if spec.json {
+			usage = append(usage, "[--json]")
+		}
+		fmt.Fprintf(table, "  %s\t%s\n", strings.Join(usage, " "), spec.summary)
+	}
+	_ = table.Flush()
+}
@@ -0,0 +1,173 @@
+package userclient
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const completionProgram = "quiz-user-service"
+
+// WriteCompletion prints a completion script for shell ("bash" or "zsh")
+// covering the one-shot commands, their flags, and the global flags in globals.
+//
+//	source <(quiz-user-service completion bash)
+func WriteCompletion(out io.Writer, shell string, globals *flag.FlagSet) error {
+	var globalFlags []*flag.Flag
+	if globals != nil {
+		globals.VisitAll(func(f *flag.Flag) {
+			globalFlags = append(globalFlags, f)
+		})
+	}
+
+	switch strings.ToLower(strings.TrimSpace(shell)) {
+	case "bash":
+		writeBashCompletion(out, globalFlags)
+	case "zsh":
+		writeZshCompletion(out, globalFlags)
+	default:
+		return fmt.Errorf("%w: unsupported shell %q (want bash or zsh)", ErrUsage, shell)
+	}
+	return nil
+}
+
+// takesValue reports whether a flag consumes the next word. Boolean flags from
+// the flag package implement IsBoolFlag.
+func takesValue(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !boolFlag.IsBoolFlag()
+}
+
+func commandFlags(spec commandSpec) []string {
+	var flags []string
+	if spec.limit {
+		flags = append(flags, "--limit")
+	}
+	if spec.json {
+		flags = append(flags, "--json")
+	}
+	return flags
+}
+
+func oneShotCommandNames() []string {
+	names := make([]string, 0, len(commands))
+	for _, spec := range commands {
+		if spec.oneShot {
+			names = append(names, spec.name)
+		}
+	}
+	return names
+}
+
+func writeBashCompletion(out io.Writer, globalFlags []*flag.Flag) {
+	var names, valueFlags []string
+	for _, f := range globalFlags {
+		names = append(names, "--"+f.Name)
+		if takesValue(f) {
+			valueFlags = append(valueFlags, "--"+f.Name, "-"+f.Name)
+		}
+	}
+
+	fmt.Fprintf(out, "# bash completion for %s\n", completionProgram)
+	fmt.Fprintln(out, "_quiz_user_service() {")
+	fmt.Fprintln(out, `    local cur="${COMP_WORDS[COMP_CWORD]}" command="" word i`)
+	fmt.Fprintln(out, "    for ((i = 1; i < COMP_CWORD; i++)); do")
+	fmt.Fprintln(out, `        word="${COMP_WORDS[i]}"`)
+	fmt.Fprintln(out, `        case "$word" in`)
+	if len(valueFlags) > 0 {
+		fmt.Fprintf(out, "            %s) ((i++)) ;;\n", strings.Join(valueFlags, "|"))
+	}
+	fmt.Fprintln(out, "            -*) ;;")
+	fmt.Fprintln(out, `            *) command="$word"; break ;;`)
+	fmt.Fprintln(out, "        esac")
+	fmt.Fprintln(out, "    done")
+	fmt.Fprintln(out, `    if [[ -z "$command" ]]; then`)
+	fmt.Fprintln(out, `        if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintf(out, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(out, "        else")
+	fmt.Fprintf(out, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(oneShotCommandNames(), " "))
+	fmt.Fprintln(out, "        fi")
+	fmt.Fprintln(out, "        return")
+	fmt.Fprintln(out, "    fi")
+	fmt.Fprintln(out, `    case "$command" in`)
+	for _, spec := range commands {
+		if !spec.oneShot {
+			continue
+		}
+		switch {
+		case spec.name == "completion":
+			fmt.Fprintln(out, `        completion) COMPREPLY=($(compgen -W "bash zsh" -- "$cur")) ;;`)
+		case len(commandFlags(spec)) > 0:
+			fmt.Fprintf(out, "        %s) [[ \"$cur\" == -* ]] && COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n",
+				spec.name, strings.Join(commandFlags(spec), " "))
+		}
+	}
+	fmt.Fprintln(out, "    esac")
+	fmt.Fprintln(out, "}")
+	fmt.Fprintf(out, "complete -F _quiz_user_service %s\n", completionProgram)
+}
+
+func writeZshCompletion(out io.Writer, globalFlags []*flag.Flag) {
+	fmt.Fprintf(out, "#compdef %s\n\n", completionProgram)
+	fmt.Fprintln(out, "_quiz_user_service() {")
+	fmt.Fprintln(out, "    local -a commands")
+	fmt.Fprintln(out, "    commands=(")
+	for _, spec := range commands {
+		if spec.oneShot {
+			fmt.Fprintf(out, "        %s\n", zshQuote(spec.name+":"+spec.summary))
+		}
+	}
+	fmt.Fprintln(out, "    )")
+	fmt.Fprintln(out, "    _arguments -C \\")
+	for _, f := range globalFlags {
+		spec := "--" + f.Name + "[" + zshEscapeBrackets(f.Usage) + "]"
+		if takesValue(f) {
+			spec += ":" + f.Name + ":"
+		}
+		fmt.Fprintf(out, "        %s \\\n", zshQuote(spec))
+	}
+	fmt.Fprintln(out, `        '1:command:->command' \`)
+	fmt.Fprintln(out, `        '*::arg:->args'`)
+	fmt.Fprintln(out, "    case $state in")
+	fmt.Fprintln(out, "        command) _describe 'command' commands ;;")
+	fmt.Fprintln(out, "        args)")
+	fmt.Fprintln(out, "            case $words[1] in")
+	for _, spec := range commands {
+		if !spec.oneShot {
+			continue
+		}
+		var specs []string
+		if spec.limit {
+			specs = append(specs, zshQuote("--limit[maximum entries to list]:limit:"))
+		}
+		if spec.json {
+			specs = append(specs, zshQuote("--json[print the result as JSON]"))
+		}
+		if spec.name == "completion" {
+			specs = append(specs, zshQuote("1:shell:(bash zsh)"))
+		}
+		if len(specs) > 0 {
+			fmt.Fprintf(out, "                %s) _arguments %s ;;\n", spec.name, strings.Join(specs, " "))
+		}
+	}
+	fmt.Fprintln(out, "            esac")
+	fmt.Fprintln(out, "            ;;")
+	fmt.Fprintln(out, "    esac")
+	fmt.Fprintln(out, "}")
+	fmt.Fprintln(out)
+	// Works both autoloaded from $fpath and sourced directly.
+	fmt.Fprintln(out, `if [ "$funcstack[1]" = "_quiz_user_service" ]; then`)
+	fmt.Fprintln(out, `    _quiz_user_service "$@"`)
+	fmt.Fprintln(out, "else")
+	fmt.Fprintf(out, "    compdef _quiz_user_service %s\n", completionProgram)
+	fmt.Fprintln(out, "fi")
+}
+
+func zshQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func zshEscapeBrackets(value string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(value)
+}
@@ -0,0 +1,123 @@
+package userclient
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// timedInput reads answers on a background goroutine so a timed question can
+// stop waiting when its countdown runs out. At most one read is in flight; a
+// read left pending by an expired question carries over to the next prompt.
+type timedInput struct {
+	reader  *bufio.Reader
+	lines   chan timedLine
+	pending bool
+	now     func() time.Time
+}
+
+type timedLine struct {
+	text string
+	err  error
+	at   time.Time
+}
+
+func newTimedInput(reader *bufio.Reader) *timedInput {
+	return &timedInput{reader: reader, lines: make(chan timedLine, 1), now: time.Now}
+}
+
+// readLine returns the first line entered after since. Lines entered earlier
+// were meant for a question that already timed out and are dropped, so a late
+// answer never lands on the next question. ok is false when deadline passes
+// first. tick is called about once a second with the time left.
+func (t *timedInput) readLine(since, deadline time.Time, tick func(left time.Duration)) (line string, ok bool, err error) {
+	expired := time.NewTimer(time.Until(deadline))
+	defer expired.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		if !t.pending {
+			t.pending = true
+			go func() {
+				text, err := t.reader.ReadString('\n')
+				t.lines <- timedLine{text: text, err: err, at: t.now()}
+			}()
+		}
+
+		select {
+		case read := <-t.lines:
+			t.pending = false
+			if read.err != nil {
+				return "", false, read.err
+			}
+			if read.at.Before(since) {
+				continue
+			}
+			return read.text, true, nil
+		case <-ticker.C:
+			tick(time.Until(deadline))
+		case <-expired.C:
+			return "", false, nil
+		}
+	}
+}
+
+// finish waits out a read left pending by the last question so the command
+// loop does not race the background goroutine for the next line.
+func (t *timedInput) finish(out io.Writer) {
+	if !t.pending {
+		return
+	}
+	select {
+	case <-t.lines:
+	default:
+		fmt.Fprintln(out, "Press Enter to continue.")
+		<-t.lines
+	}
+	t.pending = false
+}
+
+// promptTimedAnswer is promptAnswer with a countdown. It re-prompts with the
+// time left every ten seconds and each of the last five, and reports timedOut
+// when deadline passes without an answer.
+func promptTimedAnswer(input *timedInput, out io.Writer, optionCount int, multi bool, deadline time.Time) (answer string, ok, timedOut bool) {
+	if optionCount < 1 {
+		return "", false, false
+	}
+
+	since := time.Now()
+	fmt.Fprint(out, timedAnswerPrompt(optionCount, time.Until(deadline)))
+	line, entered, err := input.readLine(since, deadline, func(left time.Duration) {
+		seconds := secondsLeft(left)
+		if seconds > 0 && (seconds%10 == 0 || seconds <= 5) {
+			fmt.Fprint(out, "\n"+timedAnswerPrompt(optionCount, left))
+		}
+	})
+	if err != nil {
+		return "", false, false
+	}
+	if !entered {
+		fmt.Fprintln(out)
+		return "", false, true
+	}
+	if multi {
+		answer, ok = parseLetters(line, optionCount)
+	} else {
+		answer, ok = parseAnswer(line, optionCount)
+	}
+	return answer, ok, false
+}
+
+func timedAnswerPrompt(optionCount int, left time.Duration) string {
+	return fmt.Sprintf("Your answer (A-%c, %ds left): ", byte('A'+optionCount-1), secondsLeft(left))
+}
+
+// secondsLeft rounds up so the countdown shows 1s until time is actually up.
+func secondsLeft(left time.Duration) int {
+	if left <= 0 {
+		return 0
+	}
+	return int((left + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,66 @@
+package userclient
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimedInputDropsLinesEnteredBeforePrompt(t *testing.T) {
+	input := newTimedInput(bufio.NewReader(strings.NewReader("late\nB\n")))
+	stamps := []time.Time{time.Unix(100, 0), time.Unix(300, 0)}
+	input.now = func() time.Time {
+		stamp := stamps[0]
+		stamps = stamps[1:]
+		return stamp
+	}
+
+	line, ok, err := input.readLine(time.Unix(200, 0), time.Now().Add(time.Minute), func(time.Duration) {})
+	if err != nil || !ok || line != "B\n" {
+		t.Fatalf("readLine = (%q, %t, %v), want (\"B\\n\", true, nil)", line, ok, err)
+	}
+}
+
+func TestPromptTimedAnswerTimesOut(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	input := newTimedInput(bufio.NewReader(pr))
+	var out bytes.Buffer
+
+	answer, ok, timedOut := promptTimedAnswer(input, &out, 2, false, time.Now().Add(20*time.Millisecond))
+	if answer != "" || ok || !timedOut {
+		t.Fatalf("promptTimedAnswer = (%q, %t, %t), want (\"\", false, true)", answer, ok, timedOut)
+	}
+	if !input.pending {
+		t.Fatalf("expected the unanswered read to stay pending")
+	}
+
+	// The pending read carries over and answers the next prompt.
+	go func() { _, _ = pw.Write([]byte("b\n")) }()
+	answer, ok, timedOut = promptTimedAnswer(input, &out, 2, false, time.Now().Add(time.Minute))
+	if answer != "B" || !ok || timedOut {
+		t.Fatalf("next promptTimedAnswer = (%q, %t, %t), want (B, true, false)", answer, ok, timedOut)
+	}
+	if !strings.Contains(out.String(), "Your answer (A-B, 1s left): ") {
+		t.Fatalf("expected countdown prompt, got: %s", out.String())
+	}
+}
+
+func TestSecondsLeftRoundsUp(t *testing.T) {
+	cases := map[time.Duration]int{
+		-time.Second:      0,
+		0:                 0,
+		time.Millisecond:  1,
+		time.Second:       1,
+		9*time.Second + 1: 10,
+		30 * time.Second:  30,
+	}
+	for left, want := range cases {
+		if got := secondsLeft(left); got != want {
+			t.Fatalf("secondsLeft(%v) = %d, want %d", left, got, want)
+		}
+	}
+}
@@ -5,42 +5,82 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 	"strings"
+
+	"quiz-app/internal/quiz"
 )
 
-func promptAnswer(reader *bufio.Reader, out io.Writer, optionCount int) (string, bool) {
+// promptAnswer reads an answer to a question with optionCount options. With
+// multi it takes one or more letters, as parseLetters does.
+func promptAnswer(reader *bufio.Reader, out io.Writer, optionCount int, multi bool) (string, bool) {
 	if optionCount < 1 {
 		return "", false
 	}
 
 	maxLetter := byte('A' + optionCount - 1)
-	fmt.Fprintf(out, "Your answer (A-%c): ", maxLetter)
+	if multi {
+		fmt.Fprintf(out, "Your answers (A-%c, comma-separated): ", maxLetter)
+	} else {
+		fmt.Fprintf(out, "Your answer (A-%c): ", maxLetter)
+	}
 
 	line, err := reader.ReadString('\n')
 	if err != nil {
 		return "", false
 	}
+	if multi {
+		return parseLetters(line, optionCount)
+	}
+	return parseAnswer(line, optionCount)
+}
 
+// parseAnswer accepts a single option letter in either case.
+func parseAnswer(line string, optionCount int) (string, bool) {
 	answer := strings.ToUpper(strings.TrimSpace(line))
 	if len(answer) != 1 {
 		return "", false
 	}
 	letter := answer[0]
-	if letter < 'A' || letter > maxLetter {
+	if letter < 'A' || letter >= byte('A'+optionCount) {
 		return "", false
 	}
 
 	return answer, true
 }
 
+// parseLetters accepts one or more comma-separated option letters, such as
+// "c, a", and returns them sorted as "A,C". Repeats are rejected.
+func parseLetters(line string, optionCount int) (string, bool) {
+	var letters []string
+	for _, part := range strings.Split(line, ",") {
+		letter, ok := parseAnswer(part, optionCount)
+		if !ok || slices.Contains(letters, letter) {
+			return "", false
+		}
+		letters = append(letters, letter)
+	}
+	slices.Sort(letters)
+	return strings.Join(letters, ","), true
+}
+
 func printHelp(out io.Writer) {
 	fmt.Fprintln(out, "Commands:")
-	fmt.Fprintln(out, "  help")
-	fmt.Fprintln(out, "  quizzes [limit]")
-	fmt.Fprintln(out, "  leaderboard <quiz_id> [limit]")
-	fmt.Fprintln(out, "  play <quiz_id>")
-	fmt.Fprintln(out, "  exit")
+	for _, spec := range commands {
+		if !spec.interactive {
+			continue
+		}
+		usage := []string{spec.name}
+		if spec.args != "" {
+			usage = append(usage, spec.args)
+		}
+		if spec.limit {
+			usage = append(usage, "[limit]")
+		}
+		fmt.Fprintln(out, "  "+strings.Join(usage, " "))
+	}
+	fmt.Fprintln(out, "Add --json to quizzes, leaderboard, search, import, review, history, use, or log for JSON output.")
 }
 
 func parsePositiveLimit(args []string, index int, defaultValue int) (int, error) {
@@ -98,11 +138,22 @@ func describeClientError(err error, serverURL string) error {
 }
 
 func correctAnswerDisplay(question questionItem) string {
-	if question.CorrectIndex < 0 || question.CorrectIndex >= len(question.Options) {
+	if question.Type == quiz.TypeMultiSelect {
+		displays := make([]string, 0, len(question.CorrectIndexes))
+		for _, index := range question.CorrectIndexes {
+			displays = append(displays, optionDisplay(question, index))
+		}
+		return strings.Join(displays, "; ")
+	}
+	return optionDisplay(question, question.CorrectIndex)
+}
+
+func optionDisplay(question questionItem, index int) string {
+	if index < 0 || index >= len(question.Options) {
 		return "unknown"
 	}
 
-	option := question.Options[question.CorrectIndex]
+	option := question.Options[index]
 	if strings.TrimSpace(option.Letter) == "" {
 		return option.Text
 	}
@@ -111,3 +162,33 @@ func correctAnswerDisplay(question questionItem) string {
 	}
 	return fmt.Sprintf("%s. %s", option.Letter, option.Text)
 }
+
+// showServerVerdict prints the server's result for an answer to a
+// server-scored quiz and returns what it adds to the possible and earned
+// score. Answers the server did not score count for neither.
+func showServerVerdict(out io.Writer, style styler, result quiz.ResponseResult) (possible, score float64) {
+	switch result.Status {
+	case quiz.StatusCorrect:
+		fmt.Fprintln(out, style.green("Correct!"))
+		return 1, 1
+	case quiz.StatusPartiallyCorrect:
+		message := fmt.Sprintf("Partly right: %.0f%% credit.", result.Credit*100)
+		if result.CorrectLetter != "" {
+			message += fmt.Sprintf(" Correct answers: %s. %s", result.CorrectLetter, result.CorrectText)
+		}
+		fmt.Fprintln(out, style.bold(message))
+		return 1, result.Credit
+	case quiz.StatusIncorrect:
+		message := "Wrong."
+		if result.CorrectLetter != "" {
+			message += fmt.Sprintf(" Correct answer: %s. %s", result.CorrectLetter, result.CorrectText)
+		}
+		fmt.Fprintln(out, style.red(message))
+		return 1, 0
+	case "":
+		fmt.Fprintln(out, "Not scored: the answer did not reach the server.")
+	default:
+		fmt.Fprintf(out, "Not scored: %s.\n", result.Status)
+	}
+	return 0, 0
+}
@@ -1,12 +1,9 @@
 package userclient
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -14,36 +11,46 @@ import (
 	"time"
 
 	"quiz-app/internal/quiz"
+	"quiz-app/pkg/quizclient"
 )
 
-var ErrServiceUnavailable = errors.New("quiz service unavailable")
+// ErrServiceUnavailable wraps failures to reach the server at all.
+var ErrServiceUnavailable = quizclient.ErrUnavailable
 
-type APIError struct {
-	StatusCode int
-	Message    string
-}
-
-func (e *APIError) Error() string {
-	if strings.TrimSpace(e.Message) == "" {
-		return fmt.Sprintf("request failed with status %d", e.StatusCode)
-	}
-	return e.Message
-}
+// APIError is a non-2xx response; see quizclient.APIError.
+type APIError = quizclient.APIError
 
+// HTTPClient is the user service's view of the quiz API. Requests go through a
+// quizclient.Client; the methods here convert to the types commands work with.
 type HTTPClient struct {
-	baseURL    string
-	httpClient *http.Client
+	api *quizclient.Client
 }
 
 // quiz-user-service intentionally opts into correct_index visibility to keep
-// local scoring straightforward for this demo client flow.
+// local scoring straightforward for this demo client flow. Servers in
+// server-scoring mode withhold it, and the client waits for their verdicts.
 type questionItem struct {
-	QuestionID    string        `json:"question_id"`
-	Question      string        `json:"question"`
-	Options       []quiz.Option `json:"options"`
-	CorrectIndex  int           `json:"correct_index"`
-	AttemptStatus string        `json:"attempt_status"`
-	AttemptScore  *float64      `json:"attempt_score,omitempty"`
+	QuestionID     string            `json:"question_id"`
+	Question       string            `json:"question"`
+	Options        []quiz.Option     `json:"options"`
+	Type           quiz.QuestionKind `json:"type,omitempty"`
+	CorrectIndex   int               `json:"correct_index"`
+	CorrectIndexes []int             `json:"correct_indexes,omitempty"`
+	AttemptStatus  string            `json:"attempt_status"`
+	AttemptScore   *float64          `json:"attempt_score,omitempty"`
+	Voided         bool              `json:"voided,omitempty"`
+	ContentHash    string            `json:"content_hash,omitempty"`
+	// Nonce is sent back with the answer on servers that issue nonces.
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// question returns item with its answer key, for scoring locally.
+func (item questionItem) question() quiz.Question {
+	return quiz.Question{
+		PublicQuestion: quiz.PublicQuestion{QuestionID: item.QuestionID, Question: item.Question, Options: item.Options, Type: item.Type},
+		CorrectIndex:   item.CorrectIndex,
+		CorrectIndexes: item.CorrectIndexes,
+	}
 }
 
 const (
@@ -53,13 +60,26 @@ const (
 type questionsResponse struct {
 	QuizID        string         `json:"quiz_id"`
 	QuestionCount int            `json:"question_count"`
+	Locked        bool           `json:"locked"`
+	ClosesAt      string         `json:"closes_at,omitempty"`
 	Questions     []questionItem `json:"questions"`
+
+	// SecondsPerQuestion is the quiz's per-question countdown; zero is untimed.
+	SecondsPerQuestion int `json:"seconds_per_question,omitempty"`
+	Scoring            struct {
+		// ServerScored means correct_index is withheld, so every verdict
+		// comes from the server.
+		ServerScored bool `json:"server_scored,omitempty"`
+	} `json:"scoring"`
 }
 
 type activeQuizItem struct {
 	QuizID        string `json:"quiz_id"`
 	QuestionCount int    `json:"question_count"`
 	CreatedAt     string `json:"created_at"`
+	State         string `json:"state,omitempty"`
+	Locked        bool   `json:"locked"`
+	ClosesAt      string `json:"closes_at,omitempty"`
 }
 
 type activeQuizzesResponse struct {
@@ -78,30 +98,90 @@ type leaderboardResponse struct {
 	Leaderboard []leaderboardEntryResponse `json:"leaderboard"`
 }
 
+type questionSearchResponse struct {
+	Query   string                `json:"query"`
+	Results []quiz.PublicQuestion `json:"results"`
+}
+
 type responsesRequest struct {
 	QuizID    string                   `json:"quiz_id"`
 	Username  string                   `json:"username"`
 	Responses []quiz.SubmittedResponse `json:"responses"`
 }
 
+type responsesResponse struct {
+	Results []quiz.ResponseResult `json:"results"`
+}
+
+type createQuizQuestion struct {
+	Question     string   `json:"question"`
+	Options      []string `json:"options"`
+	CorrectIndex int      `json:"correct_index"`
+}
+
+type createQuizRequest struct {
+	Questions []createQuizQuestion `json:"questions"`
+}
+
+type createQuizResponse struct {
+	QuizID        string   `json:"quiz_id"`
+	QuestionCount int      `json:"question_count"`
+	CreatedAt     string   `json:"created_at"`
+	QuestionIDs   []string `json:"question_ids"`
+	ContentHashes []string `json:"content_hashes,omitempty"`
+}
+
+// ImportLineError is a question the server could not import from a file.
+type ImportLineError = quizclient.ImportLineError
+
+// ImportResult is the server's answer to a question bank import. Quiz is nil
+// for a dry run or when nothing could be imported.
+type ImportResult = quizclient.ImportResult
+
+// CreatedQuestion identifies one question registered by
+// CreateQuizFromQuestions. ContentHash is sent back with its answer.
+type CreatedQuestion struct {
+	QuestionID  string
+	ContentHash string
+}
+
+// UserStats is a player's daily participation streak. Days are dates in the
+// server's Timezone.
+type UserStats struct {
+	Username      string `json:"username"`
+	CurrentStreak int    `json:"current_streak"`
+	LongestStreak int    `json:"longest_streak"`
+	LastActiveDay string `json:"last_active_day,omitempty"`
+	Timezone      string `json:"timezone"`
+}
+
+// UserPreferences are a player's defaults for quizzes they create. Zero
+// fields leave the server's defaults in place.
+type UserPreferences struct {
+	Username      string `json:"username"`
+	QuestionCount int    `json:"question_count,omitempty"`
+	Difficulty    string `json:"difficulty,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+}
+
 type errorResponse struct {
 	Error string `json:"error"`
 }
 
 func NewHTTPClient(baseURL string, httpClient *http.Client) *HTTPClient {
-	baseURL = strings.TrimSpace(baseURL)
-	baseURL = strings.TrimRight(baseURL, "/")
-	if baseURL == "" {
-		baseURL = "http://127.0.0.1:8080"
-	}
-	if httpClient == nil {
-		httpClient = http.DefaultClient
-	}
+	return &HTTPClient{api: quizclient.New(baseURL, quizclient.WithHTTPClient(httpClient))}
+}
 
-	return &HTTPClient{
-		baseURL:    baseURL,
-		httpClient: httpClient,
-	}
+// SetPlayerToken sets the token from verifying the player's identity. Empty
+// sends no token.
+func (c *HTTPClient) SetPlayerToken(token string) {
+	c.api = c.api.With(quizclient.WithPlayerToken(token))
+}
+
+// SetAccountToken sets the token from logging in to the player's account.
+// Empty sends no token.
+func (c *HTTPClient) SetAccountToken(token string) {
+	c.api = c.api.With(quizclient.WithAccountToken(token))
 }
 
 func (c *HTTPClient) ListActiveQuizzes(ctx context.Context, limit int) ([]quiz.QuizMetadata, error) {
@@ -123,11 +203,19 @@ func (c *HTTPClient) ListActiveQuizzes(ctx context.Context, limit int) ([]quiz.Q
 		if err != nil {
 			return nil, err
 		}
-		quizzes = append(quizzes, quiz.QuizMetadata{
+		metadata := quiz.QuizMetadata{
 			QuizID:        item.QuizID,
 			QuestionCount: item.QuestionCount,
 			CreatedAt:     createdAt,
-		})
+			Locked:        item.Locked,
+			Draft:         item.State == string(quiz.QuizStateDraft),
+		}
+		if item.ClosesAt != "" {
+			if metadata.ClosesAt, err = parseTime(item.ClosesAt); err != nil {
+				return nil, err
+			}
+		}
+		quizzes = append(quizzes, metadata)
 	}
 
 	return quizzes, nil
@@ -164,6 +252,24 @@ func (c *HTTPClient) GetLeaderboard(ctx context.Context, quizID string, limit in
 	return entries, nil
 }
 
+// GetDailyQuiz returns today's daily quiz, which the server creates on first use.
+func (c *HTTPClient) GetDailyQuiz(ctx context.Context) (quiz.QuizMetadata, error) {
+	var payload createQuizResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/quizzes/daily", nil, &payload); err != nil {
+		return quiz.QuizMetadata{}, err
+	}
+
+	createdAt, err := parseTime(payload.CreatedAt)
+	if err != nil {
+		return quiz.QuizMetadata{}, err
+	}
+	return quiz.QuizMetadata{
+		QuizID:        payload.QuizID,
+		QuestionCount: payload.QuestionCount,
+		CreatedAt:     createdAt,
+	}, nil
+}
+
 func (c *HTTPClient) GetQuizQuestions(ctx context.Context, quizID, username string, createIfMissing bool, questionCount int) (questionsResponse, error) {
 	if strings.TrimSpace(quizID) == "" {
 		return questionsResponse{}, errors.New("quiz_id is required")
@@ -189,71 +295,175 @@ func (c *HTTPClient) GetQuizQuestions(ctx context.Context, quizID, username stri
 	return payload, nil
 }
 
-func (c *HTTPClient) PersistSingleResponse(ctx context.Context, quizID, username, questionID, answer string) error {
-	request := responsesRequest{
-		QuizID:   quizID,
-		Username: username,
-		Responses: []quiz.SubmittedResponse{
-			{
-				QuestionID: questionID,
-				Answer:     answer,
-			},
-		},
+func (c *HTTPClient) SearchQuestions(ctx context.Context, text string, limit int) ([]quiz.PublicQuestion, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("search text is required")
+	}
+
+	query := url.Values{}
+	query.Set("q", text)
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
 	}
 
-	return c.doJSON(ctx, http.MethodPost, "/responses", request, nil)
+	var payload questionSearchResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/questions/search?"+query.Encode(), nil, &payload); err != nil {
+		return nil, err
+	}
+	return payload.Results, nil
 }
 
-func parseTime(value string) (time.Time, error) {
-	parsed, err := time.Parse(time.RFC3339, value)
-	if err == nil {
-		return parsed.UTC(), nil
+// PersistSingleResponse stores one answer and returns the server's result for
+// it. The result is zero if the server sent none.
+func (c *HTTPClient) PersistSingleResponse(ctx context.Context, quizID, username string, response quiz.SubmittedResponse) (quiz.ResponseResult, error) {
+	results, err := c.SubmitResponses(ctx, quizID, username, []quiz.SubmittedResponse{response})
+	if err != nil || len(results) == 0 {
+		return quiz.ResponseResult{}, err
 	}
-	return time.Time{}, err
+	return results[0], nil
 }
 
-func (c *HTTPClient) doJSON(ctx context.Context, method, path string, requestBody any, responseBody any) error {
-	fullURL := c.baseURL + path
+// CreateQuizFromQuestions registers already-built questions as a new quiz on the
+// server. The returned questions are in the same order as questions.
+func (c *HTTPClient) CreateQuizFromQuestions(ctx context.Context, questions []quiz.Question) (quiz.QuizMetadata, []CreatedQuestion, error) {
+	if len(questions) == 0 {
+		return quiz.QuizMetadata{}, nil, errors.New("at least one question is required")
+	}
 
-	var body io.Reader
-	if requestBody != nil {
-		encoded, err := json.Marshal(requestBody)
-		if err != nil {
-			return err
+	request := createQuizRequest{Questions: make([]createQuizQuestion, 0, len(questions))}
+	for _, question := range questions {
+		options := make([]string, 0, len(question.Options))
+		for _, option := range question.Options {
+			options = append(options, option.Text)
 		}
-		body = bytes.NewReader(encoded)
+		request.Questions = append(request.Questions, createQuizQuestion{
+			Question:     question.Question,
+			Options:      options,
+			CorrectIndex: question.CorrectIndex,
+		})
 	}
 
-	request, err := http.NewRequestWithContext(ctx, method, fullURL, body)
-	if err != nil {
-		return err
+	var payload createQuizResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/quizzes", request, &payload); err != nil {
+		return quiz.QuizMetadata{}, nil, err
 	}
-	if requestBody != nil {
-		request.Header.Set("Content-Type", "application/json")
+	if len(payload.QuestionIDs) != len(questions) {
+		return quiz.QuizMetadata{}, nil, fmt.Errorf("server returned %d question ids for %d questions", len(payload.QuestionIDs), len(questions))
 	}
 
-	response, err := c.httpClient.Do(request)
+	createdAt, err := parseTime(payload.CreatedAt)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrServiceUnavailable, err)
+		return quiz.QuizMetadata{}, nil, err
 	}
-	defer response.Body.Close()
-
-	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
-		apiErr := APIError{StatusCode: response.StatusCode}
-		// Prefer server-provided error text when available so CLI feedback matches
-		// handler-level validation/reasoning.
-		var payload errorResponse
-		if err := json.NewDecoder(response.Body).Decode(&payload); err == nil && strings.TrimSpace(payload.Error) != "" {
-			apiErr.Message = payload.Error
-		}
-		if apiErr.Message == "" {
-			apiErr.Message = response.Status
+	metadata := quiz.QuizMetadata{
+		QuizID:        payload.QuizID,
+		QuestionCount: payload.QuestionCount,
+		CreatedAt:     createdAt,
+	}
+	created := make([]CreatedQuestion, 0, len(payload.QuestionIDs))
+	for idx, questionID := range payload.QuestionIDs {
+		item := CreatedQuestion{QuestionID: questionID}
+		// Older servers send no hashes; answers without one are still scored
+		// unless the server requires them.
+		if idx < len(payload.ContentHashes) {
+			item.ContentHash = payload.ContentHashes[idx]
 		}
-		return &apiErr
+		created = append(created, item)
+	}
+	return metadata, created, nil
+}
+
+// ImportQuestions uploads a question bank file in Aiken, GIFT, or Moodle XML
+// and creates a quiz from it; the server detects the format when format is
+// empty. The result is returned with failed imports too, so the per-line
+// errors can be shown alongside the *APIError.
+func (c *HTTPClient) ImportQuestions(ctx context.Context, format string, data []byte) (ImportResult, error) {
+	return c.ImportQuestionsWithOptions(ctx, data, quizclient.ImportOptions{Format: format})
+}
+
+// ImportQuestionsWithOptions is ImportQuestions with every import option,
+// such as a dry run that only previews the file.
+func (c *HTTPClient) ImportQuestionsWithOptions(ctx context.Context, data []byte, options quizclient.ImportOptions) (ImportResult, error) {
+	return c.api.ImportQuestions(ctx, data, options)
+}
+
+// CreateQuiz creates a quiz from fetched or supplied questions, with the
+// settings the creation request allows.
+func (c *HTTPClient) CreateQuiz(ctx context.Context, request quizclient.CreateQuizRequest) (quizclient.CreatedQuiz, error) {
+	return c.api.CreateQuiz(ctx, request)
+}
+
+// SubmitResponses persists a batch of answers for username in one request.
+func (c *HTTPClient) SubmitResponses(ctx context.Context, quizID, username string, responses []quiz.SubmittedResponse) ([]quiz.ResponseResult, error) {
+	request := responsesRequest{
+		QuizID:    quizID,
+		Username:  username,
+		Responses: responses,
+	}
+
+	var payload responsesResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/responses", request, &payload); err != nil {
+		return nil, err
+	}
+	return payload.Results, nil
+}
+
+// GetUserStats returns username's participation streak.
+func (c *HTTPClient) GetUserStats(ctx context.Context, username string) (UserStats, error) {
+	var payload UserStats
+	if err := c.doJSON(ctx, http.MethodGet, "/users/"+url.PathEscape(strings.TrimSpace(username))+"/stats", nil, &payload); err != nil {
+		return UserStats{}, err
+	}
+	return payload, nil
+}
+
+// GetPreferences returns username's quiz defaults.
+func (c *HTTPClient) GetPreferences(ctx context.Context, username string) (UserPreferences, error) {
+	var payload UserPreferences
+	if err := c.doJSON(ctx, http.MethodGet, "/users/"+url.PathEscape(strings.TrimSpace(username))+"/preferences", nil, &payload); err != nil {
+		return UserPreferences{}, err
 	}
+	return payload, nil
+}
+
+// SetPreferences replaces username's quiz defaults with preferences' count
+// and difficulty and returns what the server saved.
+func (c *HTTPClient) SetPreferences(ctx context.Context, username string, preferences UserPreferences) (UserPreferences, error) {
+	request := struct {
+		QuestionCount int    `json:"question_count"`
+		Difficulty    string `json:"difficulty"`
+	}{QuestionCount: preferences.QuestionCount, Difficulty: preferences.Difficulty}
+
+	var payload UserPreferences
+	if err := c.doJSON(ctx, http.MethodPut, "/users/"+url.PathEscape(strings.TrimSpace(username))+"/preferences", request, &payload); err != nil {
+		return UserPreferences{}, err
+	}
+	return payload, nil
+}
 
-	if responseBody == nil {
-		return nil
+// GetAttemptReview returns username's answers in quizID next to each
+// question.
+func (c *HTTPClient) GetAttemptReview(ctx context.Context, quizID, username string) (quizclient.AttemptReview, error) {
+	return c.api.GetAttemptReview(ctx, quizID, username)
+}
+
+// RegisterSigningKey registers publicKey, a base64 Ed25519 public key, as one
+// username's client signs queued answers with.
+func (c *HTTPClient) RegisterSigningKey(ctx context.Context, username, publicKey string) error {
+	request := struct {
+		PublicKey string `json:"public_key"`
+	}{PublicKey: publicKey}
+	return c.doJSON(ctx, http.MethodPost, "/users/"+url.PathEscape(strings.TrimSpace(username))+"/signing-keys", request, nil)
+}
+
+func parseTime(value string) (time.Time, error) {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return parsed.UTC(), nil
 	}
-	return json.NewDecoder(response.Body).Decode(responseBody)
+	return time.Time{}, err
+}
+
+func (c *HTTPClient) doJSON(ctx context.Context, method, path string, requestBody any, responseBody any) error {
+	return c.api.Do(ctx, method, path, requestBody, responseBody)
 }
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"quiz-app/internal/quiz"
 )
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -114,3 +117,91 @@ func TestParseTimeInvalid(t *testing.T) {
 		t.Fatalf("expected invalid parse error")
 	}
 }
+
+func TestSearchQuestionsBuildsQueryAndParsesResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/questions/search" {
+			t.Fatalf("path = %q", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("q"); got != "capital of" {
+			t.Fatalf("q query = %q", got)
+		}
+		if got := r.URL.Query().Get("limit"); got != "5" {
+			t.Fatalf("limit query = %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(questionSearchResponse{
+			Query:   "capital of",
+			Results: []quiz.PublicQuestion{{QuestionID: "q1", Question: "Capital of France?"}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, server.Client())
+	results, err := client.SearchQuestions(context.Background(), "capital of", 5)
+	if err != nil {
+		t.Fatalf("SearchQuestions failed: %v", err)
+	}
+	if len(results) != 1 || results[0].QuestionID != "q1" {
+		t.Fatalf("unexpected search results: %+v", results)
+	}
+}
+
+func TestCreateQuizFromQuestionsSendsOptionTexts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/quizzes" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var request createQuizRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if len(request.Questions) != 1 || request.Questions[0].Options[1] != "5" || request.Questions[0].CorrectIndex != 1 {
+			t.Errorf("unexpected request body: %+v", request)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"quiz_id":"qz_1","question_count":1,"created_at":"2026-03-02T00:00:00Z","question_ids":["q_1"]}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, server.Client())
+	question := quiz.Question{
+		PublicQuestion: quiz.PublicQuestion{
+			Question: "2 + 3?",
+			Options:  []quiz.Option{{Letter: "A", Text: "4"}, {Letter: "B", Text: "5"}},
+		},
+		CorrectIndex: 1,
+	}
+
+	metadata, created, err := client.CreateQuizFromQuestions(context.Background(), []quiz.Question{question})
+	if err != nil {
+		t.Fatalf("CreateQuizFromQuestions failed: %v", err)
+	}
+	if metadata.QuizID != "qz_1" || len(created) != 1 || created[0].QuestionID != "q_1" {
+		t.Fatalf("CreateQuizFromQuestions = (%+v, %v), want qz_1 with [q_1]", metadata, created)
+	}
+}
+
+func TestImportQuestionsKeepsLineErrorsOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/quizzes/import" || r.URL.Query().Get("format") != "aiken" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "Broken?\nA. yes\n" {
+			t.Errorf("body = %q, want the file as sent", body)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"no questions could be imported","format":"aiken","question_count":0,"errors":[{"line":1,"error":"ANSWER line is missing"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, server.Client())
+	result, err := client.ImportQuestions(context.Background(), "aiken", []byte("Broken?\nA. yes\n"))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "no questions could be imported" {
+		t.Fatalf("ImportQuestions error = (%v), want the server's 400", err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 1 || result.Quiz != nil {
+		t.Fatalf("ImportQuestions result = %+v, want one line error and no quiz", result)
+	}
+}
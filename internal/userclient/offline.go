@@ -0,0 +1,294 @@
+package userclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+// DefaultOfflineQueuePath is where answers wait for the server to come back:
+// offline.jsonl in the user's config directory. It is empty when the platform
+// has no config directory.
+func DefaultOfflineQueuePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "quiz-user-service", "offline.jsonl")
+}
+
+// DefaultSigningKeyPath is where the client keeps the private key it signs
+// queued answers with: signing.key in the user's config directory.
+func DefaultSigningKeyPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "quiz-user-service", "signing.key")
+}
+
+// pendingAnswer is one line of the offline queue: a signed answer that could
+// not be sent when it was chosen.
+type pendingAnswer struct {
+	QuizID   string                 `json:"quiz_id"`
+	Username string                 `json:"username"`
+	Response quiz.SubmittedResponse `json:"response"`
+}
+
+// offlineQueue keeps answers the server could not be reached for, each signed
+// with the client's key when it was chosen, until the sync command sends them.
+// The server accepts them late because the signature shows when they were
+// made. A nil queue queues nothing.
+type offlineQueue struct {
+	path string
+	key  ed25519.PrivateKey
+	mu   sync.Mutex
+}
+
+// newOfflineQueue loads the signing key at keyPath, creating one on first use.
+// Either path empty turns queuing off.
+func newOfflineQueue(path, keyPath string) (*offlineQueue, error) {
+	if path == "" || keyPath == "" {
+		return nil, nil
+	}
+	key, err := loadSigningKey(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", keyPath, err)
+	}
+	return &offlineQueue{path: path, key: key}, nil
+}
+
+// loadSigningKey reads a base64 Ed25519 seed from path, or writes a new one
+// readable only by the user.
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(key.Seed()) + "\n"
+		if err := os.WriteFile(path, []byte(encoded), 0o600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("not a base64 Ed25519 seed")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// publicKey is the queue's key as the server registers it.
+func (q *offlineQueue) publicKey() string {
+	return base64.StdEncoding.EncodeToString(q.key.Public().(ed25519.PublicKey))
+}
+
+// register tells the server about the signing key. Answers are only accepted
+// if they were signed after the key was registered, so sessions register it
+// while still online; registering again is harmless.
+func (q *offlineQueue) register(ctx context.Context, client *HTTPClient, username string) error {
+	if q == nil {
+		return nil
+	}
+	return client.RegisterSigningKey(ctx, username, q.publicKey())
+}
+
+// openOfflineQueue opens cfg's offline queue for username's session and
+// registers its key. A server that rejects the key outright cannot check
+// signed answers, so queuing is turned off rather than collecting answers it
+// would refuse; an unreachable server leaves queuing on.
+func openOfflineQueue(ctx context.Context, cfg Config, client *HTTPClient, username string) (*offlineQueue, error) {
+	queue, err := newOfflineQueue(cfg.OfflineQueue, cfg.SigningKey)
+	if err != nil || queue == nil {
+		return nil, err
+	}
+	if err := queue.register(ctx, client, username); err != nil {
+		if retryable, _ := retryAdvice(err); !retryable {
+			return nil, nil
+		}
+	}
+	return queue, nil
+}
+
+// add signs response as chosen at answeredAt and appends it to the queue.
+func (q *offlineQueue) add(quizID, username string, response quiz.SubmittedResponse, answeredAt time.Time) error {
+	if q == nil {
+		return errors.New("offline queuing is turned off")
+	}
+	line, err := json.Marshal(pendingAnswer{
+		QuizID:   quizID,
+		Username: username,
+		Response: quiz.SignAnswer(q.key, quizID, username, response, answeredAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// drain hands every queued answer to send, grouped by quiz and username in
+// queue order, and keeps only the groups send reports as not delivered.
+func (q *offlineQueue) drain(send func(quizID, username string, answers []pendingAnswer) (delivered bool)) (sent, kept int, err error) {
+	if q == nil {
+		return 0, 0, errors.New("offline queuing is turned off")
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending, err := readPending(q.path)
+	if err != nil {
+		return 0, 0, err
+	}
+	type batchKey struct{ quizID, username string }
+	var order []batchKey
+	batches := make(map[batchKey][]pendingAnswer)
+	for _, answer := range pending {
+		key := batchKey{answer.QuizID, answer.Username}
+		if _, ok := batches[key]; !ok {
+			order = append(order, key)
+		}
+		batches[key] = append(batches[key], answer)
+	}
+
+	var remaining []pendingAnswer
+	for _, key := range order {
+		if send(key.quizID, key.username, batches[key]) {
+			sent += len(batches[key])
+			continue
+		}
+		remaining = append(remaining, batches[key]...)
+	}
+	return sent, len(remaining), writePending(q.path, remaining)
+}
+
+// readPending reads the queue file. Lines that do not parse, such as one cut
+// short by a crash, are skipped.
+func readPending(path string) ([]pendingAnswer, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var pending []pendingAnswer
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var answer pendingAnswer
+		if err := json.Unmarshal(scanner.Bytes(), &answer); err != nil {
+			continue
+		}
+		pending = append(pending, answer)
+	}
+	return pending, scanner.Err()
+}
+
+// writePending replaces the queue file with pending through a rename, so a
+// crash mid-write leaves the old queue rather than a partial one.
+func writePending(path string, pending []pendingAnswer) error {
+	if len(pending) == 0 {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	temp := path + ".tmp"
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, answer := range pending {
+		if err := encoder.Encode(answer); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// runSync sends queued answers. A batch leaves the queue once the server has
+// ruled on it, whatever the ruling; batches the server could not be reached
+// for stay queued. Each result goes to the submission log.
+func runSync(ctx context.Context, out io.Writer, client *HTTPClient, queue *offlineQueue, log *submissionLog, serverURL string) error {
+	var lastErr error
+	sent, kept, err := queue.drain(func(quizID, username string, answers []pendingAnswer) bool {
+		responses := make([]quiz.SubmittedResponse, 0, len(answers))
+		for _, answer := range answers {
+			responses = append(responses, answer.Response)
+		}
+		results, err := client.SubmitResponses(ctx, quizID, username, responses)
+		if retryable, _ := retryAdvice(err); err != nil && retryable {
+			lastErr = err
+			return false
+		}
+		for idx, response := range responses {
+			record := submissionRecord{At: time.Now().UTC(), QuizID: quizID, QuestionID: response.QuestionID, Answer: response.Answer, Tries: 1, Synced: true}
+			switch {
+			case err != nil:
+				record.Error = err.Error()
+			case idx < len(results):
+				record.Status = results[idx].Status
+				record.AttemptScore = results[idx].AttemptScore
+				record.Bonus = results[idx].Bonus
+			}
+			_ = log.append(record)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case sent == 0 && kept == 0:
+		fmt.Fprintln(out, "No queued answers.")
+	case kept == 0:
+		fmt.Fprintf(out, "Synced %d queued answers. Run 'log' to see how the server scored them.\n", sent)
+	default:
+		fmt.Fprintf(out, "Synced %d queued answers; %d are still queued.\n", sent, kept)
+		return describeClientError(lastErr, serverURL)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package userclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+)
+
+// view decides how command results are printed: aligned tables for people, or
+// JSON for scripts when asJSON is set.
+type view struct {
+	asJSON bool
+	style  styler
+}
+
+// styler adds ANSI colors when enabled. It is disabled for JSON output and
+// when stdout is not a terminal.
+type styler struct {
+	enabled bool
+}
+
+func (s styler) bold(text string) string  { return s.wrap(ansiBold, text) }
+func (s styler) red(text string) string   { return s.wrap(ansiRed, text) }
+func (s styler) green(text string) string { return s.wrap(ansiGreen, text) }
+
+func (s styler) wrap(code, text string) string {
+	if !s.enabled || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// colorEnabled reports whether out is a terminal that should get colors.
+// NO_COLOR (https://no-color.org) turns colors off like noColor does.
+func colorEnabled(out io.Writer, noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	file, ok := out.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// splitJSONFlag removes --json from a command's arguments and reports whether
+// it was present, so any listing command can be switched to JSON on its own.
+func splitJSONFlag(args []string) ([]string, bool) {
+	kept := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == "--json" || arg == "-json" {
+			found = true
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept, found
+}
+
+// writeTable prints rows in aligned columns under a bold header. Colors are
+// applied after alignment so escape codes do not count toward column widths.
+func writeTable(out io.Writer, style styler, header []string, rows [][]string) {
+	var buf bytes.Buffer
+	table := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	_ = table.Flush()
+
+	headerLine, rest, _ := strings.Cut(buf.String(), "\n")
+	fmt.Fprintln(out, style.bold(headerLine))
+	fmt.Fprint(out, rest)
+}
+
+func writeJSON(out io.Writer, value any) {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
+
+// printError reports a failed command as {"error": ...} in JSON mode so scripts
+// always get a parseable line.
+func printError(out io.Writer, v view, err error) {
+	if v.asJSON {
+		writeJSON(out, errorResponse{Error: err.Error()})
+		return
+	}
+	fmt.Fprintln(out, v.style.red("error: "+err.Error()))
+}
+
+func formatTime(value time.Time) string {
+	if value.IsZero() {
+		return "-"
+	}
+	return value.Format(time.RFC3339)
+}
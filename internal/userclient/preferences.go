@@ -0,0 +1,93 @@
+package userclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// preferenceChange is what a prefs command asked to change; nil fields keep
+// their saved value.
+type preferenceChange struct {
+	questionCount *int
+	difficulty    *string
+}
+
+func (c preferenceChange) empty() bool {
+	return c.questionCount == nil && c.difficulty == nil
+}
+
+// parsePreferenceArgs reads count=N and difficulty=LEVEL arguments. count=0
+// and difficulty=any clear the preference, so the server default applies.
+func parsePreferenceArgs(args []string) (preferenceChange, error) {
+	var change preferenceChange
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return preferenceChange{}, fmt.Errorf("expected key=value, got %q", arg)
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "count":
+			count, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || count < 0 {
+				return preferenceChange{}, fmt.Errorf("count must be a non-negative integer, got %q", value)
+			}
+			change.questionCount = &count
+		case "difficulty":
+			difficulty := strings.ToLower(strings.TrimSpace(value))
+			switch difficulty {
+			case "any":
+				difficulty = ""
+			case "easy", "medium", "hard":
+			default:
+				return preferenceChange{}, fmt.Errorf("difficulty must be easy, medium, hard, or any, got %q", value)
+			}
+			change.difficulty = &difficulty
+		default:
+			return preferenceChange{}, fmt.Errorf("unknown preference %q (want count or difficulty)", key)
+		}
+	}
+	return change, nil
+}
+
+// runPreferences shows username's quiz defaults, first saving change if it
+// asks for any.
+func runPreferences(ctx context.Context, out io.Writer, v view, client *HTTPClient, username string, change preferenceChange, serverURL string) error {
+	var preferences UserPreferences
+	err := withRetry(ctx, func() (err error) {
+		preferences, err = client.GetPreferences(ctx, username)
+		return err
+	})
+	if err != nil {
+		return describeClientError(err, serverURL)
+	}
+
+	if !change.empty() {
+		if change.questionCount != nil {
+			preferences.QuestionCount = *change.questionCount
+		}
+		if change.difficulty != nil {
+			preferences.Difficulty = *change.difficulty
+		}
+		preferences, err = client.SetPreferences(ctx, username, preferences)
+		if err != nil {
+			return describeClientError(err, serverURL)
+		}
+	}
+
+	if v.asJSON {
+		writeJSON(out, preferences)
+		return nil
+	}
+	count, difficulty := "server default", "any"
+	if preferences.QuestionCount > 0 {
+		count = strconv.Itoa(preferences.QuestionCount)
+	}
+	if preferences.Difficulty != "" {
+		difficulty = preferences.Difficulty
+	}
+	fmt.Fprintf(out, "Quiz defaults for %s: %s questions, %s difficulty.\n", preferences.Username, count, difficulty)
+	return nil
+}
@@ -0,0 +1,232 @@
+package userclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Profile is one named server in the config file, with the credentials and
+// defaults to use against it. Empty fields leave the setting as it is.
+type Profile struct {
+	Server       string `json:"server"`
+	Username     string `json:"username,omitempty"`
+	PlayerToken  string `json:"player_token,omitempty"`
+	AccountToken string `json:"account_token,omitempty"`
+	// Timeout is the HTTP timeout as a Go duration, such as "10s".
+	Timeout          string `json:"timeout,omitempty"`
+	ListLimit        int    `json:"list_limit,omitempty"`
+	LeaderboardLimit int    `json:"leaderboard_limit,omitempty"`
+}
+
+// Profiles is the config file: profiles by name, and the one to start with
+// when --profile is not given.
+//
+//	{
+//	  "default": "work",
+//	  "profiles": {
+//	    "work":  {"server": "https://quiz.example.com", "username": "alice", "player_token": "..."},
+//	    "local": {"server": "http://127.0.0.1:8080", "timeout": "2s"}
+//	  }
+//	}
+type Profiles struct {
+	Default  string             `json:"default,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+type profileItem struct {
+	Name     string `json:"name"`
+	Server   string `json:"server"`
+	Username string `json:"username,omitempty"`
+	Current  bool   `json:"current"`
+}
+
+type profilesResponse struct {
+	Profiles []profileItem `json:"profiles"`
+}
+
+// DefaultProfilesPath is where the client looks for its config file unless
+// told otherwise: profiles.json in the user's config directory. It is empty
+// when the platform has no config directory.
+func DefaultProfilesPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "quiz-user-service", "profiles.json")
+}
+
+// LoadProfiles reads the config file at path. A missing file, or an empty
+// path, means no profiles. Names are matched case-insensitively.
+func LoadProfiles(path string) (Profiles, error) {
+	if path == "" {
+		return Profiles{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Profiles{}, nil
+	}
+	if err != nil {
+		return Profiles{}, err
+	}
+	var file Profiles
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Profiles{}, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return file.normalize(path)
+}
+
+func (p Profiles) normalize(path string) (Profiles, error) {
+	normalized := Profiles{
+		Default:  strings.ToLower(strings.TrimSpace(p.Default)),
+		Profiles: make(map[string]Profile, len(p.Profiles)),
+	}
+	for name, profile := range p.Profiles {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			return Profiles{}, fmt.Errorf("config file %s: a profile has no name", path)
+		}
+		if _, taken := normalized.Profiles[key]; taken {
+			return Profiles{}, fmt.Errorf("config file %s: profile %q is defined twice", path, key)
+		}
+		profile.Server = strings.TrimSpace(profile.Server)
+		if profile.Server == "" {
+			return Profiles{}, fmt.Errorf("config file %s: profile %q has no server", path, key)
+		}
+		if profile.Timeout != "" {
+			if timeout, err := time.ParseDuration(profile.Timeout); err != nil || timeout <= 0 {
+				return Profiles{}, fmt.Errorf("config file %s: profile %q timeout must be a positive duration such as 10s, got %q", path, key, profile.Timeout)
+			}
+		}
+		if profile.ListLimit < 0 || profile.LeaderboardLimit < 0 {
+			return Profiles{}, fmt.Errorf("config file %s: profile %q limits must not be negative", path, key)
+		}
+		normalized.Profiles[key] = profile
+	}
+	if normalized.Default != "" {
+		if _, ok := normalized.Profiles[normalized.Default]; !ok {
+			return Profiles{}, fmt.Errorf("config file %s: default profile %q is not defined", path, normalized.Default)
+		}
+	}
+	return normalized, nil
+}
+
+func (p Profiles) names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookup finds the named profile, or the default one for an empty name. The
+// returned name is empty when neither was asked for.
+func (p Profiles) lookup(name string) (string, Profile, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = p.Default
+	}
+	if name == "" {
+		return "", Profile{}, nil
+	}
+	profile, ok := p.Profiles[name]
+	if !ok {
+		defined := "none are defined"
+		if len(p.Profiles) > 0 {
+			defined = "have " + strings.Join(p.names(), ", ")
+		}
+		return "", Profile{}, fmt.Errorf("unknown profile %q (%s)", name, defined)
+	}
+	return name, profile, nil
+}
+
+// ResolveProfile fills the settings cfg leaves unset from the profile it
+// selects, so flags given on the command line win over the config file.
+func (cfg Config) ResolveProfile() (Config, error) {
+	name, profile, err := cfg.Profiles.lookup(cfg.Profile)
+	if err != nil || name == "" {
+		return cfg, err
+	}
+	cfg = cfg.withProfile(profile, false)
+	cfg.Profile = name
+	return cfg, nil
+}
+
+// switchProfile is what the use command switches to: cfg, as given before any
+// profile was applied, with every setting the named profile has replaced.
+func (cfg Config) switchProfile(name string) (Config, error) {
+	name, profile, err := cfg.Profiles.lookup(name)
+	if err != nil {
+		return Config{}, err
+	}
+	if name == "" {
+		return Config{}, errors.New("a profile name is required")
+	}
+	next := cfg.withProfile(profile, true).withDefaults()
+	next.Profile = name
+	if next.Username == "" {
+		return Config{}, fmt.Errorf("profile %q has no username and none was given with --username", name)
+	}
+	return next, nil
+}
+
+// withProfile copies profile's settings into cfg: all of them when override
+// is set, otherwise only those cfg leaves unset.
+func (cfg Config) withProfile(profile Profile, override bool) Config {
+	setString := func(current *string, value string) {
+		if value != "" && (override || strings.TrimSpace(*current) == "") {
+			*current = value
+		}
+	}
+	setInt := func(current *int, value int) {
+		if value != 0 && (override || *current == 0) {
+			*current = value
+		}
+	}
+	setString(&cfg.ServerURL, profile.Server)
+	setString(&cfg.Username, profile.Username)
+	setString(&cfg.PlayerToken, profile.PlayerToken)
+	setString(&cfg.AccountToken, profile.AccountToken)
+	setInt(&cfg.ListLimit, profile.ListLimit)
+	setInt(&cfg.LeaderboardLimit, profile.LeaderboardLimit)
+	// Validated by LoadProfiles.
+	if timeout, err := time.ParseDuration(profile.Timeout); err == nil && (override || cfg.HTTPTimeout <= 0) {
+		cfg.HTTPTimeout = timeout
+	}
+	return cfg
+}
+
+// runProfiles lists the profiles in the config file, marking the one in use.
+func runProfiles(out io.Writer, v view, cfg Config) {
+	items := make([]profileItem, 0, len(cfg.Profiles.Profiles))
+	for _, name := range cfg.Profiles.names() {
+		profile := cfg.Profiles.Profiles[name]
+		items = append(items, profileItem{Name: name, Server: profile.Server, Username: profile.Username, Current: name == cfg.Profile})
+	}
+
+	if v.asJSON {
+		writeJSON(out, profilesResponse{Profiles: items})
+		return
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(out, "No profiles are defined; add them to the config file (--config).")
+		return
+	}
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		current := ""
+		if item.Current {
+			current = "*"
+		}
+		rows = append(rows, []string{current, item.Name, item.Server, item.Username})
+	}
+	writeTable(out, v.style, []string{"", "PROFILE", "SERVER", "USERNAME"}, rows)
+}
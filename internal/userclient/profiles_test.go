@@ -0,0 +1,129 @@
+package userclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeProfiles(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadProfilesValidatesConfigFile(t *testing.T) {
+	missing, err := LoadProfiles(filepath.Join(t.TempDir(), "absent.json"))
+	if err != nil || len(missing.Profiles) != 0 {
+		t.Fatalf("LoadProfiles(missing) = (%+v, %v), want no profiles", missing, err)
+	}
+
+	profiles, err := LoadProfiles(writeProfiles(t, `{"default":"Work","profiles":{
+		"Work":  {"server":"https://quiz.example.com","username":"alice","timeout":"10s"},
+		"local": {"server":"http://127.0.0.1:8080"}
+	}}`))
+	if err != nil {
+		t.Fatalf("LoadProfiles failed: %v", err)
+	}
+	if profiles.Default != "work" || profiles.Profiles["work"].Username != "alice" || len(profiles.Profiles) != 2 {
+		t.Fatalf("profiles = %+v, want work and local with work the default", profiles)
+	}
+
+	for _, contents := range []string{
+		`{"profiles":{"work":{"username":"alice"}}}`,
+		`{"profiles":{"work":{"server":"http://a","timeout":"soon"}}}`,
+		`{"profiles":{"work":{"server":"http://a","list_limit":-1}}}`,
+		`{"default":"home","profiles":{"work":{"server":"http://a"}}}`,
+		`{"profiles":{"work":{"server":"http://a"},"WORK":{"server":"http://b"}}}`,
+		`{"profiles":`,
+	} {
+		if _, err := LoadProfiles(writeProfiles(t, contents)); err == nil {
+			t.Fatalf("LoadProfiles(%s) succeeded, want an error", contents)
+		}
+	}
+}
+
+func TestResolveProfileKeepsExplicitSettings(t *testing.T) {
+	profiles := Profiles{Default: "work", Profiles: map[string]Profile{
+		"work":    {Server: "https://work.example.com", Username: "alice", PlayerToken: "tok", Timeout: "10s", ListLimit: 25},
+		"friends": {Server: "https://friends.example.com"},
+	}}
+
+	cfg, err := Config{Profiles: profiles}.ResolveProfile()
+	if err != nil {
+		t.Fatalf("ResolveProfile failed: %v", err)
+	}
+	if cfg.Profile != "work" || cfg.ServerURL != "https://work.example.com" || cfg.Username != "alice" || cfg.PlayerToken != "tok" || cfg.HTTPTimeout != 10*time.Second || cfg.ListLimit != 25 {
+		t.Fatalf("default profile = %+v, want every work setting", cfg)
+	}
+
+	cfg, err = Config{Profiles: profiles, Profile: "work", Username: "bob", HTTPTimeout: time.Second}.ResolveProfile()
+	if err != nil || cfg.Username != "bob" || cfg.HTTPTimeout != time.Second || cfg.ServerURL != "https://work.example.com" {
+		t.Fatalf("ResolveProfile with flags = (%+v, %v), want the flags to win", cfg, err)
+	}
+
+	if _, err := (Config{Profiles: profiles, Profile: "home"}).ResolveProfile(); err == nil || !strings.Contains(err.Error(), "friends, work") {
+		t.Fatalf("unknown profile error = %v, want the defined names", err)
+	}
+	if cfg, err := (Config{ServerURL: "http://a"}).ResolveProfile(); err != nil || cfg.ServerURL != "http://a" || cfg.Profile != "" {
+		t.Fatalf("ResolveProfile without profiles = (%+v, %v), want cfg unchanged", cfg, err)
+	}
+	if err := Exec(context.Background(), strings.NewReader(""), &bytes.Buffer{}, Config{Profile: "home"}, nil, []string{"quizzes"}); !errors.Is(err, ErrUsage) {
+		t.Fatalf("Exec with an unknown profile error = %v, want ErrUsage", err)
+	}
+}
+
+func TestRunUseSwitchesServerAndUser(t *testing.T) {
+	var hits []string
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name+" "+r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"username":"x","plays":[],"quizzes":[]}`))
+		}))
+	}
+	work, friends := newServer("work"), newServer("friends")
+	defer work.Close()
+	defer friends.Close()
+
+	cfg := Config{
+		Profiles: Profiles{Default: "work", Profiles: map[string]Profile{
+			"work":    {Server: work.URL, Username: "alice"},
+			"friends": {Server: friends.URL, Username: "al"},
+			"nobody":  {Server: friends.URL},
+		}},
+	}
+	var out bytes.Buffer
+	input := "quizzes\nuse\nuse nobody\nuse friends\nquizzes\nuse home\n"
+	if err := Run(context.Background(), strings.NewReader(input), &out, cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	text := out.String()
+	for _, want := range []string{
+		"profile=work",
+		`profile "nobody" has no username`,
+		"Using profile friends: username=al server=" + friends.URL,
+		`unknown profile "home"`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("output = %q, want %q", text, want)
+		}
+	}
+	if !strings.Contains(text, "* ") || !strings.Contains(text, "nobody") {
+		t.Fatalf("use without arguments = %q, want the profiles with work marked", text)
+	}
+	if len(hits) != 2 || hits[0] != "work /quizzes/active" || hits[1] != "friends /quizzes/active" {
+		t.Fatalf("requests = %v, want one to each server", hits)
+	}
+}
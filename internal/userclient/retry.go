@@ -0,0 +1,54 @@
+package userclient
+
+import (
+	"context"
+	"time"
+
+	"quiz-app/pkg/quizclient"
+)
+
+const (
+	// commandAttempts is how many times a command sends a read before showing
+	// the error. Only retryable errors are sent again.
+	commandAttempts = 3
+	// maxRetryAfter is the longest server-requested wait a command sits
+	// through; a longer Retry-After is shown to the user instead.
+	maxRetryAfter = 5 * time.Second
+)
+
+// commandRetryDelay is the wait between command attempts when the server names
+// none, multiplied by the number of tries so far.
+var commandRetryDelay = 500 * time.Millisecond
+
+// retryAdvice reports whether err is worth sending again, and after how long
+// when the server said; see quizclient.RetryAdvice.
+func retryAdvice(err error) (retryable bool, after time.Duration) {
+	return quizclient.RetryAdvice(err)
+}
+
+// withRetry runs call up to commandAttempts times while it fails with a
+// retryable error, waiting as the server asks or backing off linearly. Only
+// idempotent requests go through it: a retried write could apply twice.
+func withRetry(ctx context.Context, call func() error) error {
+	for tries := 1; ; tries++ {
+		err := call()
+		if err == nil || tries >= commandAttempts {
+			return err
+		}
+		retryable, wait := retryAdvice(err)
+		if !retryable || wait > maxRetryAfter {
+			return err
+		}
+		if wait == 0 {
+			wait = commandRetryDelay * time.Duration(tries)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
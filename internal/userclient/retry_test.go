@@ -0,0 +1,75 @@
+package userclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewAPIErrorCarriesRetryAdvice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/busy" {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"question provider is busy; try again shortly"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid limit"}`))
+	}))
+	defer server.Close()
+	client := NewHTTPClient(server.URL, server.Client())
+
+	var apiErr *APIError
+	err := client.doJSON(context.Background(), http.MethodGet, "/busy", nil, nil)
+	if !errors.As(err, &apiErr) || !apiErr.Retryable || apiErr.RetryAfter != 2*time.Second {
+		t.Fatalf("busy error = (%+v), want retryable after 2s", err)
+	}
+	err = client.doJSON(context.Background(), http.MethodGet, "/bad", nil, nil)
+	if !errors.As(err, &apiErr) || apiErr.Retryable || apiErr.Message != "invalid limit" {
+		t.Fatalf("bad request error = (%+v), want a final rejection", err)
+	}
+
+}
+
+func TestCommandsRetryRetryableErrorsAFewTimes(t *testing.T) {
+	previous := commandRetryDelay
+	commandRetryDelay = 0
+	defer func() { commandRetryDelay = previous }()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/questions/search":
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"error":"upstream down"}`))
+		case calls.Add(1) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(`{"quizzes":[{"quiz_id":"quiz-1","question_count":5,"created_at":"2026-03-02T00:00:00Z"}]}`))
+		}
+	}))
+	defer server.Close()
+	client := NewHTTPClient(server.URL, server.Client())
+
+	var out bytes.Buffer
+	if err := runList(context.Background(), &out, view{}, client, 10, server.URL); err != nil {
+		t.Fatalf("runList failed: %v", err)
+	}
+	if calls.Load() != 2 || !strings.Contains(out.String(), "quiz-1") {
+		t.Fatalf("runList = (%d calls, %q), want success on the second try", calls.Load(), out.String())
+	}
+
+	calls.Store(0)
+	err := runSearch(context.Background(), &out, view{}, client, "capital", 10, server.URL)
+	if err == nil || err.Error() != "upstream down" || calls.Load() != commandAttempts {
+		t.Fatalf("runSearch = (%v after %d calls), want the error after %d tries", err, calls.Load(), commandAttempts)
+	}
+}
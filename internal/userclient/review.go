@@ -0,0 +1,84 @@
+package userclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"quiz-app/internal/quiz"
+	"quiz-app/pkg/quizclient"
+)
+
+// runReview shows username's answers in quizID next to each question, with
+// the correct answers once the server reveals them.
+func runReview(ctx context.Context, out io.Writer, v view, client *HTTPClient, username, quizID, serverURL string) error {
+	var review quizclient.AttemptReview
+	err := withRetry(ctx, func() (err error) {
+		review, err = client.GetAttemptReview(ctx, quizID, username)
+		return err
+	})
+	if err != nil {
+		return describeClientError(err, serverURL)
+	}
+
+	if v.asJSON {
+		writeJSON(out, review)
+		return nil
+	}
+	if review.AnsweredCount == 0 {
+		fmt.Fprintf(out, "%s has not answered quiz %s.\n", review.Username, review.QuizID)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Review of %s for %s:\n", review.QuizID, review.Username)
+	for idx, question := range review.Questions {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "%d. %s\n", idx+1, question.Question)
+		fmt.Fprintf(out, "   You: %s — %s\n", reviewAnswerDisplay(question.Options, question.ChosenLetter), reviewStatus(v.style, question))
+		if question.CorrectLetter != "" && question.Status != quiz.StatusCorrect {
+			fmt.Fprintf(out, "   Answer: %s\n", reviewAnswerDisplay(question.Options, question.CorrectLetter))
+		}
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, v.style.bold(fmt.Sprintf("Score: %s, %d of %d answered", formatScore(review.TotalScore), review.AnsweredCount, review.QuestionCount)))
+	if !review.AnswersRevealed {
+		fmt.Fprintln(out, "Correct answers are shown once you answer every question or the quiz locks.")
+	}
+	return nil
+}
+
+// reviewAnswerDisplay writes an answer's letters, such as "A,C", with their
+// option texts.
+func reviewAnswerDisplay(options []quiz.Option, letters string) string {
+	if letters == "" {
+		return "-"
+	}
+	displays := make([]string, 0, 1)
+	for _, letter := range strings.Split(letters, ",") {
+		display := letter
+		for _, option := range options {
+			if option.Letter == letter {
+				display = fmt.Sprintf("%s. %s", option.Letter, option.Text)
+				break
+			}
+		}
+		displays = append(displays, display)
+	}
+	return strings.Join(displays, "; ")
+}
+
+func reviewStatus(style styler, question quizclient.ReviewedQuestion) string {
+	switch question.Status {
+	case quiz.StatusCorrect:
+		return style.green("correct")
+	case quiz.StatusPartiallyCorrect:
+		return style.bold("partly right, " + formatScore(question.Score) + " points")
+	case quiz.StatusIncorrect:
+		return style.red("wrong")
+	case quiz.StatusVoidedQuestion:
+		return "voided by the host"
+	default:
+		return "not answered"
+	}
+}
@@ -7,15 +7,19 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"quiz-app/internal/quiz"
+	"quiz-app/pkg/quizkit"
 )
 
 const (
 	defaultServer            = "http://127.0.0.1:8080"
 	defaultListLimit         = 10
 	defaultLeaderboardLimit  = 10
-	defaultQuestionCount     = 10
 	defaultHTTPTimeout       = 5 * time.Second
 	defaultPersistTimeout    = 2 * time.Second
 	defaultMaxInvalidAnswers = 3
@@ -28,44 +32,131 @@ type Config struct {
 	LeaderboardLimit  int
 	MaxInvalidAnswers int
 	HTTPTimeout       time.Duration
+	// JSON prints quizzes, leaderboard, search, history, and log results as JSON and
+	// drops the banner and prompt so output can be piped into jq.
+	JSON bool
+	// NoColor turns off ANSI colors in human output. Colors are also off when
+	// the output is not a terminal or NO_COLOR is set.
+	NoColor bool
+	// SubmissionLog is the file every sent answer is appended to, with the
+	// server's result. Empty turns the log off.
+	SubmissionLog string
+	// PlayerToken proves the player owns a verified Username. Unverified
+	// usernames need none.
+	PlayerToken string
+	// AccountToken proves the player is logged in as a registered Username.
+	// Unregistered usernames need none unless the server requires login.
+	AccountToken string
+	// OfflineQueue is the file answers wait in when the server cannot be
+	// reached, until the sync command sends them. SigningKey is the file
+	// holding the key they are signed with, created on first use. Either one
+	// empty turns offline queuing off.
+	OfflineQueue string
+	SigningKey   string
+	// Profiles are the named servers from the config file, and Profile names
+	// the one to start with; empty uses Profiles.Default. See ResolveProfile
+	// for how a profile combines with the fields above.
+	Profiles Profiles
+	Profile  string
 }
 
-func Run(ctx context.Context, in io.Reader, out io.Writer, cfg Config) error {
-	username := strings.TrimSpace(cfg.Username)
-	if username == "" {
-		return errors.New("username is required")
-	}
+// playRecord is one finished play in this session, listed by the history command.
+type playRecord struct {
+	QuizID   string    `json:"quiz_id"`
+	Score    float64   `json:"score"`
+	Possible float64   `json:"possible"`
+	Answered int       `json:"answered"`
+	PlayedAt time.Time `json:"played_at"`
+}
 
-	serverURL := strings.TrimSpace(cfg.ServerURL)
-	if serverURL == "" {
-		serverURL = defaultServer
+type historyResponse struct {
+	Username string       `json:"username"`
+	Plays    []playRecord `json:"plays"`
+}
+
+// withDefaults fills unset fields with the client defaults.
+func (cfg Config) withDefaults() Config {
+	cfg.Username = strings.TrimSpace(cfg.Username)
+	cfg.ServerURL = strings.TrimSpace(cfg.ServerURL)
+	if cfg.ServerURL == "" {
+		cfg.ServerURL = defaultServer
+	}
+	if cfg.ListLimit <= 0 {
+		cfg.ListLimit = defaultListLimit
+	}
+	if cfg.LeaderboardLimit == 0 {
+		cfg.LeaderboardLimit = defaultLeaderboardLimit
+	}
+	if cfg.MaxInvalidAnswers <= 0 {
+		cfg.MaxInvalidAnswers = defaultMaxInvalidAnswers
 	}
+	if cfg.HTTPTimeout <= 0 {
+		cfg.HTTPTimeout = defaultHTTPTimeout
+	}
+	return cfg
+}
 
-	listLimit := cfg.ListLimit
-	if listLimit <= 0 {
-		listLimit = defaultListLimit
+func Run(ctx context.Context, in io.Reader, out io.Writer, cfg Config) error {
+	// The use command applies profiles to the settings as given, not to those
+	// filled in from the profile Run started with.
+	given := cfg
+	cfg, err := cfg.ResolveProfile()
+	if err != nil {
+		return err
 	}
-	leaderboardLimit := cfg.LeaderboardLimit
-	if leaderboardLimit == 0 {
-		leaderboardLimit = defaultLeaderboardLimit
+	cfg = cfg.withDefaults()
+	if cfg.Username == "" {
+		return errors.New("username is required")
 	}
+	var (
+		username, serverURL         string
+		listLimit, leaderboardLimit int
+		client                      *HTTPClient
+		persister                   *answerPersister
+		queue                       *offlineQueue
+	)
 	maxInvalidAnswers := cfg.MaxInvalidAnswers
-	if maxInvalidAnswers <= 0 {
-		maxInvalidAnswers = defaultMaxInvalidAnswers
+
+	// Decide on colors before line editing wraps out and hides the terminal.
+	style := styler{enabled: !cfg.JSON && colorEnabled(out, cfg.NoColor)}
+	submissions := newSubmissionLog(cfg.SubmissionLog)
+	// connect points the session at cfg's server as cfg's user.
+	connect := func(cfg Config) error {
+		next := NewHTTPClient(cfg.ServerURL, &http.Client{Timeout: cfg.HTTPTimeout})
+		next.SetPlayerToken(cfg.PlayerToken)
+		next.SetAccountToken(cfg.AccountToken)
+		nextQueue, err := openOfflineQueue(ctx, cfg, next, cfg.Username)
+		if err != nil {
+			return err
+		}
+		username, serverURL = cfg.Username, cfg.ServerURL
+		listLimit, leaderboardLimit = cfg.ListLimit, cfg.LeaderboardLimit
+		client, queue = next, nextQueue
+		persister = newAnswerPersister(client, submissions)
+		persister.queue = queue
+		return nil
 	}
-	timeout := cfg.HTTPTimeout
-	if timeout <= 0 {
-		timeout = defaultHTTPTimeout
+	if err := connect(cfg); err != nil {
+		return err
 	}
-
-	client := NewHTTPClient(serverURL, &http.Client{Timeout: timeout})
+	in, out, restore := enableLineEditing(in, out)
+	defer restore()
 	reader := bufio.NewReader(in)
+	var history []playRecord
 
-	fmt.Fprintf(out, "quiz-user-service\nusername=%s\nserver=%s\n\n", username, serverURL)
-	printHelp(out)
+	if !cfg.JSON {
+		fmt.Fprintf(out, "quiz-user-service\nusername=%s\nserver=%s\n", username, serverURL)
+		if cfg.Profile != "" {
+			fmt.Fprintf(out, "profile=%s\n", cfg.Profile)
+		}
+		fmt.Fprintln(out)
+		printHelp(out)
+	}
 
 	for {
-		fmt.Fprint(out, "\n> ")
+		if !cfg.JSON {
+			fmt.Fprint(out, "\n> ")
+		}
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			if errors.Is(err, io.EOF) {
@@ -80,8 +171,12 @@ func Run(ctx context.Context, in io.Reader, out io.Writer, cfg Config) error {
 			continue
 		}
 
-		args := strings.Fields(line)
+		args, asJSON := splitJSONFlag(strings.Fields(line))
+		if len(args) == 0 {
+			continue
+		}
 		command := strings.ToLower(args[0])
+		v := view{asJSON: cfg.JSON || asJSON, style: style}
 
 		switch command {
 		case "help":
@@ -94,8 +189,8 @@ func Run(ctx context.Context, in io.Reader, out io.Writer, cfg Config) error {
 				fmt.Fprintf(out, "invalid quizzes limit: %v\n", parseErr)
 				continue
 			}
-			if err := runList(ctx, out, client, limit, serverURL); err != nil {
-				fmt.Fprintf(out, "error: %v\n", err)
+			if err := runList(ctx, out, v, client, limit, serverURL); err != nil {
+				printError(out, v, err)
 			}
 		case "leaderboard":
 			if len(args) < 2 {
@@ -107,16 +202,118 @@ func Run(ctx context.Context, in io.Reader, out io.Writer, cfg Config) error {
 				fmt.Fprintf(out, "invalid leaderboard limit: %v\n", parseErr)
 				continue
 			}
-			if err := runLeaderboard(ctx, out, client, args[1], limit, serverURL); err != nil {
-				fmt.Fprintf(out, "error: %v\n", err)
+			if err := runLeaderboard(ctx, out, v, client, args[1], limit, serverURL); err != nil {
+				printError(out, v, err)
+			}
+		case "search":
+			if len(args) < 2 {
+				fmt.Fprintln(out, "usage: search <text>")
+				continue
+			}
+			text := strings.Join(args[1:], " ")
+			if err := runSearch(ctx, out, v, client, text, listLimit, serverURL); err != nil {
+				printError(out, v, err)
+			}
+		case "import":
+			if len(args) < 2 || len(args) > 3 {
+				fmt.Fprintln(out, "usage: import <file> [format]")
+				continue
+			}
+			format := ""
+			if len(args) == 3 {
+				format = args[2]
+			}
+			if err := runImport(ctx, out, v, client, args[1], format, serverURL); err != nil {
+				printError(out, v, err)
+			}
+		case "create":
+			if err := runCreateWizard(ctx, reader, out, style, client, username, serverURL); err != nil {
+				printError(out, v, err)
+			}
+		case "history":
+			runHistory(out, v, username, history)
+		case "use":
+			if len(args) == 1 {
+				runProfiles(out, v, cfg)
+				continue
+			}
+			if len(args) != 2 {
+				fmt.Fprintln(out, "usage: use [profile]")
+				continue
+			}
+			next, err := given.switchProfile(args[1])
+			if err == nil {
+				err = connect(next)
+			}
+			if err != nil {
+				printError(out, v, err)
+				continue
+			}
+			cfg.Profile = next.Profile
+			if v.asJSON {
+				runProfiles(out, v, cfg)
+				continue
+			}
+			fmt.Fprintf(out, "Using profile %s: username=%s server=%s\n", next.Profile, username, serverURL)
+		case "review":
+			if len(args) != 2 {
+				fmt.Fprintln(out, "usage: review <quiz_id>")
+				continue
+			}
+			if err := runReview(ctx, out, v, client, username, args[1], serverURL); err != nil {
+				printError(out, v, err)
+			}
+		case "prefs":
+			change, parseErr := parsePreferenceArgs(args[1:])
+			if parseErr != nil {
+				fmt.Fprintf(out, "invalid prefs: %v\n", parseErr)
+				fmt.Fprintln(out, "usage: prefs [count=N] [difficulty=easy|medium|hard|any]")
+				continue
+			}
+			if err := runPreferences(ctx, out, v, client, username, change, serverURL); err != nil {
+				printError(out, v, err)
+			}
+		case "log":
+			limit, parseErr := parsePositiveLimit(args, 1, listLimit)
+			if parseErr != nil {
+				fmt.Fprintf(out, "invalid log limit: %v\n", parseErr)
+				continue
+			}
+			if err := runSubmissionLog(out, v, submissions, limit); err != nil {
+				printError(out, v, err)
+			}
+		case "sync":
+			if err := runSync(ctx, out, client, queue, submissions, serverURL); err != nil {
+				printError(out, v, err)
 			}
 		case "play":
 			if len(args) != 2 {
 				fmt.Fprintln(out, "usage: play <quiz_id>")
 				continue
 			}
-			if err := runPlay(ctx, reader, out, client, username, args[1], maxInvalidAnswers, serverURL); err != nil {
-				fmt.Fprintf(out, "error: %v\n", err)
+			record, err := runPlay(ctx, reader, out, style, client, persister, username, args[1], maxInvalidAnswers, serverURL)
+			if err != nil {
+				printError(out, v, err)
+			}
+			if record.QuizID != "" {
+				history = append(history, record)
+			}
+		case "daily":
+			var metadata quiz.QuizMetadata
+			err := withRetry(ctx, func() (err error) {
+				metadata, err = client.GetDailyQuiz(ctx)
+				return err
+			})
+			if err != nil {
+				printError(out, v, describeClientError(err, serverURL))
+				continue
+			}
+			record, err := runPlay(ctx, reader, out, style, client, persister, username, metadata.QuizID, maxInvalidAnswers, serverURL)
+			if err != nil {
+				printError(out, v, err)
+			}
+			if record.QuizID != "" {
+				history = append(history, record)
 			}
 		default:
 			fmt.Fprintln(out, "unknown command. type 'help' for usage.")
@@ -124,88 +321,321 @@ func Run(ctx context.Context, in io.Reader, out io.Writer, cfg Config) error {
 	}
 }
 
-func runList(ctx context.Context, out io.Writer, client *HTTPClient, limit int, serverURL string) error {
-	quizzes, err := client.ListActiveQuizzes(ctx, limit)
+func runList(ctx context.Context, out io.Writer, v view, client *HTTPClient, limit int, serverURL string) error {
+	var quizzes []quiz.QuizMetadata
+	err := withRetry(ctx, func() (err error) {
+		quizzes, err = client.ListActiveQuizzes(ctx, limit)
+		return err
+	})
 	if err != nil {
 		return describeClientError(err, serverURL)
 	}
 
+	if v.asJSON {
+		// Same shape as GET /quizzes/active.
+		payload := activeQuizzesResponse{Quizzes: make([]activeQuizItem, 0, len(quizzes))}
+		for _, item := range quizzes {
+			entry := activeQuizItem{
+				QuizID:        item.QuizID,
+				QuestionCount: item.QuestionCount,
+				CreatedAt:     item.CreatedAt.Format(time.RFC3339),
+				State:         string(item.State(time.Now())),
+				Locked:        item.Locked,
+			}
+			if !item.ClosesAt.IsZero() {
+				entry.ClosesAt = item.ClosesAt.Format(time.RFC3339)
+			}
+			payload.Quizzes = append(payload.Quizzes, entry)
+		}
+		writeJSON(out, payload)
+		return nil
+	}
+
 	if len(quizzes) == 0 {
 		fmt.Fprintln(out, "No active quizzes.")
 		return nil
 	}
 
 	fmt.Fprintln(out, "Active quizzes:")
+	rows := make([][]string, 0, len(quizzes))
 	for idx, item := range quizzes {
-		fmt.Fprintf(out, "%d. %s (%d questions, created %s)\n",
-			idx+1,
+		status := "open"
+		switch {
+		case item.Locked:
+			status = "locked"
+		case item.Draft:
+			status = "draft"
+		case item.State(time.Now()) == quiz.QuizStateExpired:
+			status = "closed " + formatTime(item.ClosesAt)
+		case !item.ClosesAt.IsZero():
+			status = "closes " + formatTime(item.ClosesAt)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(idx + 1),
 			item.QuizID,
-			item.QuestionCount,
-			item.CreatedAt.Format(time.RFC3339),
-		)
+			strconv.Itoa(item.QuestionCount),
+			formatTime(item.CreatedAt),
+			status,
+		})
 	}
+	writeTable(out, v.style, []string{"#", "QUIZ ID", "QUESTIONS", "CREATED", "STATUS"}, rows)
 	return nil
 }
 
-func runLeaderboard(ctx context.Context, out io.Writer, client *HTTPClient, quizID string, limit int, serverURL string) error {
-	entries, err := client.GetLeaderboard(ctx, quizID, limit)
+func runLeaderboard(ctx context.Context, out io.Writer, v view, client *HTTPClient, quizID string, limit int, serverURL string) error {
+	var entries []quiz.LeaderboardEntry
+	err := withRetry(ctx, func() (err error) {
+		entries, err = client.GetLeaderboard(ctx, quizID, limit)
+		return err
+	})
 	if err != nil {
 		return describeClientError(err, serverURL)
 	}
 
+	if v.asJSON {
+		// Same shape as GET /quizzes/{quiz_id}/leaderboard.
+		payload := leaderboardResponse{QuizID: quizID, Leaderboard: make([]leaderboardEntryResponse, 0, len(entries))}
+		for _, entry := range entries {
+			payload.Leaderboard = append(payload.Leaderboard, leaderboardEntryResponse{
+				Username:         entry.Username,
+				TotalScore:       entry.TotalScore,
+				AnsweredCount:    entry.AnsweredCount,
+				LastSubmissionAt: entry.LastSubmissionAt.Format(time.RFC3339),
+			})
+		}
+		writeJSON(out, payload)
+		return nil
+	}
+
 	if len(entries) == 0 {
 		fmt.Fprintf(out, "No leaderboard entries for quiz %s.\n", quizID)
 		return nil
 	}
 
 	fmt.Fprintf(out, "Leaderboard for %s:\n", quizID)
+	rows := make([][]string, 0, len(entries))
 	for idx, entry := range entries {
-		fmt.Fprintf(out, "%d. %s score=%s answered=%d last=%s\n",
-			idx+1,
+		rows = append(rows, []string{
+			strconv.Itoa(idx + 1),
 			entry.Username,
 			formatScore(entry.TotalScore),
-			entry.AnsweredCount,
-			entry.LastSubmissionAt.Format(time.RFC3339),
-		)
+			strconv.Itoa(entry.AnsweredCount),
+			formatTime(entry.LastSubmissionAt),
+		})
+	}
+	writeTable(out, v.style, []string{"RANK", "USERNAME", "SCORE", "ANSWERED", "LAST SUBMISSION"}, rows)
+	return nil
+}
+
+func runSearch(ctx context.Context, out io.Writer, v view, client *HTTPClient, text string, limit int, serverURL string) error {
+	var results []quiz.PublicQuestion
+	err := withRetry(ctx, func() (err error) {
+		results, err = client.SearchQuestions(ctx, text, limit)
+		return err
+	})
+	if err != nil {
+		return describeClientError(err, serverURL)
+	}
+
+	if v.asJSON {
+		writeJSON(out, questionSearchResponse{Query: text, Results: results})
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintf(out, "No questions match %q.\n", text)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Questions matching %q:\n", text)
+	rows := make([][]string, 0, len(results))
+	for idx, item := range results {
+		rows = append(rows, []string{strconv.Itoa(idx + 1), item.QuestionID, item.Question})
+	}
+	writeTable(out, v.style, []string{"#", "QUESTION ID", "QUESTION"}, rows)
+	return nil
+}
+
+// runImport creates a quiz from the question bank file at path and lists the
+// questions the server left out, by line.
+func runImport(ctx context.Context, out io.Writer, v view, client *HTTPClient, path, format, serverURL string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := client.ImportQuestions(ctx, format, data)
+	if err != nil && len(result.Errors) == 0 {
+		return describeClientError(err, serverURL)
+	}
+
+	if v.asJSON {
+		writeJSON(out, result)
+		return err
+	}
+
+	if result.Quiz != nil {
+		fmt.Fprintf(out, "Created quiz %s with %d questions from %s.\n", result.Quiz.QuizID, result.Quiz.QuestionCount, result.Format)
+	}
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(out, "Skipped %d questions:\n", len(result.Errors))
+		rows := make([][]string, 0, len(result.Errors))
+		for _, lineErr := range result.Errors {
+			rows = append(rows, []string{strconv.Itoa(lineErr.Line), lineErr.Error})
+		}
+		writeTable(out, v.style, []string{"LINE", "PROBLEM"}, rows)
+	}
+	return err
+}
+
+// runSubmissionLog lists the last limit answers sent from this machine, oldest
+// first, and counts those that never reached the server.
+func runSubmissionLog(out io.Writer, v view, log *submissionLog, limit int) error {
+	if log == nil {
+		return errors.New("the submission log is turned off")
+	}
+	records, err := log.recent(limit)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, record := range records {
+		if record.failed() {
+			failed++
+		}
+	}
+
+	if v.asJSON {
+		writeJSON(out, submissionsResponse{Path: log.path, Submissions: append([]submissionRecord{}, records...), Failed: failed})
+		return nil
+	}
+
+	if len(records) == 0 {
+		fmt.Fprintf(out, "No submissions logged in %s.\n", log.path)
+		return nil
+	}
+
+	rows := make([][]string, 0, len(records))
+	for _, record := range records {
+		result := record.Status
+		switch {
+		case record.failed():
+			result = v.style.red("not saved: " + record.Error)
+		case record.Queued:
+			result = "queued for sync"
+		case result == "":
+			result = "saved"
+		}
+		if record.Synced {
+			result += " (synced)"
+		}
+		if record.AttemptScore != nil {
+			result += " (score " + formatScore(*record.AttemptScore) + ")"
+		}
+		rows = append(rows, []string{
+			formatTime(record.At),
+			record.QuizID,
+			record.QuestionID,
+			record.Answer,
+			strconv.Itoa(record.Tries),
+			result,
+		})
+	}
+	writeTable(out, v.style, []string{"SENT", "QUIZ ID", "QUESTION ID", "ANSWER", "TRIES", "RESULT"}, rows)
+	if failed > 0 {
+		fmt.Fprintln(out, v.style.red(fmt.Sprintf("%d of these answers were not saved after retries.", failed)))
 	}
 	return nil
 }
 
-func runPlay(ctx context.Context, reader *bufio.Reader, out io.Writer, client *HTTPClient, username, quizID string, maxInvalidAnswers int, serverURL string) error {
-	payload, err := client.GetQuizQuestions(ctx, quizID, username, false, 0)
+// runHistory lists the quizzes played in this session, oldest first.
+func runHistory(out io.Writer, v view, username string, history []playRecord) {
+	if v.asJSON {
+		writeJSON(out, historyResponse{Username: username, Plays: append([]playRecord{}, history...)})
+		return
+	}
+
+	if len(history) == 0 {
+		fmt.Fprintln(out, "No quizzes played in this session.")
+		return
+	}
+
+	rows := make([][]string, 0, len(history))
+	for idx, record := range history {
+		rows = append(rows, []string{
+			strconv.Itoa(idx + 1),
+			record.QuizID,
+			formatScore(record.Score) + "/" + formatScore(record.Possible),
+			strconv.Itoa(record.Answered),
+			formatTime(record.PlayedAt),
+		})
+	}
+	writeTable(out, v.style, []string{"#", "QUIZ ID", "SCORE", "ANSWERED", "PLAYED"}, rows)
+}
+
+// runPlay plays quizID and returns its record for the session history; the
+// record is empty when nothing was played.
+func runPlay(ctx context.Context, reader *bufio.Reader, out io.Writer, style styler, client *HTTPClient, persister *answerPersister, username, quizID string, maxInvalidAnswers int, serverURL string) (playRecord, error) {
+	var payload questionsResponse
+	err := withRetry(ctx, func() (err error) {
+		payload, err = client.GetQuizQuestions(ctx, quizID, username, false, 0)
+		return err
+	})
 	if err != nil {
 		var apiErr *APIError
 		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
 			createNew, promptErr := promptYesNo(reader, out, "quiz not found. create a new quiz? (yes/no): ")
 			if promptErr != nil {
-				return promptErr
+				return playRecord{}, promptErr
 			}
 			if !createNew {
-				return nil
+				return playRecord{}, nil
 			}
 
 			// Reuse the requested quiz_id so multiple users can converge on the same
 			// shareable quiz identifier after a coordinated "create if missing" flow.
-			payload, err = client.GetQuizQuestions(ctx, quizID, username, true, defaultQuestionCount)
+			// Leaving the question count unset applies the player's preferences.
+			payload, err = client.GetQuizQuestions(ctx, quizID, username, true, 0)
 			if err != nil {
-				return describeClientError(err, serverURL)
+				return playRecord{}, describeClientError(err, serverURL)
 			}
-			return runPlayWithPayload(reader, out, client, username, payload, maxInvalidAnswers)
+		} else {
+			return playRecord{}, describeClientError(err, serverURL)
 		}
-		return describeClientError(err, serverURL)
 	}
-	return runPlayWithPayload(reader, out, client, username, payload, maxInvalidAnswers)
+	record, err := runPlayWithPayload(reader, out, style, persister, username, payload, maxInvalidAnswers)
+	if err == nil && record.Answered > 0 {
+		announceStreak(ctx, out, style, client, username)
+	}
+	return record, err
 }
 
-func runPlayWithPayload(reader *bufio.Reader, out io.Writer, client *HTTPClient, username string, payload questionsResponse, maxInvalidAnswers int) error {
+// announceStreak congratulates the player on a streak of two days or more.
+// Streaks are a bonus: servers without them, or a failed lookup, print
+// nothing.
+func announceStreak(ctx context.Context, out io.Writer, style styler, client *HTTPClient, username string) {
+	stats, err := client.GetUserStats(ctx, username)
+	if err != nil || stats.CurrentStreak < 2 {
+		return
+	}
+	fmt.Fprintln(out, style.green(fmt.Sprintf("%d-day streak!", stats.CurrentStreak)))
+}
+
+// runPlayWithPayload plays the unanswered questions in payload, handing each
+// answer to persister, and returns once persister has settled them all.
+func runPlayWithPayload(reader *bufio.Reader, out io.Writer, style styler, persister *answerPersister, username string, payload questionsResponse, maxInvalidAnswers int) (playRecord, error) {
 	fmt.Fprintf(out, "quiz_id=%s\n", payload.QuizID)
 
 	// Intentional tradeoff: score is computed client-side for a simpler demo flow.
 	// The server still persists attempts, but this local score is treated as UX-only.
+	// Server-scored quizzes come without correct_index and use the server's verdicts.
 	oldPossible := 0.0
 	oldScore := 0.0
 	fresh := make([]questionItem, 0, len(payload.Questions))
 	for _, item := range payload.Questions {
+		// Voided questions were withdrawn by the host and count for nobody.
+		if item.Voided {
+			continue
+		}
 		// Treat either signal as attempted to remain compatible with incremental API evolution.
 		attempted := item.AttemptStatus == attemptStatusAlreadyAttempt || item.AttemptScore != nil
 		if attempted {
@@ -225,23 +655,50 @@ func runPlayWithPayload(reader *bufio.Reader, out io.Writer, client *HTTPClient,
 		} else {
 			fmt.Fprintln(out, "No scored attempts in this run.")
 		}
-		return nil
+		return playRecord{}, nil
 	}
 
 	newPossible := 0.0
 	newScore := 0.0
 
+	// Timed quizzes read answers in the background so the prompt can give up
+	// when the countdown runs out.
+	timeLimit := time.Duration(payload.SecondsPerQuestion) * time.Second
+	var input *timedInput
+	if timeLimit > 0 {
+		input = newTimedInput(reader)
+		defer input.finish(out)
+		fmt.Fprintf(out, "You have %ds per question.\n", payload.SecondsPerQuestion)
+	}
+
 	for _, question := range fresh {
 		fmt.Fprintln(out)
 		fmt.Fprintf(out, "%s\n\n", question.Question)
 		for _, option := range question.Options {
 			fmt.Fprintf(out, "%s. %s\n", option.Letter, option.Text)
 		}
+		multi := question.Type == quiz.TypeMultiSelect
+		if multi {
+			fmt.Fprintln(out, "Pick every correct option.")
+		}
 		fmt.Fprintln(out)
 
+		deadline := time.Now().Add(timeLimit)
 		invalidCount := 0
 		for {
-			answer, ok := promptAnswer(reader, out, len(question.Options))
+			var answer string
+			var ok bool
+			if input != nil {
+				var timedOut bool
+				answer, ok, timedOut = promptTimedAnswer(input, out, len(question.Options), multi, deadline)
+				if timedOut {
+					// Expired questions are skipped like invalid ones and stay out of the denominator.
+					fmt.Fprintln(out, style.red("Time up! Skipping question."))
+					break
+				}
+			} else {
+				answer, ok = promptAnswer(reader, out, len(question.Options), multi)
+			}
 			if !ok {
 				invalidCount++
 				if invalidCount >= maxInvalidAnswers {
@@ -252,37 +709,51 @@ func runPlayWithPayload(reader *bufio.Reader, out io.Writer, client *HTTPClient,
 				continue
 			}
 
-			answerIndex := int(answer[0] - 'A')
+			response := quiz.SubmittedResponse{QuestionID: question.QuestionID, Answer: answer, ContentHash: question.ContentHash, Nonce: question.Nonce}
+			if payload.Scoring.ServerScored {
+				// Without the answer key, the verdict is the server's.
+				possible, score := showServerVerdict(out, style, persister.persist(payload.QuizID, username, response, time.Now()))
+				newPossible += possible
+				newScore += score
+				break
+			}
+
 			// Invalid/auto-skipped questions are excluded from denominator by design.
 			newPossible += 1.0
-			if answerIndex == question.CorrectIndex {
+			switch status, credit := quizkit.GradeAnswer(question.question(), answer); status {
+			case quiz.StatusCorrect:
 				newScore += 1.0
-				fmt.Fprintln(out, "Correct!")
-			} else {
-				fmt.Fprintf(out, "Wrong. Correct answer: %s\n", correctAnswerDisplay(question))
+				fmt.Fprintln(out, style.green("Correct!"))
+			case quiz.StatusPartiallyCorrect:
+				newScore += credit
+				fmt.Fprintln(out, style.bold(fmt.Sprintf("Partly right: %.0f%% credit. Correct answers: %s", credit*100, correctAnswerDisplay(question))))
+			default:
+				fmt.Fprintln(out, style.red("Wrong. Correct answer: "+correctAnswerDisplay(question)))
 			}
 
-			fireAndForgetPersistence(client, payload.QuizID, username, question.QuestionID, answer)
+			persister.submit(payload.QuizID, username, response)
 			break
 		}
 	}
+	persister.wait()
+	if queued := persister.takeQueued(); queued > 0 {
+		fmt.Fprintf(out, "%d answers could not reach the server and are queued; run 'sync' once it is back.\n", queued)
+	}
 
 	combinedPossible := oldPossible + newPossible
 	combinedScore := oldScore + newScore
 	fmt.Fprintln(out)
 	if combinedPossible > 0 {
-		fmt.Fprintf(out, "Score: %s/%s\n", formatScore(combinedScore), formatScore(combinedPossible))
+		fmt.Fprintln(out, style.bold(fmt.Sprintf("Score: %s/%s", formatScore(combinedScore), formatScore(combinedPossible))))
+		fmt.Fprintf(out, "Run 'review %s' to go over your answers.\n", payload.QuizID)
 	} else {
 		fmt.Fprintln(out, "No scored attempts in this run.")
 	}
-	return nil
-}
-func fireAndForgetPersistence(client *HTTPClient, quizID, username, questionID, answer string) {
-	// Intentional tradeoff: best-effort persistence per question to reduce loss on mid-quiz disconnects.
-	// These async writes can complete out of order, but each (quiz,question,user) key is idempotent on server.
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), defaultPersistTimeout)
-		defer cancel()
-		_ = client.PersistSingleResponse(ctx, quizID, username, questionID, answer)
-	}()
+	return playRecord{
+		QuizID:   payload.QuizID,
+		Score:    combinedScore,
+		Possible: combinedPossible,
+		Answered: int(newPossible),
+		PlayedAt: time.Now().UTC(),
+	}, nil
 }
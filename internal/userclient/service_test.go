@@ -3,6 +3,11 @@ package userclient
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -44,16 +49,31 @@ func TestPromptAnswer(t *testing.T) {
 	reader := bufio.NewReader(strings.NewReader(" b \n"))
 	var out bytes.Buffer
 
-	answer, ok := promptAnswer(reader, &out, 2)
+	answer, ok := promptAnswer(reader, &out, 2, false)
 	if !ok || answer != "B" {
 		t.Fatalf("promptAnswer valid = (%q, %t), want (B, true)", answer, ok)
 	}
 
 	reader = bufio.NewReader(strings.NewReader("z\n"))
-	answer, ok = promptAnswer(reader, &out, 2)
+	answer, ok = promptAnswer(reader, &out, 2, false)
 	if ok || answer != "" {
 		t.Fatalf("promptAnswer invalid = (%q, %t), want (\"\", false)", answer, ok)
 	}
+
+	reader = bufio.NewReader(strings.NewReader("c, a\nA,B\n"))
+	answer, ok = promptAnswer(reader, &out, 3, true)
+	if !ok || answer != "A,C" {
+		t.Fatalf("promptAnswer multi = (%q, %t), want (A,C, true)", answer, ok)
+	}
+	answer, ok = promptAnswer(reader, &out, 3, false)
+	if ok {
+		t.Fatalf("promptAnswer single with two letters = (%q, %t), want it refused", answer, ok)
+	}
+	for _, line := range []string{"a,a", "a,,b", "a,d"} {
+		if answer, ok := parseLetters(line, 3); ok {
+			t.Fatalf("parseLetters(%q) = %q, want it refused", line, answer)
+		}
+	}
 }
 
 func TestPromptYesNoRetriesUntilValid(t *testing.T) {
@@ -83,7 +103,7 @@ func TestRunPlayWithPayloadAllAttemptedPrintsScore(t *testing.T) {
 
 	reader := bufio.NewReader(strings.NewReader(""))
 	var out bytes.Buffer
-	err := runPlayWithPayload(reader, &out, nil, "alice", payload, 3)
+	_, err := runPlayWithPayload(reader, &out, styler{}, nil, "alice", payload, 3)
 	if err != nil {
 		t.Fatalf("runPlayWithPayload failed: %v", err)
 	}
@@ -130,7 +150,7 @@ func TestRunPlayWithPayloadCombinesOldAndNewScore(t *testing.T) {
 
 	reader := bufio.NewReader(strings.NewReader("A\n"))
 	var out bytes.Buffer
-	err := runPlayWithPayload(reader, &out, client, "alice", payload, 3)
+	record, err := runPlayWithPayload(reader, &out, styler{}, newAnswerPersister(client, nil), "alice", payload, 3)
 	if err != nil {
 		t.Fatalf("runPlayWithPayload failed: %v", err)
 	}
@@ -148,6 +168,9 @@ func TestRunPlayWithPayloadCombinesOldAndNewScore(t *testing.T) {
 	if !strings.Contains(text, "Score: 2/2") {
 		t.Fatalf("expected combined score output, got: %s", text)
 	}
+	if record.QuizID != "quiz-1" || record.Score != 2 || record.Possible != 2 || record.Answered != 1 {
+		t.Fatalf("play record = (%+v), want 2/2 with one new answer", record)
+	}
 }
 
 func TestRunPlayWithPayloadShowsCorrectAnswerWhenWrong(t *testing.T) {
@@ -182,7 +205,7 @@ func TestRunPlayWithPayloadShowsCorrectAnswerWhenWrong(t *testing.T) {
 
 	reader := bufio.NewReader(strings.NewReader("A\n"))
 	var out bytes.Buffer
-	err := runPlayWithPayload(reader, &out, client, "alice", payload, 3)
+	_, err := runPlayWithPayload(reader, &out, styler{}, newAnswerPersister(client, nil), "alice", payload, 3)
 	if err != nil {
 		t.Fatalf("runPlayWithPayload failed: %v", err)
 	}
@@ -201,3 +224,346 @@ func TestRunPlayWithPayloadShowsCorrectAnswerWhenWrong(t *testing.T) {
 		t.Fatalf("expected wrong-answer score output, got: %s", text)
 	}
 }
+
+func TestRunPlayWithPayloadUsesServerVerdictsWhenServerScored(t *testing.T) {
+	var submitted []quiz.SubmittedResponse
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Responses []quiz.SubmittedResponse `json:"responses"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("decode submission: %v", err)
+		}
+		submitted = append(submitted, request.Responses...)
+		result := `{"question_id":"q1","status":"correct"}`
+		if len(submitted) == 2 {
+			result = `{"question_id":"q2","status":"incorrect","correct_letter":"A","correct_text":"Mercury"}`
+		}
+		_, _ = w.Write([]byte(`{"results":[` + result + `]}`))
+	}))
+	defer server.Close()
+
+	// correct_index is withheld, so a local check would take A for correct.
+	payload := questionsResponse{QuizID: "quiz-1", Questions: []questionItem{
+		{QuestionID: "q1", Question: "Largest planet?", Nonce: "n1", Options: []quiz.Option{{Letter: "A", Text: "Mars"}, {Letter: "B", Text: "Jupiter"}}},
+		{QuestionID: "q2", Question: "Closest to the sun?", Nonce: "n2", Options: []quiz.Option{{Letter: "A", Text: "Mercury"}, {Letter: "B", Text: "Venus"}}},
+	}}
+	payload.Scoring.ServerScored = true
+
+	var out bytes.Buffer
+	client := NewHTTPClient(server.URL, server.Client())
+	record, err := runPlayWithPayload(bufio.NewReader(strings.NewReader("B\nB\n")), &out, styler{}, newAnswerPersister(client, nil), "alice", payload, 3)
+	if err != nil {
+		t.Fatalf("runPlayWithPayload failed: %v", err)
+	}
+	if len(submitted) != 2 || submitted[0].Nonce != "n1" || submitted[1].Nonce != "n2" {
+		t.Fatalf("submitted = %+v, want both answers with their nonces", submitted)
+	}
+	if record.Score != 1 || record.Possible != 2 {
+		t.Fatalf("record = %+v, want 1 of 2", record)
+	}
+	for _, want := range []string{"Correct!", "Wrong. Correct answer: A. Mercury", "Score: 1/2"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunPlayWithPayloadShowsCountdownForTimedQuiz(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, server.Client())
+	payload := questionsResponse{
+		QuizID:             "quiz-1",
+		SecondsPerQuestion: 30,
+		Questions: []questionItem{
+			{
+				QuestionID:   "q1",
+				Question:     "2 + 3?",
+				CorrectIndex: 1,
+				Options: []quiz.Option{
+					{Letter: "A", Text: "4"},
+					{Letter: "B", Text: "5"},
+				},
+			},
+		},
+	}
+
+	reader := bufio.NewReader(strings.NewReader("b\n"))
+	var out bytes.Buffer
+	record, err := runPlayWithPayload(reader, &out, styler{}, newAnswerPersister(client, nil), "alice", payload, 3)
+	if err != nil {
+		t.Fatalf("runPlayWithPayload failed: %v", err)
+	}
+
+	text := out.String()
+	if !strings.Contains(text, "You have 30s per question.") || !strings.Contains(text, "Your answer (A-B, 30s left): ") {
+		t.Fatalf("expected countdown prompt, got: %s", text)
+	}
+	if record.Score != 1 || record.Possible != 1 {
+		t.Fatalf("record = (%v/%v), want 1/1", record.Score, record.Possible)
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	line, pos, ok := completeCommand("lea", 3, '\t')
+	if !ok || line != "leaderboard " || pos != len("leaderboard ") {
+		t.Fatalf("unique completion = (%q, %d, %t)", line, pos, ok)
+	}
+
+	if _, _, ok := completeCommand("q", 1, 'x'); ok {
+		t.Fatalf("expected non-tab keys to be ignored")
+	}
+	if _, _, ok := completeCommand("play quiz", 9, '\t'); ok {
+		t.Fatalf("expected completion to only apply to the command word")
+	}
+	if _, _, ok := completeCommand("zzz", 3, '\t'); ok {
+		t.Fatalf("expected no completion for unknown prefix")
+	}
+}
+
+func TestRunJSONModePrintsOnlyJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/quizzes/active":
+			_, _ = w.Write([]byte(`{"quizzes":[{"quiz_id":"quiz-1","question_count":5,"created_at":"2026-03-02T00:00:00Z","locked":false}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"quiz not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := Run(context.Background(), strings.NewReader("quizzes\nleaderboard missing\nhistory\n"), &out, Config{
+		Username:  "alice",
+		ServerURL: server.URL,
+		JSON:      true,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	decoder := json.NewDecoder(&out)
+	var quizzes activeQuizzesResponse
+	var failure errorResponse
+	var history historyResponse
+	for idx, target := range []any{&quizzes, &failure, &history} {
+		if err := decoder.Decode(target); err != nil {
+			t.Fatalf("output document %d is not JSON: %v", idx, err)
+		}
+	}
+	if len(quizzes.Quizzes) != 1 || quizzes.Quizzes[0].QuizID != "quiz-1" {
+		t.Fatalf("quizzes = (%+v), want quiz-1", quizzes)
+	}
+	if failure.Error != "quiz not found" {
+		t.Fatalf("leaderboard error = (%+v), want quiz not found", failure)
+	}
+	if history.Username != "alice" || history.Plays == nil || len(history.Plays) != 0 {
+		t.Fatalf("history = (%+v), want an empty list for alice", history)
+	}
+	if decoder.More() {
+		t.Fatalf("unexpected trailing output after JSON documents")
+	}
+}
+
+func TestRunLeaderboardPrintsAlignedTable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"quiz_id":"quiz-1","leaderboard":[
+			{"username":"alice","total_score":10,"answered_count":10,"last_submission_at":"2026-03-02T00:00:00Z"},
+			{"username":"bob","total_score":2.5,"answered_count":3,"last_submission_at":"2026-03-02T00:01:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := NewHTTPClient(server.URL, server.Client())
+	if err := runLeaderboard(context.Background(), &out, view{}, client, "quiz-1", 10, server.URL); err != nil {
+		t.Fatalf("runLeaderboard failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "RANK") {
+		t.Fatalf("table = %q, want a title, header, and two rows", lines)
+	}
+	column := strings.Index(lines[1], "SCORE")
+	if strings.Index(lines[2], "10 ") != column || strings.Index(lines[3], "2.5") != column {
+		t.Fatalf("scores are not aligned under SCORE:\n%s", out.String())
+	}
+}
+
+func TestSplitJSONFlag(t *testing.T) {
+	args, found := splitJSONFlag([]string{"leaderboard", "--json", "quiz-1"})
+	if !found || strings.Join(args, " ") != "leaderboard quiz-1" {
+		t.Fatalf("splitJSONFlag = (%q, %t), want flag removed", args, found)
+	}
+}
+
+func TestExecLeaderboardAcceptsFlagsAfterArguments(t *testing.T) {
+	var gotLimit string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLimit = r.URL.Query().Get("limit")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"quiz_id":"quiz-1","leaderboard":[]}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := Exec(context.Background(), strings.NewReader(""), &out, Config{ServerURL: server.URL}, nil,
+		[]string{"leaderboard", "quiz-1", "--limit", "5", "--json"})
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if gotLimit != "5" {
+		t.Fatalf("limit sent = %q, want 5", gotLimit)
+	}
+	var payload leaderboardResponse
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil || payload.QuizID != "quiz-1" {
+		t.Fatalf("output = (%s, %v), want leaderboard JSON", out.String(), err)
+	}
+
+	usageErrors := [][]string{
+		{"leaderboard"},
+		{"history"},
+		{"play", "quiz-1"},
+		{"quizzes", "--limit", "0"},
+		{"completion", "fish"},
+	}
+	for _, args := range usageErrors {
+		if err := Exec(context.Background(), strings.NewReader(""), &out, Config{ServerURL: server.URL}, nil, args); !errors.Is(err, ErrUsage) {
+			t.Fatalf("Exec(%q) error = (%v), want ErrUsage", args, err)
+		}
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	limit := flags.Int("limit", 10, "")
+	positional, err := parseInterspersed(flags, []string{"alpha", "--limit", "3", "beta", "--", "--limit"})
+	if err != nil || *limit != 3 || strings.Join(positional, ",") != "alpha,beta,--limit" {
+		t.Fatalf("parseInterspersed = (%q, %d, %v), want alpha,beta,--limit with limit 3", positional, *limit, err)
+	}
+}
+
+func TestWriteCompletionCoversCommandsAndGlobalFlags(t *testing.T) {
+	globals := flag.NewFlagSet("quiz-user-service", flag.ContinueOnError)
+	globals.String("server", "", "quiz service base URL")
+	globals.Bool("json", false, "print JSON")
+
+	for _, shell := range []string{"bash", "zsh"} {
+		var out bytes.Buffer
+		if err := WriteCompletion(&out, shell, globals); err != nil {
+			t.Fatalf("WriteCompletion(%s) failed: %v", shell, err)
+		}
+		script := out.String()
+		for _, want := range []string{"leaderboard", "--limit", "--server", "completion"} {
+			if !strings.Contains(script, want) {
+				t.Fatalf("%s completion is missing %q:\n%s", shell, want, script)
+			}
+		}
+	}
+	var out bytes.Buffer
+	_ = WriteCompletion(&out, "bash", globals)
+	if !strings.Contains(out.String(), "--server|-server) ((i++))") || strings.Contains(out.String(), "--json|-json)") {
+		t.Fatalf("bash completion should skip values of non-boolean global flags only:\n%s", out.String())
+	}
+}
+
+func TestExecPrefsMergesChangesWithSavedPreferences(t *testing.T) {
+	var put map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/alice/preferences" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPut {
+			_ = json.NewDecoder(r.Body).Decode(&put)
+			_, _ = fmt.Fprintf(w, `{"username":"alice","question_count":%v,"difficulty":%q}`, put["question_count"], put["difficulty"])
+			return
+		}
+		_, _ = w.Write([]byte(`{"username":"alice","question_count":8,"difficulty":"hard"}`))
+	}))
+	defer server.Close()
+
+	cfg := Config{ServerURL: server.URL, Username: "alice"}
+	var out bytes.Buffer
+	if err := Exec(context.Background(), strings.NewReader(""), &out, cfg, nil, []string{"prefs"}); err != nil {
+		t.Fatalf("Exec(prefs) failed: %v", err)
+	}
+	if put != nil || !strings.Contains(out.String(), "8 questions, hard difficulty") {
+		t.Fatalf("prefs output = %q (put %v), want the saved defaults unchanged", out.String(), put)
+	}
+
+	out.Reset()
+	if err := Exec(context.Background(), strings.NewReader(""), &out, cfg, nil, []string{"prefs", "difficulty=any"}); err != nil {
+		t.Fatalf("Exec(prefs difficulty=any) failed: %v", err)
+	}
+	if put["question_count"] != float64(8) || put["difficulty"] != "" {
+		t.Fatalf("PUT body = %v, want count kept and difficulty cleared", put)
+	}
+	if !strings.Contains(out.String(), "8 questions, any difficulty") {
+		t.Fatalf("prefs output = %q, want the updated defaults", out.String())
+	}
+
+	for _, args := range [][]string{{"prefs", "count=-2"}, {"prefs", "difficulty=brutal"}, {"prefs", "colour=blue"}, {"prefs", "hard"}} {
+		if err := Exec(context.Background(), strings.NewReader(""), &out, cfg, nil, args); !errors.Is(err, ErrUsage) {
+			t.Fatalf("Exec(%q) error = (%v), want ErrUsage", args, err)
+		}
+	}
+	if err := Exec(context.Background(), strings.NewReader(""), &out, Config{ServerURL: server.URL}, nil, []string{"prefs"}); !errors.Is(err, ErrUsage) {
+		t.Fatalf("Exec(prefs) without --username error = (%v), want ErrUsage", err)
+	}
+}
+
+func TestExecReviewShowsAnswersAndWhenTheKeyIsWithheld(t *testing.T) {
+	revealed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/quizzes/qz_1/attempts/alice" {
+			http.NotFound(w, r)
+			return
+		}
+		correct := ""
+		if revealed {
+			correct = `,"correct_letter":"A","correct_text":"Paris"`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"quiz_id":"qz_1","username":"alice","answers_revealed":%t,"total_score":1,"answered_count":2,"question_count":3,"questions":[
+			{"question_id":"q1","question":"Capital of France?","options":[{"letter":"A","text":"Paris"},{"letter":"B","text":"Lyon"}],"chosen_letter":"B","status":"incorrect"%s},
+			{"question_id":"q2","question":"2+2?","options":[{"letter":"A","text":"4"},{"letter":"B","text":"5"}],"chosen_letter":"A","status":"correct","score":1},
+			{"question_id":"q3","question":"Skipped?","options":[{"letter":"A","text":"yes"}],"status":"unanswered"}]}`, revealed, correct)
+	}))
+	defer server.Close()
+
+	cfg := Config{ServerURL: server.URL, Username: "alice"}
+	var out bytes.Buffer
+	if err := Exec(context.Background(), strings.NewReader(""), &out, cfg, nil, []string{"review", "qz_1"}); err != nil {
+		t.Fatalf("Exec(review) failed: %v", err)
+	}
+	for _, want := range []string{"You: B. Lyon — wrong", "You: A. 4 — correct", "You: - — not answered", "Score: 1, 2 of 3 answered", "once you answer every question"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("review output = %q, want %q", out.String(), want)
+		}
+	}
+	if strings.Contains(out.String(), "Answer:") {
+		t.Fatalf("review output = %q, want no answers before they are revealed", out.String())
+	}
+
+	revealed = true
+	out.Reset()
+	if err := Exec(context.Background(), strings.NewReader(""), &out, cfg, nil, []string{"review", "qz_1"}); err != nil {
+		t.Fatalf("Exec(review) failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Answer: A. Paris") || strings.Contains(out.String(), "once you answer") {
+		t.Fatalf("revealed review output = %q, want the correct answer", out.String())
+	}
+
+	if err := Exec(context.Background(), strings.NewReader(""), &out, Config{ServerURL: server.URL}, nil, []string{"review", "qz_1"}); !errors.Is(err, ErrUsage) {
+		t.Fatalf("Exec(review) without --username error = (%v), want ErrUsage", err)
+	}
+}
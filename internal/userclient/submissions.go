@@ -0,0 +1,222 @@
+package userclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"quiz-app/internal/quiz"
+)
+
+const (
+	// persistAttempts is how many times an answer is sent before it is logged
+	// as failed. Only transient failures are retried.
+	persistAttempts   = 3
+	persistRetryDelay = 250 * time.Millisecond
+)
+
+// submissionRecord is one line of the local submission log: an answer sent to
+// the server and the server's verdict, or the error that kept it from being
+// stored.
+type submissionRecord struct {
+	At         time.Time `json:"at"`
+	QuizID     string    `json:"quiz_id"`
+	QuestionID string    `json:"question_id"`
+	Answer     string    `json:"answer"`
+	// Status and AttemptScore are the server's result for the answer, its
+	// receipt that the attempt was stored. already_answered carries the score
+	// stored the first time.
+	Status       string   `json:"status,omitempty"`
+	AttemptScore *float64 `json:"attempt_score,omitempty"`
+	Bonus        float64  `json:"bonus,omitempty"`
+	Tries        int      `json:"tries"`
+	// Error is set when the answer was not persisted after all tries.
+	Error string `json:"error,omitempty"`
+	// Queued marks a failed answer that went to the offline queue; Synced
+	// marks the record of a queued answer sent later by the sync command.
+	Queued bool `json:"queued,omitempty"`
+	Synced bool `json:"synced,omitempty"`
+}
+
+// failed reports an answer that never reached the server and is not waiting
+// in the offline queue either.
+func (r submissionRecord) failed() bool {
+	return r.Error != "" && !r.Queued
+}
+
+type submissionsResponse struct {
+	Path        string             `json:"path"`
+	Submissions []submissionRecord `json:"submissions"`
+	Failed      int                `json:"failed"`
+}
+
+// DefaultSubmissionLogPath is where the client keeps its submission log unless
+// told otherwise: submissions.jsonl in the user's config directory. It is empty
+// when the platform has no config directory.
+func DefaultSubmissionLogPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "quiz-user-service", "submissions.jsonl")
+}
+
+// submissionLog appends records to a JSON-lines file. Records are never
+// rewritten, so the file doubles as an audit trail of everything sent. A nil
+// log drops records.
+type submissionLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+func newSubmissionLog(path string) *submissionLog {
+	if path == "" {
+		return nil
+	}
+	return &submissionLog{path: path}
+}
+
+func (l *submissionLog) append(record submissionRecord) error {
+	if l == nil {
+		return nil
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// recent returns the last limit records, oldest first. Lines that do not
+// parse, such as one cut short by a crash, are skipped.
+func (l *submissionLog) recent(limit int) ([]submissionRecord, error) {
+	if l == nil {
+		return nil, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []submissionRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record submissionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+		if limit > 0 && len(records) > limit {
+			records = records[1:]
+		}
+	}
+	return records, scanner.Err()
+}
+
+// answerPersister sends answers in the background while play continues,
+// retrying transient failures, and logs each outcome. Answers still failing
+// transiently after every try go to queue, when set, to be synced later. wait
+// blocks until every answer sent so far has been stored or given up on.
+type answerPersister struct {
+	client     *HTTPClient
+	log        *submissionLog
+	queue      *offlineQueue
+	retryDelay time.Duration
+	pending    sync.WaitGroup
+	queued     atomic.Int64
+}
+
+func newAnswerPersister(client *HTTPClient, log *submissionLog) *answerPersister {
+	return &answerPersister{client: client, log: log, retryDelay: persistRetryDelay}
+}
+
+func (p *answerPersister) submit(quizID, username string, response quiz.SubmittedResponse) {
+	// Intentional tradeoff: best-effort persistence per question to reduce loss on mid-quiz disconnects.
+	// These async writes can complete out of order, but each (quiz,question,user) key is idempotent on server.
+	answeredAt := time.Now()
+	p.pending.Add(1)
+	go func() {
+		defer p.pending.Done()
+		p.persist(quizID, username, response, answeredAt)
+	}()
+}
+
+// persist sends one answer and waits for it, retrying transient failures,
+// and logs the outcome. The result is the server's, or zero when the answer
+// was not stored.
+func (p *answerPersister) persist(quizID, username string, response quiz.SubmittedResponse, answeredAt time.Time) quiz.ResponseResult {
+	record := submissionRecord{QuizID: quizID, QuestionID: response.QuestionID, Answer: response.Answer}
+	var stored quiz.ResponseResult
+	for {
+		record.Tries++
+		ctx, cancel := context.WithTimeout(context.Background(), defaultPersistTimeout)
+		result, err := p.client.PersistSingleResponse(ctx, quizID, username, response)
+		cancel()
+		if err == nil {
+			stored = result
+			record.Status = result.Status
+			record.AttemptScore = result.AttemptScore
+			record.Bonus = result.Bonus
+			break
+		}
+		retryable, wait := retryAdvice(err)
+		if record.Tries >= persistAttempts || !retryable {
+			record.Error = err.Error()
+			if retryable && p.queue != nil {
+				record.Queued = p.queue.add(quizID, username, response, answeredAt) == nil
+				if record.Queued {
+					p.queued.Add(1)
+				}
+			}
+			break
+		}
+		if wait == 0 || wait > maxRetryAfter {
+			wait = p.retryDelay * time.Duration(record.Tries)
+		}
+		time.Sleep(wait)
+	}
+	record.At = time.Now().UTC()
+	_ = p.log.append(record)
+	return stored
+}
+
+func (p *answerPersister) wait() {
+	if p != nil {
+		p.pending.Wait()
+	}
+}
+
+// takeQueued returns how many answers went to the offline queue since the
+// last call.
+func (p *answerPersister) takeQueued() int {
+	if p == nil {
+		return 0
+	}
+	return int(p.queued.Swap(0))
+}
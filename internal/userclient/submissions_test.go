@@ -0,0 +1,159 @@
+package userclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"quiz-app/internal/quiz"
+)
+
+func TestAnswerPersisterRetriesAndLogsReceipts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(string(body), `"q-rejected"`):
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"quiz is locked"}`))
+		case calls.Add(1) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"try again"}`))
+		default:
+			_, _ = w.Write([]byte(`{"results":[{"question_id":"q1","status":"correct","bonus":0.5}]}`))
+		}
+	}))
+	defer server.Close()
+
+	log := newSubmissionLog(filepath.Join(t.TempDir(), "client", "submissions.jsonl"))
+	persister := newAnswerPersister(NewHTTPClient(server.URL, server.Client()), log)
+	persister.retryDelay = 0
+	persister.submit("quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q1", ContentHash: "", Answer: "A"})
+	persister.wait()
+	persister.submit("quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q-rejected", ContentHash: "", Answer: "B"})
+	persister.wait()
+
+	records, err := log.recent(10)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("recent = (%+v, %v), want two records", records, err)
+	}
+	if saved := records[0]; saved.QuestionID != "q1" || saved.Status != "correct" || saved.Bonus != 0.5 || saved.Tries != 2 || saved.failed() {
+		t.Fatalf("saved record = %+v, want correct after a retry", saved)
+	}
+	if rejected := records[1]; rejected.Tries != 1 || rejected.Error != "quiz is locked" {
+		t.Fatalf("rejected record = %+v, want one try and the server's error", rejected)
+	}
+
+	var out bytes.Buffer
+	if err := runSubmissionLog(&out, view{}, log, 1); err != nil {
+		t.Fatalf("runSubmissionLog failed: %v", err)
+	}
+	text := out.String()
+	if strings.Contains(text, "q1") || !strings.Contains(text, "not saved: quiz is locked") || !strings.Contains(text, "1 of these answers were not saved") {
+		t.Fatalf("log output = %q, want only the last, failed record", text)
+	}
+}
+
+func TestSubmissionLogSkipsDamagedLines(t *testing.T) {
+	log := newSubmissionLog(filepath.Join(t.TempDir(), "submissions.jsonl"))
+	if records, err := log.recent(5); err != nil || len(records) != 0 {
+		t.Fatalf("recent on a missing file = (%+v, %v), want nothing", records, err)
+	}
+	for _, questionID := range []string{"q1", "q2", "q3"} {
+		if err := log.append(submissionRecord{QuizID: "quiz-1", QuestionID: questionID}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+	// A crash mid-write leaves a partial last line.
+	file, err := os.OpenFile(log.path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	_, _ = file.WriteString(`{"quiz_id":"quiz-1","quest`)
+	_ = file.Close()
+
+	records, err := log.recent(2)
+	if err != nil || len(records) != 2 || records[0].QuestionID != "q2" || records[1].QuestionID != "q3" {
+		t.Fatalf("recent(2) = (%+v, %v), want q2 and q3", records, err)
+	}
+}
+
+func TestOfflineQueueSignsFailedAnswersAndSyncsThem(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var synced []quiz.SubmittedResponse
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"try again"}`))
+			return
+		}
+		var request struct {
+			Responses []quiz.SubmittedResponse `json:"responses"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		synced = append(synced, request.Responses...)
+		_, _ = w.Write([]byte(`{"results":[{"question_id":"q1","status":"correct"}]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	queue, err := newOfflineQueue(filepath.Join(dir, "offline.jsonl"), filepath.Join(dir, "signing.key"))
+	if err != nil {
+		t.Fatalf("newOfflineQueue failed: %v", err)
+	}
+	client := NewHTTPClient(server.URL, server.Client())
+	log := newSubmissionLog(filepath.Join(dir, "submissions.jsonl"))
+	persister := newAnswerPersister(client, log)
+	persister.retryDelay = 0
+	persister.queue = queue
+	persister.submit("quiz-1", "alice", quiz.SubmittedResponse{QuestionID: "q1", ContentHash: "hash-1", Answer: "A"})
+	persister.wait()
+	if queued := persister.takeQueued(); queued != 1 {
+		t.Fatalf("takeQueued = %d, want the unsent answer queued", queued)
+	}
+
+	// While the server is still down, sync keeps the answer queued.
+	var out bytes.Buffer
+	if err := runSync(context.Background(), &out, client, queue, log, server.URL); err == nil || !strings.Contains(out.String(), "1 are still queued") {
+		t.Fatalf("runSync while down = (%v, %q), want the answer kept", err, out.String())
+	}
+
+	down.Store(false)
+	out.Reset()
+	if err := runSync(context.Background(), &out, client, queue, log, server.URL); err != nil || !strings.Contains(out.String(), "Synced 1 queued answers") {
+		t.Fatalf("runSync = (%v, %q), want one answer synced", err, out.String())
+	}
+	if len(synced) != 1 || synced[0].AnsweredAt == nil {
+		t.Fatalf("synced = %+v, want one signed answer", synced)
+	}
+	signature, _ := base64.StdEncoding.DecodeString(synced[0].Signature)
+	if !ed25519.Verify(queue.key.Public().(ed25519.PublicKey), quiz.SignedAnswerMessage("quiz-1", "alice", synced[0]), signature) {
+		t.Fatalf("synced answer %+v does not verify against the queue's key", synced[0])
+	}
+	if pending, err := readPending(queue.path); err != nil || len(pending) != 0 {
+		t.Fatalf("queue after sync = (%+v, %v), want empty", pending, err)
+	}
+
+	records, err := log.recent(10)
+	if err != nil || len(records) != 2 || !records[0].Queued || !records[1].Synced || records[1].Status != "correct" {
+		t.Fatalf("records = (%+v, %v), want the queued failure then the synced result", records, err)
+	}
+
+	out.Reset()
+	if err := runSync(context.Background(), &out, client, queue, log, server.URL); err != nil || !strings.Contains(out.String(), "No queued answers.") {
+		t.Fatalf("runSync on an empty queue = (%v, %q)", err, out.String())
+	}
+}
@@ -0,0 +1,105 @@
+package userclient
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// commandNames feeds tab completion.
+var commandNames = interactiveCommandNames()
+
+// enableLineEditing switches an interactive terminal into raw mode and routes
+// input/output through x/term so the shell gets history (up/down) and tab
+// completion. Non-terminal input (pipes, tests) is returned unchanged.
+func enableLineEditing(in io.Reader, out io.Writer) (io.Reader, io.Writer, func()) {
+	noop := func() {}
+
+	file, ok := in.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return in, out, noop
+	}
+
+	oldState, err := term.MakeRaw(int(file.Fd()))
+	if err != nil {
+		return in, out, noop
+	}
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{file, out}, "")
+	terminal.AutoCompleteCallback = completeCommand
+
+	restore := func() {
+		_ = term.Restore(int(file.Fd()), oldState)
+	}
+	return &terminalReader{terminal: terminal}, terminal, restore
+}
+
+// terminalReader adapts line-at-a-time terminal reads to io.Reader so existing
+// bufio-based prompt helpers keep working unchanged.
+type terminalReader struct {
+	terminal *term.Terminal
+	pending  []byte
+}
+
+func (r *terminalReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		line, err := r.terminal.ReadLine()
+		if err != nil {
+			return 0, err
+		}
+		r.pending = append([]byte(line), '\n')
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// completeCommand completes the first word of the line on Tab. A unique match is
+// completed with a trailing space; multiple matches extend to their common prefix.
+func completeCommand(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' || pos != len(line) || strings.Contains(line, " ") {
+		return "", 0, false
+	}
+
+	prefix := strings.ToLower(line)
+	matches := make([]string, 0, len(commandNames))
+	for _, name := range commandNames {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", 0, false
+	case 1:
+		completed := matches[0] + " "
+		return completed, len(completed), true
+	}
+
+	sort.Strings(matches)
+	common := commonPrefix(matches[0], matches[len(matches)-1])
+	if len(common) <= len(prefix) {
+		return "", 0, false
+	}
+	return common, len(common), true
+}
+
+func commonPrefix(a, b string) string {
+	limit := len(a)
+	if len(b) < limit {
+		limit = len(b)
+	}
+	idx := 0
+	for idx < limit && a[idx] == b[idx] {
+		idx++
+	}
+	return a[:idx]
+}
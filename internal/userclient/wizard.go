@@ -0,0 +1,395 @@
+package userclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"quiz-app/internal/quiz"
+	"quiz-app/pkg/quizclient"
+)
+
+const (
+	// wizardMaxQuestions and wizardMaxSeconds mirror the server's limits on
+	// question_count and seconds_per_question, so the wizard can re-ask
+	// instead of failing at the end.
+	wizardMaxQuestions = 50
+	wizardMaxSeconds   = 3600
+	// wizardDefaultCount is the server's default question count.
+	wizardDefaultCount = 10
+)
+
+// errCreateCancelled ends the wizard when the host declines the preview.
+var errCreateCancelled = errors.New("quiz not created")
+
+// wizardLevels are the difficulties a mixed quiz is asked for, in prompt order.
+var wizardLevels = []string{"easy", "medium", "hard"}
+
+// runCreateWizard walks the host through creating a quiz: where its questions
+// come from, how many and how hard, a preview, and then the creation call.
+// Questions come from the server's provider, a question bank file, or are
+// typed in. username, when set, seeds the defaults from the user's saved
+// preferences and is credited as the author of typed questions.
+func runCreateWizard(ctx context.Context, reader *bufio.Reader, out io.Writer, style styler, client *HTTPClient, username, serverURL string) error {
+	fmt.Fprintln(out, "Create a quiz. Press Enter to take the default in brackets.")
+	source, err := promptChoice(reader, out, "Questions from (server, file, write)", []string{"server", "file", "write"}, "server")
+	if err != nil {
+		return err
+	}
+
+	var created quizclient.CreatedQuiz
+	switch source {
+	case "server":
+		created, err = createFromServer(ctx, reader, out, client, username, serverURL)
+	case "file":
+		created, err = createFromFile(ctx, reader, out, client, username, serverURL)
+	default:
+		created, err = createFromTyped(ctx, reader, out, style, client, username, serverURL)
+	}
+	if errors.Is(err, errCreateCancelled) {
+		fmt.Fprintln(out, "Nothing was created.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	printCreatedQuiz(out, style, created, serverURL)
+	return nil
+}
+
+// createFromServer has the server fetch questions from its provider.
+func createFromServer(ctx context.Context, reader *bufio.Reader, out io.Writer, client *HTTPClient, username, serverURL string) (quizclient.CreatedQuiz, error) {
+	count, difficulty := wizardDefaultCount, "any"
+	if username != "" {
+		// Saved preferences are only defaults here; failing to read them
+		// leaves the server's.
+		if preferences, err := client.GetPreferences(ctx, username); err == nil {
+			if preferences.QuestionCount > 0 {
+				count = min(preferences.QuestionCount, wizardMaxQuestions)
+			}
+			if preferences.Difficulty != "" {
+				difficulty = preferences.Difficulty
+			}
+		}
+	}
+
+	difficulty, err := promptChoice(reader, out, "Difficulty (easy, medium, hard, mixed, any)", []string{"easy", "medium", "hard", "mixed", "any"}, difficulty)
+	if err != nil {
+		return quizclient.CreatedQuiz{}, err
+	}
+	var mix map[string]int
+	switch difficulty {
+	case "any":
+		if count, err = promptInt(reader, out, "Number of questions", count, 1, wizardMaxQuestions); err != nil {
+			return quizclient.CreatedQuiz{}, err
+		}
+	case "mixed":
+		if mix, count, err = promptDifficultyMix(reader, out); err != nil {
+			return quizclient.CreatedQuiz{}, err
+		}
+	default:
+		if count, err = promptInt(reader, out, "Number of questions", count, 1, wizardMaxQuestions); err != nil {
+			return quizclient.CreatedQuiz{}, err
+		}
+		mix = map[string]int{difficulty: count}
+	}
+	seconds, err := promptInt(reader, out, "Seconds per question, 0 for untimed", 0, 0, wizardMaxSeconds)
+	if err != nil {
+		return quizclient.CreatedQuiz{}, err
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Preview:")
+	fmt.Fprintf(out, "  %d questions from the server's question provider\n", count)
+	fmt.Fprintf(out, "  difficulty: %s\n", describeMix(difficulty, mix))
+	fmt.Fprintf(out, "  timing: %s\n", describeSeconds(seconds))
+	if err := confirmCreate(reader, out); err != nil {
+		return quizclient.CreatedQuiz{}, err
+	}
+
+	// The username is left out: the preferences it would apply were already
+	// offered as defaults, and "any" must not fall back to a saved level.
+	request := quizclient.CreateQuizRequest{SecondsPerQuestion: seconds, DifficultyMix: mix}
+	if mix == nil {
+		request.QuestionCount = count
+	}
+	created, err := client.CreateQuiz(ctx, request)
+	if err != nil {
+		return quizclient.CreatedQuiz{}, describeClientError(err, serverURL)
+	}
+	return created, nil
+}
+
+// promptDifficultyMix asks how many questions of each level a mixed quiz
+// gets, until the total is between one and the server's maximum.
+func promptDifficultyMix(reader *bufio.Reader, out io.Writer) (map[string]int, int, error) {
+	for {
+		mix := make(map[string]int, len(wizardLevels))
+		total := 0
+		for _, level := range wizardLevels {
+			count, err := promptInt(reader, out, strings.ToUpper(level[:1])+level[1:]+" questions", 0, 0, wizardMaxQuestions)
+			if err != nil {
+				return nil, 0, err
+			}
+			if count > 0 {
+				mix[level] = count
+				total += count
+			}
+		}
+		if total >= 1 && total <= wizardMaxQuestions {
+			return mix, total, nil
+		}
+		fmt.Fprintf(out, "Ask for 1 to %d questions in total.\n", wizardMaxQuestions)
+	}
+}
+
+// createFromFile imports a question bank file, previewing it with a dry run
+// first.
+func createFromFile(ctx context.Context, reader *bufio.Reader, out io.Writer, client *HTTPClient, username, serverURL string) (quizclient.CreatedQuiz, error) {
+	var data []byte
+	for {
+		path, err := promptLine(reader, out, "Question file (Aiken, GIFT, or Moodle XML): ")
+		if err != nil {
+			return quizclient.CreatedQuiz{}, err
+		}
+		if path == "" {
+			continue
+		}
+		if data, err = os.ReadFile(path); err == nil {
+			break
+		}
+		fmt.Fprintln(out, err)
+	}
+
+	options := quizclient.ImportOptions{Author: username, DryRun: true}
+	preview, err := client.ImportQuestionsWithOptions(ctx, data, options)
+	if err != nil && len(preview.Errors) == 0 {
+		return quizclient.CreatedQuiz{}, describeClientError(err, serverURL)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Preview:")
+	fmt.Fprintf(out, "  %d questions read as %s\n", preview.QuestionCount, preview.Format)
+	for _, lineErr := range preview.Errors {
+		fmt.Fprintf(out, "  line %d left out: %s\n", lineErr.Line, lineErr.Error)
+	}
+	if err != nil {
+		return quizclient.CreatedQuiz{}, err
+	}
+	if err := confirmCreate(reader, out); err != nil {
+		return quizclient.CreatedQuiz{}, err
+	}
+
+	options.DryRun = false
+	result, err := client.ImportQuestionsWithOptions(ctx, data, options)
+	if err != nil {
+		return quizclient.CreatedQuiz{}, describeClientError(err, serverURL)
+	}
+	if result.Quiz == nil {
+		return quizclient.CreatedQuiz{}, errors.New("the server created no quiz from the file")
+	}
+	return *result.Quiz, nil
+}
+
+// createFromTyped creates a quiz from questions typed in one by one.
+func createFromTyped(ctx context.Context, reader *bufio.Reader, out io.Writer, style styler, client *HTTPClient, username, serverURL string) (quizclient.CreatedQuiz, error) {
+	questions := make([]quiz.Question, 0)
+	for len(questions) < wizardMaxQuestions {
+		fmt.Fprintln(out)
+		prompt, err := promptLine(reader, out, fmt.Sprintf("Question %d (empty line to finish): ", len(questions)+1))
+		if err != nil {
+			return quizclient.CreatedQuiz{}, err
+		}
+		if prompt == "" {
+			if len(questions) > 0 {
+				break
+			}
+			fmt.Fprintln(out, "Add at least one question.")
+			continue
+		}
+
+		options := make([]string, 0, 4)
+		for len(options) < 26 {
+			letter := string(rune('A' + len(options)))
+			hint := ""
+			if len(options) >= 2 {
+				hint = " (empty line to finish)"
+			}
+			option, err := promptLine(reader, out, "  Option "+letter+hint+": ")
+			if err != nil {
+				return quizclient.CreatedQuiz{}, err
+			}
+			if option == "" {
+				if len(options) >= 2 {
+					break
+				}
+				fmt.Fprintln(out, "  A question needs at least two options.")
+				continue
+			}
+			options = append(options, option)
+		}
+
+		correct := ""
+		for correct == "" {
+			line, err := promptLine(reader, out, fmt.Sprintf("  Correct option (A-%c): ", 'A'+len(options)-1))
+			if err != nil {
+				return quizclient.CreatedQuiz{}, err
+			}
+			correct, _ = parseAnswer(line, len(options))
+		}
+
+		question, err := quiz.NewQuestion(prompt, options, int(correct[0]-'A'))
+		if err != nil {
+			fmt.Fprintf(out, "  %v; enter the question again.\n", err)
+			continue
+		}
+		questions = append(questions, question)
+	}
+
+	seconds, err := promptInt(reader, out, "Seconds per question, 0 for untimed", 0, 0, wizardMaxSeconds)
+	if err != nil {
+		return quizclient.CreatedQuiz{}, err
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Preview (%d questions, %s):\n", len(questions), describeSeconds(seconds))
+	for idx, question := range questions {
+		fmt.Fprintf(out, "\n%d. %s\n", idx+1, question.Question)
+		for optionIdx, option := range question.Options {
+			line := fmt.Sprintf("   %s. %s", option.Letter, option.Text)
+			if optionIdx == question.CorrectIndex {
+				line = style.green(line + "  (correct)")
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+	if err := confirmCreate(reader, out); err != nil {
+		return quizclient.CreatedQuiz{}, err
+	}
+
+	request := quizclient.CreateQuizRequest{Author: username, SecondsPerQuestion: seconds}
+	for _, question := range questions {
+		options := make([]string, 0, len(question.Options))
+		for _, option := range question.Options {
+			options = append(options, option.Text)
+		}
+		request.Questions = append(request.Questions, quizclient.NewQuestion{
+			Question:     question.Question,
+			Options:      options,
+			CorrectIndex: question.CorrectIndex,
+		})
+	}
+	created, err := client.CreateQuiz(ctx, request)
+	if err != nil {
+		return quizclient.CreatedQuiz{}, describeClientError(err, serverURL)
+	}
+	return created, nil
+}
+
+func confirmCreate(reader *bufio.Reader, out io.Writer) error {
+	fmt.Fprintln(out)
+	create, err := promptYesNo(reader, out, "Create this quiz? (yes/no): ")
+	if err != nil {
+		return err
+	}
+	if !create {
+		return errCreateCancelled
+	}
+	return nil
+}
+
+// printCreatedQuiz prints what the host shares with players: the quiz ID and
+// a link to its questions.
+func printCreatedQuiz(out io.Writer, style styler, created quizclient.CreatedQuiz, serverURL string) {
+	fmt.Fprintln(out)
+	summary := fmt.Sprintf("Created quiz %s with %d questions", created.QuizID, created.QuestionCount)
+	if created.Origin != nil && created.Origin.Provider != "" {
+		summary += " from " + created.Origin.Provider
+	}
+	fmt.Fprintln(out, style.green(summary+"."))
+	for _, warning := range created.Warnings {
+		fmt.Fprintln(out, "Note: "+warning.Message)
+	}
+	fmt.Fprintf(out, "Quiz ID:   %s\n", style.bold(created.QuizID))
+	fmt.Fprintf(out, "Join link: %s\n", joinLink(serverURL, created.QuizID))
+	fmt.Fprintf(out, "Players can also run: quiz-user-service play %s\n", created.QuizID)
+}
+
+// joinLink is the URL players fetch quizID's questions from.
+func joinLink(serverURL, quizID string) string {
+	return strings.TrimRight(serverURL, "/") + "/questions?" + url.Values{"quiz_id": {quizID}}.Encode()
+}
+
+func describeMix(difficulty string, mix map[string]int) string {
+	if difficulty != "mixed" {
+		return difficulty
+	}
+	parts := make([]string, 0, len(wizardLevels))
+	for _, level := range wizardLevels {
+		if mix[level] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", mix[level], level))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeSeconds(seconds int) string {
+	if seconds == 0 {
+		return "untimed"
+	}
+	return fmt.Sprintf("%ds per question", seconds)
+}
+
+// promptLine reads one trimmed line.
+func promptLine(reader *bufio.Reader, out io.Writer, prompt string) (string, error) {
+	fmt.Fprint(out, prompt)
+	line, err := reader.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptChoice asks until the answer is one of choices, in any case. An empty
+// answer takes defaultValue.
+func promptChoice(reader *bufio.Reader, out io.Writer, prompt string, choices []string, defaultValue string) (string, error) {
+	for {
+		line, err := promptLine(reader, out, fmt.Sprintf("%s [%s]: ", prompt, defaultValue))
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			return defaultValue, nil
+		}
+		for _, choice := range choices {
+			if strings.EqualFold(line, choice) {
+				return choice, nil
+			}
+		}
+		fmt.Fprintf(out, "Please answer %s.\n", strings.Join(choices, ", "))
+	}
+}
+
+// promptInt asks until the answer is an integer in [minValue, maxValue]. An
+// empty answer takes defaultValue.
+func promptInt(reader *bufio.Reader, out io.Writer, prompt string, defaultValue, minValue, maxValue int) (int, error) {
+	for {
+		line, err := promptLine(reader, out, fmt.Sprintf("%s [%d]: ", prompt, defaultValue))
+		if err != nil {
+			return 0, err
+		}
+		if line == "" {
+			return defaultValue, nil
+		}
+		value, err := strconv.Atoi(line)
+		if err == nil && value >= minValue && value <= maxValue {
+			return value, nil
+		}
+		fmt.Fprintf(out, "Please enter a number from %d to %d.\n", minValue, maxValue)
+	}
+}
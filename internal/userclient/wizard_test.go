@@ -0,0 +1,118 @@
+package userclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"quiz-app/pkg/quizclient"
+)
+
+// wizardServer accepts POST /quizzes and keeps the last request body.
+func wizardServer(t *testing.T, created *quizclient.CreateQuizRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/alice/preferences":
+			_ = json.NewEncoder(w).Encode(UserPreferences{Username: "alice", QuestionCount: 8, Difficulty: "hard"})
+		case "/quizzes":
+			if err := json.NewDecoder(r.Body).Decode(created); err != nil {
+				t.Fatalf("decode create request: %v", err)
+			}
+			count := created.QuestionCount + len(created.Questions)
+			for _, n := range created.DifficultyMix {
+				count += n
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(quizclient.CreatedQuiz{QuizID: "qz_new", QuestionCount: count, Origin: &quizclient.QuizOrigin{Provider: "opentdb"}})
+		default:
+			t.Fatalf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCreateWizardTypedQuestionsPreviewsAndShares(t *testing.T) {
+	var created quizclient.CreateQuizRequest
+	server := wizardServer(t, &created)
+	client := NewHTTPClient(server.URL, server.Client())
+
+	input := strings.Join([]string{
+		"write",
+		"Capital of France?", "Paris", "", "Lyon", "", "z", "a",
+		"",
+		"30",
+		"yes",
+	}, "\n") + "\n"
+	var out bytes.Buffer
+	if err := runCreateWizard(context.Background(), bufio.NewReader(strings.NewReader(input)), &out, styler{}, client, "alice", server.URL); err != nil {
+		t.Fatalf("runCreateWizard failed: %v\n%s", err, out.String())
+	}
+
+	if len(created.Questions) != 1 || created.Author != "alice" || created.SecondsPerQuestion != 30 {
+		t.Fatalf("create request = %+v, want one question by alice at 30s", created)
+	}
+	if question := created.Questions[0]; question.Question != "Capital of France?" || len(question.Options) != 2 || question.CorrectIndex != 0 {
+		t.Fatalf("question = %+v, want Paris (A) of two options", question)
+	}
+	for _, want := range []string{
+		"A question needs at least two options.",
+		"A. Paris  (correct)",
+		"Created quiz qz_new with 1 questions",
+		"Join link: " + server.URL + "/questions?quiz_id=qz_new",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestCreateWizardServerQuestionsUsesPreferencesAndMix(t *testing.T) {
+	var created quizclient.CreateQuizRequest
+	server := wizardServer(t, &created)
+	client := NewHTTPClient(server.URL, server.Client())
+
+	// Taking the defaults asks for alice's saved 8 hard questions.
+	var out bytes.Buffer
+	if err := runCreateWizard(context.Background(), bufio.NewReader(strings.NewReader("\n\n\n\nyes\n")), &out, styler{}, client, "alice", server.URL); err != nil {
+		t.Fatalf("runCreateWizard failed: %v\n%s", err, out.String())
+	}
+	if created.QuestionCount != 0 || len(created.DifficultyMix) != 1 || created.DifficultyMix["hard"] != 8 || created.Username != "" {
+		t.Fatalf("create request = %+v, want a mix of 8 hard questions", created)
+	}
+
+	created = quizclient.CreateQuizRequest{}
+	out.Reset()
+	input := "server\nmixed\n0\n0\n0\n2\nfour\n3\n\n0\nyes\n"
+	if err := runCreateWizard(context.Background(), bufio.NewReader(strings.NewReader(input)), &out, styler{}, client, "", server.URL); err != nil {
+		t.Fatalf("runCreateWizard failed: %v\n%s", err, out.String())
+	}
+	if created.DifficultyMix["easy"] != 2 || created.DifficultyMix["medium"] != 3 || len(created.DifficultyMix) != 2 {
+		t.Fatalf("create request = %+v, want 2 easy and 3 medium", created)
+	}
+	if !strings.Contains(out.String(), "Ask for 1 to 50 questions in total.") || !strings.Contains(out.String(), "difficulty: 2 easy, 3 medium") {
+		t.Fatalf("output missing the retry or the mix preview:\n%s", out.String())
+	}
+}
+
+func TestCreateWizardDecliningCreatesNothing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+	client := NewHTTPClient(server.URL, server.Client())
+
+	var out bytes.Buffer
+	if err := runCreateWizard(context.Background(), bufio.NewReader(strings.NewReader("server\nany\n5\n0\nno\n")), &out, styler{}, client, "", server.URL); err != nil {
+		t.Fatalf("runCreateWizard failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Nothing was created.") {
+		t.Fatalf("output = %s, want the cancellation", out.String())
+	}
+}
@@ -0,0 +1,92 @@
+// Package webhook delivers service events to host-configured HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Sender posts JSON payloads to webhook URLs. Delivery is fire-and-forget:
+// one attempt per event, with failures logged rather than returned, so a slow
+// or broken receiver never holds up quiz traffic. Wait lets shutdown finish
+// the deliveries in flight.
+type Sender struct {
+	client  *http.Client
+	timeout time.Duration
+	pending sync.WaitGroup
+}
+
+func NewSender(client *http.Client) *Sender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Sender{
+		client:  client,
+		timeout: defaultTimeout,
+	}
+}
+
+// Send delivers payload to url in the background.
+func (s *Sender) Send(url string, payload any) {
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+
+		if err := s.Deliver(ctx, url, payload); err != nil {
+			log.Printf("webhook delivery failed url=%s err=%v", url, err)
+		}
+	}()
+}
+
+// Wait blocks until every delivery started by Send has finished, or until
+// ctx is done, in which case it returns ctx's error.
+func (s *Sender) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Deliver posts payload to url and waits for the response. Any non-2xx status
+// is an error.
+func (s *Sender) Deliver(ctx context.Context, url string, payload any) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "quiz-service-webhook")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeliverPostsJSON(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s content-type=%q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := NewSender(server.Client())
+	if err := sender.Deliver(context.Background(), server.URL, map[string]string{"event": "ping"}); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+	if got["event"] != "ping" {
+		t.Fatalf("received payload = %v, want event=ping", got)
+	}
+}
+
+func TestDeliverRejectsNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sender := NewSender(server.Client())
+	if err := sender.Deliver(context.Background(), server.URL, struct{}{}); err == nil {
+		t.Fatalf("expected error for 500 response")
+	}
+}
+
+func TestWaitFinishesPendingSends(t *testing.T) {
+	release := make(chan struct{})
+	delivered := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+		delivered <- struct{}{}
+	}))
+	defer server.Close()
+
+	sender := NewSender(server.Client())
+	sender.Send(server.URL, map[string]string{"event": "ping"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := sender.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait with a delivery in flight = %v, want the context's deadline", err)
+	}
+
+	close(release)
+	if err := sender.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	select {
+	case <-delivered:
+	default:
+		t.Fatal("Wait returned before the delivery finished")
+	}
+}
@@ -0,0 +1,142 @@
+package quizclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ListAdminQuizzes returns one page of every quiz on the server with its
+// activity and storage. It needs the admin token; AdminQuizPager walks all
+// the pages.
+func (c *Client) ListAdminQuizzes(ctx context.Context, query AdminQuizQuery) (AdminQuizPage, error) {
+	values := url.Values{}
+	setInt(values, "limit", query.Limit)
+	setInt(values, "offset", query.Offset)
+	setString(values, "sort", query.Sort)
+	setString(values, "order", query.Order)
+	return call[AdminQuizPage](ctx, c, http.MethodGet, withQuery("/admin/quizzes", values), nil)
+}
+
+// AdminQuizPager walks ListAdminQuizzes page by page:
+//
+//	pager := client.AdminQuizPager(quizclient.AdminQuizQuery{Sort: "attempts"})
+//	for pager.Next(ctx) {
+//		for _, quiz := range pager.Page() {
+//			...
+//		}
+//	}
+//	if err := pager.Err(); err != nil {
+//		...
+//	}
+//
+// Quizzes created while it runs can shift later pages, so one may be seen
+// twice or not at all.
+type AdminQuizPager struct {
+	client *Client
+	query  AdminQuizQuery
+	page   []AdminQuiz
+	done   bool
+	err    error
+}
+
+// AdminQuizPager starts a walk at query.Offset, query.Limit quizzes at a time.
+func (c *Client) AdminQuizPager(query AdminQuizQuery) *AdminQuizPager {
+	return &AdminQuizPager{client: c, query: query}
+}
+
+// Next fetches the next page and reports whether it has any quizzes. It
+// returns false after the last page or a failure; see Err.
+func (p *AdminQuizPager) Next(ctx context.Context) bool {
+	if p.done {
+		return false
+	}
+	page, err := p.client.ListAdminQuizzes(ctx, p.query)
+	if err != nil {
+		p.err, p.done, p.page = err, true, nil
+		return false
+	}
+	p.page = page.Quizzes
+	// The server caps the page size, so the next offset is what it returned.
+	p.query.Offset = page.Offset + len(page.Quizzes)
+	if len(page.Quizzes) == 0 || p.query.Offset >= page.Total {
+		p.done = true
+	}
+	return len(page.Quizzes) > 0
+}
+
+// Page returns the quizzes fetched by the last call to Next.
+func (p *AdminQuizPager) Page() []AdminQuiz {
+	return p.page
+}
+
+// Err returns the failure that stopped the walk, if any.
+func (p *AdminQuizPager) Err() error {
+	return p.err
+}
+
+// ListBundles lists the question bundles embedded in the server and whether
+// new quizzes draw from them.
+func (c *Client) ListBundles(ctx context.Context) ([]Bundle, error) {
+	payload, err := call[struct {
+		Bundles []Bundle `json:"bundles"`
+	}](ctx, c, http.MethodGet, "/admin/bundles", nil)
+	return payload.Bundles, err
+}
+
+// LoadBundle adds an embedded bundle to the pool new quizzes draw from.
+func (c *Client) LoadBundle(ctx context.Context, name string) (Bundle, error) {
+	path, err := expand("/admin/bundles/{name}", name)
+	if err != nil {
+		return Bundle{}, err
+	}
+	return call[Bundle](ctx, c, http.MethodPost, path, nil)
+}
+
+// GetPoolStats reports what the loaded bundles can still serve.
+func (c *Client) GetPoolStats(ctx context.Context) (PoolStats, error) {
+	return call[PoolStats](ctx, c, http.MethodGet, "/admin/pool/stats", nil)
+}
+
+// ListRetirements lists questions flagged for retirement with the given
+// status: pending, retired, kept, or all. Empty means pending.
+func (c *Client) ListRetirements(ctx context.Context, status string) ([]RetirementReview, error) {
+	values := url.Values{}
+	setString(values, "status", status)
+	payload, err := call[struct {
+		Questions []RetirementReview `json:"questions"`
+	}](ctx, c, http.MethodGet, withQuery("/admin/retirements", values), nil)
+	return payload.Questions, err
+}
+
+// DecideRetirement retires a flagged question from new quizzes ("retire") or
+// keeps it ("keep").
+func (c *Client) DecideRetirement(ctx context.Context, questionID, decision string) (RetirementReview, error) {
+	path, err := expand("/admin/retirements/{question_id}", questionID)
+	if err != nil {
+		return RetirementReview{}, err
+	}
+	request := struct {
+		Decision string `json:"decision"`
+	}{Decision: decision}
+	return call[RetirementReview](ctx, c, http.MethodPost, path, request)
+}
+
+// GetDebugVars returns the server's expvars by name, such as memstats and
+// recovered handler panics.
+func (c *Client) GetDebugVars(ctx context.Context) (map[string]json.RawMessage, error) {
+	return call[map[string]json.RawMessage](ctx, c, http.MethodGet, "/debug/vars", nil)
+}
+
+// GetOpenAPI returns the server's OpenAPI 3.1 document as JSON, for code
+// generators and API explorers.
+func (c *Client) GetOpenAPI(ctx context.Context) ([]byte, error) {
+	var data []byte
+	err := c.send(ctx, http.MethodGet, "/openapi.json", "", nil, func(response *http.Response) (err error) {
+		data, err = io.ReadAll(response.Body)
+		return err
+	})
+	return data, err
+}
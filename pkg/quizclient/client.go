@@ -0,0 +1,365 @@
+package quizclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the server New talks to when given an empty base URL: a
+// quiz-service started with its default flags.
+const DefaultBaseURL = "http://127.0.0.1:8080"
+
+// playerTokenHeader carries the token from verifying a username; see
+// Client.VerifyIdentity.
+const playerTokenHeader = "X-Player-Token"
+
+// maxErrorBodyBytes bounds how much of a failed response is read for its
+// error message.
+const maxErrorBodyBytes = 1 << 20
+
+// ErrUnavailable wraps failures to reach the server at all, such as a refused
+// connection or a timeout. The request may or may not have been applied.
+var ErrUnavailable = errors.New("quiz service unavailable")
+
+// APIError is a non-2xx response. Retryable marks failures that may succeed
+// if sent again unchanged, such as an overloaded server; RetryAfter is how long
+// the server asked callers to wait, or zero when it did not say.
+type APIError struct {
+	StatusCode int
+	Message    string
+	// RequestID identifies the request in the server log, when the server
+	// sent one.
+	RequestID string
+
+	Retryable  bool
+	RetryAfter time.Duration
+
+	// body is the response, for endpoints that report details alongside the
+	// error, such as question imports.
+	body []byte
+}
+
+func (e *APIError) Error() string {
+	if strings.TrimSpace(e.Message) == "" {
+		return fmt.Sprintf("request failed with status %d", e.StatusCode)
+	}
+	return e.Message
+}
+
+// RetryPolicy says how often a Client sends a failed read again.
+type RetryPolicy struct {
+	// Attempts is how many times a request is sent in all; one or less
+	// disables retries.
+	Attempts int
+	// Delay is the wait after the first failure when the server names none;
+	// it grows linearly with each try. Zero means half a second.
+	Delay time.Duration
+	// MaxRetryAfter is the longest server-requested wait a request sits
+	// through; a longer Retry-After returns the error instead. Zero means
+	// five seconds.
+	MaxRetryAfter time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Delay <= 0 {
+		p.Delay = 500 * time.Millisecond
+	}
+	if p.MaxRetryAfter <= 0 {
+		p.MaxRetryAfter = 5 * time.Second
+	}
+	return p
+}
+
+// Client calls one quiz-service. It is safe for concurrent use; With returns
+// a copy with different options.
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	playerToken  string
+	accountToken string
+	adminToken   string
+	retry        RetryPolicy
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests through httpClient instead of
+// http.DefaultClient. Its Timeout also ends leaderboard streams.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithPlayerToken sends the player token of a verified username, which the
+// server requires with that username's answers and signing keys. Empty sends
+// none.
+func WithPlayerToken(token string) Option {
+	return func(c *Client) { c.playerToken = strings.TrimSpace(token) }
+}
+
+// WithAccountToken sends an account token from Login as the bearer token.
+// Answers are then stored as its username, and SubmitResponses may leave the
+// username empty. WithAdminToken takes its place when both are set. Empty
+// sends none.
+func WithAccountToken(token string) Option {
+	return func(c *Client) { c.accountToken = strings.TrimSpace(token) }
+}
+
+// WithAdminToken sends token as the bearer token that admin and host endpoints
+// require. A quiz's host token works in its place for that quiz's host
+// endpoints. Empty sends none.
+func WithAdminToken(token string) Option {
+	return func(c *Client) { c.adminToken = strings.TrimSpace(token) }
+}
+
+// WithRetry sends failed reads again as policy says. Only retryable failures
+// are: an unreachable server, 408, 429, and 5xx responses.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy.withDefaults() }
+}
+
+// New returns a client for the server at baseURL, such as
+// "https://quiz.example.com". Without options it sends no tokens and does not
+// retry.
+func New(baseURL string, options ...Option) *Client {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	c := &Client{baseURL: baseURL, httpClient: http.DefaultClient}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// With returns a copy of c with options applied on top of its own, such as a
+// player token received from VerifyIdentity.
+func (c *Client) With(options ...Option) *Client {
+	next := *c
+	for _, option := range options {
+		option(&next)
+	}
+	return &next
+}
+
+// BaseURL returns the server address requests go to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Do sends a request to path, which may carry a query string, with body
+// encoded as JSON unless it is nil, and decodes a 2xx response into out unless
+// out is nil. It is what the typed methods are built on, for endpoints newer
+// than this package.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	var encoded []byte
+	contentType := ""
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			return err
+		}
+		contentType = "application/json"
+	}
+	return c.send(ctx, method, path, contentType, encoded, func(response *http.Response) error {
+		if out == nil {
+			return nil
+		}
+		return json.NewDecoder(response.Body).Decode(out)
+	})
+}
+
+// send makes the request, retrying idempotent methods under the client's
+// policy, and hands 2xx responses to read.
+func (c *Client) send(ctx context.Context, method, path, contentType string, body []byte, read func(*http.Response) error) error {
+	attempts := 1
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		attempts = max(c.retry.Attempts, 1)
+	}
+	for tries := 1; ; tries++ {
+		err := c.sendOnce(ctx, method, path, contentType, body, read)
+		if err == nil || tries >= attempts {
+			return err
+		}
+		retryable, wait := RetryAdvice(err)
+		if !retryable || wait > c.retry.MaxRetryAfter {
+			return err
+		}
+		if wait == 0 {
+			wait = c.retry.Delay * time.Duration(tries)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) sendOnce(ctx context.Context, method, path, contentType string, body []byte, read func(*http.Response) error) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if c.playerToken != "" {
+		request.Header.Set(playerTokenHeader, c.playerToken)
+	}
+	if c.adminToken != "" {
+		request.Header.Set("Authorization", "Bearer "+c.adminToken)
+	} else if c.accountToken != "" {
+		request.Header.Set("Authorization", "Bearer "+c.accountToken)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		data, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyBytes))
+		return newAPIError(response, data)
+	}
+	return read(response)
+}
+
+// newAPIError builds the error for a non-2xx response, with retry advice from
+// its status and Retry-After header. The message is the server's error text,
+// or the status text when the body has none.
+func newAPIError(response *http.Response, body []byte) *APIError {
+	var payload struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	apiErr := &APIError{
+		StatusCode: response.StatusCode,
+		Message:    strings.TrimSpace(payload.Error),
+		RequestID:  payload.RequestID,
+		body:       body,
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = response.Status
+	}
+	switch response.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		apiErr.Retryable = true
+	}
+	apiErr.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+	return apiErr
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date.
+// It returns zero when the header is missing, malformed, or in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// RetryAdvice reports whether err is worth sending again, and after how long
+// when the server said. Unreachable servers are retryable; rejections are not.
+func RetryAdvice(err error) (retryable bool, after time.Duration) {
+	if errors.Is(err, ErrUnavailable) {
+		return true, 0
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable, apiErr.RetryAfter
+	}
+	return false, 0
+}
+
+// call sends a request and decodes the response into a new T.
+func call[T any](ctx context.Context, c *Client, method, path string, body any) (T, error) {
+	var out T
+	if err := c.Do(ctx, method, path, body, &out); err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+// expand fills the {name} placeholders of a route pattern such as
+// "/quizzes/{quiz_id}/leaderboard" with values, in order, escaping each one.
+// An empty value is an error naming the placeholder.
+func expand(pattern string, values ...string) (string, error) {
+	var path strings.Builder
+	rest := pattern
+	for _, value := range values {
+		start, end := strings.IndexByte(rest, '{'), strings.IndexByte(rest, '}')
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return "", fmt.Errorf("%s is required", rest[start+1:end])
+		}
+		path.WriteString(rest[:start])
+		path.WriteString(url.PathEscape(value))
+		rest = rest[end+1:]
+	}
+	path.WriteString(rest)
+	return path.String(), nil
+}
+
+// withQuery appends query to path when it has any values.
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// setInt adds key to query when value is positive.
+func setInt(query url.Values, key string, value int) {
+	if value > 0 {
+		query.Set(key, strconv.Itoa(value))
+	}
+}
+
+// setString adds key to query when value is not blank.
+func setString(query url.Values, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		query.Set(key, value)
+	}
+}
+
+// setBool adds key=true to query when value is set.
+func setBool(query url.Values, key string, value bool) {
+	if value {
+		query.Set(key, "true")
+	}
+}
@@ -0,0 +1,20 @@
+// Package quizclient is the Go client for the quiz-service HTTP API. It has a
+// typed method for every endpoint in docs/api.md, takes a context on every
+// call, and reports failures as *APIError or ErrUnavailable.
+//
+// Create a Client with New, passing the tokens it should send:
+//
+//	client := quizclient.New("https://quiz.example.com",
+//		quizclient.WithAdminToken(os.Getenv("QUIZ_ADMIN_TOKEN")),
+//		quizclient.WithRetry(quizclient.RetryPolicy{Attempts: 3}),
+//	)
+//	quizzes, err := client.ListActiveQuizzes(ctx, 10)
+//
+// Only reads and replacing writes (GET, PUT, DELETE) are retried; a retried
+// POST could create a second quiz or score an answer twice. Endpoints added to
+// the server after this package can be called with Client.Do.
+//
+// The package depends only on the standard library and pkg/quizkit, whose
+// question and answer types it shares. Exported identifiers are kept backward
+// compatible.
+package quizclient
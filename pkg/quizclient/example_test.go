@@ -0,0 +1,67 @@
+package quizclient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"quiz-app/pkg/quizclient"
+)
+
+func ExampleNew() {
+	client := quizclient.New("https://quiz.example.com",
+		quizclient.WithRetry(quizclient.RetryPolicy{Attempts: 3}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	quizzes, err := client.ListActiveQuizzes(ctx, 5)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, quiz := range quizzes {
+		fmt.Println(quiz.QuizID, quiz.QuestionCount)
+	}
+}
+
+func ExampleClient_AdminQuizPager() {
+	client := quizclient.New("https://quiz.example.com", quizclient.WithAdminToken("admin-token"))
+
+	pager := client.AdminQuizPager(quizclient.AdminQuizQuery{Limit: 50, Sort: "attempts"})
+	for pager.Next(context.Background()) {
+		for _, quiz := range pager.Page() {
+			fmt.Println(quiz.QuizID, quiz.AttemptCount)
+		}
+	}
+	if err := pager.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleClient_StreamLeaderboard() {
+	client := quizclient.New("https://quiz.example.com")
+
+	lastEventID := ""
+	err := client.StreamLeaderboard(context.Background(), "quiz-123", lastEventID, func(event quizclient.LeaderboardEvent) error {
+		lastEventID = event.ID
+		for _, entry := range event.Entries {
+			fmt.Println(entry.Rank, entry.Username, entry.TotalScore)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleAPIError() {
+	client := quizclient.New("https://quiz.example.com")
+
+	_, err := client.GetQuizResults(context.Background(), "quiz-123")
+	var apiErr *quizclient.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		fmt.Println("the quiz has not locked yet")
+	}
+}
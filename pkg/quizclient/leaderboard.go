@@ -0,0 +1,139 @@
+package quizclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetLeaderboard returns a quiz's standings. A negative query.Limit asks for
+// as many entries as the server allows.
+func (c *Client) GetLeaderboard(ctx context.Context, quizID string, query LeaderboardQuery) (Leaderboard, error) {
+	path, err := expand("/quizzes/{quiz_id}/leaderboard", quizID)
+	if err != nil {
+		return Leaderboard{}, err
+	}
+	values := url.Values{}
+	if query.Limit != 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	setString(values, "category", query.Category)
+	return call[Leaderboard](ctx, c, http.MethodGet, withQuery(path, values), nil)
+}
+
+// StreamLeaderboard calls handle with each change to a quiz's standings until
+// ctx is done, handle fails, or the server ends the stream. Pass the ID of the
+// last event handled as lastEventID to resume after it; empty starts with a
+// snapshot. A closing event, sent when the server shuts down, is handled and
+// then the stream ends with a nil error.
+func (c *Client) StreamLeaderboard(ctx context.Context, quizID, lastEventID string, handle func(LeaderboardEvent) error) error {
+	path, err := expand("/quizzes/{quiz_id}/leaderboard/stream", quizID)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	setString(values, "last_event_id", lastEventID)
+
+	err = c.send(ctx, http.MethodGet, withQuery(path, values), "", nil, func(response *http.Response) error {
+		return readEvents(response, func(id, data string) (bool, error) {
+			var event LeaderboardEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return false, fmt.Errorf("leaderboard event: %w", err)
+			}
+			event.ID = id
+			return event.Type == EventClosing, handle(event)
+		})
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// readEvents parses server-sent events and passes the id and data of each to
+// handle, until handle fails or reports the last event. Only the id, event,
+// and data fields are used; the server sends one data line per event.
+func readEvents(response *http.Response, handle func(id, data string) (last bool, err error)) error {
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxErrorBodyBytes)
+	var id, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				id = value
+			case "data":
+				data = value
+			}
+			continue
+		}
+		if data == "" {
+			continue
+		}
+		last, err := handle(id, data)
+		if err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		data = ""
+	}
+	return scanner.Err()
+}
+
+// GetLeaderboardSettings returns a quiz's default leaderboard size, freeze,
+// lock time, and tiebreak.
+func (c *Client) GetLeaderboardSettings(ctx context.Context, quizID string) (LeaderboardSettings, error) {
+	path, err := expand("/quizzes/{quiz_id}/leaderboard/settings", quizID)
+	if err != nil {
+		return LeaderboardSettings{}, err
+	}
+	return call[LeaderboardSettings](ctx, c, http.MethodGet, path, nil)
+}
+
+// SetLeaderboardSettings replaces a quiz's leaderboard settings. It needs the
+// admin or a host token.
+func (c *Client) SetLeaderboardSettings(ctx context.Context, quizID string, settings LeaderboardSettingsUpdate) (LeaderboardSettings, error) {
+	path, err := expand("/quizzes/{quiz_id}/leaderboard/settings", quizID)
+	if err != nil {
+		return LeaderboardSettings{}, err
+	}
+	return call[LeaderboardSettings](ctx, c, http.MethodPut, path, settings)
+}
+
+// GetGlobalLeaderboard ranks players across every quiz, or across the quizzes
+// of a season.
+func (c *Client) GetGlobalLeaderboard(ctx context.Context, query GlobalLeaderboardQuery) (GlobalLeaderboard, error) {
+	values := url.Values{}
+	if query.Limit != 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	setString(values, "order", query.Order)
+	if !query.Since.IsZero() {
+		values.Set("since", query.Since.UTC().Format(time.RFC3339))
+	}
+	if !query.Until.IsZero() {
+		values.Set("until", query.Until.UTC().Format(time.RFC3339))
+	}
+	return call[GlobalLeaderboard](ctx, c, http.MethodGet, withQuery("/leaderboard/global", values), nil)
+}
+
+// GetQuizResults returns a locked quiz's final results. Before the quiz locks
+// the server answers 409.
+func (c *Client) GetQuizResults(ctx context.Context, quizID string) (QuizResults, error) {
+	path, err := expand("/quizzes/{quiz_id}/results.json", quizID)
+	if err != nil {
+		return QuizResults{}, err
+	}
+	return call[QuizResults](ctx, c, http.MethodGet, path, nil)
+}
@@ -0,0 +1,113 @@
+package quizclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// GetQuestions serves a quiz's questions, creating the quiz when query has no
+// QuizID or asks for CreateIfMissing. Adaptive quizzes are served one question
+// at a time by GetNextQuestion instead.
+func (c *Client) GetQuestions(ctx context.Context, query QuestionsQuery) (QuizQuestions, error) {
+	values := url.Values{}
+	setString(values, "quiz_id", query.QuizID)
+	setString(values, "username", query.Username)
+	setBool(values, "create_if_missing", query.CreateIfMissing)
+	setBool(values, "include_correct", query.IncludeCorrect)
+	setInt(values, "question_count", query.QuestionCount)
+	setString(values, "lang", query.Language)
+	setInt(values, "from", query.From)
+	setInt(values, "category", query.Category)
+	setString(values, "difficulty", query.Difficulty)
+	setString(values, "type", query.Type)
+	return call[QuizQuestions](ctx, c, http.MethodGet, withQuery("/questions", values), nil)
+}
+
+// SearchQuestions finds stored questions whose prompt contains text. A zero
+// limit uses the server's default.
+func (c *Client) SearchQuestions(ctx context.Context, text string, limit int) ([]quizkit.PublicQuestion, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("search text is required")
+	}
+	values := url.Values{"q": {text}}
+	setInt(values, "limit", limit)
+	payload, err := call[struct {
+		Results []quizkit.PublicQuestion `json:"results"`
+	}](ctx, c, http.MethodGet, withQuery("/questions/search", values), nil)
+	return payload.Results, err
+}
+
+// ReportQuestion flags a question as broken or unclear.
+func (c *Client) ReportQuestion(ctx context.Context, questionID, username, reason string) error {
+	path, err := expand("/questions/{question_id}/reports", questionID)
+	if err != nil {
+		return err
+	}
+	request := struct {
+		Username string `json:"username"`
+		Reason   string `json:"reason"`
+	}{Username: username, Reason: reason}
+	return c.Do(ctx, http.MethodPost, path, request, nil)
+}
+
+// GetQuestionTags returns the tags of a stored question.
+func (c *Client) GetQuestionTags(ctx context.Context, questionID string) ([]string, error) {
+	path, err := expand("/questions/{question_id}/tags", questionID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := call[struct {
+		Tags []string `json:"tags"`
+	}](ctx, c, http.MethodGet, path, nil)
+	return payload.Tags, err
+}
+
+// SetQuestionTags replaces the tags of a stored question and returns them as
+// the server normalized them. It needs the admin token.
+func (c *Client) SetQuestionTags(ctx context.Context, questionID string, tags []string) ([]string, error) {
+	path, err := expand("/questions/{question_id}/tags", questionID)
+	if err != nil {
+		return nil, err
+	}
+	request := struct {
+		Tags []string `json:"tags"`
+	}{Tags: tags}
+	payload, err := call[struct {
+		Tags []string `json:"tags"`
+	}](ctx, c, http.MethodPut, path, request)
+	return payload.Tags, err
+}
+
+// AddBankQuestions adds questions to the ad-hoc bank, so EvaluateAnswers can
+// check answers to them, and returns their IDs in order.
+func (c *Client) AddBankQuestions(ctx context.Context, questions []NewQuestion) ([]string, error) {
+	request := struct {
+		Questions []NewQuestion `json:"questions"`
+	}{Questions: questions}
+	payload, err := call[struct {
+		QuestionIDs []string `json:"question_ids"`
+	}](ctx, c, http.MethodPost, "/bank/questions", request)
+	return payload.QuestionIDs, err
+}
+
+// GetBankStats returns the ad-hoc bank's size and hit counters.
+func (c *Client) GetBankStats(ctx context.Context) (BankStats, error) {
+	return call[BankStats](ctx, c, http.MethodGet, "/bank/stats", nil)
+}
+
+// GetAuthorPerformance reports how the questions credited to author did.
+func (c *Client) GetAuthorPerformance(ctx context.Context, author string) ([]QuestionPerformance, error) {
+	path, err := expand("/authors/{author}/questions/performance", author)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := call[struct {
+		Questions []QuestionPerformance `json:"questions"`
+	}](ctx, c, http.MethodGet, path, nil)
+	return payload.Questions, err
+}
@@ -0,0 +1,336 @@
+package quizclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"quiz-app/internal/httpapi"
+	"quiz-app/internal/quiz"
+	"quiz-app/internal/quiz/sqlite"
+	"quiz-app/pkg/quizkit"
+)
+
+// TestClientCoversEveryRoute calls every method against a server built from the
+// route registry, so an endpoint added without a client method fails here.
+func TestClientCoversEveryRoute(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+	)
+	mux := http.NewServeMux()
+	for _, route := range httpapi.Routes() {
+		pattern := route.Pattern
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			seen[r.Method+" "+pattern] = true
+			mu.Unlock()
+			if strings.HasSuffix(pattern, "/stream") {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte("retry: 2000\n\nevent: closing\ndata: {\"type\":\"closing\"}\n\n"))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"added":{"username":"bo"}}`))
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx := context.Background()
+	c := New(server.URL)
+	calls := []func() error{
+		func() error { _, err := c.GetQuestions(ctx, QuestionsQuery{QuizID: "q"}); return err },
+		func() error { _, err := c.SearchQuestions(ctx, "capital", 5); return err },
+		func() error { _, err := c.GetQuestionTags(ctx, "qn"); return err },
+		func() error { _, err := c.SetQuestionTags(ctx, "qn", []string{"geo"}); return err },
+		func() error { return c.ReportQuestion(ctx, "qn", "al", "typo") },
+		func() error { _, err := c.AddBankQuestions(ctx, nil); return err },
+		func() error { _, err := c.GetBankStats(ctx); return err },
+		func() error { _, err := c.GetAuthorPerformance(ctx, "al"); return err },
+
+		func() error { _, err := c.CreateQuiz(ctx, CreateQuizRequest{QuestionCount: 3}); return err },
+		func() error {
+			_, err := c.CreateQuizzesFromBank(ctx, BankQuizRequest{Tags: []string{"geo"}, QuestionCount: 3})
+			return err
+		},
+		func() error { _, err := c.ListActiveQuizzes(ctx, 5); return err },
+		func() error {
+			_, err := c.ImportQuestions(ctx, []byte("Q?\nA. x\nANSWER: A\n"), ImportOptions{})
+			return err
+		},
+		func() error { _, err := c.ImportQuizBundle(ctx, QuizBundle{}); return err },
+		func() error { _, err := c.ArchiveQuiz(ctx, "q"); return err },
+		func() error { _, err := c.DeleteQuiz(ctx, "q"); return err },
+		func() error { _, err := c.GetDailyQuiz(ctx); return err },
+		func() error { _, err := c.GetQuizState(ctx, "q"); return err },
+		func() error { _, err := c.SetQuizState(ctx, "q", QuizStateUpdate{State: "active"}); return err },
+		func() error { _, err := c.GetRoster(ctx, "q"); return err },
+		func() error { _, err := c.GetRosterCSV(ctx, "q"); return err },
+		func() error { _, err := c.SetRoster(ctx, "q", RosterUpdate{}); return err },
+		func() error {
+			return c.StreamQuizEvents(ctx, "q", func(SubmissionEvent) error { return nil })
+		},
+		func() error { _, err := c.JoinQuiz(ctx, "q", "code"); return err },
+		func() error { _, err := c.Rematch(ctx, "q", "same"); return err },
+		func() error { _, err := c.GetNextQuestion(ctx, "q", "al", ""); return err },
+		func() error { _, err := c.GetAttemptReview(ctx, "q", "al"); return err },
+		func() error { _, err := c.GetAnswerKey(ctx, "q"); return err },
+		func() error { _, err := c.GetAnswerPositions(ctx, "q"); return err },
+		func() error { _, err := c.RebalanceAnswerPositions(ctx, "q"); return err },
+		func() error { _, err := c.ExportQuizBundle(ctx, "q", true); return err },
+		func() error { _, err := c.GetAuditLog(ctx, "q"); return err },
+		func() error { _, err := c.ListHosts(ctx, "q"); return err },
+		func() error { _, err := c.AddHost(ctx, "q", HostRequest{Username: "bo"}); return err },
+		func() error { _, err := c.GetServeLog(ctx, "q"); return err },
+		func() error { return c.VoidQuestion(ctx, "q", "qn") },
+		func() error { _, err := c.AddCompletionWebhook(ctx, "q", CompletionWebhook{}); return err },
+		func() error { _, err := c.GetStatsOverview(ctx); return err },
+
+		func() error { _, err := c.SubmitResponses(ctx, "q", "al", nil); return err },
+		func() error { _, err := c.SubmitOnBehalf(ctx, "q", OnBehalfRequest{}); return err },
+		func() error { _, err := c.EvaluateAnswers(ctx, nil); return err },
+
+		func() error { _, err := c.GetLeaderboard(ctx, "q", LeaderboardQuery{}); return err },
+		func() error {
+			return c.StreamLeaderboard(ctx, "q", "", func(LeaderboardEvent) error { return nil })
+		},
+		func() error { _, err := c.GetLeaderboardSettings(ctx, "q"); return err },
+		func() error { _, err := c.SetLeaderboardSettings(ctx, "q", LeaderboardSettingsUpdate{}); return err },
+		func() error { _, err := c.GetQuizResults(ctx, "q"); return err },
+		func() error { _, err := c.GetGlobalLeaderboard(ctx, GlobalLeaderboardQuery{}); return err },
+
+		func() error { _, err := c.ListAdminQuizzes(ctx, AdminQuizQuery{}); return err },
+		func() error { _, err := c.ListBundles(ctx); return err },
+		func() error { _, err := c.LoadBundle(ctx, "geo"); return err },
+		func() error { _, err := c.GetPoolStats(ctx); return err },
+		func() error { _, err := c.ListRetirements(ctx, ""); return err },
+		func() error { _, err := c.DecideRetirement(ctx, "qn", "keep"); return err },
+		func() error { _, err := c.GetDebugVars(ctx); return err },
+		func() error { _, err := c.GetOpenAPI(ctx); return err },
+
+		func() error { _, err := c.Register(ctx, "al", "correct horse"); return err },
+		func() error { _, err := c.Login(ctx, "al", "correct horse"); return err },
+		func() error { _, err := c.ListBookmarks(ctx, "al"); return err },
+		func() error { _, err := c.AddBookmark(ctx, "al", "qn"); return err },
+		func() error { return c.RemoveBookmark(ctx, "al", "qn") },
+		func() error { _, err := c.CreatePracticeQuiz(ctx, "al", 0); return err },
+		func() error { _, err := c.GetProfile(ctx, "al"); return err },
+		func() error { _, err := c.SetProfile(ctx, "al", true); return err },
+		func() error { _, err := c.GetPreferences(ctx, "al"); return err },
+		func() error { _, err := c.SetPreferences(ctx, "al", Preferences{}); return err },
+		func() error { _, err := c.GetUserStats(ctx, "al"); return err },
+		func() error { _, err := c.GetUserAttempts(ctx, "al", 5); return err },
+		func() error { _, err := c.GetIdentity(ctx, "al"); return err },
+		func() error { _, err := c.StartVerification(ctx, "al", "al@example.com"); return err },
+		func() error { _, err := c.VerifyIdentity(ctx, "al", "123456", ""); return err },
+		func() error { _, err := c.RegisterSigningKey(ctx, "al", "key"); return err },
+	}
+	for idx, call := range calls {
+		if err := call(); err != nil {
+			t.Fatalf("call %d failed: %v", idx, err)
+		}
+	}
+
+	// The magic link in the verification email is opened in a browser; clients
+	// POST the same token. The WebSocket leaderboard carries the same events
+	// as the stream StreamLeaderboard reads.
+	skipped := map[string]bool{
+		"GET /users/{username}/identity/verify": true,
+		"GET /quizzes/{quiz_id}/leaderboard/ws": true,
+	}
+	var missing []string
+	for _, route := range httpapi.Routes() {
+		for _, method := range route.Methods {
+			key := method + " " + route.Pattern
+			if !seen[key] && !skipped[key] {
+				missing = append(missing, key)
+			}
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Fatalf("no client method calls %v", missing)
+	}
+}
+
+func newTestService(t *testing.T) *httptest.Server {
+	t.Helper()
+	store, err := sqlite.NewSQLiteStore(filepath.Join(t.TempDir(), "client.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	server := httptest.NewServer(httpapi.NewRouterWithOptions(quiz.NewService(store, store, nil), quiz.NewBank(), httpapi.RouterOptions{AdminToken: "admin-secret"}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientPlaysAQuizAgainstTheService(t *testing.T) {
+	server := newTestService(t)
+	ctx := context.Background()
+	client := New(server.URL, WithHTTPClient(server.Client()))
+
+	created, err := client.CreateQuiz(ctx, CreateQuizRequest{Questions: []NewQuestion{
+		{Question: "Capital of France?", Options: []string{"Berlin", "Paris"}, CorrectIndex: 1},
+		{Question: "2 + 2?", Options: []string{"4", "5"}, CorrectIndex: 0},
+	}})
+	if err != nil || len(created.QuestionIDs) != 2 {
+		t.Fatalf("CreateQuiz = (%+v, %v), want a quiz with two questions", created, err)
+	}
+
+	served, err := client.GetQuestions(ctx, QuestionsQuery{QuizID: created.QuizID, Username: "alice"})
+	if err != nil || len(served.Questions) != 2 || served.Questions[0].ContentHash == "" {
+		t.Fatalf("GetQuestions = (%+v, %v), want both questions with hashes", served, err)
+	}
+	submission, err := client.SubmitResponses(ctx, created.QuizID, "alice", []quizkit.SubmittedResponse{
+		{QuestionID: served.Questions[0].QuestionID, Answer: "B", ContentHash: served.Questions[0].ContentHash},
+		{QuestionID: served.Questions[1].QuestionID, Answer: "B", ContentHash: served.Questions[1].ContentHash},
+	})
+	if err != nil || len(submission.Results) != 2 || submission.Results[0].Status != quizkit.StatusCorrect || submission.Results[1].Status != quizkit.StatusIncorrect {
+		t.Fatalf("SubmitResponses = (%+v, %v), want correct then incorrect", submission, err)
+	}
+
+	board, err := client.GetLeaderboard(ctx, created.QuizID, LeaderboardQuery{})
+	if err != nil || len(board.Leaderboard) != 1 || board.Leaderboard[0].Username != "alice" || board.Leaderboard[0].AnsweredCount != 2 {
+		t.Fatalf("GetLeaderboard = (%+v, %v), want alice with two answers", board, err)
+	}
+
+	var apiErr *APIError
+	if _, err := client.GetAnswerKey(ctx, created.QuizID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetAnswerKey without a token error = %v, want 401", err)
+	}
+	key, err := client.With(WithAdminToken("admin-secret")).GetAnswerKey(ctx, created.QuizID)
+	if err != nil || len(key.Questions) != 2 || key.Questions[0].CorrectLetter != "B" {
+		t.Fatalf("GetAnswerKey with the admin token = (%+v, %v), want the answers", key, err)
+	}
+
+	result, err := client.ImportQuestions(ctx, []byte("not a question bank"), ImportOptions{Format: "aiken"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || result.Format != "aiken" {
+		t.Fatalf("ImportQuestions = (%+v, %v), want a 400 with the parse report", result, err)
+	}
+	if _, err := client.GetLeaderboard(ctx, " ", LeaderboardQuery{}); err == nil || err.Error() != "quiz_id is required" {
+		t.Fatalf("GetLeaderboard without a quiz error = %v, want quiz_id is required", err)
+	}
+}
+
+func TestAdminQuizPagerWalksEveryPage(t *testing.T) {
+	server := newTestService(t)
+	ctx := context.Background()
+	client := New(server.URL, WithHTTPClient(server.Client()), WithAdminToken("admin-secret"))
+
+	want := make(map[string]bool)
+	for range 5 {
+		created, err := client.CreateQuiz(ctx, CreateQuizRequest{Questions: []NewQuestion{
+			{Question: "Capital of France?", Options: []string{"Berlin", "Paris"}, CorrectIndex: 1},
+		}})
+		if err != nil {
+			t.Fatalf("CreateQuiz failed: %v", err)
+		}
+		want[created.QuizID] = true
+	}
+
+	pager := client.AdminQuizPager(AdminQuizQuery{Limit: 2, Sort: "created_at", Order: "asc"})
+	pages := 0
+	for pager.Next(ctx) {
+		pages++
+		for _, item := range pager.Page() {
+			if !want[item.QuizID] {
+				t.Fatalf("page %d has %s twice or unexpectedly", pages, item.QuizID)
+			}
+			delete(want, item.QuizID)
+		}
+	}
+	if err := pager.Err(); err != nil || pages != 3 || len(want) != 0 {
+		t.Fatalf("pager = (%d pages, %d quizzes unseen, %v), want 3 pages covering all", pages, len(want), err)
+	}
+
+	pager = New(server.URL, WithHTTPClient(server.Client())).AdminQuizPager(AdminQuizQuery{})
+	var apiErr *APIError
+	if pager.Next(ctx) || !errors.As(pager.Err(), &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("pager without a token = %v, want a 401", pager.Err())
+	}
+}
+
+func TestRetryPolicyRetriesReadsOnly(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1)%2 == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"question provider is busy","request_id":"req-1"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"quizzes":[{"quiz_id":"quiz-1"}]}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	client := New(server.URL, WithHTTPClient(server.Client()), WithRetry(RetryPolicy{Attempts: 3, Delay: time.Millisecond}))
+
+	quizzes, err := client.ListActiveQuizzes(ctx, 5)
+	if err != nil || len(quizzes) != 1 || calls.Load() != 2 {
+		t.Fatalf("ListActiveQuizzes = (%v, %v after %d calls), want success on the second try", quizzes, err, calls.Load())
+	}
+
+	calls.Store(0)
+	_, err = client.CreateQuiz(ctx, CreateQuizRequest{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.Retryable || apiErr.RequestID != "req-1" || calls.Load() != 1 {
+		t.Fatalf("CreateQuiz = (%v after %d calls), want one retryable failure", err, calls.Load())
+	}
+
+	if _, err := New("http://127.0.0.1:1").GetBankStats(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("unreachable server error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestStreamLeaderboardResumesFromEventIDs(t *testing.T) {
+	var lastEventID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventID = r.URL.Query().Get("last_event_id")
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("retry: 2000\n\n" +
+			"id: 7\nevent: delta\ndata: {\"type\":\"delta\",\"quiz_id\":\"q\",\"entries\":[{\"rank\":1,\"username\":\"al\",\"total_score\":2}],\"participants\":1}\n\n" +
+			": keep-alive\n\n" +
+			"event: closing\ndata: {\"type\":\"closing\",\"message\":\"bye\"}\n\n" +
+			"id: 8\nevent: delta\ndata: {\"type\":\"delta\"}\n\n"))
+	}))
+	defer server.Close()
+
+	var events []LeaderboardEvent
+	err := New(server.URL).StreamLeaderboard(context.Background(), "q", "6", func(event LeaderboardEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil || lastEventID != "6" {
+		t.Fatalf("StreamLeaderboard = %v with last_event_id %q, want nil resuming from 6", err, lastEventID)
+	}
+	if len(events) != 2 || events[0].ID != "7" || events[0].Entries[0].Rank != 1 || events[0].Entries[0].Username != "al" || events[1].Type != EventClosing {
+		t.Fatalf("events = %+v, want the delta then closing", events)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"soon":                          0,
+		"-3":                            0,
+		"2":                             2 * time.Second,
+		"Mon, 02 Mar 2026 12:00:30 GMT": 30 * time.Second,
+		"Mon, 02 Mar 2026 11:00:00 GMT": 0,
+	}
+	for value, want := range cases {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Fatalf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package quizclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CreateQuiz creates a quiz from request. Check the result's Warnings: a quiz
+// can be created with fewer questions than asked for.
+func (c *Client) CreateQuiz(ctx context.Context, request CreateQuizRequest) (CreatedQuiz, error) {
+	return call[CreatedQuiz](ctx, c, http.MethodPost, "/quizzes", request)
+}
+
+// CreateQuizzesFromBank creates quizzes from tagged questions already stored
+// on the server, without the question provider.
+func (c *Client) CreateQuizzesFromBank(ctx context.Context, request BankQuizRequest) ([]CreatedQuiz, error) {
+	payload, err := call[struct {
+		Quizzes []CreatedQuiz `json:"quizzes"`
+	}](ctx, c, http.MethodPost, "/quizzes/from-bank", request)
+	return payload.Quizzes, err
+}
+
+// ListActiveQuizzes returns the most recently created quizzes. A zero limit
+// uses the server's default.
+func (c *Client) ListActiveQuizzes(ctx context.Context, limit int) ([]ActiveQuiz, error) {
+	values := url.Values{}
+	setInt(values, "limit", limit)
+	payload, err := call[struct {
+		Quizzes []ActiveQuiz `json:"quizzes"`
+	}](ctx, c, http.MethodGet, withQuery("/quizzes/active", values), nil)
+	return payload.Quizzes, err
+}
+
+// ImportQuestions creates a quiz from a question bank file in Aiken, GIFT, or
+// Moodle XML. When the server rejects the file the result is returned with the
+// *APIError, so its per-line errors can be shown.
+func (c *Client) ImportQuestions(ctx context.Context, data []byte, options ImportOptions) (ImportResult, error) {
+	values := url.Values{}
+	setString(values, "format", options.Format)
+	setString(values, "author", options.Author)
+	setBool(values, "practice", options.Practice)
+	setBool(values, "dry_run", options.DryRun)
+
+	var result ImportResult
+	err := c.send(ctx, http.MethodPost, withQuery("/quizzes/import", values), "text/plain; charset=utf-8", data, func(response *http.Response) error {
+		return json.NewDecoder(response.Body).Decode(&result)
+	})
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		_ = json.Unmarshal(apiErr.body, &result)
+	}
+	return result, err
+}
+
+// ImportQuizBundle recreates a quiz exported with answers under a new ID.
+// Leaderboard settings the server could not apply come back as warnings.
+func (c *Client) ImportQuizBundle(ctx context.Context, bundle QuizBundle) (CreatedQuiz, error) {
+	return call[CreatedQuiz](ctx, c, http.MethodPost, "/quizzes/import-bundle", bundle)
+}
+
+// GetDailyQuiz returns today's daily quiz, which the server creates on first
+// use.
+func (c *Client) GetDailyQuiz(ctx context.Context) (CreatedQuiz, error) {
+	return call[CreatedQuiz](ctx, c, http.MethodGet, "/quizzes/daily", nil)
+}
+
+// ArchiveQuiz locks a quiz, publishes its results, and takes it off the
+// active list. Its leaderboard and results stay readable. It needs the admin
+// token.
+func (c *Client) ArchiveQuiz(ctx context.Context, quizID string) (QuizRemoval, error) {
+	return c.removeQuiz(ctx, quizID, "archive")
+}
+
+// DeleteQuiz removes a quiz and everything stored for it. It needs the admin
+// token.
+func (c *Client) DeleteQuiz(ctx context.Context, quizID string) (QuizRemoval, error) {
+	return c.removeQuiz(ctx, quizID, "delete")
+}
+
+func (c *Client) removeQuiz(ctx context.Context, quizID, mode string) (QuizRemoval, error) {
+	path, err := expand("/quizzes/{quiz_id}", quizID)
+	if err != nil {
+		return QuizRemoval{}, err
+	}
+	return call[QuizRemoval](ctx, c, http.MethodDelete, withQuery(path, url.Values{"mode": {mode}}), nil)
+}
+
+// GetQuizState returns a quiz's lifecycle state and deadline.
+func (c *Client) GetQuizState(ctx context.Context, quizID string) (QuizState, error) {
+	path, err := expand("/quizzes/{quiz_id}/state", quizID)
+	if err != nil {
+		return QuizState{}, err
+	}
+	return call[QuizState](ctx, c, http.MethodGet, path, nil)
+}
+
+// SetQuizState activates, locks, or reschedules a quiz. Locking is final. It
+// needs the admin or a host token.
+func (c *Client) SetQuizState(ctx context.Context, quizID string, update QuizStateUpdate) (QuizState, error) {
+	path, err := expand("/quizzes/{quiz_id}/state", quizID)
+	if err != nil {
+		return QuizState{}, err
+	}
+	return call[QuizState](ctx, c, http.MethodPut, path, update)
+}
+
+// GetRoster lists a quiz's roster with live standings. It needs the admin or
+// a host token.
+func (c *Client) GetRoster(ctx context.Context, quizID string) (Roster, error) {
+	path, err := expand("/quizzes/{quiz_id}/roster", quizID)
+	if err != nil {
+		return Roster{}, err
+	}
+	return call[Roster](ctx, c, http.MethodGet, path, nil)
+}
+
+// GetRosterCSV returns the roster as a spreadsheet with a header row.
+func (c *Client) GetRosterCSV(ctx context.Context, quizID string) ([]byte, error) {
+	path, err := expand("/quizzes/{quiz_id}/roster", quizID)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	err = c.send(ctx, http.MethodGet, path+"?format=csv", "", nil, func(response *http.Response) (err error) {
+		data, err = io.ReadAll(response.Body)
+		return err
+	})
+	return data, err
+}
+
+// StreamQuizEvents calls handle with each submission to a quiz, after a
+// snapshot of every player's progress, until ctx is done, handle fails, or the
+// server ends the stream. It needs the admin or a host token. A closing
+// event, sent when the server shuts down or the quiz is deleted, is handled
+// and then the stream ends with a nil error; call again for a fresh snapshot.
+func (c *Client) StreamQuizEvents(ctx context.Context, quizID string, handle func(SubmissionEvent) error) error {
+	path, err := expand("/quizzes/{quiz_id}/events", quizID)
+	if err != nil {
+		return err
+	}
+	err = c.send(ctx, http.MethodGet, path, "", nil, func(response *http.Response) error {
+		return readEvents(response, func(_, data string) (bool, error) {
+			var event SubmissionEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return false, fmt.Errorf("submission event: %w", err)
+			}
+			return event.Type == EventClosing, handle(event)
+		})
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// SetRoster replaces a quiz's roster and returns it with standings.
+func (c *Client) SetRoster(ctx context.Context, quizID string, roster RosterUpdate) (Roster, error) {
+	path, err := expand("/quizzes/{quiz_id}/roster", quizID)
+	if err != nil {
+		return Roster{}, err
+	}
+	return call[Roster](ctx, c, http.MethodPut, path, roster)
+}
+
+// JoinQuiz looks up the rostered username a student's join code belongs to.
+func (c *Client) JoinQuiz(ctx context.Context, quizID, joinCode string) (JoinResult, error) {
+	path, err := expand("/quizzes/{quiz_id}/join", quizID)
+	if err != nil {
+		return JoinResult{}, err
+	}
+	request := struct {
+		JoinCode string `json:"join_code"`
+	}{JoinCode: joinCode}
+	return call[JoinResult](ctx, c, http.MethodPost, path, request)
+}
+
+// Rematch creates a quiz with the settings of quizID. questions is "fresh" or
+// "same"; empty picks by how the quiz was created.
+func (c *Client) Rematch(ctx context.Context, quizID, questions string) (CreatedQuiz, error) {
+	path, err := expand("/quizzes/{quiz_id}/rematch", quizID)
+	if err != nil {
+		return CreatedQuiz{}, err
+	}
+	request := struct {
+		Questions string `json:"questions"`
+	}{Questions: strings.TrimSpace(questions)}
+	return call[CreatedQuiz](ctx, c, http.MethodPost, path, request)
+}
+
+// GetNextQuestion returns the next question of an adaptive quiz for username,
+// in language when the question has it.
+func (c *Client) GetNextQuestion(ctx context.Context, quizID, username, language string) (NextQuestion, error) {
+	path, err := expand("/quizzes/{quiz_id}/next", quizID)
+	if err != nil {
+		return NextQuestion{}, err
+	}
+	values := url.Values{}
+	setString(values, "username", username)
+	setString(values, "lang", language)
+	return call[NextQuestion](ctx, c, http.MethodGet, withQuery(path, values), nil)
+}
+
+// GetAttemptReview returns username's answers in a quiz next to each
+// question. The correct answers are included once the player has answered
+// every question or the quiz has locked; AnswersRevealed says which.
+func (c *Client) GetAttemptReview(ctx context.Context, quizID, username string) (AttemptReview, error) {
+	path, err := expand("/quizzes/{quiz_id}/attempts/{username}", quizID, username)
+	if err != nil {
+		return AttemptReview{}, err
+	}
+	return call[AttemptReview](ctx, c, http.MethodGet, path, nil)
+}
+
+// GetAnswerKey returns a quiz's questions with their answers. It needs the
+// admin or a host token, and the server logs that it was served.
+func (c *Client) GetAnswerKey(ctx context.Context, quizID string) (AnswerKey, error) {
+	path, err := expand("/quizzes/{quiz_id}/answer-key", quizID)
+	if err != nil {
+		return AnswerKey{}, err
+	}
+	return call[AnswerKey](ctx, c, http.MethodGet, path, nil)
+}
+
+// GetAnswerPositions reports how often each letter is a quiz's correct answer.
+func (c *Client) GetAnswerPositions(ctx context.Context, quizID string) (AnswerPositions, error) {
+	path, err := expand("/quizzes/{quiz_id}/answer-positions", quizID)
+	if err != nil {
+		return AnswerPositions{}, err
+	}
+	return call[AnswerPositions](ctx, c, http.MethodGet, path, nil)
+}
+
+// RebalanceAnswerPositions moves correct answers of unanswered questions off
+// over-used letters. Moved questions get new IDs, so run it before players
+// fetch the quiz.
+func (c *Client) RebalanceAnswerPositions(ctx context.Context, quizID string) (AnswerPositions, error) {
+	path, err := expand("/quizzes/{quiz_id}/answer-positions", quizID)
+	if err != nil {
+		return AnswerPositions{}, err
+	}
+	return call[AnswerPositions](ctx, c, http.MethodPost, path, nil)
+}
+
+// ExportQuizBundle exports a quiz for another deployment. withAnswers adds
+// the answers, which ImportQuizBundle needs, and requires the admin or a host
+// token.
+func (c *Client) ExportQuizBundle(ctx context.Context, quizID string, withAnswers bool) (QuizBundle, error) {
+	path, err := expand("/quizzes/{quiz_id}/bundle", quizID)
+	if err != nil {
+		return QuizBundle{}, err
+	}
+	values := url.Values{}
+	setBool(values, "answers", withAnswers)
+	return call[QuizBundle](ctx, c, http.MethodGet, withQuery(path, values), nil)
+}
+
+// GetAuditLog lists the admin and host actions taken in a quiz, oldest first.
+func (c *Client) GetAuditLog(ctx context.Context, quizID string) ([]AuditEntry, error) {
+	path, err := expand("/quizzes/{quiz_id}/audit", quizID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := call[struct {
+		Entries []AuditEntry `json:"entries"`
+	}](ctx, c, http.MethodGet, path, nil)
+	return payload.Entries, err
+}
+
+// ListHosts lists a quiz's hosts.
+func (c *Client) ListHosts(ctx context.Context, quizID string) ([]Host, error) {
+	path, err := expand("/quizzes/{quiz_id}/hosts", quizID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := call[struct {
+		Hosts []Host `json:"hosts"`
+	}](ctx, c, http.MethodGet, path, nil)
+	return payload.Hosts, err
+}
+
+// AddHost adds a co-host or transfers ownership, and returns the new host's
+// token. Keep it: the server does not show it again.
+func (c *Client) AddHost(ctx context.Context, quizID string, request HostRequest) (AddedHost, error) {
+	path, err := expand("/quizzes/{quiz_id}/hosts", quizID)
+	if err != nil {
+		return AddedHost{}, err
+	}
+	payload, err := call[struct {
+		Added *AddedHost `json:"added"`
+	}](ctx, c, http.MethodPost, path, request)
+	if err != nil {
+		return AddedHost{}, err
+	}
+	if payload.Added == nil {
+		return AddedHost{}, errors.New("server response has no added host")
+	}
+	return *payload.Added, nil
+}
+
+// GetServeLog lists who fetched a quiz's questions, and whether with the
+// answer key.
+func (c *Client) GetServeLog(ctx context.Context, quizID string) ([]ServeEntry, error) {
+	path, err := expand("/quizzes/{quiz_id}/serves", quizID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := call[struct {
+		Serves []ServeEntry `json:"serves"`
+	}](ctx, c, http.MethodGet, path, nil)
+	return payload.Serves, err
+}
+
+// VoidQuestion voids a question mid-event: it is no longer scored.
+func (c *Client) VoidQuestion(ctx context.Context, quizID, questionID string) error {
+	path, err := expand("/quizzes/{quiz_id}/questions/{question_id}/void", quizID, questionID)
+	if err != nil {
+		return err
+	}
+	return c.Do(ctx, http.MethodPost, path, nil, nil)
+}
+
+// AddCompletionWebhook asks the server to notify webhook.URL when
+// participants complete a quiz.
+func (c *Client) AddCompletionWebhook(ctx context.Context, quizID string, webhook CompletionWebhook) (CompletionWebhook, error) {
+	path, err := expand("/quizzes/{quiz_id}/webhooks", quizID)
+	if err != nil {
+		return CompletionWebhook{}, err
+	}
+	webhook.QuizID = ""
+	return call[CompletionWebhook](ctx, c, http.MethodPost, path, webhook)
+}
+
+// GetStatsOverview returns service-wide activity.
+func (c *Client) GetStatsOverview(ctx context.Context) (StatsOverview, error) {
+	return call[StatsOverview](ctx, c, http.MethodGet, "/stats/overview", nil)
+}
@@ -0,0 +1,43 @@
+package quizclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// SubmitResponses scores and stores username's answers to quizID. Answers
+// for a registered username need WithAccountToken, which also supplies the
+// username when it is empty, and a verified username needs WithPlayerToken.
+// It is never retried: a resent batch would be answered already_attempted.
+func (c *Client) SubmitResponses(ctx context.Context, quizID, username string, responses []quizkit.SubmittedResponse) (Submission, error) {
+	request := struct {
+		QuizID    string                      `json:"quiz_id,omitempty"`
+		Username  string                      `json:"username,omitempty"`
+		Responses []quizkit.SubmittedResponse `json:"responses"`
+	}{QuizID: strings.TrimSpace(quizID), Username: strings.TrimSpace(username), Responses: responses}
+	return call[Submission](ctx, c, http.MethodPost, "/responses", request)
+}
+
+// SubmitOnBehalf enters a player's answers for them, such as from a paper
+// answer sheet. It needs the admin or a host token and is audited.
+func (c *Client) SubmitOnBehalf(ctx context.Context, quizID string, request OnBehalfRequest) ([]quizkit.ResponseResult, error) {
+	path, err := expand("/quizzes/{quiz_id}/responses/on-behalf", quizID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := call[Submission](ctx, c, http.MethodPost, path, request)
+	return payload.Results, err
+}
+
+// EvaluateAnswers checks answers to questions in the ad-hoc bank without
+// storing them.
+func (c *Client) EvaluateAnswers(ctx context.Context, responses []quizkit.SubmittedResponse) ([]quizkit.ResponseResult, error) {
+	request := struct {
+		Responses []quizkit.SubmittedResponse `json:"responses"`
+	}{Responses: responses}
+	payload, err := call[Submission](ctx, c, http.MethodPost, "/bank/evaluate", request)
+	return payload.Results, err
+}
@@ -0,0 +1,927 @@
+package quizclient
+
+import (
+	"time"
+
+	"quiz-app/pkg/quizkit"
+)
+
+// Warning codes the server attaches to requests that succeeded, but not
+// exactly as asked. They are stable; messages are for humans and may change.
+const (
+	WarningNotPersisted        = "not_persisted"
+	WarningQuestionCountCapped = "question_count_capped"
+	WarningProviderShortfall   = "provider_shortfall"
+	WarningDifficultyShortfall = "difficulty_shortfall"
+	WarningSettingsNotApplied  = "settings_not_applied"
+	WarningProviderFallback    = "provider_fallback"
+	WarningAnswerKeyWithheld   = "answer_key_withheld"
+)
+
+// Leaderboard and submission stream event types. Both streams start with a
+// snapshot and end with closing.
+const (
+	EventSnapshot   = "snapshot"
+	EventDelta      = "delta"
+	EventSubmission = "submission"
+	EventClosing    = "closing"
+)
+
+// Warning reports a soft failure. Field names the request parameter involved,
+// when there is one.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// ScoringPolicy describes the server's scoring rules.
+type ScoringPolicy struct {
+	CorrectPoints       float64 `json:"correct_points"`
+	IncorrectPoints     float64 `json:"incorrect_points"`
+	AttemptsPerQuestion int     `json:"attempts_per_question"`
+	RevealPolicy        string  `json:"reveal_policy"`
+	// SpeedBonus is set when fast correct answers earn extra points.
+	SpeedBonus *SpeedBonus `json:"speed_bonus,omitempty"`
+	// ServerScored is set when the server never serves correct answers;
+	// verdicts come only from SubmitResponses.
+	ServerScored bool `json:"server_scored,omitempty"`
+}
+
+// SpeedBonus adds up to MaxPoints for correct answers given within
+// WindowSeconds, falling off along Curve.
+type SpeedBonus struct {
+	MaxPoints     float64 `json:"max_points"`
+	WindowSeconds float64 `json:"window_seconds"`
+	Curve         string  `json:"curve"`
+}
+
+// QuizOrigin records how a quiz was created. FallbackFrom names the provider
+// that failed when Provider is a fallback.
+type QuizOrigin struct {
+	Provider      string                     `json:"provider"`
+	FallbackFrom  string                     `json:"fallback_from,omitempty"`
+	DifficultyMix map[quizkit.Difficulty]int `json:"difficulty_mix,omitempty"`
+	Seed          int64                      `json:"seed,omitempty"`
+	Category      int                        `json:"category,omitempty"`
+	Type          string                     `json:"type,omitempty"`
+}
+
+// QuestionsQuery selects the questions GetQuestions returns. An empty QuizID
+// creates a new quiz.
+type QuestionsQuery struct {
+	QuizID string
+	// Username marks the questions served to that player and reports their
+	// earlier answers.
+	Username        string
+	CreateIfMissing bool
+	// IncludeCorrect asks for correct answers; the server honors it only for
+	// quizzes that reveal them, and never in server-scoring mode.
+	IncludeCorrect bool
+	QuestionCount  int
+	// Language serves translated text where a question has it, such as "es".
+	Language string
+	// From skips the first From questions, for a client resuming a quiz.
+	From int
+	// Category, Difficulty, and Type narrow the questions of a quiz the
+	// request creates: an OpenTriviaDB category ID, easy, medium, or hard,
+	// and multiple or boolean.
+	Category   int
+	Difficulty string
+	Type       string
+}
+
+// QuizQuestions is a quiz and the questions served from it. The creation
+// fields are set only when the request created the quiz.
+type QuizQuestions struct {
+	QuizID             string        `json:"quiz_id"`
+	QuestionCount      int           `json:"question_count"`
+	State              string        `json:"state"`
+	Locked             bool          `json:"locked"`
+	ClosesAt           *time.Time    `json:"closes_at,omitempty"`
+	Practice           bool          `json:"practice,omitempty"`
+	Adaptive           bool          `json:"adaptive,omitempty"`
+	Scoring            ScoringPolicy `json:"scoring"`
+	From               int           `json:"from,omitempty"`
+	Questions          []Question    `json:"questions"`
+	Warnings           []Warning     `json:"warnings,omitempty"`
+	SecondsPerQuestion int           `json:"seconds_per_question,omitempty"`
+	Origin             *QuizOrigin   `json:"origin,omitempty"`
+
+	Created                bool       `json:"created,omitempty"`
+	CreatedAt              *time.Time `json:"created_at,omitempty"`
+	RequestedQuestionCount int        `json:"requested_question_count,omitempty"`
+	ActualQuestionCount    int        `json:"actual_question_count,omitempty"`
+}
+
+// Question is one question as served to a player. ContentHash, and Nonce when
+// the server issues serve nonces, are sent back with the answer.
+type Question struct {
+	QuestionID     string               `json:"question_id"`
+	Question       string               `json:"question"`
+	Options        []quizkit.Option     `json:"options"`
+	Type           quizkit.QuestionType `json:"type,omitempty"`
+	CorrectIndex   *int                 `json:"correct_index,omitempty"`
+	CorrectIndexes []int                `json:"correct_indexes,omitempty"`
+	AttemptStatus  string               `json:"attempt_status"`
+	AttemptScore   *float64             `json:"attempt_score,omitempty"`
+	Voided         bool                 `json:"voided,omitempty"`
+	ContentHash    string               `json:"content_hash"`
+	Nonce          string               `json:"nonce,omitempty"`
+	Language       string               `json:"language,omitempty"`
+	Languages      []string             `json:"languages,omitempty"`
+}
+
+// NewQuestion is a question supplied by the caller. Options are lettered A, B,
+// C... by position.
+type NewQuestion struct {
+	Question     string   `json:"question"`
+	Options      []string `json:"options"`
+	CorrectIndex int      `json:"correct_index"`
+	// Type is empty for a single-answer question, or multi_select, whose
+	// answers are CorrectIndexes, or true_false, whose options are True and
+	// False and may be left out.
+	Type           quizkit.QuestionType `json:"type,omitempty"`
+	CorrectIndexes []int                `json:"correct_indexes,omitempty"`
+	// Feedback optionally explains each option, by position.
+	Feedback []string `json:"feedback,omitempty"`
+	// Difficulty is easy, medium, or hard; empty leaves the question untagged.
+	Difficulty   string                         `json:"difficulty,omitempty"`
+	Translations map[string]quizkit.Translation `json:"translations,omitempty"`
+}
+
+// CreateQuizRequest creates a quiz from fetched questions, or from Questions
+// when it has any.
+type CreateQuizRequest struct {
+	QuestionCount int `json:"question_count,omitempty"`
+	// Username applies that user's saved preferences where the request leaves
+	// them unset.
+	Username  string        `json:"username,omitempty"`
+	Questions []NewQuestion `json:"questions,omitempty"`
+	// Author credits supplied questions to a user.
+	Author             string         `json:"author,omitempty"`
+	Practice           bool           `json:"practice,omitempty"`
+	Adaptive           bool           `json:"adaptive,omitempty"`
+	SecondsPerQuestion int            `json:"seconds_per_question,omitempty"`
+	DifficultyMix      map[string]int `json:"difficulty_mix,omitempty"`
+	// Category, Difficulty, and Type narrow fetched questions; Difficulty
+	// cannot be combined with DifficultyMix.
+	Category   int    `json:"category,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Type       string `json:"type,omitempty"`
+	// Draft creates the quiz as a draft that takes no answers until a host
+	// activates it; ClosesAt is when it stops taking answers.
+	Draft    bool       `json:"draft,omitempty"`
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+}
+
+// BankQuizRequest asks CreateQuizzesFromBank for QuizCount quizzes (one when
+// zero) of QuestionCount stored questions each, drawn from questions that
+// carry every tag and, when set, the difficulty. No question is used twice.
+type BankQuizRequest struct {
+	Tags               []string   `json:"tags,omitempty"`
+	Difficulty         string     `json:"difficulty,omitempty"`
+	QuestionCount      int        `json:"question_count,omitempty"`
+	QuizCount          int        `json:"quiz_count,omitempty"`
+	SecondsPerQuestion int        `json:"seconds_per_question,omitempty"`
+	Draft              bool       `json:"draft,omitempty"`
+	ClosesAt           *time.Time `json:"closes_at,omitempty"`
+}
+
+// CreatedQuiz describes a new quiz. ContentHashes lines up with QuestionIDs.
+type CreatedQuiz struct {
+	QuizID             string             `json:"quiz_id"`
+	QuestionCount      int                `json:"question_count"`
+	CreatedAt          time.Time          `json:"created_at"`
+	State              string             `json:"state"`
+	ClosesAt           *time.Time         `json:"closes_at,omitempty"`
+	Practice           bool               `json:"practice,omitempty"`
+	Adaptive           bool               `json:"adaptive,omitempty"`
+	QuestionIDs        []string           `json:"question_ids,omitempty"`
+	ContentHashes      []string           `json:"content_hashes,omitempty"`
+	Warnings           []Warning          `json:"warnings,omitempty"`
+	SecondsPerQuestion int                `json:"seconds_per_question,omitempty"`
+	DifficultyMix      []DifficultyBucket `json:"difficulty_mix,omitempty"`
+	Origin             *QuizOrigin        `json:"origin,omitempty"`
+}
+
+// DifficultyBucket is how many questions of one difficulty a quiz asked for
+// and got.
+type DifficultyBucket struct {
+	Difficulty quizkit.Difficulty `json:"difficulty"`
+	Requested  int                `json:"requested"`
+	Delivered  int                `json:"delivered"`
+}
+
+// ImportOptions control ImportQuestions. An empty Format lets the server
+// detect it.
+type ImportOptions struct {
+	// Format is aiken, gift, or moodle_xml.
+	Format   string
+	Author   string
+	Practice bool
+	// DryRun parses the file without creating a quiz.
+	DryRun bool
+}
+
+// ImportResult reports a question bank import. Quiz is nil for a dry run or
+// when nothing could be imported; Errors lists the questions left out.
+type ImportResult struct {
+	Format        string            `json:"format,omitempty"`
+	QuestionCount int               `json:"question_count"`
+	Errors        []ImportLineError `json:"errors,omitempty"`
+	Quiz          *CreatedQuiz      `json:"quiz,omitempty"`
+}
+
+// ImportLineError is a question the server could not import from a file.
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// QuizBundle is a quiz exported by one deployment for another to recreate
+// with ImportQuizBundle. Bundles exported without answers cannot be imported.
+type QuizBundle struct {
+	Format          string             `json:"format"`
+	Version         int                `json:"version"`
+	ExportedAt      time.Time          `json:"exported_at"`
+	SourceQuizID    string             `json:"source_quiz_id,omitempty"`
+	IncludesAnswers bool               `json:"includes_answers"`
+	Settings        QuizBundleSettings `json:"settings"`
+	Questions       []BundleQuestion   `json:"questions"`
+}
+
+// QuizBundleSettings are the quiz settings a bundle carries.
+type QuizBundleSettings struct {
+	Practice           bool                       `json:"practice,omitempty"`
+	Adaptive           bool                       `json:"adaptive,omitempty"`
+	SecondsPerQuestion int                        `json:"seconds_per_question,omitempty"`
+	Leaderboard        *LeaderboardSettingsUpdate `json:"leaderboard,omitempty"`
+}
+
+// BundleQuestion is NewQuestion with the answer optional.
+type BundleQuestion struct {
+	Question       string                         `json:"question"`
+	Options        []string                       `json:"options"`
+	CorrectIndex   *int                           `json:"correct_index,omitempty"`
+	Type           quizkit.QuestionType           `json:"type,omitempty"`
+	CorrectIndexes []int                          `json:"correct_indexes,omitempty"`
+	Feedback       []string                       `json:"feedback,omitempty"`
+	Difficulty     string                         `json:"difficulty,omitempty"`
+	Category       string                         `json:"category,omitempty"`
+	Translations   map[string]quizkit.Translation `json:"translations,omitempty"`
+}
+
+// ActiveQuiz is one recently created quiz.
+type ActiveQuiz struct {
+	QuizID        string        `json:"quiz_id"`
+	QuestionCount int           `json:"question_count"`
+	CreatedAt     time.Time     `json:"created_at"`
+	State         string        `json:"state"`
+	Locked        bool          `json:"locked"`
+	ClosesAt      *time.Time    `json:"closes_at,omitempty"`
+	Scoring       ScoringPolicy `json:"scoring"`
+	Origin        *QuizOrigin   `json:"origin,omitempty"`
+}
+
+// QuizState is a quiz's lifecycle state: draft, active, locked, expired, or
+// archived. Only active quizzes take answers.
+type QuizState struct {
+	QuizID   string     `json:"quiz_id"`
+	State    string     `json:"state"`
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+}
+
+// QuizRemoval reports an archived or deleted quiz. Attempts counts the
+// individual answers removed; an archived quiz keeps their totals.
+type QuizRemoval struct {
+	QuizID     string     `json:"quiz_id"`
+	Mode       string     `json:"mode"`
+	Attempts   int        `json:"attempts"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// QuizStateUpdate changes a quiz's state to draft, active, or locked, or its
+// deadline. Empty fields are left as they are; ClearClosesAt removes the
+// deadline.
+type QuizStateUpdate struct {
+	State         string     `json:"state,omitempty"`
+	ClosesAt      *time.Time `json:"closes_at,omitempty"`
+	ClearClosesAt bool       `json:"clear_closes_at,omitempty"`
+}
+
+// RosterUpdate replaces a quiz's whole roster; omitted students are removed.
+type RosterUpdate struct {
+	Restricted        bool            `json:"restricted"`
+	GenerateJoinCodes bool            `json:"generate_join_codes"`
+	Students          []RosterStudent `json:"students"`
+}
+
+// RosterStudent is one student on a roster upload.
+type RosterStudent struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// Roster lists a quiz's students next to their live standings.
+type Roster struct {
+	QuizID     string           `json:"quiz_id"`
+	Restricted bool             `json:"restricted"`
+	UpdatedAt  *time.Time       `json:"updated_at,omitempty"`
+	Students   []RosterStanding `json:"students"`
+}
+
+// RosterStanding is a roster student, or a player missing from the roster,
+// with their live standing.
+type RosterStanding struct {
+	Username         string     `json:"username"`
+	Name             string     `json:"name,omitempty"`
+	JoinCode         string     `json:"join_code,omitempty"`
+	OnRoster         bool       `json:"on_roster"`
+	Rank             int        `json:"rank,omitempty"`
+	TotalScore       float64    `json:"total_score"`
+	AnsweredCount    int        `json:"answered_count"`
+	LastSubmissionAt *time.Time `json:"last_submission_at,omitempty"`
+	ProxyAnswers     int        `json:"proxy_answers,omitempty"`
+}
+
+// JoinResult is the rostered student a join code belongs to.
+type JoinResult struct {
+	QuizID   string `json:"quiz_id"`
+	Username string `json:"username"`
+	Name     string `json:"name,omitempty"`
+}
+
+// NextQuestion is one step of an adaptive quiz. Question is nil once the pool
+// is used up.
+type NextQuestion struct {
+	QuizID           string             `json:"quiz_id"`
+	Done             bool               `json:"done"`
+	Question         *AdaptiveQuestion  `json:"question,omitempty"`
+	TargetDifficulty quizkit.Difficulty `json:"target_difficulty,omitempty"`
+	AnsweredCount    int                `json:"answered_count"`
+	CorrectCount     int                `json:"correct_count"`
+	Accuracy         float64            `json:"accuracy"`
+}
+
+// AdaptiveQuestion is the next question an adaptive quiz serves a player.
+type AdaptiveQuestion struct {
+	QuestionID  string             `json:"question_id"`
+	Question    string             `json:"question"`
+	Options     []quizkit.Option   `json:"options"`
+	Difficulty  quizkit.Difficulty `json:"difficulty,omitempty"`
+	ContentHash string             `json:"content_hash"`
+	Nonce       string             `json:"nonce,omitempty"`
+	Language    string             `json:"language,omitempty"`
+	Languages   []string           `json:"languages,omitempty"`
+}
+
+// AttemptReview is one player's answers in a quiz, for a post-quiz review.
+type AttemptReview struct {
+	QuizID          string             `json:"quiz_id"`
+	Username        string             `json:"username"`
+	Completed       bool               `json:"completed"`
+	Locked          bool               `json:"locked"`
+	AnswersRevealed bool               `json:"answers_revealed"`
+	TotalScore      float64            `json:"total_score"`
+	AnsweredCount   int                `json:"answered_count"`
+	QuestionCount   int                `json:"question_count"`
+	Questions       []ReviewedQuestion `json:"questions"`
+}
+
+// ReviewedQuestion is one question of an attempt review. Status is a result
+// status such as correct, or unanswered. CorrectLetter and CorrectText are
+// empty until the review's answers are revealed.
+type ReviewedQuestion struct {
+	QuestionID    string               `json:"question_id"`
+	Question      string               `json:"question"`
+	Options       []quizkit.Option     `json:"options"`
+	Type          quizkit.QuestionType `json:"type,omitempty"`
+	Voided        bool                 `json:"voided,omitempty"`
+	ChosenLetter  string               `json:"chosen_letter,omitempty"`
+	Status        string               `json:"status"`
+	Score         float64              `json:"score"`
+	AnsweredAt    *time.Time           `json:"answered_at,omitempty"`
+	CorrectLetter string               `json:"correct_letter,omitempty"`
+	CorrectText   string               `json:"correct_text,omitempty"`
+}
+
+// AnswerKey is a quiz's questions with their answers, for hosts.
+type AnswerKey struct {
+	QuizID        string              `json:"quiz_id"`
+	QuestionCount int                 `json:"question_count"`
+	Practice      bool                `json:"practice,omitempty"`
+	Adaptive      bool                `json:"adaptive,omitempty"`
+	Questions     []AnswerKeyQuestion `json:"questions"`
+}
+
+// AnswerKeyQuestion is one question of an answer key with its answer.
+type AnswerKeyQuestion struct {
+	Position       int                  `json:"position"`
+	QuestionID     string               `json:"question_id"`
+	Question       string               `json:"question"`
+	Options        []quizkit.Option     `json:"options"`
+	Type           quizkit.QuestionType `json:"type,omitempty"`
+	CorrectIndex   int                  `json:"correct_index"`
+	CorrectIndexes []int                `json:"correct_indexes,omitempty"`
+	CorrectLetter  string               `json:"correct_letter"`
+	CorrectText    string               `json:"correct_text"`
+	Difficulty     quizkit.Difficulty   `json:"difficulty,omitempty"`
+	Feedback       []string             `json:"feedback,omitempty"`
+	Voided         bool                 `json:"voided,omitempty"`
+}
+
+// AnswerPositions counts how often each letter is a quiz's correct answer.
+// Moved and Kept are set by RebalanceAnswerPositions only.
+type AnswerPositions struct {
+	QuizID    string          `json:"quiz_id"`
+	Questions int             `json:"questions"`
+	Letters   []AnswerLetter  `json:"letters"`
+	Outliers  []string        `json:"outliers"`
+	Moved     []MovedQuestion `json:"moved,omitempty"`
+	Kept      []string        `json:"kept,omitempty"`
+}
+
+// AnswerLetter counts how often a letter is a quiz's correct answer against
+// an even spread.
+type AnswerLetter struct {
+	Letter   string  `json:"letter"`
+	Correct  int     `json:"correct"`
+	Expected float64 `json:"expected"`
+	Outlier  bool    `json:"outlier"`
+}
+
+// MovedQuestion is a question that got a new ID when its answer moved.
+type MovedQuestion struct {
+	From string `json:"from_question_id"`
+	To   string `json:"to_question_id"`
+}
+
+// AuditEntry is one admin or host action in a quiz.
+type AuditEntry struct {
+	At       time.Time `json:"at"`
+	Admin    string    `json:"admin"`
+	Action   string    `json:"action"`
+	Username string    `json:"username"`
+	Detail   string    `json:"detail"`
+}
+
+// HostRequest adds a co-host, or with Role "owner" transfers ownership. Admin
+// names who made the change when the admin token is used.
+type HostRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Admin    string `json:"admin,omitempty"`
+}
+
+// Host is a user who may manage a quiz. Role is owner or co_host.
+type Host struct {
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	AddedBy  string    `json:"added_by"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// AddedHost is a new host with their token, which the server shows only once.
+// HostToken is empty when the owner hands over to an existing co-host, who
+// keeps their token.
+type AddedHost struct {
+	Host
+	HostToken string `json:"host_token,omitempty"`
+}
+
+// ServeEntry is who fetched a quiz's questions and when.
+type ServeEntry struct {
+	Username          string     `json:"username"`
+	FirstServedAt     *time.Time `json:"first_served_at,omitempty"`
+	LastServedAt      *time.Time `json:"last_served_at,omitempty"`
+	FetchCount        int        `json:"fetch_count"`
+	AnswerKeyServedAt *time.Time `json:"answer_key_served_at,omitempty"`
+	NoncesIssued      int        `json:"nonces_issued,omitempty"`
+	AnsweredCount     int        `json:"answered_count"`
+}
+
+// CompletionWebhook asks the server to notify URL when participants complete
+// a quiz. QuizID is set in responses.
+type CompletionWebhook struct {
+	QuizID             string   `json:"quiz_id,omitempty"`
+	URL                string   `json:"url"`
+	Participants       []string `json:"participants,omitempty"`
+	ThresholdPercent   int      `json:"threshold_percent,omitempty"`
+	Username           string   `json:"username,omitempty"`
+	LeaderboardChanges bool     `json:"leaderboard_changes,omitempty"`
+	FinalResults       bool     `json:"final_results,omitempty"`
+}
+
+// StatsOverview is service-wide activity for a status page.
+type StatsOverview struct {
+	GeneratedAt          time.Time       `json:"generated_at"`
+	QuizzesToday         int             `json:"quizzes_today"`
+	SubmissionsPerMinute float64         `json:"submissions_per_minute"`
+	ActiveUsersLastHour  int             `json:"active_users_last_hour"`
+	TopCategories        []CategoryCount `json:"top_categories"`
+}
+
+// CategoryCount is how many questions a category has.
+type CategoryCount struct {
+	Category      string `json:"category"`
+	QuestionCount int    `json:"question_count"`
+}
+
+// Submission is the server's verdict on a batch of answers, in request order.
+type Submission struct {
+	Results  []quizkit.ResponseResult `json:"results"`
+	Warnings []Warning                `json:"warnings,omitempty"`
+}
+
+// OnBehalfRequest enters a player's answers for them. Admin names who entered
+// them; a host who leaves it empty is recorded under their username.
+type OnBehalfRequest struct {
+	Username  string                      `json:"username"`
+	Admin     string                      `json:"admin,omitempty"`
+	Responses []quizkit.SubmittedResponse `json:"responses"`
+}
+
+// LeaderboardQuery narrows GetLeaderboard. A zero Limit uses the quiz's
+// default size.
+type LeaderboardQuery struct {
+	Limit    int
+	Category string
+}
+
+// Leaderboard is a quiz's standings. FrozenAt and RevealAt are set during an
+// end-of-quiz freeze, when the standings are those as of FrozenAt.
+type Leaderboard struct {
+	QuizID      string             `json:"quiz_id"`
+	Category    string             `json:"category,omitempty"`
+	Leaderboard []LeaderboardEntry `json:"leaderboard"`
+	FrozenAt    *time.Time         `json:"frozen_at,omitempty"`
+	RevealAt    *time.Time         `json:"reveal_at,omitempty"`
+}
+
+// LeaderboardEntry is one player's total. Anonymous marks a player shown under
+// a pseudonym.
+type LeaderboardEntry struct {
+	quizkit.LeaderboardEntry
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+// RankedEntry is a LeaderboardEntry with its position, starting at 1.
+type RankedEntry struct {
+	Rank int `json:"rank"`
+	LeaderboardEntry
+}
+
+// LeaderboardEvent is one change to a quiz's standings. A snapshot carries the
+// whole leaderboard; a delta carries the one entry that changed. ID resumes a
+// stream after it.
+type LeaderboardEvent struct {
+	ID           string        `json:"-"`
+	Type         string        `json:"type"`
+	QuizID       string        `json:"quiz_id"`
+	Entries      []RankedEntry `json:"entries"`
+	Participants int           `json:"participants"`
+	OccurredAt   time.Time     `json:"occurred_at"`
+	FrozenAt     *time.Time    `json:"frozen_at,omitempty"`
+	RevealAt     *time.Time    `json:"reveal_at,omitempty"`
+	Message      string        `json:"message,omitempty"`
+}
+
+// PlayerProgress is one player's standing on a host dashboard. Finished is
+// set once the player has answered every question that is not voided.
+type PlayerProgress struct {
+	Username      string  `json:"username"`
+	TotalScore    float64 `json:"total_score"`
+	AnsweredCount int     `json:"answered_count"`
+	Finished      bool    `json:"finished"`
+}
+
+// QuizSubmission is one submission on a host dashboard: the answers it
+// stored, the points they earned, and the player's totals afterwards.
+type QuizSubmission struct {
+	PlayerProgress
+	Answered   int     `json:"answered"`
+	ScoreDelta float64 `json:"score_delta"`
+}
+
+// SubmissionEvent is one message on a quiz's submission stream. A snapshot
+// carries every player; a submission carries the one that just submitted.
+// Both carry the quiz totals after the change.
+type SubmissionEvent struct {
+	Type          string           `json:"type"`
+	QuizID        string           `json:"quiz_id"`
+	Players       []PlayerProgress `json:"players,omitempty"`
+	Submission    *QuizSubmission  `json:"submission,omitempty"`
+	QuestionCount int              `json:"question_count"`
+	Participants  int              `json:"participants"`
+	FinishedCount int              `json:"finished_count"`
+	OccurredAt    time.Time        `json:"occurred_at"`
+	Message       string           `json:"message,omitempty"`
+}
+
+// LeaderboardSettingsUpdate replaces every leaderboard setting of a quiz;
+// zero fields reset to the server's defaults.
+type LeaderboardSettingsUpdate struct {
+	DefaultLimit  int        `json:"default_limit"`
+	FreezeSeconds int        `json:"freeze_seconds"`
+	LocksAt       *time.Time `json:"locks_at,omitempty"`
+	Tiebreak      string     `json:"tiebreak,omitempty"`
+}
+
+// LeaderboardSettings are a quiz's saved leaderboard settings.
+type LeaderboardSettings struct {
+	QuizID        string     `json:"quiz_id"`
+	DefaultLimit  int        `json:"default_limit"`
+	FreezeSeconds int        `json:"freeze_seconds"`
+	LocksAt       *time.Time `json:"locks_at,omitempty"`
+	Tiebreak      string     `json:"tiebreak"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// GlobalLeaderboardQuery narrows GetGlobalLeaderboard. Since and Until bound a
+// season by when quizzes were created; zero bounds are open. Order is
+// "normalized", the default, or "raw". A zero Limit uses the server's default
+// size; a negative one its maximum.
+type GlobalLeaderboardQuery struct {
+	Since time.Time
+	Until time.Time
+	Order string
+	Limit int
+}
+
+// GlobalLeaderboard ranks players across quizzes. QuizCount is how many
+// quizzes counted and Participants how many players are ranked in all.
+type GlobalLeaderboard struct {
+	Since        *time.Time    `json:"since,omitempty"`
+	Until        *time.Time    `json:"until,omitempty"`
+	Order        string        `json:"order"`
+	QuizCount    int           `json:"quiz_count"`
+	Participants int           `json:"participants"`
+	Leaderboard  []GlobalEntry `json:"leaderboard"`
+}
+
+// GlobalEntry is one player's standing across quizzes. RawScore sums their
+// points; NormalizedScore sums their percentage of each quiz's maximum,
+// scaled by the quiz's difficulty, so long quizzes do not dominate.
+type GlobalEntry struct {
+	Rank             int       `json:"rank"`
+	Username         string    `json:"username"`
+	Anonymous        bool      `json:"anonymous,omitempty"`
+	QuizzesPlayed    int       `json:"quizzes_played"`
+	AnsweredCount    int       `json:"answered_count"`
+	CorrectCount     int       `json:"correct_count"`
+	RawScore         float64   `json:"raw_score"`
+	NormalizedScore  float64   `json:"normalized_score"`
+	LastSubmissionAt time.Time `json:"last_submission_at"`
+}
+
+// QuizResults is a locked quiz's final standings and per-question statistics.
+// It never changes once published.
+type QuizResults struct {
+	QuizID      string            `json:"quiz_id"`
+	LockedAt    time.Time         `json:"locked_at"`
+	PublishedAt time.Time         `json:"published_at"`
+	Standings   []ResultsStanding `json:"standings"`
+	Questions   []QuestionResults `json:"questions"`
+}
+
+// ResultsStanding is one player's final standing. ProxyAnswers counts the
+// answers an admin entered for them.
+type ResultsStanding struct {
+	RankedEntry
+	ProxyAnswers int `json:"proxy_answers,omitempty"`
+}
+
+// QuestionResults counts how often each option of a question was chosen.
+type QuestionResults struct {
+	QuestionID    string             `json:"question_id"`
+	Question      string             `json:"question"`
+	Options       []quizkit.Option   `json:"options"`
+	CorrectLetter string             `json:"correct_letter"`
+	Category      string             `json:"category,omitempty"`
+	Difficulty    quizkit.Difficulty `json:"difficulty,omitempty"`
+	AttemptCount  int                `json:"attempt_count"`
+	CorrectCount  int                `json:"correct_count"`
+	AnswerCounts  map[string]int     `json:"answer_counts"`
+}
+
+// AdminQuizQuery pages through ListAdminQuizzes. Sort is created_at,
+// last_activity, attempts, participants, storage, or question_count; Order
+// is asc or desc. Zero values use the server's defaults.
+type AdminQuizQuery struct {
+	Limit  int
+	Offset int
+	Sort   string
+	Order  string
+}
+
+// AdminQuizPage is one page of every quiz on the server. Total counts them
+// all.
+type AdminQuizPage struct {
+	Quizzes []AdminQuiz `json:"quizzes"`
+	Total   int         `json:"total"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+	Sort    string      `json:"sort"`
+	Order   string      `json:"order"`
+}
+
+// AdminQuiz is one quiz listed by ListAdminQuizzes.
+type AdminQuiz struct {
+	QuizID                 string      `json:"quiz_id"`
+	QuestionCount          int         `json:"question_count"`
+	RequestedQuestionCount int         `json:"requested_question_count,omitempty"`
+	CreatedAt              time.Time   `json:"created_at"`
+	State                  string      `json:"state"`
+	Locked                 bool        `json:"locked"`
+	ClosesAt               *time.Time  `json:"closes_at,omitempty"`
+	AttemptCount           int         `json:"attempt_count"`
+	ParticipantCount       int         `json:"participant_count"`
+	StorageBytes           int64       `json:"storage_bytes"`
+	LastActivityAt         time.Time   `json:"last_activity_at"`
+	Origin                 *QuizOrigin `json:"origin,omitempty"`
+}
+
+// Bundle is a question bundle embedded in the server. Loaded bundles are what
+// new quizzes draw from.
+type Bundle struct {
+	Name          string `json:"name"`
+	Title         string `json:"title"`
+	QuestionCount int    `json:"question_count"`
+	Loaded        bool   `json:"loaded"`
+}
+
+// PoolStats describes what the loaded bundles can still serve. Fallback is set
+// while no bundle is loaded; Low once Unused falls below LowWater.
+type PoolStats struct {
+	Questions    int          `json:"questions"`
+	Unused       int          `json:"unused"`
+	Fallback     bool         `json:"fallback"`
+	LowWater     int          `json:"low_water"`
+	Low          bool         `json:"low"`
+	Bundles      []PoolBundle `json:"bundles"`
+	Categories   []PoolGroup  `json:"categories"`
+	Difficulties []PoolGroup  `json:"difficulties"`
+	Usage        []PoolUsage  `json:"usage"`
+}
+
+// PoolBundle is one loaded bundle in PoolStats.
+type PoolBundle struct {
+	Name       string    `json:"name"`
+	Questions  int       `json:"questions"`
+	Unused     int       `json:"unused"`
+	LoadedAt   time.Time `json:"loaded_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+}
+
+// PoolGroup counts a category or difficulty's questions in PoolStats.
+type PoolGroup struct {
+	Name      string `json:"name"`
+	Questions int    `json:"questions"`
+	Unused    int    `json:"unused"`
+}
+
+// PoolUsage counts the questions drawn exactly Draws times.
+type PoolUsage struct {
+	Draws     int `json:"draws"`
+	Questions int `json:"questions"`
+}
+
+// RetirementReview is a question flagged for near-0% or near-100%
+// correctness. Status is pending, retired, or kept.
+type RetirementReview struct {
+	QuestionID      string           `json:"question_id"`
+	Question        string           `json:"question"`
+	Options         []quizkit.Option `json:"options"`
+	CorrectIndex    int              `json:"correct_index"`
+	Status          string           `json:"status"`
+	AttemptCount    int              `json:"attempt_count"`
+	CorrectCount    int              `json:"correct_count"`
+	CorrectnessRate float64          `json:"correctness_rate"`
+	FlaggedAt       time.Time        `json:"flagged_at"`
+	DecidedAt       *time.Time       `json:"decided_at,omitempty"`
+}
+
+// Bookmark is a question a user saved for practice.
+type Bookmark struct {
+	QuestionID string           `json:"question_id"`
+	Question   string           `json:"question"`
+	Options    []quizkit.Option `json:"options"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// Profile is a user's settings. Anonymous hides them from public
+// leaderboards.
+type Profile struct {
+	Username  string     `json:"username"`
+	Anonymous bool       `json:"anonymous"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Preferences are a user's defaults for quizzes they create. Zero fields leave
+// the server's defaults in place.
+type Preferences struct {
+	Username      string     `json:"username,omitempty"`
+	QuestionCount int        `json:"question_count,omitempty"`
+	Difficulty    string     `json:"difficulty,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// UserStats is a player's daily streak and totals. Days are dates in the
+// server's Timezone; Totals is nil when the server keeps no per-quiz
+// summaries.
+type UserStats struct {
+	Username      string      `json:"username"`
+	CurrentStreak int         `json:"current_streak"`
+	LongestStreak int         `json:"longest_streak"`
+	LastActiveDay string      `json:"last_active_day,omitempty"`
+	Timezone      string      `json:"timezone"`
+	Totals        *UserTotals `json:"totals,omitempty"`
+}
+
+// UserTotals are a user's answers across all quizzes, including archived
+// attempts.
+type UserTotals struct {
+	QuizzesPlayed int     `json:"quizzes_played"`
+	AnsweredCount int     `json:"answered_count"`
+	CorrectCount  int     `json:"correct_count"`
+	TotalScore    float64 `json:"total_score"`
+	ArchivedCount int     `json:"archived_count"`
+}
+
+// UserAttempts is a user's attempt history, most recently played first.
+type UserAttempts struct {
+	Username string        `json:"username"`
+	Attempts []UserAttempt `json:"attempts"`
+}
+
+// UserAttempt is a user's totals in one quiz. ArchivedCount of the answers
+// were archived; QuizCreatedAt is unset when the store does not record it.
+type UserAttempt struct {
+	QuizID          string     `json:"quiz_id"`
+	QuestionCount   int        `json:"question_count"`
+	Practice        bool       `json:"practice,omitempty"`
+	TotalScore      float64    `json:"total_score"`
+	AnsweredCount   int        `json:"answered_count"`
+	CorrectCount    int        `json:"correct_count"`
+	ArchivedCount   int        `json:"archived_count"`
+	FirstAnsweredAt time.Time  `json:"first_answered_at"`
+	LastAnsweredAt  time.Time  `json:"last_answered_at"`
+	QuizCreatedAt   *time.Time `json:"quiz_created_at,omitempty"`
+}
+
+// Account is a registered username.
+type Account struct {
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountToken is a signed token from Login. Answers sent with it are stored
+// as its username.
+type AccountToken struct {
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Identity is a username's verification status. PlayerToken is set only by
+// VerifyIdentity, the one time the server shows it.
+type Identity struct {
+	Username    string     `json:"username"`
+	Verified    bool       `json:"verified"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+	PlayerToken string     `json:"player_token,omitempty"`
+}
+
+// SigningKey is a registered key for signing answers queued offline.
+type SigningKey struct {
+	Username     string    `json:"username"`
+	KeyID        string    `json:"key_id"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// QuestionPerformance is how one of an author's questions did across
+// quizzes.
+type QuestionPerformance struct {
+	QuestionID      string  `json:"question_id"`
+	Question        string  `json:"question"`
+	QuizCount       int     `json:"quiz_count"`
+	AttemptCount    int     `json:"attempt_count"`
+	CorrectCount    int     `json:"correct_count"`
+	CorrectnessRate float64 `json:"correctness_rate"`
+	ReportCount     int     `json:"report_count"`
+}
+
+// BankStats reports the ad-hoc question bank's size and counters.
+type BankStats struct {
+	Size         int   `json:"size"`
+	MaxQuestions int   `json:"max_questions"`
+	TTLSeconds   int64 `json:"ttl_seconds"`
+	Hits         int64 `json:"hits"`
+	Misses       int64 `json:"misses"`
+	StoreHits    int64 `json:"store_hits"`
+	StoreErrors  int64 `json:"store_errors"`
+	Evictions    int64 `json:"evictions"`
+	Expirations  int64 `json:"expirations"`
+}
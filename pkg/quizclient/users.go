@@ -0,0 +1,195 @@
+package quizclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Register creates an account for username. A verified username also needs
+// its player token (WithPlayerToken).
+func (c *Client) Register(ctx context.Context, username, password string) (Account, error) {
+	request := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{Username: username, Password: password}
+	return call[Account](ctx, c, http.MethodPost, "/users/register", request)
+}
+
+// Login returns an account token for WithAccountToken.
+func (c *Client) Login(ctx context.Context, username, password string) (AccountToken, error) {
+	request := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{Username: username, Password: password}
+	return call[AccountToken](ctx, c, http.MethodPost, "/users/login", request)
+}
+
+type bookmarksPayload struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// ListBookmarks returns username's bookmarked questions.
+func (c *Client) ListBookmarks(ctx context.Context, username string) ([]Bookmark, error) {
+	path, err := expand("/users/{username}/bookmarks", username)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := call[bookmarksPayload](ctx, c, http.MethodGet, path, nil)
+	return payload.Bookmarks, err
+}
+
+// AddBookmark bookmarks a question for username and returns every bookmark.
+func (c *Client) AddBookmark(ctx context.Context, username, questionID string) ([]Bookmark, error) {
+	path, err := expand("/users/{username}/bookmarks", username)
+	if err != nil {
+		return nil, err
+	}
+	request := struct {
+		QuestionID string `json:"question_id"`
+	}{QuestionID: questionID}
+	payload, err := call[bookmarksPayload](ctx, c, http.MethodPost, path, request)
+	return payload.Bookmarks, err
+}
+
+// RemoveBookmark removes a bookmark.
+func (c *Client) RemoveBookmark(ctx context.Context, username, questionID string) error {
+	path, err := expand("/users/{username}/bookmarks/{question_id}", username, questionID)
+	if err != nil {
+		return err
+	}
+	return c.Do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// CreatePracticeQuiz creates a personal quiz from up to questionCount of
+// username's bookmarks; zero uses them all.
+func (c *Client) CreatePracticeQuiz(ctx context.Context, username string, questionCount int) (CreatedQuiz, error) {
+	path, err := expand("/users/{username}/bookmarks/practice", username)
+	if err != nil {
+		return CreatedQuiz{}, err
+	}
+	request := struct {
+		QuestionCount int `json:"question_count"`
+	}{QuestionCount: questionCount}
+	return call[CreatedQuiz](ctx, c, http.MethodPost, path, request)
+}
+
+// GetProfile returns username's settings.
+func (c *Client) GetProfile(ctx context.Context, username string) (Profile, error) {
+	path, err := expand("/users/{username}/profile", username)
+	if err != nil {
+		return Profile{}, err
+	}
+	return call[Profile](ctx, c, http.MethodGet, path, nil)
+}
+
+// SetProfile hides username from public leaderboards, or shows them again.
+func (c *Client) SetProfile(ctx context.Context, username string, anonymous bool) (Profile, error) {
+	path, err := expand("/users/{username}/profile", username)
+	if err != nil {
+		return Profile{}, err
+	}
+	request := struct {
+		Anonymous *bool `json:"anonymous"`
+	}{Anonymous: &anonymous}
+	return call[Profile](ctx, c, http.MethodPut, path, request)
+}
+
+// GetPreferences returns username's defaults for quizzes they create.
+func (c *Client) GetPreferences(ctx context.Context, username string) (Preferences, error) {
+	path, err := expand("/users/{username}/preferences", username)
+	if err != nil {
+		return Preferences{}, err
+	}
+	return call[Preferences](ctx, c, http.MethodGet, path, nil)
+}
+
+// SetPreferences replaces username's quiz defaults with preferences' count and
+// difficulty and returns what the server saved.
+func (c *Client) SetPreferences(ctx context.Context, username string, preferences Preferences) (Preferences, error) {
+	path, err := expand("/users/{username}/preferences", username)
+	if err != nil {
+		return Preferences{}, err
+	}
+	request := struct {
+		QuestionCount int    `json:"question_count"`
+		Difficulty    string `json:"difficulty"`
+	}{QuestionCount: preferences.QuestionCount, Difficulty: preferences.Difficulty}
+	return call[Preferences](ctx, c, http.MethodPut, path, request)
+}
+
+// GetUserStats returns username's daily streak and answer totals.
+func (c *Client) GetUserStats(ctx context.Context, username string) (UserStats, error) {
+	path, err := expand("/users/{username}/stats", username)
+	if err != nil {
+		return UserStats{}, err
+	}
+	return call[UserStats](ctx, c, http.MethodGet, path, nil)
+}
+
+// GetUserAttempts returns every quiz username answered in, most recently
+// played first. A zero limit uses the server's default.
+func (c *Client) GetUserAttempts(ctx context.Context, username string, limit int) (UserAttempts, error) {
+	path, err := expand("/users/{username}/attempts", username)
+	if err != nil {
+		return UserAttempts{}, err
+	}
+	values := url.Values{}
+	setInt(values, "limit", limit)
+	return call[UserAttempts](ctx, c, http.MethodGet, withQuery(path, values), nil)
+}
+
+// GetIdentity reports whether username is verified.
+func (c *Client) GetIdentity(ctx context.Context, username string) (Identity, error) {
+	path, err := expand("/users/{username}/identity", username)
+	if err != nil {
+		return Identity{}, err
+	}
+	return call[Identity](ctx, c, http.MethodGet, path, nil)
+}
+
+// StartVerification emails a code and magic link for verifying username to
+// email, and returns when they expire.
+func (c *Client) StartVerification(ctx context.Context, username, email string) (time.Time, error) {
+	path, err := expand("/users/{username}/identity", username)
+	if err != nil {
+		return time.Time{}, err
+	}
+	request := struct {
+		Email string `json:"email"`
+	}{Email: email}
+	payload, err := call[struct {
+		ExpiresAt time.Time `json:"expires_at"`
+	}](ctx, c, http.MethodPost, path, request)
+	return payload.ExpiresAt, err
+}
+
+// VerifyIdentity completes a verification with the emailed code or the magic
+// link's token, and returns the player token for WithPlayerToken. The server
+// shows it only this once.
+func (c *Client) VerifyIdentity(ctx context.Context, username, code, token string) (Identity, error) {
+	path, err := expand("/users/{username}/identity/verify", username)
+	if err != nil {
+		return Identity{}, err
+	}
+	request := struct {
+		Code  string `json:"code,omitempty"`
+		Token string `json:"token,omitempty"`
+	}{Code: code, Token: token}
+	return call[Identity](ctx, c, http.MethodPost, path, request)
+}
+
+// RegisterSigningKey registers publicKey, a base64 Ed25519 public key, for
+// signing username's answers queued offline. Registering the same key again
+// returns the first registration.
+func (c *Client) RegisterSigningKey(ctx context.Context, username, publicKey string) (SigningKey, error) {
+	path, err := expand("/users/{username}/signing-keys", username)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	request := struct {
+		PublicKey string `json:"public_key"`
+	}{PublicKey: publicKey}
+	return call[SigningKey](ctx, c, http.MethodPost, path, request)
+}
@@ -0,0 +1,131 @@
+package quizkit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Difficulty tags a question for adaptive selection. The empty value means
+// untagged and is treated as medium.
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+)
+
+var difficultyLevels = []Difficulty{DifficultyEasy, DifficultyMedium, DifficultyHard}
+
+// ParseDifficulty maps case-insensitive text to a Difficulty. Empty text is
+// untagged.
+func ParseDifficulty(value string) (Difficulty, error) {
+	difficulty := Difficulty(strings.ToLower(strings.TrimSpace(value)))
+	switch difficulty {
+	case "", DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return difficulty, nil
+	default:
+		return "", fmt.Errorf("%w: unknown difficulty %q (want easy, medium, or hard)", ErrInvalidQuestion, value)
+	}
+}
+
+func (d Difficulty) level() int {
+	for idx, candidate := range difficultyLevels {
+		if candidate == d {
+			return idx
+		}
+	}
+	return 1
+}
+
+func difficultyAt(level int) Difficulty {
+	return difficultyLevels[min(max(level, 0), len(difficultyLevels)-1)]
+}
+
+// AnsweredQuestion is one answer in the order the player gave it.
+type AnsweredQuestion struct {
+	QuestionID string
+	Correct    bool
+}
+
+// SelectionPolicy picks the next question for one player from a quiz's pool,
+// given what they already answered. It returns false when nothing is left.
+// Policies must be deterministic so the question served next can be checked
+// again when the answer arrives.
+type SelectionPolicy interface {
+	Next(pool []Question, history []AnsweredQuestion) (Question, bool)
+}
+
+// Staircase steps difficulty up one level after a correct answer and down one
+// after a wrong one, starting at Start (medium when empty). The step is taken
+// from the difficulty of the question actually answered, so running out of a
+// level does not make the walk drift. Among unanswered questions it picks the
+// closest difficulty, preferring the direction of the last step, then pool
+// order. Voided questions are never served.
+type Staircase struct {
+	Start Difficulty
+}
+
+// Target returns the difficulty the next question should have.
+func (p Staircase) Target(pool []Question, history []AnsweredQuestion) Difficulty {
+	target, _ := p.target(pool, history)
+	return target
+}
+
+func (p Staircase) Next(pool []Question, history []AnsweredQuestion) (Question, bool) {
+	target, step := p.target(pool, history)
+
+	answered := make(map[string]struct{}, len(history))
+	for _, answer := range history {
+		answered[answer.QuestionID] = struct{}{}
+	}
+
+	var (
+		best      Question
+		bestScore = -1
+	)
+	for _, question := range pool {
+		if _, ok := answered[question.QuestionID]; ok || question.Voided {
+			continue
+		}
+		distance := question.Difficulty.level() - target.level()
+		// Lower is better: closeness first, then the side the walk is heading.
+		score := 2 * abs(distance)
+		if distance != 0 && (distance > 0) != (step > 0) {
+			score++
+		}
+		if bestScore < 0 || score < bestScore {
+			best, bestScore = question, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+func (p Staircase) target(pool []Question, history []AnsweredQuestion) (Difficulty, int) {
+	difficulties := make(map[string]Difficulty, len(pool))
+	for _, question := range pool {
+		difficulties[question.QuestionID] = question.Difficulty
+	}
+
+	level := p.Start.level()
+	step := 1
+	for _, answer := range history {
+		difficulty, ok := difficulties[answer.QuestionID]
+		if !ok {
+			continue
+		}
+		step = -1
+		if answer.Correct {
+			step = 1
+		}
+		level = difficultyAt(difficulty.level() + step).level()
+	}
+	return difficultyAt(level), step
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,8 @@
+// Package quizkit is the embeddable quiz domain: the question model, answer
+// evaluation, scoring policy, leaderboard ordering, and adaptive question
+// selection used by quiz-service.
+//
+// It depends only on the standard library. Storage, HTTP, and question
+// providers live elsewhere, so other Go programs can score quizzes without
+// running the service. Exported identifiers are kept backward compatible.
+package quizkit
@@ -0,0 +1,293 @@
+package quizkit
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Per-response statuses reported in ResponseResult.Status.
+const (
+	StatusCorrect   = "correct"
+	StatusIncorrect = "incorrect"
+	// StatusPartiallyCorrect means a multi-select answer earned some but not
+	// all of the question's points; ResponseResult.Credit says how much.
+	StatusPartiallyCorrect = "partially_correct"
+	StatusInvalidQuestion  = "invalid_question"
+	StatusInvalidLetter    = "invalid_letter"
+	StatusAlreadyAnswered  = "already_answered"
+	StatusVoidedQuestion   = "voided_question"
+	// StatusStaleQuestion means the question changed after it was served; the
+	// answer was not scored and the current copy is returned to ask again.
+	StatusStaleQuestion = "stale_question"
+	// StatusDuplicateInRequest means an earlier response in the same request
+	// already answered the question; only the first one is evaluated.
+	StatusDuplicateInRequest = "duplicate_in_request"
+	// StatusInvalidSignature means a signed answer's key is unknown or its
+	// signature does not match; the answer was not scored.
+	StatusInvalidSignature = "invalid_signature"
+	// StatusOutsideWindow means a signed answer claims a time the server cannot
+	// accept, such as before the question was served or too long ago to sync;
+	// the answer was not scored.
+	StatusOutsideWindow = "outside_window"
+	// StatusInvalidNonce means the server requires serve nonces and the answer
+	// carried none, or one not issued to this player for this question or
+	// already spent; the answer was not scored and the question must be
+	// fetched again.
+	StatusInvalidNonce = "invalid_nonce"
+)
+
+// SubmittedResponse is one answer for one question: a letter, the letters
+// picked on a multi-select question, or true or false.
+type SubmittedResponse struct {
+	QuestionID string `json:"question_id"`
+	Answer     string `json:"answer"`
+	// ContentHash is the content_hash the question was served with, if any.
+	ContentHash string `json:"content_hash,omitempty"`
+	// Nonce is the nonce the question was served with, when the server issues
+	// them. Each one is good for a single answer.
+	Nonce string `json:"nonce,omitempty"`
+	// AnsweredAt, KeyID, and Signature sign an answer that a client queued
+	// while offline: when the player chose it, and a signature over it by a
+	// key the client registered with the server. They come together or not
+	// at all.
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+	KeyID      string     `json:"key_id,omitempty"`
+	Signature  string     `json:"signature,omitempty"`
+	// Bonus is extra points the server adds if the answer is correct. It is
+	// computed server-side and never read from requests.
+	Bonus float64 `json:"-"`
+	// SubmittedBy names the admin who entered the answer for the player, such
+	// as from a paper answer sheet. It is set server-side and never read from
+	// requests.
+	SubmittedBy string `json:"-"`
+}
+
+// ResponseResult is the outcome of evaluating one SubmittedResponse.
+type ResponseResult struct {
+	QuestionID   string   `json:"question_id"`
+	Status       string   `json:"status"`
+	AttemptScore *float64 `json:"attempt_score,omitempty"`
+	// Credit is the share of the question's points a partially correct
+	// answer earned, between 0 and 1.
+	Credit        float64 `json:"credit,omitempty"`
+	CorrectLetter string  `json:"correct_letter,omitempty"`
+	CorrectText   string  `json:"correct_text,omitempty"`
+	// Feedback explains why the chosen option is wrong, when the author wrote
+	// feedback for it.
+	Feedback string `json:"feedback,omitempty"`
+	// Bonus is the speed bonus included in a correct answer's score.
+	Bonus float64 `json:"bonus,omitempty"`
+	// Question and ContentHash re-serve the current copy of a stale question.
+	Question    *PublicQuestion `json:"question,omitempty"`
+	ContentHash string          `json:"content_hash,omitempty"`
+}
+
+// EvaluateAnswer returns the status of answer for question: correct,
+// partially_correct, incorrect, invalid_letter for anything that does not name
+// its options, or voided_question when the question no longer accepts
+// answers.
+func EvaluateAnswer(question Question, answer string) string {
+	status, _ := GradeAnswer(question, answer)
+	return status
+}
+
+// GradeAnswer is EvaluateAnswer with the share of the question's points the
+// answer earned: 1 when correct, 0 when incorrect or not scored, and between
+// for a partially correct multi-select answer.
+func GradeAnswer(question Question, answer string) (status string, credit float64) {
+	if question.Voided {
+		return StatusVoidedQuestion, 0
+	}
+	chosen, ok := question.ParseAnswer(answer)
+	if !ok {
+		return StatusInvalidLetter, 0
+	}
+	credit = question.Credit(chosen)
+	switch {
+	case credit >= 1:
+		return StatusCorrect, 1
+	case credit > 0:
+		return StatusPartiallyCorrect, credit
+	default:
+		return StatusIncorrect, 0
+	}
+}
+
+// ParseAnswer reads answer as option indexes of q: one letter, the word true
+// or false for a true/false question, or distinct comma-separated letters
+// for a multi-select question. ok is false for anything else, including
+// letters past q's last option.
+func (q Question) ParseAnswer(answer string) (chosen []int, ok bool) {
+	if q.Type == TypeTrueFalse {
+		word := strings.TrimSpace(answer)
+		for idx, option := range q.Options {
+			if strings.EqualFold(word, option.Text) {
+				return []int{idx}, true
+			}
+		}
+	}
+
+	parts := []string{answer}
+	if q.Type == TypeMultiSelect {
+		parts = strings.Split(answer, ",")
+	}
+	chosen = make([]int, 0, len(parts))
+	for _, part := range parts {
+		letter := NormalizeLetter(part)
+		if letter == "" {
+			return nil, false
+		}
+		index := int(letter[0] - 'A')
+		if index >= len(q.Options) || slices.Contains(chosen, index) {
+			return nil, false
+		}
+		chosen = append(chosen, index)
+	}
+	slices.Sort(chosen)
+	return chosen, true
+}
+
+// Credit is the share of q's points earned by picking the options chosen.
+// A multi-select answer earns a share for each correct option picked and
+// loses one for each wrong option picked, so picking every option earns no
+// more than picking none; it never goes below 0. Other types earn 1 for the
+// correct option alone and 0 otherwise.
+func (q Question) Credit(chosen []int) float64 {
+	correct := q.CorrectOptions()
+	if q.Type != TypeMultiSelect {
+		if len(chosen) == 1 && chosen[0] == correct[0] {
+			return 1
+		}
+		return 0
+	}
+	net := 0
+	for _, index := range chosen {
+		if slices.Contains(correct, index) {
+			net++
+		} else {
+			net--
+		}
+	}
+	return max(float64(net)/float64(len(correct)), 0)
+}
+
+// FormatLetters writes option indexes as an answer, such as "A,C".
+func FormatLetters(indexes []int) string {
+	letters := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		letters = append(letters, OptionLetter(index))
+	}
+	return strings.Join(letters, ",")
+}
+
+// NormalizeLetters is NormalizeLetter for an answer naming one or more
+// options, such as " c,a ": its letters sorted and comma-separated, as stores
+// record them. It returns "" if any part is not a single letter.
+func NormalizeLetters(answer string) string {
+	letters := strings.Split(answer, ",")
+	for idx, part := range letters {
+		letters[idx] = NormalizeLetter(part)
+		if letters[idx] == "" {
+			return ""
+		}
+	}
+	slices.Sort(letters)
+	return strings.Join(letters, ",")
+}
+
+// Evaluate scores responses against questions without persisting anything.
+// Responses naming a question that is not in questions are invalid_question.
+// Results are in response order.
+func Evaluate(questions []Question, responses []SubmittedResponse) []ResponseResult {
+	lookup := make(map[string]Question, len(questions))
+	for _, question := range questions {
+		lookup[question.QuestionID] = question
+	}
+
+	results := make([]ResponseResult, 0, len(responses))
+	for _, response := range responses {
+		result := ResponseResult{QuestionID: response.QuestionID, Status: StatusInvalidQuestion}
+		if question, ok := lookup[response.QuestionID]; ok {
+			result = GradeResponse(question, response)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// GradeResponse evaluates one response to question, setting Credit on a
+// partially correct result.
+func GradeResponse(question Question, response SubmittedResponse) ResponseResult {
+	status, credit := GradeAnswer(question, response.Answer)
+	result := ResponseResult{QuestionID: response.QuestionID, Status: status}
+	if status == StatusPartiallyCorrect {
+		result.Credit = credit
+	}
+	return result
+}
+
+// RevealCorrectAnswers fills CorrectLetter/CorrectText on incorrect and
+// partially correct results; a multi-select question lists every correct
+// letter, such as "A,C", and their texts separated by "; ". Correct results
+// need no explanation and other statuses have nothing to reveal.
+func RevealCorrectAnswers(results []ResponseResult, questions []Question) {
+	lookup := make(map[string]Question, len(questions))
+	for _, question := range questions {
+		lookup[question.QuestionID] = question
+	}
+
+	for idx := range results {
+		if results[idx].Status != StatusIncorrect && results[idx].Status != StatusPartiallyCorrect {
+			continue
+		}
+		question, ok := lookup[results[idx].QuestionID]
+		if !ok {
+			continue
+		}
+		texts := make([]string, 0, 1)
+		for _, correct := range question.CorrectOptions() {
+			if correct < 0 || correct >= len(question.Options) {
+				texts = nil
+				break
+			}
+			texts = append(texts, question.Options[correct].Text)
+		}
+		if len(texts) == 0 {
+			continue
+		}
+		results[idx].CorrectLetter = question.CorrectLetters()
+		results[idx].CorrectText = strings.Join(texts, "; ")
+	}
+}
+
+// ExplainIncorrectAnswers fills Feedback on incorrect results from the chosen
+// option's feedback. results must be in responses order, as Evaluate and
+// stores return them.
+func ExplainIncorrectAnswers(results []ResponseResult, responses []SubmittedResponse, questions []Question) {
+	lookup := make(map[string]Question, len(questions))
+	for _, question := range questions {
+		if len(question.Feedback) > 0 {
+			lookup[question.QuestionID] = question
+		}
+	}
+	if len(lookup) == 0 {
+		return
+	}
+
+	for idx := range results {
+		if results[idx].Status != StatusIncorrect || idx >= len(responses) || responses[idx].QuestionID != results[idx].QuestionID {
+			continue
+		}
+		question, ok := lookup[results[idx].QuestionID]
+		if !ok {
+			continue
+		}
+		// Feedback explains one option, so an answer picking several gets none.
+		chosen, ok := question.ParseAnswer(responses[idx].Answer)
+		if !ok || len(chosen) != 1 {
+			continue
+		}
+		results[idx].Feedback = question.FeedbackFor(chosen[0])
+	}
+}
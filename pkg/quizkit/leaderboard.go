@@ -0,0 +1,70 @@
+package quizkit
+
+import (
+	"sort"
+	"time"
+)
+
+// LeaderboardEntry is one user's standing in a quiz.
+type LeaderboardEntry struct {
+	Username         string    `json:"username"`
+	TotalScore       float64   `json:"total_score"`
+	AnsweredCount    int       `json:"answered_count"`
+	LastSubmissionAt time.Time `json:"last_submission_at"`
+}
+
+// Tiebreak decides the order of entries with equal scores.
+type Tiebreak string
+
+const (
+	// TiebreakLastSubmission ranks whoever reached the score first ahead. The
+	// empty Tiebreak means the same.
+	TiebreakLastSubmission Tiebreak = "last_submission"
+	// TiebreakFewestAnswers ranks whoever answered fewer questions ahead, for
+	// formats where the same score from fewer questions is the better run.
+	// Entries that also tie on answers fall back to TiebreakLastSubmission.
+	TiebreakFewestAnswers Tiebreak = "fewest_answers"
+)
+
+// Valid reports whether t is a known tiebreak or empty.
+func (t Tiebreak) Valid() bool {
+	return t == "" || t == TiebreakLastSubmission || t == TiebreakFewestAnswers
+}
+
+// RanksBefore reports whether a places ahead of b under the default
+// tiebreak.
+func RanksBefore(a, b LeaderboardEntry) bool {
+	return RanksBeforeBy(TiebreakLastSubmission, a, b)
+}
+
+// RanksBeforeBy reports whether a places ahead of b when ties are broken by
+// tiebreak.
+func RanksBeforeBy(tiebreak Tiebreak, a, b LeaderboardEntry) bool {
+	// Ranking policy:
+	// 1) higher score first
+	// 2) with fewest_answers, fewer answered questions win ties
+	// 3) earlier final submission wins ties
+	// 4) username lexical order for deterministic output
+	if a.TotalScore != b.TotalScore {
+		return a.TotalScore > b.TotalScore
+	}
+	if tiebreak == TiebreakFewestAnswers && a.AnsweredCount != b.AnsweredCount {
+		return a.AnsweredCount < b.AnsweredCount
+	}
+	if !a.LastSubmissionAt.Equal(b.LastSubmissionAt) {
+		return a.LastSubmissionAt.Before(b.LastSubmissionAt)
+	}
+	return a.Username < b.Username
+}
+
+// SortLeaderboard orders entries in place using RanksBefore.
+func SortLeaderboard(entries []LeaderboardEntry) {
+	SortLeaderboardBy(TiebreakLastSubmission, entries)
+}
+
+// SortLeaderboardBy orders entries in place using RanksBeforeBy.
+func SortLeaderboardBy(tiebreak Tiebreak, entries []LeaderboardEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return RanksBeforeBy(tiebreak, entries[i], entries[j])
+	})
+}
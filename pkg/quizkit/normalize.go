@@ -0,0 +1,63 @@
+package quizkit
+
+import "math"
+
+// Rankings across quizzes cannot add raw totals: a 50-question quiz would
+// dwarf a 10-question one. A normalized score is instead the share of the
+// quiz's maximum a player reached, as a percentage, scaled by how hard the
+// quiz's questions were. A perfect run on a quiz of medium or untagged
+// questions is worth 100 whatever its length.
+
+// difficultyStep is how much each difficulty level above or below medium
+// adds to or takes from a question's weight.
+const difficultyStep = 0.25
+
+// DifficultyWeight is what a question of difficulty d counts for in
+// normalized scores: 0.75 for easy, 1 for medium or untagged, 1.25 for hard.
+func DifficultyWeight(d Difficulty) float64 {
+	return 1 + difficultyStep*float64(d.level()-1)
+}
+
+// QuizWeight is the mean DifficultyWeight of the questions that count toward
+// scores, leaving out voided ones. It is zero when none count.
+func QuizWeight(questions []Question) float64 {
+	total, counted := 0.0, 0
+	for _, question := range questions {
+		if question.Voided {
+			continue
+		}
+		total += DifficultyWeight(question.Difficulty)
+		counted++
+	}
+	if counted == 0 {
+		return 0
+	}
+	return total / float64(counted)
+}
+
+// MaxScore is the most a player can score on questions under p: a correct
+// answer with the full speed bonus on every question that is not voided.
+func (p ScoringPolicy) MaxScore(questions []Question) float64 {
+	perQuestion := p.CorrectPoints
+	if p.SpeedBonus.Enabled() {
+		perQuestion += p.SpeedBonus.MaxPoints
+	}
+	total := 0.0
+	for _, question := range questions {
+		if !question.Voided {
+			total += perQuestion
+		}
+	}
+	return total
+}
+
+// NormalizedScore is score as a percentage of maxScore, multiplied by weight
+// and rounded to thousandths. Negative scores count as zero, and a quiz
+// nobody can score on is worth nothing.
+func NormalizedScore(score, maxScore, weight float64) float64 {
+	if maxScore <= 0 || score <= 0 {
+		return 0
+	}
+	share := min(score/maxScore, 1)
+	return math.Round(share*100*weight*1000) / 1000
+}
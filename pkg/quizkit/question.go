@@ -0,0 +1,297 @@
+package quizkit
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Option is one lettered answer choice.
+type Option struct {
+	Letter string `json:"letter"`
+	Text   string `json:"text"`
+}
+
+// PublicQuestion is the part of a question that is safe to show players.
+type PublicQuestion struct {
+	QuestionID string   `json:"question_id"`
+	Question   string   `json:"question"`
+	Options    []Option `json:"options"`
+	// Type tells players how to answer; it is omitted for single-answer
+	// questions.
+	Type QuestionType `json:"type,omitempty"`
+}
+
+// QuestionType says how a question is answered.
+type QuestionType string
+
+const (
+	// TypeSingle questions have exactly one correct option. It is the zero
+	// value, so questions stored before types existed read as single-answer.
+	TypeSingle QuestionType = ""
+	// TypeMultiSelect questions have one or more correct options. Players
+	// answer with every letter they pick, such as "A,C", and earn partial
+	// credit; see Question.Credit.
+	TypeMultiSelect QuestionType = "multi_select"
+	// TypeTrueFalse questions have the options True and False. Players answer
+	// with the letter or the word.
+	TypeTrueFalse QuestionType = "true_false"
+)
+
+// ParseQuestionType accepts single, multi_select, or true_false in any case.
+// Empty means single.
+func ParseQuestionType(value string) (QuestionType, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "single":
+		return TypeSingle, nil
+	case string(TypeMultiSelect):
+		return TypeMultiSelect, nil
+	case string(TypeTrueFalse):
+		return TypeTrueFalse, nil
+	default:
+		return "", fmt.Errorf("%w: type must be one of: single, multi_select, true_false", ErrInvalidQuestion)
+	}
+}
+
+// Question is a PublicQuestion plus its answer key.
+type Question struct {
+	PublicQuestion
+	// CorrectIndex is the correct option; for a multi-select question, the
+	// first of CorrectIndexes.
+	CorrectIndex int
+	// CorrectIndexes lists every correct option of a multi-select question in
+	// ascending order. It is nil for other types.
+	CorrectIndexes []int
+	// Voided is set when the host withdrew the question from its quiz. Voided
+	// questions accept no answers and their attempts do not count toward scores.
+	Voided bool
+	// Feedback optionally explains each option, by position, for example why a
+	// distractor is wrong. Like CorrectIndex it is never shown with the question.
+	Feedback []string
+	// Difficulty drives adaptive selection; see Staircase.
+	Difficulty Difficulty
+	// Category is the provider's topic, such as "Science: Computers". Empty
+	// for custom questions.
+	Category string
+	// Source names where the question came from: the provider that supplied
+	// it, such as "opentdb", or "custom" for questions a caller wrote. Empty
+	// when unknown. Like CorrectIndex it is never shown with the question.
+	Source string
+	// Translations holds the question in other languages, keyed by language
+	// tag. See Translated.
+	Translations map[string]Translation
+}
+
+// ErrInvalidQuestion reports a caller-supplied question that cannot be stored.
+var ErrInvalidQuestion = errors.New("invalid question")
+
+// MaxOptions keeps option letters within A-Z.
+const MaxOptions = 26
+
+// NewQuestion builds a question from caller-supplied text, keeping the option
+// order as given so letters line up with what the caller displayed.
+func NewQuestion(prompt string, options []string, correctIndex int) (Question, error) {
+	question, err := newQuestion(prompt, options)
+	if err != nil {
+		return Question{}, err
+	}
+	if correctIndex < 0 || correctIndex >= len(options) {
+		return Question{}, fmt.Errorf("%w: correct_index %d is out of range", ErrInvalidQuestion, correctIndex)
+	}
+	question.CorrectIndex = correctIndex
+	question.QuestionID = MakeQuestionID(question)
+	return question, nil
+}
+
+// NewMultiSelectQuestion builds a question with one or more correct options,
+// given by index in any order.
+func NewMultiSelectQuestion(prompt string, options []string, correctIndexes []int) (Question, error) {
+	question, err := newQuestion(prompt, options)
+	if err != nil {
+		return Question{}, err
+	}
+	if len(correctIndexes) == 0 {
+		return Question{}, fmt.Errorf("%w: correct_indexes needs at least one option", ErrInvalidQuestion)
+	}
+	sorted := slices.Clone(correctIndexes)
+	slices.Sort(sorted)
+	for idx, correct := range sorted {
+		if correct < 0 || correct >= len(options) {
+			return Question{}, fmt.Errorf("%w: correct_indexes %d is out of range", ErrInvalidQuestion, correct)
+		}
+		if idx > 0 && sorted[idx-1] == correct {
+			return Question{}, fmt.Errorf("%w: correct_indexes lists %d twice", ErrInvalidQuestion, correct)
+		}
+	}
+	question.Type = TypeMultiSelect
+	question.CorrectIndex = sorted[0]
+	question.CorrectIndexes = sorted
+	question.QuestionID = MakeQuestionID(question)
+	return question, nil
+}
+
+// NewTrueFalseQuestion builds a question with the options True (A) and
+// False (B), answer being the correct one.
+func NewTrueFalseQuestion(prompt string, answer bool) (Question, error) {
+	question, err := newQuestion(prompt, []string{"True", "False"})
+	if err != nil {
+		return Question{}, err
+	}
+	question.Type = TypeTrueFalse
+	if !answer {
+		question.CorrectIndex = 1
+	}
+	question.QuestionID = MakeQuestionID(question)
+	return question, nil
+}
+
+// newQuestion checks the prompt and options shared by every question type
+// and letters the options.
+func newQuestion(prompt string, options []string) (Question, error) {
+	if strings.TrimSpace(prompt) == "" {
+		return Question{}, fmt.Errorf("%w: question text is required", ErrInvalidQuestion)
+	}
+	if len(options) < 2 || len(options) > MaxOptions {
+		return Question{}, fmt.Errorf("%w: between 2 and %d options are required", ErrInvalidQuestion, MaxOptions)
+	}
+
+	question := Question{
+		PublicQuestion: PublicQuestion{
+			Question: prompt,
+			Options:  make([]Option, 0, len(options)),
+		},
+	}
+	for idx, text := range options {
+		if strings.TrimSpace(text) == "" {
+			return Question{}, fmt.Errorf("%w: option %d is empty", ErrInvalidQuestion, idx)
+		}
+		question.Options = append(question.Options, Option{
+			Letter: OptionLetter(idx),
+			Text:   text,
+		})
+	}
+	return question, nil
+}
+
+// CorrectOptions returns the indexes of every correct option, ascending.
+func (q Question) CorrectOptions() []int {
+	if q.Type == TypeMultiSelect && len(q.CorrectIndexes) > 0 {
+		return q.CorrectIndexes
+	}
+	return []int{q.CorrectIndex}
+}
+
+// CorrectLetters is the answer that scores full marks, such as "B" or
+// "A,C".
+func (q Question) CorrectLetters() string {
+	return FormatLetters(q.CorrectOptions())
+}
+
+// WithFeedback returns a copy of q carrying per-option feedback. feedback is
+// matched to options by position and may be shorter than the option list;
+// empty entries mean no feedback for that option.
+func (q Question) WithFeedback(feedback []string) (Question, error) {
+	if len(feedback) > len(q.Options) {
+		return Question{}, fmt.Errorf("%w: %d feedback entries for %d options", ErrInvalidQuestion, len(feedback), len(q.Options))
+	}
+
+	q.Feedback = nil
+	for _, text := range feedback {
+		if strings.TrimSpace(text) != "" {
+			q.Feedback = append([]string(nil), feedback...)
+			break
+		}
+	}
+	return q, nil
+}
+
+// FeedbackFor returns the feedback for the option at index, or "".
+func (q Question) FeedbackFor(index int) string {
+	if index < 0 || index >= len(q.Feedback) {
+		return ""
+	}
+	return q.Feedback[index]
+}
+
+// OptionLetter returns the letter for a zero-based option index (0 -> "A").
+func OptionLetter(index int) string {
+	return string(rune('A' + index))
+}
+
+// MakeQuestionID generates a deterministic question ID from prompt + option text.
+// Option ordering is intentionally part of the key for this project. A
+// multi-select question's type is part of it too, so it never shares an ID,
+// and with it a stored answer key, with a single-answer question.
+func MakeQuestionID(question Question) string {
+	const hashChars = 12
+
+	var keyBuilder strings.Builder
+	keyBuilder.WriteString(question.Question)
+	for _, option := range question.Options {
+		keyBuilder.WriteString("|")
+		keyBuilder.WriteString(option.Text)
+	}
+	if question.Type == TypeMultiSelect {
+		keyBuilder.WriteString("|#")
+		keyBuilder.WriteString(string(question.Type))
+	}
+
+	hash := sha1.Sum([]byte(keyBuilder.String()))
+	encoded := hex.EncodeToString(hash[:])
+	return "q_" + encoded[:hashChars]
+}
+
+// ContentHash fingerprints what a question asks and which option is correct,
+// so a submission can prove it answered the copy that is stored now. Unlike
+// the question ID it covers CorrectIndex, which is why it is keyed: without
+// key, anyone could try each index until the hash matched.
+func ContentHash(question Question, key []byte) string {
+	const hashChars = 16
+
+	mac := hmac.New(sha256.New, key)
+	// Length prefixes keep "a|b" + "c" distinct from "a" + "b|c".
+	writeField := func(value string) {
+		fmt.Fprintf(mac, "%d:%s", len(value), value)
+	}
+	writeField(question.Question)
+	for _, option := range question.Options {
+		writeField(option.Text)
+	}
+	fmt.Fprintf(mac, "#%d", question.CorrectIndex)
+	if question.Type == TypeMultiSelect {
+		fmt.Fprintf(mac, "%v", question.CorrectIndexes)
+	}
+	return hex.EncodeToString(mac.Sum(nil))[:hashChars]
+}
+
+// NormalizeLetter trims and uppercases an answer and returns only single-letter
+// values. Only ASCII letters count: Unicode case mapping would otherwise turn
+// inputs such as "ſ" into "S".
+func NormalizeLetter(answer string) string {
+	letter := strings.TrimSpace(answer)
+	if len(letter) != 1 {
+		return ""
+	}
+	c := letter[0]
+	if 'a' <= c && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	if c < 'A' || c > 'Z' {
+		return ""
+	}
+	return string(c)
+}
+
+// ToPublicQuestions strips answer keys for display.
+func ToPublicQuestions(questions []Question) []PublicQuestion {
+	public := make([]PublicQuestion, 0, len(questions))
+	for _, question := range questions {
+		public = append(public, question.PublicQuestion)
+	}
+	return public
+}
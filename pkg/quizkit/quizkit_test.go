@@ -0,0 +1,386 @@
+package quizkit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEvaluateStatuses(t *testing.T) {
+	question, err := NewQuestion("Capital of France?", []string{"Berlin", "Paris", "Rome"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	voided := question
+	voided.QuestionID = "q_voided"
+	voided.Voided = true
+
+	results := Evaluate([]Question{question, voided}, []SubmittedResponse{
+		{QuestionID: question.QuestionID, Answer: " b "},
+		{QuestionID: question.QuestionID, Answer: "A"},
+		{QuestionID: question.QuestionID, Answer: "D"},
+		{QuestionID: question.QuestionID, Answer: "AB"},
+		{QuestionID: "q_missing", Answer: "A"},
+		{QuestionID: "q_voided", Answer: "B"},
+	})
+
+	want := []string{StatusCorrect, StatusIncorrect, StatusInvalidLetter, StatusInvalidLetter, StatusInvalidQuestion, StatusVoidedQuestion}
+	if len(results) != len(want) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(want))
+	}
+	for idx, status := range want {
+		if results[idx].Status != status {
+			t.Fatalf("results[%d].Status = (%q), want %q", idx, results[idx].Status, status)
+		}
+	}
+
+	RevealCorrectAnswers(results, []Question{question})
+	if results[1].CorrectLetter != "B" || results[1].CorrectText != "Paris" || results[0].CorrectLetter != "" {
+		t.Fatalf("revealed results = (%+v), want only the incorrect one revealed", results[:2])
+	}
+}
+
+func TestMultiSelectPartialCredit(t *testing.T) {
+	question, err := NewMultiSelectQuestion("Which are planets?", []string{"Mars", "Moon", "Venus", "Sun"}, []int{2, 0})
+	if err != nil {
+		t.Fatalf("NewMultiSelectQuestion failed: %v", err)
+	}
+	if question.Type != TypeMultiSelect || question.CorrectIndex != 0 || question.CorrectLetters() != "A,C" {
+		t.Fatalf("question = (%+v), want multi_select with A and C correct", question)
+	}
+	single, _ := NewQuestion(question.Question, []string{"Mars", "Moon", "Venus", "Sun"}, 0)
+	if single.QuestionID == question.QuestionID {
+		t.Fatalf("multi-select and single-answer questions share ID %q", question.QuestionID)
+	}
+
+	for _, tc := range []struct {
+		answer string
+		status string
+		credit float64
+	}{
+		{"c, a", StatusCorrect, 1},
+		{"A", StatusPartiallyCorrect, 0.5},
+		{"A,B,C", StatusPartiallyCorrect, 0.5},
+		{"A,B", StatusIncorrect, 0},
+		{"A,B,C,D", StatusIncorrect, 0},
+		{"A,A", StatusInvalidLetter, 0},
+		{"A,E", StatusInvalidLetter, 0},
+		{"", StatusInvalidLetter, 0},
+	} {
+		if status, credit := GradeAnswer(question, tc.answer); status != tc.status || credit != tc.credit {
+			t.Fatalf("GradeAnswer(%q) = (%q, %v), want (%q, %v)", tc.answer, status, credit, tc.status, tc.credit)
+		}
+	}
+
+	if got := NormalizeLetters(" c,a "); got != "A,C" {
+		t.Fatalf("NormalizeLetters = %q, want A,C", got)
+	}
+
+	results := Evaluate([]Question{question}, []SubmittedResponse{{QuestionID: question.QuestionID, Answer: "A"}})
+	RevealCorrectAnswers(results, []Question{question})
+	if results[0].Credit != 0.5 || results[0].CorrectLetter != "A,C" || results[0].CorrectText != "Mars; Venus" {
+		t.Fatalf("result = (%+v), want half credit with both answers revealed", results[0])
+	}
+
+	for _, correct := range [][]int{nil, {4}, {1, 1}} {
+		if _, err := NewMultiSelectQuestion("Which?", []string{"a", "b", "c"}, correct); !errors.Is(err, ErrInvalidQuestion) {
+			t.Fatalf("NewMultiSelectQuestion(%v) error = %v, want ErrInvalidQuestion", correct, err)
+		}
+	}
+}
+
+func TestTrueFalseAcceptsLettersAndWords(t *testing.T) {
+	question, err := NewTrueFalseQuestion("The Sun is a star.", true)
+	if err != nil {
+		t.Fatalf("NewTrueFalseQuestion failed: %v", err)
+	}
+	if question.Type != TypeTrueFalse || question.Options[1].Text != "False" || question.CorrectIndex != 0 {
+		t.Fatalf("question = (%+v), want True correct of True and False", question)
+	}
+	want := map[string]string{"A": StatusCorrect, " true ": StatusCorrect, "FALSE": StatusIncorrect, "b": StatusIncorrect, "yes": StatusInvalidLetter, "A,B": StatusInvalidLetter}
+	for answer, status := range want {
+		if got := EvaluateAnswer(question, answer); got != status {
+			t.Fatalf("EvaluateAnswer(%q) = %q, want %q", answer, got, status)
+		}
+	}
+	if kind, err := ParseQuestionType(" True_False "); err != nil || kind != TypeTrueFalse {
+		t.Fatalf("ParseQuestionType = (%q, %v), want true_false", kind, err)
+	}
+	if _, err := ParseQuestionType("boolean"); err == nil {
+		t.Fatalf("ParseQuestionType(boolean) succeeded, want error")
+	}
+}
+
+func TestExplainIncorrectAnswers(t *testing.T) {
+	question, err := NewQuestion("Capital of France?", []string{"Berlin", "Paris", "Rome"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	if _, err := question.WithFeedback([]string{"a", "b", "c", "d"}); err == nil {
+		t.Fatalf("WithFeedback(too many) error = nil, want error")
+	}
+	if plain, _ := question.WithFeedback([]string{"", " "}); plain.Feedback != nil {
+		t.Fatalf("blank feedback = (%q), want nil", plain.Feedback)
+	}
+	question, err = question.WithFeedback([]string{"Berlin is Germany's capital."})
+	if err != nil {
+		t.Fatalf("WithFeedback failed: %v", err)
+	}
+
+	responses := []SubmittedResponse{
+		{QuestionID: question.QuestionID, Answer: "a"},
+		{QuestionID: question.QuestionID, Answer: "C"},
+		{QuestionID: question.QuestionID, Answer: "B"},
+	}
+	results := Evaluate([]Question{question}, responses)
+	ExplainIncorrectAnswers(results, responses, []Question{question})
+
+	if results[0].Feedback != "Berlin is Germany's capital." || results[1].Feedback != "" || results[2].Feedback != "" {
+		t.Fatalf("feedback = (%q, %q, %q), want only the Berlin explanation", results[0].Feedback, results[1].Feedback, results[2].Feedback)
+	}
+}
+
+func TestScoringPolicyScore(t *testing.T) {
+	policy := DefaultScoringPolicy()
+	if policy.Score(StatusCorrect) != 1 || policy.Score(StatusIncorrect) != 0 || policy.Score(StatusAlreadyAnswered) != 0 {
+		t.Fatalf("default policy scores = (%v, %v, %v), want (1, 0, 0)",
+			policy.Score(StatusCorrect), policy.Score(StatusIncorrect), policy.Score(StatusAlreadyAnswered))
+	}
+}
+
+func TestSpeedBonusPoints(t *testing.T) {
+	linear := SpeedBonus{MaxPoints: 0.5, Window: 10 * time.Second, Curve: BonusLinear}
+	quadratic := SpeedBonus{MaxPoints: 0.5, Window: 10 * time.Second, Curve: BonusQuadratic}
+	cases := []struct {
+		bonus   SpeedBonus
+		elapsed time.Duration
+		want    float64
+	}{
+		{linear, -time.Second, 0.5},
+		{linear, 0, 0.5},
+		{linear, 5 * time.Second, 0.25},
+		{linear, 10 * time.Second, 0},
+		{quadratic, 5 * time.Second, 0.125},
+		{quadratic, time.Minute, 0},
+		{SpeedBonus{MaxPoints: 1}, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := tc.bonus.Points(tc.elapsed); got != tc.want {
+			t.Fatalf("%+v.Points(%v) = %v, want %v", tc.bonus, tc.elapsed, got, tc.want)
+		}
+	}
+
+	if got, err := ParseBonusCurve(" Quadratic "); err != nil || got != BonusQuadratic {
+		t.Fatalf("ParseBonusCurve = (%q, %v), want (quadratic, nil)", got, err)
+	}
+	if got, err := ParseBonusCurve(""); err != nil || got != BonusLinear {
+		t.Fatalf("default ParseBonusCurve = (%q, %v), want (linear, nil)", got, err)
+	}
+	if _, err := ParseBonusCurve("cubic"); err == nil {
+		t.Fatalf("expected error for unknown bonus curve")
+	}
+}
+
+func TestNormalizedScoreEvensOutLengthAndDifficulty(t *testing.T) {
+	long := make([]Question, 50)
+	short := []Question{
+		{Difficulty: DifficultyHard},
+		{Difficulty: DifficultyHard},
+		{Difficulty: DifficultyEasy, Voided: true},
+	}
+	policy := DefaultScoringPolicy()
+	if maxScore, weight := policy.MaxScore(long), QuizWeight(long); maxScore != 50 || weight != 1 {
+		t.Fatalf("50 untagged questions = (max %v, weight %v), want (50, 1)", maxScore, weight)
+	}
+	if maxScore, weight := policy.MaxScore(short), QuizWeight(short); maxScore != 2 || weight != 1.25 {
+		t.Fatalf("two hard questions and a voided one = (max %v, weight %v), want (2, 1.25)", maxScore, weight)
+	}
+	policy.SpeedBonus = SpeedBonus{MaxPoints: 0.5, Window: time.Second}
+	if maxScore := policy.MaxScore(short); maxScore != 3 {
+		t.Fatalf("MaxScore with a speed bonus = %v, want 3", maxScore)
+	}
+
+	cases := []struct {
+		score, maxScore, weight, want float64
+	}{
+		{50, 50, 1, 100},
+		{2, 2, 1.25, 125},
+		{1, 3, 1, 33.333},
+		{-1, 10, 1, 0},
+		{12, 10, 1, 100},
+		{0, 0, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := NormalizedScore(tc.score, tc.maxScore, tc.weight); got != tc.want {
+			t.Fatalf("NormalizedScore(%v, %v, %v) = %v, want %v", tc.score, tc.maxScore, tc.weight, got, tc.want)
+		}
+	}
+	if QuizWeight(nil) != 0 || DifficultyWeight(DifficultyEasy) != 0.75 {
+		t.Fatalf("QuizWeight(nil) = %v, DifficultyWeight(easy) = %v, want 0 and 0.75", QuizWeight(nil), DifficultyWeight(DifficultyEasy))
+	}
+}
+
+func TestSortLeaderboard(t *testing.T) {
+	early := time.Unix(100, 0)
+	late := time.Unix(200, 0)
+	entries := []LeaderboardEntry{
+		{Username: "carol", TotalScore: 2, LastSubmissionAt: late},
+		{Username: "bob", TotalScore: 2, LastSubmissionAt: early},
+		{Username: "dave", TotalScore: 3, LastSubmissionAt: late},
+		{Username: "alice", TotalScore: 2, LastSubmissionAt: early},
+	}
+
+	SortLeaderboard(entries)
+
+	want := []string{"dave", "alice", "bob", "carol"}
+	for idx, username := range want {
+		if entries[idx].Username != username {
+			t.Fatalf("entries[%d] = (%s), want %s", idx, entries[idx].Username, username)
+		}
+	}
+}
+
+func TestSortLeaderboardByFewestAnswers(t *testing.T) {
+	early := time.Unix(100, 0)
+	late := time.Unix(200, 0)
+	entries := []LeaderboardEntry{
+		{Username: "alice", TotalScore: 2, AnsweredCount: 4, LastSubmissionAt: early},
+		{Username: "bob", TotalScore: 2, AnsweredCount: 2, LastSubmissionAt: late},
+		{Username: "carol", TotalScore: 2, AnsweredCount: 2, LastSubmissionAt: early},
+		{Username: "dave", TotalScore: 3, AnsweredCount: 5, LastSubmissionAt: late},
+	}
+
+	SortLeaderboardBy(TiebreakFewestAnswers, entries)
+
+	want := []string{"dave", "carol", "bob", "alice"}
+	for idx, username := range want {
+		if entries[idx].Username != username {
+			t.Fatalf("entries[%d] = (%s), want %s", idx, entries[idx].Username, username)
+		}
+	}
+}
+
+func TestStaircaseStepsDifficultyWithAnswers(t *testing.T) {
+	pool := []Question{
+		{PublicQuestion: PublicQuestion{QuestionID: "e1"}, Difficulty: DifficultyEasy},
+		{PublicQuestion: PublicQuestion{QuestionID: "m1"}, Difficulty: DifficultyMedium},
+		{PublicQuestion: PublicQuestion{QuestionID: "m2"}, Difficulty: DifficultyMedium},
+		{PublicQuestion: PublicQuestion{QuestionID: "h1"}, Difficulty: DifficultyHard},
+		{PublicQuestion: PublicQuestion{QuestionID: "h2"}, Difficulty: DifficultyHard, Voided: true},
+	}
+	policy := Staircase{}
+
+	steps := []struct {
+		history []AnsweredQuestion
+		want    string
+	}{
+		{nil, "m1"},
+		{[]AnsweredQuestion{{"m1", true}}, "h1"},
+		// Hard is used up (h2 is voided), so the closest level below is served.
+		{[]AnsweredQuestion{{"m1", true}, {"h1", true}}, "m2"},
+		{[]AnsweredQuestion{{"m1", false}}, "e1"},
+		// Easy is used up after a wrong answer; the walk heads down, so medium
+		// is the only option left above it.
+		{[]AnsweredQuestion{{"m1", false}, {"e1", false}}, "m2"},
+	}
+	for idx, step := range steps {
+		next, ok := policy.Next(pool, step.history)
+		if !ok || next.QuestionID != step.want {
+			t.Fatalf("steps[%d] Next = (%q, %t), want %q", idx, next.QuestionID, ok, step.want)
+		}
+	}
+
+	if target := policy.Target(pool, []AnsweredQuestion{{"m1", false}}); target != DifficultyEasy {
+		t.Fatalf("Target after a wrong medium answer = (%q), want easy", target)
+	}
+	all := []AnsweredQuestion{{"e1", true}, {"m1", true}, {"m2", true}, {"h1", true}}
+	if _, ok := policy.Next(pool, all); ok {
+		t.Fatalf("Next with the pool used up returned a question, want none")
+	}
+}
+
+func TestContentHashCoversAnswerKeyAndKey(t *testing.T) {
+	question, err := NewQuestion("Largest planet?", []string{"Mars", "Jupiter"}, 1)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	key := []byte("k1")
+	hash := ContentHash(question, key)
+
+	corrected := question
+	corrected.CorrectIndex = 0
+	if ContentHash(corrected, key) == hash {
+		t.Fatalf("ContentHash unchanged after correcting the answer key")
+	}
+	multi, err := NewMultiSelectQuestion("Inner planets?", []string{"Mercury", "Venus", "Jupiter"}, []int{0, 1})
+	if err != nil {
+		t.Fatalf("NewMultiSelectQuestion failed: %v", err)
+	}
+	widened := multi
+	widened.CorrectIndexes = []int{0, 1, 2}
+	if ContentHash(widened, key) == ContentHash(multi, key) {
+		t.Fatalf("ContentHash unchanged after adding a correct option")
+	}
+	if ContentHash(question, []byte("k2")) == hash {
+		t.Fatalf("ContentHash unchanged under a different key")
+	}
+	if ContentHash(question, key) != hash || len(hash) != 16 {
+		t.Fatalf("ContentHash = (%q), want a stable 16-character hash", hash)
+	}
+}
+
+func FuzzNormalizeLetter(f *testing.F) {
+	for _, seed := range []string{"a", " B ", "", "AB", "1", "ſ", "ı", "\xff"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, answer string) {
+		letter := NormalizeLetter(answer)
+		if letter == "" {
+			return
+		}
+		if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' {
+			t.Fatalf("NormalizeLetter(%q) = %q, want one of A-Z or empty", answer, letter)
+		}
+		if again := NormalizeLetter(letter); again != letter {
+			t.Fatalf("NormalizeLetter(%q) = %q, want it unchanged", letter, again)
+		}
+	})
+}
+
+func TestTranslationsKeepLettersAndAnswerKey(t *testing.T) {
+	question, err := NewQuestion("Capital of Spain?", []string{"Madrid", "Lisbon"}, 0)
+	if err != nil {
+		t.Fatalf("NewQuestion failed: %v", err)
+	}
+	question, err = question.WithTranslations(map[string]Translation{
+		"ES": {Question: "¿Capital de España?", Options: []string{"Madrid", "Lisboa"}},
+	})
+	if err != nil {
+		t.Fatalf("WithTranslations failed: %v", err)
+	}
+
+	public, ok := question.Translated("es")
+	if !ok || public.QuestionID != question.QuestionID || public.Options[1] != (Option{Letter: "B", Text: "Lisboa"}) {
+		t.Fatalf("Translated(es) = (%+v, %v), want Spanish text under the same ID and letters", public, ok)
+	}
+	if _, ok := question.Translated("fr"); ok {
+		t.Fatalf("Translated(fr) ok, want no French translation")
+	}
+	if got := EvaluateAnswer(question, public.Options[0].Letter); got != StatusCorrect {
+		t.Fatalf("answer from Spanish view = %q, want correct", got)
+	}
+
+	if _, err := question.WithTranslations(map[string]Translation{"es": {Question: "?", Options: []string{"Madrid"}}}); !errors.Is(err, ErrInvalidQuestion) {
+		t.Fatalf("short translation error = %v, want ErrInvalidQuestion", err)
+	}
+	for _, tag := range []string{"pt-BR", "zh-hant-tw"} {
+		if _, err := ParseLanguage(tag); err != nil {
+			t.Fatalf("ParseLanguage(%q) = %v, want valid", tag, err)
+		}
+	}
+	for _, tag := range []string{"", "e", "english", "es-", "1a"} {
+		if _, err := ParseLanguage(tag); err == nil {
+			t.Fatalf("ParseLanguage(%q) succeeded, want error", tag)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package quizkit
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// RevealPolicy controls whether scored results echo the canonical answer back to
+// the caller, so clients that never received correct_index can still explain a miss.
+type RevealPolicy string
+
+const (
+	RevealNever       RevealPolicy = "never"
+	RevealAfterAnswer RevealPolicy = "after_answer"
+)
+
+// ParseRevealPolicy maps a flag/config value to a RevealPolicy. Empty means never.
+func ParseRevealPolicy(value string) (RevealPolicy, error) {
+	switch RevealPolicy(strings.ToLower(strings.TrimSpace(value))) {
+	case "", RevealNever:
+		return RevealNever, nil
+	case RevealAfterAnswer:
+		return RevealAfterAnswer, nil
+	default:
+		return "", errors.New("reveal policy must be one of: never, after_answer")
+	}
+}
+
+// ScoringPolicy summarizes how submitted answers are scored, so clients can
+// explain the rules without hard-coding them.
+type ScoringPolicy struct {
+	CorrectPoints       float64
+	IncorrectPoints     float64
+	AttemptsPerQuestion int
+	RevealPolicy        RevealPolicy
+	SpeedBonus          SpeedBonus
+}
+
+// DefaultScoringPolicy is the policy quiz-service applies: one point per correct
+// answer, none for a miss, and a single attempt per question.
+func DefaultScoringPolicy() ScoringPolicy {
+	return ScoringPolicy{
+		CorrectPoints:       1,
+		IncorrectPoints:     0,
+		AttemptsPerQuestion: 1,
+		RevealPolicy:        RevealNever,
+	}
+}
+
+// Score returns the points a result status is worth. Statuses other than
+// correct and incorrect are not scored attempts and are worth nothing.
+func (p ScoringPolicy) Score(status string) float64 {
+	switch status {
+	case StatusCorrect:
+		return p.CorrectPoints
+	case StatusIncorrect:
+		return p.IncorrectPoints
+	default:
+		return 0
+	}
+}
+
+// BonusCurve shapes how a speed bonus decays over its window.
+type BonusCurve string
+
+const (
+	// BonusLinear loses the same number of points every second.
+	BonusLinear BonusCurve = "linear"
+	// BonusQuadratic drops quickly at first and flattens toward the end, so the
+	// fastest answers stand out.
+	BonusQuadratic BonusCurve = "quadratic"
+)
+
+// ParseBonusCurve maps a flag/config value to a BonusCurve. Empty means linear.
+func ParseBonusCurve(value string) (BonusCurve, error) {
+	switch BonusCurve(strings.ToLower(strings.TrimSpace(value))) {
+	case "", BonusLinear:
+		return BonusLinear, nil
+	case BonusQuadratic:
+		return BonusQuadratic, nil
+	default:
+		return "", errors.New("bonus curve must be one of: linear, quadratic")
+	}
+}
+
+// SpeedBonus awards extra points for fast correct answers: MaxPoints for an
+// instant answer, decaying along Curve to nothing once Window has passed since
+// the question was served. The zero value awards no bonus.
+type SpeedBonus struct {
+	MaxPoints float64
+	Window    time.Duration
+	Curve     BonusCurve
+}
+
+// Enabled reports whether b can award any points.
+func (b SpeedBonus) Enabled() bool {
+	return b.MaxPoints > 0 && b.Window > 0
+}
+
+// Points returns the bonus for a correct answer given elapsed after the
+// question was served, rounded to thousandths so stored totals stay readable.
+func (b SpeedBonus) Points(elapsed time.Duration) float64 {
+	if !b.Enabled() || elapsed >= b.Window {
+		return 0
+	}
+	remaining := 1 - float64(max(elapsed, 0))/float64(b.Window)
+	if b.Curve == BonusQuadratic {
+		remaining *= remaining
+	}
+	return math.Round(b.MaxPoints*remaining*1000) / 1000
+}
@@ -0,0 +1,110 @@
+package quizkit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Translation is a question's prompt and option texts in another language.
+// Options are listed in canonical order, so every language shows the same
+// letter for the same option and answers score against CorrectIndex unchanged.
+type Translation struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+}
+
+// ParseLanguage normalizes a language tag such as "es" or "pt-BR" to lower
+// case. It accepts a 2-3 letter primary subtag followed by up to three
+// alphanumeric subtags; it does not check tags against the IANA registry.
+func ParseLanguage(value string) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(value))
+	if !validLanguageTag(tag) {
+		return "", fmt.Errorf("%w: invalid language tag %q", ErrInvalidQuestion, value)
+	}
+	return tag, nil
+}
+
+func validLanguageTag(tag string) bool {
+	subtags := strings.Split(tag, "-")
+	if len(subtags) > 4 || len(subtags[0]) < 2 || len(subtags[0]) > 3 {
+		return false
+	}
+	for idx, subtag := range subtags {
+		if idx > 0 && (subtag == "" || len(subtag) > 8) {
+			return false
+		}
+		for _, c := range []byte(subtag) {
+			letter := 'a' <= c && c <= 'z'
+			digit := '0' <= c && c <= '9'
+			if !letter && (idx == 0 || !digit) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// WithTranslations returns a copy of q carrying translations keyed by language
+// tag. Each translation needs a prompt and one text per option.
+func (q Question) WithTranslations(translations map[string]Translation) (Question, error) {
+	q.Translations = nil
+	for language, translation := range translations {
+		tag, err := ParseLanguage(language)
+		if err != nil {
+			return Question{}, err
+		}
+		if strings.TrimSpace(translation.Question) == "" {
+			return Question{}, fmt.Errorf("%w: %s translation has no question text", ErrInvalidQuestion, tag)
+		}
+		if len(translation.Options) != len(q.Options) {
+			return Question{}, fmt.Errorf("%w: %s translation has %d options, want %d", ErrInvalidQuestion, tag, len(translation.Options), len(q.Options))
+		}
+		for idx, text := range translation.Options {
+			if strings.TrimSpace(text) == "" {
+				return Question{}, fmt.Errorf("%w: %s translation option %d is empty", ErrInvalidQuestion, tag, idx)
+			}
+		}
+		if _, dup := q.Translations[tag]; dup {
+			return Question{}, fmt.Errorf("%w: duplicate %s translation", ErrInvalidQuestion, tag)
+		}
+		if q.Translations == nil {
+			q.Translations = make(map[string]Translation, len(translations))
+		}
+		q.Translations[tag] = Translation{
+			Question: translation.Question,
+			Options:  append([]string(nil), translation.Options...),
+		}
+	}
+	return q, nil
+}
+
+// Languages returns the languages q is translated into, sorted.
+func (q Question) Languages() []string {
+	languages := make([]string, 0, len(q.Translations))
+	for language := range q.Translations {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Translated returns the public view of q in language, keeping question ID and
+// option letters. It returns the canonical text and false when q has no such
+// translation.
+func (q Question) Translated(language string) (PublicQuestion, bool) {
+	translation, ok := q.Translations[language]
+	if !ok || len(translation.Options) != len(q.Options) {
+		return q.PublicQuestion, false
+	}
+	public := PublicQuestion{
+		QuestionID: q.QuestionID,
+		Question:   translation.Question,
+		Options:    make([]Option, 0, len(q.Options)),
+		Type:       q.Type,
+	}
+	for idx, option := range q.Options {
+		public.Options = append(public.Options, Option{Letter: option.Letter, Text: translation.Options[idx]})
+	}
+	return public, true
+}